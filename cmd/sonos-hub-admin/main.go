@@ -0,0 +1,175 @@
+// Command sonos-hub-admin runs maintenance tasks against the hub's database.
+//
+// Usage:
+//
+//	go run ./cmd/sonos-hub-admin <command> [flags]
+//
+//	# Preview which set items would get local artwork copies
+//	go run ./cmd/sonos-hub-admin backfill-artwork --dry-run
+//
+//	# Backfill routine artwork from a specific speaker and database
+//	go run ./cmd/sonos-hub-admin backfill-routine-artwork --device-ip 192.168.1.10 --db ./data/sonos-hub.db
+//
+// Commands:
+//
+//	backfill-artwork          Store local copies of Sonos favorite artwork for set items
+//	backfill-icons            Add service logos to the music content of routines playing a Sonos favorite
+//	backfill-routine-artwork  Store local copies of Sonos favorite artwork for routines
+//
+// Flags default to the SQLITE_DB_PATH, DEFAULT_SONOS_IP and ARTWORK_DIR environment
+// variables. A summary table is printed when the command finishes; the exit status is
+// 1 if the command failed or any row couldn't be updated, and 2 for usage errors.
+//
+// Commands migrate the database before running, except with --dry-run, which writes
+// nothing and so needs a database that is already up to date.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/strefethen/sonos-hub-go/internal/artwork"
+	"github.com/strefethen/sonos-hub-go/internal/db"
+	"github.com/strefethen/sonos-hub-go/internal/maintenance"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+const (
+	exitOK      = 0
+	exitFailed  = 1
+	exitUsage   = 2
+	soapTimeout = 10 * time.Second
+	// browseTimeout bounds browsing every favorite from the speaker.
+	browseTimeout = 30 * time.Second
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		usage(stderr)
+		return exitUsage
+	}
+
+	task, ok := maintenance.Lookup(args[0])
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n", args[0])
+		usage(stderr)
+		return exitUsage
+	}
+
+	flags := flag.NewFlagSet(task.Name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	dbPath := flags.String("db", envOr("SQLITE_DB_PATH", "./data/sonos-hub.db"), "SQLite database path")
+	deviceIP := flags.String("device-ip", envOr("DEFAULT_SONOS_IP", "192.168.1.10"), "Sonos speaker to browse favorites from")
+	artworkDir := flags.String("artwork-dir", envOr("ARTWORK_DIR", "./data/artwork"), "Directory for local favorite artwork")
+	dryRun := flags.Bool("dry-run", false, "Report what would change without writing")
+	if err := flags.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(stderr, "unexpected arguments: %v\n", flags.Args())
+		return exitUsage
+	}
+
+	// db.Init would create a missing database; a typo in --db shouldn't
+	if _, err := os.Stat(*dbPath); err != nil {
+		fmt.Fprintf(stderr, "database not found: %v\n", err)
+		return exitFailed
+	}
+
+	log.Printf("%s: Opening database at %s", task.Name, *dbPath)
+	dbPair, err := openDatabase(*dbPath, *dryRun)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to open database: %v\n", err)
+		return exitFailed
+	}
+	defer dbPair.Close()
+
+	favorites, err := artwork.NewFavoriteCache(dbPair, *artworkDir, artwork.SOAPFavoriteSource{
+		Client:   soap.NewClient(soapTimeout),
+		DeviceIP: *deviceIP,
+		Timeout:  browseTimeout,
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to open artwork directory: %v\n", err)
+		return exitFailed
+	}
+
+	result, err := task.Run(maintenance.Env{DB: dbPair, Artwork: favorites}, maintenance.Options{DryRun: *dryRun})
+	result.Task, result.DryRun = task.Name, *dryRun // Unset when the task fails early
+	printSummary(stdout, result)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s failed: %v\n", task.Name, err)
+		return exitFailed
+	}
+	if result.Failed > 0 {
+		return exitFailed
+	}
+	return exitOK
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: sonos-hub-admin <command> [--db path] [--device-ip ip] [--artwork-dir dir] [--dry-run]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, task := range maintenance.Tasks() {
+		fmt.Fprintf(tw, "  %s\t%s\n", task.Name, task.Description)
+	}
+	tw.Flush()
+}
+
+func printSummary(w io.Writer, result maintenance.Result) {
+	updated := "UPDATED"
+	if result.DryRun {
+		updated = "WOULD UPDATE"
+	}
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "TASK\tSCANNED\t%s\tSKIPPED\tFAILED\n", updated)
+	fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\n", result.Task, result.Scanned, result.Updated, result.Skipped, result.Failed)
+	tw.Flush()
+}
+
+// openDatabase opens the hub's database, migrating it first unless this is a dry run.
+// A dry run leaves the schema alone, so it can only run against an up-to-date database.
+func openDatabase(dbPath string, dryRun bool) (*db.DBPair, error) {
+	if !dryRun {
+		return db.Init(dbPath)
+	}
+
+	dbPair, err := db.Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	version, err := db.SchemaVersion(dbPair.Reader())
+	if err != nil {
+		dbPair.Close()
+		return nil, err
+	}
+	if latest := db.LatestVersion(); version != latest {
+		dbPair.Close()
+		return nil, fmt.Errorf("schema is at version %d, not %d; run once without --dry-run to migrate it", version, latest)
+	}
+	return dbPair, nil
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
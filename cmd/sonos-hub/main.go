@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,17 +14,39 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 
 	"github.com/strefethen/sonos-hub-go/internal/config"
+	"github.com/strefethen/sonos-hub-go/internal/db"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
 	"github.com/strefethen/sonos-hub-go/internal/server"
 )
 
 func main() {
+	migrateOnly := flag.Bool("migrate-only", false, "apply pending database migrations and exit without starting the server")
+	flag.Parse()
+
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("config error: %v", err)
 	}
+	if _, err := logging.Setup(os.Stderr, cfg.LogFormat, cfg.LogLevel); err != nil {
+		log.Fatalf("config error: %v", err)
+	}
+
+	if *migrateOnly {
+		slog.Info("Using database", "path", cfg.SQLiteDBPath)
+		dbPair, err := db.Init(cfg.SQLiteDBPath)
+		if err != nil {
+			log.Fatalf("migration error: %v", err)
+		}
+		if err := dbPair.Close(); err != nil {
+			log.Fatalf("close database: %v", err)
+		}
+		slog.Info("Database schema is up to date", "version", db.LatestVersion())
+		return
+	}
+
 	addr := cfg.Host + ":" + cfg.Port
 
-	handler, shutdownHandler, err := server.NewHandler(cfg, server.Options{})
+	handler, shutdownHandler, err := server.NewHandler(cfg, server.Options{AdvertiseMDNS: cfg.MDNSEnabled})
 	if err != nil {
 		log.Fatalf("server init error: %v", err)
 	}
@@ -41,14 +65,14 @@ func main() {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 		if err := shutdownHandler(ctx); err != nil {
-			log.Printf("shutdown error: %v", err)
+			slog.Error("Shutdown error", "error", err)
 		}
 		if err := srv.Shutdown(ctx); err != nil {
-			log.Printf("shutdown error: %v", err)
+			slog.Error("Shutdown error", "error", err)
 		}
 	}()
 
-	log.Printf("sonos-hub-go listening on %s", addr)
+	slog.Info("sonos-hub-go listening", "addr", addr)
 	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatalf("server error: %v", err)
 	}
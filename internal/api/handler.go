@@ -1,20 +1,85 @@
 package api
 
 import (
-	"log"
+	"bufio"
+	"log/slog"
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
 )
 
 // Handler adapts handlers that return errors into http.Handler.
 type Handler func(w http.ResponseWriter, r *http.Request) error
 
-// ServeHTTP implements http.Handler.
+// ServeHTTP implements http.Handler. Each request is logged once it completes, with the
+// request ID added by RequestIDMiddleware.
 func (handler Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if err := handler(w, r); err != nil {
-		WriteError(w, r, err)
+	start := time.Now()
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+	err := handler(recorder, r)
+	if err != nil {
+		WriteError(recorder, r, err)
+	}
+
+	level := slog.LevelInfo
+	if recorder.status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	args := []any{
+		"method", r.Method,
+		"path", r.URL.Path,
+		"status", recorder.status,
+		"duration_ms", time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		args = append(args, "error", err.Error())
+	}
+	logging.From(r.Context(), nil).Log(r.Context(), level, "HTTP request", args...)
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (rec *statusRecorder) WriteHeader(code int) {
+	if !rec.wroteHeader {
+		rec.status = code
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	return rec.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// Flush implements http.Flusher for streaming responses.
+func (rec *statusRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker for WebSocket upgrades.
+func (rec *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := rec.ResponseWriter.(http.Hijacker); ok {
+		rec.status = http.StatusSwitchingProtocols
+		return hijacker.Hijack()
 	}
+	return nil, nil, http.ErrNotSupported
 }
 
 // RecovererMiddleware converts panics into 500 responses.
@@ -22,7 +87,8 @@ func RecovererMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if recovered := recover(); recovered != nil {
-				log.Printf("panic recovered: %v", recovered)
+				logging.From(r.Context(), nil).Error("Panic recovered",
+					"method", r.Method, "path", r.URL.Path, "panic", recovered)
 				WriteError(w, r, apperrors.NewInternalError("Internal server error"))
 			}
 		}()
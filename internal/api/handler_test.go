@@ -0,0 +1,45 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+)
+
+func TestHandler_LogsRequests(t *testing.T) {
+	previous := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	var buf bytes.Buffer
+	_, err := logging.Setup(&buf, logging.FormatJSON, slog.LevelInfo)
+	require.NoError(t, err)
+
+	handler := RequestIDMiddleware(Handler(func(w http.ResponseWriter, r *http.Request) error {
+		return apperrors.NewValidationError("name is required", nil)
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/v1/scenes", nil)
+	req.Header.Set("x-request-id", "req-123")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, "req-123", rec.Header().Get("x-request-id"))
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	require.Equal(t, "HTTP request", line["msg"])
+	require.Equal(t, "INFO", line["level"])
+	require.Equal(t, "req-123", line["request_id"])
+	require.Equal(t, "POST", line["method"])
+	require.Equal(t, "/v1/scenes", line["path"])
+	require.Equal(t, float64(http.StatusBadRequest), line["status"])
+	require.Contains(t, line, "duration_ms")
+	require.Contains(t, line["error"], "name is required")
+}
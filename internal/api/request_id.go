@@ -5,13 +5,16 @@ import (
 	"net/http"
 
 	"github.com/google/uuid"
+
+	"github.com/strefethen/sonos-hub-go/internal/logging"
 )
 
 type contextKey string
 
 const requestIDKey contextKey = "requestID"
 
-// RequestIDMiddleware ensures every request has a request ID.
+// RequestIDMiddleware ensures every request has a request ID. The ID is echoed in the
+// x-request-id response header and added to the request's log lines (see logging.From).
 func RequestIDMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		requestID := r.Header.Get("x-request-id")
@@ -20,6 +23,7 @@ func RequestIDMiddleware(next http.Handler) http.Handler {
 		}
 
 		ctx := context.WithValue(r.Context(), requestIDKey, requestID)
+		ctx = logging.With(ctx, "request_id", requestID)
 		w.Header().Set("x-request-id", requestID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -30,7 +34,12 @@ func GetRequestID(r *http.Request) string {
 	if r == nil {
 		return ""
 	}
-	if value := r.Context().Value(requestIDKey); value != nil {
+	return RequestIDFromContext(r.Context())
+}
+
+// RequestIDFromContext returns the request ID stored by RequestIDMiddleware, or "".
+func RequestIDFromContext(ctx context.Context) string {
+	if value := ctx.Value(requestIDKey); value != nil {
 		if requestID, ok := value.(string); ok {
 			return requestID
 		}
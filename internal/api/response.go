@@ -2,7 +2,10 @@ package api
 
 import (
 	"encoding/json"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/strefethen/sonos-hub-go/internal/apperrors"
@@ -25,6 +28,12 @@ const (
 	ObjectPhysicalDevice  = "physical_device"
 	ObjectAuditEvent      = "audit_event"
 	ObjectRoutineTemplate = "routine_template"
+	ObjectMaintenanceTask = "maintenance_task"
+	ObjectDatabaseRestore = "database_restore"
+	ObjectAPIKey          = "api_key"
+	ObjectWebhook         = "webhook"
+	ObjectWebhookDelivery = "webhook_delivery"
+	ObjectRoutineConflict = "routine_conflict"
 )
 
 // =============================================================================
@@ -37,6 +46,12 @@ func RFC3339Millis(t time.Time) string {
 	return t.UTC().Format("2006-01-02T15:04:05.000Z")
 }
 
+// RFC3339MillisIn formats time with milliseconds in the given location, including its offset.
+// Example: "2006-01-02T10:04:05.000-05:00". Use alongside RFC3339Millis, never instead of it.
+func RFC3339MillisIn(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format("2006-01-02T15:04:05.000Z07:00")
+}
+
 // =============================================================================
 // Stripe API Standard Response Types
 // =============================================================================
@@ -79,10 +94,22 @@ func WriteJSON(w http.ResponseWriter, status int, payload any) error {
 	return json.NewEncoder(w).Encode(payload)
 }
 
+// ContentTypeProblemJSON is the RFC 7807 media type for problem documents.
+const ContentTypeProblemJSON = "application/problem+json"
+
 // WriteError serializes an AppError into the Stripe-style error response.
 // Response format: {"error": {"type": "...", "code": "...", "message": "..."}}
+// Clients that send Accept: application/problem+json receive an RFC 7807 document instead.
 func WriteError(w http.ResponseWriter, r *http.Request, err error) {
 	appErr := apperrors.EnsureAppError(err)
+	w.Header().Add("Vary", "Accept")
+
+	if acceptsProblemJSON(r) {
+		w.Header().Set("Content-Type", ContentTypeProblemJSON)
+		w.WriteHeader(appErr.StatusCode)
+		_ = json.NewEncoder(w).Encode(appErr.ProblemDetails(r.URL.Path))
+		return
+	}
 
 	response := StripeErrorResponse{
 		Error: appErr.StripeErrorBody(),
@@ -91,6 +118,29 @@ func WriteError(w http.ResponseWriter, r *http.Request, err error) {
 	_ = WriteJSON(w, appErr.StatusCode, response)
 }
 
+// acceptsProblemJSON reports whether the Accept header asks for application/problem+json
+// with a non-zero quality. The legacy format remains the default for all other values.
+func acceptsProblemJSON(r *http.Request) bool {
+	if r == nil {
+		return false
+	}
+	for _, header := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(header, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil || mediaType != ContentTypeProblemJSON {
+				continue
+			}
+			if q, ok := params["q"]; ok {
+				if value, err := strconv.ParseFloat(q, 64); err != nil || value <= 0 {
+					continue
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
 // =============================================================================
 // Stripe-Style Response Helpers
 // =============================================================================
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+)
+
+func TestWriteErrorDefaultsToLegacyFormat(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/v1/scenes/missing", nil)
+	rec := httptest.NewRecorder()
+
+	WriteError(rec, req, apperrors.NewAppError(apperrors.ErrorCodeSceneNotFound, "Scene not found", 404, nil, nil))
+
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var body StripeErrorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	require.Equal(t, "SCENE_NOT_FOUND", body.Error.Code)
+	require.Equal(t, "Scene not found", body.Error.Message)
+}
+
+func TestWriteErrorProblemJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/v1/scenes/missing", nil)
+	req.Header.Set("Accept", "application/json;q=0.5, application/problem+json")
+	rec := httptest.NewRecorder()
+
+	WriteError(rec, req, apperrors.NewAppError(apperrors.ErrorCodeSceneNotFound, "Scene not found", 404, map[string]any{"scene_id": "missing"}, nil))
+
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Equal(t, ContentTypeProblemJSON, rec.Header().Get("Content-Type"))
+
+	var body apperrors.ProblemDetails
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	require.Equal(t, apperrors.ProblemTypePrefix+"SCENE_NOT_FOUND", body.Type)
+	require.Equal(t, "Not Found", body.Title)
+	require.Equal(t, 404, body.Status)
+	require.Equal(t, "Scene not found", body.Detail)
+	require.Equal(t, "/v1/scenes/missing", body.Instance)
+	require.Equal(t, "SCENE_NOT_FOUND", body.Code)
+	require.Equal(t, "missing", body.Details["scene_id"])
+}
+
+func TestAcceptsProblemJSON(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"application/json", false},
+		{"*/*", false},
+		{"application/problem+json", true},
+		{"application/json, application/problem+json;q=0.9", true},
+		{"application/problem+json;q=0", false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		require.Equal(t, tt.want, acceptsProblemJSON(req), tt.accept)
+	}
+}
@@ -0,0 +1,169 @@
+package apikeys
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/auth"
+	"github.com/strefethen/sonos-hub-go/internal/config"
+	"github.com/strefethen/sonos-hub-go/internal/db"
+)
+
+func setupTestRepo(t *testing.T) *Repository {
+	t.Helper()
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+	return NewRepository(dbPair)
+}
+
+func TestRepository_CreateVerifyDelete(t *testing.T) {
+	repo := setupTestRepo(t)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo.now = func() time.Time { return now }
+
+	key, plaintext, err := repo.Create("Home Assistant", []auth.Scope{auth.ScopeControl})
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(plaintext, auth.APIKeyPrefix))
+	require.True(t, strings.HasPrefix(plaintext, key.Prefix))
+
+	// Only the hash is stored
+	var stored int
+	require.NoError(t, repo.reader.QueryRow("SELECT COUNT(*) FROM api_keys WHERE key_hash = ?", plaintext).Scan(&stored))
+	require.Zero(t, stored)
+
+	user, ok, err := repo.VerifyAPIKey(plaintext)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, auth.User{
+		Sub:        key.KeyID,
+		DeviceName: "Home Assistant",
+		Type:       auth.TokenTypeAPIKey,
+		Scopes:     []auth.Scope{auth.ScopeControl},
+	}, user)
+
+	keys, err := repo.List()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.NotNil(t, keys[0].LastUsedAt)
+	require.True(t, now.Equal(*keys[0].LastUsedAt))
+
+	_, ok, err = repo.VerifyAPIKey(plaintext + "x")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, repo.Delete(key.KeyID))
+	require.ErrorIs(t, repo.Delete(key.KeyID), ErrNotFound)
+	_, ok, err = repo.VerifyAPIKey(plaintext)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestMiddleware_APIKeyScopes(t *testing.T) {
+	repo := setupTestRepo(t)
+	_, readKey, err := repo.Create("reader", []auth.Scope{auth.ScopeRead})
+	require.NoError(t, err)
+	_, controlKey, err := repo.Create("controller", []auth.Scope{auth.ScopeControl})
+	require.NoError(t, err)
+	_, adminKey, err := repo.Create("admin", []auth.Scope{auth.ScopeAdmin})
+	require.NoError(t, err)
+
+	cfg := config.Config{JWTSecret: strings.Repeat("s", 32), JWTAccessTokenExpirySec: 3600, JWTRefreshTokenExpirySec: 3600}
+	router := chi.NewRouter()
+	router.Use(auth.Middleware(cfg, repo))
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		user, _ := auth.UserFromContext(r.Context())
+		w.Write([]byte(user.DeviceName))
+	}
+	router.Get("/v1/routines", ok)
+	router.Post("/v1/routines", ok)
+	router.Post("/v1/playback/pause", ok)
+	router.Get("/v1/admin/backup", ok)
+	RegisterRoutes(router, repo)
+
+	serve := func(method, path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		method, path, key string
+		want              int
+	}{
+		{http.MethodGet, "/v1/routines", readKey, http.StatusOK},
+		{http.MethodPost, "/v1/routines", readKey, http.StatusForbidden},
+		{http.MethodPost, "/v1/playback/pause", readKey, http.StatusForbidden},
+		{http.MethodGet, "/v1/routines", controlKey, http.StatusOK},
+		{http.MethodPost, "/v1/playback/pause", controlKey, http.StatusOK},
+		{http.MethodGet, "/v1/admin/backup", controlKey, http.StatusForbidden},
+		{http.MethodGet, "/v1/admin/api-keys", controlKey, http.StatusForbidden},
+		{http.MethodPost, "/v1/playback/pause", adminKey, http.StatusOK},
+		{http.MethodGet, "/v1/admin/backup", adminKey, http.StatusOK},
+		{http.MethodGet, "/v1/admin/api-keys", adminKey, http.StatusOK},
+		{http.MethodGet, "/v1/routines", auth.APIKeyPrefix + "unknown", http.StatusUnauthorized},
+		{http.MethodGet, "/v1/routines", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		rec := serve(tt.method, tt.path, tt.key)
+		require.Equal(t, tt.want, rec.Code, "%s %s with %q: %s", tt.method, tt.path, tt.key, rec.Body.String())
+	}
+
+	// Paired devices' JWTs keep full access
+	tokens, err := auth.GenerateTokenPair(cfg, auth.TokenPayload{Sub: "device-1", DeviceName: "Phone"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, serve(http.MethodGet, "/v1/admin/backup", tokens.AccessToken).Code)
+}
+
+func TestRoutes(t *testing.T) {
+	repo := setupTestRepo(t)
+	router := chi.NewRouter()
+	RegisterRoutes(router, repo)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	for _, body := range []string{`{"scopes":["read"]}`, `{"name":"x","scopes":[]}`, `{"name":"x","scopes":["root"]}`} {
+		require.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/v1/admin/api-keys", body).Code, body)
+	}
+
+	rec := serve(http.MethodPost, "/v1/admin/api-keys", `{"name":"Script","scopes":["read","control"]}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	require.Equal(t, "api_key", created["object"])
+	require.Equal(t, []any{"read", "control"}, created["scopes"])
+	require.NotEmpty(t, created["key"])
+	keyID := created["id"].(string)
+
+	// The key itself is only returned on creation
+	rec = serve(http.MethodGet, "/v1/admin/api-keys", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list struct {
+		Data []map[string]any `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Data, 1)
+	require.Equal(t, keyID, list.Data[0]["id"])
+	require.NotContains(t, list.Data[0], "key")
+	require.Nil(t, list.Data[0]["last_used_at"])
+
+	require.Equal(t, http.StatusNoContent, serve(http.MethodDelete, "/v1/admin/api-keys/"+keyID, "").Code)
+	require.Equal(t, http.StatusNotFound, serve(http.MethodDelete, "/v1/admin/api-keys/"+keyID, "").Code)
+}
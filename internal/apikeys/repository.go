@@ -0,0 +1,190 @@
+// Package apikeys manages API keys: long-lived bearer tokens with scopes, for scripts
+// and integrations that can't go through device pairing.
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/strefethen/sonos-hub-go/internal/auth"
+)
+
+// lastUsedInterval limits how often a key's last_used_at is written, so busy clients
+// don't cost a write per request.
+const lastUsedInterval = time.Minute
+
+// ErrNotFound is returned when an API key doesn't exist.
+var ErrNotFound = errors.New("api key not found")
+
+// DBPair interface for dependency injection (matches db.DBPair).
+type DBPair interface {
+	Reader() *sql.DB
+	Writer() *sql.DB
+}
+
+// APIKey is a stored key. The key itself is never stored, only its hash.
+type APIKey struct {
+	KeyID      string
+	Name       string
+	Prefix     string // The first characters of the key, to help identify it
+	Scopes     []auth.Scope
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+}
+
+// Repository stores API keys.
+type Repository struct {
+	reader *sql.DB
+	writer *sql.DB
+	now    func() time.Time
+}
+
+// NewRepository creates an API key repository.
+func NewRepository(dbPair DBPair) *Repository {
+	return &Repository{
+		reader: dbPair.Reader(),
+		writer: dbPair.Writer(),
+		now:    time.Now,
+	}
+}
+
+// Create generates and stores a new key. It returns the key's plaintext, which can't be
+// recovered later.
+func (r *Repository) Create(name string, scopes []auth.Scope) (*APIKey, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("generate api key: %w", err)
+	}
+	plaintext := auth.APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	scopesJSON, err := json.Marshal(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	key := &APIKey{
+		KeyID:     uuid.New().String(),
+		Name:      name,
+		Prefix:    plaintext[:len(auth.APIKeyPrefix)+6],
+		Scopes:    scopes,
+		CreatedAt: r.now().UTC().Truncate(time.Second),
+	}
+	_, err = r.writer.Exec(`
+		INSERT INTO api_keys (key_id, name, key_hash, key_prefix, scopes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, key.KeyID, key.Name, hashKey(plaintext), key.Prefix, string(scopesJSON), key.CreatedAt.Format(time.RFC3339))
+	if err != nil {
+		return nil, "", err
+	}
+	return key, plaintext, nil
+}
+
+// List returns every key, oldest first.
+func (r *Repository) List() ([]APIKey, error) {
+	rows, err := r.reader.Query(`
+		SELECT key_id, name, key_prefix, scopes, created_at, last_used_at
+		FROM api_keys ORDER BY created_at, key_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		key, err := scanKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// Delete revokes a key. Returns ErrNotFound if it doesn't exist.
+func (r *Repository) Delete(keyID string) error {
+	result, err := r.writer.Exec("DELETE FROM api_keys WHERE key_id = ?", keyID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// VerifyAPIKey implements auth.APIKeyVerifier. ok is false if the key doesn't exist.
+func (r *Repository) VerifyAPIKey(plaintext string) (auth.User, bool, error) {
+	row := r.reader.QueryRow(`
+		SELECT key_id, name, key_prefix, scopes, created_at, last_used_at
+		FROM api_keys WHERE key_hash = ?
+	`, hashKey(plaintext))
+	key, err := scanKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return auth.User{}, false, nil
+	}
+	if err != nil {
+		return auth.User{}, false, err
+	}
+
+	now := r.now().UTC()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedInterval {
+		if _, err := r.writer.Exec(
+			"UPDATE api_keys SET last_used_at = ? WHERE key_id = ?", now.Format(time.RFC3339), key.KeyID,
+		); err != nil {
+			return auth.User{}, false, err
+		}
+	}
+
+	return auth.User{
+		Sub:        key.KeyID,
+		DeviceName: key.Name,
+		Type:       auth.TokenTypeAPIKey,
+		Scopes:     key.Scopes,
+	}, true, nil
+}
+
+// hashKey returns the hex SHA-256 of a key. Keys are random, so an unsalted hash is
+// enough to keep them out of the database.
+func hashKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanKey(row rowScanner) (*APIKey, error) {
+	var (
+		key        APIKey
+		scopesJSON string
+		createdAt  string
+		lastUsedAt sql.NullString
+	)
+	if err := row.Scan(&key.KeyID, &key.Name, &key.Prefix, &scopesJSON, &createdAt, &lastUsedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(scopesJSON), &key.Scopes); err != nil {
+		return nil, fmt.Errorf("api key %s scopes: %w", key.KeyID, err)
+	}
+	key.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	if lastUsedAt.Valid {
+		if t, err := time.Parse(time.RFC3339, lastUsedAt.String); err == nil {
+			key.LastUsedAt = &t
+		}
+	}
+	return &key, nil
+}
@@ -0,0 +1,115 @@
+package apikeys
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/auth"
+)
+
+// maxNameLength caps the length of a key's name.
+const maxNameLength = 100
+
+// RegisterRoutes wires API key routes to the router. They sit under /v1/admin, so API
+// keys need the admin scope to manage other keys.
+func RegisterRoutes(router chi.Router, repo *Repository) {
+	router.Method(http.MethodPost, "/v1/admin/api-keys", api.Handler(createKey(repo)))
+	router.Method(http.MethodGet, "/v1/admin/api-keys", api.Handler(listKeys(repo)))
+	router.Method(http.MethodDelete, "/v1/admin/api-keys/{key_id}", api.Handler(deleteKey(repo)))
+}
+
+// createKey handles POST /v1/admin/api-keys
+// The response is the only time the key itself is returned.
+func createKey(repo *Repository) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var body struct {
+			Name   string       `json:"name"`
+			Scopes []auth.Scope `json:"scopes"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return apperrors.NewValidationError("invalid request body", nil)
+		}
+
+		body.Name = strings.TrimSpace(body.Name)
+		if body.Name == "" {
+			return apperrors.NewValidationError("name is required", nil)
+		}
+		if len(body.Name) > maxNameLength {
+			return apperrors.NewValidationError("name is too long", map[string]any{"max_length": maxNameLength})
+		}
+		if len(body.Scopes) == 0 {
+			return apperrors.NewValidationError("scopes is required", map[string]any{"valid_scopes": auth.Scopes})
+		}
+		for _, scope := range body.Scopes {
+			if !scope.IsValid() {
+				return apperrors.NewValidationError("invalid scope: "+string(scope), map[string]any{"valid_scopes": auth.Scopes})
+			}
+		}
+
+		key, plaintext, err := repo.Create(body.Name, body.Scopes)
+		if err != nil {
+			return apperrors.NewInternalError("Failed to create API key")
+		}
+
+		resource := formatKey(key)
+		resource["key"] = plaintext
+		return api.WriteResource(w, http.StatusCreated, resource)
+	}
+}
+
+// listKeys handles GET /v1/admin/api-keys
+func listKeys(repo *Repository) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		keys, err := repo.List()
+		if err != nil {
+			return apperrors.NewInternalError("Failed to list API keys")
+		}
+
+		data := make([]map[string]any, 0, len(keys))
+		for i := range keys {
+			data = append(data, formatKey(&keys[i]))
+		}
+		return api.WriteList(w, "/v1/admin/api-keys", data, false)
+	}
+}
+
+// deleteKey handles DELETE /v1/admin/api-keys/{key_id}
+// Requests using the key fail from then on.
+func deleteKey(repo *Repository) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		keyID := chi.URLParam(r, "key_id")
+		err := repo.Delete(keyID)
+		if errors.Is(err, ErrNotFound) {
+			return apperrors.NewNotFoundResource("API key", keyID)
+		}
+		if err != nil {
+			return apperrors.NewInternalError("Failed to delete API key")
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+func formatKey(key *APIKey) map[string]any {
+	var lastUsedAt any
+	if key.LastUsedAt != nil {
+		lastUsedAt = key.LastUsedAt.UTC().Format(time.RFC3339)
+	}
+	return map[string]any{
+		"object":       api.ObjectAPIKey,
+		"id":           key.KeyID,
+		"name":         key.Name,
+		"prefix":       key.Prefix,
+		"scopes":       key.Scopes,
+		"created_at":   key.CreatedAt.UTC().Format(time.RFC3339),
+		"last_used_at": lastUsedAt,
+	}
+}
@@ -1,5 +1,7 @@
 package apperrors
 
+import "net/http"
+
 // =============================================================================
 // Error Codes
 // =============================================================================
@@ -137,6 +139,45 @@ func (err *AppError) StripeErrorBody() StripeErrorBody {
 	}
 }
 
+// =============================================================================
+// RFC 7807 Problem Details
+// =============================================================================
+
+// ProblemTypePrefix is prepended to the error code to form the problem "type" URI.
+const ProblemTypePrefix = "urn:sonos-hub:error:"
+
+// ProblemDetails is the RFC 7807 application/problem+json payload.
+// Code, Details and Remediation are extension members carrying the legacy fields.
+type ProblemDetails struct {
+	Type        string         `json:"type"`
+	Title       string         `json:"title"`
+	Status      int            `json:"status"`
+	Detail      string         `json:"detail,omitempty"`
+	Instance    string         `json:"instance,omitempty"`
+	Code        string         `json:"code"`
+	Details     map[string]any `json:"details,omitempty"`
+	Remediation *Remediation   `json:"remediation,omitempty"`
+}
+
+// ProblemDetails returns the error as an RFC 7807 problem document.
+// The error code maps to type, and instance identifies the failing request.
+func (err *AppError) ProblemDetails(instance string) ProblemDetails {
+	title := http.StatusText(err.StatusCode)
+	if title == "" {
+		title = string(err.Code)
+	}
+	return ProblemDetails{
+		Type:        ProblemTypePrefix + string(err.Code),
+		Title:       title,
+		Status:      err.StatusCode,
+		Detail:      err.Message,
+		Instance:    instance,
+		Code:        string(err.Code),
+		Details:     err.Details,
+		Remediation: err.Remediation,
+	}
+}
+
 func NewAppError(code ErrorCode, message string, statusCode int, details map[string]any, remediation *Remediation) *AppError {
 	return &AppError{
 		Code:        code,
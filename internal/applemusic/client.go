@@ -36,7 +36,7 @@ func NewClient(cfg ClientConfig) *Client {
 
 	storefront := cfg.Storefront
 	if storefront == "" {
-		storefront = "us"
+		storefront = DefaultStorefront
 	}
 
 	timeout := cfg.Timeout
@@ -65,9 +65,23 @@ type Pagination struct {
 	Total  int `json:"total"`
 }
 
+// Storefront returns the client's default storefront (country code).
+func (c *Client) Storefront() string {
+	return c.storefront
+}
+
+// resolveStorefront returns the override if set, otherwise the client default.
+func (c *Client) resolveStorefront(override string) string {
+	if override != "" {
+		return override
+	}
+	return c.storefront
+}
+
 // Search performs a search on the Apple Music catalog.
 // types should be comma-separated: "songs,albums,playlists"
-func (c *Client) Search(ctx context.Context, query string, types string, limit, offset int) (*SearchResult, error) {
+// storefront overrides the client default when non-empty.
+func (c *Client) Search(ctx context.Context, query string, types string, limit, offset int, storefront string) (*SearchResult, error) {
 	if query == "" {
 		return &SearchResult{
 			Results:    make(map[string][]APISearchResult),
@@ -76,7 +90,7 @@ func (c *Client) Search(ctx context.Context, query string, types string, limit,
 	}
 
 	// Build URL
-	endpoint := fmt.Sprintf("%s/v1/catalog/%s/search", c.baseURL, c.storefront)
+	endpoint := fmt.Sprintf("%s/v1/catalog/%s/search", c.baseURL, c.resolveStorefront(storefront))
 
 	// Build query parameters
 	params := url.Values{}
@@ -123,7 +137,8 @@ type SuggestionsResult struct {
 
 // GetSuggestions fetches search suggestions from Apple Music.
 // types should be comma-separated for top results filtering.
-func (c *Client) GetSuggestions(ctx context.Context, query string, types string, limit int) (*SuggestionsResult, error) {
+// storefront overrides the client default when non-empty.
+func (c *Client) GetSuggestions(ctx context.Context, query string, types string, limit int, storefront string) (*SuggestionsResult, error) {
 	if query == "" {
 		return &SuggestionsResult{
 			Terms:      []APISuggestion{},
@@ -132,7 +147,7 @@ func (c *Client) GetSuggestions(ctx context.Context, query string, types string,
 	}
 
 	// Build URL
-	endpoint := fmt.Sprintf("%s/v1/catalog/%s/search/suggestions", c.baseURL, c.storefront)
+	endpoint := fmt.Sprintf("%s/v1/catalog/%s/search/suggestions", c.baseURL, c.resolveStorefront(storefront))
 
 	// Build query parameters
 	params := url.Values{}
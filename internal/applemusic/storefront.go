@@ -0,0 +1,41 @@
+package applemusic
+
+import "strings"
+
+// DefaultStorefront is used when no storefront is configured or requested.
+const DefaultStorefront = "us"
+
+// knownStorefronts lists ISO 3166-1 alpha-2 country codes accepted as Apple Music storefronts.
+var knownStorefronts = map[string]bool{
+	"ad": true, "ae": true, "af": true, "ag": true, "ai": true, "al": true, "am": true, "ao": true,
+	"ar": true, "at": true, "au": true, "az": true, "ba": true, "bb": true, "bd": true, "be": true,
+	"bf": true, "bg": true, "bh": true, "bj": true, "bm": true, "bn": true, "bo": true, "br": true,
+	"bs": true, "bt": true, "bw": true, "by": true, "bz": true, "ca": true, "cd": true, "cg": true,
+	"ch": true, "ci": true, "cl": true, "cm": true, "cn": true, "co": true, "cr": true, "cv": true,
+	"cy": true, "cz": true, "de": true, "dk": true, "dm": true, "do": true, "dz": true, "ec": true,
+	"ee": true, "eg": true, "es": true, "fi": true, "fj": true, "fm": true, "fr": true, "ga": true,
+	"gb": true, "gd": true, "ge": true, "gh": true, "gm": true, "gr": true, "gt": true, "gw": true,
+	"gy": true, "hk": true, "hn": true, "hr": true, "hu": true, "id": true, "ie": true, "il": true,
+	"in": true, "iq": true, "is": true, "it": true, "jm": true, "jo": true, "jp": true, "ke": true,
+	"kg": true, "kh": true, "kn": true, "kr": true, "kw": true, "ky": true, "kz": true, "la": true,
+	"lb": true, "lc": true, "lk": true, "lr": true, "lt": true, "lu": true, "lv": true, "ly": true,
+	"ma": true, "md": true, "me": true, "mg": true, "mk": true, "ml": true, "mm": true, "mn": true,
+	"mo": true, "mr": true, "ms": true, "mt": true, "mu": true, "mv": true, "mw": true, "mx": true,
+	"my": true, "mz": true, "na": true, "ne": true, "ng": true, "ni": true, "nl": true, "no": true,
+	"np": true, "nr": true, "nz": true, "om": true, "pa": true, "pe": true, "pg": true, "ph": true,
+	"pk": true, "pl": true, "pt": true, "pw": true, "py": true, "qa": true, "ro": true, "rs": true,
+	"ru": true, "rw": true, "sa": true, "sb": true, "sc": true, "se": true, "sg": true, "si": true,
+	"sk": true, "sl": true, "sn": true, "sr": true, "sv": true, "sz": true, "tc": true, "td": true,
+	"th": true, "tj": true, "tm": true, "tn": true, "to": true, "tr": true, "tt": true, "tw": true,
+	"tz": true, "ua": true, "ug": true, "us": true, "uy": true, "uz": true, "vc": true, "ve": true,
+	"vg": true, "vn": true, "vu": true, "xk": true, "ye": true, "za": true, "zm": true, "zw": true,
+}
+
+// NormalizeStorefront lowercases and trims a storefront code and reports whether it is a known two-letter code.
+func NormalizeStorefront(code string) (string, bool) {
+	normalized := strings.ToLower(strings.TrimSpace(code))
+	if len(normalized) != 2 {
+		return normalized, false
+	}
+	return normalized, knownStorefronts[normalized]
+}
@@ -0,0 +1,29 @@
+package applemusic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeStorefront(t *testing.T) {
+	code, ok := NormalizeStorefront(" GB ")
+	require.True(t, ok)
+	require.Equal(t, "gb", code)
+
+	_, ok = NormalizeStorefront("usa")
+	require.False(t, ok)
+
+	_, ok = NormalizeStorefront("zz")
+	require.False(t, ok)
+
+	_, ok = NormalizeStorefront("")
+	require.False(t, ok)
+}
+
+func TestClientResolveStorefront(t *testing.T) {
+	client := NewClient(ClientConfig{})
+	require.Equal(t, DefaultStorefront, client.Storefront())
+	require.Equal(t, DefaultStorefront, client.resolveStorefront(""))
+	require.Equal(t, "jp", client.resolveStorefront("jp"))
+}
@@ -0,0 +1,82 @@
+// Package artwork proxies album art through the hub so clients can load it over the
+// hub's own origin: speakers serve art over plain HTTP on the LAN, and service CDN URLs
+// can carry short-lived tokens.
+package artwork
+
+import (
+	"net"
+	"net/url"
+	"strings"
+	"sync/atomic"
+)
+
+// ProxyPath is the route that serves proxied artwork.
+const ProxyPath = "/v1/assets/artwork"
+
+// sonosDevicePort is the port speakers serve album art on.
+const sonosDevicePort = "1400"
+
+// DefaultAllowedHosts are the service CDNs the proxy fetches from. A host matches an
+// entry when it is the entry or a subdomain of it.
+var DefaultAllowedHosts = []string{
+	"scdn.co",               // Spotify
+	"spotifycdn.com",        // Spotify
+	"mzstatic.com",          // Apple Music
+	"media-amazon.com",      // Amazon Music, Audible
+	"ssl-images-amazon.com", // Amazon Music
+	"tunein.com",            // TuneIn
+	"radiotime.com",         // TuneIn
+	"sonos.com",             // Sonos Radio
+	"sonos.radio",           // Sonos Radio
+	"dzcdn.net",             // Deezer
+	"tidal.com",             // Tidal
+	"p-cdn.us",              // Pandora
+	"sndcdn.com",            // SoundCloud
+	"ytimg.com",             // YouTube Music
+	"ggpht.com",             // YouTube Music
+	"googleusercontent.com", // YouTube Music
+}
+
+var rewriteEnabled atomic.Bool
+
+// SetRewriteEnabled turns rewriting by RewriteURL on or off. The server sets it once at
+// startup from ARTWORK_PROXY_ENABLED; the proxy route itself is always available.
+func SetRewriteEnabled(enabled bool) {
+	rewriteEnabled.Store(enabled)
+}
+
+// RewriteURL returns uri pointed at the artwork proxy when rewriting is enabled and uri
+// is speaker or service CDN artwork the proxy would serve. Anything else, including
+// relative hub paths like service logos, is returned unchanged.
+func RewriteURL(uri string) string {
+	if !rewriteEnabled.Load() || uri == "" {
+		return uri
+	}
+	parsed, err := url.Parse(uri)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return uri
+	}
+	if !isAllowedHost(parsed.Hostname(), DefaultAllowedHosts) && !looksLikeDevice(parsed) {
+		return uri
+	}
+	return ProxyPath + "?src=" + url.QueryEscape(uri)
+}
+
+// looksLikeDevice reports whether u is on a speaker's album art port. The proxy still
+// checks the host against discovered speakers before fetching.
+func looksLikeDevice(u *url.URL) bool {
+	return u.Scheme == "http" && u.Port() == sonosDevicePort && net.ParseIP(u.Hostname()) != nil
+}
+
+func isAllowedHost(host string, allowed []string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "" {
+		return false
+	}
+	for _, suffix := range allowed {
+		if host == suffix || strings.HasSuffix(host, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+package artwork
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+)
+
+// BackfillResult summarizes a backfill run.
+type BackfillResult struct {
+	Scanned int // Rows still storing a Sonos artwork URL
+	Updated int // In a dry run, rows that would be localized
+	Failed  int // Rows left as they were because the artwork couldn't be downloaded
+}
+
+// BackfillSetItems localizes the artwork of set items that still store a Sonos URL, or
+// none at all. A set whose artwork was copied from one of those items is updated too.
+// A dry run only counts the items, downloading and writing nothing. progress, if set, is
+// called after each item.
+func (c *FavoriteCache) BackfillSetItems(dryRun bool, progress func(BackfillResult)) (BackfillResult, error) {
+	var result BackfillResult
+
+	rows, err := c.repo.reader.Query(`
+		SELECT set_id, sonos_favorite_id, COALESCE(artwork_url, '')
+		FROM set_items
+		WHERE sonos_favorite_id LIKE 'FV:2/%'
+		AND (artwork_url IS NULL OR artwork_url NOT LIKE ?)
+	`, LocalPathPrefix+"%")
+	if err != nil {
+		return result, err
+	}
+	type setItem struct {
+		setID, favoriteID, artworkURL string
+	}
+	var items []setItem
+	for rows.Next() {
+		var item setItem
+		if err := rows.Scan(&item.setID, &item.favoriteID, &item.artworkURL); err != nil {
+			rows.Close()
+			return result, err
+		}
+		items = append(items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+
+	for _, item := range items {
+		result.Scanned++
+		localized := true
+		if !dryRun {
+			if localized, err = c.backfillSetItem(item.setID, item.favoriteID, item.artworkURL); err != nil {
+				return result, err
+			}
+		}
+		if localized {
+			result.Updated++
+		} else {
+			result.Failed++
+		}
+		if progress != nil {
+			progress(result)
+		}
+	}
+	return result, nil
+}
+
+// backfillSetItem localizes one set item's artwork, reporting whether it could. Only
+// database errors are returned.
+func (c *FavoriteCache) backfillSetItem(setID, favoriteID, artworkURL string) (bool, error) {
+	localPath, err := c.Localize(favoriteID, artworkURL)
+	if err != nil {
+		slog.Warn("ARTWORK: Failed to localize artwork", "favorite_id", favoriteID, "set_id", setID, "error", err)
+		return false, nil
+	}
+	if _, err := c.repo.writer.Exec(`
+		UPDATE set_items SET artwork_url = ? WHERE set_id = ? AND sonos_favorite_id = ?
+	`, localPath, setID, favoriteID); err != nil {
+		return false, err
+	}
+	if artworkURL != "" {
+		if _, err := c.repo.writer.Exec(`
+			UPDATE music_sets SET artwork_url = ? WHERE set_id = ? AND artwork_url = ?
+		`, localPath, setID, artworkURL); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// BackfillRoutines localizes the favorite artwork of routines that still store a Sonos
+// URL, or none at all, in either the artwork column or the music content JSON. A dry run
+// only counts the routines, downloading and writing nothing. progress, if set, is called
+// after each routine.
+func (c *FavoriteCache) BackfillRoutines(dryRun bool, progress func(BackfillResult)) (BackfillResult, error) {
+	var result BackfillResult
+
+	rows, err := c.repo.reader.Query(`
+		SELECT routine_id, music_sonos_favorite_id, COALESCE(music_sonos_favorite_artwork_url, ''), music_content_json
+		FROM routines
+		WHERE music_sonos_favorite_id LIKE 'FV:2/%'
+		AND deleted_at IS NULL
+	`)
+	if err != nil {
+		return result, err
+	}
+	var routines []routineArtwork
+	for rows.Next() {
+		var r routineArtwork
+		var contentJSON sql.NullString
+		if err := rows.Scan(&r.routineID, &r.favoriteID, &r.artworkURL, &contentJSON); err != nil {
+			rows.Close()
+			return result, err
+		}
+		if contentJSON.Valid && contentJSON.String != "" {
+			_ = json.Unmarshal([]byte(contentJSON.String), &r.content)
+		}
+		contentURL, _ := r.content["artworkUrl"].(string)
+		if IsLocalPath(r.artworkURL) && (r.content == nil || IsLocalPath(contentURL)) {
+			continue
+		}
+		// Routines saved from the app only carry the artwork in the content JSON
+		if r.artworkURL == "" {
+			r.artworkURL = contentURL
+		}
+		routines = append(routines, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+
+	for _, r := range routines {
+		result.Scanned++
+		localized := true
+		if !dryRun {
+			if localized, err = c.backfillRoutine(r); err != nil {
+				return result, err
+			}
+		}
+		if localized {
+			result.Updated++
+		} else {
+			result.Failed++
+		}
+		if progress != nil {
+			progress(result)
+		}
+	}
+	return result, nil
+}
+
+// routineArtwork is a routine whose favorite artwork needs localizing.
+type routineArtwork struct {
+	routineID, favoriteID, artworkURL string
+	content                           map[string]any // Decoded music_content_json, if any
+}
+
+// backfillRoutine localizes one routine's artwork, reporting whether it could. Only
+// database errors are returned.
+func (c *FavoriteCache) backfillRoutine(r routineArtwork) (bool, error) {
+	localPath, err := c.Localize(r.favoriteID, r.artworkURL)
+	if err != nil {
+		slog.Warn("ARTWORK: Failed to localize artwork", "routine_id", r.routineID, "error", err)
+		return false, nil
+	}
+
+	var contentJSON sql.NullString
+	if r.content != nil {
+		r.content["artworkUrl"] = localPath
+		encoded, err := json.Marshal(r.content)
+		if err != nil {
+			return false, err
+		}
+		contentJSON = sql.NullString{String: string(encoded), Valid: true}
+	}
+	if _, err := c.repo.writer.Exec(`
+		UPDATE routines
+		SET music_sonos_favorite_artwork_url = ?,
+			music_content_json = COALESCE(?, music_content_json),
+			updated_at = ?
+		WHERE routine_id = ?
+	`, localPath, contentJSON, formatTime(c.now()), r.routineID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
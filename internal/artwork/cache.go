@@ -0,0 +1,198 @@
+package artwork
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// tempFilePattern names in-progress downloads; they're renamed into place once complete.
+const tempFilePattern = "artwork-*.tmp"
+
+var cacheKeyPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// diskCache stores artwork on disk, evicting the least recently used files once the
+// total size passes maxBytes.
+type diskCache struct {
+	dir      string
+	maxBytes int64
+
+	mu    sync.Mutex
+	order *list.List               // Front is most recently used
+	files map[string]*list.Element // By key
+	size  int64
+}
+
+type cacheEntry struct {
+	key         string
+	size        int64
+	contentType string // Empty for files found on disk at startup
+}
+
+// cacheKey names the cache file for src.
+func cacheKey(src string) string {
+	sum := sha256.Sum256([]byte(src))
+	return hex.EncodeToString(sum[:])
+}
+
+// newDiskCache opens the cache in dir, creating it if needed and indexing files left by
+// a previous run so the oldest are evicted first.
+func newDiskCache(dir string, maxBytes int64) (*diskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	cache := &diskCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		order:    list.New(),
+		files:    make(map[string]*list.Element),
+	}
+
+	// Downloads interrupted by a restart are never completed
+	if stale, err := filepath.Glob(filepath.Join(dir, tempFilePattern)); err == nil {
+		for _, path := range stale {
+			_ = os.Remove(path)
+		}
+	}
+
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type existing struct {
+		entry   *cacheEntry
+		modTime int64
+	}
+	found := make([]existing, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || !cacheKeyPattern.MatchString(dirEntry.Name()) {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+		found = append(found, existing{
+			entry:   &cacheEntry{key: dirEntry.Name(), size: info.Size()},
+			modTime: info.ModTime().UnixNano(),
+		})
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].modTime > found[j].modTime })
+
+	cache.mu.Lock()
+	for _, file := range found {
+		cache.files[file.entry.key] = cache.order.PushBack(file.entry)
+		cache.size += file.entry.size
+	}
+	cache.evictLocked()
+	cache.mu.Unlock()
+
+	return cache, nil
+}
+
+// Open returns the cached file for key and its content type, marking it recently used.
+func (c *diskCache) Open(key string) (*os.File, string, bool) {
+	c.mu.Lock()
+	element, ok := c.files[key]
+	if !ok {
+		c.mu.Unlock()
+		return nil, "", false
+	}
+	c.order.MoveToFront(element)
+	contentType := element.Value.(*cacheEntry).contentType
+	c.mu.Unlock()
+
+	file, err := os.Open(c.path(key))
+	if err != nil {
+		c.remove(key)
+		return nil, "", false
+	}
+	return file, contentType, true
+}
+
+// Create starts writing a file for key. The file only becomes visible to Open once
+// commit is called; abort discards it.
+func (c *diskCache) Create(key, contentType string) (file *os.File, commit func() error, abort func(), err error) {
+	file, err = os.CreateTemp(c.dir, tempFilePattern)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	abort = func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}
+	commit = func() error {
+		info, err := file.Stat()
+		if err != nil {
+			abort()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			_ = os.Remove(file.Name())
+			return err
+		}
+		if err := os.Rename(file.Name(), c.path(key)); err != nil {
+			_ = os.Remove(file.Name())
+			return err
+		}
+		c.add(&cacheEntry{key: key, size: info.Size(), contentType: contentType})
+		return nil
+	}
+	return file, commit, abort, nil
+}
+
+// Size returns the total size of the cached files.
+func (c *diskCache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+func (c *diskCache) add(entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.files[entry.key]; ok {
+		c.size -= element.Value.(*cacheEntry).size
+		c.order.Remove(element)
+	}
+	c.files[entry.key] = c.order.PushFront(entry)
+	c.size += entry.size
+	c.evictLocked()
+}
+
+func (c *diskCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.files[key]; ok {
+		c.size -= element.Value.(*cacheEntry).size
+		c.order.Remove(element)
+		delete(c.files, key)
+	}
+}
+
+// evictLocked removes least recently used files until the cache fits in maxBytes.
+func (c *diskCache) evictLocked() {
+	for c.size > c.maxBytes && c.order.Len() > 0 {
+		element := c.order.Back()
+		entry := element.Value.(*cacheEntry)
+		c.order.Remove(element)
+		delete(c.files, entry.key)
+		c.size -= entry.size
+		if err := os.Remove(c.path(entry.key)); err != nil && !os.IsNotExist(err) {
+			slog.Warn("ARTWORK: Failed to evict", "key", entry.key, "error", err)
+		}
+	}
+}
+
+func (c *diskCache) path(key string) string {
+	return filepath.Join(c.dir, key)
+}
@@ -0,0 +1,364 @@
+package artwork
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// LocalPathPrefix starts the path of every locally stored favorite artwork.
+const LocalPathPrefix = ProxyPath + "/"
+
+// Favorite artwork refresh settings.
+const (
+	RefreshInterval      = 7 * 24 * time.Hour // How often each copy is revalidated
+	refreshCheckInterval = 24 * time.Hour     // How often the refresh job looks for stale copies
+	refreshStartDelay    = time.Minute        // Lets discovery settle before the first run
+)
+
+// errNoSource is returned when a favorite has no artwork to download.
+var errNoSource = errors.New("favorite has no artwork")
+
+// FavoriteSource supplies the current artwork URL of each Sonos favorite.
+type FavoriteSource interface {
+	// FavoriteArtworkURLs returns absolute artwork URLs by favorite ID.
+	FavoriteArtworkURLs() (map[string]string, error)
+}
+
+// SOAPFavoriteSource browses favorites from one speaker.
+type SOAPFavoriteSource struct {
+	Client   *soap.Client
+	DeviceIP string
+	Timeout  time.Duration
+}
+
+// FavoriteArtworkURLs browses every favorite, making speaker-relative artwork URLs absolute.
+func (s SOAPFavoriteSource) FavoriteArtworkURLs() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.Timeout)
+	defer cancel()
+
+	urls := make(map[string]string)
+	start := 0
+	for {
+		result, err := s.Client.Browse(ctx, s.DeviceIP, "FV:2", "BrowseDirectChildren", "*", start, 100)
+		if err != nil {
+			return nil, err
+		}
+		for _, favorite := range result.Items {
+			if uri := absoluteArtworkURL(favorite.AlbumArtURI, s.DeviceIP); uri != "" {
+				urls[favorite.ID] = uri
+			}
+		}
+		start += len(result.Items)
+		if len(result.Items) == 0 || start >= result.TotalMatches {
+			return urls, nil
+		}
+	}
+}
+
+// absoluteArtworkURL decodes HTML entities left in favorite metadata and makes
+// speaker-relative paths absolute.
+func absoluteArtworkURL(uri, deviceIP string) string {
+	uri = strings.ReplaceAll(strings.TrimSpace(uri), "&amp;", "&")
+	if strings.HasPrefix(uri, "/") && deviceIP != "" {
+		return "http://" + deviceIP + ":" + sonosDevicePort + uri
+	}
+	if strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://") {
+		return uri
+	}
+	return ""
+}
+
+// IsLocalPath reports whether uri is a locally stored favorite artwork path.
+func IsLocalPath(uri string) bool {
+	return strings.HasPrefix(uri, LocalPathPrefix)
+}
+
+// FavoriteCache keeps local copies of Sonos favorite artwork. Favorite artwork URLs embed
+// session tokens that expire, so set items and routines store the local path instead,
+// which stays the same when the copy is refreshed.
+type FavoriteCache struct {
+	dir       string
+	repo      *repository
+	favorites FavoriteSource
+	client    *http.Client
+	now       func() time.Time
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewFavoriteCache creates a FavoriteCache storing images in dir.
+func NewFavoriteCache(dbPair DBPair, dir string, favorites FavoriteSource) (*FavoriteCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create favorite artwork dir: %w", err)
+	}
+	return &FavoriteCache{
+		dir:       dir,
+		repo:      newRepository(dbPair),
+		favorites: favorites,
+		client:    &http.Client{Timeout: DefaultFetchTimeout},
+		now:       time.Now,
+		stopCh:    make(chan struct{}),
+	}, nil
+}
+
+// favoriteKey names a favorite's artwork. It depends only on the favorite, so the local
+// path survives refreshes.
+func favoriteKey(favoriteID string) string {
+	return cacheKey("favorite:" + favoriteID)
+}
+
+// LocalPath returns the path a favorite's artwork is served from.
+func LocalPath(favoriteID string) string {
+	return LocalPathPrefix + favoriteKey(favoriteID)
+}
+
+// Localize returns the local path for favoriteID's artwork, downloading it the first
+// time. artworkURL is where the caller last saw the artwork; when it is empty or relative
+// the favorite's current URL is browsed instead. On failure artworkURL is returned
+// along with the error, so callers can fall back to storing it as before. A nil
+// FavoriteCache returns artworkURL unchanged.
+func (c *FavoriteCache) Localize(favoriteID, artworkURL string) (string, error) {
+	if c == nil || favoriteID == "" || IsLocalPath(artworkURL) {
+		return artworkURL, nil
+	}
+
+	hash := favoriteKey(favoriteID)
+	existing, err := c.repo.Get(hash)
+	if err != nil {
+		return artworkURL, err
+	}
+	if existing != nil && c.hasFile(hash) {
+		return LocalPath(favoriteID), nil
+	}
+
+	source := absoluteArtworkURL(artworkURL, "")
+	if source == "" {
+		urls, err := c.favorites.FavoriteArtworkURLs()
+		if err != nil {
+			return artworkURL, fmt.Errorf("browse favorites: %w", err)
+		}
+		source = urls[favoriteID]
+	}
+	if source == "" {
+		return artworkURL, errNoSource
+	}
+
+	if err := c.download(hash, favoriteID, source); err != nil {
+		return artworkURL, err
+	}
+	return LocalPath(favoriteID), nil
+}
+
+// download fetches source into the favorite's file, replacing any earlier copy.
+func (c *FavoriteCache) download(hash, favoriteID, source string) error {
+	req, err := http.NewRequest(http.MethodGet, source, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "image/*")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("artwork source returned %d", resp.StatusCode)
+	}
+
+	file, err := os.CreateTemp(c.dir, tempFilePattern)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name()) // No-op once renamed
+
+	size, err := io.Copy(file, io.LimitReader(resp.Body, maxArtworkBytes+1))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if size > maxArtworkBytes {
+		return fmt.Errorf("artwork larger than %d bytes", maxArtworkBytes)
+	}
+
+	contentType, err := sniffFile(file.Name())
+	if err != nil {
+		return err
+	}
+	if !isImageType(contentType) {
+		return fmt.Errorf("artwork source returned %s, not an image", contentType)
+	}
+
+	if err := os.Rename(file.Name(), c.filePath(hash)); err != nil {
+		return err
+	}
+
+	now := c.now()
+	return c.repo.Upsert(FavoriteArtwork{
+		Hash:        hash,
+		FavoriteID:  favoriteID,
+		SourceURL:   source,
+		ContentType: contentType,
+		SizeBytes:   size,
+		FetchedAt:   now,
+		CheckedAt:   now,
+	})
+}
+
+// RefreshResult summarizes a refresh of stale artwork.
+type RefreshResult struct {
+	Checked   int
+	Refreshed int
+	Failed    int // Copies kept because the favorite or its artwork couldn't be fetched
+}
+
+// RefreshStale downloads again every copy not checked within RefreshInterval, from the
+// favorite's current artwork URL. A copy that can't be refreshed is kept as it is.
+func (c *FavoriteCache) RefreshStale() (RefreshResult, error) {
+	var result RefreshResult
+	stale, err := c.repo.CheckedBefore(c.now().Add(-RefreshInterval))
+	if err != nil || len(stale) == 0 {
+		return result, err
+	}
+
+	// Stored URLs have probably expired; fall back to them only if browsing fails
+	urls, err := c.favorites.FavoriteArtworkURLs()
+	if err != nil {
+		slog.Warn("ARTWORK: Failed to browse favorites for refresh, retrying stored URLs", "error", err)
+		urls = nil
+	}
+
+	for _, entry := range stale {
+		result.Checked++
+		source := entry.SourceURL
+		if current := urls[entry.FavoriteID]; current != "" {
+			source = current
+		}
+		if err := c.download(entry.Hash, entry.FavoriteID, source); err != nil {
+			slog.Warn("ARTWORK: Failed to refresh favorite artwork", "favorite_id", entry.FavoriteID, "error", err)
+			result.Failed++
+			if err := c.repo.MarkChecked(entry.Hash, c.now()); err != nil {
+				return result, err
+			}
+			continue
+		}
+		result.Refreshed++
+	}
+	return result, nil
+}
+
+// Serve writes the artwork with hash to w.
+func (c *FavoriteCache) Serve(w http.ResponseWriter, r *http.Request, hash string) error {
+	if !cacheKeyPattern.MatchString(hash) {
+		return apperrors.NewNotFoundResource("Artwork", hash)
+	}
+	artwork, err := c.repo.Get(hash)
+	if err != nil {
+		return apperrors.NewInternalError("Failed to look up artwork")
+	}
+	if artwork == nil {
+		return apperrors.NewNotFoundResource("Artwork", hash)
+	}
+	file, err := os.Open(c.filePath(hash))
+	if err != nil {
+		return apperrors.NewNotFoundResource("Artwork", hash)
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", artwork.ContentType)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, "", artwork.FetchedAt, file)
+	return nil
+}
+
+// StartRefreshJob backfills and refreshes artwork in the background: shortly after
+// start, then daily.
+func (c *FavoriteCache) StartRefreshJob() {
+	slog.Info("Starting favorite artwork refresh job", "interval", RefreshInterval)
+	c.wg.Add(1)
+	go c.runRefreshLoop()
+}
+
+// StopRefreshJob stops the background refresh job.
+func (c *FavoriteCache) StopRefreshJob() {
+	close(c.stopCh)
+	c.wg.Wait()
+}
+
+func (c *FavoriteCache) runRefreshLoop() {
+	defer c.wg.Done()
+
+	timer := time.NewTimer(refreshStartDelay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-timer.C:
+			c.runMaintenance()
+			timer.Reset(refreshCheckInterval)
+		}
+	}
+}
+
+// runMaintenance localizes artwork still stored as Sonos URLs, then refreshes stale copies.
+func (c *FavoriteCache) runMaintenance() {
+	if result, err := c.BackfillSetItems(false, nil); err != nil {
+		slog.Error("ARTWORK: Set item backfill failed", "error", err)
+	} else if result.Updated > 0 || result.Failed > 0 {
+		slog.Info("ARTWORK: Set item backfill", "updated", result.Updated, "failed", result.Failed)
+	}
+
+	if result, err := c.BackfillRoutines(false, nil); err != nil {
+		slog.Error("ARTWORK: Routine backfill failed", "error", err)
+	} else if result.Updated > 0 || result.Failed > 0 {
+		slog.Info("ARTWORK: Routine backfill", "updated", result.Updated, "failed", result.Failed)
+	}
+
+	if result, err := c.RefreshStale(); err != nil {
+		slog.Error("ARTWORK: Refresh failed", "error", err)
+	} else if result.Checked > 0 {
+		slog.Info("ARTWORK: Refreshed stale favorite artwork", "refreshed", result.Refreshed, "checked", result.Checked, "kept", result.Failed)
+	}
+}
+
+func (c *FavoriteCache) filePath(hash string) string {
+	return filepath.Join(c.dir, hash+".jpg")
+}
+
+func (c *FavoriteCache) hasFile(hash string) bool {
+	_, err := os.Stat(c.filePath(hash))
+	return err == nil
+}
+
+// sniffFile detects the content type of the file at path.
+func sniffFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return http.DetectContentType(head[:n]), nil
+}
@@ -0,0 +1,265 @@
+package artwork
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/db"
+)
+
+// fakeFavorites returns fixed artwork URLs, counting browses.
+type fakeFavorites struct {
+	urls    map[string]string
+	err     error
+	browses int32
+}
+
+func (f *fakeFavorites) FavoriteArtworkURLs() (map[string]string, error) {
+	atomic.AddInt32(&f.browses, 1)
+	return f.urls, f.err
+}
+
+func setupFavoriteCache(t *testing.T, favorites FavoriteSource) (*FavoriteCache, *db.DBPair) {
+	t.Helper()
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+
+	cache, err := NewFavoriteCache(dbPair, t.TempDir(), favorites)
+	require.NoError(t, err)
+	return cache, dbPair
+}
+
+// newArtServer serves art at any path except /gone, counting requests.
+func newArtServer(t *testing.T, art []byte) (*httptest.Server, *int32) {
+	t.Helper()
+	var fetches int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		if r.URL.Path == "/gone" {
+			http.Error(w, "expired", http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write(art)
+	}))
+	t.Cleanup(server.Close)
+	return server, &fetches
+}
+
+func TestFavoriteCache_Localize(t *testing.T) {
+	art := pngBytes(t)
+	server, fetches := newArtServer(t, art)
+	favorites := &fakeFavorites{urls: map[string]string{"FV:2/7": server.URL + "/current"}}
+	cache, _ := setupFavoriteCache(t, favorites)
+
+	localPath, err := cache.Localize("FV:2/5", server.URL+"/art?token=abc")
+	require.NoError(t, err)
+	require.Equal(t, LocalPath("FV:2/5"), localPath)
+	require.True(t, IsLocalPath(localPath))
+
+	// Already stored: no download, and local paths pass through
+	again, err := cache.Localize("FV:2/5", server.URL+"/other")
+	require.NoError(t, err)
+	require.Equal(t, localPath, again)
+	again, err = cache.Localize("FV:2/5", localPath)
+	require.NoError(t, err)
+	require.Equal(t, localPath, again)
+	require.Equal(t, int32(1), atomic.LoadInt32(fetches))
+	require.Zero(t, atomic.LoadInt32(&favorites.browses))
+
+	// Without a usable URL the favorite's current artwork is browsed
+	localPath, err = cache.Localize("FV:2/7", "/getaa?s=1")
+	require.NoError(t, err)
+	require.Equal(t, LocalPath("FV:2/7"), localPath)
+	require.Equal(t, int32(1), atomic.LoadInt32(&favorites.browses))
+
+	// Failures hand back the original URL
+	localPath, err = cache.Localize("FV:2/9", server.URL+"/gone")
+	require.Error(t, err)
+	require.Equal(t, server.URL+"/gone", localPath)
+
+	// A nil cache leaves URLs alone
+	var disabled *FavoriteCache
+	localPath, err = disabled.Localize("FV:2/5", "http://speaker/art")
+	require.NoError(t, err)
+	require.Equal(t, "http://speaker/art", localPath)
+}
+
+func TestFavoriteCache_Serve(t *testing.T) {
+	art := pngBytes(t)
+	server, _ := newArtServer(t, art)
+	cache, _ := setupFavoriteCache(t, &fakeFavorites{})
+
+	localPath, err := cache.Localize("FV:2/5", server.URL+"/art")
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	RegisterRoutes(router, nil, cache)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, localPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+	require.Equal(t, art, rec.Body.Bytes())
+
+	for _, path := range []string{LocalPath("FV:2/404"), LocalPathPrefix + "..%2Fetc"} {
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusNotFound, rec.Code, path)
+	}
+}
+
+func TestFavoriteCache_RefreshStale(t *testing.T) {
+	art := pngBytes(t)
+	server, fetches := newArtServer(t, art)
+	favorites := &fakeFavorites{urls: map[string]string{"FV:2/5": server.URL + "/fresh"}}
+	cache, _ := setupFavoriteCache(t, favorites)
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+	_, err := cache.Localize("FV:2/5", server.URL+"/art")
+	require.NoError(t, err)
+	_, err = cache.Localize("FV:2/6", server.URL+"/art")
+	require.NoError(t, err)
+
+	result, err := cache.RefreshStale()
+	require.NoError(t, err)
+	require.Equal(t, RefreshResult{}, result)
+
+	// A week on, FV:2/5 refreshes from its current URL; FV:2/6 is gone from favorites
+	// and its stored URL has expired, so its copy is kept
+	now = now.Add(RefreshInterval + time.Hour)
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(fetches, 1)
+		if r.URL.Path != "/fresh" {
+			http.Error(w, "expired", http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write(art)
+	})
+	result, err = cache.RefreshStale()
+	require.NoError(t, err)
+	require.Equal(t, RefreshResult{Checked: 2, Refreshed: 1, Failed: 1}, result)
+	require.True(t, cache.hasFile(favoriteKey("FV:2/6")))
+
+	refreshed, err := cache.repo.Get(favoriteKey("FV:2/5"))
+	require.NoError(t, err)
+	require.Equal(t, server.URL+"/fresh", refreshed.SourceURL)
+	require.True(t, refreshed.FetchedAt.Equal(now))
+
+	// Both count as checked until the next interval
+	result, err = cache.RefreshStale()
+	require.NoError(t, err)
+	require.Equal(t, RefreshResult{}, result)
+
+	// If browsing fails, stored URLs are retried
+	favorites.err = errors.New("no speaker")
+	now = now.Add(RefreshInterval + time.Hour)
+	result, err = cache.RefreshStale()
+	require.NoError(t, err)
+	require.Equal(t, RefreshResult{Checked: 2, Refreshed: 1, Failed: 1}, result)
+}
+
+func TestFavoriteCache_BackfillSetItems(t *testing.T) {
+	art := pngBytes(t)
+	server, fetches := newArtServer(t, art)
+	cache, dbPair := setupFavoriteCache(t, &fakeFavorites{urls: map[string]string{"FV:2/2": server.URL + "/art2"}})
+
+	rawURL := server.URL + "/art1"
+	exec := func(query string, args ...any) {
+		t.Helper()
+		_, err := dbPair.Writer().Exec(query, args...)
+		require.NoError(t, err)
+	}
+	exec(`INSERT INTO music_sets (set_id, name, selection_policy, artwork_url, created_at, updated_at)
+		VALUES ('set-1', 'Morning', 'ROTATION', ?, '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z')`, rawURL)
+	exec(`INSERT INTO set_items (set_id, sonos_favorite_id, position, added_at, artwork_url) VALUES
+		('set-1', 'FV:2/1', 0, '2026-01-01T00:00:00Z', ?),
+		('set-1', 'FV:2/2', 1, '2026-01-01T00:00:00Z', NULL),
+		('set-1', 'FV:2/3', 2, '2026-01-01T00:00:00Z', ?),
+		('set-1', 'spotify:track:1', 3, '2026-01-01T00:00:00Z', 'https://i.scdn.co/image/abc')`,
+		rawURL, server.URL+"/gone")
+
+	// A dry run downloads and writes nothing
+	result, err := cache.BackfillSetItems(true, nil)
+	require.NoError(t, err)
+	require.Equal(t, BackfillResult{Scanned: 3, Updated: 3}, result)
+	require.Equal(t, int32(0), atomic.LoadInt32(fetches))
+
+	result, err = cache.BackfillSetItems(false, nil)
+	require.NoError(t, err)
+	require.Equal(t, BackfillResult{Scanned: 3, Updated: 2, Failed: 1}, result)
+
+	artworkURL := func(favoriteID string) string {
+		var url string
+		require.NoError(t, dbPair.Reader().QueryRow(
+			`SELECT artwork_url FROM set_items WHERE sonos_favorite_id = ?`, favoriteID).Scan(&url))
+		return url
+	}
+	require.Equal(t, LocalPath("FV:2/1"), artworkURL("FV:2/1"))
+	require.Equal(t, LocalPath("FV:2/2"), artworkURL("FV:2/2"))
+	require.Equal(t, server.URL+"/gone", artworkURL("FV:2/3"))
+	require.Equal(t, "https://i.scdn.co/image/abc", artworkURL("spotify:track:1"))
+
+	var setArtwork string
+	require.NoError(t, dbPair.Reader().QueryRow(`SELECT artwork_url FROM music_sets WHERE set_id = 'set-1'`).Scan(&setArtwork))
+	require.Equal(t, LocalPath("FV:2/1"), setArtwork)
+
+	// Only the failed item is retried
+	result, err = cache.BackfillSetItems(false, nil)
+	require.NoError(t, err)
+	require.Equal(t, BackfillResult{Scanned: 1, Failed: 1}, result)
+}
+
+func TestFavoriteCache_BackfillRoutines(t *testing.T) {
+	art := pngBytes(t)
+	server, _ := newArtServer(t, art)
+	cache, dbPair := setupFavoriteCache(t, &fakeFavorites{})
+
+	_, err := dbPair.Writer().Exec(`INSERT INTO scenes (scene_id, name) VALUES ('scene-1', 'Kitchen')`)
+	require.NoError(t, err)
+	content := `{"type":"sonos_favorite","favoriteId":"FV:2/1","name":"Jazz","artworkUrl":"` + server.URL + `/art"}`
+	_, err = dbPair.Writer().Exec(`
+		INSERT INTO routines (routine_id, name, timezone, schedule_time, scene_id, music_sonos_favorite_id,
+			music_content_json, created_at, updated_at)
+		VALUES ('routine-1', 'Wake up', 'UTC', '07:00', 'scene-1', 'FV:2/1', ?, '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z')
+	`, content)
+	require.NoError(t, err)
+
+	result, err := cache.BackfillRoutines(false, nil)
+	require.NoError(t, err)
+	require.Equal(t, BackfillResult{Scanned: 1, Updated: 1}, result)
+
+	var column string
+	var contentJSON sql.NullString
+	require.NoError(t, dbPair.Reader().QueryRow(`
+		SELECT music_sonos_favorite_artwork_url, music_content_json FROM routines WHERE routine_id = 'routine-1'
+	`).Scan(&column, &contentJSON))
+	require.Equal(t, LocalPath("FV:2/1"), column)
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal([]byte(contentJSON.String), &decoded))
+	require.Equal(t, LocalPath("FV:2/1"), decoded["artworkUrl"])
+	require.Equal(t, "Jazz", decoded["name"])
+
+	// Nothing left to do
+	result, err = cache.BackfillRoutines(false, nil)
+	require.NoError(t, err)
+	require.Equal(t, BackfillResult{}, result)
+
+	// The copy is on disk under the favorite's hash
+	_, err = os.Stat(cache.filePath(favoriteKey("FV:2/1")))
+	require.NoError(t, err)
+}
@@ -0,0 +1,224 @@
+package artwork
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+)
+
+// Proxy defaults.
+const (
+	DefaultCacheMaxBytes = 100 << 20 // 100 MiB
+	DefaultFetchTimeout  = 10 * time.Second
+	maxArtworkBytes      = 10 << 20 // Larger responses aren't album art
+	maxRedirects         = 3
+	sniffLen             = 512 // Bytes http.DetectContentType looks at
+)
+
+// cacheControl lets clients keep artwork for a day; the src URL changes with the art.
+const cacheControl = "public, max-age=86400"
+
+// ErrSourceNotAllowed is returned for artwork that isn't on a discovered speaker or an
+// allowed service CDN.
+var ErrSourceNotAllowed = errors.New("artwork source not allowed")
+
+// Config configures a Proxy.
+type Config struct {
+	CacheDir      string
+	CacheMaxBytes int64 // Unset uses DefaultCacheMaxBytes
+	// IsDeviceIP reports whether an IP belongs to a discovered speaker.
+	IsDeviceIP   func(ip string) bool
+	AllowedHosts []string      // Unset uses DefaultAllowedHosts
+	FetchTimeout time.Duration // Unset uses DefaultFetchTimeout
+}
+
+// Proxy fetches artwork from speakers and service CDNs and caches it on disk.
+type Proxy struct {
+	client       *http.Client
+	cache        *diskCache
+	isDeviceIP   func(ip string) bool
+	allowedHosts []string
+}
+
+// NewProxy creates a Proxy, opening its cache directory.
+func NewProxy(cfg Config) (*Proxy, error) {
+	if cfg.CacheMaxBytes <= 0 {
+		cfg.CacheMaxBytes = DefaultCacheMaxBytes
+	}
+	if cfg.AllowedHosts == nil {
+		cfg.AllowedHosts = DefaultAllowedHosts
+	}
+	if cfg.FetchTimeout <= 0 {
+		cfg.FetchTimeout = DefaultFetchTimeout
+	}
+	if cfg.IsDeviceIP == nil {
+		cfg.IsDeviceIP = func(string) bool { return false }
+	}
+
+	cache, err := newDiskCache(cfg.CacheDir, cfg.CacheMaxBytes)
+	if err != nil {
+		return nil, fmt.Errorf("open artwork cache: %w", err)
+	}
+
+	proxy := &Proxy{
+		cache:        cache,
+		isDeviceIP:   cfg.IsDeviceIP,
+		allowedHosts: cfg.AllowedHosts,
+	}
+	proxy.client = &http.Client{
+		Timeout: cfg.FetchTimeout,
+		// A redirect must land somewhere the proxy would fetch from directly
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.New("too many redirects")
+			}
+			if !proxy.Allowed(req.URL) {
+				return ErrSourceNotAllowed
+			}
+			return nil
+		},
+	}
+	return proxy, nil
+}
+
+// Allowed reports whether the proxy fetches from src: a speaker's album art port, or an
+// allowed service CDN.
+func (p *Proxy) Allowed(src *url.URL) bool {
+	switch src.Scheme {
+	case "http", "https":
+	default:
+		return false
+	}
+	if src.User != nil {
+		return false
+	}
+
+	host := src.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		return src.Scheme == "http" && src.Port() == sonosDevicePort && p.isDeviceIP(ip.String())
+	}
+	return isAllowedHost(host, p.allowedHosts)
+}
+
+// Serve writes the artwork at src to w, from the cache or streamed from src while it is
+// cached. Once the response has started, failures are logged rather than returned.
+func (p *Proxy) Serve(w http.ResponseWriter, r *http.Request, src *url.URL) error {
+	if !p.Allowed(src) {
+		return apperrors.NewForbiddenError("Artwork source is not a Sonos speaker or allowed service CDN")
+	}
+
+	key := cacheKey(src.String())
+	if file, contentType, ok := p.cache.Open(key); ok {
+		defer file.Close()
+		if contentType == "" {
+			head := make([]byte, sniffLen)
+			n, _ := io.ReadFull(file, head)
+			contentType = http.DetectContentType(head[:n])
+			if _, err := file.Seek(0, io.SeekStart); err != nil {
+				return apperrors.NewInternalError("Failed to read cached artwork")
+			}
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", cacheControl)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("X-Cache", "HIT")
+		http.ServeContent(w, r, "", time.Time{}, file)
+		return nil
+	}
+
+	return p.fetch(w, r, src, key)
+}
+
+// fetch streams src to w, writing it to the cache as it goes. Partial or oversized
+// downloads aren't cached.
+func (p *Proxy) fetch(w http.ResponseWriter, r *http.Request, src *url.URL, key string) error {
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, src.String(), nil)
+	if err != nil {
+		return apperrors.NewValidationError("src must be a valid URL", nil)
+	}
+	req.Header.Set("Accept", "image/*")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrSourceNotAllowed) {
+			return apperrors.NewForbiddenError("Artwork source redirected somewhere not allowed")
+		}
+		slog.Warn("ARTWORK: Fetch failed", "host", src.Host, "error", err)
+		return artworkUnavailable(0)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return apperrors.NewNotFoundError("Artwork not found at source", nil)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return artworkUnavailable(resp.StatusCode)
+	}
+	if resp.ContentLength > maxArtworkBytes {
+		return apperrors.NewAppError(apperrors.ErrorCodeContentUnavailable, "Artwork is too large", http.StatusBadGateway, map[string]any{
+			"max_bytes": maxArtworkBytes,
+		}, nil)
+	}
+
+	body := bufio.NewReaderSize(io.LimitReader(resp.Body, maxArtworkBytes+1), sniffLen)
+	contentType := resp.Header.Get("Content-Type")
+	if !isImageType(contentType) {
+		// Speakers and some CDNs omit or mislabel the type; let the bytes decide
+		head, _ := body.Peek(sniffLen)
+		contentType = http.DetectContentType(head)
+	}
+	if !isImageType(contentType) {
+		return apperrors.NewAppError(apperrors.ErrorCodeContentUnavailable, "Artwork source did not return an image", http.StatusBadGateway, nil, nil)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("X-Cache", "MISS")
+	if resp.ContentLength >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(resp.ContentLength, 10))
+	}
+
+	file, commit, abort, err := p.cache.Create(key, contentType)
+	if err != nil {
+		slog.Warn("ARTWORK: Failed to create cache file", "error", err)
+		_, _ = io.Copy(w, body)
+		return nil
+	}
+
+	written, err := io.Copy(io.MultiWriter(w, file), body)
+	if err != nil || written > maxArtworkBytes || (resp.ContentLength >= 0 && written != resp.ContentLength) {
+		abort()
+		return nil
+	}
+	if err := commit(); err != nil {
+		slog.Warn("ARTWORK: Failed to cache artwork", "error", err)
+	}
+	return nil
+}
+
+// isImageType reports whether contentType is a raster image type. SVG is refused since it
+// can carry script that would run on the hub's origin.
+func isImageType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && strings.HasPrefix(mediaType, "image/") && mediaType != "image/svg+xml"
+}
+
+func artworkUnavailable(upstreamStatus int) error {
+	var details map[string]any
+	if upstreamStatus != 0 {
+		details = map[string]any{"upstream_status": upstreamStatus}
+	}
+	return apperrors.NewAppError(apperrors.ErrorCodeContentUnavailable, "Failed to fetch artwork", http.StatusBadGateway, details, nil)
+}
@@ -0,0 +1,108 @@
+package artwork
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// DBPair interface for dependency injection (matches db.DBPair).
+type DBPair interface {
+	Reader() *sql.DB
+	Writer() *sql.DB
+}
+
+// FavoriteArtwork is a locally stored copy of a Sonos favorite's artwork.
+type FavoriteArtwork struct {
+	Hash        string
+	FavoriteID  string
+	SourceURL   string // Artwork URL the copy was last downloaded from
+	ContentType string
+	SizeBytes   int64
+	FetchedAt   time.Time
+	CheckedAt   time.Time // Last time the source was revalidated, successfully or not
+}
+
+// repository handles database operations for favorite artwork.
+type repository struct {
+	reader *sql.DB
+	writer *sql.DB
+}
+
+func newRepository(dbPair DBPair) *repository {
+	return &repository{reader: dbPair.Reader(), writer: dbPair.Writer()}
+}
+
+const selectColumns = `
+	SELECT artwork_hash, favorite_id, source_url, content_type, size_bytes, fetched_at, checked_at
+	FROM favorite_artwork`
+
+// Get returns the artwork with hash, or nil if there is none.
+func (r *repository) Get(hash string) (*FavoriteArtwork, error) {
+	artwork, err := scanArtwork(r.reader.QueryRow(selectColumns+` WHERE artwork_hash = ?`, hash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return artwork, err
+}
+
+// Upsert records a fresh download.
+func (r *repository) Upsert(artwork FavoriteArtwork) error {
+	_, err := r.writer.Exec(`
+		INSERT INTO favorite_artwork (artwork_hash, favorite_id, source_url, content_type, size_bytes, fetched_at, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(artwork_hash) DO UPDATE SET
+			source_url = excluded.source_url,
+			content_type = excluded.content_type,
+			size_bytes = excluded.size_bytes,
+			fetched_at = excluded.fetched_at,
+			checked_at = excluded.checked_at
+	`, artwork.Hash, artwork.FavoriteID, artwork.SourceURL, artwork.ContentType, artwork.SizeBytes,
+		formatTime(artwork.FetchedAt), formatTime(artwork.CheckedAt))
+	return err
+}
+
+// MarkChecked records a revalidation that kept the existing copy.
+func (r *repository) MarkChecked(hash string, checkedAt time.Time) error {
+	_, err := r.writer.Exec(`UPDATE favorite_artwork SET checked_at = ? WHERE artwork_hash = ?`, formatTime(checkedAt), hash)
+	return err
+}
+
+// CheckedBefore returns artwork last checked before cutoff, least recently checked first.
+func (r *repository) CheckedBefore(cutoff time.Time) ([]FavoriteArtwork, error) {
+	rows, err := r.reader.Query(selectColumns+` WHERE checked_at < ? ORDER BY checked_at`, formatTime(cutoff))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stale []FavoriteArtwork
+	for rows.Next() {
+		artwork, err := scanArtwork(rows)
+		if err != nil {
+			return nil, err
+		}
+		stale = append(stale, *artwork)
+	}
+	return stale, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanArtwork(row rowScanner) (*FavoriteArtwork, error) {
+	var artwork FavoriteArtwork
+	var fetchedAt, checkedAt string
+	if err := row.Scan(&artwork.Hash, &artwork.FavoriteID, &artwork.SourceURL, &artwork.ContentType,
+		&artwork.SizeBytes, &fetchedAt, &checkedAt); err != nil {
+		return nil, err
+	}
+	artwork.FetchedAt, _ = time.Parse(time.RFC3339, fetchedAt)
+	artwork.CheckedAt, _ = time.Parse(time.RFC3339, checkedAt)
+	return &artwork, nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
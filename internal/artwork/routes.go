@@ -0,0 +1,46 @@
+package artwork
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+)
+
+// RegisterRoutes wires artwork routes to the router. Either of proxy and favorites may be
+// nil when it couldn't be set up; its routes are then left out.
+func RegisterRoutes(router chi.Router, proxy *Proxy, favorites *FavoriteCache) {
+	if proxy != nil {
+		router.Method(http.MethodGet, ProxyPath, api.Handler(getArtworkHandler(proxy)))
+	}
+	if favorites != nil {
+		router.Method(http.MethodGet, ProxyPath+"/{artwork_hash}", api.Handler(getFavoriteArtworkHandler(favorites)))
+	}
+}
+
+// getArtworkHandler handles GET /v1/assets/artwork?src=<encoded-uri>
+func getArtworkHandler(proxy *Proxy) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		src := r.URL.Query().Get("src")
+		if src == "" {
+			return apperrors.NewValidationError("src is required", nil)
+		}
+		parsed, err := url.Parse(src)
+		if err != nil || !parsed.IsAbs() {
+			return apperrors.NewValidationError("src must be an absolute URL", map[string]any{
+				"src": src,
+			})
+		}
+		return proxy.Serve(w, r, parsed)
+	}
+}
+
+// getFavoriteArtworkHandler handles GET /v1/assets/artwork/{artwork_hash}
+func getFavoriteArtworkHandler(favorites *FavoriteCache) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		return favorites.Serve(w, r, chi.URLParam(r, "artwork_hash"))
+	}
+}
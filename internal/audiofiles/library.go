@@ -0,0 +1,156 @@
+// Package audiofiles serves audio files dropped into the hub's audio directory, such as
+// custom alarm sounds, so speakers can play them from the hub.
+package audiofiles
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AudioPath is where the hub serves audio files; speakers fetch them from here.
+const AudioPath = "/v1/assets/audio"
+
+// maxFilenameLength is the longest file name most filesystems allow.
+const maxFilenameLength = 255
+
+// contentTypes are the file extensions speakers can play, with the type they're served as.
+var contentTypes = map[string]string{
+	".mp3":  "audio/mpeg",
+	".m4a":  "audio/mp4",
+	".aac":  "audio/aac",
+	".flac": "audio/flac",
+	".wav":  "audio/wav",
+	".ogg":  "audio/ogg",
+}
+
+var (
+	// ErrInvalidFilename is returned for names that aren't a plain audio file name.
+	ErrInvalidFilename = errors.New("invalid audio file name")
+	// ErrFileNotFound is returned when the audio directory has no such file.
+	ErrFileNotFound = errors.New("audio file not found")
+	// ErrNoPublicBaseURL is returned for file URLs when PUBLIC_BASE_URL isn't set.
+	ErrNoPublicBaseURL = errors.New("PUBLIC_BASE_URL must be set to play audio files from the hub")
+)
+
+// File describes an audio file in the library.
+type File struct {
+	Filename    string
+	ContentType string
+	SizeBytes   int64
+	ModifiedAt  time.Time
+}
+
+// Library is the hub's audio directory.
+type Library struct {
+	dir     string
+	baseURL string
+}
+
+// NewLibrary creates a library of the audio files in dir. baseURL is the hub's address as
+// speakers reach it (e.g. "http://192.168.1.5:9000"); without it, files can be listed and
+// served but not played.
+func NewLibrary(dir, baseURL string) *Library {
+	return &Library{dir: dir, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// ValidateFilename checks that name is a plain file name, with no directory parts, with an
+// extension speakers can play.
+func ValidateFilename(name string) error {
+	switch {
+	case name == "" || len(name) > maxFilenameLength:
+		return fmt.Errorf("%w: must be 1 to %d characters", ErrInvalidFilename, maxFilenameLength)
+	case strings.ContainsAny(name, "/\\\x00") || name != filepath.Base(name):
+		return fmt.Errorf("%w: must not contain a path", ErrInvalidFilename)
+	case strings.HasPrefix(name, "."):
+		return fmt.Errorf("%w: must not start with a dot", ErrInvalidFilename)
+	}
+	if _, ok := contentTypes[strings.ToLower(filepath.Ext(name))]; !ok {
+		return fmt.Errorf("%w: extension must be one of %s", ErrInvalidFilename, strings.Join(Extensions(), ", "))
+	}
+	return nil
+}
+
+// Extensions returns the playable file extensions, sorted.
+func Extensions() []string {
+	extensions := make([]string, 0, len(contentTypes))
+	for extension := range contentTypes {
+		extensions = append(extensions, extension)
+	}
+	sort.Strings(extensions)
+	return extensions
+}
+
+// List returns the playable files in the audio directory by name. A missing directory
+// has no files.
+func (l *Library) List() ([]File, error) {
+	entries, err := os.ReadDir(l.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []File{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read audio directory: %w", err)
+	}
+
+	files := make([]File, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || ValidateFilename(entry.Name()) != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue // Removed since the directory was read
+		}
+		files = append(files, fileFromInfo(info))
+	}
+	return files, nil
+}
+
+// Open opens a file for serving. The file is opened within the audio directory, so
+// neither the name nor a symlink can reach outside it.
+func (l *Library) Open(name string) (*os.File, File, error) {
+	if err := ValidateFilename(name); err != nil {
+		return nil, File{}, err
+	}
+	file, err := os.OpenInRoot(l.dir, name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, File{}, fmt.Errorf("%w: %s", ErrFileNotFound, name)
+		}
+		return nil, File{}, fmt.Errorf("open audio file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		file.Close()
+		return nil, File{}, fmt.Errorf("%w: %s", ErrFileNotFound, name)
+	}
+	return file, fileFromInfo(info), nil
+}
+
+// FileURL returns the URL speakers fetch the named file from, after checking it exists.
+func (l *Library) FileURL(name string) (string, error) {
+	if l.baseURL == "" {
+		return "", ErrNoPublicBaseURL
+	}
+	file, _, err := l.Open(name)
+	if err != nil {
+		return "", err
+	}
+	file.Close()
+	return l.baseURL + AudioPath + "/" + url.PathEscape(name), nil
+}
+
+func fileFromInfo(info fs.FileInfo) File {
+	return File{
+		Filename:    info.Name(),
+		ContentType: contentTypes[strings.ToLower(filepath.Ext(info.Name()))],
+		SizeBytes:   info.Size(),
+		ModifiedAt:  info.ModTime(),
+	}
+}
@@ -0,0 +1,154 @@
+package audiofiles
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestValidateFilename(t *testing.T) {
+	for _, name := range []string{"alarm.mp3", "Wake Up.M4A", "chime (2).wav", "bell.flac"} {
+		require.NoError(t, ValidateFilename(name), name)
+	}
+
+	for _, name := range []string{
+		"",
+		"../secret.mp3",
+		"..",
+		"sounds/alarm.mp3",
+		`sounds\alarm.mp3`,
+		"/etc/alarm.mp3",
+		".hidden.mp3",
+		"alarm.mp3\x00.txt",
+		"alarm.exe",
+		"alarm",
+		"playlist.m3u",
+	} {
+		err := ValidateFilename(name)
+		require.ErrorIs(t, err, ErrInvalidFilename, name)
+	}
+}
+
+func TestLibrary_List(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "b-chime.wav"), "RIFF")
+	writeFile(t, filepath.Join(dir, "a-alarm.mp3"), "ID3 alarm")
+	writeFile(t, filepath.Join(dir, "notes.txt"), "not audio")
+	writeFile(t, filepath.Join(dir, ".hidden.mp3"), "ID3")
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "folder.mp3"), 0o755))
+
+	files, err := NewLibrary(dir, "").List()
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Equal(t, "a-alarm.mp3", files[0].Filename)
+	require.Equal(t, "audio/mpeg", files[0].ContentType)
+	require.Equal(t, int64(9), files[0].SizeBytes)
+	require.Equal(t, "b-chime.wav", files[1].Filename)
+
+	files, err = NewLibrary(filepath.Join(dir, "missing"), "").List()
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
+
+func TestLibrary_OpenStaysInDirectory(t *testing.T) {
+	outside := t.TempDir()
+	writeFile(t, filepath.Join(outside, "secret.mp3"), "ID3")
+	dir := t.TempDir()
+	require.NoError(t, os.Symlink(filepath.Join(outside, "secret.mp3"), filepath.Join(dir, "link.mp3")))
+
+	_, _, err := NewLibrary(dir, "").Open("link.mp3")
+	require.Error(t, err)
+
+	_, _, err = NewLibrary(dir, "").Open("../secret.mp3")
+	require.ErrorIs(t, err, ErrInvalidFilename)
+}
+
+func TestLibrary_FileURL(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "wake up.mp3"), "ID3")
+
+	url, err := NewLibrary(dir, "http://192.168.1.5:9000/").FileURL("wake up.mp3")
+	require.NoError(t, err)
+	require.Equal(t, "http://192.168.1.5:9000/v1/assets/audio/wake%20up.mp3", url)
+
+	_, err = NewLibrary(dir, "http://192.168.1.5:9000").FileURL("missing.mp3")
+	require.ErrorIs(t, err, ErrFileNotFound)
+
+	_, err = NewLibrary(dir, "").FileURL("wake up.mp3")
+	require.ErrorIs(t, err, ErrNoPublicBaseURL)
+}
+
+func TestAudioRoutes(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "alarm.mp3"), "0123456789")
+	router := chi.NewRouter()
+	RegisterRoutes(router, NewLibrary(dir, "http://192.168.1.5:9000"))
+
+	get := func(path string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("whole file", func(t *testing.T) {
+		rec := get("/v1/assets/audio/alarm.mp3", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "audio/mpeg", rec.Header().Get("Content-Type"))
+		require.Equal(t, "bytes", rec.Header().Get("Accept-Ranges"))
+		require.Equal(t, "0123456789", rec.Body.String())
+	})
+
+	t.Run("range", func(t *testing.T) {
+		rec := get("/v1/assets/audio/alarm.mp3", http.Header{"Range": {"bytes=2-5"}})
+		require.Equal(t, http.StatusPartialContent, rec.Code)
+		require.Equal(t, "bytes 2-5/10", rec.Header().Get("Content-Range"))
+		require.Equal(t, "2345", rec.Body.String())
+	})
+
+	t.Run("rejected names", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, get("/v1/assets/audio/..%2F..%2Fetc%2Fpasswd.mp3", nil).Code)
+		require.Equal(t, http.StatusBadRequest, get("/v1/assets/audio/notes.txt", nil).Code)
+		require.Equal(t, http.StatusNotFound, get("/v1/assets/audio/missing.mp3", nil).Code)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		rec := get("/v1/audio-files", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var body struct {
+			Data []map[string]any `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		require.Len(t, body.Data, 1)
+		require.Equal(t, "alarm.mp3", body.Data[0]["filename"])
+		require.Equal(t, "/v1/assets/audio/alarm.mp3", body.Data[0]["path"])
+		require.Equal(t, float64(10), body.Data[0]["size_bytes"])
+	})
+}
+
+func TestAudioRoutes_HideLibraryErrors(t *testing.T) {
+	// A file where the audio directory should be fails every read
+	notADir := filepath.Join(t.TempDir(), "audio")
+	writeFile(t, notADir, "")
+	router := chi.NewRouter()
+	RegisterRoutes(router, NewLibrary(notADir, "http://192.168.1.5:9000"))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/audio-files", nil))
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.NotContains(t, rec.Body.String(), notADir)
+}
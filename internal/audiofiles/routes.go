@@ -0,0 +1,80 @@
+package audiofiles
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+)
+
+// RegisterRoutes wires the audio file routes to the router.
+func RegisterRoutes(router chi.Router, library *Library) {
+	router.Method(http.MethodGet, AudioPath+"/{filename}", api.Handler(serveAudioFile(library)))
+	router.Method(http.MethodGet, "/v1/audio-files", api.Handler(listAudioFiles(library)))
+}
+
+// serveAudioFile handles GET /v1/assets/audio/{filename}. It's unauthenticated, like the
+// other assets, since speakers fetch it; range requests are supported for seeking.
+func serveAudioFile(library *Library) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		name, err := url.PathUnescape(chi.URLParam(r, "filename"))
+		if err != nil {
+			return apperrors.NewValidationError("filename is not a valid path segment", nil)
+		}
+		file, info, err := library.Open(name)
+		if err != nil {
+			return libraryError(r, err, name)
+		}
+		defer file.Close()
+
+		w.Header().Set("Content-Type", info.ContentType)
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		http.ServeContent(w, r, info.Filename, info.ModifiedAt, file)
+		return nil
+	}
+}
+
+// listAudioFiles handles GET /v1/audio-files
+func listAudioFiles(library *Library) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		files, err := library.List()
+		if err != nil {
+			// The error names the audio directory, which clients have no use for
+			logging.From(r.Context(), nil).Error("Failed to list audio files", "error", err)
+			return apperrors.NewInternalError("Failed to list audio files")
+		}
+
+		formatted := make([]map[string]any, 0, len(files))
+		for _, file := range files {
+			formatted = append(formatted, map[string]any{
+				"object":       "audio_file",
+				"filename":     file.Filename,
+				"content_type": file.ContentType,
+				"size_bytes":   file.SizeBytes,
+				"modified_at":  api.RFC3339Millis(file.ModifiedAt),
+				"path":         AudioPath + "/" + url.PathEscape(file.Filename),
+			})
+		}
+		return api.WriteList(w, "/v1/audio-files", formatted, false)
+	}
+}
+
+// libraryError maps library errors to API errors. Unexpected errors are logged and
+// reported without their cause, which can include filesystem paths.
+func libraryError(r *http.Request, err error, name string) error {
+	switch {
+	case errors.Is(err, ErrInvalidFilename):
+		return apperrors.NewValidationError(err.Error(), map[string]any{"filename": name})
+	case errors.Is(err, ErrFileNotFound):
+		return apperrors.NewNotFoundError("Audio file not found", map[string]any{"filename": name})
+	default:
+		logging.From(r.Context(), nil).Error("Failed to open audio file", "filename", name, "error", err)
+		return apperrors.NewInternalError("Failed to open audio file")
+	}
+}
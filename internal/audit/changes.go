@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/auth"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+)
+
+// ActorSystem is the actor recorded for changes made without an authenticated device.
+const ActorSystem = "system"
+
+// Change describes a successful mutation of a routine, scene or music set.
+type Change struct {
+	Type         EventType
+	Action       string // What was done, e.g. "create", "snooze", "add_item"
+	ResourceType string // One of the Resource* constants
+	ResourceID   string
+	Before       any // The resource before the change; nil when it was created
+	After        any // The resource after the change; nil when it was deleted
+}
+
+// Recorder records changes in the audit log. It is implemented by Service, and a nil
+// Recorder turns auditing off.
+type Recorder interface {
+	RecordChange(ctx context.Context, change Change)
+}
+
+// Record records change with recorder, when there is one.
+func Record(ctx context.Context, recorder Recorder, change Change) {
+	if recorder == nil {
+		return
+	}
+	recorder.RecordChange(ctx, change)
+}
+
+// Before reads a resource for the diff of a change about to be made, when there is a
+// recorder. A failed read leaves the before side empty; the change reports its own errors.
+func Before[T any](recorder Recorder, read func() (*T, error)) *T {
+	if recorder == nil {
+		return nil
+	}
+	value, _ := read()
+	return value
+}
+
+// RecordChange writes an audit event for change. The actor and request ID come from ctx,
+// and the payload's "diff" holds each top-level field whose JSON differs between Before
+// and After. Recording is best effort: a failure is logged rather than returned, so it
+// never fails the change itself.
+func (s *Service) RecordChange(ctx context.Context, change Change) {
+	logger := logging.From(ctx, s.logger)
+
+	diff, err := Diff(change.Before, change.After)
+	if err != nil {
+		logger.Warn("Failed to diff audited change", "resource_type", change.ResourceType, "resource_id", change.ResourceID, "error", err)
+		diff = map[string]any{}
+	}
+
+	actor := ActorSystem
+	payload := map[string]any{"diff": diff}
+	if user, ok := auth.UserFromContext(ctx); ok && user.Sub != "" {
+		actor = user.Sub
+		if user.DeviceName != "" {
+			payload["device_name"] = user.DeviceName
+		}
+	}
+
+	input := WriteEventInput{
+		Type:         string(change.Type),
+		Actor:        &actor,
+		Action:       &change.Action,
+		ResourceType: &change.ResourceType,
+		Message:      fmt.Sprintf("%s %s: %s", change.ResourceType, change.ResourceID, change.Action),
+		Payload:      payload,
+	}
+	if change.ResourceID != "" {
+		input.ResourceID = &change.ResourceID
+		if change.ResourceType == ResourceRoutine {
+			input.RoutineID = &change.ResourceID
+		}
+	}
+	if requestID := api.RequestIDFromContext(ctx); requestID != "" {
+		input.RequestID = &requestID
+	}
+
+	if _, err := s.RecordEvent(input); err != nil {
+		logger.Warn("Failed to record audit event", "type", change.Type, "resource_type", change.ResourceType, "resource_id", change.ResourceID, "error", err)
+	}
+}
+
+// Diff compares the JSON encodings of before and after field by field and returns
+// {"field": {"before": ..., "after": ...}} for each top-level field that differs. A nil
+// side counts as an object with no fields.
+func Diff(before, after any) (map[string]any, error) {
+	beforeFields, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := map[string]any{}
+	for key, value := range beforeFields {
+		if other, ok := afterFields[key]; !ok || !reflect.DeepEqual(value, other) {
+			diff[key] = map[string]any{"before": value, "after": afterFields[key]}
+		}
+	}
+	for key, value := range afterFields {
+		if _, ok := beforeFields[key]; !ok {
+			diff[key] = map[string]any{"before": nil, "after": value}
+		}
+	}
+	return diff, nil
+}
+
+// jsonFields returns value's JSON object fields. value must encode as an object or null.
+func jsonFields(value any) (map[string]any, error) {
+	if value == nil {
+		return map[string]any{}, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("audited value must be a JSON object: %w", err)
+	}
+	if fields == nil {
+		fields = map[string]any{}
+	}
+	return fields, nil
+}
@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/auth"
+	"github.com/strefethen/sonos-hub-go/internal/config"
+	"github.com/strefethen/sonos-hub-go/internal/db"
+)
+
+type testRoutine struct {
+	RoutineID string `json:"routine_id"`
+	Name      string `json:"name"`
+	Enabled   bool   `json:"enabled"`
+	Weekdays  []int  `json:"weekdays,omitempty"`
+}
+
+func TestDiff(t *testing.T) {
+	before := &testRoutine{RoutineID: "r-1", Name: "Morning", Enabled: true, Weekdays: []int{1, 2}}
+	after := &testRoutine{RoutineID: "r-1", Name: "Wake up", Enabled: true}
+
+	diff, err := Diff(before, after)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		"name":     map[string]any{"before": "Morning", "after": "Wake up"},
+		"weekdays": map[string]any{"before": []any{float64(1), float64(2)}, "after": nil},
+	}, diff)
+
+	// A create shows every field as new, a typed nil pointer included
+	diff, err = Diff((*testRoutine)(nil), after)
+	require.NoError(t, err)
+	require.Len(t, diff, 3)
+	require.Equal(t, map[string]any{"before": nil, "after": "r-1"}, diff["routine_id"])
+
+	_, err = Diff("not an object", after)
+	require.Error(t, err)
+}
+
+func TestService_RecordChange(t *testing.T) {
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+
+	var logs bytes.Buffer
+	service := NewService(config.Config{}, dbPair, slog.New(slog.NewJSONHandler(&logs, nil)))
+
+	ctx := auth.WithUser(context.Background(), auth.User{Sub: "device-1", DeviceName: "Kitchen iPad"})
+	service.RecordChange(ctx, Change{
+		Type:         EventRoutineUpdated,
+		Action:       "disable",
+		ResourceType: ResourceRoutine,
+		ResourceID:   "r-1",
+		Before:       &testRoutine{RoutineID: "r-1", Name: "Morning", Enabled: true},
+		After:        &testRoutine{RoutineID: "r-1", Name: "Morning", Enabled: false},
+	})
+	service.RecordChange(context.Background(), Change{
+		Type:         EventSceneCreated,
+		Action:       "create",
+		ResourceType: ResourceScene,
+		ResourceID:   "s-1",
+		After:        map[string]any{"scene_id": "s-1"},
+	})
+
+	resourceType, resourceID := ResourceRoutine, "r-1"
+	events, _, _, err := service.QueryEvents(EventQueryFilters{ResourceType: &resourceType, ResourceID: &resourceID})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	event := events[0]
+	require.Equal(t, string(EventRoutineUpdated), event.Type)
+	require.Equal(t, "device-1", *event.Actor)
+	require.Equal(t, "disable", *event.Action)
+	require.Equal(t, "r-1", *event.RoutineID)
+	require.Equal(t, "Kitchen iPad", event.Payload["device_name"])
+	require.Equal(t, map[string]any{
+		"enabled": map[string]any{"before": true, "after": false},
+	}, event.Payload["diff"])
+
+	resourceType, resourceID = ResourceScene, "s-1"
+	events, _, _, err = service.QueryEvents(EventQueryFilters{ResourceType: &resourceType, ResourceID: &resourceID})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, ActorSystem, *events[0].Actor)
+	require.Nil(t, events[0].RoutineID)
+
+	// A failed insert is logged, not returned or panicked on
+	require.NoError(t, dbPair.Close())
+	service.RecordChange(ctx, Change{Type: EventRoutineDeleted, Action: "delete", ResourceType: ResourceRoutine, ResourceID: "r-1"})
+	require.Contains(t, logs.String(), "Failed to record audit event")
+	require.Contains(t, logs.String(), `"resource_id":"r-1"`)
+}
+
+func TestRecordAndBefore_WithoutRecorder(t *testing.T) {
+	reads := 0
+	read := func() (*testRoutine, error) {
+		reads++
+		return &testRoutine{RoutineID: "routine-1"}, nil
+	}
+
+	// Without a recorder nothing is read or recorded
+	require.Nil(t, Before(nil, read))
+	require.Zero(t, reads)
+	Record(context.Background(), nil, Change{Type: EventRoutineUpdated})
+
+	recorder := &Service{}
+	require.Equal(t, "routine-1", Before(recorder, read).RoutineID)
+	require.Equal(t, 1, reads)
+}
@@ -31,6 +31,10 @@ type AuditEvent struct {
 	JobID            *string        `json:"job_id,omitempty"`
 	SceneExecutionID *string        `json:"scene_execution_id,omitempty"`
 	UDN              *string        `json:"udn,omitempty"`
+	Actor            *string        `json:"actor,omitempty"`
+	Action           *string        `json:"action,omitempty"`
+	ResourceType     *string        `json:"resource_type,omitempty"`
+	ResourceID       *string        `json:"resource_id,omitempty"`
 	Message          string         `json:"message"`
 	Payload          map[string]any `json:"payload"`
 }
@@ -44,6 +48,10 @@ type WriteEventInput struct {
 	JobID            *string        `json:"job_id,omitempty"`
 	SceneExecutionID *string        `json:"scene_execution_id,omitempty"`
 	UDN              *string        `json:"udn,omitempty"`
+	Actor            *string        `json:"actor,omitempty"`
+	Action           *string        `json:"action,omitempty"`
+	ResourceType     *string        `json:"resource_type,omitempty"`
+	ResourceID       *string        `json:"resource_id,omitempty"`
 	Message          string         `json:"message"`
 	Payload          map[string]any `json:"payload,omitempty"`
 }
@@ -58,6 +66,8 @@ type EventQueryFilters struct {
 	RoutineID        *string     `json:"routine_id,omitempty"`
 	SceneExecutionID *string     `json:"scene_execution_id,omitempty"`
 	UDN              *string     `json:"udn,omitempty"`
+	ResourceType     *string     `json:"resource_type,omitempty"`
+	ResourceID       *string     `json:"resource_id,omitempty"`
 	Limit            int         `json:"limit,omitempty"`
 	Offset           int         `json:"offset,omitempty"`
 }
@@ -102,9 +112,10 @@ func (r *Repository) InsertEvent(input WriteEventInput) (*AuditEvent, error) {
 	}
 
 	_, err = r.writer.Exec(`
-		INSERT INTO audit_events (event_id, timestamp, type, level, request_id, routine_id, job_id, scene_execution_id, udn, message, payload)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, eventID, timestamp, input.Type, string(level), input.RequestID, input.RoutineID, input.JobID, input.SceneExecutionID, input.UDN, input.Message, string(payloadJSON))
+		INSERT INTO audit_events (event_id, timestamp, type, level, request_id, routine_id, job_id, scene_execution_id, udn, actor, action, resource_type, resource_id, message, payload)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, eventID, timestamp, input.Type, string(level), input.RequestID, input.RoutineID, input.JobID, input.SceneExecutionID, input.UDN,
+		input.Actor, input.Action, input.ResourceType, input.ResourceID, input.Message, string(payloadJSON))
 	if err != nil {
 		return nil, err
 	}
@@ -121,12 +132,16 @@ func (r *Repository) WriteEvent(input WriteEventInput) (*AuditEvent, error) {
 // Returns nil, nil if not found.
 func (r *Repository) GetEvent(eventID string) (*AuditEvent, error) {
 	row := r.reader.QueryRow(`
-		SELECT event_id, timestamp, type, level, request_id, routine_id, job_id, scene_execution_id, udn, message, payload
+		SELECT `+eventColumns+`
 		FROM audit_events
 		WHERE event_id = ?
 	`, eventID)
 
-	return r.scanEvent(row)
+	event, err := r.scanEvent(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return event, err
 }
 
 // GetByID is an alias for GetEvent to match the service interface.
@@ -155,7 +170,7 @@ func (r *Repository) QueryEvents(filters EventQueryFilters) ([]AuditEvent, int,
 	}
 
 	query := `
-		SELECT event_id, timestamp, type, level, request_id, routine_id, job_id, scene_execution_id, udn, message, payload
+		SELECT ` + eventColumns + `
 		FROM audit_events
 		` + whereClause + `
 		ORDER BY timestamp DESC
@@ -171,7 +186,7 @@ func (r *Repository) QueryEvents(filters EventQueryFilters) ([]AuditEvent, int,
 
 	var events []AuditEvent
 	for rows.Next() {
-		event, err := r.scanEventRows(rows)
+		event, err := r.scanEvent(rows)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -263,6 +278,14 @@ func (r *Repository) buildWhereClause(filters EventQueryFilters) (string, []any)
 		conditions = append(conditions, "udn = ?")
 		args = append(args, *filters.UDN)
 	}
+	if filters.ResourceType != nil {
+		conditions = append(conditions, "resource_type = ?")
+		args = append(args, *filters.ResourceType)
+	}
+	if filters.ResourceID != nil {
+		conditions = append(conditions, "resource_id = ?")
+		args = append(args, *filters.ResourceID)
+	}
 	if filters.StartDate != nil {
 		conditions = append(conditions, "timestamp >= ?")
 		args = append(args, *filters.StartDate)
@@ -280,72 +303,43 @@ func (r *Repository) buildWhereClause(filters EventQueryFilters) (string, []any)
 	return whereClause, args
 }
 
-func (r *Repository) scanEvent(row *sql.Row) (*AuditEvent, error) {
-	var event AuditEvent
-	var timestamp string
-	var level string
-	var requestID sql.NullString
-	var routineID sql.NullString
-	var jobID sql.NullString
-	var sceneExecutionID sql.NullString
-	var deviceID sql.NullString
-	var payloadJSON string
-
-	err := row.Scan(
-		&event.EventID,
-		&timestamp,
-		&event.Type,
-		&level,
-		&requestID,
-		&routineID,
-		&jobID,
-		&sceneExecutionID,
-		&deviceID,
-		&event.Message,
-		&payloadJSON,
-	)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
-		}
-		return nil, err
-	}
+// eventColumns is the column list scanEvent reads, in order.
+const eventColumns = "event_id, timestamp, type, level, request_id, routine_id, job_id, scene_execution_id, udn, actor, action, resource_type, resource_id, message, payload"
 
-	return r.parseEvent(&event, timestamp, level, requestID, routineID, jobID, sceneExecutionID, deviceID, payloadJSON)
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
 }
 
-func (r *Repository) scanEventRows(rows *sql.Rows) (*AuditEvent, error) {
+func (r *Repository) scanEvent(row rowScanner) (*AuditEvent, error) {
 	var event AuditEvent
 	var timestamp string
 	var level string
-	var requestID sql.NullString
-	var routineID sql.NullString
-	var jobID sql.NullString
-	var sceneExecutionID sql.NullString
-	var deviceID sql.NullString
 	var payloadJSON string
+	optional := []struct {
+		value sql.NullString
+		dest  **string
+	}{
+		{dest: &event.RequestID},
+		{dest: &event.RoutineID},
+		{dest: &event.JobID},
+		{dest: &event.SceneExecutionID},
+		{dest: &event.UDN},
+		{dest: &event.Actor},
+		{dest: &event.Action},
+		{dest: &event.ResourceType},
+		{dest: &event.ResourceID},
+	}
 
-	err := rows.Scan(
-		&event.EventID,
-		&timestamp,
-		&event.Type,
-		&level,
-		&requestID,
-		&routineID,
-		&jobID,
-		&sceneExecutionID,
-		&deviceID,
-		&event.Message,
-		&payloadJSON,
-	)
-	if err != nil {
+	dests := []any{&event.EventID, &timestamp, &event.Type, &level}
+	for i := range optional {
+		dests = append(dests, &optional[i].value)
+	}
+	dests = append(dests, &event.Message, &payloadJSON)
+	if err := row.Scan(dests...); err != nil {
 		return nil, err
 	}
 
-	return r.parseEvent(&event, timestamp, level, requestID, routineID, jobID, sceneExecutionID, deviceID, payloadJSON)
-}
-
-func (r *Repository) parseEvent(event *AuditEvent, timestamp, level string, requestID, routineID, jobID, sceneExecutionID, deviceID sql.NullString, payloadJSON string) (*AuditEvent, error) {
 	var err error
 	event.Timestamp, err = time.Parse(time.RFC3339, timestamp)
 	if err != nil {
@@ -354,27 +348,18 @@ func (r *Repository) parseEvent(event *AuditEvent, timestamp, level string, requ
 
 	event.Level = EventLevel(level)
 
-	if requestID.Valid {
-		event.RequestID = &requestID.String
-	}
-	if routineID.Valid {
-		event.RoutineID = &routineID.String
-	}
-	if jobID.Valid {
-		event.JobID = &jobID.String
-	}
-	if sceneExecutionID.Valid {
-		event.SceneExecutionID = &sceneExecutionID.String
-	}
-	if deviceID.Valid {
-		event.UDN = &deviceID.String
+	for _, column := range optional {
+		if column.value.Valid {
+			value := column.value.String
+			*column.dest = &value
+		}
 	}
 
 	if err := json.Unmarshal([]byte(payloadJSON), &event.Payload); err != nil {
 		return nil, err
 	}
 
-	return event, nil
+	return &event, nil
 }
 
 func nowISO() string {
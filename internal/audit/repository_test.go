@@ -497,3 +497,34 @@ func TestRepository_GetByID_Alias(t *testing.T) {
 	require.NotNil(t, fetched)
 	require.Equal(t, created.EventID, fetched.EventID)
 }
+
+func TestRepository_QueryEvents_WithResourceFilters(t *testing.T) {
+	repo := setupTestDB(t)
+
+	routine := ResourceRoutine
+	scene := ResourceScene
+	routineID := "routine-1"
+	otherRoutineID := "routine-2"
+	actor := "device-1"
+	action := "update"
+
+	_, err := repo.InsertEvent(WriteEventInput{Type: string(EventRoutineUpdated), Actor: &actor, Action: &action, ResourceType: &routine, ResourceID: &routineID, Message: "M1"})
+	require.NoError(t, err)
+	_, err = repo.InsertEvent(WriteEventInput{Type: string(EventRoutineUpdated), ResourceType: &routine, ResourceID: &otherRoutineID, Message: "M2"})
+	require.NoError(t, err)
+	_, err = repo.InsertEvent(WriteEventInput{Type: string(EventSceneUpdated), ResourceType: &scene, ResourceID: &routineID, Message: "M3"})
+	require.NoError(t, err)
+
+	events, total, err := repo.QueryEvents(EventQueryFilters{ResourceType: &routine, ResourceID: &routineID})
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	require.Equal(t, "M1", events[0].Message)
+	require.Equal(t, "device-1", *events[0].Actor)
+	require.Equal(t, "update", *events[0].Action)
+	require.Equal(t, ResourceRoutine, *events[0].ResourceType)
+	require.Equal(t, "routine-1", *events[0].ResourceID)
+
+	_, total, err = repo.QueryEvents(EventQueryFilters{ResourceType: &routine})
+	require.NoError(t, err)
+	require.Equal(t, 2, total)
+}
@@ -28,6 +28,10 @@ var validEventTypes = map[string]bool{
 	string(EventJobSkipped):              true,
 	string(EventSceneCreated):            true,
 	string(EventSceneUpdated):            true,
+	string(EventSceneDeleted):            true,
+	string(EventMusicSetCreated):         true,
+	string(EventMusicSetUpdated):         true,
+	string(EventMusicSetDeleted):         true,
 	string(EventSceneExecutionStarted):   true,
 	string(EventSceneExecutionStep):      true,
 	string(EventSceneExecutionCompleted): true,
@@ -257,6 +261,14 @@ func parseQueryFilters(r *http.Request) (EventQueryFilters, error) {
 		filters.UDN = &udn
 	}
 
+	// Parse resource filters (the change history of one routine, scene or set)
+	if resourceType := query.Get("resource_type"); resourceType != "" {
+		filters.ResourceType = &resourceType
+	}
+	if resourceID := query.Get("resource_id"); resourceID != "" {
+		filters.ResourceID = &resourceID
+	}
+
 	// Parse 'limit' (1-1000, default 100)
 	if limitStr := query.Get("limit"); limitStr != "" {
 		limit, err := strconv.Atoi(limitStr)
@@ -314,6 +326,20 @@ func formatEvent(event *AuditEvent) map[string]any {
 		result["correlation"] = correlation
 	}
 
+	// Add who changed which resource, for events recorded by RecordChange
+	if event.Actor != nil {
+		result["actor"] = *event.Actor
+	}
+	if event.Action != nil {
+		result["action"] = *event.Action
+	}
+	if event.ResourceType != nil {
+		result["resource_type"] = *event.ResourceType
+	}
+	if event.ResourceID != nil {
+		result["resource_id"] = *event.ResourceID
+	}
+
 	// Add payload if present and non-empty
 	if event.Payload != nil && len(event.Payload) > 0 {
 		result["payload"] = event.Payload
@@ -2,7 +2,7 @@ package audit
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
 
@@ -18,12 +18,19 @@ const (
 	MaxConsecutiveFailures   = 3
 )
 
+// RetentionProvider supplies the audit retention window at prune time.
+// This is implemented by settings.Service so retention edits apply without a restart.
+type RetentionProvider interface {
+	AuditRetentionDays() int
+}
+
 // Service provides audit log management functionality.
 type Service struct {
 	cfg                 config.Config
-	logger              *log.Logger
+	logger              *slog.Logger
 	repo                *Repository
 	retentionDays       int
+	retentionProvider   RetentionProvider
 	pruneInterval       time.Duration
 	defaultQueryLimit   int
 	maxQueryLimit       int
@@ -36,9 +43,9 @@ type Service struct {
 
 // NewService creates a new audit service.
 // Accepts a DBPair for optimal SQLite concurrency with separate reader/writer pools.
-func NewService(cfg config.Config, dbPair DBPair, logger *log.Logger) *Service {
+func NewService(cfg config.Config, dbPair DBPair, logger *slog.Logger) *Service {
 	if logger == nil {
-		logger = log.Default()
+		logger = slog.Default()
 	}
 
 	repo := NewRepository(dbPair)
@@ -65,8 +72,8 @@ func (s *Service) RecordEvent(input WriteEventInput) (*AuditEvent, error) {
 		input.Level = &level
 	}
 
-	s.logger.Printf("[DEBUG] Recording audit event: type=%s level=%s message=%s",
-		input.Type, *input.Level, input.Message)
+	s.logger.Debug("Recording audit event",
+		"type", input.Type, "level", *input.Level, "message", input.Message)
 
 	event, err := s.repo.InsertEvent(input)
 	if err != nil {
@@ -125,8 +132,8 @@ func (s *Service) GetEvent(eventID string) (*AuditEvent, error) {
 // StartPruneJob starts the background prune job.
 // Runs immediately on start, then at pruneInterval.
 func (s *Service) StartPruneJob() {
-	s.logger.Printf("Starting audit prune job (interval: %v, retention: %d days)",
-		s.pruneInterval, s.retentionDays)
+	s.logger.Info("Starting audit prune job",
+		"interval", s.pruneInterval, "retention_days", s.currentRetentionDays())
 
 	s.wg.Add(1)
 	go s.runPruneLoop()
@@ -134,10 +141,10 @@ func (s *Service) StartPruneJob() {
 
 // StopPruneJob stops the background prune job.
 func (s *Service) StopPruneJob() {
-	s.logger.Printf("Stopping audit prune job")
+	s.logger.Info("Stopping audit prune job")
 	close(s.stopCh)
 	s.wg.Wait()
-	s.logger.Printf("Audit prune job stopped")
+	s.logger.Info("Audit prune job stopped")
 }
 
 // runPruneLoop is the background goroutine that periodically prunes old events.
@@ -146,9 +153,9 @@ func (s *Service) runPruneLoop() {
 
 	// Run immediately on start
 	if count, err := s.Prune(); err != nil {
-		s.logger.Printf("Error pruning audit events on start: %v", err)
+		s.logger.Error("Error pruning audit events on start", "error", err)
 	} else if count > 0 {
-		s.logger.Printf("Pruned %d audit events on startup", count)
+		s.logger.Info("Pruned audit events on startup", "count", count)
 	}
 
 	ticker := time.NewTicker(s.pruneInterval)
@@ -160,17 +167,33 @@ func (s *Service) runPruneLoop() {
 			return
 		case <-ticker.C:
 			if count, err := s.Prune(); err != nil {
-				s.logger.Printf("Error pruning audit events: %v", err)
+				s.logger.Error("Error pruning audit events", "error", err)
 			} else if count > 0 {
-				s.logger.Printf("Pruned %d audit events", count)
+				s.logger.Info("Pruned audit events", "count", count)
 			}
 		}
 	}
 }
 
+// SetRetentionProvider sets a provider consulted on each prune for the retention window.
+// Must be called before StartPruneJob.
+func (s *Service) SetRetentionProvider(provider RetentionProvider) {
+	s.retentionProvider = provider
+}
+
+// currentRetentionDays returns the provider's retention window, or the default if unset.
+func (s *Service) currentRetentionDays() int {
+	if s.retentionProvider != nil {
+		if days := s.retentionProvider.AuditRetentionDays(); days > 0 {
+			return days
+		}
+	}
+	return s.retentionDays
+}
+
 // Prune manually triggers pruning, returns count deleted.
 func (s *Service) Prune() (int64, error) {
-	count, err := s.repo.PruneOldEvents(s.retentionDays)
+	count, err := s.repo.PruneOldEvents(s.currentRetentionDays())
 	if err != nil {
 		s.recordFailure()
 		return 0, fmt.Errorf("failed to prune audit events: %w", err)
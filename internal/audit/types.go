@@ -14,6 +14,10 @@ const (
 	EventJobSkipped              EventType = "JOB_SKIPPED"
 	EventSceneCreated            EventType = "SCENE_CREATED"
 	EventSceneUpdated            EventType = "SCENE_UPDATED"
+	EventSceneDeleted            EventType = "SCENE_DELETED"
+	EventMusicSetCreated         EventType = "MUSIC_SET_CREATED"
+	EventMusicSetUpdated         EventType = "MUSIC_SET_UPDATED"
+	EventMusicSetDeleted         EventType = "MUSIC_SET_DELETED"
 	EventSceneExecutionStarted   EventType = "SCENE_EXECUTION_STARTED"
 	EventSceneExecutionStep      EventType = "SCENE_EXECUTION_STEP"
 	EventSceneExecutionCompleted EventType = "SCENE_EXECUTION_COMPLETED"
@@ -24,6 +28,21 @@ const (
 	EventPlaybackFailed          EventType = "PLAYBACK_FAILED"
 	EventSystemStartup           EventType = "SYSTEM_STARTUP"
 	EventSystemError             EventType = "SYSTEM_ERROR"
+	EventMaintenanceStarted      EventType = "MAINTENANCE_STARTED"
+	EventMaintenanceCompleted    EventType = "MAINTENANCE_COMPLETED"
+	EventMaintenanceFailed       EventType = "MAINTENANCE_FAILED"
+	EventDatabaseBackup          EventType = "DATABASE_BACKUP"
+	EventDatabaseRestored        EventType = "DATABASE_RESTORED"
+	EventDatabaseRestoreFailed   EventType = "DATABASE_RESTORE_FAILED"
+	EventSpotifyConnected        EventType = "SPOTIFY_SEARCH_CONNECTED"
+	EventSpotifyDisconnected     EventType = "SPOTIFY_SEARCH_DISCONNECTED"
+)
+
+// Resource types of events recorded with RecordChange.
+const (
+	ResourceRoutine  = "routine"
+	ResourceScene    = "scene"
+	ResourceMusicSet = "music_set"
 )
 
 // EventCorrelation contains IDs that link related events together.
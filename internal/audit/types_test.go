@@ -20,6 +20,10 @@ func TestEventTypeConstants(t *testing.T) {
 	require.Equal(t, EventType("JOB_SKIPPED"), EventJobSkipped)
 	require.Equal(t, EventType("SCENE_CREATED"), EventSceneCreated)
 	require.Equal(t, EventType("SCENE_UPDATED"), EventSceneUpdated)
+	require.Equal(t, EventType("SCENE_DELETED"), EventSceneDeleted)
+	require.Equal(t, EventType("MUSIC_SET_CREATED"), EventMusicSetCreated)
+	require.Equal(t, EventType("MUSIC_SET_UPDATED"), EventMusicSetUpdated)
+	require.Equal(t, EventType("MUSIC_SET_DELETED"), EventMusicSetDeleted)
 	require.Equal(t, EventType("SCENE_EXECUTION_STARTED"), EventSceneExecutionStarted)
 	require.Equal(t, EventType("SCENE_EXECUTION_STEP"), EventSceneExecutionStep)
 	require.Equal(t, EventType("SCENE_EXECUTION_COMPLETED"), EventSceneExecutionCompleted)
@@ -30,6 +34,14 @@ func TestEventTypeConstants(t *testing.T) {
 	require.Equal(t, EventType("PLAYBACK_FAILED"), EventPlaybackFailed)
 	require.Equal(t, EventType("SYSTEM_STARTUP"), EventSystemStartup)
 	require.Equal(t, EventType("SYSTEM_ERROR"), EventSystemError)
+	require.Equal(t, EventType("MAINTENANCE_STARTED"), EventMaintenanceStarted)
+	require.Equal(t, EventType("MAINTENANCE_COMPLETED"), EventMaintenanceCompleted)
+	require.Equal(t, EventType("MAINTENANCE_FAILED"), EventMaintenanceFailed)
+	require.Equal(t, EventType("DATABASE_BACKUP"), EventDatabaseBackup)
+	require.Equal(t, EventType("DATABASE_RESTORED"), EventDatabaseRestored)
+	require.Equal(t, EventType("DATABASE_RESTORE_FAILED"), EventDatabaseRestoreFailed)
+	require.Equal(t, EventType("SPOTIFY_SEARCH_CONNECTED"), EventSpotifyConnected)
+	require.Equal(t, EventType("SPOTIFY_SEARCH_DISCONNECTED"), EventSpotifyDisconnected)
 }
 
 func TestEventLevelConstants(t *testing.T) {
@@ -6,11 +6,25 @@ type contextKey string
 
 const userKey contextKey = "authUser"
 
-// User represents an authenticated device.
+// User represents an authenticated device or API key.
 type User struct {
 	Sub        string
-	DeviceName string
+	DeviceName string // The key's name for API keys
 	Type       TokenType
+	Scopes     []Scope // Set for API keys; paired devices have full access
+}
+
+// HasScope reports whether the user may make requests needing scope.
+func (u User) HasScope(scope Scope) bool {
+	if u.Type != TokenTypeAPIKey {
+		return true
+	}
+	for _, granted := range u.Scopes {
+		if granted.rank() >= scope.rank() {
+			return true
+		}
+	}
+	return false
 }
 
 // WithUser stores an authenticated user in the context.
@@ -9,12 +9,13 @@ import (
 	"github.com/strefethen/sonos-hub-go/internal/config"
 )
 
-// TokenType describes access vs refresh tokens.
+// TokenType describes access vs refresh tokens, and API keys.
 type TokenType string
 
 const (
 	TokenTypeAccess  TokenType = "access"
 	TokenTypeRefresh TokenType = "refresh"
+	TokenTypeAPIKey  TokenType = "api_key"
 )
 
 // TokenPayload represents the validated payload data.
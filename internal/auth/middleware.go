@@ -17,6 +17,7 @@ var publicRoutes = map[string]struct{}{
 	"/v1/health/live":                  {},
 	"/v1/health/ready":                 {},
 	"/metrics":                         {},
+	"/v1/system/identity":              {}, // Lets clients confirm a hub they found before they have a key
 	"/ws/spotify-search":               {},
 	"/v1/sonos-cloud/webhook":          {}, // Sonos Cloud webhooks from Sonos servers
 	"/v1/sonos-cloud/auth/callback":    {}, // OAuth callback from Sonos
@@ -29,8 +30,10 @@ var publicPrefixes = []string{
 	"/upnp", // UPnP NOTIFY callbacks from Sonos devices
 }
 
-// Middleware validates JWT tokens for protected routes.
-func Middleware(cfg config.Config) func(http.Handler) http.Handler {
+// Middleware validates JWT tokens and API keys for protected routes. API keys must also
+// hold the scope the route needs (see RequiredScope). keys may be nil, in which case API
+// keys are rejected.
+func Middleware(cfg config.Config, keys APIKeyVerifier) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if isPublicRoute(r.URL.Path) {
@@ -38,7 +41,7 @@ func Middleware(cfg config.Config) func(http.Handler) http.Handler {
 				return
 			}
 
-			if isTestModeRequest(r, cfg) {
+			if IsTestModeRequest(r, cfg) {
 				user := User{
 					Sub:        "test-device",
 					DeviceName: "Test Device",
@@ -63,6 +66,11 @@ func Middleware(cfg config.Config) func(http.Handler) http.Handler {
 				return
 			}
 
+			if strings.HasPrefix(token, APIKeyPrefix) {
+				serveAPIKey(w, r, next, keys, token)
+				return
+			}
+
 			payload, err := VerifyToken(cfg, token)
 			if err != nil {
 				if err == ErrTokenExpired {
@@ -88,6 +96,28 @@ func Middleware(cfg config.Config) func(http.Handler) http.Handler {
 	}
 }
 
+// serveAPIKey authenticates a request bearing an API key and checks the key's scopes.
+func serveAPIKey(w http.ResponseWriter, r *http.Request, next http.Handler, keys APIKeyVerifier, key string) {
+	if keys == nil {
+		api.WriteError(w, r, apperrors.NewUnauthorizedError("Invalid API key", apperrors.ErrorCodeAuthTokenInvalid))
+		return
+	}
+	user, ok, err := keys.VerifyAPIKey(key)
+	if err != nil {
+		api.WriteError(w, r, apperrors.NewInternalError("Failed to verify API key"))
+		return
+	}
+	if !ok {
+		api.WriteError(w, r, apperrors.NewUnauthorizedError("Invalid API key", apperrors.ErrorCodeAuthTokenInvalid))
+		return
+	}
+	if required := RequiredScope(r); !user.HasScope(required) {
+		api.WriteError(w, r, apperrors.NewForbiddenError("API key lacks the "+string(required)+" scope"))
+		return
+	}
+	next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), user)))
+}
+
 func isPublicRoute(path string) bool {
 	if _, ok := publicRoutes[path]; ok {
 		return true
@@ -100,7 +130,9 @@ func isPublicRoute(path string) bool {
 	return false
 }
 
-func isTestModeRequest(r *http.Request, cfg config.Config) bool {
+// IsTestModeRequest reports whether r carries X-Test-Mode: true on a development server
+// that allows test mode, as the parity test suite's requests do.
+func IsTestModeRequest(r *http.Request, cfg config.Config) bool {
 	if !cfg.AllowTestMode {
 		return false
 	}
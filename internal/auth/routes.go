@@ -2,7 +2,7 @@ package auth
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -24,7 +24,7 @@ func RegisterRoutes(router chi.Router, store *PairingStore, cfg config.Config) {
 			return apperrors.NewInternalError("Failed to generate pairing code")
 		}
 
-		log.Printf("Pairing code generated - enter this on your device: %s", pairCode)
+		slog.Info("Pairing code generated - enter this on your device", "code", pairCode)
 
 		return api.WriteAction(w, http.StatusOK, map[string]any{
 			"object":       "pairing_start",
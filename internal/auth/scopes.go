@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+)
+
+// APIKeyPrefix starts every API key, which is how the middleware tells them apart from
+// paired devices' JWTs.
+const APIKeyPrefix = "shk_"
+
+// Scope is a permission granted to an API key. Scopes are ordered: admin includes
+// control, and control includes read.
+type Scope string
+
+const (
+	ScopeRead    Scope = "read"    // GET requests
+	ScopeControl Scope = "control" // Playback, volume, and routine, scene and set changes
+	ScopeAdmin   Scope = "admin"   // /v1/admin: API keys, maintenance, backup and restore
+)
+
+// Scopes lists the valid scopes from least to most privileged.
+var Scopes = []Scope{ScopeRead, ScopeControl, ScopeAdmin}
+
+// IsValid reports whether s is a known scope.
+func (s Scope) IsValid() bool {
+	return s.rank() > 0
+}
+
+func (s Scope) rank() int {
+	for i, scope := range Scopes {
+		if s == scope {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// APIKeyVerifier resolves an API key presented as a bearer token to the user it
+// authenticates (implemented by apikeys.Repository). ok is false for unknown keys.
+type APIKeyVerifier interface {
+	VerifyAPIKey(key string) (user User, ok bool, err error)
+}
+
+// RequiredScope returns the scope an API key needs for r.
+func RequiredScope(r *http.Request) Scope {
+	if r.URL.Path == "/v1/admin" || strings.HasPrefix(r.URL.Path, "/v1/admin/") {
+		return ScopeAdmin
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return ScopeRead
+	}
+	return ScopeControl
+}
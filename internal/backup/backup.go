@@ -0,0 +1,301 @@
+// Package backup takes consistent snapshots of the hub's SQLite database and restores
+// them into the running server.
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/strefethen/sonos-hub-go/internal/audit"
+	"github.com/strefethen/sonos-hub-go/internal/db"
+)
+
+// DBPair interface for dependency injection (matches db.DBPair).
+type DBPair interface {
+	Reader() *sql.DB
+	Writer() *sql.DB
+}
+
+// Scheduler is the part of the scheduler service paused during a restore (implemented by
+// scheduler.Service).
+type Scheduler interface {
+	IsRunning() bool
+	Start()
+	Stop()
+}
+
+// EventRecorder records audit events (implemented by audit.Service).
+type EventRecorder interface {
+	RecordEvent(input audit.WriteEventInput) (*audit.AuditEvent, error)
+}
+
+// ErrBusy is returned when a backup or restore is started while a restore is running.
+var ErrBusy = errors.New("a database restore is in progress")
+
+// InvalidBackupError is returned when an uploaded file can't be restored.
+type InvalidBackupError struct {
+	Reason  string
+	Details map[string]any
+}
+
+func (e *InvalidBackupError) Error() string {
+	return "invalid backup: " + e.Reason
+}
+
+// Counts are row counts used to sanity check a restored database.
+type Counts struct {
+	Routines  int
+	MusicSets int
+	Scenes    int
+}
+
+// RestoreResult describes a completed restore.
+type RestoreResult struct {
+	SchemaVersion int // The backup's version, before migrations brought it up to date
+	Counts        Counts
+	RestoredAt    time.Time
+}
+
+// Service creates and restores database backups.
+type Service struct {
+	db        DBPair
+	scheduler Scheduler
+	events    EventRecorder
+	now       func() time.Time
+
+	// Held for writing while a restore replaces the database, so no backup
+	// captures it half-restored
+	mu sync.RWMutex
+}
+
+// NewService creates a backup service. scheduler and events may be nil.
+func NewService(dbPair DBPair, scheduler Scheduler, events EventRecorder) *Service {
+	return &Service{
+		db:        dbPair,
+		scheduler: scheduler,
+		events:    events,
+		now:       time.Now,
+	}
+}
+
+// Snapshot writes a consistent copy of the database to a new temporary file and returns
+// its path. The caller removes the file. VACUUM INTO reads inside a single transaction,
+// so writes made while it runs are either wholly in the copy or not at all. It runs on a
+// reader connection, so those writes aren't blocked.
+func (s *Service) Snapshot() (string, error) {
+	if !s.mu.TryRLock() {
+		return "", ErrBusy
+	}
+	defer s.mu.RUnlock()
+
+	file, err := os.CreateTemp("", "sonos-hub-backup-*.db")
+	if err != nil {
+		return "", fmt.Errorf("create snapshot file: %w", err)
+	}
+	path := file.Name()
+	file.Close() // VACUUM INTO writes to an empty existing file
+
+	if _, err := s.db.Reader().Exec("VACUUM INTO ?", path); err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("snapshot database: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("stat snapshot: %w", err)
+	}
+	s.record(audit.EventDatabaseBackup, audit.LevelInfo, "Database backup downloaded", map[string]any{
+		"size_bytes": info.Size(),
+	})
+	return path, nil
+}
+
+// Restore replaces the database with the SQLite file at path. The file must be a hub
+// database whose schema version this binary supports; older versions are migrated after
+// the restore. The scheduler is paused while the contents are swapped.
+func (s *Service) Restore(path string) (*RestoreResult, error) {
+	if !s.mu.TryLock() {
+		return nil, ErrBusy
+	}
+	defer s.mu.Unlock()
+
+	version, err := validateBackup(path)
+	if err != nil {
+		s.recordRestoreFailure(err)
+		return nil, err
+	}
+
+	if s.scheduler != nil && s.scheduler.IsRunning() {
+		slog.Info("BACKUP: Pausing scheduler for restore")
+		s.scheduler.Stop()
+		defer func() {
+			slog.Info("BACKUP: Resuming scheduler")
+			s.scheduler.Start()
+		}()
+	}
+
+	if err := s.copyInto(path); err != nil {
+		err = fmt.Errorf("restore database: %w", err)
+		s.recordRestoreFailure(err)
+		return nil, err
+	}
+	if _, err := db.Migrate(s.db.Writer()); err != nil {
+		err = fmt.Errorf("migrate restored database: %w", err)
+		s.recordRestoreFailure(err)
+		return nil, err
+	}
+
+	counts, err := countRows(s.db.Reader())
+	if err != nil {
+		return nil, err
+	}
+	result := &RestoreResult{SchemaVersion: version, Counts: counts, RestoredAt: s.now()}
+
+	// Recorded after the restore, so the event lands in the restored audit log
+	s.record(audit.EventDatabaseRestored, audit.LevelInfo, "Database restored from backup", map[string]any{
+		"schema_version": version,
+		"routines":       counts.Routines,
+		"music_sets":     counts.MusicSets,
+		"scenes":         counts.Scenes,
+	})
+	return result, nil
+}
+
+// copyInto replaces the live database's contents with the database at path using
+// SQLite's online backup API. The copy is a single write transaction on the writer's
+// connection, so readers see either the old database or the new one, and the server's
+// open connections stay valid.
+func (s *Service) copyInto(path string) error {
+	ctx := context.Background()
+
+	source, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	sourceConn, err := source.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer sourceConn.Close()
+
+	destConn, err := s.db.Writer().Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+
+	return destConn.Raw(func(destDriverConn any) error {
+		return sourceConn.Raw(func(sourceDriverConn any) error {
+			dest, ok := destDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected database driver %T", destDriverConn)
+			}
+			src, ok := sourceDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected database driver %T", sourceDriverConn)
+			}
+
+			backup, err := dest.Backup("main", src, "main")
+			if err != nil {
+				return err
+			}
+			if _, err := backup.Step(-1); err != nil {
+				backup.Finish()
+				return err
+			}
+			return backup.Finish()
+		})
+	})
+}
+
+// validateBackup checks that path is an intact hub database this binary can run, and
+// returns its schema version.
+func validateBackup(path string) (int, error) {
+	conn, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return 0, &InvalidBackupError{Reason: "file could not be opened as a SQLite database"}
+	}
+	defer conn.Close()
+
+	var check string
+	if err := conn.QueryRow("PRAGMA quick_check").Scan(&check); err != nil {
+		return 0, &InvalidBackupError{Reason: "file is not a SQLite database"}
+	}
+	if check != "ok" {
+		return 0, &InvalidBackupError{Reason: "database failed its integrity check", Details: map[string]any{"check": check}}
+	}
+
+	var tables int
+	if err := conn.QueryRow(
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'",
+	).Scan(&tables); err != nil {
+		return 0, &InvalidBackupError{Reason: "database schema could not be read"}
+	}
+	if tables == 0 {
+		return 0, &InvalidBackupError{Reason: "database has no schema_migrations table; it is not a sonos-hub backup"}
+	}
+
+	var version int
+	if err := conn.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, &InvalidBackupError{Reason: "schema version could not be read"}
+	}
+	if version == 0 {
+		return 0, &InvalidBackupError{Reason: "database has no applied migrations"}
+	}
+	if latest := db.LatestVersion(); version > latest {
+		return 0, &InvalidBackupError{
+			Reason:  "backup is from a newer version of the server",
+			Details: map[string]any{"schema_version": version, "supported_version": latest},
+		}
+	}
+	return version, nil
+}
+
+func countRows(conn *sql.DB) (Counts, error) {
+	var counts Counts
+	queries := []struct {
+		query string
+		dest  *int
+	}{
+		{"SELECT COUNT(*) FROM routines WHERE deleted_at IS NULL", &counts.Routines},
+		{"SELECT COUNT(*) FROM music_sets WHERE deleted_at IS NULL", &counts.MusicSets},
+		{"SELECT COUNT(*) FROM scenes WHERE deleted_at IS NULL", &counts.Scenes},
+	}
+	for _, q := range queries {
+		if err := conn.QueryRow(q.query).Scan(q.dest); err != nil {
+			return counts, fmt.Errorf("count rows: %w", err)
+		}
+	}
+	return counts, nil
+}
+
+func (s *Service) recordRestoreFailure(err error) {
+	slog.Error("BACKUP: Restore failed", "error", err)
+	s.record(audit.EventDatabaseRestoreFailed, audit.LevelError, "Database restore failed: "+err.Error(), map[string]any{
+		"error": err.Error(),
+	})
+}
+
+func (s *Service) record(eventType audit.EventType, level audit.EventLevel, message string, payload map[string]any) {
+	if s.events == nil {
+		return
+	}
+	if _, err := s.events.RecordEvent(audit.WriteEventInput{
+		Type:    string(eventType),
+		Level:   &level,
+		Message: message,
+		Payload: payload,
+	}); err != nil {
+		slog.Warn("BACKUP: Failed to record audit event", "error", err)
+	}
+}
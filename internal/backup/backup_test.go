@@ -0,0 +1,208 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/audit"
+	"github.com/strefethen/sonos-hub-go/internal/db"
+)
+
+// fakeScheduler records pauses and resumes.
+type fakeScheduler struct {
+	running bool
+	calls   []string
+}
+
+func (f *fakeScheduler) IsRunning() bool { return f.running }
+func (f *fakeScheduler) Start()          { f.running = true; f.calls = append(f.calls, "start") }
+func (f *fakeScheduler) Stop()           { f.running = false; f.calls = append(f.calls, "stop") }
+
+// fakeRecorder keeps recorded audit events.
+type fakeRecorder struct {
+	mu     sync.Mutex
+	events []audit.WriteEventInput
+}
+
+func (f *fakeRecorder) RecordEvent(input audit.WriteEventInput) (*audit.AuditEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, input)
+	return &audit.AuditEvent{}, nil
+}
+
+func (f *fakeRecorder) types() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	types := make([]string, 0, len(f.events))
+	for _, event := range f.events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func setupTestDB(t *testing.T) *db.DBPair {
+	t.Helper()
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+	return dbPair
+}
+
+func insertScene(t *testing.T, dbPair *db.DBPair, sceneID string) {
+	t.Helper()
+	_, err := dbPair.Writer().Exec("INSERT INTO scenes (scene_id, name) VALUES (?, ?)", sceneID, "Scene "+sceneID)
+	require.NoError(t, err)
+}
+
+func insertRoutine(t *testing.T, dbPair *db.DBPair, routineID, sceneID string) {
+	t.Helper()
+	_, err := dbPair.Writer().Exec(`
+		INSERT INTO routines (routine_id, name, timezone, schedule_time, scene_id, created_at, updated_at)
+		VALUES (?, ?, 'UTC', '07:00', ?, '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z')
+	`, routineID, "Routine "+routineID, sceneID)
+	require.NoError(t, err)
+}
+
+func TestSnapshotAndRestore(t *testing.T) {
+	dbPair := setupTestDB(t)
+	insertScene(t, dbPair, "scene-1")
+	insertRoutine(t, dbPair, "routine-1", "scene-1")
+	insertRoutine(t, dbPair, "routine-2", "scene-1")
+
+	scheduler := &fakeScheduler{running: true}
+	recorder := &fakeRecorder{}
+	service := NewService(dbPair, scheduler, recorder)
+
+	path, err := service.Snapshot()
+	require.NoError(t, err)
+	defer os.Remove(path)
+
+	// Changes after the snapshot are undone by restoring it
+	_, err = dbPair.Writer().Exec("DELETE FROM routines WHERE routine_id = 'routine-2'")
+	require.NoError(t, err)
+	insertScene(t, dbPair, "scene-2")
+
+	result, err := service.Restore(path)
+	require.NoError(t, err)
+	require.Equal(t, db.LatestVersion(), result.SchemaVersion)
+	require.Equal(t, Counts{Routines: 2, MusicSets: 0, Scenes: 1}, result.Counts)
+	require.Equal(t, []string{"stop", "start"}, scheduler.calls)
+
+	// The server's existing connections see the restored data
+	var count int
+	require.NoError(t, dbPair.Reader().QueryRow("SELECT COUNT(*) FROM routines").Scan(&count))
+	require.Equal(t, 2, count)
+	_, err = dbPair.Writer().Exec("UPDATE routines SET name = 'Renamed' WHERE routine_id = 'routine-2'")
+	require.NoError(t, err)
+
+	var journalMode string
+	require.NoError(t, dbPair.Writer().QueryRow("PRAGMA journal_mode").Scan(&journalMode))
+	require.Equal(t, "wal", journalMode)
+
+	require.Equal(t, []string{string(audit.EventDatabaseBackup), string(audit.EventDatabaseRestored)}, recorder.types())
+}
+
+func TestRestore_RejectsInvalidBackups(t *testing.T) {
+	dbPair := setupTestDB(t)
+	insertScene(t, dbPair, "scene-1")
+	scheduler := &fakeScheduler{running: true}
+	recorder := &fakeRecorder{}
+	service := NewService(dbPair, scheduler, recorder)
+
+	dir := t.TempDir()
+	garbage := filepath.Join(dir, "garbage.db")
+	require.NoError(t, os.WriteFile(garbage, []byte("not a database at all, just some text"), 0o644))
+
+	newer, err := service.Snapshot()
+	require.NoError(t, err)
+	defer os.Remove(newer)
+	newerDB, err := db.Init(newer)
+	require.NoError(t, err)
+	_, err = newerDB.Writer().Exec(
+		"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, 'from_the_future', '2030-01-01T00:00:00Z')",
+		db.LatestVersion()+1,
+	)
+	require.NoError(t, err)
+	require.NoError(t, newerDB.Close())
+
+	for name, path := range map[string]string{"garbage": garbage, "newer": newer} {
+		_, err := service.Restore(path)
+		var invalid *InvalidBackupError
+		require.ErrorAs(t, err, &invalid, name)
+	}
+
+	// Nothing was touched
+	require.Empty(t, scheduler.calls)
+	var count int
+	require.NoError(t, dbPair.Reader().QueryRow("SELECT COUNT(*) FROM scenes").Scan(&count))
+	require.Equal(t, 1, count)
+	require.Equal(t, []string{
+		string(audit.EventDatabaseBackup),
+		string(audit.EventDatabaseRestoreFailed),
+		string(audit.EventDatabaseRestoreFailed),
+	}, recorder.types())
+}
+
+func TestBackupRoutes(t *testing.T) {
+	dbPair := setupTestDB(t)
+	insertScene(t, dbPair, "scene-1")
+	insertRoutine(t, dbPair, "routine-1", "scene-1")
+	service := NewService(dbPair, nil, nil)
+
+	router := chi.NewRouter()
+	RegisterRoutes(router, service)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/admin/backup", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/vnd.sqlite3", rec.Header().Get("Content-Type"))
+	require.Contains(t, rec.Header().Get("Content-Disposition"), "attachment; filename=sonos-hub-")
+	snapshot := rec.Body.Bytes()
+	require.True(t, bytes.HasPrefix(snapshot, []byte("SQLite format 3\x00")))
+
+	_, err := dbPair.Writer().Exec("DELETE FROM routines")
+	require.NoError(t, err)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "backup.db")
+	require.NoError(t, err)
+	_, err = io.Copy(part, bytes.NewReader(snapshot))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/admin/restore", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var restored map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &restored))
+	require.Equal(t, "database_restore", restored["object"])
+	require.Equal(t, float64(1), restored["routines"])
+	require.Equal(t, float64(1), restored["scenes"])
+	require.Equal(t, float64(0), restored["music_sets"])
+
+	// A raw body works too; an empty one is rejected
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/admin/restore", bytes.NewReader(snapshot)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/admin/restore", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
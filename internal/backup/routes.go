@@ -0,0 +1,131 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+)
+
+// maxRestoreBytes caps the size of an uploaded backup.
+const maxRestoreBytes = 512 << 20
+
+// RegisterRoutes wires backup routes to the router.
+func RegisterRoutes(router chi.Router, service *Service) {
+	router.Method(http.MethodGet, "/v1/admin/backup", api.Handler(backupHandler(service)))
+	router.Method(http.MethodPost, "/v1/admin/restore", api.Handler(restoreHandler(service)))
+}
+
+// backupHandler handles GET /v1/admin/backup
+func backupHandler(service *Service) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		path, err := service.Snapshot()
+		if errors.Is(err, ErrBusy) {
+			return apperrors.NewConflictError("A database restore is in progress", nil)
+		}
+		if err != nil {
+			return apperrors.NewInternalError("Failed to back up database")
+		}
+		defer os.Remove(path)
+
+		file, err := os.Open(path)
+		if err != nil {
+			return apperrors.NewInternalError("Failed to back up database")
+		}
+		defer file.Close()
+
+		now := service.now().UTC()
+		filename := fmt.Sprintf("sonos-hub-%s.db", now.Format("20060102-150405"))
+		w.Header().Set("Content-Type", "application/vnd.sqlite3")
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+		w.Header().Set("Cache-Control", "no-store")
+		http.ServeContent(w, r, "", now, file)
+		return nil
+	}
+}
+
+// restoreHandler handles POST /v1/admin/restore
+// The backup is either the "file" field of a multipart form or the raw request body.
+func restoreHandler(service *Service) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		r.Body = http.MaxBytesReader(w, r.Body, maxRestoreBytes)
+
+		upload, err := readUpload(r)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(upload)
+
+		result, err := service.Restore(upload)
+		if errors.Is(err, ErrBusy) {
+			return apperrors.NewConflictError("A database restore is already in progress", nil)
+		}
+		var invalid *InvalidBackupError
+		if errors.As(err, &invalid) {
+			return apperrors.NewValidationError(invalid.Reason, invalid.Details)
+		}
+		if err != nil {
+			return apperrors.NewInternalError("Failed to restore database")
+		}
+
+		return api.WriteResource(w, http.StatusOK, map[string]any{
+			"object":         api.ObjectDatabaseRestore,
+			"schema_version": result.SchemaVersion,
+			"routines":       result.Counts.Routines,
+			"music_sets":     result.Counts.MusicSets,
+			"scenes":         result.Counts.Scenes,
+			"restored_at":    api.RFC3339Millis(result.RestoredAt),
+		})
+	}
+}
+
+// readUpload saves the uploaded backup to a temporary file and returns its path.
+func readUpload(r *http.Request) (string, error) {
+	var body io.Reader = r.Body
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			return "", uploadError(err)
+		}
+		defer r.MultipartForm.RemoveAll()
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return "", apperrors.NewValidationError("file is required", nil)
+		}
+		defer file.Close()
+		body = file
+	}
+
+	out, err := os.CreateTemp("", "sonos-hub-restore-*.db")
+	if err != nil {
+		return "", apperrors.NewInternalError("Failed to store upload")
+	}
+	written, err := io.Copy(out, body)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(out.Name())
+		return "", uploadError(err)
+	}
+	if written == 0 {
+		os.Remove(out.Name())
+		return "", apperrors.NewValidationError("backup file is empty", nil)
+	}
+	return out.Name(), nil
+}
+
+func uploadError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return apperrors.NewValidationError("backup file is too large", map[string]any{"max_bytes": tooLarge.Limit})
+	}
+	return apperrors.NewValidationError("backup upload could not be read", nil)
+}
@@ -2,10 +2,13 @@ package config
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
+
+	"github.com/strefethen/sonos-hub-go/internal/applemusic"
 )
 
 // Config holds the base server configuration.
@@ -31,11 +34,49 @@ type Config struct {
 	// ZoneCacheTTLSeconds is the TTL for zone group topology cache in seconds.
 	// Zone topology changes infrequently so caching reduces SOAP calls.
 	ZoneCacheTTLSeconds int
+	// FavoritesCacheTTLSeconds is the TTL for the Sonos favorites cache in seconds.
+	// POST /v1/sonos/favorites/refresh invalidates it early; 0 disables caching.
+	FavoritesCacheTTLSeconds int
+	// SOAP fan-out limits: calls in flight overall and per device, and the per-device
+	// circuit breaker that fails fast after consecutive timeouts (threshold 0 disables it).
+	SOAPMaxConcurrent          int
+	SOAPMaxConcurrentPerDevice int
+	SOAPBreakerThreshold       int
+	SOAPBreakerCooldownSec     int
+	// Rate limit for /v1/sonos/*, per client IP and route group: a burst of
+	// SonosRateLimitBurst requests, then SonosRateLimitPerSec a second (0 disables it).
+	SonosRateLimitPerSec int
+	SonosRateLimitBurst  int
+	// mDNS advertisement of the hub as _sonos-hub._tcp, so apps find it without being
+	// given its address. HubName names the service; it defaults to the host name.
+	MDNSEnabled bool
+	HubName     string
+	// Artwork proxy: when enabled, album art URLs in responses point at
+	// /v1/assets/artwork, which caches fetched art on disk up to ArtworkCacheMaxMB.
+	ArtworkProxyEnabled bool
+	ArtworkCacheDir     string
+	ArtworkCacheMaxMB   int
+	// ArtworkDir holds local copies of Sonos favorite artwork, which set items and
+	// routines reference instead of the expiring Sonos URLs.
+	ArtworkDir string
+	// AudioDir holds audio files, such as alarm sounds, that the hub serves for speakers to
+	// play. PublicBaseURL is the hub's address as speakers reach it, which their URLs need.
+	AudioDir      string
+	PublicBaseURL string
 
 	// UPnP Event Subscription settings
 	UPnPEventsEnabled          bool
 	UPnPSubscriptionTimeoutSec int
 	UPnPStateCacheTTLSeconds   int
+	NowPlayingSampleIntervalSec int // Interval for recording now-playing history (0 disables)
+
+	// MQTT: with a broker URL, speaker state and routine events are published under
+	// MQTTTopicPrefix and commands accepted from it; without one, MQTT is off
+	MQTTBrokerURL        string
+	MQTTUsername         string
+	MQTTPassword         string
+	MQTTTopicPrefix      string
+	MQTTStateIntervalSec int // How often speaker state is checked for changes
 
 	// Apple Music API settings
 	AppleTeamID          string // Apple Developer Team ID
@@ -44,6 +85,32 @@ type Config struct {
 	AppleTokenExpirySec  int    // Token TTL in seconds (max 15552000 = 6 months)
 	AppleMusicAPIURL     string // Apple Music API base URL
 	DefaultStorefront    string // Apple Music storefront (country code)
+
+	// Spotify search: how long searches wait for a disconnected extension to reconnect (0 fails them at once)
+	SpotifySearchReconnectGraceMs int
+
+	// Apple Music and library search results are cached this long, up to this many searches (0 disables)
+	SearchCacheTTLSeconds int
+	SearchCacheMaxEntries int
+
+	// Scheduler settings
+	RoutineTriggerCooldownSec int // Minimum seconds between manual trigger/run calls per routine (0 disables)
+
+	// Retention: finished jobs and play history older than these are pruned nightly (0 keeps them forever)
+	JobRetentionDays     int
+	HistoryRetentionDays int
+
+	// Deleted routines can be restored for this many days before they're purged (0 keeps them forever)
+	DeletedRoutineRetentionDays int
+
+	// Hub location for sunrise/sunset schedules; without it those routines use their fixed time
+	HasCoordinates bool
+	Latitude       float64
+	Longitude      float64
+
+	// Logging: the minimum level written and the line format ("text" or "json")
+	LogLevel  slog.Level
+	LogFormat string
 }
 
 // Load reads configuration from environment variables with defaults.
@@ -55,9 +122,18 @@ func Load() (Config, error) {
 	// Warn if database path appears to point to the Node.js project instead of Go project
 	// This happens when SQLITE_DB_PATH is exported in shell from another project
 	if strings.Contains(sqlitePath, "/sonos-hub/") && !strings.Contains(sqlitePath, "/sonos-hub-go/") {
-		log.Printf("WARNING: SQLITE_DB_PATH appears to point to Node.js project: %s", sqlitePath)
-		log.Printf("WARNING: Expected Go project database in sonos-hub-go/data/")
-		log.Printf("WARNING: Fix: unset SQLITE_DB_PATH && set -a && source .env && set +a && air")
+		slog.Warn("SQLITE_DB_PATH appears to point to the Node.js project; expected a database in sonos-hub-go/data/",
+			"path", sqlitePath,
+			"fix", "unset SQLITE_DB_PATH && set -a && source .env && set +a && air")
+	}
+
+	var logLevel slog.Level
+	if err := logLevel.UnmarshalText([]byte(envString("LOG_LEVEL", "info"))); err != nil {
+		return Config{}, fmt.Errorf("LOG_LEVEL must be debug, info, warn or error: %w", err)
+	}
+	logFormat := strings.ToLower(envString("LOG_FORMAT", "text"))
+	if logFormat != "text" && logFormat != "json" {
+		return Config{}, fmt.Errorf("LOG_FORMAT must be text or json, got %q", logFormat)
 	}
 
 	nodeEnv := envString("NODE_ENV", "development")
@@ -76,9 +152,44 @@ func Load() (Config, error) {
 	sonosClientSecret := envString("SONOS_CLIENT_SECRET", "")
 	sonosRedirectURI := envString("SONOS_REDIRECT_URI", "")
 	zoneCacheTTL := envInt("ZONE_CACHE_TTL_SECONDS", 30)
+	favoritesCacheTTL := envInt("FAVORITES_CACHE_TTL_SECONDS", 300)
+	soapMaxConcurrent := envInt("SOAP_MAX_CONCURRENT", 16)
+	soapMaxConcurrentPerDevice := envInt("SOAP_MAX_CONCURRENT_PER_DEVICE", 4)
+	soapBreakerThreshold := envInt("SOAP_BREAKER_THRESHOLD", 3)
+	soapBreakerCooldown := envInt("SOAP_BREAKER_COOLDOWN_SECONDS", 30)
+	sonosRateLimitPerSec := envInt("SONOS_RATE_LIMIT_PER_SECOND", 5)
+	sonosRateLimitBurst := envInt("SONOS_RATE_LIMIT_BURST", 20)
+	mdnsEnabled := envBool("MDNS_ENABLED", true)
+	hubName := envString("HUB_NAME", "")
+	artworkProxyEnabled := envBool("ARTWORK_PROXY_ENABLED", false)
+	artworkCacheDir := envString("ARTWORK_CACHE_DIR", "./data/artwork-cache")
+	artworkCacheMaxMB := envInt("ARTWORK_CACHE_MAX_MB", 100)
+	artworkDir := envString("ARTWORK_DIR", "./data/artwork")
+	audioDir := envString("AUDIO_DIR", "./data/audio")
+	publicBaseURL := strings.TrimRight(envString("PUBLIC_BASE_URL", ""), "/")
+	if publicBaseURL != "" {
+		parsed, err := url.Parse(publicBaseURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return Config{}, fmt.Errorf("PUBLIC_BASE_URL must be an http or https URL such as http://192.168.1.5:9000, got %q", publicBaseURL)
+		}
+	}
 	upnpEventsEnabled := envBool("UPNP_EVENTS_ENABLED", true)
 	upnpSubscriptionTimeout := envInt("UPNP_SUBSCRIPTION_TIMEOUT", 3600)
 	upnpStateCacheTTL := envInt("UPNP_STATE_CACHE_TTL_SECONDS", 30)
+	nowPlayingSampleInterval := envInt("NOW_PLAYING_SAMPLE_INTERVAL_SECONDS", 60)
+
+	mqttBrokerURL := envString("MQTT_BROKER_URL", "")
+	if mqttBrokerURL != "" {
+		parsed, err := url.Parse(mqttBrokerURL)
+		if err != nil || !isMQTTScheme(parsed.Scheme) || parsed.Host == "" {
+			return Config{}, fmt.Errorf("MQTT_BROKER_URL must be a broker URL such as tcp://192.168.1.2:1883 (tcp, ssl, mqtt, mqtts, ws or wss), got %q", mqttBrokerURL)
+		}
+	}
+	mqttTopicPrefix := strings.Trim(envString("MQTT_TOPIC_PREFIX", "sonos-hub"), "/")
+	if mqttTopicPrefix == "" || strings.ContainsAny(mqttTopicPrefix, "+#") {
+		return Config{}, fmt.Errorf("MQTT_TOPIC_PREFIX must be a topic without wildcards, got %q", mqttTopicPrefix)
+	}
+	mqttStateInterval := envInt("MQTT_STATE_INTERVAL_SECONDS", 5)
 
 	// Apple Music settings (all optional - service disabled if team ID empty)
 	appleTeamID := envString("APPLE_TEAM_ID", "")
@@ -86,7 +197,35 @@ func Load() (Config, error) {
 	applePrivateKeyPath := envString("APPLE_PRIVATE_KEY_PATH", "")
 	appleTokenExpiry := envInt("APPLE_TOKEN_EXPIRY_SECONDS", 86400) // Default 24 hours
 	appleMusicAPIURL := envString("APPLE_MUSIC_API_URL", "https://api.music.apple.com")
-	defaultStorefront := envString("DEFAULT_STOREFRONT", "us")
+	defaultStorefront, ok := applemusic.NormalizeStorefront(envString("DEFAULT_STOREFRONT", applemusic.DefaultStorefront))
+	if !ok {
+		return Config{}, fmt.Errorf("DEFAULT_STOREFRONT must be a known two-letter country code, got %q", defaultStorefront)
+	}
+
+	spotifySearchReconnectGrace := envInt("SPOTIFY_SEARCH_RECONNECT_GRACE_MS", 3000)
+	searchCacheTTL := envInt("SEARCH_CACHE_TTL_SECONDS", 60)
+	searchCacheMaxEntries := envInt("SEARCH_CACHE_MAX_ENTRIES", 500)
+
+	routineTriggerCooldown := envInt("ROUTINE_TRIGGER_COOLDOWN_SECONDS", 5)
+	jobRetentionDays := envInt("JOB_RETENTION_DAYS", 90)
+	historyRetentionDays := envInt("HISTORY_RETENTION_DAYS", 365)
+	deletedRoutineRetentionDays := envInt("DELETED_ROUTINE_RETENTION_DAYS", 30)
+
+	// Both coordinates are required; a partial or out-of-range location is a config error
+	latitude, hasLatitude, err := envFloat("HUB_LATITUDE")
+	if err != nil {
+		return Config{}, err
+	}
+	longitude, hasLongitude, err := envFloat("HUB_LONGITUDE")
+	if err != nil {
+		return Config{}, err
+	}
+	if hasLatitude != hasLongitude {
+		return Config{}, fmt.Errorf("HUB_LATITUDE and HUB_LONGITUDE must be set together")
+	}
+	if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+		return Config{}, fmt.Errorf("HUB_LATITUDE must be within [-90, 90] and HUB_LONGITUDE within [-180, 180]")
+	}
 
 	if len(strings.TrimSpace(jwtSecret)) < 32 {
 		return Config{}, fmt.Errorf("JWT_SECRET must be at least 32 characters")
@@ -112,18 +251,60 @@ func Load() (Config, error) {
 		SonosClientSecret:        sonosClientSecret,
 		SonosRedirectURI:           sonosRedirectURI,
 		ZoneCacheTTLSeconds:        zoneCacheTTL,
+		FavoritesCacheTTLSeconds:   favoritesCacheTTL,
+		SOAPMaxConcurrent:          soapMaxConcurrent,
+		SOAPMaxConcurrentPerDevice: soapMaxConcurrentPerDevice,
+		SOAPBreakerThreshold:       soapBreakerThreshold,
+		SOAPBreakerCooldownSec:     soapBreakerCooldown,
+		SonosRateLimitPerSec:       sonosRateLimitPerSec,
+		SonosRateLimitBurst:        sonosRateLimitBurst,
+		MDNSEnabled:                mdnsEnabled,
+		HubName:                    hubName,
+		ArtworkProxyEnabled:        artworkProxyEnabled,
+		ArtworkCacheDir:            artworkCacheDir,
+		ArtworkCacheMaxMB:          artworkCacheMaxMB,
+		ArtworkDir:                 artworkDir,
+		AudioDir:                   audioDir,
+		PublicBaseURL:              publicBaseURL,
 		UPnPEventsEnabled:          upnpEventsEnabled,
 		UPnPSubscriptionTimeoutSec: upnpSubscriptionTimeout,
 		UPnPStateCacheTTLSeconds:   upnpStateCacheTTL,
+		NowPlayingSampleIntervalSec: nowPlayingSampleInterval,
+		MQTTBrokerURL:              mqttBrokerURL,
+		MQTTUsername:               envString("MQTT_USERNAME", ""),
+		MQTTPassword:               envString("MQTT_PASSWORD", ""),
+		MQTTTopicPrefix:            mqttTopicPrefix,
+		MQTTStateIntervalSec:       mqttStateInterval,
 		AppleTeamID:                appleTeamID,
 		AppleKeyID:                 appleKeyID,
 		ApplePrivateKeyPath:        applePrivateKeyPath,
 		AppleTokenExpirySec:        appleTokenExpiry,
 		AppleMusicAPIURL:           appleMusicAPIURL,
 		DefaultStorefront:          defaultStorefront,
+		SpotifySearchReconnectGraceMs: spotifySearchReconnectGrace,
+		SearchCacheTTLSeconds:      searchCacheTTL,
+		SearchCacheMaxEntries:      searchCacheMaxEntries,
+		RoutineTriggerCooldownSec:  routineTriggerCooldown,
+		JobRetentionDays:           jobRetentionDays,
+		HistoryRetentionDays:       historyRetentionDays,
+		DeletedRoutineRetentionDays: deletedRoutineRetentionDays,
+		HasCoordinates:             hasLatitude && hasLongitude,
+		Latitude:                   latitude,
+		Longitude:                  longitude,
+		LogLevel:                   logLevel,
+		LogFormat:                  logFormat,
 	}, nil
 }
 
+// isMQTTScheme reports whether scheme is one the MQTT client can connect with.
+func isMQTTScheme(scheme string) bool {
+	switch scheme {
+	case "tcp", "ssl", "tls", "mqtt", "mqtts", "ws", "wss":
+		return true
+	}
+	return false
+}
+
 func envString(key, fallback string) string {
 	val := os.Getenv(key)
 	if val == "" {
@@ -144,6 +325,19 @@ func envInt(key string, fallback int) int {
 	return parsed
 }
 
+// envFloat parses an optional float variable, reporting whether it was set.
+func envFloat(key string) (float64, bool, error) {
+	val := os.Getenv(key)
+	if val == "" {
+		return 0, false, nil
+	}
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s must be a number, got %q", key, val)
+	}
+	return parsed, true, nil
+}
+
 func envBool(key string, fallback bool) bool {
 	val := os.Getenv(key)
 	if val == "" {
@@ -40,9 +40,26 @@ func (p *DBPair) Close() error {
 	return nil
 }
 
-// Init opens the SQLite database with optimal connection pooling for concurrency.
-// Returns a DBPair with separate reader and writer pools.
+// Init opens the SQLite database with optimal connection pooling for concurrency and
+// applies any pending migrations. Returns a DBPair with separate reader and writer pools.
 func Init(dbPath string) (*DBPair, error) {
+	dbPair, err := Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bring the schema up to date using writer
+	if _, err := Migrate(dbPair.writer); err != nil {
+		dbPair.Close()
+		return nil, err
+	}
+
+	return dbPair, nil
+}
+
+// Open opens the SQLite database like Init but leaves the schema as it is, for tools
+// that must not change the database, such as a dry run.
+func Open(dbPath string) (*DBPair, error) {
 	if dbPath == "" {
 		return nil, errors.New("db path is required")
 	}
@@ -52,12 +69,12 @@ func Init(dbPath string) (*DBPair, error) {
 	}
 
 	// Writer: Single connection, handles all writes
-	// - _journal=WAL: Write-ahead logging for concurrent reads
-	// - _busy_timeout=5000: Wait up to 5 seconds for locks
-	// - cache=shared: Share cache between connections for consistency
+	// - _txlock=immediate: Transactions take the write lock up front, so they wait out
+	//   the busy timeout instead of failing when another process holds the lock
 	// - mode=rwc: Read-write-create mode
-	writerConnStr := fmt.Sprintf("%s?_journal=WAL&_busy_timeout=5000&cache=shared&mode=rwc", dbPath)
-	writer, err := sql.Open("sqlite3", writerConnStr)
+	// Shared cache is deliberately not used: its table locks fail with "database is
+	// locked" immediately rather than waiting for the busy timeout.
+	writer, err := sql.Open("sqlite3", connString(dbPath, "_txlock=immediate&mode=rwc"))
 	if err != nil {
 		return nil, fmt.Errorf("open writer: %w", err)
 	}
@@ -65,19 +82,20 @@ func Init(dbPath string) (*DBPair, error) {
 	writer.SetMaxIdleConns(1)        // Keep one connection warm
 	writer.SetConnMaxLifetime(time.Hour)
 
-	// Apply PRAGMAs on writer (affects the database)
-	if _, err := writer.Exec("PRAGMA journal_mode = WAL;"); err != nil {
+	// Confirm the database accepted WAL (it can't for some filesystems)
+	var journalMode string
+	if err := writer.QueryRow("PRAGMA journal_mode").Scan(&journalMode); err != nil {
 		writer.Close()
 		return nil, fmt.Errorf("set WAL: %w", err)
 	}
-	if _, err := writer.Exec("PRAGMA foreign_keys = ON;"); err != nil {
+	if !strings.EqualFold(journalMode, "wal") {
 		writer.Close()
-		return nil, fmt.Errorf("set foreign_keys: %w", err)
+		return nil, fmt.Errorf("set WAL: journal mode is %s", journalMode)
 	}
 
 	// Reader: Multiple connections for concurrent reads
 	// - mode=ro: Read-only mode
-	readerConnStr := fmt.Sprintf("%s?_journal=WAL&_busy_timeout=5000&cache=shared&mode=ro", dbPath)
+	readerConnStr := connString(dbPath, "mode=ro")
 	reader, err := sql.Open("sqlite3", readerConnStr)
 	if err != nil {
 		writer.Close()
@@ -87,22 +105,18 @@ func Init(dbPath string) (*DBPair, error) {
 	reader.SetMaxIdleConns(2)        // Keep 2 connections warm
 	reader.SetConnMaxLifetime(time.Hour)
 
-	// Apply schema using writer
-	if _, err := writer.Exec(schemaSQL); err != nil {
-		reader.Close()
-		writer.Close()
-		return nil, fmt.Errorf("apply schema: %w", err)
-	}
-
-	if err := runMigrations(writer); err != nil {
-		reader.Close()
-		writer.Close()
-		return nil, err
-	}
-
 	return &DBPair{reader: reader, writer: writer}, nil
 }
 
+// connString builds a DSN with the settings every connection needs. They are set per
+// connection by the driver, so they also apply to connections reopened by the pool.
+// - _journal_mode=WAL: Write-ahead logging so readers don't block the writer
+// - _busy_timeout=5000: Wait up to 5 seconds for locks
+// - _foreign_keys=on: Enforce foreign keys (and their ON DELETE actions)
+func connString(dbPath, extra string) string {
+	return fmt.Sprintf("file:%s?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=on&%s", dbPath, extra)
+}
+
 func ensureDir(path string) error {
 	dir := filepath.Dir(path)
 	if _, err := os.Stat(dir); err == nil {
@@ -113,149 +127,6 @@ func ensureDir(path string) error {
 	return os.MkdirAll(dir, 0o755)
 }
 
-func runMigrations(db *sql.DB) error {
-	jobsColumns, err := tableColumns(db, "jobs")
-	if err != nil {
-		return err
-	}
-
-	if !jobsColumns["retry_after"] {
-		if _, err := db.Exec("ALTER TABLE jobs ADD COLUMN retry_after TEXT"); err != nil {
-			return fmt.Errorf("add jobs.retry_after: %w", err)
-		}
-	}
-
-	if !jobsColumns["claimed_at"] {
-		if _, err := db.Exec("ALTER TABLE jobs ADD COLUMN claimed_at TEXT"); err != nil {
-			return fmt.Errorf("add jobs.claimed_at: %w", err)
-		}
-	}
-
-	if !jobsColumns["idempotency_key"] {
-		if _, err := db.Exec("ALTER TABLE jobs ADD COLUMN idempotency_key TEXT"); err != nil {
-			return fmt.Errorf("add jobs.idempotency_key: %w", err)
-		}
-		if _, err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_idempotency ON jobs(idempotency_key) WHERE idempotency_key IS NOT NULL"); err != nil {
-			return fmt.Errorf("create idx_jobs_idempotency: %w", err)
-		}
-	}
-
-	routinesColumns, err := tableColumns(db, "routines")
-	if err != nil {
-		return err
-	}
-
-	if !routinesColumns["speakers_json"] {
-		if _, err := db.Exec("ALTER TABLE routines ADD COLUMN speakers_json TEXT"); err != nil {
-			return fmt.Errorf("add routines.speakers_json: %w", err)
-		}
-	}
-
-	if !routinesColumns["last_run_at"] {
-		if _, err := db.Exec("ALTER TABLE routines ADD COLUMN last_run_at TEXT"); err != nil {
-			return fmt.Errorf("add routines.last_run_at: %w", err)
-		}
-	}
-
-	if err := backfillSpeakersJSON(db); err != nil {
-		return err
-	}
-
-	// Add template visual fields if missing
-	templatesColumns, err := tableColumns(db, "routine_templates")
-	if err != nil {
-		return err
-	}
-
-	if !templatesColumns["icon"] {
-		if _, err := db.Exec("ALTER TABLE routine_templates ADD COLUMN icon TEXT"); err != nil {
-			return fmt.Errorf("add routine_templates.icon: %w", err)
-		}
-	}
-	if !templatesColumns["image_name"] {
-		if _, err := db.Exec("ALTER TABLE routine_templates ADD COLUMN image_name TEXT"); err != nil {
-			return fmt.Errorf("add routine_templates.image_name: %w", err)
-		}
-	}
-	if !templatesColumns["gradient_color_1"] {
-		if _, err := db.Exec("ALTER TABLE routine_templates ADD COLUMN gradient_color_1 TEXT"); err != nil {
-			return fmt.Errorf("add routine_templates.gradient_color_1: %w", err)
-		}
-	}
-	if !templatesColumns["gradient_color_2"] {
-		if _, err := db.Exec("ALTER TABLE routine_templates ADD COLUMN gradient_color_2 TEXT"); err != nil {
-			return fmt.Errorf("add routine_templates.gradient_color_2: %w", err)
-		}
-	}
-	if !templatesColumns["accent_color"] {
-		if _, err := db.Exec("ALTER TABLE routine_templates ADD COLUMN accent_color TEXT"); err != nil {
-			return fmt.Errorf("add routine_templates.accent_color: %w", err)
-		}
-	}
-
-	// Add artwork_url and display_name columns to set_items for existing databases
-	setItemsColumns, err := tableColumns(db, "set_items")
-	if err != nil {
-		return err
-	}
-
-	if !setItemsColumns["artwork_url"] {
-		if _, err := db.Exec("ALTER TABLE set_items ADD COLUMN artwork_url TEXT"); err != nil {
-			return fmt.Errorf("add set_items.artwork_url: %w", err)
-		}
-	}
-
-	if !setItemsColumns["display_name"] {
-		if _, err := db.Exec("ALTER TABLE set_items ADD COLUMN display_name TEXT"); err != nil {
-			return fmt.Errorf("add set_items.display_name: %w", err)
-		}
-	}
-
-	// Migrate play_history to add ON DELETE SET NULL for routine_id FK
-	if err := migratePlayHistoryFK(db); err != nil {
-		return err
-	}
-
-	// Add deleted_at columns for soft delete support
-	if !routinesColumns["deleted_at"] {
-		if _, err := db.Exec("ALTER TABLE routines ADD COLUMN deleted_at TEXT"); err != nil {
-			return fmt.Errorf("add routines.deleted_at: %w", err)
-		}
-		// Index for cleanup queries
-		if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_routines_deleted_at ON routines(deleted_at) WHERE deleted_at IS NOT NULL"); err != nil {
-			return fmt.Errorf("create idx_routines_deleted_at: %w", err)
-		}
-	}
-
-	musicSetsColumns, err := tableColumns(db, "music_sets")
-	if err != nil {
-		return err
-	}
-	if !musicSetsColumns["deleted_at"] {
-		if _, err := db.Exec("ALTER TABLE music_sets ADD COLUMN deleted_at TEXT"); err != nil {
-			return fmt.Errorf("add music_sets.deleted_at: %w", err)
-		}
-		if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_music_sets_deleted_at ON music_sets(deleted_at) WHERE deleted_at IS NOT NULL"); err != nil {
-			return fmt.Errorf("create idx_music_sets_deleted_at: %w", err)
-		}
-	}
-
-	scenesColumns, err := tableColumns(db, "scenes")
-	if err != nil {
-		return err
-	}
-	if !scenesColumns["deleted_at"] {
-		if _, err := db.Exec("ALTER TABLE scenes ADD COLUMN deleted_at TEXT"); err != nil {
-			return fmt.Errorf("add scenes.deleted_at: %w", err)
-		}
-		if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_scenes_deleted_at ON scenes(deleted_at) WHERE deleted_at IS NOT NULL"); err != nil {
-			return fmt.Errorf("create idx_scenes_deleted_at: %w", err)
-		}
-	}
-
-	return nil
-}
-
 // migratePlayHistoryFK recreates play_history table with ON DELETE SET NULL for routine_id FK.
 // This is safe: wrapped in transaction, verifies row counts before dropping old table.
 func migratePlayHistoryFK(db *sql.DB) error {
@@ -344,23 +215,46 @@ func migratePlayHistoryFK(db *sql.DB) error {
 	return nil
 }
 
-func tableColumns(db *sql.DB, table string) (map[string]bool, error) {
+// columnInfo is a column as reported by PRAGMA table_info.
+type columnInfo struct {
+	name         string
+	colType      string
+	notNull      bool
+	defaultValue sql.NullString
+}
+
+// definition returns the column's definition for ALTER TABLE ADD COLUMN.
+func (c columnInfo) definition() string {
+	definition := c.name
+	if c.colType != "" {
+		definition += " " + c.colType
+	}
+	if c.notNull {
+		definition += " NOT NULL"
+	}
+	if c.defaultValue.Valid {
+		definition += " DEFAULT " + c.defaultValue.String
+	}
+	return definition
+}
+
+func tableColumnInfo(db *sql.DB, table string) ([]columnInfo, error) {
 	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	columns := make(map[string]bool)
+	var columns []columnInfo
 	for rows.Next() {
 		var cid int
-		var name, colType string
+		var column columnInfo
 		var notNull, pk int
-		var defaultVal sql.NullString
-		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultVal, &pk); err != nil {
+		if err := rows.Scan(&cid, &column.name, &column.colType, &notNull, &column.defaultValue, &pk); err != nil {
 			return nil, err
 		}
-		columns[name] = true
+		column.notNull = notNull != 0
+		columns = append(columns, column)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -370,6 +264,19 @@ func tableColumns(db *sql.DB, table string) (map[string]bool, error) {
 	return columns, nil
 }
 
+func tableColumns(db *sql.DB, table string) (map[string]bool, error) {
+	info, err := tableColumnInfo(db, table)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]bool, len(info))
+	for _, column := range info {
+		columns[column.name] = true
+	}
+	return columns, nil
+}
+
 type sceneMember struct {
 	UDN          string `json:"udn"`
 	TargetVolume *int   `json:"target_volume"`
@@ -0,0 +1,256 @@
+package db
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migrations are SQL files named NNNN_description.sql. Each runs once, in version order,
+// in its own transaction. Never edit a migration that has shipped; add a new file.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// ErrSchemaTooNew is returned when the database has migrations this binary doesn't
+// know about, meaning a newer version of the server has already upgraded it.
+var ErrSchemaTooNew = errors.New("database schema is newer than this binary")
+
+// Migration is one versioned schema change.
+type Migration struct {
+	Version int
+	Name    string // File name without the .sql extension
+	SQL     string
+}
+
+// Migrations returns the embedded migrations in version order.
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	migrations := make([]Migration, 0, len(entries))
+	seen := make(map[int]string)
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: name must start with a version number", entry.Name())
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s have the same version", other, name)
+		}
+		seen[version] = name
+
+		contents, err := migrationFiles.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", entry.Name(), err)
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, SQL: string(contents)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// LatestVersion returns the schema version this binary migrates databases to.
+func LatestVersion() int {
+	migrations, err := Migrations()
+	if err != nil || len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// SchemaVersion returns the latest migration applied to the database, or 0 when it has
+// none.
+func SchemaVersion(db *sql.DB) (int, error) {
+	tracked, err := tableExists(db, "schema_migrations")
+	if err != nil || !tracked {
+		return 0, err
+	}
+	var version int
+	if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// Migrate applies the migrations the database hasn't had yet and returns them. It is
+// safe to run on every start. It fails with ErrSchemaTooNew, without changing anything,
+// if the database has a version newer than LatestVersion.
+//
+// Databases created before versioned migrations have tables but no schema_migrations
+// table. They are first brought up to the baseline schema (migration 0001).
+func Migrate(db *sql.DB) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	if len(migrations) == 0 || migrations[0].Version != 1 {
+		return nil, errors.New("migrations must start at version 1")
+	}
+
+	tracked, err := tableExists(db, "schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	legacy := false
+	if !tracked {
+		tables, err := tableNames(db)
+		if err != nil {
+			return nil, err
+		}
+		legacy = len(tables) > 0
+	}
+
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TEXT NOT NULL
+		)
+	`); err != nil {
+		return nil, fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current); err != nil {
+		return nil, fmt.Errorf("read schema version: %w", err)
+	}
+	latest := migrations[len(migrations)-1].Version
+	if current > latest {
+		return nil, fmt.Errorf("%w: database is at version %d, this binary supports up to %d", ErrSchemaTooNew, current, latest)
+	}
+
+	if legacy {
+		slog.Info("DB: Upgrading database created before versioned migrations")
+		if err := upgradeLegacySchema(db, migrations[0]); err != nil {
+			return nil, fmt.Errorf("upgrade legacy schema: %w", err)
+		}
+	}
+
+	var applied []Migration
+	for _, migration := range migrations {
+		if migration.Version <= current {
+			continue
+		}
+		ran, err := applyMigration(db, migration)
+		if err != nil {
+			return applied, fmt.Errorf("migration %s: %w", migration.Name, err)
+		}
+		if ran {
+			slog.Info("DB: Applied migration", "migration", migration.Name)
+			applied = append(applied, migration)
+		}
+	}
+	return applied, nil
+}
+
+// applyMigration runs migration in a transaction and records it. Returns false if another
+// process applied it first.
+func applyMigration(db *sql.DB, migration Migration) (bool, error) {
+	ran := false
+	err := WithTx(db, func(tx *sql.Tx) error {
+		var exists int
+		err := tx.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = ?", migration.Version).Scan(&exists)
+		if err != nil || exists > 0 {
+			return err
+		}
+		if _, err := tx.Exec(migration.SQL); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(
+			"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+			migration.Version, migration.Name, nowISO(),
+		); err != nil {
+			return err
+		}
+		ran = true
+		return nil
+	})
+	return ran, err
+}
+
+// upgradeLegacySchema adds the columns baseline has that the database's existing tables
+// lack (tables that are missing entirely are created by the baseline itself), then runs
+// the data fixes older versions ran on every start.
+func upgradeLegacySchema(db *sql.DB, baseline Migration) error {
+	reference, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return fmt.Errorf("open reference schema: %w", err)
+	}
+	defer reference.Close()
+	reference.SetMaxOpenConns(1) // Each in-memory connection is a separate database
+	if _, err := reference.Exec(baseline.SQL); err != nil {
+		return fmt.Errorf("build reference schema: %w", err)
+	}
+
+	tables, err := tableNames(reference)
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		existing, err := tableColumns(db, table)
+		if err != nil {
+			return err
+		}
+		if len(existing) == 0 {
+			continue
+		}
+		columns, err := tableColumnInfo(reference, table)
+		if err != nil {
+			return err
+		}
+		for _, column := range columns {
+			if existing[column.name] {
+				continue
+			}
+			if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", table, column.definition())); err != nil {
+				return fmt.Errorf("add %s.%s: %w", table, column.name, err)
+			}
+			slog.Info("DB: Added column", "table", table, "column", column.name)
+		}
+	}
+
+	if err := migratePlayHistoryFK(db); err != nil {
+		return err
+	}
+	return backfillSpeakersJSON(db)
+}
+
+func tableExists(db *sql.DB, table string) (bool, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("check table %s: %w", table, err)
+	}
+	return count > 0, nil
+}
+
+func tableNames(db *sql.DB) ([]string, error) {
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
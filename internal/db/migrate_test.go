@@ -0,0 +1,214 @@
+package db
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+func appliedVersions(t *testing.T, db *sql.DB) []int {
+	t.Helper()
+	rows, err := db.Query("SELECT version FROM schema_migrations ORDER BY version")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var versions []int
+	for rows.Next() {
+		var version int
+		require.NoError(t, rows.Scan(&version))
+		versions = append(versions, version)
+	}
+	require.NoError(t, rows.Err())
+	return versions
+}
+
+func TestMigrations_Embedded(t *testing.T) {
+	migrations, err := Migrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	require.Equal(t, 1, migrations[0].Version)
+	require.Equal(t, "0001_initial", migrations[0].Name)
+	for i, migration := range migrations {
+		require.Equal(t, i+1, migration.Version, "migration versions must have no gaps")
+	}
+	require.Equal(t, migrations[len(migrations)-1].Version, LatestVersion())
+}
+
+func TestMigrate_FreshDatabase(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	dbPair, err := Init(dbPath)
+	require.NoError(t, err)
+	versions := appliedVersions(t, dbPair.Writer())
+	require.Len(t, versions, LatestVersion())
+
+	// Running again applies nothing
+	applied, err := Migrate(dbPair.Writer())
+	require.NoError(t, err)
+	require.Empty(t, applied)
+	require.NoError(t, dbPair.Close())
+
+	dbPair, err = Init(dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+	require.Equal(t, versions, appliedVersions(t, dbPair.Writer()))
+}
+
+func TestOpen_LeavesSchemaAlone(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	dbPair, err := Open(dbPath)
+	require.NoError(t, err)
+	version, err := SchemaVersion(dbPair.Reader())
+	require.NoError(t, err)
+	require.Zero(t, version)
+	exists, err := tableExists(dbPair.Writer(), "schema_migrations")
+	require.NoError(t, err)
+	require.False(t, exists)
+	require.NoError(t, dbPair.Close())
+
+	dbPair, err = Init(dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+	version, err = SchemaVersion(dbPair.Reader())
+	require.NoError(t, err)
+	require.Equal(t, LatestVersion(), version)
+}
+
+func TestMigrate_LegacyDatabase(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	// A database from before versioned migrations, missing newer columns and tables
+	legacy, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	_, err = legacy.Exec(`
+		CREATE TABLE scenes (
+			scene_id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			members TEXT NOT NULL DEFAULT '[]',
+			created_at TEXT NOT NULL DEFAULT (datetime('now')),
+			updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+		);
+		CREATE TABLE routines (
+			routine_id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			timezone TEXT NOT NULL,
+			schedule_time TEXT NOT NULL,
+			scene_id TEXT NOT NULL,
+			created_at TEXT NOT NULL,
+			updated_at TEXT NOT NULL
+		);
+		INSERT INTO scenes (scene_id, name, members) VALUES ('scene-1', 'Kitchen', '[{"udn":"uuid:RINCON_A","target_volume":20}]');
+		INSERT INTO routines (routine_id, name, timezone, schedule_time, scene_id, created_at, updated_at)
+			VALUES ('routine-1', 'Morning', 'UTC', '07:00', 'scene-1', '2024-01-01T00:00:00Z', '2024-01-01T00:00:00Z');
+	`)
+	require.NoError(t, err)
+	require.NoError(t, legacy.Close())
+
+	dbPair, err := Init(dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+
+	columns, err := tableColumns(dbPair.Writer(), "routines")
+	require.NoError(t, err)
+	require.True(t, columns["occasions_enabled"])
+	require.True(t, columns["deleted_at"])
+
+	var name string
+	var occasionsEnabled int
+	var speakersJSON sql.NullString
+	require.NoError(t, dbPair.Reader().QueryRow(
+		"SELECT name, occasions_enabled, speakers_json FROM routines WHERE routine_id = 'routine-1'",
+	).Scan(&name, &occasionsEnabled, &speakersJSON))
+	require.Equal(t, "Morning", name)
+	require.Equal(t, 1, occasionsEnabled) // Column default
+	require.JSONEq(t, `[{"udn":"uuid:RINCON_A","volume":20}]`, speakersJSON.String)
+
+	exists, err := tableExists(dbPair.Writer(), "music_sets")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Len(t, appliedVersions(t, dbPair.Writer()), LatestVersion())
+}
+
+func TestMigrate_DropMusicMode(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	legacy, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	_, err = legacy.Exec(`
+		CREATE TABLE scenes (
+			scene_id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			members TEXT NOT NULL DEFAULT '[]',
+			created_at TEXT NOT NULL DEFAULT (datetime('now')),
+			updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+		);
+		CREATE TABLE routines (
+			routine_id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			timezone TEXT NOT NULL,
+			schedule_time TEXT NOT NULL,
+			scene_id TEXT NOT NULL,
+			music_mode TEXT NOT NULL DEFAULT 'FIXED',
+			music_policy_type TEXT NOT NULL DEFAULT 'FIXED',
+			music_set_id TEXT,
+			music_sonos_favorite_id TEXT,
+			music_content_json TEXT,
+			created_at TEXT NOT NULL,
+			updated_at TEXT NOT NULL
+		);
+		INSERT INTO routines (routine_id, name, timezone, schedule_time, scene_id, music_mode, music_policy_type, music_set_id, music_sonos_favorite_id, music_content_json, created_at, updated_at) VALUES
+			('predates-policy', 'Morning', 'UTC', '07:00', 'scene-1', 'SHUFFLE', 'FIXED', 'set-1', NULL, NULL, '2024-01-01T00:00:00Z', '2024-01-01T00:00:00Z'),
+			('fixed', 'Evening', 'UTC', '19:00', 'scene-1', 'FIXED', 'FIXED', NULL, 'FV:2/1', '{"type":"sonos_favorite"}', '2024-01-01T00:00:00Z', '2024-01-01T00:00:00Z'),
+			('switched', 'Night', 'UTC', '22:00', 'scene-1', 'FIXED', 'ROTATION', 'set-1', 'FV:2/1', '{"type":"sonos_favorite"}', '2024-01-01T00:00:00Z', '2024-01-01T00:00:00Z');
+	`)
+	require.NoError(t, err)
+	require.NoError(t, legacy.Close())
+
+	dbPair, err := Init(dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+
+	columns, err := tableColumns(dbPair.Writer(), "routines")
+	require.NoError(t, err)
+	require.False(t, columns["music_mode"])
+
+	routine := func(id string) (policyType string, favoriteID, contentJSON sql.NullString) {
+		require.NoError(t, dbPair.Reader().QueryRow(
+			"SELECT music_policy_type, music_sonos_favorite_id, music_content_json FROM routines WHERE routine_id = ?", id,
+		).Scan(&policyType, &favoriteID, &contentJSON))
+		return policyType, favoriteID, contentJSON
+	}
+
+	policyType, _, _ := routine("predates-policy")
+	require.Equal(t, "SHUFFLE", policyType, "the mode it was created with wins over the default")
+
+	policyType, favoriteID, contentJSON := routine("fixed")
+	require.Equal(t, "FIXED", policyType)
+	require.Equal(t, "FV:2/1", favoriteID.String)
+	require.True(t, contentJSON.Valid)
+
+	policyType, favoriteID, contentJSON = routine("switched")
+	require.Equal(t, "ROTATION", policyType, "the policy type wins over a stale mode")
+	require.False(t, favoriteID.Valid)
+	require.False(t, contentJSON.Valid)
+}
+
+func TestMigrate_DatabaseNewerThanBinary(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	dbPair, err := Init(dbPath)
+	require.NoError(t, err)
+	_, err = dbPair.Writer().Exec(
+		"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, 'from_the_future', '2030-01-01T00:00:00Z')",
+		LatestVersion()+1,
+	)
+	require.NoError(t, err)
+	require.NoError(t, dbPair.Close())
+
+	_, err = Init(dbPath)
+	require.ErrorIs(t, err, ErrSchemaTooNew)
+}
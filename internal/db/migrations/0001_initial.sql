@@ -1,6 +1,3 @@
-package db
-
-const schemaSQL = `
 -- ===========================================================================
 -- SCENES (from scene-engine)
 -- ===========================================================================
@@ -14,11 +11,14 @@ CREATE TABLE IF NOT EXISTS scenes (
   members TEXT NOT NULL DEFAULT '[]',
   volume_ramp TEXT,
   teardown TEXT,
+  grouping_mode TEXT NOT NULL DEFAULT 'grouped',
   deleted_at TEXT,
   created_at TEXT NOT NULL DEFAULT (datetime('now')),
   updated_at TEXT NOT NULL DEFAULT (datetime('now'))
 );
 
+CREATE INDEX IF NOT EXISTS idx_scenes_deleted_at ON scenes(deleted_at) WHERE deleted_at IS NOT NULL;
+
 CREATE TABLE IF NOT EXISTS scene_executions (
   scene_execution_id TEXT PRIMARY KEY,
   scene_id TEXT NOT NULL,
@@ -73,12 +73,27 @@ CREATE TABLE IF NOT EXISTS routines (
   occasions_enabled INTEGER NOT NULL DEFAULT 1,
   speakers_json TEXT,
   last_run_at TEXT,
+  missed_run_policy TEXT NOT NULL DEFAULT 'skip',
+  missed_run_within_minutes INTEGER,
+  schedule_interval_days INTEGER,
+  schedule_anchor_date TEXT,
+  duration_minutes INTEGER,
+  schedule_time_mode TEXT NOT NULL DEFAULT 'fixed',
+  schedule_offset_minutes INTEGER NOT NULL DEFAULT 0,
+  max_attempts INTEGER NOT NULL DEFAULT 3,
+  retry_backoff_seconds INTEGER NOT NULL DEFAULT 2,
+  holiday_music_set_id TEXT,
+  restore_previous_state INTEGER NOT NULL DEFAULT 0,
+  music_play_mode_json TEXT,
+  sleep_timer_minutes INTEGER,
   deleted_at TEXT,
   created_at TEXT NOT NULL,
   updated_at TEXT NOT NULL,
   FOREIGN KEY (scene_id) REFERENCES scenes(scene_id)
 );
 
+CREATE INDEX IF NOT EXISTS idx_routines_deleted_at ON routines(deleted_at) WHERE deleted_at IS NOT NULL;
+
 CREATE TABLE IF NOT EXISTS jobs (
   job_id TEXT PRIMARY KEY,
   routine_id TEXT NOT NULL,
@@ -90,6 +105,10 @@ CREATE TABLE IF NOT EXISTS jobs (
   retry_after TEXT,
   claimed_at TEXT,
   idempotency_key TEXT,
+  missed_run_decision TEXT,
+  step_log TEXT,
+  execution_detail TEXT,
+  playback_snapshot TEXT,
   created_at TEXT NOT NULL,
   updated_at TEXT NOT NULL,
   FOREIGN KEY (routine_id) REFERENCES routines(routine_id) ON DELETE CASCADE,
@@ -100,13 +119,16 @@ CREATE INDEX IF NOT EXISTS idx_jobs_routine_id ON jobs(routine_id);
 CREATE INDEX IF NOT EXISTS idx_jobs_scheduled_for ON jobs(scheduled_for);
 CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs(status);
 CREATE INDEX IF NOT EXISTS idx_jobs_scheduled_status ON jobs(scheduled_for, status);
+CREATE INDEX IF NOT EXISTS idx_jobs_scheduled_job ON jobs(scheduled_for, job_id);
 CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_routine_scheduled ON jobs(routine_id, scheduled_for);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_idempotency ON jobs(idempotency_key) WHERE idempotency_key IS NOT NULL;
 -- Note: idx_jobs_idempotency index is created in migrations after column is added
 
 CREATE TABLE IF NOT EXISTS holidays (
   date TEXT PRIMARY KEY,
   name TEXT NOT NULL,
-  is_custom INTEGER NOT NULL DEFAULT 0
+  is_custom INTEGER NOT NULL DEFAULT 0,
+  recurring INTEGER NOT NULL DEFAULT 0
 );
 
 CREATE TABLE IF NOT EXISTS routine_templates (
@@ -151,11 +173,14 @@ CREATE TABLE IF NOT EXISTS music_sets (
   occasion_start TEXT,
   occasion_end TEXT,
   artwork_url TEXT,
+  sort_order INTEGER,
   deleted_at TEXT,
   created_at TEXT NOT NULL,
   updated_at TEXT NOT NULL
 );
 
+CREATE INDEX IF NOT EXISTS idx_music_sets_deleted_at ON music_sets(deleted_at) WHERE deleted_at IS NOT NULL;
+
 CREATE TABLE IF NOT EXISTS set_items (
   set_id TEXT NOT NULL,
   sonos_favorite_id TEXT NOT NULL,
@@ -186,6 +211,43 @@ CREATE TABLE IF NOT EXISTS play_history (
 CREATE INDEX IF NOT EXISTS idx_play_history_favorite ON play_history(sonos_favorite_id, played_at);
 CREATE INDEX IF NOT EXISTS idx_play_history_set ON play_history(set_id, played_at);
 
+-- ==========================================================================
+-- NOW PLAYING HISTORY (sampled listening log)
+-- ==========================================================================
+
+CREATE TABLE IF NOT EXISTS now_playing_history (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  coordinator_uuid TEXT NOT NULL,
+  room_name TEXT NOT NULL,
+  title TEXT NOT NULL,
+  artist TEXT,
+  album TEXT,
+  album_art_uri TEXT,
+  track_uri TEXT,
+  service_name TEXT,
+  source TEXT,
+  played_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_now_playing_history_room ON now_playing_history(room_name, played_at);
+CREATE INDEX IF NOT EXISTS idx_now_playing_history_coordinator ON now_playing_history(coordinator_uuid, played_at);
+
+-- ==========================================================================
+-- FAVORITE ARTWORK (local copies of Sonos favorite art, served from /v1/assets/artwork)
+-- ==========================================================================
+
+CREATE TABLE IF NOT EXISTS favorite_artwork (
+  artwork_hash TEXT PRIMARY KEY,
+  favorite_id TEXT NOT NULL,
+  source_url TEXT NOT NULL,
+  content_type TEXT NOT NULL,
+  size_bytes INTEGER NOT NULL,
+  fetched_at TEXT NOT NULL,
+  checked_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_favorite_artwork_checked ON favorite_artwork(checked_at);
+
 -- ==========================================================================
 -- AUDIT LOG (from audit-log)
 -- ==========================================================================
@@ -243,4 +305,18 @@ CREATE TABLE IF NOT EXISTS sonos_cloud_tokens (
     created_at TEXT DEFAULT CURRENT_TIMESTAMP,
     updated_at TEXT DEFAULT CURRENT_TIMESTAMP
 );
-`
+
+-- ==========================================================================
+-- IDEMPOTENCY KEYS (replayed responses for retried POST requests)
+-- ==========================================================================
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  idempotency_key TEXT PRIMARY KEY,
+  request_hash TEXT NOT NULL,
+  status_code INTEGER,
+  content_type TEXT,
+  response_body BLOB,
+  created_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys(created_at);
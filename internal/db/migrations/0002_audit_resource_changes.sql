@@ -0,0 +1,8 @@
+-- Resource changes made through the API (routines, scenes, music sets) record who made
+-- them, what was done, and to which resource, so one resource's history can be queried.
+ALTER TABLE audit_events ADD COLUMN actor TEXT;
+ALTER TABLE audit_events ADD COLUMN action TEXT;
+ALTER TABLE audit_events ADD COLUMN resource_type TEXT;
+ALTER TABLE audit_events ADD COLUMN resource_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_resource ON audit_events(resource_type, resource_id) WHERE resource_id IS NOT NULL;
@@ -0,0 +1,11 @@
+-- API keys for clients that can't pair, such as scripts behind a reverse proxy. Only a
+-- SHA-256 hash of each key is stored; the plaintext is shown once, when it's created.
+CREATE TABLE IF NOT EXISTS api_keys (
+  key_id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  key_hash TEXT NOT NULL UNIQUE,
+  key_prefix TEXT NOT NULL,
+  scopes TEXT NOT NULL DEFAULT '[]',
+  created_at TEXT NOT NULL,
+  last_used_at TEXT
+);
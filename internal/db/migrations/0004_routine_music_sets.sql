@@ -0,0 +1,15 @@
+-- The music sets a ROTATION/SHUFFLE routine picks from, each with a relative weight.
+-- routines.music_set_id keeps the first set, for clients that only know about one.
+CREATE TABLE IF NOT EXISTS routine_music_sets (
+  routine_id TEXT NOT NULL,
+  set_id TEXT NOT NULL,
+  weight INTEGER NOT NULL DEFAULT 1 CHECK (weight > 0),
+  position INTEGER NOT NULL,
+  PRIMARY KEY (routine_id, set_id),
+  FOREIGN KEY (routine_id) REFERENCES routines(routine_id) ON DELETE CASCADE
+);
+
+-- Existing routines pick from their one set
+INSERT OR IGNORE INTO routine_music_sets (routine_id, set_id, weight, position)
+SELECT routine_id, music_set_id, 1, 0 FROM routines
+WHERE music_set_id IS NOT NULL AND music_set_id != '';
@@ -0,0 +1,6 @@
+-- Whose plays a routine's no-repeat window counts: its sets' ("set"), its own
+-- ("routine") or every routine's ("global"). Empty means global.
+ALTER TABLE routines ADD COLUMN music_no_repeat_scope TEXT NOT NULL DEFAULT '';
+
+-- Global no-repeat lookups scan plays by time
+CREATE INDEX IF NOT EXISTS idx_play_history_played_at ON play_history(played_at, sonos_favorite_id);
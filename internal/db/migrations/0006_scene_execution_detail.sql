@@ -0,0 +1,3 @@
+-- Per-member results of a scene execution (group, volume, transport and play
+-- commands on each speaker) and the content it started, as JSON.
+ALTER TABLE scene_executions ADD COLUMN detail TEXT;
@@ -0,0 +1,4 @@
+-- What a music_policy_type NONE routine does to its speakers instead of playing music:
+-- a JSON array of {"type": "set_volume" | "stop" | "pause", "volume": 0-100}. NULL means
+-- the routine has no actions.
+ALTER TABLE routines ADD COLUMN actions_json TEXT;
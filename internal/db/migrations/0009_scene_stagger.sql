@@ -0,0 +1,3 @@
+-- Milliseconds between members starting playback in an independent scene, so large
+-- scenes don't all hit the network at once. 0 starts them back to back.
+ALTER TABLE scenes ADD COLUMN stagger_ms INTEGER NOT NULL DEFAULT 0;
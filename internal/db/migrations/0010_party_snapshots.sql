@@ -0,0 +1,11 @@
+-- Grouping and playback captured before party mode joined every speaker into one group,
+-- so POST /v1/sonos/groups/restore/{snapshot_id} can put them back after a restart.
+CREATE TABLE IF NOT EXISTS party_snapshots (
+  snapshot_id TEXT PRIMARY KEY,
+  coordinator_udn TEXT NOT NULL,
+  groups TEXT NOT NULL DEFAULT '[]',
+  created_at TEXT NOT NULL,
+  expires_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_party_snapshots_expires ON party_snapshots(expires_at);
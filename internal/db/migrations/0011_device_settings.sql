@@ -0,0 +1,7 @@
+-- Hub-side settings for a speaker, keyed by UDN so they survive rediscovery and IP
+-- changes. alias is shown instead of the Sonos room name; empty means none.
+CREATE TABLE IF NOT EXISTS device_settings (
+  udn TEXT PRIMARY KEY,
+  alias TEXT NOT NULL DEFAULT '',
+  updated_at TEXT NOT NULL
+);
@@ -0,0 +1,7 @@
+-- A routine's gentle wake: {"start_volume", "end_volume", "ramp_minutes"}. Playback starts
+-- at start_volume and is ramped up to end_volume after it starts. NULL means none.
+ALTER TABLE routines ADD COLUMN wake_profile_json TEXT;
+
+-- The wake ramp a completed job is still running, as JSON, so it resumes after a hub
+-- restart. Cleared once the ramp finishes or is aborted.
+ALTER TABLE jobs ADD COLUMN wake_ramp TEXT;
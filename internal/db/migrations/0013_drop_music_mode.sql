@@ -0,0 +1,26 @@
+-- music_mode was written once at creation and never read or updated, while
+-- music_policy_type is what routines actually play by. Before dropping it, reconcile
+-- the rows where the two disagree.
+
+-- Rows from before music_policy_type existed took its FIXED default while music_mode
+-- held the real mode. They have a set to play and nothing FIXED could play.
+UPDATE routines SET music_policy_type = music_mode
+WHERE music_policy_type = 'FIXED'
+  AND music_mode IN ('ROTATION', 'SHUFFLE')
+  AND music_set_id IS NOT NULL
+  AND music_sonos_favorite_id IS NULL
+  AND music_content_json IS NULL;
+
+-- The favorite and direct content only apply to FIXED. Routines that moved off FIXED
+-- kept them, and would have shown them again if switched back.
+UPDATE routines SET
+  music_sonos_favorite_id = NULL,
+  music_sonos_favorite_name = NULL,
+  music_sonos_favorite_artwork_url = NULL,
+  music_sonos_favorite_service_logo_url = NULL,
+  music_sonos_favorite_service_name = NULL,
+  music_content_type = NULL,
+  music_content_json = NULL
+WHERE music_policy_type != 'FIXED';
+
+ALTER TABLE routines DROP COLUMN music_mode;
@@ -0,0 +1,3 @@
+-- Whether a job was triggered by hand (routine trigger or run, or an execution retry) rather
+-- than generated from the routine's schedule. Quiet hours only skip scheduled jobs.
+ALTER TABLE jobs ADD COLUMN manual INTEGER NOT NULL DEFAULT 0;
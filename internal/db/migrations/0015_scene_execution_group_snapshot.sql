@@ -0,0 +1,3 @@
+-- The groups a grouped_then_restore execution's members were in before it grouped them,
+-- as JSON, so they can be put back after a restart. Cleared once they're restored.
+ALTER TABLE scene_executions ADD COLUMN group_snapshot TEXT;
@@ -0,0 +1,3 @@
+-- Why a failed job failed, e.g. devices_offline, so the API doesn't have to match on
+-- last_error. NULL for failures without a specific reason.
+ALTER TABLE jobs ADD COLUMN failure_reason TEXT;
@@ -0,0 +1,22 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// WithTx runs fn in a transaction on writer, committing if fn returns nil and rolling
+// back otherwise. The writer has a single connection, so fn must only write through tx;
+// writing through writer itself would wait forever for the connection fn holds.
+func WithTx(writer *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := writer.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() // No-op if committed
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
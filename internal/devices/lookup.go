@@ -1,13 +1,13 @@
 package devices
 
 import (
-	"log"
+	"log/slog"
 	"strings"
 )
 
-// findDevice looks up a device by UDN or room name.
-// The lookup order is: UDN → room_name (case-insensitive fallback)
-func findDevice(devices []LogicalDevice, logger *log.Logger, identifier string) *LogicalDevice {
+// findDevice looks up a device by UDN, room name or alias.
+// The lookup order is: UDN → room_name or alias (case-insensitive fallback)
+func findDevice(devices []LogicalDevice, logger *slog.Logger, identifier string) *LogicalDevice {
 	// First, try to find by UDN (primary identifier)
 	for _, device := range devices {
 		if device.UDN == identifier {
@@ -18,8 +18,8 @@ func findDevice(devices []LogicalDevice, logger *log.Logger, identifier string)
 
 	// Fallback: try room name (case-insensitive)
 	for _, device := range devices {
-		if strings.EqualFold(device.RoomName, identifier) {
-			logger.Printf("Device found by room name fallback: requested=%s found=%s", identifier, device.UDN)
+		if strings.EqualFold(device.RoomName, identifier) || (device.Alias != "" && strings.EqualFold(device.Alias, identifier)) {
+			logger.Info("Device found by room name fallback", "requested", identifier, "udn", device.UDN)
 			copy := device
 			return &copy
 		}
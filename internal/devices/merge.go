@@ -1,6 +1,6 @@
 package devices
 
-import "log"
+import "log/slog"
 
 func mergeTopologies(newTopo DeviceTopology, existing *DeviceTopology) DeviceTopology {
 	if existing == nil {
@@ -58,7 +58,7 @@ func mergeTopologies(newTopo DeviceTopology, existing *DeviceTopology) DeviceTop
 		health := computeHealth(missed)
 
 		if missed >= RemovalThreshold {
-			log.Printf("Removing device after missed scans: %s (%s)", device.UDN, device.RoomName)
+			slog.Info("Removing device after missed scans", "udn", device.UDN, "room", device.RoomName)
 			continue
 		}
 
@@ -76,6 +76,25 @@ func mergeTopologies(newTopo DeviceTopology, existing *DeviceTopology) DeviceTop
 	}
 }
 
+// wentOffline returns the devices merged marks offline that weren't offline in existing.
+func wentOffline(existing *DeviceTopology, merged DeviceTopology) []LogicalDevice {
+	if existing == nil {
+		return nil
+	}
+	wasOffline := make(map[string]bool, len(existing.Devices))
+	for _, device := range existing.Devices {
+		wasOffline[primaryUDN(device)] = device.Health == DeviceHealthOffline
+	}
+
+	var offline []LogicalDevice
+	for _, device := range merged.Devices {
+		if device.Health == DeviceHealthOffline && !wasOffline[primaryUDN(device)] {
+			offline = append(offline, device)
+		}
+	}
+	return offline
+}
+
 func computeHealth(missedScans int) DeviceHealthStatus {
 	if missedScans >= OfflineThreshold {
 		return DeviceHealthOffline
@@ -1,7 +1,7 @@
 package devices
 
 import (
-	"log"
+	"log/slog"
 	"regexp"
 	"strings"
 	"time"
@@ -66,6 +66,8 @@ func NormalizeDevices(raw []RawSonosDevice, topology *ZoneGroupTopology) DeviceT
 		}
 	}
 
+	bondedTo := identifyBondedSatellites(topology)
+
 	fallbackPairs := identifyStereoPairsByRoomName(physicalDevices, processedUDNs)
 	for _, pair := range fallbackPairs {
 		stereoPairs = append(stereoPairs, pair)
@@ -139,7 +141,8 @@ func NormalizeDevices(raw []RawSonosDevice, topology *ZoneGroupTopology) DeviceT
 		}
 
 		isStereoMember := stereoSuffixRegex.MatchString(device.RoomName)
-		isTargetable := !isStereoMember && device.Role == DeviceRoleNormal && device.IsCoordinatorCapable
+		primaryUDN, isBonded := bondedTo[normalizeUDN(device.UDN)]
+		isTargetable := !isStereoMember && !isBonded && device.Role == DeviceRoleNormal && device.IsCoordinatorCapable
 
 		logicalDevices = append(logicalDevices, LogicalDevice{
 			UDN:                  device.UDN,
@@ -150,6 +153,8 @@ func NormalizeDevices(raw []RawSonosDevice, topology *ZoneGroupTopology) DeviceT
 			IsTargetable:         isTargetable,
 			IsCoordinatorCapable: device.IsCoordinatorCapable,
 			SupportsAirPlay:      device.SupportsAirPlay,
+			IsBonded:             isBonded,
+			BondedTo:             primaryUDN,
 			LogicalGroupID:       "",
 			PhysicalDevices:      []PhysicalDevice{device},
 			LastSeenAt:           device.LastSeenAt,
@@ -175,6 +180,39 @@ func GetTargetableDevices(topology DeviceTopology) []LogicalDevice {
 	return devices
 }
 
+// identifyBondedSatellites maps each bonded satellite in topology to the UDN of its
+// primary: the visible member of its group in the same room, or else the group's
+// coordinator. Satellites are usually folded into a home theater or stereo pair; this
+// catches the ones that aren't, such as surrounds listed as invisible members.
+func identifyBondedSatellites(topology *ZoneGroupTopology) map[string]string {
+	bondedTo := make(map[string]string)
+	if topology == nil {
+		return bondedTo
+	}
+	isBonded := func(member ZoneMember) bool {
+		return member.Invisible || member.IsSatellite || member.IsSubwoofer
+	}
+
+	for _, group := range topology.Groups {
+		for _, member := range group.Members {
+			if !isBonded(member) {
+				continue
+			}
+			primary := group.CoordinatorUDN
+			for _, candidate := range group.Members {
+				if !isBonded(candidate) && candidate.ZoneName == member.ZoneName {
+					primary = candidate.UDN
+					break
+				}
+			}
+			if primary != "" && primary != member.UDN {
+				bondedTo[member.UDN] = primary
+			}
+		}
+	}
+	return bondedTo
+}
+
 func cleanRoomName(roomName string) string {
 	return strings.TrimSpace(roomSuffixRegex.ReplaceAllString(roomName, ""))
 }
@@ -277,12 +315,12 @@ func identifyHomeTheaterGroup(members []ZoneMember, devicesByUDN map[string]Phys
 }
 
 func identifyStereoPair(members []ZoneMember, coordinatorUDN string, devicesByUDN map[string]PhysicalDevice) *StereoPair {
-	log.Printf("[STEREO-DIAG] Checking zone: %d members, coordinatorUDN=%s", len(members), coordinatorUDN)
+	slog.Debug("[STEREO-DIAG] Checking zone", "members", len(members), "coordinator_udn", coordinatorUDN)
 
 	// Log all members and their channel maps
 	for i, member := range members {
-		log.Printf("[STEREO-DIAG]   member[%d]: UDN=%s, ZoneName=%s, ChannelMapSet=%q",
-			i, member.UDN, member.ZoneName, member.ChannelMapSet)
+		slog.Debug("[STEREO-DIAG] Member",
+			"index", i, "udn", member.UDN, "zone_name", member.ZoneName, "channel_map_set", member.ChannelMapSet)
 	}
 
 	// Log devicesByUDN keys for comparison
@@ -290,10 +328,10 @@ func identifyStereoPair(members []ZoneMember, coordinatorUDN string, devicesByUD
 	for k := range devicesByUDN {
 		keys = append(keys, k)
 	}
-	log.Printf("[STEREO-DIAG]   devicesByUDN keys: %v", keys)
+	slog.Debug("[STEREO-DIAG] devicesByUDN keys", "keys", keys)
 
 	if len(members) != 2 {
-		log.Printf("[STEREO-DIAG]   FAIL: len(members)=%d, expected 2", len(members))
+		slog.Debug("[STEREO-DIAG] Not a pair: expected 2 members", "members", len(members))
 		return nil
 	}
 
@@ -308,10 +346,10 @@ func identifyStereoPair(members []ZoneMember, coordinatorUDN string, devicesByUD
 		}
 	}
 
-	log.Printf("[STEREO-DIAG]   hasStereoPattern=%v, channelSets=%v", hasStereoPattern, channelSets)
+	slog.Debug("[STEREO-DIAG] Channel maps", "has_stereo_pattern", hasStereoPattern, "channel_sets", channelSets)
 
 	if !hasStereoPattern {
-		log.Printf("[STEREO-DIAG]   FAIL: no stereo pattern (LF,LF or RF,RF) found in ChannelMapSet")
+		slog.Debug("[STEREO-DIAG] Not a pair: no stereo pattern (LF,LF or RF,RF) found in ChannelMapSet")
 		return nil
 	}
 
@@ -335,23 +373,23 @@ func identifyStereoPair(members []ZoneMember, coordinatorUDN string, devicesByUD
 		}
 	}
 
-	log.Printf("[STEREO-DIAG]   Parsed: leftUDN=%s, rightUDN=%s", leftUDN, rightUDN)
+	slog.Debug("[STEREO-DIAG] Parsed channel map", "left_udn", leftUDN, "right_udn", rightUDN)
 
 	if leftUDN == "" || rightUDN == "" {
-		log.Printf("[STEREO-DIAG]   FAIL: could not parse left/right UDNs from ChannelMapSet")
+		slog.Debug("[STEREO-DIAG] Not a pair: could not parse left/right UDNs from ChannelMapSet")
 		return nil
 	}
 
 	left, okLeft := devicesByUDN[leftUDN]
 	right, okRight := devicesByUDN[rightUDN]
 
-	log.Printf("[STEREO-DIAG]   Direct lookup: leftFound=%v, rightFound=%v", okLeft, okRight)
+	slog.Debug("[STEREO-DIAG] Direct lookup", "left_found", okLeft, "right_found", okRight)
 
 	// If direct lookup failed, try with normalized UDNs (strip uuid: prefix if present)
 	if !okLeft || !okRight {
 		normalizedLeft := strings.TrimPrefix(leftUDN, "uuid:")
 		normalizedRight := strings.TrimPrefix(rightUDN, "uuid:")
-		log.Printf("[STEREO-DIAG]   Trying normalized: leftUDN=%s, rightUDN=%s", normalizedLeft, normalizedRight)
+		slog.Debug("[STEREO-DIAG] Trying normalized UDNs", "left_udn", normalizedLeft, "right_udn", normalizedRight)
 
 		if !okLeft {
 			left, okLeft = devicesByUDN[normalizedLeft]
@@ -359,11 +397,11 @@ func identifyStereoPair(members []ZoneMember, coordinatorUDN string, devicesByUD
 		if !okRight {
 			right, okRight = devicesByUDN[normalizedRight]
 		}
-		log.Printf("[STEREO-DIAG]   After normalization: leftFound=%v, rightFound=%v", okLeft, okRight)
+		slog.Debug("[STEREO-DIAG] Normalized lookup", "left_found", okLeft, "right_found", okRight)
 	}
 
 	if !okLeft || !okRight {
-		log.Printf("[STEREO-DIAG]   FAIL: devices not found in devicesByUDN map")
+		slog.Debug("[STEREO-DIAG] Not a pair: devices not found in devicesByUDN map")
 		return nil
 	}
 
@@ -376,8 +414,8 @@ func identifyStereoPair(members []ZoneMember, coordinatorUDN string, devicesByUD
 	namespace := uuid.MustParse(sonosNamespace)
 	pairID := uuid.NewSHA1(namespace, []byte("pair-"+left.UDN)).String()
 
-	log.Printf("[STEREO-DIAG]   SUCCESS: Stereo pair identified - Room=%s, Coordinator=%s",
-		cleanRoomName(left.RoomName), coordinator.RoomName)
+	slog.Debug("[STEREO-DIAG] Stereo pair identified",
+		"room", cleanRoomName(left.RoomName), "coordinator", coordinator.RoomName)
 
 	return &StereoPair{
 		PairID:      pairID,
@@ -312,3 +312,41 @@ func TestNormalizeUDN(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizeDevices_BondedSatellites(t *testing.T) {
+	raw := []RawSonosDevice{
+		{UDN: "RINCON_BEAM", RoomName: "Living Room", ModelNumber: "S14"},
+		{UDN: "RINCON_SURROUND", RoomName: "Living Room", ModelNumber: "S40"},
+		{UDN: "RINCON_KITCHEN", RoomName: "Kitchen", ModelNumber: "S40"},
+	}
+	// The surround is listed as an invisible member rather than a satellite, so it
+	// isn't folded into a home theater group
+	topology := &ZoneGroupTopology{Groups: []ZoneGroup{
+		{CoordinatorUDN: "RINCON_KITCHEN", Members: []ZoneMember{
+			{UDN: "RINCON_KITCHEN", ZoneName: "Kitchen", IsCoordinator: true},
+			{UDN: "RINCON_BEAM", ZoneName: "Living Room"},
+			{UDN: "RINCON_SURROUND", ZoneName: "Living Room", Invisible: true},
+		}},
+	}}
+
+	normalized := NormalizeDevices(raw, topology)
+	require.Len(t, normalized.Devices, 3)
+	var surround LogicalDevice
+	for _, device := range normalized.Devices {
+		if device.UDN == "RINCON_SURROUND" {
+			surround = device
+		}
+	}
+	require.True(t, surround.IsBonded)
+	require.False(t, surround.IsTargetable)
+	require.Equal(t, "RINCON_BEAM", surround.BondedTo, "bonded to the speaker in its room, not the group's coordinator")
+
+	targetable := GetTargetableDevices(normalized)
+	require.Len(t, targetable, 2)
+
+	primary := normalized.BondedPrimary("RINCON_SURROUND")
+	require.NotNil(t, primary)
+	require.Equal(t, "RINCON_BEAM", primary.UDN)
+	require.Nil(t, normalized.BondedPrimary("RINCON_BEAM"))
+	require.Nil(t, normalized.BondedPrimary("RINCON_UNKNOWN"))
+}
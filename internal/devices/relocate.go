@@ -0,0 +1,177 @@
+package devices
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+const (
+	// relocateRetryInterval is how long after failing to find a device at a new address
+	// before trying again, so a speaker that's simply off doesn't cause a rescan per call.
+	relocateRetryInterval = 30 * time.Second
+	// relocatedTTL is how long calls to a device's old address are redirected to its new
+	// one, covering callers that resolved the address before it moved.
+	relocatedTTL = 10 * time.Minute
+)
+
+type relocation struct {
+	newIP string // Empty if the device wasn't found
+	at    time.Time
+}
+
+// RelocateDevice implements soap.Relocator. When staleIP belongs to a known device, it
+// reads the zone group state from the household's other speakers to find the device's
+// current address, falling back to a full rescan, and updates the topology. Returns
+// ok=false if staleIP isn't a known device, the device can't be found, or it hasn't moved.
+func (service *Service) RelocateDevice(staleIP string) (string, bool) {
+	if service.testMode || staleIP == "" {
+		return "", false
+	}
+
+	service.relocateMu.Lock()
+	defer service.relocateMu.Unlock()
+
+	now := time.Now()
+	for ip, previous := range service.relocations {
+		if now.Sub(previous.at) >= relocatedTTL {
+			delete(service.relocations, ip)
+		}
+	}
+	if previous, ok := service.relocations[staleIP]; ok {
+		if previous.newIP != "" && now.Sub(previous.at) < relocatedTTL {
+			return previous.newIP, true
+		}
+		if previous.newIP == "" && now.Sub(previous.at) < relocateRetryInterval {
+			return "", false
+		}
+	}
+
+	udn, others := service.deviceAtIP(staleIP)
+	if udn == "" {
+		return "", false
+	}
+	service.logger.Info("Device unreachable, looking for its new address", "udn", udn, "ip", staleIP)
+
+	newIP := service.findDeviceInZoneState(udn, staleIP, others)
+	if newIP == "" {
+		service.logger.Info("Device not found in zone group state, triggering rescan", "udn", udn)
+		if _, err := service.performDiscovery(); err != nil {
+			service.logger.Warn("Rescan failed while relocating device", "udn", udn, "error", err)
+		} else if device, _ := service.GetDevice(udn); device != nil && device.IP != staleIP {
+			newIP = device.IP
+		}
+	}
+
+	service.relocations[staleIP] = relocation{newIP: newIP, at: now}
+	if newIP == "" || newIP == staleIP {
+		service.logger.Warn("Could not find device's new address", "udn", udn, "ip", staleIP)
+		return "", false
+	}
+
+	service.moveDevice(udn, staleIP, newIP)
+	service.logger.Info("Device moved to a new address", "udn", udn, "old_ip", staleIP, "new_ip", newIP)
+	return newIP, true
+}
+
+// deviceAtIP returns the UDN of the speaker at ip, and the addresses of the other
+// speakers, to ask where it went.
+func (service *Service) deviceAtIP(ip string) (string, []string) {
+	service.topologyMu.RLock()
+	defer service.topologyMu.RUnlock()
+	if service.topology == nil {
+		return "", nil
+	}
+
+	var udn string
+	var others []string
+	for _, device := range service.topology.Devices {
+		for _, physical := range device.PhysicalDevices {
+			if physical.IP == ip {
+				udn = physical.UDN
+			} else if physical.IP != "" {
+				others = append(others, physical.IP)
+			}
+		}
+		if device.IP == ip && udn == "" {
+			udn = device.UDN
+		} else if device.IP != ip && device.IP != "" {
+			others = append(others, device.IP)
+		}
+	}
+	return udn, dedupeStrings(others)
+}
+
+// findDeviceInZoneState asks each of ips for the household's zone group state and
+// returns the address it lists for udn, or "" if none answers with one other than staleIP.
+func (service *Service) findDeviceInZoneState(udn, staleIP string, ips []string) string {
+	for _, ip := range ips {
+		ctx, cancel := context.WithTimeout(soap.WithoutRelocation(context.Background()), time.Duration(service.cfg.SonosTimeoutMs)*time.Millisecond)
+		state, err := service.soapClient.GetZoneGroupState(ctx, ip)
+		cancel()
+		if err != nil {
+			service.logger.Debug("Zone group state unavailable while relocating device", "ip", ip, "error", err)
+			continue
+		}
+		for _, group := range state.Groups {
+			for _, member := range group.Members {
+				if strings.TrimPrefix(member.UUID, "uuid:") != udn {
+					continue
+				}
+				if addr := addressFromLocation(member.Location); addr != "" && addr != staleIP {
+					return addr
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// moveDevice updates the topology and known IPs for a device that moved from oldIP to newIP.
+func (service *Service) moveDevice(udn, oldIP, newIP string) {
+	service.topologyMu.Lock()
+	if service.topology != nil {
+		for i := range service.topology.Devices {
+			device := &service.topology.Devices[i]
+			if device.IP == oldIP {
+				device.IP = newIP
+			}
+			for j := range device.PhysicalDevices {
+				if device.PhysicalDevices[j].IP == oldIP {
+					device.PhysicalDevices[j].IP = newIP
+				}
+			}
+		}
+	}
+	var devices []LogicalDevice
+	if service.topology != nil {
+		devices = append(devices, service.topology.Devices...)
+	}
+	service.topologyMu.Unlock()
+
+	service.knownIPsMu.Lock()
+	delete(service.knownIPs, oldIP)
+	service.knownIPs[newIP] = time.Now()
+	service.knownIPsMu.Unlock()
+
+	// Event subscriptions follow the device to its new address
+	service.notifyDiscoveryCallback(devices)
+}
+
+// addressFromLocation returns the address in a zone member's device description URL,
+// e.g. "http://192.168.1.10:1400/xml/device_description.xml" gives "192.168.1.10". The
+// port is kept if it isn't Sonos's usual 1400.
+func addressFromLocation(location string) string {
+	parsed, err := url.Parse(location)
+	if err != nil || parsed.Hostname() == "" {
+		return ""
+	}
+	if port := parsed.Port(); port != "" && port != "1400" {
+		return net.JoinHostPort(parsed.Hostname(), port)
+	}
+	return parsed.Hostname()
+}
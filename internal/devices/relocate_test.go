@@ -0,0 +1,126 @@
+package devices
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/config"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// fakeSpeaker is a SOAP server standing in for a Sonos speaker.
+type fakeSpeaker struct {
+	server    *httptest.Server
+	zoneState string // GetZoneGroupState's inner XML
+
+	mu      sync.Mutex
+	actions []string
+}
+
+func newFakeSpeaker(t *testing.T) *fakeSpeaker {
+	t.Helper()
+	speaker := &fakeSpeaker{}
+	speaker.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, action, _ := strings.Cut(strings.Trim(r.Header.Get("SOAPACTION"), `"`), "#")
+		speaker.mu.Lock()
+		speaker.actions = append(speaker.actions, action)
+		speaker.mu.Unlock()
+
+		result := ""
+		if action == "GetZoneGroupState" {
+			result = "<ZoneGroupState>" + html.EscapeString(speaker.zoneState) + "</ZoneGroupState>"
+		}
+		fmt.Fprintf(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><u:%sResponse>%s</u:%sResponse></s:Body></s:Envelope>`, action, result, action)
+	}))
+	t.Cleanup(speaker.server.Close)
+	return speaker
+}
+
+func (s *fakeSpeaker) addr() string {
+	return strings.TrimPrefix(s.server.URL, "http://")
+}
+
+func (s *fakeSpeaker) calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.actions...)
+}
+
+func zoneMember(udn, name, addr string) string {
+	return fmt.Sprintf(`<ZoneGroupMember UUID="%s" ZoneName="%s" Location="http://%s/xml/device_description.xml"/>`, udn, name, addr)
+}
+
+func TestRelocateDevice_AfterIPChange(t *testing.T) {
+	// The kitchen speaker's old address refuses connections; it now answers on another port
+	oldKitchen := newFakeSpeaker(t)
+	staleAddr := oldKitchen.addr()
+	oldKitchen.server.Close()
+	kitchen := newFakeSpeaker(t)
+	living := newFakeSpeaker(t)
+	living.zoneState = `<ZoneGroupState><ZoneGroups>` +
+		`<ZoneGroup Coordinator="RINCON_KITCHEN" ID="RINCON_KITCHEN:1">` + zoneMember("RINCON_KITCHEN", "Kitchen", kitchen.addr()) + `</ZoneGroup>` +
+		`<ZoneGroup Coordinator="RINCON_LIVING" ID="RINCON_LIVING:1">` + zoneMember("RINCON_LIVING", "Living Room", living.addr()) + `</ZoneGroup>` +
+		`</ZoneGroups></ZoneGroupState>`
+
+	client := soap.NewClient(time.Second)
+	service := NewService(config.Config{SonosTimeoutMs: 1000}, logging.Discard(), client)
+	client.SetRelocator(service)
+	service.topology = &DeviceTopology{Devices: []LogicalDevice{
+		{UDN: "RINCON_KITCHEN", RoomName: "Kitchen", IP: staleAddr, PhysicalDevices: []PhysicalDevice{{UDN: "RINCON_KITCHEN", IP: staleAddr}}},
+		{UDN: "RINCON_LIVING", RoomName: "Living Room", IP: living.addr(), PhysicalDevices: []PhysicalDevice{{UDN: "RINCON_LIVING", IP: living.addr()}}},
+	}}
+	service.knownIPs[staleAddr] = time.Now()
+
+	var notified []DeviceInfo
+	service.SetDiscoveryCallback(func(devices []DeviceInfo) { notified = devices })
+
+	// The call to the old address is retried at the new one
+	require.NoError(t, client.Pause(context.Background(), staleAddr))
+	require.Equal(t, []string{"Pause"}, kitchen.calls())
+	require.Equal(t, []string{"GetZoneGroupState"}, living.calls())
+
+	// The registry has the new address
+	ip, err := service.ResolveDeviceIP("RINCON_KITCHEN")
+	require.NoError(t, err)
+	require.Equal(t, kitchen.addr(), ip)
+	require.True(t, service.IsKnownDeviceIP(kitchen.addr()))
+	require.False(t, service.IsKnownDeviceIP(staleAddr))
+	require.Contains(t, notified, DeviceInfo{IP: kitchen.addr(), UDN: "RINCON_KITCHEN"})
+
+	// Callers still holding the old address are redirected without asking again
+	require.NoError(t, client.Pause(context.Background(), staleAddr))
+	require.Equal(t, []string{"Pause", "Pause"}, kitchen.calls())
+	require.Len(t, living.calls(), 1)
+}
+
+func TestRelocateDevice_UnknownAddress(t *testing.T) {
+	gone := newFakeSpeaker(t)
+	addr := gone.addr()
+	gone.server.Close()
+
+	client := soap.NewClient(time.Second)
+	service := NewService(config.Config{SonosTimeoutMs: 1000}, logging.Discard(), client)
+	client.SetRelocator(service)
+	service.topology = &DeviceTopology{Devices: []LogicalDevice{}}
+
+	// Addresses that aren't a known speaker's fail as before
+	var unreachable *soap.SonosUnreachableError
+	require.ErrorAs(t, client.Pause(context.Background(), addr), &unreachable)
+	require.Empty(t, service.relocations)
+}
+
+func TestAddressFromLocation(t *testing.T) {
+	require.Equal(t, "192.168.1.10", addressFromLocation("http://192.168.1.10:1400/xml/device_description.xml"))
+	require.Equal(t, "127.0.0.1:5000", addressFromLocation("http://127.0.0.1:5000/xml/device_description.xml"))
+	require.Equal(t, "", addressFromLocation(""))
+}
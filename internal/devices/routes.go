@@ -1,7 +1,11 @@
 package devices
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/go-chi/chi/v5"
 
@@ -12,7 +16,16 @@ import (
 // RegisterRoutes wires device routes to the router.
 func RegisterRoutes(router chi.Router, service *Service) {
 	router.Method(http.MethodGet, "/v1/devices", api.Handler(func(w http.ResponseWriter, r *http.Request) error {
-		devices, err := service.GetDevices()
+		// Bonded satellites and other devices that can't be targeted are hidden by default
+		var devices []LogicalDevice
+		var err error
+		if r.URL.Query().Get("include_hidden") == "true" {
+			var topology DeviceTopology
+			topology, err = service.GetTopology()
+			devices = topology.Devices
+		} else {
+			devices, err = service.GetDevices()
+		}
 		if err != nil {
 			return apperrors.NewInternalError("Failed to load devices")
 		}
@@ -42,6 +55,41 @@ func RegisterRoutes(router chi.Router, service *Service) {
 		return api.WriteResource(w, http.StatusOK, formatDevice(*device))
 	}))
 
+	router.Method(http.MethodPatch, "/v1/devices/{udn}", api.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		udn := chi.URLParam(r, "udn")
+
+		var body struct {
+			Alias *string `json:"alias"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return apperrors.NewValidationError("invalid request body", nil)
+		}
+		// A null or empty alias clears it
+		alias := ""
+		if body.Alias != nil {
+			alias = strings.TrimSpace(*body.Alias)
+		}
+		if utf8.RuneCountInString(alias) > MaxAliasLength {
+			return apperrors.NewValidationError("alias must be at most 64 characters", nil)
+		}
+
+		device, err := service.SetAlias(udn, alias)
+		if errors.Is(err, ErrSettingsUnavailable) {
+			return apperrors.NewAppError("SERVICE_UNAVAILABLE", "Device settings not available", 503, nil, nil)
+		}
+		if errors.Is(err, ErrAliasTaken) {
+			return apperrors.NewConflictError("alias is already another speaker's alias or room name", map[string]any{"alias": alias})
+		}
+		if err != nil {
+			return apperrors.NewInternalError("Failed to update device")
+		}
+		if device == nil {
+			return apperrors.NewNotFoundResource("Device", udn)
+		}
+
+		return api.WriteResource(w, http.StatusOK, formatDevice(*device))
+	}))
+
 	router.Method(http.MethodPost, "/v1/devices/rescan", api.Handler(func(w http.ResponseWriter, r *http.Request) error {
 		count, durationMs, err := service.Rescan()
 		if err != nil {
@@ -115,19 +163,26 @@ func RegisterRoutes(router chi.Router, service *Service) {
 				"object":         "device_stats",
 				"total":          0,
 				"online":         0,
+				"degraded":       0,
 				"offline":        0,
 				"last_discovery": nil,
+				"soap":           formatSOAPStats(service),
 			})
 		}
 
+		// Degraded devices missed a scan or two but still count as online
 		online := 0
+		degraded := 0
 		offline := 0
 		for _, device := range topology.Devices {
 			switch device.Health {
-			case DeviceHealthOK:
-				online++
-			case DeviceHealthOffline, DeviceHealthDegraded:
+			case DeviceHealthOffline:
 				offline++
+			case DeviceHealthDegraded:
+				degraded++
+				online++
+			default:
+				online++
 			}
 		}
 
@@ -136,12 +191,45 @@ func RegisterRoutes(router chi.Router, service *Service) {
 			"object":         "device_stats",
 			"total":          len(topology.Devices),
 			"online":         online,
+			"degraded":       degraded,
 			"offline":        offline,
 			"last_discovery": api.RFC3339Millis(topology.UpdatedAt),
+			"soap":           formatSOAPStats(service),
 		})
 	}))
 }
 
+// formatSOAPStats formats SOAP concurrency and circuit breaker state for debugging, or
+// nil when the hub doesn't report it.
+func formatSOAPStats(service *Service) any {
+	stats, ok := service.SOAPStats()
+	if !ok {
+		return nil
+	}
+
+	breakers := make([]map[string]any, 0, len(stats.Breakers))
+	for _, breaker := range stats.Breakers {
+		var openUntil any
+		if breaker.OpenUntil != nil {
+			openUntil = api.RFC3339Millis(*breaker.OpenUntil)
+		}
+		breakers = append(breakers, map[string]any{
+			"ip":                   breaker.IP,
+			"state":                string(breaker.State),
+			"consecutive_failures": breaker.ConsecutiveFailures,
+			"in_flight":            breaker.InFlight,
+			"open_until":           openUntil,
+		})
+	}
+
+	return map[string]any{
+		"in_flight":                 stats.InFlight,
+		"max_concurrent":            stats.MaxConcurrent,
+		"max_concurrent_per_device": stats.MaxConcurrentPerDevice,
+		"breakers":                  breakers,
+	}
+}
+
 func formatDevice(device LogicalDevice) map[string]any {
 	logicalGroup := any(nil)
 	if device.LogicalGroupID != "" {
@@ -156,21 +244,33 @@ func formatDevice(device LogicalDevice) map[string]any {
 	return map[string]any{
 		"object":                 api.ObjectDevice,
 		"udn":                    device.UDN, // Primary identifier
-		"room_name":              device.RoomName,
+		"room_name":              device.DisplayName(),
+		"sonos_room_name":        device.RoomName,
+		"alias":                  emptyToNil(device.Alias),
 		"ip":                     device.IP,
 		"model":                  device.Model,
 		"role":                   device.Role,
 		"is_targetable":          device.IsTargetable,
 		"is_coordinator_capable": device.IsCoordinatorCapable,
 		"supports_airplay":       device.SupportsAirPlay,
+		"is_bonded":              device.IsBonded,
+		"bonded_to_udn":          emptyToNil(device.BondedTo),
 		"logical_group_id":       logicalGroup,
 		"last_seen_at":           api.RFC3339Millis(device.LastSeenAt),
 		"physical_device_count":  physicalCount,
 		"health":                 device.Health,
+		"online":                 device.Health != DeviceHealthOffline,
 		"missed_scans":           device.MissedScans,
 	}
 }
 
+func emptyToNil(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
 func formatPhysicalDevice(device PhysicalDevice) map[string]any {
 	return map[string]any{
 		"object":                 api.ObjectPhysicalDevice,
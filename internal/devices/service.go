@@ -3,7 +3,7 @@ package devices
 import (
 	"context"
 	"errors"
-	"log"
+	"log/slog"
 	"strings"
 	"sync"
 	"time"
@@ -27,6 +27,10 @@ type discoveryResult struct {
 // The callback receives a list of device IP addresses and UDNs.
 type DeviceDiscoveryCallback func(devices []DeviceInfo)
 
+// DeviceOfflineCallback is called when a device misses enough discovery scans to be
+// considered offline.
+type DeviceOfflineCallback func(device LogicalDevice)
+
 // DeviceInfo contains basic device identification for callbacks.
 type DeviceInfo struct {
 	IP  string
@@ -35,7 +39,7 @@ type DeviceInfo struct {
 
 type Service struct {
 	cfg                config.Config
-	logger             *log.Logger
+	logger             *slog.Logger
 	soapClient         *soap.Client
 	topologyMu         sync.RWMutex
 	topology           *DeviceTopology
@@ -45,6 +49,10 @@ type Service struct {
 	knownIPsMu sync.Mutex
 	knownIPs   map[string]time.Time
 
+	// Where unreachable devices were found again, by old IP (see RelocateDevice)
+	relocateMu  sync.Mutex
+	relocations map[string]relocation
+
 	discoveryMu       sync.Mutex
 	discoveryInFlight bool
 	discoveryWaiters  []chan discoveryResult
@@ -55,17 +63,31 @@ type Service struct {
 	// Callback for device discovery events (e.g., for UPnP event subscriptions)
 	discoveryCallbackMu sync.RWMutex
 	discoveryCallback   DeviceDiscoveryCallback
+
+	// Callback for devices going offline (e.g., for webhooks)
+	offlineCallbackMu sync.RWMutex
+	offlineCallback   DeviceOfflineCallback
+
+	// SOAP concurrency and circuit breakers, reported by /v1/devices/stats
+	soapStatsMu       sync.RWMutex
+	soapStatsProvider SOAPStatsProvider
+
+	// Aliases shown instead of Sonos room names, by UDN (see SetAlias)
+	settingsMu sync.RWMutex
+	settings   *SettingsRepository
+	aliases    map[string]string
 }
 
-func NewService(cfg config.Config, logger *log.Logger, soapClient *soap.Client) *Service {
+func NewService(cfg config.Config, logger *slog.Logger, soapClient *soap.Client) *Service {
 	if logger == nil {
-		logger = log.Default()
+		logger = slog.Default()
 	}
 	return &Service{
-		cfg:        cfg,
-		logger:     logger,
-		soapClient: soapClient,
-		knownIPs:   make(map[string]time.Time),
+		cfg:         cfg,
+		logger:      logger,
+		soapClient:  soapClient,
+		knownIPs:    make(map[string]time.Time),
+		relocations: make(map[string]relocation),
 	}
 }
 
@@ -167,28 +189,28 @@ func (service *Service) StartPeriodicDiscovery() {
 	}
 
 	if service.cfg.SSDPRescanIntervalMs <= 0 {
-		service.logger.Print("Periodic discovery disabled")
+		service.logger.Info("Periodic discovery disabled")
 		return
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	service.periodicCancel = cancel
 
-	service.logger.Printf("Starting periodic discovery interval=%dms", service.cfg.SSDPRescanIntervalMs)
+	service.logger.Info("Starting periodic discovery", "interval_ms", service.cfg.SSDPRescanIntervalMs)
 
 	go func() {
 		ticker := time.NewTicker(time.Duration(service.cfg.SSDPRescanIntervalMs) * time.Millisecond)
 		defer ticker.Stop()
 
 		if _, err := service.performDiscovery(); err != nil {
-			service.logger.Printf("Initial discovery failed: %v", err)
+			service.logger.Warn("Initial discovery failed", "error", err)
 		}
 
 		for {
 			select {
 			case <-ticker.C:
 				if _, err := service.performDiscovery(); err != nil {
-					service.logger.Printf("Periodic discovery failed: %v", err)
+					service.logger.Warn("Periodic discovery failed", "error", err)
 				}
 			case <-ctx.Done():
 				return
@@ -214,6 +236,47 @@ func (service *Service) SetDiscoveryCallback(callback DeviceDiscoveryCallback) {
 	service.discoveryCallback = callback
 }
 
+// SetOfflineCallback sets a callback to be invoked when a device goes offline.
+// It's called once per transition, not on every scan the device stays offline.
+func (service *Service) SetOfflineCallback(callback DeviceOfflineCallback) {
+	service.offlineCallbackMu.Lock()
+	defer service.offlineCallbackMu.Unlock()
+	service.offlineCallback = callback
+}
+
+// SetSOAPStatsProvider sets the source of the SOAP stats reported by /v1/devices/stats.
+func (service *Service) SetSOAPStatsProvider(provider SOAPStatsProvider) {
+	service.soapStatsMu.Lock()
+	defer service.soapStatsMu.Unlock()
+	service.soapStatsProvider = provider
+}
+
+// SOAPStats returns the current SOAP stats, or false when no provider is set.
+func (service *Service) SOAPStats() (SOAPStats, bool) {
+	service.soapStatsMu.RLock()
+	provider := service.soapStatsProvider
+	service.soapStatsMu.RUnlock()
+
+	if provider == nil {
+		return SOAPStats{}, false
+	}
+	return provider.SOAPStats(), true
+}
+
+// notifyOfflineCallback calls the registered callback for each device that went offline.
+func (service *Service) notifyOfflineCallback(devices []LogicalDevice) {
+	service.offlineCallbackMu.RLock()
+	callback := service.offlineCallback
+	service.offlineCallbackMu.RUnlock()
+
+	if callback == nil {
+		return
+	}
+	for _, device := range devices {
+		callback(device)
+	}
+}
+
 // notifyDiscoveryCallback calls the registered callback with discovered devices.
 func (service *Service) notifyDiscoveryCallback(devices []LogicalDevice) {
 	service.discoveryCallbackMu.RLock()
@@ -249,6 +312,70 @@ func (service *Service) IsHealthy() bool {
 	return true
 }
 
+// IsKnownDeviceIP reports whether ip belongs to a Sonos speaker seen by discovery,
+// including bonded satellites and subs that aren't targetable themselves.
+func (service *Service) IsKnownDeviceIP(ip string) bool {
+	if ip == "" {
+		return false
+	}
+
+	service.knownIPsMu.Lock()
+	_, known := service.knownIPs[ip]
+	service.knownIPsMu.Unlock()
+	if known {
+		return true
+	}
+
+	service.topologyMu.RLock()
+	defer service.topologyMu.RUnlock()
+	if service.topology == nil {
+		return false
+	}
+	for _, device := range service.topology.Devices {
+		if device.IP == ip {
+			return true
+		}
+		for _, physical := range device.PhysicalDevices {
+			if physical.IP == ip {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// OfflineDevices returns the UDNs in udns that aren't online: missing from the topology,
+// or marked OFFLINE after missing several discovery scans. It waits for discovery when
+// none has finished yet, so runs right after startup see the speakers. Test mode reports
+// every device online.
+func (service *Service) OfflineDevices(udns []string) ([]string, error) {
+	if service.testMode || len(udns) == 0 {
+		return nil, nil
+	}
+	topology, err := service.GetTopology()
+	if err != nil {
+		return nil, err
+	}
+
+	health := make(map[string]DeviceHealthStatus)
+	for _, device := range topology.Devices {
+		health[device.UDN] = device.Health
+		for _, physical := range device.PhysicalDevices {
+			if _, ok := health[physical.UDN]; !ok {
+				health[physical.UDN] = device.Health
+			}
+		}
+	}
+
+	var offline []string
+	for _, udn := range udns {
+		if status, ok := health[udn]; !ok || status == DeviceHealthOffline {
+			offline = append(offline, udn)
+		}
+	}
+	return offline, nil
+}
+
 func (service *Service) ResolveDeviceIP(deviceID string) (string, error) {
 	device, err := service.GetDevice(deviceID)
 	if err != nil {
@@ -258,9 +385,9 @@ func (service *Service) ResolveDeviceIP(deviceID string) (string, error) {
 		return device.IP, nil
 	}
 
-	service.logger.Printf("Device not found in topology, triggering rescan: %s", deviceID)
+	service.logger.Info("Device not found in topology, triggering rescan", "device_id", deviceID)
 	if _, err := service.performDiscovery(); err != nil {
-		service.logger.Printf("Rescan failed while resolving device: %v", err)
+		service.logger.Warn("Rescan failed while resolving device", "device_id", deviceID, "error", err)
 		return "", err
 	}
 
@@ -269,7 +396,7 @@ func (service *Service) ResolveDeviceIP(deviceID string) (string, error) {
 		return "", err
 	}
 	if device != nil {
-		service.logger.Printf("Device resolved after rescan: %s -> %s", deviceID, device.IP)
+		service.logger.Info("Device resolved after rescan", "device_id", deviceID, "ip", device.IP)
 		return device.IP, nil
 	}
 	return "", nil
@@ -361,13 +488,15 @@ func (service *Service) runDiscovery() discoveryResult {
 	newTopology := NormalizeDevices(devices, zoneTopology)
 
 	service.topologyMu.Lock()
-	merged := mergeTopologies(newTopology, service.topology)
+	merged := service.withAliases(mergeTopologies(newTopology, service.topology))
+	offline := wentOffline(service.topology, merged)
 	service.topology = &merged
 	topologyDevices := merged.Devices // Copy for callback outside lock
 	service.topologyMu.Unlock()
 
 	// Notify discovery callback (e.g., for UPnP event subscriptions)
 	service.notifyDiscoveryCallback(topologyDevices)
+	service.notifyOfflineCallback(offline)
 
 	return discoveryResult{
 		devices:    len(service.topology.Devices),
@@ -376,33 +505,35 @@ func (service *Service) runDiscovery() discoveryResult {
 }
 
 func (service *Service) fetchZoneGroupTopology(ip string) *ZoneGroupTopology {
-	service.logger.Printf("[TOPOLOGY-DIAG] Fetching zone group topology from IP=%s, timeout=%dms", ip, service.cfg.SonosTimeoutMs)
+	service.logger.Debug("[TOPOLOGY-DIAG] Fetching zone group topology", "ip", ip, "timeout_ms", service.cfg.SonosTimeoutMs)
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(service.cfg.SonosTimeoutMs)*time.Millisecond)
+	// Not relocated: a failure here would wait on the discovery that's calling it
+	ctx, cancel := context.WithTimeout(soap.WithoutRelocation(context.Background()), time.Duration(service.cfg.SonosTimeoutMs)*time.Millisecond)
 	defer cancel()
 
 	state, err := service.soapClient.GetZoneGroupState(ctx, ip)
 	if err != nil {
-		service.logger.Printf("[TOPOLOGY-DIAG] FAIL: GetZoneGroupState error: %v", err)
+		service.logger.Warn("[TOPOLOGY-DIAG] GetZoneGroupState failed", "ip", ip, "error", err)
 		return nil
 	}
 
-	service.logger.Printf("[TOPOLOGY-DIAG] Got zone state, %d groups", len(state.Groups))
+	service.logger.Debug("[TOPOLOGY-DIAG] Got zone state", "groups", len(state.Groups))
 	for i, group := range state.Groups {
-		service.logger.Printf("[TOPOLOGY-DIAG]   Group[%d]: CoordinatorUDN=%s, %d members",
-			i, group.Coordinator, len(group.Members))
+		service.logger.Debug("[TOPOLOGY-DIAG] Group",
+			"index", i, "coordinator_udn", group.Coordinator, "members", len(group.Members))
 		for j, member := range group.Members {
-			service.logger.Printf("[TOPOLOGY-DIAG]     Member[%d]: UUID=%s, ZoneName=%s, ChannelMapSet=%q, Satellite=%v, SW=%v",
-				j, member.UUID, member.ZoneName, member.ChannelMapSet, member.IsSatellite, member.IsSubwoofer)
+			service.logger.Debug("[TOPOLOGY-DIAG] Member",
+				"group", i, "index", j, "uuid", member.UUID, "zone_name", member.ZoneName,
+				"channel_map_set", member.ChannelMapSet, "satellite", member.IsSatellite, "subwoofer", member.IsSubwoofer)
 		}
 	}
 
 	topology := convertZoneGroupState(state)
 	if topology == nil {
-		service.logger.Printf("[TOPOLOGY-DIAG] FAIL: convertZoneGroupState returned nil")
+		service.logger.Warn("[TOPOLOGY-DIAG] convertZoneGroupState returned nil", "ip", ip)
 		return nil
 	}
-	service.logger.Printf("[TOPOLOGY-DIAG] Converted topology: %d groups", len(topology.Groups))
+	service.logger.Debug("[TOPOLOGY-DIAG] Converted topology", "groups", len(topology.Groups))
 	return topology
 }
 
@@ -0,0 +1,53 @@
+package devices
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/config"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+func TestOfflineDevices(t *testing.T) {
+	service := NewService(config.Config{SonosTimeoutMs: 1000}, logging.Discard(), soap.NewClient(time.Second))
+	service.topology = &DeviceTopology{Devices: []LogicalDevice{
+		{UDN: "RINCON_KITCHEN", Health: DeviceHealthOK},
+		{UDN: "RINCON_DEN", Health: DeviceHealthDegraded},
+		{UDN: "RINCON_PATIO", Health: DeviceHealthOffline},
+		{UDN: "RINCON_LIVING", Health: DeviceHealthOK, PhysicalDevices: []PhysicalDevice{
+			{UDN: "RINCON_LIVING"}, {UDN: "RINCON_SUB"},
+		}},
+	}}
+
+	offline, err := service.OfflineDevices([]string{
+		"RINCON_KITCHEN", "RINCON_DEN", "RINCON_PATIO", "RINCON_SUB", "RINCON_UNKNOWN",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"RINCON_PATIO", "RINCON_UNKNOWN"}, offline, "degraded devices and bonded members count as online")
+
+	offline, err = service.OfflineDevices(nil)
+	require.NoError(t, err)
+	require.Empty(t, offline)
+}
+
+func TestWentOffline(t *testing.T) {
+	device := func(udn string, missed int) LogicalDevice {
+		return LogicalDevice{UDN: udn, PhysicalDevices: []PhysicalDevice{{UDN: udn}}, MissedScans: missed, Health: computeHealth(missed)}
+	}
+	existing := &DeviceTopology{Devices: []LogicalDevice{
+		device("RINCON_KITCHEN", OfflineThreshold-1),
+		device("RINCON_PATIO", OfflineThreshold),
+		device("RINCON_DEN", 0),
+	}}
+
+	// Only the den answered this scan
+	merged := mergeTopologies(DeviceTopology{Devices: []LogicalDevice{device("RINCON_DEN", 0)}}, existing)
+	offline := wentOffline(existing, merged)
+	require.Len(t, offline, 1, "a device already offline isn't reported again")
+	require.Equal(t, "RINCON_KITCHEN", offline[0].UDN)
+
+	require.Empty(t, wentOffline(nil, merged), "the first scan has nothing to compare")
+}
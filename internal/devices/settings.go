@@ -0,0 +1,170 @@
+package devices
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// MaxAliasLength bounds a device alias, in characters.
+const MaxAliasLength = 64
+
+// DBPair interface for dependency injection (matches db.DBPair).
+type DBPair interface {
+	Reader() *sql.DB
+	Writer() *sql.DB
+}
+
+// SettingsRepository stores hub-side settings for speakers, such as their aliases.
+type SettingsRepository struct {
+	reader *sql.DB
+	writer *sql.DB
+}
+
+// NewSettingsRepository creates a device settings repository.
+func NewSettingsRepository(dbPair DBPair) *SettingsRepository {
+	return &SettingsRepository{
+		reader: dbPair.Reader(),
+		writer: dbPair.Writer(),
+	}
+}
+
+// Aliases returns every device's alias, by UDN.
+func (r *SettingsRepository) Aliases() (map[string]string, error) {
+	rows, err := r.reader.Query(`SELECT udn, alias FROM device_settings WHERE alias != ''`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := make(map[string]string)
+	for rows.Next() {
+		var udn, alias string
+		if err := rows.Scan(&udn, &alias); err != nil {
+			return nil, err
+		}
+		aliases[udn] = alias
+	}
+	return aliases, rows.Err()
+}
+
+// SetAlias stores the device's alias; an empty alias clears it.
+func (r *SettingsRepository) SetAlias(udn, alias string) error {
+	_, err := r.writer.Exec(`
+		INSERT INTO device_settings (udn, alias, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(udn) DO UPDATE SET alias = excluded.alias, updated_at = excluded.updated_at
+	`, udn, alias, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// ErrSettingsUnavailable is returned when device settings can't be changed because no
+// SettingsRepository is set.
+var ErrSettingsUnavailable = errors.New("device settings not available")
+
+// ErrAliasTaken is returned when an alias is already another speaker's alias or room
+// name, so looking a speaker up by name would be ambiguous.
+var ErrAliasTaken = errors.New("alias is already used by another speaker")
+
+// SetSettingsRepository sets where device settings are stored and loads the aliases.
+func (service *Service) SetSettingsRepository(repo *SettingsRepository) error {
+	aliases, err := repo.Aliases()
+	if err != nil {
+		return err
+	}
+	service.settingsMu.Lock()
+	service.settings = repo
+	service.aliases = aliases
+	service.settingsMu.Unlock()
+
+	service.refreshAliases()
+	return nil
+}
+
+// Alias returns the device's alias, or "" when it has none.
+func (service *Service) Alias(udn string) string {
+	service.settingsMu.RLock()
+	defer service.settingsMu.RUnlock()
+	return service.aliases[udn]
+}
+
+// SetAlias gives the device an alias shown instead of its Sonos room name, keyed by its
+// UDN so it survives rediscovery; an empty alias clears it. Returns the updated device,
+// or nil when there's no such device. An alias matching another speaker's alias or room
+// name, ignoring case, fails with ErrAliasTaken.
+func (service *Service) SetAlias(deviceID, alias string) (*LogicalDevice, error) {
+	device, err := service.GetDevice(deviceID)
+	if err != nil || device == nil {
+		return nil, err
+	}
+
+	// Bonded satellites share their primary's room name, so only primaries are compared
+	var roomNames []string
+	service.topologyMu.RLock()
+	if service.topology != nil {
+		for _, other := range service.topology.Devices {
+			if other.UDN != device.UDN && !other.IsBonded {
+				roomNames = append(roomNames, other.RoomName)
+			}
+		}
+	}
+	service.topologyMu.RUnlock()
+
+	service.settingsMu.Lock()
+	if service.settings == nil {
+		service.settingsMu.Unlock()
+		return nil, ErrSettingsUnavailable
+	}
+	if alias != "" {
+		for udn, other := range service.aliases {
+			if udn != device.UDN {
+				roomNames = append(roomNames, other)
+			}
+		}
+		for _, name := range roomNames {
+			if strings.EqualFold(name, alias) {
+				service.settingsMu.Unlock()
+				return nil, ErrAliasTaken
+			}
+		}
+	}
+	if err := service.settings.SetAlias(device.UDN, alias); err != nil {
+		service.settingsMu.Unlock()
+		return nil, err
+	}
+	if alias == "" {
+		delete(service.aliases, device.UDN)
+	} else {
+		service.aliases[device.UDN] = alias
+	}
+	service.settingsMu.Unlock()
+
+	service.refreshAliases()
+	device.Alias = alias
+	return device, nil
+}
+
+// refreshAliases swaps in a copy of the cached topology with the current aliases.
+func (service *Service) refreshAliases() {
+	service.topologyMu.Lock()
+	defer service.topologyMu.Unlock()
+	if service.topology != nil {
+		topology := service.withAliases(*service.topology)
+		service.topology = &topology
+	}
+}
+
+// withAliases returns topology with each device's alias set. The devices are copied, so
+// callers holding the cached topology don't see them change.
+func (service *Service) withAliases(topology DeviceTopology) DeviceTopology {
+	service.settingsMu.RLock()
+	defer service.settingsMu.RUnlock()
+
+	devices := make([]LogicalDevice, len(topology.Devices))
+	for i, device := range topology.Devices {
+		device.Alias = service.aliases[device.UDN]
+		devices[i] = device
+	}
+	topology.Devices = devices
+	return topology
+}
@@ -0,0 +1,76 @@
+package devices
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/config"
+	"github.com/strefethen/sonos-hub-go/internal/db"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+func TestDeviceAliases(t *testing.T) {
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+
+	newService := func() *Service {
+		return NewService(config.Config{SonosTimeoutMs: 1000}, logging.Discard(), soap.NewClient(time.Second))
+	}
+	discovered := func() DeviceTopology {
+		return DeviceTopology{Devices: []LogicalDevice{
+			{UDN: "RINCON_KIDS", RoomName: "Bedroom 2"},
+			{UDN: "RINCON_DEN", RoomName: "Den"},
+		}}
+	}
+
+	service := newService()
+	_, err = service.SetAlias("RINCON_KIDS", "Kids Room")
+	require.NoError(t, err, "an unknown device isn't an error")
+
+	service.topology = &DeviceTopology{Devices: discovered().Devices}
+	_, err = service.SetAlias("RINCON_KIDS", "Kids Room")
+	require.ErrorIs(t, err, ErrSettingsUnavailable)
+
+	require.NoError(t, service.SetSettingsRepository(NewSettingsRepository(dbPair)))
+	cached := service.topology
+	device, err := service.SetAlias("Bedroom 2", "Kids Room")
+	require.NoError(t, err)
+	require.Equal(t, "Kids Room", device.DisplayName())
+	require.Empty(t, cached.Devices[0].Alias, "readers of the old topology don't see it change")
+
+	device, err = service.GetDevice("kids room")
+	require.NoError(t, err)
+	require.Equal(t, "RINCON_KIDS", device.UDN, "devices can be found by alias")
+	require.Equal(t, "Bedroom 2", device.RoomName)
+	require.Equal(t, "Den", service.topology.Devices[1].DisplayName())
+
+	// Aliases can't shadow another speaker's name, since lookups by name would be ambiguous
+	_, err = service.SetAlias("RINCON_DEN", "kids ROOM")
+	require.ErrorIs(t, err, ErrAliasTaken)
+	_, err = service.SetAlias("RINCON_KIDS", "den")
+	require.ErrorIs(t, err, ErrAliasTaken)
+	device, err = service.SetAlias("RINCON_KIDS", "Kids Room")
+	require.NoError(t, err, "a speaker can keep its own alias")
+	require.Equal(t, "Kids Room", device.Alias)
+
+	// A restarted hub loads the alias and applies it to rediscovered devices
+	restarted := newService()
+	require.NoError(t, restarted.SetSettingsRepository(NewSettingsRepository(dbPair)))
+	require.Equal(t, "Kids Room", restarted.Alias("RINCON_KIDS"))
+	rediscovered := restarted.withAliases(discovered())
+	require.Equal(t, "Kids Room", rediscovered.Devices[0].Alias)
+
+	restarted.topology = &rediscovered
+	device, err = restarted.SetAlias("RINCON_KIDS", "")
+	require.NoError(t, err)
+	require.Equal(t, "Bedroom 2", device.DisplayName())
+	aliases, err := NewSettingsRepository(dbPair).Aliases()
+	require.NoError(t, err)
+	require.Empty(t, aliases)
+}
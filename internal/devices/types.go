@@ -32,6 +32,37 @@ const (
 	DeviceHealthOffline  DeviceHealthStatus = "OFFLINE"
 )
 
+// CircuitState is the state of a device's SOAP circuit breaker.
+type CircuitState string
+
+const (
+	CircuitClosed CircuitState = "closed" // Calls go through
+	CircuitOpen   CircuitState = "open"   // Calls fail fast as device unavailable
+)
+
+// CircuitBreakerStatus is one device's SOAP circuit breaker.
+type CircuitBreakerStatus struct {
+	IP                  string
+	State               CircuitState
+	ConsecutiveFailures int
+	InFlight            int
+	OpenUntil           *time.Time // Set while open
+}
+
+// SOAPStats describes SOAP call concurrency and per-device circuit breakers.
+type SOAPStats struct {
+	InFlight               int
+	MaxConcurrent          int
+	MaxConcurrentPerDevice int
+	Breakers               []CircuitBreakerStatus
+}
+
+// SOAPStatsProvider reports SOAPStats for /v1/devices/stats. It is implemented by
+// sonos.SOAPLimiter, which lives above this package.
+type SOAPStatsProvider interface {
+	SOAPStats() SOAPStats
+}
+
 const (
 	DegradedThreshold = 1
 	OfflineThreshold  = 3
@@ -74,12 +105,15 @@ type PhysicalDevice struct {
 type LogicalDevice struct {
 	UDN                  string // Primary identifier (from first physical device)
 	RoomName             string
+	Alias                string // Hub-side name shown instead of RoomName; empty when none
 	IP                   string
 	Model                string
 	Role                 DeviceRole
 	IsTargetable         bool
 	IsCoordinatorCapable bool
 	SupportsAirPlay      bool
+	IsBonded             bool   // A surround, sub or other satellite that plays through BondedTo
+	BondedTo             string // UDN of the bonded satellite's primary device
 	LogicalGroupID       string
 	PhysicalDevices      []PhysicalDevice
 	LastSeenAt           time.Time
@@ -87,6 +121,14 @@ type LogicalDevice struct {
 	MissedScans          int
 }
 
+// DisplayName is the device's alias, or its Sonos room name when it has none.
+func (device LogicalDevice) DisplayName() string {
+	if device.Alias != "" {
+		return device.Alias
+	}
+	return device.RoomName
+}
+
 // DeviceTopology is the full relationship graph.
 type DeviceTopology struct {
 	Devices           []LogicalDevice
@@ -95,6 +137,36 @@ type DeviceTopology struct {
 	UpdatedAt         time.Time
 }
 
+// BondedPrimary returns the device that the speaker with udn is bonded to, or nil when
+// it isn't a bonded satellite. That's a home theater's surround or sub, the second
+// speaker of a stereo pair, or a speaker the Sonos app hides because it's bonded.
+func (topology DeviceTopology) BondedPrimary(udn string) *LogicalDevice {
+	for i := range topology.Devices {
+		device := &topology.Devices[i]
+		if device.UDN != udn {
+			continue
+		}
+		if !device.IsBonded {
+			return nil
+		}
+		for j := range topology.Devices {
+			if topology.Devices[j].UDN == device.BondedTo {
+				return &topology.Devices[j]
+			}
+		}
+		return &LogicalDevice{UDN: device.BondedTo}
+	}
+
+	for i := range topology.Devices {
+		for _, physical := range topology.Devices[i].PhysicalDevices {
+			if physical.UDN == udn {
+				return &topology.Devices[i]
+			}
+		}
+	}
+	return nil
+}
+
 // HomeTheaterGroup represents Arc + surrounds + sub.
 type HomeTheaterGroup struct {
 	GroupID   string
@@ -130,6 +202,7 @@ type ZoneMember struct {
 	IsCoordinator bool
 	IsSatellite   bool
 	IsSubwoofer   bool
+	Invisible     bool // Hidden in the Sonos app, as bonded speakers are
 	ChannelMapSet string
 }
 
@@ -18,6 +18,7 @@ func convertZoneGroupState(state soap.ZoneGroupState) *ZoneGroupTopology {
 				IsCoordinator: member.IsCoordinator,
 				IsSatellite:   member.IsSatellite,
 				IsSubwoofer:   member.IsSubwoofer,
+				Invisible:     !member.IsVisible,
 				ChannelMapSet: member.ChannelMapSet,
 			})
 		}
@@ -2,7 +2,7 @@ package discovery
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"net/url"
 	"strings"
 	"time"
@@ -10,14 +10,14 @@ import (
 
 // DiscoverDevices performs multi-pass SSDP discovery and optional fallback probes.
 func DiscoverDevices(ctx context.Context, passes int, passInterval, timeout time.Duration, knownIPs []string) ([]*RawDevice, error) {
-	log.Printf("Starting discovery with %d known IPs: %v", len(knownIPs), knownIPs)
+	slog.Info("Starting discovery", "known_ips", knownIPs)
 
 	responses, err := Discover(ctx, passes, passInterval, timeout)
 	if err != nil {
-		log.Printf("SSDP discovery error: %v", err)
+		slog.Warn("SSDP discovery error", "error", err)
 		return nil, err
 	}
-	log.Printf("SSDP returned %d responses", len(responses))
+	slog.Info("SSDP returned responses", "count", len(responses))
 
 	devices := make([]*RawDevice, 0)
 	seenIPs := make(map[string]struct{})
@@ -37,23 +37,23 @@ func DiscoverDevices(ctx context.Context, passes int, passInterval, timeout time
 		cancel()
 
 		if err != nil {
-			log.Printf("SSDP probe failed for %s: %v", ip, err)
+			slog.Warn("SSDP probe failed", "ip", ip, "error", err)
 			continue
 		}
 		if device == nil {
-			log.Printf("SSDP probe returned nil for %s", ip)
+			slog.Warn("SSDP probe returned nil", "ip", ip)
 			continue
 		}
 		device.Location = loc
 		devices = append(devices, device)
-		log.Printf("SSDP discovered device: %s (%s)", device.RoomName, ip)
+		slog.Info("SSDP discovered device", "room", device.RoomName, "ip", ip)
 	}
 
 	// Probe known IPs that weren't discovered via SSDP
-	log.Printf("Probing %d known IPs not found via SSDP", len(knownIPs)-len(seenIPs))
+	slog.Info("Probing known IPs not found via SSDP", "count", len(knownIPs)-len(seenIPs))
 	for _, ip := range knownIPs {
 		if _, ok := seenIPs[ip]; ok {
-			log.Printf("Skipping %s - already discovered via SSDP", ip)
+			slog.Debug("Skipping IP already discovered via SSDP", "ip", ip)
 			continue
 		}
 
@@ -63,18 +63,18 @@ func DiscoverDevices(ctx context.Context, passes int, passInterval, timeout time
 		cancel()
 
 		if err != nil {
-			log.Printf("Fallback probe failed for %s: %v", ip, err)
+			slog.Warn("Fallback probe failed", "ip", ip, "error", err)
 			continue
 		}
 		if device == nil {
-			log.Printf("Fallback probe returned nil for %s", ip)
+			slog.Warn("Fallback probe returned nil", "ip", ip)
 			continue
 		}
 		devices = append(devices, device)
-		log.Printf("Fallback discovered device: %s (%s)", device.RoomName, ip)
+		slog.Info("Fallback discovered device", "room", device.RoomName, "ip", ip)
 	}
 
-	log.Printf("Discovery complete: %d devices found", len(devices))
+	slog.Info("Discovery complete", "devices", len(devices))
 	return devices, nil
 }
 
@@ -0,0 +1,154 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/auth"
+)
+
+const (
+	// HeaderKey is the request header carrying the client's idempotency key.
+	HeaderKey = "Idempotency-Key"
+	// HeaderReplayed is set on responses replayed from a stored key.
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+// Middleware makes POST requests to the given path patterns (path.Match syntax, e.g.
+// "/v1/routines/*/trigger") idempotent when they carry an Idempotency-Key header.
+// Keys belong to the authenticated client, so clients that pick the same key don't
+// collide; it must run after auth.Middleware.
+// The first request with a key runs normally and its response is stored; retries with
+// the same key and body within KeyTTL replay that response, while a different body
+// is rejected with 409. Only successful responses are stored: errors such as a 429
+// cooldown or a 409 conflict may clear up, so the request can be retried with the key.
+func Middleware(repo *Repository, patterns ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(HeaderKey)
+			if key == "" || r.Method != http.MethodPost || !matchesAny(patterns, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxKeyLength {
+				api.WriteError(w, r, apperrors.NewValidationError("Idempotency-Key must be at most 255 characters", map[string]any{"header": HeaderKey}))
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				api.WriteError(w, r, apperrors.NewValidationError("Failed to read request body", nil))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			hash := requestHash(r, body)
+			key = scopedKey(r, key)
+			existing, reserved, err := repo.Reserve(key, hash, time.Now())
+			if err != nil {
+				api.WriteError(w, r, apperrors.NewInternalError("Failed to check idempotency key"))
+				return
+			}
+			if !reserved {
+				replay(w, r, existing, hash)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+			completed := false
+			defer func() {
+				if !completed {
+					_ = repo.Release(key)
+				}
+			}()
+
+			next.ServeHTTP(recorder, r)
+
+			if recorder.statusCode >= 200 && recorder.statusCode < 300 {
+				if err := repo.Complete(key, recorder.statusCode, recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err == nil {
+					completed = true
+				}
+			}
+		})
+	}
+}
+
+// replay writes the stored response for a key, or a conflict when the key can't be replayed.
+func replay(w http.ResponseWriter, r *http.Request, record *Record, hash string) {
+	if record == nil {
+		// Released between the reserve and the lookup; the client should retry
+		api.WriteError(w, r, apperrors.NewConflictError("Request with this Idempotency-Key is in progress", map[string]any{"header": HeaderKey}))
+		return
+	}
+	if record.RequestHash != hash {
+		api.WriteError(w, r, apperrors.NewConflictError("Idempotency-Key was already used with a different request", map[string]any{"header": HeaderKey}))
+		return
+	}
+	if record.StatusCode == nil {
+		api.WriteError(w, r, apperrors.NewConflictError("Request with this Idempotency-Key is in progress", map[string]any{"header": HeaderKey}))
+		return
+	}
+
+	if record.ContentType != "" {
+		w.Header().Set("Content-Type", record.ContentType)
+	}
+	w.Header().Set(HeaderReplayed, "true")
+	w.WriteHeader(*record.StatusCode)
+	_, _ = w.Write(record.ResponseBody)
+}
+
+// scopedKey stores a client's key under the authenticated device or API key that sent it.
+func scopedKey(r *http.Request, key string) string {
+	user, _ := auth.UserFromContext(r.Context())
+	return string(user.Type) + ":" + user.Sub + ":" + key
+}
+
+// requestHash fingerprints a request by method, path and body.
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + "\n" + strings.TrimSuffix(r.URL.Path, "/") + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func matchesAny(patterns []string, requestPath string) bool {
+	requestPath = strings.TrimSuffix(requestPath, "/")
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, requestPath); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// responseRecorder passes a response through while keeping a copy of its status and body.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (rr *responseRecorder) WriteHeader(code int) {
+	if !rr.wroteHeader {
+		rr.statusCode = code
+		rr.wroteHeader = true
+	}
+	rr.ResponseWriter.WriteHeader(code)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.wroteHeader = true
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
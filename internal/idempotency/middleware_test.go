@@ -0,0 +1,156 @@
+package idempotency
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/auth"
+	"github.com/strefethen/sonos-hub-go/internal/db"
+)
+
+func setupTestDB(t *testing.T) *Repository {
+	t.Helper()
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+
+	return NewRepository(dbPair)
+}
+
+func TestRepository_ReserveExpiresAfterTTL(t *testing.T) {
+	repo := setupTestDB(t)
+	now := time.Date(2025, 6, 21, 12, 0, 0, 0, time.UTC)
+
+	_, reserved, err := repo.Reserve("key-1", "hash-1", now)
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	existing, reserved, err := repo.Reserve("key-1", "hash-1", now.Add(time.Minute))
+	require.NoError(t, err)
+	require.False(t, reserved)
+	require.Nil(t, existing.StatusCode, "in flight until completed")
+
+	require.NoError(t, repo.Complete("key-1", 201, "application/json", []byte(`{"ok":true}`)))
+	existing, _, err = repo.Reserve("key-1", "hash-1", now.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 201, *existing.StatusCode)
+	require.Equal(t, `{"ok":true}`, string(existing.ResponseBody))
+
+	_, reserved, err = repo.Reserve("key-1", "hash-2", now.Add(KeyTTL+time.Minute))
+	require.NoError(t, err)
+	require.True(t, reserved, "expired keys can be reused")
+}
+
+func TestRepository_AbandonedReservationExpires(t *testing.T) {
+	repo := setupTestDB(t)
+	now := time.Date(2025, 6, 21, 12, 0, 0, 0, time.UTC)
+
+	_, reserved, err := repo.Reserve("key-1", "hash-1", now)
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	_, reserved, err = repo.Reserve("key-1", "hash-1", now.Add(ReservationTTL-time.Second))
+	require.NoError(t, err)
+	require.False(t, reserved, "still in flight")
+
+	_, reserved, err = repo.Reserve("key-1", "hash-1", now.Add(ReservationTTL+time.Second))
+	require.NoError(t, err)
+	require.True(t, reserved, "a request that never completed frees its key")
+}
+
+func TestMiddleware(t *testing.T) {
+	repo := setupTestDB(t)
+	calls := 0
+	handler := Middleware(repo, "/v1/routines", "/v1/routines/*/trigger")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		switch string(body) {
+		case "fail":
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		case "cooldown":
+			if calls == 9 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"call":` + string(rune('0'+calls)) + `}`))
+	}))
+
+	doAs := func(user *auth.User, path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(HeaderKey, key)
+		}
+		if user != nil {
+			req = req.WithContext(auth.WithUser(req.Context(), *user))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	do := func(path, key, body string) *httptest.ResponseRecorder {
+		return doAs(nil, path, key, body)
+	}
+
+	first := do("/v1/routines", "key-1", `{"name":"a"}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+	require.Equal(t, `{"call":1}`, first.Body.String())
+
+	t.Run("replays the stored response", func(t *testing.T) {
+		rec := do("/v1/routines", "key-1", `{"name":"a"}`)
+		require.Equal(t, http.StatusCreated, rec.Code)
+		require.Equal(t, `{"call":1}`, rec.Body.String())
+		require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		require.Equal(t, "true", rec.Header().Get(HeaderReplayed))
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("rejects a different request with the same key", func(t *testing.T) {
+		rec := do("/v1/routines", "key-1", `{"name":"b"}`)
+		require.Equal(t, http.StatusConflict, rec.Code)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("server errors are not stored", func(t *testing.T) {
+		require.Equal(t, http.StatusInternalServerError, do("/v1/routines/r-1/trigger", "key-2", "fail").Code)
+		require.Equal(t, http.StatusInternalServerError, do("/v1/routines/r-1/trigger", "key-2", "fail").Code)
+		require.Equal(t, 3, calls)
+	})
+
+	t.Run("ignores unmatched paths and requests without a key", func(t *testing.T) {
+		do("/v1/scenes", "key-3", "")
+		do("/v1/scenes", "key-3", "")
+		do("/v1/routines", "", `{"name":"a"}`)
+		require.Equal(t, 6, calls)
+	})
+
+	t.Run("keys belong to the client that sent them", func(t *testing.T) {
+		phone := &auth.User{Sub: "device-1", Type: auth.TokenTypeAccess}
+		script := &auth.User{Sub: "key-abc", Type: auth.TokenTypeAPIKey}
+		require.Equal(t, http.StatusCreated, doAs(phone, "/v1/routines", "shared", `{"name":"a"}`).Code)
+		rec := doAs(script, "/v1/routines/r-1/trigger", "shared", "")
+		require.Equal(t, http.StatusCreated, rec.Code)
+		require.Empty(t, rec.Header().Get(HeaderReplayed))
+		require.Equal(t, "true", doAs(phone, "/v1/routines", "shared", `{"name":"a"}`).Header().Get(HeaderReplayed))
+		require.Equal(t, 8, calls)
+	})
+
+	t.Run("client errors are not stored", func(t *testing.T) {
+		require.Equal(t, http.StatusTooManyRequests, do("/v1/routines/r-1/trigger", "key-4", "cooldown").Code)
+		rec := do("/v1/routines/r-1/trigger", "key-4", "cooldown")
+		require.Equal(t, http.StatusCreated, rec.Code, "the retry runs once the cooldown is over")
+		require.Empty(t, rec.Header().Get(HeaderReplayed))
+		require.Equal(t, 10, calls)
+	})
+}
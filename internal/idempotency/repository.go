@@ -0,0 +1,119 @@
+package idempotency
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// KeyTTL is how long a completed response is replayed for its key.
+const KeyTTL = 24 * time.Hour
+
+// ReservationTTL is how long a key whose request never completed, say because the hub
+// stopped mid-request, is held before it can be reserved again.
+const ReservationTTL = time.Minute
+
+// Record is a stored idempotency key. StatusCode is nil while the original request
+// is still in flight.
+type Record struct {
+	Key          string
+	RequestHash  string
+	StatusCode   *int
+	ContentType  string
+	ResponseBody []byte
+	CreatedAt    time.Time
+}
+
+// DBPair interface for dependency injection (matches db.DBPair).
+type DBPair interface {
+	Reader() *sql.DB
+	Writer() *sql.DB
+}
+
+// Repository handles database operations for idempotency keys.
+// Uses separate reader/writer connections for optimal SQLite concurrency.
+type Repository struct {
+	reader *sql.DB // For SELECT queries
+	writer *sql.DB // For INSERT/UPDATE/DELETE
+}
+
+// NewRepository creates a new idempotency Repository.
+func NewRepository(dbPair DBPair) *Repository {
+	return &Repository{reader: dbPair.Reader(), writer: dbPair.Writer()}
+}
+
+// Reserve claims a key for a request. Returns (nil, true) when the key was newly
+// reserved and the request should run, or the existing record and false when the key
+// was already used within KeyTTL. Expired keys, and reservations older than
+// ReservationTTL that never completed, are purged first.
+func (r *Repository) Reserve(key, requestHash string, now time.Time) (*Record, bool, error) {
+	if _, err := r.writer.Exec(`
+		DELETE FROM idempotency_keys
+		WHERE created_at < ? OR (status_code IS NULL AND created_at < ?)
+	`, now.Add(-KeyTTL).UTC().Format(time.RFC3339), now.Add(-ReservationTTL).UTC().Format(time.RFC3339)); err != nil {
+		return nil, false, err
+	}
+
+	result, err := r.writer.Exec(`
+		INSERT OR IGNORE INTO idempotency_keys (idempotency_key, request_hash, created_at)
+		VALUES (?, ?, ?)
+	`, key, requestHash, now.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, false, err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return nil, false, err
+	} else if rows == 1 {
+		return nil, true, nil
+	}
+
+	record, err := r.Get(key)
+	if err != nil {
+		return nil, false, err
+	}
+	return record, false, nil
+}
+
+// Get returns the record for a key, or nil if there is none.
+func (r *Repository) Get(key string) (*Record, error) {
+	var (
+		record      Record
+		statusCode  sql.NullInt64
+		contentType sql.NullString
+		createdAt   string
+	)
+	// Read through the writer so a key reserved moments ago is always visible
+	err := r.writer.QueryRow(`
+		SELECT idempotency_key, request_hash, status_code, content_type, response_body, created_at
+		FROM idempotency_keys WHERE idempotency_key = ?
+	`, key).Scan(&record.Key, &record.RequestHash, &statusCode, &contentType, &record.ResponseBody, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if statusCode.Valid {
+		code := int(statusCode.Int64)
+		record.StatusCode = &code
+	}
+	record.ContentType = contentType.String
+	record.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	return &record, nil
+}
+
+// Complete stores the response for a reserved key so retries can replay it.
+func (r *Repository) Complete(key string, statusCode int, contentType string, body []byte) error {
+	_, err := r.writer.Exec(`
+		UPDATE idempotency_keys SET status_code = ?, content_type = ?, response_body = ?
+		WHERE idempotency_key = ?
+	`, statusCode, contentType, body, key)
+	return err
+}
+
+// Release deletes a reserved key so the request can be retried, e.g. after a server error.
+func (r *Repository) Release(key string) error {
+	_, err := r.writer.Exec(`DELETE FROM idempotency_keys WHERE idempotency_key = ? AND status_code IS NULL`, key)
+	return err
+}
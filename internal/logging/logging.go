@@ -0,0 +1,73 @@
+// Package logging configures the process-wide slog logger and carries request- and
+// job-scoped attributes (request_id, job_id) through contexts, so every line logged on
+// behalf of one request or routine run can be found together.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// Log formats accepted by LOG_FORMAT.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// New creates a logger that writes lines at level and above to w in format.
+func New(w io.Writer, format string, level slog.Leveler) (*slog.Logger, error) {
+	options := &slog.HandlerOptions{Level: level}
+	switch format {
+	case FormatText, "":
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q (want %s or %s)", format, FormatText, FormatJSON)
+	}
+}
+
+// Setup makes a logger from New the default. slog's top-level functions and the log
+// package both write through it afterwards.
+func Setup(w io.Writer, format string, level slog.Leveler) (*slog.Logger, error) {
+	logger, err := New(w, format, level)
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(logger)
+	return logger, nil
+}
+
+// Discard returns a logger that drops everything, for tests.
+func Discard() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+type attrsKey struct{}
+
+// With returns a copy of ctx carrying args, as key-value pairs or slog.Attrs. From adds
+// them to every line logged with the returned context.
+func With(ctx context.Context, args ...any) context.Context {
+	existing, _ := ctx.Value(attrsKey{}).([]any)
+	combined := make([]any, 0, len(existing)+len(args))
+	combined = append(combined, existing...)
+	combined = append(combined, args...)
+	return context.WithValue(ctx, attrsKey{}, combined)
+}
+
+// From returns logger with the attributes added to ctx by With. A nil logger means
+// slog.Default().
+func From(ctx context.Context, logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if ctx == nil {
+		return logger
+	}
+	if args, ok := ctx.Value(attrsKey{}).([]any); ok && len(args) > 0 {
+		return logger.With(args...)
+	}
+	return logger
+}
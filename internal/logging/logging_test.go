@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, FormatJSON, slog.LevelInfo)
+	require.NoError(t, err)
+
+	logger.Debug("hidden")
+	logger.Info("shown", "job_id", "job-1")
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	require.Equal(t, "shown", line["msg"])
+	require.Equal(t, "job-1", line["job_id"])
+
+	buf.Reset()
+	logger, err = New(&buf, FormatText, slog.LevelDebug)
+	require.NoError(t, err)
+	logger.Debug("visible")
+	require.Contains(t, buf.String(), "level=DEBUG msg=visible")
+
+	_, err = New(&buf, "xml", slog.LevelInfo)
+	require.Error(t, err)
+}
+
+func TestSetup_RoutesLogPackage(t *testing.T) {
+	previous := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	var buf bytes.Buffer
+	_, err := Setup(&buf, FormatJSON, slog.LevelInfo)
+	require.NoError(t, err)
+
+	log.Printf("from the log package")
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	require.Equal(t, "from the log package", line["msg"])
+	require.Equal(t, "INFO", line["level"])
+}
+
+func TestWithAndFrom(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, FormatJSON, slog.LevelInfo)
+	require.NoError(t, err)
+
+	ctx := With(context.Background(), "request_id", "req-1")
+	ctx = With(ctx, slog.String("job_id", "job-1"))
+	From(ctx, logger.With("component", "scheduler")).Info("running")
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	require.Equal(t, "req-1", line["request_id"])
+	require.Equal(t, "job-1", line["job_id"])
+	require.Equal(t, "scheduler", line["component"])
+
+	// Without attributes the logger is returned unchanged
+	require.Same(t, logger, From(context.Background(), logger))
+	require.Same(t, slog.Default(), From(context.Background(), nil))
+}
@@ -0,0 +1,138 @@
+package maintenance
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
+)
+
+// iconRoutine is a routine playing a Sonos favorite, with the columns its music content
+// is built from.
+type iconRoutine struct {
+	routineID, favoriteID, name, artworkURL, serviceLogoURL, serviceName string
+	contentJSON                                                          sql.NullString
+}
+
+// rowOutcome is what happened to one row.
+type rowOutcome int
+
+const (
+	rowUpdated rowOutcome = iota
+	rowSkipped
+	rowFailed
+)
+
+// BackfillIcons adds serviceLogoUrl and serviceName to the music_content_json of routines
+// playing a Sonos favorite, which the iOS app uses to show service icons. The logo comes
+// from the routine's service logo column, or is derived from its service name.
+func BackfillIcons(env Env, opts Options) (Result, error) {
+	result := Result{Task: "backfill-icons", DryRun: opts.DryRun}
+
+	rows, err := env.DB.Reader().Query(`
+		SELECT
+			routine_id,
+			music_sonos_favorite_id,
+			COALESCE(music_sonos_favorite_name, ''),
+			COALESCE(music_sonos_favorite_artwork_url, ''),
+			COALESCE(music_sonos_favorite_service_logo_url, ''),
+			COALESCE(music_sonos_favorite_service_name, ''),
+			music_content_json
+		FROM routines
+		WHERE music_sonos_favorite_id IS NOT NULL
+		AND music_sonos_favorite_id != ''
+		AND deleted_at IS NULL
+	`)
+	if err != nil {
+		return result, err
+	}
+	var routines []iconRoutine
+	for rows.Next() {
+		var r iconRoutine
+		if err := rows.Scan(&r.routineID, &r.favoriteID, &r.name, &r.artworkURL,
+			&r.serviceLogoURL, &r.serviceName, &r.contentJSON); err != nil {
+			rows.Close()
+			return result, err
+		}
+		routines = append(routines, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+
+	for _, r := range routines {
+		result.Scanned++
+		outcome, err := backfillIcon(env, opts, r)
+		if err != nil {
+			return result, err
+		}
+		switch outcome {
+		case rowUpdated:
+			result.Updated++
+		case rowSkipped:
+			result.Skipped++
+		case rowFailed:
+			result.Failed++
+		}
+		opts.progress(result)
+	}
+	return result, nil
+}
+
+// backfillIcon adds the service logo to one routine's music content.
+func backfillIcon(env Env, opts Options, r iconRoutine) (rowOutcome, error) {
+	content := map[string]any{}
+	if r.contentJSON.Valid && r.contentJSON.String != "" {
+		if err := json.Unmarshal([]byte(r.contentJSON.String), &content); err != nil {
+			slog.Warn("MAINTENANCE: Routine has invalid music_content_json", "routine_id", r.routineID, "error", err)
+			return rowFailed, nil
+		}
+	}
+	if logo, _ := content["serviceLogoUrl"].(string); logo != "" {
+		return rowSkipped, nil
+	}
+
+	serviceLogoURL := r.serviceLogoURL
+	if serviceLogoURL == "" && r.serviceName != "" {
+		serviceLogoURL = sonos.GetServiceLogoFromName(r.serviceName)
+	}
+	if serviceLogoURL == "" {
+		return rowSkipped, nil
+	}
+	if opts.DryRun {
+		return rowUpdated, nil
+	}
+
+	setDefault(content, "type", "sonos_favorite")
+	setDefault(content, "favoriteId", r.favoriteID)
+	setDefault(content, "name", r.name)
+	setDefault(content, "artworkUrl", r.artworkURL)
+	setDefault(content, "serviceName", r.serviceName)
+	content["serviceLogoUrl"] = serviceLogoURL
+
+	encoded, err := json.Marshal(content)
+	if err != nil {
+		return rowFailed, err
+	}
+	if _, err := env.DB.Writer().Exec(`
+		UPDATE routines SET music_content_json = ?, updated_at = ? WHERE routine_id = ?
+	`, string(encoded), time.Now().UTC().Format(time.RFC3339), r.routineID); err != nil {
+		slog.Warn("MAINTENANCE: Failed to update routine", "routine_id", r.routineID, "error", err)
+		return rowFailed, nil
+	}
+	return rowUpdated, nil
+}
+
+// setDefault sets key to value unless content already has a value for it or value is empty.
+func setDefault(content map[string]any, key, value string) {
+	if value == "" {
+		return
+	}
+	if existing, _ := content[key].(string); existing != "" {
+		return
+	}
+	content[key] = value
+}
@@ -0,0 +1,202 @@
+// Package maintenance implements the data backfills and cache refreshes run by
+// sonos-hub-admin and POST /v1/admin/maintenance.
+package maintenance
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/strefethen/sonos-hub-go/internal/artwork"
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
+)
+
+// DBPair interface for dependency injection (matches db.DBPair).
+type DBPair interface {
+	Reader() *sql.DB
+	Writer() *sql.DB
+}
+
+// Env is what tasks run against. Tasks needing a field that is unset fail.
+type Env struct {
+	DB        DBPair
+	Artwork   *artwork.FavoriteCache
+	Favorites sonos.FavoritesProvider
+}
+
+// Options controls a task run.
+type Options struct {
+	DryRun bool // Report what would change without writing
+	// Progress, if set, is called with the counts so far as rows are processed.
+	Progress func(Result)
+}
+
+func (o Options) progress(result Result) {
+	if o.Progress != nil {
+		o.Progress(result)
+	}
+}
+
+// Result summarizes a task run.
+type Result struct {
+	Task    string
+	DryRun  bool
+	Scanned int
+	Updated int // In a dry run, rows that would be updated
+	Skipped int // Rows that needed no change or had nothing to add
+	Failed  int
+}
+
+// Task is a named maintenance operation.
+type Task struct {
+	Name        string
+	Description string
+	Run         func(env Env, opts Options) (Result, error)
+}
+
+var tasks = map[string]Task{
+	"backfill-icons": {
+		Name:        "backfill-icons",
+		Description: "Add service logos to the music content of routines playing a Sonos favorite",
+		Run:         BackfillIcons,
+	},
+	"backfill-artwork": {
+		Name:        "backfill-artwork",
+		Description: "Store local copies of Sonos favorite artwork for set items",
+		Run:         BackfillArtwork,
+	},
+	"backfill-routine-artwork": {
+		Name:        "backfill-routine-artwork",
+		Description: "Store local copies of Sonos favorite artwork for routines",
+		Run:         BackfillRoutineArtwork,
+	},
+}
+
+// apiTasks are the tasks run through POST /v1/admin/maintenance, by API name.
+var apiTasks = map[string]Task{
+	"backfill_artwork": {
+		Name:        "backfill_artwork",
+		Description: "Store local copies of Sonos favorite artwork for set items and routines",
+		Run:         BackfillAllArtwork,
+	},
+	"backfill_icons": {
+		Name:        "backfill_icons",
+		Description: tasks["backfill-icons"].Description,
+		Run:         BackfillIcons,
+	},
+	"refresh_favorites_cache": {
+		Name:        "refresh_favorites_cache",
+		Description: "Browse Sonos favorites again, replacing the cached list",
+		Run:         RefreshFavoritesCache,
+	},
+}
+
+// Tasks returns every task, sorted by name.
+func Tasks() []Task {
+	list := make([]Task, 0, len(tasks))
+	for _, task := range tasks {
+		list = append(list, task)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Lookup returns the task with name.
+func Lookup(name string) (Task, bool) {
+	task, ok := tasks[name]
+	return task, ok
+}
+
+// LookupAPITask returns the task run through the admin API as name.
+func LookupAPITask(name string) (Task, bool) {
+	task, ok := apiTasks[name]
+	return task, ok
+}
+
+// APITaskNames returns the names LookupAPITask accepts, sorted.
+func APITaskNames() []string {
+	names := make([]string, 0, len(apiTasks))
+	for name := range apiTasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BackfillArtwork localizes the favorite artwork of set items.
+func BackfillArtwork(env Env, opts Options) (Result, error) {
+	if env.Artwork == nil {
+		return Result{}, fmt.Errorf("favorite artwork cache is not configured")
+	}
+	backfill, err := env.Artwork.BackfillSetItems(opts.DryRun, func(backfill artwork.BackfillResult) {
+		opts.progress(artworkResult("backfill-artwork", opts, backfill))
+	})
+	return artworkResult("backfill-artwork", opts, backfill), err
+}
+
+// BackfillRoutineArtwork localizes the favorite artwork of routines.
+func BackfillRoutineArtwork(env Env, opts Options) (Result, error) {
+	if env.Artwork == nil {
+		return Result{}, fmt.Errorf("favorite artwork cache is not configured")
+	}
+	backfill, err := env.Artwork.BackfillRoutines(opts.DryRun, func(backfill artwork.BackfillResult) {
+		opts.progress(artworkResult("backfill-routine-artwork", opts, backfill))
+	})
+	return artworkResult("backfill-routine-artwork", opts, backfill), err
+}
+
+// BackfillAllArtwork localizes the favorite artwork of set items, then routines.
+func BackfillAllArtwork(env Env, opts Options) (Result, error) {
+	if env.Artwork == nil {
+		return Result{}, fmt.Errorf("favorite artwork cache is not configured")
+	}
+	setItems, err := env.Artwork.BackfillSetItems(opts.DryRun, func(backfill artwork.BackfillResult) {
+		opts.progress(artworkResult("backfill_artwork", opts, backfill))
+	})
+	if err != nil {
+		return artworkResult("backfill_artwork", opts, setItems), err
+	}
+	routines, err := env.Artwork.BackfillRoutines(opts.DryRun, func(backfill artwork.BackfillResult) {
+		opts.progress(artworkResult("backfill_artwork", opts, addBackfill(setItems, backfill)))
+	})
+	return artworkResult("backfill_artwork", opts, addBackfill(setItems, routines)), err
+}
+
+// RefreshFavoritesCache discards the cached Sonos favorites and browses them again. A dry
+// run counts the favorites, from the cache when it is fresh.
+func RefreshFavoritesCache(env Env, opts Options) (Result, error) {
+	result := Result{Task: "refresh_favorites_cache", DryRun: opts.DryRun}
+	if env.Favorites == nil {
+		return result, fmt.Errorf("favorites cache is not configured")
+	}
+
+	browse := env.Favorites.RefreshFavorites
+	if opts.DryRun {
+		browse = env.Favorites.BrowseAllFavorites
+	}
+	favorites, err := browse()
+	if err != nil {
+		return result, err
+	}
+	result.Scanned = len(favorites)
+	result.Updated = len(favorites)
+	return result, nil
+}
+
+func addBackfill(a, b artwork.BackfillResult) artwork.BackfillResult {
+	return artwork.BackfillResult{
+		Scanned: a.Scanned + b.Scanned,
+		Updated: a.Updated + b.Updated,
+		Failed:  a.Failed + b.Failed,
+	}
+}
+
+func artworkResult(task string, opts Options, backfill artwork.BackfillResult) Result {
+	return Result{
+		Task:    task,
+		DryRun:  opts.DryRun,
+		Scanned: backfill.Scanned,
+		Updated: backfill.Updated,
+		Failed:  backfill.Failed,
+	}
+}
@@ -0,0 +1,98 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/db"
+)
+
+func setupTestDB(t *testing.T) *db.DBPair {
+	t.Helper()
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+
+	_, err = dbPair.Writer().Exec(`INSERT INTO scenes (scene_id, name) VALUES ('scene-1', 'Kitchen')`)
+	require.NoError(t, err)
+	return dbPair
+}
+
+func insertRoutine(t *testing.T, dbPair *db.DBPair, id, serviceName, serviceLogoURL, contentJSON string) {
+	t.Helper()
+	_, err := dbPair.Writer().Exec(`
+		INSERT INTO routines (routine_id, name, timezone, schedule_time, scene_id, music_sonos_favorite_id,
+			music_sonos_favorite_name, music_sonos_favorite_service_name, music_sonos_favorite_service_logo_url,
+			music_content_json, created_at, updated_at)
+		VALUES (?, ?, 'UTC', '07:00', 'scene-1', 'FV:2/' || ?, 'Jazz', NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''),
+			'2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z')
+	`, id, id, id, serviceName, serviceLogoURL, contentJSON)
+	require.NoError(t, err)
+}
+
+func routineContent(t *testing.T, dbPair *db.DBPair, id string) map[string]any {
+	t.Helper()
+	var contentJSON *string
+	require.NoError(t, dbPair.Reader().QueryRow(
+		`SELECT music_content_json FROM routines WHERE routine_id = ?`, id).Scan(&contentJSON))
+	if contentJSON == nil {
+		return nil
+	}
+	var content map[string]any
+	require.NoError(t, json.Unmarshal([]byte(*contentJSON), &content))
+	return content
+}
+
+func TestBackfillIcons(t *testing.T) {
+	dbPair := setupTestDB(t)
+	insertRoutine(t, dbPair, "1", "Spotify", "", "")
+	insertRoutine(t, dbPair, "2", "", "/v1/assets/service-logos/tidal.png", `{"type":"sonos_favorite","favoriteId":"FV:2/2","artworkUrl":"/v1/assets/artwork/abc"}`)
+	insertRoutine(t, dbPair, "3", "Spotify", "", `{"serviceLogoUrl":"/v1/assets/service-logos/spotify.png"}`)
+	insertRoutine(t, dbPair, "4", "", "", "")
+	insertRoutine(t, dbPair, "5", "Spotify", "", `{not json`)
+	env := Env{DB: dbPair}
+
+	// A dry run counts without writing
+	result, err := BackfillIcons(env, Options{DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, Result{Task: "backfill-icons", DryRun: true, Scanned: 5, Updated: 2, Skipped: 2, Failed: 1}, result)
+	require.Nil(t, routineContent(t, dbPair, "1"))
+
+	result, err = BackfillIcons(env, Options{})
+	require.NoError(t, err)
+	require.Equal(t, Result{Task: "backfill-icons", Scanned: 5, Updated: 2, Skipped: 2, Failed: 1}, result)
+
+	require.Equal(t, map[string]any{
+		"type":           "sonos_favorite",
+		"favoriteId":     "FV:2/1",
+		"name":           "Jazz",
+		"serviceName":    "Spotify",
+		"serviceLogoUrl": "/v1/assets/service-logos/spotify.png",
+	}, routineContent(t, dbPair, "1"))
+
+	// Existing content is kept
+	content := routineContent(t, dbPair, "2")
+	require.Equal(t, "/v1/assets/artwork/abc", content["artworkUrl"])
+	require.Equal(t, "/v1/assets/service-logos/tidal.png", content["serviceLogoUrl"])
+
+	// A second run has nothing left to add
+	result, err = BackfillIcons(env, Options{})
+	require.NoError(t, err)
+	require.Equal(t, 0, result.Updated)
+}
+
+func TestArtworkTasksRequireCache(t *testing.T) {
+	for _, name := range []string{"backfill-artwork", "backfill-routine-artwork"} {
+		task, ok := Lookup(name)
+		require.True(t, ok)
+		_, err := task.Run(Env{DB: setupTestDB(t)}, Options{DryRun: true})
+		require.Error(t, err, name)
+	}
+	_, ok := Lookup("backfill-everything")
+	require.False(t, ok)
+	require.Len(t, Tasks(), 3)
+}
@@ -0,0 +1,86 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+)
+
+// RegisterRoutes wires maintenance routes to the router.
+func RegisterRoutes(router chi.Router, runner *Runner) {
+	router.Method(http.MethodPost, "/v1/admin/maintenance", api.Handler(startTaskHandler(runner)))
+	router.Method(http.MethodGet, "/v1/admin/maintenance/{task_id}", api.Handler(getTaskHandler(runner)))
+}
+
+// startTaskHandler handles POST /v1/admin/maintenance
+func startTaskHandler(runner *Runner) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var body struct {
+			Task   string `json:"task"`
+			DryRun bool   `json:"dry_run"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return apperrors.NewValidationError("invalid request body", nil)
+		}
+		task, ok := LookupAPITask(body.Task)
+		if !ok {
+			return apperrors.NewValidationError("task must be one of the supported maintenance tasks", map[string]any{
+				"task":      body.Task,
+				"supported": APITaskNames(),
+			})
+		}
+
+		run, err := runner.Start(task, body.DryRun)
+		if errors.Is(err, ErrTaskRunning) {
+			return apperrors.NewConflictError("A maintenance task is already running", map[string]any{
+				"task_id": run.ID,
+				"task":    run.Task,
+			})
+		}
+		if err != nil {
+			return apperrors.NewInternalError("Failed to start maintenance task")
+		}
+		return api.WriteResource(w, http.StatusAccepted, formatRun(run))
+	}
+}
+
+// getTaskHandler handles GET /v1/admin/maintenance/{task_id}
+func getTaskHandler(runner *Runner) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		taskID := chi.URLParam(r, "task_id")
+		run, ok := runner.Get(taskID)
+		if !ok {
+			return apperrors.NewNotFoundResource("Maintenance task", taskID)
+		}
+		return api.WriteResource(w, http.StatusOK, formatRun(run))
+	}
+}
+
+func formatRun(run Run) map[string]any {
+	response := map[string]any{
+		"object":      api.ObjectMaintenanceTask,
+		"id":          run.ID,
+		"task":        run.Task,
+		"dry_run":     run.DryRun,
+		"status":      run.Status,
+		"scanned":     run.Result.Scanned,
+		"updated":     run.Result.Updated,
+		"skipped":     run.Result.Skipped,
+		"errors":      run.Result.Failed,
+		"error":       nil,
+		"started_at":  api.RFC3339Millis(run.StartedAt),
+		"finished_at": nil,
+	}
+	if run.Error != "" {
+		response["error"] = run.Error
+	}
+	if !run.FinishedAt.IsZero() {
+		response["finished_at"] = api.RFC3339Millis(run.FinishedAt)
+	}
+	return response
+}
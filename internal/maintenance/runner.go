@@ -0,0 +1,185 @@
+package maintenance
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/strefethen/sonos-hub-go/internal/audit"
+)
+
+// Run statuses.
+const (
+	RunStatusRunning   = "running"
+	RunStatusCompleted = "completed"
+	RunStatusFailed    = "failed"
+)
+
+// finishedRunRetention is how long a finished run can still be looked up.
+const finishedRunRetention = time.Hour
+
+// ErrTaskRunning is returned when a task is started while another is running.
+var ErrTaskRunning = errors.New("a maintenance task is already running")
+
+// EventRecorder records audit events (implemented by audit.Service).
+type EventRecorder interface {
+	RecordEvent(input audit.WriteEventInput) (*audit.AuditEvent, error)
+}
+
+// Run is a snapshot of a task run.
+type Run struct {
+	ID         string
+	Task       string
+	DryRun     bool
+	Status     string
+	Result     Result
+	Error      string
+	StartedAt  time.Time
+	FinishedAt time.Time // Zero while running
+}
+
+// Runner runs maintenance tasks in the background, one at a time. Each run's start and
+// outcome are written to the audit log. Tasks only touch rows that still need work, so a
+// run interrupted by a restart is resumed by starting the task again.
+type Runner struct {
+	env    Env
+	events EventRecorder
+	now    func() time.Time
+
+	mu     sync.Mutex
+	runs   map[string]*Run // By ID, including recently finished runs
+	active *Run
+	wg     sync.WaitGroup
+}
+
+// NewRunner creates a Runner. events may be nil.
+func NewRunner(env Env, events EventRecorder) *Runner {
+	return &Runner{
+		env:    env,
+		events: events,
+		now:    time.Now,
+		runs:   make(map[string]*Run),
+	}
+}
+
+// Start begins running task and returns at once, or returns ErrTaskRunning along with
+// the run in progress.
+func (r *Runner) Start(task Task, dryRun bool) (Run, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.active != nil {
+		return *r.active, ErrTaskRunning
+	}
+	r.pruneLocked()
+
+	run := &Run{
+		ID:        uuid.NewString(),
+		Task:      task.Name,
+		DryRun:    dryRun,
+		Status:    RunStatusRunning,
+		Result:    Result{Task: task.Name, DryRun: dryRun},
+		StartedAt: r.now(),
+	}
+	r.runs[run.ID] = run
+	r.active = run
+
+	r.wg.Add(1)
+	go r.run(task, run)
+	return *run, nil
+}
+
+// Get returns the run with id.
+func (r *Runner) Get(id string) (Run, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run, ok := r.runs[id]
+	if !ok {
+		return Run{}, false
+	}
+	return *run, true
+}
+
+// Wait blocks until the running task, if any, finishes.
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
+
+func (r *Runner) run(task Task, run *Run) {
+	defer r.wg.Done()
+
+	r.record(audit.EventMaintenanceStarted, audit.LevelInfo, "Maintenance task "+task.Name+" started", run, Result{}, nil)
+
+	result, err := task.Run(r.env, Options{
+		DryRun: run.DryRun,
+		Progress: func(progress Result) {
+			r.mu.Lock()
+			run.Result = progress
+			r.mu.Unlock()
+		},
+	})
+	result.Task, result.DryRun = task.Name, run.DryRun
+
+	r.mu.Lock()
+	run.Result = result
+	run.FinishedAt = r.now()
+	run.Status = RunStatusCompleted
+	if err != nil {
+		run.Status = RunStatusFailed
+		run.Error = err.Error()
+	}
+	r.active = nil
+	r.mu.Unlock()
+
+	if err != nil {
+		slog.Error("MAINTENANCE: Task failed", "task", task.Name, "error", err)
+		r.record(audit.EventMaintenanceFailed, audit.LevelError, "Maintenance task "+task.Name+" failed: "+err.Error(), run, result, err)
+		return
+	}
+	level := audit.LevelInfo
+	if result.Failed > 0 {
+		level = audit.LevelWarn
+	}
+	r.record(audit.EventMaintenanceCompleted, level, "Maintenance task "+task.Name+" completed", run, result, nil)
+}
+
+func (r *Runner) record(eventType audit.EventType, level audit.EventLevel, message string, run *Run, result Result, err error) {
+	if r.events == nil {
+		return
+	}
+	payload := map[string]any{
+		"task_id": run.ID,
+		"task":    run.Task,
+		"dry_run": run.DryRun,
+	}
+	if eventType != audit.EventMaintenanceStarted {
+		payload["scanned"] = result.Scanned
+		payload["updated"] = result.Updated
+		payload["skipped"] = result.Skipped
+		payload["errors"] = result.Failed
+	}
+	if err != nil {
+		payload["error"] = err.Error()
+	}
+	if _, recordErr := r.events.RecordEvent(audit.WriteEventInput{
+		Type:    string(eventType),
+		Level:   &level,
+		Message: message,
+		Payload: payload,
+	}); recordErr != nil {
+		slog.Warn("MAINTENANCE: Failed to record audit event", "error", recordErr)
+	}
+}
+
+// pruneLocked forgets runs that finished more than finishedRunRetention ago.
+func (r *Runner) pruneLocked() {
+	cutoff := r.now().Add(-finishedRunRetention)
+	for id, run := range r.runs {
+		if run.Status != RunStatusRunning && run.FinishedAt.Before(cutoff) {
+			delete(r.runs, id)
+		}
+	}
+}
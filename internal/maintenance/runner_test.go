@@ -0,0 +1,141 @@
+package maintenance
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/audit"
+)
+
+// fakeRecorder keeps recorded audit events.
+type fakeRecorder struct {
+	mu     sync.Mutex
+	events []audit.WriteEventInput
+}
+
+func (f *fakeRecorder) RecordEvent(input audit.WriteEventInput) (*audit.AuditEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, input)
+	return &audit.AuditEvent{}, nil
+}
+
+func (f *fakeRecorder) types() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	types := make([]string, 0, len(f.events))
+	for _, event := range f.events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func TestRunner_OneTaskAtATime(t *testing.T) {
+	recorder := &fakeRecorder{}
+	runner := NewRunner(Env{}, recorder)
+
+	release := make(chan struct{})
+	progressed := make(chan struct{})
+	blocking := Task{Name: "blocking", Run: func(env Env, opts Options) (Result, error) {
+		opts.Progress(Result{Scanned: 1, Updated: 1})
+		close(progressed)
+		<-release
+		return Result{Scanned: 2, Updated: 1, Failed: 1}, nil
+	}}
+
+	run, err := runner.Start(blocking, false)
+	require.NoError(t, err)
+	require.Equal(t, RunStatusRunning, run.Status)
+
+	<-progressed
+	current, ok := runner.Get(run.ID)
+	require.True(t, ok)
+	require.Equal(t, 1, current.Result.Scanned)
+
+	active, err := runner.Start(blocking, true)
+	require.ErrorIs(t, err, ErrTaskRunning)
+	require.Equal(t, run.ID, active.ID)
+
+	close(release)
+	runner.Wait()
+
+	finished, ok := runner.Get(run.ID)
+	require.True(t, ok)
+	require.Equal(t, RunStatusCompleted, finished.Status)
+	require.Equal(t, Result{Task: "blocking", Scanned: 2, Updated: 1, Failed: 1}, finished.Result)
+	require.False(t, finished.FinishedAt.IsZero())
+
+	require.Equal(t, []string{string(audit.EventMaintenanceStarted), string(audit.EventMaintenanceCompleted)}, recorder.types())
+	completed := recorder.events[1]
+	require.Equal(t, audit.LevelWarn, *completed.Level)
+	require.Equal(t, run.ID, completed.Payload["task_id"])
+	require.Equal(t, 1, completed.Payload["errors"])
+
+	// Another task can start once the first has finished
+	_, err = runner.Start(Task{Name: "next", Run: func(Env, Options) (Result, error) { return Result{}, nil }}, false)
+	require.NoError(t, err)
+	runner.Wait()
+}
+
+func TestMaintenanceRoutes(t *testing.T) {
+	dbPair := setupTestDB(t)
+	insertRoutine(t, dbPair, "1", "Spotify", "", "")
+	recorder := &fakeRecorder{}
+	runner := NewRunner(Env{DB: dbPair}, recorder)
+
+	router := chi.NewRouter()
+	RegisterRoutes(router, runner)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/admin/maintenance", bytes.NewBufferString(body)))
+		return rec
+	}
+
+	require.Equal(t, http.StatusBadRequest, post(`{"task":"drop_tables"}`).Code)
+
+	rec := post(`{"task":"backfill_icons","dry_run":true}`)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	var started map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &started))
+	require.Equal(t, "maintenance_task", started["object"])
+	require.Equal(t, "backfill_icons", started["task"])
+	require.Equal(t, true, started["dry_run"])
+	runner.Wait()
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/admin/maintenance/"+started["id"].(string), nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var finished map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &finished))
+	require.Equal(t, "completed", finished["status"])
+	require.Equal(t, float64(1), finished["scanned"])
+	require.Equal(t, float64(1), finished["updated"])
+	require.Equal(t, float64(0), finished["errors"])
+	require.NotNil(t, finished["finished_at"])
+
+	// The dry run left the routine alone
+	require.Nil(t, routineContent(t, dbPair, "1"))
+
+	// Artwork needs the favorite artwork cache, which isn't set up here
+	rec = post(`{"task":"backfill_artwork"}`)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	runner.Wait()
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &started))
+	failed, ok := runner.Get(started["id"].(string))
+	require.True(t, ok)
+	require.Equal(t, RunStatusFailed, failed.Status)
+	require.NotEmpty(t, failed.Error)
+	require.Contains(t, recorder.types(), string(audit.EventMaintenanceFailed))
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/admin/maintenance/unknown", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,51 @@
+// Package mdns advertises the hub on the local network over multicast DNS, so apps can
+// find it without the user typing in its address.
+package mdns
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/grandcat/zeroconf"
+)
+
+// ServiceType is the DNS-SD service type the hub registers.
+const ServiceType = "_sonos-hub._tcp"
+
+const domain = "local."
+
+// Advertiser keeps the hub's mDNS registration alive until stopped.
+type Advertiser struct {
+	server *zeroconf.Server
+}
+
+// Advertise registers instance as a ServiceType service on port, on every multicast
+// interface, with txt as its TXT record.
+func Advertise(instance string, port int, txt map[string]string) (*Advertiser, error) {
+	server, err := zeroconf.Register(instance, ServiceType, domain, port, txtRecord(txt), nil)
+	if err != nil {
+		return nil, fmt.Errorf("register mDNS service: %w", err)
+	}
+	slog.Info("MDNS: Advertising hub", "instance", instance, "service", ServiceType, "port", port)
+	return &Advertiser{server: server}, nil
+}
+
+// Stop withdraws the registration. Safe to call on a nil Advertiser.
+func (a *Advertiser) Stop() {
+	if a == nil || a.server == nil {
+		return
+	}
+	a.server.Shutdown()
+	a.server = nil
+}
+
+// txtRecord encodes txt as key=value strings, sorted by key so the record is stable.
+func txtRecord(txt map[string]string) []string {
+	record := make([]string, 0, len(txt))
+	for key, value := range txt {
+		record = append(record, key+"="+value)
+	}
+	sort.Strings(record)
+	return record
+}
@@ -0,0 +1,19 @@
+package mdns
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTXTRecord(t *testing.T) {
+	require.Equal(t,
+		[]string{"api_version=v1", "hub_id=hub-1", "version=1.0.0"},
+		txtRecord(map[string]string{"version": "1.0.0", "hub_id": "hub-1", "api_version": "v1"}))
+	require.Empty(t, txtRecord(nil))
+}
+
+func TestAdvertiser_StopNil(t *testing.T) {
+	var advertiser *Advertiser
+	advertiser.Stop()
+}
@@ -0,0 +1,317 @@
+// Package mqtt bridges the hub to an MQTT broker for Home Assistant and other MQTT
+// setups: it publishes each speaker's playback state and routine events, and plays,
+// pauses and sets volume on command.
+//
+// Topics, under the configured prefix:
+//
+//	<prefix>/status             online or offline (retained, offline is the last will)
+//	<prefix>/<udn>/state        JSON playback state of speaker <udn>'s group, with its own volume (retained)
+//	<prefix>/events/<event>     routine.completed and routine.failed, as JSON
+//	<prefix>/<udn>/set/volume   0-100
+//	<prefix>/<udn>/set/play     payload ignored
+//	<prefix>/<udn>/set/pause    payload ignored
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/webhooks"
+)
+
+// DefaultStateInterval is used when no state interval is configured.
+const DefaultStateInterval = 5 * time.Second
+
+// disconnectTimeout bounds publishing offline and disconnecting at shutdown.
+const disconnectTimeout = 2 * time.Second
+
+// Config configures the connection to the broker.
+type Config struct {
+	BrokerURL     string
+	ClientID      string
+	Username      string
+	Password      string
+	TopicPrefix   string
+	StateInterval time.Duration
+}
+
+// Controller runs commands on speakers (implemented by sonos.Service).
+type Controller interface {
+	ResolveDeviceIP(deviceID string) (string, error)
+	Play(deviceIP string) error
+	Pause(deviceIP string) error
+	SetVolume(deviceIP string, level int) error
+}
+
+// StateSource reports what each speaker is playing, with its own volume and mute
+// (implemented by sonos.Service).
+type StateSource interface {
+	NowPlayingDevices() ([]map[string]any, error)
+}
+
+// publishFunc sends a message to the broker without waiting for it.
+type publishFunc func(topic string, retained bool, payload []byte)
+
+// Bridge publishes state and events to the broker and runs commands received from it.
+type Bridge struct {
+	prefix     string
+	interval   time.Duration
+	controller Controller
+	state      StateSource
+	logger     *slog.Logger
+	client     paho.Client
+	publish    publishFunc
+
+	mu        sync.Mutex
+	published map[string][]byte // Last state published per speaker UDN
+
+	refreshCh chan struct{}
+	stopCh    chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewBridge creates a bridge to the broker in cfg. Nothing connects until Start.
+func NewBridge(cfg Config, controller Controller, state StateSource, logger *slog.Logger) *Bridge {
+	bridge := newBridge(cfg, controller, state, nil, logger)
+
+	options := paho.NewClientOptions().
+		AddBroker(cfg.BrokerURL).
+		SetClientID(cfg.ClientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetWill(bridge.topic("status"), "offline", 1, true).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetOrderMatters(false). // Commands make SOAP calls; don't hold up other messages
+		SetOnConnectHandler(bridge.onConnect).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			bridge.logger.Warn("MQTT: Connection lost, reconnecting", "error", err)
+		})
+	bridge.client = paho.NewClient(options)
+	bridge.publish = func(topic string, retained bool, payload []byte) {
+		bridge.client.Publish(topic, 1, retained, payload)
+	}
+	return bridge
+}
+
+func newBridge(cfg Config, controller Controller, state StateSource, publish publishFunc, logger *slog.Logger) *Bridge {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	interval := cfg.StateInterval
+	if interval <= 0 {
+		interval = DefaultStateInterval
+	}
+	return &Bridge{
+		prefix:     cfg.TopicPrefix,
+		interval:   interval,
+		controller: controller,
+		state:      state,
+		logger:     logger,
+		publish:    publish,
+		published:  make(map[string][]byte),
+		refreshCh:  make(chan struct{}, 1),
+		stopCh:     make(chan struct{}),
+	}
+}
+
+// Start connects in the background, retrying until the broker answers, and starts
+// publishing state.
+func (b *Bridge) Start() {
+	b.logger.Info("MQTT: Connecting", "topic_prefix", b.prefix)
+	b.client.Connect()
+
+	b.wg.Add(1)
+	go b.runStateLoop()
+}
+
+// Stop stops publishing, marks the hub offline and disconnects.
+func (b *Bridge) Stop() {
+	close(b.stopCh)
+	b.wg.Wait()
+
+	if b.client.IsConnected() {
+		b.client.Publish(b.topic("status"), 1, true, "offline").WaitTimeout(disconnectTimeout)
+	}
+	b.client.Disconnect(uint(disconnectTimeout.Milliseconds()))
+	b.logger.Info("MQTT: Disconnected")
+}
+
+// Publish sends a routine event to <prefix>/events/<event>. It doesn't wait for the broker.
+func (b *Bridge) Publish(event webhooks.Event, data map[string]any) {
+	payload, err := json.Marshal(map[string]any{
+		"event":      event,
+		"created_at": api.RFC3339Millis(time.Now()),
+		"data":       data,
+	})
+	if err != nil {
+		b.logger.Warn("MQTT: Failed to encode event", "event", event, "error", err)
+		return
+	}
+	b.publish(b.topic("events", string(event)), false, payload)
+}
+
+// onConnect runs on every connection, including reconnects.
+func (b *Bridge) onConnect(client paho.Client) {
+	b.logger.Info("MQTT: Connected")
+	client.Publish(b.topic("status"), 1, true, "online")
+	client.Subscribe(b.topic("+", "set", "+"), 1, func(_ paho.Client, message paho.Message) {
+		if err := b.handleCommand(message.Topic(), message.Payload()); err != nil {
+			b.logger.Warn("MQTT: Command failed", "topic", message.Topic(), "error", err)
+		}
+	})
+
+	// The broker may have lost retained state while we were away
+	b.mu.Lock()
+	b.published = make(map[string][]byte)
+	b.mu.Unlock()
+	b.requestRefresh()
+}
+
+// handleCommand runs the command in a <prefix>/<udn>/set/<command> message.
+func (b *Bridge) handleCommand(topic string, payload []byte) error {
+	parts := strings.Split(strings.TrimPrefix(topic, b.prefix+"/"), "/")
+	if len(parts) != 3 || parts[1] != "set" {
+		return fmt.Errorf("unexpected topic %q", topic)
+	}
+	udn, command := parts[0], parts[2]
+
+	var run func(deviceIP string) error
+	switch command {
+	case "play":
+		run = b.controller.Play
+	case "pause":
+		run = b.controller.Pause
+	case "volume":
+		level, err := strconv.Atoi(strings.TrimSpace(string(payload)))
+		if err != nil || level < 0 || level > 100 {
+			return fmt.Errorf("volume must be 0-100, got %q", payload)
+		}
+		run = func(deviceIP string) error {
+			return b.controller.SetVolume(deviceIP, level)
+		}
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+
+	deviceIP, err := b.controller.ResolveDeviceIP(udn)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", udn, err)
+	}
+	if err := run(deviceIP); err != nil {
+		return err
+	}
+	b.requestRefresh()
+	return nil
+}
+
+// requestRefresh publishes state on the next pass of the state loop rather than
+// waiting for the interval.
+func (b *Bridge) requestRefresh() {
+	select {
+	case b.refreshCh <- struct{}{}:
+	default:
+	}
+}
+
+// runStateLoop publishes state every interval, and on request, while connected.
+func (b *Bridge) runStateLoop() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stopCh:
+			return
+		case <-ticker.C:
+		case <-b.refreshCh:
+		}
+		if !b.client.IsConnected() {
+			continue
+		}
+		devices, err := b.state.NowPlayingDevices()
+		if err != nil {
+			b.logger.Warn("MQTT: Failed to fetch playback state", "error", err)
+			continue
+		}
+		b.publishStates(devices)
+	}
+}
+
+// publishStates publishes each speaker's state that changed since it was last published,
+// and clears the retained state of speakers no longer reported.
+func (b *Bridge) publishStates(devices []map[string]any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	current := make(map[string]bool, len(devices))
+	for _, device := range devices {
+		udn, _ := device["udn"].(string)
+		if udn == "" {
+			continue
+		}
+		current[udn] = true
+
+		payload, err := json.Marshal(deviceState(device))
+		if err != nil {
+			b.logger.Warn("MQTT: Failed to encode state", "udn", udn, "error", err)
+			continue
+		}
+		if string(payload) == string(b.published[udn]) {
+			continue
+		}
+		b.publish(b.topic(udn, "state"), true, payload)
+		b.published[udn] = payload
+	}
+
+	for udn := range b.published {
+		if !current[udn] {
+			// An empty retained message deletes the retained state
+			b.publish(b.topic(udn, "state"), true, nil)
+			delete(b.published, udn)
+		}
+	}
+}
+
+// deviceState is the state published for a speaker: its group's playback with its own
+// volume and mute. The track position is left out: it changes every poll while playing.
+func deviceState(device map[string]any) map[string]any {
+	state := map[string]any{
+		"room_name":      device["room_name"],
+		"coordinator_id": device["coordinator_id"],
+		"member_rooms":   device["member_rooms"],
+	}
+	playback, _ := device["playback"].(map[string]any)
+	state["state"] = playback["state"]
+	state["volume"] = playback["volume"]
+	state["muted"] = playback["muted"]
+	state["is_tv"] = playback["isTV"]
+	state["container"] = playback["container"]
+
+	var track map[string]any
+	if current, ok := playback["track"].(map[string]any); ok {
+		track = make(map[string]any, len(current))
+		for key, value := range current {
+			if key != "position_seconds" {
+				track[key] = value
+			}
+		}
+	}
+	state["track"] = track
+	return state
+}
+
+// topic joins parts under the prefix.
+func (b *Bridge) topic(parts ...string) string {
+	return b.prefix + "/" + strings.Join(parts, "/")
+}
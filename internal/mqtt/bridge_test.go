@@ -0,0 +1,166 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/webhooks"
+)
+
+type message struct {
+	topic    string
+	retained bool
+	payload  []byte
+}
+
+type fakeController struct {
+	calls []string
+	err   error
+}
+
+func (c *fakeController) ResolveDeviceIP(deviceID string) (string, error) {
+	if deviceID == "RINCON_UNKNOWN" {
+		return "", errors.New("device not found")
+	}
+	return "192.168.1.20", nil
+}
+
+func (c *fakeController) Play(deviceIP string) error {
+	c.calls = append(c.calls, "play "+deviceIP)
+	return c.err
+}
+
+func (c *fakeController) Pause(deviceIP string) error {
+	c.calls = append(c.calls, "pause "+deviceIP)
+	return c.err
+}
+
+func (c *fakeController) SetVolume(deviceIP string, level int) error {
+	c.calls = append(c.calls, fmt.Sprintf("volume %s %d", deviceIP, level))
+	return c.err
+}
+
+func newTestBridge(controller Controller) (*Bridge, *[]message) {
+	var messages []message
+	publish := func(topic string, retained bool, payload []byte) {
+		messages = append(messages, message{topic: topic, retained: retained, payload: payload})
+	}
+	return newBridge(Config{TopicPrefix: "sonos-hub"}, controller, nil, publish, logging.Discard()), &messages
+}
+
+func nowPlayingDevice(udn, coordinatorUDN, state string, volume int, position int) map[string]any {
+	return map[string]any{
+		"udn":            udn,
+		"coordinator_id": coordinatorUDN,
+		"room_name":      "Kitchen",
+		"member_rooms":   []string{},
+		"playback": map[string]any{
+			"state":  state,
+			"volume": volume,
+			"muted":  false,
+			"isTV":   false,
+			"track": map[string]any{
+				"title":            "Blue in Green",
+				"artist":           "Miles Davis",
+				"position_seconds": position,
+			},
+			"container": nil,
+		},
+	}
+}
+
+func TestPublishStates(t *testing.T) {
+	bridge, messages := newTestBridge(&fakeController{})
+
+	bridge.publishStates([]map[string]any{nowPlayingDevice("RINCON_KITCHEN", "RINCON_KITCHEN", "PLAYING", 25, 10)})
+	require.Len(t, *messages, 1)
+	require.Equal(t, "sonos-hub/RINCON_KITCHEN/state", (*messages)[0].topic)
+	require.True(t, (*messages)[0].retained)
+
+	var state map[string]any
+	require.NoError(t, json.Unmarshal((*messages)[0].payload, &state))
+	require.Equal(t, "PLAYING", state["state"])
+	require.Equal(t, float64(25), state["volume"])
+	require.Equal(t, "Kitchen", state["room_name"])
+	require.Equal(t, "Blue in Green", state["track"].(map[string]any)["title"])
+	require.NotContains(t, state["track"], "position_seconds")
+
+	// The track moving on isn't a change
+	bridge.publishStates([]map[string]any{nowPlayingDevice("RINCON_KITCHEN", "RINCON_KITCHEN", "PLAYING", 25, 40)})
+	require.Len(t, *messages, 1)
+
+	bridge.publishStates([]map[string]any{nowPlayingDevice("RINCON_KITCHEN", "RINCON_KITCHEN", "PLAYING", 30, 45)})
+	require.Len(t, *messages, 2, "a volume change is published")
+
+	// The kitchen joined the den's group: each speaker has its own state and volume
+	bridge.publishStates([]map[string]any{
+		nowPlayingDevice("RINCON_DEN", "RINCON_DEN", "PAUSED_PLAYBACK", 10, 0),
+		nowPlayingDevice("RINCON_KITCHEN", "RINCON_DEN", "PAUSED_PLAYBACK", 30, 0),
+	})
+	require.Len(t, *messages, 4)
+	require.Equal(t, "sonos-hub/RINCON_DEN/state", (*messages)[2].topic)
+	require.Equal(t, "sonos-hub/RINCON_KITCHEN/state", (*messages)[3].topic)
+	require.NoError(t, json.Unmarshal((*messages)[3].payload, &state))
+	require.Equal(t, float64(30), state["volume"])
+	require.Equal(t, "RINCON_DEN", state["coordinator_id"])
+
+	// The kitchen went offline: its retained state is cleared
+	bridge.publishStates([]map[string]any{nowPlayingDevice("RINCON_DEN", "RINCON_DEN", "PAUSED_PLAYBACK", 10, 0)})
+	require.Len(t, *messages, 5)
+	require.Equal(t, message{topic: "sonos-hub/RINCON_KITCHEN/state", retained: true}, (*messages)[4])
+}
+
+func TestHandleCommand(t *testing.T) {
+	tests := []struct {
+		topic     string
+		payload   string
+		wantCalls []string
+		wantErr   bool
+	}{
+		{"sonos-hub/RINCON_KITCHEN/set/play", "", []string{"play 192.168.1.20"}, false},
+		{"sonos-hub/RINCON_KITCHEN/set/pause", "PAUSE", []string{"pause 192.168.1.20"}, false},
+		{"sonos-hub/RINCON_KITCHEN/set/volume", " 35\n", []string{"volume 192.168.1.20 35"}, false},
+		{"sonos-hub/RINCON_KITCHEN/set/volume", "101", nil, true},
+		{"sonos-hub/RINCON_KITCHEN/set/volume", "loud", nil, true},
+		{"sonos-hub/RINCON_KITCHEN/set/next", "", nil, true},
+		{"sonos-hub/RINCON_UNKNOWN/set/play", "", nil, true},
+		{"sonos-hub/RINCON_KITCHEN/get/play", "", nil, true},
+	}
+	for _, tt := range tests {
+		controller := &fakeController{}
+		bridge, _ := newTestBridge(controller)
+
+		err := bridge.handleCommand(tt.topic, []byte(tt.payload))
+		if tt.wantErr {
+			require.Error(t, err, tt.topic)
+		} else {
+			require.NoError(t, err, tt.topic)
+		}
+		require.Equal(t, tt.wantCalls, controller.calls, tt.topic)
+	}
+
+	// Speaker errors are reported
+	controller := &fakeController{err: errors.New("timeout")}
+	bridge, _ := newTestBridge(controller)
+	require.EqualError(t, bridge.handleCommand("sonos-hub/RINCON_KITCHEN/set/play", nil), "timeout")
+}
+
+func TestPublishEvent(t *testing.T) {
+	bridge, messages := newTestBridge(&fakeController{})
+
+	bridge.Publish(webhooks.EventRoutineFailed, map[string]any{"routine_id": "routine-1", "error": "speaker offline"})
+
+	require.Len(t, *messages, 1)
+	require.Equal(t, "sonos-hub/events/routine.failed", (*messages)[0].topic)
+	require.False(t, (*messages)[0].retained)
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal((*messages)[0].payload, &payload))
+	require.Equal(t, "routine.failed", payload["event"])
+	require.Equal(t, "routine-1", payload["data"].(map[string]any)["routine_id"])
+	require.NotEmpty(t, payload["created_at"])
+}
@@ -0,0 +1,66 @@
+package music
+
+import (
+	"net/http"
+
+	"github.com/strefethen/sonos-hub-go/internal/audit"
+)
+
+// recordSetChange audits a successful change to a set. setID is empty for changes that
+// span every set, like reordering them.
+func recordSetChange(r *http.Request, recorder audit.Recorder, eventType audit.EventType, action, setID string, before, after any) {
+	audit.Record(r.Context(), recorder, audit.Change{
+		Type:         eventType,
+		Action:       action,
+		ResourceType: audit.ResourceMusicSet,
+		ResourceID:   setID,
+		Before:       before,
+		After:        after,
+	})
+}
+
+// recordItemsChange audits a change to a set's items as the item order before and after.
+func recordItemsChange(r *http.Request, service *Service, recorder audit.Recorder, action, setID string, before map[string]any) {
+	if recorder == nil {
+		return
+	}
+	recordSetChange(r, recorder, audit.EventMusicSetUpdated, action, setID, before, itemOrder(service, recorder, setID))
+}
+
+// setBeforeChange reads a set for the audit diff of a change about to be made.
+func setBeforeChange(service *Service, recorder audit.Recorder, setID string) *MusicSet {
+	return audit.Before(recorder, func() (*MusicSet, error) { return service.GetSet(setID) })
+}
+
+// setOrder returns the set order, for auditing a reorder, as {"set_ids": [...]}.
+func setOrder(service *Service, recorder audit.Recorder) map[string]any {
+	if recorder == nil {
+		return nil
+	}
+	sets, _, err := service.ListSets(1000, 0)
+	if err != nil {
+		return nil
+	}
+	setIDs := make([]string, 0, len(sets))
+	for _, set := range sets {
+		setIDs = append(setIDs, set.SetID)
+	}
+	return map[string]any{"set_ids": setIDs}
+}
+
+// itemOrder returns a set's items, for auditing changes to them, in order as
+// {"items": [sonos_favorite_id, ...]}.
+func itemOrder(service *Service, recorder audit.Recorder, setID string) map[string]any {
+	if recorder == nil {
+		return nil
+	}
+	items, err := service.GetItems(setID)
+	if err != nil {
+		return nil
+	}
+	favoriteIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		favoriteIDs = append(favoriteIDs, item.SonosFavoriteID)
+	}
+	return map[string]any{"items": favoriteIDs}
+}
@@ -0,0 +1,277 @@
+package music
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/artwork"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// SetExportObject identifies a set export document.
+const SetExportObject = "music_set_export"
+
+// SetExportVersion is the version of set export documents this hub writes. Documents
+// from older versions can be imported.
+const SetExportVersion = 1
+
+// maxImportRenames bounds the "Name (N)" names tried when an import collides.
+const maxImportRenames = 100
+
+// FavoriteCatalog lists the system's Sonos favorites (implemented by sonos.Service).
+type FavoriteCatalog interface {
+	BrowseAllFavorites() ([]soap.FavoriteItem, error)
+}
+
+// SetExport is a music set as a self-contained document, for copying it to another hub.
+// It has no database IDs; importing it creates a new set.
+type SetExport struct {
+	Object     string         `json:"object"`
+	Version    int            `json:"version"`
+	ExportedAt string         `json:"exported_at"`
+	Set        ExportedSet    `json:"set"`
+	Items      []ExportedItem `json:"items"`
+}
+
+// ExportedSet is the set metadata in a SetExport.
+type ExportedSet struct {
+	Name            string  `json:"name"`
+	SelectionPolicy string  `json:"selection_policy"`
+	OccasionStart   *string `json:"occasion_start,omitempty"` // MM-DD format
+	OccasionEnd     *string `json:"occasion_end,omitempty"`   // MM-DD format
+}
+
+// ExportedItem is one set item in a SetExport, in set order.
+type ExportedItem struct {
+	SonosFavoriteID string  `json:"sonos_favorite_id"`
+	ContentType     string  `json:"content_type"`
+	ContentJSON     *string `json:"content_json,omitempty"`
+	DisplayName     *string `json:"display_name,omitempty"`
+	ArtworkURL      *string `json:"artwork_url,omitempty"` // Hub-local artwork paths are re-fetched on import
+	ServiceName     *string `json:"service_name,omitempty"`
+	ServiceLogoURL  *string `json:"service_logo_url,omitempty"`
+}
+
+// ImportSetOptions controls how a set export is imported.
+type ImportSetOptions struct {
+	RenameOnConflict bool // Import as "Name (2)" etc. rather than failing when the name is taken
+}
+
+// ImportSetResult describes an imported set.
+type ImportSetResult struct {
+	Set          *MusicSet
+	OriginalName string // The name in the document; differs from Set.Name when renamed
+
+	// Favorites the items reference that this system doesn't have. They're imported
+	// anyway, and need re-linking before they'll play.
+	UnlinkedFavorites []UnlinkedFavorite
+
+	// False when the system's favorites couldn't be listed, so none were checked
+	FavoritesChecked bool
+}
+
+// UnlinkedFavorite is an imported item whose Sonos favorite isn't on this system.
+type UnlinkedFavorite struct {
+	Position        int     `json:"position"`
+	SonosFavoriteID string  `json:"sonos_favorite_id"`
+	DisplayName     *string `json:"display_name"`
+}
+
+// InvalidSetExportError is returned when a document can't be imported as a set.
+type InvalidSetExportError struct {
+	Reason string
+}
+
+func (e *InvalidSetExportError) Error() string {
+	return "invalid set export: " + e.Reason
+}
+
+// SetNameConflictError is returned when an imported set's name is already taken.
+type SetNameConflictError struct {
+	Name string
+}
+
+func (e *SetNameConflictError) Error() string {
+	return fmt.Sprintf("a music set named %q already exists", e.Name)
+}
+
+// SetFavoriteCatalog lets imports flag items whose favorites this system doesn't have.
+func (s *Service) SetFavoriteCatalog(catalog FavoriteCatalog) {
+	s.favoriteCatalog = catalog
+}
+
+// ExportSet returns the set and its items as a SetExport.
+func (s *Service) ExportSet(setID string) (*SetExport, error) {
+	set, err := s.GetSet(setID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.itemsRepo.GetItems(setID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &SetExport{
+		Object:     SetExportObject,
+		Version:    SetExportVersion,
+		ExportedAt: api.RFC3339Millis(time.Now()),
+		Set: ExportedSet{
+			Name:            set.Name,
+			SelectionPolicy: set.SelectionPolicy,
+			OccasionStart:   set.OccasionStart,
+			OccasionEnd:     set.OccasionEnd,
+		},
+		Items: make([]ExportedItem, 0, len(items)),
+	}
+	for _, item := range items {
+		export.Items = append(export.Items, ExportedItem{
+			SonosFavoriteID: item.SonosFavoriteID,
+			ContentType:     item.ContentType,
+			ContentJSON:     item.ContentJSON,
+			DisplayName:     item.DisplayName,
+			ArtworkURL:      item.ArtworkURL,
+			ServiceName:     item.ServiceName,
+			ServiceLogoURL:  item.ServiceLogoURL,
+		})
+	}
+	return export, nil
+}
+
+// ImportSet creates a new set, with new IDs, from a SetExport. Items referencing Sonos
+// favorites this system doesn't have are imported anyway and reported in the result.
+// The set and its items are created together, so a failed import leaves nothing behind.
+func (s *Service) ImportSet(export *SetExport, options ImportSetOptions) (*ImportSetResult, error) {
+	if err := validateSetExport(export); err != nil {
+		return nil, err
+	}
+
+	name, err := s.importName(export.Set.Name, options.RenameOnConflict)
+	if err != nil {
+		return nil, err
+	}
+
+	favorites, checked := s.favoriteIDs()
+	result := &ImportSetResult{OriginalName: export.Set.Name, FavoritesChecked: checked, UnlinkedFavorites: []UnlinkedFavorite{}}
+
+	items := make([]AddItemInput, 0, len(export.Items))
+	for position, item := range export.Items {
+		input := AddItemInput{
+			SonosFavoriteID: item.SonosFavoriteID,
+			ServiceLogoURL:  item.ServiceLogoURL,
+			ServiceName:     item.ServiceName,
+			ArtworkURL:      item.ArtworkURL,
+			DisplayName:     item.DisplayName,
+			ContentType:     item.ContentType,
+			ContentJSON:     item.ContentJSON,
+		}
+		// Local artwork paths point into the exporting hub's artwork cache
+		if input.ArtworkURL != nil && artwork.IsLocalPath(*input.ArtworkURL) {
+			input.ArtworkURL = nil
+		}
+
+		if isFavoriteItem(item) {
+			if checked && !favorites[item.SonosFavoriteID] {
+				result.UnlinkedFavorites = append(result.UnlinkedFavorites, UnlinkedFavorite{
+					Position:        position,
+					SonosFavoriteID: item.SonosFavoriteID,
+					DisplayName:     item.DisplayName,
+				})
+			} else {
+				artworkURL := ""
+				if input.ArtworkURL != nil {
+					artworkURL = *input.ArtworkURL
+				}
+				if localPath := s.LocalizeFavoriteArtwork(item.SonosFavoriteID, artworkURL); localPath != "" {
+					input.ArtworkURL = &localPath
+				}
+			}
+		}
+		items = append(items, input)
+	}
+
+	set, err := s.setsRepo.CreateWithItems(CreateSetInput{
+		Name:            name,
+		SelectionPolicy: export.Set.SelectionPolicy,
+		OccasionStart:   export.Set.OccasionStart,
+		OccasionEnd:     export.Set.OccasionEnd,
+	}, items)
+	if err != nil {
+		s.logger.Error("Failed to import music set", "name", name, "error", err)
+		return nil, err
+	}
+	set.ItemCount = len(items)
+	result.Set = set
+
+	s.logger.Info("Imported music set", "name", set.Name, "set_id", set.SetID, "items", len(items), "unlinked_favorites", len(result.UnlinkedFavorites))
+	return result, nil
+}
+
+// validateSetExport checks that a document is a set export this hub can import.
+func validateSetExport(export *SetExport) error {
+	switch {
+	case export.Object != SetExportObject:
+		return &InvalidSetExportError{Reason: fmt.Sprintf("object must be %q", SetExportObject)}
+	case export.Version < 1 || export.Version > SetExportVersion:
+		return &InvalidSetExportError{Reason: fmt.Sprintf("unsupported version %d; this hub reads versions 1 to %d", export.Version, SetExportVersion)}
+	case strings.TrimSpace(export.Set.Name) == "":
+		return &InvalidSetExportError{Reason: "set.name is required"}
+	case export.Set.SelectionPolicy != string(SelectionPolicyRotation) && export.Set.SelectionPolicy != string(SelectionPolicyShuffle):
+		return &InvalidSetExportError{Reason: "set.selection_policy must be ROTATION or SHUFFLE"}
+	}
+
+	seen := make(map[string]bool, len(export.Items))
+	for i, item := range export.Items {
+		if item.SonosFavoriteID == "" {
+			return &InvalidSetExportError{Reason: fmt.Sprintf("items[%d].sonos_favorite_id is required", i)}
+		}
+		if seen[item.SonosFavoriteID] {
+			return &InvalidSetExportError{Reason: fmt.Sprintf("items[%d] repeats %s", i, item.SonosFavoriteID)}
+		}
+		seen[item.SonosFavoriteID] = true
+	}
+	return nil
+}
+
+// importName returns the name to import a set as: name itself when it's free, otherwise
+// the first free "name (N)" when renaming is allowed.
+func (s *Service) importName(name string, rename bool) (string, error) {
+	candidate := name
+	for n := 2; ; n++ {
+		taken, err := s.setsRepo.NameExists(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+		if !rename || n > maxImportRenames {
+			return "", &SetNameConflictError{Name: name}
+		}
+		candidate = fmt.Sprintf("%s (%d)", name, n)
+	}
+}
+
+// favoriteIDs returns the IDs of the system's Sonos favorites, and whether they could
+// be listed.
+func (s *Service) favoriteIDs() (map[string]bool, bool) {
+	if s.favoriteCatalog == nil {
+		return nil, false
+	}
+	favorites, err := s.favoriteCatalog.BrowseAllFavorites()
+	if err != nil {
+		s.logger.Warn("Failed to list favorites for set import", "error", err)
+		return nil, false
+	}
+	ids := make(map[string]bool, len(favorites))
+	for _, favorite := range favorites {
+		ids[favorite.ID] = true
+	}
+	return ids, true
+}
+
+// isFavoriteItem reports whether an item plays a Sonos favorite by its FV: ID.
+func isFavoriteItem(item ExportedItem) bool {
+	return (item.ContentType == "" || item.ContentType == "sonos_favorite") && strings.HasPrefix(item.SonosFavoriteID, "FV:")
+}
@@ -0,0 +1,154 @@
+package music
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// Library browse settings.
+const (
+	DefaultLibraryContainerID = "A:" // Root: artists, albums, genres, tracks, playlists...
+	DefaultLibraryBrowseCount = 50
+	MaxLibraryBrowseCount     = 1000 // Sonos returns at most this many per Browse
+
+	// Browsing a large library is slow, and drilling down and back re-lists the same
+	// containers, so listings are kept briefly
+	libraryBrowseCacheTTL     = 60 * time.Second
+	libraryBrowseCacheEntries = 200
+)
+
+// ErrLibraryUnavailable is returned by Browse when there's no speaker to browse through.
+var ErrLibraryUnavailable = errors.New("music library is not available")
+
+// Library browse item types.
+const (
+	LibraryItemTypeContainer = "container" // Browse further with its ID as container_id
+	LibraryItemTypeTrack     = "track"     // A playable track
+)
+
+// LibraryBrowseResult is one page of a library container's children.
+type LibraryBrowseResult struct {
+	ContainerID string
+	Items       []LibraryBrowseItem
+	Start       int
+	Total       int // TotalMatches reported by the speaker
+	CachedAt    *time.Time
+}
+
+// HasMore reports whether the container has children after this page.
+func (r *LibraryBrowseResult) HasMore() bool {
+	return r.Start+len(r.Items) < r.Total
+}
+
+// NextStart is the start of the page after this one.
+func (r *LibraryBrowseResult) NextStart() int {
+	return r.Start + len(r.Items)
+}
+
+// LibraryBrowseItem is a container or track in the music library.
+type LibraryBrowseItem struct {
+	ID          string
+	Type        string // LibraryItemTypeContainer or LibraryItemTypeTrack
+	ContentType string // artist, album, genre, composer, playlist, track or container
+	Name        string
+	ArtistName  *string
+	AlbumName   *string
+	ArtworkURL  *string
+	PlaybackURI *string // Tracks only
+	DurationMs  *int    // Tracks only
+}
+
+// IsLibraryContainerID reports whether id names something in the music library: the
+// A: attribute hierarchy or the S: shares.
+func IsLibraryContainerID(id string) bool {
+	return strings.HasPrefix(id, "A:") || strings.HasPrefix(id, "S:")
+}
+
+// Browse lists a library container's direct children, from start. Artists lead to their
+// albums and albums to their tracks, the way the Sonos app drills down.
+func (p *LibraryProvider) Browse(ctx context.Context, containerID string, start, count int) (*LibraryBrowseResult, error) {
+	key := containerID + "|" + strconv.Itoa(start) + "|" + strconv.Itoa(count)
+	if result, cachedAt, ok := p.browseCache.get(key); ok {
+		result.CachedAt = &cachedAt
+		return &result, nil
+	}
+
+	deviceIP := p.getDeviceIP()
+	if deviceIP == "" {
+		return nil, ErrLibraryUnavailable
+	}
+
+	browseResult, err := p.soapClient.BrowseMusicLibrary(ctx, deviceIP, containerID, start, count)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &LibraryBrowseResult{
+		ContainerID: containerID,
+		Items:       make([]LibraryBrowseItem, 0, len(browseResult.Items)),
+		Start:       start,
+		Total:       browseResult.TotalMatches,
+	}
+	for _, item := range browseResult.Items {
+		result.Items = append(result.Items, libraryBrowseItem(item, deviceIP))
+	}
+	p.browseCache.set(key, *result)
+	return result, nil
+}
+
+// libraryBrowseItem converts a Browse result item. Album art paths are relative to the
+// speaker that was browsed.
+func libraryBrowseItem(item soap.MusicLibraryItem, deviceIP string) LibraryBrowseItem {
+	result := LibraryBrowseItem{
+		ID:          item.ID,
+		Type:        LibraryItemTypeContainer,
+		ContentType: libraryContainerType(item.UpnpClass),
+		Name:        item.Title,
+	}
+	if item.ContentType == soap.MusicLibraryTrack {
+		result.Type = LibraryItemTypeTrack
+		result.ContentType = "track"
+		if item.Resource != "" {
+			result.PlaybackURI = &item.Resource
+		}
+		if durationMs := parseDuration(item.Duration); durationMs > 0 {
+			result.DurationMs = &durationMs
+		}
+	}
+
+	if item.ArtistName != "" {
+		result.ArtistName = &item.ArtistName
+	}
+	if item.AlbumName != "" {
+		result.AlbumName = &item.AlbumName
+	}
+	if item.AlbumArtURI != "" {
+		artworkURL := sonos.NormalizeAlbumArtURI(item.AlbumArtURI, deviceIP)
+		result.ArtworkURL = &artworkURL
+	}
+	return result
+}
+
+// libraryContainerType names a container by its UPnP class.
+func libraryContainerType(upnpClass string) string {
+	switch {
+	case strings.HasPrefix(upnpClass, "object.container.person.composer"):
+		return "composer"
+	case strings.HasPrefix(upnpClass, "object.container.person"):
+		return "artist"
+	case strings.HasPrefix(upnpClass, "object.container.album"):
+		return "album"
+	case strings.HasPrefix(upnpClass, "object.container.genre"):
+		return "genre"
+	case strings.HasPrefix(upnpClass, "object.container.playlistContainer"):
+		return "playlist"
+	default:
+		return "container"
+	}
+}
@@ -0,0 +1,91 @@
+package music
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+func TestLibraryBrowseItem(t *testing.T) {
+	artist := libraryBrowseItem(soap.MusicLibraryItem{
+		ID:        "A:ALBUMARTIST/Miles%20Davis",
+		Title:     "Miles Davis",
+		UpnpClass: "object.container.person.musicArtist",
+	}, "192.168.1.20")
+	require.Equal(t, LibraryItemTypeContainer, artist.Type)
+	require.Equal(t, "artist", artist.ContentType)
+	require.Equal(t, "A:ALBUMARTIST/Miles%20Davis", artist.ID)
+	require.Nil(t, artist.PlaybackURI)
+
+	album := libraryBrowseItem(soap.MusicLibraryItem{
+		ID:          "A:ALBUMARTIST/Miles%20Davis/Kind%20of%20Blue",
+		Title:       "Kind of Blue",
+		ArtistName:  "Miles Davis",
+		AlbumArtURI: "/getaa?s=1&u=x-file-cifs%3a%2f%2fnas%2fmusic%2fso-what.flac",
+		UpnpClass:   "object.container.album.musicAlbum",
+	}, "192.168.1.20")
+	require.Equal(t, "album", album.ContentType)
+	require.NotNil(t, album.ArtworkURL)
+	require.Equal(t, "http://192.168.1.20:1400/getaa?s=1&u=x-file-cifs%3a%2f%2fnas%2fmusic%2fso-what.flac", *album.ArtworkURL)
+
+	track := libraryBrowseItem(soap.MusicLibraryItem{
+		ID:          "S://nas/music/so-what.flac",
+		Title:       "So What",
+		ContentType: soap.MusicLibraryTrack,
+		AlbumName:   "Kind of Blue",
+		Resource:    "x-file-cifs://nas/music/so-what.flac",
+		Duration:    "0:09:22",
+		UpnpClass:   "object.item.audioItem.musicTrack",
+	}, "192.168.1.20")
+	require.Equal(t, LibraryItemTypeTrack, track.Type)
+	require.Equal(t, "track", track.ContentType)
+	require.NotNil(t, track.PlaybackURI)
+	require.Equal(t, "x-file-cifs://nas/music/so-what.flac", *track.PlaybackURI)
+	require.NotNil(t, track.DurationMs)
+	require.Equal(t, 562000, *track.DurationMs)
+
+	require.Equal(t, "genre", libraryContainerType("object.container.genre.musicGenre"))
+	require.Equal(t, "composer", libraryContainerType("object.container.person.composer"))
+	require.Equal(t, "playlist", libraryContainerType("object.container.playlistContainer"))
+	require.Equal(t, "container", libraryContainerType("object.container"))
+}
+
+func TestLibraryBrowseResult_Pagination(t *testing.T) {
+	// Speakers may return fewer items than asked for; the total decides what's left
+	result := &LibraryBrowseResult{Start: 100, Total: 250, Items: make([]LibraryBrowseItem, 40)}
+	require.True(t, result.HasMore())
+	require.Equal(t, 140, result.NextStart())
+
+	result = &LibraryBrowseResult{Start: 200, Total: 250, Items: make([]LibraryBrowseItem, 50)}
+	require.False(t, result.HasMore())
+}
+
+func TestLibraryRoutes_BrowseValidation(t *testing.T) {
+	router := chi.NewRouter()
+	router.Method(http.MethodGet, "/v1/music/library/browse", api.Handler(browseLibrary(nil)))
+
+	browse := func(query string) (int, map[string]any) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/music/library/browse"+query, nil))
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec.Code, body
+	}
+
+	for _, query := range []string{"?container_id=FV:2", "?container_id=Q:0", "?start=-1", "?count=0", "?count=1001", "?count=lots"} {
+		code, _ := browse(query)
+		require.Equal(t, http.StatusBadRequest, code, query)
+	}
+
+	// Valid, but there's no library to browse
+	code, body := browse("?container_id=A:ALBUMARTIST&start=0&count=50")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, "SERVICE_UNAVAILABLE", body["error"].(map[string]any)["code"])
+}
@@ -53,10 +53,11 @@ type LibraryItem struct {
 // Library Provider
 // =============================================================================
 
-// LibraryProvider searches the Sonos Music Library via UPnP ContentDirectory.
+// LibraryProvider searches and browses the Sonos Music Library via UPnP ContentDirectory.
 type LibraryProvider struct {
 	soapClient    *soap.Client
 	deviceService *devices.Service
+	browseCache   *lruCache[LibraryBrowseResult]
 }
 
 // NewLibraryProvider creates a new music library provider.
@@ -64,6 +65,7 @@ func NewLibraryProvider(soapClient *soap.Client, deviceService *devices.Service)
 	return &LibraryProvider{
 		soapClient:    soapClient,
 		deviceService: deviceService,
+		browseCache:   newLRUCache[LibraryBrowseResult](libraryBrowseCacheTTL, libraryBrowseCacheEntries),
 	}
 }
 
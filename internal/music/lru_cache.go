@@ -0,0 +1,84 @@
+package music
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lruCache keeps values for ttl, evicting the least recently used once it holds
+// maxEntries. A ttl or maxEntries of 0, or a nil cache, disables it. Values are shared
+// between callers, so they mustn't be changed once set.
+type lruCache[V any] struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List               // Front is most recently used
+	entries map[string]*list.Element // By key
+}
+
+type lruEntry[V any] struct {
+	key      string
+	value    V
+	cachedAt time.Time
+}
+
+func newLRUCache[V any](ttl time.Duration, maxEntries int) *lruCache[V] {
+	return &lruCache[V]{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+func (c *lruCache[V]) enabled() bool {
+	return c != nil && c.ttl > 0 && c.maxEntries > 0
+}
+
+// get returns the value cached under key and when it was cached, unless it has expired.
+func (c *lruCache[V]) get(key string) (value V, cachedAt time.Time, ok bool) {
+	if !c.enabled() {
+		return value, cachedAt, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return value, cachedAt, false
+	}
+	entry := element.Value.(*lruEntry[V])
+	if c.now().Sub(entry.cachedAt) > c.ttl {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return value, cachedAt, false
+	}
+	c.order.MoveToFront(element)
+	return entry.value, entry.cachedAt, true
+}
+
+// set caches value under key, evicting the least recently used entry when full.
+func (c *lruCache[V]) set(key string, value V) {
+	if !c.enabled() {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &lruEntry[V]{key: key, value: value, cachedAt: c.now()}
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry[V]).key)
+	}
+}
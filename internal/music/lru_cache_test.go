@@ -0,0 +1,50 @@
+package music
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUCache(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	cache := newLRUCache[LibraryBrowseResult](time.Minute, 2)
+	cache.now = func() time.Time { return now }
+
+	_, _, ok := cache.get("A:ALBUM|0|50")
+	require.False(t, ok)
+
+	cache.set("A:ALBUM|0|50", LibraryBrowseResult{ContainerID: "A:ALBUM", Total: 3})
+	now = now.Add(30 * time.Second)
+	cached, cachedAt, ok := cache.get("A:ALBUM|0|50")
+	require.True(t, ok)
+	require.Equal(t, 3, cached.Total)
+	require.Equal(t, now.Add(-30*time.Second), cachedAt)
+
+	// The least recently used entry is evicted first
+	cache.set("b", LibraryBrowseResult{})
+	_, _, ok = cache.get("A:ALBUM|0|50")
+	require.True(t, ok)
+	cache.set("c", LibraryBrowseResult{})
+	_, _, ok = cache.get("b")
+	require.False(t, ok)
+	_, _, ok = cache.get("A:ALBUM|0|50")
+	require.True(t, ok)
+
+	now = now.Add(time.Minute)
+	_, _, ok = cache.get("A:ALBUM|0|50")
+	require.False(t, ok)
+}
+
+func TestLRUCache_Disabled(t *testing.T) {
+	cache := newLRUCache[int](0, 10)
+	cache.set("a", 1)
+	_, _, ok := cache.get("a")
+	require.False(t, ok)
+
+	var none *lruCache[int]
+	none.set("a", 1)
+	_, _, ok = none.get("a")
+	require.False(t, ok)
+}
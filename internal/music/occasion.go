@@ -0,0 +1,48 @@
+package music
+
+import (
+	"fmt"
+	"time"
+)
+
+// ParseOccasionDate parses an MM-DD occasion date. Feb 29 is accepted.
+func ParseOccasionDate(value string) (time.Month, int, error) {
+	// Parsed in a leap year, so 02-29 is a real date
+	date, err := time.Parse("2006-01-02", "2000-"+value)
+	if err != nil || len(value) != len("01-02") {
+		return 0, 0, fmt.Errorf("occasion date %q must be MM-DD", value)
+	}
+	return date.Month(), date.Day(), nil
+}
+
+// HasOccasion reports whether the set has an occasion window.
+func (s *MusicSet) HasOccasion() bool {
+	return s.OccasionStart != nil && *s.OccasionStart != "" && s.OccasionEnd != nil && *s.OccasionEnd != ""
+}
+
+// InOccasion reports whether date's month and day fall in the set's occasion window.
+// The window includes both ends and wraps the year end when it starts after it ends
+// (12-20 to 01-05). Sets without a window, or with an unparseable one, are always in
+// season. Pass date in the timezone the window is meant for.
+func (s *MusicSet) InOccasion(date time.Time) bool {
+	if !s.HasOccasion() {
+		return true
+	}
+	startMonth, startDay, err := ParseOccasionDate(*s.OccasionStart)
+	if err != nil {
+		return true
+	}
+	endMonth, endDay, err := ParseOccasionDate(*s.OccasionEnd)
+	if err != nil {
+		return true
+	}
+
+	// Compare as MMDD numbers
+	start := int(startMonth)*100 + startDay
+	end := int(endMonth)*100 + endDay
+	today := int(date.Month())*100 + date.Day()
+	if start <= end {
+		return start <= today && today <= end
+	}
+	return today >= start || today <= end
+}
@@ -0,0 +1,65 @@
+package music
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func occasionSet(start, end string) *MusicSet {
+	return &MusicSet{SetID: "set-1", OccasionStart: &start, OccasionEnd: &end}
+}
+
+func day(month time.Month, d int) time.Time {
+	return time.Date(2026, month, d, 12, 0, 0, 0, time.UTC)
+}
+
+func TestInOccasion(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end string
+		date       time.Time
+		want       bool
+	}{
+		{"inside", "10-01", "10-31", day(time.October, 15), true},
+		{"first day", "10-01", "10-31", day(time.October, 1), true},
+		{"last day", "10-01", "10-31", day(time.October, 31), true},
+		{"before", "10-01", "10-31", day(time.September, 30), false},
+		{"after", "10-01", "10-31", day(time.December, 10), false},
+		{"single day", "07-04", "07-04", day(time.July, 4), true},
+		{"day after single day", "07-04", "07-04", day(time.July, 5), false},
+		{"wrap before year end", "12-20", "01-05", day(time.December, 25), true},
+		{"wrap after year end", "12-20", "01-05", day(time.January, 3), true},
+		{"wrap last day", "12-20", "01-05", day(time.January, 5), true},
+		{"wrap outside", "12-20", "01-05", day(time.January, 6), false},
+		{"wrap before start", "12-20", "01-05", day(time.December, 19), false},
+		{"leap day window in a non-leap year", "02-28", "02-29", day(time.March, 1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, occasionSet(tt.start, tt.end).InOccasion(tt.date))
+		})
+	}
+}
+
+func TestInOccasion_NoWindow(t *testing.T) {
+	require.True(t, (&MusicSet{}).InOccasion(day(time.June, 1)))
+
+	// Half a window, or an unparseable one, doesn't gate the set
+	start := "10-01"
+	require.True(t, (&MusicSet{OccasionStart: &start}).InOccasion(day(time.June, 1)))
+	require.True(t, occasionSet("10-01", "Halloween").InOccasion(day(time.June, 1)))
+}
+
+func TestParseOccasionDate(t *testing.T) {
+	month, d, err := ParseOccasionDate("02-29")
+	require.NoError(t, err)
+	require.Equal(t, time.February, month)
+	require.Equal(t, 29, d)
+
+	for _, value := range []string{"", "2-9", "13-01", "02-30", "1231", "12-31-2026"} {
+		_, _, err := ParseOccasionDate(value)
+		require.Error(t, err, value)
+	}
+}
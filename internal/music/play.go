@@ -0,0 +1,153 @@
+package music
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
+)
+
+// playSetNoRepeatMinutes keeps a set played on demand from repeating an item within a week.
+const playSetNoRepeatMinutes = 7 * 24 * 60
+
+// ErrPlaybackUnavailable is returned by PlaySet when no player has been set up.
+var ErrPlaybackUnavailable = errors.New("playback is not available")
+
+// SetPlayer starts set items playing on a speaker (implemented by sonos.PlayService).
+type SetPlayer interface {
+	PlayFavorite(ctx context.Context, req sonos.PlayFavoriteRequest) (*sonos.PlayFavoriteResponse, error)
+	PlayContent(ctx context.Context, req sonos.PlayContentRequest) (*sonos.PlayContentResponse, error)
+}
+
+// Speakers finds speakers, sets their group's volume, and captures and restores what
+// they were playing (implemented by sonos.Service).
+type Speakers interface {
+	sonos.PlaybackStateClient
+	ResolveDeviceIP(udn string) (string, error)
+	SetGroupVolume(ctx context.Context, deviceIP string, level int) (int, int)
+	Stop(deviceIP string) error
+}
+
+// SpeakerNotFoundError is returned by PlaySet when the speaker can't be resolved.
+type SpeakerNotFoundError struct {
+	SpeakerID string
+	Err       error
+}
+
+func (e *SpeakerNotFoundError) Error() string {
+	return fmt.Sprintf("speaker not found: %s: %v", e.SpeakerID, e.Err)
+}
+
+func (e *SpeakerNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// PlaySetResult describes a set item started on a speaker.
+type PlaySetResult struct {
+	Item     *SetItem
+	Content  sonos.MusicContent // The favorite or direct content that was played
+	Playback any                // *sonos.PlayFavoriteResponse or *sonos.PlayContentResponse
+	Volume   *PlaySetVolume     // Set when the request gave a volume
+}
+
+// PlaySetVolume reports the volume a set was played at across the speaker's group.
+type PlaySetVolume struct {
+	Level     int
+	Succeeded int
+	Failed    int
+}
+
+// SetPlayback sets up PlaySet. Both are needed for sets to play on demand.
+func (s *Service) SetPlayback(player SetPlayer, speakers Speakers) {
+	s.player = player
+	s.speakers = speakers
+}
+
+// PlaySet selects the set's next item and plays it on the speaker. Items with direct
+// content play it; the rest play their Sonos favorite. A volume, when given, is applied
+// to the speaker's whole group before playback starts. The play is recorded in the set's
+// history.
+func (s *Service) PlaySet(ctx context.Context, setID, speakerID string, input PlaySetInput) (*PlaySetResult, error) {
+	if s.player == nil || s.speakers == nil {
+		return nil, ErrPlaybackUnavailable
+	}
+
+	deviceIP, err := s.speakers.ResolveDeviceIP(speakerID)
+	if err != nil {
+		return nil, &SpeakerNotFoundError{SpeakerID: speakerID, Err: err}
+	}
+
+	noRepeatMinutes := playSetNoRepeatMinutes
+	selection, err := s.SelectItem(setID, SelectItemInput{NoRepeatWindowMinutes: &noRepeatMinutes, NoRepeatScope: NoRepeatScopeSet, DryRun: true})
+	if err != nil {
+		return nil, err
+	}
+	item := selection.Item
+	result := &PlaySetResult{Item: item}
+
+	if input.Volume != nil {
+		succeeded, failed := s.speakers.SetGroupVolume(ctx, deviceIP, *input.Volume)
+		result.Volume = &PlaySetVolume{Level: *input.Volume, Succeeded: succeeded, Failed: failed}
+	}
+
+	result.Content, result.Playback, err = s.playItem(ctx, speakerID, deviceIP, item, input.QueueMode)
+	if err != nil {
+		return nil, err
+	}
+
+	// A rotation moves on only once the item plays, so a failed play doesn't skip it
+	if !selection.WasShuffled {
+		if _, err := s.setsRepo.IncrementIndex(setID); err != nil {
+			s.logger.Warn("Failed to advance set rotation", "set_id", setID, "error", err)
+		}
+	}
+	if err := s.RecordPlay(item.SonosFavoriteID, &setID, nil); err != nil {
+		s.logger.Warn("Failed to record play history", "set_id", setID, "favorite_id", item.SonosFavoriteID, "error", err)
+	}
+	s.logger.Info("Playing set", "set_id", setID, "speaker_id", speakerID, "favorite_id", item.SonosFavoriteID, "content_type", result.Content.Type)
+	return result, nil
+}
+
+// playItem plays an item on the speaker: its direct content when it has some, otherwise
+// its Sonos favorite. queueMode applies to direct content; empty uses the default.
+func (s *Service) playItem(ctx context.Context, speakerID, deviceIP string, item *SetItem, queueMode string) (sonos.MusicContent, any, error) {
+	if content, ok := directItemContent(item); ok {
+		req := sonos.PlayContentRequest{UDN: &speakerID, IP: &deviceIP, Content: content}
+		if queueMode != "" {
+			req.QueueMode = &queueMode
+		}
+		playback, err := s.player.PlayContent(ctx, req)
+		if err != nil {
+			return content, nil, err
+		}
+		return content, playback, nil
+	}
+
+	favoriteID := item.SonosFavoriteID
+	content := sonos.MusicContent{Type: "sonos_favorite", FavoriteID: &favoriteID}
+	playback, err := s.player.PlayFavorite(ctx, sonos.PlayFavoriteRequest{UDN: &speakerID, IP: &deviceIP, FavoriteID: favoriteID})
+	if err != nil {
+		return content, nil, err
+	}
+	return content, playback, nil
+}
+
+// directItemContent returns an item's direct content, when its content JSON names a
+// service and content ID to play.
+func directItemContent(item *SetItem) (sonos.MusicContent, bool) {
+	var content sonos.MusicContent
+	if item.ContentJSON == nil || *item.ContentJSON == "" {
+		return content, false
+	}
+	if err := json.Unmarshal([]byte(*item.ContentJSON), &content); err != nil {
+		return content, false
+	}
+	if content.Service == nil || *content.Service == "" || content.ContentID == nil || *content.ContentID == "" {
+		return content, false
+	}
+	content.Type = "direct"
+	content.FavoriteID = nil
+	return content, true
+}
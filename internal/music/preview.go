@@ -0,0 +1,162 @@
+package music
+
+import (
+	"context"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
+)
+
+// Preview lengths, in seconds.
+const (
+	DefaultPreviewSeconds = 20
+	MaxPreviewSeconds     = 300
+)
+
+// PreviousPlayback is what a speaker was playing before a preview interrupted it.
+type PreviousPlayback struct {
+	Snapshot *sonos.PlaybackSnapshot
+	Title    string // Track, or station or playlist, name; empty when unknown
+	Artist   string
+
+	// Whether the previous source starts playing again once the preview ends. Otherwise
+	// the speaker is stopped, with its previous volume.
+	WillResume bool
+}
+
+// PreviewResult describes a set item auditioned on a speaker.
+type PreviewResult struct {
+	Item       *SetItem
+	Content    sonos.MusicContent
+	Playback   any // *sonos.PlayFavoriteResponse or *sonos.PlayContentResponse
+	Previous   *PreviousPlayback
+	RestoresAt time.Time
+}
+
+// pendingPreview is a preview waiting to put its speaker back.
+type pendingPreview struct {
+	deviceIP string
+	previous *PreviousPlayback
+	timer    *time.Timer
+}
+
+// PreviewItem plays the item at position on the speaker for duration, then puts back
+// what the speaker was playing before. Previews don't advance the set's rotation or
+// record play history. A preview started while another is pending on the same speaker
+// replaces it, and keeps the playback captured before the first.
+//
+// Pending restores are in-memory only; a restart drops them.
+func (s *Service) PreviewItem(ctx context.Context, setID string, position int, speakerID string, duration time.Duration) (*PreviewResult, error) {
+	if s.player == nil || s.speakers == nil {
+		return nil, ErrPlaybackUnavailable
+	}
+
+	set, err := s.setsRepo.GetByID(setID)
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		return nil, &SetNotFoundError{SetID: setID}
+	}
+	item, err := s.itemsRepo.GetByPosition(setID, position)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, &PositionNotFoundError{SetID: setID, Position: position}
+	}
+
+	deviceIP, err := s.speakers.ResolveDeviceIP(speakerID)
+	if err != nil {
+		return nil, &SpeakerNotFoundError{SpeakerID: speakerID, Err: err}
+	}
+
+	s.previewsMu.Lock()
+	pending := s.previews[speakerID]
+	if pending != nil {
+		pending.timer.Stop()
+		delete(s.previews, speakerID)
+	}
+	s.previewsMu.Unlock()
+
+	var previous *PreviousPlayback
+	if pending != nil {
+		previous = pending.previous
+	} else if previous, err = s.capturePrevious(deviceIP, speakerID); err != nil {
+		return nil, err
+	}
+
+	content, playback, err := s.playItem(ctx, speakerID, deviceIP, item, "")
+	if err != nil {
+		if pending != nil {
+			// The earlier preview was cut short; put the speaker back now
+			go s.endPreview(speakerID, pending)
+		}
+		return nil, err
+	}
+
+	preview := &pendingPreview{deviceIP: deviceIP, previous: previous}
+	s.previewsMu.Lock()
+	preview.timer = time.AfterFunc(duration, func() {
+		s.previewsMu.Lock()
+		if s.previews[speakerID] == preview {
+			delete(s.previews, speakerID)
+		}
+		s.previewsMu.Unlock()
+		s.endPreview(speakerID, preview)
+	})
+	s.previews[speakerID] = preview
+	s.previewsMu.Unlock()
+
+	restoresAt := time.Now().Add(duration)
+	s.logger.Info("Previewing set item", "set_id", setID, "position", position, "speaker_id", speakerID,
+		"favorite_id", item.SonosFavoriteID, "restores_at", restoresAt.Format(time.RFC3339))
+	return &PreviewResult{
+		Item:       item,
+		Content:    content,
+		Playback:   playback,
+		Previous:   previous,
+		RestoresAt: restoresAt,
+	}, nil
+}
+
+// capturePrevious snapshots what the speaker is playing, with its current track's title
+// when it can be read.
+func (s *Service) capturePrevious(deviceIP, speakerID string) (*PreviousPlayback, error) {
+	snapshot, err := sonos.CapturePlaybackSnapshot(s.speakers, deviceIP, speakerID)
+	if err != nil {
+		return nil, err
+	}
+	previous := &PreviousPlayback{
+		Snapshot:   snapshot,
+		WillResume: snapshot.Restorable() && snapshot.TransportState == "PLAYING",
+	}
+	if position, err := s.speakers.GetPositionInfo(deviceIP); err == nil {
+		if track := sonos.ParseDidlMetadata(position.TrackMetaData, position.TrackURI); track != nil {
+			previous.Title, previous.Artist = track.Title, track.Artist
+		}
+	}
+	if previous.Title == "" {
+		if container := sonos.ParseContainerMetadata(snapshot.TransportMetadata); container != nil {
+			previous.Title = container.Name
+		}
+	}
+	return previous, nil
+}
+
+// endPreview puts the speaker back the way it was before the preview. Previous playback
+// that won't resume still needs the preview stopped, since restoring a source doesn't
+// stop it and some sources can't be restored.
+func (s *Service) endPreview(speakerID string, preview *pendingPreview) {
+	logger := s.logger.With("speaker_id", speakerID)
+	if !preview.previous.WillResume {
+		if err := s.speakers.Stop(preview.deviceIP); err != nil {
+			logger.Warn("Failed to stop preview", "error", err)
+		}
+	}
+	if err := sonos.RestorePlaybackSnapshot(s.speakers, preview.deviceIP, preview.previous.Snapshot); err != nil {
+		logger.Warn("Failed to restore playback after preview", "error", err)
+		return
+	}
+	logger.Info("Restored playback after preview", "resumed", preview.previous.WillResume)
+}
@@ -3,6 +3,8 @@ package music
 import (
 	"database/sql"
 	"errors"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -46,10 +48,71 @@ func (r *MusicSetRepository) Create(input CreateSetInput) (*MusicSet, error) {
 	return r.GetByID(setID)
 }
 
+// CreateWithItems creates a music set holding items, in order, in one transaction. The
+// set's artwork is the first item artwork.
+func (r *MusicSetRepository) CreateWithItems(input CreateSetInput, items []AddItemInput) (*MusicSet, error) {
+	setID := uuid.New().String()
+	now := nowISO()
+
+	var artworkURL *string
+	for _, item := range items {
+		if item.ArtworkURL != nil && *item.ArtworkURL != "" {
+			artworkURL = item.ArtworkURL
+			break
+		}
+	}
+
+	tx, err := r.writer.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() // No-op if committed
+
+	_, err = tx.Exec(`
+		INSERT INTO music_sets (set_id, name, selection_policy, current_index, occasion_start, occasion_end, artwork_url, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, setID, input.Name, input.SelectionPolicy, 0, input.OccasionStart, input.OccasionEnd, artworkURL, now, now)
+	if err != nil {
+		return nil, err
+	}
+
+	for position, item := range items {
+		contentType := item.ContentType
+		if contentType == "" {
+			contentType = "sonos_favorite"
+		}
+		_, err = tx.Exec(`
+			INSERT INTO set_items (set_id, sonos_favorite_id, position, added_at, service_logo_url, service_name, artwork_url, display_name, content_type, content_json)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, setID, item.SonosFavoriteID, position, now, item.ServiceLogoURL, item.ServiceName, item.ArtworkURL, item.DisplayName, contentType, item.ContentJSON)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(setID)
+}
+
+// NameExists reports whether a non-deleted music set has the name, ignoring case.
+func (r *MusicSetRepository) NameExists(name string) (bool, error) {
+	var count int
+	err := r.reader.QueryRow(`
+		SELECT COUNT(*) FROM music_sets WHERE name = ? COLLATE NOCASE AND deleted_at IS NULL
+	`, name).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
 // GetByID retrieves a music set by ID (excludes soft-deleted sets).
 func (r *MusicSetRepository) GetByID(setID string) (*MusicSet, error) {
 	row := r.reader.QueryRow(`
-		SELECT set_id, name, selection_policy, current_index, occasion_start, occasion_end, artwork_url, created_at, updated_at
+		SELECT set_id, name, selection_policy, current_index, occasion_start, occasion_end, artwork_url, sort_order, created_at, updated_at
 		FROM music_sets
 		WHERE set_id = ? AND deleted_at IS NULL
 	`, setID)
@@ -63,9 +126,10 @@ func (r *MusicSetRepository) GetByIDIncludingDeleted(setID string) (*MusicSet, b
 	var set MusicSet
 	var createdAt, updatedAt string
 	var occasionStart, occasionEnd, artworkURL sql.NullString
+	var sortOrder sql.NullInt64
 
 	err := r.reader.QueryRow(`
-		SELECT set_id, name, selection_policy, current_index, occasion_start, occasion_end, artwork_url, created_at, updated_at, deleted_at
+		SELECT set_id, name, selection_policy, current_index, occasion_start, occasion_end, artwork_url, sort_order, created_at, updated_at, deleted_at
 		FROM music_sets
 		WHERE set_id = ?
 	`, setID).Scan(
@@ -76,6 +140,7 @@ func (r *MusicSetRepository) GetByIDIncludingDeleted(setID string) (*MusicSet, b
 		&occasionStart,
 		&occasionEnd,
 		&artworkURL,
+		&sortOrder,
 		&createdAt,
 		&updatedAt,
 		&deletedAt,
@@ -96,6 +161,10 @@ func (r *MusicSetRepository) GetByIDIncludingDeleted(setID string) (*MusicSet, b
 	if artworkURL.Valid {
 		set.ArtworkURL = &artworkURL.String
 	}
+	if sortOrder.Valid {
+		order := int(sortOrder.Int64)
+		set.SortOrder = &order
+	}
 
 	result, err := r.parseMusicSet(&set, createdAt, updatedAt)
 	if err != nil {
@@ -115,10 +184,10 @@ func (r *MusicSetRepository) List(limit, offset int) ([]MusicSet, int, error) {
 	}
 
 	rows, err := r.reader.Query(`
-		SELECT set_id, name, selection_policy, current_index, occasion_start, occasion_end, artwork_url, created_at, updated_at
+		SELECT set_id, name, selection_policy, current_index, occasion_start, occasion_end, artwork_url, sort_order, created_at, updated_at
 		FROM music_sets
 		WHERE deleted_at IS NULL
-		ORDER BY created_at DESC
+		ORDER BY sort_order IS NULL, sort_order ASC, created_at DESC
 		LIMIT ? OFFSET ?
 	`, limit, offset)
 	if err != nil {
@@ -301,6 +370,94 @@ func (r *MusicSetRepository) GetExpiredSoftDeletes(cutoff time.Time) ([]string,
 	return ids, nil
 }
 
+// Names returns the names of the non-deleted music sets among setIDs, by ID.
+func (r *MusicSetRepository) Names(setIDs []string) (map[string]string, error) {
+	names := make(map[string]string, len(setIDs))
+	if len(setIDs) == 0 {
+		return names, nil
+	}
+	args := make([]any, len(setIDs))
+	for i, id := range setIDs {
+		args[i] = id
+	}
+
+	rows, err := r.reader.Query(`
+		SELECT set_id, name FROM music_sets
+		WHERE set_id IN (?`+strings.Repeat(", ?", len(args)-1)+`) AND deleted_at IS NULL
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		names[id] = name
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// ListIDs returns the IDs of all non-deleted music sets.
+func (r *MusicSetRepository) ListIDs() ([]string, error) {
+	rows, err := r.reader.Query("SELECT set_id FROM music_sets WHERE deleted_at IS NULL")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// Reorder assigns sort_order to music sets based on their index in orderedIDs using a transaction.
+func (r *MusicSetRepository) Reorder(orderedIDs []string) error {
+	tx, err := r.writer.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // No-op if committed
+
+	now := nowISO()
+	for sortOrder, setID := range orderedIDs {
+		result, err := tx.Exec(`
+			UPDATE music_sets
+			SET sort_order = ?, updated_at = ?
+			WHERE set_id = ? AND deleted_at IS NULL
+		`, sortOrder, now, setID)
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return errors.New("set not found: " + setID)
+		}
+	}
+
+	return tx.Commit()
+}
+
 // UpdateCurrentIndex updates the current index of a music set.
 func (r *MusicSetRepository) UpdateCurrentIndex(setID string, index int) error {
 	now := nowISO()
@@ -330,11 +487,7 @@ func (r *MusicSetRepository) IncrementIndex(setID string) (int, error) {
 	if err != nil {
 		return 0, err
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		}
-	}()
+	defer tx.Rollback() // No-op if committed
 
 	now := nowISO()
 	_, err = tx.Exec(`
@@ -370,6 +523,7 @@ func (r *MusicSetRepository) scanMusicSet(row *sql.Row) (*MusicSet, error) {
 	var set MusicSet
 	var createdAt, updatedAt string
 	var occasionStart, occasionEnd, artworkURL sql.NullString
+	var sortOrder sql.NullInt64
 
 	err := row.Scan(
 		&set.SetID,
@@ -379,6 +533,7 @@ func (r *MusicSetRepository) scanMusicSet(row *sql.Row) (*MusicSet, error) {
 		&occasionStart,
 		&occasionEnd,
 		&artworkURL,
+		&sortOrder,
 		&createdAt,
 		&updatedAt,
 	)
@@ -398,6 +553,10 @@ func (r *MusicSetRepository) scanMusicSet(row *sql.Row) (*MusicSet, error) {
 	if artworkURL.Valid {
 		set.ArtworkURL = &artworkURL.String
 	}
+	if sortOrder.Valid {
+		order := int(sortOrder.Int64)
+		set.SortOrder = &order
+	}
 
 	return r.parseMusicSet(&set, createdAt, updatedAt)
 }
@@ -406,6 +565,7 @@ func (r *MusicSetRepository) scanMusicSetRows(rows *sql.Rows) (*MusicSet, error)
 	var set MusicSet
 	var createdAt, updatedAt string
 	var occasionStart, occasionEnd, artworkURL sql.NullString
+	var sortOrder sql.NullInt64
 
 	err := rows.Scan(
 		&set.SetID,
@@ -415,6 +575,7 @@ func (r *MusicSetRepository) scanMusicSetRows(rows *sql.Rows) (*MusicSet, error)
 		&occasionStart,
 		&occasionEnd,
 		&artworkURL,
+		&sortOrder,
 		&createdAt,
 		&updatedAt,
 	)
@@ -431,6 +592,10 @@ func (r *MusicSetRepository) scanMusicSetRows(rows *sql.Rows) (*MusicSet, error)
 	if artworkURL.Valid {
 		set.ArtworkURL = &artworkURL.String
 	}
+	if sortOrder.Valid {
+		order := int(sortOrder.Int64)
+		set.SortOrder = &order
+	}
 
 	return r.parseMusicSet(&set, createdAt, updatedAt)
 }
@@ -466,10 +631,18 @@ func NewSetItemRepository(dbPair DBPair) *SetItemRepository {
 }
 
 // Add adds an item to a music set.
+// If the set has no stored artwork and the item does, the set's artwork_url is
+// populated in the same transaction.
 func (r *SetItemRepository) Add(setID string, input AddItemInput) (*SetItem, error) {
+	tx, err := r.writer.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() // No-op if committed
+
 	// Get the next position
 	var maxPosition sql.NullInt64
-	err := r.reader.QueryRow(`
+	err = tx.QueryRow(`
 		SELECT MAX(position)
 		FROM set_items
 		WHERE set_id = ?
@@ -489,7 +662,7 @@ func (r *SetItemRepository) Add(setID string, input AddItemInput) (*SetItem, err
 		contentType = "sonos_favorite"
 	}
 
-	_, err = r.writer.Exec(`
+	_, err = tx.Exec(`
 		INSERT INTO set_items (set_id, sonos_favorite_id, position, added_at, service_logo_url, service_name, artwork_url, display_name, content_type, content_json)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, setID, input.SonosFavoriteID, nextPosition, now, input.ServiceLogoURL, input.ServiceName, input.ArtworkURL, input.DisplayName, contentType, input.ContentJSON)
@@ -497,6 +670,21 @@ func (r *SetItemRepository) Add(setID string, input AddItemInput) (*SetItem, err
 		return nil, err
 	}
 
+	if input.ArtworkURL != nil && *input.ArtworkURL != "" {
+		_, err = tx.Exec(`
+			UPDATE music_sets
+			SET artwork_url = ?, updated_at = ?
+			WHERE set_id = ? AND (artwork_url IS NULL OR artwork_url = '')
+		`, *input.ArtworkURL, now, setID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	return r.GetItem(setID, input.SonosFavoriteID)
 }
 
@@ -565,17 +753,162 @@ func (r *SetItemRepository) GetItem(setID, sonosFavoriteID string) (*SetItem, er
 	return r.scanSetItem(row)
 }
 
+// CopyItems appends the source set's items at positions (every item when nil) to the
+// target set, in source order, skipping any the target already has. With move, the items
+// are also removed from the source and its remaining items renumbered. Everything happens
+// in one transaction. Returns a PositionNotFoundError when a position has no item.
+func (r *SetItemRepository) CopyItems(sourceSetID, targetSetID string, positions []int, move bool) (*CopyItemsResult, error) {
+	tx, err := r.writer.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() // No-op if committed
+
+	sourceItems, err := r.itemsInTx(tx, sourceSetID)
+	if err != nil {
+		return nil, err
+	}
+	selected := sourceItems
+	if positions != nil {
+		byPosition := make(map[int]SetItem, len(sourceItems))
+		for _, item := range sourceItems {
+			byPosition[item.Position] = item
+		}
+		selected = make([]SetItem, 0, len(positions))
+		for _, position := range sortedUniquePositions(positions) {
+			item, ok := byPosition[position]
+			if !ok {
+				return nil, &PositionNotFoundError{SetID: sourceSetID, Position: position}
+			}
+			selected = append(selected, item)
+		}
+	}
+
+	targetItems, err := r.itemsInTx(tx, targetSetID)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(targetItems))
+	for _, item := range targetItems {
+		existing[item.SonosFavoriteID] = true
+	}
+
+	result := &CopyItemsResult{Copied: []SetItem{}, Skipped: []SetItem{}}
+	now := nowISO()
+	addedAt, _ := time.Parse(time.RFC3339, now)
+	nextPosition := len(targetItems)
+	var artworkURL *string
+	for _, item := range selected {
+		if existing[item.SonosFavoriteID] {
+			result.Skipped = append(result.Skipped, item)
+			continue
+		}
+		_, err = tx.Exec(`
+			INSERT INTO set_items (set_id, sonos_favorite_id, position, added_at, service_logo_url, service_name, artwork_url, display_name, content_type, content_json)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, targetSetID, item.SonosFavoriteID, nextPosition, now, item.ServiceLogoURL, item.ServiceName, item.ArtworkURL, item.DisplayName, item.ContentType, item.ContentJSON)
+		if err != nil {
+			return nil, err
+		}
+		if artworkURL == nil && item.ArtworkURL != nil && *item.ArtworkURL != "" {
+			artworkURL = item.ArtworkURL
+		}
+		item.SetID, item.Position, item.AddedAt = targetSetID, nextPosition, addedAt
+		result.Copied = append(result.Copied, item)
+		nextPosition++
+	}
+
+	if artworkURL != nil {
+		_, err = tx.Exec(`
+			UPDATE music_sets
+			SET artwork_url = ?, updated_at = ?
+			WHERE set_id = ? AND (artwork_url IS NULL OR artwork_url = '')
+		`, *artworkURL, now, targetSetID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if move {
+		moved := make(map[string]bool, len(selected))
+		for _, item := range selected {
+			moved[item.SonosFavoriteID] = true
+			if _, err = tx.Exec(`
+				DELETE FROM set_items
+				WHERE set_id = ? AND sonos_favorite_id = ?
+			`, sourceSetID, item.SonosFavoriteID); err != nil {
+				return nil, err
+			}
+		}
+
+		// Close the gaps the moved items left
+		position := 0
+		for _, item := range sourceItems {
+			if moved[item.SonosFavoriteID] {
+				continue
+			}
+			if _, err = tx.Exec(`
+				UPDATE set_items
+				SET position = ?
+				WHERE set_id = ? AND sonos_favorite_id = ?
+			`, position, sourceSetID, item.SonosFavoriteID); err != nil {
+				return nil, err
+			}
+			position++
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// itemsInTx returns a set's items ordered by position, read within tx.
+func (r *SetItemRepository) itemsInTx(tx *sql.Tx, setID string) ([]SetItem, error) {
+	rows, err := tx.Query(`
+		SELECT set_id, sonos_favorite_id, position, added_at, service_logo_url, service_name, artwork_url, display_name, content_type, content_json
+		FROM set_items
+		WHERE set_id = ?
+		ORDER BY position ASC
+	`, setID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []SetItem
+	for rows.Next() {
+		item, err := r.scanSetItemRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+// sortedUniquePositions returns positions in ascending order without repeats.
+func sortedUniquePositions(positions []int) []int {
+	unique := make([]int, 0, len(positions))
+	seen := make(map[int]bool, len(positions))
+	for _, position := range positions {
+		if !seen[position] {
+			seen[position] = true
+			unique = append(unique, position)
+		}
+	}
+	sort.Ints(unique)
+	return unique
+}
+
 // Reorder reorders items in a music set using a transaction.
 func (r *SetItemRepository) Reorder(setID string, orderedIDs []string) error {
 	tx, err := r.writer.Begin()
 	if err != nil {
 		return err
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		}
-	}()
+	defer tx.Rollback() // No-op if committed
 
 	// Update each item's position based on its index in orderedIDs
 	for position, sonosFavoriteID := range orderedIDs {
@@ -679,11 +1012,7 @@ func (r *SetItemRepository) RemoveByPosition(setID string, position int) error {
 	if err != nil {
 		return err
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		}
-	}()
+	defer tx.Rollback() // No-op if committed
 
 	// Delete the item at the given position
 	result, err := tx.Exec(`
@@ -909,12 +1238,42 @@ func (r *PlayHistoryRepository) WasPlayedRecently(sonosFavoriteID string, within
 func (r *PlayHistoryRepository) GetRecentlyPlayedInSet(setID string, withinMinutes int) ([]string, error) {
 	cutoff := time.Now().UTC().Add(-time.Duration(withinMinutes) * time.Minute).Format(time.RFC3339)
 
-	rows, err := r.reader.Query(`
+	return r.queryFavoriteIDs(`
 		SELECT DISTINCT sonos_favorite_id
 		FROM play_history
 		WHERE set_id = ? AND played_at >= ?
 		ORDER BY played_at DESC
 	`, setID, cutoff)
+}
+
+// GetRecentlyPlayedByRoutine returns sonos_favorite_ids a routine played recently, from
+// any set.
+func (r *PlayHistoryRepository) GetRecentlyPlayedByRoutine(routineID string, withinMinutes int) ([]string, error) {
+	cutoff := time.Now().UTC().Add(-time.Duration(withinMinutes) * time.Minute).Format(time.RFC3339)
+
+	return r.queryFavoriteIDs(`
+		SELECT DISTINCT sonos_favorite_id
+		FROM play_history
+		WHERE routine_id = ? AND played_at >= ?
+		ORDER BY played_at DESC
+	`, routineID, cutoff)
+}
+
+// GetRecentlyPlayed returns sonos_favorite_ids played recently by anything.
+func (r *PlayHistoryRepository) GetRecentlyPlayed(withinMinutes int) ([]string, error) {
+	cutoff := time.Now().UTC().Add(-time.Duration(withinMinutes) * time.Minute).Format(time.RFC3339)
+
+	return r.queryFavoriteIDs(`
+		SELECT DISTINCT sonos_favorite_id
+		FROM play_history
+		WHERE played_at >= ?
+		ORDER BY played_at DESC
+	`, cutoff)
+}
+
+// queryFavoriteIDs runs a query selecting sonos_favorite_id.
+func (r *PlayHistoryRepository) queryFavoriteIDs(query string, args ...any) ([]string, error) {
+	rows, err := r.reader.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -955,6 +1314,32 @@ func (r *PlayHistoryRepository) Prune(olderThanDays int) (int64, error) {
 	return result.RowsAffected()
 }
 
+// PruneOlderThan deletes play history recorded before cutoff and returns the count
+// deleted. Rows are deleted batchSize at a time so no single statement holds the write
+// lock for long.
+func (r *PlayHistoryRepository) PruneOlderThan(cutoff time.Time, batchSize int) (int64, error) {
+	cutoffStr := cutoff.UTC().Format(time.RFC3339)
+	var total int64
+	for {
+		result, err := r.writer.Exec(`
+			DELETE FROM play_history WHERE id IN (
+				SELECT id FROM play_history WHERE played_at < ? LIMIT ?
+			)
+		`, cutoffStr, batchSize)
+		if err != nil {
+			return total, err
+		}
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += deleted
+		if deleted < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
+
 func (r *PlayHistoryRepository) scanPlayHistoryRows(rows *sql.Rows) (*PlayHistory, error) {
 	var h PlayHistory
 	var setID, routineID sql.NullString
@@ -85,6 +85,24 @@ func TestMusicSetRepository_GetByID_NotFound(t *testing.T) {
 	require.Nil(t, set)
 }
 
+func TestMusicSetRepository_Names(t *testing.T) {
+	setRepo, _, _, _ := setupTestDB(t)
+
+	morning, err := setRepo.Create(CreateSetInput{Name: "Morning", SelectionPolicy: string(SelectionPolicyRotation)})
+	require.NoError(t, err)
+	deleted, err := setRepo.Create(CreateSetInput{Name: "Deleted", SelectionPolicy: string(SelectionPolicyRotation)})
+	require.NoError(t, err)
+	require.NoError(t, setRepo.Delete(deleted.SetID))
+
+	names, err := setRepo.Names([]string{morning.SetID, deleted.SetID, "nonexistent"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{morning.SetID: "Morning"}, names)
+
+	names, err = setRepo.Names(nil)
+	require.NoError(t, err)
+	require.Empty(t, names)
+}
+
 func TestMusicSetRepository_List(t *testing.T) {
 	setRepo, _, _, _ := setupTestDB(t)
 
@@ -303,6 +321,41 @@ func TestSetItemRepository_Add_AutoPosition(t *testing.T) {
 	require.Equal(t, 2, item3.Position)
 }
 
+func TestSetItemRepository_Add_PopulatesSetArtwork(t *testing.T) {
+	setRepo, itemRepo, _, _ := setupTestDB(t)
+
+	set, err := setRepo.Create(CreateSetInput{
+		Name:            "Test Set",
+		SelectionPolicy: string(SelectionPolicyRotation),
+	})
+	require.NoError(t, err)
+	require.Nil(t, set.ArtworkURL)
+
+	// Item without artwork leaves the set artwork unset
+	_, err = itemRepo.Add(set.SetID, AddItemInput{SonosFavoriteID: "fav-1"})
+	require.NoError(t, err)
+	stored, err := setRepo.GetByID(set.SetID)
+	require.NoError(t, err)
+	require.Nil(t, stored.ArtworkURL)
+
+	// First item with artwork populates the set artwork
+	firstArt := "https://example.com/first.jpg"
+	_, err = itemRepo.Add(set.SetID, AddItemInput{SonosFavoriteID: "fav-2", ArtworkURL: &firstArt})
+	require.NoError(t, err)
+	stored, err = setRepo.GetByID(set.SetID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.ArtworkURL)
+	require.Equal(t, firstArt, *stored.ArtworkURL)
+
+	// Later items do not overwrite existing set artwork
+	secondArt := "https://example.com/second.jpg"
+	_, err = itemRepo.Add(set.SetID, AddItemInput{SonosFavoriteID: "fav-3", ArtworkURL: &secondArt})
+	require.NoError(t, err)
+	stored, err = setRepo.GetByID(set.SetID)
+	require.NoError(t, err)
+	require.Equal(t, firstArt, *stored.ArtworkURL)
+}
+
 func TestSetItemRepository_Add_WithContentType(t *testing.T) {
 	setRepo, itemRepo, _, _ := setupTestDB(t)
 
@@ -537,6 +590,86 @@ func TestSetItemRepository_GetByPosition_NotFound(t *testing.T) {
 	require.Nil(t, item)
 }
 
+func TestSetItemRepository_CopyItems(t *testing.T) {
+	setRepo, itemRepo, _, _ := setupTestDB(t)
+
+	newSet := func(name string, favoriteIDs ...string) string {
+		set, err := setRepo.Create(CreateSetInput{Name: name, SelectionPolicy: string(SelectionPolicyRotation)})
+		require.NoError(t, err)
+		for _, id := range favoriteIDs {
+			_, err := itemRepo.Add(set.SetID, AddItemInput{SonosFavoriteID: id})
+			require.NoError(t, err)
+		}
+		return set.SetID
+	}
+	favoriteIDs := func(setID string) []string {
+		items, err := itemRepo.GetItems(setID)
+		require.NoError(t, err)
+		ids := []string{}
+		for i, item := range items {
+			require.Equal(t, i, item.Position)
+			ids = append(ids, item.SonosFavoriteID)
+		}
+		return ids
+	}
+
+	artworkURL := "https://example.com/cover.jpg"
+	content := `{"type":"direct","service":"spotify","content_type":"album","content_id":"1"}`
+	displayName := "Kind of Blue"
+	source := newSet("Winter", "fav-1", "fav-2")
+	_, err := itemRepo.Add(source, AddItemInput{SonosFavoriteID: "spotify:album:1", ContentType: "spotify", ContentJSON: &content, ArtworkURL: &artworkURL, DisplayName: &displayName})
+	require.NoError(t, err)
+	target := newSet("Holiday", "fav-2")
+
+	// Positions are copied in source order, after the target's items, skipping duplicates
+	result, err := itemRepo.CopyItems(source, target, []int{2, 1, 2}, false)
+	require.NoError(t, err)
+	require.Len(t, result.Copied, 1)
+	require.Equal(t, 1, result.Copied[0].Position)
+	require.Len(t, result.Skipped, 1)
+	require.Equal(t, "fav-2", result.Skipped[0].SonosFavoriteID)
+	require.Equal(t, []string{"fav-2", "spotify:album:1"}, favoriteIDs(target))
+	require.Equal(t, []string{"fav-1", "fav-2", "spotify:album:1"}, favoriteIDs(source))
+
+	copied, err := itemRepo.GetItem(target, "spotify:album:1")
+	require.NoError(t, err)
+	require.Equal(t, "spotify", copied.ContentType)
+	require.Equal(t, content, *copied.ContentJSON)
+	require.Equal(t, displayName, *copied.DisplayName)
+	targetSet, err := setRepo.GetByID(target)
+	require.NoError(t, err)
+	require.Equal(t, artworkURL, *targetSet.ArtworkURL)
+
+	t.Run("move removes from the source", func(t *testing.T) {
+		spring := newSet("Spring", "fav-9")
+		result, err := itemRepo.CopyItems(source, spring, []int{0, 2}, true)
+		require.NoError(t, err)
+		require.Len(t, result.Copied, 2)
+		require.Equal(t, []string{"fav-9", "fav-1", "spotify:album:1"}, favoriteIDs(spring))
+		require.Equal(t, []string{"fav-2"}, favoriteIDs(source))
+
+		// Every item, and skipped items still leave the source
+		_, err = itemRepo.Add(spring, AddItemInput{SonosFavoriteID: "fav-2"})
+		require.NoError(t, err)
+		result, err = itemRepo.CopyItems(spring, source, nil, true)
+		require.NoError(t, err)
+		require.Len(t, result.Copied, 3)
+		require.Len(t, result.Skipped, 1)
+		require.Equal(t, []string{"fav-2", "fav-9", "fav-1", "spotify:album:1"}, favoriteIDs(source))
+		require.Empty(t, favoriteIDs(spring))
+	})
+
+	t.Run("missing position changes nothing", func(t *testing.T) {
+		other := newSet("Other")
+		_, err := itemRepo.CopyItems(source, other, []int{0, 7}, true)
+		var positionErr *PositionNotFoundError
+		require.ErrorAs(t, err, &positionErr)
+		require.Equal(t, 7, positionErr.Position)
+		require.Empty(t, favoriteIDs(other))
+		require.Len(t, favoriteIDs(source), 4)
+	})
+}
+
 func TestSetItemRepository_CascadeDelete(t *testing.T) {
 	setRepo, itemRepo, _, _ := setupTestDB(t)
 
@@ -599,9 +732,9 @@ func TestPlayHistoryRepository_Record_WithRoutine(t *testing.T) {
 
 	routineID := "routine-test-123"
 	_, err = conn.Exec(`
-		INSERT INTO routines (routine_id, name, enabled, timezone, schedule_type, schedule_time, holiday_behavior, scene_id, music_mode, music_policy_type, skip_next, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))
-	`, routineID, "Test Routine", 1, "UTC", "weekly", "08:00", "SKIP", sceneID, "FIXED", "FIXED", 0)
+		INSERT INTO routines (routine_id, name, enabled, timezone, schedule_type, schedule_time, holiday_behavior, scene_id, music_policy_type, skip_next, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))
+	`, routineID, "Test Routine", 1, "UTC", "weekly", "08:00", "SKIP", sceneID, "FIXED", 0)
 	require.NoError(t, err)
 
 	err = historyRepo.Record("fav-123", nil, &routineID)
@@ -726,6 +859,27 @@ func TestPlayHistoryRepository_GetRecentlyPlayedInSet(t *testing.T) {
 	require.Contains(t, recentlyPlayed, "fav-2")
 }
 
+func TestPlayHistoryRepository_GetRecentlyPlayed(t *testing.T) {
+	setRepo, _, historyRepo, _ := setupTestDB(t)
+
+	first, err := setRepo.Create(CreateSetInput{Name: "First", SelectionPolicy: string(SelectionPolicyRotation)})
+	require.NoError(t, err)
+	second, err := setRepo.Create(CreateSetInput{Name: "Second", SelectionPolicy: string(SelectionPolicyRotation)})
+	require.NoError(t, err)
+
+	require.NoError(t, historyRepo.Record("fav-1", &first.SetID, nil))
+	require.NoError(t, historyRepo.Record("fav-2", &second.SetID, nil))
+	require.NoError(t, historyRepo.Record("fav-3", nil, nil))
+
+	recentlyPlayed, err := historyRepo.GetRecentlyPlayed(5)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"fav-1", "fav-2", "fav-3"}, recentlyPlayed)
+
+	byRoutine, err := historyRepo.GetRecentlyPlayedByRoutine("routine-none", 5)
+	require.NoError(t, err)
+	require.Empty(t, byRoutine)
+}
+
 func TestPlayHistoryRepository_GetRecentlyPlayedInSet_Empty(t *testing.T) {
 	setRepo, _, historyRepo, _ := setupTestDB(t)
 
@@ -784,6 +938,34 @@ func TestPlayHistoryRepository_Prune_NoOldRecords(t *testing.T) {
 	require.Equal(t, int64(0), deleted)
 }
 
+func TestPlayHistoryRepository_PruneOlderThan(t *testing.T) {
+	_, _, historyRepo, conn := setupTestDB(t)
+
+	require.NoError(t, historyRepo.Record("fav-recent", nil, nil))
+
+	// Five old records, so pruning takes more than one batch of two
+	for i := 0; i < 5; i++ {
+		oldTime := time.Now().UTC().AddDate(0, 0, -400-i).Format(time.RFC3339)
+		_, err := conn.Exec(`
+			INSERT INTO play_history (sonos_favorite_id, played_at)
+			VALUES (?, ?)
+		`, "fav-old", oldTime)
+		require.NoError(t, err)
+	}
+
+	deleted, err := historyRepo.PruneOlderThan(time.Now().AddDate(0, 0, -365), 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), deleted)
+
+	history, err := historyRepo.GetHistory("fav-old", 10)
+	require.NoError(t, err)
+	require.Len(t, history, 0)
+
+	history, err = historyRepo.GetHistory("fav-recent", 10)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+}
+
 // ==========================================================================
 // Integration Tests
 // ==========================================================================
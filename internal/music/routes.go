@@ -1,17 +1,26 @@
 package music
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
 	"github.com/strefethen/sonos-hub-go/internal/api"
 	"github.com/strefethen/sonos-hub-go/internal/apperrors"
 	"github.com/strefethen/sonos-hub-go/internal/applemusic"
+	"github.com/strefethen/sonos-hub-go/internal/artwork"
+	"github.com/strefethen/sonos-hub-go/internal/audit"
 	"github.com/strefethen/sonos-hub-go/internal/devices"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
 	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
 	"github.com/strefethen/sonos-hub-go/internal/spotifysearch"
 )
@@ -20,47 +29,57 @@ import (
 // spotifyManager is optional - if nil, Spotify search will return 503.
 // appleClient is optional - if nil, Apple Music search will return 503.
 // soapClient and deviceService are optional - if nil, library search will return empty results.
-func RegisterRoutes(router chi.Router, service *Service, spotifyManager *spotifysearch.ConnectionManager, appleClient *applemusic.Client, soapClient *soap.Client, deviceService *devices.Service) {
+// recorder is optional - if nil, set changes aren't audited.
+func RegisterRoutes(router chi.Router, service *Service, spotifyManager *spotifysearch.ConnectionManager, appleClient *applemusic.Client, soapClient *soap.Client, deviceService *devices.Service, recorder audit.Recorder) {
 	// Create library provider if dependencies are available
 	var libraryProvider *LibraryProvider
 	if soapClient != nil && deviceService != nil {
 		libraryProvider = NewLibraryProvider(soapClient, deviceService)
 	}
+	searchCache := newSearchCache(time.Duration(service.cfg.SearchCacheTTLSeconds)*time.Second, service.cfg.SearchCacheMaxEntries)
 	// Set CRUD
-	router.Method(http.MethodPost, "/v1/music/sets", api.Handler(createSet(service)))
+	router.Method(http.MethodPost, "/v1/music/sets", api.Handler(createSet(service, recorder)))
 	router.Method(http.MethodGet, "/v1/music/sets", api.Handler(listSets(service)))
+	router.Method(http.MethodPut, "/v1/music/sets/reorder", api.Handler(reorderSets(service, recorder)))
+	router.Method(http.MethodPost, "/v1/music/sets/import", api.Handler(importSet(service, recorder)))
 	router.Method(http.MethodGet, "/v1/music/sets/{set_id}", api.Handler(getSet(service)))
-	router.Method(http.MethodPatch, "/v1/music/sets/{set_id}", api.Handler(updateSet(service)))
-	router.Method(http.MethodDelete, "/v1/music/sets/{set_id}", api.Handler(deleteSet(service)))
-	router.Method(http.MethodPost, "/v1/music/sets/{set_id}/restore", api.Handler(restoreSet(service)))
+	router.Method(http.MethodPatch, "/v1/music/sets/{set_id}", api.Handler(updateSet(service, recorder)))
+	router.Method(http.MethodDelete, "/v1/music/sets/{set_id}", api.Handler(deleteSet(service, recorder)))
+	router.Method(http.MethodPost, "/v1/music/sets/{set_id}/restore", api.Handler(restoreSet(service, recorder)))
+	router.Method(http.MethodGet, "/v1/music/sets/{set_id}/export", api.Handler(exportSet(service)))
 
 	// Item management
-	router.Method(http.MethodPost, "/v1/music/sets/{set_id}/items", api.Handler(addItem(service)))
+	router.Method(http.MethodPost, "/v1/music/sets/{set_id}/items", api.Handler(addItem(service, recorder)))
 	router.Method(http.MethodGet, "/v1/music/sets/{set_id}/items", api.Handler(listItems(service)))
-	router.Method(http.MethodDelete, "/v1/music/sets/{set_id}/items/{sonos_favorite_id}", api.Handler(removeItem(service)))
-	router.Method(http.MethodPut, "/v1/music/sets/{set_id}/items/reorder", api.Handler(reorderItems(service)))
+	router.Method(http.MethodGet, "/v1/music/sets/{set_id}/items/search", api.Handler(searchItems(service)))
+	router.Method(http.MethodDelete, "/v1/music/sets/{set_id}/items/{sonos_favorite_id}", api.Handler(removeItem(service, recorder)))
+	router.Method(http.MethodPut, "/v1/music/sets/{set_id}/items/reorder", api.Handler(reorderItems(service, recorder)))
+	router.Method(http.MethodPost, "/v1/music/sets/{set_id}/items/copy", api.Handler(copyItems(service, recorder)))
 
 	// History
 	router.Method(http.MethodGet, "/v1/music/sets/{set_id}/history", api.Handler(getHistory(service)))
+	router.Method(http.MethodGet, "/v1/music/sets/{set_id}/shuffle-preview", api.Handler(shufflePreview(service)))
 
 	// Content management (iOS app format)
-	router.Method(http.MethodPost, "/v1/music/sets/{set_id}/content", api.Handler(addContent(service)))
-	router.Method(http.MethodDelete, "/v1/music/sets/{set_id}/content/{position}", api.Handler(removeContentByPosition(service)))
+	router.Method(http.MethodPost, "/v1/music/sets/{set_id}/content", api.Handler(addContent(service, recorder)))
+	router.Method(http.MethodDelete, "/v1/music/sets/{set_id}/content/{position}", api.Handler(removeContentByPosition(service, recorder)))
 
 	// Play music set on device
 	router.Method(http.MethodPost, "/v1/music/sets/{set_id}/play", api.Handler(playSet(service)))
+	router.Method(http.MethodPost, "/v1/music/sets/{set_id}/items/{position}/preview", api.Handler(previewItem(service)))
 
 	// Search and suggestions
-	router.Method(http.MethodGet, "/v1/music/search", api.Handler(searchMusic(spotifyManager, appleClient, libraryProvider)))
+	router.Method(http.MethodGet, "/v1/music/search", api.Handler(searchMusic(spotifyManager, appleClient, libraryProvider, searchCache)))
 	router.Method(http.MethodGet, "/v1/music/suggestions", api.Handler(getMusicSuggestions(appleClient)))
+	router.Method(http.MethodGet, "/v1/music/library/browse", api.Handler(browseLibrary(libraryProvider)))
 
 	// Providers
-	router.Method(http.MethodGet, "/v1/music/providers", api.Handler(listProviders(service, spotifyManager, appleClient)))
+	router.Method(http.MethodGet, "/v1/music/providers", api.Handler(listProviders(service, spotifyManager, appleClient, searchCache)))
 }
 
 // createSet handles POST /v1/music/sets
 // Returns set at root level matching Node.js format
-func createSet(service *Service) func(w http.ResponseWriter, r *http.Request) error {
+func createSet(service *Service, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		var input CreateSetInput
 		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
@@ -81,11 +100,15 @@ func createSet(service *Service) func(w http.ResponseWriter, r *http.Request) er
 				"allowed_values": []string{string(SelectionPolicyRotation), string(SelectionPolicyShuffle)},
 			})
 		}
+		if err := validateOccasionDates(input.OccasionStart, input.OccasionEnd); err != nil {
+			return err
+		}
 
 		set, err := service.CreateSet(input)
 		if err != nil {
 			return apperrors.NewInternalError("Failed to create set")
 		}
+		recordSetChange(r, recorder, audit.EventMusicSetCreated, "create", set.SetID, nil, set)
 
 		// Stripe-style: return resource directly
 		setResponse := map[string]any{
@@ -108,6 +131,73 @@ func createSet(service *Service) func(w http.ResponseWriter, r *http.Request) er
 	}
 }
 
+// exportSet handles GET /v1/music/sets/{set_id}/export
+// Returns the set and its items as a document another hub can import.
+func exportSet(service *Service) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		setID := chi.URLParam(r, "set_id")
+
+		export, err := service.ExportSet(setID)
+		if err != nil {
+			if isSetNotFoundError(err) {
+				return apperrors.NewAppError(apperrors.ErrorCodeSetNotFound, "Set not found", 404, map[string]any{"set_id": setID}, nil)
+			}
+			return apperrors.NewInternalError("Failed to export set")
+		}
+		return api.WriteResource(w, http.StatusOK, export)
+	}
+}
+
+// importSet handles POST /v1/music/sets/import
+// Creates a new set from an export document. ?on_conflict=rename imports under a free
+// "Name (N)" when the name is taken; the default, error, fails with 409.
+func importSet(service *Service, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var options ImportSetOptions
+		switch onConflict := r.URL.Query().Get("on_conflict"); onConflict {
+		case "", "error":
+		case "rename":
+			options.RenameOnConflict = true
+		default:
+			return apperrors.NewValidationError("on_conflict must be error or rename", map[string]any{
+				"allowed_values": []string{"error", "rename"},
+			})
+		}
+
+		var export SetExport
+		if err := json.NewDecoder(r.Body).Decode(&export); err != nil {
+			return apperrors.NewValidationError("invalid request body", nil)
+		}
+		if err := validateOccasionDates(export.Set.OccasionStart, export.Set.OccasionEnd); err != nil {
+			return err
+		}
+
+		result, err := service.ImportSet(&export, options)
+		if err != nil {
+			switch err := err.(type) {
+			case *InvalidSetExportError:
+				return apperrors.NewValidationError(err.Reason, nil)
+			case *SetNameConflictError:
+				return apperrors.NewAppError(apperrors.ErrorCodeConflict, "A music set with this name already exists", 409, map[string]any{
+					"name": err.Name,
+					"hint": "retry with ?on_conflict=rename",
+				}, nil)
+			}
+			return apperrors.NewInternalError("Failed to import set")
+		}
+		recordSetChange(r, recorder, audit.EventMusicSetCreated, "import", result.Set.SetID, nil, result.Set)
+
+		return api.WriteResource(w, http.StatusCreated, map[string]any{
+			"object":             "music_set_import",
+			"set":                formatSet(result.Set),
+			"original_name":      result.OriginalName,
+			"renamed":            result.Set.Name != result.OriginalName,
+			"favorites_checked":  result.FavoritesChecked,
+			"unlinked_favorites": result.UnlinkedFavorites,
+		})
+	}
+}
+
 // listSets handles GET /v1/music/sets
 // Node.js returns all sets without pagination
 func listSets(service *Service) func(w http.ResponseWriter, r *http.Request) error {
@@ -145,11 +235,11 @@ func listSets(service *Service) func(w http.ResponseWriter, r *http.Request) err
 				if formattedSet["artwork_url"] == nil && len(items) > 0 {
 					firstItem := items[0]
 					if firstItem.ArtworkURL != nil && *firstItem.ArtworkURL != "" {
-						formattedSet["artwork_url"] = *firstItem.ArtworkURL
+						formattedSet["artwork_url"] = artwork.RewriteURL(*firstItem.ArtworkURL)
 					} else if firstItem.ContentJSON != nil && *firstItem.ContentJSON != "" {
 						var metadata ContentMetadata
 						if err := json.Unmarshal([]byte(*firstItem.ContentJSON), &metadata); err == nil && metadata.ArtworkURL != "" {
-							formattedSet["artwork_url"] = metadata.ArtworkURL
+							formattedSet["artwork_url"] = artwork.RewriteURL(metadata.ArtworkURL)
 						}
 					}
 				}
@@ -173,6 +263,46 @@ func listSets(service *Service) func(w http.ResponseWriter, r *http.Request) err
 	}
 }
 
+// reorderSets handles PUT /v1/music/sets/reorder
+// Expects {"set_ids": ["id1", "id2", ...]} covering every set exactly once
+func reorderSets(service *Service, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var input ReorderSetsInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			return apperrors.NewValidationError("invalid request body", nil)
+		}
+
+		if input.SetIDs == nil {
+			return apperrors.NewValidationError("set_ids array is required", nil)
+		}
+
+		before := setOrder(service, recorder)
+		if err := service.ReorderSets(input); err != nil {
+			if orderErr, ok := err.(*InvalidSetOrderError); ok {
+				details := map[string]any{}
+				if len(orderErr.Missing) > 0 {
+					details["missing_set_ids"] = orderErr.Missing
+				}
+				if len(orderErr.Unknown) > 0 {
+					details["unknown_set_ids"] = orderErr.Unknown
+				}
+				if len(orderErr.Duplicate) > 0 {
+					details["duplicate_set_ids"] = orderErr.Duplicate
+				}
+				return apperrors.NewValidationError("set_ids must contain every set exactly once", details)
+			}
+			return apperrors.NewInternalError("Failed to reorder sets")
+		}
+		recordSetChange(r, recorder, audit.EventMusicSetUpdated, "reorder", "", before, map[string]any{"set_ids": input.SetIDs})
+
+		// Stripe-style: return action result directly
+		return api.WriteAction(w, http.StatusOK, map[string]any{
+			"object":  "reorder",
+			"success": true,
+		})
+	}
+}
+
 // getSet handles GET /v1/music/sets/{set_id}
 // Returns set with items, matching Node.js format exactly
 func getSet(service *Service) func(w http.ResponseWriter, r *http.Request) error {
@@ -252,7 +382,7 @@ func getSet(service *Service) func(w http.ResponseWriter, r *http.Request) error
 				formattedItem["service_name"] = *item.ServiceName
 			}
 			if item.ArtworkURL != nil {
-				formattedItem["artwork_url"] = *item.ArtworkURL
+				formattedItem["artwork_url"] = artwork.RewriteURL(*item.ArtworkURL)
 			}
 			// Populate display_name from item or extract from content_json
 			if item.DisplayName != nil {
@@ -306,7 +436,7 @@ func getSet(service *Service) func(w http.ResponseWriter, r *http.Request) error
 
 // updateSet handles PATCH /v1/music/sets/{set_id}
 // Returns set at root level matching Node.js format
-func updateSet(service *Service) func(w http.ResponseWriter, r *http.Request) error {
+func updateSet(service *Service, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		setID := chi.URLParam(r, "set_id")
 
@@ -323,7 +453,11 @@ func updateSet(service *Service) func(w http.ResponseWriter, r *http.Request) er
 				})
 			}
 		}
+		if err := validateOccasionDates(input.OccasionStart, input.OccasionEnd); err != nil {
+			return err
+		}
 
+		before := setBeforeChange(service, recorder, setID)
 		set, err := service.UpdateSet(setID, input)
 		if err != nil {
 			if isSetNotFoundError(err) {
@@ -331,6 +465,7 @@ func updateSet(service *Service) func(w http.ResponseWriter, r *http.Request) er
 			}
 			return apperrors.NewInternalError("Failed to update set")
 		}
+		recordSetChange(r, recorder, audit.EventMusicSetUpdated, "update", setID, before, set)
 
 		// Stripe-style: return resource directly
 		setResponse := map[string]any{
@@ -355,10 +490,11 @@ func updateSet(service *Service) func(w http.ResponseWriter, r *http.Request) er
 
 // deleteSet handles DELETE /v1/music/sets/{set_id}
 // Returns 204 No Content with empty body matching Node.js
-func deleteSet(service *Service) func(w http.ResponseWriter, r *http.Request) error {
+func deleteSet(service *Service, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		setID := chi.URLParam(r, "set_id")
 
+		before := setBeforeChange(service, recorder, setID)
 		err := service.DeleteSet(setID)
 		if err != nil {
 			// Check for not found error
@@ -367,6 +503,7 @@ func deleteSet(service *Service) func(w http.ResponseWriter, r *http.Request) er
 			}
 			return apperrors.NewInternalError("Failed to delete set")
 		}
+		recordSetChange(r, recorder, audit.EventMusicSetDeleted, "delete", setID, before, nil)
 
 		w.WriteHeader(http.StatusNoContent)
 		return nil
@@ -375,7 +512,7 @@ func deleteSet(service *Service) func(w http.ResponseWriter, r *http.Request) er
 
 // restoreSet handles POST /v1/music/sets/{set_id}/restore
 // Restores a soft-deleted music set
-func restoreSet(service *Service) func(w http.ResponseWriter, r *http.Request) error {
+func restoreSet(service *Service, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		setID := chi.URLParam(r, "set_id")
 
@@ -401,6 +538,7 @@ func restoreSet(service *Service) func(w http.ResponseWriter, r *http.Request) e
 			}
 			return apperrors.NewInternalError("Failed to restore set")
 		}
+		recordSetChange(r, recorder, audit.EventMusicSetUpdated, "restore", setID, set, restoredSet)
 
 		// Stripe-style: return resource directly
 		setResponse := map[string]any{
@@ -424,7 +562,7 @@ func restoreSet(service *Service) func(w http.ResponseWriter, r *http.Request) e
 
 // addItem handles POST /v1/music/sets/{set_id}/items
 // Returns item at root level matching Node.js format
-func addItem(service *Service) func(w http.ResponseWriter, r *http.Request) error {
+func addItem(service *Service, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		setID := chi.URLParam(r, "set_id")
 
@@ -438,6 +576,7 @@ func addItem(service *Service) func(w http.ResponseWriter, r *http.Request) erro
 			return apperrors.NewValidationError("sonos_favorite_id is required", nil)
 		}
 
+		before := itemOrder(service, recorder, setID)
 		item, err := service.AddItem(setID, input)
 		if err != nil {
 			if isSetNotFoundError(err) {
@@ -445,6 +584,7 @@ func addItem(service *Service) func(w http.ResponseWriter, r *http.Request) erro
 			}
 			return apperrors.NewInternalError("Failed to add item to set")
 		}
+		recordItemsChange(r, service, recorder, "add_item", setID, before)
 
 		// Build music_content
 		musicContent := map[string]any{
@@ -473,7 +613,7 @@ func addItem(service *Service) func(w http.ResponseWriter, r *http.Request) erro
 			itemResponse["service_name"] = *item.ServiceName
 		}
 		if item.ArtworkURL != nil {
-			itemResponse["artwork_url"] = *item.ArtworkURL
+			itemResponse["artwork_url"] = artwork.RewriteURL(*item.ArtworkURL)
 		}
 		if item.DisplayName != nil {
 			itemResponse["display_name"] = *item.DisplayName
@@ -521,13 +661,43 @@ func listItems(service *Service) func(w http.ResponseWriter, r *http.Request) er
 	}
 }
 
+// searchItems handles GET /v1/music/sets/{set_id}/items/search?q=
+// Filters the set's items by display name, title, artist, or album. Each match
+// includes its position so the set editor can jump straight to it.
+func searchItems(service *Service) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		setID := chi.URLParam(r, "set_id")
+
+		query := strings.TrimSpace(r.URL.Query().Get("q"))
+		if query == "" {
+			return apperrors.NewValidationError("q is required", nil)
+		}
+
+		items, err := service.SearchItems(setID, query)
+		if err != nil {
+			if isSetNotFoundError(err) {
+				return apperrors.NewAppError(apperrors.ErrorCodeSetNotFound, "Set not found", 404, map[string]any{"set_id": setID}, nil)
+			}
+			return apperrors.NewInternalError("Failed to search items")
+		}
+
+		formatted := make([]map[string]any, 0, len(items))
+		for i := range items {
+			formatted = append(formatted, formatItem(&items[i]))
+		}
+
+		return api.WriteList(w, "/v1/music/sets/"+setID+"/items/search", formatted, false)
+	}
+}
+
 // removeItem handles DELETE /v1/music/sets/{set_id}/items/{sonos_favorite_id}
 // Returns 204 No Content with empty body matching Node.js
-func removeItem(service *Service) func(w http.ResponseWriter, r *http.Request) error {
+func removeItem(service *Service, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		setID := chi.URLParam(r, "set_id")
 		sonosFavoriteID := chi.URLParam(r, "sonos_favorite_id")
 
+		before := itemOrder(service, recorder, setID)
 		err := service.RemoveItem(setID, sonosFavoriteID)
 		if err != nil {
 			if isSetNotFoundError(err) {
@@ -541,6 +711,7 @@ func removeItem(service *Service) func(w http.ResponseWriter, r *http.Request) e
 			}
 			return apperrors.NewInternalError("Failed to remove item from set")
 		}
+		recordItemsChange(r, service, recorder, "remove_item", setID, before)
 
 		w.WriteHeader(http.StatusNoContent)
 		return nil
@@ -550,7 +721,7 @@ func removeItem(service *Service) func(w http.ResponseWriter, r *http.Request) e
 // reorderItems handles PUT /v1/music/sets/{set_id}/items/reorder
 // Note: Go expects {"items": ["id1", "id2"]} while Node.js expects {"positions": [{sonos_favorite_id, position}]}
 // Returns { success: true } matching Node.js format
-func reorderItems(service *Service) func(w http.ResponseWriter, r *http.Request) error {
+func reorderItems(service *Service, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		setID := chi.URLParam(r, "set_id")
 
@@ -564,6 +735,7 @@ func reorderItems(service *Service) func(w http.ResponseWriter, r *http.Request)
 			return apperrors.NewValidationError("items array is required and must not be empty", nil)
 		}
 
+		before := itemOrder(service, recorder, setID)
 		err := service.ReorderItems(setID, input)
 		if err != nil {
 			if isSetNotFoundError(err) {
@@ -576,6 +748,7 @@ func reorderItems(service *Service) func(w http.ResponseWriter, r *http.Request)
 			}
 			return apperrors.NewInternalError("Failed to reorder items")
 		}
+		recordItemsChange(r, service, recorder, "reorder_items", setID, before)
 
 		// Stripe-style: return action result directly
 		return api.WriteAction(w, http.StatusOK, map[string]any{
@@ -623,6 +796,67 @@ func getHistory(service *Service) func(w http.ResponseWriter, r *http.Request) e
 	}
 }
 
+// shufflePreview handles GET /v1/music/sets/{set_id}/shuffle-preview
+// Simulates upcoming shuffle picks without recording history.
+func shufflePreview(service *Service) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		setID := chi.URLParam(r, "set_id")
+
+		count := 10
+		if c := r.URL.Query().Get("count"); c != "" {
+			parsed, err := strconv.Atoi(c)
+			if err != nil || parsed < 1 || parsed > 100 {
+				return apperrors.NewValidationError("count must be between 1 and 100", nil)
+			}
+			count = parsed
+		}
+
+		var noRepeatWindow *int
+		if raw := r.URL.Query().Get("no_repeat_window_minutes"); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed < 0 {
+				return apperrors.NewValidationError("no_repeat_window_minutes must be a non-negative integer", nil)
+			}
+			noRepeatWindow = &parsed
+		}
+
+		items, err := service.PreviewShuffle(setID, count, noRepeatWindow)
+		if err != nil {
+			if isSetNotFoundError(err) {
+				return apperrors.NewAppError(apperrors.ErrorCodeSetNotFound, "Set not found", 404, map[string]any{"set_id": setID}, nil)
+			}
+			if notShuffle, ok := err.(*NotShuffleSetError); ok {
+				return apperrors.NewValidationError("shuffle preview is only available for SHUFFLE sets", map[string]any{
+					"set_id":           setID,
+					"selection_policy": notShuffle.SelectionPolicy,
+				})
+			}
+			if isEmptySetError(err) {
+				return apperrors.NewValidationError("set has no items", map[string]any{"set_id": setID})
+			}
+			return apperrors.NewInternalError("Failed to preview shuffle")
+		}
+
+		formatted := make([]map[string]any, 0, len(items))
+		for i := range items {
+			formatted = append(formatted, formatItem(&items[i]))
+		}
+
+		response := map[string]any{
+			"object":                   "shuffle_preview",
+			"set_id":                   setID,
+			"count":                    len(formatted),
+			"no_repeat_window_minutes": nil,
+			"items":                    formatted,
+		}
+		if noRepeatWindow != nil {
+			response["no_repeat_window_minutes"] = *noRepeatWindow
+		}
+
+		return api.WriteResource(w, http.StatusOK, response)
+	}
+}
+
 // formatSet formats a MusicSet for JSON response.
 func formatSet(set *MusicSet) map[string]any {
 	result := map[string]any{
@@ -644,7 +878,10 @@ func formatSet(set *MusicSet) map[string]any {
 		result["occasion_end"] = *set.OccasionEnd
 	}
 	if set.ArtworkURL != nil {
-		result["artwork_url"] = *set.ArtworkURL
+		result["artwork_url"] = artwork.RewriteURL(*set.ArtworkURL)
+	}
+	if set.SortOrder != nil {
+		result["sort_order"] = *set.SortOrder
 	}
 	return result
 }
@@ -668,7 +905,7 @@ func formatItem(item *SetItem) map[string]any {
 		result["service_name"] = *item.ServiceName
 	}
 	if item.ArtworkURL != nil {
-		result["artwork_url"] = *item.ArtworkURL
+		result["artwork_url"] = artwork.RewriteURL(*item.ArtworkURL)
 	}
 	if item.DisplayName != nil {
 		result["display_name"] = *item.DisplayName
@@ -701,6 +938,24 @@ func formatHistory(h *PlayHistory) map[string]any {
 
 // Error type checking helpers - these should match error types defined in the service layer
 
+// validateOccasionDates checks that the occasion dates given are MM-DD. Empty strings
+// are allowed, since they clear the date on update.
+func validateOccasionDates(start, end *string) error {
+	fields := []struct {
+		name  string
+		value *string
+	}{{"occasion_start", start}, {"occasion_end", end}}
+	for _, field := range fields {
+		if field.value == nil || *field.value == "" {
+			continue
+		}
+		if _, _, err := ParseOccasionDate(*field.value); err != nil {
+			return apperrors.NewValidationError(field.name+" must be a MM-DD date, such as 12-20", map[string]any{field.name: *field.value})
+		}
+	}
+	return nil
+}
+
 // SetNotFoundError represents a set not found error.
 type SetNotFoundError struct {
 	SetID string
@@ -739,6 +994,27 @@ func (e *PositionNotFoundError) Error() string {
 	return "item not found at position"
 }
 
+// InvalidSetOrderError represents a set reorder request that is not a permutation of existing sets.
+type InvalidSetOrderError struct {
+	Missing   []string
+	Unknown   []string
+	Duplicate []string
+}
+
+func (e *InvalidSetOrderError) Error() string {
+	return "set order must include every set exactly once"
+}
+
+// NotShuffleSetError represents a shuffle-only operation on a non-SHUFFLE set.
+type NotShuffleSetError struct {
+	SetID           string
+	SelectionPolicy string
+}
+
+func (e *NotShuffleSetError) Error() string {
+	return "set is not a SHUFFLE set: " + e.SetID
+}
+
 // isSetNotFoundError checks if the error is a SetNotFoundError.
 func isSetNotFoundError(err error) bool {
 	_, ok := err.(*SetNotFoundError)
@@ -779,7 +1055,7 @@ func getContentType(mc MusicContent) string {
 
 // addContent handles POST /v1/music/sets/{set_id}/content
 // This endpoint uses the MusicContent format expected by the iOS app.
-func addContent(service *Service) func(w http.ResponseWriter, r *http.Request) error {
+func addContent(service *Service, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		setID := chi.URLParam(r, "set_id")
 
@@ -833,6 +1109,7 @@ func addContent(service *Service) func(w http.ResponseWriter, r *http.Request) e
 			ContentJSON:     &contentJSONStr,
 		}
 
+		before := itemOrder(service, recorder, setID)
 		item, err := service.AddItem(setID, addInput)
 		if err != nil {
 			if isSetNotFoundError(err) {
@@ -840,6 +1117,7 @@ func addContent(service *Service) func(w http.ResponseWriter, r *http.Request) e
 			}
 			return apperrors.NewInternalError("Failed to add content to set")
 		}
+		recordItemsChange(r, service, recorder, "add_item", setID, before)
 
 		// Stripe-style: return resource directly
 		itemResponse := map[string]any{
@@ -862,7 +1140,7 @@ func addContent(service *Service) func(w http.ResponseWriter, r *http.Request) e
 			itemResponse["service_name"] = *item.ServiceName
 		}
 		if item.ArtworkURL != nil {
-			itemResponse["artwork_url"] = *item.ArtworkURL
+			itemResponse["artwork_url"] = artwork.RewriteURL(*item.ArtworkURL)
 		}
 		if item.DisplayName != nil {
 			itemResponse["display_name"] = *item.DisplayName
@@ -872,9 +1150,79 @@ func addContent(service *Service) func(w http.ResponseWriter, r *http.Request) e
 	}
 }
 
+// copyItems handles POST /v1/music/sets/{set_id}/items/copy
+// Copies (or with move, moves) items from another set, appending them after this set's
+// items. Items this set already has are skipped.
+func copyItems(service *Service, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		setID := chi.URLParam(r, "set_id")
+
+		var input CopyItemsInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			return apperrors.NewValidationError("invalid request body", nil)
+		}
+		if input.SourceSetID == "" {
+			return apperrors.NewValidationError("source_set_id is required", nil)
+		}
+		if input.SourceSetID == setID {
+			return apperrors.NewValidationError("source_set_id must be a different set", nil)
+		}
+		for _, position := range input.Positions {
+			if position < 0 {
+				return apperrors.NewValidationError("positions must be non-negative integers", map[string]any{"provided": position})
+			}
+		}
+
+		action := "copy_items"
+		if input.Move {
+			action = "move_items"
+		}
+		before := itemOrder(service, recorder, setID)
+		sourceBefore := itemOrder(service, recorder, input.SourceSetID)
+
+		result, err := service.CopyItems(setID, input)
+		if err != nil {
+			switch err := err.(type) {
+			case *SetNotFoundError:
+				return apperrors.NewAppError(apperrors.ErrorCodeSetNotFound, "Set not found", 404, map[string]any{"set_id": err.SetID}, nil)
+			case *PositionNotFoundError:
+				return apperrors.NewAppError("ITEM_NOT_FOUND", "Item not found at position", 404, map[string]any{
+					"set_id":   err.SetID,
+					"position": err.Position,
+				}, nil)
+			}
+			return apperrors.NewInternalError("Failed to copy items")
+		}
+		recordItemsChange(r, service, recorder, action, setID, before)
+		if input.Move {
+			recordItemsChange(r, service, recorder, action, input.SourceSetID, sourceBefore)
+		}
+
+		copied := make([]map[string]any, 0, len(result.Copied))
+		for i := range result.Copied {
+			copied = append(copied, formatItem(&result.Copied[i]))
+		}
+		skipped := make([]map[string]any, 0, len(result.Skipped))
+		for i := range result.Skipped {
+			skipped = append(skipped, formatItem(&result.Skipped[i]))
+		}
+
+		return api.WriteAction(w, http.StatusOK, map[string]any{
+			"object":        "set_item_copy",
+			"set_id":        setID,
+			"source_set_id": input.SourceSetID,
+			"moved":         input.Move,
+			"copied_count":  len(copied),
+			"skipped_count": len(skipped),
+			"copied":        copied,
+			"skipped":       skipped,
+		})
+	}
+}
+
 // removeContentByPosition handles DELETE /v1/music/sets/{set_id}/content/{position}
 // Removes an item from a music set by its position (0-indexed).
-func removeContentByPosition(service *Service) func(w http.ResponseWriter, r *http.Request) error {
+func removeContentByPosition(service *Service, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		setID := chi.URLParam(r, "set_id")
 		positionStr := chi.URLParam(r, "position")
@@ -886,6 +1234,7 @@ func removeContentByPosition(service *Service) func(w http.ResponseWriter, r *ht
 			})
 		}
 
+		before := itemOrder(service, recorder, setID)
 		err = service.RemoveItemByPosition(setID, position)
 		if err != nil {
 			if isSetNotFoundError(err) {
@@ -899,6 +1248,7 @@ func removeContentByPosition(service *Service) func(w http.ResponseWriter, r *ht
 			}
 			return apperrors.NewInternalError("Failed to remove content from set")
 		}
+		recordItemsChange(r, service, recorder, "remove_item", setID, before)
 
 		w.WriteHeader(http.StatusNoContent)
 		return nil
@@ -929,6 +1279,9 @@ func playSet(service *Service) func(w http.ResponseWriter, r *http.Request) erro
 		if speakerID == "" {
 			return apperrors.NewValidationError("speaker_id is required", nil)
 		}
+		if input.Volume != nil && (*input.Volume < 0 || *input.Volume > 100) {
+			return apperrors.NewValidationError("volume must be between 0 and 100", map[string]any{"volume": *input.Volume})
+		}
 
 		// 1. Verify set exists and get items
 		_, err := service.GetSet(setID)
@@ -947,53 +1300,279 @@ func playSet(service *Service) func(w http.ResponseWriter, r *http.Request) erro
 			return apperrors.NewAppError("SET_EMPTY", "Music set has no items to play", 400, nil, nil)
 		}
 
-		// 2. Select next content from set using its policy (168 hours = 1 week no-repeat)
-		noRepeatHours := 168
-		selectionInput := SelectItemInput{NoRepeatWindowMinutes: &noRepeatHours}
-		result, err := service.SelectItem(setID, selectionInput)
+		// 2. Select the next item and play it on the speaker
+		result, err := service.PlaySet(r.Context(), setID, speakerID, input)
 		if err != nil {
-			if isSetNotFoundError(err) {
-				return apperrors.NewAppError(apperrors.ErrorCodeSetNotFound, "Set not found", 404, map[string]any{"set_id": setID}, nil)
+			return playSetError(r.Context(), setID, speakerID, err)
+		}
+
+		stripPlaybackDebug(r, result.Playback)
+
+		response := map[string]any{
+			"object":        "play_set",
+			"set_id":        setID,
+			"udn":           speakerID,
+			"item":          result.Item,
+			"music_content": result.Content,
+			"playback":      result.Playback,
+			"volume":        nil,
+			"status":        "playing",
+		}
+		if result.Volume != nil {
+			response["volume"] = map[string]any{
+				"level":           result.Volume.Level,
+				"succeeded_count": result.Volume.Succeeded,
+				"failed_count":    result.Volume.Failed,
+				"all_succeeded":   result.Volume.Failed == 0,
 			}
-			if isEmptySetError(err) {
-				return apperrors.NewAppError("SELECTION_FAILED", "Could not select music from set", 400, nil, nil)
-			}
-			return apperrors.NewInternalError("Failed to select item")
 		}
+		return api.WriteAction(w, http.StatusOK, response)
+	}
+}
 
-		// Build music_content for response
-		var musicContent map[string]any
-		if result.Item != nil {
-			musicContent = map[string]any{
-				"type":        "sonos_favorite",
-				"favorite_id": result.Item.SonosFavoriteID,
+// previewItem handles POST /v1/music/sets/{set_id}/items/{position}/preview
+// Plays the item at position (0-indexed) for a few seconds, then puts back what the
+// speaker was playing. Rotation state and play history are left alone.
+func previewItem(service *Service) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		setID := chi.URLParam(r, "set_id")
+		positionStr := chi.URLParam(r, "position")
+
+		position, err := strconv.Atoi(positionStr)
+		if err != nil || position < 0 {
+			return apperrors.NewValidationError("position must be a non-negative integer", map[string]any{
+				"provided": positionStr,
+			})
+		}
+
+		var input PreviewItemInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			return apperrors.NewValidationError("invalid request body", nil)
+		}
+		speakerID := input.UDN
+		if speakerID == "" {
+			speakerID = input.SpeakerID
+		}
+		if speakerID == "" {
+			return apperrors.NewValidationError("udn is required", nil)
+		}
+		seconds := DefaultPreviewSeconds
+		if input.DurationSeconds != nil {
+			seconds = *input.DurationSeconds
+		}
+		if seconds < 1 || seconds > MaxPreviewSeconds {
+			return apperrors.NewValidationError("duration_seconds must be between 1 and "+strconv.Itoa(MaxPreviewSeconds), map[string]any{
+				"duration_seconds": seconds,
+			})
+		}
+
+		result, err := service.PreviewItem(r.Context(), setID, position, speakerID, time.Duration(seconds)*time.Second)
+		if err != nil {
+			if isPositionNotFoundError(err) {
+				return apperrors.NewAppError("ITEM_NOT_FOUND", "Item not found at position", 404, map[string]any{
+					"set_id":   setID,
+					"position": position,
+				}, nil)
 			}
+			return playSetError(r.Context(), setID, speakerID, err)
 		}
+		stripPlaybackDebug(r, result.Playback)
 
-		// Note: Scene engine integration not yet implemented
-		// Node.js would:
-		// 3. Create ephemeral scene with name `Play: ${set.name}`
-		// 4. Execute scene with musicContent and favoriteId
-		// 5. Schedule cleanup of ephemeral scene after 5 minutes
-		// For now, return stub response with Stripe-style format
+		snapshot := result.Previous.Snapshot
+		previous := map[string]any{
+			"transport_state": snapshot.TransportState,
+			"transport_uri":   snapshot.TransportURI,
+			"title":           stringOrNil(result.Previous.Title),
+			"artist":          stringOrNil(result.Previous.Artist),
+			"volume":          snapshot.Volume,
+			"will_resume":     result.Previous.WillResume,
+			"skip_reason":     stringOrNil(snapshot.SkipReason),
+		}
 
 		return api.WriteAction(w, http.StatusOK, map[string]any{
-			"object":             "play_set",
-			"scene_id":           nil, // Would be created scene ID
-			"scene_execution_id": nil, // Would be execution ID
-			"music_content":      musicContent,
-			"status":             "playing",
+			"object":           "set_item_preview",
+			"set_id":           setID,
+			"position":         position,
+			"udn":              speakerID,
+			"item":             result.Item,
+			"music_content":    result.Content,
+			"playback":         result.Playback,
+			"duration_seconds": seconds,
+			"restores_at":      api.RFC3339Millis(result.RestoresAt),
+			"previous":         previous,
 		})
 	}
 }
 
+// stripPlaybackDebug drops full playback metadata from a play result unless the request
+// asked for ?debug=true.
+func stripPlaybackDebug(r *http.Request, playback any) {
+	if r.URL.Query().Get("debug") == "true" {
+		return
+	}
+	switch p := playback.(type) {
+	case *sonos.PlayFavoriteResponse:
+		p.StripDebug()
+	case *sonos.PlayContentResponse:
+		p.StripDebug()
+	}
+}
+
+// stringOrNil returns value, or nil when it is empty, for nullable response fields.
+func stringOrNil(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// playSetError maps a PlaySet failure to its API error. Unexpected failures are logged
+// and reported without their cause.
+func playSetError(ctx context.Context, setID, speakerID string, err error) error {
+	var (
+		speakerNotFound     *SpeakerNotFoundError
+		setNotFound         *SetNotFoundError
+		emptySet            *EmptySetError
+		favoriteNotFound    *sonos.FavoriteNotFoundError
+		serviceNotSupported *sonos.ServiceNotSupportedError
+		needsBootstrap      *sonos.ServiceNeedsBootstrapError
+	)
+	switch {
+	case errors.Is(err, ErrPlaybackUnavailable):
+		return apperrors.NewAppError("SERVICE_UNAVAILABLE", "Playback not available", 503, nil, nil)
+	case errors.As(err, &speakerNotFound):
+		return apperrors.NewAppError(apperrors.ErrorCodeDeviceNotFound, "Speaker not found", 404, map[string]any{"speaker_id": speakerID}, nil)
+	case errors.As(err, &setNotFound):
+		return apperrors.NewAppError(apperrors.ErrorCodeSetNotFound, "Set not found", 404, map[string]any{"set_id": setID}, nil)
+	case errors.As(err, &emptySet):
+		return apperrors.NewAppError("SELECTION_FAILED", "Could not select music from set", 400, nil, nil)
+	case errors.As(err, &favoriteNotFound), errors.As(err, &serviceNotSupported), errors.As(err, &needsBootstrap):
+		return apperrors.NewValidationError(err.Error(), map[string]any{"set_id": setID})
+	}
+	logging.From(ctx, nil).Error("Failed to play set", "set_id", setID, "speaker_id", speakerID, "error", err)
+	return apperrors.NewInternalError("Failed to play set")
+}
+
 // ==========================================================================
 // Search Handler
 // ==========================================================================
 
+// browseLibrary handles GET /v1/music/library/browse, listing one page of a music library
+// container. Container items' IDs are the next container_id to drill down with.
+func browseLibrary(libraryProvider *LibraryProvider) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		containerID := r.URL.Query().Get("container_id")
+		if containerID == "" {
+			containerID = DefaultLibraryContainerID
+		}
+		if !IsLibraryContainerID(containerID) {
+			return apperrors.NewValidationError("container_id must be a music library ID starting with A: or S:", map[string]any{"container_id": containerID})
+		}
+
+		start := 0
+		if s := r.URL.Query().Get("start"); s != "" {
+			parsed, err := strconv.Atoi(s)
+			if err != nil || parsed < 0 {
+				return apperrors.NewValidationError("start must be a non-negative integer", map[string]any{"start": s})
+			}
+			start = parsed
+		}
+		count := DefaultLibraryBrowseCount
+		if c := r.URL.Query().Get("count"); c != "" {
+			parsed, err := strconv.Atoi(c)
+			if err != nil || parsed < 1 || parsed > MaxLibraryBrowseCount {
+				return apperrors.NewValidationError("count must be between 1 and "+strconv.Itoa(MaxLibraryBrowseCount), map[string]any{"count": c})
+			}
+			count = parsed
+		}
+
+		if libraryProvider == nil {
+			return apperrors.NewAppError("SERVICE_UNAVAILABLE", "Music library not available", 503, nil, nil)
+		}
+		result, err := libraryProvider.Browse(r.Context(), containerID, start, count)
+		if err == ErrLibraryUnavailable {
+			return apperrors.NewAppError("SERVICE_UNAVAILABLE", "Music library not available: no Sonos devices found", 503, nil, nil)
+		}
+		if err != nil {
+			return apperrors.NewInternalError("Library browse failed")
+		}
+
+		items := make([]map[string]any, 0, len(result.Items))
+		for _, item := range result.Items {
+			items = append(items, map[string]any{
+				"id":           item.ID,
+				"type":         item.Type,
+				"content_type": item.ContentType,
+				"name":         item.Name,
+				"artist_name":  item.ArtistName,
+				"album_name":   item.AlbumName,
+				"artwork_url":  item.ArtworkURL,
+				"playback_uri": item.PlaybackURI,
+				"duration_ms":  item.DurationMs,
+			})
+		}
+
+		var nextStart any
+		if result.HasMore() {
+			nextStart = result.NextStart()
+		}
+		var cachedAt any
+		if result.CachedAt != nil {
+			cachedAt = api.RFC3339Millis(*result.CachedAt)
+		}
+		return api.WriteResource(w, http.StatusOK, map[string]any{
+			"object":       "music_library_browse",
+			"container_id": result.ContainerID,
+			"items":        items,
+			"pagination": map[string]any{
+				"start":      result.Start,
+				"count":      count,
+				"returned":   len(result.Items),
+				"total":      result.Total,
+				"has_more":   result.HasMore(),
+				"next_start": nextStart,
+			},
+			"cached_at": cachedAt,
+		})
+	}
+}
+
+// searchProviders are the providers GET /v1/music/search accepts, and what provider=all
+// searches.
+var searchProviders = []string{"apple_music", "library", "spotify"}
+
+// multiSearchProviderTimeout bounds each provider in a multi-provider search, so one slow
+// provider doesn't hold up the others. It leaves room for the Spotify extension's
+// reconnect grace.
+const multiSearchProviderTimeout = 10 * time.Second
+
+// musicSearchParams are the parsed GET /v1/music/search query parameters.
+type musicSearchParams struct {
+	query      string
+	typesParam string // Comma-separated content types, as given
+	limit      int
+	offset     int
+	storefront string // Apple Music only
+	nocache    bool   // Search again rather than use cached results
+}
+
+// types splits typesParam, or returns nil when it wasn't given.
+func (p musicSearchParams) types() []string {
+	if p.typesParam == "" {
+		return nil
+	}
+	var types []string
+	for _, t := range strings.Split(p.typesParam, ",") {
+		types = append(types, strings.TrimSpace(t))
+	}
+	return types
+}
+
 // searchMusic handles GET /v1/music/search
-// Mirrors Node.js music-search.ts format
-func searchMusic(spotifyManager *spotifysearch.ConnectionManager, appleClient *applemusic.Client, libraryProvider *LibraryProvider) func(w http.ResponseWriter, r *http.Request) error {
+// Mirrors Node.js music-search.ts format. provider=all, or a comma-separated list, searches
+// several providers at once and groups the results by provider. Apple Music and library
+// results are cached briefly; nocache=true searches again.
+func searchMusic(spotifyManager *spotifysearch.ConnectionManager, appleClient *applemusic.Client, libraryProvider *LibraryProvider, cache *searchCache) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		query := r.URL.Query().Get("query")
 		if query == "" {
@@ -1015,17 +1594,26 @@ func searchMusic(spotifyManager *spotifysearch.ConnectionManager, appleClient *a
 				offset = parsed
 			}
 		}
+		params := musicSearchParams{query: query, typesParam: typesParam, limit: limit, offset: offset}
+		if nocache := r.URL.Query().Get("nocache"); nocache == "true" || nocache == "1" {
+			params.nocache = true
+		}
 
 		// Validate provider
 		if provider == "" {
 			return apperrors.NewValidationError("provider is required", map[string]any{
-				"supported_providers": []string{"apple_music", "library", "spotify"},
+				"supported_providers": searchProviders,
 			})
 		}
 
+		if provider == "all" || strings.Contains(provider, ",") {
+			return searchMusicProviders(w, r, provider, params, spotifyManager, appleClient, libraryProvider, cache)
+		}
+
 		// Handle Spotify search via WebSocket extension
 		if provider == "spotify" {
-			if spotifyManager == nil || !spotifyManager.IsConnected() {
+			// A disconnected extension gets a short grace period to reconnect; Search waits for it
+			if spotifyManager == nil {
 				return apperrors.NewAppError("SERVICE_UNAVAILABLE", "Spotify search extension not connected", 503, nil, nil)
 			}
 
@@ -1033,135 +1621,9 @@ func searchMusic(spotifyManager *spotifysearch.ConnectionManager, appleClient *a
 				return apperrors.NewValidationError("query is required for spotify search", nil)
 			}
 
-			// Parse content types from request
-			contentTypes := spotifysearch.AllContentTypes()
-			if typesParam != "" {
-				contentTypes = nil
-				for _, t := range strings.Split(typesParam, ",") {
-					t = strings.TrimSpace(t)
-					contentTypes = append(contentTypes, spotifysearch.SpotifyContentType(t))
-				}
-			}
-
-			// Perform search via extension
-			results, err := spotifyManager.Search(r.Context(), query, contentTypes)
+			resultsMap, err := searchSpotify(r.Context(), spotifyManager, params)
 			if err != nil {
-				if err == spotifysearch.ErrExtensionNotConnected {
-					return apperrors.NewAppError("SERVICE_UNAVAILABLE", "Spotify search extension not connected", 503, nil, nil)
-				}
-				if err == spotifysearch.ErrSearchTimeout {
-					return apperrors.NewAppError("SEARCH_TIMEOUT", "Spotify search timed out", 504, nil, nil)
-				}
-				return apperrors.NewInternalError("Spotify search failed")
-			}
-
-			// Convert to API response format (snake_case)
-			resultsMap := make(map[string]any)
-			if results.Tracks != nil && len(results.Tracks) > 0 {
-				tracks := make([]map[string]any, len(results.Tracks))
-				for i, t := range results.Tracks {
-					tracks[i] = map[string]any{
-						"id":           t.ID,
-						"name":         t.Name,
-						"playback_uri": t.URI,
-						"artwork_url":  t.ImageURL,
-						"artist_name":  t.ArtistName,
-						"album_name":   t.AlbumName,
-						"duration_ms":  t.DurationMs,
-						"content_type": "tracks",
-						"provider":     "spotify",
-					}
-				}
-				resultsMap["tracks"] = tracks
-			}
-			if results.Albums != nil && len(results.Albums) > 0 {
-				albums := make([]map[string]any, len(results.Albums))
-				for i, a := range results.Albums {
-					albums[i] = map[string]any{
-						"id":           a.ID,
-						"name":         a.Name,
-						"playback_uri": a.URI,
-						"artwork_url":  a.ImageURL,
-						"artist_name":  a.ArtistName,
-						"content_type": "albums",
-						"provider":     "spotify",
-					}
-				}
-				resultsMap["albums"] = albums
-			}
-			if results.Artists != nil && len(results.Artists) > 0 {
-				artists := make([]map[string]any, len(results.Artists))
-				for i, a := range results.Artists {
-					artists[i] = map[string]any{
-						"id":           a.ID,
-						"name":         a.Name,
-						"playback_uri": a.URI,
-						"artwork_url":  a.ImageURL,
-						"content_type": "artists",
-						"provider":     "spotify",
-					}
-				}
-				resultsMap["artists"] = artists
-			}
-			if results.Playlists != nil && len(results.Playlists) > 0 {
-				playlists := make([]map[string]any, len(results.Playlists))
-				for i, p := range results.Playlists {
-					playlists[i] = map[string]any{
-						"id":           p.ID,
-						"name":         p.Name,
-						"playback_uri": p.URI,
-						"artwork_url":  p.ImageURL,
-						"owner_name":   p.OwnerName,
-						"description":  p.Description,
-						"content_type": "playlists",
-						"provider":     "spotify",
-					}
-				}
-				resultsMap["playlists"] = playlists
-			}
-			if results.Genres != nil && len(results.Genres) > 0 {
-				genres := make([]map[string]any, len(results.Genres))
-				for i, g := range results.Genres {
-					genres[i] = map[string]any{
-						"id":           g.ID,
-						"name":         g.Name,
-						"playback_uri": g.URI,
-						"artwork_url":  g.ImageURL,
-						"content_type": "genres",
-						"provider":     "spotify",
-					}
-				}
-				resultsMap["genres"] = genres
-			}
-			if results.Audiobooks != nil && len(results.Audiobooks) > 0 {
-				audiobooks := make([]map[string]any, len(results.Audiobooks))
-				for i, a := range results.Audiobooks {
-					audiobooks[i] = map[string]any{
-						"id":           a.ID,
-						"name":         a.Name,
-						"playback_uri": a.URI,
-						"artwork_url":  a.ImageURL,
-						"author_name":  a.AuthorName,
-						"content_type": "audiobooks",
-						"provider":     "spotify",
-					}
-				}
-				resultsMap["audiobooks"] = audiobooks
-			}
-			if results.Podcasts != nil && len(results.Podcasts) > 0 {
-				podcasts := make([]map[string]any, len(results.Podcasts))
-				for i, p := range results.Podcasts {
-					podcasts[i] = map[string]any{
-						"id":             p.ID,
-						"name":           p.Name,
-						"playback_uri":   p.URI,
-						"artwork_url":    p.ImageURL,
-						"publisher_name": p.PublisherName,
-						"content_type":   "podcasts",
-						"provider":       "spotify",
-					}
-				}
-				resultsMap["podcasts"] = podcasts
+				return err
 			}
 
 			return api.WriteResource(w, http.StatusOK, map[string]any{
@@ -1179,53 +1641,9 @@ func searchMusic(spotifyManager *spotifysearch.ConnectionManager, appleClient *a
 
 		// Handle Library search via UPnP ContentDirectory
 		if provider == "library" {
-			if libraryProvider == nil {
-				// No library provider available - return empty results
-				return api.WriteResource(w, http.StatusOK, map[string]any{
-					"object":   "music_search",
-					"provider": provider,
-					"query":    query,
-					"results":  map[string][]any{},
-					"pagination": map[string]any{
-						"limit":  limit,
-						"offset": offset,
-						"total":  0,
-					},
-				})
-			}
-
-			// Parse content types from request
-			var types []string
-			if typesParam != "" {
-				for _, t := range strings.Split(typesParam, ",") {
-					types = append(types, strings.TrimSpace(t))
-				}
-			}
-
-			// Perform library search
-			result, err := libraryProvider.Search(r.Context(), query, types, limit, offset)
+			resultsMap, pagination, err := searchLibrary(r.Context(), libraryProvider, cache, params)
 			if err != nil {
-				return apperrors.NewInternalError("Library search failed")
-			}
-
-			// Convert LibraryItem results to API format
-			resultsMap := make(map[string]any)
-			for contentType, items := range result.Results {
-				apiItems := make([]map[string]any, len(items))
-				for i, item := range items {
-					apiItems[i] = map[string]any{
-						"id":           item.ID,
-						"name":         item.Name,
-						"content_type": item.ContentType,
-						"provider":     item.Provider,
-						"artist_name":  item.ArtistName,
-						"album_name":   item.AlbumName,
-						"artwork_url":  item.ArtworkURL,
-						"playback_uri": item.PlaybackURI,
-						"duration_ms":  item.DurationMs,
-					}
-				}
-				resultsMap[contentType] = apiItems
+				return err
 			}
 
 			return api.WriteResource(w, http.StatusOK, map[string]any{
@@ -1234,9 +1652,9 @@ func searchMusic(spotifyManager *spotifysearch.ConnectionManager, appleClient *a
 				"query":    query,
 				"results":  resultsMap,
 				"pagination": map[string]any{
-					"limit":  result.Pagination.Limit,
-					"offset": result.Pagination.Offset,
-					"total":  result.Pagination.Total,
+					"limit":  pagination.Limit,
+					"offset": pagination.Offset,
+					"total":  pagination.Total,
 				},
 			})
 		}
@@ -1247,78 +1665,442 @@ func searchMusic(spotifyManager *spotifysearch.ConnectionManager, appleClient *a
 				return apperrors.NewAppError("SERVICE_UNAVAILABLE", "Apple Music not configured", 503, nil, nil)
 			}
 
-			// Apple Music API has a max limit of 25
-			appleLimit := limit
-			if appleLimit > 25 {
-				appleLimit = 25
+			storefront, err := parseStorefrontParam(r, appleClient)
+			if err != nil {
+				return err
 			}
+			params.storefront = storefront
 
-			// Perform Apple Music search
-			result, err := appleClient.Search(r.Context(), query, typesParam, appleLimit, offset)
+			resultsMap, err := searchAppleMusic(r.Context(), appleClient, cache, params)
 			if err != nil {
-				return apperrors.NewInternalError("Apple Music search failed: " + err.Error())
+				return err
 			}
 
 			// Parse types parameter into array for response
-			var types []string
-			if typesParam != "" {
-				for _, t := range strings.Split(typesParam, ",") {
-					types = append(types, strings.TrimSpace(t))
-				}
-			} else {
+			types := params.types()
+			if types == nil {
 				types = []string{"songs", "albums", "artists", "playlists"}
 			}
 
-			// Convert results to API format (snake_case)
-			// iOS expects "type" field on each item (not "content_type")
-			resultsMap := make(map[string]any)
-			for contentType, items := range result.Results {
-				apiItems := make([]map[string]any, len(items))
-				for i, item := range items {
-					apiItem := map[string]any{
-						"id":   item.ID,
-						"name": item.Name,
-						"type": item.ContentType, // iOS expects "type" not "content_type"
-					}
-					if item.ArtistName != nil {
-						apiItem["artist_name"] = *item.ArtistName
-					}
-					if item.AlbumName != nil {
-						apiItem["album_name"] = *item.AlbumName
-					}
-					if item.ArtworkURL != nil {
-						apiItem["artwork_url"] = *item.ArtworkURL
-					}
-					if item.PlaybackURI != nil {
-						apiItem["playback_uri"] = *item.PlaybackURI
-					}
-					if item.DurationMs != nil {
-						apiItem["duration_ms"] = *item.DurationMs
-					}
-					if item.CuratorName != nil {
-						apiItem["curator_name"] = *item.CuratorName
-					}
-					apiItems[i] = apiItem
-				}
-				resultsMap[contentType] = apiItems
-			}
-
 			// iOS expects: query, types (array), results, totals (optional)
 			return api.WriteResource(w, http.StatusOK, map[string]any{
-				"query":   query,
-				"types":   types,
-				"results": resultsMap,
+				"query":      query,
+				"types":      types,
+				"storefront": storefront,
+				"results":    resultsMap,
 			})
 		}
 
 		// Unknown provider
 		return apperrors.NewValidationError("unsupported provider", map[string]any{
 			"provider":            provider,
-			"supported_providers": []string{"apple_music", "library", "spotify"},
+			"supported_providers": searchProviders,
 		})
 	}
 }
 
+// searchMusicProviders searches several providers concurrently, each under
+// multiSearchProviderTimeout. A provider that fails, times out or isn't set up reports a
+// status and error in its own block rather than failing the search.
+func searchMusicProviders(w http.ResponseWriter, r *http.Request, provider string, params musicSearchParams, spotifyManager *spotifysearch.ConnectionManager, appleClient *applemusic.Client, libraryProvider *LibraryProvider, cache *searchCache) error {
+	providers, err := parseSearchProviders(provider)
+	if err != nil {
+		return err
+	}
+	if params.query == "" {
+		return apperrors.NewValidationError("query is required when searching several providers", nil)
+	}
+
+	// A bad storefront is the caller's mistake, so it fails the request like it does for
+	// a single provider
+	if appleClient != nil && slices.Contains(providers, "apple_music") {
+		storefront, err := parseStorefrontParam(r, appleClient)
+		if err != nil {
+			return err
+		}
+		params.storefront = storefront
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	blocks := make(map[string]any, len(providers))
+	for _, name := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.Context(), multiSearchProviderTimeout)
+			defer cancel()
+			block := searchProvider(ctx, name, params, spotifyManager, appleClient, libraryProvider, cache)
+			mu.Lock()
+			blocks[name] = block
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return api.WriteResource(w, http.StatusOK, map[string]any{
+		"object":    "music_search",
+		"provider":  provider,
+		"providers": providers,
+		"query":     params.query,
+		"results":   blocks,
+		"pagination": map[string]any{
+			"limit":  params.limit,
+			"offset": params.offset,
+		},
+	})
+}
+
+// parseSearchProviders expands provider=all, or a comma-separated list of providers in the
+// order given.
+func parseSearchProviders(provider string) ([]string, error) {
+	if provider == "all" {
+		return slices.Clone(searchProviders), nil
+	}
+
+	var providers []string
+	for _, name := range strings.Split(provider, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || slices.Contains(providers, name) {
+			continue
+		}
+		if !slices.Contains(searchProviders, name) {
+			return nil, apperrors.NewValidationError("unsupported provider", map[string]any{
+				"provider":            name,
+				"supported_providers": searchProviders,
+			})
+		}
+		providers = append(providers, name)
+	}
+	if len(providers) == 0 {
+		return nil, apperrors.NewValidationError("provider is required", map[string]any{
+			"supported_providers": searchProviders,
+		})
+	}
+	return providers, nil
+}
+
+// searchProvider runs one provider's part of a multi-provider search. Its block has a
+// status of ok, unavailable (not set up or not connected), timeout or error; anything but
+// ok comes with an error and empty results.
+func searchProvider(ctx context.Context, provider string, params musicSearchParams, spotifyManager *spotifysearch.ConnectionManager, appleClient *applemusic.Client, libraryProvider *LibraryProvider, cache *searchCache) map[string]any {
+	block := map[string]any{"status": "ok"}
+
+	var resultsMap map[string]any
+	var err error
+	switch provider {
+	case "spotify":
+		if spotifyManager == nil {
+			err = apperrors.NewAppError("SERVICE_UNAVAILABLE", "Spotify search extension not connected", 503, nil, nil)
+			break
+		}
+		resultsMap, err = searchSpotify(ctx, spotifyManager, params)
+	case "library":
+		var pagination LibrarySearchPagination
+		resultsMap, pagination, err = searchLibrary(ctx, libraryProvider, cache, params)
+		if err == nil {
+			block["pagination"] = map[string]any{
+				"limit":  pagination.Limit,
+				"offset": pagination.Offset,
+				"total":  pagination.Total,
+			}
+		}
+	case "apple_music":
+		if appleClient == nil {
+			err = apperrors.NewAppError("SERVICE_UNAVAILABLE", "Apple Music not configured", 503, nil, nil)
+			break
+		}
+		block["storefront"] = params.storefront
+		resultsMap, err = searchAppleMusic(ctx, appleClient, cache, params)
+	}
+
+	if err != nil {
+		appErr := apperrors.EnsureAppError(err)
+		status := "error"
+		switch {
+		case ctx.Err() == context.DeadlineExceeded || appErr.StatusCode == http.StatusGatewayTimeout:
+			status = "timeout"
+			appErr = apperrors.NewAppError("SEARCH_TIMEOUT", "Search timed out", 504, nil, nil)
+		case appErr.StatusCode == http.StatusServiceUnavailable:
+			status = "unavailable"
+		}
+		block["status"] = status
+		block["error"] = map[string]any{
+			"code":    appErr.Code,
+			"message": appErr.Message,
+		}
+		block["results"] = map[string]any{}
+		return block
+	}
+
+	block["results"] = resultsMap
+	return block
+}
+
+// searchSpotify searches through the browser extension. Items carry both content_type
+// and type.
+func searchSpotify(ctx context.Context, spotifyManager *spotifysearch.ConnectionManager, params musicSearchParams) (map[string]any, error) {
+	// Parse content types from request
+	contentTypes := spotifysearch.AllContentTypes()
+	if types := params.types(); types != nil {
+		contentTypes = nil
+		for _, t := range types {
+			contentTypes = append(contentTypes, spotifysearch.SpotifyContentType(t))
+		}
+	}
+
+	// Perform search via extension
+	results, err := spotifyManager.Search(ctx, params.query, contentTypes)
+	if err != nil {
+		if err == spotifysearch.ErrExtensionNotConnected || err == spotifysearch.ErrExtensionDisconnected {
+			return nil, apperrors.NewAppError("SERVICE_UNAVAILABLE", "Spotify search extension not connected", 503, nil, nil)
+		}
+		if err == spotifysearch.ErrSearchTimeout {
+			return nil, apperrors.NewAppError("SEARCH_TIMEOUT", "Spotify search timed out", 504, nil, nil)
+		}
+		return nil, apperrors.NewInternalError("Spotify search failed")
+	}
+
+	// Convert to API response format (snake_case)
+	resultsMap := make(map[string]any)
+	if results.Tracks != nil && len(results.Tracks) > 0 {
+		tracks := make([]map[string]any, len(results.Tracks))
+		for i, t := range results.Tracks {
+			tracks[i] = map[string]any{
+				"id":           t.ID,
+				"name":         t.Name,
+				"playback_uri": t.URI,
+				"artwork_url":  t.ImageURL,
+				"artist_name":  t.ArtistName,
+				"album_name":   t.AlbumName,
+				"duration_ms":  t.DurationMs,
+				"content_type": "tracks",
+				"type":         "tracks",
+				"provider":     "spotify",
+			}
+		}
+		resultsMap["tracks"] = tracks
+	}
+	if results.Albums != nil && len(results.Albums) > 0 {
+		albums := make([]map[string]any, len(results.Albums))
+		for i, a := range results.Albums {
+			albums[i] = map[string]any{
+				"id":           a.ID,
+				"name":         a.Name,
+				"playback_uri": a.URI,
+				"artwork_url":  a.ImageURL,
+				"artist_name":  a.ArtistName,
+				"content_type": "albums",
+				"type":         "albums",
+				"provider":     "spotify",
+			}
+		}
+		resultsMap["albums"] = albums
+	}
+	if results.Artists != nil && len(results.Artists) > 0 {
+		artists := make([]map[string]any, len(results.Artists))
+		for i, a := range results.Artists {
+			artists[i] = map[string]any{
+				"id":           a.ID,
+				"name":         a.Name,
+				"playback_uri": a.URI,
+				"artwork_url":  a.ImageURL,
+				"content_type": "artists",
+				"type":         "artists",
+				"provider":     "spotify",
+			}
+		}
+		resultsMap["artists"] = artists
+	}
+	if results.Playlists != nil && len(results.Playlists) > 0 {
+		playlists := make([]map[string]any, len(results.Playlists))
+		for i, p := range results.Playlists {
+			playlists[i] = map[string]any{
+				"id":           p.ID,
+				"name":         p.Name,
+				"playback_uri": p.URI,
+				"artwork_url":  p.ImageURL,
+				"owner_name":   p.OwnerName,
+				"description":  p.Description,
+				"content_type": "playlists",
+				"type":         "playlists",
+				"provider":     "spotify",
+			}
+		}
+		resultsMap["playlists"] = playlists
+	}
+	if results.Genres != nil && len(results.Genres) > 0 {
+		genres := make([]map[string]any, len(results.Genres))
+		for i, g := range results.Genres {
+			genres[i] = map[string]any{
+				"id":           g.ID,
+				"name":         g.Name,
+				"playback_uri": g.URI,
+				"artwork_url":  g.ImageURL,
+				"content_type": "genres",
+				"type":         "genres",
+				"provider":     "spotify",
+			}
+		}
+		resultsMap["genres"] = genres
+	}
+	if results.Audiobooks != nil && len(results.Audiobooks) > 0 {
+		audiobooks := make([]map[string]any, len(results.Audiobooks))
+		for i, a := range results.Audiobooks {
+			audiobooks[i] = map[string]any{
+				"id":           a.ID,
+				"name":         a.Name,
+				"playback_uri": a.URI,
+				"artwork_url":  a.ImageURL,
+				"author_name":  a.AuthorName,
+				"content_type": "audiobooks",
+				"type":         "audiobooks",
+				"provider":     "spotify",
+			}
+		}
+		resultsMap["audiobooks"] = audiobooks
+	}
+	if results.Podcasts != nil && len(results.Podcasts) > 0 {
+		podcasts := make([]map[string]any, len(results.Podcasts))
+		for i, p := range results.Podcasts {
+			podcasts[i] = map[string]any{
+				"id":             p.ID,
+				"name":           p.Name,
+				"playback_uri":   p.URI,
+				"artwork_url":    p.ImageURL,
+				"publisher_name": p.PublisherName,
+				"content_type":   "podcasts",
+				"type":           "podcasts",
+				"provider":       "spotify",
+			}
+		}
+		resultsMap["podcasts"] = podcasts
+	}
+	return resultsMap, nil
+}
+
+// searchLibrary searches the music library through a speaker's ContentDirectory. Without
+// a library provider there's nothing to search, so the results are empty. Items carry
+// both content_type and type.
+func searchLibrary(ctx context.Context, libraryProvider *LibraryProvider, cache *searchCache, params musicSearchParams) (map[string]any, LibrarySearchPagination, error) {
+	if libraryProvider == nil {
+		// No library provider available - return empty results
+		return map[string]any{}, LibrarySearchPagination{Limit: params.limit, Offset: params.offset}, nil
+	}
+
+	cacheKey := searchCacheKey("library", params)
+	if !params.nocache {
+		if entry, ok := cache.get("library", cacheKey); ok {
+			return entry.results, entry.pagination, nil
+		}
+	}
+
+	// Perform library search
+	result, err := libraryProvider.Search(ctx, params.query, params.types(), params.limit, params.offset)
+	if err != nil {
+		return nil, LibrarySearchPagination{}, apperrors.NewInternalError("Library search failed")
+	}
+
+	// Convert LibraryItem results to API format
+	resultsMap := make(map[string]any)
+	for contentType, items := range result.Results {
+		apiItems := make([]map[string]any, len(items))
+		for i, item := range items {
+			apiItems[i] = map[string]any{
+				"id":           item.ID,
+				"name":         item.Name,
+				"content_type": item.ContentType,
+				"type":         item.ContentType,
+				"provider":     item.Provider,
+				"artist_name":  item.ArtistName,
+				"album_name":   item.AlbumName,
+				"artwork_url":  item.ArtworkURL,
+				"playback_uri": item.PlaybackURI,
+				"duration_ms":  item.DurationMs,
+			}
+		}
+		resultsMap[contentType] = apiItems
+	}
+	cache.set(cacheKey, resultsMap, result.Pagination)
+	return resultsMap, result.Pagination, nil
+}
+
+// searchAppleMusic searches the Apple Music catalog in params.storefront. Items carry both
+// type, which iOS reads, and content_type.
+func searchAppleMusic(ctx context.Context, appleClient *applemusic.Client, cache *searchCache, params musicSearchParams) (map[string]any, error) {
+	cacheKey := searchCacheKey("apple_music", params)
+	if !params.nocache {
+		if entry, ok := cache.get("apple_music", cacheKey); ok {
+			return entry.results, nil
+		}
+	}
+
+	// Apple Music API has a max limit of 25
+	appleLimit := params.limit
+	if appleLimit > 25 {
+		appleLimit = 25
+	}
+
+	// Perform Apple Music search
+	result, err := appleClient.Search(ctx, params.query, params.typesParam, appleLimit, params.offset, params.storefront)
+	if err != nil {
+		return nil, apperrors.NewInternalError("Apple Music search failed: " + err.Error())
+	}
+
+	// Convert results to API format (snake_case)
+	resultsMap := make(map[string]any)
+	for contentType, items := range result.Results {
+		apiItems := make([]map[string]any, len(items))
+		for i, item := range items {
+			apiItem := map[string]any{
+				"id":           item.ID,
+				"name":         item.Name,
+				"type":         item.ContentType, // iOS expects "type"
+				"content_type": item.ContentType, // As the other providers name it
+				"provider":     "apple_music",
+			}
+			if item.ArtistName != nil {
+				apiItem["artist_name"] = *item.ArtistName
+			}
+			if item.AlbumName != nil {
+				apiItem["album_name"] = *item.AlbumName
+			}
+			if item.ArtworkURL != nil {
+				apiItem["artwork_url"] = *item.ArtworkURL
+			}
+			if item.PlaybackURI != nil {
+				apiItem["playback_uri"] = *item.PlaybackURI
+			}
+			if item.DurationMs != nil {
+				apiItem["duration_ms"] = *item.DurationMs
+			}
+			if item.CuratorName != nil {
+				apiItem["curator_name"] = *item.CuratorName
+			}
+			apiItems[i] = apiItem
+		}
+		resultsMap[contentType] = apiItems
+	}
+	cache.set(cacheKey, resultsMap, LibrarySearchPagination{})
+	return resultsMap, nil
+}
+
+// parseStorefrontParam reads the optional storefront query param, falling back to the
+// client's configured storefront. Unknown codes are rejected with a validation error.
+func parseStorefrontParam(r *http.Request, appleClient *applemusic.Client) (string, error) {
+	raw := r.URL.Query().Get("storefront")
+	if raw == "" {
+		return appleClient.Storefront(), nil
+	}
+	storefront, ok := applemusic.NormalizeStorefront(raw)
+	if !ok {
+		return "", apperrors.NewValidationError("storefront must be a known two-letter country code", map[string]any{
+			"storefront": raw,
+		})
+	}
+	return storefront, nil
+}
+
 // ==========================================================================
 // Suggestions Handler
 // ==========================================================================
@@ -1358,8 +2140,13 @@ func getMusicSuggestions(appleClient *applemusic.Client) func(w http.ResponseWri
 			return apperrors.NewAppError("SERVICE_UNAVAILABLE", "Apple Music not configured", 503, nil, nil)
 		}
 
+		storefront, err := parseStorefrontParam(r, appleClient)
+		if err != nil {
+			return err
+		}
+
 		// Get suggestions from Apple Music API
-		result, err := appleClient.GetSuggestions(r.Context(), query, typesParam, limit)
+		result, err := appleClient.GetSuggestions(r.Context(), query, typesParam, limit, storefront)
 		if err != nil {
 			return apperrors.NewInternalError("Apple Music suggestions failed: " + err.Error())
 		}
@@ -1394,6 +2181,7 @@ func getMusicSuggestions(appleClient *applemusic.Client) func(w http.ResponseWri
 			"object":      "music_suggestions",
 			"provider":    provider,
 			"query":       query,
+			"storefront":  storefront,
 			"terms":       terms,
 			"top_results": topResults,
 		})
@@ -1412,9 +2200,17 @@ type MusicProvider struct {
 	RequiresAuth bool   `json:"requires_auth"`
 }
 
+// formatSearchCacheStats formats a provider's search cache counters.
+func formatSearchCacheStats(stats SearchCacheStats) map[string]any {
+	return map[string]any{
+		"hits":   stats.Hits,
+		"misses": stats.Misses,
+	}
+}
+
 // listProviders handles GET /v1/music/providers
 // Mirrors Node.js music-search.ts providers format
-func listProviders(service *Service, spotifyManager *spotifysearch.ConnectionManager, appleClient *applemusic.Client) func(w http.ResponseWriter, r *http.Request) error {
+func listProviders(service *Service, spotifyManager *spotifysearch.ConnectionManager, appleClient *applemusic.Client, cache *searchCache) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		// Return available search providers
 		// Matches Node.js music-search.ts /v1/music/providers response, with Stripe-style envelope
@@ -1432,6 +2228,7 @@ func listProviders(service *Service, spotifyManager *spotifysearch.ConnectionMan
 				"supported_types":      []string{"albums", "artists", "tracks", "playlists", "stations"},
 				"supports_suggestions": true,
 				"status":               appleStatus,
+				"search_cache":         formatSearchCacheStats(cache.Stats("apple_music")),
 			},
 			{
 				"object":               "music_provider",
@@ -1439,13 +2236,21 @@ func listProviders(service *Service, spotifyManager *spotifysearch.ConnectionMan
 				"display_name":         "Music Library",
 				"supported_types":      []string{"albums", "artists", "tracks", "playlists"},
 				"supports_suggestions": false,
+				"search_cache":         formatSearchCacheStats(cache.Stats("library")),
 			},
 		}
 
 		// Add Spotify provider with connection status
 		spotifyStatus := "disconnected"
-		if spotifyManager != nil && spotifyManager.IsConnected() {
-			spotifyStatus = "connected"
+		var lastConnectedAt any
+		reconnectCount := 0
+		if spotifyManager != nil {
+			status := spotifyManager.GetStatus()
+			spotifyStatus = status.Extension
+			if status.LastConnectedAt != nil {
+				lastConnectedAt = api.RFC3339Millis(*status.LastConnectedAt)
+			}
+			reconnectCount = status.ReconnectCount
 		}
 		providers = append(providers, map[string]any{
 			"object":               "music_provider",
@@ -1454,6 +2259,8 @@ func listProviders(service *Service, spotifyManager *spotifysearch.ConnectionMan
 			"supported_types":      []string{"albums", "artists", "tracks", "playlists", "genres", "audiobooks", "podcasts"},
 			"supports_suggestions": false,
 			"status":               spotifyStatus,
+			"last_connected_at":    lastConnectedAt,
+			"reconnect_count":      reconnectCount,
 		})
 
 		// Stripe-style list response (small fixed list - no pagination needed)
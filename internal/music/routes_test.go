@@ -0,0 +1,586 @@
+package music
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/artwork"
+	"github.com/strefethen/sonos-hub-go/internal/audit"
+	"github.com/strefethen/sonos-hub-go/internal/config"
+	"github.com/strefethen/sonos-hub-go/internal/db"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+	"github.com/strefethen/sonos-hub-go/internal/spotifysearch"
+)
+
+// fakeAuditRecorder keeps recorded changes.
+type fakeAuditRecorder struct {
+	changes []audit.Change
+}
+
+func (f *fakeAuditRecorder) RecordChange(_ context.Context, change audit.Change) {
+	f.changes = append(f.changes, change)
+}
+
+func TestSetRoutes_AuditChanges(t *testing.T) {
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+
+	recorder := &fakeAuditRecorder{}
+	router := chi.NewRouter()
+	RegisterRoutes(router, NewService(config.Config{}, dbPair, logging.Discard()), nil, nil, nil, nil, recorder)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := serve(http.MethodPost, "/v1/music/sets", `{"name":"Jazz","selection_policy":"ROTATION"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	setID := created["id"].(string)
+
+	rec = serve(http.MethodPatch, "/v1/music/sets/"+setID, `{"name":"Cool Jazz"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec = serve(http.MethodPost, "/v1/music/sets/"+setID+"/items", `{"sonos_favorite_id":"FV:2/1"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	rec = serve(http.MethodDelete, "/v1/music/sets/"+setID, "")
+	require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+	// Failed mutations aren't audited
+	rec = serve(http.MethodDelete, "/v1/music/sets/missing", "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	require.Len(t, recorder.changes, 4)
+	for _, change := range recorder.changes {
+		require.Equal(t, audit.ResourceMusicSet, change.ResourceType)
+		require.Equal(t, setID, change.ResourceID)
+	}
+	require.Equal(t, audit.EventMusicSetCreated, recorder.changes[0].Type)
+	require.Equal(t, audit.EventMusicSetDeleted, recorder.changes[3].Type)
+
+	diff, err := audit.Diff(recorder.changes[1].Before, recorder.changes[1].After)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"before": "Jazz", "after": "Cool Jazz"}, diff["name"])
+
+	added := recorder.changes[2]
+	require.Equal(t, "add_item", added.Action)
+	diff, err = audit.Diff(added.Before, added.After)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"before": []any{}, "after": []any{"FV:2/1"}}, diff["items"])
+}
+
+func TestSetRoutes_OccasionValidation(t *testing.T) {
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+
+	router := chi.NewRouter()
+	RegisterRoutes(router, NewService(config.Config{}, dbPair, logging.Discard()), nil, nil, nil, nil, nil)
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := serve(http.MethodPost, "/v1/music/sets", `{"name":"Halloween","selection_policy":"SHUFFLE","occasion_start":"Oct 1","occasion_end":"10-31"}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+
+	rec = serve(http.MethodPost, "/v1/music/sets", `{"name":"Holidays","selection_policy":"SHUFFLE","occasion_start":"12-20","occasion_end":"01-05"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	setID := created["id"].(string)
+
+	rec = serve(http.MethodPatch, "/v1/music/sets/"+setID, `{"occasion_end":"02-30"}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	// Empty clears the date
+	rec = serve(http.MethodPatch, "/v1/music/sets/"+setID, `{"occasion_start":"","occasion_end":""}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
+
+// fakePlayback records set playback instead of driving speakers. The speaker's state is
+// what it was playing before any set playback.
+type fakePlayback struct {
+	favorites []sonos.PlayFavoriteRequest
+	contents  []sonos.PlayContentRequest
+	volumes   []int
+	playErr   error
+
+	media    soap.MediaInfo
+	position soap.PositionInfo
+	state    string
+	volume   int
+
+	mu    sync.Mutex
+	calls []string // Transport commands, which previews send from a timer
+}
+
+func (f *fakePlayback) record(call string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+}
+
+func (f *fakePlayback) transportCalls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+func (f *fakePlayback) GetMediaInfo(string) (soap.MediaInfo, error) { return f.media, nil }
+
+func (f *fakePlayback) GetPositionInfo(string) (soap.PositionInfo, error) { return f.position, nil }
+
+func (f *fakePlayback) GetTransportInfo(string) (soap.TransportInfo, error) {
+	return soap.TransportInfo{CurrentTransportState: f.state}, nil
+}
+
+func (f *fakePlayback) GetVolume(string) (soap.VolumeInfo, error) {
+	return soap.VolumeInfo{CurrentVolume: f.volume}, nil
+}
+
+func (f *fakePlayback) SetAVTransportURIWithMetadata(_, uri, _ string) error {
+	f.record("uri " + uri)
+	return nil
+}
+
+func (f *fakePlayback) Seek(_, unit, target string) error {
+	f.record("seek " + unit + " " + target)
+	return nil
+}
+
+func (f *fakePlayback) SetVolume(_ string, level int) error {
+	f.record("volume " + strconv.Itoa(level))
+	return nil
+}
+
+func (f *fakePlayback) Play(string) error {
+	f.record("play")
+	return nil
+}
+
+func (f *fakePlayback) Stop(string) error {
+	f.record("stop")
+	return nil
+}
+
+func (f *fakePlayback) PlayFavorite(_ context.Context, req sonos.PlayFavoriteRequest) (*sonos.PlayFavoriteResponse, error) {
+	if f.playErr != nil {
+		return nil, f.playErr
+	}
+	f.favorites = append(f.favorites, req)
+	return &sonos.PlayFavoriteResponse{Object: "play_favorite", UDN: *req.UDN, FavoriteID: req.FavoriteID}, nil
+}
+
+func (f *fakePlayback) PlayContent(_ context.Context, req sonos.PlayContentRequest) (*sonos.PlayContentResponse, error) {
+	if f.playErr != nil {
+		return nil, f.playErr
+	}
+	f.contents = append(f.contents, req)
+	return &sonos.PlayContentResponse{Object: "play_content", UDN: *req.UDN}, nil
+}
+
+func (f *fakePlayback) ResolveDeviceIP(udn string) (string, error) {
+	if udn != "RINCON_1" {
+		return "", errors.New("device not found")
+	}
+	return "192.168.1.10", nil
+}
+
+func (f *fakePlayback) SetGroupVolume(_ context.Context, _ string, level int) (int, int) {
+	f.volumes = append(f.volumes, level)
+	return 2, 1 // Grouped with two other speakers, one of them unreachable
+}
+
+func TestSetRoutes_Play(t *testing.T) {
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+
+	service := NewService(config.Config{}, dbPair, logging.Discard())
+	router := chi.NewRouter()
+	RegisterRoutes(router, service, nil, nil, nil, nil, nil)
+
+	serve := func(path, body string) (*httptest.ResponseRecorder, map[string]any) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		var response map[string]any
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		return rec, response
+	}
+
+	set, err := service.CreateSet(CreateSetInput{Name: "Jazz", SelectionPolicy: string(SelectionPolicyRotation)})
+	require.NoError(t, err)
+	_, err = service.AddItem(set.SetID, AddItemInput{SonosFavoriteID: "FV:2/1"})
+	require.NoError(t, err)
+	content := `{"type":"direct","service":"spotify","content_type":"playlist","content_id":"37i9dQZF1DX0SM0LYsmbMT","title":"Jazz Vibes"}`
+	_, err = service.AddItem(set.SetID, AddItemInput{SonosFavoriteID: "spotify:playlist:37i9dQZF1DX0SM0LYsmbMT", ContentJSON: &content})
+	require.NoError(t, err)
+	path := "/v1/music/sets/" + set.SetID + "/play"
+
+	rec, _ := serve(path, `{"speaker_id":"RINCON_1"}`)
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code, rec.Body.String())
+
+	playback := &fakePlayback{}
+	service.SetPlayback(playback, playback)
+
+	// Favorites play through PlayFavorite on the resolved speaker
+	rec, response := serve(path, `{"speaker_id":"RINCON_1"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, "play_set", response["object"])
+	require.Equal(t, "playing", response["status"])
+	require.Nil(t, response["volume"])
+	require.Equal(t, map[string]any{"type": "sonos_favorite", "favorite_id": "FV:2/1"}, response["music_content"])
+	require.Equal(t, "play_favorite", response["playback"].(map[string]any)["object"])
+	require.Len(t, playback.favorites, 1)
+	require.Equal(t, "192.168.1.10", *playback.favorites[0].IP)
+	require.Empty(t, playback.volumes)
+
+	// Direct content plays through PlayContent, after the group's volume is set
+	rec, response = serve(path, `{"udn":"RINCON_1","volume":25,"queue_mode":"PLAY_NEXT"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, "play_content", response["playback"].(map[string]any)["object"])
+	require.Len(t, playback.contents, 1)
+	require.Equal(t, "direct", playback.contents[0].Content.Type)
+	require.Equal(t, "37i9dQZF1DX0SM0LYsmbMT", *playback.contents[0].Content.ContentID)
+	require.Equal(t, "PLAY_NEXT", *playback.contents[0].QueueMode)
+	require.Equal(t, []int{25}, playback.volumes)
+	require.Equal(t, map[string]any{"level": float64(25), "succeeded_count": float64(2), "failed_count": float64(1), "all_succeeded": false}, response["volume"])
+
+	// Both plays are in the set's history
+	history, err := service.GetPlayHistory(set.SetID, 10)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	t.Run("errors", func(t *testing.T) {
+		rec, _ := serve(path, `{"speaker_id":"RINCON_1","volume":101}`)
+		require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+		rec, _ = serve(path, `{"speaker_id":"RINCON_MISSING"}`)
+		require.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+		rec, _ = serve("/v1/music/sets/missing/play", `{"speaker_id":"RINCON_1"}`)
+		require.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+
+		playback.playErr = fmt.Errorf("play: %w", &sonos.FavoriteNotFoundError{FavoriteID: "FV:2/1"})
+		rec, _ = serve(path, `{"speaker_id":"RINCON_1"}`)
+		require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+		playback.playErr = errors.New("speaker unreachable at 192.168.1.10")
+		rec, _ = serve(path, `{"speaker_id":"RINCON_1"}`)
+		require.Equal(t, http.StatusInternalServerError, rec.Code, rec.Body.String())
+		require.NotContains(t, rec.Body.String(), "192.168.1.10", "the cause is logged, not returned")
+
+		// Failed plays aren't recorded, and don't move the rotation on
+		history, err := service.GetPlayHistory(set.SetID, 10)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		unchanged, err := service.GetSet(set.SetID)
+		require.NoError(t, err)
+		require.Equal(t, 2, unchanged.CurrentIndex)
+	})
+}
+
+func TestSetRoutes_Preview(t *testing.T) {
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+
+	service := NewService(config.Config{}, dbPair, logging.Discard())
+	router := chi.NewRouter()
+	RegisterRoutes(router, service, nil, nil, nil, nil, nil)
+
+	serve := func(path, body string) (*httptest.ResponseRecorder, map[string]any) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		var response map[string]any
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		return rec, response
+	}
+
+	set, err := service.CreateSet(CreateSetInput{Name: "Jazz", SelectionPolicy: string(SelectionPolicyRotation)})
+	require.NoError(t, err)
+	for _, id := range []string{"FV:2/1", "FV:2/2"} {
+		_, err = service.AddItem(set.SetID, AddItemInput{SonosFavoriteID: id})
+		require.NoError(t, err)
+	}
+
+	// The speaker is playing track 3 of its queue
+	playback := &fakePlayback{
+		media: soap.MediaInfo{CurrentURI: "x-rincon-queue:RINCON_1#0"},
+		position: soap.PositionInfo{
+			Track:         3,
+			RelTime:       "0:01:23",
+			TrackURI:      "x-sonos-spotify:spotify%3atrack%3a1",
+			TrackMetaData: `<DIDL-Lite xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/"><item><dc:title>So What</dc:title><dc:creator>Miles Davis</dc:creator><upnp:class>object.item.audioItem.musicTrack</upnp:class></item></DIDL-Lite>`,
+		},
+		state:  "PLAYING",
+		volume: 22,
+	}
+	service.SetPlayback(playback, playback)
+
+	rec, response := serve("/v1/music/sets/"+set.SetID+"/items/1/preview", `{"udn":"RINCON_1","duration_seconds":1}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, "set_item_preview", response["object"])
+	require.Equal(t, map[string]any{"type": "sonos_favorite", "favorite_id": "FV:2/2"}, response["music_content"])
+	previous := response["previous"].(map[string]any)
+	require.Equal(t, "So What", previous["title"])
+	require.Equal(t, "Miles Davis", previous["artist"])
+	require.Equal(t, "PLAYING", previous["transport_state"])
+	require.Equal(t, true, previous["will_resume"])
+	require.Len(t, playback.favorites, 1)
+	require.Equal(t, "FV:2/2", playback.favorites[0].FavoriteID)
+
+	// The previous queue position, volume and playback come back once the preview ends
+	require.Eventually(t, func() bool { return len(playback.transportCalls()) == 5 }, 3*time.Second, 20*time.Millisecond)
+	require.Equal(t, []string{"uri x-rincon-queue:RINCON_1#0", "seek TRACK_NR 3", "seek REL_TIME 0:01:23", "volume 22", "play"}, playback.transportCalls())
+
+	// Neither rotation nor history moved
+	history, err := service.GetPlayHistory(set.SetID, 10)
+	require.NoError(t, err)
+	require.Empty(t, history)
+	selection, err := service.SelectItem(set.SetID, SelectItemInput{})
+	require.NoError(t, err)
+	require.Equal(t, "FV:2/1", selection.Item.SonosFavoriteID)
+
+	t.Run("stopped speaker is stopped again", func(t *testing.T) {
+		stopped := &fakePlayback{state: "STOPPED", volume: 10}
+		service.SetPlayback(stopped, stopped)
+		rec, response := serve("/v1/music/sets/"+set.SetID+"/items/0/preview", `{"udn":"RINCON_1","duration_seconds":1}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		require.Equal(t, false, response["previous"].(map[string]any)["will_resume"])
+		require.Eventually(t, func() bool { return len(stopped.transportCalls()) == 2 }, 3*time.Second, 20*time.Millisecond)
+		require.Equal(t, []string{"stop", "volume 10"}, stopped.transportCalls())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		rec, _ := serve("/v1/music/sets/"+set.SetID+"/items/5/preview", `{"udn":"RINCON_1"}`)
+		require.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+		rec, _ = serve("/v1/music/sets/"+set.SetID+"/items/x/preview", `{"udn":"RINCON_1"}`)
+		require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+		rec, _ = serve("/v1/music/sets/"+set.SetID+"/items/0/preview", `{"udn":"RINCON_1","duration_seconds":0}`)
+		require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+		rec, _ = serve("/v1/music/sets/"+set.SetID+"/items/0/preview", `{}`)
+		require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+		rec, _ = serve("/v1/music/sets/missing/items/0/preview", `{"udn":"RINCON_1"}`)
+		require.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+	})
+}
+
+// fakeFavoriteCatalog is a system with a fixed set of favorites.
+type fakeFavoriteCatalog []string
+
+func (f fakeFavoriteCatalog) BrowseAllFavorites() ([]soap.FavoriteItem, error) {
+	items := make([]soap.FavoriteItem, 0, len(f))
+	for _, id := range f {
+		items = append(items, soap.FavoriteItem{ID: id})
+	}
+	return items, nil
+}
+
+func TestSetRoutes_ExportImport(t *testing.T) {
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+
+	service := NewService(config.Config{}, dbPair, logging.Discard())
+	service.SetFavoriteCatalog(fakeFavoriteCatalog{"FV:2/1"})
+	recorder := &fakeAuditRecorder{}
+	router := chi.NewRouter()
+	RegisterRoutes(router, service, nil, nil, nil, nil, recorder)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	start, end := "12-01", "12-31"
+	set, err := service.CreateSet(CreateSetInput{Name: "Holiday Jazz", SelectionPolicy: string(SelectionPolicyShuffle), OccasionStart: &start, OccasionEnd: &end})
+	require.NoError(t, err)
+	name, localArtwork, remoteArtwork := "Jingle Bell Swing", artwork.LocalPath("FV:2/1"), "https://example.com/cover.jpg"
+	content := `{"type":"direct","service":"spotify","content_type":"album","content_id":"1","title":"Swing"}`
+	for _, input := range []AddItemInput{
+		{SonosFavoriteID: "FV:2/1", DisplayName: &name, ArtworkURL: &localArtwork},
+		{SonosFavoriteID: "FV:2/9"},
+		{SonosFavoriteID: "spotify:album:1", ContentType: "spotify", ContentJSON: &content, ArtworkURL: &remoteArtwork},
+	} {
+		_, err := service.AddItem(set.SetID, input)
+		require.NoError(t, err)
+	}
+
+	rec := serve(http.MethodGet, "/v1/music/sets/"+set.SetID+"/export", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	document := rec.Body.String()
+	require.NotContains(t, document, set.SetID)
+	var export SetExport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &export))
+	require.Equal(t, SetExportObject, export.Object)
+	require.Equal(t, ExportedSet{Name: "Holiday Jazz", SelectionPolicy: "SHUFFLE", OccasionStart: &start, OccasionEnd: &end}, export.Set)
+	require.Len(t, export.Items, 3)
+	require.Equal(t, content, *export.Items[2].ContentJSON)
+
+	// The name is taken on this hub
+	rec = serve(http.MethodPost, "/v1/music/sets/import", document)
+	require.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+
+	rec = serve(http.MethodPost, "/v1/music/sets/import?on_conflict=rename", document)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var imported map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &imported))
+	require.Equal(t, true, imported["renamed"])
+	require.Equal(t, "Holiday Jazz", imported["original_name"])
+	require.Equal(t, true, imported["favorites_checked"])
+	require.Equal(t, []any{map[string]any{"position": float64(1), "sonos_favorite_id": "FV:2/9", "display_name": nil}}, imported["unlinked_favorites"])
+	importedSet := imported["set"].(map[string]any)
+	require.Equal(t, "Holiday Jazz (2)", importedSet["name"])
+	require.Equal(t, float64(3), importedSet["item_count"])
+	require.Equal(t, "import", recorder.changes[len(recorder.changes)-1].Action)
+
+	items, err := service.GetItems(importedSet["id"].(string))
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	require.Equal(t, []string{"FV:2/1", "FV:2/9", "spotify:album:1"}, []string{items[0].SonosFavoriteID, items[1].SonosFavoriteID, items[2].SonosFavoriteID})
+	require.Equal(t, name, *items[0].DisplayName)
+	require.Nil(t, items[0].ArtworkURL) // The exporting hub's local copy isn't carried over
+	require.Equal(t, remoteArtwork, *items[2].ArtworkURL)
+	require.Equal(t, content, *items[2].ContentJSON)
+
+	rec = serve(http.MethodPost, "/v1/music/sets/import?on_conflict=rename", document)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), `"name":"Holiday Jazz (3)"`)
+
+	t.Run("invalid documents", func(t *testing.T) {
+		for _, body := range []string{
+			`not json`,
+			`{"object":"music_set","version":1,"set":{"name":"A","selection_policy":"SHUFFLE"},"items":[]}`,
+			`{"object":"music_set_export","version":2,"set":{"name":"A","selection_policy":"SHUFFLE"},"items":[]}`,
+			`{"object":"music_set_export","version":1,"set":{"name":"","selection_policy":"SHUFFLE"},"items":[]}`,
+			`{"object":"music_set_export","version":1,"set":{"name":"A","selection_policy":"RANDOM"},"items":[]}`,
+			`{"object":"music_set_export","version":1,"set":{"name":"A","selection_policy":"SHUFFLE","occasion_start":"13-01"},"items":[]}`,
+			`{"object":"music_set_export","version":1,"set":{"name":"A","selection_policy":"SHUFFLE"},"items":[{"sonos_favorite_id":"FV:2/1"},{"sonos_favorite_id":"FV:2/1"}]}`,
+		} {
+			rec := serve(http.MethodPost, "/v1/music/sets/import", body)
+			require.Equal(t, http.StatusBadRequest, rec.Code, body)
+		}
+		rec := serve(http.MethodPost, "/v1/music/sets/import?on_conflict=replace", document)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		rec = serve(http.MethodGet, "/v1/music/sets/missing/export", "")
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestSearchRoutes_MultiProvider(t *testing.T) {
+	spotifyManager := spotifysearch.NewConnectionManager()
+	spotifyManager.SetReconnectGrace(0)
+	t.Cleanup(spotifyManager.Close)
+
+	router := chi.NewRouter()
+	router.Method(http.MethodGet, "/v1/music/search", api.Handler(searchMusic(spotifyManager, nil, nil, nil)))
+	search := func(query string) (int, map[string]any) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/music/search"+query, nil))
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec.Code, body
+	}
+
+	// One provider failing doesn't fail the others
+	code, body := search("?provider=all&query=kind+of+blue")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "music_search", body["object"])
+	require.Equal(t, []any{"apple_music", "library", "spotify"}, body["providers"])
+	results := body["results"].(map[string]any)
+	apple := results["apple_music"].(map[string]any)
+	require.Equal(t, "unavailable", apple["status"])
+	require.Equal(t, "SERVICE_UNAVAILABLE", apple["error"].(map[string]any)["code"])
+	spotify := results["spotify"].(map[string]any)
+	require.Equal(t, "unavailable", spotify["status"])
+	require.Empty(t, spotify["results"])
+	library := results["library"].(map[string]any)
+	require.Equal(t, "ok", library["status"])
+	require.Nil(t, library["error"])
+
+	// A comma list searches just those, once each
+	code, body = search("?provider=library,+spotify,library&query=kind+of+blue")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, []any{"library", "spotify"}, body["providers"])
+	require.Len(t, body["results"], 2)
+
+	for _, query := range []string{"?provider=library,tidal&query=x", "?provider=all", "?provider=,&query=x"} {
+		code, _ := search(query)
+		require.Equal(t, http.StatusBadRequest, code, query)
+	}
+
+	// Single-provider responses keep their shape
+	code, body = search("?provider=library&query=kind+of+blue")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "library", body["provider"])
+	require.Empty(t, body["results"])
+	require.NotNil(t, body["pagination"])
+	code, _ = search("?provider=spotify&query=kind+of+blue")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+}
+
+func TestSearchRoutes_SpotifyItemsCarryBothTypeFields(t *testing.T) {
+	spotifyManager := spotifysearch.NewConnectionManager()
+	t.Cleanup(spotifyManager.Close)
+	extensionRouter := chi.NewRouter()
+	spotifysearch.RegisterRoutes(extensionRouter, spotifyManager)
+	server := httptest.NewServer(extensionRouter)
+	t.Cleanup(server.Close)
+
+	// Stand in for the browser extension, answering one search
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/spotify-search", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		var request spotifysearch.SearchRequest
+		if conn.ReadJSON(&request) != nil {
+			return
+		}
+		conn.WriteJSON(spotifysearch.SearchResultMessage{
+			Type:      "searchResult",
+			RequestID: request.RequestID,
+			Results: spotifysearch.GroupedSearchResults{
+				Albums: []spotifysearch.SpotifyAlbum{{ID: "1weenld61qoidwYuZ1GESA", Name: "Kind of Blue", URI: "spotify:album:1weenld61qoidwYuZ1GESA"}},
+			},
+		})
+	}()
+
+	router := chi.NewRouter()
+	router.Method(http.MethodGet, "/v1/music/search", api.Handler(searchMusic(spotifyManager, nil, nil, nil)))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/music/search?provider=spotify,apple_music&query=kind+of+blue&types=albums", nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var body struct {
+		Results map[string]struct {
+			Status  string                      `json:"status"`
+			Results map[string][]map[string]any `json:"results"`
+		} `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Equal(t, "ok", body.Results["spotify"].Status)
+	require.Equal(t, "unavailable", body.Results["apple_music"].Status)
+	album := body.Results["spotify"].Results["albums"][0]
+	require.Equal(t, "Kind of Blue", album["name"])
+	require.Equal(t, "albums", album["content_type"])
+	require.Equal(t, "albums", album["type"])
+}
@@ -0,0 +1,96 @@
+package music
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// searchCache keeps recent Apple Music and library search results, so the same query
+// re-sent as someone types isn't searched again (Apple rate limits us). Spotify searches
+// aren't cached: the extension searches with its own session state. Results are evicted
+// after ttl, and the least recently used once there are maxEntries.
+type searchCache struct {
+	results *lruCache[searchCacheEntry]
+
+	mu    sync.Mutex
+	stats map[string]*SearchCacheStats
+}
+
+// SearchCacheStats counts a provider's search cache lookups since startup.
+type SearchCacheStats struct {
+	Hits   int64
+	Misses int64
+}
+
+type searchCacheEntry struct {
+	results    map[string]any
+	pagination LibrarySearchPagination // Library only
+}
+
+// newSearchCache creates a cache. A ttl or maxEntries of 0 disables it.
+func newSearchCache(ttl time.Duration, maxEntries int) *searchCache {
+	return &searchCache{
+		results: newLRUCache[searchCacheEntry](ttl, maxEntries),
+		stats:   make(map[string]*SearchCacheStats),
+	}
+}
+
+// searchCacheKey identifies a provider's search. Storefront only applies to Apple Music.
+func searchCacheKey(provider string, params musicSearchParams) string {
+	return strings.Join([]string{
+		provider,
+		strings.ToLower(strings.TrimSpace(params.query)),
+		params.typesParam,
+		strconv.Itoa(params.limit),
+		strconv.Itoa(params.offset),
+		params.storefront,
+	}, "|")
+}
+
+// get returns provider's cached results for key, counting the hit or miss.
+func (c *searchCache) get(provider, key string) (*searchCacheEntry, bool) {
+	if c == nil || !c.results.enabled() {
+		return nil, false
+	}
+	entry, _, ok := c.results.get(key)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.providerStatsLocked(provider)
+	if !ok {
+		stats.Misses++
+		return nil, false
+	}
+	stats.Hits++
+	return &entry, true
+}
+
+// set caches results under key, evicting the least recently used entry when full. The
+// results are shared between responses, so they mustn't be changed after this.
+func (c *searchCache) set(key string, results map[string]any, pagination LibrarySearchPagination) {
+	if c == nil {
+		return
+	}
+	c.results.set(key, searchCacheEntry{results: results, pagination: pagination})
+}
+
+// Stats returns provider's hit and miss counts.
+func (c *searchCache) Stats(provider string) SearchCacheStats {
+	if c == nil {
+		return SearchCacheStats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return *c.providerStatsLocked(provider)
+}
+
+func (c *searchCache) providerStatsLocked(provider string) *SearchCacheStats {
+	stats, ok := c.stats[provider]
+	if !ok {
+		stats = &SearchCacheStats{}
+		c.stats[provider] = stats
+	}
+	return stats
+}
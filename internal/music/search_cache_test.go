@@ -0,0 +1,62 @@
+package music
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchCache(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	cache := newSearchCache(time.Minute, 2)
+	cache.results.now = func() time.Time { return now }
+
+	params := musicSearchParams{query: "Kind of Blue", typesParam: "albums", limit: 25, storefront: "us"}
+	key := searchCacheKey("apple_music", params)
+	_, ok := cache.get("apple_music", key)
+	require.False(t, ok)
+
+	cache.set(key, map[string]any{"albums": []map[string]any{{"name": "Kind of Blue"}}}, LibrarySearchPagination{})
+	entry, ok := cache.get("apple_music", key)
+	require.True(t, ok)
+	require.Contains(t, entry.results, "albums")
+
+	// Case and surrounding spaces in the query don't matter; the other parameters do
+	params.query = " kind of blue"
+	require.Equal(t, key, searchCacheKey("apple_music", params))
+	params.storefront = "gb"
+	require.NotEqual(t, key, searchCacheKey("apple_music", params))
+	require.NotEqual(t, key, searchCacheKey("library", musicSearchParams{query: "Kind of Blue", typesParam: "albums", limit: 25, storefront: "us"}))
+
+	// The least recently used search is evicted first
+	cache.set("b", map[string]any{}, LibrarySearchPagination{})
+	_, ok = cache.get("apple_music", key)
+	require.True(t, ok)
+	cache.set("c", map[string]any{}, LibrarySearchPagination{})
+	_, ok = cache.get("apple_music", "b")
+	require.False(t, ok)
+	_, ok = cache.get("apple_music", key)
+	require.True(t, ok)
+
+	now = now.Add(61 * time.Second)
+	_, ok = cache.get("apple_music", key)
+	require.False(t, ok)
+
+	require.Equal(t, SearchCacheStats{Hits: 3, Misses: 3}, cache.Stats("apple_music"))
+	require.Equal(t, SearchCacheStats{}, cache.Stats("library"))
+}
+
+func TestSearchCache_Disabled(t *testing.T) {
+	cache := newSearchCache(0, 500)
+	cache.set("a", map[string]any{}, LibrarySearchPagination{})
+	_, ok := cache.get("library", "a")
+	require.False(t, ok)
+	require.Equal(t, SearchCacheStats{}, cache.Stats("library"))
+
+	var none *searchCache
+	none.set("a", map[string]any{}, LibrarySearchPagination{})
+	_, ok = none.get("library", "a")
+	require.False(t, ok)
+	require.Equal(t, SearchCacheStats{}, none.Stats("library"))
+}
@@ -0,0 +1,140 @@
+package music
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/config"
+	"github.com/strefethen/sonos-hub-go/internal/db"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+)
+
+func TestPickWeightedSet(t *testing.T) {
+	jazz := setCandidate{set: &MusicSet{SetID: "jazz"}, weight: 70}
+	classical := setCandidate{set: &MusicSet{SetID: "classical"}, weight: 30}
+	candidates := []setCandidate{jazz, classical}
+
+	require.Equal(t, "jazz", pickWeightedSet(candidates, 0).set.SetID)
+	require.Equal(t, "jazz", pickWeightedSet(candidates, 0.69).set.SetID)
+	require.Equal(t, "classical", pickWeightedSet(candidates, 0.70).set.SetID)
+	require.Equal(t, "classical", pickWeightedSet(candidates, 0.9999).set.SetID)
+
+	// Unweighted sets share evenly
+	even := []setCandidate{{set: &MusicSet{SetID: "a"}}, {set: &MusicSet{SetID: "b"}}}
+	require.Equal(t, "a", pickWeightedSet(even, 0.49).set.SetID)
+	require.Equal(t, "b", pickWeightedSet(even, 0.5).set.SetID)
+}
+
+func TestSelectItemFromSets(t *testing.T) {
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+	service := NewService(config.Config{}, dbPair, logging.Discard())
+
+	newSet := func(name string, favoriteIDs ...string) string {
+		set, err := service.CreateSet(CreateSetInput{Name: name, SelectionPolicy: string(SelectionPolicyShuffle)})
+		require.NoError(t, err)
+		for _, id := range favoriteIDs {
+			_, err := service.AddItem(set.SetID, AddItemInput{SonosFavoriteID: id})
+			require.NoError(t, err)
+		}
+		return set.SetID
+	}
+	jazz := newSet("Jazz", "FV:jazz")
+	classical := newSet("Classical", "FV:bach", "FV:mozart")
+	sets := []WeightedSet{{SetID: jazz, Weight: 1000}, {SetID: classical, Weight: 1}}
+	window := 60
+	input := SelectItemInput{NoRepeatWindowMinutes: &window}
+
+	// Jazz has played its only item, so classical is picked despite its weight
+	require.NoError(t, service.RecordPlay("FV:jazz", &jazz, nil))
+	require.NoError(t, service.RecordPlay("FV:bach", &classical, nil))
+	for range 10 {
+		result, err := service.SelectItemFromSets(sets, input)
+		require.NoError(t, err)
+		require.Equal(t, "FV:mozart", result.Item.SonosFavoriteID)
+		require.Equal(t, classical, result.Item.SetID)
+	}
+
+	// The window spans sets: a favorite played from one set isn't repeated from another
+	shared := newSet("Shared", "FV:mozart", "FV:chopin")
+	require.NoError(t, service.RecordPlay("FV:mozart", &classical, nil))
+	for range 10 {
+		result, err := service.SelectItemFromSets([]WeightedSet{{SetID: shared, Weight: 1}, {SetID: classical, Weight: 1}}, input)
+		require.NoError(t, err)
+		require.Equal(t, "FV:chopin", result.Item.SonosFavoriteID)
+	}
+
+	t.Run("everything played uses every set", func(t *testing.T) {
+		require.NoError(t, service.RecordPlay("FV:chopin", &shared, nil))
+		result, err := service.SelectItemFromSets([]WeightedSet{{SetID: jazz, Weight: 1}, {SetID: classical, Weight: 1}}, input)
+		require.NoError(t, err)
+		require.NotNil(t, result.Item)
+	})
+
+	t.Run("missing and empty sets are skipped", func(t *testing.T) {
+		empty := newSet("Empty")
+		result, err := service.SelectItemFromSets([]WeightedSet{{SetID: "missing", Weight: 100}, {SetID: empty, Weight: 100}, {SetID: jazz, Weight: 1}}, SelectItemInput{})
+		require.NoError(t, err)
+		require.Equal(t, "FV:jazz", result.Item.SonosFavoriteID)
+
+		_, err = service.SelectItemFromSets([]WeightedSet{{SetID: "missing", Weight: 1}, {SetID: empty, Weight: 1}}, SelectItemInput{})
+		var notFound *SetNotFoundError
+		require.True(t, errors.As(err, &notFound))
+	})
+}
+
+func TestSelectItem_NoRepeatScope(t *testing.T) {
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+	service := NewService(config.Config{}, dbPair, logging.Discard())
+
+	// Play history references routines, which need a scene
+	_, err = dbPair.Writer().Exec(`
+		INSERT INTO scenes (scene_id, name, coordinator_preference, fallback_policy, members, created_at, updated_at)
+		VALUES ('scene-1', 'Scene', 'ARC_FIRST', 'PLAYBASE_IF_ARC_TV_ACTIVE', '[]', datetime('now'), datetime('now'))
+	`)
+	require.NoError(t, err)
+	for _, routineID := range []string{"routine-kitchen", "routine-bathroom"} {
+		_, err := dbPair.Writer().Exec(`
+			INSERT INTO routines (routine_id, name, enabled, timezone, schedule_type, schedule_time, holiday_behavior, scene_id, music_policy_type, skip_next, created_at, updated_at)
+			VALUES (?, ?, 1, 'UTC', 'weekly', '07:00', 'SKIP', 'scene-1', 'SHUFFLE', 0, datetime('now'), datetime('now'))
+		`, routineID, routineID)
+		require.NoError(t, err)
+	}
+
+	albums, err := service.CreateSet(CreateSetInput{Name: "Albums", SelectionPolicy: string(SelectionPolicyShuffle)})
+	require.NoError(t, err)
+	for _, id := range []string{"FV:a", "FV:b", "FV:c"} {
+		_, err := service.AddItem(albums.SetID, AddItemInput{SonosFavoriteID: id})
+		require.NoError(t, err)
+	}
+	other, err := service.CreateSet(CreateSetInput{Name: "Other", SelectionPolicy: string(SelectionPolicyShuffle)})
+	require.NoError(t, err)
+
+	// The bathroom routine played FV:a from this set; the kitchen routine played FV:b
+	// from another set
+	bathroom, kitchen := "routine-bathroom", "routine-kitchen"
+	require.NoError(t, service.RecordPlay("FV:a", &albums.SetID, &bathroom))
+	require.NoError(t, service.RecordPlay("FV:b", &other.SetID, &kitchen))
+
+	window := 60
+	picks := func(scope NoRepeatScope) map[string]bool {
+		picked := make(map[string]bool)
+		for range 20 {
+			result, err := service.SelectItem(albums.SetID, SelectItemInput{NoRepeatWindowMinutes: &window, NoRepeatScope: scope, RoutineID: kitchen})
+			require.NoError(t, err)
+			picked[result.Item.SonosFavoriteID] = true
+		}
+		return picked
+	}
+
+	require.NotContains(t, picks(NoRepeatScopeSet), "FV:a")
+	require.NotContains(t, picks(NoRepeatScopeRoutine), "FV:b")
+	require.Equal(t, map[string]bool{"FV:c": true}, picks(NoRepeatScopeGlobal))
+	require.Equal(t, map[string]bool{"FV:c": true}, picks(""), "global is the default")
+}
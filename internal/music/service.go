@@ -2,27 +2,40 @@ package music
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"math/rand"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/strefethen/sonos-hub-go/internal/artwork"
 	"github.com/strefethen/sonos-hub-go/internal/config"
 )
 
 // Service provides music catalog management functionality.
 type Service struct {
 	cfg         config.Config
-	logger      *log.Logger
+	logger      *slog.Logger
 	setsRepo    *MusicSetRepository
 	itemsRepo   *SetItemRepository
 	historyRepo *PlayHistoryRepository
+
+	favoriteArtwork *artwork.FavoriteCache // Optional: local copies of favorite artwork
+	favoriteCatalog FavoriteCatalog        // Optional: flags imported items missing their favorite
+
+	// Optional: play sets on demand; see SetPlayback
+	player   SetPlayer
+	speakers Speakers
+
+	previewsMu sync.Mutex
+	previews   map[string]*pendingPreview // Speaker UDN -> preview waiting to restore
 }
 
 // NewService creates a new music catalog service.
 // Accepts a DBPair for optimal SQLite concurrency with separate reader/writer pools.
-func NewService(cfg config.Config, dbPair DBPair, logger *log.Logger) *Service {
+func NewService(cfg config.Config, dbPair DBPair, logger *slog.Logger) *Service {
 	if logger == nil {
-		logger = log.Default()
+		logger = slog.Default()
 	}
 
 	return &Service{
@@ -31,6 +44,7 @@ func NewService(cfg config.Config, dbPair DBPair, logger *log.Logger) *Service {
 		setsRepo:    NewMusicSetRepository(dbPair),
 		itemsRepo:   NewSetItemRepository(dbPair),
 		historyRepo: NewPlayHistoryRepository(dbPair),
+		previews:    make(map[string]*pendingPreview),
 	}
 }
 
@@ -42,11 +56,11 @@ func NewService(cfg config.Config, dbPair DBPair, logger *log.Logger) *Service {
 func (s *Service) CreateSet(input CreateSetInput) (*MusicSet, error) {
 	set, err := s.setsRepo.Create(input)
 	if err != nil {
-		s.logger.Printf("Failed to create music set: %v", err)
+		s.logger.Error("Failed to create music set", "error", err)
 		return nil, err
 	}
 
-	s.logger.Printf("Created music set: %s (%s)", set.Name, set.SetID)
+	s.logger.Info("Created music set", "name", set.Name, "set_id", set.SetID)
 	return set, nil
 }
 
@@ -70,6 +84,11 @@ func (s *Service) GetSet(setID string) (*MusicSet, error) {
 	return set, nil
 }
 
+// SetNames returns the names of the music sets among setIDs that exist, by ID.
+func (s *Service) SetNames(setIDs []string) (map[string]string, error) {
+	return s.setsRepo.Names(setIDs)
+}
+
 // ListSets retrieves music sets with pagination.
 func (s *Service) ListSets(limit, offset int) ([]MusicSet, int, error) {
 	sets, total, err := s.setsRepo.List(limit, offset)
@@ -89,6 +108,49 @@ func (s *Service) ListSets(limit, offset int) ([]MusicSet, int, error) {
 	return sets, total, nil
 }
 
+// ReorderSets sets the list order of all music sets.
+// The input must be a permutation of the existing (non-deleted) set IDs.
+func (s *Service) ReorderSets(input ReorderSetsInput) error {
+	existingIDs, err := s.setsRepo.ListIDs()
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool, len(existingIDs))
+	for _, id := range existingIDs {
+		existing[id] = true
+	}
+
+	orderErr := &InvalidSetOrderError{}
+	provided := make(map[string]bool, len(input.SetIDs))
+	for _, id := range input.SetIDs {
+		if provided[id] {
+			orderErr.Duplicate = append(orderErr.Duplicate, id)
+			continue
+		}
+		provided[id] = true
+		if !existing[id] {
+			orderErr.Unknown = append(orderErr.Unknown, id)
+		}
+	}
+	for _, id := range existingIDs {
+		if !provided[id] {
+			orderErr.Missing = append(orderErr.Missing, id)
+		}
+	}
+	if len(orderErr.Duplicate) > 0 || len(orderErr.Unknown) > 0 || len(orderErr.Missing) > 0 {
+		return orderErr
+	}
+
+	if err := s.setsRepo.Reorder(input.SetIDs); err != nil {
+		s.logger.Error("Failed to reorder music sets", "error", err)
+		return err
+	}
+
+	s.logger.Info("Reordered music sets", "count", len(input.SetIDs))
+	return nil
+}
+
 // UpdateSet updates a music set.
 func (s *Service) UpdateSet(setID string, input UpdateSetInput) (*MusicSet, error) {
 	// Verify set exists
@@ -102,7 +164,7 @@ func (s *Service) UpdateSet(setID string, input UpdateSetInput) (*MusicSet, erro
 
 	set, err := s.setsRepo.Update(setID, input)
 	if err != nil {
-		s.logger.Printf("Failed to update music set %s: %v", setID, err)
+		s.logger.Error("Failed to update music set", "set_id", setID, "error", err)
 		return nil, err
 	}
 
@@ -113,7 +175,7 @@ func (s *Service) UpdateSet(setID string, input UpdateSetInput) (*MusicSet, erro
 	}
 	set.ItemCount = count
 
-	s.logger.Printf("Updated music set: %s", setID)
+	s.logger.Info("Updated music set", "set_id", setID)
 	return set, nil
 }
 
@@ -129,11 +191,11 @@ func (s *Service) DeleteSet(setID string) error {
 	}
 
 	if err := s.setsRepo.Delete(setID); err != nil {
-		s.logger.Printf("Failed to delete music set %s: %v", setID, err)
+		s.logger.Error("Failed to delete music set", "set_id", setID, "error", err)
 		return err
 	}
 
-	s.logger.Printf("Deleted music set: %s", setID)
+	s.logger.Info("Deleted music set", "set_id", setID)
 	return nil
 }
 
@@ -146,14 +208,33 @@ func (s *Service) GetSetIncludingDeleted(setID string) (*MusicSet, bool, error)
 func (s *Service) RestoreSet(setID string) (*MusicSet, error) {
 	set, err := s.setsRepo.Restore(setID)
 	if err != nil {
-		s.logger.Printf("Failed to restore music set %s: %v", setID, err)
+		s.logger.Error("Failed to restore music set", "set_id", setID, "error", err)
 		return nil, err
 	}
 
-	s.logger.Printf("Restored music set: %s", setID)
+	s.logger.Info("Restored music set", "set_id", setID)
 	return set, nil
 }
 
+// SetFavoriteArtwork sets the cache new set items and routines store favorite artwork in.
+func (s *Service) SetFavoriteArtwork(cache *artwork.FavoriteCache) {
+	s.favoriteArtwork = cache
+}
+
+// LocalizeFavoriteArtwork returns the local path for a Sonos favorite's artwork,
+// downloading it the first time. artworkURL is returned unchanged when the favorite
+// artwork cache isn't set up or the download fails.
+func (s *Service) LocalizeFavoriteArtwork(favoriteID, artworkURL string) string {
+	if !strings.HasPrefix(favoriteID, "FV:2/") {
+		return artworkURL
+	}
+	localPath, err := s.favoriteArtwork.Localize(favoriteID, artworkURL)
+	if err != nil {
+		s.logger.Warn("Failed to store favorite artwork locally", "favorite_id", favoriteID, "error", err)
+	}
+	return localPath
+}
+
 // ==========================================================================
 // Item Management
 // ==========================================================================
@@ -169,13 +250,24 @@ func (s *Service) AddItem(setID string, input AddItemInput) (*SetItem, error) {
 		return nil, &SetNotFoundError{SetID: setID}
 	}
 
+	// Sonos favorite artwork URLs expire, so store a local copy's path instead
+	if input.ContentType == "" || input.ContentType == "sonos_favorite" {
+		artworkURL := ""
+		if input.ArtworkURL != nil {
+			artworkURL = *input.ArtworkURL
+		}
+		if localPath := s.LocalizeFavoriteArtwork(input.SonosFavoriteID, artworkURL); localPath != "" {
+			input.ArtworkURL = &localPath
+		}
+	}
+
 	item, err := s.itemsRepo.Add(setID, input)
 	if err != nil {
-		s.logger.Printf("Failed to add item to set %s: %v", setID, err)
+		s.logger.Error("Failed to add item to set", "set_id", setID, "error", err)
 		return nil, err
 	}
 
-	s.logger.Printf("Added item %s to set %s at position %d", input.SonosFavoriteID, setID, item.Position)
+	s.logger.Info("Added item to set", "favorite_id", input.SonosFavoriteID, "set_id", setID, "position", item.Position)
 	return item, nil
 }
 
@@ -200,11 +292,11 @@ func (s *Service) RemoveItem(setID, sonosFavoriteID string) error {
 	}
 
 	if err := s.itemsRepo.Remove(setID, sonosFavoriteID); err != nil {
-		s.logger.Printf("Failed to remove item %s from set %s: %v", sonosFavoriteID, setID, err)
+		s.logger.Error("Failed to remove item from set", "favorite_id", sonosFavoriteID, "set_id", setID, "error", err)
 		return err
 	}
 
-	s.logger.Printf("Removed item %s from set %s", sonosFavoriteID, setID)
+	s.logger.Info("Removed item from set", "favorite_id", sonosFavoriteID, "set_id", setID)
 	return nil
 }
 
@@ -222,6 +314,69 @@ func (s *Service) GetItems(setID string) ([]SetItem, error) {
 	return s.itemsRepo.GetItems(setID)
 }
 
+// SearchItems returns the items in a music set whose display name, or title, artist,
+// or album from content_json, contains the query (case-insensitive). Items keep their
+// set order and positions.
+func (s *Service) SearchItems(setID, query string) ([]SetItem, error) {
+	items, err := s.GetItems(setID)
+	if err != nil {
+		return nil, err
+	}
+
+	query = strings.ToLower(strings.TrimSpace(query))
+	matches := make([]SetItem, 0)
+	for _, item := range items {
+		if itemMatchesQuery(&item, query) {
+			matches = append(matches, item)
+		}
+	}
+	return matches, nil
+}
+
+// itemMatchesQuery reports whether a lowercased query appears in an item's searchable fields.
+func itemMatchesQuery(item *SetItem, query string) bool {
+	fields := make([]string, 0, 4)
+	if item.DisplayName != nil {
+		fields = append(fields, *item.DisplayName)
+	}
+	if item.ContentJSON != nil && *item.ContentJSON != "" {
+		var metadata ContentMetadata
+		if err := json.Unmarshal([]byte(*item.ContentJSON), &metadata); err == nil {
+			fields = append(fields, metadata.Title, metadata.Artist, metadata.Album)
+		}
+	}
+
+	for _, field := range fields {
+		if field != "" && strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetFavoriteIDs returns the sonos_favorite_ids of all items in a music set.
+// Returns nil without error if the set does not exist.
+func (s *Service) SetFavoriteIDs(setID string) (map[string]bool, error) {
+	existing, err := s.setsRepo.GetByID(setID)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, nil
+	}
+
+	items, err := s.itemsRepo.GetItems(setID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]bool, len(items))
+	for _, item := range items {
+		ids[item.SonosFavoriteID] = true
+	}
+	return ids, nil
+}
+
 // RemoveItemByPosition removes an item from a music set by its position.
 func (s *Service) RemoveItemByPosition(setID string, position int) error {
 	// Verify set exists
@@ -243,14 +398,41 @@ func (s *Service) RemoveItemByPosition(setID string, position int) error {
 	}
 
 	if err := s.itemsRepo.RemoveByPosition(setID, position); err != nil {
-		s.logger.Printf("Failed to remove item at position %d from set %s: %v", position, setID, err)
+		s.logger.Error("Failed to remove item from set", "position", position, "set_id", setID, "error", err)
 		return err
 	}
 
-	s.logger.Printf("Removed item at position %d from set %s", position, setID)
+	s.logger.Info("Removed item from set", "position", position, "set_id", setID)
 	return nil
 }
 
+// CopyItems copies items from another set into this one, appending them after its
+// existing items and skipping any it already has. With input.Move they're also taken
+// out of the source set, including skipped items, since the target already has them.
+func (s *Service) CopyItems(setID string, input CopyItemsInput) (*CopyItemsResult, error) {
+	for _, id := range []string{setID, input.SourceSetID} {
+		existing, err := s.setsRepo.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			return nil, &SetNotFoundError{SetID: id}
+		}
+	}
+
+	result, err := s.itemsRepo.CopyItems(input.SourceSetID, setID, input.Positions, input.Move)
+	if err != nil {
+		if !isPositionNotFoundError(err) {
+			s.logger.Error("Failed to copy items between sets", "source_set_id", input.SourceSetID, "set_id", setID, "error", err)
+		}
+		return nil, err
+	}
+
+	s.logger.Info("Copied items between sets", "source_set_id", input.SourceSetID, "set_id", setID,
+		"copied", len(result.Copied), "skipped", len(result.Skipped), "move", input.Move)
+	return result, nil
+}
+
 // ListItems retrieves items in a music set with pagination.
 // Uses database-level pagination for efficiency.
 func (s *Service) ListItems(setID string, limit, offset int) ([]SetItem, int, error) {
@@ -309,11 +491,11 @@ func (s *Service) ReorderItems(setID string, input ReorderItemsInput) error {
 	}
 
 	if err := s.itemsRepo.Reorder(setID, input.Items); err != nil {
-		s.logger.Printf("Failed to reorder items in set %s: %v", setID, err)
+		s.logger.Error("Failed to reorder items in set", "set_id", setID, "error", err)
 		return err
 	}
 
-	s.logger.Printf("Reordered %d items in set %s", len(input.Items), setID)
+	s.logger.Info("Reordered items in set", "count", len(input.Items), "set_id", setID)
 	return nil
 }
 
@@ -343,16 +525,136 @@ func (s *Service) SelectItem(setID string, input SelectItemInput) (*SelectionRes
 
 	switch SelectionPolicy(set.SelectionPolicy) {
 	case SelectionPolicyShuffle:
-		return s.selectShuffle(set, items, input.NoRepeatWindowMinutes)
+		return s.selectShuffle(set, items, s.recentlyPlayed([]string{setID}, input))
 	case SelectionPolicyRotation:
 		fallthrough
 	default:
-		return s.selectRotation(set, items)
+		return s.selectRotation(set, items, input.DryRun)
+	}
+}
+
+// SelectItemFromSets picks one of sets at random in proportion to its weight, then
+// selects an item from it as SelectItem does. The no-repeat window spans every set: with
+// the set scope, an item played recently from any of them isn't picked again. A set with
+// nothing left to play is passed over while another has something. Once every set has been played
+// through, they are all eligible again, as with a single set. Sets that are missing or
+// empty are skipped; it fails only if none are left.
+func (s *Service) SelectItemFromSets(sets []WeightedSet, input SelectItemInput) (*SelectionResult, error) {
+	if len(sets) == 1 {
+		return s.SelectItem(sets[0].SetID, input)
+	}
+
+	setIDs := make([]string, 0, len(sets))
+	for _, weighted := range sets {
+		setIDs = append(setIDs, weighted.SetID)
+	}
+	recentlyPlayed := s.recentlyPlayed(setIDs, input)
+
+	var candidates, fresh []setCandidate
+	var firstErr error
+	for _, weighted := range sets {
+		set, err := s.setsRepo.GetByID(weighted.SetID)
+		if err == nil && set == nil {
+			err = &SetNotFoundError{SetID: weighted.SetID}
+		}
+		var items []SetItem
+		if err == nil {
+			items, err = s.itemsRepo.GetItems(weighted.SetID)
+		}
+		if err == nil && len(items) == 0 {
+			err = &EmptySetError{SetID: weighted.SetID}
+		}
+		if err != nil {
+			s.logger.Warn("Skipping music set", "set_id", weighted.SetID, "error", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		candidate := setCandidate{set: set, items: items, weight: weighted.Weight}
+		candidates = append(candidates, candidate)
+		if len(excludeRecentlyPlayed(items, recentlyPlayed)) > 0 {
+			fresh = append(fresh, candidate)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, firstErr
+	}
+	if len(fresh) > 0 {
+		candidates = fresh
+	} else {
+		s.logger.Info("All items in every set were recently played, using every set", "set_ids", setIDs)
+	}
+
+	chosen := pickWeightedSet(candidates, rand.Float64())
+	s.logger.Info("Weighted selection picked set", "set_id", chosen.set.SetID, "weight", chosen.weight, "candidates", len(candidates))
+	if SelectionPolicy(chosen.set.SelectionPolicy) == SelectionPolicyShuffle {
+		return s.selectShuffle(chosen.set, chosen.items, recentlyPlayed)
+	}
+	return s.selectRotation(chosen.set, chosen.items, input.DryRun)
+}
+
+// setCandidate is a set SelectItemFromSets may pick, with its items.
+type setCandidate struct {
+	set    *MusicSet
+	items  []SetItem
+	weight int
+}
+
+// pickWeightedSet picks the candidate that roll, in [0, 1), lands on when each candidate
+// gets a share of the range proportional to its weight. Weights below 1 count as 1.
+func pickWeightedSet(candidates []setCandidate, roll float64) setCandidate {
+	total := 0
+	for _, candidate := range candidates {
+		total += max(candidate.weight, 1)
+	}
+	target := roll * float64(total)
+	for _, candidate := range candidates {
+		target -= float64(max(candidate.weight, 1))
+		if target < 0 {
+			return candidate
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+// recentlyPlayed returns the favorites played within the no-repeat window, counting the
+// plays input's scope covers: from any of setIDs, by the routine, or by anything. It is
+// empty when there is no window, or the history can't be read.
+func (s *Service) recentlyPlayed(setIDs []string, input SelectItemInput) map[string]bool {
+	recentlyPlayed := make(map[string]bool)
+	if input.NoRepeatWindowMinutes == nil || *input.NoRepeatWindowMinutes <= 0 {
+		return recentlyPlayed
+	}
+	window := *input.NoRepeatWindowMinutes
+	add := func(favoriteIDs []string, err error) {
+		if err != nil {
+			s.logger.Warn("Failed to get recently played items", "scope", input.NoRepeatScope, "error", err)
+			// Continue with all items if we can't get history
+			return
+		}
+		for _, id := range favoriteIDs {
+			recentlyPlayed[id] = true
+		}
+	}
+
+	switch {
+	case input.NoRepeatScope == NoRepeatScopeRoutine && input.RoutineID != "":
+		add(s.historyRepo.GetRecentlyPlayedByRoutine(input.RoutineID, window))
+	case input.NoRepeatScope == NoRepeatScopeSet || input.NoRepeatScope == NoRepeatScopeRoutine:
+		for _, setID := range setIDs {
+			add(s.historyRepo.GetRecentlyPlayedInSet(setID, window))
+		}
+	default:
+		add(s.historyRepo.GetRecentlyPlayed(window))
 	}
+	return recentlyPlayed
 }
 
-// selectRotation selects the next item in rotation order.
-func (s *Service) selectRotation(set *MusicSet, items []SetItem) (*SelectionResult, error) {
+// selectRotation selects the next item in rotation order. A dry run leaves the set's
+// index where it is.
+func (s *Service) selectRotation(set *MusicSet, items []SetItem, dryRun bool) (*SelectionResult, error) {
 	// Get item at current_index % item_count
 	itemCount := len(items)
 	selectedIndex := set.CurrentIndex % itemCount
@@ -371,15 +673,19 @@ func (s *Service) selectRotation(set *MusicSet, items []SetItem) (*SelectionResu
 		selectedItem = &items[selectedIndex]
 	}
 
+	if dryRun {
+		return &SelectionResult{Item: selectedItem, NextIndex: set.CurrentIndex + 1}, nil
+	}
+
 	// Atomically increment the index
 	newIndex, err := s.setsRepo.IncrementIndex(set.SetID)
 	if err != nil {
-		s.logger.Printf("Failed to increment index for set %s: %v", set.SetID, err)
+		s.logger.Error("Failed to increment set index", "set_id", set.SetID, "error", err)
 		return nil, err
 	}
 
-	s.logger.Printf("Rotation selected item %s from set %s (index %d -> %d)",
-		selectedItem.SonosFavoriteID, set.SetID, set.CurrentIndex, newIndex)
+	s.logger.Info("Rotation selected item",
+		"favorite_id", selectedItem.SonosFavoriteID, "set_id", set.SetID, "index", set.CurrentIndex, "next_index", newIndex)
 
 	return &SelectionResult{
 		Item:        selectedItem,
@@ -388,39 +694,21 @@ func (s *Service) selectRotation(set *MusicSet, items []SetItem) (*SelectionResu
 	}, nil
 }
 
-// selectShuffle randomly selects an item, optionally avoiding recently played items.
-func (s *Service) selectShuffle(set *MusicSet, items []SetItem, noRepeatWindowMinutes *int) (*SelectionResult, error) {
+// selectShuffle randomly selects an item, avoiding the recently played items if any
+// are left.
+func (s *Service) selectShuffle(set *MusicSet, items []SetItem, recentlyPlayed map[string]bool) (*SelectionResult, error) {
 	availableItems := items
-
-	// If no-repeat window is specified, filter out recently played items
-	if noRepeatWindowMinutes != nil && *noRepeatWindowMinutes > 0 {
-		recentlyPlayed, err := s.historyRepo.GetRecentlyPlayedInSet(set.SetID, *noRepeatWindowMinutes)
-		if err != nil {
-			s.logger.Printf("Failed to get recently played items for set %s: %v", set.SetID, err)
-			// Continue with all items if we can't get history
-		} else if len(recentlyPlayed) > 0 {
-			// Create a set of recently played IDs
-			recentlyPlayedSet := make(map[string]bool)
-			for _, id := range recentlyPlayed {
-				recentlyPlayedSet[id] = true
-			}
-
-			// Filter out recently played items
-			var filtered []SetItem
-			for _, item := range items {
-				if !recentlyPlayedSet[item.SonosFavoriteID] {
-					filtered = append(filtered, item)
-				}
-			}
-
-			// Only use filtered list if it's not empty
-			if len(filtered) > 0 {
-				availableItems = filtered
-				s.logger.Printf("Filtered out %d recently played items from set %s, %d available",
-					len(items)-len(filtered), set.SetID, len(filtered))
-			} else {
-				s.logger.Printf("All items in set %s were recently played, using full list", set.SetID)
-			}
+	skipped := 0
+
+	if len(recentlyPlayed) > 0 {
+		// Only use filtered list if it's not empty
+		if filtered := excludeRecentlyPlayed(items, recentlyPlayed); len(filtered) > 0 {
+			availableItems = filtered
+			skipped = len(items) - len(filtered)
+			s.logger.Info("Filtered out recently played items",
+				"set_id", set.SetID, "filtered", len(items)-len(filtered), "available", len(filtered))
+		} else {
+			s.logger.Info("All items were recently played, using full list", "set_id", set.SetID)
 		}
 	}
 
@@ -429,16 +717,84 @@ func (s *Service) selectShuffle(set *MusicSet, items []SetItem, noRepeatWindowMi
 	selectedIndex := rand.Intn(len(availableItems))
 	selectedItem := &availableItems[selectedIndex]
 
-	s.logger.Printf("Shuffle selected item %s from set %s (from %d available)",
-		selectedItem.SonosFavoriteID, set.SetID, len(availableItems))
+	s.logger.Info("Shuffle selected item",
+		"favorite_id", selectedItem.SonosFavoriteID, "set_id", set.SetID, "available", len(availableItems))
 
 	return &SelectionResult{
-		Item:        selectedItem,
-		NextIndex:   set.CurrentIndex, // Index doesn't change for shuffle
-		WasShuffled: true,
+		Item:                  selectedItem,
+		NextIndex:             set.CurrentIndex, // Index doesn't change for shuffle
+		WasShuffled:           true,
+		RecentlyPlayedSkipped: skipped,
 	}, nil
 }
 
+// excludeRecentlyPlayed returns the items not in recentlyPlayed, or nil if every item was played.
+func excludeRecentlyPlayed(items []SetItem, recentlyPlayed map[string]bool) []SetItem {
+	var filtered []SetItem
+	for _, item := range items {
+		if !recentlyPlayed[item.SonosFavoriteID] {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// PreviewShuffle simulates the next count shuffle selections for a SHUFFLE set without
+// recording history or changing set state. Each simulated pick is treated as played within
+// the no-repeat window, so the preview shows how the window spreads repeats out.
+// Picks are random, so the preview is a representative sample, not a guarantee.
+func (s *Service) PreviewShuffle(setID string, count int, noRepeatWindowMinutes *int) ([]SetItem, error) {
+	set, err := s.setsRepo.GetByID(setID)
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		return nil, &SetNotFoundError{SetID: setID}
+	}
+	if SelectionPolicy(set.SelectionPolicy) != SelectionPolicyShuffle {
+		return nil, &NotShuffleSetError{SetID: setID, SelectionPolicy: set.SelectionPolicy}
+	}
+
+	items, err := s.itemsRepo.GetItems(setID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, &EmptySetError{SetID: setID}
+	}
+
+	windowed := noRepeatWindowMinutes != nil && *noRepeatWindowMinutes > 0
+	excluded := make(map[string]bool)
+	if windowed {
+		recentlyPlayed, err := s.historyRepo.GetRecentlyPlayedInSet(setID, *noRepeatWindowMinutes)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range recentlyPlayed {
+			excluded[id] = true
+		}
+	}
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	preview := make([]SetItem, 0, count)
+	for i := 0; i < count; i++ {
+		available := items
+		if windowed {
+			if filtered := excludeRecentlyPlayed(items, excluded); len(filtered) > 0 {
+				available = filtered
+			}
+		}
+
+		selected := available[rng.Intn(len(available))]
+		preview = append(preview, selected)
+		if windowed {
+			excluded[selected.SonosFavoriteID] = true
+		}
+	}
+
+	return preview, nil
+}
+
 // ==========================================================================
 // Play History
 // ==========================================================================
@@ -446,11 +802,18 @@ func (s *Service) selectShuffle(set *MusicSet, items []SetItem, noRepeatWindowMi
 // RecordPlay records that a favorite was played.
 func (s *Service) RecordPlay(sonosFavoriteID string, setID, routineID *string) error {
 	if err := s.historyRepo.Record(sonosFavoriteID, setID, routineID); err != nil {
-		s.logger.Printf("Failed to record play for %s: %v", sonosFavoriteID, err)
+		s.logger.Error("Failed to record play", "favorite_id", sonosFavoriteID, "error", err)
 		return err
 	}
 
-	s.logger.Printf("Recorded play for %s (set: %v, routine: %v)", sonosFavoriteID, setID, routineID)
+	args := []any{"favorite_id", sonosFavoriteID}
+	if setID != nil {
+		args = append(args, "set_id", *setID)
+	}
+	if routineID != nil {
+		args = append(args, "routine_id", *routineID)
+	}
+	s.logger.Info("Recorded play", args...)
 	return nil
 }
 
@@ -533,7 +896,7 @@ func (s *Service) GetSetEnrichment(setID string) (*MusicSetEnrichment, error) {
 	// Get the first item to extract artwork and service info
 	items, err := s.itemsRepo.GetItems(setID)
 	if err != nil {
-		s.logger.Printf("Failed to get items for set enrichment %s: %v", setID, err)
+		s.logger.Warn("Failed to get items for set enrichment", "set_id", setID, "error", err)
 		return enrichment, nil // Return partial enrichment without items info
 	}
 
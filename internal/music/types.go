@@ -10,6 +10,24 @@ const (
 	SelectionPolicyShuffle  SelectionPolicy = "SHUFFLE"
 )
 
+// NoRepeatScope determines whose plays a no-repeat window counts.
+type NoRepeatScope string
+
+const (
+	NoRepeatScopeSet     NoRepeatScope = "set"     // Plays from the sets being selected from
+	NoRepeatScopeRoutine NoRepeatScope = "routine" // Plays by the same routine, from any set
+	NoRepeatScopeGlobal  NoRepeatScope = "global"  // Every play, whatever played it (the default)
+)
+
+// IsValid reports whether s is a known scope.
+func (s NoRepeatScope) IsValid() bool {
+	switch s {
+	case NoRepeatScopeSet, NoRepeatScopeRoutine, NoRepeatScopeGlobal:
+		return true
+	}
+	return false
+}
+
 // ContentType represents the source type of music content.
 type ContentType string
 
@@ -38,6 +56,7 @@ type MusicSet struct {
 	OccasionStart   *string   `json:"occasion_start,omitempty"` // MM-DD format
 	OccasionEnd     *string   `json:"occasion_end,omitempty"`   // MM-DD format
 	ArtworkURL      *string   `json:"artwork_url,omitempty"`
+	SortOrder       *int      `json:"sort_order,omitempty"` // User-defined list position, nil until reordered
 	ItemCount       int       `json:"item_count,omitempty"` // Computed field
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
@@ -82,6 +101,16 @@ type SelectionResult struct {
 	Item        *SetItem `json:"item"`
 	NextIndex   int      `json:"next_index"`
 	WasShuffled bool     `json:"was_shuffled"`
+
+	// Items passed over because they were played within the no-repeat window
+	RecentlyPlayedSkipped int `json:"recently_played_skipped,omitempty"`
+}
+
+// WeightedSet is one of several music sets to select from, with its relative chance of
+// being picked.
+type WeightedSet struct {
+	SetID  string `json:"set_id"`
+	Weight int    `json:"weight"`
 }
 
 // CreateSetInput contains the input for creating a music set.
@@ -116,6 +145,11 @@ type ReorderItemsInput struct {
 	Items []string `json:"items"` // Ordered list of sonos_favorite_ids
 }
 
+// ReorderSetsInput contains the input for reordering the music set list.
+type ReorderSetsInput struct {
+	SetIDs []string `json:"set_ids"` // Ordered list of set_ids, must include every set exactly once
+}
+
 // PlaySetInput contains the input for playing a music set on a device.
 type PlaySetInput struct {
 	UDN       string `json:"udn"`
@@ -124,9 +158,38 @@ type PlaySetInput struct {
 	QueueMode string `json:"queue_mode,omitempty"`
 }
 
+// CopyItemsInput contains the input for copying items between music sets.
+type CopyItemsInput struct {
+	SourceSetID string `json:"source_set_id"`
+	Positions   []int  `json:"positions,omitempty"` // Source positions; omitted copies every item
+	Move        bool   `json:"move,omitempty"`      // Also remove the items from the source
+}
+
+// CopyItemsResult describes items copied between music sets.
+type CopyItemsResult struct {
+	Copied  []SetItem // As added to the target
+	Skipped []SetItem // Already in the target; as in the source
+}
+
+// PreviewItemInput contains the input for auditioning a set item on a speaker.
+type PreviewItemInput struct {
+	UDN             string `json:"udn"`
+	SpeakerID       string `json:"speaker_id"`
+	DurationSeconds *int   `json:"duration_seconds,omitempty"` // Defaults to DefaultPreviewSeconds
+}
+
 // SelectItemInput contains the input for selecting an item from a music set.
 type SelectItemInput struct {
 	NoRepeatWindowMinutes *int `json:"no_repeat_window_minutes,omitempty"`
+
+	// Whose plays the window counts; empty is global. The routine scope needs RoutineID,
+	// and without one counts the set's plays.
+	NoRepeatScope NoRepeatScope `json:"no_repeat_scope,omitempty"`
+	RoutineID     string        `json:"routine_id,omitempty"`
+
+	// DryRun picks an item without advancing a rotation set's index, for previewing
+	// what a selection would play
+	DryRun bool `json:"-"`
 }
 
 // MusicContent represents content that can be added to a music set.
@@ -0,0 +1,153 @@
+package nowplaying
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// DBPair interface for dependency injection (matches db.DBPair).
+type DBPair interface {
+	Reader() *sql.DB
+	Writer() *sql.DB
+}
+
+// Repository handles database operations for now-playing history.
+// Uses separate reader/writer connections for optimal SQLite concurrency.
+type Repository struct {
+	reader *sql.DB // For SELECT queries
+	writer *sql.DB // For INSERT/UPDATE/DELETE
+}
+
+// NewRepository creates a new now-playing history Repository.
+func NewRepository(dbPair DBPair) *Repository {
+	return &Repository{reader: dbPair.Reader(), writer: dbPair.Writer()}
+}
+
+// Insert records a new history entry.
+func (r *Repository) Insert(input RecordInput) (*HistoryEntry, error) {
+	playedAt := input.PlayedAt
+	if playedAt.IsZero() {
+		playedAt = time.Now()
+	}
+
+	result, err := r.writer.Exec(`
+		INSERT INTO now_playing_history (coordinator_uuid, room_name, title, artist, album, album_art_uri, track_uri, service_name, source, played_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, input.CoordinatorUUID, input.RoomName, input.Title,
+		nullString(input.Artist), nullString(input.Album), nullString(input.AlbumArtURI),
+		nullString(input.TrackURI), nullString(input.ServiceName), nullString(input.Source),
+		playedAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	row := r.reader.QueryRow(selectColumns+` WHERE id = ?`, id)
+	return scanEntry(row)
+}
+
+// LatestForCoordinator returns the most recent entry for a coordinator.
+// Returns nil, nil if the coordinator has no history.
+func (r *Repository) LatestForCoordinator(coordinatorUUID string) (*HistoryEntry, error) {
+	row := r.reader.QueryRow(selectColumns+`
+		WHERE coordinator_uuid = ?
+		ORDER BY played_at DESC, id DESC
+		LIMIT 1
+	`, coordinatorUUID)
+	return scanEntry(row)
+}
+
+// Query returns history entries newest first, optionally filtered by room and start time.
+// Fetches one extra row so callers can report has_more.
+func (r *Repository) Query(query HistoryQuery) ([]HistoryEntry, bool, error) {
+	where := ""
+	args := []any{}
+	if query.Room != "" {
+		where += " AND room_name = ? COLLATE NOCASE"
+		args = append(args, query.Room)
+	}
+	if query.Since != nil {
+		where += " AND played_at >= ?"
+		args = append(args, query.Since.UTC().Format(time.RFC3339))
+	}
+	if where != "" {
+		where = " WHERE" + where[len(" AND"):]
+	}
+
+	args = append(args, query.Limit+1)
+	rows, err := r.reader.Query(selectColumns+where+`
+		ORDER BY played_at DESC, id DESC
+		LIMIT ?
+	`, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	entries := []HistoryEntry{}
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, false, err
+		}
+		entries = append(entries, *entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(entries) > query.Limit
+	if hasMore {
+		entries = entries[:query.Limit]
+	}
+	return entries, hasMore, nil
+}
+
+const selectColumns = `
+	SELECT id, coordinator_uuid, room_name, title, artist, album, album_art_uri, track_uri, service_name, source, played_at
+	FROM now_playing_history`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEntry(row rowScanner) (*HistoryEntry, error) {
+	var (
+		entry                                                 HistoryEntry
+		artist, album, albumArtURI, trackURI, service, source sql.NullString
+		playedAt                                              string
+	)
+	err := row.Scan(&entry.ID, &entry.CoordinatorUUID, &entry.RoomName, &entry.Title,
+		&artist, &album, &albumArtURI, &trackURI, &service, &source, &playedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Artist = nullableString(artist)
+	entry.Album = nullableString(album)
+	entry.AlbumArtURI = nullableString(albumArtURI)
+	entry.TrackURI = nullableString(trackURI)
+	entry.ServiceName = nullableString(service)
+	entry.Source = nullableString(source)
+	entry.PlayedAt, _ = time.Parse(time.RFC3339, playedAt)
+	return &entry, nil
+}
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+func nullableString(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
@@ -0,0 +1,110 @@
+package nowplaying
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/db"
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+func setupTestDB(t *testing.T) *Repository {
+	t.Helper()
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test.db")
+
+	dbPair, err := db.Init(dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+
+	return NewRepository(dbPair)
+}
+
+func TestRepository_InsertAndLatest(t *testing.T) {
+	repo := setupTestDB(t)
+
+	latest, err := repo.LatestForCoordinator("RINCON_1")
+	require.NoError(t, err)
+	require.Nil(t, latest)
+
+	base := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	_, err = repo.Insert(RecordInput{CoordinatorUUID: "RINCON_1", RoomName: "Kitchen", Title: "First", PlayedAt: base})
+	require.NoError(t, err)
+	entry, err := repo.Insert(RecordInput{CoordinatorUUID: "RINCON_1", RoomName: "Kitchen", Title: "Second", Artist: "Band", PlayedAt: base.Add(time.Minute)})
+	require.NoError(t, err)
+	require.Equal(t, "Band", *entry.Artist)
+	require.Nil(t, entry.Album)
+
+	latest, err = repo.LatestForCoordinator("RINCON_1")
+	require.NoError(t, err)
+	require.Equal(t, "Second", latest.Title)
+	require.True(t, base.Add(time.Minute).Equal(latest.PlayedAt))
+}
+
+func TestRepository_Query(t *testing.T) {
+	repo := setupTestDB(t)
+
+	base := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	for i, room := range []string{"Kitchen", "Den", "Kitchen", "Kitchen"} {
+		_, err := repo.Insert(RecordInput{
+			CoordinatorUUID: "RINCON_" + room,
+			RoomName:        room,
+			Title:           "Track",
+			PlayedAt:        base.Add(time.Duration(i) * time.Minute),
+		})
+		require.NoError(t, err)
+	}
+
+	entries, hasMore, err := repo.Query(HistoryQuery{Room: "kitchen", Limit: 10})
+	require.NoError(t, err)
+	require.False(t, hasMore)
+	require.Len(t, entries, 3)
+	require.True(t, entries[0].PlayedAt.After(entries[1].PlayedAt), "newest first")
+
+	since := base.Add(2 * time.Minute)
+	entries, _, err = repo.Query(HistoryQuery{Since: &since, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	entries, hasMore, err = repo.Query(HistoryQuery{Limit: 2})
+	require.NoError(t, err)
+	require.True(t, hasMore)
+	require.Len(t, entries, 2)
+}
+
+func TestRecordInput_SameTrack(t *testing.T) {
+	artist := "Band"
+	uri := "x-sonos-spotify:track1"
+	entry := &HistoryEntry{Title: "Song", Artist: &artist, TrackURI: &uri}
+
+	require.True(t, RecordInput{Title: "Song", Artist: "Band", TrackURI: uri}.sameTrack(entry))
+	require.False(t, RecordInput{Title: "Song", Artist: "Band", TrackURI: "x-sonos-spotify:track2"}.sameTrack(entry))
+	require.False(t, RecordInput{Title: "Song"}.sameTrack(nil))
+}
+
+func TestTrackFromPlayback(t *testing.T) {
+	didl := `<DIDL-Lite xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/"><item><dc:title>Song</dc:title><dc:creator>Band</dc:creator><upnp:albumArtURI>/getaa?u=x</upnp:albumArtURI><upnp:class>object.item.audioItem.musicTrack</upnp:class></item></DIDL-Lite>`
+	result := sonos.HybridGroupResult{
+		Coordinator: sonos.CoordinatorInfo{UUID: "RINCON_1", ZoneName: "Kitchen", IP: "192.168.1.20"},
+		Playback: sonos.HybridPlaybackInfo{GroupPlaybackInfo: sonos.GroupPlaybackInfo{
+			TransportInfo: &soap.TransportInfo{CurrentTransportState: "PLAYING"},
+			PositionInfo:  &soap.PositionInfo{TrackURI: "x-file-cifs://nas/song.mp3", TrackMetaData: didl},
+		}},
+	}
+
+	input, ok := trackFromPlayback(result, time.Now())
+	require.True(t, ok)
+	require.Equal(t, "Song", input.Title)
+	require.Equal(t, "Band", input.Artist)
+	require.Equal(t, "Kitchen", input.RoomName)
+	require.Equal(t, "http://192.168.1.20:1400/getaa?u=x", input.AlbumArtURI)
+
+	result.Playback.TransportInfo.CurrentTransportState = "PAUSED_PLAYBACK"
+	_, ok = trackFromPlayback(result, time.Now())
+	require.False(t, ok)
+}
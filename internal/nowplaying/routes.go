@@ -0,0 +1,81 @@
+package nowplaying
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+)
+
+// Query limits for the history endpoint.
+const (
+	DefaultQueryLimit = 100
+	MaxQueryLimit     = 1000
+)
+
+// RegisterRoutes wires now-playing history routes to the router.
+func RegisterRoutes(router chi.Router, repo *Repository) {
+	router.Method(http.MethodGet, "/v1/sonos/now-playing/history", api.Handler(listHistory(repo)))
+}
+
+// listHistory handles GET /v1/sonos/now-playing/history
+func listHistory(repo *Repository) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		query := HistoryQuery{
+			Room:  r.URL.Query().Get("room"),
+			Limit: DefaultQueryLimit,
+		}
+
+		if since := r.URL.Query().Get("since"); since != "" {
+			parsed, err := time.Parse(time.RFC3339, since)
+			if err != nil {
+				return apperrors.NewValidationError("since must be an RFC3339 timestamp", map[string]any{
+					"since": since,
+				})
+			}
+			query.Since = &parsed
+		}
+
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			limit, err := strconv.Atoi(limitStr)
+			if err != nil || limit < 1 || limit > MaxQueryLimit {
+				return apperrors.NewValidationError("limit must be between 1 and 1000", nil)
+			}
+			query.Limit = limit
+		}
+
+		entries, hasMore, err := repo.Query(query)
+		if err != nil {
+			return apperrors.NewInternalError("Failed to query now-playing history")
+		}
+
+		formatted := make([]map[string]any, 0, len(entries))
+		for i := range entries {
+			formatted = append(formatted, formatEntry(&entries[i]))
+		}
+
+		return api.WriteList(w, "/v1/sonos/now-playing/history", formatted, hasMore)
+	}
+}
+
+// formatEntry formats a HistoryEntry for JSON response.
+func formatEntry(entry *HistoryEntry) map[string]any {
+	return map[string]any{
+		"object":         "now_playing_entry",
+		"id":             entry.ID,
+		"coordinator_id": entry.CoordinatorUUID,
+		"room_name":      entry.RoomName,
+		"title":          entry.Title,
+		"artist":         entry.Artist,
+		"album":          entry.Album,
+		"album_art_uri":  entry.AlbumArtURI,
+		"track_uri":      entry.TrackURI,
+		"service_name":   entry.ServiceName,
+		"source":         entry.Source,
+		"played_at":      api.RFC3339Millis(entry.PlayedAt),
+	}
+}
@@ -0,0 +1,172 @@
+package nowplaying
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
+)
+
+// DefaultSampleInterval is used when no interval is configured.
+const DefaultSampleInterval = 60 * time.Second
+
+// Sampler periodically records what each group coordinator is playing.
+// Consecutive samples of the same track on a coordinator are recorded once.
+type Sampler struct {
+	sonosService *sonos.Service
+	repo         *Repository
+	interval     time.Duration
+	logger       *slog.Logger
+	stopCh       chan struct{}
+	wg           sync.WaitGroup
+	running      bool
+	mu           sync.Mutex
+}
+
+// NewSampler creates a new now-playing sampler.
+func NewSampler(sonosService *sonos.Service, repo *Repository, interval time.Duration, logger *slog.Logger) *Sampler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if interval <= 0 {
+		interval = DefaultSampleInterval
+	}
+
+	return &Sampler{
+		sonosService: sonosService,
+		repo:         repo,
+		interval:     interval,
+		logger:       logger,
+		stopCh:       make(chan struct{}),
+	}
+}
+
+// Start begins periodic sampling in the background.
+func (s *Sampler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return
+	}
+	s.running = true
+
+	s.logger.Info("Starting now-playing sampler", "interval", s.interval)
+	s.wg.Add(1)
+	go s.runLoop()
+}
+
+// Stop stops the background sampler and waits for it to exit.
+func (s *Sampler) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	s.mu.Unlock()
+
+	close(s.stopCh)
+	s.wg.Wait()
+	s.logger.Info("Now-playing sampler stopped")
+}
+
+// runLoop is the background goroutine that samples on each tick.
+func (s *Sampler) runLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			if _, err := s.SampleOnce(); err != nil {
+				s.logger.Warn("Now-playing sample failed", "error", err)
+			}
+		}
+	}
+}
+
+// SampleOnce fetches playback for all groups and records any new tracks.
+// Returns the number of entries recorded.
+func (s *Sampler) SampleOnce() (int, error) {
+	entryIP := s.sonosService.EntryDeviceIP()
+	if entryIP == "" {
+		return 0, nil // Nothing discovered yet
+	}
+
+	zoneState, err := s.sonosService.GetZoneGroupStateCached(entryIP)
+	if err != nil {
+		return 0, fmt.Errorf("fetch zone group state: %w", err)
+	}
+
+	coordinators := sonos.ExtractCoordinators(zoneState, sonos.BuildUUIDToIPMap(zoneState))
+	results, _ := sonos.FetchAllGroupsPlaybackHybrid(s.sonosService, coordinators)
+
+	now := time.Now()
+	recorded := 0
+	for _, result := range results {
+		input, ok := trackFromPlayback(result, now)
+		if !ok {
+			continue
+		}
+
+		latest, err := s.repo.LatestForCoordinator(input.CoordinatorUUID)
+		if err != nil {
+			return recorded, fmt.Errorf("load latest entry: %w", err)
+		}
+		if input.sameTrack(latest) {
+			continue
+		}
+
+		if _, err := s.repo.Insert(input); err != nil {
+			return recorded, fmt.Errorf("record entry: %w", err)
+		}
+		recorded++
+	}
+
+	return recorded, nil
+}
+
+// trackFromPlayback converts a group's playback into a history record.
+// Only actively playing music is recorded; TV input and stopped/paused groups are ignored.
+func trackFromPlayback(result sonos.HybridGroupResult, now time.Time) (RecordInput, bool) {
+	pb := result.Playback
+	if pb.Error != nil || pb.TransportInfo == nil || pb.PositionInfo == nil {
+		return RecordInput{}, false
+	}
+	if pb.TransportInfo.CurrentTransportState != "PLAYING" {
+		return RecordInput{}, false
+	}
+	if pb.MediaInfo != nil && strings.Contains(pb.MediaInfo.CurrentURI, "x-sonos-htastream") {
+		return RecordInput{}, false
+	}
+
+	metadata := sonos.ParseDidlMetadata(pb.PositionInfo.TrackMetaData, pb.PositionInfo.TrackURI)
+	if metadata == nil {
+		return RecordInput{}, false
+	}
+
+	albumArt := metadata.AlbumArtURI
+	if albumArt != "" {
+		albumArt = sonos.NormalizeAlbumArtURI(albumArt, result.Coordinator.IP)
+	}
+
+	return RecordInput{
+		CoordinatorUUID: result.Coordinator.UUID,
+		RoomName:        result.Coordinator.ZoneName,
+		Title:           metadata.Title,
+		Artist:          metadata.Artist,
+		Album:           metadata.Album,
+		AlbumArtURI:     albumArt,
+		TrackURI:        pb.PositionInfo.TrackURI,
+		ServiceName:     metadata.ServiceName,
+		Source:          metadata.Source,
+		PlayedAt:        now,
+	}, true
+}
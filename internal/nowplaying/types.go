@@ -0,0 +1,57 @@
+package nowplaying
+
+import "time"
+
+// HistoryEntry is a single track observed playing on a group coordinator.
+type HistoryEntry struct {
+	ID              int64     `json:"id"`
+	CoordinatorUUID string    `json:"coordinator_uuid"`
+	RoomName        string    `json:"room_name"`
+	Title           string    `json:"title"`
+	Artist          *string   `json:"artist,omitempty"`
+	Album           *string   `json:"album,omitempty"`
+	AlbumArtURI     *string   `json:"album_art_uri,omitempty"`
+	TrackURI        *string   `json:"track_uri,omitempty"`
+	ServiceName     *string   `json:"service_name,omitempty"`
+	Source          *string   `json:"source,omitempty"`
+	PlayedAt        time.Time `json:"played_at"`
+}
+
+// RecordInput contains the fields for recording a history entry.
+type RecordInput struct {
+	CoordinatorUUID string
+	RoomName        string
+	Title           string
+	Artist          string
+	Album           string
+	AlbumArtURI     string
+	TrackURI        string
+	ServiceName     string
+	Source          string
+	PlayedAt        time.Time
+}
+
+// sameTrack reports whether the input describes the same track as an existing entry.
+// Used to dedupe consecutive samples of a track that is still playing.
+func (input RecordInput) sameTrack(entry *HistoryEntry) bool {
+	if entry == nil {
+		return false
+	}
+	return input.Title == entry.Title &&
+		input.Artist == deref(entry.Artist) &&
+		input.TrackURI == deref(entry.TrackURI)
+}
+
+// HistoryQuery contains optional filters for querying history.
+type HistoryQuery struct {
+	Room  string
+	Since *time.Time
+	Limit int
+}
+
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,128 @@
+// Package ratelimit throttles clients that call SOAP-heavy endpoints faster than the
+// speakers can handle, using a token bucket per client IP and route group.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// idleBucketTTL is how long a full bucket is kept before it's dropped, so the map
+// doesn't grow with every client ever seen.
+const idleBucketTTL = 10 * time.Minute
+
+// Stats describes the limiter's configuration and what it has done since start.
+type Stats struct {
+	Enabled           bool
+	RequestsPerSecond int
+	Burst             int
+	TrackedClients    int   // Buckets currently held, one per client IP and route group
+	Allowed           int64 // Requests allowed since start
+	Limited           int64 // Requests rejected since start
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter is a set of token buckets. Each key may make burst requests at once, then
+// perSecond requests a second. State is in-memory only; a restart refills every bucket.
+type Limiter struct {
+	mu        sync.Mutex
+	perSecond int
+	burst     int
+	buckets   map[string]*bucket
+	allowed   int64
+	limited   int64
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+// NewLimiter creates a limiter. A zero or negative perSecond disables it. burst is
+// raised to at least 1.
+func NewLimiter(perSecond, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		perSecond: perSecond,
+		burst:     burst,
+		buckets:   make(map[string]*bucket),
+		now:       time.Now,
+	}
+}
+
+// Enabled reports whether the limiter rejects anything.
+func (l *Limiter) Enabled() bool {
+	return l != nil && l.perSecond > 0
+}
+
+// Allow takes a token from key's bucket. Returns ok=false and the wait until a token is
+// available when the bucket is empty.
+func (l *Limiter) Allow(key string) (time.Duration, bool) {
+	if !l.Enabled() {
+		return 0, true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &bucket{tokens: float64(l.burst), updated: now}
+		l.buckets[key] = b
+	} else {
+		b.tokens = l.refill(b, now)
+		b.updated = now
+	}
+	l.pruneLocked(now)
+
+	if b.tokens < 1 {
+		l.limited++
+		wait := (1 - b.tokens) / float64(l.perSecond)
+		return time.Duration(math.Ceil(wait * float64(time.Second))), false
+	}
+	b.tokens--
+	l.allowed++
+	return 0, true
+}
+
+// Stats returns the limiter's configuration and counters.
+func (l *Limiter) Stats() Stats {
+	if l == nil {
+		return Stats{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return Stats{
+		Enabled:           l.Enabled(),
+		RequestsPerSecond: l.perSecond,
+		Burst:             l.burst,
+		TrackedClients:    len(l.buckets),
+		Allowed:           l.allowed,
+		Limited:           l.limited,
+	}
+}
+
+// refill returns b's tokens at now, capped at burst.
+func (l *Limiter) refill(b *bucket, now time.Time) float64 {
+	tokens := b.tokens + now.Sub(b.updated).Seconds()*float64(l.perSecond)
+	return math.Min(tokens, float64(l.burst))
+}
+
+// pruneLocked drops buckets that have been full and unused for idleBucketTTL. It scans
+// at most once a minute.
+func (l *Limiter) pruneLocked(now time.Time) {
+	if now.Sub(l.lastPrune) < time.Minute {
+		return
+	}
+	l.lastPrune = now
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= idleBucketTTL && l.refill(b, now) >= float64(l.burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
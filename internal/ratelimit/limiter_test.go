@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewLimiter(2, 3)
+	limiter.now = func() time.Time { return now }
+
+	// The burst is available at once, then the bucket refills at 2 a second
+	for i := 0; i < 3; i++ {
+		_, ok := limiter.Allow("client-1")
+		require.True(t, ok)
+	}
+	wait, ok := limiter.Allow("client-1")
+	require.False(t, ok)
+	require.Equal(t, 500*time.Millisecond, wait)
+
+	// Buckets are per key
+	_, ok = limiter.Allow("client-2")
+	require.True(t, ok)
+
+	now = now.Add(500 * time.Millisecond)
+	_, ok = limiter.Allow("client-1")
+	require.True(t, ok)
+	_, ok = limiter.Allow("client-1")
+	require.False(t, ok)
+
+	require.Equal(t, Stats{Enabled: true, RequestsPerSecond: 2, Burst: 3, TrackedClients: 2, Allowed: 5, Limited: 2}, limiter.Stats())
+
+	// Idle, full buckets are dropped
+	now = now.Add(idleBucketTTL)
+	_, ok = limiter.Allow("client-3")
+	require.True(t, ok)
+	require.Equal(t, 1, limiter.Stats().TrackedClients)
+}
+
+func TestLimiter_Disabled(t *testing.T) {
+	limiter := NewLimiter(0, 1)
+	for i := 0; i < 10; i++ {
+		_, ok := limiter.Allow("client-1")
+		require.True(t, ok)
+	}
+	require.False(t, limiter.Stats().Enabled)
+
+	var nilLimiter *Limiter
+	_, ok := nilLimiter.Allow("client-1")
+	require.True(t, ok)
+}
+
+func TestMiddleware(t *testing.T) {
+	limiter := NewLimiter(1, 2)
+	exempt := func(r *http.Request) bool { return r.Header.Get("X-Test-Mode") == "true" }
+	handler := Middleware(limiter, "/v1/sonos/", exempt)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	serve := func(path, remoteAddr string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	require.Equal(t, http.StatusNoContent, serve("/v1/sonos/playback", "10.0.0.1:5000", nil).Code)
+	require.Equal(t, http.StatusNoContent, serve("/v1/sonos/playback/now-playing", "10.0.0.1:5001", nil).Code)
+
+	rec := serve("/v1/sonos/playback", "10.0.0.1:5002", nil)
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "1", rec.Header().Get("Retry-After"))
+	var body struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Equal(t, "RATE_LIMITED", body.Error.Code)
+
+	// Other route groups, other clients, exempt requests and other routes aren't affected
+	require.Equal(t, http.StatusNoContent, serve("/v1/sonos/volume", "10.0.0.1:5003", nil).Code)
+	require.Equal(t, http.StatusNoContent, serve("/v1/sonos/playback", "10.0.0.2:5000", nil).Code)
+	require.Equal(t, http.StatusNoContent, serve("/v1/sonos/playback", "10.0.0.1:5004", map[string]string{"X-Test-Mode": "true"}).Code)
+	require.Equal(t, http.StatusNoContent, serve("/v1/routines", "10.0.0.1:5005", nil).Code)
+	// Forwarded headers can't be used to dodge the limit
+	require.Equal(t, http.StatusTooManyRequests, serve("/v1/sonos/playback", "10.0.0.1:5006", map[string]string{"X-Forwarded-For": "10.9.9.9"}).Code)
+}
@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+)
+
+// Middleware rate-limits requests under prefix (e.g. "/v1/sonos/"), per client IP and
+// route group. The group is the path segment after prefix, so polling now-playing
+// doesn't use up the budget for volume changes. Rejected requests get 429 with
+// Retry-After. exempt may be nil; requests it returns true for aren't limited.
+func Middleware(limiter *Limiter, prefix string, exempt func(*http.Request) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !limiter.Enabled() || !strings.HasPrefix(r.URL.Path, prefix) || (exempt != nil && exempt(r)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			group, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, prefix), "/")
+			wait, ok := limiter.Allow(clientIP(r) + " " + group)
+			if ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			retryAfter := max(int(math.Ceil(wait.Seconds())), 1)
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			api.WriteError(w, r, apperrors.NewAppError(apperrors.ErrorCodeRateLimited, "Too many requests; slow down", http.StatusTooManyRequests, map[string]any{
+				"route_group":         group,
+				"retry_after_seconds": retryAfter,
+			}, nil))
+		})
+	}
+}
+
+// clientIP returns the IP of the connection's remote end. Forwarded headers aren't
+// trusted, since any client could set them to dodge its limit.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,193 @@
+// Package retention prunes finished jobs, old play history and long-deleted routines so
+// the database doesn't grow without bound.
+package retention
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Default configuration values
+const (
+	DefaultBatchSize = 500
+	DefaultRunHour   = 3 // Local time, when the hub is usually idle
+)
+
+// Repository deletes rows older than a cutoff in batches (implemented by
+// scheduler.JobsRepository, music.PlayHistoryRepository and scheduler.DeletedRoutinePurger).
+type Repository interface {
+	PruneOlderThan(cutoff time.Time, batchSize int) (int64, error)
+}
+
+// Status describes the retention settings and the most recent prune.
+type Status struct {
+	JobRetentionDays     int
+	HistoryRetentionDays int
+	LastPrunedAt         *time.Time // Nil until the first prune
+	JobsDeleted          int64      // Rows deleted by the last prune
+	HistoryDeleted       int64
+	LastError            string
+
+	// Soft-deleted routines are purged this many days after deletion (0 keeps them)
+	DeletedRoutineRetentionDays int
+	DeletedRoutinesPurged       int64 // Routines purged by the last prune
+}
+
+// Pruner runs nightly, deleting finished jobs older than the job retention window and
+// play history older than the history retention window. A window of 0 days keeps those
+// rows forever.
+type Pruner struct {
+	jobs        Repository
+	history     Repository
+	jobDays     int
+	historyDays int
+	batchSize   int
+
+	deletedRoutines    Repository
+	deletedRoutineDays int
+	logger             *slog.Logger
+	now                func() time.Time
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+
+	mu     sync.RWMutex
+	status Status
+}
+
+// NewPruner creates a pruner. Call Start to schedule nightly runs.
+func NewPruner(jobs, history Repository, jobDays, historyDays int, logger *slog.Logger) *Pruner {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Pruner{
+		jobs:        jobs,
+		history:     history,
+		jobDays:     jobDays,
+		historyDays: historyDays,
+		batchSize:   DefaultBatchSize,
+		logger:      logger,
+		now:         time.Now,
+		stopCh:      make(chan struct{}),
+		status: Status{
+			JobRetentionDays:     jobDays,
+			HistoryRetentionDays: historyDays,
+		},
+	}
+}
+
+// SetDeletedRoutinePurge purges soft-deleted routines once they've been deleted for
+// days. Call before Start.
+func (p *Pruner) SetDeletedRoutinePurge(routines Repository, days int) {
+	p.deletedRoutines = routines
+	p.deletedRoutineDays = days
+	p.status.DeletedRoutineRetentionDays = days
+}
+
+// Start starts the background job, which prunes every night at DefaultRunHour.
+func (p *Pruner) Start() {
+	if p.jobDays <= 0 && p.historyDays <= 0 && !p.purgesDeletedRoutines() {
+		p.logger.Info("Retention pruning disabled")
+		return
+	}
+	p.logger.Info("Starting retention prune job", "job_retention_days", p.jobDays, "history_retention_days", p.historyDays,
+		"deleted_routine_retention_days", p.deletedRoutineDays)
+
+	p.wg.Add(1)
+	go p.run()
+}
+
+// Stop stops the background job, waiting for a prune in progress to finish.
+func (p *Pruner) Stop() {
+	close(p.stopCh)
+	p.wg.Wait()
+}
+
+// Status returns the retention settings and the outcome of the last prune.
+func (p *Pruner) Status() Status {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.status
+}
+
+func (p *Pruner) run() {
+	defer p.wg.Done()
+
+	for {
+		timer := time.NewTimer(time.Until(nextRun(p.now())))
+		select {
+		case <-p.stopCh:
+			timer.Stop()
+			return
+		case <-timer.C:
+			if err := p.Prune(); err != nil {
+				p.logger.Error("Error pruning old jobs and play history", "error", err)
+			}
+		}
+	}
+}
+
+// Prune deletes rows outside the retention windows now and records the outcome in Status.
+// Every table is pruned even if an earlier one fails.
+func (p *Pruner) Prune() error {
+	now := p.now()
+	var jobsDeleted, historyDeleted, routinesPurged int64
+	var errs []error
+
+	if p.jobDays > 0 {
+		count, err := p.jobs.PruneOlderThan(now.AddDate(0, 0, -p.jobDays), p.batchSize)
+		jobsDeleted = count
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if p.historyDays > 0 {
+		count, err := p.history.PruneOlderThan(now.AddDate(0, 0, -p.historyDays), p.batchSize)
+		historyDeleted = count
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if p.purgesDeletedRoutines() {
+		count, err := p.deletedRoutines.PruneOlderThan(now.AddDate(0, 0, -p.deletedRoutineDays), p.batchSize)
+		routinesPurged = count
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	err := errors.Join(errs...)
+
+	if jobsDeleted > 0 || historyDeleted > 0 || routinesPurged > 0 {
+		p.logger.Info("Pruned old jobs and play history", "jobs_deleted", jobsDeleted, "play_history_deleted", historyDeleted,
+			"deleted_routines_purged", routinesPurged)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status.LastPrunedAt = &now
+	p.status.JobsDeleted = jobsDeleted
+	p.status.HistoryDeleted = historyDeleted
+	p.status.DeletedRoutinesPurged = routinesPurged
+	p.status.LastError = ""
+	if err != nil {
+		p.status.LastError = err.Error()
+	}
+	return err
+}
+
+// purgesDeletedRoutines reports whether soft-deleted routines are purged.
+func (p *Pruner) purgesDeletedRoutines() bool {
+	return p.deletedRoutines != nil && p.deletedRoutineDays > 0
+}
+
+// nextRun returns the next DefaultRunHour after now, in now's location.
+func nextRun(now time.Time) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), DefaultRunHour, 0, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
@@ -0,0 +1,96 @@
+package retention
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRepository records the cutoff it was asked to prune before.
+type fakeRepository struct {
+	cutoff  time.Time
+	deleted int64
+	err     error
+	calls   int
+}
+
+func (f *fakeRepository) PruneOlderThan(cutoff time.Time, batchSize int) (int64, error) {
+	f.calls++
+	f.cutoff = cutoff
+	return f.deleted, f.err
+}
+
+func TestPruner_Prune(t *testing.T) {
+	now := time.Date(2026, 3, 10, 3, 0, 0, 0, time.UTC)
+	jobs := &fakeRepository{deleted: 12}
+	history := &fakeRepository{deleted: 40}
+	pruner := NewPruner(jobs, history, 90, 365, nil)
+	pruner.now = func() time.Time { return now }
+
+	status := pruner.Status()
+	require.Nil(t, status.LastPrunedAt)
+	require.Equal(t, 90, status.JobRetentionDays)
+	require.Equal(t, 365, status.HistoryRetentionDays)
+
+	require.NoError(t, pruner.Prune())
+	require.Equal(t, now.AddDate(0, 0, -90), jobs.cutoff)
+	require.Equal(t, now.AddDate(0, 0, -365), history.cutoff)
+
+	status = pruner.Status()
+	require.NotNil(t, status.LastPrunedAt)
+	require.Equal(t, now, *status.LastPrunedAt)
+	require.Equal(t, int64(12), status.JobsDeleted)
+	require.Equal(t, int64(40), status.HistoryDeleted)
+	require.Empty(t, status.LastError)
+}
+
+func TestPruner_Prune_DisabledAndFailing(t *testing.T) {
+	jobs := &fakeRepository{err: errors.New("database is locked")}
+	history := &fakeRepository{deleted: 3}
+	pruner := NewPruner(jobs, history, 30, 0, nil)
+
+	// History is kept forever; the job failure is reported
+	require.Error(t, pruner.Prune())
+	require.Equal(t, 1, jobs.calls)
+	require.Equal(t, 0, history.calls)
+
+	status := pruner.Status()
+	require.NotNil(t, status.LastPrunedAt)
+	require.Equal(t, "database is locked", status.LastError)
+	require.Equal(t, int64(0), status.HistoryDeleted)
+}
+
+func TestPruner_Prune_DeletedRoutines(t *testing.T) {
+	now := time.Date(2026, 3, 10, 3, 0, 0, 0, time.UTC)
+	routines := &fakeRepository{deleted: 2}
+	pruner := NewPruner(&fakeRepository{}, &fakeRepository{}, 0, 0, nil)
+	pruner.now = func() time.Time { return now }
+	pruner.SetDeletedRoutinePurge(routines, 30)
+
+	require.NoError(t, pruner.Prune())
+	require.Equal(t, now.AddDate(0, 0, -30), routines.cutoff)
+
+	status := pruner.Status()
+	require.Equal(t, 30, status.DeletedRoutineRetentionDays)
+	require.Equal(t, int64(2), status.DeletedRoutinesPurged)
+
+	// 0 days keeps deleted routines until they're restored
+	pruner.SetDeletedRoutinePurge(routines, 0)
+	require.NoError(t, pruner.Prune())
+	require.Equal(t, 1, routines.calls)
+}
+
+func TestNextRun(t *testing.T) {
+	loc := time.FixedZone("PST", -8*60*60)
+
+	before := time.Date(2026, 3, 10, 1, 30, 0, 0, loc)
+	require.Equal(t, time.Date(2026, 3, 10, 3, 0, 0, 0, loc), nextRun(before))
+
+	at := time.Date(2026, 3, 10, 3, 0, 0, 0, loc)
+	require.Equal(t, time.Date(2026, 3, 11, 3, 0, 0, 0, loc), nextRun(at))
+
+	after := time.Date(2026, 3, 10, 22, 0, 0, 0, loc)
+	require.Equal(t, time.Date(2026, 3, 11, 3, 0, 0, 0, loc), nextRun(after))
+}
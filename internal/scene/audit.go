@@ -0,0 +1,25 @@
+package scene
+
+import (
+	"net/http"
+
+	"github.com/strefethen/sonos-hub-go/internal/audit"
+)
+
+// recordSceneChange audits a successful scene mutation. before is nil for a create and
+// after is nil for a delete.
+func recordSceneChange(r *http.Request, recorder audit.Recorder, eventType audit.EventType, action, sceneID string, before, after *Scene) {
+	audit.Record(r.Context(), recorder, audit.Change{
+		Type:         eventType,
+		Action:       action,
+		ResourceType: audit.ResourceScene,
+		ResourceID:   sceneID,
+		Before:       before,
+		After:        after,
+	})
+}
+
+// sceneBeforeChange reads a scene for the audit diff of a change about to be made.
+func sceneBeforeChange(service *Service, recorder audit.Recorder, sceneID string) *Scene {
+	return audit.Before(recorder, func() (*Scene, error) { return service.GetScene(sceneID) })
+}
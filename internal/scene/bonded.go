@@ -0,0 +1,62 @@
+package scene
+
+import (
+	"fmt"
+
+	"github.com/strefethen/sonos-hub-go/internal/devices"
+)
+
+// BondedMemberError is returned when a member or its fallback is a bonded satellite,
+// such as a home theater's surround or sub, which can't play on its own.
+type BondedMemberError struct {
+	Field           string // "udn" or "fallback_udn"
+	UDN             string // The bonded satellite
+	PrimaryUDN      string
+	PrimaryRoomName string // Empty when the primary isn't in the topology
+}
+
+func (e *BondedMemberError) Error() string {
+	primary := e.PrimaryUDN
+	if e.PrimaryRoomName != "" {
+		primary = fmt.Sprintf("%s (%s)", e.PrimaryRoomName, e.PrimaryUDN)
+	}
+	return fmt.Sprintf("%s %s is bonded to %s; use %s instead", e.Field, e.UDN, primary, e.PrimaryUDN)
+}
+
+// Details are the error details reported with a validation error.
+func (e *BondedMemberError) Details() map[string]any {
+	return map[string]any{
+		e.Field:             e.UDN,
+		"primary_udn":       e.PrimaryUDN,
+		"primary_room_name": e.PrimaryRoomName,
+	}
+}
+
+// ValidateMemberBonds rejects members and fallbacks that are bonded satellites. A nil
+// topology means devices have not been discovered yet, so nothing is rejected.
+func ValidateMemberBonds(members []SceneMember, topology *devices.DeviceTopology) error {
+	if topology == nil {
+		return nil
+	}
+	for _, member := range members {
+		if err := bondedMemberError(topology, "udn", member.UDN); err != nil {
+			return err
+		}
+		if member.FallbackUDN == "" {
+			continue
+		}
+		if err := bondedMemberError(topology, "fallback_udn", member.FallbackUDN); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bondedMemberError returns a BondedMemberError when udn is a bonded satellite, or nil.
+func bondedMemberError(topology *devices.DeviceTopology, field, udn string) error {
+	primary := topology.BondedPrimary(udn)
+	if primary == nil {
+		return nil
+	}
+	return &BondedMemberError{Field: field, UDN: udn, PrimaryUDN: primary.UDN, PrimaryRoomName: primary.DisplayName()}
+}
@@ -0,0 +1,43 @@
+package scene
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/devices"
+)
+
+func TestValidateMemberBonds(t *testing.T) {
+	topology := &devices.DeviceTopology{
+		Devices: []devices.LogicalDevice{
+			{UDN: "RINCON_ARC", RoomName: "Living Room", PhysicalDevices: []devices.PhysicalDevice{
+				{UDN: "RINCON_ARC"}, {UDN: "RINCON_SUB"},
+			}},
+			{UDN: "RINCON_KITCHEN", RoomName: "Kitchen"},
+			{UDN: "RINCON_SURROUND", RoomName: "Living Room", IsBonded: true, BondedTo: "RINCON_ARC"},
+		},
+	}
+
+	t.Run("primaries are valid", func(t *testing.T) {
+		members := []SceneMember{{UDN: "RINCON_ARC", FallbackUDN: "RINCON_KITCHEN"}}
+		require.NoError(t, ValidateMemberBonds(members, topology))
+		require.NoError(t, ValidateMemberBonds([]SceneMember{{UDN: "RINCON_SUB"}}, nil))
+	})
+
+	t.Run("a satellite in its primary's physical devices is rejected", func(t *testing.T) {
+		err := ValidateMemberBonds([]SceneMember{{UDN: "RINCON_SUB"}}, topology)
+		var bonded *BondedMemberError
+		require.ErrorAs(t, err, &bonded)
+		require.Equal(t, "RINCON_ARC", bonded.PrimaryUDN)
+		require.Equal(t, "udn RINCON_SUB is bonded to Living Room (RINCON_ARC); use RINCON_ARC instead", err.Error())
+	})
+
+	t.Run("a bonded device is rejected as a fallback", func(t *testing.T) {
+		members := []SceneMember{{UDN: "RINCON_KITCHEN", FallbackUDN: "RINCON_SURROUND"}}
+		var bonded *BondedMemberError
+		require.ErrorAs(t, ValidateMemberBonds(members, topology), &bonded)
+		require.Equal(t, "fallback_udn", bonded.Field)
+		require.Equal(t, "Living Room", bonded.PrimaryRoomName)
+	})
+}
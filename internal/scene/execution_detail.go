@@ -0,0 +1,105 @@
+package scene
+
+import "time"
+
+// executionRecord collects the per-member results of an execution as it runs. Execute
+// is sequential, so it needs no locking.
+type executionRecord struct {
+	startedAt time.Time
+	detail    ExecutionDetail
+}
+
+// newExecutionRecord starts a record with every scene member not reached yet, except
+// those the options leave out.
+func newExecutionRecord(scene *Scene, options ExecuteOptions) *executionRecord {
+	excluded := make(map[string]bool, len(options.ExcludeMembers))
+	for _, udn := range options.ExcludeMembers {
+		excluded[udn] = true
+	}
+
+	record := &executionRecord{
+		startedAt: time.Now(),
+		detail:    ExecutionDetail{Members: make([]MemberResult, 0, len(scene.Members))},
+	}
+	for _, member := range scene.Members {
+		status := MemberStatusNotReached
+		if excluded[member.UDN] {
+			status = MemberStatusExcluded
+		}
+		record.detail.Members = append(record.detail.Members, MemberResult{
+			UDN:      member.UDN,
+			RoomName: member.RoomName,
+			Status:   status,
+		})
+	}
+
+	if content := options.MusicContent; content != nil {
+		record.detail.Content = &ExecutionContent{
+			Type:            content.Type,
+			SonosFavoriteID: content.SonosFavoriteID,
+			URI:             content.URI,
+			UsesQueue:       content.UsesQueue,
+			QueueMode:       options.QueueMode,
+		}
+	}
+	return record
+}
+
+// member returns the result for the member with the given UDN, adding one if the
+// scene didn't have it.
+func (r *executionRecord) member(udn string) *MemberResult {
+	for i := range r.detail.Members {
+		if r.detail.Members[i].UDN == udn {
+			return &r.detail.Members[i]
+		}
+	}
+	r.detail.Members = append(r.detail.Members, MemberResult{UDN: udn, Status: MemberStatusNotReached})
+	return &r.detail.Members[len(r.detail.Members)-1]
+}
+
+// useFallbacks records the fallback speakers applyMemberFallbacks stood in for
+// unreachable members.
+func (r *executionRecord) useFallbacks(used []map[string]any) {
+	for _, usage := range used {
+		primaryUDN, _ := usage["primary_udn"].(string)
+		result := r.member(primaryUDN)
+		result.UDN, _ = usage["fallback_udn"].(string)
+		result.RoomName, _ = usage["fallback_room_name"].(string)
+		result.PrimaryUDN = primaryUDN
+	}
+}
+
+// finish works out each member's status from its commands and returns the detail to
+// store with the execution.
+func (r *executionRecord) finish() *ExecutionDetail {
+	for i := range r.detail.Members {
+		member := &r.detail.Members[i]
+		if member.Status == MemberStatusExcluded {
+			continue
+		}
+		member.Status = MemberStatusNotReached
+		member.Error = ""
+		for _, command := range []*MemberCommand{member.Group, member.Volume, member.Transport, member.Play} {
+			if command == nil {
+				continue
+			}
+			if !command.Success {
+				member.Status = MemberStatusFailed
+				member.Error = command.Error
+				break
+			}
+			member.Status = MemberStatusOK
+		}
+	}
+	r.detail.DurationMs = time.Since(r.startedAt).Milliseconds()
+	return &r.detail
+}
+
+// memberCommand returns the result of a command started at start that returned err.
+func memberCommand(start time.Time, err error) *MemberCommand {
+	command := &MemberCommand{Success: err == nil, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		command.Error = err.Error()
+	}
+	return command
+}
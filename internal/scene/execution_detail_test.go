@@ -0,0 +1,72 @@
+package scene
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutionRecord(t *testing.T) {
+	scene := &Scene{Members: []SceneMember{
+		{UDN: "RINCON_LIVING", RoomName: "Living Room"},
+		{UDN: "RINCON_KITCHEN", RoomName: "Kitchen"},
+		{UDN: "RINCON_PATIO", RoomName: "Patio"},
+		{UDN: "RINCON_DEN", RoomName: "Den"},
+		{UDN: "RINCON_OFFICE", RoomName: "Office"},
+	}}
+	record := newExecutionRecord(scene, ExecuteOptions{
+		MusicContent:   &MusicContent{Type: "sonos_favorite", SonosFavoriteID: "FV:2/7", URI: "x-rincon-cpcontainer:1006206c", UsesQueue: true},
+		QueueMode:      QueueModeReplaceAndPlay,
+		ExcludeMembers: []string{"RINCON_OFFICE"},
+	})
+
+	record.useFallbacks([]map[string]any{{
+		"primary_udn":        "RINCON_PATIO",
+		"fallback_udn":       "RINCON_GARAGE",
+		"fallback_room_name": "Garage",
+	}})
+	record.member("RINCON_LIVING").Coordinator = true
+
+	start := time.Now()
+	record.member("RINCON_LIVING").Volume = memberCommand(start, nil)
+	record.member("RINCON_LIVING").Transport = memberCommand(start, nil)
+	record.member("RINCON_LIVING").Play = memberCommand(start, nil)
+	record.member("RINCON_LIVING").Warnings = []string{"failed to add to queue: UPnPError 701"}
+	record.member("RINCON_KITCHEN").Group = memberCommand(start, nil)
+	record.member("RINCON_KITCHEN").Volume = memberCommand(start, errors.New("connection refused"))
+	record.member("RINCON_GARAGE").Group = memberCommand(start, nil)
+
+	detail := record.finish()
+	require.Len(t, detail.Members, 5)
+
+	living := detail.Members[0]
+	require.True(t, living.Coordinator)
+	require.Equal(t, MemberStatusOK, living.Status, "warnings don't fail a member")
+	require.Empty(t, living.Error)
+	require.Equal(t, []string{"failed to add to queue: UPnPError 701"}, living.Warnings)
+
+	kitchen := detail.Members[1]
+	require.Equal(t, MemberStatusFailed, kitchen.Status)
+	require.Equal(t, "connection refused", kitchen.Error)
+	require.True(t, kitchen.Group.Success)
+	require.False(t, kitchen.Volume.Success)
+
+	garage := detail.Members[2]
+	require.Equal(t, "RINCON_GARAGE", garage.UDN)
+	require.Equal(t, "Garage", garage.RoomName)
+	require.Equal(t, "RINCON_PATIO", garage.PrimaryUDN)
+	require.Equal(t, MemberStatusOK, garage.Status)
+
+	require.Equal(t, MemberStatusNotReached, detail.Members[3].Status)
+	require.Equal(t, MemberStatusExcluded, detail.Members[4].Status)
+
+	require.Equal(t, &ExecutionContent{
+		Type:            "sonos_favorite",
+		SonosFavoriteID: "FV:2/7",
+		URI:             "x-rincon-cpcontainer:1006206c",
+		UsesQueue:       true,
+		QueueMode:       QueueModeReplaceAndPlay,
+	}, detail.Content)
+}
@@ -4,17 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
 	"time"
 
 	"github.com/strefethen/sonos-hub-go/internal/devices"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
 	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
 )
 
 // Executor orchestrates scene execution.
 type Executor struct {
-	logger         *log.Logger
+	logger         *slog.Logger
 	execRepo       *ExecutionsRepository
 	lock           *CoordinatorLock
 	preflight      *PreFlightChecker
@@ -27,7 +28,7 @@ type Executor struct {
 
 // NewExecutor creates a new Executor.
 func NewExecutor(
-	logger *log.Logger,
+	logger *slog.Logger,
 	execRepo *ExecutionsRepository,
 	lock *CoordinatorLock,
 	preflight *PreFlightChecker,
@@ -36,7 +37,7 @@ func NewExecutor(
 	timeout time.Duration,
 ) *Executor {
 	if logger == nil {
-		logger = log.Default()
+		logger = slog.Default()
 	}
 	return &Executor{
 		logger:         logger,
@@ -57,8 +58,12 @@ func NewExecutor(
 	}
 }
 
-// Execute runs a scene execution through all steps.
-func (e *Executor) Execute(scene *Scene, execution *SceneExecution, options ExecuteOptions) (*SceneExecution, error) {
+// Execute runs a scene execution through all steps. Log lines carry ctx's attributes
+// plus the scene and execution IDs.
+func (e *Executor) Execute(ctx context.Context, scene *Scene, execution *SceneExecution, options ExecuteOptions) (*SceneExecution, error) {
+	ctx = logging.With(ctx, "scene_id", scene.SceneID, "scene_execution_id", execution.SceneExecutionID)
+	logger := logging.From(ctx, e.logger)
+	record := newExecutionRecord(scene, options)
 	var coordinatorIP string
 	var coordinatorUDN string
 	var lockAcquired bool
@@ -67,65 +72,103 @@ func (e *Executor) Execute(scene *Scene, execution *SceneExecution, options Exec
 	defer func() {
 		if lockAcquired && coordinatorUDN != "" {
 			e.lock.Unlock(coordinatorUDN)
-			e.updateStep(execution.SceneExecutionID, "release_lock", StepStatusCompleted, nil, nil)
+			e.updateStep(ctx, execution.SceneExecutionID, "release_lock", StepStatusCompleted, nil, nil)
 		}
 	}()
 
-	// Step 1: Determine coordinator
-	e.updateStep(execution.SceneExecutionID, "determine_coordinator", StepStatusRunning, nil, nil)
-	coordinator, err := e.determineCoordinator(scene, options)
+	// Step 1: Determine coordinator (after retargeting unreachable members to fallbacks)
+	e.updateStep(ctx, execution.SceneExecutionID, "determine_coordinator", StepStatusRunning, nil, nil)
+	scene = excludeMembers(scene, options.ExcludeMembers)
+	scene = withStartVolume(scene, options.StartVolume)
+	scene, fallbacksUsed := e.applyMemberFallbacks(ctx, scene)
+	record.useFallbacks(fallbacksUsed)
+	coordinator, err := e.determineCoordinator(ctx, scene, options)
 	if err != nil {
-		e.updateStep(execution.SceneExecutionID, "determine_coordinator", StepStatusFailed, &err, nil)
-		return e.failExecution(execution, err)
+		e.updateStep(ctx, execution.SceneExecutionID, "determine_coordinator", StepStatusFailed, &err, nil)
+		return e.failExecution(ctx, execution, record, err)
 	}
 	coordinatorIP = coordinator.IP
 	coordinatorUDN = coordinator.UDN
+	record.member(coordinatorUDN).Coordinator = true
 	if err := e.execRepo.SetCoordinator(execution.SceneExecutionID, coordinatorUDN); err != nil {
-		e.logger.Printf("Failed to set coordinator: %v", err)
+		logger.Warn("Failed to set coordinator", "error", err)
 	}
-	e.updateStep(execution.SceneExecutionID, "determine_coordinator", StepStatusCompleted, nil, map[string]any{
+	coordinatorDetails := map[string]any{
 		"coordinator_udn": coordinatorUDN,
 		"coordinator_ip":  coordinatorIP,
-	})
+	}
+	if len(fallbacksUsed) > 0 {
+		coordinatorDetails["fallback_used"] = fallbacksUsed
+	}
+	if len(options.ExcludeMembers) > 0 {
+		coordinatorDetails["excluded_members"] = options.ExcludeMembers
+	}
+	e.updateStep(ctx, execution.SceneExecutionID, "determine_coordinator", StepStatusCompleted, nil, coordinatorDetails)
 
 	// Step 2: Acquire lock
-	e.updateStep(execution.SceneExecutionID, "acquire_lock", StepStatusRunning, nil, nil)
+	e.updateStep(ctx, execution.SceneExecutionID, "acquire_lock", StepStatusRunning, nil, nil)
 	if !e.lock.TryLock(coordinatorUDN) {
 		err := fmt.Errorf("coordinator %s is locked by another execution", coordinatorUDN)
-		e.updateStep(execution.SceneExecutionID, "acquire_lock", StepStatusFailed, &err, nil)
-		return e.failExecution(execution, err)
+		e.updateStep(ctx, execution.SceneExecutionID, "acquire_lock", StepStatusFailed, &err, nil)
+		return e.failExecution(ctx, execution, record, err)
 	}
 	lockAcquired = true
-	e.updateStep(execution.SceneExecutionID, "acquire_lock", StepStatusCompleted, nil, nil)
+	e.updateStep(ctx, execution.SceneExecutionID, "acquire_lock", StepStatusCompleted, nil, nil)
 
 	// Step 3: Ensure group
-	e.updateStep(execution.SceneExecutionID, "ensure_group", StepStatusRunning, nil, nil)
-	groupResults := e.ensureGroup(scene, coordinatorIP, coordinatorUDN)
-	e.updateStep(execution.SceneExecutionID, "ensure_group", StepStatusCompleted, nil, map[string]any{
-		"results": groupResults,
-	})
+	groupingMode := GroupingMode(scene.GroupingMode)
+	if groupingMode == "" {
+		groupingMode = GroupingModeGrouped
+	}
+	if groupingMode == GroupingModeIndependent {
+		e.updateStep(ctx, execution.SceneExecutionID, "ensure_group", StepStatusSkipped, nil, map[string]any{
+			"grouping_mode": string(groupingMode),
+		})
+	} else {
+		e.updateStep(ctx, execution.SceneExecutionID, "ensure_group", StepStatusRunning, nil, nil)
+		groupDetails := map[string]any{"grouping_mode": string(groupingMode)}
+		if groupingMode == GroupingModeGroupedThenRestore {
+			// Without a snapshot the scene still groups; it just can't be restored on stop
+			if err := e.captureGroups(ctx, scene, execution.SceneExecutionID, coordinatorIP, coordinatorUDN); err != nil {
+				logger.Warn("Failed to capture groups", "error", err)
+				groupDetails["restore_unavailable"] = err.Error()
+			}
+		}
+		groupDetails["results"] = e.ensureGroup(ctx, scene, coordinatorIP, coordinatorUDN, record)
+		e.updateStep(ctx, execution.SceneExecutionID, "ensure_group", StepStatusCompleted, nil, groupDetails)
+	}
 
 	// Step 4: Apply volume
-	e.updateStep(execution.SceneExecutionID, "apply_volume", StepStatusRunning, nil, nil)
-	volumeResults := e.applyVolume(scene)
-	e.updateStep(execution.SceneExecutionID, "apply_volume", StepStatusCompleted, nil, map[string]any{
+	e.updateStep(ctx, execution.SceneExecutionID, "apply_volume", StepStatusRunning, nil, nil)
+	volumeResults, fades := e.applyVolume(ctx, scene, record)
+	e.updateStep(ctx, execution.SceneExecutionID, "apply_volume", StepStatusCompleted, nil, map[string]any{
 		"results": volumeResults,
 	})
+	// Members that fade in were set to 0; ramp them once playback starts, or restore
+	// their target volume if it never does. Groups are restored right away too.
+	playbackStarted := false
+	defer func() {
+		if !playbackStarted {
+			e.finishFades(ctx, fades)
+			e.RestoreGroups(ctx, execution.SceneExecutionID)
+		}
+	}()
 
 	// Step 5: Pre-flight check
-	e.updateStep(execution.SceneExecutionID, "pre_flight_check", StepStatusRunning, nil, nil)
-	if err := e.runPreFlightWithRecovery(coordinatorIP, coordinator.RoomName, options.TVPolicy); err != nil {
-		e.updateStep(execution.SceneExecutionID, "pre_flight_check", StepStatusFailed, &err, nil)
-		return e.failExecution(execution, err)
+	e.updateStep(ctx, execution.SceneExecutionID, "pre_flight_check", StepStatusRunning, nil, nil)
+	if err := e.runPreFlightWithRecovery(ctx, coordinatorIP, coordinator.RoomName, options.TVPolicy); err != nil {
+		e.updateStep(ctx, execution.SceneExecutionID, "pre_flight_check", StepStatusFailed, &err, nil)
+		return e.failExecution(ctx, execution, record, err)
 	}
-	e.updateStep(execution.SceneExecutionID, "pre_flight_check", StepStatusCompleted, nil, nil)
+	e.updateStep(ctx, execution.SceneExecutionID, "pre_flight_check", StepStatusCompleted, nil, nil)
 
 	// Step 6: Start playback (fire-and-forget with short timeout)
-	e.updateStep(execution.SceneExecutionID, "start_playback", StepStatusRunning, nil, nil)
-	expectedContent, err := e.startPlayback(coordinatorIP, coordinatorUDN, options)
+	e.updateStep(ctx, execution.SceneExecutionID, "start_playback", StepStatusRunning, nil, nil)
+	playbackStart := time.Now()
+	expectedContent, err := e.startPlayback(ctx, coordinatorIP, coordinatorUDN, options, record.member(coordinatorUDN))
 	if err != nil {
-		e.updateStep(execution.SceneExecutionID, "start_playback", StepStatusFailed, &err, nil)
-		return e.failExecution(execution, err)
+		e.updateStep(ctx, execution.SceneExecutionID, "start_playback", StepStatusFailed, &err, nil)
+		return e.failExecution(ctx, execution, record, err)
 	}
 	startPlaybackDetails := map[string]any{}
 	if expectedContent != nil {
@@ -137,12 +180,22 @@ func (e *Executor) Execute(scene *Scene, execution *SceneExecution, options Exec
 			startPlaybackDetails["queue_uri"] = expectedContent.QueueURI
 		}
 	}
-	e.updateStep(execution.SceneExecutionID, "start_playback", StepStatusCompleted, nil, startPlaybackDetails)
+	if groupingMode == GroupingModeIndependent {
+		var coordinatorOffsetMs int64
+		record.member(coordinatorUDN).StartOffsetMs = &coordinatorOffsetMs
+		startPlaybackDetails["members"] = e.startMemberPlayback(ctx, scene, coordinatorUDN, options, record, playbackStart)
+		if scene.StaggerMs > 0 {
+			startPlaybackDetails["stagger_ms"] = staggerInterval(scene.StaggerMs, len(scene.Members)).Milliseconds()
+		}
+	}
+	e.updateStep(ctx, execution.SceneExecutionID, "start_playback", StepStatusCompleted, nil, startPlaybackDetails)
+	e.startFades(ctx, fades)
+	playbackStarted = true
 
 	// Step 7: Verify playback (with monitoring/polling)
-	e.updateStep(execution.SceneExecutionID, "verify_playback", StepStatusRunning, nil, nil)
+	e.updateStep(ctx, execution.SceneExecutionID, "verify_playback", StepStatusRunning, nil, nil)
 	// Create a context with the overall monitoring timeout
-	monitorCtx, monitorCancel := context.WithTimeout(context.Background(), e.monitorConfig.MaxWaitTime+5*time.Second)
+	monitorCtx, monitorCancel := context.WithTimeout(ctx, e.monitorConfig.MaxWaitTime+5*time.Second)
 	defer monitorCancel()
 	verification := e.verifyPlayback(monitorCtx, coordinatorIP, expectedContent)
 
@@ -162,19 +215,20 @@ func (e *Executor) Execute(scene *Scene, execution *SceneExecution, options Exec
 	if verification.DataSource != "" {
 		verifyDetails["data_source"] = verification.DataSource
 	}
-	e.updateStep(execution.SceneExecutionID, "verify_playback", StepStatusCompleted, nil, verifyDetails)
+	e.updateStep(ctx, execution.SceneExecutionID, "verify_playback", StepStatusCompleted, nil, verifyDetails)
 
 	// Complete execution
 	status := ExecutionStatusPlayingConfirmed
 	if !verification.PlaybackConfirmed && !verification.VerificationUnavailable {
 		status = ExecutionStatusFailed
 	}
+	e.saveDetail(ctx, execution.SceneExecutionID, record)
 	if err := e.execRepo.Complete(execution.SceneExecutionID, status, &verification, nil); err != nil {
-		e.logger.Printf("Failed to complete execution: %v", err)
+		logger.Error("Failed to complete execution", "error", err)
 	}
 
 	// Release lock (will be done in defer, but mark step complete)
-	e.updateStep(execution.SceneExecutionID, "release_lock", StepStatusRunning, nil, nil)
+	e.updateStep(ctx, execution.SceneExecutionID, "release_lock", StepStatusRunning, nil, nil)
 	// Lock release happens in defer
 
 	return e.execRepo.GetByID(execution.SceneExecutionID)
@@ -188,7 +242,7 @@ type coordinatorInfo struct {
 }
 
 // determineCoordinator finds the best coordinator for a scene.
-func (e *Executor) determineCoordinator(scene *Scene, options ExecuteOptions) (*coordinatorInfo, error) {
+func (e *Executor) determineCoordinator(ctx context.Context, scene *Scene, options ExecuteOptions) (*coordinatorInfo, error) {
 	if len(scene.Members) == 0 {
 		return nil, fmt.Errorf("scene has no members")
 	}
@@ -206,11 +260,11 @@ func (e *Executor) determineCoordinator(scene *Scene, options ExecuteOptions) (*
 							// Check if it's in TV mode and policy says skip
 							if options.TVPolicy == TVPolicySkip {
 								// Check media info for TV mode
-								ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
-								mediaInfo, err := e.soapClient.GetMediaInfo(ctx, device.IP)
+								mediaCtx, cancel := context.WithTimeout(ctx, e.timeout)
+								mediaInfo, err := e.soapClient.GetMediaInfo(mediaCtx, device.IP)
 								cancel()
 								if err == nil && strings.Contains(mediaInfo.CurrentURI, "x-sonos-htastream") {
-									e.logger.Printf("Skipping Arc %s due to TV mode and SKIP policy", device.RoomName)
+									logging.From(ctx, e.logger).Info("Skipping Arc due to TV mode and SKIP policy", "room", device.RoomName)
 									continue
 								}
 							}
@@ -228,7 +282,7 @@ func (e *Executor) determineCoordinator(scene *Scene, options ExecuteOptions) (*
 
 	// Fallback: use first member
 	firstMember := scene.Members[0]
-	ip, err := e.resolveMemberIP(firstMember)
+	ip, err := e.resolveMemberIP(ctx, firstMember)
 	if err != nil {
 		return nil, err
 	}
@@ -246,7 +300,7 @@ func (e *Executor) determineCoordinator(scene *Scene, options ExecuteOptions) (*
 }
 
 // ensureGroup joins all members to the coordinator.
-func (e *Executor) ensureGroup(scene *Scene, coordinatorIP, coordinatorUDN string) []map[string]any {
+func (e *Executor) ensureGroup(ctx context.Context, scene *Scene, coordinatorIP, coordinatorUDN string, record *executionRecord) []map[string]any {
 	var results []map[string]any
 
 	// coordinatorUDN is already a RINCON_ format UDN
@@ -262,8 +316,10 @@ func (e *Executor) ensureGroup(scene *Scene, coordinatorIP, coordinatorUDN strin
 			continue
 		}
 
-		memberIP, err := e.resolveMemberIP(member)
+		start := time.Now()
+		memberIP, err := e.resolveMemberIP(ctx, member)
 		if err != nil {
+			record.member(member.UDN).Group = memberCommand(start, err)
 			results = append(results, map[string]any{
 				"udn":     member.UDN,
 				"success": false,
@@ -274,9 +330,10 @@ func (e *Executor) ensureGroup(scene *Scene, coordinatorIP, coordinatorUDN strin
 
 		// Join to coordinator group
 		groupURI := fmt.Sprintf("x-rincon:%s", coordinatorUUID)
-		ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
-		err = e.soapClient.SetAVTransportURI(ctx, memberIP, groupURI, "")
+		joinCtx, cancel := context.WithTimeout(ctx, e.timeout)
+		err = e.soapClient.SetAVTransportURI(joinCtx, memberIP, groupURI, "")
 		cancel()
+		record.member(member.UDN).Group = memberCommand(start, err)
 
 		if err != nil {
 			results = append(results, map[string]any{
@@ -295,17 +352,21 @@ func (e *Executor) ensureGroup(scene *Scene, coordinatorIP, coordinatorUDN strin
 	return results
 }
 
-// applyVolume sets target volumes on members.
-func (e *Executor) applyVolume(scene *Scene) []map[string]any {
+// applyVolume sets target volumes on members. Members with a fade-in are set to 0
+// instead and returned as fades to start once playback begins.
+func (e *Executor) applyVolume(ctx context.Context, scene *Scene, record *executionRecord) ([]map[string]any, []memberFade) {
 	var results []map[string]any
+	var fades []memberFade
 
 	for _, member := range scene.Members {
 		if member.TargetVolume == nil {
 			continue
 		}
 
-		memberIP, err := e.resolveMemberIP(member)
+		start := time.Now()
+		memberIP, err := e.resolveMemberIP(ctx, member)
 		if err != nil {
+			record.member(member.UDN).Volume = memberCommand(start, err)
 			results = append(results, map[string]any{
 				"udn":     member.UDN,
 				"success": false,
@@ -314,9 +375,15 @@ func (e *Executor) applyVolume(scene *Scene) []map[string]any {
 			continue
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
-		err = e.soapClient.SetVolume(ctx, memberIP, *member.TargetVolume)
+		volume := *member.TargetVolume
+		if member.fadesIn() {
+			volume = 0
+		}
+
+		volumeCtx, cancel := context.WithTimeout(ctx, e.timeout)
+		err = e.soapClient.SetVolume(volumeCtx, memberIP, volume)
 		cancel()
+		record.member(member.UDN).Volume = memberCommand(start, err)
 
 		if err != nil {
 			results = append(results, map[string]any{
@@ -324,21 +391,33 @@ func (e *Executor) applyVolume(scene *Scene) []map[string]any {
 				"success": false,
 				"error":   err.Error(),
 			})
-		} else {
-			results = append(results, map[string]any{
-				"udn":     member.UDN,
-				"success": true,
-				"volume":  *member.TargetVolume,
+			continue
+		}
+
+		result := map[string]any{
+			"udn":     member.UDN,
+			"success": true,
+			"volume":  *member.TargetVolume,
+		}
+		if member.fadesIn() {
+			result["fade_in_ms"] = *member.FadeInMs
+			fades = append(fades, memberFade{
+				UDN:          member.UDN,
+				IP:           memberIP,
+				TargetVolume: *member.TargetVolume,
+				DurationMs:   *member.FadeInMs,
+				Curve:        member.FadeCurve,
 			})
 		}
+		results = append(results, result)
 	}
 
-	return results
+	return results, fades
 }
 
 // runPreFlightWithRecovery runs preflight check with auto-fix attempts.
-func (e *Executor) runPreFlightWithRecovery(coordinatorIP, roomName string, tvPolicy TVPolicy) error {
-	result, err := e.preflight.Check(coordinatorIP, roomName, 0)
+func (e *Executor) runPreFlightWithRecovery(ctx context.Context, coordinatorIP, roomName string, tvPolicy TVPolicy) error {
+	result, err := e.preflight.Check(ctx, coordinatorIP, roomName, 0)
 	if err != nil {
 		return err
 	}
@@ -353,7 +432,7 @@ func (e *Executor) runPreFlightWithRecovery(coordinatorIP, roomName string, tvPo
 		case TVPolicySkip:
 			return fmt.Errorf("routine skipped: TV mode active on %s", roomName)
 		case TVPolicyUseFallback:
-			e.logger.Printf("TV mode on %s, fallback not yet implemented", roomName)
+			logging.From(ctx, e.logger).Warn("TV mode active, fallback not yet implemented", "room", roomName)
 			// For now, proceed with auto-fix attempt
 		case TVPolicyAlwaysPlay:
 			// Proceed with auto-fix
@@ -362,9 +441,9 @@ func (e *Executor) runPreFlightWithRecovery(coordinatorIP, roomName string, tvPo
 
 	// Attempt auto-fix if possible
 	if result.Issue != nil && result.Issue.AutoFixable {
-		if e.preflight.AttemptAutoFix(result) {
+		if e.preflight.AttemptAutoFix(ctx, result) {
 			// Re-check after fix
-			result, err = e.preflight.Check(coordinatorIP, roomName, 0)
+			result, err = e.preflight.Check(ctx, coordinatorIP, roomName, 0)
 			if err != nil {
 				return err
 			}
@@ -389,84 +468,97 @@ func (e *Executor) runPreFlightWithRecovery(coordinatorIP, roomName string, tvPo
 // IMPORTANT: Each operation gets its own timeout context to prevent slow operations
 // (like AddURIToQueue for podcasts which can take 2-3s) from consuming the timeout
 // for subsequent operations like Play.
-func (e *Executor) startPlayback(coordinatorIP, coordinatorUDN string, options ExecuteOptions) (*ExpectedContent, error) {
+//
+// The transport and play commands' results are recorded on member, errors included
+// even where playback carries on regardless. A failed queue add is only a warning,
+// since setting the transport and playing decide whether the member plays.
+func (e *Executor) startPlayback(ctx context.Context, coordinatorIP, coordinatorUDN string, options ExecuteOptions, member *MemberResult) (*ExpectedContent, error) {
+	logger := logging.From(ctx, e.logger)
 	expected := &ExpectedContent{}
 
 	// If music content is provided, set it up first
 	if options.MusicContent != nil && options.MusicContent.URI != "" {
 		expected.URI = options.MusicContent.URI
 		expected.UsesQueue = options.MusicContent.UsesQueue
+		transportStart := time.Now()
 
 		if options.MusicContent.UsesQueue {
 			// Queue-based playback for containers (playlists, albums, podcasts)
-			e.logger.Printf("Using queue-based playback for container content")
+			logger.Info("Using queue-based playback for container content", "ip", coordinatorIP)
 
 			// Clear queue first (best effort - log but continue) - own timeout
-			clearCtx, clearCancel := context.WithTimeout(context.Background(), e.commandTimeout)
+			clearCtx, clearCancel := context.WithTimeout(ctx, e.commandTimeout)
 			if err := e.clearQueueWithRetry(clearCtx, coordinatorIP); err != nil {
-				e.logger.Printf("Warning: failed to clear queue: %v", err)
+				logger.Warn("Failed to clear queue", "error", err)
 			}
 			clearCancel()
 
 			// Add content to queue - own timeout (this is the slow one for podcasts)
 			// timeout OK, device unreachable is fatal
-			addCtx, addCancel := context.WithTimeout(context.Background(), e.commandTimeout)
-			_, err := e.soapClient.AddURIToQueue(addCtx, coordinatorIP,
+			addCtx, addCancel := context.WithTimeout(ctx, e.commandTimeout)
+			_, addErr := e.soapClient.AddURIToQueue(addCtx, coordinatorIP,
 				options.MusicContent.URI, options.MusicContent.Metadata, 0, false)
 			addCancel()
-			if err != nil {
-				if isDeviceUnreachableError(err) {
-					return nil, fmt.Errorf("device unreachable: %w", err)
+			if addErr != nil {
+				if isDeviceUnreachableError(addErr) {
+					member.Transport = memberCommand(transportStart, addErr)
+					return nil, fmt.Errorf("device unreachable: %w", addErr)
 				}
-				if !isTimeoutError(err) {
+				if !isTimeoutError(addErr) {
 					// Log non-timeout errors but continue - will verify via polling
-					e.logger.Printf("Warning: AddURIToQueue error (will verify): %v", err)
+					logger.Warn("AddURIToQueue error (will verify)", "error", addErr)
 				}
+				member.Warnings = append(member.Warnings, "failed to add to queue: "+addErr.Error())
 			}
 
 			// Set transport to the queue - own timeout
 			expected.QueueURI = fmt.Sprintf("x-rincon-queue:%s#0", coordinatorUDN)
-			setCtx, setCancel := context.WithTimeout(context.Background(), e.commandTimeout)
-			err = e.soapClient.SetAVTransportURI(setCtx, coordinatorIP, expected.QueueURI, "")
+			setCtx, setCancel := context.WithTimeout(ctx, e.commandTimeout)
+			err := e.soapClient.SetAVTransportURI(setCtx, coordinatorIP, expected.QueueURI, "")
 			setCancel()
+			member.Transport = memberCommand(transportStart, err)
 			if err != nil && isDeviceUnreachableError(err) {
 				return nil, fmt.Errorf("device unreachable: %w", err)
 			}
 		} else {
 			// Direct playback for tracks and stations
-			e.logger.Printf("Using direct playback for track/station content")
+			logger.Info("Using direct playback for track/station content", "ip", coordinatorIP)
 
 			// Clear queue first if replacing - own timeout
 			if options.QueueMode == QueueModeReplaceAndPlay {
-				clearCtx, clearCancel := context.WithTimeout(context.Background(), e.commandTimeout)
+				clearCtx, clearCancel := context.WithTimeout(ctx, e.commandTimeout)
 				if err := e.clearQueueWithRetry(clearCtx, coordinatorIP); err != nil {
-					e.logger.Printf("Warning: failed to clear queue: %v", err)
+					logger.Warn("Failed to clear queue", "error", err)
 				}
 				clearCancel()
 			}
 
 			// Set the URI directly - own timeout
-			setCtx, setCancel := context.WithTimeout(context.Background(), e.commandTimeout)
+			setCtx, setCancel := context.WithTimeout(ctx, e.commandTimeout)
 			err := e.soapClient.SetAVTransportURI(setCtx, coordinatorIP,
 				options.MusicContent.URI, options.MusicContent.Metadata)
 			setCancel()
+			member.Transport = memberCommand(transportStart, err)
 			if err != nil && isDeviceUnreachableError(err) {
 				return nil, fmt.Errorf("device unreachable: %w", err)
 			}
 		}
 	} else if options.FavoriteID != "" {
 		// Legacy: play favorite by ID (would need to resolve favorite URI)
-		e.logger.Printf("Playing favorite %s (legacy mode)", options.FavoriteID)
+		logger.Info("Playing favorite (legacy mode)", "favorite_id", options.FavoriteID)
 	}
 
 	// Send play command - ALWAYS gets fresh timeout regardless of prior operations
-	playCtx, playCancel := context.WithTimeout(context.Background(), e.commandTimeout)
+	playCtx, playCancel := context.WithTimeout(ctx, e.commandTimeout)
 	defer playCancel()
-	if err := e.soapClient.Play(playCtx, coordinatorIP); err != nil {
+	playStart := time.Now()
+	err := e.soapClient.Play(playCtx, coordinatorIP)
+	member.Play = memberCommand(playStart, err)
+	if err != nil {
 		if isDeviceUnreachableError(err) {
 			return nil, fmt.Errorf("device unreachable: %w", err)
 		}
-		e.logger.Printf("Play command error (will verify): %v", err)
+		logger.Warn("Play command error (will verify)", "error", err)
 	}
 
 	return expected, nil
@@ -478,7 +570,7 @@ func (e *Executor) clearQueueWithRetry(ctx context.Context, ip string) error {
 	if err != nil {
 		// Check for error 800 (invalid state)
 		if strings.Contains(err.Error(), "800") {
-			e.logger.Printf("Got error 800, stopping first then retrying clear")
+			logging.From(ctx, e.logger).Info("Got error 800, stopping first then retrying clear")
 			_ = e.soapClient.Stop(ctx, ip)
 			return e.soapClient.RemoveAllTracksFromQueue(ctx, ip)
 		}
@@ -572,6 +664,7 @@ func (e *Executor) isExpectedContentPlaying(state *PlaybackState, expected *Expe
 // monitorPlayback polls device state until playback is confirmed or failure detected.
 // Accepts a parent context for cancellation (e.g., if HTTP request is cancelled).
 func (e *Executor) monitorPlayback(parentCtx context.Context, coordinatorIP string, expected *ExpectedContent) *PlaybackResult {
+	logger := logging.From(parentCtx, e.logger)
 	result := &PlaybackResult{}
 	startTime := time.Now()
 	pollDelay := e.monitorConfig.InitialPollDelay // 500ms
@@ -610,12 +703,12 @@ func (e *Executor) monitorPlayback(parentCtx context.Context, coordinatorIP stri
 		}
 		result.Attempts++
 
-		ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+		ctx, cancel := context.WithTimeout(parentCtx, e.timeout)
 		state, err := e.fetchPlaybackState(ctx, coordinatorIP)
 		cancel()
 
 		if err != nil {
-			e.logger.Printf("Poll %d: error fetching state: %v", result.Attempts, err)
+			logger.Warn("Playback poll failed", "attempt", result.Attempts, "error", err)
 			if result.Attempts >= 5 {
 				result.FailureReason = FailureReasonDeviceOffline
 				result.FailureMessage = err.Error()
@@ -625,8 +718,8 @@ func (e *Executor) monitorPlayback(parentCtx context.Context, coordinatorIP stri
 		}
 
 		result.FinalState = state
-		e.logger.Printf("Poll %d: state=%s avTransport=%s track=%s",
-			result.Attempts, state.TransportState, state.AVTransportURI, state.TrackURI)
+		logger.Debug("Playback poll",
+			"attempt", result.Attempts, "state", state.TransportState, "av_transport_uri", state.AVTransportURI, "track_uri", state.TrackURI)
 
 		// TV mode check - early exit (isExpectedContentPlaying also checks, but explicit is clearer)
 		if strings.Contains(state.AVTransportURI, "x-sonos-htastream") {
@@ -638,7 +731,7 @@ func (e *Executor) monitorPlayback(parentCtx context.Context, coordinatorIP stri
 		// Success check
 		if e.isExpectedContentPlaying(state, expected) {
 			result.Success = true
-			e.logger.Printf("Playback confirmed after %d polls", result.Attempts)
+			logger.Info("Playback confirmed", "attempts", result.Attempts)
 			return result
 		}
 
@@ -647,7 +740,7 @@ func (e *Executor) monitorPlayback(parentCtx context.Context, coordinatorIP stri
 			stoppedCount = 0 // Reset stopped counter
 			if transitionStart.IsZero() {
 				transitionStart = time.Now()
-				e.logger.Printf("Device transitioning, waiting...")
+				logger.Debug("Device transitioning, waiting")
 			} else if time.Since(transitionStart) > e.monitorConfig.TransitionTimeout {
 				result.FailureReason = FailureReasonStuckTransitioning
 				result.FailureMessage = fmt.Sprintf("stuck transitioning for %v", time.Since(transitionStart))
@@ -673,7 +766,7 @@ func (e *Executor) monitorPlayback(parentCtx context.Context, coordinatorIP stri
 
 		// PAUSED_PLAYBACK - unusual but possible, keep waiting
 		if state.TransportState == "PAUSED_PLAYBACK" {
-			e.logger.Printf("Device paused, waiting for playback...")
+			logger.Debug("Device paused, waiting for playback")
 		}
 	}
 }
@@ -704,7 +797,7 @@ func (e *Executor) verifyPlayback(ctx context.Context, coordinatorIP string, exp
 }
 
 // resolveMemberIP resolves a scene member to an IP address.
-func (e *Executor) resolveMemberIP(member SceneMember) (string, error) {
+func (e *Executor) resolveMemberIP(ctx context.Context, member SceneMember) (string, error) {
 	// Try UDN first (primary identifier)
 	ip, err := e.deviceService.ResolveDeviceIP(member.UDN)
 	if err == nil && ip != "" {
@@ -715,7 +808,7 @@ func (e *Executor) resolveMemberIP(member SceneMember) (string, error) {
 	if member.RoomName != "" {
 		ip, err = e.deviceService.ResolveDeviceIP(member.RoomName)
 		if err == nil && ip != "" {
-			e.logger.Printf("Resolved device via room_name fallback: %s -> %s", member.RoomName, ip)
+			logging.From(ctx, e.logger).Info("Resolved device via room_name fallback", "room", member.RoomName, "ip", ip)
 			return ip, nil
 		}
 	}
@@ -724,7 +817,7 @@ func (e *Executor) resolveMemberIP(member SceneMember) (string, error) {
 }
 
 // updateStep updates a step's status in the execution record.
-func (e *Executor) updateStep(execID, stepName string, status StepStatus, errPtr *error, details map[string]any) {
+func (e *Executor) updateStep(ctx context.Context, execID, stepName string, status StepStatus, errPtr *error, details map[string]any) {
 	now := time.Now().UTC()
 	update := StepUpdate{
 		Status:  &status,
@@ -743,15 +836,23 @@ func (e *Executor) updateStep(execID, stepName string, status StepStatus, errPtr
 	}
 
 	if err := e.execRepo.UpdateStep(execID, stepName, update); err != nil {
-		e.logger.Printf("Failed to update step %s: %v", stepName, err)
+		logging.From(ctx, e.logger).Warn("Failed to update step", "step", stepName, "error", err)
+	}
+}
+
+// saveDetail stores the execution's per-member results.
+func (e *Executor) saveDetail(ctx context.Context, execID string, record *executionRecord) {
+	if err := e.execRepo.SetDetail(execID, record.finish()); err != nil {
+		logging.From(ctx, e.logger).Warn("Failed to save execution detail", "error", err)
 	}
 }
 
 // failExecution marks an execution as failed.
-func (e *Executor) failExecution(execution *SceneExecution, err error) (*SceneExecution, error) {
+func (e *Executor) failExecution(ctx context.Context, execution *SceneExecution, record *executionRecord, err error) (*SceneExecution, error) {
+	e.saveDetail(ctx, execution.SceneExecutionID, record)
 	errMsg := err.Error()
 	if completeErr := e.execRepo.Complete(execution.SceneExecutionID, ExecutionStatusFailed, nil, &errMsg); completeErr != nil {
-		e.logger.Printf("Failed to mark execution as failed: %v", completeErr)
+		logging.From(ctx, e.logger).Error("Failed to mark execution as failed", "error", completeErr)
 	}
 	updated, _ := e.execRepo.GetByID(execution.SceneExecutionID)
 	if updated != nil {
@@ -0,0 +1,97 @@
+package scene
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
+)
+
+// MaxFadeInMs bounds a member's fade-in to ten minutes.
+const MaxFadeInMs = 10 * 60 * 1000
+
+// fadeCurves are the accepted fade_curve values; empty means linear.
+var fadeCurves = map[string]bool{"": true, "linear": true, "ease-in": true, "ease-out": true}
+
+// InvalidFadeError is returned when a member's fade_in_ms or fade_curve cannot be used.
+type InvalidFadeError struct {
+	UDN    string
+	Field  string
+	Reason string
+}
+
+func (e *InvalidFadeError) Error() string {
+	return fmt.Sprintf("invalid %s for member %s: %s", e.Field, e.UDN, e.Reason)
+}
+
+// ValidateMemberFades checks that member fade-ins have a usable duration and curve.
+func ValidateMemberFades(members []SceneMember) error {
+	for _, member := range members {
+		if member.FadeInMs != nil && (*member.FadeInMs < 0 || *member.FadeInMs > MaxFadeInMs) {
+			return &InvalidFadeError{UDN: member.UDN, Field: "fade_in_ms", Reason: fmt.Sprintf("must be between 0 and %d", MaxFadeInMs)}
+		}
+		if !fadeCurves[member.FadeCurve] {
+			return &InvalidFadeError{UDN: member.UDN, Field: "fade_curve", Reason: "must be one of linear, ease-in, ease-out"}
+		}
+	}
+	return nil
+}
+
+// fadesIn reports whether the member starts silent and ramps to its target volume.
+func (m SceneMember) fadesIn() bool {
+	return m.TargetVolume != nil && m.FadeInMs != nil && *m.FadeInMs > 0
+}
+
+// memberFade is a pending fade-in for a member whose volume was set to 0 before playback.
+type memberFade struct {
+	UDN          string
+	IP           string
+	TargetVolume int
+	DurationMs   int
+	Curve        string
+}
+
+// startFades ramps each member to its target volume in the background, so long fades
+// don't hold up playback verification.
+func (e *Executor) startFades(ctx context.Context, fades []memberFade) {
+	for _, fade := range fades {
+		go e.fadeIn(ctx, fade)
+	}
+}
+
+// fadeIn ramps a member from 0 to its target volume using the same steps as the volume
+// ramp endpoint, only sending steps that change the level. Stops at the first failure.
+func (e *Executor) fadeIn(ctx context.Context, fade memberFade) {
+	levels, stepDelay := sonos.VolumeRampSteps(0, fade.TargetVolume, fade.DurationMs, fade.Curve)
+
+	current := 0
+	for step, level := range levels {
+		if level != current {
+			volumeCtx, cancel := context.WithTimeout(ctx, e.commandTimeout)
+			err := e.soapClient.SetVolume(volumeCtx, fade.IP, level)
+			cancel()
+			if err != nil {
+				logging.From(ctx, e.logger).Warn("Fade-in stopped", "udn", fade.UDN, "volume", current, "error", err)
+				return
+			}
+			current = level
+		}
+		if step < len(levels)-1 {
+			time.Sleep(stepDelay)
+		}
+	}
+}
+
+// finishFades sets members straight to their target volume, for executions that fail
+// before playback starts and would otherwise leave them silent.
+func (e *Executor) finishFades(ctx context.Context, fades []memberFade) {
+	for _, fade := range fades {
+		volumeCtx, cancel := context.WithTimeout(ctx, e.commandTimeout)
+		if err := e.soapClient.SetVolume(volumeCtx, fade.IP, fade.TargetVolume); err != nil {
+			logging.From(ctx, e.logger).Warn("Failed to restore volume after failed execution", "udn", fade.UDN, "error", err)
+		}
+		cancel()
+	}
+}
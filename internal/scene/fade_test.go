@@ -0,0 +1,31 @@
+package scene
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateMemberFades(t *testing.T) {
+	require.NoError(t, ValidateMemberFades([]SceneMember{
+		{UDN: "a", TargetVolume: intPtr(30), FadeInMs: intPtr(30000), FadeCurve: "ease-in"},
+		{UDN: "b", TargetVolume: intPtr(20), FadeInMs: intPtr(0)},
+		{UDN: "c"},
+	}))
+
+	err := ValidateMemberFades([]SceneMember{{UDN: "a", FadeInMs: intPtr(MaxFadeInMs + 1)}})
+	var invalid *InvalidFadeError
+	require.ErrorAs(t, err, &invalid)
+	require.Equal(t, "fade_in_ms", invalid.Field)
+
+	err = ValidateMemberFades([]SceneMember{{UDN: "a", FadeInMs: intPtr(1000), FadeCurve: "bounce"}})
+	require.ErrorAs(t, err, &invalid)
+	require.Equal(t, "fade_curve", invalid.Field)
+}
+
+func TestSceneMemberFadesIn(t *testing.T) {
+	require.True(t, SceneMember{TargetVolume: intPtr(30), FadeInMs: intPtr(1000)}.fadesIn())
+	require.False(t, SceneMember{TargetVolume: intPtr(30), FadeInMs: intPtr(0)}.fadesIn())
+	require.False(t, SceneMember{FadeInMs: intPtr(1000)}.fadesIn(), "members without a target volume keep their current volume")
+	require.False(t, SceneMember{TargetVolume: intPtr(30)}.fadesIn())
+}
@@ -0,0 +1,146 @@
+package scene
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/strefethen/sonos-hub-go/internal/devices"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+)
+
+// InvalidFallbackError is returned when a member's fallback_udn cannot be used.
+type InvalidFallbackError struct {
+	UDN         string
+	FallbackUDN string
+	Reason      string
+}
+
+func (e *InvalidFallbackError) Error() string {
+	return fmt.Sprintf("invalid fallback_udn %s for member %s: %s", e.FallbackUDN, e.UDN, e.Reason)
+}
+
+// ValidateMemberFallbacks checks that every member fallback_udn refers to a known device.
+// Members without a fallback are ignored. A nil topology means devices have not been
+// discovered yet, so fallbacks cannot be validated and are rejected.
+func ValidateMemberFallbacks(members []SceneMember, topology *devices.DeviceTopology) error {
+	for _, member := range members {
+		if member.FallbackUDN == "" {
+			continue
+		}
+		if member.FallbackUDN == member.UDN {
+			return &InvalidFallbackError{UDN: member.UDN, FallbackUDN: member.FallbackUDN, Reason: "fallback must differ from the primary speaker"}
+		}
+		if topology == nil {
+			return &InvalidFallbackError{UDN: member.UDN, FallbackUDN: member.FallbackUDN, Reason: "device topology is not yet available"}
+		}
+		if findTopologyDevice(topology, member.FallbackUDN) == nil {
+			return &InvalidFallbackError{UDN: member.UDN, FallbackUDN: member.FallbackUDN, Reason: "device not found"}
+		}
+	}
+	return nil
+}
+
+// findTopologyDevice returns the device with the given UDN, or nil.
+func findTopologyDevice(topology *devices.DeviceTopology, udn string) *devices.LogicalDevice {
+	for i := range topology.Devices {
+		if topology.Devices[i].UDN == udn {
+			return &topology.Devices[i]
+		}
+	}
+	return nil
+}
+
+// applyMemberFallbacks retargets unreachable members to their fallback speakers.
+// Returns the scene to execute (a copy if any member changed) and a record of each
+// fallback that was used. If the fallback is also unreachable the primary is kept so
+// later steps report the original failure.
+func (e *Executor) applyMemberFallbacks(ctx context.Context, scene *Scene) (*Scene, []map[string]any) {
+	var used []map[string]any
+	members := make([]SceneMember, len(scene.Members))
+	copy(members, scene.Members)
+
+	for i, member := range members {
+		if member.FallbackUDN == "" || e.isMemberReachable(ctx, member) {
+			continue
+		}
+
+		fallback := SceneMember{
+			UDN:          member.FallbackUDN,
+			TargetVolume: member.TargetVolume,
+			Mute:         member.Mute,
+			FadeInMs:     member.FadeInMs,
+			FadeCurve:    member.FadeCurve,
+		}
+		if topology := e.deviceService.GetTopologyIfCached(); topology != nil {
+			if device := findTopologyDevice(topology, member.FallbackUDN); device != nil {
+				fallback.RoomName = device.RoomName
+			}
+		}
+
+		if !e.isMemberReachable(ctx, fallback) {
+			logging.From(ctx, e.logger).Warn("Primary and fallback both unreachable", "udn", member.UDN, "fallback_udn", member.FallbackUDN)
+			continue
+		}
+
+		logging.From(ctx, e.logger).Info("Primary unreachable, using fallback", "udn", member.UDN, "fallback_udn", member.FallbackUDN)
+		members[i] = fallback
+		usage := map[string]any{
+			"primary_udn":  member.UDN,
+			"fallback_udn": fallback.UDN,
+		}
+		if fallback.RoomName != "" {
+			usage["fallback_room_name"] = fallback.RoomName
+		}
+		used = append(used, usage)
+	}
+
+	if len(used) == 0 {
+		return scene, nil
+	}
+
+	retargeted := *scene
+	retargeted.Members = members
+	return &retargeted, used
+}
+
+// excludeMembers returns the scene without the given member UDNs (a copy if any were
+// removed), for executions that leave some speakers out.
+func excludeMembers(scene *Scene, udns []string) *Scene {
+	if len(udns) == 0 {
+		return scene
+	}
+	excluded := make(map[string]bool, len(udns))
+	for _, udn := range udns {
+		excluded[udn] = true
+	}
+
+	members := make([]SceneMember, 0, len(scene.Members))
+	for _, member := range scene.Members {
+		if !excluded[member.UDN] {
+			members = append(members, member)
+		}
+	}
+	if len(members) == len(scene.Members) {
+		return scene
+	}
+
+	trimmed := *scene
+	trimmed.Members = members
+	return &trimmed
+}
+
+// isMemberReachable reports whether the member resolves to an IP that answers a
+// transport query within the command timeout.
+func (e *Executor) isMemberReachable(ctx context.Context, member SceneMember) bool {
+	ip, err := e.resolveMemberIP(ctx, member)
+	if err != nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.commandTimeout)
+	defer cancel()
+	if _, err := e.soapClient.GetTransportInfo(ctx, ip); err != nil && isConnectionError(err) {
+		return false
+	}
+	return true
+}
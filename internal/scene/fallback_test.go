@@ -0,0 +1,58 @@
+package scene
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/devices"
+)
+
+func TestValidateMemberFallbacks(t *testing.T) {
+	topology := &devices.DeviceTopology{
+		Devices: []devices.LogicalDevice{
+			{UDN: "RINCON_PRIMARY", RoomName: "Kitchen"},
+			{UDN: "RINCON_BACKUP", RoomName: "Dining Room"},
+		},
+	}
+
+	t.Run("members without fallbacks are valid", func(t *testing.T) {
+		members := []SceneMember{{UDN: "RINCON_PRIMARY"}}
+		require.NoError(t, ValidateMemberFallbacks(members, nil))
+	})
+
+	t.Run("known fallback is valid", func(t *testing.T) {
+		members := []SceneMember{{UDN: "RINCON_PRIMARY", FallbackUDN: "RINCON_BACKUP"}}
+		require.NoError(t, ValidateMemberFallbacks(members, topology))
+	})
+
+	t.Run("unknown fallback is rejected", func(t *testing.T) {
+		members := []SceneMember{{UDN: "RINCON_PRIMARY", FallbackUDN: "RINCON_MISSING"}}
+		err := ValidateMemberFallbacks(members, topology)
+		var invalid *InvalidFallbackError
+		require.ErrorAs(t, err, &invalid)
+		require.Equal(t, "RINCON_MISSING", invalid.FallbackUDN)
+		require.Equal(t, "device not found", invalid.Reason)
+	})
+
+	t.Run("fallback equal to primary is rejected", func(t *testing.T) {
+		members := []SceneMember{{UDN: "RINCON_PRIMARY", FallbackUDN: "RINCON_PRIMARY"}}
+		require.Error(t, ValidateMemberFallbacks(members, topology))
+	})
+
+	t.Run("fallback without topology is rejected", func(t *testing.T) {
+		members := []SceneMember{{UDN: "RINCON_PRIMARY", FallbackUDN: "RINCON_BACKUP"}}
+		require.Error(t, ValidateMemberFallbacks(members, nil))
+	})
+}
+
+func TestExcludeMembers(t *testing.T) {
+	scene := &Scene{Members: []SceneMember{{UDN: "RINCON_ARC"}, {UDN: "RINCON_DEN"}}}
+
+	require.Same(t, scene, excludeMembers(scene, nil))
+	require.Same(t, scene, excludeMembers(scene, []string{"RINCON_OTHER"}))
+
+	trimmed := excludeMembers(scene, []string{"RINCON_ARC"})
+	require.Equal(t, []SceneMember{{UDN: "RINCON_DEN"}}, trimmed.Members)
+	require.Len(t, scene.Members, 2, "the original scene is unchanged")
+}
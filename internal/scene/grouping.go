@@ -0,0 +1,219 @@
+package scene
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// ValidGroupingMode reports whether mode is an accepted grouping_mode; empty means grouped.
+func ValidGroupingMode(mode string) bool {
+	switch GroupingMode(mode) {
+	case "", GroupingModeIndependent, GroupingModeGrouped, GroupingModeGroupedThenRestore:
+		return true
+	}
+	return false
+}
+
+// MaxGroupSnapshotAge is how long after a grouped_then_restore execution stopping its
+// scene still restores the groups from before it. Older layouts are likely stale.
+const MaxGroupSnapshotAge = 24 * time.Hour
+
+// groupSnapshot records which group each scene member belonged to before the scene
+// grouped them, so the layout can be put back when the scene is stopped. It is stored
+// with the execution that grouped them.
+type groupSnapshot struct {
+	CoordinatorUDN string            `json:"coordinator_udn"` // Coordinator the scene grouped members under
+	Previous       map[string]string `json:"previous"`        // Member UDN -> coordinator UDN of its previous group
+}
+
+// groupChange moves a member out of the scene group: to JoinUDN's group, or to a
+// standalone group when JoinUDN is empty.
+type groupChange struct {
+	UDN     string
+	JoinUDN string
+}
+
+// newGroupSnapshot captures the previous group of each member found in the zone group state.
+func newGroupSnapshot(state soap.ZoneGroupState, coordinatorUDN string, members []SceneMember) *groupSnapshot {
+	previous := make(map[string]string)
+	for _, member := range members {
+		for _, group := range state.Groups {
+			for _, zoneMember := range group.Members {
+				if zoneMember.UUID == member.UDN {
+					previous[member.UDN] = group.Coordinator
+				}
+			}
+		}
+	}
+	return &groupSnapshot{CoordinatorUDN: coordinatorUDN, Previous: previous}
+}
+
+// restorePlan orders the changes that put members back in their previous groups.
+// Members that led their own group leave first so others can rejoin them, and the
+// scene coordinator moves last so it doesn't take the scene group with it.
+func (s *groupSnapshot) restorePlan() []groupChange {
+	udns := make([]string, 0, len(s.Previous))
+	for udn := range s.Previous {
+		udns = append(udns, udn)
+	}
+	sort.Strings(udns)
+
+	var leave, join []groupChange
+	var coordinatorChange *groupChange
+	for _, udn := range udns {
+		previous := s.Previous[udn]
+		switch {
+		case udn == s.CoordinatorUDN:
+			if previous != udn {
+				coordinatorChange = &groupChange{UDN: udn, JoinUDN: previous}
+			}
+		case previous == udn:
+			leave = append(leave, groupChange{UDN: udn})
+		case previous != s.CoordinatorUDN:
+			join = append(join, groupChange{UDN: udn, JoinUDN: previous})
+		}
+	}
+
+	plan := append(leave, join...)
+	if coordinatorChange != nil {
+		plan = append(plan, *coordinatorChange)
+	}
+	return plan
+}
+
+// captureGroups snapshots the current groups of the scene's members before they're
+// grouped, and stores it with the execution. When an earlier execution of the scene
+// hasn't been restored yet, its snapshot moves to this one instead, so stopping the
+// scene still restores the layout from before the first execution.
+func (e *Executor) captureGroups(ctx context.Context, scene *Scene, execID, coordinatorIP, coordinatorUDN string) error {
+	previousExecID, err := e.execRepo.LatestGroupSnapshotExecution(scene.SceneID, time.Now().Add(-MaxGroupSnapshotAge))
+	if err != nil {
+		return fmt.Errorf("failed to check for an earlier group snapshot: %w", err)
+	}
+	var snapshot *groupSnapshot
+	if previousExecID != "" && previousExecID != execID {
+		if snapshot, err = e.execRepo.TakeGroupSnapshot(previousExecID); err != nil {
+			return fmt.Errorf("failed to read the earlier group snapshot: %w", err)
+		}
+	}
+
+	if snapshot == nil {
+		stateCtx, cancel := context.WithTimeout(ctx, e.timeout)
+		state, err := e.soapClient.GetZoneGroupState(stateCtx, coordinatorIP)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to get zone group state: %w", err)
+		}
+		snapshot = newGroupSnapshot(state, coordinatorUDN, scene.Members)
+	}
+
+	if err := e.execRepo.SetGroupSnapshot(execID, snapshot); err != nil {
+		return fmt.Errorf("failed to save group snapshot: %w", err)
+	}
+	return nil
+}
+
+// RestoreSceneGroups restores the groups of the scene's most recent grouped_then_restore
+// execution that hasn't been restored, if it started within MaxGroupSnapshotAge.
+// Returns nil when there is nothing to restore.
+func (e *Executor) RestoreSceneGroups(ctx context.Context, sceneID string) []DeviceResult {
+	execID, err := e.execRepo.LatestGroupSnapshotExecution(sceneID, time.Now().Add(-MaxGroupSnapshotAge))
+	if err != nil {
+		logging.From(ctx, e.logger).Warn("Failed to find group snapshot", "scene_id", sceneID, "error", err)
+		return nil
+	}
+	if execID == "" {
+		return nil
+	}
+	return e.RestoreGroups(ctx, execID)
+}
+
+// RestoreGroups puts an execution's members back in the groups captured before it
+// grouped them, then forgets the snapshot. Returns nil when there is nothing to
+// restore. A member that fails to move doesn't stop the others.
+func (e *Executor) RestoreGroups(ctx context.Context, execID string) []DeviceResult {
+	snapshot, err := e.execRepo.TakeGroupSnapshot(execID)
+	if err != nil {
+		logging.From(ctx, e.logger).Warn("Failed to read group snapshot", "scene_execution_id", execID, "error", err)
+		return nil
+	}
+	if snapshot == nil {
+		return nil
+	}
+
+	results := []DeviceResult{}
+	for _, change := range snapshot.restorePlan() {
+		result := DeviceResult{UDN: change.UDN}
+
+		ip, err := e.resolveMemberIP(ctx, SceneMember{UDN: change.UDN})
+		if err == nil {
+			commandCtx, cancel := context.WithTimeout(ctx, e.commandTimeout)
+			if change.JoinUDN == "" {
+				err = e.soapClient.BecomeCoordinatorOfStandaloneGroup(commandCtx, ip)
+			} else {
+				err = e.soapClient.SetAVTransportURI(commandCtx, ip, fmt.Sprintf("x-rincon:%s", change.JoinUDN), "")
+			}
+			cancel()
+		}
+
+		if err != nil {
+			logging.From(ctx, e.logger).Warn("Failed to restore group", "udn", change.UDN, "error", err)
+			result.Error = err.Error()
+		} else {
+			result.Success = true
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// startMemberPlayback starts the content on each non-coordinator member for
+// independent scenes, spacing the starts out by the scene's stagger_ms from
+// playbackStart, when the coordinator started. Failures are reported per member and
+// don't fail the execution.
+func (e *Executor) startMemberPlayback(ctx context.Context, scene *Scene, coordinatorUDN string, options ExecuteOptions, record *executionRecord, playbackStart time.Time) []map[string]any {
+	var results []map[string]any
+	interval := staggerInterval(scene.StaggerMs, len(scene.Members))
+	started := 1 // The coordinator
+
+	for _, member := range scene.Members {
+		if member.UDN == coordinatorUDN {
+			continue
+		}
+
+		result := record.member(member.UDN)
+		err := waitUntil(ctx, playbackStart.Add(interval*time.Duration(started)))
+		started++
+		start := time.Now()
+		var memberIP string
+		if err == nil {
+			offsetMs := start.Sub(playbackStart).Milliseconds()
+			result.StartOffsetMs = &offsetMs
+			memberIP, err = e.resolveMemberIP(ctx, member)
+		}
+		if err == nil {
+			_, err = e.startPlayback(ctx, memberIP, member.UDN, options, result)
+		} else {
+			result.Play = memberCommand(start, err)
+		}
+		if err != nil {
+			results = append(results, map[string]any{
+				"udn":     member.UDN,
+				"success": false,
+				"error":   err.Error(),
+			})
+			continue
+		}
+		results = append(results, map[string]any{
+			"udn":     member.UDN,
+			"success": true,
+		})
+	}
+
+	return results
+}
@@ -0,0 +1,91 @@
+package scene
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+func TestValidGroupingMode(t *testing.T) {
+	for _, mode := range []string{"", "independent", "grouped", "grouped_then_restore"} {
+		require.True(t, ValidGroupingMode(mode), mode)
+	}
+	require.False(t, ValidGroupingMode("GROUPED"))
+	require.False(t, ValidGroupingMode("party"))
+}
+
+func TestGroupSnapshotRestorePlan(t *testing.T) {
+	state := soap.ZoneGroupState{Groups: []soap.ZoneGroup{
+		{Coordinator: "RINCON_LIVING", Members: []soap.ZoneMember{{UUID: "RINCON_LIVING"}, {UUID: "RINCON_KITCHEN"}}},
+		{Coordinator: "RINCON_DEN", Members: []soap.ZoneMember{{UUID: "RINCON_DEN"}, {UUID: "RINCON_OFFICE"}}},
+		{Coordinator: "RINCON_BEDROOM", Members: []soap.ZoneMember{{UUID: "RINCON_BEDROOM"}}},
+	}}
+	members := []SceneMember{
+		{UDN: "RINCON_LIVING"},
+		{UDN: "RINCON_KITCHEN"},
+		{UDN: "RINCON_OFFICE"},
+		{UDN: "RINCON_BEDROOM"},
+		{UDN: "RINCON_OFFLINE"},
+	}
+
+	snapshot := newGroupSnapshot(state, "RINCON_LIVING", members)
+	require.Equal(t, map[string]string{
+		"RINCON_LIVING":  "RINCON_LIVING",
+		"RINCON_KITCHEN": "RINCON_LIVING",
+		"RINCON_OFFICE":  "RINCON_DEN",
+		"RINCON_BEDROOM": "RINCON_BEDROOM",
+	}, snapshot.Previous)
+
+	// Kitchen was already with the coordinator and stays; Bedroom leaves before Office rejoins Den
+	require.Equal(t, []groupChange{
+		{UDN: "RINCON_BEDROOM"},
+		{UDN: "RINCON_OFFICE", JoinUDN: "RINCON_DEN"},
+	}, snapshot.restorePlan())
+
+	// A coordinator that was following another group rejoins it last
+	snapshot = newGroupSnapshot(state, "RINCON_OFFICE", members)
+	require.Equal(t, []groupChange{
+		{UDN: "RINCON_BEDROOM"},
+		{UDN: "RINCON_LIVING"},
+		{UDN: "RINCON_KITCHEN", JoinUDN: "RINCON_LIVING"},
+		{UDN: "RINCON_OFFICE", JoinUDN: "RINCON_DEN"},
+	}, snapshot.restorePlan())
+}
+
+func TestExecutor_CaptureGroupsCarriesOverUnrestoredSnapshot(t *testing.T) {
+	scenesRepo, execRepo := setupTestDBWithExec(t)
+	scene, err := scenesRepo.Create(CreateSceneInput{Name: "Dinner", Members: []SceneMember{{UDN: "RINCON_LIVING"}}})
+	require.NoError(t, err)
+	first, err := execRepo.Create(CreateExecutionInput{SceneID: scene.SceneID})
+	require.NoError(t, err)
+	second, err := execRepo.Create(CreateExecutionInput{SceneID: scene.SceneID})
+	require.NoError(t, err)
+
+	snapshot := &groupSnapshot{CoordinatorUDN: "RINCON_LIVING", Previous: map[string]string{"RINCON_LIVING": "RINCON_DEN"}}
+	require.NoError(t, execRepo.SetGroupSnapshot(first.SceneExecutionID, snapshot))
+	latest, err := execRepo.LatestGroupSnapshotExecution(scene.SceneID, time.Now().Add(-MaxGroupSnapshotAge))
+	require.NoError(t, err)
+	require.Equal(t, first.SceneExecutionID, latest)
+	latest, err = execRepo.LatestGroupSnapshotExecution(scene.SceneID, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Empty(t, latest, "stale snapshots aren't restored")
+
+	// Executing again before the first is restored keeps the layout from before it,
+	// without asking the speakers
+	executor := &Executor{execRepo: execRepo}
+	require.NoError(t, executor.captureGroups(context.Background(), scene, second.SceneExecutionID, "", "RINCON_LIVING"))
+
+	taken, err := execRepo.TakeGroupSnapshot(first.SceneExecutionID)
+	require.NoError(t, err)
+	require.Nil(t, taken)
+	taken, err = execRepo.TakeGroupSnapshot(second.SceneExecutionID)
+	require.NoError(t, err)
+	require.Equal(t, snapshot, taken)
+	taken, err = execRepo.TakeGroupSnapshot(second.SceneExecutionID)
+	require.NoError(t, err)
+	require.Nil(t, taken, "a snapshot is only restored once")
+}
@@ -2,7 +2,7 @@ package scene
 
 import (
 	"errors"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
 )
@@ -29,13 +29,13 @@ type deviceMutex struct {
 type CoordinatorLock struct {
 	mu      sync.Mutex
 	mutexes map[string]*deviceMutex
-	logger  *log.Logger
+	logger  *slog.Logger
 }
 
 // NewCoordinatorLock creates a new CoordinatorLock.
-func NewCoordinatorLock(logger *log.Logger) *CoordinatorLock {
+func NewCoordinatorLock(logger *slog.Logger) *CoordinatorLock {
 	if logger == nil {
-		logger = log.Default()
+		logger = slog.Default()
 	}
 	return &CoordinatorLock{
 		mutexes: make(map[string]*deviceMutex),
@@ -77,11 +77,11 @@ func (cl *CoordinatorLock) WithLock(deviceID string, timeout time.Duration, fn f
 	dm.lockTime = time.Now()
 	dm.owner = deviceID
 
-	cl.logger.Printf("Acquired coordinator lock for device %s", deviceID)
+	cl.logger.Debug("Acquired coordinator lock", "device_id", deviceID)
 
 	// Set up auto-release timer as safety net
 	autoRelease := time.AfterFunc(timeout, func() {
-		cl.logger.Printf("Auto-releasing coordinator lock for device %s after timeout", deviceID)
+		cl.logger.Warn("Auto-releasing coordinator lock after timeout", "device_id", deviceID)
 		dm.mu.Unlock()
 		dm.locked = false
 	})
@@ -90,7 +90,7 @@ func (cl *CoordinatorLock) WithLock(deviceID string, timeout time.Duration, fn f
 		autoRelease.Stop()
 		dm.locked = false
 		dm.mu.Unlock()
-		cl.logger.Printf("Released coordinator lock for device %s", deviceID)
+		cl.logger.Debug("Released coordinator lock", "device_id", deviceID)
 	}()
 
 	return fn()
@@ -105,7 +105,7 @@ func (cl *CoordinatorLock) TryLock(deviceID string) bool {
 		dm.locked = true
 		dm.lockTime = time.Now()
 		dm.owner = deviceID
-		cl.logger.Printf("Acquired coordinator lock for device %s (try)", deviceID)
+		cl.logger.Debug("Acquired coordinator lock (try)", "device_id", deviceID)
 		return true
 	}
 	return false
@@ -117,7 +117,7 @@ func (cl *CoordinatorLock) Unlock(deviceID string) {
 	if dm.locked {
 		dm.locked = false
 		dm.mu.Unlock()
-		cl.logger.Printf("Released coordinator lock for device %s (manual)", deviceID)
+		cl.logger.Debug("Released coordinator lock (manual)", "device_id", deviceID)
 	}
 }
 
@@ -4,12 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
 	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
 )
 
@@ -38,13 +39,13 @@ type PreFlightResult struct {
 type PreFlightChecker struct {
 	soapClient *soap.Client
 	timeout    time.Duration
-	logger     *log.Logger
+	logger     *slog.Logger
 }
 
 // NewPreFlightChecker creates a new PreFlightChecker.
-func NewPreFlightChecker(soapClient *soap.Client, timeout time.Duration, logger *log.Logger) *PreFlightChecker {
+func NewPreFlightChecker(soapClient *soap.Client, timeout time.Duration, logger *slog.Logger) *PreFlightChecker {
 	if logger == nil {
-		logger = log.Default()
+		logger = slog.Default()
 	}
 	return &PreFlightChecker{
 		soapClient: soapClient,
@@ -54,8 +55,9 @@ func NewPreFlightChecker(soapClient *soap.Client, timeout time.Duration, logger
 }
 
 // Check performs preflight checks on a device.
-func (pf *PreFlightChecker) Check(deviceIP, roomName string, retryCount int) (*PreFlightResult, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), pf.timeout)
+func (pf *PreFlightChecker) Check(ctx context.Context, deviceIP, roomName string, retryCount int) (*PreFlightResult, error) {
+	parentCtx := ctx
+	ctx, cancel := context.WithTimeout(ctx, pf.timeout)
 	defer cancel()
 
 	// Get transport info - also tests reachability
@@ -128,10 +130,10 @@ func (pf *PreFlightChecker) Check(deviceIP, roomName string, retryCount int) (*P
 	// Check 3: TRANSITIONING - retry with backoff
 	if transportInfo.CurrentTransportState == "TRANSITIONING" {
 		if retryCount < maxPreflightRetries {
-			pf.logger.Printf("Device %s is transitioning, retrying in %v (attempt %d/%d)",
-				roomName, preflightRetryDelay, retryCount+1, maxPreflightRetries)
+			logging.From(ctx, pf.logger).Info("Device is transitioning, retrying",
+				"room", roomName, "delay", preflightRetryDelay, "attempt", retryCount+1, "max_attempts", maxPreflightRetries)
 			time.Sleep(preflightRetryDelay)
-			return pf.Check(deviceIP, roomName, retryCount+1)
+			return pf.Check(parentCtx, deviceIP, roomName, retryCount+1)
 		}
 		return &PreFlightResult{
 			CanProceed: false,
@@ -155,20 +157,20 @@ func (pf *PreFlightChecker) Check(deviceIP, roomName string, retryCount int) (*P
 }
 
 // AttemptAutoFix tries to fix a detected issue.
-func (pf *PreFlightChecker) AttemptAutoFix(result *PreFlightResult) bool {
+func (pf *PreFlightChecker) AttemptAutoFix(ctx context.Context, result *PreFlightResult) bool {
 	if result == nil || result.Issue == nil || !result.Issue.AutoFixable || result.SuggestedFix == nil {
 		return false
 	}
 
-	pf.logger.Printf("Attempting auto-fix for %s issue on %s",
-		result.Issue.Type, result.Issue.RoomName)
+	logger := logging.From(ctx, pf.logger).With("room", result.Issue.RoomName)
+	logger.Info("Attempting auto-fix", "issue", result.Issue.Type)
 
 	if err := result.SuggestedFix(); err != nil {
-		pf.logger.Printf("Auto-fix failed: %v", err)
+		logger.Warn("Auto-fix failed", "error", err)
 		return false
 	}
 
-	pf.logger.Printf("Auto-fix succeeded for %s", result.Issue.RoomName)
+	logger.Info("Auto-fix succeeded")
 	return true
 }
 
@@ -42,6 +42,11 @@ func (r *ScenesRepository) Create(input CreateSceneInput) (*Scene, error) {
 		fallbackPolicy = string(FallbackPolicyPlaybaseIfArcTVActive)
 	}
 
+	groupingMode := input.GroupingMode
+	if groupingMode == "" {
+		groupingMode = string(GroupingModeGrouped)
+	}
+
 	members := input.Members
 	if members == nil {
 		members = []SceneMember{}
@@ -69,9 +74,9 @@ func (r *ScenesRepository) Create(input CreateSceneInput) (*Scene, error) {
 	}
 
 	_, err = r.writer.Exec(`
-		INSERT INTO scenes (scene_id, name, description, coordinator_preference, fallback_policy, members, volume_ramp, teardown, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, sceneID, input.Name, input.Description, coordinatorPref, fallbackPolicy, string(membersJSON), nullableString(volumeRampJSON), nullableString(teardownJSON), now, now)
+		INSERT INTO scenes (scene_id, name, description, coordinator_preference, fallback_policy, members, volume_ramp, teardown, grouping_mode, stagger_ms, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, sceneID, input.Name, input.Description, coordinatorPref, fallbackPolicy, string(membersJSON), nullableString(volumeRampJSON), nullableString(teardownJSON), groupingMode, input.StaggerMs, now, now)
 	if err != nil {
 		return nil, err
 	}
@@ -82,7 +87,7 @@ func (r *ScenesRepository) Create(input CreateSceneInput) (*Scene, error) {
 // GetByID retrieves a scene by ID (excludes soft-deleted scenes).
 func (r *ScenesRepository) GetByID(sceneID string) (*Scene, error) {
 	row := r.reader.QueryRow(`
-		SELECT scene_id, name, description, coordinator_preference, fallback_policy, members, volume_ramp, teardown, created_at, updated_at
+		SELECT scene_id, name, description, coordinator_preference, fallback_policy, members, volume_ramp, teardown, grouping_mode, stagger_ms, created_at, updated_at
 		FROM scenes
 		WHERE scene_id = ? AND deleted_at IS NULL
 	`, sceneID)
@@ -101,7 +106,7 @@ func (r *ScenesRepository) GetByIDIncludingDeleted(sceneID string) (*Scene, bool
 	var createdAt, updatedAt string
 
 	err := r.reader.QueryRow(`
-		SELECT scene_id, name, description, coordinator_preference, fallback_policy, members, volume_ramp, teardown, created_at, updated_at, deleted_at
+		SELECT scene_id, name, description, coordinator_preference, fallback_policy, members, volume_ramp, teardown, grouping_mode, stagger_ms, created_at, updated_at, deleted_at
 		FROM scenes
 		WHERE scene_id = ?
 	`, sceneID).Scan(
@@ -113,6 +118,8 @@ func (r *ScenesRepository) GetByIDIncludingDeleted(sceneID string) (*Scene, bool
 		&membersJSON,
 		&volumeRampJSON,
 		&teardownJSON,
+		&scene.GroupingMode,
+		&scene.StaggerMs,
 		&createdAt,
 		&updatedAt,
 		&deletedAt,
@@ -142,7 +149,7 @@ func (r *ScenesRepository) List(limit, offset int) ([]Scene, int, error) {
 	}
 
 	rows, err := r.reader.Query(`
-		SELECT scene_id, name, description, coordinator_preference, fallback_policy, members, volume_ramp, teardown, created_at, updated_at
+		SELECT scene_id, name, description, coordinator_preference, fallback_policy, members, volume_ramp, teardown, grouping_mode, stagger_ms, created_at, updated_at
 		FROM scenes
 		WHERE deleted_at IS NULL
 		ORDER BY created_at DESC
@@ -173,14 +180,32 @@ func (r *ScenesRepository) List(limit, offset int) ([]Scene, int, error) {
 	return scenes, total, nil
 }
 
+// execer is implemented by *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
 // Update updates a scene.
 func (r *ScenesRepository) Update(sceneID string, input UpdateSceneInput) (*Scene, error) {
+	found, err := r.update(r.writer, sceneID, input)
+	if err != nil || !found {
+		return nil, err
+	}
+	return r.GetByID(sceneID)
+}
+
+// UpdateTx updates a scene as part of tx. Returns false if the scene does not exist.
+func (r *ScenesRepository) UpdateTx(tx *sql.Tx, sceneID string, input UpdateSceneInput) (bool, error) {
+	return r.update(tx, sceneID, input)
+}
+
+func (r *ScenesRepository) update(exec execer, sceneID string, input UpdateSceneInput) (bool, error) {
 	existing, err := r.GetByID(sceneID)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 	if existing == nil {
-		return nil, nil
+		return false, nil
 	}
 
 	name := existing.Name
@@ -218,16 +243,26 @@ func (r *ScenesRepository) Update(sceneID string, input UpdateSceneInput) (*Scen
 		teardown = input.Teardown
 	}
 
+	groupingMode := existing.GroupingMode
+	if input.GroupingMode != nil && *input.GroupingMode != "" {
+		groupingMode = *input.GroupingMode
+	}
+
+	staggerMs := existing.StaggerMs
+	if input.StaggerMs != nil {
+		staggerMs = *input.StaggerMs
+	}
+
 	membersJSON, err := json.Marshal(members)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 
 	var volumeRampJSON []byte
 	if volumeRamp != nil {
 		volumeRampJSON, err = json.Marshal(volumeRamp)
 		if err != nil {
-			return nil, err
+			return false, err
 		}
 	}
 
@@ -235,21 +270,21 @@ func (r *ScenesRepository) Update(sceneID string, input UpdateSceneInput) (*Scen
 	if teardown != nil {
 		teardownJSON, err = json.Marshal(teardown)
 		if err != nil {
-			return nil, err
+			return false, err
 		}
 	}
 
 	now := nowISO()
-	_, err = r.writer.Exec(`
+	_, err = exec.Exec(`
 		UPDATE scenes
-		SET name = ?, description = ?, coordinator_preference = ?, fallback_policy = ?, members = ?, volume_ramp = ?, teardown = ?, updated_at = ?
+		SET name = ?, description = ?, coordinator_preference = ?, fallback_policy = ?, members = ?, volume_ramp = ?, teardown = ?, grouping_mode = ?, stagger_ms = ?, updated_at = ?
 		WHERE scene_id = ?
-	`, name, description, coordinatorPref, fallbackPolicy, string(membersJSON), nullableString(volumeRampJSON), nullableString(teardownJSON), now, sceneID)
+	`, name, description, coordinatorPref, fallbackPolicy, string(membersJSON), nullableString(volumeRampJSON), nullableString(teardownJSON), groupingMode, staggerMs, now, sceneID)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 
-	return r.GetByID(sceneID)
+	return true, nil
 }
 
 // Delete soft-deletes a scene by setting deleted_at timestamp.
@@ -370,6 +405,8 @@ func (r *ScenesRepository) scanScene(row *sql.Row) (*Scene, error) {
 		&membersJSON,
 		&volumeRampJSON,
 		&teardownJSON,
+		&scene.GroupingMode,
+		&scene.StaggerMs,
 		&createdAt,
 		&updatedAt,
 	)
@@ -400,6 +437,8 @@ func (r *ScenesRepository) scanSceneRows(rows *sql.Rows) (*Scene, error) {
 		&membersJSON,
 		&volumeRampJSON,
 		&teardownJSON,
+		&scene.GroupingMode,
+		&scene.StaggerMs,
 		&createdAt,
 		&updatedAt,
 	)
@@ -485,7 +524,7 @@ func (r *ExecutionsRepository) Create(input CreateExecutionInput) (*SceneExecuti
 // GetByID retrieves an execution by ID.
 func (r *ExecutionsRepository) GetByID(execID string) (*SceneExecution, error) {
 	row := r.reader.QueryRow(`
-		SELECT scene_execution_id, scene_id, idempotency_key, coordinator_used_udn, status, started_at, ended_at, steps, verification, error
+		SELECT scene_execution_id, scene_id, idempotency_key, coordinator_used_udn, status, started_at, ended_at, steps, verification, error, detail
 		FROM scene_executions
 		WHERE scene_execution_id = ?
 	`, execID)
@@ -496,7 +535,7 @@ func (r *ExecutionsRepository) GetByID(execID string) (*SceneExecution, error) {
 // GetByIdempotencyKey retrieves an execution by idempotency key.
 func (r *ExecutionsRepository) GetByIdempotencyKey(key string) (*SceneExecution, error) {
 	row := r.reader.QueryRow(`
-		SELECT scene_execution_id, scene_id, idempotency_key, coordinator_used_udn, status, started_at, ended_at, steps, verification, error
+		SELECT scene_execution_id, scene_id, idempotency_key, coordinator_used_udn, status, started_at, ended_at, steps, verification, error, detail
 		FROM scene_executions
 		WHERE idempotency_key = ?
 	`, key)
@@ -513,7 +552,7 @@ func (r *ExecutionsRepository) ListBySceneID(sceneID string, limit, offset int)
 	}
 
 	rows, err := r.reader.Query(`
-		SELECT scene_execution_id, scene_id, idempotency_key, coordinator_used_udn, status, started_at, ended_at, steps, verification, error
+		SELECT scene_execution_id, scene_id, idempotency_key, coordinator_used_udn, status, started_at, ended_at, steps, verification, error, detail
 		FROM scene_executions
 		WHERE scene_id = ?
 		ORDER BY started_at DESC
@@ -550,11 +589,7 @@ func (r *ExecutionsRepository) UpdateStep(execID, stepName string, update StepUp
 	if err != nil {
 		return err
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		}
-	}()
+	defer tx.Rollback() // No-op if committed
 
 	var stepsJSON string
 	err = tx.QueryRow("SELECT steps FROM scene_executions WHERE scene_execution_id = ?", execID).Scan(&stepsJSON)
@@ -638,6 +673,88 @@ func (r *ExecutionsRepository) Complete(execID string, status ExecutionStatus, v
 	return err
 }
 
+// SetDetail stores the per-member results of an execution.
+func (r *ExecutionsRepository) SetDetail(execID string, detail *ExecutionDetail) error {
+	detailJSON, err := json.Marshal(detail)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.writer.Exec(`
+		UPDATE scene_executions
+		SET detail = ?
+		WHERE scene_execution_id = ?
+	`, string(detailJSON), execID)
+	return err
+}
+
+// SetGroupSnapshot stores the groups to put an execution's members back in.
+func (r *ExecutionsRepository) SetGroupSnapshot(execID string, snapshot *groupSnapshot) error {
+	snapshotJSON, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.writer.Exec(`
+		UPDATE scene_executions
+		SET group_snapshot = ?
+		WHERE scene_execution_id = ?
+	`, string(snapshotJSON), execID)
+	return err
+}
+
+// TakeGroupSnapshot clears and returns an execution's group snapshot, so only one caller
+// restores it. Returns nil when the execution has none.
+func (r *ExecutionsRepository) TakeGroupSnapshot(execID string) (*groupSnapshot, error) {
+	tx, err := r.writer.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var snapshotJSON sql.NullString
+	err = tx.QueryRow(`
+		SELECT group_snapshot FROM scene_executions WHERE scene_execution_id = ?
+	`, execID).Scan(&snapshotJSON)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !snapshotJSON.Valid) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`
+		UPDATE scene_executions SET group_snapshot = NULL WHERE scene_execution_id = ?
+	`, execID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	var snapshot groupSnapshot
+	if err := json.Unmarshal([]byte(snapshotJSON.String), &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// LatestGroupSnapshotExecution returns the ID of the scene's most recent execution that
+// still has a group snapshot and started after since, or "" when there is none.
+func (r *ExecutionsRepository) LatestGroupSnapshotExecution(sceneID string, since time.Time) (string, error) {
+	var execID string
+	err := r.reader.QueryRow(`
+		SELECT scene_execution_id
+		FROM scene_executions
+		WHERE scene_id = ? AND group_snapshot IS NOT NULL AND started_at > ?
+		ORDER BY started_at DESC
+		LIMIT 1
+	`, sceneID, since.UTC().Format(time.RFC3339)).Scan(&execID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return execID, err
+}
+
 func (r *ExecutionsRepository) scanExecution(row *sql.Row) (*SceneExecution, error) {
 	var exec SceneExecution
 	var idempotencyKey sql.NullString
@@ -648,6 +765,7 @@ func (r *ExecutionsRepository) scanExecution(row *sql.Row) (*SceneExecution, err
 	var stepsJSON string
 	var verificationJSON sql.NullString
 	var errorMsg sql.NullString
+	var detailJSON sql.NullString
 
 	err := row.Scan(
 		&exec.SceneExecutionID,
@@ -660,6 +778,7 @@ func (r *ExecutionsRepository) scanExecution(row *sql.Row) (*SceneExecution, err
 		&stepsJSON,
 		&verificationJSON,
 		&errorMsg,
+		&detailJSON,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -668,7 +787,7 @@ func (r *ExecutionsRepository) scanExecution(row *sql.Row) (*SceneExecution, err
 		return nil, err
 	}
 
-	return r.parseExecution(&exec, idempotencyKey, coordinator, status, startedAt, endedAt, stepsJSON, verificationJSON, errorMsg)
+	return r.parseExecution(&exec, idempotencyKey, coordinator, status, startedAt, endedAt, stepsJSON, verificationJSON, errorMsg, detailJSON)
 }
 
 func (r *ExecutionsRepository) scanExecutionRows(rows *sql.Rows) (*SceneExecution, error) {
@@ -681,6 +800,7 @@ func (r *ExecutionsRepository) scanExecutionRows(rows *sql.Rows) (*SceneExecutio
 	var stepsJSON string
 	var verificationJSON sql.NullString
 	var errorMsg sql.NullString
+	var detailJSON sql.NullString
 
 	err := rows.Scan(
 		&exec.SceneExecutionID,
@@ -693,15 +813,16 @@ func (r *ExecutionsRepository) scanExecutionRows(rows *sql.Rows) (*SceneExecutio
 		&stepsJSON,
 		&verificationJSON,
 		&errorMsg,
+		&detailJSON,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	return r.parseExecution(&exec, idempotencyKey, coordinator, status, startedAt, endedAt, stepsJSON, verificationJSON, errorMsg)
+	return r.parseExecution(&exec, idempotencyKey, coordinator, status, startedAt, endedAt, stepsJSON, verificationJSON, errorMsg, detailJSON)
 }
 
-func (r *ExecutionsRepository) parseExecution(exec *SceneExecution, idempotencyKey, coordinator sql.NullString, status, startedAt string, endedAt sql.NullString, stepsJSON string, verificationJSON, errorMsg sql.NullString) (*SceneExecution, error) {
+func (r *ExecutionsRepository) parseExecution(exec *SceneExecution, idempotencyKey, coordinator sql.NullString, status, startedAt string, endedAt sql.NullString, stepsJSON string, verificationJSON, errorMsg, detailJSON sql.NullString) (*SceneExecution, error) {
 	if idempotencyKey.Valid {
 		exec.IdempotencyKey = &idempotencyKey.String
 	}
@@ -741,6 +862,14 @@ func (r *ExecutionsRepository) parseExecution(exec *SceneExecution, idempotencyK
 		exec.Error = &errorMsg.String
 	}
 
+	if detailJSON.Valid && detailJSON.String != "" {
+		var detail ExecutionDetail
+		if err := json.Unmarshal([]byte(detailJSON.String), &detail); err != nil {
+			return nil, err
+		}
+		exec.Detail = &detail
+	}
+
 	return exec, nil
 }
 
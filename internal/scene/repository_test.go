@@ -302,6 +302,34 @@ func TestExecutionsRepository_Complete_WithError(t *testing.T) {
 	require.Equal(t, "device offline", *updated.Error)
 }
 
+func TestExecutionsRepository_SetDetail(t *testing.T) {
+	scenesRepo, execRepo := setupTestDBWithExec(t)
+
+	scene, err := scenesRepo.Create(CreateSceneInput{Name: "Test Scene"})
+	require.NoError(t, err)
+	exec, err := execRepo.Create(CreateExecutionInput{SceneID: scene.SceneID})
+	require.NoError(t, err)
+	require.Nil(t, exec.Detail)
+
+	detail := &ExecutionDetail{
+		Members: []MemberResult{
+			{UDN: "RINCON_A", Coordinator: true, Status: MemberStatusOK, Play: &MemberCommand{Success: true, DurationMs: 40}},
+			{UDN: "RINCON_B", Status: MemberStatusFailed, Volume: &MemberCommand{Error: "connection refused", DurationMs: 3000}, Error: "connection refused"},
+		},
+		Content:    &ExecutionContent{Type: "direct", URI: "x-sonosapi-stream:s123", QueueMode: QueueModeReplaceAndPlay},
+		DurationMs: 3100,
+	}
+	require.NoError(t, execRepo.SetDetail(exec.SceneExecutionID, detail))
+
+	updated, err := execRepo.GetByID(exec.SceneExecutionID)
+	require.NoError(t, err)
+	require.Equal(t, detail, updated.Detail)
+
+	executions, _, err := execRepo.ListBySceneID(scene.SceneID, 10, 0)
+	require.NoError(t, err)
+	require.Equal(t, detail, executions[0].Detail)
+}
+
 func TestExecutionsRepository_ListBySceneID(t *testing.T) {
 	scenesRepo, execRepo := setupTestDBWithExec(t)
 
@@ -321,3 +349,38 @@ func TestExecutionsRepository_ListBySceneID(t *testing.T) {
 	require.Len(t, executions, 3)
 	require.Equal(t, 5, total)
 }
+
+func TestScenesRepository_GroupingMode(t *testing.T) {
+	repo := setupTestDB(t)
+
+	scene, err := repo.Create(CreateSceneInput{Name: "Default"})
+	require.NoError(t, err)
+	require.Equal(t, string(GroupingModeGrouped), scene.GroupingMode)
+
+	independent := string(GroupingModeIndependent)
+	scene, err = repo.Update(scene.SceneID, UpdateSceneInput{GroupingMode: &independent})
+	require.NoError(t, err)
+	require.Equal(t, independent, scene.GroupingMode)
+
+	scene, err = repo.Update(scene.SceneID, UpdateSceneInput{Name: &independent})
+	require.NoError(t, err)
+	require.Equal(t, independent, scene.GroupingMode, "unchanged when omitted")
+}
+
+func TestScenesRepository_StaggerMs(t *testing.T) {
+	repo := setupTestDB(t)
+
+	scene, err := repo.Create(CreateSceneInput{Name: "Default"})
+	require.NoError(t, err)
+	require.Equal(t, 0, scene.StaggerMs)
+
+	stagger := 750
+	scene, err = repo.Update(scene.SceneID, UpdateSceneInput{StaggerMs: &stagger})
+	require.NoError(t, err)
+	require.Equal(t, 750, scene.StaggerMs)
+
+	name := "Renamed"
+	scene, err = repo.Update(scene.SceneID, UpdateSceneInput{Name: &name})
+	require.NoError(t, err)
+	require.Equal(t, 750, scene.StaggerMs, "unchanged when omitted")
+}
@@ -11,16 +11,19 @@ import (
 
 	"github.com/strefethen/sonos-hub-go/internal/api"
 	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/audit"
 )
 
-// RegisterRoutes wires scene routes to the router.
-func RegisterRoutes(router chi.Router, service *Service) {
+// RegisterRoutes wires scene routes to the router. recorder may be nil to skip auditing
+// scene changes.
+func RegisterRoutes(router chi.Router, service *Service, recorder audit.Recorder) {
 	// Scene CRUD
-	router.Method(http.MethodPost, "/v1/scenes", api.Handler(createScene(service)))
+	router.Method(http.MethodPost, "/v1/scenes", api.Handler(createScene(service, recorder)))
 	router.Method(http.MethodGet, "/v1/scenes", api.Handler(listScenes(service)))
 	router.Method(http.MethodGet, "/v1/scenes/{scene_id}", api.Handler(getScene(service)))
-	router.Method(http.MethodPut, "/v1/scenes/{scene_id}", api.Handler(updateScene(service)))
-	router.Method(http.MethodDelete, "/v1/scenes/{scene_id}", api.Handler(deleteScene(service)))
+	router.Method(http.MethodPut, "/v1/scenes/{scene_id}", api.Handler(updateScene(service, recorder)))
+	router.Method(http.MethodDelete, "/v1/scenes/{scene_id}", api.Handler(deleteScene(service, recorder)))
+	router.Method(http.MethodPost, "/v1/scenes/{scene_id}/adjust-volumes", api.Handler(adjustSceneVolumes(service, recorder)))
 
 	// Scene execution
 	router.Method(http.MethodPost, "/v1/scenes/{scene_id}/execute", api.Handler(executeScene(service)))
@@ -28,9 +31,10 @@ func RegisterRoutes(router chi.Router, service *Service) {
 
 	// Executions
 	router.Method(http.MethodGet, "/v1/scenes/{scene_id}/executions", api.Handler(listExecutions(service)))
+	router.Method(http.MethodGet, "/v1/scenes/{scene_id}/executions/{execution_id}", api.Handler(getExecution(service)))
 }
 
-func createScene(service *Service) func(w http.ResponseWriter, r *http.Request) error {
+func createScene(service *Service, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		var input CreateSceneInput
 		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
@@ -40,11 +44,21 @@ func createScene(service *Service) func(w http.ResponseWriter, r *http.Request)
 		if input.Name == "" {
 			return apperrors.NewValidationError("name is required", nil)
 		}
+		if !ValidGroupingMode(input.GroupingMode) {
+			return groupingModeError(input.GroupingMode)
+		}
+		if !ValidStaggerMs(input.StaggerMs) {
+			return staggerMsError(input.StaggerMs)
+		}
+		if err := service.ValidateMembers(input.Members); err != nil {
+			return fallbackValidationError(err)
+		}
 
 		scene, err := service.CreateScene(input)
 		if err != nil {
 			return apperrors.NewInternalError("Failed to create scene")
 		}
+		recordSceneChange(r, recorder, audit.EventSceneCreated, "create", scene.SceneID, nil, scene)
 
 		// Stripe-style: return resource directly
 		return api.WriteResource(w, http.StatusCreated, formatScene(scene))
@@ -100,7 +114,7 @@ func getScene(service *Service) func(w http.ResponseWriter, r *http.Request) err
 	}
 }
 
-func updateScene(service *Service) func(w http.ResponseWriter, r *http.Request) error {
+func updateScene(service *Service, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		sceneID := chi.URLParam(r, "scene_id")
 
@@ -108,7 +122,17 @@ func updateScene(service *Service) func(w http.ResponseWriter, r *http.Request)
 		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 			return apperrors.NewValidationError("invalid request body", nil)
 		}
+		if input.GroupingMode != nil && !ValidGroupingMode(*input.GroupingMode) {
+			return groupingModeError(*input.GroupingMode)
+		}
+		if input.StaggerMs != nil && !ValidStaggerMs(*input.StaggerMs) {
+			return staggerMsError(*input.StaggerMs)
+		}
+		if err := service.ValidateMembers(input.Members); err != nil {
+			return fallbackValidationError(err)
+		}
 
+		before := sceneBeforeChange(service, recorder, sceneID)
 		scene, err := service.UpdateScene(sceneID, input)
 		if err != nil {
 			return apperrors.NewInternalError("Failed to update scene")
@@ -116,16 +140,51 @@ func updateScene(service *Service) func(w http.ResponseWriter, r *http.Request)
 		if scene == nil {
 			return apperrors.NewAppError(apperrors.ErrorCodeSceneNotFound, "Scene not found", 404, map[string]any{"scene_id": sceneID}, nil)
 		}
+		recordSceneChange(r, recorder, audit.EventSceneUpdated, "update", sceneID, before, scene)
 
 		// Stripe-style: return resource directly
 		return api.WriteResource(w, http.StatusOK, formatScene(scene))
 	}
 }
 
-func deleteScene(service *Service) func(w http.ResponseWriter, r *http.Request) error {
+func adjustSceneVolumes(service *Service, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		sceneID := chi.URLParam(r, "scene_id")
 
+		var input AdjustVolumesInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			return apperrors.NewValidationError("invalid request body", nil)
+		}
+		if (input.Delta == nil) == (input.Scale == nil) {
+			return apperrors.NewValidationError("exactly one of delta or scale is required", nil)
+		}
+		if input.Delta != nil && (*input.Delta < -100 || *input.Delta > 100) {
+			return apperrors.NewValidationError("delta must be between -100 and 100", map[string]any{"delta": *input.Delta})
+		}
+		if input.Scale != nil && (*input.Scale < 0 || *input.Scale > 10) {
+			return apperrors.NewValidationError("scale must be between 0 and 10", map[string]any{"scale": *input.Scale})
+		}
+
+		before := sceneBeforeChange(service, recorder, sceneID)
+		scene, err := service.AdjustVolumes(sceneID, input)
+		if err != nil {
+			return apperrors.NewInternalError("Failed to adjust scene volumes")
+		}
+		if scene == nil {
+			return apperrors.NewAppError(apperrors.ErrorCodeSceneNotFound, "Scene not found", 404, map[string]any{"scene_id": sceneID}, nil)
+		}
+		recordSceneChange(r, recorder, audit.EventSceneUpdated, "adjust_volumes", sceneID, before, scene)
+
+		// Stripe-style: return resource directly
+		return api.WriteResource(w, http.StatusOK, formatScene(scene))
+	}
+}
+
+func deleteScene(service *Service, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		sceneID := chi.URLParam(r, "scene_id")
+
+		before := sceneBeforeChange(service, recorder, sceneID)
 		err := service.DeleteScene(sceneID)
 		if err != nil {
 			var inUseErr *SceneInUseError
@@ -141,6 +200,7 @@ func deleteScene(service *Service) func(w http.ResponseWriter, r *http.Request)
 			}
 			return apperrors.NewInternalError("Failed to delete scene")
 		}
+		recordSceneChange(r, recorder, audit.EventSceneDeleted, "delete", sceneID, before, nil)
 
 		// Return 204 No Content with empty body (Node.js parity)
 		w.WriteHeader(http.StatusNoContent)
@@ -166,7 +226,7 @@ func executeScene(service *Service) func(w http.ResponseWriter, r *http.Request)
 			}
 		}
 
-		execution, err := service.ExecuteScene(sceneID, idempotencyKey, options)
+		execution, err := service.ExecuteScene(r.Context(), sceneID, idempotencyKey, options)
 		if err != nil {
 			var notFoundErr *SceneNotFoundError
 			if errors.As(err, &notFoundErr) {
@@ -218,14 +278,20 @@ func stopScene(service *Service) func(w http.ResponseWriter, r *http.Request) er
 			status = http.StatusMultiStatus // 207
 		}
 
-		// Stripe-style: return action result directly with object type
-		return api.WriteAction(w, status, map[string]any{
+		response := map[string]any{
 			"object":        "scene_stop",
 			"scene_id":      sceneID,
 			"results":       results,
 			"all_succeeded": allSucceeded,
 			"stopped_at":    api.RFC3339Millis(time.Now()),
-		})
+		}
+		// Put grouped_then_restore members back in their previous groups once stopped
+		if restored := service.RestoreGroups(r.Context(), sceneID); restored != nil {
+			response["restored_groups"] = restored
+		}
+
+		// Stripe-style: return action result directly with object type
+		return api.WriteAction(w, status, response)
 	}
 }
 
@@ -263,6 +329,60 @@ func listExecutions(service *Service) func(w http.ResponseWriter, r *http.Reques
 	}
 }
 
+// getExecution returns one execution with its per-member results, so a partial
+// failure can be traced to the speaker that failed.
+func getExecution(service *Service) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		sceneID := chi.URLParam(r, "scene_id")
+		executionID := chi.URLParam(r, "execution_id")
+
+		execution, err := service.GetExecution(executionID)
+		if err != nil {
+			return apperrors.NewInternalError("Failed to get execution")
+		}
+		if execution == nil || execution.SceneID != sceneID {
+			return apperrors.NewNotFoundResource("Scene execution", executionID)
+		}
+
+		result := formatExecution(execution)
+		result["detail"] = formatExecutionDetail(execution.Detail)
+		return api.WriteResource(w, http.StatusOK, result)
+	}
+}
+
+// fallbackValidationError converts a member validation failure to a 400 response.
+func fallbackValidationError(err error) error {
+	if invalid, ok := err.(*InvalidFallbackError); ok {
+		return apperrors.NewValidationError("invalid fallback_udn: "+invalid.Reason, map[string]any{
+			"udn":          invalid.UDN,
+			"fallback_udn": invalid.FallbackUDN,
+		})
+	}
+	if invalid, ok := err.(*InvalidFadeError); ok {
+		return apperrors.NewValidationError(invalid.Field+" "+invalid.Reason, map[string]any{
+			"udn": invalid.UDN,
+		})
+	}
+	if bonded, ok := err.(*BondedMemberError); ok {
+		return apperrors.NewValidationError(bonded.Error(), bonded.Details())
+	}
+	return apperrors.NewInternalError("Failed to validate scene members")
+}
+
+// groupingModeError reports an unsupported grouping_mode as a 400 response.
+func groupingModeError(mode string) error {
+	return apperrors.NewValidationError("grouping_mode must be one of independent, grouped, grouped_then_restore", map[string]any{
+		"grouping_mode": mode,
+	})
+}
+
+// staggerMsError reports an out of range stagger_ms as a 400 response.
+func staggerMsError(ms int) error {
+	return apperrors.NewValidationError("stagger_ms must be between 0 and "+strconv.Itoa(MaxStaggerMs), map[string]any{
+		"stagger_ms": ms,
+	})
+}
+
 func formatScene(scene *Scene) map[string]any {
 	members := make([]map[string]any, 0, len(scene.Members))
 	for _, m := range scene.Members {
@@ -278,6 +398,15 @@ func formatScene(scene *Scene) map[string]any {
 		if m.Mute != nil {
 			member["mute"] = *m.Mute
 		}
+		if m.FallbackUDN != "" {
+			member["fallback_udn"] = m.FallbackUDN
+		}
+		if m.FadeInMs != nil {
+			member["fade_in_ms"] = *m.FadeInMs
+		}
+		if m.FadeCurve != "" {
+			member["fade_curve"] = m.FadeCurve
+		}
 		members = append(members, member)
 	}
 
@@ -288,6 +417,8 @@ func formatScene(scene *Scene) map[string]any {
 		"coordinator_preference": scene.CoordinatorPreference,
 		"fallback_policy":        scene.FallbackPolicy,
 		"members":                members,
+		"grouping_mode":          scene.GroupingMode,
+		"stagger_ms":             scene.StaggerMs,
 		"created_at":             api.RFC3339Millis(scene.CreatedAt),
 		"updated_at":             api.RFC3339Millis(scene.UpdatedAt),
 	}
@@ -331,6 +462,52 @@ func formatScene(scene *Scene) map[string]any {
 	return result
 }
 
+// formatExecutionDetail formats an execution's per-member results. Executions still
+// running, or recorded before results were kept, have none.
+func formatExecutionDetail(detail *ExecutionDetail) map[string]any {
+	if detail == nil {
+		return nil
+	}
+
+	members := make([]map[string]any, 0, len(detail.Members))
+	for _, member := range detail.Members {
+		formatted := map[string]any{
+			"udn":         member.UDN,
+			"room_name":   member.RoomName,
+			"coordinator": member.Coordinator,
+			"status":      string(member.Status),
+			"group":       member.Group,
+			"volume":      member.Volume,
+			"transport":   member.Transport,
+			"play":        member.Play,
+			"error":       nil,
+		}
+		if member.PrimaryUDN != "" {
+			formatted["primary_udn"] = member.PrimaryUDN
+		}
+		if member.Error != "" {
+			formatted["error"] = member.Error
+		}
+		members = append(members, formatted)
+	}
+
+	result := map[string]any{
+		"members":     members,
+		"content":     nil,
+		"duration_ms": detail.DurationMs,
+	}
+	if content := detail.Content; content != nil {
+		result["content"] = map[string]any{
+			"type":              content.Type,
+			"sonos_favorite_id": content.SonosFavoriteID,
+			"uri":               content.URI,
+			"uses_queue":        content.UsesQueue,
+			"queue_mode":        string(content.QueueMode),
+		}
+	}
+	return result
+}
+
 func formatExecution(exec *SceneExecution) map[string]any {
 	steps := make([]map[string]any, 0, len(exec.Steps))
 	for _, s := range exec.Steps {
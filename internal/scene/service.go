@@ -3,19 +3,20 @@ package scene
 import (
 	"context"
 	"database/sql"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/strefethen/sonos-hub-go/internal/config"
 	"github.com/strefethen/sonos-hub-go/internal/devices"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
 	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
 )
 
 // Service provides scene management functionality.
 type Service struct {
 	cfg           config.Config
-	logger        *log.Logger
+	logger        *slog.Logger
 	reader        *sql.DB // For ad-hoc read queries
 	scenesRepo    *ScenesRepository
 	execRepo      *ExecutionsRepository
@@ -31,12 +32,12 @@ type Service struct {
 func NewService(
 	cfg config.Config,
 	dbPair DBPair,
-	logger *log.Logger,
+	logger *slog.Logger,
 	deviceService *devices.Service,
 	soapClient *soap.Client,
 ) *Service {
 	if logger == nil {
-		logger = log.Default()
+		logger = slog.Default()
 	}
 
 	timeout := time.Duration(cfg.SonosTimeoutMs) * time.Millisecond
@@ -66,6 +67,22 @@ func (s *Service) CreateScene(input CreateSceneInput) (*Scene, error) {
 	return s.scenesRepo.Create(input)
 }
 
+// ValidateMembers checks member fade-ins, and speakers and their fallbacks against the
+// cached device topology.
+func (s *Service) ValidateMembers(members []SceneMember) error {
+	if err := ValidateMemberFades(members); err != nil {
+		return err
+	}
+	var topology *devices.DeviceTopology
+	if s.deviceService != nil {
+		topology = s.deviceService.GetTopologyIfCached()
+	}
+	if err := ValidateMemberFallbacks(members, topology); err != nil {
+		return err
+	}
+	return ValidateMemberBonds(members, topology)
+}
+
 // GetScene retrieves a scene by ID.
 func (s *Service) GetScene(sceneID string) (*Scene, error) {
 	return s.scenesRepo.GetByID(sceneID)
@@ -81,6 +98,22 @@ func (s *Service) UpdateScene(sceneID string, input UpdateSceneInput) (*Scene, e
 	return s.scenesRepo.Update(sceneID, input)
 }
 
+// UpdateSceneTx updates a scene as part of tx, so it commits or rolls back together with
+// the caller's other writes. Returns false if the scene does not exist.
+func (s *Service) UpdateSceneTx(tx *sql.Tx, sceneID string, input UpdateSceneInput) (bool, error) {
+	return s.scenesRepo.UpdateTx(tx, sceneID, input)
+}
+
+// AdjustVolumes applies a volume delta or scale to every member's target volume in one update.
+// Returns nil if the scene does not exist.
+func (s *Service) AdjustVolumes(sceneID string, input AdjustVolumesInput) (*Scene, error) {
+	existing, err := s.scenesRepo.GetByID(sceneID)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+	return s.scenesRepo.Update(sceneID, UpdateSceneInput{Members: AdjustMemberVolumes(existing.Members, input)})
+}
+
 // DeleteScene soft-deletes a scene.
 // With soft delete, we no longer need to check for routine references since
 // the scene can be restored if the routine is restored.
@@ -88,6 +121,11 @@ func (s *Service) DeleteScene(sceneID string) error {
 	return s.scenesRepo.Delete(sceneID)
 }
 
+// PurgeScene permanently removes a soft-deleted scene and its executions.
+func (s *Service) PurgeScene(sceneID string) error {
+	return s.scenesRepo.HardDelete(sceneID)
+}
+
 // RestoreScene restores a soft-deleted scene.
 func (s *Service) RestoreScene(sceneID string) (*Scene, error) {
 	return s.scenesRepo.Restore(sceneID)
@@ -95,7 +133,8 @@ func (s *Service) RestoreScene(sceneID string) (*Scene, error) {
 
 // ExecuteScene starts an async execution of a scene.
 // Returns immediately with the execution record; actual execution happens in background.
-func (s *Service) ExecuteScene(sceneID string, idempotencyKey *string, options ExecuteOptions) (*SceneExecution, error) {
+// The execution outlives ctx, but logs with its attributes (request or job IDs).
+func (s *Service) ExecuteScene(ctx context.Context, sceneID string, idempotencyKey *string, options ExecuteOptions) (*SceneExecution, error) {
 	// Check for existing execution with same idempotency key
 	if idempotencyKey != nil && *idempotencyKey != "" {
 		existing, err := s.execRepo.GetByIdempotencyKey(*idempotencyKey)
@@ -103,7 +142,7 @@ func (s *Service) ExecuteScene(sceneID string, idempotencyKey *string, options E
 			return nil, err
 		}
 		if existing != nil {
-			s.logger.Printf("Returning existing execution for idempotency key: %s", *idempotencyKey)
+			logging.From(ctx, s.logger).Info("Returning existing execution for idempotency key", "idempotency_key", *idempotencyKey)
 			return existing, nil
 		}
 	}
@@ -127,9 +166,11 @@ func (s *Service) ExecuteScene(sceneID string, idempotencyKey *string, options E
 	}
 
 	// Start async execution
+	ctx = context.WithoutCancel(ctx)
 	go func() {
-		if _, err := s.executor.Execute(scene, execution, options); err != nil {
-			s.logger.Printf("Scene execution failed: %v", err)
+		if _, err := s.executor.Execute(ctx, scene, execution, options); err != nil {
+			logging.From(ctx, s.logger).Error("Scene execution failed",
+				"scene_id", sceneID, "scene_execution_id", execution.SceneExecutionID, "error", err)
 			// Ensure execution is marked as failed
 			current, _ := s.execRepo.GetByID(execution.SceneExecutionID)
 			if current != nil && current.Status == ExecutionStatusStarting {
@@ -188,6 +229,18 @@ func (s *Service) StopScene(sceneID string) ([]DeviceResult, error) {
 	})
 }
 
+// RestoreGroups puts a grouped_then_restore scene's members back in the groups they
+// were in before it was executed. Returns nil when there is nothing to restore.
+func (s *Service) RestoreGroups(ctx context.Context, sceneID string) []DeviceResult {
+	return s.executor.RestoreSceneGroups(ctx, sceneID)
+}
+
+// RestoreExecutionGroups puts the members of a grouped_then_restore execution back in
+// the groups they were in before it. Returns nil when there is nothing to restore.
+func (s *Service) RestoreExecutionGroups(ctx context.Context, sceneExecutionID string) []DeviceResult {
+	return s.executor.RestoreGroups(ctx, sceneExecutionID)
+}
+
 // executeOnMembers runs a function on all scene members in parallel.
 func (s *Service) executeOnMembers(scene *Scene, fn func(ip string) error) ([]DeviceResult, error) {
 	results := make([]DeviceResult, len(scene.Members))
@@ -0,0 +1,48 @@
+package scene
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// MaxStaggerMs is the largest stagger_ms a scene accepts.
+	MaxStaggerMs = 10000
+	// maxStaggerTotal bounds the delay staggering adds to an execution; with many
+	// members the interval shrinks to fit.
+	maxStaggerTotal = MaxStaggerMs * time.Millisecond
+)
+
+// ValidStaggerMs reports whether ms is an accepted stagger_ms.
+func ValidStaggerMs(ms int) bool {
+	return ms >= 0 && ms <= MaxStaggerMs
+}
+
+// staggerInterval returns the gap between starting each of count members, so the last
+// starts no later than maxStaggerTotal after the first.
+func staggerInterval(staggerMs, count int) time.Duration {
+	if staggerMs <= 0 || count < 2 {
+		return 0
+	}
+	interval := time.Duration(staggerMs) * time.Millisecond
+	if total := interval * time.Duration(count-1); total > maxStaggerTotal {
+		interval = maxStaggerTotal / time.Duration(count-1)
+	}
+	return interval
+}
+
+// waitUntil sleeps until at, returning early with ctx's error if it's cancelled.
+func waitUntil(ctx context.Context, at time.Time) error {
+	wait := time.Until(at)
+	if wait <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,51 @@
+package scene
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidStaggerMs(t *testing.T) {
+	require.True(t, ValidStaggerMs(0))
+	require.True(t, ValidStaggerMs(MaxStaggerMs))
+	require.False(t, ValidStaggerMs(-1))
+	require.False(t, ValidStaggerMs(MaxStaggerMs+1))
+}
+
+func TestStaggerInterval(t *testing.T) {
+	tests := []struct {
+		name      string
+		staggerMs int
+		count     int
+		want      time.Duration
+	}{
+		{"disabled", 0, 6, 0},
+		{"single member", 500, 1, 0},
+		{"within the cap", 500, 6, 500 * time.Millisecond},
+		{"exactly the cap", 2000, 6, 2 * time.Second},
+		{"shrunk to fit the cap", 5000, 6, 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, staggerInterval(tt.staggerMs, tt.count))
+		})
+	}
+}
+
+func TestWaitUntil(t *testing.T) {
+	require.NoError(t, waitUntil(context.Background(), time.Now().Add(-time.Second)))
+
+	start := time.Now()
+	require.NoError(t, waitUntil(context.Background(), start.Add(20*time.Millisecond)))
+	require.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	// Cancellation cuts the wait short
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start = time.Now()
+	require.ErrorIs(t, waitUntil(ctx, start.Add(time.Minute)), context.Canceled)
+	require.Less(t, time.Since(start), time.Second)
+}
@@ -67,6 +67,19 @@ const (
 	GroupBehaviorRequireCoordinator GroupBehavior = "REQUIRE_COORDINATOR"
 )
 
+// GroupingMode determines whether scene members play as one group.
+type GroupingMode string
+
+const (
+	// GroupingModeIndependent starts playback on each member separately
+	GroupingModeIndependent GroupingMode = "independent"
+	// GroupingModeGrouped joins members to the coordinator before playback
+	GroupingModeGrouped GroupingMode = "grouped"
+	// GroupingModeGroupedThenRestore groups like GroupingModeGrouped and restores the
+	// previous groups when the scene is stopped
+	GroupingModeGroupedThenRestore GroupingMode = "grouped_then_restore"
+)
+
 // TVPolicy determines how TV mode is handled.
 type TVPolicy string
 
@@ -82,6 +95,12 @@ type SceneMember struct {
 	RoomName     string `json:"room_name,omitempty"` // Stored for human-readable fallback
 	TargetVolume *int   `json:"target_volume,omitempty"`
 	Mute         *bool  `json:"mute,omitempty"`
+	FallbackUDN  string `json:"fallback_udn,omitempty"` // Used when the primary is unreachable at run time
+
+	// FadeInMs starts the member at volume 0 and ramps it to TargetVolume over this many
+	// milliseconds once playback starts, shaped by FadeCurve (linear, ease-in, ease-out)
+	FadeInMs  *int   `json:"fade_in_ms,omitempty"`
+	FadeCurve string `json:"fade_curve,omitempty"`
 }
 
 // VolumeRamp defines volume ramping behavior.
@@ -107,6 +126,8 @@ type Scene struct {
 	Members               []SceneMember `json:"members"`
 	VolumeRamp            *VolumeRamp   `json:"volume_ramp,omitempty"`
 	Teardown              *Teardown     `json:"teardown,omitempty"`
+	GroupingMode          string        `json:"grouping_mode"`
+	StaggerMs             int           `json:"stagger_ms"` // Gap between members starting playback in independent mode
 	CreatedAt             time.Time     `json:"created_at"`
 	UpdatedAt             time.Time     `json:"updated_at"`
 }
@@ -185,21 +206,84 @@ type Verification struct {
 
 // SceneExecution represents a single execution of a scene.
 type SceneExecution struct {
-	SceneExecutionID   string          `json:"scene_execution_id"`
-	SceneID            string          `json:"scene_id"`
-	IdempotencyKey     *string         `json:"idempotency_key,omitempty"`
-	CoordinatorUsedUDN *string         `json:"coordinator_used_udn,omitempty"`
-	Status             ExecutionStatus `json:"status"`
-	StartedAt          time.Time       `json:"started_at"`
-	EndedAt            *time.Time      `json:"ended_at,omitempty"`
-	Steps              []ExecutionStep `json:"steps"`
-	Verification       *Verification   `json:"verification,omitempty"`
-	Error              *string         `json:"error,omitempty"`
+	SceneExecutionID   string           `json:"scene_execution_id"`
+	SceneID            string           `json:"scene_id"`
+	IdempotencyKey     *string          `json:"idempotency_key,omitempty"`
+	CoordinatorUsedUDN *string          `json:"coordinator_used_udn,omitempty"`
+	Status             ExecutionStatus  `json:"status"`
+	StartedAt          time.Time        `json:"started_at"`
+	EndedAt            *time.Time       `json:"ended_at,omitempty"`
+	Steps              []ExecutionStep  `json:"steps"`
+	Verification       *Verification    `json:"verification,omitempty"`
+	Error              *string          `json:"error,omitempty"`
+	Detail             *ExecutionDetail `json:"detail,omitempty"` // Set when the execution ends
+}
+
+// MemberStatus summarizes how an execution went on one member.
+type MemberStatus string
+
+const (
+	// MemberStatusOK means every command sent to the member succeeded
+	MemberStatusOK MemberStatus = "ok"
+	// MemberStatusFailed means at least one command sent to the member failed
+	MemberStatusFailed MemberStatus = "failed"
+	// MemberStatusNotReached means the execution ended before sending the member anything
+	MemberStatusNotReached MemberStatus = "not_reached"
+	// MemberStatusExcluded means the execution left the member out (exclude_members)
+	MemberStatusExcluded MemberStatus = "excluded"
+)
+
+// MemberCommand is the result of one command an execution sent to a member.
+type MemberCommand struct {
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// MemberResult records what an execution did on one member. Commands it didn't send
+// are nil: grouped members get a group command but no transport or play, since they
+// follow the coordinator.
+type MemberResult struct {
+	UDN         string         `json:"udn"`
+	RoomName    string         `json:"room_name,omitempty"`
+	PrimaryUDN  string         `json:"primary_udn,omitempty"` // Set when standing in as a fallback
+	Coordinator bool           `json:"coordinator"`
+	Status      MemberStatus   `json:"status"`
+	Group       *MemberCommand `json:"group,omitempty"`
+	Volume      *MemberCommand `json:"volume,omitempty"`
+	Transport   *MemberCommand `json:"transport,omitempty"`
+	Play        *MemberCommand `json:"play,omitempty"`
+	Error       string         `json:"error,omitempty"` // First failed command's error
+
+	// Warnings are errors the member's playback carried on past, like a failed queue add
+	// that the transport and play commands recovered from
+	Warnings []string `json:"warnings,omitempty"`
+
+	// StartOffsetMs is when the member's playback started, in milliseconds after the
+	// coordinator's, for independent scenes
+	StartOffsetMs *int64 `json:"start_offset_ms,omitempty"`
+}
+
+// ExecutionContent is the resolved content an execution started.
+type ExecutionContent struct {
+	Type            string    `json:"type,omitempty"`
+	SonosFavoriteID string    `json:"sonos_favorite_id,omitempty"`
+	URI             string    `json:"uri,omitempty"`
+	UsesQueue       bool      `json:"uses_queue"`
+	QueueMode       QueueMode `json:"queue_mode,omitempty"`
+}
+
+// ExecutionDetail is the structured record of an execution: how each member fared and
+// what content was started, so a partial failure shows which speaker failed and why.
+type ExecutionDetail struct {
+	Members    []MemberResult    `json:"members"`
+	Content    *ExecutionContent `json:"content,omitempty"`
+	DurationMs int64             `json:"duration_ms"`
 }
 
 // MusicContent represents the content to play.
 type MusicContent struct {
-	Type            string `json:"type"` // sonos_favorite, direct
+	Type            string `json:"type"` // sonos_favorite, direct, local_file
 	SonosFavoriteID string `json:"sonos_favorite_id,omitempty"`
 	URI             string `json:"uri,omitempty"`
 	Metadata        string `json:"metadata,omitempty"`
@@ -213,6 +297,12 @@ type ExecuteOptions struct {
 	GroupBehavior GroupBehavior `json:"group_behavior,omitempty"`
 	TVPolicy      TVPolicy      `json:"tv_policy,omitempty"`
 	FavoriteID    string        `json:"favorite_id,omitempty"` // deprecated
+
+	// ExcludeMembers leaves these member UDNs out of this execution, e.g. speakers in TV mode
+	ExcludeMembers []string `json:"exclude_members,omitempty"`
+
+	// StartVolume overrides every member's target volume and fade-in for this execution
+	StartVolume *int `json:"start_volume,omitempty"`
 }
 
 // CreateSceneInput contains the input for creating a scene.
@@ -224,6 +314,8 @@ type CreateSceneInput struct {
 	Members               []SceneMember `json:"members"`
 	VolumeRamp            *VolumeRamp   `json:"volume_ramp,omitempty"`
 	Teardown              *Teardown     `json:"teardown,omitempty"`
+	GroupingMode          string        `json:"grouping_mode,omitempty"`
+	StaggerMs             int           `json:"stagger_ms,omitempty"`
 }
 
 // UpdateSceneInput contains the input for updating a scene.
@@ -235,6 +327,8 @@ type UpdateSceneInput struct {
 	Members               []SceneMember `json:"members,omitempty"`
 	VolumeRamp            *VolumeRamp   `json:"volume_ramp,omitempty"`
 	Teardown              *Teardown     `json:"teardown,omitempty"`
+	GroupingMode          *string       `json:"grouping_mode,omitempty"`
+	StaggerMs             *int          `json:"stagger_ms,omitempty"`
 }
 
 // CreateExecutionInput contains the input for creating an execution.
@@ -0,0 +1,54 @@
+package scene
+
+import "math"
+
+// AdjustVolumesInput adjusts every member's target volume at once.
+// Exactly one of Delta (added to each volume) or Scale (multiplied into each volume) is set.
+type AdjustVolumesInput struct {
+	Delta *int     `json:"delta,omitempty"`
+	Scale *float64 `json:"scale,omitempty"`
+}
+
+// AdjustMemberVolumes returns a copy of members with each target volume adjusted and
+// clamped to 0..100. Members without a target volume keep their current volume at run
+// time, so they are left unset.
+func AdjustMemberVolumes(members []SceneMember, input AdjustVolumesInput) []SceneMember {
+	adjusted := make([]SceneMember, len(members))
+	copy(adjusted, members)
+
+	for i := range adjusted {
+		if adjusted[i].TargetVolume == nil {
+			continue
+		}
+		volume := *adjusted[i].TargetVolume
+		if input.Delta != nil {
+			volume += *input.Delta
+		}
+		if input.Scale != nil {
+			volume = int(math.Round(float64(volume) * *input.Scale))
+		}
+		volume = max(0, min(100, volume))
+		adjusted[i].TargetVolume = &volume
+	}
+
+	return adjusted
+}
+
+// withStartVolume returns a copy of scene whose members all start at volume, without
+// fade-ins, so a caller can bring the volume up itself after playback starts.
+func withStartVolume(scene *Scene, volume *int) *Scene {
+	if volume == nil {
+		return scene
+	}
+	members := make([]SceneMember, len(scene.Members))
+	copy(members, scene.Members)
+	for i := range members {
+		level := *volume
+		members[i].TargetVolume = &level
+		members[i].FadeInMs = nil
+	}
+
+	started := *scene
+	started.Members = members
+	return &started
+}
@@ -0,0 +1,62 @@
+package scene
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func targetVolumes(members []SceneMember) []*int {
+	volumes := make([]*int, len(members))
+	for i, member := range members {
+		volumes[i] = member.TargetVolume
+	}
+	return volumes
+}
+
+func intPtr(v int) *int { return &v }
+
+func TestAdjustMemberVolumes_DeltaClamps(t *testing.T) {
+	members := []SceneMember{
+		{UDN: "a", TargetVolume: intPtr(10)},
+		{UDN: "b", TargetVolume: intPtr(95)},
+		{UDN: "c"},
+	}
+
+	up := AdjustMemberVolumes(members, AdjustVolumesInput{Delta: intPtr(10)})
+	require.Equal(t, []*int{intPtr(20), intPtr(100), nil}, targetVolumes(up))
+
+	down := AdjustMemberVolumes(members, AdjustVolumesInput{Delta: intPtr(-20)})
+	require.Equal(t, []*int{intPtr(0), intPtr(75), nil}, targetVolumes(down))
+
+	// Input is not modified
+	require.Equal(t, 10, *members[0].TargetVolume)
+}
+
+func TestAdjustMemberVolumes_ScaleRounds(t *testing.T) {
+	scale := 0.8
+	members := []SceneMember{
+		{UDN: "a", TargetVolume: intPtr(33)},
+		{UDN: "b", TargetVolume: intPtr(0)},
+	}
+
+	adjusted := AdjustMemberVolumes(members, AdjustVolumesInput{Scale: &scale})
+	require.Equal(t, []*int{intPtr(26), intPtr(0)}, targetVolumes(adjusted))
+
+	double := 2.0
+	adjusted = AdjustMemberVolumes(members, AdjustVolumesInput{Scale: &double})
+	require.Equal(t, []*int{intPtr(66), intPtr(0)}, targetVolumes(adjusted))
+}
+
+func TestWithStartVolume(t *testing.T) {
+	scene := &Scene{Members: []SceneMember{
+		{UDN: "a", TargetVolume: intPtr(30), FadeInMs: intPtr(5000)},
+		{UDN: "b"},
+	}}
+	require.Same(t, scene, withStartVolume(scene, nil))
+
+	started := withStartVolume(scene, intPtr(5))
+	require.Equal(t, []*int{intPtr(5), intPtr(5)}, targetVolumes(started.Members))
+	require.Nil(t, started.Members[0].FadeInMs)
+	require.Equal(t, intPtr(30), scene.Members[0].TargetVolume, "the scene itself is unchanged")
+}
@@ -0,0 +1,264 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// MaxRoutineActions bounds the actions a routine can run.
+const MaxRoutineActions = 10
+
+// RoutineActionType is something a NONE routine does to its speakers instead of
+// playing music.
+type RoutineActionType string
+
+const (
+	RoutineActionSetVolume RoutineActionType = "set_volume"
+	RoutineActionStop      RoutineActionType = "stop"
+	RoutineActionPause     RoutineActionType = "pause"
+)
+
+// IsValid reports whether t is a known action type.
+func (t RoutineActionType) IsValid() bool {
+	switch t {
+	case RoutineActionSetVolume, RoutineActionStop, RoutineActionPause:
+		return true
+	}
+	return false
+}
+
+// RoutineAction is one action a routine runs on each of its speakers.
+type RoutineAction struct {
+	Type   RoutineActionType `json:"type"`
+	Volume *int              `json:"volume,omitempty"` // set_volume only; each speaker's own volume when unset
+}
+
+// ExecutionAction is an action a job ran and how it went on each speaker.
+type ExecutionAction struct {
+	Type    RoutineActionType       `json:"type"`
+	Volume  *int                    `json:"volume,omitempty"`
+	Results []ExecutionActionResult `json:"results"`
+}
+
+// ExecutionActionResult is the outcome of an action on one of the routine's speakers.
+type ExecutionActionResult struct {
+	UDN      string `json:"udn"`
+	RoomName string `json:"room_name,omitempty"`
+	Volume   *int   `json:"volume,omitempty"` // Volume set, for set_volume
+
+	// Stop and pause go to the group coordinator when the speaker is grouped
+	CoordinatorUDN string `json:"coordinator_udn,omitempty"`
+
+	// Nothing to stop or pause: the speaker's group wasn't playing, or an earlier
+	// speaker in the same group already stopped it
+	Skipped bool   `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ActionController runs routine actions on speakers (implemented by sonos.Service).
+type ActionController interface {
+	GetMediaInfo(deviceIP string) (soap.MediaInfo, error)
+	GetTransportInfo(deviceIP string) (soap.TransportInfo, error)
+	SetVolume(deviceIP string, level int) error
+	Stop(deviceIP string) error
+	Pause(deviceIP string) error
+}
+
+// SetActionController enables routine actions, which NONE routines run on their
+// speakers instead of playing music.
+func (a *RoutineExecutorAdapter) SetActionController(controller ActionController) {
+	a.actions = controller
+}
+
+// validateRoutineActions checks a routine's actions against the music policy type and
+// the number of speakers they will run on.
+func validateRoutineActions(policyType MusicPolicyType, actions []RoutineAction, speakerCount int) error {
+	if len(actions) == 0 {
+		return nil
+	}
+	if policyType != MusicPolicyTypeNone {
+		return apperrors.NewValidationError("actions can only be used with music_policy type NONE", map[string]any{"music_policy_type": string(policyType)})
+	}
+	if len(actions) > MaxRoutineActions {
+		return apperrors.NewValidationError("a routine can have at most "+strconv.Itoa(MaxRoutineActions)+" actions", map[string]any{"actions": len(actions)})
+	}
+	if speakerCount == 0 {
+		return apperrors.NewValidationError("actions require speakers", nil)
+	}
+	for i, action := range actions {
+		if !action.Type.IsValid() {
+			return apperrors.NewValidationError("action type must be one of set_volume, stop, pause", map[string]any{"index": i, "type": string(action.Type)})
+		}
+		if action.Volume == nil {
+			continue
+		}
+		if action.Type != RoutineActionSetVolume {
+			return apperrors.NewValidationError("volume can only be given for set_volume actions", map[string]any{"index": i, "type": string(action.Type)})
+		}
+		if *action.Volume < 0 || *action.Volume > 100 {
+			return apperrors.NewValidationError("action volume must be between 0 and 100", map[string]any{"index": i, "volume": *action.Volume})
+		}
+	}
+	return nil
+}
+
+// prepareActionsUpdate validates the actions existing will have once input is applied.
+// Giving actions without a music policy makes the routine NONE; moving a routine to a
+// music policy without giving actions drops the ones it had.
+func prepareActionsUpdate(existing *Routine, input *UpdateRoutineInput) error {
+	policyType := existing.MusicPolicyType
+	if input.MusicPolicyType != nil {
+		policyType = *input.MusicPolicyType
+	}
+
+	actions := existing.Actions
+	if input.Actions != nil {
+		actions = *input.Actions
+		if len(actions) > 0 && input.MusicPolicyType == nil {
+			policyType = MusicPolicyTypeNone
+			input.MusicPolicyType = &policyType
+		}
+	} else if policyType != MusicPolicyTypeNone && len(actions) > 0 {
+		cleared := []RoutineAction{}
+		input.Actions = &cleared
+		actions = nil
+	}
+
+	speakerCount := len(existing.SpeakersJSON)
+	if len(input.SpeakersJSON) > 0 {
+		speakerCount = len(input.SpeakersJSON)
+	}
+	return validateRoutineActions(policyType, actions, speakerCount)
+}
+
+// runActions runs the routine's actions, in order, on each of its speakers except
+// those in exclude. A failure on one speaker doesn't stop the others; the run fails
+// only when an action failed on every speaker it ran on.
+func (a *RoutineExecutorAdapter) runActions(ctx context.Context, routine *Routine, exclude []string, roomNames map[string]string) ([]ExecutionAction, error) {
+	if a.actions == nil || a.ipResolver == nil {
+		return nil, errors.New("routine actions are not available")
+	}
+	logger := logging.From(ctx, a.logger)
+
+	excluded := make(map[string]bool, len(exclude))
+	for _, udn := range exclude {
+		excluded[udn] = true
+	}
+
+	var failed []string
+	executed := make([]ExecutionAction, 0, len(routine.Actions))
+	for _, action := range routine.Actions {
+		done := make(map[string]bool) // Coordinators already stopped or paused
+		result := ExecutionAction{Type: action.Type, Volume: action.Volume, Results: []ExecutionActionResult{}}
+		errorCount := 0
+		for _, speaker := range routine.SpeakersJSON {
+			if excluded[speaker.UDN] {
+				continue
+			}
+			speakerResult := ExecutionActionResult{UDN: speaker.UDN, RoomName: roomNames[speaker.UDN]}
+			var err error
+			if action.Type == RoutineActionSetVolume {
+				err = a.setActionVolume(action, speaker, &speakerResult)
+			} else {
+				err = a.stopOrPause(action.Type, speaker.UDN, done, &speakerResult)
+			}
+			if err != nil {
+				speakerResult.Error = err.Error()
+				errorCount++
+				logger.Warn("Routine action failed", "action", action.Type, "udn", speaker.UDN, "error", err)
+			}
+			result.Results = append(result.Results, speakerResult)
+		}
+		if errorCount > 0 && errorCount == len(result.Results) {
+			failed = append(failed, string(action.Type))
+		}
+		executed = append(executed, result)
+	}
+
+	if len(failed) > 0 {
+		return executed, fmt.Errorf("routine action failed on every speaker: %s", strings.Join(failed, ", "))
+	}
+	logger.Info("Ran routine actions", "actions", len(executed))
+	return executed, nil
+}
+
+// setActionVolume sets the action's volume, or the speaker's own, on the speaker.
+func (a *RoutineExecutorAdapter) setActionVolume(action RoutineAction, speaker Speaker, result *ExecutionActionResult) error {
+	volume := action.Volume
+	if volume == nil {
+		volume = speaker.Volume
+	}
+	if volume == nil {
+		return errors.New("no volume to set")
+	}
+
+	ip, err := a.ipResolver.ResolveDeviceIP(speaker.UDN)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", speaker.UDN, err)
+	}
+	if err := a.actions.SetVolume(ip, *volume); err != nil {
+		return err
+	}
+	level := *volume
+	result.Volume = &level
+	return nil
+}
+
+// stopOrPause stops or pauses the group udn belongs to. Grouped speakers follow their
+// coordinator, so the command goes there; done holds the coordinators already handled
+// by this action.
+func (a *RoutineExecutorAdapter) stopOrPause(actionType RoutineActionType, udn string, done map[string]bool, result *ExecutionActionResult) error {
+	ip, err := a.ipResolver.ResolveDeviceIP(udn)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", udn, err)
+	}
+	media, err := a.actions.GetMediaInfo(ip)
+	if err != nil {
+		return err
+	}
+	if isGroupMemberURI(media.CurrentURI) {
+		coordinatorUDN := strings.TrimPrefix(media.CurrentURI, "x-rincon:")
+		result.CoordinatorUDN = coordinatorUDN
+		if done[coordinatorUDN] {
+			result.Skipped = true
+			return nil
+		}
+		if ip, err = a.ipResolver.ResolveDeviceIP(coordinatorUDN); err != nil {
+			return fmt.Errorf("resolve coordinator %s: %w", coordinatorUDN, err)
+		}
+		udn = coordinatorUDN
+	}
+	if done[udn] {
+		result.Skipped = true
+		return nil
+	}
+	done[udn] = true
+
+	// Sonos rejects pausing, and sometimes stopping, a group that isn't playing
+	transport, err := a.actions.GetTransportInfo(ip)
+	if err != nil {
+		return err
+	}
+	switch transport.CurrentTransportState {
+	case "STOPPED":
+		result.Skipped = true
+		return nil
+	case "PAUSED_PLAYBACK":
+		if actionType == RoutineActionPause {
+			result.Skipped = true
+			return nil
+		}
+	}
+
+	if actionType == RoutineActionPause {
+		return a.actions.Pause(ip)
+	}
+	return a.actions.Stop(ip)
+}
@@ -0,0 +1,233 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/scene"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+type fakeActionController struct {
+	uris   map[string]string // IP -> current transport URI
+	states map[string]string // IP -> transport state
+	failIP string
+	calls  []string
+}
+
+func (f *fakeActionController) GetMediaInfo(deviceIP string) (soap.MediaInfo, error) {
+	return soap.MediaInfo{CurrentURI: f.uris[deviceIP]}, nil
+}
+
+func (f *fakeActionController) GetTransportInfo(deviceIP string) (soap.TransportInfo, error) {
+	state := f.states[deviceIP]
+	if state == "" {
+		state = "PLAYING"
+	}
+	return soap.TransportInfo{CurrentTransportState: state}, nil
+}
+
+func (f *fakeActionController) SetVolume(deviceIP string, level int) error {
+	return f.record(fmt.Sprintf("volume %s %d", deviceIP, level), deviceIP)
+}
+
+func (f *fakeActionController) Stop(deviceIP string) error {
+	return f.record("stop "+deviceIP, deviceIP)
+}
+
+func (f *fakeActionController) Pause(deviceIP string) error {
+	return f.record("pause "+deviceIP, deviceIP)
+}
+
+func (f *fakeActionController) record(call, deviceIP string) error {
+	if deviceIP == f.failIP {
+		return errors.New("timeout")
+	}
+	f.calls = append(f.calls, call)
+	return nil
+}
+
+// failingSceneExecutor fails the test if the scene runs.
+type failingSceneExecutor struct {
+	t *testing.T
+}
+
+func (f *failingSceneExecutor) ExecuteScene(ctx context.Context, sceneID string, idempotencyKey *string, options scene.ExecuteOptions) (*scene.SceneExecution, error) {
+	f.t.Fatal("scene executed for a routine with actions")
+	return nil, nil
+}
+
+func intPtr(v int) *int { return &v }
+
+func TestRoutinesRepository_Actions(t *testing.T) {
+	routinesRepo, _, _, scenesRepo := setupTestDB(t)
+
+	s, err := scenesRepo.Create(scene.CreateSceneInput{Name: "Test Scene", Members: []scene.SceneMember{}})
+	require.NoError(t, err)
+
+	actions := []RoutineAction{{Type: RoutineActionSetVolume, Volume: intPtr(10)}, {Type: RoutineActionPause}}
+	routine, err := routinesRepo.Create(CreateRoutineInput{
+		Name:            "Quiet Hours",
+		Timezone:        "UTC",
+		ScheduleTime:    "22:00",
+		SceneID:         s.SceneID,
+		MusicPolicyType: MusicPolicyTypeNone,
+		Actions:         actions,
+	})
+	require.NoError(t, err)
+	require.Equal(t, MusicPolicyTypeNone, routine.MusicPolicyType)
+	require.Equal(t, actions, routine.Actions)
+
+	// Unrelated updates keep the actions; an empty list clears them
+	newName := "Lights Out"
+	updated, err := routinesRepo.Update(routine.RoutineID, UpdateRoutineInput{Name: &newName})
+	require.NoError(t, err)
+	require.Equal(t, actions, updated.Actions)
+
+	updated, err = routinesRepo.Update(routine.RoutineID, UpdateRoutineInput{Actions: &[]RoutineAction{}})
+	require.NoError(t, err)
+	require.Empty(t, updated.Actions)
+}
+
+func TestValidateRoutineActions(t *testing.T) {
+	tests := []struct {
+		name       string
+		policyType MusicPolicyType
+		actions    []RoutineAction
+		speakers   int
+		wantErr    bool
+	}{
+		{"no actions", MusicPolicyTypeFixed, nil, 0, false},
+		{"volume preset", MusicPolicyTypeNone, []RoutineAction{{Type: RoutineActionSetVolume, Volume: intPtr(10)}}, 2, false},
+		{"speaker volumes", MusicPolicyTypeNone, []RoutineAction{{Type: RoutineActionSetVolume}, {Type: RoutineActionStop}}, 1, false},
+		{"music policy", MusicPolicyTypeFixed, []RoutineAction{{Type: RoutineActionStop}}, 1, true},
+		{"no speakers", MusicPolicyTypeNone, []RoutineAction{{Type: RoutineActionStop}}, 0, true},
+		{"unknown type", MusicPolicyTypeNone, []RoutineAction{{Type: "mute"}}, 1, true},
+		{"volume on stop", MusicPolicyTypeNone, []RoutineAction{{Type: RoutineActionStop, Volume: intPtr(10)}}, 1, true},
+		{"volume out of range", MusicPolicyTypeNone, []RoutineAction{{Type: RoutineActionSetVolume, Volume: intPtr(101)}}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRoutineActions(tt.policyType, tt.actions, tt.speakers)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPrepareActionsUpdate(t *testing.T) {
+	existing := &Routine{
+		MusicPolicyType: MusicPolicyTypeFixed,
+		SpeakersJSON:    []Speaker{{UDN: "udn-kitchen"}},
+	}
+
+	// Actions alone turn the routine into a NONE routine
+	input := UpdateRoutineInput{Actions: &[]RoutineAction{{Type: RoutineActionStop}}}
+	require.NoError(t, prepareActionsUpdate(existing, &input))
+	require.Equal(t, MusicPolicyTypeNone, *input.MusicPolicyType)
+
+	// Moving to a music policy drops the actions
+	existing.MusicPolicyType = MusicPolicyTypeNone
+	existing.Actions = []RoutineAction{{Type: RoutineActionStop}}
+	fixed := MusicPolicyTypeFixed
+	input = UpdateRoutineInput{MusicPolicyType: &fixed}
+	require.NoError(t, prepareActionsUpdate(existing, &input))
+	require.NotNil(t, input.Actions)
+	require.Empty(t, *input.Actions)
+
+	// Unless actions are given too
+	input = UpdateRoutineInput{MusicPolicyType: &fixed, Actions: &existing.Actions}
+	require.Error(t, prepareActionsUpdate(existing, &input))
+}
+
+func TestRoutineExecutorAdapter_Actions(t *testing.T) {
+	resolver := fakeIPResolver{"udn-kitchen": "10.0.0.1", "udn-den": "10.0.0.2", "udn-office": "10.0.0.3"}
+	speakers := []Speaker{{UDN: "udn-kitchen", Volume: intPtr(20)}, {UDN: "udn-den", Volume: intPtr(30)}, {UDN: "udn-office"}}
+
+	newAdapter := func(controller *fakeActionController) *RoutineExecutorAdapter {
+		adapter := &RoutineExecutorAdapter{sceneExecutor: &failingSceneExecutor{t: t}, ipResolver: resolver, logger: logging.Discard()}
+		adapter.SetActionController(controller)
+		return adapter
+	}
+
+	t.Run("set volume uses the action's volume", func(t *testing.T) {
+		controller := &fakeActionController{}
+		routine := &Routine{
+			RoutineID: "routine-1", MusicPolicyType: MusicPolicyTypeNone, SpeakersJSON: speakers,
+			Actions: []RoutineAction{{Type: RoutineActionSetVolume, Volume: intPtr(10)}},
+		}
+		execution, err := newAdapter(controller).ExecuteRoutine(context.Background(), routine, time.Time{}, nil)
+		require.NoError(t, err)
+		require.Nil(t, execution.SceneExecution)
+		require.Equal(t, []string{"volume 10.0.0.1 10", "volume 10.0.0.2 10", "volume 10.0.0.3 10"}, controller.calls)
+		require.Len(t, execution.Detail.Actions, 1)
+		require.Equal(t, intPtr(10), execution.Detail.Actions[0].Results[0].Volume)
+	})
+
+	t.Run("set volume falls back to each speaker's volume", func(t *testing.T) {
+		controller := &fakeActionController{}
+		routine := &Routine{
+			RoutineID: "routine-1", MusicPolicyType: MusicPolicyTypeNone, SpeakersJSON: speakers,
+			Actions: []RoutineAction{{Type: RoutineActionSetVolume}},
+		}
+		execution, err := newAdapter(controller).ExecuteRoutine(context.Background(), routine, time.Time{}, nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"volume 10.0.0.1 20", "volume 10.0.0.2 30"}, controller.calls)
+		require.Equal(t, "no volume to set", execution.Detail.Actions[0].Results[2].Error)
+	})
+
+	t.Run("stop goes to the group coordinator once", func(t *testing.T) {
+		controller := &fakeActionController{
+			uris:   map[string]string{"10.0.0.2": "x-rincon:udn-kitchen"},
+			states: map[string]string{"10.0.0.3": "STOPPED"},
+		}
+		routine := &Routine{
+			RoutineID: "routine-1", MusicPolicyType: MusicPolicyTypeNone, SpeakersJSON: speakers,
+			Actions: []RoutineAction{{Type: RoutineActionStop}},
+		}
+		execution, err := newAdapter(controller).ExecuteRoutine(context.Background(), routine, time.Time{}, nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"stop 10.0.0.1"}, controller.calls)
+
+		results := execution.Detail.Actions[0].Results
+		require.Equal(t, ExecutionActionResult{UDN: "udn-den", CoordinatorUDN: "udn-kitchen", Skipped: true}, results[1])
+		require.True(t, results[2].Skipped, "a stopped speaker is left alone")
+	})
+
+	t.Run("an action failing everywhere fails the run", func(t *testing.T) {
+		controller := &fakeActionController{failIP: "10.0.0.1"}
+		routine := &Routine{
+			RoutineID: "routine-1", MusicPolicyType: MusicPolicyTypeNone, SpeakersJSON: speakers[:1],
+			Actions: []RoutineAction{{Type: RoutineActionPause}},
+		}
+		_, err := newAdapter(controller).ExecuteRoutine(context.Background(), routine, time.Time{}, nil)
+		require.EqualError(t, err, "routine action failed on every speaker: pause")
+	})
+}
+
+func TestFormatRoutine_NoMusicPolicy(t *testing.T) {
+	routine := &Routine{
+		RoutineID:       "routine-1",
+		MusicPolicyType: MusicPolicyTypeNone,
+		Actions:         []RoutineAction{{Type: RoutineActionStop}},
+	}
+	formatted := formatRoutineWithDeviceMap(routine, nil)
+	require.NotContains(t, formatted, "music_policy")
+	require.NotContains(t, formatted, "music_set")
+	require.Equal(t, routine.Actions, formatted["actions"])
+
+	routine.MusicPolicyType = MusicPolicyTypeFixed
+	routine.Actions = nil
+	formatted = formatRoutineWithDeviceMap(routine, nil)
+	require.Contains(t, formatted, "music_policy")
+	require.Equal(t, []RoutineAction{}, formatted["actions"])
+}
@@ -0,0 +1,51 @@
+package scheduler
+
+import (
+	"context"
+
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
+)
+
+// SetAudioSettingsController enables per-speaker audio_settings: once a routine's playback
+// has started, each speaker's EQ (night mode, speech enhancement, bass, treble, loudness)
+// is applied.
+func (a *RoutineExecutorAdapter) SetAudioSettingsController(client sonos.AudioSettingsClient) {
+	a.audioSettings = client
+}
+
+// applyAudioSettings applies each speaker's audio_settings, skipping excluded speakers,
+// and records the settings on the detail's devices. Failures are logged and don't fail
+// the run.
+func (a *RoutineExecutorAdapter) applyAudioSettings(ctx context.Context, routine *Routine, exclude []string, detail *ExecutionDetail) {
+	if a.audioSettings == nil || a.ipResolver == nil {
+		return
+	}
+	logger := logging.From(ctx, a.logger)
+
+	excluded := make(map[string]bool, len(exclude))
+	for _, udn := range exclude {
+		excluded[udn] = true
+	}
+
+	applied := make(map[string]*sonos.AudioSettings)
+	for _, speaker := range routine.SpeakersJSON {
+		if speaker.AudioSettings == nil || speaker.AudioSettings.IsEmpty() || excluded[speaker.UDN] {
+			continue
+		}
+		ip, err := a.ipResolver.ResolveDeviceIP(speaker.UDN)
+		if err != nil || ip == "" {
+			logger.Warn("Failed to resolve speaker to apply audio settings", "udn", speaker.UDN, "error", err)
+			continue
+		}
+		if err := sonos.ApplyAudioSettings(a.audioSettings, ip, *speaker.AudioSettings); err != nil {
+			logger.Warn("Failed to apply audio settings", "udn", speaker.UDN, "error", err)
+			continue
+		}
+		applied[speaker.UDN] = speaker.AudioSettings
+	}
+
+	for i := range detail.Devices {
+		detail.Devices[i].AudioSettings = applied[detail.Devices[i].UDN]
+	}
+}
@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// fakeAudioSettingsClient records night mode per speaker IP; speakers other than the
+// Arc reject it like a speaker without home theater EQ.
+type fakeAudioSettingsClient struct {
+	nightMode map[string]int
+}
+
+func (f *fakeAudioSettingsClient) GetEQ(deviceIP, eqType string) (int, error) {
+	return f.nightMode[deviceIP], nil
+}
+
+func (f *fakeAudioSettingsClient) SetEQ(deviceIP, eqType string, value int) error {
+	if deviceIP != "10.0.0.1" {
+		return &soap.SonosRejectedError{Action: "SetEQ", Code: "402"}
+	}
+	f.nightMode[deviceIP] = value
+	return nil
+}
+
+func (f *fakeAudioSettingsClient) GetBass(deviceIP string) (int, error)       { return 0, nil }
+func (f *fakeAudioSettingsClient) SetBass(deviceIP string, level int) error   { return nil }
+func (f *fakeAudioSettingsClient) GetTreble(deviceIP string) (int, error)     { return 0, nil }
+func (f *fakeAudioSettingsClient) SetTreble(deviceIP string, level int) error { return nil }
+func (f *fakeAudioSettingsClient) GetLoudness(deviceIP string) (bool, error)  { return false, nil }
+func (f *fakeAudioSettingsClient) SetLoudness(deviceIP string, enabled bool) error {
+	return nil
+}
+
+func TestRoutineExecutorAdapter_AudioSettings(t *testing.T) {
+	client := &fakeAudioSettingsClient{nightMode: map[string]int{}}
+	adapter := &RoutineExecutorAdapter{
+		sceneExecutor: &fakeSceneExecutor{},
+		ipResolver:    fakeIPResolver{"udn-arc": "10.0.0.1", "udn-den": "10.0.0.2"},
+		logger:        logging.Discard(),
+	}
+	adapter.SetAudioSettingsController(client)
+
+	nightMode := true
+	settings := &sonos.AudioSettings{NightMode: &nightMode}
+	routine := &Routine{
+		RoutineID: "routine-1",
+		SceneID:   "scene-1",
+		SpeakersJSON: []Speaker{
+			{UDN: "udn-arc", AudioSettings: settings},
+			{UDN: "udn-den", AudioSettings: settings},
+		},
+	}
+
+	execution, err := adapter.ExecuteRoutine(context.Background(), routine, time.Time{}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, client.nightMode["10.0.0.1"])
+
+	// The Den rejects night mode; the run still succeeds without it
+	require.Len(t, execution.Detail.Devices, 2)
+	require.Equal(t, settings, execution.Detail.Devices[0].AudioSettings)
+	require.Nil(t, execution.Detail.Devices[1].AudioSettings)
+}
@@ -0,0 +1,242 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/scene"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// MaxRoutineDurationMinutes caps a routine's auto-stop duration at one day.
+const MaxRoutineDurationMinutes = 24 * 60
+
+// PlaybackController is the transport control needed to stop a routine's playback.
+// It is implemented by sonos.Service.
+type PlaybackController interface {
+	GetMediaInfo(deviceIP string) (soap.MediaInfo, error)
+	Stop(deviceIP string) error
+}
+
+// DeviceIPResolver resolves a speaker UDN to its current IP.
+// It is implemented by devices.Service.
+type DeviceIPResolver interface {
+	ResolveDeviceIP(udn string) (string, error)
+}
+
+// GroupRestorer puts the members of a grouped_then_restore scene execution back in the
+// groups they were in before it. It is implemented by scene.Service.
+type GroupRestorer interface {
+	RestoreExecutionGroups(ctx context.Context, sceneExecutionID string) []scene.DeviceResult
+}
+
+// AutoStopper stops playback a routine started once the routine's duration elapses.
+// Pending stops are in-memory only; a restart drops them.
+type AutoStopper struct {
+	controller PlaybackController
+	resolver   DeviceIPResolver
+	restorer   *PlaybackRestorer
+	groups     GroupRestorer
+	logger     *slog.Logger
+
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+}
+
+// autoStop describes one pending stop for a routine run.
+type autoStop struct {
+	routineID        string
+	sceneExecutionID string // The run's scene execution, whose groups are restored
+	udns             []string
+	expectedURI      string
+	usesQueue        bool
+	restore          bool // Put back the previous playback instead of stopping
+	logger           *slog.Logger
+}
+
+// NewAutoStopper creates an AutoStopper.
+func NewAutoStopper(controller PlaybackController, resolver DeviceIPResolver, logger *slog.Logger) *AutoStopper {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &AutoStopper{
+		controller: controller,
+		resolver:   resolver,
+		logger:     logger,
+		timers:     make(map[string]*time.Timer),
+	}
+}
+
+// SetPlaybackRestorer enables restore_previous_state: when the duration of such a
+// routine elapses, the playback captured before the run is put back instead.
+func (s *AutoStopper) SetPlaybackRestorer(restorer *PlaybackRestorer) {
+	s.restorer = restorer
+}
+
+// SetGroupRestorer puts the speakers of grouped_then_restore scenes back in their
+// previous groups when the duration elapses.
+func (s *AutoStopper) SetGroupRestorer(groups GroupRestorer) {
+	s.groups = groups
+}
+
+// Schedule arranges for the run's speakers to be stopped after the given delay. speakers
+// are the ones the run played on: the routine's, or for routines created from a bare
+// scene_id, the scene's members. sceneExecutionID is the run's scene execution, whose
+// groups are restored after.
+// content is the music the routine started, used to detect that the user has since
+// played something else; nil skips that check. A newer run replaces any pending stop.
+// The stop's log lines carry ctx's log attributes, such as the run's job_id.
+func (s *AutoStopper) Schedule(ctx context.Context, routine *Routine, speakers []Speaker, sceneExecutionID string, content *scene.MusicContent, after time.Duration) {
+	stop := autoStop{
+		routineID:        routine.RoutineID,
+		sceneExecutionID: sceneExecutionID,
+		restore:          routine.RestorePreviousState,
+		logger:           logging.From(ctx, s.logger).With("routine_id", routine.RoutineID),
+	}
+	for _, speaker := range speakers {
+		stop.udns = append(stop.udns, speaker.UDN)
+		if speaker.FallbackUDN != "" {
+			stop.udns = append(stop.udns, speaker.FallbackUDN)
+		}
+	}
+	if len(stop.udns) == 0 {
+		stop.logger.Info("Routine has no speakers, skipping auto-stop")
+		return
+	}
+	if content != nil {
+		stop.expectedURI = content.URI
+		stop.usesQueue = content.UsesQueue
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.timers[stop.routineID]; ok {
+		existing.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(after, func() {
+		s.mu.Lock()
+		if s.timers[stop.routineID] == timer {
+			delete(s.timers, stop.routineID)
+		}
+		s.mu.Unlock()
+		s.run(stop)
+	})
+	s.timers[stop.routineID] = timer
+
+	stop.logger.Info("Routine will auto-stop", "at", time.Now().Add(after).Format(time.RFC3339))
+}
+
+// Cancel drops any pending stop for the routine.
+func (s *AutoStopper) Cancel(routineID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if timer, ok := s.timers[routineID]; ok {
+		timer.Stop()
+		delete(s.timers, routineID)
+	}
+}
+
+// Pending reports whether a stop is scheduled for the routine.
+func (s *AutoStopper) Pending(routineID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.timers[routineID]
+	return ok
+}
+
+// Stop cancels all pending stops.
+func (s *AutoStopper) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for routineID, timer := range s.timers {
+		timer.Stop()
+		delete(s.timers, routineID)
+	}
+}
+
+// run stops each of the routine's speakers that is still playing what the routine started,
+// or restores the previous playback for restore_previous_state routines. Routines without
+// a snapshot, e.g. because every speaker was unreachable before the run, are stopped.
+// Speakers a grouped_then_restore scene grouped go back to their previous groups: before
+// their playback is restored, or once they're stopped.
+func (s *AutoStopper) run(stop autoStop) {
+	defer s.restoreGroups(stop)
+	if stop.restore && s.restorer != nil {
+		s.restoreGroups(stop)
+		_, _, err := s.restorer.Restore(stop.routineID)
+		if err == nil {
+			return
+		}
+		if !errors.Is(err, ErrNoPlaybackSnapshot) {
+			stop.logger.Warn("Auto-stop failed to restore previous playback", "error", err)
+		}
+	}
+
+	for _, udn := range stop.udns {
+		ip, err := s.resolver.ResolveDeviceIP(udn)
+		if err != nil || ip == "" {
+			continue
+		}
+
+		media, err := s.controller.GetMediaInfo(ip)
+		if err != nil {
+			stop.logger.Warn("Auto-stop failed to read media info", "ip", ip, "error", err)
+			continue
+		}
+		if !stop.matches(media.CurrentURI) {
+			if !isGroupMemberURI(media.CurrentURI) {
+				stop.logger.Info("Auto-stop found playback changed, leaving it alone", "ip", ip)
+			}
+			continue
+		}
+
+		if err := s.controller.Stop(ip); err != nil {
+			stop.logger.Warn("Auto-stop failed to stop speaker", "ip", ip, "error", err)
+			continue
+		}
+		stop.logger.Info("Auto-stopped routine", "ip", ip)
+	}
+}
+
+// restoreGroups puts the run's speakers back in the groups they were in before it, if
+// its scene grouped them and they haven't been put back already.
+func (s *AutoStopper) restoreGroups(stop autoStop) {
+	if s.groups == nil || stop.sceneExecutionID == "" {
+		return
+	}
+	for _, result := range s.groups.RestoreExecutionGroups(context.Background(), stop.sceneExecutionID) {
+		if !result.Success {
+			stop.logger.Warn("Auto-stop failed to restore group", "udn", result.UDN, "error", result.Error)
+		}
+	}
+}
+
+// matches reports whether a speaker's transport URI is still the routine's playback.
+// Grouped members follow their coordinator, so only the coordinator is stopped.
+func (stop autoStop) matches(currentURI string) bool {
+	if currentURI == "" || isGroupMemberURI(currentURI) {
+		return false
+	}
+	if stop.usesQueue {
+		// Container content plays from the queue rather than its own URI
+		return strings.HasPrefix(currentURI, "x-rincon-queue:")
+	}
+	if stop.expectedURI == "" {
+		return true
+	}
+	return currentURI == stop.expectedURI
+}
+
+// isGroupMemberURI reports whether a transport URI points at a group coordinator.
+func isGroupMemberURI(uri string) bool {
+	return strings.HasPrefix(uri, "x-rincon:")
+}
@@ -0,0 +1,163 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/scene"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+type fakePlaybackController struct {
+	mu      sync.Mutex
+	uris    map[string]string
+	stopped []string
+}
+
+func (f *fakePlaybackController) GetMediaInfo(deviceIP string) (soap.MediaInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return soap.MediaInfo{CurrentURI: f.uris[deviceIP]}, nil
+}
+
+func (f *fakePlaybackController) Stop(deviceIP string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stopped = append(f.stopped, deviceIP)
+	return nil
+}
+
+func (f *fakePlaybackController) stoppedIPs() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.stopped...)
+}
+
+type fakeIPResolver map[string]string
+
+func (f fakeIPResolver) ResolveDeviceIP(udn string) (string, error) {
+	return f[udn], nil
+}
+
+func newTestAutoStopper(uris map[string]string) (*AutoStopper, *fakePlaybackController) {
+	controller := &fakePlaybackController{uris: uris}
+	resolver := fakeIPResolver{"udn-kitchen": "10.0.0.1", "udn-den": "10.0.0.2"}
+	return NewAutoStopper(controller, resolver, logging.Discard()), controller
+}
+
+var autoStopSpeakers = []Speaker{{UDN: "udn-kitchen"}, {UDN: "udn-den"}}
+
+func autoStopRoutine() *Routine {
+	return &Routine{RoutineID: "routine-1", SpeakersJSON: autoStopSpeakers}
+}
+
+func TestAutoStopper_StopsMatchingPlayback(t *testing.T) {
+	stopper, controller := newTestAutoStopper(map[string]string{
+		"10.0.0.1": "x-sonos-spotify:track1",
+		"10.0.0.2": "x-rincon:RINCON_KITCHEN",
+	})
+
+	stopper.Schedule(context.Background(), autoStopRoutine(), autoStopSpeakers, "", &scene.MusicContent{URI: "x-sonos-spotify:track1"}, 10*time.Millisecond)
+	require.True(t, stopper.Pending("routine-1"))
+
+	require.Eventually(t, func() bool { return !stopper.Pending("routine-1") }, time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool { return len(controller.stoppedIPs()) > 0 }, time.Second, 5*time.Millisecond)
+	// The grouped member follows its coordinator and is not stopped directly
+	require.Equal(t, []string{"10.0.0.1"}, controller.stoppedIPs())
+}
+
+func TestAutoStopper_LeavesChangedPlayback(t *testing.T) {
+	stopper, controller := newTestAutoStopper(map[string]string{
+		"10.0.0.1": "x-sonos-spotify:something-else",
+	})
+
+	stopper.Schedule(context.Background(), autoStopRoutine(), autoStopSpeakers, "", &scene.MusicContent{URI: "x-sonos-spotify:track1"}, 0)
+	require.Eventually(t, func() bool { return !stopper.Pending("routine-1") }, time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	require.Empty(t, controller.stoppedIPs())
+}
+
+func TestAutoStopper_Cancel(t *testing.T) {
+	stopper, controller := newTestAutoStopper(map[string]string{"10.0.0.1": "x-sonos-spotify:track1"})
+
+	stopper.Schedule(context.Background(), autoStopRoutine(), autoStopSpeakers, "", &scene.MusicContent{URI: "x-sonos-spotify:track1"}, 20*time.Millisecond)
+	stopper.Cancel("routine-1")
+	require.False(t, stopper.Pending("routine-1"))
+
+	time.Sleep(50 * time.Millisecond)
+	require.Empty(t, controller.stoppedIPs())
+}
+
+type fakeGroupRestorer struct {
+	mu       sync.Mutex
+	restored []string
+}
+
+func (f *fakeGroupRestorer) RestoreExecutionGroups(ctx context.Context, sceneExecutionID string) []scene.DeviceResult {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.restored = append(f.restored, sceneExecutionID)
+	return nil
+}
+
+func (f *fakeGroupRestorer) restoredExecutions() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.restored...)
+}
+
+func TestAutoStopper_RestoresGroups(t *testing.T) {
+	stopper, controller := newTestAutoStopper(map[string]string{"10.0.0.1": "x-sonos-spotify:track1"})
+	groups := &fakeGroupRestorer{}
+	stopper.SetGroupRestorer(groups)
+
+	stopper.Schedule(context.Background(), autoStopRoutine(), autoStopSpeakers, "exec-1", &scene.MusicContent{URI: "x-sonos-spotify:track1"}, 0)
+	require.Eventually(t, func() bool { return len(groups.restoredExecutions()) > 0 }, time.Second, 5*time.Millisecond)
+	require.Equal(t, []string{"exec-1"}, groups.restoredExecutions())
+	require.Equal(t, []string{"10.0.0.1"}, controller.stoppedIPs(), "speakers are stopped before they're regrouped")
+}
+
+func TestAutoStopper_NoSpeakers(t *testing.T) {
+	stopper, _ := newTestAutoStopper(nil)
+	stopper.Schedule(context.Background(), &Routine{RoutineID: "routine-1"}, nil, "", nil, time.Minute)
+	require.False(t, stopper.Pending("routine-1"))
+}
+
+func TestAutoStopper_SceneOnlyRoutine(t *testing.T) {
+	stopper, controller := newTestAutoStopper(map[string]string{"10.0.0.2": "x-sonos-spotify:track1"})
+
+	// A routine created from a bare scene_id stops the scene's members
+	routine := &Routine{RoutineID: "routine-1", SceneID: "scene-1"}
+	members := speakersOf(routine, &scene.Scene{Members: []scene.SceneMember{{UDN: "udn-den"}}})
+	stopper.Schedule(context.Background(), routine, members, "", &scene.MusicContent{URI: "x-sonos-spotify:track1"}, 0)
+	require.Eventually(t, func() bool { return len(controller.stoppedIPs()) > 0 }, time.Second, 5*time.Millisecond)
+	require.Equal(t, []string{"10.0.0.2"}, controller.stoppedIPs())
+}
+
+func TestAutoStop_Matches(t *testing.T) {
+	tests := []struct {
+		name    string
+		stop    autoStop
+		current string
+		want    bool
+	}{
+		{"same uri", autoStop{expectedURI: "x-sonos-http:a"}, "x-sonos-http:a", true},
+		{"different uri", autoStop{expectedURI: "x-sonos-http:a"}, "x-sonos-http:b", false},
+		{"idle", autoStop{expectedURI: "x-sonos-http:a"}, "", false},
+		{"group member", autoStop{}, "x-rincon:RINCON_1", false},
+		{"queue content", autoStop{expectedURI: "x-rincon-cpcontainer:a", usesQueue: true}, "x-rincon-queue:RINCON_1#0", true},
+		{"queue content replaced by stream", autoStop{expectedURI: "x-rincon-cpcontainer:a", usesQueue: true}, "x-sonosapi-stream:b", false},
+		{"no music content", autoStop{}, "x-sonosapi-stream:b", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.stop.matches(tt.current))
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+)
+
+// FailureReasonDevicesOffline is the failure_reason of jobs that failed with a
+// DevicesOfflineError.
+const FailureReasonDevicesOffline = "devices_offline"
+
+// DevicesOfflineError is returned by ExecuteRoutine when none of the speakers the routine
+// targets is online. The job fails with it, and is retried like any other failure, since
+// the speakers may come back before the next attempt.
+type DevicesOfflineError struct {
+	UDNs  []string
+	Rooms []string // Room names where known, otherwise UDNs
+}
+
+func (e *DevicesOfflineError) Error() string {
+	return "Speakers offline: " + strings.Join(e.Rooms, ", ")
+}
+
+// jobFailureReason returns the failure_reason stored on a job that failed with err, or
+// "" when the failure has no specific reason.
+func jobFailureReason(err error) string {
+	var offline *DevicesOfflineError
+	if errors.As(err, &offline) {
+		return FailureReasonDevicesOffline
+	}
+	return ""
+}
+
+// offlineSpeakers returns the UDNs of the speakers with no online device to play on: the
+// speaker itself and its fallback, if any, are both in offline.
+func offlineSpeakers(speakers []Speaker, offline []string) []string {
+	isOffline := make(map[string]bool, len(offline))
+	for _, udn := range offline {
+		isOffline[udn] = true
+	}
+
+	var udns []string
+	for _, speaker := range speakers {
+		if !isOffline[speaker.UDN] {
+			continue
+		}
+		if speaker.FallbackUDN != "" && !isOffline[speaker.FallbackUDN] {
+			continue
+		}
+		udns = append(udns, speaker.UDN)
+	}
+	return udns
+}
+
+// checkSpeakersOnline returns the UDNs of the speakers with no online device to play on,
+// for the run to leave out, or a DevicesOfflineError when that is all of them. A failed
+// lookup is logged and leaves nothing out, since the scene execution reports unreachable
+// speakers itself.
+func (a *RoutineExecutorAdapter) checkSpeakersOnline(ctx context.Context, speakers []Speaker) ([]string, error) {
+	if a.deviceService == nil || len(speakers) == 0 {
+		return nil, nil
+	}
+
+	udns := make([]string, 0, len(speakers)*2)
+	for _, speaker := range speakers {
+		udns = append(udns, speaker.UDN)
+		if speaker.FallbackUDN != "" {
+			udns = append(udns, speaker.FallbackUDN)
+		}
+	}
+	offline, err := a.deviceService.OfflineDevices(udns)
+	if err != nil {
+		logging.From(ctx, a.logger).Warn("Failed to check speakers are online", "error", err)
+		return nil, nil
+	}
+
+	unavailable := offlineSpeakers(speakers, offline)
+	if len(unavailable) < len(speakers) {
+		return unavailable, nil
+	}
+	roomNames := buildDeviceRoomMap(a.deviceService)
+	rooms := make([]string, 0, len(unavailable))
+	for _, udn := range unavailable {
+		if name := roomNames[udn]; name != "" {
+			rooms = append(rooms, name)
+		} else {
+			rooms = append(rooms, udn)
+		}
+	}
+	return nil, &DevicesOfflineError{UDNs: unavailable, Rooms: rooms}
+}
@@ -0,0 +1,81 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/config"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/scene"
+)
+
+func TestOfflineSpeakers(t *testing.T) {
+	speakers := []Speaker{
+		{UDN: "udn-kitchen"},
+		{UDN: "udn-patio", FallbackUDN: "udn-den"},
+		{UDN: "udn-garage", FallbackUDN: "udn-attic"},
+		{UDN: "udn-office"},
+	}
+
+	require.Empty(t, offlineSpeakers(speakers, nil))
+	require.Equal(t, []string{"udn-kitchen", "udn-garage"},
+		offlineSpeakers(speakers, []string{"udn-kitchen", "udn-patio", "udn-garage", "udn-attic"}),
+		"a speaker whose fallback is online can still play")
+}
+
+func TestSpeakersOf(t *testing.T) {
+	twenty := 20
+	routineScene := &scene.Scene{Members: []scene.SceneMember{
+		{UDN: "udn-kitchen", TargetVolume: &twenty, FallbackUDN: "udn-den"},
+		{UDN: "udn-patio"},
+	}}
+
+	require.Equal(t, []Speaker{
+		{UDN: "udn-kitchen", Volume: &twenty, FallbackUDN: "udn-den"},
+		{UDN: "udn-patio"},
+	}, speakersOf(&Routine{SceneID: "scene-1"}, routineScene), "routines from a bare scene_id play on its members")
+
+	own := []Speaker{{UDN: "udn-office"}}
+	require.Equal(t, own, speakersOf(&Routine{SpeakersJSON: own}, routineScene))
+	require.Empty(t, speakersOf(&Routine{SceneID: "scene-1"}, nil))
+
+	t.Run("the adapter looks up the scene", func(t *testing.T) {
+		sceneService := scene.NewService(config.Config{}, setupRunnerTestDB(t), nil, nil, nil)
+		created, err := sceneService.CreateScene(scene.CreateSceneInput{Name: "Den", Members: []scene.SceneMember{{UDN: "udn-den"}}})
+		require.NoError(t, err)
+
+		adapter := &RoutineExecutorAdapter{logger: logging.Discard()}
+		require.Empty(t, adapter.speakers(context.Background(), &Routine{SceneID: created.SceneID}))
+		adapter.SetSceneService(sceneService)
+		require.Equal(t, []Speaker{{UDN: "udn-den"}}, adapter.speakers(context.Background(), &Routine{SceneID: created.SceneID}))
+	})
+}
+
+func TestFormatJobAsExecution_DevicesOffline(t *testing.T) {
+	offlineErr := fmt.Errorf("run: %w", &DevicesOfflineError{UDNs: []string{"udn-kitchen"}, Rooms: []string{"Kitchen"}})
+	require.Equal(t, FailureReasonDevicesOffline, jobFailureReason(offlineErr))
+	require.Empty(t, jobFailureReason(fmt.Errorf("Speakers offline: Kitchen")), "the message alone is not a reason")
+
+	dbPair := setupRunnerTestDB(t)
+	routine := createTestRoutine(t, NewRoutinesRepository(dbPair), createTestScene(t, dbPair))
+	jobsRepo := NewJobsRepository(dbPair)
+	job := createTestJob(t, jobsRepo, routine.RoutineID, time.Now().UTC())
+	require.NoError(t, jobsRepo.FailJobWithReason(job.JobID, offlineErr.Error(), jobFailureReason(offlineErr), false))
+
+	job, err := jobsRepo.GetByID(job.JobID)
+	require.NoError(t, err)
+	formatted := formatJobAsExecution(job, nil)
+	require.Equal(t, "devices_offline", formatted["failure_reason"])
+	require.Equal(t, "run: Speakers offline: Kitchen", formatted["failure_message"])
+
+	// A later attempt failing for another reason replaces it
+	require.NoError(t, jobsRepo.FailJob(job.JobID, "scene execution failed", false))
+	job, err = jobsRepo.GetByID(job.JobID)
+	require.NoError(t, err)
+	require.Nil(t, job.FailureReason)
+	require.Equal(t, "execution_failed", formatJobAsExecution(job, nil)["failure_reason"])
+}
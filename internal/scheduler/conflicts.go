@@ -0,0 +1,266 @@
+package scheduler
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/devices"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/scene"
+)
+
+// ConflictWindow is how close two routines' runs on a shared speaker must be to
+// fight over its transport.
+const ConflictWindow = 2 * time.Minute
+
+const (
+	// conflictHorizon is how far ahead runs are compared; a year covers monthly and
+	// yearly schedules.
+	conflictHorizon = 366 * 24 * time.Hour
+	// maxConflictRuns bounds the runs computed for each routine within the horizon.
+	maxConflictRuns = 1000
+)
+
+// RoutineConflict is two enabled routines due to run on a shared speaker within
+// ConflictWindow of each other.
+type RoutineConflict struct {
+	Routine    *Routine
+	Other      *Routine
+	SharedUDNs []string
+	RunAt      time.Time // Routine's first run that overlaps one of Other's
+	OtherRunAt time.Time // The run of Other it overlaps
+}
+
+// conflictCandidate is a routine with its speakers and, once computed, its upcoming runs.
+type conflictCandidate struct {
+	routine *Routine
+	udns    []string
+	runs    []time.Time
+	hasRuns bool
+}
+
+// RoutineConflicts returns the conflicts between routine and the other enabled
+// routines, one per other routine. Runs are compared as instants, so routines in
+// different timezones conflict when their local times line up. speakersOf returns a
+// routine's speaker UDNs. A disabled routine has no conflicts.
+func (g *JobGenerator) RoutineConflicts(routine *Routine, others []Routine, speakersOf func(*Routine) []string, now time.Time) []RoutineConflict {
+	if !routine.Enabled {
+		return nil
+	}
+
+	candidate := &conflictCandidate{routine: routine, udns: speakersOf(routine)}
+	var conflicts []RoutineConflict
+	for i := range others {
+		other := &others[i]
+		if other.RoutineID == routine.RoutineID || !other.Enabled {
+			continue
+		}
+		otherCandidate := &conflictCandidate{routine: other, udns: speakersOf(other)}
+		if conflict := g.conflictBetween(candidate, otherCandidate, now); conflict != nil {
+			conflicts = append(conflicts, *conflict)
+		}
+	}
+	return conflicts
+}
+
+// AllConflicts returns every conflicting pair among the enabled routines, ordered by
+// when the conflict next happens.
+func (g *JobGenerator) AllConflicts(routines []Routine, speakersOf func(*Routine) []string, now time.Time) []RoutineConflict {
+	candidates := make([]*conflictCandidate, 0, len(routines))
+	for i := range routines {
+		if routines[i].Enabled {
+			candidates = append(candidates, &conflictCandidate{routine: &routines[i], udns: speakersOf(&routines[i])})
+		}
+	}
+
+	var conflicts []RoutineConflict
+	for i := range candidates {
+		for j := i + 1; j < len(candidates); j++ {
+			if conflict := g.conflictBetween(candidates[i], candidates[j], now); conflict != nil {
+				conflicts = append(conflicts, *conflict)
+			}
+		}
+	}
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		return conflicts[i].RunAt.Before(conflicts[j].RunAt)
+	})
+	return conflicts
+}
+
+// conflictBetween reports the first time a and b run within ConflictWindow of each
+// other on a shared speaker, or nil when they don't.
+func (g *JobGenerator) conflictBetween(a, b *conflictCandidate, now time.Time) *RoutineConflict {
+	shared := sharedUDNs(a.udns, b.udns)
+	if len(shared) == 0 {
+		return nil
+	}
+
+	runsA, runsB := g.candidateRuns(a, now), g.candidateRuns(b, now)
+	i, j := 0, 0
+	for i < len(runsA) && j < len(runsB) {
+		gap := runsA[i].Sub(runsB[j])
+		if gap.Abs() <= ConflictWindow {
+			return &RoutineConflict{
+				Routine:    a.routine,
+				Other:      b.routine,
+				SharedUDNs: shared,
+				RunAt:      runsA[i].UTC(),
+				OtherRunAt: runsB[j].UTC(),
+			}
+		}
+		if gap < 0 {
+			i++
+		} else {
+			j++
+		}
+	}
+	return nil
+}
+
+// candidateRuns computes the candidate's runs within the horizon from its schedule
+// alone, the first time they're needed. Snooze, skip_next and holidays aren't applied:
+// they move single runs, not the schedule.
+func (g *JobGenerator) candidateRuns(candidate *conflictCandidate, now time.Time) []time.Time {
+	if candidate.hasRuns {
+		return candidate.runs
+	}
+	candidate.hasRuns = true
+
+	generator := &JobGenerator{}
+	if g != nil {
+		generator.coordinates = g.coordinates
+	}
+	until := now.Add(conflictHorizon)
+	after := now
+	for len(candidate.runs) < maxConflictRuns {
+		next, err := generator.CalculateNextRun(candidate.routine, after)
+		if err != nil || next.IsZero() || !next.After(after) || next.After(until) {
+			break
+		}
+		candidate.runs = append(candidate.runs, next)
+		after = next
+	}
+	return candidate.runs
+}
+
+// sharedUDNs returns the UDNs in both a and b, in a's order.
+func sharedUDNs(a, b []string) []string {
+	inB := make(map[string]bool, len(b))
+	for _, udn := range b {
+		inB[udn] = true
+	}
+	var shared []string
+	for _, udn := range a {
+		if inB[udn] {
+			shared = append(shared, udn)
+			delete(inB, udn)
+		}
+	}
+	return shared
+}
+
+// routineSpeakers returns a function giving a routine's speaker UDNs: its speakers, or
+// for routines created from a bare scene_id, the scene's members. sceneService may be nil.
+func routineSpeakers(sceneService *scene.Service) func(*Routine) []string {
+	sceneMembers := make(map[string][]string)
+	return func(routine *Routine) []string {
+		if len(routine.SpeakersJSON) > 0 {
+			udns := make([]string, 0, len(routine.SpeakersJSON))
+			for _, speaker := range routine.SpeakersJSON {
+				udns = append(udns, speaker.UDN)
+			}
+			return udns
+		}
+		if sceneService == nil || routine.SceneID == "" {
+			return nil
+		}
+		if udns, ok := sceneMembers[routine.SceneID]; ok {
+			return udns
+		}
+		var udns []string
+		if s, err := sceneService.GetScene(routine.SceneID); err == nil && s != nil {
+			for _, member := range s.Members {
+				udns = append(udns, member.UDN)
+			}
+		}
+		sceneMembers[routine.SceneID] = udns
+		return udns
+	}
+}
+
+// routineConflictWarnings lists routine's conflicts as warnings for the create and update
+// responses. Conflicts don't block saving; a failure to check is logged and gives no warnings.
+func routineConflictWarnings(r *http.Request, routine *Routine, routinesRepo *RoutinesRepository, sceneService *scene.Service, nextRuns *JobGenerator, deviceRoomMap map[string]string) []map[string]any {
+	warnings := []map[string]any{}
+	routines, _, err := routinesRepo.List(1000, 0, true)
+	if err != nil {
+		logging.From(r.Context(), nil).Warn("Failed to check routine conflicts", "routine_id", routine.RoutineID, "error", err)
+		return warnings
+	}
+
+	for _, conflict := range nextRuns.RoutineConflicts(routine, routines, routineSpeakers(sceneService), time.Now()) {
+		rooms := roomNamesFor(conflict.SharedUDNs, deviceRoomMap)
+		warnings = append(warnings, map[string]any{
+			"code": "routine_conflict",
+			"message": fmt.Sprintf("Runs within %d minutes of %q on %s", int(ConflictWindow.Minutes()),
+				conflict.Other.Name, strings.Join(rooms, ", ")),
+			"run_at":       api.RFC3339Millis(conflict.RunAt),
+			"shared_udns":  conflict.SharedUDNs,
+			"shared_rooms": rooms,
+			"conflicting_routine": map[string]any{
+				"id":     conflict.Other.RoutineID,
+				"name":   conflict.Other.Name,
+				"run_at": api.RFC3339Millis(conflict.OtherRunAt),
+			},
+		})
+	}
+	return warnings
+}
+
+// listRoutineConflicts handles GET /v1/routines/conflicts
+// It lists every pair of enabled routines due to run on a shared speaker within
+// ConflictWindow of each other, soonest first.
+func listRoutineConflicts(routinesRepo *RoutinesRepository, sceneService *scene.Service, deviceService *devices.Service, nextRuns *JobGenerator) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		routines, _, err := routinesRepo.List(1000, 0, true)
+		if err != nil {
+			logging.From(r.Context(), nil).Error("Failed to list routines for conflicts", "error", err)
+			return apperrors.NewInternalError("Failed to list routines")
+		}
+
+		deviceRoomMap := buildDeviceRoomMap(deviceService)
+		conflicts := nextRuns.AllConflicts(routines, routineSpeakers(sceneService), time.Now())
+		formatted := make([]map[string]any, 0, len(conflicts))
+		for _, conflict := range conflicts {
+			formatted = append(formatted, map[string]any{
+				"object": api.ObjectRoutineConflict,
+				"routines": []map[string]any{
+					{"id": conflict.Routine.RoutineID, "name": conflict.Routine.Name, "run_at": api.RFC3339Millis(conflict.RunAt)},
+					{"id": conflict.Other.RoutineID, "name": conflict.Other.Name, "run_at": api.RFC3339Millis(conflict.OtherRunAt)},
+				},
+				"shared_udns":  conflict.SharedUDNs,
+				"shared_rooms": roomNamesFor(conflict.SharedUDNs, deviceRoomMap),
+			})
+		}
+
+		return api.WriteList(w, "/v1/routines/conflicts", formatted, false)
+	}
+}
+
+// roomNamesFor names each UDN's room, falling back to the UDN for unknown speakers.
+func roomNamesFor(udns []string, deviceRoomMap map[string]string) []string {
+	rooms := make([]string, 0, len(udns))
+	for _, udn := range udns {
+		if name := deviceRoomMap[udn]; name != "" {
+			rooms = append(rooms, name)
+		} else {
+			rooms = append(rooms, udn)
+		}
+	}
+	return rooms
+}
@@ -0,0 +1,163 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/config"
+	"github.com/strefethen/sonos-hub-go/internal/db"
+	"github.com/strefethen/sonos-hub-go/internal/scene"
+)
+
+func conflictRoutine(id, timezone, scheduleTime string, weekdays []int, udns ...string) Routine {
+	speakers := make([]Speaker, 0, len(udns))
+	for _, udn := range udns {
+		speakers = append(speakers, Speaker{UDN: udn})
+	}
+	return Routine{
+		RoutineID:        id,
+		Name:             id,
+		Enabled:          true,
+		Timezone:         timezone,
+		ScheduleType:     ScheduleTypeWeekly,
+		ScheduleWeekdays: weekdays,
+		ScheduleTime:     scheduleTime,
+		SpeakersJSON:     speakers,
+	}
+}
+
+func TestRoutineConflicts(t *testing.T) {
+	// Thursday
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	weekdays := []int{1, 2, 3, 4, 5}
+	routine := conflictRoutine("kitchen-wake", "America/Los_Angeles", "07:00", weekdays, "RINCON_KITCHEN", "RINCON_DEN")
+
+	tests := []struct {
+		name  string
+		other Routine
+		want  bool
+	}{
+		{"same time and speaker", conflictRoutine("other", "America/Los_Angeles", "07:00", weekdays, "RINCON_KITCHEN"), true},
+		{"within two minutes", conflictRoutine("other", "America/Los_Angeles", "07:02", []int{3}, "RINCON_DEN"), true},
+		{"three minutes apart", conflictRoutine("other", "America/Los_Angeles", "07:03", weekdays, "RINCON_KITCHEN"), false},
+		{"different speakers", conflictRoutine("other", "America/Los_Angeles", "07:00", weekdays, "RINCON_OFFICE"), false},
+		{"different days", conflictRoutine("other", "America/Los_Angeles", "07:00", []int{0, 6}, "RINCON_KITCHEN"), false},
+		{"same instant in another timezone", conflictRoutine("other", "America/New_York", "10:01", weekdays, "RINCON_KITCHEN"), true},
+		{"same local time in another timezone", conflictRoutine("other", "America/New_York", "07:00", weekdays, "RINCON_KITCHEN"), false},
+		{"disabled", func() Routine {
+			other := conflictRoutine("other", "America/Los_Angeles", "07:00", weekdays, "RINCON_KITCHEN")
+			other.Enabled = false
+			return other
+		}(), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			others := []Routine{routine, tt.other}
+			conflicts := (*JobGenerator)(nil).RoutineConflicts(&routine, others, routineSpeakers(nil), now)
+			if !tt.want {
+				require.Empty(t, conflicts)
+				return
+			}
+			require.Len(t, conflicts, 1)
+			require.Equal(t, "other", conflicts[0].Other.RoutineID)
+			require.NotEmpty(t, conflicts[0].SharedUDNs)
+			require.LessOrEqual(t, conflicts[0].RunAt.Sub(conflicts[0].OtherRunAt).Abs(), ConflictWindow)
+		})
+	}
+
+	t.Run("first conflicting run", func(t *testing.T) {
+		other := conflictRoutine("other", "America/Los_Angeles", "07:01", []int{1}, "RINCON_KITCHEN")
+		conflicts := (*JobGenerator)(nil).RoutineConflicts(&routine, []Routine{other}, routineSpeakers(nil), now)
+		require.Len(t, conflicts, 1)
+		require.Equal(t, []string{"RINCON_KITCHEN"}, conflicts[0].SharedUDNs)
+		// Monday January 5th, 07:00 Pacific
+		require.Equal(t, time.Date(2026, 1, 5, 15, 0, 0, 0, time.UTC), conflicts[0].RunAt)
+		require.Equal(t, time.Date(2026, 1, 5, 15, 1, 0, 0, time.UTC), conflicts[0].OtherRunAt)
+	})
+
+	t.Run("disabled routine has no conflicts", func(t *testing.T) {
+		disabled := routine
+		disabled.Enabled = false
+		other := conflictRoutine("other", "America/Los_Angeles", "07:00", weekdays, "RINCON_KITCHEN")
+		require.Empty(t, (*JobGenerator)(nil).RoutineConflicts(&disabled, []Routine{other}, routineSpeakers(nil), now))
+	})
+}
+
+func TestAllConflicts(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	routines := []Routine{
+		conflictRoutine("weekend", "UTC", "09:00", []int{0, 6}, "RINCON_KITCHEN"),
+		conflictRoutine("saturday", "UTC", "09:01", []int{6}, "RINCON_KITCHEN"),
+		conflictRoutine("weekday", "UTC", "07:00", []int{1, 2, 3, 4, 5}, "RINCON_KITCHEN"),
+		conflictRoutine("friday", "UTC", "07:00", []int{5}, "RINCON_KITCHEN", "RINCON_DEN"),
+		conflictRoutine("den", "UTC", "07:00", []int{5}, "RINCON_DEN"),
+	}
+
+	conflicts := (*JobGenerator)(nil).AllConflicts(routines, routineSpeakers(nil), now)
+	pairs := make([]string, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		pairs = append(pairs, conflict.Routine.RoutineID+"/"+conflict.Other.RoutineID)
+	}
+	// Soonest first: Friday the 2nd, then Saturday the 3rd
+	require.Equal(t, []string{"weekday/friday", "friday/den", "weekend/saturday"}, pairs)
+}
+
+func TestRoutineConflictRoutes(t *testing.T) {
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+
+	router := chi.NewRouter()
+	RegisterRoutes(router, RouteDeps{
+		Routines: NewRoutinesRepository(dbPair),
+		Jobs:     NewJobsRepository(dbPair),
+		Holidays: NewHolidaysRepository(dbPair),
+		Scenes:   scene.NewService(config.Config{}, dbPair, nil, nil, nil),
+	})
+
+	serve := func(method, path, body string) map[string]any {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rec, req)
+		require.Less(t, rec.Code, 300, rec.Body.String())
+		var result map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		return result
+	}
+	create := func(name, scheduleTime string) map[string]any {
+		return serve(http.MethodPost, "/v1/routines", `{"name":"`+name+`","timezone":"UTC","schedule_type":"weekly",
+			"schedule_weekdays":[1,2,3,4,5],"schedule_time":"`+scheduleTime+`","speakers":[{"udn":"RINCON_KITCHEN","volume":20}]}`)
+	}
+
+	first := create("Wake Up", "07:00")
+	require.Equal(t, []any{}, first["warnings"])
+
+	second := create("Morning News", "07:01")
+	warnings := second["warnings"].([]any)
+	require.Len(t, warnings, 1)
+	warning := warnings[0].(map[string]any)
+	require.Equal(t, "routine_conflict", warning["code"])
+	require.Equal(t, []any{"RINCON_KITCHEN"}, warning["shared_udns"])
+	require.Equal(t, first["id"], warning["conflicting_routine"].(map[string]any)["id"])
+
+	// Moving it clear of the first routine resolves the conflict
+	updated := serve(http.MethodPut, "/v1/routines/"+second["id"].(string), `{"schedule_time":"07:30"}`)
+	require.Equal(t, []any{}, updated["warnings"])
+
+	create("Coffee Time", "06:59")
+	list := serve(http.MethodGet, "/v1/routines/conflicts", "")
+	data := list["data"].([]any)
+	require.Len(t, data, 1)
+	conflict := data[0].(map[string]any)
+	require.Equal(t, "routine_conflict", conflict["object"])
+	require.Len(t, conflict["routines"], 2)
+}
@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+// TriggerCooldown rate-limits manual trigger/run requests per routine.
+// State is in-memory only; a restart clears all cooldowns.
+type TriggerCooldown struct {
+	mu     sync.Mutex
+	window time.Duration
+	last   map[string]time.Time
+	now    func() time.Time
+}
+
+// NewTriggerCooldown creates a cooldown with the given window.
+// A zero or negative window disables the cooldown.
+func NewTriggerCooldown(window time.Duration) *TriggerCooldown {
+	return &TriggerCooldown{
+		window: window,
+		last:   make(map[string]time.Time),
+		now:    time.Now,
+	}
+}
+
+// Acquire records a trigger for the routine if it is outside the cooldown window.
+// Returns ok=false and the remaining wait when the routine was triggered too recently.
+func (c *TriggerCooldown) Acquire(routineID string) (time.Duration, bool) {
+	if c == nil || c.window <= 0 {
+		return 0, true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if last, exists := c.last[routineID]; exists {
+		if remaining := c.window - now.Sub(last); remaining > 0 {
+			return remaining, false
+		}
+	}
+
+	c.last[routineID] = now
+	c.pruneLocked(now)
+	return 0, true
+}
+
+// Release clears the cooldown for a routine, e.g. when the triggered job could not be created.
+func (c *TriggerCooldown) Release(routineID string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.last, routineID)
+}
+
+// pruneLocked drops expired entries so the map doesn't grow with every routine ever triggered.
+func (c *TriggerCooldown) pruneLocked(now time.Time) {
+	for id, last := range c.last {
+		if now.Sub(last) >= c.window {
+			delete(c.last, id)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTriggerCooldown(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cooldown := NewTriggerCooldown(5 * time.Second)
+	cooldown.now = func() time.Time { return now }
+
+	_, ok := cooldown.Acquire("routine-1")
+	require.True(t, ok)
+
+	now = now.Add(2 * time.Second)
+	remaining, ok := cooldown.Acquire("routine-1")
+	require.False(t, ok)
+	require.Equal(t, 3*time.Second, remaining)
+
+	// Cooldown is per-routine
+	_, ok = cooldown.Acquire("routine-2")
+	require.True(t, ok)
+
+	now = now.Add(3 * time.Second)
+	_, ok = cooldown.Acquire("routine-1")
+	require.True(t, ok)
+}
+
+func TestTriggerCooldown_Release(t *testing.T) {
+	cooldown := NewTriggerCooldown(time.Minute)
+
+	_, ok := cooldown.Acquire("routine-1")
+	require.True(t, ok)
+
+	cooldown.Release("routine-1")
+	_, ok = cooldown.Acquire("routine-1")
+	require.True(t, ok)
+}
+
+func TestTriggerCooldown_Disabled(t *testing.T) {
+	cooldown := NewTriggerCooldown(0)
+	for i := 0; i < 3; i++ {
+		_, ok := cooldown.Acquire("routine-1")
+		require.True(t, ok)
+	}
+
+	var nilCooldown *TriggerCooldown
+	_, ok := nilCooldown.Acquire("routine-1")
+	require.True(t, ok)
+}
@@ -0,0 +1,290 @@
+package scheduler
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/scene"
+)
+
+// PlanBlocker names what would stop a routine from playing.
+type PlanBlocker string
+
+const (
+	PlanBlockerDisabled       PlanBlocker = "disabled"
+	PlanBlockerSnoozed        PlanBlocker = "snoozed"
+	PlanBlockerSkipNext       PlanBlocker = "skip_next"
+	PlanBlockerHoliday        PlanBlocker = "holiday"
+	PlanBlockerDevicesOffline PlanBlocker = "devices_offline"
+	PlanBlockerTVPolicy       PlanBlocker = "tv_policy"
+	PlanBlockerOccasion       PlanBlocker = "occasion"
+)
+
+// RoutinePlan is what running a routine now would do, resolved without playing anything,
+// advancing music set rotations, or writing history.
+type RoutinePlan struct {
+	Object    string `json:"object"` // Always "routine_plan"
+	RoutineID string `json:"routine_id"`
+
+	// WouldRun is false when anything in Blockers would stop the run. The schedule's
+	// blockers only apply to today's scheduled run; the rest apply to any run.
+	WouldRun bool          `json:"would_run"`
+	Blockers []PlanBlocker `json:"blockers"`
+
+	Schedule PlanSchedule      `json:"schedule"`
+	Devices  []PlanDevice      `json:"devices"`
+	TVPolicy *TVPolicyDecision `json:"tv_policy,omitempty"`
+	Music    *PlanMusic        `json:"music,omitempty"`   // Nil for routines that play nothing
+	Actions  []RoutineAction   `json:"actions,omitempty"` // What routines that play nothing do instead
+	Volumes  []PlanVolume      `json:"volumes"`
+	Wake     *WakeProfile      `json:"wake,omitempty"` // Volumes start at start_volume and ramp up
+}
+
+// PlanSchedule is whether the routine's scheduled run today goes ahead.
+type PlanSchedule struct {
+	Enabled        bool     `json:"enabled"`
+	ScheduledToday bool     `json:"scheduled_today"`
+	NextRunAt      *string  `json:"next_run_at"` // In the routine's timezone; nil when nothing is scheduled
+	SkipReason     *string  `json:"skip_reason"` // Why the next run won't happen: holiday, snoozed or skip_next
+	Holiday        *Holiday `json:"holiday,omitempty"`
+	DelayedUntil   *string  `json:"delayed_until,omitempty"` // Where holiday_behavior DELAY moves the next run
+}
+
+// PlanDevice is one of the routine's speakers.
+type PlanDevice struct {
+	UDN         string `json:"udn"`
+	RoomName    string `json:"room_name,omitempty"`
+	Online      bool   `json:"online"`
+	TVMode      bool   `json:"tv_mode"`
+	FallbackUDN string `json:"fallback_udn,omitempty"`
+
+	// Whether the run would play on this speaker or its fallback
+	Plays bool `json:"plays"`
+}
+
+// PlanMusic is the music the run would play.
+type PlanMusic struct {
+	PolicyType MusicPolicyType   `json:"policy_type"`
+	Content    *ExecutionContent `json:"content"` // Nil when nothing would play
+
+	// For music from a set, the item picked. Shuffle picks at random, so a run may pick
+	// another item; recently_played_skipped counts the items the no-repeat window ruled out.
+	MusicSetID            string       `json:"music_set_id,omitempty"`
+	Item                  *PlanSetItem `json:"item,omitempty"`
+	Shuffled              bool         `json:"shuffled,omitempty"`
+	RecentlyPlayedSkipped int          `json:"recently_played_skipped,omitempty"`
+
+	HolidayOverride *HolidayOverride  `json:"holiday_override,omitempty"`
+	Occasion        *OccasionDecision `json:"occasion,omitempty"`
+
+	// Why the content couldn't be resolved; the scene would still run, without music
+	Error string `json:"error,omitempty"`
+}
+
+// PlanSetItem is the music set item a run would play.
+type PlanSetItem struct {
+	Position        int     `json:"position"`
+	SonosFavoriteID string  `json:"sonos_favorite_id"`
+	DisplayName     *string `json:"display_name,omitempty"`
+	ContentType     string  `json:"content_type"`
+}
+
+// PlanVolume is the volume a speaker would be set to. Speakers without a target volume
+// keep their current volume.
+type PlanVolume struct {
+	UDN      string `json:"udn"`
+	RoomName string `json:"room_name,omitempty"`
+	Volume   *int   `json:"volume"`
+	FadeInMs *int   `json:"fade_in_ms,omitempty"`
+}
+
+// PlanRoutine resolves what running the routine at now would do: whether its next
+// scheduled run goes ahead, its speakers, the TV policy, the music it would pick, and the
+// volumes it would apply. routineScene is the routine's scene, and may be nil, as may
+// nextRuns, in which case holidays aren't checked.
+func (a *RoutineExecutorAdapter) PlanRoutine(ctx context.Context, routine *Routine, routineScene *scene.Scene, nextRuns *JobGenerator, now time.Time) (*RoutinePlan, error) {
+	roomNames := buildDeviceRoomMap(a.deviceService)
+	plan := &RoutinePlan{Object: "routine_plan", RoutineID: routine.RoutineID, Blockers: []PlanBlocker{}, Volumes: []PlanVolume{}}
+	if err := plan.planSchedule(routine, nextRuns, now); err != nil {
+		// The routine can still be triggered by hand, so plan the rest of the run
+		logging.From(ctx, a.logger).Warn("Failed to compute routine's next run", "routine_id", routine.RoutineID, "error", err)
+	}
+
+	// Offline speakers sit the run out; with none online, nothing plays
+	speakers := speakersOf(routine, routineScene)
+	var exclude []string
+	plan.Devices = a.planDevices(ctx, speakers, roomNames)
+	for _, device := range plan.Devices {
+		if !device.Plays {
+			exclude = append(exclude, device.UDN)
+		}
+	}
+	if len(plan.Devices) > 0 && len(exclude) == len(plan.Devices) {
+		plan.Blockers = append(plan.Blockers, PlanBlockerDevicesOffline)
+	}
+
+	// Speakers the TV policy sets aside don't play, or with nothing left, nothing does
+	tvModeUDNs := a.tvModeSpeakers(ctx, speakers)
+	plan.TVPolicy = decideTVPolicy(routine.ArcTVPolicy, tvModeUDNs, len(speakers))
+	if plan.TVPolicy != nil {
+		switch plan.TVPolicy.Action {
+		case TVPolicyActionSkipped:
+			plan.Blockers = append(plan.Blockers, PlanBlockerTVPolicy)
+		case TVPolicyActionUsedFallback:
+			exclude = append(exclude, tvModeUDNs...)
+		}
+	}
+	for i := range plan.Devices {
+		plan.Devices[i].TVMode = slices.Contains(tvModeUDNs, plan.Devices[i].UDN)
+		if slices.Contains(exclude, plan.Devices[i].UDN) {
+			plan.Devices[i].Plays = false
+		}
+	}
+
+	// Routines with actions act on their speakers directly, and run no scene
+	if routine.MusicPolicyType == MusicPolicyTypeNone && len(routine.Actions) > 0 {
+		plan.Actions = routine.Actions
+		plan.WouldRun = len(plan.Blockers) == 0
+		return plan, nil
+	}
+
+	if routine.MusicPolicyType != MusicPolicyTypeNone {
+		plan.Music = a.planMusic(ctx, routine, now)
+		if occasion := plan.Music.Occasion; occasion != nil && occasion.Action == OccasionActionSkipped {
+			plan.Blockers = append(plan.Blockers, PlanBlockerOccasion)
+		}
+	}
+	plan.WouldRun = len(plan.Blockers) == 0
+
+	var startVolume *int
+	if routine.WakeProfile != nil && routine.WakeProfile.RampMinutes > 0 {
+		plan.Wake = routine.WakeProfile
+		startVolume = &routine.WakeProfile.StartVolume
+	}
+	if routineScene != nil {
+		for _, member := range routineScene.Members {
+			if slices.Contains(exclude, member.UDN) {
+				continue
+			}
+			volume := PlanVolume{UDN: member.UDN, RoomName: roomNames[member.UDN], Volume: member.TargetVolume, FadeInMs: member.FadeInMs}
+			if volume.RoomName == "" {
+				volume.RoomName = member.RoomName
+			}
+			if startVolume != nil {
+				volume.Volume, volume.FadeInMs = startVolume, nil
+			}
+			plan.Volumes = append(plan.Volumes, volume)
+		}
+	}
+
+	return plan, nil
+}
+
+// planSchedule fills in the routine's next scheduled run. A disabled routine, or a run
+// today that snooze, skip_next, or a holiday would skip or delay, blocks the plan. When
+// the next run can't be computed, the schedule is left as nothing scheduled.
+func (p *RoutinePlan) planSchedule(routine *Routine, nextRuns *JobGenerator, now time.Time) error {
+	p.Schedule.Enabled = routine.Enabled
+	if !routine.Enabled {
+		p.Blockers = append(p.Blockers, PlanBlockerDisabled)
+	}
+
+	occurrences, err := nextRuns.UpcomingOccurrences(routine, now, 1)
+	if err != nil || len(occurrences) == 0 {
+		return err
+	}
+	next := occurrences[0]
+	loc := inRoutineTimezone(routine, now).Location()
+	nextRunAt := api.RFC3339MillisIn(next.ScheduledFor, loc)
+	p.Schedule.NextRunAt = &nextRunAt
+	p.Schedule.Holiday = next.Holiday
+	if next.SkipReason != "" {
+		reason := string(next.SkipReason)
+		p.Schedule.SkipReason = &reason
+	}
+	if next.DelayedUntil != nil {
+		delayedUntil := api.RFC3339MillisIn(*next.DelayedUntil, loc)
+		p.Schedule.DelayedUntil = &delayedUntil
+	}
+
+	today := now.In(loc)
+	scheduled := next.ScheduledFor.In(loc)
+	p.Schedule.ScheduledToday = scheduled.YearDay() == today.YearDay() && scheduled.Year() == today.Year()
+	if !p.Schedule.ScheduledToday {
+		return nil
+	}
+	switch {
+	case next.SkipReason == OccurrenceSkipSnoozed:
+		p.Blockers = append(p.Blockers, PlanBlockerSnoozed)
+	case next.SkipReason == OccurrenceSkipSkipNext:
+		p.Blockers = append(p.Blockers, PlanBlockerSkipNext)
+	case next.SkipReason == OccurrenceSkipHoliday || next.DelayedUntil != nil:
+		p.Blockers = append(p.Blockers, PlanBlockerHoliday)
+	}
+	return nil
+}
+
+// planDevices reports whether each speaker the routine plays on, and its fallback, is
+// online. As when the routine runs, a failed lookup counts every speaker as online.
+func (a *RoutineExecutorAdapter) planDevices(ctx context.Context, speakers []Speaker, roomNames map[string]string) []PlanDevice {
+	udns := make([]string, 0, len(speakers)*2)
+	for _, speaker := range speakers {
+		udns = append(udns, speaker.UDN)
+		if speaker.FallbackUDN != "" {
+			udns = append(udns, speaker.FallbackUDN)
+		}
+	}
+
+	var offline []string
+	if a.deviceService != nil && len(udns) > 0 {
+		var err error
+		if offline, err = a.deviceService.OfflineDevices(udns); err != nil {
+			logging.From(ctx, a.logger).Warn("Failed to check speakers are online", "error", err)
+			offline = nil
+		}
+	}
+	unavailable := offlineSpeakers(speakers, offline)
+
+	devices := make([]PlanDevice, 0, len(speakers))
+	for _, speaker := range speakers {
+		devices = append(devices, PlanDevice{
+			UDN:         speaker.UDN,
+			RoomName:    roomNames[speaker.UDN],
+			Online:      !slices.Contains(offline, speaker.UDN),
+			FallbackUDN: speaker.FallbackUDN,
+			Plays:       !slices.Contains(unavailable, speaker.UDN),
+		})
+	}
+	return devices
+}
+
+// planMusic resolves the music a run would play, as a dry run: set items are picked
+// without advancing rotations or recording plays.
+func (a *RoutineExecutorAdapter) planMusic(ctx context.Context, routine *Routine, now time.Time) *PlanMusic {
+	planned := &PlanMusic{PolicyType: routine.MusicPolicyType}
+	if a.musicService == nil && (routine.MusicPolicyType == MusicPolicyTypeRotation || routine.MusicPolicyType == MusicPolicyTypeShuffle) {
+		planned.Error = "music sets are not available"
+		return planned
+	}
+
+	resolved, err := a.resolveRoutineMusic(ctx, routine, now, true)
+	if err != nil {
+		planned.Error = err.Error()
+	}
+	if resolved.content != nil {
+		planned.Content = resolved.summary
+	}
+	if selection := resolved.selection; selection != nil && selection.Item != nil {
+		item := selection.Item
+		planned.MusicSetID = item.SetID
+		planned.Item = &PlanSetItem{Position: item.Position, SonosFavoriteID: item.SonosFavoriteID, DisplayName: item.DisplayName, ContentType: item.ContentType}
+		planned.Shuffled = selection.WasShuffled
+		planned.RecentlyPlayedSkipped = selection.RecentlyPlayedSkipped
+	}
+	planned.HolidayOverride = resolved.override
+	planned.Occasion = resolved.occasion
+	return planned
+}
@@ -0,0 +1,176 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/audiofiles"
+	"github.com/strefethen/sonos-hub-go/internal/config"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/music"
+	"github.com/strefethen/sonos-hub-go/internal/scene"
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
+)
+
+func TestRoutineExecutorAdapter_PlanRoutine(t *testing.T) {
+	dbPair := setupRunnerTestDB(t)
+	musicService := music.NewService(config.Config{}, dbPair, logging.Discard())
+	holidaysRepo := NewHolidaysRepository(dbPair)
+	generator := NewJobGenerator(NewRoutinesRepository(dbPair), NewJobsRepository(dbPair), holidaysRepo, logging.Discard())
+
+	// A set of hub audio files, which resolve without a speaker
+	dir := t.TempDir()
+	set, err := musicService.CreateSet(music.CreateSetInput{Name: "Mornings", SelectionPolicy: "ROTATION"})
+	require.NoError(t, err)
+	for _, filename := range []string{"birdsong.mp3", "waves.mp3"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, filename), []byte("ID3"), 0o644))
+		contentJSON := `{"type":"local_file","filename":"` + filename + `","title":"` + filename + `"}`
+		_, err := musicService.AddItem(set.SetID, music.AddItemInput{SonosFavoriteID: "local:" + filename, ContentType: "local_file", ContentJSON: &contentJSON})
+		require.NoError(t, err)
+	}
+	resolver := sonos.NewContentResolver(nil, nil, time.Second, nil)
+	resolver.SetLocalFileProvider(audiofiles.NewLibrary(dir, "http://192.168.1.5:9000"))
+
+	adapter := &RoutineExecutorAdapter{
+		sceneExecutor:   &failingSceneExecutor{t: t},
+		musicService:    musicService,
+		contentResolver: resolver,
+		ipResolver:      fakeIPResolver{"udn-arc": "10.0.0.1", "udn-den": "10.0.0.2"},
+		logger:          logging.Discard(),
+	}
+	adapter.SetTVModeDetector(&fakePlaybackController{uris: map[string]string{"10.0.0.1": "x-sonos-htastream:RINCON_ARC:spdif"}})
+
+	// 06:00 on a Monday, an hour before the routine is due
+	now := time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC)
+	useFallback := string(ArcTVPolicyUseFallback)
+	twenty, fade := 20, 5000
+	routineScene := &scene.Scene{Members: []scene.SceneMember{
+		{UDN: "udn-arc", TargetVolume: &twenty},
+		{UDN: "udn-den", TargetVolume: &twenty, FadeInMs: &fade},
+	}}
+	routine := func() *Routine {
+		return &Routine{
+			RoutineID:        "routine-1",
+			Enabled:          true,
+			Timezone:         "UTC",
+			ScheduleType:     ScheduleTypeWeekly,
+			ScheduleWeekdays: []int{1, 2, 3, 4, 5},
+			ScheduleTime:     "07:00",
+			HolidayBehavior:  HolidayBehaviorSkip,
+			MusicPolicyType:  MusicPolicyTypeRotation,
+			MusicSetID:       &set.SetID,
+			ArcTVPolicy:      &useFallback,
+			SpeakersJSON:     []Speaker{{UDN: "udn-arc"}, {UDN: "udn-den"}},
+		}
+	}
+
+	plan, err := adapter.PlanRoutine(context.Background(), routine(), routineScene, generator, now)
+	require.NoError(t, err)
+	require.True(t, plan.WouldRun)
+	require.Empty(t, plan.Blockers)
+	require.True(t, plan.Schedule.ScheduledToday)
+	require.Equal(t, "2026-03-02T07:00:00.000Z", *plan.Schedule.NextRunAt)
+
+	require.Equal(t, []PlanDevice{
+		{UDN: "udn-arc", Online: true, TVMode: true},
+		{UDN: "udn-den", Online: true, Plays: true},
+	}, plan.Devices)
+	require.Equal(t, TVPolicyActionUsedFallback, plan.TVPolicy.Action)
+	require.Equal(t, []PlanVolume{{UDN: "udn-den", Volume: &twenty, FadeInMs: &fade}}, plan.Volumes, "the TV stays out of it")
+
+	require.Equal(t, "local:birdsong.mp3", plan.Music.Item.SonosFavoriteID)
+	require.Equal(t, "birdsong.mp3", plan.Music.Content.Title)
+	require.Equal(t, "http://192.168.1.5:9000/v1/assets/audio/birdsong.mp3", plan.Music.Content.URI)
+
+	t.Run("leaves the rotation and history alone", func(t *testing.T) {
+		again, err := adapter.PlanRoutine(context.Background(), routine(), routineScene, generator, now)
+		require.NoError(t, err)
+		require.Equal(t, "local:birdsong.mp3", again.Music.Item.SonosFavoriteID)
+
+		unchanged, err := musicService.GetSet(set.SetID)
+		require.NoError(t, err)
+		require.Equal(t, 0, unchanged.CurrentIndex)
+		history, err := musicService.GetPlayHistory(set.SetID, 10)
+		require.NoError(t, err)
+		require.Empty(t, history)
+	})
+
+	t.Run("scene-only routines plan the scene's members", func(t *testing.T) {
+		sceneOnly := routine()
+		sceneOnly.SpeakersJSON = nil
+		plan, err := adapter.PlanRoutine(context.Background(), sceneOnly, routineScene, generator, now)
+		require.NoError(t, err)
+		require.NotNil(t, plan.TVPolicy)
+		require.Equal(t, TVPolicyActionUsedFallback, plan.TVPolicy.Action)
+		require.Equal(t, []string{"udn-arc"}, plan.TVPolicy.TVModeUDNs)
+		require.Equal(t, []PlanDevice{
+			{UDN: "udn-arc", Online: true, TVMode: true},
+			{UDN: "udn-den", Online: true, Plays: true},
+		}, plan.Devices)
+		require.Equal(t, []PlanVolume{{UDN: "udn-den", Volume: &twenty, FadeInMs: &fade}}, plan.Volumes)
+	})
+
+	t.Run("wake profiles start quietly", func(t *testing.T) {
+		wake := routine()
+		wake.WakeProfile = &WakeProfile{StartVolume: 5, EndVolume: 30, RampMinutes: 10}
+		plan, err := adapter.PlanRoutine(context.Background(), wake, routineScene, generator, now)
+		require.NoError(t, err)
+		five := 5
+		require.Equal(t, []PlanVolume{{UDN: "udn-den", Volume: &five}}, plan.Volumes)
+		require.Equal(t, wake.WakeProfile, plan.Wake)
+	})
+
+	t.Run("shuffle skips recently played items", func(t *testing.T) {
+		shuffle, err := musicService.CreateSet(music.CreateSetInput{Name: "Shuffled", SelectionPolicy: "SHUFFLE"})
+		require.NoError(t, err)
+		for _, filename := range []string{"birdsong.mp3", "waves.mp3"} {
+			contentJSON := `{"type":"local_file","filename":"` + filename + `"}`
+			_, err := musicService.AddItem(shuffle.SetID, music.AddItemInput{SonosFavoriteID: "local:" + filename, ContentType: "local_file", ContentJSON: &contentJSON})
+			require.NoError(t, err)
+		}
+		require.NoError(t, musicService.RecordPlay("local:waves.mp3", &shuffle.SetID, nil))
+
+		window := 24 * 60
+		shuffled := routine()
+		shuffled.MusicPolicyType = MusicPolicyTypeShuffle
+		shuffled.MusicSetID = &shuffle.SetID
+		shuffled.MusicNoRepeatWindowMinutes = &window
+		shuffled.MusicNoRepeatScope = music.NoRepeatScopeSet
+		plan, err := adapter.PlanRoutine(context.Background(), shuffled, routineScene, generator, now)
+		require.NoError(t, err)
+		require.Equal(t, "local:birdsong.mp3", plan.Music.Item.SonosFavoriteID)
+		require.True(t, plan.Music.Shuffled)
+		require.Equal(t, 1, plan.Music.RecentlyPlayedSkipped)
+	})
+
+	t.Run("gates that block today's run", func(t *testing.T) {
+		skipped := routine()
+		skipped.SkipNext = true
+		plan, err := adapter.PlanRoutine(context.Background(), skipped, routineScene, generator, now)
+		require.NoError(t, err)
+		require.False(t, plan.WouldRun)
+		require.Equal(t, []PlanBlocker{PlanBlockerSkipNext}, plan.Blockers)
+		require.Equal(t, "skip_next", *plan.Schedule.SkipReason)
+
+		snoozed := routine()
+		snoozed.Enabled = false
+		until := now.Add(2 * time.Hour)
+		snoozed.SnoozeUntil = &until
+		plan, err = adapter.PlanRoutine(context.Background(), snoozed, routineScene, generator, now)
+		require.NoError(t, err)
+		require.Equal(t, []PlanBlocker{PlanBlockerDisabled, PlanBlockerSnoozed}, plan.Blockers)
+
+		_, err = holidaysRepo.Create(CreateHolidayInput{Date: now, Name: "Town Holiday"})
+		require.NoError(t, err)
+		plan, err = adapter.PlanRoutine(context.Background(), routine(), routineScene, generator, now)
+		require.NoError(t, err)
+		require.Equal(t, []PlanBlocker{PlanBlockerHoliday}, plan.Blockers)
+		require.Equal(t, "Town Holiday", plan.Schedule.Holiday.Name)
+		require.NotNil(t, plan.Music.Content, "the rest of the plan is still resolved")
+	})
+}
@@ -0,0 +1,173 @@
+package scheduler
+
+import (
+	"encoding/json"
+
+	"github.com/strefethen/sonos-hub-go/internal/music"
+	"github.com/strefethen/sonos-hub-go/internal/scene"
+)
+
+// buildExecutionDetail summarizes a routine run from its speakers and the scene execution's
+// step details: fallbacks from determine_coordinator and volumes from apply_volume.
+// roomNames maps UDN to room name and may be empty.
+func buildExecutionDetail(routine *Routine, execution *scene.SceneExecution, roomNames map[string]string) *ExecutionDetail {
+	detail := &ExecutionDetail{Devices: []ExecutionDevice{}}
+
+	fallbacks := make(map[string]string)     // primary UDN -> fallback UDN
+	fallbackRooms := make(map[string]string) // fallback UDN -> room name, for speakers not in roomNames
+	volumes := make(map[string]int)
+	var stepUDNs []string
+	if execution != nil {
+		for _, step := range execution.Steps {
+			switch step.Step {
+			case "determine_coordinator":
+				for _, used := range stepDetailList(step.Details["fallback_used"]) {
+					primary, _ := used["primary_udn"].(string)
+					fallback, _ := used["fallback_udn"].(string)
+					if primary != "" && fallback != "" {
+						fallbacks[primary] = fallback
+					}
+					if roomName, _ := used["fallback_room_name"].(string); roomName != "" {
+						fallbackRooms[fallback] = roomName
+					}
+				}
+			case "apply_volume":
+				for _, result := range stepDetailList(step.Details["results"]) {
+					udn, _ := result["udn"].(string)
+					stepUDNs = append(stepUDNs, udn)
+					if ok, _ := result["success"].(bool); !ok {
+						continue
+					}
+					if volume, ok := stepDetailInt(result["volume"]); ok {
+						volumes[udn] = volume
+					}
+				}
+			}
+		}
+	}
+
+	// Routines created from a bare scene_id have no speakers; fall back to the members
+	// the scene touched
+	udns := make([]string, 0, len(routine.SpeakersJSON))
+	for _, speaker := range routine.SpeakersJSON {
+		udns = append(udns, speaker.UDN)
+	}
+	if len(udns) == 0 {
+		if execution != nil && execution.CoordinatorUsedUDN != nil {
+			udns = append(udns, *execution.CoordinatorUsedUDN)
+		}
+		udns = append(udns, stepUDNs...)
+	}
+
+	seen := make(map[string]bool)
+	for _, udn := range udns {
+		device := ExecutionDevice{UDN: udn}
+		if fallback, ok := fallbacks[udn]; ok {
+			device.UDN = fallback
+			device.Fallback = true
+			detail.FallbackUsed = true
+		}
+		if device.UDN == "" || seen[device.UDN] {
+			continue
+		}
+		seen[device.UDN] = true
+
+		device.RoomName = roomNames[device.UDN]
+		if device.RoomName == "" {
+			device.RoomName = fallbackRooms[device.UDN]
+		}
+		if volume, ok := volumes[device.UDN]; ok {
+			device.Volume = &volume
+		}
+		detail.Devices = append(detail.Devices, device)
+	}
+
+	return detail
+}
+
+// stepDetailList reads a list of objects from a step's details. Details read back from
+// the database are decoded JSON, while fresh ones are the executor's own maps.
+func stepDetailList(value any) []map[string]any {
+	switch v := value.(type) {
+	case []map[string]any:
+		return v
+	case []any:
+		list := make([]map[string]any, 0, len(v))
+		for _, item := range v {
+			if m, ok := item.(map[string]any); ok {
+				list = append(list, m)
+			}
+		}
+		return list
+	default:
+		return nil
+	}
+}
+
+// stepDetailInt reads a number from a step's details.
+func stepDetailInt(value any) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case float64:
+		return int(v), true
+	default:
+		return 0, false
+	}
+}
+
+// storedContentDisplay is the display metadata kept in music_content_json.
+type storedContentDisplay struct {
+	Title       string `json:"title"`
+	Name        string `json:"name"`
+	ArtworkURL  string `json:"artworkUrl"`
+	Service     string `json:"service"`
+	ServiceName string `json:"serviceName"`
+}
+
+// routineContentSummary describes the content a FIXED routine resolved to.
+func routineContentSummary(routine *Routine, content *scene.MusicContent) *ExecutionContent {
+	return summarizeContent(content, routine.MusicContentJSON,
+		routine.MusicSonosFavoriteName, routine.MusicSonosFavoriteArtworkUrl, routine.MusicSonosFavoriteServiceName)
+}
+
+// setItemContentSummary describes the content a music set item resolved to.
+func setItemContentSummary(item *music.SetItem, content *scene.MusicContent) *ExecutionContent {
+	return summarizeContent(content, item.ContentJSON, item.DisplayName, item.ArtworkURL, item.ServiceName)
+}
+
+// summarizeContent builds a display summary for resolved content, preferring the metadata
+// stored in contentJSON and filling gaps from the favorite columns. Returns nil when
+// nothing was resolved.
+func summarizeContent(content *scene.MusicContent, contentJSON, name, artworkURL, serviceName *string) *ExecutionContent {
+	if content == nil {
+		return nil
+	}
+
+	summary := &ExecutionContent{Type: content.Type, URI: content.URI}
+	if contentJSON != nil && *contentJSON != "" {
+		var stored storedContentDisplay
+		if err := json.Unmarshal([]byte(*contentJSON), &stored); err == nil {
+			summary.Title = stored.Title
+			if summary.Title == "" {
+				summary.Title = stored.Name
+			}
+			summary.ArtworkURL = stored.ArtworkURL
+			summary.ServiceName = stored.ServiceName
+			if summary.ServiceName == "" {
+				summary.ServiceName = stored.Service
+			}
+		}
+	}
+	if summary.Title == "" && name != nil {
+		summary.Title = *name
+	}
+	if summary.ArtworkURL == "" && artworkURL != nil {
+		summary.ArtworkURL = *artworkURL
+	}
+	if summary.ServiceName == "" && serviceName != nil {
+		summary.ServiceName = *serviceName
+	}
+
+	return summary
+}
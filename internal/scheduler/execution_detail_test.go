@@ -0,0 +1,156 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/music"
+	"github.com/strefethen/sonos-hub-go/internal/scene"
+)
+
+// decodedSteps round-trips steps through JSON, as they are after being read back from the database.
+func decodedSteps(t *testing.T, steps []scene.ExecutionStep) []scene.ExecutionStep {
+	data, err := json.Marshal(steps)
+	require.NoError(t, err)
+	var decoded []scene.ExecutionStep
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	return decoded
+}
+
+func TestBuildExecutionDetail(t *testing.T) {
+	volume := 25
+	routine := &Routine{SpeakersJSON: []Speaker{
+		{UDN: "udn-kitchen", Volume: &volume},
+		{UDN: "udn-den", FallbackUDN: "udn-office"},
+	}}
+	execution := &scene.SceneExecution{Steps: decodedSteps(t, []scene.ExecutionStep{
+		{Step: "determine_coordinator", Details: map[string]any{
+			"fallback_used": []map[string]any{{"primary_udn": "udn-den", "fallback_udn": "udn-office", "fallback_room_name": "Office"}},
+		}},
+		{Step: "apply_volume", Details: map[string]any{
+			"results": []map[string]any{
+				{"udn": "udn-kitchen", "success": true, "volume": 25},
+				{"udn": "udn-office", "success": false, "error": "timeout"},
+			},
+		}},
+	})}
+
+	detail := buildExecutionDetail(routine, execution, map[string]string{"udn-kitchen": "Kitchen"})
+
+	require.True(t, detail.FallbackUsed)
+	require.Len(t, detail.Devices, 2)
+	require.Equal(t, "udn-kitchen", detail.Devices[0].UDN)
+	require.Equal(t, "Kitchen", detail.Devices[0].RoomName)
+	require.NotNil(t, detail.Devices[0].Volume)
+	require.Equal(t, 25, *detail.Devices[0].Volume)
+	require.False(t, detail.Devices[0].Fallback)
+
+	require.Equal(t, "udn-office", detail.Devices[1].UDN)
+	require.Equal(t, "Office", detail.Devices[1].RoomName)
+	require.Nil(t, detail.Devices[1].Volume, "failed volume changes are not reported as applied")
+	require.True(t, detail.Devices[1].Fallback)
+}
+
+func TestBuildExecutionDetail_SceneOnlyRoutine(t *testing.T) {
+	coordinator := "udn-kitchen"
+	execution := &scene.SceneExecution{
+		CoordinatorUsedUDN: &coordinator,
+		Steps: []scene.ExecutionStep{
+			{Step: "apply_volume", Details: map[string]any{
+				"results": []map[string]any{
+					{"udn": "udn-kitchen", "success": true, "volume": 30},
+					{"udn": "udn-den", "success": true, "volume": 20},
+				},
+			}},
+		},
+	}
+
+	detail := buildExecutionDetail(&Routine{}, execution, nil)
+
+	require.False(t, detail.FallbackUsed)
+	require.Len(t, detail.Devices, 2)
+	require.Equal(t, "udn-kitchen", detail.Devices[0].UDN)
+	require.Equal(t, 30, *detail.Devices[0].Volume)
+	require.Equal(t, "udn-den", detail.Devices[1].UDN)
+
+	empty := buildExecutionDetail(&Routine{}, nil, nil)
+	require.NotNil(t, empty.Devices)
+	require.Empty(t, empty.Devices)
+}
+
+func TestSummarizeContent(t *testing.T) {
+	content := &scene.MusicContent{Type: "direct", URI: "x-sonos-http:track"}
+
+	t.Run("prefers stored content metadata", func(t *testing.T) {
+		contentJSON := `{"type":"direct","service":"apple_music","title":"Morning Jazz","artworkUrl":"https://art/1.jpg"}`
+		routine := &Routine{MusicContentJSON: &contentJSON}
+
+		summary := routineContentSummary(routine, content)
+		require.Equal(t, &ExecutionContent{
+			Type:        "direct",
+			Title:       "Morning Jazz",
+			ArtworkURL:  "https://art/1.jpg",
+			ServiceName: "apple_music",
+			URI:         "x-sonos-http:track",
+		}, summary)
+	})
+
+	t.Run("falls back to favorite columns", func(t *testing.T) {
+		name, artwork, service := "KEXP", "https://art/kexp.jpg", "TuneIn"
+		routine := &Routine{
+			MusicSonosFavoriteName:        &name,
+			MusicSonosFavoriteArtworkUrl:  &artwork,
+			MusicSonosFavoriteServiceName: &service,
+		}
+
+		summary := routineContentSummary(routine, &scene.MusicContent{Type: "sonos_favorite"})
+		require.Equal(t, "KEXP", summary.Title)
+		require.Equal(t, "https://art/kexp.jpg", summary.ArtworkURL)
+		require.Equal(t, "TuneIn", summary.ServiceName)
+	})
+
+	t.Run("set item", func(t *testing.T) {
+		name := "Focus Mix"
+		summary := setItemContentSummary(&music.SetItem{DisplayName: &name}, content)
+		require.Equal(t, "Focus Mix", summary.Title)
+	})
+
+	t.Run("nothing resolved", func(t *testing.T) {
+		require.Nil(t, routineContentSummary(&Routine{}, nil))
+	})
+}
+
+func TestFormatJobAsExecution_Detail(t *testing.T) {
+	volume := 25
+	job := &Job{
+		JobID:     "job-1",
+		RoutineID: "routine-1",
+		Status:    JobStatusCompleted,
+		ExecutionDetail: &ExecutionDetail{
+			Devices: []ExecutionDevice{
+				{UDN: "udn-kitchen", RoomName: "Kitchen", Volume: &volume},
+				{UDN: "udn-office", Fallback: true},
+			},
+			Content:         &ExecutionContent{Type: "sonos_favorite", Title: "KEXP"},
+			FallbackUsed:    true,
+			HolidayOverride: &HolidayOverride{HolidayName: "Christmas", MusicSetID: "set-christmas"},
+		},
+	}
+
+	result := formatJobAsExecution(job, map[string]string{"routine-1": "Morning"})
+	require.Equal(t, []string{"Kitchen", "udn-office"}, result["target_devices"])
+	require.Equal(t, true, result["fallback_used"])
+	require.Equal(t, "KEXP", result["content_played"].(map[string]any)["title"])
+	require.Len(t, result["devices"], 2)
+	require.Equal(t, "Christmas", result["holiday_override"].(map[string]any)["holiday_name"])
+
+	// Jobs from before execution details were recorded
+	job.ExecutionDetail = nil
+	result = formatJobAsExecution(job, nil)
+	require.Equal(t, []string{}, result["target_devices"])
+	require.Nil(t, result["content_played"])
+	require.Equal(t, false, result["fallback_used"])
+	require.NotContains(t, result, "holiday_override")
+}
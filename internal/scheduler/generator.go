@@ -3,7 +3,7 @@ package scheduler
 import (
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
 	"time"
 
@@ -13,17 +13,21 @@ import (
 // MaxDelayIterations is the maximum number of days to search for a non-holiday date.
 const MaxDelayIterations = 30
 
+// AnchorDateFormat is the layout of an INTERVAL schedule's schedule_anchor_date.
+const AnchorDateFormat = "2006-01-02"
+
 // JobGenerator handles the generation of jobs for routines.
 type JobGenerator struct {
 	routinesRepo *RoutinesRepository
 	jobsRepo     *JobsRepository
 	holidaysRepo *HolidaysRepository
-	logger       *log.Logger
+	logger       *slog.Logger
+	coordinates  *Coordinates // nil: sunrise/sunset schedules use their fixed time
 }
 
 // NewJobGenerator creates a new JobGenerator.
 func NewJobGenerator(routinesRepo *RoutinesRepository, jobsRepo *JobsRepository,
-	holidaysRepo *HolidaysRepository, logger *log.Logger) *JobGenerator {
+	holidaysRepo *HolidaysRepository, logger *slog.Logger) *JobGenerator {
 	return &JobGenerator{
 		routinesRepo: routinesRepo,
 		jobsRepo:     jobsRepo,
@@ -32,9 +36,15 @@ func NewJobGenerator(routinesRepo *RoutinesRepository, jobsRepo *JobsRepository,
 	}
 }
 
+// SetCoordinates sets the hub location used for sunrise/sunset schedules.
+func (g *JobGenerator) SetCoordinates(coords *Coordinates) {
+	g.coordinates = coords
+}
+
 // CalculateNextRun calculates the next run time for a routine.
 // Handles CRON, INTERVAL, ONE_TIME, weekly, monthly, and yearly schedule types.
-// Uses the routine's timezone for calculations.
+// Uses the routine's timezone for calculations. Sunrise/sunset schedules fall back
+// to schedule_time when no coordinates are configured.
 func (g *JobGenerator) CalculateNextRun(routine *Routine, after time.Time) (time.Time, error) {
 	loc, err := time.LoadLocation(routine.Timezone)
 	if err != nil {
@@ -43,6 +53,10 @@ func (g *JobGenerator) CalculateNextRun(routine *Routine, after time.Time) (time
 
 	afterLocal := after.In(loc)
 
+	if routine.ScheduleTimeMode.IsSolar() && g.coordinates != nil {
+		return g.calculateSolarNextRun(routine, afterLocal, loc)
+	}
+
 	switch routine.ScheduleType {
 	case ScheduleTypeCron:
 		return g.calculateCronNextRun(routine, afterLocal, loc)
@@ -61,16 +75,139 @@ func (g *JobGenerator) CalculateNextRun(routine *Routine, after time.Time) (time
 	}
 }
 
+// UpcomingRun returns when the routine will next fire, for display. Snooze and skip_next
+// are applied; holidays are not. Returns nil for disabled or finished routines.
+func (g *JobGenerator) UpcomingRun(routine *Routine, now time.Time) *time.Time {
+	if g == nil || !routine.Enabled {
+		return nil
+	}
+
+	after := now
+	if routine.SnoozeUntil != nil && routine.SnoozeUntil.After(after) {
+		after = *routine.SnoozeUntil
+	}
+
+	next, err := g.CalculateNextRun(routine, after)
+	if err == nil && !next.IsZero() && routine.SkipNext {
+		next, err = g.CalculateNextRun(routine, next)
+	}
+	if err != nil || next.IsZero() {
+		return nil
+	}
+
+	next = next.UTC()
+	return &next
+}
+
+// ScheduledOnDate reports whether a routine's schedule fires on the given calendar date.
+// Only the date's year, month, and day are used. One-time schedules store no year, so they
+// match on month and day. CRON schedules are not stored and never match.
+func ScheduledOnDate(routine *Routine, date time.Time) bool {
+	switch routine.ScheduleType {
+	case ScheduleTypeWeekly:
+		for _, d := range routine.ScheduleWeekdays {
+			if time.Weekday(d) == date.Weekday() {
+				return true
+			}
+		}
+		return false
+	case ScheduleTypeMonthly:
+		return routine.ScheduleDay != nil && *routine.ScheduleDay == date.Day()
+	case ScheduleTypeYearly, ScheduleTypeOneTime, ScheduleTypeOnce:
+		return routine.ScheduleMonth != nil && routine.ScheduleDay != nil &&
+			time.Month(*routine.ScheduleMonth) == date.Month() && *routine.ScheduleDay == date.Day()
+	case ScheduleTypeInterval:
+		if routine.ScheduleIntervalDays == nil || *routine.ScheduleIntervalDays < 1 || routine.ScheduleAnchorDate == nil {
+			return false
+		}
+		anchor, err := time.Parse(AnchorDateFormat, *routine.ScheduleAnchorDate)
+		if err != nil {
+			return false
+		}
+		elapsed := daysBetween(anchor, date)
+		return elapsed >= 0 && elapsed%*routine.ScheduleIntervalDays == 0
+	default:
+		return false
+	}
+}
+
+// SkipsOnHoliday reports whether a holiday suppresses the routine rather than running or delaying it.
+// Mirrors ApplyHolidayBehavior, where unknown behaviors default to SKIP.
+func SkipsOnHoliday(routine *Routine) bool {
+	switch routine.HolidayBehavior {
+	case HolidayBehaviorRun, HolidayBehaviorDelay, HolidayBehaviorPlayAlternate:
+		return false
+	default:
+		return true
+	}
+}
+
 func (g *JobGenerator) calculateCronNextRun(routine *Routine, after time.Time, loc *time.Location) (time.Time, error) {
 	// For now, cron expressions are not stored in the current schema
 	// This is a placeholder for future CRON support
 	return time.Time{}, errors.New("CRON schedule type requires cron expression support")
 }
 
+// calculateIntervalNextRun finds the next run of an every-N-days schedule. Occurrences are
+// counted in calendar days from the anchor date and built from wall-clock time in the
+// routine's timezone, so the run time stays fixed across DST transitions.
 func (g *JobGenerator) calculateIntervalNextRun(routine *Routine, after time.Time, loc *time.Location) (time.Time, error) {
-	// For now, interval schedules are not stored in the current schema
-	// This is a placeholder for future INTERVAL support
-	return time.Time{}, errors.New("INTERVAL schedule type requires interval_minutes support")
+	if routine.ScheduleIntervalDays == nil || *routine.ScheduleIntervalDays < 1 {
+		return time.Time{}, errors.New("schedule_interval_days must be at least 1 for INTERVAL schedule type")
+	}
+	if routine.ScheduleAnchorDate == nil {
+		return time.Time{}, errors.New("schedule_anchor_date is required for INTERVAL schedule type")
+	}
+
+	anchor, err := time.ParseInLocation(AnchorDateFormat, *routine.ScheduleAnchorDate, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid schedule_anchor_date %q: %w", *routine.ScheduleAnchorDate, err)
+	}
+
+	hour, minute, err := parseScheduleTime(routine.ScheduleTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	interval := *routine.ScheduleIntervalDays
+	occurrence := func(k int) time.Time {
+		return wallClockTime(anchor.Year(), anchor.Month(), anchor.Day()+k*interval, hour, minute, loc)
+	}
+
+	// Start from the last occurrence on or before after's date, then step forward
+	k := 0
+	if elapsed := daysBetween(anchor, after); elapsed > 0 {
+		k = elapsed / interval
+	}
+	candidate := occurrence(k)
+	for !candidate.After(after) {
+		k++
+		candidate = occurrence(k)
+	}
+
+	return candidate, nil
+}
+
+// wallClockTime returns hour:minute on the given date in loc. A time that doesn't exist
+// because clocks spring forward over it (e.g. 02:30 on a US DST start) resolves to the
+// same distance past the transition (03:30), rather than time.Date's earlier offset.
+func wallClockTime(year int, month time.Month, day, hour, minute int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, hour, minute, 0, 0, loc)
+
+	requested := time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	actual := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	if actual.Before(requested) {
+		t = t.Add(requested.Sub(actual))
+	}
+	return t
+}
+
+// daysBetween returns the number of calendar days from from's date to to's date,
+// ignoring time of day and DST offset changes.
+func daysBetween(from, to time.Time) int {
+	fromDate := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDate := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toDate.Sub(fromDate).Hours() / 24)
 }
 
 func (g *JobGenerator) calculateOneTimeNextRun(routine *Routine, after time.Time, loc *time.Location) (time.Time, error) {
@@ -85,7 +222,7 @@ func (g *JobGenerator) calculateOneTimeNextRun(routine *Routine, after time.Time
 	}
 
 	// Construct the one-time run date
-	runAt := time.Date(after.Year(), time.Month(*routine.ScheduleMonth), *routine.ScheduleDay, hour, minute, 0, 0, loc)
+	runAt := wallClockTime(after.Year(), time.Month(*routine.ScheduleMonth), *routine.ScheduleDay, hour, minute, loc)
 
 	// If the date is in the past, return zero time (already executed)
 	if runAt.Before(after) || runAt.Equal(after) {
@@ -119,7 +256,7 @@ func (g *JobGenerator) calculateWeeklyNextRun(routine *Routine, after time.Time,
 	}
 
 	// Start from the day after 'after' and check each day
-	candidate := time.Date(after.Year(), after.Month(), after.Day(), hour, minute, 0, 0, loc)
+	candidate := wallClockTime(after.Year(), after.Month(), after.Day(), hour, minute, loc)
 
 	// If today's scheduled time is still in the future and today is a valid weekday, use it
 	if candidate.After(after) && containsWeekday(weekdays, candidate.Weekday()) {
@@ -128,7 +265,7 @@ func (g *JobGenerator) calculateWeeklyNextRun(routine *Routine, after time.Time,
 
 	// Otherwise, find the next valid weekday
 	for i := 1; i <= 7; i++ {
-		candidate = candidate.AddDate(0, 0, 1)
+		candidate = wallClockTime(after.Year(), after.Month(), after.Day()+i, hour, minute, loc)
 		if containsWeekday(weekdays, candidate.Weekday()) {
 			return candidate, nil
 		}
@@ -150,19 +287,21 @@ func (g *JobGenerator) calculateMonthlyNextRun(routine *Routine, after time.Time
 	day := *routine.ScheduleDay
 
 	// Try this month
-	candidate := time.Date(after.Year(), after.Month(), day, hour, minute, 0, 0, loc)
+	candidate := wallClockTime(after.Year(), after.Month(), day, hour, minute, loc)
 	if candidate.After(after) && candidate.Day() == day {
 		return candidate, nil
 	}
 
-	// Try next month
-	candidate = time.Date(after.Year(), after.Month()+1, day, hour, minute, 0, 0, loc)
-	// Handle day overflow (e.g., Feb 30 -> Mar 2)
-	for candidate.Day() != day && candidate.Day() < day {
-		candidate = candidate.AddDate(0, 1, 0)
+	// Try the following months, skipping those without the day (e.g., Feb 30 would
+	// normalize to Mar 2)
+	for i := 1; i <= 12; i++ {
+		candidate = wallClockTime(after.Year(), after.Month()+time.Month(i), day, hour, minute, loc)
+		if candidate.Day() == day {
+			return candidate, nil
+		}
 	}
 
-	return candidate, nil
+	return time.Time{}, fmt.Errorf("invalid schedule_day: %d", day)
 }
 
 func (g *JobGenerator) calculateYearlyNextRun(routine *Routine, after time.Time, loc *time.Location) (time.Time, error) {
@@ -179,13 +318,13 @@ func (g *JobGenerator) calculateYearlyNextRun(routine *Routine, after time.Time,
 	day := *routine.ScheduleDay
 
 	// Try this year
-	candidate := time.Date(after.Year(), month, day, hour, minute, 0, 0, loc)
+	candidate := wallClockTime(after.Year(), month, day, hour, minute, loc)
 	if candidate.After(after) {
 		return candidate, nil
 	}
 
 	// Try next year
-	candidate = time.Date(after.Year()+1, month, day, hour, minute, 0, 0, loc)
+	candidate = wallClockTime(after.Year()+1, month, day, hour, minute, loc)
 	return candidate, nil
 }
 
@@ -203,7 +342,7 @@ func (g *JobGenerator) GenerateJobs(now time.Time) (int, error) {
 		job, err := g.GenerateJobForRoutine(routine, now)
 		if err != nil {
 			if g.logger != nil {
-				g.logger.Printf("Error generating job for routine %s: %v", routine.RoutineID, err)
+				g.logger.Error("Error generating job", "routine_id", routine.RoutineID, "error", err)
 			}
 			continue
 		}
@@ -283,6 +422,7 @@ func (g *JobGenerator) GenerateJobForRoutine(routine *Routine, now time.Time) (*
 // SKIP: Returns nil (no job created)
 // DELAY: Finds next non-holiday date
 // RUN: Returns the original scheduled time
+// PLAY_ALTERNATE: Returns the original scheduled time; the executor swaps the music
 func (g *JobGenerator) ApplyHolidayBehavior(routine *Routine, scheduledFor time.Time) (*time.Time, error) {
 	isHoliday, _, err := g.holidaysRepo.IsHolidayWithDetails(scheduledFor)
 	if err != nil {
@@ -296,7 +436,7 @@ func (g *JobGenerator) ApplyHolidayBehavior(routine *Routine, scheduledFor time.
 	switch routine.HolidayBehavior {
 	case HolidayBehaviorSkip:
 		return nil, nil
-	case HolidayBehaviorRun:
+	case HolidayBehaviorRun, HolidayBehaviorPlayAlternate:
 		return &scheduledFor, nil
 	case HolidayBehaviorDelay:
 		return g.findNextNonHoliday(scheduledFor)
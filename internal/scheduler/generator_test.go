@@ -457,6 +457,37 @@ func TestApplyHolidayBehavior_Run(t *testing.T) {
 	require.Equal(t, scheduledFor, *result) // Should run even on holiday
 }
 
+// Test holiday PLAY_ALTERNATE behavior
+func TestApplyHolidayBehavior_PlayAlternate(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test.db")
+
+	dbPair, err := db.Init(dbPath)
+	require.NoError(t, err)
+	defer dbPair.Close()
+
+	holidaysRepo := NewHolidaysRepository(dbPair)
+	generator := NewJobGenerator(nil, nil, holidaysRepo, nil)
+
+	_, err = holidaysRepo.Create(CreateHolidayInput{
+		Date: time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC),
+		Name: "Christmas",
+	})
+	require.NoError(t, err)
+
+	routine := &Routine{
+		HolidayBehavior: HolidayBehaviorPlayAlternate,
+	}
+	require.False(t, SkipsOnHoliday(routine))
+
+	scheduledFor := time.Date(2024, 12, 25, 9, 0, 0, 0, time.UTC)
+
+	result, err := generator.ApplyHolidayBehavior(routine, scheduledFor)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	require.Equal(t, scheduledFor, *result) // Runs on the holiday; the executor swaps the music
+}
+
 // Test GenerateJobForRoutine
 func TestGenerateJobForRoutine_DisabledRoutine(t *testing.T) {
 	generator, _, _, _, _ := setupTestGeneratorDB(t)
@@ -692,6 +723,106 @@ func TestCalculateNextRun_UnsupportedScheduleType(t *testing.T) {
 	require.Contains(t, err.Error(), "unsupported schedule type")
 }
 
+func TestCalculateNextRun_Interval(t *testing.T) {
+	generator, _, _, _, _ := setupTestGeneratorDB(t)
+
+	interval := 3
+	anchor := "2024-01-10"
+	routine := &Routine{
+		RoutineID:            "test-interval",
+		ScheduleType:         ScheduleTypeInterval,
+		ScheduleIntervalDays: &interval,
+		ScheduleAnchorDate:   &anchor,
+		ScheduleTime:         "08:00",
+		Timezone:             "UTC",
+		Enabled:              true,
+	}
+
+	// Before the anchor: first run is the anchor itself
+	nextRun, err := generator.CalculateNextRun(routine, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 1, 10, 8, 0, 0, 0, time.UTC), nextRun)
+
+	// Between occurrences: Jan 14 falls between Jan 13 and Jan 16
+	nextRun, err = generator.CalculateNextRun(routine, time.Date(2024, 1, 14, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 1, 16, 8, 0, 0, 0, time.UTC), nextRun)
+
+	// On an occurrence day before the scheduled time
+	nextRun, err = generator.CalculateNextRun(routine, time.Date(2024, 1, 16, 7, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 1, 16, 8, 0, 0, 0, time.UTC), nextRun)
+
+	// On an occurrence day after the scheduled time
+	nextRun, err = generator.CalculateNextRun(routine, time.Date(2024, 1, 16, 9, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 1, 19, 8, 0, 0, 0, time.UTC), nextRun)
+}
+
+func TestCalculateNextRun_IntervalAcrossDST(t *testing.T) {
+	generator, _, _, _, _ := setupTestGeneratorDB(t)
+
+	// US DST starts Sunday March 10, 2024
+	interval := 2
+	anchor := "2024-03-08"
+	routine := &Routine{
+		RoutineID:            "test-interval-dst",
+		ScheduleType:         ScheduleTypeInterval,
+		ScheduleIntervalDays: &interval,
+		ScheduleAnchorDate:   &anchor,
+		ScheduleTime:         "07:30",
+		Timezone:             "America/New_York",
+		Enabled:              true,
+	}
+
+	loc, _ := time.LoadLocation("America/New_York")
+	after := time.Date(2024, 3, 8, 8, 0, 0, 0, loc)
+
+	nextRun, err := generator.CalculateNextRun(routine, after)
+	require.NoError(t, err)
+	nextLocal := nextRun.In(loc)
+	require.Equal(t, 10, nextLocal.Day())
+	require.Equal(t, 7, nextLocal.Hour())
+	require.Equal(t, 30, nextLocal.Minute())
+	// One hour less than the 47.5 wall-clock hours elapses because the clocks sprang forward
+	require.Equal(t, 46*time.Hour+30*time.Minute, nextRun.Sub(after))
+}
+
+func TestCalculateNextRun_IntervalInvalid(t *testing.T) {
+	generator, _, _, _, _ := setupTestGeneratorDB(t)
+
+	zero := 0
+	anchor := "2024-01-10"
+	badAnchor := "01/10/2024"
+
+	cases := []struct {
+		name    string
+		days    *int
+		anchor  *string
+		message string
+	}{
+		{"missing interval", nil, &anchor, "schedule_interval_days"},
+		{"zero interval", &zero, &anchor, "schedule_interval_days"},
+		{"missing anchor", func() *int { v := 3; return &v }(), nil, "schedule_anchor_date"},
+		{"malformed anchor", func() *int { v := 3; return &v }(), &badAnchor, "invalid schedule_anchor_date"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			routine := &Routine{
+				ScheduleType:         ScheduleTypeInterval,
+				ScheduleIntervalDays: tc.days,
+				ScheduleAnchorDate:   tc.anchor,
+				ScheduleTime:         "08:00",
+				Timezone:             "UTC",
+			}
+			_, err := generator.CalculateNextRun(routine, time.Now())
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.message)
+		})
+	}
+}
+
 // Test CronScheduleParser
 func TestCronScheduleParser_ParseCron(t *testing.T) {
 	parser := &CronScheduleParser{}
@@ -771,3 +902,43 @@ func TestGenerateJobs_Integration(t *testing.T) {
 	require.Len(t, jobs, 1)
 	require.Equal(t, routine.RoutineID, jobs[0].RoutineID)
 }
+
+func TestScheduledOnDate(t *testing.T) {
+	month, day := 12, 25
+	otherDay := 24
+	christmas := time.Date(2025, time.December, 25, 0, 0, 0, 0, time.UTC) // Thursday
+	three, five := 3, 5
+	anchor := "2025-12-01"      // 24 days before Christmas
+	laterAnchor := "2025-12-28" // after Christmas
+
+	cases := []struct {
+		name    string
+		routine Routine
+		want    bool
+	}{
+		{"weekly match", Routine{ScheduleType: ScheduleTypeWeekly, ScheduleWeekdays: []int{1, 4}}, true},
+		{"weekly miss", Routine{ScheduleType: ScheduleTypeWeekly, ScheduleWeekdays: []int{0, 6}}, false},
+		{"monthly match", Routine{ScheduleType: ScheduleTypeMonthly, ScheduleDay: &day}, true},
+		{"monthly miss", Routine{ScheduleType: ScheduleTypeMonthly, ScheduleDay: &otherDay}, false},
+		{"yearly match", Routine{ScheduleType: ScheduleTypeYearly, ScheduleMonth: &month, ScheduleDay: &day}, true},
+		{"yearly miss", Routine{ScheduleType: ScheduleTypeYearly, ScheduleMonth: &month, ScheduleDay: &otherDay}, false},
+		{"once match", Routine{ScheduleType: ScheduleTypeOnce, ScheduleMonth: &month, ScheduleDay: &day}, true},
+		{"cron never matches", Routine{ScheduleType: ScheduleTypeCron}, false},
+		{"interval match", Routine{ScheduleType: ScheduleTypeInterval, ScheduleIntervalDays: &three, ScheduleAnchorDate: &anchor}, true},
+		{"interval miss", Routine{ScheduleType: ScheduleTypeInterval, ScheduleIntervalDays: &five, ScheduleAnchorDate: &anchor}, false},
+		{"interval before anchor", Routine{ScheduleType: ScheduleTypeInterval, ScheduleIntervalDays: &three, ScheduleAnchorDate: &laterAnchor}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, ScheduledOnDate(&tc.routine, christmas))
+		})
+	}
+}
+
+func TestSkipsOnHoliday(t *testing.T) {
+	require.True(t, SkipsOnHoliday(&Routine{HolidayBehavior: HolidayBehaviorSkip}))
+	require.True(t, SkipsOnHoliday(&Routine{}))
+	require.False(t, SkipsOnHoliday(&Routine{HolidayBehavior: HolidayBehaviorRun}))
+	require.False(t, SkipsOnHoliday(&Routine{HolidayBehavior: HolidayBehaviorDelay}))
+}
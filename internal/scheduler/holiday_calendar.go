@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// CalendarHoliday is a holiday produced by a HolidayProvider.
+type CalendarHoliday struct {
+	Date time.Time
+	Name string
+}
+
+// HolidayProvider computes a country's public holidays for a year without any network access.
+type HolidayProvider interface {
+	// Country returns the ISO 3166-1 alpha-2 code the provider covers.
+	Country() string
+	// Holidays returns the year's holidays in date order.
+	Holidays(year int) []CalendarHoliday
+}
+
+// holidayProviders lists the built-in calendars by country code.
+var holidayProviders = map[string]HolidayProvider{
+	"US": usHolidayProvider{},
+}
+
+// HolidayProviderFor returns the built-in calendar for a country code, case-insensitively.
+func HolidayProviderFor(country string) (HolidayProvider, bool) {
+	provider, ok := holidayProviders[strings.ToUpper(country)]
+	return provider, ok
+}
+
+// HolidayCountries returns the country codes with a built-in calendar, sorted.
+func HolidayCountries() []string {
+	countries := make([]string, 0, len(holidayProviders))
+	for country := range holidayProviders {
+		countries = append(countries, country)
+	}
+	sort.Strings(countries)
+	return countries
+}
+
+// usHolidayProvider computes US federal holidays on their actual dates (not the
+// weekday they are observed on when they fall on a weekend).
+type usHolidayProvider struct{}
+
+func (usHolidayProvider) Country() string { return "US" }
+
+func (usHolidayProvider) Holidays(year int) []CalendarHoliday {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	holidays := []CalendarHoliday{
+		{date(time.January, 1), "New Year's Day"},
+		{nthWeekday(year, time.January, time.Monday, 3), "Martin Luther King Jr. Day"},
+		{nthWeekday(year, time.February, time.Monday, 3), "Washington's Birthday"},
+		{lastWeekday(year, time.May, time.Monday), "Memorial Day"},
+	}
+	if year >= 2021 {
+		holidays = append(holidays, CalendarHoliday{date(time.June, 19), "Juneteenth National Independence Day"})
+	}
+	holidays = append(holidays,
+		CalendarHoliday{date(time.July, 4), "Independence Day"},
+		CalendarHoliday{nthWeekday(year, time.September, time.Monday, 1), "Labor Day"},
+		CalendarHoliday{nthWeekday(year, time.October, time.Monday, 2), "Columbus Day"},
+		CalendarHoliday{date(time.November, 11), "Veterans Day"},
+		CalendarHoliday{nthWeekday(year, time.November, time.Thursday, 4), "Thanksgiving Day"},
+		CalendarHoliday{date(time.December, 25), "Christmas Day"},
+	)
+
+	return holidays
+}
+
+// nthWeekday returns the nth (1-based) weekday of a month, e.g. the 4th Thursday of November.
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, offset+7*(n-1))
+}
+
+// lastWeekday returns the last weekday of a month, e.g. the last Monday of May.
+func lastWeekday(year int, month time.Month, weekday time.Weekday) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+	offset := (int(last.Weekday()) - int(weekday) + 7) % 7
+	return last.AddDate(0, 0, -offset)
+}
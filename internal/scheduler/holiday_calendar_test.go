@@ -0,0 +1,47 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUSHolidayProvider(t *testing.T) {
+	provider, ok := HolidayProviderFor("us")
+	require.True(t, ok)
+	require.Equal(t, "US", provider.Country())
+
+	dates := map[string]string{}
+	for _, holiday := range provider.Holidays(2025) {
+		dates[holiday.Name] = holiday.Date.Format("2006-01-02")
+	}
+	require.Equal(t, map[string]string{
+		"New Year's Day":                       "2025-01-01",
+		"Martin Luther King Jr. Day":           "2025-01-20",
+		"Washington's Birthday":                "2025-02-17",
+		"Memorial Day":                         "2025-05-26",
+		"Juneteenth National Independence Day": "2025-06-19",
+		"Independence Day":                     "2025-07-04",
+		"Labor Day":                            "2025-09-01",
+		"Columbus Day":                         "2025-10-13",
+		"Veterans Day":                         "2025-11-11",
+		"Thanksgiving Day":                     "2025-11-27",
+		"Christmas Day":                        "2025-12-25",
+	}, dates)
+
+	// Juneteenth only from 2021
+	require.Len(t, provider.Holidays(2020), 10)
+
+	_, ok = HolidayProviderFor("XX")
+	require.False(t, ok)
+}
+
+func TestNthAndLastWeekday(t *testing.T) {
+	require.Equal(t, time.Date(2024, 11, 28, 0, 0, 0, 0, time.UTC), nthWeekday(2024, time.November, time.Thursday, 4))
+	// The month starts on the weekday itself
+	require.Equal(t, time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), nthWeekday(2026, time.June, time.Monday, 1))
+	// The month ends on the weekday itself
+	require.Equal(t, time.Date(2026, 5, 25, 0, 0, 0, 0, time.UTC), lastWeekday(2026, time.May, time.Monday))
+	require.Equal(t, time.Date(2027, 5, 31, 0, 0, 0, 0, time.UTC), lastWeekday(2027, time.May, time.Monday))
+}
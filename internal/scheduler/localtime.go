@@ -0,0 +1,59 @@
+package scheduler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+)
+
+// localTimeZoneRoutine selects each routine's own timezone for ?tz=.
+const localTimeZoneRoutine = "routine"
+
+// localTimeZone is the ?tz= option that adds *_local variants of routine timestamps.
+// The UTC fields remain the canonical values; the local variants are for display only.
+type localTimeZone struct {
+	location   *time.Location // Fixed zone for every routine, unless perRoutine
+	perRoutine bool           // tz=routine: convert using each routine's timezone
+}
+
+// parseLocalTimeZone reads ?tz= as an IANA timezone name or "routine".
+// Returns nil when the option is absent.
+func parseLocalTimeZone(r *http.Request) (*localTimeZone, error) {
+	tz := r.URL.Query().Get("tz")
+	if tz == "" {
+		return nil, nil
+	}
+	if tz == localTimeZoneRoutine {
+		return &localTimeZone{perRoutine: true}, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, apperrors.NewValidationError("tz must be an IANA timezone name or \"routine\"", map[string]any{"tz": tz})
+	}
+	return &localTimeZone{location: loc}, nil
+}
+
+// addLocalTimestamps adds last_run_at_local and next_run_at_local alongside the UTC fields.
+// Routines with an unloadable timezone fall back to UTC under tz=routine.
+func (tz *localTimeZone) addLocalTimestamps(result map[string]any, routine *Routine) {
+	if tz == nil {
+		return
+	}
+
+	loc := tz.location
+	if tz.perRoutine {
+		var err error
+		if loc, err = time.LoadLocation(routine.Timezone); err != nil {
+			loc = time.UTC
+		}
+	}
+
+	if routine.LastRunAt != nil {
+		result["last_run_at_local"] = api.RFC3339MillisIn(*routine.LastRunAt, loc)
+	}
+	if routine.NextRunAt != nil {
+		result["next_run_at_local"] = api.RFC3339MillisIn(*routine.NextRunAt, loc)
+	}
+}
@@ -0,0 +1,50 @@
+package scheduler
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLocalTimeZone(t *testing.T) {
+	tz, err := parseLocalTimeZone(httptest.NewRequest("GET", "/v1/routines", nil))
+	require.NoError(t, err)
+	require.Nil(t, tz)
+
+	tz, err = parseLocalTimeZone(httptest.NewRequest("GET", "/v1/routines?tz=routine", nil))
+	require.NoError(t, err)
+	require.True(t, tz.perRoutine)
+
+	tz, err = parseLocalTimeZone(httptest.NewRequest("GET", "/v1/routines?tz=America/New_York", nil))
+	require.NoError(t, err)
+	require.Equal(t, "America/New_York", tz.location.String())
+
+	_, err = parseLocalTimeZone(httptest.NewRequest("GET", "/v1/routines?tz=Mars/Olympus", nil))
+	require.Error(t, err)
+}
+
+func TestAddLocalTimestamps(t *testing.T) {
+	lastRun := time.Date(2025, time.January, 15, 12, 30, 0, 0, time.UTC)
+	nextRun := time.Date(2025, time.July, 15, 12, 30, 0, 0, time.UTC)
+	routine := &Routine{Timezone: "America/Los_Angeles", LastRunAt: &lastRun, NextRunAt: &nextRun}
+
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	result := map[string]any{}
+	(&localTimeZone{location: newYork}).addLocalTimestamps(result, routine)
+	require.Equal(t, "2025-01-15T07:30:00.000-05:00", result["last_run_at_local"])
+	require.Equal(t, "2025-07-15T08:30:00.000-04:00", result["next_run_at_local"])
+
+	result = map[string]any{}
+	(&localTimeZone{perRoutine: true}).addLocalTimestamps(result, routine)
+	require.Equal(t, "2025-01-15T04:30:00.000-08:00", result["last_run_at_local"])
+
+	// No option: no local variants
+	result = map[string]any{}
+	var none *localTimeZone
+	none.addLocalTimestamps(result, routine)
+	require.Empty(t, result)
+}
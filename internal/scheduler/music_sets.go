@@ -0,0 +1,108 @@
+package scheduler
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/strefethen/sonos-hub-go/internal/music"
+)
+
+// MaxRoutineMusicSets caps the music sets one routine picks from.
+const MaxRoutineMusicSets = 10
+
+// WeightedMusicSets returns the sets a ROTATION/SHUFFLE routine picks from, by weight.
+// A routine with only MusicSetID picks from that set.
+func (r *Routine) WeightedMusicSets() []music.WeightedSet {
+	if len(r.MusicSets) > 0 {
+		return r.MusicSets
+	}
+	if r.MusicSetID != nil && *r.MusicSetID != "" {
+		return []music.WeightedSet{{SetID: *r.MusicSetID, Weight: 1}}
+	}
+	return nil
+}
+
+// withoutMusicSets returns sets less those in setIDs.
+func withoutMusicSets(sets []music.WeightedSet, setIDs []string) []music.WeightedSet {
+	remaining := make([]music.WeightedSet, 0, len(sets))
+	for _, set := range sets {
+		if !slices.Contains(setIDs, set.SetID) {
+			remaining = append(remaining, set)
+		}
+	}
+	return remaining
+}
+
+// routineMusicSets returns the sets to store for a routine: sets when given, otherwise
+// setID as the only set (none when it is empty). Missing weights default to 1.
+func routineMusicSets(sets []music.WeightedSet, setID *string) []music.WeightedSet {
+	if len(sets) == 0 {
+		if setID == nil || *setID == "" {
+			return nil
+		}
+		return []music.WeightedSet{{SetID: *setID, Weight: 1}}
+	}
+
+	normalized := make([]music.WeightedSet, len(sets))
+	for i, set := range sets {
+		normalized[i] = set
+		if set.Weight <= 0 {
+			normalized[i].Weight = 1
+		}
+	}
+	return normalized
+}
+
+// replaceMusicSets replaces the routine's music sets with sets, in order, as part of the
+// transaction that writes the routine.
+func replaceMusicSets(tx *sql.Tx, routineID string, sets []music.WeightedSet) error {
+	if _, err := tx.Exec("DELETE FROM routine_music_sets WHERE routine_id = ?", routineID); err != nil {
+		return fmt.Errorf("clear routine music sets: %w", err)
+	}
+	for position, set := range sets {
+		if _, err := tx.Exec(
+			"INSERT INTO routine_music_sets (routine_id, set_id, weight, position) VALUES (?, ?, ?, ?)",
+			routineID, set.SetID, set.Weight, position,
+		); err != nil {
+			return fmt.Errorf("add routine music set %s: %w", set.SetID, err)
+		}
+	}
+	return nil
+}
+
+// loadMusicSets fills in the routines' MusicSets.
+func (r *RoutinesRepository) loadMusicSets(routines ...*Routine) error {
+	if len(routines) == 0 {
+		return nil
+	}
+	byID := make(map[string]*Routine, len(routines))
+	args := make([]any, 0, len(routines))
+	for _, routine := range routines {
+		byID[routine.RoutineID] = routine
+		args = append(args, routine.RoutineID)
+	}
+
+	rows, err := r.reader.Query(`
+		SELECT routine_id, set_id, weight FROM routine_music_sets
+		WHERE routine_id IN (?`+strings.Repeat(", ?", len(args)-1)+`)
+		ORDER BY routine_id, position
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("query routine music sets: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var routineID string
+		var set music.WeightedSet
+		if err := rows.Scan(&routineID, &set.SetID, &set.Weight); err != nil {
+			return fmt.Errorf("read routine music set: %w", err)
+		}
+		if routine := byID[routineID]; routine != nil {
+			routine.MusicSets = append(routine.MusicSets, set)
+		}
+	}
+	return rows.Err()
+}
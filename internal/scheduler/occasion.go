@@ -0,0 +1,126 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/music"
+)
+
+// Music fallback behaviors (music_policy.fallback_behavior) for ROTATION/SHUFFLE routines.
+const (
+	MusicFallbackRelaxWindow = "RELAX_WINDOW" // Play from the set anyway
+	MusicFallbackSkipRoutine = "SKIP_ROUTINE" // Skip the run
+)
+
+// OccasionAction is what a routine did because its music set was out of season.
+type OccasionAction string
+
+const (
+	OccasionActionSkipped  OccasionAction = "skipped"  // SKIP_ROUTINE
+	OccasionActionRelaxed  OccasionAction = "relaxed"  // RELAX_WINDOW: played the set anyway
+	OccasionActionNoMusic  OccasionAction = "no_music" // No fallback: the scene ran without set music
+	OccasionActionExcluded OccasionAction = "excluded" // Played from the routine's other sets
+)
+
+// OccasionDecision records an occasions_enabled routine run on a day outside its music
+// set's occasion window. For a routine with several sets, MusicSetID is the first set out
+// of season.
+type OccasionDecision struct {
+	MusicSetID    string         `json:"music_set_id"`
+	OccasionStart string         `json:"occasion_start"`
+	OccasionEnd   string         `json:"occasion_end"`
+	Action        OccasionAction `json:"action"`
+
+	// With action excluded, the sets left out of the selection
+	ExcludedSetIDs []string `json:"excluded_set_ids,omitempty"`
+}
+
+// OccasionSkipError is returned by ExecuteRoutine when fallback_behavior SKIP_ROUTINE
+// skips a run because the music set is out of season.
+type OccasionSkipError struct {
+	Decision *OccasionDecision
+	Detail   *ExecutionDetail
+	SetName  string
+}
+
+func (e *OccasionSkipError) Error() string {
+	return fmt.Sprintf("Skipped: music set %s is only played %s to %s", e.SetName, e.Decision.OccasionStart, e.Decision.OccasionEnd)
+}
+
+// decideOccasion applies an occasions_enabled routine's occasion gating to its music set.
+// The set's window is checked against today in the routine's timezone. Returns nil when
+// the set may play as usual.
+func decideOccasion(routine *Routine, set *music.MusicSet, now time.Time) *OccasionDecision {
+	if !routine.OccasionsEnabled || set == nil || set.InOccasion(inRoutineTimezone(routine, now)) {
+		return nil
+	}
+
+	decision := &OccasionDecision{
+		MusicSetID:    set.SetID,
+		OccasionStart: *set.OccasionStart,
+		OccasionEnd:   *set.OccasionEnd,
+		Action:        OccasionActionNoMusic,
+	}
+	if routine.MusicFallbackBehavior != nil {
+		switch *routine.MusicFallbackBehavior {
+		case MusicFallbackRelaxWindow:
+			decision.Action = OccasionActionRelaxed
+		case MusicFallbackSkipRoutine:
+			decision.Action = OccasionActionSkipped
+		}
+	}
+	return decision
+}
+
+// occasionDecision looks up the routine's music sets and applies occasion gating to them
+// for the day the run is scheduled (now), returning the decision and the first set out of
+// season. Only ROTATION/SHUFFLE routines
+// play from sets. While any of a routine's sets is in season, the others are excluded
+// from selection; once none are, the fallback applies. A set that can't be read isn't
+// gated; resolving its content reports the problem.
+func (a *RoutineExecutorAdapter) occasionDecision(ctx context.Context, routine *Routine, now time.Time) (*OccasionDecision, *music.MusicSet) {
+	if !routine.OccasionsEnabled || a.musicService == nil ||
+		(routine.MusicPolicyType != MusicPolicyTypeRotation && routine.MusicPolicyType != MusicPolicyTypeShuffle) {
+		return nil, nil
+	}
+
+	var first *OccasionDecision
+	var firstSet *music.MusicSet
+	var excluded []string
+	inSeason := 0
+	for _, weighted := range routine.WeightedMusicSets() {
+		set, err := a.musicService.GetSet(weighted.SetID)
+		if err != nil || set == nil {
+			logging.From(ctx, a.logger).Warn("Failed to check music set occasion", "music_set_id", weighted.SetID, "error", err)
+			inSeason++
+			continue
+		}
+		decision := decideOccasion(routine, set, now)
+		if decision == nil {
+			inSeason++
+			continue
+		}
+		excluded = append(excluded, set.SetID)
+		if first == nil {
+			first, firstSet = decision, set
+		}
+	}
+
+	if first != nil && inSeason > 0 {
+		first.Action = OccasionActionExcluded
+		first.ExcludedSetIDs = excluded
+	}
+	return first, firstSet
+}
+
+// inRoutineTimezone returns now in the routine's timezone, or in UTC if it can't be loaded.
+func inRoutineTimezone(routine *Routine, now time.Time) time.Time {
+	loc, err := time.LoadLocation(routine.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return now.In(loc)
+}
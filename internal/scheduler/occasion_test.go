@@ -0,0 +1,128 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/config"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/music"
+)
+
+func TestDecideOccasion(t *testing.T) {
+	start, end := "01-01", "01-05"
+	set := &music.MusicSet{SetID: "set-new-year", Name: "New Year", OccasionStart: &start, OccasionEnd: &end}
+	routine := func(timezone string, fallback *string) *Routine {
+		return &Routine{Timezone: timezone, OccasionsEnabled: true, MusicFallbackBehavior: fallback}
+	}
+
+	// 23:30 UTC on Dec 31 is already Jan 1 in Tokyo
+	newYearsEve := time.Date(2025, 12, 31, 23, 30, 0, 0, time.UTC)
+	require.Nil(t, decideOccasion(routine("Asia/Tokyo", nil), set, newYearsEve))
+	require.NotNil(t, decideOccasion(routine("UTC", nil), set, newYearsEve))
+
+	// 05:00 UTC on Jan 6 is still Jan 5 in Los Angeles
+	lastNight := time.Date(2026, 1, 6, 5, 0, 0, 0, time.UTC)
+	require.Nil(t, decideOccasion(routine("America/Los_Angeles", nil), set, lastNight))
+	require.NotNil(t, decideOccasion(routine("UTC", nil), set, lastNight))
+	require.NotNil(t, decideOccasion(routine("Not/A_Zone", nil), set, lastNight), "unknown timezones use UTC")
+
+	t.Run("fallback behavior", func(t *testing.T) {
+		relax, skip := MusicFallbackRelaxWindow, MusicFallbackSkipRoutine
+		require.Equal(t, &OccasionDecision{MusicSetID: "set-new-year", OccasionStart: "01-01", OccasionEnd: "01-05", Action: OccasionActionNoMusic},
+			decideOccasion(routine("UTC", nil), set, lastNight))
+		require.Equal(t, OccasionActionRelaxed, decideOccasion(routine("UTC", &relax), set, lastNight).Action)
+		require.Equal(t, OccasionActionSkipped, decideOccasion(routine("UTC", &skip), set, lastNight).Action)
+	})
+
+	t.Run("occasions disabled", func(t *testing.T) {
+		disabled := routine("UTC", nil)
+		disabled.OccasionsEnabled = false
+		require.Nil(t, decideOccasion(disabled, set, lastNight))
+	})
+
+	t.Run("set without a window", func(t *testing.T) {
+		require.Nil(t, decideOccasion(routine("UTC", nil), &music.MusicSet{SetID: "set-any"}, lastNight))
+	})
+}
+
+func TestRoutineExecutorAdapter_Occasion(t *testing.T) {
+	dbPair := setupRunnerTestDB(t)
+	musicService := music.NewService(config.Config{}, dbPair, logging.Discard())
+
+	// A window that starts the day after tomorrow, so today is out of season
+	now := time.Now().UTC()
+	start := now.AddDate(0, 0, 2).Format("01-02")
+	end := now.AddDate(0, 0, 5).Format("01-02")
+	set, err := musicService.CreateSet(music.CreateSetInput{Name: "Halloween", SelectionPolicy: "SHUFFLE", OccasionStart: &start, OccasionEnd: &end})
+	require.NoError(t, err)
+
+	sceneExecutor := &fakeSceneExecutor{}
+	adapter := &RoutineExecutorAdapter{sceneExecutor: sceneExecutor, musicService: musicService, logger: logging.Discard()}
+	routine := func(fallback *string) *Routine {
+		return &Routine{
+			RoutineID:             "routine-1",
+			SceneID:               "scene-1",
+			Timezone:              "UTC",
+			MusicPolicyType:       MusicPolicyTypeShuffle,
+			MusicSetID:            &set.SetID,
+			MusicFallbackBehavior: fallback,
+			OccasionsEnabled:      true,
+		}
+	}
+
+	t.Run("SKIP_ROUTINE skips the run", func(t *testing.T) {
+		skip := MusicFallbackSkipRoutine
+		_, err := adapter.ExecuteRoutine(context.Background(), routine(&skip), time.Time{}, nil)
+		var occasionSkip *OccasionSkipError
+		require.True(t, errors.As(err, &occasionSkip))
+		require.Equal(t, OccasionActionSkipped, occasionSkip.Detail.Occasion.Action)
+		require.Equal(t, "Skipped: music set Halloween is only played "+start+" to "+end, err.Error())
+		require.Empty(t, sceneExecutor.options)
+
+		reason, detail, skipped := skippedRun(err)
+		require.True(t, skipped)
+		require.Equal(t, err.Error(), reason)
+		formatted := formatJobAsExecution(&Job{Status: JobStatusSkipped, ExecutionDetail: detail}, nil)
+		require.Equal(t, "out_of_occasion", formatted["failure_reason"])
+	})
+
+	t.Run("no fallback runs the scene without music", func(t *testing.T) {
+		execution, err := adapter.ExecuteRoutine(context.Background(), routine(nil), time.Time{}, nil)
+		require.NoError(t, err)
+		require.Nil(t, sceneExecutor.options[0].MusicContent)
+		require.Equal(t, OccasionActionNoMusic, execution.Detail.Occasion.Action)
+	})
+
+	t.Run("sets in season are played instead", func(t *testing.T) {
+		everyday, err := musicService.CreateSet(music.CreateSetInput{Name: "Everyday", SelectionPolicy: "SHUFFLE"})
+		require.NoError(t, err)
+		mixed := routine(nil)
+		mixed.MusicSets = []music.WeightedSet{{SetID: set.SetID, Weight: 1}, {SetID: everyday.SetID, Weight: 3}}
+
+		decision, outOfSeason := adapter.occasionDecision(context.Background(), mixed, now)
+		require.Equal(t, OccasionActionExcluded, decision.Action)
+		require.Equal(t, []string{set.SetID}, decision.ExcludedSetIDs)
+		require.Equal(t, set.SetID, outOfSeason.SetID)
+		require.Equal(t, []music.WeightedSet{{SetID: everyday.SetID, Weight: 3}},
+			withoutMusicSets(mixed.WeightedMusicSets(), decision.ExcludedSetIDs))
+	})
+
+	t.Run("runs are gated on their scheduled day", func(t *testing.T) {
+		execution, err := adapter.ExecuteRoutine(context.Background(), routine(nil), now.AddDate(0, 0, 2), nil)
+		require.NoError(t, err)
+		require.Nil(t, execution.Detail.Occasion)
+	})
+
+	t.Run("occasions disabled plays the set", func(t *testing.T) {
+		disabled := routine(nil)
+		disabled.OccasionsEnabled = false
+		execution, err := adapter.ExecuteRoutine(context.Background(), disabled, time.Time{}, nil)
+		require.NoError(t, err)
+		require.Nil(t, execution.Detail.Occasion)
+	})
+}
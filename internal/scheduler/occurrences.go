@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultOccurrencesCount is how many occurrences the preview returns by default.
+	DefaultOccurrencesCount = 10
+	// MaxOccurrencesCount bounds the occurrences preview.
+	MaxOccurrencesCount = 100
+)
+
+// OccurrenceSkipReason explains why a scheduled occurrence won't run.
+type OccurrenceSkipReason string
+
+const (
+	OccurrenceSkipHoliday  OccurrenceSkipReason = "holiday"
+	OccurrenceSkipSnoozed  OccurrenceSkipReason = "snoozed"
+	OccurrenceSkipSkipNext OccurrenceSkipReason = "skip_next"
+)
+
+// Occurrence is one upcoming scheduled time of a routine, annotated with what the
+// scheduler will do with it.
+type Occurrence struct {
+	ScheduledFor time.Time
+	SkipReason   OccurrenceSkipReason // Empty when the occurrence will run
+	Holiday      *Holiday             // Set when the occurrence falls on a holiday, even if it runs
+	DelayedUntil *time.Time           // Set when holiday_behavior DELAY moves the occurrence
+}
+
+// NextOccurrences computes up to count scheduled times strictly after after, from the
+// routine's schedule alone: enabled, snooze, skip_next and holidays are not applied.
+// Times are in the routine's timezone. It depends only on its arguments; coords may be
+// nil, in which case sunrise/sunset schedules use schedule_time. Fewer than count are
+// returned when the schedule ends, e.g. a one-time routine.
+func NextOccurrences(routine *Routine, after time.Time, count int, coords *Coordinates) ([]time.Time, error) {
+	generator := &JobGenerator{coordinates: coords}
+
+	occurrences := make([]time.Time, 0, count)
+	for len(occurrences) < count {
+		next, err := generator.CalculateNextRun(routine, after)
+		if err != nil {
+			return nil, err
+		}
+		if next.IsZero() || !next.After(after) {
+			break
+		}
+		occurrences = append(occurrences, next)
+		after = next
+	}
+
+	return occurrences, nil
+}
+
+// UpcomingOccurrences returns the routine's next count occurrences after now, annotated
+// with the snooze, skip_next and holiday behavior the scheduler will apply to each.
+// Holidays are only checked when the generator has a holidays repository.
+func (g *JobGenerator) UpcomingOccurrences(routine *Routine, now time.Time, count int) ([]Occurrence, error) {
+	var coords *Coordinates
+	var holidaysRepo *HolidaysRepository
+	if g != nil {
+		coords = g.coordinates
+		holidaysRepo = g.holidaysRepo
+	}
+
+	times, err := NextOccurrences(routine, now, count, coords)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences := make([]Occurrence, 0, len(times))
+	skipNextPending := routine.SkipNext
+	for _, scheduledFor := range times {
+		occurrence := Occurrence{ScheduledFor: scheduledFor}
+
+		if holidaysRepo != nil {
+			isHoliday, holiday, err := holidaysRepo.IsHolidayWithDetails(scheduledFor)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check holiday: %w", err)
+			}
+			if isHoliday {
+				occurrence.Holiday = holiday
+			}
+		}
+
+		switch {
+		case routine.SnoozeUntil != nil && scheduledFor.Before(*routine.SnoozeUntil):
+			occurrence.SkipReason = OccurrenceSkipSnoozed
+		case skipNextPending:
+			// skip_next consumes the first occurrence after any snooze
+			occurrence.SkipReason = OccurrenceSkipSkipNext
+			skipNextPending = false
+		case occurrence.Holiday != nil:
+			switch routine.HolidayBehavior {
+			case HolidayBehaviorRun, HolidayBehaviorPlayAlternate:
+			case HolidayBehaviorDelay:
+				delayed, err := g.findNextNonHoliday(scheduledFor)
+				if err != nil {
+					return nil, err
+				}
+				occurrence.DelayedUntil = delayed
+			default:
+				occurrence.SkipReason = OccurrenceSkipHoliday
+			}
+		}
+
+		occurrences = append(occurrences, occurrence)
+	}
+
+	return occurrences, nil
+}
@@ -0,0 +1,116 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextOccurrences_DSTBoundaries(t *testing.T) {
+	ny, _ := time.LoadLocation("America/New_York")
+	routine := &Routine{
+		ScheduleType:     ScheduleTypeWeekly,
+		ScheduleWeekdays: []int{0, 1, 2, 3, 4, 5, 6},
+		ScheduleTime:     "07:00",
+		Timezone:         "America/New_York",
+	}
+
+	// Spring forward on March 9, 2025: the local time stays 07:00 while the UTC offset changes
+	occurrences, err := NextOccurrences(routine, time.Date(2025, 3, 8, 12, 0, 0, 0, ny), 3, nil)
+	require.NoError(t, err)
+	require.Len(t, occurrences, 3)
+	for i, occurrence := range occurrences {
+		require.Equal(t, 9+i, occurrence.Day())
+		require.Equal(t, 7, occurrence.Hour())
+	}
+	require.Equal(t, 24*time.Hour-time.Hour, occurrences[0].Sub(time.Date(2025, 3, 8, 7, 0, 0, 0, ny)))
+
+	// Fall back on November 2, 2025
+	occurrences, err = NextOccurrences(routine, time.Date(2025, 10, 31, 12, 0, 0, 0, ny), 2, nil)
+	require.NoError(t, err)
+	require.Equal(t, 25*time.Hour, occurrences[1].Sub(occurrences[0]))
+
+	// A time skipped by spring forward runs an hour later that day
+	routine.ScheduleTime = "02:30"
+	occurrences, err = NextOccurrences(routine, time.Date(2025, 3, 8, 12, 0, 0, 0, ny), 2, nil)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2025, 3, 9, 3, 30, 0, 0, ny), occurrences[0])
+	require.Equal(t, time.Date(2025, 3, 10, 2, 30, 0, 0, ny), occurrences[1])
+}
+
+func TestNextOccurrences_EndsAndSkipsMissingDays(t *testing.T) {
+	utc := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	month, day := 6, 1
+	oneTime := &Routine{ScheduleType: ScheduleTypeOneTime, ScheduleMonth: &month, ScheduleDay: &day, ScheduleTime: "08:00", Timezone: "UTC"}
+	occurrences, err := NextOccurrences(oneTime, utc, 5, nil)
+	require.NoError(t, err)
+	require.Equal(t, []time.Time{time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)}, occurrences)
+
+	// Months without a 31st are skipped
+	day = 31
+	monthly := &Routine{ScheduleType: ScheduleTypeMonthly, ScheduleDay: &day, ScheduleTime: "08:00", Timezone: "UTC"}
+	occurrences, err = NextOccurrences(monthly, utc, 4, nil)
+	require.NoError(t, err)
+	require.Len(t, occurrences, 4)
+	for i, want := range []time.Month{time.January, time.March, time.May, time.July} {
+		require.Equal(t, want, occurrences[i].Month())
+		require.Equal(t, 31, occurrences[i].Day())
+	}
+}
+
+func TestUpcomingOccurrences(t *testing.T) {
+	generator, _, _, holidaysRepo, _ := setupTestGeneratorDB(t)
+	now := time.Date(2025, 12, 20, 12, 0, 0, 0, time.UTC)
+
+	_, err := holidaysRepo.Create(CreateHolidayInput{Date: time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC), Name: "Christmas"})
+	require.NoError(t, err)
+
+	snoozeUntil := time.Date(2025, 12, 22, 0, 0, 0, 0, time.UTC)
+	routine := &Routine{
+		ScheduleType:     ScheduleTypeWeekly,
+		ScheduleWeekdays: []int{0, 1, 2, 3, 4, 5, 6},
+		ScheduleTime:     "07:00",
+		Timezone:         "UTC",
+		Enabled:          true,
+		SnoozeUntil:      &snoozeUntil,
+		SkipNext:         true,
+		HolidayBehavior:  HolidayBehaviorSkip,
+	}
+
+	occurrences, err := generator.UpcomingOccurrences(routine, now, 7)
+	require.NoError(t, err)
+	require.Len(t, occurrences, 7)
+
+	reasons := make([]OccurrenceSkipReason, len(occurrences))
+	for i, occurrence := range occurrences {
+		reasons[i] = occurrence.SkipReason
+	}
+	// Dec 21 is snoozed, skip_next consumes Dec 22, and Christmas is skipped
+	require.Equal(t, []OccurrenceSkipReason{
+		OccurrenceSkipSnoozed, OccurrenceSkipSkipNext, "", "", OccurrenceSkipHoliday, "", "",
+	}, reasons)
+	require.Equal(t, "Christmas", occurrences[4].Holiday.Name)
+
+	routine.SnoozeUntil = nil
+	routine.SkipNext = false
+	routine.HolidayBehavior = HolidayBehaviorDelay
+	occurrences, err = generator.UpcomingOccurrences(routine, now, 5)
+	require.NoError(t, err)
+	christmas := occurrences[4]
+	require.Empty(t, christmas.SkipReason)
+	require.NotNil(t, christmas.Holiday)
+	require.Equal(t, time.Date(2025, 12, 26, 7, 0, 0, 0, time.UTC), *christmas.DelayedUntil)
+
+	routine.HolidayBehavior = HolidayBehaviorRun
+	occurrences, err = generator.UpcomingOccurrences(routine, now, 5)
+	require.NoError(t, err)
+	require.Empty(t, occurrences[4].SkipReason)
+	require.Nil(t, occurrences[4].DelayedUntil)
+
+	var noGenerator *JobGenerator
+	occurrences, err = noGenerator.UpcomingOccurrences(routine, now, 5)
+	require.NoError(t, err)
+	require.Nil(t, occurrences[4].Holiday, "holidays aren't checked without a repository")
+}
@@ -0,0 +1,173 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
+)
+
+// ErrNoPlaybackSnapshot is returned when a routine has no captured playback to restore.
+var ErrNoPlaybackSnapshot = errors.New("no playback snapshot to restore")
+
+// PlaybackStateController captures and restores speaker playback, and stops speakers
+// whose previous source can't be put back. It is implemented by sonos.Service.
+type PlaybackStateController interface {
+	sonos.PlaybackStateClient
+	Stop(deviceIP string) error
+}
+
+// RestoreAction is what restoring a snapshot did to one speaker.
+type RestoreAction string
+
+const (
+	RestoreActionRestored RestoreAction = "restored" // Previous playback was put back
+	RestoreActionStopped  RestoreAction = "stopped"  // Source can't be put back, so the routine's playback was stopped
+	RestoreActionRejoined RestoreAction = "rejoined" // Grouped member, rejoined to its coordinator
+	RestoreActionFailed   RestoreAction = "failed"
+)
+
+// DeviceRestoreResult reports how one speaker was restored.
+type DeviceRestoreResult struct {
+	UDN        string        `json:"udn"`
+	Action     RestoreAction `json:"action"`
+	SkipReason string        `json:"skip_reason,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// PlaybackRestorer snapshots what a routine's speakers were playing before a run and
+// puts it back afterwards, for routines with restore_previous_state. Snapshots are
+// stored on the run's job, so a restart doesn't lose them.
+type PlaybackRestorer struct {
+	controller  PlaybackStateController
+	resolver    DeviceIPResolver
+	jobsRepo    *JobsRepository
+	autoStopper *AutoStopper
+	logger      *slog.Logger
+}
+
+// NewPlaybackRestorer creates a PlaybackRestorer. autoStopper may be nil; when set, a
+// manual restore cancels the routine's pending auto-stop.
+func NewPlaybackRestorer(controller PlaybackStateController, resolver DeviceIPResolver, jobsRepo *JobsRepository, autoStopper *AutoStopper, logger *slog.Logger) *PlaybackRestorer {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &PlaybackRestorer{
+		controller:  controller,
+		resolver:    resolver,
+		jobsRepo:    jobsRepo,
+		autoStopper: autoStopper,
+		logger:      logger,
+	}
+}
+
+// Capture snapshots each of the speakers a routine plays on before a run. A snapshot from
+// an earlier run that hasn't been restored yet is carried over instead, so back-to-back
+// runs still restore what played before the first. Unreachable speakers are left out.
+func (p *PlaybackRestorer) Capture(ctx context.Context, routine *Routine, speakers []Speaker) []sonos.PlaybackSnapshot {
+	logger := logging.From(ctx, p.logger)
+	_, pending, err := p.jobsRepo.GetPendingPlaybackSnapshot(routine.RoutineID)
+	if err != nil {
+		logger.Warn("Failed to check pending playback snapshot", "error", err)
+	}
+	if pending != nil {
+		return pending
+	}
+
+	snapshots := []sonos.PlaybackSnapshot{}
+	for _, speaker := range speakers {
+		ip, err := p.resolver.ResolveDeviceIP(speaker.UDN)
+		if err != nil || ip == "" {
+			continue
+		}
+		snapshot, err := sonos.CapturePlaybackSnapshot(p.controller, ip, speaker.UDN)
+		if err != nil {
+			logger.Warn("Failed to snapshot playback", "udn", speaker.UDN, "error", err)
+			continue
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+	return snapshots
+}
+
+// Restore puts back the playback captured before the routine's latest run and clears
+// the routine's snapshots, so each is applied once. Speakers that were on TV audio,
+// line-in or nothing have the routine's playback stopped instead; grouped members
+// rejoin their coordinator once the rest are restored, so they pick up what it plays.
+// Returns the job the snapshot came from.
+func (p *PlaybackRestorer) Restore(routineID string) (string, []DeviceRestoreResult, error) {
+	if p.autoStopper != nil {
+		p.autoStopper.Cancel(routineID)
+	}
+
+	jobID, snapshots, err := p.jobsRepo.GetPendingPlaybackSnapshot(routineID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to load playback snapshot: %w", err)
+	}
+	if jobID == "" {
+		return "", nil, ErrNoPlaybackSnapshot
+	}
+	if err := p.jobsRepo.ClearPlaybackSnapshots(routineID); err != nil {
+		return "", nil, fmt.Errorf("failed to clear playback snapshot: %w", err)
+	}
+
+	logger := p.logger.With("routine_id", routineID, "job_id", jobID)
+	results := make([]DeviceRestoreResult, 0, len(snapshots))
+	for _, members := range []bool{false, true} {
+		for i := range snapshots {
+			if (snapshots[i].SkipReason == sonos.SnapshotSkipGrouped) != members {
+				continue
+			}
+			result := p.restoreDevice(&snapshots[i])
+			if result.Error != "" {
+				logger.Warn("Restore failed on speaker", "action", result.Action, "udn", result.UDN, "error", result.Error)
+			}
+			results = append(results, result)
+		}
+	}
+	logger.Info("Restored previous playback")
+	return jobID, results, nil
+}
+
+// restoreDevice restores one speaker's snapshot.
+func (p *PlaybackRestorer) restoreDevice(snapshot *sonos.PlaybackSnapshot) DeviceRestoreResult {
+	result := DeviceRestoreResult{UDN: snapshot.UDN, Action: RestoreActionRestored, SkipReason: snapshot.SkipReason}
+
+	ip, err := p.resolver.ResolveDeviceIP(snapshot.UDN)
+	if err == nil && ip == "" {
+		err = fmt.Errorf("device %s has no IP", snapshot.UDN)
+	}
+	if err != nil {
+		result.Action = RestoreActionFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	switch snapshot.SkipReason {
+	case "":
+	case sonos.SnapshotSkipGrouped:
+		// The captured x-rincon: URI names the coordinator, and setting it joins its group
+		result.Action = RestoreActionRejoined
+		if err := p.controller.SetAVTransportURIWithMetadata(ip, snapshot.TransportURI, ""); err != nil {
+			result.Action = RestoreActionFailed
+			result.Error = fmt.Sprintf("failed to rejoin group: %v", err)
+			return result
+		}
+	default:
+		result.Action = RestoreActionStopped
+		if err := p.controller.Stop(ip); err != nil {
+			result.Action = RestoreActionFailed
+			result.Error = fmt.Sprintf("failed to stop: %v", err)
+			return result
+		}
+	}
+
+	if err := sonos.RestorePlaybackSnapshot(p.controller, ip, snapshot); err != nil {
+		result.Action = RestoreActionFailed
+		result.Error = err.Error()
+	}
+	return result
+}
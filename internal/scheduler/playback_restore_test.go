@@ -0,0 +1,218 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/scene"
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// fakeStateController holds each speaker's transport URI, state and volume, and
+// records the commands sent to it.
+type fakeStateController struct {
+	mu      sync.Mutex
+	uris    map[string]string
+	states  map[string]string
+	volumes map[string]int
+	calls   []string
+}
+
+func (f *fakeStateController) GetMediaInfo(deviceIP string) (soap.MediaInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return soap.MediaInfo{CurrentURI: f.uris[deviceIP]}, nil
+}
+
+func (f *fakeStateController) GetPositionInfo(deviceIP string) (soap.PositionInfo, error) {
+	return soap.PositionInfo{Track: 3, RelTime: "0:02:10"}, nil
+}
+
+func (f *fakeStateController) GetTransportInfo(deviceIP string) (soap.TransportInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return soap.TransportInfo{CurrentTransportState: f.states[deviceIP]}, nil
+}
+
+func (f *fakeStateController) GetVolume(deviceIP string) (soap.VolumeInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return soap.VolumeInfo{CurrentVolume: f.volumes[deviceIP]}, nil
+}
+
+func (f *fakeStateController) record(call string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+	return nil
+}
+
+func (f *fakeStateController) SetAVTransportURIWithMetadata(deviceIP, uri, metadata string) error {
+	return f.record(fmt.Sprintf("%s uri %s", deviceIP, uri))
+}
+
+func (f *fakeStateController) Seek(deviceIP, unit, target string) error {
+	return f.record(fmt.Sprintf("%s seek %s %s", deviceIP, unit, target))
+}
+
+func (f *fakeStateController) SetVolume(deviceIP string, level int) error {
+	return f.record(fmt.Sprintf("%s volume %d", deviceIP, level))
+}
+
+func (f *fakeStateController) Play(deviceIP string) error {
+	return f.record(deviceIP + " play")
+}
+
+func (f *fakeStateController) Stop(deviceIP string) error {
+	return f.record(deviceIP + " stop")
+}
+
+func (f *fakeStateController) recorded() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+func newFakeStateController() *fakeStateController {
+	return &fakeStateController{
+		uris:    map[string]string{"10.0.0.1": "x-rincon-queue:RINCON_KITCHEN#0", "10.0.0.2": "x-sonos-htastream:RINCON_ARC:spdif"},
+		states:  map[string]string{"10.0.0.1": "PLAYING", "10.0.0.2": "PLAYING"},
+		volumes: map[string]int{"10.0.0.1": 18, "10.0.0.2": 35},
+	}
+}
+
+func TestPlaybackRestorer_RunAndRestore(t *testing.T) {
+	dbPair := setupRunnerTestDB(t)
+	jobsRepo := NewJobsRepository(dbPair)
+	routinesRepo := NewRoutinesRepository(dbPair)
+
+	restorePrevious := true
+	routine := createTestRoutine(t, routinesRepo, createTestScene(t, dbPair))
+	routine, err := routinesRepo.Update(routine.RoutineID, UpdateRoutineInput{
+		RestorePreviousState: &restorePrevious,
+		SpeakersJSON:         []Speaker{{UDN: "udn-kitchen"}, {UDN: "udn-arc"}},
+	})
+	require.NoError(t, err)
+	require.True(t, routine.RestorePreviousState)
+
+	controller := newFakeStateController()
+	resolver := fakeIPResolver{"udn-kitchen": "10.0.0.1", "udn-arc": "10.0.0.2"}
+	restorer := NewPlaybackRestorer(controller, resolver, jobsRepo, nil, logging.Discard())
+	adapter := &RoutineExecutorAdapter{sceneExecutor: &fakeSceneExecutor{}, logger: logging.Discard()}
+	adapter.SetPlaybackRestorer(restorer)
+
+	runner := NewJobRunner(newTestLogger(), jobsRepo, routinesRepo, adapter, 100*time.Millisecond, 3)
+	job := createTestJob(t, jobsRepo, routine.RoutineID, time.Now().UTC().Add(-time.Minute))
+	require.NoError(t, runner.executeJob(job))
+
+	jobID, snapshots, err := jobsRepo.GetPendingPlaybackSnapshot(routine.RoutineID)
+	require.NoError(t, err)
+	require.Equal(t, job.JobID, jobID)
+	require.Equal(t, []sonos.PlaybackSnapshot{
+		{UDN: "udn-kitchen", TransportURI: "x-rincon-queue:RINCON_KITCHEN#0", Track: 3, RelTime: "0:02:10", TransportState: "PLAYING", Volume: 18},
+		{UDN: "udn-arc", TransportURI: "x-sonos-htastream:RINCON_ARC:spdif", TransportState: "PLAYING", Volume: 35, SkipReason: sonos.SnapshotSkipTV},
+	}, snapshots)
+
+	// A second run before the restore keeps what played before the first
+	controller.uris["10.0.0.1"] = "x-sonosapi-stream:routine-music"
+	second := createTestJob(t, jobsRepo, routine.RoutineID, time.Now().UTC())
+	require.NoError(t, runner.executeJob(second))
+	jobID, carried, err := jobsRepo.GetPendingPlaybackSnapshot(routine.RoutineID)
+	require.NoError(t, err)
+	require.Equal(t, second.JobID, jobID)
+	require.Equal(t, snapshots, carried)
+
+	jobID, results, err := restorer.Restore(routine.RoutineID)
+	require.NoError(t, err)
+	require.Equal(t, second.JobID, jobID)
+	require.Equal(t, []DeviceRestoreResult{
+		{UDN: "udn-kitchen", Action: RestoreActionRestored},
+		{UDN: "udn-arc", Action: RestoreActionStopped, SkipReason: sonos.SnapshotSkipTV},
+	}, results)
+	require.Equal(t, []string{
+		"10.0.0.1 uri x-rincon-queue:RINCON_KITCHEN#0",
+		"10.0.0.1 seek TRACK_NR 3",
+		"10.0.0.1 seek REL_TIME 0:02:10",
+		"10.0.0.1 volume 18",
+		"10.0.0.1 play",
+		"10.0.0.2 stop",
+		"10.0.0.2 volume 35",
+	}, controller.recorded())
+
+	// Each snapshot is applied once
+	_, _, err = restorer.Restore(routine.RoutineID)
+	require.ErrorIs(t, err, ErrNoPlaybackSnapshot)
+}
+
+func TestAutoStopper_RestoresPreviousPlayback(t *testing.T) {
+	dbPair := setupRunnerTestDB(t)
+	jobsRepo := NewJobsRepository(dbPair)
+	routinesRepo := NewRoutinesRepository(dbPair)
+	routine := createTestRoutine(t, routinesRepo, createTestScene(t, dbPair))
+	routine.RestorePreviousState = true
+	routine.SpeakersJSON = []Speaker{{UDN: "udn-kitchen"}}
+
+	controller := newFakeStateController()
+	resolver := fakeIPResolver{"udn-kitchen": "10.0.0.1"}
+	stopper := NewAutoStopper(controller, resolver, logging.Discard())
+	stopper.SetPlaybackRestorer(NewPlaybackRestorer(controller, resolver, jobsRepo, stopper, logging.Discard()))
+
+	job := createTestJob(t, jobsRepo, routine.RoutineID, time.Now().UTC())
+	require.NoError(t, jobsRepo.SetPlaybackSnapshot(job.JobID, []sonos.PlaybackSnapshot{
+		{UDN: "udn-kitchen", TransportURI: "x-sonosapi-stream:s12345", TransportState: "STOPPED", Volume: 12},
+	}))
+
+	stopper.Schedule(context.Background(), routine, routine.SpeakersJSON, "", nil, 10*time.Millisecond)
+	require.Eventually(t, func() bool { return len(controller.recorded()) == 2 }, time.Second, 5*time.Millisecond)
+	require.Equal(t, []string{"10.0.0.1 uri x-sonosapi-stream:s12345", "10.0.0.1 volume 12"}, controller.recorded())
+
+	// Without a snapshot the routine's playback is stopped as usual
+	controller.uris["10.0.0.1"] = "x-sonosapi-stream:routine-music"
+	stopper.Schedule(context.Background(), routine, routine.SpeakersJSON, "", nil, 10*time.Millisecond)
+	require.Eventually(t, func() bool { return len(controller.recorded()) == 3 }, time.Second, 5*time.Millisecond)
+	require.Equal(t, "10.0.0.1 stop", controller.recorded()[2])
+}
+
+func TestPlaybackRestorer_RejoinsGroupedMembers(t *testing.T) {
+	dbPair := setupRunnerTestDB(t)
+	jobsRepo := NewJobsRepository(dbPair)
+	routine := createTestRoutine(t, NewRoutinesRepository(dbPair), createTestScene(t, dbPair))
+
+	// A scene-only routine: the den was grouped with the kitchen before the run
+	controller := newFakeStateController()
+	controller.uris["10.0.0.3"] = "x-rincon:RINCON_KITCHEN"
+	controller.volumes["10.0.0.3"] = 22
+	resolver := fakeIPResolver{"udn-kitchen": "10.0.0.1", "udn-den": "10.0.0.3"}
+	restorer := NewPlaybackRestorer(controller, resolver, jobsRepo, nil, logging.Discard())
+
+	routineScene := &scene.Scene{Members: []scene.SceneMember{{UDN: "udn-den"}, {UDN: "udn-kitchen"}}}
+	snapshots := restorer.Capture(context.Background(), routine, speakersOf(routine, routineScene))
+	require.Len(t, snapshots, 2)
+	require.Equal(t, sonos.SnapshotSkipGrouped, snapshots[0].SkipReason)
+
+	job := createTestJob(t, jobsRepo, routine.RoutineID, time.Now().UTC())
+	require.NoError(t, jobsRepo.SetPlaybackSnapshot(job.JobID, snapshots))
+
+	_, results, err := restorer.Restore(routine.RoutineID)
+	require.NoError(t, err)
+	require.Equal(t, []DeviceRestoreResult{
+		{UDN: "udn-kitchen", Action: RestoreActionRestored},
+		{UDN: "udn-den", Action: RestoreActionRejoined, SkipReason: sonos.SnapshotSkipGrouped},
+	}, results, "members rejoin once their coordinator is restored")
+	require.Equal(t, []string{
+		"10.0.0.1 uri x-rincon-queue:RINCON_KITCHEN#0",
+		"10.0.0.1 seek TRACK_NR 3",
+		"10.0.0.1 seek REL_TIME 0:02:10",
+		"10.0.0.1 volume 18",
+		"10.0.0.1 play",
+		"10.0.0.3 uri x-rincon:RINCON_KITCHEN",
+		"10.0.0.3 volume 22",
+	}, controller.recorded())
+}
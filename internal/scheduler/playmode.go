@@ -0,0 +1,41 @@
+package scheduler
+
+import (
+	"context"
+
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/scene"
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
+)
+
+// SetPlayModeController enables music_policy.play_mode: once a routine's playback has
+// started, its shuffle, repeat and crossfade settings are applied to the coordinator.
+func (a *RoutineExecutorAdapter) SetPlayModeController(client sonos.PlayModeClient) {
+	a.playModes = client
+}
+
+// applyPlayMode applies the routine's play mode to the coordinator the scene played on.
+// Failures are logged and don't fail the run; sources such as radio don't support
+// shuffle or repeat. Returns the resulting play mode, or nil when none was applied.
+func (a *RoutineExecutorAdapter) applyPlayMode(ctx context.Context, routine *Routine, execution *scene.SceneExecution) *sonos.PlayMode {
+	if a.playModes == nil || a.ipResolver == nil || routine.MusicPlayMode == nil || routine.MusicPlayMode.IsEmpty() {
+		return nil
+	}
+	if execution == nil || execution.CoordinatorUsedUDN == nil {
+		return nil
+	}
+
+	ip, err := a.ipResolver.ResolveDeviceIP(*execution.CoordinatorUsedUDN)
+	if err != nil || ip == "" {
+		logging.From(ctx, a.logger).Warn("Failed to resolve coordinator to apply play mode",
+			"udn", *execution.CoordinatorUsedUDN, "error", err)
+		return nil
+	}
+
+	playMode, err := sonos.ApplyPlayMode(a.playModes, ip, *routine.MusicPlayMode)
+	if err != nil {
+		logging.From(ctx, a.logger).Warn("Failed to apply play mode", "error", err)
+		return nil
+	}
+	return &playMode
+}
@@ -0,0 +1,120 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/scene"
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+type fakePlayModeClient struct {
+	modes      map[string]string
+	crossfades map[string]bool
+}
+
+func (f *fakePlayModeClient) GetTransportSettings(deviceIP string) (soap.TransportSettings, error) {
+	return soap.TransportSettings{PlayMode: f.modes[deviceIP]}, nil
+}
+
+func (f *fakePlayModeClient) SetPlayMode(deviceIP, mode string) error {
+	f.modes[deviceIP] = mode
+	return nil
+}
+
+func (f *fakePlayModeClient) GetCrossfadeMode(deviceIP string) (bool, error) {
+	return f.crossfades[deviceIP], nil
+}
+
+func (f *fakePlayModeClient) SetCrossfadeMode(deviceIP string, enabled bool) error {
+	f.crossfades[deviceIP] = enabled
+	return nil
+}
+
+type coordinatorSceneExecutor struct {
+	coordinatorUDN string
+}
+
+func (f *coordinatorSceneExecutor) ExecuteScene(ctx context.Context, sceneID string, idempotencyKey *string, options scene.ExecuteOptions) (*scene.SceneExecution, error) {
+	return &scene.SceneExecution{
+		SceneExecutionID:   "exec-1",
+		SceneID:            sceneID,
+		Status:             scene.ExecutionStatusPlayingConfirmed,
+		CoordinatorUsedUDN: &f.coordinatorUDN,
+	}, nil
+}
+
+func TestRoutinesRepository_MusicPlayMode(t *testing.T) {
+	routinesRepo, _, _, scenesRepo := setupTestDB(t)
+
+	s, err := scenesRepo.Create(scene.CreateSceneInput{
+		Name:    "Test Scene",
+		Members: []scene.SceneMember{},
+	})
+	require.NoError(t, err)
+
+	shuffle := true
+	repeat := sonos.RepeatAll
+	routine, err := routinesRepo.Create(CreateRoutineInput{
+		Name:          "Morning",
+		Timezone:      "UTC",
+		ScheduleTime:  "08:00",
+		SceneID:       s.SceneID,
+		MusicPlayMode: &sonos.PlayModeUpdate{Shuffle: &shuffle, Repeat: &repeat},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, routine.MusicPlayMode)
+	require.True(t, *routine.MusicPlayMode.Shuffle)
+	require.Equal(t, sonos.RepeatAll, *routine.MusicPlayMode.Repeat)
+	require.Nil(t, routine.MusicPlayMode.Crossfade)
+
+	// Unrelated updates keep the play mode; an empty one clears it
+	newName := "Morning Music"
+	updated, err := routinesRepo.Update(routine.RoutineID, UpdateRoutineInput{Name: &newName})
+	require.NoError(t, err)
+	require.Equal(t, routine.MusicPlayMode, updated.MusicPlayMode)
+
+	updated, err = routinesRepo.Update(routine.RoutineID, UpdateRoutineInput{MusicPlayMode: &sonos.PlayModeUpdate{}})
+	require.NoError(t, err)
+	require.Nil(t, updated.MusicPlayMode)
+}
+
+func TestRoutineExecutorAdapter_PlayMode(t *testing.T) {
+	client := &fakePlayModeClient{
+		modes:      map[string]string{"10.0.0.1": "NORMAL"},
+		crossfades: map[string]bool{},
+	}
+	adapter := &RoutineExecutorAdapter{
+		sceneExecutor: &coordinatorSceneExecutor{coordinatorUDN: "udn-kitchen"},
+		ipResolver:    fakeIPResolver{"udn-kitchen": "10.0.0.1"},
+		logger:        logging.Discard(),
+	}
+	adapter.SetPlayModeController(client)
+
+	t.Run("no play mode leaves the speaker alone", func(t *testing.T) {
+		execution, err := adapter.ExecuteRoutine(context.Background(), &Routine{RoutineID: "routine-1", SceneID: "scene-1"}, time.Time{}, nil)
+		require.NoError(t, err)
+		require.Nil(t, execution.Detail.PlayMode)
+		require.Equal(t, "NORMAL", client.modes["10.0.0.1"])
+	})
+
+	t.Run("play mode is applied to the coordinator", func(t *testing.T) {
+		shuffle := true
+		crossfade := true
+		routine := &Routine{
+			RoutineID:     "routine-1",
+			SceneID:       "scene-1",
+			MusicPlayMode: &sonos.PlayModeUpdate{Shuffle: &shuffle, Crossfade: &crossfade},
+		}
+		execution, err := adapter.ExecuteRoutine(context.Background(), routine, time.Time{}, nil)
+		require.NoError(t, err)
+		require.Equal(t, &sonos.PlayMode{Shuffle: true, Repeat: sonos.RepeatNone, Crossfade: true}, execution.Detail.PlayMode)
+		require.Equal(t, "SHUFFLE_NOREPEAT", client.modes["10.0.0.1"])
+		require.True(t, client.crossfades["10.0.0.1"])
+	})
+}
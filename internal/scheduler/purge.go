@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// ScenePurger permanently removes soft-deleted scenes (implemented by scene.Service).
+type ScenePurger interface {
+	PurgeScene(sceneID string) error
+}
+
+// DeletedRoutinePurger hard-deletes routines that were soft-deleted before a cutoff,
+// along with their scenes. Deleting a routine takes its jobs and music set links with it;
+// its play history is kept, unlinked. It's run nightly by retention.Pruner.
+type DeletedRoutinePurger struct {
+	routines *RoutinesRepository
+	scenes   ScenePurger
+	logger   *slog.Logger
+}
+
+// NewDeletedRoutinePurger creates a purger. A nil scenes leaves purged routines' scenes
+// soft-deleted.
+func NewDeletedRoutinePurger(routines *RoutinesRepository, scenes ScenePurger, logger *slog.Logger) *DeletedRoutinePurger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &DeletedRoutinePurger{routines: routines, scenes: scenes, logger: logger}
+}
+
+// PruneOlderThan hard-deletes routines soft-deleted before cutoff and returns the count
+// purged. Routines are few, so they're purged one at a time rather than in batches. A
+// failure doesn't stop the rest from being purged.
+func (p *DeletedRoutinePurger) PruneOlderThan(cutoff time.Time, batchSize int) (int64, error) {
+	routineIDs, err := p.routines.GetExpiredSoftDeletes(cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	var errs []error
+	for _, routineID := range routineIDs {
+		routine, _, err := p.routines.GetByIDIncludingDeleted(routineID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if routine == nil {
+			continue
+		}
+
+		if err := p.routines.HardDelete(routineID); err != nil {
+			if !errors.Is(err, sql.ErrNoRows) { // Restored since it was listed
+				errs = append(errs, err)
+			}
+			continue
+		}
+		purged++
+
+		// The routine referenced the scene, so it has to go first
+		if routine.SceneID != "" && p.scenes != nil {
+			if err := p.scenes.PurgeScene(routine.SceneID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+				errs = append(errs, err)
+			}
+		}
+		p.logger.Info("Purged deleted routine", "routine_id", routineID, "scene_id", routine.SceneID)
+	}
+	return purged, errors.Join(errs...)
+}
@@ -0,0 +1,106 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/scene"
+)
+
+// repoScenePurger purges scenes straight from the repository, as scene.Service does.
+type repoScenePurger struct {
+	scenes *scene.ScenesRepository
+}
+
+func (p repoScenePurger) PurgeScene(sceneID string) error {
+	return p.scenes.HardDelete(sceneID)
+}
+
+func TestDeletedRoutinePurger_PruneOlderThan(t *testing.T) {
+	routinesRepo, jobsRepo, _, scenesRepo := setupTestDB(t)
+
+	createRoutine := func(name string) *Routine {
+		s, err := scenesRepo.Create(scene.CreateSceneInput{Name: name + " Scene", Members: []scene.SceneMember{}})
+		require.NoError(t, err)
+		routine, err := routinesRepo.Create(CreateRoutineInput{
+			Name:         name,
+			Timezone:     "UTC",
+			ScheduleTime: "08:00",
+			SceneID:      s.SceneID,
+		})
+		require.NoError(t, err)
+		return routine
+	}
+
+	deleted := createRoutine("Deleted")
+	job, err := jobsRepo.Create(CreateJobInput{RoutineID: deleted.RoutineID, ScheduledFor: time.Now().UTC()})
+	require.NoError(t, err)
+	require.NoError(t, routinesRepo.Delete(deleted.RoutineID))
+	require.NoError(t, scenesRepo.Delete(deleted.SceneID))
+	live := createRoutine("Live")
+
+	purger := NewDeletedRoutinePurger(routinesRepo, repoScenePurger{scenes: scenesRepo}, nil)
+
+	// Deleted after the cutoff: still restorable
+	purged, err := purger.PruneOlderThan(time.Now().Add(-time.Hour), 500)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), purged)
+	_, isDeleted, err := routinesRepo.GetByIDIncludingDeleted(deleted.RoutineID)
+	require.NoError(t, err)
+	require.True(t, isDeleted)
+
+	purged, err = purger.PruneOlderThan(time.Now().Add(time.Hour), 500)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), purged)
+
+	// The routine, its scene and its jobs are gone
+	routine, _, err := routinesRepo.GetByIDIncludingDeleted(deleted.RoutineID)
+	require.NoError(t, err)
+	require.Nil(t, routine)
+	s, _, err := scenesRepo.GetByIDIncludingDeleted(deleted.SceneID)
+	require.NoError(t, err)
+	require.Nil(t, s)
+	gone, err := jobsRepo.GetByID(job.JobID)
+	require.NoError(t, err)
+	require.Nil(t, gone)
+
+	// Routines that weren't deleted are untouched
+	routine, err = routinesRepo.GetByID(live.RoutineID)
+	require.NoError(t, err)
+	require.NotNil(t, routine)
+	s, err = scenesRepo.GetByID(live.SceneID)
+	require.NoError(t, err)
+	require.NotNil(t, s)
+}
+
+func TestRoutinesRepository_ListIncludingDeleted(t *testing.T) {
+	routinesRepo, _, _, scenesRepo := setupTestDB(t)
+
+	s, err := scenesRepo.Create(scene.CreateSceneInput{Name: "Test Scene", Members: []scene.SceneMember{}})
+	require.NoError(t, err)
+	var routineIDs []string
+	for _, name := range []string{"Kept", "Deleted"} {
+		routine, err := routinesRepo.Create(CreateRoutineInput{Name: name, Timezone: "UTC", ScheduleTime: "08:00", SceneID: s.SceneID})
+		require.NoError(t, err)
+		routineIDs = append(routineIDs, routine.RoutineID)
+	}
+	require.NoError(t, routinesRepo.Delete(routineIDs[1]))
+
+	routines, total, err := routinesRepo.List(10, 0, false)
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	require.Len(t, routines, 1)
+	require.Nil(t, routines[0].DeletedAt)
+
+	routines, total, err = routinesRepo.ListIncludingDeleted(10, 0, false)
+	require.NoError(t, err)
+	require.Equal(t, 2, total)
+	deletedAt := map[string]*time.Time{}
+	for _, routine := range routines {
+		deletedAt[routine.RoutineID] = routine.DeletedAt
+	}
+	require.Nil(t, deletedAt[routineIDs[0]])
+	require.NotNil(t, deletedAt[routineIDs[1]])
+}
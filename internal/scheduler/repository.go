@@ -5,9 +5,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/strefethen/sonos-hub-go/internal/db"
+	"github.com/strefethen/sonos-hub-go/internal/music"
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
 )
 
 // ==========================================================================
@@ -21,6 +27,7 @@ const (
 	MusicPolicyTypeFixed    MusicPolicyType = "FIXED"
 	MusicPolicyTypeRotation MusicPolicyType = "ROTATION"
 	MusicPolicyTypeShuffle  MusicPolicyType = "SHUFFLE"
+	MusicPolicyTypeNone     MusicPolicyType = "NONE" // Chooses no music; the routine runs its actions, or its scene as is
 )
 
 // ArcTVPolicy determines behavior when Arc is in TV mode.
@@ -34,8 +41,12 @@ const (
 
 // Speaker represents a speaker configuration for a routine.
 type Speaker struct {
-	UDN    string `json:"udn"`
-	Volume *int   `json:"volume,omitempty"`
+	UDN           string               `json:"udn"`
+	Volume        *int                 `json:"volume,omitempty"`
+	FallbackUDN   string               `json:"fallback_udn,omitempty"`
+	FadeInMs      *int                 `json:"fade_in_ms,omitempty"`
+	FadeCurve     string               `json:"fade_curve,omitempty"`
+	AudioSettings *sonos.AudioSettings `json:"audio_settings,omitempty"`
 }
 
 // ==========================================================================
@@ -52,9 +63,12 @@ type CreateRoutineInput struct {
 	ScheduleMonth              *int            `json:"schedule_month,omitempty"`
 	ScheduleDay                *int            `json:"schedule_day,omitempty"`
 	ScheduleTime               string          `json:"schedule_time"`
+	ScheduleIntervalDays       *int            `json:"schedule_interval_days,omitempty"`
+	ScheduleAnchorDate         *string         `json:"schedule_anchor_date,omitempty"` // YYYY-MM-DD
+	ScheduleTimeMode           TimeMode        `json:"schedule_time_mode,omitempty"`
+	ScheduleOffsetMinutes      *int            `json:"schedule_offset_minutes,omitempty"`
 	HolidayBehavior            HolidayBehavior `json:"holiday_behavior,omitempty"`
 	SceneID                    string          `json:"scene_id"`
-	MusicMode                  string          `json:"music_mode,omitempty"`
 	MusicPolicyType            MusicPolicyType `json:"music_policy_type,omitempty"`
 	MusicSetID                 *string         `json:"music_set_id,omitempty"`
 	MusicSonosFavoriteID       *string         `json:"music_sonos_favorite_id,omitempty"`
@@ -66,6 +80,27 @@ type CreateRoutineInput struct {
 	ArcTVPolicy                *ArcTVPolicy    `json:"arc_tv_policy,omitempty"`
 	TemplateID                 *string         `json:"template_id,omitempty"`
 	SpeakersJSON               []Speaker       `json:"speakers,omitempty"`
+	MissedRunPolicy            MissedRunPolicy `json:"missed_run_policy,omitempty"`
+	MissedRunWithinMinutes     *int            `json:"missed_run_within_minutes,omitempty"`
+	DurationMinutes            *int            `json:"duration_minutes,omitempty"` // Auto-stop after this many minutes
+	MaxAttempts                *int            `json:"max_attempts,omitempty"`
+	RetryBackoffSeconds        *int            `json:"retry_backoff_seconds,omitempty"`
+
+	HolidayMusicSetID *string `json:"holiday_music_set_id,omitempty"` // Played on holidays with PLAY_ALTERNATE
+
+	MusicSets []music.WeightedSet `json:"music_sets,omitempty"` // Sets to pick from by weight; MusicSetID alone is one set
+
+	RestorePreviousState bool `json:"restore_previous_state,omitempty"` // Put back what was playing after the run
+
+	MusicPlayMode *sonos.PlayModeUpdate `json:"music_play_mode,omitempty"` // Applied after playback starts
+
+	SleepTimerMinutes *int `json:"sleep_timer_minutes,omitempty"` // Sleep timer armed after playback starts
+
+	MusicNoRepeatScope music.NoRepeatScope `json:"music_no_repeat_scope,omitempty"` // Whose plays the no-repeat window counts
+
+	Actions []RoutineAction `json:"actions,omitempty"` // Run instead of playing music; NONE policy only
+
+	WakeProfile *WakeProfile `json:"wake_profile,omitempty"` // Volume ramp after playback starts
 }
 
 // UpdateRoutineInput contains the input for updating a routine.
@@ -78,9 +113,12 @@ type UpdateRoutineInput struct {
 	ScheduleMonth              *int             `json:"schedule_month,omitempty"`
 	ScheduleDay                *int             `json:"schedule_day,omitempty"`
 	ScheduleTime               *string          `json:"schedule_time,omitempty"`
+	ScheduleIntervalDays       *int             `json:"schedule_interval_days,omitempty"`
+	ScheduleAnchorDate         *string          `json:"schedule_anchor_date,omitempty"` // YYYY-MM-DD
+	ScheduleTimeMode           *TimeMode        `json:"schedule_time_mode,omitempty"`
+	ScheduleOffsetMinutes      *int             `json:"schedule_offset_minutes,omitempty"`
 	HolidayBehavior            *HolidayBehavior `json:"holiday_behavior,omitempty"`
 	SceneID                    *string          `json:"scene_id,omitempty"`
-	MusicMode                  *string          `json:"music_mode,omitempty"`
 	MusicPolicyType            *MusicPolicyType `json:"music_policy_type,omitempty"`
 	MusicSetID                 *string          `json:"music_set_id,omitempty"`
 	MusicSonosFavoriteID       *string          `json:"music_sonos_favorite_id,omitempty"`
@@ -94,6 +132,27 @@ type UpdateRoutineInput struct {
 	SnoozeUntil                *time.Time       `json:"snooze_until,omitempty"`
 	TemplateID                 *string          `json:"template_id,omitempty"`
 	SpeakersJSON               []Speaker        `json:"speakers,omitempty"`
+	MissedRunPolicy            *MissedRunPolicy `json:"missed_run_policy,omitempty"`
+	MissedRunWithinMinutes     *int             `json:"missed_run_within_minutes,omitempty"`
+	DurationMinutes            *int             `json:"duration_minutes,omitempty"` // Auto-stop after this many minutes; 0 clears
+	MaxAttempts                *int             `json:"max_attempts,omitempty"`
+	RetryBackoffSeconds        *int             `json:"retry_backoff_seconds,omitempty"`
+
+	HolidayMusicSetID *string `json:"holiday_music_set_id,omitempty"` // Played on holidays with PLAY_ALTERNATE; empty clears
+
+	MusicSets []music.WeightedSet `json:"music_sets,omitempty"` // Replaces the sets; MusicSetID alone replaces them with one set
+
+	RestorePreviousState *bool `json:"restore_previous_state,omitempty"` // Put back what was playing after the run
+
+	MusicPlayMode *sonos.PlayModeUpdate `json:"music_play_mode,omitempty"` // Applied after playback starts; empty clears
+
+	SleepTimerMinutes *int `json:"sleep_timer_minutes,omitempty"` // Sleep timer armed after playback starts; 0 clears
+
+	MusicNoRepeatScope *music.NoRepeatScope `json:"music_no_repeat_scope,omitempty"` // Whose plays the no-repeat window counts
+
+	Actions *[]RoutineAction `json:"actions,omitempty"` // Replaces the actions; empty clears
+
+	WakeProfile *WakeProfile `json:"wake_profile,omitempty"` // Replaces the wake profile; ramp_minutes 0 clears
 }
 
 // CreateJobInput contains the input for creating a job.
@@ -101,6 +160,24 @@ type CreateJobInput struct {
 	RoutineID      string    `json:"routine_id"`
 	ScheduledFor   time.Time `json:"scheduled_for"`
 	IdempotencyKey *string   `json:"idempotency_key,omitempty"`
+	Manual         bool      `json:"manual,omitempty"` // Triggered by hand rather than by the schedule
+}
+
+// JobQueryFilters narrows ListAll. Nil fields match every job.
+type JobQueryFilters struct {
+	Status    *string
+	Statuses  []JobStatus // Any of these statuses
+	RoutineID *string
+	From      *time.Time // Inclusive lower bound on scheduled_for
+	To        *time.Time // Inclusive upper bound on scheduled_for
+
+	// StartingAfter is a keyset cursor: the ID of the last job on the previous page.
+	// Only jobs after it in list order (scheduled_for DESC, job_id DESC) are returned
+	// and Offset is ignored.
+	StartingAfter *string
+
+	Limit  int
+	Offset int
 }
 
 // CreateHolidayInput contains the input for creating a holiday.
@@ -108,6 +185,9 @@ type CreateHolidayInput struct {
 	Date     time.Time `json:"date"`
 	Name     string    `json:"name"`
 	IsCustom bool      `json:"is_custom"`
+
+	// Recurring holidays repeat every year on the same month and day
+	Recurring bool `json:"recurring"`
 }
 
 // ==========================================================================
@@ -124,12 +204,23 @@ func (r *RoutinesRepository) GetByID(routineID string) (*Routine, error) {
 			music_sonos_favorite_name, music_sonos_favorite_artwork_url,
 			music_sonos_favorite_service_logo_url, music_sonos_favorite_service_name,
 			music_content_type, music_content_json, music_no_repeat_window_minutes,
-			music_fallback_behavior, occasions_enabled, last_run_at
+			music_fallback_behavior, occasions_enabled, last_run_at,
+			missed_run_policy, missed_run_within_minutes, schedule_interval_days, schedule_anchor_date,
+			duration_minutes, schedule_time_mode, schedule_offset_minutes,
+			max_attempts, retry_backoff_seconds, holiday_music_set_id, restore_previous_state, music_play_mode_json,
+			sleep_timer_minutes, music_no_repeat_scope, actions_json, wake_profile_json
 		FROM routines
 		WHERE routine_id = ? AND deleted_at IS NULL
 	`, routineID)
 
-	return r.scanRoutineRow(row)
+	routine, err := r.scanRoutineRow(row)
+	if err != nil || routine == nil {
+		return routine, err
+	}
+	if err := r.loadMusicSets(routine); err != nil {
+		return nil, err
+	}
+	return routine, nil
 }
 
 // GetByIDIncludingDeleted retrieves a routine by ID including soft-deleted ones (for restore).
@@ -143,7 +234,11 @@ func (r *RoutinesRepository) GetByIDIncludingDeleted(routineID string) (*Routine
 			music_sonos_favorite_name, music_sonos_favorite_artwork_url,
 			music_sonos_favorite_service_logo_url, music_sonos_favorite_service_name,
 			music_content_type, music_content_json, music_no_repeat_window_minutes,
-			music_fallback_behavior, occasions_enabled, last_run_at, deleted_at
+			music_fallback_behavior, occasions_enabled, last_run_at,
+			missed_run_policy, missed_run_within_minutes, schedule_interval_days, schedule_anchor_date,
+			duration_minutes, schedule_time_mode, schedule_offset_minutes,
+			max_attempts, retry_backoff_seconds, holiday_music_set_id, restore_previous_state, music_play_mode_json,
+			sleep_timer_minutes, music_no_repeat_scope, actions_json, wake_profile_json, deleted_at
 		FROM routines
 		WHERE routine_id = ?
 	`, routineID)
@@ -165,6 +260,22 @@ func (r *RoutinesRepository) GetByIDIncludingDeleted(routineID string) (*Routine
 	var musicFallbackBehavior sql.NullString
 	var occasionsEnabled int
 	var lastRunAt sql.NullString
+	var missedRunPolicy sql.NullString
+	var missedRunWithinMinutes sql.NullInt64
+	var scheduleIntervalDays sql.NullInt64
+	var scheduleAnchorDate sql.NullString
+	var durationMinutes sql.NullInt64
+	var scheduleTimeMode sql.NullString
+	var scheduleOffsetMinutes sql.NullInt64
+	var maxAttempts sql.NullInt64
+	var retryBackoffSeconds sql.NullInt64
+	var holidayMusicSetID sql.NullString
+	var restorePreviousState int
+	var musicPlayModeJSON sql.NullString
+	var sleepTimerMinutes sql.NullInt64
+	var musicNoRepeatScope sql.NullString
+	var actionsJSON sql.NullString
+	var wakeProfileJSON sql.NullString
 
 	err := row.Scan(
 		&routine.RoutineID,
@@ -198,6 +309,22 @@ func (r *RoutinesRepository) GetByIDIncludingDeleted(routineID string) (*Routine
 		&musicFallbackBehavior,
 		&occasionsEnabled,
 		&lastRunAt,
+		&missedRunPolicy,
+		&missedRunWithinMinutes,
+		&scheduleIntervalDays,
+		&scheduleAnchorDate,
+		&durationMinutes,
+		&scheduleTimeMode,
+		&scheduleOffsetMinutes,
+		&maxAttempts,
+		&retryBackoffSeconds,
+		&holidayMusicSetID,
+		&restorePreviousState,
+		&musicPlayModeJSON,
+		&sleepTimerMinutes,
+		&musicNoRepeatScope,
+		&actionsJSON,
+		&wakeProfileJSON,
 		&deletedAt,
 	)
 	if err != nil {
@@ -207,10 +334,13 @@ func (r *RoutinesRepository) GetByIDIncludingDeleted(routineID string) (*Routine
 		return nil, false, err
 	}
 
-	result, err := r.parseRoutine(&routine, enabled, weekdaysJSON, scheduleMonth, scheduleDay, musicPolicyType, speakersJSON, skipNext, snoozeUntil, createdAt, updatedAt, musicSetID, musicSonosFavoriteID, templateID, arcTVPolicy, musicSonosFavoriteName, musicSonosFavoriteArtworkUrl, musicSonosFavoriteServiceLogoUrl, musicSonosFavoriteServiceName, musicContentType, musicContentJSON, musicNoRepeatWindowMinutes, musicFallbackBehavior, occasionsEnabled, lastRunAt)
+	result, err := r.parseRoutine(&routine, enabled, weekdaysJSON, scheduleMonth, scheduleDay, musicPolicyType, speakersJSON, skipNext, snoozeUntil, createdAt, updatedAt, musicSetID, musicSonosFavoriteID, templateID, arcTVPolicy, musicSonosFavoriteName, musicSonosFavoriteArtworkUrl, musicSonosFavoriteServiceLogoUrl, musicSonosFavoriteServiceName, musicContentType, musicContentJSON, musicNoRepeatWindowMinutes, musicFallbackBehavior, occasionsEnabled, lastRunAt, missedRunPolicy, missedRunWithinMinutes, scheduleIntervalDays, scheduleAnchorDate, durationMinutes, scheduleTimeMode, scheduleOffsetMinutes, maxAttempts, retryBackoffSeconds, holidayMusicSetID, restorePreviousState, musicPlayModeJSON, sleepTimerMinutes, musicNoRepeatScope, actionsJSON, wakeProfileJSON)
 	if err != nil {
 		return nil, false, err
 	}
+	if err := r.loadMusicSets(result); err != nil {
+		return nil, false, err
+	}
 
 	isDeleted := deletedAt.Valid && deletedAt.String != ""
 	return result, isDeleted, nil
@@ -235,6 +365,22 @@ func (r *RoutinesRepository) scanRoutineRow(row *sql.Row) (*Routine, error) {
 	var musicFallbackBehavior sql.NullString
 	var occasionsEnabled int
 	var lastRunAt sql.NullString
+	var missedRunPolicy sql.NullString
+	var missedRunWithinMinutes sql.NullInt64
+	var scheduleIntervalDays sql.NullInt64
+	var scheduleAnchorDate sql.NullString
+	var durationMinutes sql.NullInt64
+	var scheduleTimeMode sql.NullString
+	var scheduleOffsetMinutes sql.NullInt64
+	var maxAttempts sql.NullInt64
+	var retryBackoffSeconds sql.NullInt64
+	var holidayMusicSetID sql.NullString
+	var restorePreviousState int
+	var musicPlayModeJSON sql.NullString
+	var sleepTimerMinutes sql.NullInt64
+	var musicNoRepeatScope sql.NullString
+	var actionsJSON sql.NullString
+	var wakeProfileJSON sql.NullString
 
 	err := row.Scan(
 		&routine.RoutineID,
@@ -268,6 +414,22 @@ func (r *RoutinesRepository) scanRoutineRow(row *sql.Row) (*Routine, error) {
 		&musicFallbackBehavior,
 		&occasionsEnabled,
 		&lastRunAt,
+		&missedRunPolicy,
+		&missedRunWithinMinutes,
+		&scheduleIntervalDays,
+		&scheduleAnchorDate,
+		&durationMinutes,
+		&scheduleTimeMode,
+		&scheduleOffsetMinutes,
+		&maxAttempts,
+		&retryBackoffSeconds,
+		&holidayMusicSetID,
+		&restorePreviousState,
+		&musicPlayModeJSON,
+		&sleepTimerMinutes,
+		&musicNoRepeatScope,
+		&actionsJSON,
+		&wakeProfileJSON,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -276,7 +438,7 @@ func (r *RoutinesRepository) scanRoutineRow(row *sql.Row) (*Routine, error) {
 		return nil, err
 	}
 
-	return r.parseRoutine(&routine, enabled, weekdaysJSON, scheduleMonth, scheduleDay, musicPolicyType, speakersJSON, skipNext, snoozeUntil, createdAt, updatedAt, musicSetID, musicSonosFavoriteID, templateID, arcTVPolicy, musicSonosFavoriteName, musicSonosFavoriteArtworkUrl, musicSonosFavoriteServiceLogoUrl, musicSonosFavoriteServiceName, musicContentType, musicContentJSON, musicNoRepeatWindowMinutes, musicFallbackBehavior, occasionsEnabled, lastRunAt)
+	return r.parseRoutine(&routine, enabled, weekdaysJSON, scheduleMonth, scheduleDay, musicPolicyType, speakersJSON, skipNext, snoozeUntil, createdAt, updatedAt, musicSetID, musicSonosFavoriteID, templateID, arcTVPolicy, musicSonosFavoriteName, musicSonosFavoriteArtworkUrl, musicSonosFavoriteServiceLogoUrl, musicSonosFavoriteServiceName, musicContentType, musicContentJSON, musicNoRepeatWindowMinutes, musicFallbackBehavior, occasionsEnabled, lastRunAt, missedRunPolicy, missedRunWithinMinutes, scheduleIntervalDays, scheduleAnchorDate, durationMinutes, scheduleTimeMode, scheduleOffsetMinutes, maxAttempts, retryBackoffSeconds, holidayMusicSetID, restorePreviousState, musicPlayModeJSON, sleepTimerMinutes, musicNoRepeatScope, actionsJSON, wakeProfileJSON)
 }
 
 // scanRoutineRows scans a row from rows into a Routine.
@@ -298,6 +460,23 @@ func (r *RoutinesRepository) scanRoutineRows(rows *sql.Rows) (*Routine, error) {
 	var musicFallbackBehavior sql.NullString
 	var occasionsEnabled int
 	var lastRunAt sql.NullString
+	var missedRunPolicy sql.NullString
+	var missedRunWithinMinutes sql.NullInt64
+	var scheduleIntervalDays sql.NullInt64
+	var scheduleAnchorDate sql.NullString
+	var durationMinutes sql.NullInt64
+	var scheduleTimeMode sql.NullString
+	var scheduleOffsetMinutes sql.NullInt64
+	var maxAttempts sql.NullInt64
+	var retryBackoffSeconds sql.NullInt64
+	var holidayMusicSetID sql.NullString
+	var restorePreviousState int
+	var musicPlayModeJSON sql.NullString
+	var sleepTimerMinutes sql.NullInt64
+	var musicNoRepeatScope sql.NullString
+	var actionsJSON sql.NullString
+	var wakeProfileJSON sql.NullString
+	var deletedAt sql.NullString
 
 	err := rows.Scan(
 		&routine.RoutineID,
@@ -331,19 +510,68 @@ func (r *RoutinesRepository) scanRoutineRows(rows *sql.Rows) (*Routine, error) {
 		&musicFallbackBehavior,
 		&occasionsEnabled,
 		&lastRunAt,
+		&missedRunPolicy,
+		&missedRunWithinMinutes,
+		&scheduleIntervalDays,
+		&scheduleAnchorDate,
+		&durationMinutes,
+		&scheduleTimeMode,
+		&scheduleOffsetMinutes,
+		&maxAttempts,
+		&retryBackoffSeconds,
+		&holidayMusicSetID,
+		&restorePreviousState,
+		&musicPlayModeJSON,
+		&sleepTimerMinutes,
+		&musicNoRepeatScope,
+		&actionsJSON,
+		&wakeProfileJSON,
+		&deletedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	return r.parseRoutine(&routine, enabled, weekdaysJSON, scheduleMonth, scheduleDay, musicPolicyType, speakersJSON, skipNext, snoozeUntil, createdAt, updatedAt, musicSetID, musicSonosFavoriteID, templateID, arcTVPolicy, musicSonosFavoriteName, musicSonosFavoriteArtworkUrl, musicSonosFavoriteServiceLogoUrl, musicSonosFavoriteServiceName, musicContentType, musicContentJSON, musicNoRepeatWindowMinutes, musicFallbackBehavior, occasionsEnabled, lastRunAt)
+	result, err := r.parseRoutine(&routine, enabled, weekdaysJSON, scheduleMonth, scheduleDay, musicPolicyType, speakersJSON, skipNext, snoozeUntil, createdAt, updatedAt, musicSetID, musicSonosFavoriteID, templateID, arcTVPolicy, musicSonosFavoriteName, musicSonosFavoriteArtworkUrl, musicSonosFavoriteServiceLogoUrl, musicSonosFavoriteServiceName, musicContentType, musicContentJSON, musicNoRepeatWindowMinutes, musicFallbackBehavior, occasionsEnabled, lastRunAt, missedRunPolicy, missedRunWithinMinutes, scheduleIntervalDays, scheduleAnchorDate, durationMinutes, scheduleTimeMode, scheduleOffsetMinutes, maxAttempts, retryBackoffSeconds, holidayMusicSetID, restorePreviousState, musicPlayModeJSON, sleepTimerMinutes, musicNoRepeatScope, actionsJSON, wakeProfileJSON)
+	if err != nil {
+		return nil, err
+	}
+	if deletedAt.Valid && deletedAt.String != "" {
+		if t, err := time.Parse(time.RFC3339, deletedAt.String); err == nil {
+			result.DeletedAt = &t
+		}
+	}
+	return result, nil
 }
 
 // parseRoutine parses nullable fields into a Routine.
-func (r *RoutinesRepository) parseRoutine(routine *Routine, enabled int, weekdaysJSON sql.NullString, scheduleMonth, scheduleDay sql.NullInt64, musicPolicyType, speakersJSON sql.NullString, skipNext int, snoozeUntil sql.NullString, createdAt, updatedAt string, musicSetID, musicSonosFavoriteID, templateID, arcTVPolicy, musicSonosFavoriteName, musicSonosFavoriteArtworkUrl, musicSonosFavoriteServiceLogoUrl, musicSonosFavoriteServiceName, musicContentType, musicContentJSON sql.NullString, musicNoRepeatWindowMinutes sql.NullInt64, musicFallbackBehavior sql.NullString, occasionsEnabled int, lastRunAt sql.NullString) (*Routine, error) {
+func (r *RoutinesRepository) parseRoutine(routine *Routine, enabled int, weekdaysJSON sql.NullString, scheduleMonth, scheduleDay sql.NullInt64, musicPolicyType, speakersJSON sql.NullString, skipNext int, snoozeUntil sql.NullString, createdAt, updatedAt string, musicSetID, musicSonosFavoriteID, templateID, arcTVPolicy, musicSonosFavoriteName, musicSonosFavoriteArtworkUrl, musicSonosFavoriteServiceLogoUrl, musicSonosFavoriteServiceName, musicContentType, musicContentJSON sql.NullString, musicNoRepeatWindowMinutes sql.NullInt64, musicFallbackBehavior sql.NullString, occasionsEnabled int, lastRunAt sql.NullString, missedRunPolicy sql.NullString, missedRunWithinMinutes sql.NullInt64, scheduleIntervalDays sql.NullInt64, scheduleAnchorDate sql.NullString, durationMinutes sql.NullInt64, scheduleTimeMode sql.NullString, scheduleOffsetMinutes, maxAttempts, retryBackoffSeconds sql.NullInt64, holidayMusicSetID sql.NullString, restorePreviousState int, musicPlayModeJSON sql.NullString, sleepTimerMinutes sql.NullInt64, musicNoRepeatScope, actionsJSON, wakeProfileJSON sql.NullString) (*Routine, error) {
 	routine.Enabled = enabled == 1
 	routine.SkipNext = skipNext == 1
 	routine.OccasionsEnabled = occasionsEnabled == 1
+	routine.RestorePreviousState = restorePreviousState == 1
+
+	if musicPlayModeJSON.Valid && musicPlayModeJSON.String != "" {
+		var playMode sonos.PlayModeUpdate
+		if err := json.Unmarshal([]byte(musicPlayModeJSON.String), &playMode); err != nil {
+			return nil, fmt.Errorf("failed to parse music_play_mode_json: %w", err)
+		}
+		routine.MusicPlayMode = &playMode
+	}
+
+	if actionsJSON.Valid && actionsJSON.String != "" {
+		if err := json.Unmarshal([]byte(actionsJSON.String), &routine.Actions); err != nil {
+			return nil, fmt.Errorf("failed to parse actions_json: %w", err)
+		}
+	}
+
+	if wakeProfileJSON.Valid && wakeProfileJSON.String != "" {
+		var profile WakeProfile
+		if err := json.Unmarshal([]byte(wakeProfileJSON.String), &profile); err != nil {
+			return nil, fmt.Errorf("failed to parse wake_profile_json: %w", err)
+		}
+		routine.WakeProfile = &profile
+	}
 
 	if weekdaysJSON.Valid && weekdaysJSON.String != "" {
 		if err := json.Unmarshal([]byte(weekdaysJSON.String), &routine.ScheduleWeekdays); err != nil {
@@ -427,6 +655,50 @@ func (r *RoutinesRepository) parseRoutine(routine *Routine, enabled int, weekday
 		}
 		routine.LastRunAt = &t
 	}
+	routine.MissedRunPolicy = MissedRunPolicySkip
+	if missedRunPolicy.Valid && missedRunPolicy.String != "" {
+		routine.MissedRunPolicy = MissedRunPolicy(missedRunPolicy.String)
+	}
+	if missedRunWithinMinutes.Valid {
+		v := int(missedRunWithinMinutes.Int64)
+		routine.MissedRunWithinMinutes = &v
+	}
+	if scheduleIntervalDays.Valid {
+		v := int(scheduleIntervalDays.Int64)
+		routine.ScheduleIntervalDays = &v
+	}
+	if scheduleAnchorDate.Valid && scheduleAnchorDate.String != "" {
+		routine.ScheduleAnchorDate = &scheduleAnchorDate.String
+	}
+	if durationMinutes.Valid {
+		v := int(durationMinutes.Int64)
+		routine.DurationMinutes = &v
+	}
+	if sleepTimerMinutes.Valid {
+		v := int(sleepTimerMinutes.Int64)
+		routine.SleepTimerMinutes = &v
+	}
+	routine.MusicNoRepeatScope = music.NoRepeatScopeGlobal
+	if musicNoRepeatScope.Valid && musicNoRepeatScope.String != "" {
+		routine.MusicNoRepeatScope = music.NoRepeatScope(musicNoRepeatScope.String)
+	}
+	routine.ScheduleTimeMode = TimeModeFixed
+	if scheduleTimeMode.Valid && scheduleTimeMode.String != "" {
+		routine.ScheduleTimeMode = TimeMode(scheduleTimeMode.String)
+	}
+	if scheduleOffsetMinutes.Valid {
+		routine.ScheduleOffsetMinutes = int(scheduleOffsetMinutes.Int64)
+	}
+	if maxAttempts.Valid && maxAttempts.Int64 > 0 {
+		routine.MaxAttempts = int(maxAttempts.Int64)
+	}
+	routine.RetryBackoffSeconds = DefaultRetryBackoffSeconds
+	if retryBackoffSeconds.Valid && retryBackoffSeconds.Int64 > 0 {
+		routine.RetryBackoffSeconds = int(retryBackoffSeconds.Int64)
+	}
+	if holidayMusicSetID.Valid {
+		routine.HolidayMusicSetID = &holidayMusicSetID.String
+	}
 
 	var err error
 	routine.CreatedAt, err = time.Parse(time.RFC3339, createdAt)
@@ -460,11 +732,6 @@ func (r *RoutinesRepository) Create(input CreateRoutineInput) (*Routine, error)
 		holidayBehavior = HolidayBehaviorSkip
 	}
 
-	musicMode := input.MusicMode
-	if musicMode == "" {
-		musicMode = "FIXED"
-	}
-
 	musicPolicyType := input.MusicPolicyType
 	if musicPolicyType == "" {
 		musicPolicyType = MusicPolicyTypeFixed
@@ -475,6 +742,26 @@ func (r *RoutinesRepository) Create(input CreateRoutineInput) (*Routine, error)
 		scheduleType = ScheduleTypeWeekly
 	}
 
+	scheduleTimeMode := input.ScheduleTimeMode
+	if scheduleTimeMode == "" {
+		scheduleTimeMode = TimeModeFixed
+	}
+
+	scheduleOffsetMinutes := 0
+	if input.ScheduleOffsetMinutes != nil {
+		scheduleOffsetMinutes = *input.ScheduleOffsetMinutes
+	}
+
+	maxAttempts := 0
+	if input.MaxAttempts != nil {
+		maxAttempts = *input.MaxAttempts
+	}
+
+	retryBackoffSeconds := DefaultRetryBackoffSeconds
+	if input.RetryBackoffSeconds != nil {
+		retryBackoffSeconds = *input.RetryBackoffSeconds
+	}
+
 	var weekdaysJSON *string
 	if len(input.ScheduleWeekdays) > 0 {
 		bytes, err := json.Marshal(input.ScheduleWeekdays)
@@ -495,30 +782,61 @@ func (r *RoutinesRepository) Create(input CreateRoutineInput) (*Routine, error)
 		speakersJSON = &s
 	}
 
+	missedRunPolicy := input.MissedRunPolicy
+	if missedRunPolicy == "" {
+		missedRunPolicy = MissedRunPolicySkip
+	}
+
 	var arcTVPolicyStr *string
 	if input.ArcTVPolicy != nil {
 		s := string(*input.ArcTVPolicy)
 		arcTVPolicyStr = &s
 	}
 
-	_, err := r.writer.Exec(`
-		INSERT INTO routines (
-			routine_id, name, enabled, timezone, schedule_type, schedule_weekdays,
-			schedule_month, schedule_day, schedule_time, holiday_behavior, scene_id,
-			music_mode, music_policy_type, music_set_id, music_sonos_favorite_id,
-			music_content_type, music_content_json, music_no_repeat_window,
-			music_no_repeat_window_minutes, music_fallback_behavior, arc_tv_policy,
-			skip_next, snooze_until, template_id, speakers_json, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`,
-		routineID, input.Name, boolToInt(enabled), input.Timezone, string(scheduleType),
-		weekdaysJSON, input.ScheduleMonth, input.ScheduleDay, input.ScheduleTime,
-		string(holidayBehavior), input.SceneID, musicMode, string(musicPolicyType),
-		input.MusicSetID, input.MusicSonosFavoriteID, input.MusicContentType,
-		input.MusicContentJSON, input.MusicNoRepeatWindow, input.MusicNoRepeatWindowMinutes,
-		input.MusicFallbackBehavior, arcTVPolicyStr, 0, nil, input.TemplateID,
-		speakersJSON, now, now,
-	)
+	sleepTimerMinutes := input.SleepTimerMinutes
+	if sleepTimerMinutes != nil && *sleepTimerMinutes == 0 {
+		sleepTimerMinutes = nil
+	}
+
+	musicSets := routineMusicSets(input.MusicSets, input.MusicSetID)
+	musicSetID := input.MusicSetID
+	if len(musicSets) > 0 {
+		musicSetID = &musicSets[0].SetID
+	}
+
+	err := db.WithTx(r.writer, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			INSERT INTO routines (
+				routine_id, name, enabled, timezone, schedule_type, schedule_weekdays,
+				schedule_month, schedule_day, schedule_time, holiday_behavior, scene_id,
+				music_policy_type, music_set_id, music_sonos_favorite_id,
+				music_content_type, music_content_json, music_no_repeat_window,
+				music_no_repeat_window_minutes, music_fallback_behavior, arc_tv_policy,
+				skip_next, snooze_until, template_id, speakers_json, missed_run_policy,
+				missed_run_within_minutes, schedule_interval_days, schedule_anchor_date,
+				duration_minutes, schedule_time_mode, schedule_offset_minutes, max_attempts,
+				retry_backoff_seconds, holiday_music_set_id, restore_previous_state, music_play_mode_json,
+				sleep_timer_minutes, music_no_repeat_scope, actions_json, wake_profile_json, created_at, updated_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`,
+			routineID, input.Name, boolToInt(enabled), input.Timezone, string(scheduleType),
+			weekdaysJSON, input.ScheduleMonth, input.ScheduleDay, input.ScheduleTime,
+			string(holidayBehavior), input.SceneID, string(musicPolicyType),
+			musicSetID, input.MusicSonosFavoriteID, input.MusicContentType,
+			input.MusicContentJSON, input.MusicNoRepeatWindow, input.MusicNoRepeatWindowMinutes,
+			input.MusicFallbackBehavior, arcTVPolicyStr, 0, nil, input.TemplateID,
+			speakersJSON, string(missedRunPolicy), input.MissedRunWithinMinutes,
+			input.ScheduleIntervalDays, input.ScheduleAnchorDate, input.DurationMinutes,
+			string(scheduleTimeMode), scheduleOffsetMinutes, maxAttempts, retryBackoffSeconds,
+			input.HolidayMusicSetID, boolToInt(input.RestorePreviousState), playModeJSON(input.MusicPlayMode),
+			sleepTimerMinutes, string(input.MusicNoRepeatScope), routineActionsJSON(input.Actions),
+			wakeProfileJSON(input.WakeProfile), now, now,
+		)
+		if err != nil {
+			return err
+		}
+		return replaceMusicSets(tx, routineID, musicSets)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -528,50 +846,51 @@ func (r *RoutinesRepository) Create(input CreateRoutineInput) (*Routine, error)
 
 // List retrieves routines with pagination and optional filtering (excludes soft-deleted).
 func (r *RoutinesRepository) List(limit, offset int, enabledOnly bool) ([]Routine, int, error) {
-	var total int
-	var countQuery string
+	return r.list(limit, offset, enabledOnly, false)
+}
+
+// ListIncludingDeleted is List with soft-deleted routines included, their DeletedAt set.
+func (r *RoutinesRepository) ListIncludingDeleted(limit, offset int, enabledOnly bool) ([]Routine, int, error) {
+	return r.list(limit, offset, enabledOnly, true)
+}
+
+func (r *RoutinesRepository) list(limit, offset int, enabledOnly, includeDeleted bool) ([]Routine, int, error) {
+	conditions := []string{}
 	if enabledOnly {
-		countQuery = "SELECT COUNT(*) FROM routines WHERE enabled = 1 AND deleted_at IS NULL"
-	} else {
-		countQuery = "SELECT COUNT(*) FROM routines WHERE deleted_at IS NULL"
+		conditions = append(conditions, "enabled = 1")
+	}
+	if !includeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
 	}
-	err := r.reader.QueryRow(countQuery).Scan(&total)
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	err := r.reader.QueryRow("SELECT COUNT(*) FROM routines " + where).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	var query string
-	if enabledOnly {
-		query = `
-			SELECT routine_id, name, enabled, timezone, schedule_type, schedule_weekdays,
-				schedule_month, schedule_day, schedule_time, holiday_behavior, scene_id,
-				music_policy_type, speakers_json, skip_next, snooze_until, created_at, updated_at,
-				music_set_id, music_sonos_favorite_id, template_id, arc_tv_policy,
-				music_sonos_favorite_name, music_sonos_favorite_artwork_url,
-				music_sonos_favorite_service_logo_url, music_sonos_favorite_service_name,
-				music_content_type, music_content_json, music_no_repeat_window_minutes,
-				music_fallback_behavior, occasions_enabled, last_run_at
-			FROM routines
-			WHERE enabled = 1 AND deleted_at IS NULL
-			ORDER BY created_at DESC
-			LIMIT ? OFFSET ?
-		`
-	} else {
-		query = `
-			SELECT routine_id, name, enabled, timezone, schedule_type, schedule_weekdays,
-				schedule_month, schedule_day, schedule_time, holiday_behavior, scene_id,
-				music_policy_type, speakers_json, skip_next, snooze_until, created_at, updated_at,
-				music_set_id, music_sonos_favorite_id, template_id, arc_tv_policy,
-				music_sonos_favorite_name, music_sonos_favorite_artwork_url,
-				music_sonos_favorite_service_logo_url, music_sonos_favorite_service_name,
-				music_content_type, music_content_json, music_no_repeat_window_minutes,
-				music_fallback_behavior, occasions_enabled, last_run_at
-			FROM routines
-			WHERE deleted_at IS NULL
-			ORDER BY created_at DESC
-			LIMIT ? OFFSET ?
-		`
-	}
+	query := `
+		SELECT routine_id, name, enabled, timezone, schedule_type, schedule_weekdays,
+			schedule_month, schedule_day, schedule_time, holiday_behavior, scene_id,
+			music_policy_type, speakers_json, skip_next, snooze_until, created_at, updated_at,
+			music_set_id, music_sonos_favorite_id, template_id, arc_tv_policy,
+			music_sonos_favorite_name, music_sonos_favorite_artwork_url,
+			music_sonos_favorite_service_logo_url, music_sonos_favorite_service_name,
+			music_content_type, music_content_json, music_no_repeat_window_minutes,
+			music_fallback_behavior, occasions_enabled, last_run_at,
+			missed_run_policy, missed_run_within_minutes, schedule_interval_days, schedule_anchor_date,
+			duration_minutes, schedule_time_mode, schedule_offset_minutes,
+			max_attempts, retry_backoff_seconds, holiday_music_set_id, restore_previous_state, music_play_mode_json,
+			sleep_timer_minutes, music_no_repeat_scope, actions_json, wake_profile_json, deleted_at
+		FROM routines
+		` + where + `
+		ORDER BY created_at DESC
+		LIMIT ? OFFSET ?
+	`
 
 	rows, err := r.reader.Query(query, limit, offset)
 	if err != nil {
@@ -595,6 +914,13 @@ func (r *RoutinesRepository) List(limit, offset int, enabledOnly bool) ([]Routin
 	if routines == nil {
 		routines = []Routine{}
 	}
+	pointers := make([]*Routine, len(routines))
+	for i := range routines {
+		pointers[i] = &routines[i]
+	}
+	if err := r.loadMusicSets(pointers...); err != nil {
+		return nil, 0, err
+	}
 
 	return routines, total, nil
 }
@@ -609,6 +935,44 @@ func (r *RoutinesRepository) Update(routineID string, input UpdateRoutineInput)
 		return nil, nil
 	}
 
+	err = db.WithTx(r.writer, func(tx *sql.Tx) error {
+		return r.update(tx, existing, input)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(routineID)
+}
+
+// UpdateWithScene updates a routine and, in the same transaction, runs updateScene (for
+// the routine's scene), so neither change is kept if the other fails. updateScene must
+// write through tx. Returns nil if the routine does not exist.
+func (r *RoutinesRepository) UpdateWithScene(routineID string, input UpdateRoutineInput, updateScene func(tx *sql.Tx) error) (*Routine, error) {
+	existing, err := r.GetByID(routineID)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, nil
+	}
+
+	err = db.WithTx(r.writer, func(tx *sql.Tx) error {
+		if err := updateScene(tx); err != nil {
+			return err
+		}
+		return r.update(tx, existing, input)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(routineID)
+}
+
+// update writes input over existing in tx, along with its music sets, so the routine
+// row and its sets are saved or rolled back together.
+func (r *RoutinesRepository) update(tx *sql.Tx, existing *Routine, input UpdateRoutineInput) error {
+	routineID := existing.RoutineID
+
 	name := existing.Name
 	if input.Name != nil {
 		name = *input.Name
@@ -633,14 +997,14 @@ func (r *RoutinesRepository) Update(routineID string, input UpdateRoutineInput)
 	if input.ScheduleWeekdays != nil {
 		bytes, err := json.Marshal(input.ScheduleWeekdays)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		s := string(bytes)
 		scheduleWeekdays = &s
 	} else if len(existing.ScheduleWeekdays) > 0 {
 		bytes, err := json.Marshal(existing.ScheduleWeekdays)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		s := string(bytes)
 		scheduleWeekdays = &s
@@ -656,11 +1020,83 @@ func (r *RoutinesRepository) Update(routineID string, input UpdateRoutineInput)
 		scheduleDay = input.ScheduleDay
 	}
 
+	scheduleIntervalDays := existing.ScheduleIntervalDays
+	if input.ScheduleIntervalDays != nil {
+		scheduleIntervalDays = input.ScheduleIntervalDays
+	}
+
+	scheduleAnchorDate := existing.ScheduleAnchorDate
+	if input.ScheduleAnchorDate != nil {
+		scheduleAnchorDate = input.ScheduleAnchorDate
+	}
+
 	scheduleTime := existing.ScheduleTime
 	if input.ScheduleTime != nil {
 		scheduleTime = *input.ScheduleTime
 	}
 
+	scheduleTimeMode := existing.ScheduleTimeMode
+	if input.ScheduleTimeMode != nil {
+		scheduleTimeMode = *input.ScheduleTimeMode
+	}
+
+	scheduleOffsetMinutes := existing.ScheduleOffsetMinutes
+	if input.ScheduleOffsetMinutes != nil {
+		scheduleOffsetMinutes = *input.ScheduleOffsetMinutes
+	}
+
+	maxAttempts := existing.MaxAttempts
+	if input.MaxAttempts != nil {
+		maxAttempts = *input.MaxAttempts
+	}
+
+	retryBackoffSeconds := existing.RetryBackoffSeconds
+	if input.RetryBackoffSeconds != nil {
+		retryBackoffSeconds = *input.RetryBackoffSeconds
+	}
+
+	// Empty string clears the holiday music set
+	holidayMusicSetID := existing.HolidayMusicSetID
+	if input.HolidayMusicSetID != nil {
+		holidayMusicSetID = input.HolidayMusicSetID
+		if *input.HolidayMusicSetID == "" {
+			holidayMusicSetID = nil
+		}
+	}
+
+	restorePreviousState := existing.RestorePreviousState
+	if input.RestorePreviousState != nil {
+		restorePreviousState = *input.RestorePreviousState
+	}
+
+	musicPlayMode := existing.MusicPlayMode
+	if input.MusicPlayMode != nil {
+		musicPlayMode = input.MusicPlayMode
+	}
+
+	sleepTimerMinutes := existing.SleepTimerMinutes
+	if input.SleepTimerMinutes != nil {
+		sleepTimerMinutes = input.SleepTimerMinutes
+		if *sleepTimerMinutes == 0 {
+			sleepTimerMinutes = nil
+		}
+	}
+
+	musicNoRepeatScope := existing.MusicNoRepeatScope
+	if input.MusicNoRepeatScope != nil {
+		musicNoRepeatScope = *input.MusicNoRepeatScope
+	}
+
+	actions := existing.Actions
+	if input.Actions != nil {
+		actions = *input.Actions
+	}
+
+	wakeProfile := existing.WakeProfile
+	if input.WakeProfile != nil {
+		wakeProfile = input.WakeProfile
+	}
+
 	holidayBehavior := existing.HolidayBehavior
 	if input.HolidayBehavior != nil {
 		holidayBehavior = *input.HolidayBehavior
@@ -697,6 +1133,9 @@ func (r *RoutinesRepository) Update(routineID string, input UpdateRoutineInput)
 	if input.MusicSetID != nil {
 		musicSetID = input.MusicSetID
 	}
+	if len(input.MusicSets) > 0 {
+		musicSetID = &input.MusicSets[0].SetID
+	}
 
 	musicSonosFavoriteID := existing.MusicSonosFavoriteID
 	if input.MusicSonosFavoriteID != nil {
@@ -713,6 +1152,17 @@ func (r *RoutinesRepository) Update(routineID string, input UpdateRoutineInput)
 		musicContentJSON = input.MusicContentJSON
 	}
 
+	// The favorite and direct content belong to FIXED, so a routine leaving FIXED drops
+	// them rather than keeping them around to resurface if it's switched back
+	favoriteName := existing.MusicSonosFavoriteName
+	favoriteArtworkURL := existing.MusicSonosFavoriteArtworkUrl
+	favoriteServiceLogoURL := existing.MusicSonosFavoriteServiceLogoUrl
+	favoriteServiceName := existing.MusicSonosFavoriteServiceName
+	if musicPolicyType != MusicPolicyTypeFixed {
+		musicSonosFavoriteID, musicContentType, musicContentJSON = nil, nil, nil
+		favoriteName, favoriteArtworkURL, favoriteServiceLogoURL, favoriteServiceName = nil, nil, nil, nil
+	}
+
 	musicNoRepeatWindowMinutes := existing.MusicNoRepeatWindowMinutes
 	if input.MusicNoRepeatWindowMinutes != nil {
 		musicNoRepeatWindowMinutes = input.MusicNoRepeatWindowMinutes
@@ -734,49 +1184,84 @@ func (r *RoutinesRepository) Update(routineID string, input UpdateRoutineInput)
 		templateID = input.TemplateID
 	}
 
+	missedRunPolicy := existing.MissedRunPolicy
+	if input.MissedRunPolicy != nil {
+		missedRunPolicy = *input.MissedRunPolicy
+	}
+
+	missedRunWithinMinutes := existing.MissedRunWithinMinutes
+	if input.MissedRunWithinMinutes != nil {
+		missedRunWithinMinutes = input.MissedRunWithinMinutes
+	}
+
+	// A duration of zero turns auto-stop off
+	durationMinutes := existing.DurationMinutes
+	if input.DurationMinutes != nil {
+		durationMinutes = input.DurationMinutes
+		if *durationMinutes == 0 {
+			durationMinutes = nil
+		}
+	}
+
 	// Handle speakers JSON update
 	var speakersJSONStr *string
 	if input.SpeakersJSON != nil {
 		bytes, err := json.Marshal(input.SpeakersJSON)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		s := string(bytes)
 		speakersJSONStr = &s
 	} else if existing.SpeakersJSON != nil {
 		bytes, err := json.Marshal(existing.SpeakersJSON)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		s := string(bytes)
 		speakersJSONStr = &s
 	}
 
 	now := nowISO()
-	_, err = r.writer.Exec(`
+	_, err := tx.Exec(`
 		UPDATE routines SET
 			name = ?, enabled = ?, timezone = ?, schedule_type = ?, schedule_weekdays = ?,
 			schedule_month = ?, schedule_day = ?, schedule_time = ?, holiday_behavior = ?,
+			schedule_interval_days = ?, schedule_anchor_date = ?,
+			schedule_time_mode = ?, schedule_offset_minutes = ?,
+			max_attempts = ?, retry_backoff_seconds = ?, holiday_music_set_id = ?,
 			scene_id = ?, skip_next = ?, snooze_until = ?,
 			music_policy_type = ?, music_set_id = ?, music_sonos_favorite_id = ?,
+			music_sonos_favorite_name = ?, music_sonos_favorite_artwork_url = ?,
+			music_sonos_favorite_service_logo_url = ?, music_sonos_favorite_service_name = ?,
 			music_content_type = ?, music_content_json = ?, music_no_repeat_window_minutes = ?,
 			music_fallback_behavior = ?, arc_tv_policy = ?, template_id = ?, speakers_json = ?,
-			updated_at = ?
+			missed_run_policy = ?, missed_run_within_minutes = ?, duration_minutes = ?,
+			restore_previous_state = ?, music_play_mode_json = ?, sleep_timer_minutes = ?,
+			music_no_repeat_scope = ?, actions_json = ?, wake_profile_json = ?, updated_at = ?
 		WHERE routine_id = ?
 	`,
 		name, boolToInt(enabled), timezone, string(scheduleType), scheduleWeekdays,
-		scheduleMonth, scheduleDay, scheduleTime, string(holidayBehavior), sceneID,
+		scheduleMonth, scheduleDay, scheduleTime, string(holidayBehavior),
+		scheduleIntervalDays, scheduleAnchorDate,
+		string(scheduleTimeMode), scheduleOffsetMinutes,
+		maxAttempts, retryBackoffSeconds, holidayMusicSetID, sceneID,
 		boolToInt(skipNext), snoozeUntilStr,
 		string(musicPolicyType), musicSetID, musicSonosFavoriteID,
+		favoriteName, favoriteArtworkURL, favoriteServiceLogoURL, favoriteServiceName,
 		musicContentType, musicContentJSON, musicNoRepeatWindowMinutes,
 		musicFallbackBehavior, arcTVPolicy, templateID, speakersJSONStr,
-		now, routineID,
+		string(missedRunPolicy), missedRunWithinMinutes, durationMinutes,
+		boolToInt(restorePreviousState), playModeJSON(musicPlayMode), sleepTimerMinutes,
+		string(musicNoRepeatScope), routineActionsJSON(actions), wakeProfileJSON(wakeProfile), now, routineID,
 	)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return r.GetByID(routineID)
+	if len(input.MusicSets) > 0 || input.MusicSetID != nil {
+		return replaceMusicSets(tx, routineID, routineMusicSets(input.MusicSets, input.MusicSetID))
+	}
+	return nil
 }
 
 // ClearSnooze removes the snooze from a routine.
@@ -919,7 +1404,11 @@ func (r *RoutinesRepository) GetDueRoutines(now time.Time) ([]Routine, error) {
 			music_sonos_favorite_name, music_sonos_favorite_artwork_url,
 			music_sonos_favorite_service_logo_url, music_sonos_favorite_service_name,
 			music_content_type, music_content_json, music_no_repeat_window_minutes,
-			music_fallback_behavior, occasions_enabled, last_run_at
+			music_fallback_behavior, occasions_enabled, last_run_at,
+			missed_run_policy, missed_run_within_minutes, schedule_interval_days, schedule_anchor_date,
+			duration_minutes, schedule_time_mode, schedule_offset_minutes,
+			max_attempts, retry_backoff_seconds, holiday_music_set_id, restore_previous_state, music_play_mode_json,
+			sleep_timer_minutes, music_no_repeat_scope, actions_json, wake_profile_json, deleted_at
 		FROM routines
 		WHERE enabled = 1 AND skip_next = 0 AND deleted_at IS NULL
 		  AND (snooze_until IS NULL OR snooze_until <= ?)
@@ -957,7 +1446,8 @@ func (r *RoutinesRepository) GetDueRoutines(now time.Time) ([]Routine, error) {
 func (r *JobsRepository) GetByID(jobID string) (*Job, error) {
 	row := r.reader.QueryRow(`
 		SELECT job_id, routine_id, scheduled_for, status, attempts, last_error,
-			scene_execution_id, retry_after, claimed_at, idempotency_key, created_at, updated_at
+			scene_execution_id, retry_after, claimed_at, idempotency_key, missed_run_decision, execution_detail,
+			manual, failure_reason, created_at, updated_at
 		FROM jobs
 		WHERE job_id = ?
 	`, jobID)
@@ -968,7 +1458,8 @@ func (r *JobsRepository) GetByID(jobID string) (*Job, error) {
 // scanJobRow scans a single row into a Job.
 func (r *JobsRepository) scanJobRow(row *sql.Row) (*Job, error) {
 	var job Job
-	var lastError, sceneExecutionID, retryAfter, claimedAt, idempotencyKey sql.NullString
+	var lastError, sceneExecutionID, retryAfter, claimedAt, idempotencyKey, missedRunDecision, executionDetail sql.NullString
+	var failureReason sql.NullString
 	var scheduledFor, createdAt, updatedAt string
 	var status string
 
@@ -983,6 +1474,10 @@ func (r *JobsRepository) scanJobRow(row *sql.Row) (*Job, error) {
 		&retryAfter,
 		&claimedAt,
 		&idempotencyKey,
+		&missedRunDecision,
+		&executionDetail,
+		&job.Manual,
+		&failureReason,
 		&createdAt,
 		&updatedAt,
 	)
@@ -993,11 +1488,14 @@ func (r *JobsRepository) scanJobRow(row *sql.Row) (*Job, error) {
 		return nil, err
 	}
 
-	return r.parseJob(&job, status, scheduledFor, lastError, sceneExecutionID, retryAfter, claimedAt, idempotencyKey, createdAt, updatedAt)
+	if failureReason.Valid {
+		job.FailureReason = &failureReason.String
+	}
+	return r.parseJob(&job, status, scheduledFor, lastError, sceneExecutionID, retryAfter, claimedAt, idempotencyKey, missedRunDecision, executionDetail, createdAt, updatedAt)
 }
 
 // parseJob parses nullable fields into a Job.
-func (r *JobsRepository) parseJob(job *Job, status, scheduledFor string, lastError, sceneExecutionID, retryAfter, claimedAt, idempotencyKey sql.NullString, createdAt, updatedAt string) (*Job, error) {
+func (r *JobsRepository) parseJob(job *Job, status, scheduledFor string, lastError, sceneExecutionID, retryAfter, claimedAt, idempotencyKey, missedRunDecision, executionDetail sql.NullString, createdAt, updatedAt string) (*Job, error) {
 	job.Status = JobStatus(status)
 
 	var err error
@@ -1029,6 +1527,17 @@ func (r *JobsRepository) parseJob(job *Job, status, scheduledFor string, lastErr
 	if idempotencyKey.Valid {
 		job.IdempotencyKey = &idempotencyKey.String
 	}
+	if missedRunDecision.Valid {
+		decision := MissedRunDecision(missedRunDecision.String)
+		job.MissedRunDecision = &decision
+	}
+	if executionDetail.Valid && executionDetail.String != "" {
+		// The detail is display-only, so an unreadable blob is dropped rather than failing the read
+		var detail ExecutionDetail
+		if err := json.Unmarshal([]byte(executionDetail.String), &detail); err == nil {
+			job.ExecutionDetail = &detail
+		}
+	}
 
 	job.CreatedAt, err = time.Parse(time.RFC3339, createdAt)
 	if err != nil {
@@ -1067,9 +1576,9 @@ func (r *JobsRepository) CreateWithInput(input CreateJobInput) (*Job, error) {
 	}
 
 	_, err := r.writer.Exec(`
-		INSERT INTO jobs (job_id, routine_id, scheduled_for, status, attempts, idempotency_key, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`, jobID, input.RoutineID, scheduledForStr, string(JobStatusPending), 0, idempotencyKey, now, now)
+		INSERT INTO jobs (job_id, routine_id, scheduled_for, status, attempts, idempotency_key, manual, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, jobID, input.RoutineID, scheduledForStr, string(JobStatusPending), 0, idempotencyKey, boolToInt(input.Manual), now, now)
 	if err != nil {
 		return nil, err
 	}
@@ -1087,7 +1596,8 @@ func (r *JobsRepository) ListByRoutineID(routineID string, limit, offset int) ([
 
 	rows, err := r.reader.Query(`
 		SELECT job_id, routine_id, scheduled_for, status, attempts, last_error,
-			scene_execution_id, retry_after, claimed_at, idempotency_key, created_at, updated_at
+			scene_execution_id, retry_after, claimed_at, idempotency_key, missed_run_decision, execution_detail,
+			manual, failure_reason, created_at, updated_at
 		FROM jobs
 		WHERE routine_id = ?
 		ORDER BY scheduled_for DESC
@@ -1119,15 +1629,17 @@ func (r *JobsRepository) ListByRoutineID(routineID string, limit, offset int) ([
 }
 
 // GetPendingJobs retrieves scheduled jobs ordered by scheduled_for.
+// Jobs waiting out a retry backoff (retry_after in the future) are skipped.
 func (r *JobsRepository) GetPendingJobs(limit int) ([]Job, error) {
 	rows, err := r.reader.Query(`
 		SELECT job_id, routine_id, scheduled_for, status, attempts, last_error,
-			scene_execution_id, retry_after, claimed_at, idempotency_key, created_at, updated_at
+			scene_execution_id, retry_after, claimed_at, idempotency_key, missed_run_decision, execution_detail,
+			manual, failure_reason, created_at, updated_at
 		FROM jobs
-		WHERE status = ?
+		WHERE status = ? AND (retry_after IS NULL OR retry_after <= ?)
 		ORDER BY scheduled_for ASC
 		LIMIT ?
-	`, string(JobStatusPending), limit)
+	`, string(JobStatusPending), time.Now().UTC().Format(time.RFC3339), limit)
 	if err != nil {
 		return nil, err
 	}
@@ -1187,21 +1699,46 @@ func (r *JobsRepository) StartJob(jobID string) error {
 
 // CompleteJob sets status=COMPLETED.
 func (r *JobsRepository) CompleteJob(jobID string, sceneExecutionID string) error {
+	return r.CompleteJobWithDetail(jobID, sceneExecutionID, nil)
+}
+
+// CompleteJobWithDetail sets status=COMPLETED and stores what the job played and where.
+// A nil detail leaves execution_detail empty.
+func (r *JobsRepository) CompleteJobWithDetail(jobID string, sceneExecutionID string, detail *ExecutionDetail) error {
 	now := nowISO()
 	var execID *string
 	if sceneExecutionID != "" {
 		execID = &sceneExecutionID
 	}
+	var detailJSON *string
+	if detail != nil {
+		data, err := json.Marshal(detail)
+		if err != nil {
+			return err
+		}
+		s := string(data)
+		detailJSON = &s
+	}
 	_, err := r.writer.Exec(`
-		UPDATE jobs SET status = ?, scene_execution_id = ?, updated_at = ?
+		UPDATE jobs SET status = ?, scene_execution_id = ?, execution_detail = ?, updated_at = ?
 		WHERE job_id = ?
-	`, string(JobStatusCompleted), execID, now, jobID)
+	`, string(JobStatusCompleted), execID, detailJSON, now, jobID)
 	return err
 }
 
 // FailJob increments attempts, sets last_error, and conditionally sets status=FAILED.
 func (r *JobsRepository) FailJob(jobID string, errMsg string, canRetry bool) error {
+	return r.FailJobWithReason(jobID, errMsg, "", canRetry)
+}
+
+// FailJobWithReason is FailJob recording why the job failed, e.g. FailureReasonDevicesOffline.
+// An empty reason clears any reason left by an earlier attempt.
+func (r *JobsRepository) FailJobWithReason(jobID string, errMsg string, reason string, canRetry bool) error {
 	now := nowISO()
+	var failureReason *string
+	if reason != "" {
+		failureReason = &reason
+	}
 
 	if canRetry {
 		// Increment attempts, set error, but keep status as PENDING for retry
@@ -1209,11 +1746,12 @@ func (r *JobsRepository) FailJob(jobID string, errMsg string, canRetry bool) err
 			UPDATE jobs SET
 				attempts = attempts + 1,
 				last_error = ?,
+				failure_reason = ?,
 				status = ?,
 				claimed_at = NULL,
 				updated_at = ?
 			WHERE job_id = ?
-		`, errMsg, string(JobStatusPending), now, jobID)
+		`, errMsg, failureReason, string(JobStatusPending), now, jobID)
 		return err
 	}
 
@@ -1222,29 +1760,223 @@ func (r *JobsRepository) FailJob(jobID string, errMsg string, canRetry bool) err
 		UPDATE jobs SET
 			attempts = attempts + 1,
 			last_error = ?,
+			failure_reason = ?,
 			status = ?,
 			updated_at = ?
 		WHERE job_id = ?
-	`, errMsg, string(JobStatusFailed), now, jobID)
+	`, errMsg, failureReason, string(JobStatusFailed), now, jobID)
 	return err
 }
 
 // SkipJob sets status=SKIPPED.
 func (r *JobsRepository) SkipJob(jobID string, reason string) error {
+	return r.SkipJobWithDetail(jobID, reason, nil)
+}
+
+// SkipJobWithDetail sets status=SKIPPED and stores the execution detail explaining the skip.
+// A nil detail leaves execution_detail unchanged.
+func (r *JobsRepository) SkipJobWithDetail(jobID string, reason string, detail *ExecutionDetail) error {
 	now := nowISO()
+	var detailJSON *string
+	if detail != nil {
+		data, err := json.Marshal(detail)
+		if err != nil {
+			return err
+		}
+		s := string(data)
+		detailJSON = &s
+	}
+	_, err := r.writer.Exec(`
+		UPDATE jobs SET status = ?, last_error = ?, execution_detail = COALESCE(?, execution_detail), updated_at = ?
+		WHERE job_id = ?
+	`, string(JobStatusSkipped), reason, detailJSON, now, jobID)
+	return err
+}
+
+// SetPlaybackSnapshot stores what a job's speakers were playing before it ran, so it
+// can be put back later. A nil snapshot clears it.
+func (r *JobsRepository) SetPlaybackSnapshot(jobID string, snapshots []sonos.PlaybackSnapshot) error {
+	var snapshotJSON *string
+	if snapshots != nil {
+		data, err := json.Marshal(snapshots)
+		if err != nil {
+			return err
+		}
+		s := string(data)
+		snapshotJSON = &s
+	}
 	_, err := r.writer.Exec(`
-		UPDATE jobs SET status = ?, last_error = ?, updated_at = ?
+		UPDATE jobs SET playback_snapshot = ?, updated_at = ?
 		WHERE job_id = ?
-	`, string(JobStatusSkipped), reason, now, jobID)
+	`, snapshotJSON, nowISO(), jobID)
 	return err
 }
 
+// GetPendingPlaybackSnapshot returns the playback snapshot from the routine's most
+// recent job that has one. Returns an empty job ID when there is nothing to restore.
+func (r *JobsRepository) GetPendingPlaybackSnapshot(routineID string) (string, []sonos.PlaybackSnapshot, error) {
+	var jobID, snapshotJSON string
+	err := r.reader.QueryRow(`
+		SELECT job_id, playback_snapshot FROM jobs
+		WHERE routine_id = ? AND playback_snapshot IS NOT NULL
+		ORDER BY scheduled_for DESC
+		LIMIT 1
+	`, routineID).Scan(&jobID, &snapshotJSON)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	var snapshots []sonos.PlaybackSnapshot
+	if err := json.Unmarshal([]byte(snapshotJSON), &snapshots); err != nil {
+		return "", nil, err
+	}
+	return jobID, snapshots, nil
+}
+
+// ClearPlaybackSnapshots drops every playback snapshot held for the routine's jobs.
+func (r *JobsRepository) ClearPlaybackSnapshots(routineID string) error {
+	_, err := r.writer.Exec(`
+		UPDATE jobs SET playback_snapshot = NULL, updated_at = ?
+		WHERE routine_id = ? AND playback_snapshot IS NOT NULL
+	`, nowISO(), routineID)
+	return err
+}
+
+// SetWakeRamp saves a running wake ramp's progress on its job.
+func (r *JobsRepository) SetWakeRamp(jobID string, ramp *WakeRamp) error {
+	data, err := json.Marshal(ramp)
+	if err != nil {
+		return err
+	}
+	_, err = r.writer.Exec(`
+		UPDATE jobs SET wake_ramp = ?, updated_at = ?
+		WHERE job_id = ?
+	`, string(data), nowISO(), jobID)
+	return err
+}
+
+// ListWakeRamps returns the wake ramps that are still running, by job ID.
+func (r *JobsRepository) ListWakeRamps() (map[string]*WakeRamp, error) {
+	rows, err := r.reader.Query(`SELECT job_id, wake_ramp FROM jobs WHERE wake_ramp IS NOT NULL`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ramps := make(map[string]*WakeRamp)
+	for rows.Next() {
+		var jobID, rampJSON string
+		if err := rows.Scan(&jobID, &rampJSON); err != nil {
+			return nil, err
+		}
+		var ramp WakeRamp
+		if err := json.Unmarshal([]byte(rampJSON), &ramp); err != nil {
+			return nil, fmt.Errorf("job %s wake_ramp: %w", jobID, err)
+		}
+		ramps[jobID] = &ramp
+	}
+	return ramps, rows.Err()
+}
+
+// FinishWakeRamp clears a job's running wake ramp and records how it ended in the job's
+// execution detail.
+func (r *JobsRepository) FinishWakeRamp(jobID string, ramp *WakeRamp) error {
+	var detailJSON sql.NullString
+	err := r.writer.QueryRow(`SELECT execution_detail FROM jobs WHERE job_id = ?`, jobID).Scan(&detailJSON)
+	if err != nil {
+		return err
+	}
+	detail := &ExecutionDetail{Devices: []ExecutionDevice{}}
+	if detailJSON.Valid && detailJSON.String != "" {
+		if err := json.Unmarshal([]byte(detailJSON.String), detail); err != nil {
+			return fmt.Errorf("job %s execution_detail: %w", jobID, err)
+		}
+	}
+	detail.Wake = ramp
+	data, err := json.Marshal(detail)
+	if err != nil {
+		return err
+	}
+	_, err = r.writer.Exec(`
+		UPDATE jobs SET wake_ramp = NULL, execution_detail = ?, updated_at = ?
+		WHERE job_id = ?
+	`, string(data), nowISO(), jobID)
+	return err
+}
+
+// SetMissedRunDecision records the catch-up decision for a job missed during downtime.
+func (r *JobsRepository) SetMissedRunDecision(jobID string, decision MissedRunDecision) error {
+	now := nowISO()
+	_, err := r.writer.Exec(`
+		UPDATE jobs SET missed_run_decision = ?, updated_at = ?
+		WHERE job_id = ?
+	`, string(decision), now, jobID)
+	return err
+}
+
+// AppendJobLog appends step log entries to a job. Only the newest MaxJobLogEntries are
+// kept and long errors are truncated, so retries cannot grow the log without bound.
+// The log lives on the job row and is removed with it.
+func (r *JobsRepository) AppendJobLog(jobID string, entries []JobLogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	existing, err := r.GetJobLog(jobID)
+	if err != nil {
+		return err
+	}
+
+	combined := append(existing, entries...)
+	for i := range combined {
+		if len(combined[i].Error) > MaxJobLogErrorLength {
+			combined[i].Error = combined[i].Error[:MaxJobLogErrorLength] + "..."
+		}
+	}
+	if len(combined) > MaxJobLogEntries {
+		combined = combined[len(combined)-MaxJobLogEntries:]
+	}
+
+	data, err := json.Marshal(combined)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.writer.Exec(`
+		UPDATE jobs SET step_log = ?, updated_at = ?
+		WHERE job_id = ?
+	`, string(data), nowISO(), jobID)
+	return err
+}
+
+// GetJobLog returns a job's step log, oldest entry first. Returns an empty log for
+// jobs that have not run yet.
+func (r *JobsRepository) GetJobLog(jobID string) ([]JobLogEntry, error) {
+	var stepLog sql.NullString
+	err := r.reader.QueryRow("SELECT step_log FROM jobs WHERE job_id = ?", jobID).Scan(&stepLog)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	entries := []JobLogEntry{}
+	if stepLog.Valid && stepLog.String != "" {
+		if err := json.Unmarshal([]byte(stepLog.String), &entries); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
 // GetStaleClaimedJobs returns jobs that were claimed but not completed within the timeout.
 func (r *JobsRepository) GetStaleClaimedJobs(olderThan time.Duration) ([]Job, error) {
 	cutoff := time.Now().UTC().Add(-olderThan).Format(time.RFC3339)
 	rows, err := r.reader.Query(`
 		SELECT job_id, routine_id, scheduled_for, status, attempts, last_error,
-			scene_execution_id, retry_after, claimed_at, idempotency_key, created_at, updated_at
+			scene_execution_id, retry_after, claimed_at, idempotency_key, missed_run_decision, execution_detail,
+			manual, failure_reason, created_at, updated_at
 		FROM jobs
 		WHERE status = ? AND claimed_at < ?
 	`, string(JobStatusClaimed), cutoff)
@@ -1273,9 +2005,38 @@ func (r *JobsRepository) GetStaleClaimedJobs(olderThan time.Duration) ([]Job, er
 	return jobs, nil
 }
 
+// PruneOlderThan deletes completed, failed and skipped jobs scheduled before cutoff and
+// returns the count deleted. Rows are deleted batchSize at a time so no single statement
+// holds the write lock for long.
+func (r *JobsRepository) PruneOlderThan(cutoff time.Time, batchSize int) (int64, error) {
+	cutoffStr := cutoff.UTC().Format(time.RFC3339)
+	var total int64
+	for {
+		result, err := r.writer.Exec(`
+			DELETE FROM jobs WHERE job_id IN (
+				SELECT job_id FROM jobs
+				WHERE status IN (?, ?, ?) AND scheduled_for < ?
+				LIMIT ?
+			)
+		`, string(JobStatusCompleted), string(JobStatusFailed), string(JobStatusSkipped), cutoffStr, batchSize)
+		if err != nil {
+			return total, err
+		}
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += deleted
+		if deleted < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
+
 func (r *JobsRepository) scanJobRows(rows *sql.Rows) (*Job, error) {
 	var job Job
-	var lastError, sceneExecutionID, retryAfter, claimedAt, idempotencyKey sql.NullString
+	var lastError, sceneExecutionID, retryAfter, claimedAt, idempotencyKey, missedRunDecision, executionDetail sql.NullString
+	var failureReason sql.NullString
 	var scheduledFor, createdAt, updatedAt string
 	var status string
 
@@ -1290,6 +2051,10 @@ func (r *JobsRepository) scanJobRows(rows *sql.Rows) (*Job, error) {
 		&retryAfter,
 		&claimedAt,
 		&idempotencyKey,
+		&missedRunDecision,
+		&executionDetail,
+		&job.Manual,
+		&failureReason,
 		&createdAt,
 		&updatedAt,
 	)
@@ -1297,7 +2062,10 @@ func (r *JobsRepository) scanJobRows(rows *sql.Rows) (*Job, error) {
 		return nil, err
 	}
 
-	return r.parseJob(&job, status, scheduledFor, lastError, sceneExecutionID, retryAfter, claimedAt, idempotencyKey, createdAt, updatedAt)
+	if failureReason.Valid {
+		job.FailureReason = &failureReason.String
+	}
+	return r.parseJob(&job, status, scheduledFor, lastError, sceneExecutionID, retryAfter, claimedAt, idempotencyKey, missedRunDecision, executionDetail, createdAt, updatedAt)
 }
 
 // ==========================================================================
@@ -1309,9 +2077,9 @@ func (r *HolidaysRepository) Create(input CreateHolidayInput) (*Holiday, error)
 	dateStr := input.Date.Format("2006-01-02")
 
 	_, err := r.writer.Exec(`
-		INSERT INTO holidays (date, name, is_custom)
-		VALUES (?, ?, ?)
-	`, dateStr, input.Name, boolToInt(input.IsCustom))
+		INSERT INTO holidays (date, name, is_custom, recurring)
+		VALUES (?, ?, ?, ?)
+	`, dateStr, input.Name, boolToInt(input.IsCustom), boolToInt(input.Recurring))
 	if err != nil {
 		return nil, err
 	}
@@ -1319,16 +2087,46 @@ func (r *HolidaysRepository) Create(input CreateHolidayInput) (*Holiday, error)
 	return r.GetByID(dateStr)
 }
 
+// Import creates holidays from a calendar, skipping any date that is already a holiday
+// (including by a recurring holiday), so importing the same year twice is a no-op.
+// Imported holidays are not custom.
+func (r *HolidaysRepository) Import(holidays []CalendarHoliday) ([]Holiday, int, error) {
+	created := []Holiday{}
+	skipped := 0
+
+	for _, calendarHoliday := range holidays {
+		exists, _, err := r.IsHolidayWithDetails(calendarHoliday.Date)
+		if err != nil {
+			return nil, 0, err
+		}
+		if exists {
+			skipped++
+			continue
+		}
+
+		holiday, err := r.Create(CreateHolidayInput{
+			Date: calendarHoliday.Date,
+			Name: calendarHoliday.Name,
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		created = append(created, *holiday)
+	}
+
+	return created, skipped, nil
+}
+
 // GetByID retrieves a holiday by date string.
 func (r *HolidaysRepository) GetByID(holidayID string) (*Holiday, error) {
 	var holiday Holiday
-	var isCustom int
+	var isCustom, recurring int
 
 	err := r.reader.QueryRow(`
-		SELECT date, name, is_custom
+		SELECT date, name, is_custom, recurring
 		FROM holidays
 		WHERE date = ?
-	`, holidayID).Scan(&holiday.Date, &holiday.Name, &isCustom)
+	`, holidayID).Scan(&holiday.Date, &holiday.Name, &isCustom, &recurring)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -1337,6 +2135,7 @@ func (r *HolidaysRepository) GetByID(holidayID string) (*Holiday, error) {
 	}
 
 	holiday.IsCustom = isCustom == 1
+	holiday.Recurring = recurring == 1
 
 	return &holiday, nil
 }
@@ -1349,8 +2148,8 @@ func (r *HolidaysRepository) List(limit, offset int) ([]Holiday, int, error) {
 		return nil, 0, err
 	}
 
-	rows, err := r.reader.Query(`
-		SELECT date, name, is_custom
+	holidays, err := r.queryHolidays(`
+		SELECT date, name, is_custom, recurring
 		FROM holidays
 		ORDER BY date ASC
 		LIMIT ? OFFSET ?
@@ -1358,29 +2157,6 @@ func (r *HolidaysRepository) List(limit, offset int) ([]Holiday, int, error) {
 	if err != nil {
 		return nil, 0, err
 	}
-	defer rows.Close()
-
-	var holidays []Holiday
-	for rows.Next() {
-		var holiday Holiday
-		var isCustom int
-
-		if err := rows.Scan(&holiday.Date, &holiday.Name, &isCustom); err != nil {
-			return nil, 0, err
-		}
-
-		holiday.IsCustom = isCustom == 1
-
-		holidays = append(holidays, holiday)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, 0, err
-	}
-
-	if holidays == nil {
-		holidays = []Holiday{}
-	}
 
 	return holidays, total, nil
 }
@@ -1409,6 +2185,8 @@ func (r *HolidaysRepository) IsHoliday(date time.Time) (bool, *Holiday, error) {
 }
 
 // IsHolidayWithDetails checks if a date is a holiday and returns the holiday details.
+// Recurring holidays match on month and day in any year; a match from another year is
+// returned as that year's occurrence, with HolidayID set to the stored date.
 func (r *HolidaysRepository) IsHolidayWithDetails(date time.Time) (bool, *Holiday, error) {
 	dateStr := date.Format("2006-01-02")
 
@@ -1421,35 +2199,96 @@ func (r *HolidaysRepository) IsHolidayWithDetails(date time.Time) (bool, *Holida
 		return true, holiday, nil
 	}
 
+	// A Feb 29 holiday falls on Feb 28 outside leap years, so both are candidates then
+	monthDays := []any{date.Format("01-02")}
+	if date.Month() == time.February && date.Day() == 28 && !isLeapYear(date.Year()) {
+		monthDays = append(monthDays, "02-29")
+	}
+
+	recurring, err := r.queryHolidays(`
+		SELECT date, name, is_custom, recurring
+		FROM holidays
+		WHERE recurring = 1 AND substr(date, 6) IN (?`+strings.Repeat(", ?", len(monthDays)-1)+`)
+		ORDER BY substr(date, 6) ASC, date ASC
+		LIMIT 1
+	`, monthDays...)
+	if err != nil {
+		return false, nil, err
+	}
+
+	if len(recurring) > 0 {
+		occurrence := recurring[0]
+		occurrence.HolidayID = occurrence.Date
+		occurrence.Date = dateStr
+		return true, &occurrence, nil
+	}
+
 	return false, nil, nil
 }
 
-// GetHolidaysInRange retrieves holidays within a date range.
+// GetHolidaysInRange retrieves holidays within a date range, including every occurrence of
+// recurring holidays that falls inside it. Occurrences carry the stored date as HolidayID.
 func (r *HolidaysRepository) GetHolidaysInRange(start, end time.Time) ([]Holiday, error) {
 	startStr := start.Format("2006-01-02")
 	endStr := end.Format("2006-01-02")
 
-	rows, err := r.reader.Query(`
-		SELECT date, name, is_custom
+	holidays, err := r.queryHolidays(`
+		SELECT date, name, is_custom, recurring
 		FROM holidays
-		WHERE date >= ? AND date <= ?
-		ORDER BY date ASC
+		WHERE recurring = 0 AND date >= ? AND date <= ?
 	`, startStr, endStr)
 	if err != nil {
 		return nil, err
 	}
+
+	recurring, err := r.queryHolidays(`
+		SELECT date, name, is_custom, recurring
+		FROM holidays
+		WHERE recurring = 1
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, holiday := range recurring {
+		for year := start.Year(); year <= end.Year(); year++ {
+			occurrence, ok := recurringHolidayDate(holiday.Date, year)
+			if !ok || occurrence < startStr || occurrence > endStr {
+				continue
+			}
+			expanded := holiday
+			expanded.HolidayID = holiday.Date
+			expanded.Date = occurrence
+			holidays = append(holidays, expanded)
+		}
+	}
+
+	sort.SliceStable(holidays, func(i, j int) bool {
+		return holidays[i].Date < holidays[j].Date
+	})
+
+	return holidays, nil
+}
+
+// queryHolidays runs a holidays SELECT of date, name, is_custom, recurring.
+func (r *HolidaysRepository) queryHolidays(query string, args ...any) ([]Holiday, error) {
+	rows, err := r.reader.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
-	var holidays []Holiday
+	holidays := []Holiday{}
 	for rows.Next() {
 		var holiday Holiday
-		var isCustom int
+		var isCustom, recurring int
 
-		if err := rows.Scan(&holiday.Date, &holiday.Name, &isCustom); err != nil {
+		if err := rows.Scan(&holiday.Date, &holiday.Name, &isCustom, &recurring); err != nil {
 			return nil, err
 		}
 
 		holiday.IsCustom = isCustom == 1
+		holiday.Recurring = recurring == 1
 
 		holidays = append(holidays, holiday)
 	}
@@ -1458,11 +2297,27 @@ func (r *HolidaysRepository) GetHolidaysInRange(start, end time.Time) ([]Holiday
 		return nil, err
 	}
 
-	if holidays == nil {
-		holidays = []Holiday{}
+	return holidays, nil
+}
+
+// recurringHolidayDate returns the YYYY-MM-DD date a recurring holiday stored as stored
+// falls on in year. Feb 29 holidays fall on Feb 28 outside leap years.
+func recurringHolidayDate(stored string, year int) (string, bool) {
+	date, err := time.Parse("2006-01-02", stored)
+	if err != nil {
+		return "", false
 	}
 
-	return holidays, nil
+	day := date.Day()
+	if date.Month() == time.February && day == 29 && !isLeapYear(year) {
+		day = 28
+	}
+
+	return time.Date(year, date.Month(), day, 0, 0, 0, 0, time.UTC).Format("2006-01-02"), true
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
 }
 
 // UpdateLastRunAt updates the last_run_at timestamp for a routine.
@@ -1487,70 +2342,92 @@ func (r *JobsRepository) SetRetryAfter(jobID string, retryAfter time.Time) error
 	return err
 }
 
-// ListAll retrieves all jobs with pagination and optional status filtering.
-func (r *JobsRepository) ListAll(limit, offset int, statusFilter string) ([]Job, int, error) {
-	var total int
-	var countQuery string
-	var args []any
-
-	if statusFilter != "" {
-		countQuery = "SELECT COUNT(*) FROM jobs WHERE status = ?"
-		args = append(args, statusFilter)
-	} else {
-		countQuery = "SELECT COUNT(*) FROM jobs"
-	}
+// ListAll retrieves jobs newest first, filtered and paginated. It fetches one row past
+// the limit to report whether more jobs exist, so no COUNT over the table is needed.
+func (r *JobsRepository) ListAll(filters JobQueryFilters) ([]Job, bool, error) {
+	whereClause, args := r.buildWhereClause(filters)
 
-	err := r.reader.QueryRow(countQuery, args...).Scan(&total)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	var query string
-	if statusFilter != "" {
-		query = `
-			SELECT job_id, routine_id, scheduled_for, status, attempts, last_error,
-				scene_execution_id, retry_after, claimed_at, idempotency_key, created_at, updated_at
-			FROM jobs
-			WHERE status = ?
-			ORDER BY scheduled_for DESC
-			LIMIT ? OFFSET ?
-		`
-		args = []any{statusFilter, limit, offset}
-	} else {
-		query = `
-			SELECT job_id, routine_id, scheduled_for, status, attempts, last_error,
-				scene_execution_id, retry_after, claimed_at, idempotency_key, created_at, updated_at
-			FROM jobs
-			ORDER BY scheduled_for DESC
-			LIMIT ? OFFSET ?
-		`
-		args = []any{limit, offset}
+	query := `
+		SELECT job_id, routine_id, scheduled_for, status, attempts, last_error,
+			scene_execution_id, retry_after, claimed_at, idempotency_key, missed_run_decision, execution_detail,
+			manual, failure_reason, created_at, updated_at
+		FROM jobs
+		` + whereClause + `
+		ORDER BY scheduled_for DESC, job_id DESC
+		LIMIT ?`
+	args = append(args, filters.Limit+1)
+	if filters.StartingAfter == nil && filters.Offset > 0 {
+		query += " OFFSET ?"
+		args = append(args, filters.Offset)
 	}
 
 	rows, err := r.reader.Query(query, args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, false, err
 	}
 	defer rows.Close()
 
-	var jobs []Job
+	jobs := []Job{}
 	for rows.Next() {
 		job, err := r.scanJobRows(rows)
 		if err != nil {
-			return nil, 0, err
+			return nil, false, err
 		}
 		jobs = append(jobs, *job)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, 0, err
+		return nil, false, err
 	}
 
-	if jobs == nil {
-		jobs = []Job{}
+	hasMore := len(jobs) > filters.Limit
+	if hasMore {
+		jobs = jobs[:filters.Limit]
 	}
 
-	return jobs, total, nil
+	return jobs, hasMore, nil
+}
+
+// buildWhereClause builds a dynamic WHERE clause based on provided filters.
+func (r *JobsRepository) buildWhereClause(filters JobQueryFilters) (string, []any) {
+	conditions := []string{}
+	args := []any{}
+
+	if filters.Status != nil {
+		conditions = append(conditions, "status = ?")
+		args = append(args, *filters.Status)
+	}
+	if len(filters.Statuses) > 0 {
+		placeholders := make([]string, len(filters.Statuses))
+		for i, status := range filters.Statuses {
+			placeholders[i] = "?"
+			args = append(args, string(status))
+		}
+		conditions = append(conditions, "status IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if filters.RoutineID != nil {
+		conditions = append(conditions, "routine_id = ?")
+		args = append(args, *filters.RoutineID)
+	}
+	if filters.From != nil {
+		conditions = append(conditions, "scheduled_for >= ?")
+		args = append(args, filters.From.UTC().Format(time.RFC3339))
+	}
+	if filters.To != nil {
+		conditions = append(conditions, "scheduled_for <= ?")
+		args = append(args, filters.To.UTC().Format(time.RFC3339))
+	}
+	if filters.StartingAfter != nil {
+		conditions = append(conditions, "(scheduled_for, job_id) < (SELECT scheduled_for, job_id FROM jobs WHERE job_id = ?)")
+		args = append(args, *filters.StartingAfter)
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	return whereClause, args
 }
 
 // GetStaleRunningJobs returns jobs that are in RUNNING state but haven't completed within the timeout.
@@ -1558,7 +2435,8 @@ func (r *JobsRepository) GetStaleRunningJobs(olderThan time.Duration) ([]Job, er
 	cutoff := time.Now().UTC().Add(-olderThan).Format(time.RFC3339)
 	rows, err := r.reader.Query(`
 		SELECT job_id, routine_id, scheduled_for, status, attempts, last_error,
-			scene_execution_id, retry_after, claimed_at, idempotency_key, created_at, updated_at
+			scene_execution_id, retry_after, claimed_at, idempotency_key, missed_run_decision, execution_detail,
+			manual, failure_reason, created_at, updated_at
 		FROM jobs
 		WHERE status = ? AND claimed_at < ?
 	`, string(JobStatusRunning), cutoff)
@@ -1595,6 +2473,48 @@ func nowISO() string {
 	return time.Now().UTC().Format(time.RFC3339)
 }
 
+// playModeJSON encodes a routine's play mode for music_play_mode_json; an empty play
+// mode is stored as NULL.
+func playModeJSON(playMode *sonos.PlayModeUpdate) *string {
+	if playMode == nil || playMode.IsEmpty() {
+		return nil
+	}
+	data, err := json.Marshal(playMode)
+	if err != nil {
+		return nil
+	}
+	encoded := string(data)
+	return &encoded
+}
+
+// routineActionsJSON encodes a routine's actions for actions_json; no actions are
+// stored as NULL.
+func routineActionsJSON(actions []RoutineAction) *string {
+	if len(actions) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(actions)
+	if err != nil {
+		return nil
+	}
+	encoded := string(data)
+	return &encoded
+}
+
+// wakeProfileJSON encodes a routine's wake profile for wake_profile_json; a profile
+// without ramp minutes is stored as NULL.
+func wakeProfileJSON(profile *WakeProfile) *string {
+	if profile == nil || profile.RampMinutes == 0 {
+		return nil
+	}
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return nil
+	}
+	encoded := string(data)
+	return &encoded
+}
+
 func boolToInt(b bool) int {
 	if b {
 		return 1
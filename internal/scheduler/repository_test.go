@@ -1,7 +1,12 @@
 package scheduler
 
 import (
+	"database/sql"
+	"errors"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -9,6 +14,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/strefethen/sonos-hub-go/internal/db"
+	"github.com/strefethen/sonos-hub-go/internal/music"
 	"github.com/strefethen/sonos-hub-go/internal/scene"
 )
 
@@ -201,6 +207,57 @@ func TestRoutinesRepository_Update(t *testing.T) {
 	require.Equal(t, "UTC", updated.Timezone) // Preserved
 }
 
+func TestRoutinesRepository_UpdateWithScene(t *testing.T) {
+	routinesRepo, _, _, scenesRepo := setupTestDB(t)
+
+	s, err := scenesRepo.Create(scene.CreateSceneInput{
+		Name:    "Test Scene",
+		Members: []scene.SceneMember{{UDN: "uuid:RINCON_A"}},
+	})
+	require.NoError(t, err)
+	routine, err := routinesRepo.Create(CreateRoutineInput{
+		Name:         "Test Routine",
+		Timezone:     "UTC",
+		ScheduleTime: "08:00",
+		SceneID:      s.SceneID,
+	})
+	require.NoError(t, err)
+
+	newName := "Renamed Routine"
+	members := []scene.SceneMember{{UDN: "uuid:RINCON_B"}}
+	updated, err := routinesRepo.UpdateWithScene(routine.RoutineID, UpdateRoutineInput{Name: &newName}, func(tx *sql.Tx) error {
+		found, err := scenesRepo.UpdateTx(tx, s.SceneID, scene.UpdateSceneInput{Members: members})
+		require.True(t, found)
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, newName, updated.Name)
+	fetchedScene, err := scenesRepo.GetByID(s.SceneID)
+	require.NoError(t, err)
+	require.Equal(t, "uuid:RINCON_B", fetchedScene.Members[0].UDN)
+
+	// A failure rolls back both the scene and the routine
+	otherName := "Other Name"
+	_, err = routinesRepo.UpdateWithScene(routine.RoutineID, UpdateRoutineInput{Name: &otherName}, func(tx *sql.Tx) error {
+		if _, err := scenesRepo.UpdateTx(tx, s.SceneID, scene.UpdateSceneInput{Members: []scene.SceneMember{}}); err != nil {
+			return err
+		}
+		return errors.New("scene update rejected")
+	})
+	require.EqualError(t, err, "scene update rejected")
+
+	fetchedScene, err = scenesRepo.GetByID(s.SceneID)
+	require.NoError(t, err)
+	require.Len(t, fetchedScene.Members, 1)
+	fetched, err := routinesRepo.GetByID(routine.RoutineID)
+	require.NoError(t, err)
+	require.Equal(t, newName, fetched.Name)
+
+	// The writer connection was released
+	_, err = routinesRepo.Update(routine.RoutineID, UpdateRoutineInput{Name: &otherName})
+	require.NoError(t, err)
+}
+
 func TestRoutinesRepository_Update_NotFound(t *testing.T) {
 	routinesRepo, _, _, _ := setupTestDB(t)
 
@@ -325,6 +382,123 @@ func TestRoutinesRepository_WithSpeakers(t *testing.T) {
 	require.Nil(t, routine.SpeakersJSON[1].Volume)
 }
 
+func TestRoutinesRepository_HolidayMusicSet(t *testing.T) {
+	routinesRepo, _, _, scenesRepo := setupTestDB(t)
+
+	s, err := scenesRepo.Create(scene.CreateSceneInput{
+		Name:    "Test Scene",
+		Members: []scene.SceneMember{},
+	})
+	require.NoError(t, err)
+
+	setID := "set-christmas"
+	routine, err := routinesRepo.Create(CreateRoutineInput{
+		Name:              "Morning",
+		Timezone:          "UTC",
+		ScheduleTime:      "08:00",
+		SceneID:           s.SceneID,
+		HolidayBehavior:   HolidayBehaviorPlayAlternate,
+		HolidayMusicSetID: &setID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, HolidayBehaviorPlayAlternate, routine.HolidayBehavior)
+	require.Equal(t, &setID, routine.HolidayMusicSetID)
+
+	// Unrelated updates keep the set; an empty string clears it
+	newName := "Morning Music"
+	updated, err := routinesRepo.Update(routine.RoutineID, UpdateRoutineInput{Name: &newName})
+	require.NoError(t, err)
+	require.Equal(t, &setID, updated.HolidayMusicSetID)
+
+	cleared := ""
+	updated, err = routinesRepo.Update(routine.RoutineID, UpdateRoutineInput{HolidayMusicSetID: &cleared})
+	require.NoError(t, err)
+	require.Nil(t, updated.HolidayMusicSetID)
+}
+
+func TestRoutinesRepository_MusicSets(t *testing.T) {
+	routinesRepo, _, _, scenesRepo := setupTestDB(t)
+
+	s, err := scenesRepo.Create(scene.CreateSceneInput{
+		Name:    "Test Scene",
+		Members: []scene.SceneMember{},
+	})
+	require.NoError(t, err)
+
+	sets := []music.WeightedSet{{SetID: "set-jazz", Weight: 70}, {SetID: "set-classical", Weight: 30}}
+	routine, err := routinesRepo.Create(CreateRoutineInput{
+		Name:            "Morning",
+		Timezone:        "UTC",
+		ScheduleTime:    "08:00",
+		SceneID:         s.SceneID,
+		MusicPolicyType: MusicPolicyTypeShuffle,
+		MusicSets:       sets,
+	})
+	require.NoError(t, err)
+	require.Equal(t, sets, routine.MusicSets)
+	require.Equal(t, "set-jazz", *routine.MusicSetID, "music_set_id keeps the first set")
+
+	// Unrelated updates keep the sets
+	newName := "Morning Music"
+	updated, err := routinesRepo.Update(routine.RoutineID, UpdateRoutineInput{Name: &newName})
+	require.NoError(t, err)
+	require.Equal(t, sets, updated.MusicSets)
+
+	routines, _, err := routinesRepo.List(10, 0, false)
+	require.NoError(t, err)
+	require.Len(t, routines, 1)
+	require.Equal(t, sets, routines[0].MusicSets)
+
+	// set_id is shorthand for a single set
+	setID := "set-ambient"
+	updated, err = routinesRepo.Update(routine.RoutineID, UpdateRoutineInput{MusicSetID: &setID})
+	require.NoError(t, err)
+	require.Equal(t, []music.WeightedSet{{SetID: "set-ambient", Weight: 1}}, updated.MusicSets)
+	require.Equal(t, updated.MusicSets, updated.WeightedMusicSets())
+
+	// Sets that can't be saved roll back the rest of the update
+	renamed := "Evening"
+	_, err = routinesRepo.Update(routine.RoutineID, UpdateRoutineInput{
+		Name:      &renamed,
+		MusicSets: []music.WeightedSet{{SetID: "set-jazz", Weight: 1}, {SetID: "set-jazz", Weight: 2}},
+	})
+	require.Error(t, err)
+	unchanged, err := routinesRepo.GetByID(routine.RoutineID)
+	require.NoError(t, err)
+	require.Equal(t, "Morning Music", unchanged.Name)
+	require.Equal(t, updated.MusicSets, unchanged.MusicSets)
+}
+
+func TestRoutinesRepository_NoRepeatScope(t *testing.T) {
+	routinesRepo, _, _, scenesRepo := setupTestDB(t)
+
+	s, err := scenesRepo.Create(scene.CreateSceneInput{
+		Name:    "Test Scene",
+		Members: []scene.SceneMember{},
+	})
+	require.NoError(t, err)
+
+	routine, err := routinesRepo.Create(CreateRoutineInput{
+		Name:         "Morning",
+		Timezone:     "UTC",
+		ScheduleTime: "07:00",
+		SceneID:      s.SceneID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, music.NoRepeatScopeGlobal, routine.MusicNoRepeatScope, "global by default")
+
+	scope := music.NoRepeatScopeRoutine
+	updated, err := routinesRepo.Update(routine.RoutineID, UpdateRoutineInput{MusicNoRepeatScope: &scope})
+	require.NoError(t, err)
+	require.Equal(t, music.NoRepeatScopeRoutine, updated.MusicNoRepeatScope)
+
+	// Unrelated updates keep it
+	newName := "Morning Music"
+	updated, err = routinesRepo.Update(routine.RoutineID, UpdateRoutineInput{Name: &newName})
+	require.NoError(t, err)
+	require.Equal(t, music.NoRepeatScopeRoutine, updated.MusicNoRepeatScope)
+}
+
 // ==========================================================================
 // JobsRepository Tests
 // ==========================================================================
@@ -474,6 +648,43 @@ func TestJobsRepository_GetPendingJobs(t *testing.T) {
 	require.True(t, jobs[0].ScheduledFor.Before(jobs[1].ScheduledFor))
 }
 
+func TestJobsRepository_GetPendingJobs_SkipsRetryBackoff(t *testing.T) {
+	routinesRepo, jobsRepo, _, scenesRepo := setupTestDB(t)
+
+	s, err := scenesRepo.Create(scene.CreateSceneInput{
+		Name:    "Test Scene",
+		Members: []scene.SceneMember{},
+	})
+	require.NoError(t, err)
+
+	routine, err := routinesRepo.Create(CreateRoutineInput{
+		Name:         "Test Routine",
+		Timezone:     "UTC",
+		ScheduleTime: "08:00",
+		SceneID:      s.SceneID,
+	})
+	require.NoError(t, err)
+
+	waiting, err := jobsRepo.Create(CreateJobInput{
+		RoutineID:    routine.RoutineID,
+		ScheduledFor: time.Now().Add(-1 * time.Minute).UTC(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, jobsRepo.SetRetryAfter(waiting.JobID, time.Now().Add(time.Minute)))
+
+	ready, err := jobsRepo.Create(CreateJobInput{
+		RoutineID:    routine.RoutineID,
+		ScheduledFor: time.Now().Add(-2 * time.Minute).UTC(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, jobsRepo.SetRetryAfter(ready.JobID, time.Now().Add(-time.Second)))
+
+	jobs, err := jobsRepo.GetPendingJobs(10)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	require.Equal(t, ready.JobID, jobs[0].JobID)
+}
+
 func TestJobsRepository_ClaimJob(t *testing.T) {
 	routinesRepo, jobsRepo, _, scenesRepo := setupTestDB(t)
 
@@ -539,6 +750,86 @@ func TestJobsRepository_ClaimJob_AlreadyClaimed(t *testing.T) {
 	require.Contains(t, err.Error(), "already claimed")
 }
 
+func TestJobsRepository_ClaimJob_Concurrent(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	// Two pools on one file, like the server and sonos-hub-admin running side by side
+	serverDB, err := db.Init(dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { serverDB.Close() })
+	adminDB, err := db.Init(dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { adminDB.Close() })
+
+	s, err := scene.NewScenesRepository(serverDB).Create(scene.CreateSceneInput{
+		Name:    "Test Scene",
+		Members: []scene.SceneMember{},
+	})
+	require.NoError(t, err)
+	routine, err := NewRoutinesRepository(serverDB).Create(CreateRoutineInput{
+		Name:         "Test Routine",
+		Timezone:     "UTC",
+		ScheduleTime: "08:00",
+		SceneID:      s.SceneID,
+	})
+	require.NoError(t, err)
+
+	const jobCount = 50
+	const workers = 8
+	jobRepos := []*JobsRepository{NewJobsRepository(serverDB), NewJobsRepository(adminDB)}
+	base := time.Now().Add(time.Hour).UTC().Truncate(time.Minute)
+	jobIDs := make([]string, jobCount)
+	for i := range jobIDs {
+		job, err := jobRepos[0].Create(CreateJobInput{
+			RoutineID:    routine.RoutineID,
+			ScheduledFor: base.Add(time.Duration(i) * time.Minute),
+		})
+		require.NoError(t, err)
+		jobIDs[i] = job.JobID
+	}
+
+	var claims [jobCount]int32
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*jobCount+workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			repo := jobRepos[w%len(jobRepos)]
+			for n := range jobIDs {
+				i := (n + w) % jobCount // Start each worker at a different job
+				err := repo.ClaimJob(jobIDs[i])
+				if err == nil {
+					atomic.AddInt32(&claims[i], 1)
+				} else if err.Error() != "job not found or already claimed" {
+					errs <- err
+				}
+			}
+		}(w)
+
+		// Routine updates compete for the same write lock
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			routines := NewRoutinesRepository([]*db.DBPair{serverDB, adminDB}[w%2])
+			name := "Renamed Routine"
+			if _, err := routines.Update(routine.RoutineID, UpdateRoutineInput{Name: &name}); err != nil {
+				errs <- err
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+
+	// A "database is locked" error would surface here
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	for i, count := range claims {
+		require.Equal(t, int32(1), count, "job %d claimed %d times", i, count)
+	}
+}
+
 func TestJobsRepository_StartJob(t *testing.T) {
 	routinesRepo, jobsRepo, _, scenesRepo := setupTestDB(t)
 
@@ -610,6 +901,122 @@ func TestJobsRepository_CompleteJob(t *testing.T) {
 	require.Nil(t, fetched.SceneExecutionID) // No scene execution ID when empty string passed
 }
 
+func TestJobsRepository_CompleteJobWithDetail(t *testing.T) {
+	routinesRepo, jobsRepo, _, scenesRepo := setupTestDB(t)
+
+	s, err := scenesRepo.Create(scene.CreateSceneInput{
+		Name:    "Test Scene",
+		Members: []scene.SceneMember{},
+	})
+	require.NoError(t, err)
+
+	routine, err := routinesRepo.Create(CreateRoutineInput{
+		Name:         "Test Routine",
+		Timezone:     "UTC",
+		ScheduleTime: "08:00",
+		SceneID:      s.SceneID,
+	})
+	require.NoError(t, err)
+
+	job, err := jobsRepo.Create(CreateJobInput{
+		RoutineID:    routine.RoutineID,
+		ScheduledFor: time.Now().Add(time.Hour).UTC(),
+	})
+	require.NoError(t, err)
+	require.Nil(t, job.ExecutionDetail)
+
+	volume := 20
+	detail := &ExecutionDetail{
+		Devices: []ExecutionDevice{{UDN: "udn-kitchen", RoomName: "Kitchen", Volume: &volume}},
+		Content: &ExecutionContent{Type: "sonos_favorite", Title: "KEXP", URI: "x-sonosapi-stream:kexp"},
+	}
+	require.NoError(t, jobsRepo.CompleteJobWithDetail(job.JobID, "", detail))
+
+	fetched, err := jobsRepo.GetByID(job.JobID)
+	require.NoError(t, err)
+	require.Equal(t, JobStatusCompleted, fetched.Status)
+	require.Equal(t, detail, fetched.ExecutionDetail)
+
+	// An unreadable blob is dropped instead of failing the read
+	_, err = jobsRepo.writer.Exec("UPDATE jobs SET execution_detail = ? WHERE job_id = ?", "{not json", job.JobID)
+	require.NoError(t, err)
+	jobs, _, err := jobsRepo.ListAll(JobQueryFilters{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	require.Nil(t, jobs[0].ExecutionDetail)
+}
+
+func TestJobsRepository_ListAll_FiltersAndCursor(t *testing.T) {
+	routinesRepo, jobsRepo, _, scenesRepo := setupTestDB(t)
+
+	s, err := scenesRepo.Create(scene.CreateSceneInput{
+		Name:    "Test Scene",
+		Members: []scene.SceneMember{},
+	})
+	require.NoError(t, err)
+
+	morning, err := routinesRepo.Create(CreateRoutineInput{Name: "Morning", Timezone: "UTC", ScheduleTime: "08:00", SceneID: s.SceneID})
+	require.NoError(t, err)
+	evening, err := routinesRepo.Create(CreateRoutineInput{Name: "Evening", Timezone: "UTC", ScheduleTime: "20:00", SceneID: s.SceneID})
+	require.NoError(t, err)
+
+	// Two jobs per day for June 1-3; the two on each day share a timestamp
+	base := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	for day := 0; day < 3; day++ {
+		for _, routine := range []*Routine{morning, evening} {
+			_, err := jobsRepo.Create(CreateJobInput{RoutineID: routine.RoutineID, ScheduledFor: base.AddDate(0, 0, day)})
+			require.NoError(t, err)
+		}
+	}
+
+	routineID := morning.RoutineID
+	jobs, hasMore, err := jobsRepo.ListAll(JobQueryFilters{RoutineID: &routineID, Limit: 10})
+	require.NoError(t, err)
+	require.False(t, hasMore)
+	require.Len(t, jobs, 3)
+	require.Equal(t, 3, jobs[0].ScheduledFor.Day(), "newest first")
+
+	from := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 6, 2, 23, 59, 59, 0, time.UTC)
+	jobs, _, err = jobsRepo.ListAll(JobQueryFilters{From: &from, To: &to, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	for _, job := range jobs {
+		require.Equal(t, 2, job.ScheduledFor.Day())
+	}
+
+	require.NoError(t, jobsRepo.CompleteJob(jobs[0].JobID, ""))
+	require.NoError(t, jobsRepo.SkipJob(jobs[1].JobID, "snoozed"))
+	jobs, _, err = jobsRepo.ListAll(JobQueryFilters{Statuses: []JobStatus{JobStatusCompleted, JobStatusSkipped}, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+
+	// Walking the cursor visits every job once, including ties on scheduled_for
+	seen := map[string]bool{}
+	filters := JobQueryFilters{Limit: 4}
+	for page := 0; ; page++ {
+		require.Less(t, page, 5)
+		jobs, hasMore, err = jobsRepo.ListAll(filters)
+		require.NoError(t, err)
+		for _, job := range jobs {
+			require.False(t, seen[job.JobID], "job returned twice")
+			seen[job.JobID] = true
+		}
+		if !hasMore {
+			break
+		}
+		cursor := jobs[len(jobs)-1].JobID
+		filters.StartingAfter = &cursor
+	}
+	require.Len(t, seen, 6)
+
+	// Offset still pages without a cursor
+	jobs, hasMore, err = jobsRepo.ListAll(JobQueryFilters{Limit: 4, Offset: 4})
+	require.NoError(t, err)
+	require.False(t, hasMore)
+	require.Len(t, jobs, 2)
+}
+
 func TestJobsRepository_FailJob_WithRetry(t *testing.T) {
 	routinesRepo, jobsRepo, _, scenesRepo := setupTestDB(t)
 
@@ -722,6 +1129,54 @@ func TestJobsRepository_SkipJob(t *testing.T) {
 	require.Equal(t, "holiday", *fetched.LastError)
 }
 
+func TestJobsRepository_JobLog(t *testing.T) {
+	routinesRepo, jobsRepo, _, scenesRepo := setupTestDB(t)
+
+	s, err := scenesRepo.Create(scene.CreateSceneInput{
+		Name:    "Test Scene",
+		Members: []scene.SceneMember{},
+	})
+	require.NoError(t, err)
+
+	routine, err := routinesRepo.Create(CreateRoutineInput{
+		Name:         "Test Routine",
+		Timezone:     "UTC",
+		ScheduleTime: "08:00",
+		SceneID:      s.SceneID,
+	})
+	require.NoError(t, err)
+
+	job, err := jobsRepo.Create(CreateJobInput{
+		RoutineID:    routine.RoutineID,
+		ScheduledFor: time.Now().Add(time.Hour).UTC(),
+	})
+	require.NoError(t, err)
+
+	// A job that has not run has an empty log
+	entries, err := jobsRepo.GetJobLog(job.JobID)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	// Entries accumulate, long errors are truncated, and only the newest entries are kept
+	longError := strings.Repeat("e", MaxJobLogErrorLength+100)
+	batch := make([]JobLogEntry, 0, MaxJobLogEntries)
+	for i := 0; i < MaxJobLogEntries; i++ {
+		batch = append(batch, JobLogEntry{Attempt: 1, Step: "claim", Status: JobLogStatusOK, StartedAt: time.Now().UTC()})
+	}
+	require.NoError(t, jobsRepo.AppendJobLog(job.JobID, batch))
+	require.NoError(t, jobsRepo.AppendJobLog(job.JobID, []JobLogEntry{
+		{Attempt: 2, Step: "execute_routine", Status: JobLogStatusFailed, StartedAt: time.Now().UTC(), Error: longError},
+	}))
+
+	entries, err = jobsRepo.GetJobLog(job.JobID)
+	require.NoError(t, err)
+	require.Len(t, entries, MaxJobLogEntries)
+	last := entries[len(entries)-1]
+	require.Equal(t, 2, last.Attempt)
+	require.Equal(t, JobLogStatusFailed, last.Status)
+	require.Len(t, last.Error, MaxJobLogErrorLength+len("..."))
+}
+
 func TestJobsRepository_GetStaleClaimedJobs(t *testing.T) {
 	routinesRepo, jobsRepo, _, scenesRepo := setupTestDB(t)
 
@@ -762,6 +1217,71 @@ func TestJobsRepository_GetStaleClaimedJobs(t *testing.T) {
 	require.Len(t, staleJobs, 0)
 }
 
+func TestJobsRepository_PruneOlderThan(t *testing.T) {
+	routinesRepo, jobsRepo, _, scenesRepo := setupTestDB(t)
+
+	s, err := scenesRepo.Create(scene.CreateSceneInput{
+		Name:    "Test Scene",
+		Members: []scene.SceneMember{},
+	})
+	require.NoError(t, err)
+
+	routine, err := routinesRepo.Create(CreateRoutineInput{
+		Name:         "Test Routine",
+		Timezone:     "UTC",
+		ScheduleTime: "08:00",
+		SceneID:      s.SceneID,
+	})
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	createJob := func(scheduledFor time.Time) string {
+		job, err := jobsRepo.Create(CreateJobInput{RoutineID: routine.RoutineID, ScheduledFor: scheduledFor})
+		require.NoError(t, err)
+		return job.JobID
+	}
+
+	// Five old finished jobs, so pruning takes more than one batch of two
+	var oldJobIDs []string
+	for i := 0; i < 5; i++ {
+		jobID := createJob(now.AddDate(0, 0, -100-i))
+		oldJobIDs = append(oldJobIDs, jobID)
+		switch i % 3 {
+		case 0:
+			require.NoError(t, jobsRepo.CompleteJob(jobID, ""))
+		case 1:
+			require.NoError(t, jobsRepo.FailJob(jobID, "boom", false))
+		case 2:
+			require.NoError(t, jobsRepo.SkipJob(jobID, "holiday"))
+		}
+	}
+
+	// Kept: an old job that never finished, and a recent finished job
+	oldPendingID := createJob(now.AddDate(0, 0, -200))
+	recentID := createJob(now.AddDate(0, 0, -1))
+	require.NoError(t, jobsRepo.CompleteJob(recentID, ""))
+
+	deleted, err := jobsRepo.PruneOlderThan(now.AddDate(0, 0, -90), 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), deleted)
+
+	for _, jobID := range oldJobIDs {
+		job, err := jobsRepo.GetByID(jobID)
+		require.NoError(t, err)
+		require.Nil(t, job)
+	}
+	for _, jobID := range []string{oldPendingID, recentID} {
+		job, err := jobsRepo.GetByID(jobID)
+		require.NoError(t, err)
+		require.NotNil(t, job)
+	}
+
+	// Nothing left to prune
+	deleted, err = jobsRepo.PruneOlderThan(now.AddDate(0, 0, -90), 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), deleted)
+}
+
 // ==========================================================================
 // HolidaysRepository Tests
 // ==========================================================================
@@ -887,6 +1407,118 @@ func TestHolidaysRepository_IsHoliday(t *testing.T) {
 	require.Nil(t, holiday)
 }
 
+func TestHolidaysRepository_IsHoliday_Recurring(t *testing.T) {
+	_, _, holidaysRepo, _ := setupTestDB(t)
+
+	for _, input := range []CreateHolidayInput{
+		{Date: time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC), Name: "Christmas", Recurring: true},
+		{Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Name: "New Year", Recurring: true},
+		{Date: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), Name: "Leap Day", Recurring: true},
+		{Date: time.Date(2024, 7, 4, 0, 0, 0, 0, time.UTC), Name: "Picnic"},
+	} {
+		holiday, err := holidaysRepo.Create(input)
+		require.NoError(t, err)
+		require.Equal(t, input.Recurring, holiday.Recurring)
+	}
+
+	tests := []struct {
+		name   string
+		date   time.Time
+		want   string // Holiday name, empty for none
+		wantID string
+	}{
+		{"stored date", time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC), "Christmas", ""},
+		{"following year", time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC), "Christmas", "2024-12-25"},
+		{"earlier year", time.Date(2023, 12, 25, 0, 0, 0, 0, time.UTC), "Christmas", "2024-12-25"},
+		{"new year across the year boundary", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), "New Year", "2024-01-01"},
+		{"new year's eve", time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC), "", ""},
+		{"leap day in a leap year", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC), "Leap Day", "2024-02-29"},
+		{"leap day falls on feb 28 otherwise", time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC), "Leap Day", "2024-02-29"},
+		{"feb 28 in a leap year", time.Date(2028, 2, 28, 0, 0, 0, 0, time.UTC), "", ""},
+		{"non-recurring in another year", time.Date(2025, 7, 4, 0, 0, 0, 0, time.UTC), "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isHoliday, holiday, err := holidaysRepo.IsHolidayWithDetails(tt.date)
+			require.NoError(t, err)
+			if tt.want == "" {
+				require.False(t, isHoliday)
+				require.Nil(t, holiday)
+				return
+			}
+			require.True(t, isHoliday)
+			require.Equal(t, tt.want, holiday.Name)
+			require.Equal(t, tt.date.Format("2006-01-02"), holiday.Date)
+			require.Equal(t, tt.wantID, holiday.HolidayID)
+		})
+	}
+}
+
+func TestHolidaysRepository_GetHolidaysInRange_Recurring(t *testing.T) {
+	_, _, holidaysRepo, _ := setupTestDB(t)
+
+	for _, input := range []CreateHolidayInput{
+		{Date: time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC), Name: "Christmas", Recurring: true},
+		{Date: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), Name: "Leap Day", Recurring: true},
+		{Date: time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC), Name: "Office Closed"},
+	} {
+		_, err := holidaysRepo.Create(input)
+		require.NoError(t, err)
+	}
+
+	// Across the year boundary
+	holidays, err := holidaysRepo.GetHolidaysInRange(
+		time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2027, 1, 31, 0, 0, 0, 0, time.UTC),
+	)
+	require.NoError(t, err)
+	var dates []string
+	for _, holiday := range holidays {
+		dates = append(dates, holiday.Date)
+	}
+	require.Equal(t, []string{"2025-12-25", "2025-12-31", "2026-02-28", "2026-12-25"}, dates)
+	require.Equal(t, "2024-12-25", holidays[0].HolidayID)
+	require.Empty(t, holidays[1].HolidayID)
+
+	// The stored year is expanded like any other
+	holidays, err = holidaysRepo.GetHolidaysInRange(
+		time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	)
+	require.NoError(t, err)
+	require.Len(t, holidays, 1)
+	require.Equal(t, "2024-02-29", holidays[0].Date)
+}
+
+func TestHolidaysRepository_Import(t *testing.T) {
+	_, _, holidaysRepo, _ := setupTestDB(t)
+
+	// A recurring holiday already covers Christmas
+	_, err := holidaysRepo.Create(CreateHolidayInput{
+		Date:      time.Date(2020, 12, 25, 0, 0, 0, 0, time.UTC),
+		Name:      "Christmas",
+		Recurring: true,
+	})
+	require.NoError(t, err)
+
+	provider, _ := HolidayProviderFor("US")
+	created, skipped, err := holidaysRepo.Import(provider.Holidays(2025))
+	require.NoError(t, err)
+	require.Len(t, created, 10)
+	require.Equal(t, 1, skipped)
+	require.False(t, created[0].IsCustom)
+
+	created, skipped, err = holidaysRepo.Import(provider.Holidays(2025))
+	require.NoError(t, err)
+	require.Empty(t, created)
+	require.Equal(t, 11, skipped)
+
+	_, total, err := holidaysRepo.List(100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 11, total)
+}
+
 func TestHolidaysRepository_GetHolidaysInRange(t *testing.T) {
 	_, _, holidaysRepo, _ := setupTestDB(t)
 
@@ -3,7 +3,8 @@ package scheduler
 import (
 	"database/sql"
 	"encoding/json"
-	"log"
+	"errors"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
@@ -12,61 +13,136 @@ import (
 
 	"github.com/strefethen/sonos-hub-go/internal/api"
 	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/artwork"
+	"github.com/strefethen/sonos-hub-go/internal/audiofiles"
+	"github.com/strefethen/sonos-hub-go/internal/audit"
 	"github.com/strefethen/sonos-hub-go/internal/devices"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
 	"github.com/strefethen/sonos-hub-go/internal/music"
 	"github.com/strefethen/sonos-hub-go/internal/scene"
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
+	"github.com/strefethen/sonos-hub-go/internal/templates"
 )
 
+// RouteDeps holds the services the scheduler routes are built from. The repositories
+// are required; the rest may be nil.
+type RouteDeps struct {
+	Routines *RoutinesRepository
+	Jobs     *JobsRepository
+	Holidays *HolidaysRepository
+	Scenes   *scene.Service
+	Devices  *devices.Service
+	Music    *music.Service
+	// TriggerCooldown rate limits manual triggers; nil disables it.
+	TriggerCooldown *TriggerCooldown
+	// NextRuns computes each routine's next_run_at; nil omits it.
+	NextRuns *JobGenerator
+	// PlaybackRestorer undoes a routine's playback; nil reports nothing to restore.
+	PlaybackRestorer *PlaybackRestorer
+	// Recorder audits routine changes; nil skips auditing.
+	Recorder audit.Recorder
+	// Templates enables creating routines from templates; nil leaves the route out.
+	Templates *templates.Service
+	// Planner serves dry runs; nil makes them unavailable.
+	Planner *RoutineExecutorAdapter
+	// Runtime supplies the default timezone; nil leaves routines created without one in UTC.
+	Runtime RuntimeSettings
+}
+
 // RegisterRoutes wires scheduler routes to the router.
-func RegisterRoutes(router chi.Router, routinesRepo *RoutinesRepository, jobsRepo *JobsRepository, holidaysRepo *HolidaysRepository, sceneService *scene.Service, deviceService *devices.Service, musicService *music.Service) {
+func RegisterRoutes(router chi.Router, deps RouteDeps) {
 	// Routine CRUD
-	router.Method(http.MethodPost, "/v1/routines", api.Handler(createRoutine(routinesRepo, sceneService, deviceService, musicService)))
-	router.Method(http.MethodGet, "/v1/routines", api.Handler(listRoutines(routinesRepo, deviceService, musicService)))
-	router.Method(http.MethodGet, "/v1/routines/{routine_id}", api.Handler(getRoutine(routinesRepo, deviceService, musicService)))
-	router.Method(http.MethodPut, "/v1/routines/{routine_id}", api.Handler(updateRoutine(routinesRepo, sceneService, deviceService, musicService)))
-	router.Method(http.MethodDelete, "/v1/routines/{routine_id}", api.Handler(deleteRoutine(routinesRepo, sceneService)))
+	router.Method(http.MethodPost, "/v1/routines", api.Handler(createRoutine(deps.Routines, deps.Scenes, deps.Devices, deps.Music, deps.NextRuns, deps.Recorder, deps.Runtime)))
+	router.Method(http.MethodGet, "/v1/routines", api.Handler(listRoutines(deps.Routines, deps.Devices, deps.Music, deps.NextRuns)))
+	router.Method(http.MethodGet, "/v1/routines/conflicts", api.Handler(listRoutineConflicts(deps.Routines, deps.Scenes, deps.Devices, deps.NextRuns)))
+	router.Method(http.MethodGet, "/v1/routines/{routine_id}", api.Handler(getRoutine(deps.Routines, deps.Devices, deps.Music, deps.NextRuns)))
+	router.Method(http.MethodPut, "/v1/routines/{routine_id}", api.Handler(updateRoutine(deps.Routines, deps.Scenes, deps.Devices, deps.Music, deps.NextRuns, deps.Recorder)))
+	router.Method(http.MethodDelete, "/v1/routines/{routine_id}", api.Handler(deleteRoutine(deps.Routines, deps.Scenes, deps.Recorder)))
+	router.Method(http.MethodGet, "/v1/routines/{routine_id}/schedule", api.Handler(getRoutineSchedule(deps.Routines)))
+	router.Method(http.MethodGet, "/v1/routines/{routine_id}/occurrences", api.Handler(listRoutineOccurrences(deps.Routines, deps.NextRuns)))
 
 	// Routine actions
-	router.Method(http.MethodPost, "/v1/routines/{routine_id}/enable", api.Handler(enableRoutine(routinesRepo, deviceService, musicService)))
-	router.Method(http.MethodPost, "/v1/routines/{routine_id}/disable", api.Handler(disableRoutine(routinesRepo, deviceService, musicService)))
-	router.Method(http.MethodPost, "/v1/routines/{routine_id}/trigger", api.Handler(triggerRoutine(routinesRepo, jobsRepo)))
-	router.Method(http.MethodPost, "/v1/routines/{routine_id}/snooze", api.Handler(snoozeRoutine(routinesRepo, deviceService, musicService)))
-	router.Method(http.MethodPost, "/v1/routines/{routine_id}/unsnooze", api.Handler(unsnoozeRoutine(routinesRepo, deviceService, musicService)))
-	router.Method(http.MethodPost, "/v1/routines/{routine_id}/skip", api.Handler(skipNextOccurrence(routinesRepo, deviceService, musicService)))
-	router.Method(http.MethodPost, "/v1/routines/{routine_id}/unskip", api.Handler(unskipNextOccurrence(routinesRepo, deviceService, musicService)))
-	router.Method(http.MethodPost, "/v1/routines/{routine_id}/run", api.Handler(runRoutine(routinesRepo, jobsRepo)))
-	router.Method(http.MethodPost, "/v1/routines/{routine_id}/restore", api.Handler(restoreRoutine(routinesRepo, sceneService, deviceService, musicService)))
-	router.Method(http.MethodPost, "/v1/routines/test", api.Handler(testRoutine(sceneService)))
+	router.Method(http.MethodPost, "/v1/routines/{routine_id}/enable", api.Handler(enableRoutine(deps.Routines, deps.Devices, deps.Music, deps.NextRuns, deps.Recorder)))
+	router.Method(http.MethodPost, "/v1/routines/{routine_id}/disable", api.Handler(disableRoutine(deps.Routines, deps.Devices, deps.Music, deps.NextRuns, deps.Recorder)))
+	router.Method(http.MethodPost, "/v1/routines/{routine_id}/trigger", api.Handler(triggerRoutine(deps.Routines, deps.Jobs, deps.TriggerCooldown)))
+	router.Method(http.MethodPost, "/v1/routines/{routine_id}/snooze", api.Handler(snoozeRoutine(deps.Routines, deps.Devices, deps.Music, deps.NextRuns, deps.Recorder)))
+	router.Method(http.MethodPost, "/v1/routines/{routine_id}/unsnooze", api.Handler(unsnoozeRoutine(deps.Routines, deps.Devices, deps.Music, deps.NextRuns, deps.Recorder)))
+	router.Method(http.MethodPost, "/v1/routines/{routine_id}/skip", api.Handler(skipNextOccurrence(deps.Routines, deps.Devices, deps.Music, deps.NextRuns, deps.Recorder)))
+	router.Method(http.MethodPost, "/v1/routines/{routine_id}/unskip", api.Handler(unskipNextOccurrence(deps.Routines, deps.Devices, deps.Music, deps.Recorder)))
+	router.Method(http.MethodPost, "/v1/routines/{routine_id}/run", api.Handler(runRoutine(deps.Routines, deps.Jobs, deps.TriggerCooldown)))
+	router.Method(http.MethodPost, "/v1/routines/{routine_id}/restore", api.Handler(restoreRoutine(deps.Routines, deps.Scenes, deps.Devices, deps.Music, deps.NextRuns, deps.Recorder)))
+	router.Method(http.MethodPost, "/v1/routines/{routine_id}/restore-playback", api.Handler(restoreRoutinePlayback(deps.Routines, deps.PlaybackRestorer)))
+	router.Method(http.MethodPost, "/v1/routines/{routine_id}/dry-run", api.Handler(dryRunRoutine(deps.Routines, deps.Scenes, deps.NextRuns, deps.Planner)))
+	router.Method(http.MethodPost, "/v1/routines/test", api.Handler(testRoutine(deps.Scenes)))
+
+	// Routines from templates
+	if deps.Templates != nil {
+		router.Method(http.MethodPost, "/v1/routine-templates/{template_id}/instantiate", api.Handler(instantiateTemplate(deps.Templates, deps.Routines, deps.Scenes, deps.Devices, deps.Music, deps.NextRuns, deps.Recorder, deps.Runtime)))
+	}
 
 	// Jobs
-	router.Method(http.MethodGet, "/v1/jobs/{job_id}", api.Handler(getJob(jobsRepo)))
-	router.Method(http.MethodGet, "/v1/routines/{routine_id}/jobs", api.Handler(listJobsForRoutine(routinesRepo, jobsRepo)))
+	router.Method(http.MethodGet, "/v1/jobs/{job_id}", api.Handler(getJob(deps.Routines, deps.Jobs)))
+	router.Method(http.MethodGet, "/v1/jobs/{job_id}/log", api.Handler(getJobLog(deps.Jobs, deps.Scenes)))
+	router.Method(http.MethodGet, "/v1/routines/{routine_id}/jobs", api.Handler(listJobsForRoutine(deps.Routines, deps.Jobs)))
 
 	// Executions (jobs across all routines)
-	router.Method(http.MethodGet, "/v1/executions", api.Handler(listExecutions(jobsRepo, routinesRepo)))
-	router.Method(http.MethodPost, "/v1/executions/{execution_id}/retry", api.Handler(retryExecution(jobsRepo)))
+	router.Method(http.MethodGet, "/v1/executions", api.Handler(listExecutions(deps.Jobs, deps.Routines)))
+	router.Method(http.MethodPost, "/v1/executions/{execution_id}/retry", api.Handler(retryExecution(deps.Jobs)))
 
 	// Holidays
-	router.Method(http.MethodPost, "/v1/holidays", api.Handler(createHoliday(holidaysRepo)))
-	router.Method(http.MethodGet, "/v1/holidays", api.Handler(listHolidays(holidaysRepo)))
-	router.Method(http.MethodGet, "/v1/holidays/check", api.Handler(checkHoliday(holidaysRepo)))
-	router.Method(http.MethodGet, "/v1/holidays/{holiday_id}", api.Handler(getHoliday(holidaysRepo)))
-	router.Method(http.MethodDelete, "/v1/holidays/{holiday_id}", api.Handler(deleteHoliday(holidaysRepo)))
+	router.Method(http.MethodPost, "/v1/holidays", api.Handler(createHoliday(deps.Holidays)))
+	router.Method(http.MethodGet, "/v1/holidays", api.Handler(listHolidays(deps.Holidays)))
+	router.Method(http.MethodPost, "/v1/holidays/import", api.Handler(importHolidays(deps.Holidays)))
+	router.Method(http.MethodGet, "/v1/holidays/check", api.Handler(checkHoliday(deps.Holidays)))
+	router.Method(http.MethodGet, "/v1/holidays/impact", api.Handler(holidayImpact(deps.Routines)))
+	router.Method(http.MethodGet, "/v1/holidays/{holiday_id}", api.Handler(getHoliday(deps.Holidays)))
+	router.Method(http.MethodDelete, "/v1/holidays/{holiday_id}", api.Handler(deleteHoliday(deps.Holidays)))
 }
 
 // ==========================================================================
 // Routine Handlers
 // ==========================================================================
 
+// recordRoutineChange audits a successful routine mutation. before is nil for a create
+// and after is nil for a delete.
+func recordRoutineChange(r *http.Request, recorder audit.Recorder, eventType audit.EventType, action string, before, after *Routine) {
+	routineID := ""
+	if after != nil {
+		routineID = after.RoutineID
+	} else if before != nil {
+		routineID = before.RoutineID
+	}
+	audit.Record(r.Context(), recorder, audit.Change{
+		Type:         eventType,
+		Action:       action,
+		ResourceType: audit.ResourceRoutine,
+		ResourceID:   routineID,
+		Before:       before,
+		After:        after,
+	})
+}
+
+// routineBeforeChange reads a routine for the audit diff of a change about to be made.
+func routineBeforeChange(routinesRepo *RoutinesRepository, recorder audit.Recorder, routineID string) *Routine {
+	return audit.Before(recorder, func() (*Routine, error) { return routinesRepo.GetByID(routineID) })
+}
+
 // ScheduleInput handles nested schedule from iOS.
 // iOS sends { "schedule": { "type": "weekly", "weekdays": [2,3,4,5,6], "time": "07:30" } }
 // but Go expects flat fields: schedule_type, schedule_weekdays, schedule_time.
 type ScheduleInput struct {
-	Type     string `json:"type"`
-	Weekdays []int  `json:"weekdays,omitempty"`
-	Month    *int   `json:"month,omitempty"`
-	Day      *int   `json:"day,omitempty"`
-	Time     string `json:"time"`
+	Type         string  `json:"type"`
+	Weekdays     []int   `json:"weekdays,omitempty"`
+	Month        *int    `json:"month,omitempty"`
+	Day          *int    `json:"day,omitempty"`
+	Time         string  `json:"time"`
+	IntervalDays *int    `json:"interval_days,omitempty"` // INTERVAL only
+	AnchorDate   *string `json:"anchor_date,omitempty"`   // INTERVAL only, YYYY-MM-DD
+
+	TimeMode      string `json:"time_mode,omitempty"`      // fixed, sunrise, or sunset
+	OffsetMinutes *int   `json:"offset_minutes,omitempty"` // Minutes after (or before, if negative) the solar event
+
+	DurationMinutes *int `json:"duration_minutes,omitempty"` // Auto-stop after this many minutes
 }
 
 // createRoutineRequest is the input structure for creating a routine.
@@ -77,100 +153,227 @@ type createRoutineRequest struct {
 	Speakers    []SpeakerInput `json:"speakers,omitempty"`     // iOS sends speakers instead of scene_id
 	MusicPolicy *MusicPolicy   `json:"music_policy,omitempty"` // Nested music policy from iOS
 	Schedule    *ScheduleInput `json:"schedule,omitempty"`     // Nested schedule from iOS
+
+	GroupingMode string `json:"grouping_mode,omitempty"` // Stored on the routine's scene
+	StaggerMs    *int   `json:"stagger_ms,omitempty"`    // Stored on the routine's scene
 }
 
-func createRoutine(routinesRepo *RoutinesRepository, sceneService *scene.Service, deviceService *devices.Service, musicService *music.Service) func(w http.ResponseWriter, r *http.Request) error {
+func createRoutine(routinesRepo *RoutinesRepository, sceneService *scene.Service, deviceService *devices.Service, musicService *music.Service, nextRuns *JobGenerator, recorder audit.Recorder, runtime RuntimeSettings) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
+		localTZ, err := parseLocalTimeZone(r)
+		if err != nil {
+			return err
+		}
+
 		var req createRoutineRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			return apperrors.NewValidationError("invalid request body", nil)
 		}
 
-		// Validate required fields
-		if req.Name == "" {
-			return apperrors.NewValidationError("name is required", nil)
+		routine, err := createRoutineFromRequest(r, req, routinesRepo, sceneService, musicService, recorder, runtime)
+		if err != nil {
+			return err
 		}
 
-		// Require either scene_id OR speakers
-		if req.SceneID == "" && len(req.Speakers) == 0 {
-			return apperrors.NewValidationError("either scene_id or speakers is required", nil)
-		}
+		// Build device room map for speaker enrichment
+		deviceRoomMap := buildDeviceRoomMap(deviceService)
 
-		// Auto-create scene if speakers provided and no scene_id
-		if len(req.Speakers) > 0 && req.SceneID == "" {
-			// Convert SpeakerInput to SceneMember
-			members := make([]scene.SceneMember, len(req.Speakers))
-			for i, s := range req.Speakers {
-				vol := s.Volume
-				members[i] = scene.SceneMember{
-					UDN:          s.UDN,
-					TargetVolume: &vol,
-				}
-			}
+		// Stripe-style: return resource directly, with any schedule conflicts as warnings
+		result := formatRoutineWithEnrichment(routine, deviceRoomMap, musicService, nextRuns, localTZ)
+		result["warnings"] = routineConflictWarnings(r, routine, routinesRepo, sceneService, nextRuns, deviceRoomMap)
+		return api.WriteResource(w, http.StatusCreated, result)
+	}
+}
 
-			// Auto-create scene for this routine
-			description := "Auto-created scene for routine"
-			newScene, err := sceneService.CreateScene(scene.CreateSceneInput{
-				Name:        "Routine: " + req.Name,
-				Description: &description,
-				Members:     members,
-			})
-			if err != nil {
-				log.Printf("Failed to auto-create scene for routine: %v", err)
-				return apperrors.NewInternalError("Failed to create scene for routine")
-			}
-			req.SceneID = newScene.SceneID
-			log.Printf("Auto-created scene %s for routine %s", newScene.SceneID, req.Name)
+// createRoutineFromRequest validates req and creates the routine, auto-creating its
+// scene from the speakers when no scene_id is given. Routines without a timezone get
+// runtime's default timezone.
+func createRoutineFromRequest(r *http.Request, req createRoutineRequest, routinesRepo *RoutinesRepository, sceneService *scene.Service, musicService *music.Service, recorder audit.Recorder, runtime RuntimeSettings) (*Routine, error) {
+	// Validate required fields
+	if req.Name == "" {
+		return nil, apperrors.NewValidationError("name is required", nil)
+	}
+	missedRunPolicy := req.MissedRunPolicy
+	if missedRunPolicy == "" {
+		missedRunPolicy = MissedRunPolicySkip
+	}
+	if err := validateMissedRunPolicy(missedRunPolicy, req.MissedRunWithinMinutes); err != nil {
+		return nil, err
+	}
 
-			// Also convert speakers to internal format for storage
-			req.SpeakersJSON = make([]Speaker, len(req.Speakers))
-			for i, s := range req.Speakers {
-				vol := s.Volume
-				req.SpeakersJSON[i] = Speaker{
-					UDN:    s.UDN,
-					Volume: &vol,
-				}
-			}
+	// Process nested schedule from iOS and flatten to database columns
+	if req.Schedule != nil {
+		processSchedule(&req.CreateRoutineInput, req.Schedule)
+	}
+	if req.Timezone == "" && runtime != nil {
+		req.Timezone = runtime.DefaultTimezone()
+	}
+	if err := validateSchedule(req.ScheduleTime, req.ScheduleWeekdays, req.ScheduleMonth, req.ScheduleDay, req.Timezone); err != nil {
+		return nil, err
+	}
+	if req.ScheduleType == ScheduleTypeInterval {
+		if err := validateIntervalSchedule(req.ScheduleIntervalDays, req.ScheduleAnchorDate); err != nil {
+			return nil, err
 		}
-
-		// Verify scene exists (either pre-existing or just created)
-		existingScene, err := sceneService.GetScene(req.SceneID)
-		if err != nil {
-			return apperrors.NewInternalError("Failed to verify scene")
+	}
+	if req.DurationMinutes != nil {
+		if err := validateDurationMinutes(*req.DurationMinutes); err != nil {
+			return nil, err
 		}
-		if existingScene == nil {
-			return apperrors.NewAppError(apperrors.ErrorCodeSceneNotFound, "Scene not found", 404, map[string]any{"scene_id": req.SceneID}, nil)
+	}
+	if err := validateRetryPolicy(req.MaxAttempts, req.RetryBackoffSeconds); err != nil {
+		return nil, err
+	}
+	if err := validateHolidayMusicSet(musicService, req.HolidayBehavior, req.HolidayMusicSetID); err != nil {
+		return nil, err
+	}
+	if req.ScheduleTimeMode != "" || req.ScheduleOffsetMinutes != nil {
+		timeMode := req.ScheduleTimeMode
+		if timeMode == "" {
+			timeMode = TimeModeFixed
 		}
-
-		// Process nested music_policy from iOS and flatten to database columns
-		if req.MusicPolicy != nil {
-			processMusicPolicy(&req.CreateRoutineInput, req.MusicPolicy)
+		if err := validateTimeMode(timeMode, req.ScheduleOffsetMinutes); err != nil {
+			return nil, err
 		}
+	}
 
-		// Process nested schedule from iOS and flatten to database columns
-		if req.Schedule != nil {
-			processSchedule(&req.CreateRoutineInput, req.Schedule)
+	// Require either scene_id OR speakers
+	if req.SceneID == "" && len(req.Speakers) == 0 {
+		return nil, apperrors.NewValidationError("either scene_id or speakers is required", nil)
+	}
+	if err := validateGroupingMode(req.GroupingMode); err != nil {
+		return nil, err
+	}
+	if err := validateStaggerMs(req.StaggerMs); err != nil {
+		return nil, err
+	}
+	existingSceneID := req.SceneID
+
+	// Auto-create scene if speakers provided and no scene_id
+	if len(req.Speakers) > 0 && req.SceneID == "" {
+		// Convert SpeakerInput to SceneMember
+		members := make([]scene.SceneMember, len(req.Speakers))
+		for i, s := range req.Speakers {
+			vol := s.Volume
+			members[i] = scene.SceneMember{
+				UDN:          s.UDN,
+				TargetVolume: &vol,
+				FallbackUDN:  s.FallbackUDN,
+				FadeInMs:     s.FadeInMs,
+				FadeCurve:    s.FadeCurve,
+			}
+		}
+		if err := validateSpeakerMembers(sceneService, members); err != nil {
+			return nil, err
+		}
+		if err := validateSpeakerAudioSettings(req.Speakers); err != nil {
+			return nil, err
 		}
 
-		routine, err := routinesRepo.Create(req.CreateRoutineInput)
+		// Auto-create scene for this routine
+		description := "Auto-created scene for routine"
+		sceneInput := scene.CreateSceneInput{
+			Name:         "Routine: " + req.Name,
+			Description:  &description,
+			Members:      members,
+			GroupingMode: req.GroupingMode,
+		}
+		if req.StaggerMs != nil {
+			sceneInput.StaggerMs = *req.StaggerMs
+		}
+		newScene, err := sceneService.CreateScene(sceneInput)
 		if err != nil {
-			log.Printf("Failed to create routine: %v", err)
-			return apperrors.NewInternalError("Failed to create routine")
+			logging.From(r.Context(), nil).Error("Failed to auto-create scene for routine", "error", err)
+			return nil, apperrors.NewInternalError("Failed to create scene for routine")
+		}
+		req.SceneID = newScene.SceneID
+		logging.From(r.Context(), nil).Info("Auto-created scene for routine", "scene_id", newScene.SceneID, "routine_name", req.Name)
+
+		// Also convert speakers to internal format for storage
+		req.SpeakersJSON = make([]Speaker, len(req.Speakers))
+		for i, s := range req.Speakers {
+			vol := s.Volume
+			req.SpeakersJSON[i] = Speaker{
+				UDN:           s.UDN,
+				Volume:        &vol,
+				FallbackUDN:   s.FallbackUDN,
+				FadeInMs:      s.FadeInMs,
+				FadeCurve:     s.FadeCurve,
+				AudioSettings: s.AudioSettings,
+			}
 		}
+	}
 
-		// Build device room map for speaker enrichment
-		deviceRoomMap := buildDeviceRoomMap(deviceService)
+	// Verify scene exists (either pre-existing or just created)
+	existingScene, err := sceneService.GetScene(req.SceneID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("Failed to verify scene")
+	}
+	if existingScene == nil {
+		return nil, apperrors.NewAppError(apperrors.ErrorCodeSceneNotFound, "Scene not found", 404, map[string]any{"scene_id": req.SceneID}, nil)
+	}
+	if existingSceneID != "" && (req.GroupingMode != "" || req.StaggerMs != nil) {
+		sceneUpdate := scene.UpdateSceneInput{StaggerMs: req.StaggerMs}
+		if req.GroupingMode != "" {
+			sceneUpdate.GroupingMode = &req.GroupingMode
+		}
+		if _, err := sceneService.UpdateScene(existingSceneID, sceneUpdate); err != nil {
+			logging.From(r.Context(), nil).Warn("Failed to update scene playback settings", "error", err)
+			return nil, apperrors.NewInternalError("Failed to update scene")
+		}
+	}
 
-		// Stripe-style: return resource directly
-		return api.WriteResource(w, http.StatusCreated, formatRoutineWithEnrichment(routine, deviceRoomMap, musicService))
+	// Process nested music_policy from iOS and flatten to database columns
+	if req.MusicPolicy != nil {
+		if err := validateLocalFileContent(req.MusicPolicy); err != nil {
+			return nil, err
+		}
+		localizeFavoriteArtwork(musicService, req.MusicPolicy)
+		processMusicPolicy(&req.CreateRoutineInput, req.MusicPolicy)
+	}
+	if err := validateMusicSets(musicService, req.MusicSets); err != nil {
+		return nil, err
+	}
+	if err := validateNoRepeatScope(req.MusicNoRepeatScope); err != nil {
+		return nil, err
+	}
+	if err := validatePlayMode(req.MusicPlayMode); err != nil {
+		return nil, err
+	}
+	if err := validateSleepTimerMinutes(req.SleepTimerMinutes); err != nil {
+		return nil, err
+	}
+	// Actions without a music policy make a routine that plays nothing
+	if len(req.Actions) > 0 && req.MusicPolicyType == "" {
+		req.MusicPolicyType = MusicPolicyTypeNone
+	}
+	if err := validateRoutineActions(req.MusicPolicyType, req.Actions, len(req.SpeakersJSON)); err != nil {
+		return nil, err
+	}
+	if err := validateWakeProfile(req.WakeProfile, req.MusicPolicyType); err != nil {
+		return nil, err
+	}
+
+	routine, err := routinesRepo.Create(req.CreateRoutineInput)
+	if err != nil {
+		logging.From(r.Context(), nil).Error("Failed to create routine", "error", err)
+		return nil, apperrors.NewInternalError("Failed to create routine")
 	}
+	recordRoutineChange(r, recorder, audit.EventRoutineCreated, "create", nil, routine)
+	return routine, nil
 }
 
-func listRoutines(routinesRepo *RoutinesRepository, deviceService *devices.Service, musicService *music.Service) func(w http.ResponseWriter, r *http.Request) error {
+func listRoutines(routinesRepo *RoutinesRepository, deviceService *devices.Service, musicService *music.Service, nextRuns *JobGenerator) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
+		localTZ, err := parseLocalTimeZone(r)
+		if err != nil {
+			return err
+		}
+
 		limit := 20
 		offset := 0
 		enabledOnly := false
+		includeDeleted := false
 
 		if l := r.URL.Query().Get("limit"); l != "" {
 			if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
@@ -185,21 +388,30 @@ func listRoutines(routinesRepo *RoutinesRepository, deviceService *devices.Servi
 		if e := r.URL.Query().Get("enabled"); e != "" {
 			enabledOnly = e == "true" || e == "1"
 		}
+		if d := r.URL.Query().Get("include_deleted"); d != "" {
+			includeDeleted = d == "true" || d == "1"
+		}
 
-		routines, total, err := routinesRepo.List(limit, offset, enabledOnly)
+		var routines []Routine
+		var total int
+		if includeDeleted {
+			routines, total, err = routinesRepo.ListIncludingDeleted(limit, offset, enabledOnly)
+		} else {
+			routines, total, err = routinesRepo.List(limit, offset, enabledOnly)
+		}
 		if err != nil {
-			log.Printf("GET /v1/routines error: %v", err)
+			logging.From(r.Context(), nil).Error("Failed to list routines", "error", err)
 			return apperrors.NewInternalError("Failed to list routines")
 		}
 
-		log.Printf("GET /v1/routines: returning %d routines (total: %d, limit: %d, offset: %d)", len(routines), total, limit, offset)
+		logging.From(r.Context(), nil).Debug("Listed routines", "count", len(routines), "total", total, "limit", limit, "offset", offset)
 
 		// Build device room map for speaker enrichment
 		deviceRoomMap := buildDeviceRoomMap(deviceService)
 
 		formatted := make([]map[string]any, 0, len(routines))
 		for _, routine := range routines {
-			formatted = append(formatted, formatRoutineWithEnrichment(&routine, deviceRoomMap, musicService))
+			formatted = append(formatted, formatRoutineWithEnrichment(&routine, deviceRoomMap, musicService, nextRuns, localTZ))
 		}
 
 		hasMore := offset+len(routines) < total
@@ -208,8 +420,13 @@ func listRoutines(routinesRepo *RoutinesRepository, deviceService *devices.Servi
 	}
 }
 
-func getRoutine(routinesRepo *RoutinesRepository, deviceService *devices.Service, musicService *music.Service) func(w http.ResponseWriter, r *http.Request) error {
+func getRoutine(routinesRepo *RoutinesRepository, deviceService *devices.Service, musicService *music.Service, nextRuns *JobGenerator) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
+		localTZ, err := parseLocalTimeZone(r)
+		if err != nil {
+			return err
+		}
+
 		routineID := chi.URLParam(r, "routine_id")
 
 		routine, err := routinesRepo.GetByID(routineID)
@@ -224,11 +441,107 @@ func getRoutine(routinesRepo *RoutinesRepository, deviceService *devices.Service
 		deviceRoomMap := buildDeviceRoomMap(deviceService)
 
 		// Stripe-style: return resource directly
-		return api.WriteResource(w, http.StatusOK, formatRoutineWithEnrichment(routine, deviceRoomMap, musicService))
+		return api.WriteResource(w, http.StatusOK, formatRoutineWithEnrichment(routine, deviceRoomMap, musicService, nextRuns, localTZ))
+	}
+}
+
+// getRoutineSchedule returns the canonical schedule exactly as stored, without the
+// iOS-oriented re-nesting applied by getRoutine.
+func getRoutineSchedule(routinesRepo *RoutinesRepository) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		routineID := chi.URLParam(r, "routine_id")
+
+		routine, err := routinesRepo.GetByID(routineID)
+		if err != nil {
+			return apperrors.NewInternalError("Failed to get routine")
+		}
+		if routine == nil {
+			return apperrors.NewAppError(apperrors.ErrorCodeRoutineNotFound, "Routine not found", 404, map[string]any{"routine_id": routineID}, nil)
+		}
+
+		schedule := NormalizeSchedule(routine)
+		return api.WriteResource(w, http.StatusOK, map[string]any{
+			"object":           "routine_schedule",
+			"routine_id":       routine.RoutineID,
+			"type":             string(schedule.Type),
+			"time":             schedule.Time,
+			"weekdays":         schedule.Weekdays,
+			"month":            schedule.Month,
+			"day":              schedule.Day,
+			"interval_days":    schedule.IntervalDays,
+			"anchor_date":      schedule.AnchorDate,
+			"time_mode":        string(schedule.TimeMode),
+			"offset_minutes":   schedule.OffsetMinutes,
+			"timezone":         schedule.Timezone,
+			"holiday_behavior": string(schedule.HolidayBehavior),
+		})
+	}
+}
+
+// listRoutineOccurrences previews a routine's next scheduled times in its own timezone,
+// annotated with whether each will be skipped (snoozed, skip_next, holiday) or delayed.
+func listRoutineOccurrences(routinesRepo *RoutinesRepository, nextRuns *JobGenerator) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		routineID := chi.URLParam(r, "routine_id")
+
+		count := DefaultOccurrencesCount
+		if c := r.URL.Query().Get("count"); c != "" {
+			parsed, err := strconv.Atoi(c)
+			if err != nil || parsed < 1 || parsed > MaxOccurrencesCount {
+				return apperrors.NewValidationError("count must be between 1 and "+strconv.Itoa(MaxOccurrencesCount), map[string]any{"count": c})
+			}
+			count = parsed
+		}
+
+		routine, err := routinesRepo.GetByID(routineID)
+		if err != nil {
+			return apperrors.NewInternalError("Failed to get routine")
+		}
+		if routine == nil {
+			return apperrors.NewAppError(apperrors.ErrorCodeRoutineNotFound, "Routine not found", 404, map[string]any{"routine_id": routineID}, nil)
+		}
+
+		occurrences, err := nextRuns.UpcomingOccurrences(routine, time.Now(), count)
+		if err != nil {
+			logging.From(r.Context(), nil).Error("Failed to compute occurrences", "routine_id", routineID, "error", err)
+			return apperrors.NewAppError(apperrors.ErrorCodeInvalidSchedule, "Routine schedule has no computable occurrences", 422, map[string]any{"routine_id": routineID}, nil)
+		}
+
+		loc, err := time.LoadLocation(routine.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		formatted := make([]map[string]any, 0, len(occurrences))
+		for _, occurrence := range occurrences {
+			item := map[string]any{
+				"object":        "routine_occurrence",
+				"scheduled_for": api.RFC3339MillisIn(occurrence.ScheduledFor, loc),
+				"will_run":      occurrence.SkipReason == "",
+				"skip_reason":   nil,
+				"holiday":       nil,
+				"delayed_until": nil,
+			}
+			if occurrence.SkipReason != "" {
+				item["skip_reason"] = string(occurrence.SkipReason)
+			}
+			if occurrence.Holiday != nil {
+				item["holiday"] = map[string]any{
+					"date": occurrence.Holiday.Date,
+					"name": occurrence.Holiday.Name,
+				}
+			}
+			if occurrence.DelayedUntil != nil {
+				item["delayed_until"] = api.RFC3339MillisIn(*occurrence.DelayedUntil, loc)
+			}
+			formatted = append(formatted, item)
+		}
+
+		return api.WriteList(w, "/v1/routines/"+routineID+"/occurrences", formatted, false)
 	}
 }
 
 // buildDeviceRoomMap creates a map of udn -> room_name from the device service.
+// Bonded satellites are left out; they play as part of their primary device.
 // NON-BLOCKING: Returns empty map if topology not yet available.
 // Matches Node.js behavior: continue without room names if device registry unavailable.
 func buildDeviceRoomMap(deviceService *devices.Service) map[string]string {
@@ -240,12 +553,265 @@ func buildDeviceRoomMap(deviceService *devices.Service) map[string]string {
 	topology := deviceService.GetTopologyIfCached()
 	if topology != nil {
 		for _, device := range topology.Devices {
-			deviceRoomMap[device.UDN] = device.RoomName
+			if !device.IsBonded {
+				deviceRoomMap[device.UDN] = device.DisplayName()
+			}
 		}
 	}
 	return deviceRoomMap
 }
 
+// validateSpeakerMembers rejects speakers whose fallback_udn is not a known device.
+func validateSpeakerMembers(sceneService *scene.Service, members []scene.SceneMember) error {
+	err := sceneService.ValidateMembers(members)
+	if err == nil {
+		return nil
+	}
+	if invalid, ok := err.(*scene.InvalidFallbackError); ok {
+		return apperrors.NewValidationError("invalid fallback_udn: "+invalid.Reason, map[string]any{
+			"udn":          invalid.UDN,
+			"fallback_udn": invalid.FallbackUDN,
+		})
+	}
+	if invalid, ok := err.(*scene.InvalidFadeError); ok {
+		return apperrors.NewValidationError(invalid.Field+" "+invalid.Reason, map[string]any{
+			"udn": invalid.UDN,
+		})
+	}
+	if bonded, ok := err.(*scene.BondedMemberError); ok {
+		return apperrors.NewValidationError(bonded.Error(), bonded.Details())
+	}
+	return apperrors.NewInternalError("Failed to validate speakers")
+}
+
+// validateStaggerMs checks a stagger_ms for the routine's scene; nil leaves it unchanged.
+func validateStaggerMs(ms *int) error {
+	if ms != nil && !scene.ValidStaggerMs(*ms) {
+		return apperrors.NewValidationError("stagger_ms must be between 0 and "+strconv.Itoa(scene.MaxStaggerMs), map[string]any{"stagger_ms": *ms})
+	}
+	return nil
+}
+
+// validateGroupingMode checks a grouping_mode for the routine's scene.
+func validateGroupingMode(mode string) error {
+	if !scene.ValidGroupingMode(mode) {
+		return apperrors.NewValidationError("grouping_mode must be one of independent, grouped, grouped_then_restore", map[string]any{"grouping_mode": mode})
+	}
+	return nil
+}
+
+// validateMissedRunPolicy checks a missed_run_policy and its missed_run_within_minutes window.
+func validateMissedRunPolicy(policy MissedRunPolicy, withinMinutes *int) error {
+	if !policy.IsValid() {
+		return apperrors.NewValidationError("missed_run_policy must be one of run_immediately, skip, run_if_within_minutes", map[string]any{"missed_run_policy": string(policy)})
+	}
+	if withinMinutes != nil && *withinMinutes < 1 {
+		return apperrors.NewValidationError("missed_run_within_minutes must be a positive integer", map[string]any{"missed_run_within_minutes": *withinMinutes})
+	}
+	if policy == MissedRunPolicyRunIfWithinMinutes && withinMinutes == nil {
+		return apperrors.NewValidationError("missed_run_within_minutes is required for run_if_within_minutes", nil)
+	}
+	return nil
+}
+
+// validateIntervalSchedule checks the interval and anchor date of an INTERVAL schedule.
+func validateIntervalSchedule(intervalDays *int, anchorDate *string) error {
+	if intervalDays == nil || *intervalDays < 1 {
+		return apperrors.NewValidationError("schedule_interval_days must be at least 1 for INTERVAL schedules", nil)
+	}
+	if anchorDate == nil || *anchorDate == "" {
+		return apperrors.NewValidationError("schedule_anchor_date is required for INTERVAL schedules", nil)
+	}
+	if _, err := time.Parse(AnchorDateFormat, *anchorDate); err != nil {
+		return apperrors.NewValidationError("schedule_anchor_date must be a date in YYYY-MM-DD format", map[string]any{"schedule_anchor_date": *anchorDate})
+	}
+	return nil
+}
+
+// validateSchedule checks a routine's flattened schedule fields: schedule_time is HH:MM on a
+// 24-hour clock, weekdays use time.Weekday numbering as sent by the iOS app (0 = Sunday
+// through 6 = Saturday), the month is 1-12, the day exists in that month (any month when
+// none is set) and the timezone is an IANA name. Empty fields are not checked.
+func validateSchedule(scheduleTime string, weekdays []int, month, day *int, timezone string) error {
+	if scheduleTime != "" {
+		if _, err := time.Parse("15:04", scheduleTime); err != nil || len(scheduleTime) != len("15:04") {
+			return apperrors.NewValidationError("schedule_time must be HH:MM in 24-hour format", map[string]any{"schedule_time": scheduleTime})
+		}
+	}
+	for _, weekday := range weekdays {
+		if weekday < int(time.Sunday) || weekday > int(time.Saturday) {
+			return apperrors.NewValidationError("schedule_weekdays must be between 0 (Sunday) and 6 (Saturday)", map[string]any{"schedule_weekdays": weekdays})
+		}
+	}
+	if month != nil && (*month < 1 || *month > 12) {
+		return apperrors.NewValidationError("schedule_month must be between 1 and 12", map[string]any{"schedule_month": *month})
+	}
+	if day != nil {
+		maxDay := 31
+		if month != nil {
+			// A leap year, so yearly routines can run on February 29
+			maxDay = time.Date(2024, time.Month(*month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		}
+		if *day < 1 || *day > maxDay {
+			return apperrors.NewValidationError("schedule_day must be between 1 and "+strconv.Itoa(maxDay), map[string]any{"schedule_day": *day})
+		}
+	}
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return apperrors.NewValidationError("timezone must be an IANA timezone name", map[string]any{"timezone": timezone})
+		}
+	}
+	return nil
+}
+
+// validateDurationMinutes checks a routine's auto-stop duration.
+func validateDurationMinutes(minutes int) error {
+	if minutes < 1 || minutes > MaxRoutineDurationMinutes {
+		return apperrors.NewValidationError("duration_minutes must be between 1 and "+strconv.Itoa(MaxRoutineDurationMinutes), map[string]any{"duration_minutes": minutes})
+	}
+	return nil
+}
+
+// validateSleepTimerMinutes checks a routine's sleep_timer_minutes; zero clears it on update.
+func validateSleepTimerMinutes(minutes *int) error {
+	if minutes == nil {
+		return nil
+	}
+	if err := sonos.ValidateSleepTimerMinutes(*minutes); err != nil {
+		return apperrors.NewValidationError("sleep_timer_minutes "+err.Error(), map[string]any{"sleep_timer_minutes": *minutes})
+	}
+	return nil
+}
+
+// validatePlayMode checks a routine's music_policy.play_mode.
+func validatePlayMode(playMode *sonos.PlayModeUpdate) error {
+	if playMode == nil {
+		return nil
+	}
+	if err := playMode.Validate(); err != nil {
+		return apperrors.NewValidationError("music_policy.play_mode: "+err.Error(), map[string]any{"play_mode": playMode})
+	}
+	return nil
+}
+
+// validateLocalFileContent checks a local_file music_content's file name. The file itself
+// needn't exist yet; a routine that runs without it fails to resolve its content.
+func validateLocalFileContent(policy *MusicPolicy) error {
+	content := policy.MusicContent
+	if content == nil || content.Type != "local_file" {
+		return nil
+	}
+	if content.Filename == nil || *content.Filename == "" {
+		return apperrors.NewValidationError("music_policy.music_content.filename is required for local_file content", nil)
+	}
+	if err := audiofiles.ValidateFilename(*content.Filename); err != nil {
+		return apperrors.NewValidationError("music_policy.music_content.filename: "+err.Error(), map[string]any{"filename": *content.Filename})
+	}
+	return nil
+}
+
+// validateSpeakerAudioSettings checks each speaker's audio_settings levels.
+func validateSpeakerAudioSettings(speakers []SpeakerInput) error {
+	for _, s := range speakers {
+		if s.AudioSettings == nil {
+			continue
+		}
+		if err := s.AudioSettings.Validate(); err != nil {
+			return apperrors.NewValidationError("audio_settings: "+err.Error(), map[string]any{"udn": s.UDN})
+		}
+	}
+	return nil
+}
+
+// validateRetryPolicy checks a routine's max_attempts and retry_backoff_seconds.
+func validateRetryPolicy(maxAttempts, backoffSeconds *int) error {
+	if maxAttempts != nil && (*maxAttempts < 1 || *maxAttempts > MaxRoutineAttempts) {
+		return apperrors.NewValidationError("max_attempts must be between 1 and "+strconv.Itoa(MaxRoutineAttempts), map[string]any{"max_attempts": *maxAttempts})
+	}
+	if backoffSeconds != nil && (*backoffSeconds < 1 || *backoffSeconds > MaxRetryBackoffSeconds) {
+		return apperrors.NewValidationError("retry_backoff_seconds must be between 1 and "+strconv.Itoa(MaxRetryBackoffSeconds), map[string]any{"retry_backoff_seconds": *backoffSeconds})
+	}
+	return nil
+}
+
+// routineMaxAttempts is a routine's max_attempts for responses: nil when the routine leaves
+// it to the runner's limit.
+func routineMaxAttempts(routine *Routine) *int {
+	if routine.MaxAttempts == 0 {
+		return nil
+	}
+	return &routine.MaxAttempts
+}
+
+// validateHolidayMusicSet checks that a PLAY_ALTERNATE routine has a holiday music set
+// and that a referenced set exists.
+func validateHolidayMusicSet(musicService *music.Service, behavior HolidayBehavior, setID *string) error {
+	if setID == nil || *setID == "" {
+		if behavior == HolidayBehaviorPlayAlternate {
+			return apperrors.NewValidationError("holiday_music_set_id is required when holiday_behavior is PLAY_ALTERNATE", nil)
+		}
+		return nil
+	}
+
+	if _, err := musicService.GetSet(*setID); err != nil {
+		var notFound *music.SetNotFoundError
+		if errors.As(err, &notFound) {
+			return apperrors.NewAppError(apperrors.ErrorCodeSetNotFound, "Holiday music set not found", 404, map[string]any{"holiday_music_set_id": *setID}, nil)
+		}
+		return apperrors.NewInternalError("Failed to verify holiday music set")
+	}
+	return nil
+}
+
+// validateMusicSets checks a routine's weighted music sets: at most MaxRoutineMusicSets,
+// each named once with a non-negative weight, and each existing.
+func validateMusicSets(musicService *music.Service, sets []music.WeightedSet) error {
+	if len(sets) > MaxRoutineMusicSets {
+		return apperrors.NewValidationError("a routine can have at most "+strconv.Itoa(MaxRoutineMusicSets)+" music sets", map[string]any{"sets": len(sets)})
+	}
+	seen := make(map[string]bool, len(sets))
+	for _, set := range sets {
+		if set.SetID == "" {
+			return apperrors.NewValidationError("each music set requires a set_id", nil)
+		}
+		if seen[set.SetID] {
+			return apperrors.NewValidationError("music set is listed more than once", map[string]any{"set_id": set.SetID})
+		}
+		seen[set.SetID] = true
+		if set.Weight < 0 {
+			return apperrors.NewValidationError("music set weight must not be negative", map[string]any{"set_id": set.SetID, "weight": set.Weight})
+		}
+
+		if _, err := musicService.GetSet(set.SetID); err != nil {
+			var notFound *music.SetNotFoundError
+			if errors.As(err, &notFound) {
+				return apperrors.NewAppError(apperrors.ErrorCodeSetNotFound, "Music set not found", 404, map[string]any{"set_id": set.SetID}, nil)
+			}
+			return apperrors.NewInternalError("Failed to verify music set")
+		}
+	}
+	return nil
+}
+
+// validateNoRepeatScope checks a routine's no_repeat_scope; empty is the default.
+func validateNoRepeatScope(scope music.NoRepeatScope) error {
+	if scope != "" && !scope.IsValid() {
+		return apperrors.NewValidationError("no_repeat_scope must be one of set, routine, global", map[string]any{"no_repeat_scope": string(scope)})
+	}
+	return nil
+}
+
+// validateTimeMode checks a schedule's time_mode and its solar offset.
+func validateTimeMode(mode TimeMode, offsetMinutes *int) error {
+	if !mode.IsValid() {
+		return apperrors.NewValidationError("time_mode must be one of fixed, sunrise, sunset", map[string]any{"time_mode": string(mode)})
+	}
+	if offsetMinutes != nil && (*offsetMinutes < -MaxSolarOffsetMinutes || *offsetMinutes > MaxSolarOffsetMinutes) {
+		return apperrors.NewValidationError("offset_minutes must be between -"+strconv.Itoa(MaxSolarOffsetMinutes)+" and "+strconv.Itoa(MaxSolarOffsetMinutes), map[string]any{"offset_minutes": *offsetMinutes})
+	}
+	return nil
+}
+
 // updateRoutineRequest is the input structure for updating a routine.
 // It supports both scene_id and speakers (to update scene members).
 // iOS sends nested music_policy and schedule objects which we flatten to database columns.
@@ -254,10 +820,18 @@ type updateRoutineRequest struct {
 	Speakers    []SpeakerInput `json:"speakers,omitempty"`     // iOS sends speakers to update scene members
 	MusicPolicy *MusicPolicy   `json:"music_policy,omitempty"` // Nested music policy from iOS
 	Schedule    *ScheduleInput `json:"schedule,omitempty"`     // Nested schedule from iOS
+
+	GroupingMode *string `json:"grouping_mode,omitempty"` // Stored on the routine's scene
+	StaggerMs    *int    `json:"stagger_ms,omitempty"`    // Stored on the routine's scene
 }
 
-func updateRoutine(routinesRepo *RoutinesRepository, sceneService *scene.Service, deviceService *devices.Service, musicService *music.Service) func(w http.ResponseWriter, r *http.Request) error {
+func updateRoutine(routinesRepo *RoutinesRepository, sceneService *scene.Service, deviceService *devices.Service, musicService *music.Service, nextRuns *JobGenerator, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
+		localTZ, err := parseLocalTimeZone(r)
+		if err != nil {
+			return err
+		}
+
 		routineID := chi.URLParam(r, "routine_id")
 
 		var req updateRoutineRequest
@@ -274,7 +848,103 @@ func updateRoutine(routinesRepo *RoutinesRepository, sceneService *scene.Service
 			return apperrors.NewAppError(apperrors.ErrorCodeRoutineNotFound, "Routine not found", 404, map[string]any{"routine_id": routineID}, nil)
 		}
 
+		if req.MissedRunPolicy != nil || req.MissedRunWithinMinutes != nil {
+			missedRunPolicy := existingRoutine.MissedRunPolicy
+			if req.MissedRunPolicy != nil {
+				missedRunPolicy = *req.MissedRunPolicy
+			}
+			withinMinutes := existingRoutine.MissedRunWithinMinutes
+			if req.MissedRunWithinMinutes != nil {
+				withinMinutes = req.MissedRunWithinMinutes
+			}
+			if err := validateMissedRunPolicy(missedRunPolicy, withinMinutes); err != nil {
+				return err
+			}
+		}
+
+		// Process nested schedule from iOS and flatten to database columns
+		if req.Schedule != nil {
+			processScheduleUpdate(&req.UpdateRoutineInput, req.Schedule)
+		}
+		if req.ScheduleTime != nil || len(req.ScheduleWeekdays) > 0 || req.ScheduleMonth != nil || req.ScheduleDay != nil || req.Timezone != nil {
+			scheduleTime, timezone := "", ""
+			if req.ScheduleTime != nil {
+				scheduleTime = *req.ScheduleTime
+			}
+			if req.Timezone != nil {
+				timezone = *req.Timezone
+			}
+			// The day is checked against the month the routine will have after the update
+			month, day := existingRoutine.ScheduleMonth, existingRoutine.ScheduleDay
+			if req.ScheduleMonth != nil {
+				month = req.ScheduleMonth
+			}
+			if req.ScheduleDay != nil {
+				day = req.ScheduleDay
+			}
+			if err := validateSchedule(scheduleTime, req.ScheduleWeekdays, month, day, timezone); err != nil {
+				return err
+			}
+		}
+		scheduleType := existingRoutine.ScheduleType
+		if req.ScheduleType != nil {
+			scheduleType = *req.ScheduleType
+		}
+		if scheduleType == ScheduleTypeInterval {
+			intervalDays := existingRoutine.ScheduleIntervalDays
+			if req.ScheduleIntervalDays != nil {
+				intervalDays = req.ScheduleIntervalDays
+			}
+			anchorDate := existingRoutine.ScheduleAnchorDate
+			if req.ScheduleAnchorDate != nil {
+				anchorDate = req.ScheduleAnchorDate
+			}
+			if err := validateIntervalSchedule(intervalDays, anchorDate); err != nil {
+				return err
+			}
+		}
+		// Zero clears the duration on update
+		if req.DurationMinutes != nil && *req.DurationMinutes != 0 {
+			if err := validateDurationMinutes(*req.DurationMinutes); err != nil {
+				return err
+			}
+		}
+		if err := validateRetryPolicy(req.MaxAttempts, req.RetryBackoffSeconds); err != nil {
+			return err
+		}
+		if req.HolidayBehavior != nil || req.HolidayMusicSetID != nil {
+			holidayBehavior := existingRoutine.HolidayBehavior
+			if req.HolidayBehavior != nil {
+				holidayBehavior = *req.HolidayBehavior
+			}
+			holidayMusicSetID := existingRoutine.HolidayMusicSetID
+			if req.HolidayMusicSetID != nil {
+				holidayMusicSetID = req.HolidayMusicSetID
+			}
+			if err := validateHolidayMusicSet(musicService, holidayBehavior, holidayMusicSetID); err != nil {
+				return err
+			}
+		}
+		if req.ScheduleTimeMode != nil || req.ScheduleOffsetMinutes != nil {
+			timeMode := existingRoutine.ScheduleTimeMode
+			if req.ScheduleTimeMode != nil {
+				timeMode = *req.ScheduleTimeMode
+			}
+			if err := validateTimeMode(timeMode, req.ScheduleOffsetMinutes); err != nil {
+				return err
+			}
+		}
+		if req.GroupingMode != nil {
+			if err := validateGroupingMode(*req.GroupingMode); err != nil {
+				return err
+			}
+		}
+		if err := validateStaggerMs(req.StaggerMs); err != nil {
+			return err
+		}
+
 		// If speakers are provided, update the scene members
+		var sceneUpdate *scene.UpdateSceneInput
 		if len(req.Speakers) > 0 {
 			// Convert SpeakerInput to SceneMember
 			members := make([]scene.SceneMember, len(req.Speakers))
@@ -283,33 +953,40 @@ func updateRoutine(routinesRepo *RoutinesRepository, sceneService *scene.Service
 				members[i] = scene.SceneMember{
 					UDN:          s.UDN,
 					TargetVolume: &vol,
+					FallbackUDN:  s.FallbackUDN,
+					FadeInMs:     s.FadeInMs,
+					FadeCurve:    s.FadeCurve,
 				}
 			}
-
-			// Update existing scene with new members
-			sceneID := existingRoutine.SceneID
-			if req.SceneID != nil {
-				sceneID = *req.SceneID
+			if err := validateSpeakerMembers(sceneService, members); err != nil {
+				return err
+			}
+			if err := validateSpeakerAudioSettings(req.Speakers); err != nil {
+				return err
 			}
 
-			_, err := sceneService.UpdateScene(sceneID, scene.UpdateSceneInput{
-				Members: members,
-			})
-			if err != nil {
-				log.Printf("Failed to update scene members: %v", err)
-				return apperrors.NewInternalError("Failed to update scene")
+			// Update existing scene with new members (together with the routine below)
+			sceneUpdate = &scene.UpdateSceneInput{
+				Members:      members,
+				GroupingMode: req.GroupingMode,
+				StaggerMs:    req.StaggerMs,
 			}
-			log.Printf("Updated scene %s members for routine %s", sceneID, routineID)
 
 			// Also convert speakers to internal format for storage
 			req.SpeakersJSON = make([]Speaker, len(req.Speakers))
 			for i, s := range req.Speakers {
 				vol := s.Volume
 				req.SpeakersJSON[i] = Speaker{
-					UDN:    s.UDN,
-					Volume: &vol,
+					UDN:           s.UDN,
+					Volume:        &vol,
+					FallbackUDN:   s.FallbackUDN,
+					FadeInMs:      s.FadeInMs,
+					FadeCurve:     s.FadeCurve,
+					AudioSettings: s.AudioSettings,
 				}
 			}
+		} else if req.GroupingMode != nil || req.StaggerMs != nil {
+			sceneUpdate = &scene.UpdateSceneInput{GroupingMode: req.GroupingMode, StaggerMs: req.StaggerMs}
 		}
 
 		// If scene_id is being updated, verify it exists
@@ -325,31 +1002,89 @@ func updateRoutine(routinesRepo *RoutinesRepository, sceneService *scene.Service
 
 		// Process nested music_policy from iOS and flatten to database columns
 		if req.MusicPolicy != nil {
+			if err := validateLocalFileContent(req.MusicPolicy); err != nil {
+				return err
+			}
+			localizeFavoriteArtwork(musicService, req.MusicPolicy)
 			processMusicPolicyUpdate(&req.UpdateRoutineInput, req.MusicPolicy)
 		}
-
-		// Process nested schedule from iOS and flatten to database columns
-		if req.Schedule != nil {
-			processScheduleUpdate(&req.UpdateRoutineInput, req.Schedule)
+		if err := validateMusicSets(musicService, req.MusicSets); err != nil {
+			return err
+		}
+		if req.MusicNoRepeatScope != nil {
+			if err := validateNoRepeatScope(*req.MusicNoRepeatScope); err != nil {
+				return err
+			}
+		}
+		if err := validatePlayMode(req.MusicPlayMode); err != nil {
+			return err
+		}
+		if err := validateSleepTimerMinutes(req.SleepTimerMinutes); err != nil {
+			return err
+		}
+		if req.MusicPolicyType != nil || req.Actions != nil {
+			if err := prepareActionsUpdate(existingRoutine, &req.UpdateRoutineInput); err != nil {
+				return err
+			}
+		}
+		if req.WakeProfile != nil || req.MusicPolicyType != nil {
+			wakeProfile, policyType := existingRoutine.WakeProfile, existingRoutine.MusicPolicyType
+			if req.WakeProfile != nil {
+				wakeProfile = req.WakeProfile
+			}
+			if req.MusicPolicyType != nil {
+				policyType = *req.MusicPolicyType
+			}
+			if err := validateWakeProfile(wakeProfile, policyType); err != nil {
+				return err
+			}
 		}
 
-		routine, err := routinesRepo.Update(routineID, req.UpdateRoutineInput)
+		var routine *Routine
+		if sceneUpdate != nil {
+			// The scene and routine are updated in one transaction, so a failure can't
+			// leave the scene's speakers out of step with the routine's
+			sceneID := existingRoutine.SceneID
+			if req.SceneID != nil {
+				sceneID = *req.SceneID
+			}
+			sceneFailed := false
+			routine, err = routinesRepo.UpdateWithScene(routineID, req.UpdateRoutineInput, func(tx *sql.Tx) error {
+				if _, err := sceneService.UpdateSceneTx(tx, sceneID, *sceneUpdate); err != nil {
+					sceneFailed = true
+					return err
+				}
+				return nil
+			})
+			if sceneFailed {
+				logging.From(r.Context(), nil).Error("Failed to update scene for routine", "scene_id", sceneID, "routine_id", routineID, "error", err)
+				return apperrors.NewInternalError("Failed to update scene")
+			}
+			if err == nil && routine != nil {
+				logging.From(r.Context(), nil).Info("Updated scene for routine", "scene_id", sceneID, "routine_id", routineID)
+			}
+		} else {
+			routine, err = routinesRepo.Update(routineID, req.UpdateRoutineInput)
+		}
 		if err != nil {
 			return apperrors.NewInternalError("Failed to update routine")
 		}
 		if routine == nil {
 			return apperrors.NewAppError(apperrors.ErrorCodeRoutineNotFound, "Routine not found", 404, map[string]any{"routine_id": routineID}, nil)
 		}
+		recordRoutineChange(r, recorder, audit.EventRoutineUpdated, "update", existingRoutine, routine)
 
 		// Build device room map for speaker enrichment
 		deviceRoomMap := buildDeviceRoomMap(deviceService)
 
-		// Stripe-style: return resource directly
-		return api.WriteResource(w, http.StatusOK, formatRoutineWithEnrichment(routine, deviceRoomMap, musicService))
+		// Stripe-style: return resource directly, with any schedule conflicts as warnings
+		result := formatRoutineWithEnrichment(routine, deviceRoomMap, musicService, nextRuns, localTZ)
+		result["warnings"] = routineConflictWarnings(r, routine, routinesRepo, sceneService, nextRuns, deviceRoomMap)
+		return api.WriteResource(w, http.StatusOK, result)
 	}
 }
 
-func deleteRoutine(routinesRepo *RoutinesRepository, sceneService *scene.Service) func(w http.ResponseWriter, r *http.Request) error {
+func deleteRoutine(routinesRepo *RoutinesRepository, sceneService *scene.Service, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		routineID := chi.URLParam(r, "routine_id")
 
@@ -362,7 +1097,7 @@ func deleteRoutine(routinesRepo *RoutinesRepository, sceneService *scene.Service
 			return apperrors.NewAppError(apperrors.ErrorCodeRoutineNotFound, "Routine not found", 404, map[string]any{"routine_id": routineID}, nil)
 		}
 
-		// Delete routine first (jobs deleted via CASCADE, play_history set to NULL via CASCADE)
+		// Soft-delete the routine first; its jobs and history stay until the purge hard-deletes it
 		err = routinesRepo.Delete(routineID)
 		if err != nil {
 			if err == sql.ErrNoRows {
@@ -370,6 +1105,7 @@ func deleteRoutine(routinesRepo *RoutinesRepository, sceneService *scene.Service
 			}
 			return apperrors.NewInternalError("Failed to delete routine")
 		}
+		recordRoutineChange(r, recorder, audit.EventRoutineDeleted, "delete", routine, nil)
 
 		// Delete the associated scene (ignore errors, scene may already be deleted)
 		if routine.SceneID != "" && sceneService != nil {
@@ -382,8 +1118,13 @@ func deleteRoutine(routinesRepo *RoutinesRepository, sceneService *scene.Service
 	}
 }
 
-func restoreRoutine(routinesRepo *RoutinesRepository, sceneService *scene.Service, deviceService *devices.Service, musicService *music.Service) func(w http.ResponseWriter, r *http.Request) error {
+func restoreRoutine(routinesRepo *RoutinesRepository, sceneService *scene.Service, deviceService *devices.Service, musicService *music.Service, nextRuns *JobGenerator, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
+		localTZ, err := parseLocalTimeZone(r)
+		if err != nil {
+			return err
+		}
+
 		routineID := chi.URLParam(r, "routine_id")
 
 		// Check if routine exists and get its deletion state
@@ -408,6 +1149,7 @@ func restoreRoutine(routinesRepo *RoutinesRepository, sceneService *scene.Servic
 			}
 			return apperrors.NewInternalError("Failed to restore routine")
 		}
+		recordRoutineChange(r, recorder, audit.EventRoutineUpdated, "restore", routine, restoredRoutine)
 
 		// Also restore the associated scene
 		if restoredRoutine.SceneID != "" && sceneService != nil {
@@ -417,17 +1159,23 @@ func restoreRoutine(routinesRepo *RoutinesRepository, sceneService *scene.Servic
 		// Build device room map for speaker enrichment
 		deviceRoomMap := buildDeviceRoomMap(deviceService)
 
-		log.Printf("Restored routine %s and scene %s", routineID, restoredRoutine.SceneID)
+		logging.From(r.Context(), nil).Info("Restored routine and scene", "routine_id", routineID, "scene_id", restoredRoutine.SceneID)
 
 		// Stripe-style: return resource directly
-		return api.WriteResource(w, http.StatusOK, formatRoutineWithEnrichment(restoredRoutine, deviceRoomMap, musicService))
+		return api.WriteResource(w, http.StatusOK, formatRoutineWithEnrichment(restoredRoutine, deviceRoomMap, musicService, nextRuns, localTZ))
 	}
 }
 
-func enableRoutine(routinesRepo *RoutinesRepository, deviceService *devices.Service, musicService *music.Service) func(w http.ResponseWriter, r *http.Request) error {
+func enableRoutine(routinesRepo *RoutinesRepository, deviceService *devices.Service, musicService *music.Service, nextRuns *JobGenerator, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
+		localTZ, err := parseLocalTimeZone(r)
+		if err != nil {
+			return err
+		}
+
 		routineID := chi.URLParam(r, "routine_id")
 
+		before := routineBeforeChange(routinesRepo, recorder, routineID)
 		enabled := true
 		routine, err := routinesRepo.Update(routineID, UpdateRoutineInput{Enabled: &enabled})
 		if err != nil {
@@ -436,19 +1184,26 @@ func enableRoutine(routinesRepo *RoutinesRepository, deviceService *devices.Serv
 		if routine == nil {
 			return apperrors.NewAppError(apperrors.ErrorCodeRoutineNotFound, "Routine not found", 404, map[string]any{"routine_id": routineID}, nil)
 		}
+		recordRoutineChange(r, recorder, audit.EventRoutineUpdated, "enable", before, routine)
 
 		// Build device room map for speaker enrichment
 		deviceRoomMap := buildDeviceRoomMap(deviceService)
 
 		// Stripe-style: return resource directly
-		return api.WriteResource(w, http.StatusOK, formatRoutineWithEnrichment(routine, deviceRoomMap, musicService))
+		return api.WriteResource(w, http.StatusOK, formatRoutineWithEnrichment(routine, deviceRoomMap, musicService, nextRuns, localTZ))
 	}
 }
 
-func disableRoutine(routinesRepo *RoutinesRepository, deviceService *devices.Service, musicService *music.Service) func(w http.ResponseWriter, r *http.Request) error {
+func disableRoutine(routinesRepo *RoutinesRepository, deviceService *devices.Service, musicService *music.Service, nextRuns *JobGenerator, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
+		localTZ, err := parseLocalTimeZone(r)
+		if err != nil {
+			return err
+		}
+
 		routineID := chi.URLParam(r, "routine_id")
 
+		before := routineBeforeChange(routinesRepo, recorder, routineID)
 		enabled := false
 		routine, err := routinesRepo.Update(routineID, UpdateRoutineInput{Enabled: &enabled})
 		if err != nil {
@@ -457,16 +1212,17 @@ func disableRoutine(routinesRepo *RoutinesRepository, deviceService *devices.Ser
 		if routine == nil {
 			return apperrors.NewAppError(apperrors.ErrorCodeRoutineNotFound, "Routine not found", 404, map[string]any{"routine_id": routineID}, nil)
 		}
+		recordRoutineChange(r, recorder, audit.EventRoutineUpdated, "disable", before, routine)
 
 		// Build device room map for speaker enrichment
 		deviceRoomMap := buildDeviceRoomMap(deviceService)
 
 		// Stripe-style: return resource directly
-		return api.WriteResource(w, http.StatusOK, formatRoutineWithEnrichment(routine, deviceRoomMap, musicService))
+		return api.WriteResource(w, http.StatusOK, formatRoutineWithEnrichment(routine, deviceRoomMap, musicService, nextRuns, localTZ))
 	}
 }
 
-func triggerRoutine(routinesRepo *RoutinesRepository, jobsRepo *JobsRepository) func(w http.ResponseWriter, r *http.Request) error {
+func triggerRoutine(routinesRepo *RoutinesRepository, jobsRepo *JobsRepository, triggerCooldown *TriggerCooldown) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		routineID := chi.URLParam(r, "routine_id")
 
@@ -479,27 +1235,56 @@ func triggerRoutine(routinesRepo *RoutinesRepository, jobsRepo *JobsRepository)
 			return apperrors.NewAppError(apperrors.ErrorCodeRoutineNotFound, "Routine not found", 404, map[string]any{"routine_id": routineID}, nil)
 		}
 
+		if err := acquireTriggerCooldown(w, triggerCooldown, routineID); err != nil {
+			return err
+		}
+
 		// Create a job scheduled for now (immediate execution)
 		job, err := jobsRepo.Create(CreateJobInput{
 			RoutineID:    routineID,
 			ScheduledFor: time.Now().UTC(),
+			Manual:       true,
 		})
 		if err != nil {
+			triggerCooldown.Release(routineID)
 			return apperrors.NewInternalError("Failed to create job")
 		}
 
 		// Stripe-style: return resource directly
-		return api.WriteResource(w, http.StatusAccepted, formatJob(job))
+		return api.WriteResource(w, http.StatusAccepted, formatJob(job, routine))
 	}
 }
 
+// acquireTriggerCooldown rejects a manual trigger/run with 429 and Retry-After
+// when the routine was triggered within the cooldown window.
+func acquireTriggerCooldown(w http.ResponseWriter, triggerCooldown *TriggerCooldown, routineID string) error {
+	remaining, ok := triggerCooldown.Acquire(routineID)
+	if ok {
+		return nil
+	}
+	retryAfter := int(math.Ceil(remaining.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	return apperrors.NewAppError(apperrors.ErrorCodeRateLimited, "Routine was triggered too recently", http.StatusTooManyRequests, map[string]any{
+		"routine_id":          routineID,
+		"retry_after_seconds": retryAfter,
+	}, nil)
+}
+
 // SnoozeInput represents the request body for snoozing a routine.
+// Exactly one of Until, DurationMinutes, or Preset is set.
 type SnoozeInput struct {
-	Until time.Time `json:"until"`
+	Until           time.Time    `json:"until"`
+	DurationMinutes *int         `json:"duration_minutes,omitempty"`
+	Preset          SnoozePreset `json:"preset,omitempty"`
 }
 
-func snoozeRoutine(routinesRepo *RoutinesRepository, deviceService *devices.Service, musicService *music.Service) func(w http.ResponseWriter, r *http.Request) error {
+func snoozeRoutine(routinesRepo *RoutinesRepository, deviceService *devices.Service, musicService *music.Service, nextRuns *JobGenerator, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
+		localTZ, err := parseLocalTimeZone(r)
+		if err != nil {
+			return err
+		}
+
 		routineID := chi.URLParam(r, "routine_id")
 
 		var input SnoozeInput
@@ -507,37 +1292,77 @@ func snoozeRoutine(routinesRepo *RoutinesRepository, deviceService *devices.Serv
 			return apperrors.NewValidationError("invalid request body", nil)
 		}
 
-		// Validate snooze time is in the future
-		if input.Until.IsZero() {
-			return apperrors.NewValidationError("until time is required", nil)
+		given := 0
+		for _, set := range []bool{!input.Until.IsZero(), input.DurationMinutes != nil, input.Preset != ""} {
+			if set {
+				given++
+			}
+		}
+		if given == 0 {
+			return apperrors.NewValidationError("one of until, duration_minutes, or preset is required", nil)
+		}
+		if given > 1 {
+			return apperrors.NewValidationError("only one of until, duration_minutes, or preset may be given", nil)
 		}
-		if input.Until.Before(time.Now()) {
+		if input.DurationMinutes != nil && (*input.DurationMinutes < 1 || *input.DurationMinutes > MaxSnoozeDurationMinutes) {
+			return apperrors.NewValidationError("duration_minutes must be between 1 and "+strconv.Itoa(MaxSnoozeDurationMinutes), map[string]any{"duration_minutes": *input.DurationMinutes})
+		}
+		if input.Preset != "" && input.Preset != SnoozePresetUntilTomorrow {
+			return apperrors.NewValidationError("preset must be until_tomorrow", map[string]any{"preset": string(input.Preset)})
+		}
+
+		// Validate snooze time is in the future
+		now := time.Now()
+		if !input.Until.IsZero() && input.Until.Before(now) {
 			return apperrors.NewValidationError("until time must be in the future", map[string]any{
 				"until":   input.Until.Format(time.RFC3339),
-				"current": time.Now().UTC().Format(time.RFC3339),
+				"current": now.UTC().Format(time.RFC3339),
 			})
 		}
 
-		routine, err := routinesRepo.Update(routineID, UpdateRoutineInput{SnoozeUntil: &input.Until})
+		existing, err := routinesRepo.GetByID(routineID)
+		if err != nil {
+			return apperrors.NewInternalError("Failed to snooze routine")
+		}
+		if existing == nil {
+			return apperrors.NewAppError(apperrors.ErrorCodeRoutineNotFound, "Routine not found", 404, map[string]any{"routine_id": routineID}, nil)
+		}
+
+		until := input.Until
+		switch {
+		case input.DurationMinutes != nil:
+			until = now.Add(time.Duration(*input.DurationMinutes) * time.Minute)
+		case input.Preset == SnoozePresetUntilTomorrow:
+			until = nextRuns.SnoozeUntilTomorrow(existing, now)
+		}
+
+		routine, err := routinesRepo.Update(routineID, UpdateRoutineInput{SnoozeUntil: &until})
 		if err != nil {
 			return apperrors.NewInternalError("Failed to snooze routine")
 		}
 		if routine == nil {
 			return apperrors.NewAppError(apperrors.ErrorCodeRoutineNotFound, "Routine not found", 404, map[string]any{"routine_id": routineID}, nil)
 		}
+		recordRoutineChange(r, recorder, audit.EventRoutineUpdated, "snooze", existing, routine)
 
 		// Build device room map for speaker enrichment
 		deviceRoomMap := buildDeviceRoomMap(deviceService)
 
 		// Stripe-style: return resource directly
-		return api.WriteResource(w, http.StatusOK, formatRoutineWithEnrichment(routine, deviceRoomMap, musicService))
+		return api.WriteResource(w, http.StatusOK, formatRoutineWithEnrichment(routine, deviceRoomMap, musicService, nextRuns, localTZ))
 	}
 }
 
-func unsnoozeRoutine(routinesRepo *RoutinesRepository, deviceService *devices.Service, musicService *music.Service) func(w http.ResponseWriter, r *http.Request) error {
+func unsnoozeRoutine(routinesRepo *RoutinesRepository, deviceService *devices.Service, musicService *music.Service, nextRuns *JobGenerator, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
+		localTZ, err := parseLocalTimeZone(r)
+		if err != nil {
+			return err
+		}
+
 		routineID := chi.URLParam(r, "routine_id")
 
+		before := routineBeforeChange(routinesRepo, recorder, routineID)
 		routine, err := routinesRepo.ClearSnooze(routineID)
 		if err != nil {
 			return apperrors.NewInternalError("Failed to unsnooze routine")
@@ -545,19 +1370,26 @@ func unsnoozeRoutine(routinesRepo *RoutinesRepository, deviceService *devices.Se
 		if routine == nil {
 			return apperrors.NewAppError(apperrors.ErrorCodeRoutineNotFound, "Routine not found", 404, map[string]any{"routine_id": routineID}, nil)
 		}
+		recordRoutineChange(r, recorder, audit.EventRoutineUpdated, "unsnooze", before, routine)
 
 		// Build device room map for speaker enrichment
 		deviceRoomMap := buildDeviceRoomMap(deviceService)
 
 		// Stripe-style: return resource directly
-		return api.WriteResource(w, http.StatusOK, formatRoutineWithEnrichment(routine, deviceRoomMap, musicService))
+		return api.WriteResource(w, http.StatusOK, formatRoutineWithEnrichment(routine, deviceRoomMap, musicService, nextRuns, localTZ))
 	}
 }
 
-func skipNextOccurrence(routinesRepo *RoutinesRepository, deviceService *devices.Service, musicService *music.Service) func(w http.ResponseWriter, r *http.Request) error {
-	return func(w http.ResponseWriter, r *http.Request) error {
+func skipNextOccurrence(routinesRepo *RoutinesRepository, deviceService *devices.Service, musicService *music.Service, nextRuns *JobGenerator, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		localTZ, err := parseLocalTimeZone(r)
+		if err != nil {
+			return err
+		}
+
 		routineID := chi.URLParam(r, "routine_id")
 
+		before := routineBeforeChange(routinesRepo, recorder, routineID)
 		skipNext := true
 		routine, err := routinesRepo.Update(routineID, UpdateRoutineInput{SkipNext: &skipNext})
 		if err != nil {
@@ -566,12 +1398,13 @@ func skipNextOccurrence(routinesRepo *RoutinesRepository, deviceService *devices
 		if routine == nil {
 			return apperrors.NewAppError(apperrors.ErrorCodeRoutineNotFound, "Routine not found", 404, map[string]any{"routine_id": routineID}, nil)
 		}
+		recordRoutineChange(r, recorder, audit.EventRoutineUpdated, "skip", before, routine)
 
 		// Build device room map for speaker enrichment
 		deviceRoomMap := buildDeviceRoomMap(deviceService)
 
 		// Stripe-style: return resource directly
-		return api.WriteResource(w, http.StatusOK, formatRoutineWithEnrichment(routine, deviceRoomMap, musicService))
+		return api.WriteResource(w, http.StatusOK, formatRoutineWithEnrichment(routine, deviceRoomMap, musicService, nextRuns, localTZ))
 	}
 }
 
@@ -579,7 +1412,7 @@ func skipNextOccurrence(routinesRepo *RoutinesRepository, deviceService *devices
 // Job Handlers
 // ==========================================================================
 
-func getJob(jobsRepo *JobsRepository) func(w http.ResponseWriter, r *http.Request) error {
+func getJob(routinesRepo *RoutinesRepository, jobsRepo *JobsRepository) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		jobID := chi.URLParam(r, "job_id")
 
@@ -591,11 +1424,113 @@ func getJob(jobsRepo *JobsRepository) func(w http.ResponseWriter, r *http.Reques
 			return apperrors.NewAppError(apperrors.ErrorCodeJobNotFound, "Job not found", 404, map[string]any{"job_id": jobID}, nil)
 		}
 
+		// The routine supplies the retry policy; a deleted routine just omits it
+		routine, err := routinesRepo.GetByID(job.RoutineID)
+		if err != nil {
+			return apperrors.NewInternalError("Failed to get routine")
+		}
+
 		// Stripe-style: return resource directly
-		return api.WriteResource(w, http.StatusOK, formatJob(job))
+		return api.WriteResource(w, http.StatusOK, formatJob(job, routine))
+	}
+}
+
+// getJobLog handles GET /v1/jobs/{job_id}/log
+// Returns the job's persisted step log plus the steps of the scene execution it started
+// (reachability, grouping, volume, playback, verification), so a failure can be traced
+// to the exact step.
+func getJobLog(jobsRepo *JobsRepository, sceneService *scene.Service) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		jobID := chi.URLParam(r, "job_id")
+
+		job, err := jobsRepo.GetByID(jobID)
+		if err != nil {
+			return apperrors.NewInternalError("Failed to get job")
+		}
+		if job == nil {
+			return apperrors.NewAppError(apperrors.ErrorCodeJobNotFound, "Job not found", 404, map[string]any{"job_id": jobID}, nil)
+		}
+
+		entries, err := jobsRepo.GetJobLog(jobID)
+		if err != nil {
+			return apperrors.NewInternalError("Failed to get job log")
+		}
+
+		steps := make([]map[string]any, 0, len(entries))
+		for _, entry := range entries {
+			step := map[string]any{
+				"attempt":     entry.Attempt,
+				"step":        entry.Step,
+				"status":      string(entry.Status),
+				"started_at":  api.RFC3339Millis(entry.StartedAt),
+				"duration_ms": entry.DurationMs,
+				"error":       nil,
+			}
+			if entry.Error != "" {
+				step["error"] = entry.Error
+			}
+			steps = append(steps, step)
+		}
+
+		response := map[string]any{
+			"object":          "job_log",
+			"job_id":          job.JobID,
+			"routine_id":      job.RoutineID,
+			"status":          string(job.Status),
+			"attempts":        job.Attempts,
+			"steps":           steps,
+			"scene_execution": nil,
+		}
+
+		if job.SceneExecutionID != nil && sceneService != nil {
+			execution, err := sceneService.GetExecution(*job.SceneExecutionID)
+			if err != nil {
+				return apperrors.NewInternalError("Failed to get scene execution")
+			}
+			if execution != nil {
+				response["scene_execution"] = formatJobLogSceneExecution(execution)
+			}
+		}
+
+		return api.WriteResource(w, http.StatusOK, response)
 	}
 }
 
+// formatJobLogSceneExecution formats a scene execution's steps for the job log.
+func formatJobLogSceneExecution(execution *scene.SceneExecution) map[string]any {
+	steps := make([]map[string]any, 0, len(execution.Steps))
+	for _, s := range execution.Steps {
+		step := map[string]any{
+			"step":        s.Step,
+			"status":      string(s.Status),
+			"started_at":  nil,
+			"duration_ms": nil,
+			"error":       nil,
+		}
+		if s.StartedAt != nil {
+			step["started_at"] = api.RFC3339Millis(*s.StartedAt)
+			if s.EndedAt != nil {
+				step["duration_ms"] = s.EndedAt.Sub(*s.StartedAt).Milliseconds()
+			}
+		}
+		if s.Error != "" {
+			step["error"] = s.Error
+		}
+		steps = append(steps, step)
+	}
+
+	result := map[string]any{
+		"scene_execution_id": execution.SceneExecutionID,
+		"status":             string(execution.Status),
+		"error":              nil,
+		"steps":              steps,
+	}
+	if execution.Error != nil {
+		result["error"] = *execution.Error
+	}
+	return result
+}
+
 func listJobsForRoutine(routinesRepo *RoutinesRepository, jobsRepo *JobsRepository) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		routineID := chi.URLParam(r, "routine_id")
@@ -630,7 +1565,7 @@ func listJobsForRoutine(routinesRepo *RoutinesRepository, jobsRepo *JobsReposito
 
 		formatted := make([]map[string]any, 0, len(jobs))
 		for _, job := range jobs {
-			formatted = append(formatted, formatJob(&job))
+			formatted = append(formatted, formatJob(&job, routine))
 		}
 
 		hasMore := offset+len(jobs) < total
@@ -672,9 +1607,10 @@ func createHoliday(holidaysRepo *HolidaysRepository) func(w http.ResponseWriter,
 		}
 
 		holiday, err := holidaysRepo.Create(CreateHolidayInput{
-			Date:     date,
-			Name:     input.Name,
-			IsCustom: input.IsCustom,
+			Date:      date,
+			Name:      input.Name,
+			IsCustom:  input.IsCustom,
+			Recurring: input.Recurring,
 		})
 		if err != nil {
 			return apperrors.NewInternalError("Failed to create holiday")
@@ -685,6 +1621,55 @@ func createHoliday(holidaysRepo *HolidaysRepository) func(w http.ResponseWriter,
 	}
 }
 
+// ImportHolidaysAPIInput represents the request body for importing a holiday calendar.
+type ImportHolidaysAPIInput struct {
+	Country string `json:"country"`
+	Year    int    `json:"year"`
+}
+
+// importHolidays seeds the holidays table from a built-in national calendar.
+func importHolidays(holidaysRepo *HolidaysRepository) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var input ImportHolidaysAPIInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			return apperrors.NewValidationError("invalid request body", nil)
+		}
+
+		if input.Country == "" {
+			return apperrors.NewValidationError("country is required", nil)
+		}
+		provider, ok := HolidayProviderFor(input.Country)
+		if !ok {
+			return apperrors.NewValidationError("unsupported country", map[string]any{
+				"country":   input.Country,
+				"supported": HolidayCountries(),
+			})
+		}
+		if input.Year < 1900 || input.Year > 2100 {
+			return apperrors.NewValidationError("year must be between 1900 and 2100", map[string]any{"year": input.Year})
+		}
+
+		created, skipped, err := holidaysRepo.Import(provider.Holidays(input.Year))
+		if err != nil {
+			return apperrors.NewInternalError("Failed to import holidays")
+		}
+
+		formatted := make([]map[string]any, 0, len(created))
+		for _, holiday := range created {
+			formatted = append(formatted, formatHoliday(&holiday))
+		}
+
+		return api.WriteAction(w, http.StatusOK, map[string]any{
+			"object":        "holiday_import",
+			"country":       provider.Country(),
+			"year":          input.Year,
+			"created_count": len(created),
+			"skipped_count": skipped,
+			"created":       formatted,
+		})
+	}
+}
+
 func listHolidays(holidaysRepo *HolidaysRepository) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		limit := 100
@@ -784,6 +1769,49 @@ func checkHoliday(holidaysRepo *HolidaysRepository) func(w http.ResponseWriter,
 	}
 }
 
+// holidayImpact lists the enabled routines a holiday on the given date would skip.
+// Read-only: it does not require the date to already be a holiday.
+func holidayImpact(routinesRepo *RoutinesRepository) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		dateStr := r.URL.Query().Get("date")
+		if dateStr == "" {
+			return apperrors.NewValidationError("date query parameter is required", nil)
+		}
+
+		date, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			return apperrors.NewValidationError("invalid date format, expected YYYY-MM-DD", map[string]any{"date": dateStr})
+		}
+
+		routines, _, err := routinesRepo.List(1000, 0, true) // Get all enabled routines
+		if err != nil {
+			return apperrors.NewInternalError("Failed to list routines")
+		}
+
+		affected := make([]map[string]any, 0)
+		for i := range routines {
+			routine := &routines[i]
+			if !SkipsOnHoliday(routine) || !ScheduledOnDate(routine, date) {
+				continue
+			}
+			affected = append(affected, map[string]any{
+				"id":            routine.RoutineID,
+				"name":          routine.Name,
+				"schedule_type": string(routine.ScheduleType),
+				"schedule_time": routine.ScheduleTime,
+				"timezone":      routine.Timezone,
+			})
+		}
+
+		return api.WriteAction(w, http.StatusOK, map[string]any{
+			"object":           "holiday_impact",
+			"date":             dateStr,
+			"skipped_routines": affected,
+			"total_skipped":    len(affected),
+		})
+	}
+}
+
 // ==========================================================================
 // Formatters
 // ==========================================================================
@@ -803,6 +1831,18 @@ func formatRoutineWithDeviceMap(routine *Routine, deviceRoomMap map[string]strin
 		"occasions_enabled": routine.OccasionsEnabled,
 		"created_at":        api.RFC3339Millis(routine.CreatedAt),
 		"updated_at":        api.RFC3339Millis(routine.UpdatedAt),
+
+		"missed_run_policy":         string(routine.MissedRunPolicy),
+		"missed_run_within_minutes": routine.MissedRunWithinMinutes,
+
+		"max_attempts":          routineMaxAttempts(routine),
+		"retry_backoff_seconds": routine.RetryBackoffSeconds,
+
+		"holiday_music_set_id": routine.HolidayMusicSetID,
+
+		"restore_previous_state": routine.RestorePreviousState,
+		"sleep_timer_minutes":    routine.SleepTimerMinutes,
+		"wake_profile":           routine.WakeProfile,
 	}
 
 	// Build nested schedule object (iOS expected format)
@@ -822,11 +1862,33 @@ func formatRoutineWithDeviceMap(routine *Routine, deviceRoomMap map[string]strin
 	if routine.ScheduleDay != nil {
 		schedule["day"] = *routine.ScheduleDay
 	}
+	if routine.ScheduleIntervalDays != nil {
+		schedule["interval_days"] = *routine.ScheduleIntervalDays
+	}
+	if routine.ScheduleAnchorDate != nil {
+		schedule["anchor_date"] = *routine.ScheduleAnchorDate
+	}
+	if routine.DurationMinutes != nil {
+		schedule["duration_minutes"] = *routine.DurationMinutes
+	}
+	if routine.ScheduleTimeMode.IsSolar() {
+		schedule["time_mode"] = string(routine.ScheduleTimeMode)
+		schedule["offset_minutes"] = routine.ScheduleOffsetMinutes
+	} else {
+		schedule["time_mode"] = string(TimeModeFixed)
+	}
 	result["schedule"] = schedule
 
+	// Routines that play nothing have no music_policy, just their actions
+	actions := routine.Actions
+	if actions == nil {
+		actions = []RoutineAction{}
+	}
+	result["actions"] = actions
+
 	// Build nested music_policy object (iOS expected format)
 	// Node.js only includes sonos_favorite_* and music_content for FIXED policy
-	if routine.MusicPolicyType != "" {
+	if routine.MusicPolicyType != "" && routine.MusicPolicyType != MusicPolicyTypeNone {
 		musicPolicy := map[string]any{
 			"type": string(routine.MusicPolicyType),
 		}
@@ -872,10 +1934,10 @@ func formatRoutineWithDeviceMap(routine *Routine, deviceRoomMap map[string]strin
 						}
 					}
 
-					// Only include music_content for direct type (not sonos_favorite)
+					// Only include music_content for direct and local_file types (not sonos_favorite)
 					// iOS DirectMusicContent struct requires service, content_type, content_id fields
 					// which sonos_favorite doesn't have - it uses the extracted metadata fields above
-					if contentType == "direct" {
+					if contentType == "direct" || contentType == "local_file" {
 						// Transform camelCase keys to snake_case for API response
 						normalized := make(map[string]any)
 						for k, v := range content {
@@ -885,7 +1947,7 @@ func formatRoutineWithDeviceMap(routine *Routine, deviceRoomMap map[string]strin
 							case "contentId":
 								normalized["content_id"] = v
 							case "artworkUrl":
-								normalized["artwork_url"] = v
+								normalized["artwork_url"] = rewriteArtworkValue(v)
 							case "favoriteId":
 								normalized["favorite_id"] = v
 							case "serviceLogoUrl":
@@ -902,7 +1964,7 @@ func formatRoutineWithDeviceMap(routine *Routine, deviceRoomMap map[string]strin
 			}
 
 			musicPolicy["sonos_favorite_name"] = sonosFavoriteName
-			musicPolicy["sonos_favorite_artwork_url"] = sonosFavoriteArtworkUrl
+			musicPolicy["sonos_favorite_artwork_url"] = rewriteArtworkValue(sonosFavoriteArtworkUrl)
 			musicPolicy["sonos_favorite_service_logo_url"] = sonosFavoriteServiceLogoUrl
 			musicPolicy["sonos_favorite_service_name"] = sonosFavoriteServiceName
 			musicPolicy["music_content"] = musicContentForAPI
@@ -926,6 +1988,18 @@ func formatRoutineWithDeviceMap(routine *Routine, deviceRoomMap map[string]strin
 			} else {
 				musicPolicy["no_repeat_window_minutes"] = nil
 			}
+			sets := []map[string]any{}
+			for _, set := range routine.WeightedMusicSets() {
+				sets = append(sets, map[string]any{"set_id": set.SetID, "weight": set.Weight})
+			}
+			musicPolicy["sets"] = sets
+			musicPolicy["no_repeat_scope"] = string(routine.MusicNoRepeatScope)
+		}
+
+		if routine.MusicPlayMode != nil {
+			musicPolicy["play_mode"] = routine.MusicPlayMode
+		} else {
+			musicPolicy["play_mode"] = nil
 		}
 
 		result["music_policy"] = musicPolicy
@@ -955,7 +2029,7 @@ func formatRoutineWithDeviceMap(routine *Routine, deviceRoomMap map[string]strin
 					artworkUrl, _ := content["artworkUrl"].(string)
 					var artworkUrlVal any = nil
 					if artworkUrl != "" {
-						artworkUrlVal = artworkUrl
+						artworkUrlVal = artwork.RewriteURL(artworkUrl)
 					}
 					musicSetValue = map[string]any{
 						"name":             title,
@@ -963,6 +2037,14 @@ func formatRoutineWithDeviceMap(routine *Routine, deviceRoomMap map[string]strin
 						"service_logo_url": nil,
 						"service_name":     serviceName,
 					}
+				} else if contentType == "local_file" {
+					title, _ := content["title"].(string)
+					musicSetValue = map[string]any{
+						"name":             title,
+						"artwork_url":      nil,
+						"service_logo_url": nil,
+						"service_name":     nil,
+					}
 				} else if contentType == "sonos_favorite" {
 					// Sonos favorite: use name and artworkUrl from content if present
 					name, nameOk := content["name"].(string)
@@ -975,7 +2057,7 @@ func formatRoutineWithDeviceMap(routine *Routine, deviceRoomMap map[string]strin
 							nameVal = name
 						}
 						if artworkOk && artworkUrl != "" {
-							artworkVal = artworkUrl
+							artworkVal = artwork.RewriteURL(artworkUrl)
 						}
 						if serviceLogoUrl, ok := content["serviceLogoUrl"].(string); ok && serviceLogoUrl != "" {
 							serviceLogoVal = serviceLogoUrl
@@ -1030,6 +2112,18 @@ func formatRoutineWithDeviceMap(routine *Routine, deviceRoomMap map[string]strin
 		} else {
 			speaker["volume"] = nil
 		}
+		if s.FallbackUDN != "" {
+			speaker["fallback_udn"] = s.FallbackUDN
+		}
+		if s.FadeInMs != nil {
+			speaker["fade_in_ms"] = *s.FadeInMs
+		}
+		if s.FadeCurve != "" {
+			speaker["fade_curve"] = s.FadeCurve
+		}
+		if s.AudioSettings != nil {
+			speaker["audio_settings"] = s.AudioSettings
+		}
 		// Add room_name from device registry lookup
 		if deviceRoomMap != nil {
 			if roomName, ok := deviceRoomMap[s.UDN]; ok {
@@ -1049,14 +2143,23 @@ func formatRoutineWithDeviceMap(routine *Routine, deviceRoomMap map[string]strin
 	if routine.NextRunAt != nil {
 		result["next_run_at"] = api.RFC3339Millis(*routine.NextRunAt)
 	}
+	if routine.DeletedAt != nil {
+		result["deleted_at"] = api.RFC3339Millis(*routine.DeletedAt)
+	}
 
 	return result
 }
 
 // formatRoutineWithEnrichment formats a routine with device and music set enrichment.
 // For ROTATION/SHUFFLE policies, fetches enrichment data from the music set to populate artwork.
-func formatRoutineWithEnrichment(routine *Routine, deviceRoomMap map[string]string, musicService *music.Service) map[string]any {
+// With a ?tz= option, *_local timestamp variants are added alongside the UTC fields.
+func formatRoutineWithEnrichment(routine *Routine, deviceRoomMap map[string]string, musicService *music.Service, nextRuns *JobGenerator, localTZ *localTimeZone) map[string]any {
+	// Deleted routines don't run
+	if routine.NextRunAt == nil && routine.DeletedAt == nil {
+		routine.NextRunAt = nextRuns.UpcomingRun(routine, time.Now())
+	}
 	result := formatRoutineWithDeviceMap(routine, deviceRoomMap)
+	localTZ.addLocalTimestamps(result, routine)
 
 	// For ROTATION/SHUFFLE policies, fetch enrichment from the music set
 	// This provides artwork_url from the first item in the set
@@ -1071,7 +2174,7 @@ func formatRoutineWithEnrichment(routine *Routine, deviceRoomMap map[string]stri
 					"service_name":     nil,
 				}
 				if enrichment.ArtworkURL != nil {
-					musicSet["artwork_url"] = *enrichment.ArtworkURL
+					musicSet["artwork_url"] = artwork.RewriteURL(*enrichment.ArtworkURL)
 				}
 				if enrichment.ServiceLogoURL != nil {
 					musicSet["service_logo_url"] = *enrichment.ServiceLogoURL
@@ -1086,17 +2189,40 @@ func formatRoutineWithEnrichment(routine *Routine, deviceRoomMap map[string]stri
 		} else {
 			result["music_set"] = nil
 		}
+		nameMusicSets(result, musicService)
 	}
 
 	return result
 }
 
+// nameMusicSets adds each set's name to the music_policy sets list. A set that no
+// longer exists gets a null name.
+func nameMusicSets(result map[string]any, musicService *music.Service) {
+	musicPolicy, ok := result["music_policy"].(map[string]any)
+	if !ok {
+		return
+	}
+	sets, _ := musicPolicy["sets"].([]map[string]any)
+	setIDs := make([]string, len(sets))
+	for i, entry := range sets {
+		setIDs[i] = entry["set_id"].(string)
+	}
+	names, _ := musicService.SetNames(setIDs)
+	for _, entry := range sets {
+		entry["name"] = nil
+		if name, ok := names[entry["set_id"].(string)]; ok {
+			entry["name"] = name
+		}
+	}
+}
+
 // formatRoutine is a convenience wrapper for formatRoutineWithDeviceMap without device enrichment.
 func formatRoutine(routine *Routine) map[string]any {
 	return formatRoutineWithDeviceMap(routine, nil)
 }
 
-func formatJob(job *Job) map[string]any {
+// formatJob formats a job. routine supplies the retry policy and may be nil.
+func formatJob(job *Job, routine *Routine) map[string]any {
 	result := map[string]any{
 		"object":        api.ObjectJob,
 		"id":            job.JobID,
@@ -1104,6 +2230,7 @@ func formatJob(job *Job) map[string]any {
 		"scheduled_for": api.RFC3339Millis(job.ScheduledFor),
 		"status":        string(job.Status),
 		"attempts":      job.Attempts,
+		"manual":        job.Manual,
 		"created_at":    api.RFC3339Millis(job.CreatedAt),
 		"updated_at":    api.RFC3339Millis(job.UpdatedAt),
 	}
@@ -1113,9 +2240,21 @@ func formatJob(job *Job) map[string]any {
 	}
 	if job.SceneExecutionID != nil {
 		result["scene_execution_id"] = *job.SceneExecutionID
+		// Per-speaker results of the run, for drilling into a partial failure
+		if routine != nil && routine.SceneID != "" {
+			result["scene_execution_url"] = "/v1/scenes/" + routine.SceneID + "/executions/" + *job.SceneExecutionID
+		}
 	}
 	if job.RetryAfter != nil {
 		result["retry_after"] = api.RFC3339Millis(*job.RetryAfter)
+		// Seconds until a pending retry becomes eligible, for "retrying in 2m"
+		if wait := time.Until(*job.RetryAfter); wait > 0 && job.Status == JobStatusPending {
+			result["retry_in_seconds"] = int(math.Ceil(wait.Seconds()))
+		}
+	}
+	if routine != nil {
+		result["max_attempts"] = routineMaxAttempts(routine)
+		result["retry_backoff_seconds"] = routine.RetryBackoffSeconds
 	}
 	if job.ClaimedAt != nil {
 		result["claimed_at"] = api.RFC3339Millis(*job.ClaimedAt)
@@ -1123,6 +2262,9 @@ func formatJob(job *Job) map[string]any {
 	if job.IdempotencyKey != nil {
 		result["idempotency_key"] = *job.IdempotencyKey
 	}
+	if job.MissedRunDecision != nil {
+		result["missed_run_decision"] = string(*job.MissedRunDecision)
+	}
 	if job.StartedAt != nil {
 		result["started_at"] = api.RFC3339Millis(*job.StartedAt)
 	}
@@ -1177,8 +2319,119 @@ func formatJobAsExecution(job *Job, routineNames map[string]string) map[string]a
 		"fallback_used":   false,
 	}
 
+	// Jobs completed before execution details were recorded keep the empty defaults
+	if detail := job.ExecutionDetail; detail != nil {
+		targetDevices := make([]string, 0, len(detail.Devices))
+		devices := make([]map[string]any, 0, len(detail.Devices))
+		for _, device := range detail.Devices {
+			name := device.RoomName
+			if name == "" {
+				name = device.UDN
+			}
+			targetDevices = append(targetDevices, name)
+
+			formatted := map[string]any{
+				"udn":       device.UDN,
+				"room_name": device.RoomName,
+				"volume":    device.Volume,
+				"fallback":  device.Fallback,
+			}
+			if device.AudioSettings != nil {
+				formatted["audio_settings"] = device.AudioSettings
+			}
+			devices = append(devices, formatted)
+		}
+		result["target_devices"] = targetDevices
+		result["devices"] = devices
+		result["fallback_used"] = detail.FallbackUsed
+
+		if content := detail.Content; content != nil {
+			result["content_played"] = map[string]any{
+				"type":         content.Type,
+				"title":        content.Title,
+				"artwork_url":  artwork.RewriteURL(content.ArtworkURL),
+				"service_name": content.ServiceName,
+				"uri":          content.URI,
+			}
+		}
+		if override := detail.HolidayOverride; override != nil {
+			result["holiday_override"] = map[string]any{
+				"holiday_name": override.HolidayName,
+				"music_set_id": override.MusicSetID,
+			}
+		}
+		if decision := detail.TVPolicy; decision != nil {
+			result["tv_policy"] = map[string]any{
+				"policy":       string(decision.Policy),
+				"action":       string(decision.Action),
+				"tv_mode_udns": decision.TVModeUDNs,
+			}
+		}
+		if occasion := detail.Occasion; occasion != nil {
+			formatted := map[string]any{
+				"music_set_id":   occasion.MusicSetID,
+				"occasion_start": occasion.OccasionStart,
+				"occasion_end":   occasion.OccasionEnd,
+				"action":         string(occasion.Action),
+			}
+			if len(occasion.ExcludedSetIDs) > 0 {
+				formatted["excluded_set_ids"] = occasion.ExcludedSetIDs
+			}
+			result["occasion"] = formatted
+		}
+		if playMode := detail.PlayMode; playMode != nil {
+			result["play_mode"] = map[string]any{
+				"shuffle":   playMode.Shuffle,
+				"repeat":    playMode.Repeat,
+				"crossfade": playMode.Crossfade,
+			}
+		}
+		if detail.SleepTimerMinutes != nil {
+			result["sleep_timer_minutes"] = *detail.SleepTimerMinutes
+		}
+		if len(detail.Actions) > 0 {
+			result["actions"] = detail.Actions
+		}
+		if len(detail.OfflineUDNs) > 0 {
+			result["offline_udns"] = detail.OfflineUDNs
+		}
+		if wake := detail.Wake; wake != nil {
+			formatted := map[string]any{
+				"start_volume": wake.StartVolume,
+				"end_volume":   wake.EndVolume,
+				"ramp_minutes": wake.RampMinutes,
+				"udns":         wake.UDNs,
+				"volume":       wake.Volume,
+				"status":       string(wake.Status),
+				"started_at":   api.RFC3339Millis(wake.StartedAt),
+			}
+			if wake.Status == WakeStatusAborted {
+				formatted["aborted_udn"] = wake.AbortedUDN
+				formatted["observed_volume"] = wake.ObservedVolume
+			}
+			if wake.Error != "" {
+				formatted["error"] = wake.Error
+			}
+			if wake.FinishedAt != nil {
+				formatted["finished_at"] = api.RFC3339Millis(*wake.FinishedAt)
+			}
+			result["wake"] = formatted
+		}
+	}
+
 	if job.Status == JobStatusFailed {
 		result["failure_reason"] = "execution_failed"
+		if job.FailureReason != nil {
+			result["failure_reason"] = *job.FailureReason
+		}
+	}
+	if job.Status == JobStatusSkipped && job.ExecutionDetail != nil {
+		switch {
+		case job.ExecutionDetail.TVPolicy != nil:
+			result["failure_reason"] = "tv_mode_active"
+		case job.ExecutionDetail.Occasion != nil:
+			result["failure_reason"] = "out_of_occasion"
+		}
 	}
 	if job.LastError != nil {
 		result["failure_message"] = *job.LastError
@@ -1229,6 +2482,11 @@ func processMusicPolicy(input *CreateRoutineInput, policy *MusicPolicy) {
 		input.MusicPolicyType = policyType
 	}
 
+	// Play mode applies to every policy type
+	if policy.PlayMode != nil {
+		input.MusicPlayMode = policy.PlayMode
+	}
+
 	// For FIXED policy, extract Sonos favorite fields
 	if policy.Type == "FIXED" {
 		if policy.SonosFavoriteID != nil {
@@ -1249,6 +2507,9 @@ func processMusicPolicy(input *CreateRoutineInput, policy *MusicPolicy) {
 		if policy.SetID != nil {
 			input.MusicSetID = policy.SetID
 		}
+		if len(policy.Sets) > 0 {
+			input.MusicSets = policy.Sets
+		}
 		if policy.NoRepeatWindow != nil {
 			input.MusicNoRepeatWindowMinutes = policy.NoRepeatWindow
 		}
@@ -1258,6 +2519,9 @@ func processMusicPolicy(input *CreateRoutineInput, policy *MusicPolicy) {
 		if policy.FallbackBehavior != nil {
 			input.MusicFallbackBehavior = policy.FallbackBehavior
 		}
+		if policy.NoRepeatScope != nil {
+			input.MusicNoRepeatScope = *policy.NoRepeatScope
+		}
 	}
 }
 
@@ -1273,6 +2537,11 @@ func processMusicPolicyUpdate(input *UpdateRoutineInput, policy *MusicPolicy) {
 		input.MusicPolicyType = &policyType
 	}
 
+	// Play mode applies to every policy type
+	if policy.PlayMode != nil {
+		input.MusicPlayMode = policy.PlayMode
+	}
+
 	// For FIXED policy, extract Sonos favorite fields
 	if policy.Type == "FIXED" {
 		if policy.SonosFavoriteID != nil {
@@ -1293,6 +2562,9 @@ func processMusicPolicyUpdate(input *UpdateRoutineInput, policy *MusicPolicy) {
 		if policy.SetID != nil {
 			input.MusicSetID = policy.SetID
 		}
+		if len(policy.Sets) > 0 {
+			input.MusicSets = policy.Sets
+		}
 		if policy.NoRepeatWindow != nil {
 			input.MusicNoRepeatWindowMinutes = policy.NoRepeatWindow
 		}
@@ -1302,6 +2574,24 @@ func processMusicPolicyUpdate(input *UpdateRoutineInput, policy *MusicPolicy) {
 		if policy.FallbackBehavior != nil {
 			input.MusicFallbackBehavior = policy.FallbackBehavior
 		}
+		if policy.NoRepeatScope != nil {
+			input.MusicNoRepeatScope = policy.NoRepeatScope
+		}
+	}
+}
+
+// localizeFavoriteArtwork replaces a FIXED policy's favorite artwork URL, which expires,
+// with the path of a local copy.
+func localizeFavoriteArtwork(musicService *music.Service, policy *MusicPolicy) {
+	if musicService == nil || policy.Type != "FIXED" || policy.SonosFavoriteID == nil {
+		return
+	}
+	artworkURL := ""
+	if policy.SonosFavoriteArtworkUrl != nil {
+		artworkURL = *policy.SonosFavoriteArtworkUrl
+	}
+	if localPath := musicService.LocalizeFavoriteArtwork(*policy.SonosFavoriteID, artworkURL); localPath != "" {
+		policy.SonosFavoriteArtworkUrl = &localPath
 	}
 }
 
@@ -1333,6 +2623,14 @@ func buildMusicContentJSON(policy *MusicPolicy) string {
 		if policy.MusicContent.ArtworkUrl != nil {
 			content["artworkUrl"] = *policy.MusicContent.ArtworkUrl
 		}
+	} else if policy.MusicContent != nil && policy.MusicContent.Type == "local_file" && policy.MusicContent.Filename != nil {
+		// Audio file served by the hub; the title defaults to the file name for display
+		content["type"] = "local_file"
+		content["filename"] = *policy.MusicContent.Filename
+		content["title"] = *policy.MusicContent.Filename
+		if policy.MusicContent.Title != nil && *policy.MusicContent.Title != "" {
+			content["title"] = *policy.MusicContent.Title
+		}
 	} else if policy.SonosFavoriteID != nil {
 		// Sonos favorite content
 		content["type"] = "sonos_favorite"
@@ -1387,6 +2685,21 @@ func processSchedule(input *CreateRoutineInput, schedule *ScheduleInput) {
 	if schedule.Time != "" {
 		input.ScheduleTime = schedule.Time
 	}
+	if schedule.IntervalDays != nil {
+		input.ScheduleIntervalDays = schedule.IntervalDays
+	}
+	if schedule.AnchorDate != nil {
+		input.ScheduleAnchorDate = schedule.AnchorDate
+	}
+	if schedule.DurationMinutes != nil {
+		input.DurationMinutes = schedule.DurationMinutes
+	}
+	if schedule.TimeMode != "" {
+		input.ScheduleTimeMode = TimeMode(schedule.TimeMode)
+	}
+	if schedule.OffsetMinutes != nil {
+		input.ScheduleOffsetMinutes = schedule.OffsetMinutes
+	}
 }
 
 // processScheduleUpdate extracts nested schedule from iOS request and flattens
@@ -1412,16 +2725,33 @@ func processScheduleUpdate(input *UpdateRoutineInput, schedule *ScheduleInput) {
 	if schedule.Time != "" {
 		input.ScheduleTime = &schedule.Time
 	}
+	if schedule.IntervalDays != nil {
+		input.ScheduleIntervalDays = schedule.IntervalDays
+	}
+	if schedule.AnchorDate != nil {
+		input.ScheduleAnchorDate = schedule.AnchorDate
+	}
+	if schedule.DurationMinutes != nil {
+		input.DurationMinutes = schedule.DurationMinutes
+	}
+	if schedule.TimeMode != "" {
+		timeMode := TimeMode(schedule.TimeMode)
+		input.ScheduleTimeMode = &timeMode
+	}
+	if schedule.OffsetMinutes != nil {
+		input.ScheduleOffsetMinutes = schedule.OffsetMinutes
+	}
 }
 
 // ==========================================================================
 // Additional Routine Handlers
 // ==========================================================================
 
-func unskipNextOccurrence(routinesRepo *RoutinesRepository, _ *devices.Service, _ *music.Service) func(w http.ResponseWriter, r *http.Request) error {
+func unskipNextOccurrence(routinesRepo *RoutinesRepository, _ *devices.Service, _ *music.Service, recorder audit.Recorder) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		routineID := chi.URLParam(r, "routine_id")
 
+		before := routineBeforeChange(routinesRepo, recorder, routineID)
 		skipNext := false
 		routine, err := routinesRepo.Update(routineID, UpdateRoutineInput{SkipNext: &skipNext})
 		if err != nil {
@@ -1430,6 +2760,7 @@ func unskipNextOccurrence(routinesRepo *RoutinesRepository, _ *devices.Service,
 		if routine == nil {
 			return apperrors.NewAppError(apperrors.ErrorCodeRoutineNotFound, "Routine not found", 404, map[string]any{"routine_id": routineID}, nil)
 		}
+		recordRoutineChange(r, recorder, audit.EventRoutineUpdated, "unskip", before, routine)
 
 		// Stripe-style: return action result directly
 		return api.WriteAction(w, http.StatusOK, map[string]any{
@@ -1441,12 +2772,46 @@ func unskipNextOccurrence(routinesRepo *RoutinesRepository, _ *devices.Service,
 	}
 }
 
+// restoreRoutinePlayback puts back what the routine's speakers were playing before its
+// latest restore_previous_state run, without waiting for duration_minutes to elapse.
+func restoreRoutinePlayback(routinesRepo *RoutinesRepository, playbackRestorer *PlaybackRestorer) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		routineID := chi.URLParam(r, "routine_id")
+
+		routine, err := routinesRepo.GetByID(routineID)
+		if err != nil {
+			return apperrors.NewInternalError("Failed to get routine")
+		}
+		if routine == nil {
+			return apperrors.NewAppError(apperrors.ErrorCodeRoutineNotFound, "Routine not found", 404, map[string]any{"routine_id": routineID}, nil)
+		}
+
+		if playbackRestorer == nil {
+			return apperrors.NewConflictError("No previous playback to restore", map[string]any{"routine_id": routineID})
+		}
+		jobID, results, err := playbackRestorer.Restore(routineID)
+		if errors.Is(err, ErrNoPlaybackSnapshot) {
+			return apperrors.NewConflictError("No previous playback to restore", map[string]any{"routine_id": routineID})
+		}
+		if err != nil {
+			return apperrors.NewInternalError("Failed to restore previous playback")
+		}
+
+		return api.WriteAction(w, http.StatusOK, map[string]any{
+			"object":     "playback_restore",
+			"routine_id": routineID,
+			"job_id":     jobID,
+			"devices":    results,
+		})
+	}
+}
+
 // RunRoutineInput represents the request body for running a routine with options.
 type RunRoutineInput struct {
 	DeviceOverride *string `json:"device_override,omitempty"`
 }
 
-func runRoutine(routinesRepo *RoutinesRepository, jobsRepo *JobsRepository) func(w http.ResponseWriter, r *http.Request) error {
+func runRoutine(routinesRepo *RoutinesRepository, jobsRepo *JobsRepository, triggerCooldown *TriggerCooldown) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		routineID := chi.URLParam(r, "routine_id")
 
@@ -1466,16 +2831,22 @@ func runRoutine(routinesRepo *RoutinesRepository, jobsRepo *JobsRepository) func
 			return apperrors.NewAppError(apperrors.ErrorCodeRoutineNotFound, "Routine not found", 404, map[string]any{"routine_id": routineID}, nil)
 		}
 
+		if err := acquireTriggerCooldown(w, triggerCooldown, routineID); err != nil {
+			return err
+		}
+
 		// Create a job scheduled for now (immediate execution)
 		job, err := jobsRepo.Create(CreateJobInput{
 			RoutineID:    routineID,
 			ScheduledFor: time.Now().UTC(),
+			Manual:       true,
 		})
 		if err != nil {
+			triggerCooldown.Release(routineID)
 			return apperrors.NewInternalError("Failed to create job")
 		}
 
-		jobResponse := formatJob(job)
+		jobResponse := formatJob(job, routine)
 		if input.DeviceOverride != nil {
 			jobResponse["device_override"] = *input.DeviceOverride
 		}
@@ -1525,29 +2896,99 @@ func testRoutine(sceneService *scene.Service) func(w http.ResponseWriter, r *htt
 	}
 }
 
+// dryRunRoutine resolves what running the routine now would do, without playing anything
+// or writing history.
+func dryRunRoutine(routinesRepo *RoutinesRepository, sceneService *scene.Service, nextRuns *JobGenerator, planner *RoutineExecutorAdapter) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		routineID := chi.URLParam(r, "routine_id")
+
+		routine, err := routinesRepo.GetByID(routineID)
+		if err != nil {
+			return apperrors.NewInternalError("Failed to get routine")
+		}
+		if routine == nil {
+			return apperrors.NewAppError(apperrors.ErrorCodeRoutineNotFound, "Routine not found", 404, map[string]any{"routine_id": routineID}, nil)
+		}
+		if planner == nil {
+			return apperrors.NewAppError("SERVICE_UNAVAILABLE", "Routine dry runs not available", 503, nil, nil)
+		}
+
+		routineScene, err := sceneService.GetScene(routine.SceneID)
+		if err != nil {
+			return apperrors.NewInternalError("Failed to get routine scene")
+		}
+
+		plan, err := planner.PlanRoutine(r.Context(), routine, routineScene, nextRuns, time.Now())
+		if err != nil {
+			logging.From(r.Context(), nil).Error("Failed to plan routine", "routine_id", routineID, "error", err)
+			return apperrors.NewInternalError("Failed to plan routine")
+		}
+
+		return api.WriteAction(w, http.StatusOK, plan)
+	}
+}
+
 // ==========================================================================
 // Execution Handlers
 // ==========================================================================
 
 func listExecutions(jobsRepo *JobsRepository, routinesRepo *RoutinesRepository) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
-		limit := 50 // Match Node.js default
-		offset := 0
-		statusFilter := ""
+		query := r.URL.Query()
+		filters := JobQueryFilters{
+			Limit: 50, // Match Node.js default
+		}
 
-		if l := r.URL.Query().Get("limit"); l != "" {
+		if l := query.Get("limit"); l != "" {
 			if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
-				limit = parsed
+				filters.Limit = parsed
 			}
 		}
-		if o := r.URL.Query().Get("offset"); o != "" {
+		if o := query.Get("offset"); o != "" {
 			if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
-				offset = parsed
+				filters.Offset = parsed
+			}
+		}
+		if status := query.Get("status"); status != "" {
+			filters.Status = &status
+		}
+		if routineID := query.Get("routine_id"); routineID != "" {
+			filters.RoutineID = &routineID
+		}
+
+		if from := query.Get("from"); from != "" {
+			parsed, err := parseExecutionRangeBound(from, false)
+			if err != nil {
+				return apperrors.NewValidationError("invalid 'from' datetime format, expected ISO 8601 or YYYY-MM-DD", map[string]any{"from": from})
+			}
+			filters.From = &parsed
+		}
+		if to := query.Get("to"); to != "" {
+			parsed, err := parseExecutionRangeBound(to, true)
+			if err != nil {
+				return apperrors.NewValidationError("invalid 'to' datetime format, expected ISO 8601 or YYYY-MM-DD", map[string]any{"to": to})
+			}
+			filters.To = &parsed
+		}
+		if filters.From != nil && filters.To != nil && filters.From.After(*filters.To) {
+			return apperrors.NewValidationError("'from' must not be after 'to'", map[string]any{
+				"from": query.Get("from"),
+				"to":   query.Get("to"),
+			})
+		}
+
+		if cursor := query.Get("starting_after"); cursor != "" {
+			cursorJob, err := jobsRepo.GetByID(cursor)
+			if err != nil {
+				return apperrors.NewInternalError("Failed to list executions")
+			}
+			if cursorJob == nil {
+				return apperrors.NewValidationError("starting_after does not match an execution", map[string]any{"starting_after": cursor})
 			}
+			filters.StartingAfter = &cursor
 		}
-		statusFilter = r.URL.Query().Get("status")
 
-		jobs, total, err := jobsRepo.ListAll(limit, offset, statusFilter)
+		jobs, hasMore, err := jobsRepo.ListAll(filters)
 		if err != nil {
 			return apperrors.NewInternalError("Failed to list executions")
 		}
@@ -1567,12 +3008,27 @@ func listExecutions(jobsRepo *JobsRepository, routinesRepo *RoutinesRepository)
 			executions = append(executions, formatJobAsExecution(&job, routineNames))
 		}
 
-		hasMore := offset+len(jobs) < total
 		// Stripe-style list response
 		return api.WriteList(w, "/v1/executions", executions, hasMore)
 	}
 }
 
+// parseExecutionRangeBound parses a from/to bound as RFC3339 or a bare YYYY-MM-DD date in UTC.
+// A bare date used as an upper bound covers the whole day.
+func parseExecutionRangeBound(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	return t, nil
+}
+
 func retryExecution(jobsRepo *JobsRepository) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		executionID := chi.URLParam(r, "execution_id")
@@ -1598,6 +3054,7 @@ func retryExecution(jobsRepo *JobsRepository) func(w http.ResponseWriter, r *htt
 		newJob, err := jobsRepo.Create(CreateJobInput{
 			RoutineID:    originalJob.RoutineID,
 			ScheduledFor: time.Now().UTC(),
+			Manual:       true,
 		})
 		if err != nil {
 			return apperrors.NewInternalError("Failed to create retry job")
@@ -1612,3 +3069,12 @@ func retryExecution(jobsRepo *JobsRepository) func(w http.ResponseWriter, r *htt
 		})
 	}
 }
+
+// rewriteArtworkValue applies artwork.RewriteURL to an artwork URL decoded from stored
+// content JSON, leaving nil and non-string values as they are.
+func rewriteArtworkValue(value any) any {
+	if uri, ok := value.(string); ok {
+		return artwork.RewriteURL(uri)
+	}
+	return value
+}
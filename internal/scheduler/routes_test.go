@@ -0,0 +1,277 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/audit"
+	"github.com/strefethen/sonos-hub-go/internal/config"
+	"github.com/strefethen/sonos-hub-go/internal/db"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/scene"
+)
+
+// fakeAuditRecorder keeps recorded changes.
+type fakeAuditRecorder struct {
+	mu      sync.Mutex
+	changes []audit.Change
+}
+
+func (f *fakeAuditRecorder) RecordChange(_ context.Context, change audit.Change) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.changes = append(f.changes, change)
+}
+
+func TestRoutineRoutes_AuditChanges(t *testing.T) {
+	routinesRepo, jobsRepo, holidaysRepo, scenesRepo := setupTestDB(t)
+	s, err := scenesRepo.Create(scene.CreateSceneInput{Name: "Test Scene", Members: []scene.SceneMember{}})
+	require.NoError(t, err)
+	routine, err := routinesRepo.Create(CreateRoutineInput{
+		Name:         "Morning",
+		Timezone:     "UTC",
+		ScheduleTime: "07:00",
+		SceneID:      s.SceneID,
+	})
+	require.NoError(t, err)
+
+	recorder := &fakeAuditRecorder{}
+	router := chi.NewRouter()
+	RegisterRoutes(router, RouteDeps{
+		Routines: routinesRepo,
+		Jobs:     jobsRepo,
+		Holidays: holidaysRepo,
+		Recorder: recorder,
+	})
+
+	serve := func(method, path string) int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusOK, serve(http.MethodPost, "/v1/routines/"+routine.RoutineID+"/disable"))
+	require.Equal(t, http.StatusNoContent, serve(http.MethodDelete, "/v1/routines/"+routine.RoutineID))
+	// Failed mutations aren't audited
+	require.Equal(t, http.StatusNotFound, serve(http.MethodPost, "/v1/routines/missing/enable"))
+
+	require.Len(t, recorder.changes, 2)
+
+	disabled := recorder.changes[0]
+	require.Equal(t, audit.EventRoutineUpdated, disabled.Type)
+	require.Equal(t, "disable", disabled.Action)
+	require.Equal(t, audit.ResourceRoutine, disabled.ResourceType)
+	require.Equal(t, routine.RoutineID, disabled.ResourceID)
+	diff, err := audit.Diff(disabled.Before, disabled.After)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"before": true, "after": false}, diff["enabled"])
+
+	deleted := recorder.changes[1]
+	require.Equal(t, audit.EventRoutineDeleted, deleted.Type)
+	require.Equal(t, routine.RoutineID, deleted.ResourceID)
+	require.Nil(t, deleted.After)
+}
+
+func TestRoutineRoutes_StaggerMs(t *testing.T) {
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+
+	routinesRepo := NewRoutinesRepository(dbPair)
+	sceneService := scene.NewService(config.Config{}, dbPair, nil, nil, nil)
+	router := chi.NewRouter()
+	RegisterRoutes(router, RouteDeps{
+		Routines: routinesRepo,
+		Jobs:     NewJobsRepository(dbPair),
+		Holidays: NewHolidaysRepository(dbPair),
+		Scenes:   sceneService,
+	})
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	sceneStagger := func(routineID string) int {
+		routine, err := routinesRepo.GetByID(routineID)
+		require.NoError(t, err)
+		routineScene, err := sceneService.GetScene(routine.SceneID)
+		require.NoError(t, err)
+		return routineScene.StaggerMs
+	}
+
+	body := `{"name":"Wake Up","timezone":"UTC","schedule_time":"07:00","grouping_mode":"independent",
+		"speakers":[{"udn":"RINCON_KITCHEN","volume":20},{"udn":"RINCON_DEN","volume":20}],"stagger_ms":%s}`
+	rec := serve(http.MethodPost, "/v1/routines", strings.Replace(body, "%s", "20000", 1))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serve(http.MethodPost, "/v1/routines", strings.Replace(body, "%s", "500", 1))
+	require.Less(t, rec.Code, 300, rec.Body.String())
+	var created map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	routineID := created["id"].(string)
+	require.Equal(t, 500, sceneStagger(routineID))
+
+	// Other updates leave it alone; 0 turns it off
+	rec = serve(http.MethodPut, "/v1/routines/"+routineID, `{"name":"Early Wake Up"}`)
+	require.Less(t, rec.Code, 300, rec.Body.String())
+	require.Equal(t, 500, sceneStagger(routineID))
+
+	rec = serve(http.MethodPut, "/v1/routines/"+routineID, `{"stagger_ms":0}`)
+	require.Less(t, rec.Code, 300, rec.Body.String())
+	require.Equal(t, 0, sceneStagger(routineID))
+}
+
+func TestRoutineRoutes_MusicPolicyTypeChange(t *testing.T) {
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+
+	routinesRepo := NewRoutinesRepository(dbPair)
+	sceneService := scene.NewService(config.Config{}, dbPair, nil, nil, nil)
+	router := chi.NewRouter()
+	RegisterRoutes(router, RouteDeps{
+		Routines: routinesRepo,
+		Jobs:     NewJobsRepository(dbPair),
+		Holidays: NewHolidaysRepository(dbPair),
+		Scenes:   sceneService,
+	})
+
+	serve := func(method, path, body string) map[string]any {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rec, req)
+		require.Less(t, rec.Code, 300, rec.Body.String())
+		var routine map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &routine))
+		return routine
+	}
+
+	created := serve(http.MethodPost, "/v1/routines", `{"name":"Wake Up","timezone":"UTC","schedule_time":"07:00",
+		"speakers":[{"udn":"RINCON_KITCHEN","volume":20}],
+		"music_policy":{"type":"FIXED","sonos_favorite_id":"FV:2/7","sonos_favorite_name":"Morning Jazz"}}`)
+	routineID := created["id"].(string)
+	require.Equal(t, "FV:2/7", created["music_policy"].(map[string]any)["sonos_favorite_id"])
+	require.Equal(t, "Morning Jazz", created["music_set"].(map[string]any)["name"])
+
+	updated := serve(http.MethodPut, "/v1/routines/"+routineID, `{"music_policy":{"type":"SHUFFLE","set_id":"set-1"}}`)
+	policy := updated["music_policy"].(map[string]any)
+	require.Equal(t, "SHUFFLE", policy["type"])
+	require.Equal(t, "set-1", policy["set_id"])
+	for key := range policy {
+		require.NotContains(t, key, "sonos_favorite")
+	}
+	require.NotContains(t, policy, "music_content")
+	require.Nil(t, updated["music_set"], "set-1 doesn't exist, and the favorite doesn't stand in for it")
+
+	routine, err := routinesRepo.GetByID(routineID)
+	require.NoError(t, err)
+	require.Nil(t, routine.MusicSonosFavoriteID)
+	require.Nil(t, routine.MusicContentJSON)
+
+	// Switching back doesn't bring the old favorite with it
+	updated = serve(http.MethodPut, "/v1/routines/"+routineID, `{"music_policy":{"type":"FIXED"}}`)
+	policy = updated["music_policy"].(map[string]any)
+	require.Nil(t, policy["sonos_favorite_id"])
+	require.Nil(t, policy["sonos_favorite_name"])
+	require.Nil(t, updated["music_set"])
+}
+
+func TestRoutineRoutes_DryRun(t *testing.T) {
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+
+	routinesRepo := NewRoutinesRepository(dbPair)
+	jobsRepo := NewJobsRepository(dbPair)
+	holidaysRepo := NewHolidaysRepository(dbPair)
+	sceneService := scene.NewService(config.Config{}, dbPair, nil, nil, nil)
+	generator := NewJobGenerator(routinesRepo, jobsRepo, holidaysRepo, logging.Discard())
+	planner := &RoutineExecutorAdapter{sceneExecutor: &failingSceneExecutor{t: t}, logger: logging.Discard()}
+
+	serve := func(planner *RoutineExecutorAdapter, method, path, body string) *httptest.ResponseRecorder {
+		router := chi.NewRouter()
+		RegisterRoutes(router, RouteDeps{
+			Routines: routinesRepo,
+			Jobs:     jobsRepo,
+			Holidays: holidaysRepo,
+			Scenes:   sceneService,
+			NextRuns: generator,
+			Planner:  planner,
+		})
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(planner, http.MethodPost, "/v1/routines", `{"name":"Wake Up","timezone":"UTC","schedule_time":"07:00",
+		"schedule_weekdays":[0,1,2,3,4,5,6],
+		"speakers":[{"udn":"RINCON_KITCHEN","volume":20}]}`)
+	require.Less(t, rec.Code, 300, rec.Body.String())
+	var created map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	routineID := created["id"].(string)
+
+	rec = serve(planner, http.MethodPost, "/v1/routines/"+routineID+"/dry-run", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var plan map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &plan))
+	require.Equal(t, "routine_plan", plan["object"])
+	require.Equal(t, true, plan["would_run"])
+	require.NotNil(t, plan["schedule"].(map[string]any)["next_run_at"])
+	require.Equal(t, []any{map[string]any{"udn": "RINCON_KITCHEN", "volume": float64(20)}}, plan["volumes"])
+
+	rec = serve(planner, http.MethodPost, "/v1/routines/missing/dry-run", "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	rec = serve(nil, http.MethodPost, "/v1/routines/"+routineID+"/dry-run", "")
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestRoutineRoutes_DefaultTimezone(t *testing.T) {
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+
+	routinesRepo := NewRoutinesRepository(dbPair)
+	sceneService := scene.NewService(config.Config{}, dbPair, nil, nil, nil)
+	router := chi.NewRouter()
+	RegisterRoutes(router, RouteDeps{
+		Routines: routinesRepo,
+		Jobs:     NewJobsRepository(dbPair),
+		Holidays: NewHolidaysRepository(dbPair),
+		Scenes:   sceneService,
+		Runtime:  fakeRuntimeSettings{timezone: "America/Chicago"},
+	})
+
+	create := func(body string) *Routine {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/routines", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		var created map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		routine, err := routinesRepo.GetByID(created["id"].(string))
+		require.NoError(t, err)
+		return routine
+	}
+
+	routine := create(`{"name":"Wake Up","schedule_time":"07:00","speakers":[{"udn":"RINCON_KITCHEN","volume":20}]}`)
+	require.Equal(t, "America/Chicago", routine.Timezone)
+	routine = create(`{"name":"Wake Up","timezone":"Europe/London","schedule_time":"07:00","speakers":[{"udn":"RINCON_KITCHEN","volume":20}]}`)
+	require.Equal(t, "Europe/London", routine.Timezone)
+}
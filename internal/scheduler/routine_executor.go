@@ -4,18 +4,31 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/strefethen/sonos-hub-go/internal/devices"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
 	"github.com/strefethen/sonos-hub-go/internal/music"
 	"github.com/strefethen/sonos-hub-go/internal/scene"
 	"github.com/strefethen/sonos-hub-go/internal/sonos"
 )
 
-// RoutineExecutor handles music resolution before scene execution
+// RoutineExecutor handles music resolution before scene execution.
+// Lines logged during the run carry the log attributes on ctx (see logging.With).
+// Date-based decisions are made for scheduledFor, so a retried or late job plays what it
+// would have on time; a zero scheduledFor means now.
 type RoutineExecutor interface {
-	ExecuteRoutine(routine *Routine, idempotencyKey *string) (*scene.SceneExecution, error)
+	ExecuteRoutine(ctx context.Context, routine *Routine, scheduledFor time.Time, idempotencyKey *string) (*RoutineExecution, error)
+}
+
+// RoutineExecution is the outcome of running a routine: the scene execution it started
+// and a summary of what played where.
+type RoutineExecution struct {
+	SceneExecution *scene.SceneExecution
+	Detail         *ExecutionDetail
+	Snapshots      []sonos.PlaybackSnapshot // Playback before the run, for restore_previous_state
 }
 
 // RoutineExecutorAdapter implements RoutineExecutor
@@ -25,11 +38,22 @@ type RoutineExecutorAdapter struct {
 	musicService    *music.Service
 	contentResolver *sonos.ContentResolver
 	deviceService   *devices.Service
-	logger          *log.Logger
+	autoStopper     *AutoStopper
+	restorer        *PlaybackRestorer
+	holidaysRepo    *HolidaysRepository
+	sceneService    *scene.Service
+	mediaInfo       MediaInfoProvider
+	ipResolver      DeviceIPResolver
+	playModes       sonos.PlayModeClient
+	audioSettings   sonos.AudioSettingsClient
+	sleepTimer      sonos.SleepTimerClient
+	actions         ActionController
+	logger          *slog.Logger
 	timeout         time.Duration
 }
 
-// NewRoutineExecutorAdapter creates a new RoutineExecutorAdapter
+// NewRoutineExecutorAdapter creates a new RoutineExecutorAdapter. Speakers are resolved
+// to IPs through deviceService for every feature that calls them directly.
 func NewRoutineExecutorAdapter(
 	sceneExecutor SceneExecutor,
 	musicService *music.Service,
@@ -37,124 +61,411 @@ func NewRoutineExecutorAdapter(
 	deviceService *devices.Service,
 	timeout time.Duration,
 ) *RoutineExecutorAdapter {
-	return &RoutineExecutorAdapter{
+	adapter := &RoutineExecutorAdapter{
 		sceneExecutor:   sceneExecutor,
 		musicService:    musicService,
 		contentResolver: contentResolver,
 		deviceService:   deviceService,
-		logger:          log.Default(),
+		logger:          slog.Default(),
 		timeout:         timeout,
 	}
+	if deviceService != nil {
+		adapter.ipResolver = deviceService
+	}
+	return adapter
+}
+
+// SetAutoStopper enables stopping playback after a routine's duration_minutes.
+func (a *RoutineExecutorAdapter) SetAutoStopper(autoStopper *AutoStopper) {
+	a.autoStopper = autoStopper
+}
+
+// SetPlaybackRestorer enables restore_previous_state: the routine's speakers are
+// snapshotted before each run so their playback can be put back afterwards.
+func (a *RoutineExecutorAdapter) SetPlaybackRestorer(restorer *PlaybackRestorer) {
+	a.restorer = restorer
+}
+
+// SetSceneService lets routines created from a bare scene_id be run on the scene's
+// members: checked for being online, TV mode, and the like. Without it such routines
+// have no speakers until the scene runs.
+func (a *RoutineExecutorAdapter) SetSceneService(sceneService *scene.Service) {
+	a.sceneService = sceneService
+}
+
+// SetHolidaysRepository enables the PLAY_ALTERNATE holiday behavior, which swaps in the
+// routine's holiday music set on holidays.
+func (a *RoutineExecutorAdapter) SetHolidaysRepository(holidaysRepo *HolidaysRepository) {
+	a.holidaysRepo = holidaysRepo
 }
 
 // ExecuteRoutine resolves music content and executes the scene
-func (a *RoutineExecutorAdapter) ExecuteRoutine(routine *Routine, idempotencyKey *string) (*scene.SceneExecution, error) {
+func (a *RoutineExecutorAdapter) ExecuteRoutine(ctx context.Context, routine *Routine, scheduledFor time.Time, idempotencyKey *string) (*RoutineExecution, error) {
+	logger := logging.From(ctx, a.logger)
 	options := scene.ExecuteOptions{}
 
+	// Offline speakers sit the run out; it fails early, with the rooms named, rather than
+	// partway through the scene when none of them can play
+	speakers := a.speakers(ctx, routine)
+	offline, err := a.checkSpeakersOnline(ctx, speakers)
+	if err != nil {
+		return nil, err
+	}
+	if len(offline) > 0 {
+		logger.Warn("Routine speakers offline, playing on the rest", "offline_udns", offline)
+		options.ExcludeMembers = offline
+	}
+
 	// Set TV policy from routine if configured
 	if routine.ArcTVPolicy != nil {
 		options.TVPolicy = scene.TVPolicy(*routine.ArcTVPolicy)
 	}
 
-	// Resolve music content based on policy type
-	musicContent, err := a.resolveMusicContent(routine)
+	// Enforce the TV policy before resolving music, so a skipped run doesn't use up a set item
+	tvDecision := decideTVPolicy(routine.ArcTVPolicy, a.tvModeSpeakers(ctx, speakers), len(speakers))
+	if tvDecision != nil {
+		roomNames := buildDeviceRoomMap(a.deviceService)
+		logger.Info("Routine speakers in TV mode",
+			"tv_mode_udns", tvDecision.TVModeUDNs, "arc_tv_policy", tvDecision.Policy, "action", tvDecision.Action)
+		switch tvDecision.Action {
+		case TVPolicyActionSkipped:
+			detail := buildExecutionDetail(routine, nil, roomNames)
+			detail.TVPolicy = tvDecision
+			rooms := make([]string, 0, len(tvDecision.TVModeUDNs))
+			for _, udn := range tvDecision.TVModeUDNs {
+				if name := roomNames[udn]; name != "" {
+					udn = name
+				}
+				rooms = append(rooms, udn)
+			}
+			return nil, &TVModeSkipError{Decision: tvDecision, Detail: detail, Rooms: rooms}
+		case TVPolicyActionUsedFallback:
+			options.ExcludeMembers = append(options.ExcludeMembers, tvDecision.TVModeUDNs...)
+		}
+	}
+
+	// Routines with actions act on their speakers directly; the scene would start playback
+	if routine.MusicPolicyType == MusicPolicyTypeNone && len(routine.Actions) > 0 {
+		return a.executeActions(ctx, routine, tvDecision, offline)
+	}
+
+	// Resolve music content based on policy type, or from the holiday set on holidays
+	if scheduledFor.IsZero() {
+		scheduledFor = time.Now()
+	}
+	resolved, err := a.resolveRoutineMusic(ctx, routine, scheduledFor, false)
+	if occasion := resolved.occasion; occasion != nil && occasion.Action == OccasionActionSkipped {
+		detail := buildExecutionDetail(routine, nil, buildDeviceRoomMap(a.deviceService))
+		detail.Occasion = occasion
+		return nil, &OccasionSkipError{Decision: occasion, Detail: detail, SetName: resolved.outOfSeason.Name}
+	}
 	if err != nil {
-		a.logger.Printf("Warning: failed to resolve music for routine %s: %v", routine.RoutineID, err)
+		logger.Warn("Failed to resolve music for routine", "error", err)
 		// Continue - scene still executes for grouping/volume
-	} else if musicContent != nil {
-		options.MusicContent = musicContent
+	} else if resolved.content != nil {
+		options.MusicContent = resolved.content
 		options.QueueMode = scene.QueueModeReplaceAndPlay
 	}
 
-	return a.sceneExecutor.ExecuteScene(routine.SceneID, idempotencyKey, options)
+	// A wake starts every speaker quietly; the ramp brings them up once playback starts
+	if routine.WakeProfile != nil && routine.WakeProfile.RampMinutes > 0 {
+		startVolume := routine.WakeProfile.StartVolume
+		options.StartVolume = &startVolume
+	}
+
+	// Snapshot what's playing before the scene takes over the speakers
+	var snapshots []sonos.PlaybackSnapshot
+	if routine.RestorePreviousState && a.restorer != nil {
+		snapshots = a.restorer.Capture(ctx, routine, speakers)
+	}
+
+	execution, err := a.sceneExecutor.ExecuteScene(ctx, routine.SceneID, idempotencyKey, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.autoStopper != nil && routine.DurationMinutes != nil {
+		a.autoStopper.Schedule(ctx, routine, speakers, execution.SceneExecutionID, options.MusicContent, time.Duration(*routine.DurationMinutes)*time.Minute)
+	}
+
+	detail := buildExecutionDetail(routine, execution, buildDeviceRoomMap(a.deviceService))
+	if options.MusicContent != nil {
+		detail.Content = resolved.summary
+	}
+	detail.HolidayOverride = resolved.override
+	detail.Occasion = resolved.occasion
+	detail.TVPolicy = tvDecision
+	detail.PlayMode = a.applyPlayMode(ctx, routine, execution)
+	detail.SleepTimerMinutes = a.applySleepTimer(ctx, routine, execution)
+	if tvDecision != nil && tvDecision.Action == TVPolicyActionUsedFallback {
+		withoutDevices(detail, tvDecision.TVModeUDNs)
+		detail.FallbackUsed = true
+	}
+	if len(offline) > 0 {
+		withoutDevices(detail, offline)
+		detail.OfflineUDNs = offline
+	}
+	a.applyAudioSettings(ctx, routine, options.ExcludeMembers, detail)
+	if options.StartVolume != nil {
+		detail.Wake = newWakeRamp(*routine.WakeProfile, detail, time.Now())
+	}
+
+	return &RoutineExecution{SceneExecution: execution, Detail: detail, Snapshots: snapshots}, nil
+}
+
+// executeActions runs a NONE routine's actions in place of its scene. Speakers the TV
+// policy set aside, and offline speakers, are left alone.
+func (a *RoutineExecutorAdapter) executeActions(ctx context.Context, routine *Routine, tvDecision *TVPolicyDecision, offline []string) (*RoutineExecution, error) {
+	roomNames := buildDeviceRoomMap(a.deviceService)
+	var exclude []string
+	if tvDecision != nil && tvDecision.Action == TVPolicyActionUsedFallback {
+		exclude = tvDecision.TVModeUDNs
+	}
+	exclude = append(exclude, offline...)
+
+	actions, err := a.runActions(ctx, routine, exclude, roomNames)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := buildExecutionDetail(routine, nil, roomNames)
+	detail.TVPolicy = tvDecision
+	detail.Actions = actions
+	if len(exclude) > 0 {
+		withoutDevices(detail, exclude)
+		detail.FallbackUsed = len(exclude) > len(offline)
+	}
+	if len(offline) > 0 {
+		detail.OfflineUDNs = offline
+	}
+	return &RoutineExecution{Detail: detail}, nil
 }
 
-// resolveMusicContent dispatches based on MusicPolicyType
-func (a *RoutineExecutorAdapter) resolveMusicContent(routine *Routine) (*scene.MusicContent, error) {
+// speakers returns the speakers a routine plays on: its speakers, or for routines
+// created from a bare scene_id, its scene's members. A failed scene lookup is logged and
+// gives none.
+func (a *RoutineExecutorAdapter) speakers(ctx context.Context, routine *Routine) []Speaker {
+	if len(routine.SpeakersJSON) > 0 || a.sceneService == nil || routine.SceneID == "" {
+		return routine.SpeakersJSON
+	}
+	routineScene, err := a.sceneService.GetScene(routine.SceneID)
+	if err != nil {
+		logging.From(ctx, a.logger).Warn("Failed to load routine scene", "scene_id", routine.SceneID, "error", err)
+		return nil
+	}
+	return speakersOf(routine, routineScene)
+}
+
+// speakersOf returns the speakers a routine plays on: its speakers, or routineScene's
+// members when it has none. routineScene may be nil.
+func speakersOf(routine *Routine, routineScene *scene.Scene) []Speaker {
+	if len(routine.SpeakersJSON) > 0 || routineScene == nil {
+		return routine.SpeakersJSON
+	}
+	speakers := make([]Speaker, 0, len(routineScene.Members))
+	for _, member := range routineScene.Members {
+		speakers = append(speakers, Speaker{
+			UDN:         member.UDN,
+			Volume:      member.TargetVolume,
+			FallbackUDN: member.FallbackUDN,
+			FadeInMs:    member.FadeInMs,
+			FadeCurve:   member.FadeCurve,
+		})
+	}
+	return speakers
+}
+
+// holidayOverride reports whether the routine should play its holiday music set: it uses
+// PLAY_ALTERNATE with a set configured and now is a holiday in the routine's timezone.
+func (a *RoutineExecutorAdapter) holidayOverride(ctx context.Context, routine *Routine, now time.Time) *HolidayOverride {
+	if a.holidaysRepo == nil || routine.HolidayBehavior != HolidayBehaviorPlayAlternate ||
+		routine.HolidayMusicSetID == nil || *routine.HolidayMusicSetID == "" {
+		return nil
+	}
+
+	isHoliday, holiday, err := a.holidaysRepo.IsHolidayWithDetails(inRoutineTimezone(routine, now))
+	if err != nil {
+		logging.From(ctx, a.logger).Warn("Failed to check holiday for routine", "error", err)
+		return nil
+	}
+	if !isHoliday {
+		return nil
+	}
+
+	return &HolidayOverride{HolidayName: holiday.Name, MusicSetID: *routine.HolidayMusicSetID}
+}
+
+// routineMusic is the music a run resolved, and the holiday and occasion decisions that
+// chose it.
+type routineMusic struct {
+	content   *scene.MusicContent
+	summary   *ExecutionContent
+	selection *music.SelectionResult // The set item picked, for music from a set
+	override  *HolidayOverride
+	occasion  *OccasionDecision
+
+	// With occasion, the first set out of season
+	outOfSeason *music.MusicSet
+}
+
+// resolveRoutineMusic resolves what a run scheduled for now plays: the holiday set when the
+// routine is due a holiday override, otherwise its own music with occasion gating
+// applied. Nothing is resolved when the occasion fallback skips the run or plays no
+// music. A dry run picks set items without advancing rotations or recording plays. On
+// error the decisions made so far are still returned.
+func (a *RoutineExecutorAdapter) resolveRoutineMusic(ctx context.Context, routine *Routine, now time.Time, dryRun bool) (*routineMusic, error) {
+	if holiday := a.resolveHolidayContent(ctx, routine, now, dryRun); holiday != nil {
+		return holiday, nil
+	}
+
+	resolved := &routineMusic{}
+	resolved.occasion, resolved.outOfSeason = a.occasionDecision(ctx, routine, now)
+	if occasion := resolved.occasion; occasion != nil {
+		logging.From(ctx, a.logger).Info("Music set out of season",
+			"music_set_id", occasion.MusicSetID, "occasion_start", occasion.OccasionStart,
+			"occasion_end", occasion.OccasionEnd, "action", occasion.Action)
+	}
+
+	var err error
+	switch occasion := resolved.occasion; {
+	case occasion == nil || occasion.Action == OccasionActionRelaxed:
+		resolved.content, resolved.summary, resolved.selection, err = a.resolveMusicContent(ctx, routine, dryRun)
+	case occasion.Action == OccasionActionExcluded:
+		inSeason := *routine
+		inSeason.MusicSets = withoutMusicSets(routine.WeightedMusicSets(), occasion.ExcludedSetIDs)
+		resolved.content, resolved.summary, resolved.selection, err = a.resolveMusicContent(ctx, &inSeason, dryRun)
+	}
+	return resolved, err
+}
+
+// resolveHolidayContent resolves the holiday music set when the routine is due a holiday
+// override. It returns nil when the routine's normal content should play, including when
+// the holiday set can't be resolved.
+func (a *RoutineExecutorAdapter) resolveHolidayContent(ctx context.Context, routine *Routine, now time.Time, dryRun bool) *routineMusic {
+	logger := logging.From(ctx, a.logger)
+	override := a.holidayOverride(ctx, routine, now)
+	if override == nil {
+		return nil
+	}
+
+	alternate := *routine
+	alternate.MusicSetID = &override.MusicSetID
+	alternate.MusicSets = nil
+	content, summary, selection, err := a.resolveSetContent(ctx, &alternate, dryRun)
+	if err != nil || content == nil {
+		logger.Warn("Failed to resolve holiday set, playing normal content",
+			"music_set_id", override.MusicSetID, "error", err)
+		return nil
+	}
+
+	logger.Info("Routine playing holiday set", "music_set_id", override.MusicSetID, "holiday", override.HolidayName)
+	return &routineMusic{content: content, summary: summary, selection: selection, override: override}
+}
+
+// resolveMusicContent dispatches based on MusicPolicyType. Alongside the playable content
+// it returns a display summary (title, artwork, service) for the executions history, and
+// for music from a set, the selection.
+func (a *RoutineExecutorAdapter) resolveMusicContent(ctx context.Context, routine *Routine, dryRun bool) (*scene.MusicContent, *ExecutionContent, *music.SelectionResult, error) {
 	switch routine.MusicPolicyType {
-	case MusicPolicyTypeFixed:
-		return a.resolveFixedContent(routine)
+	case MusicPolicyTypeNone:
+		return nil, nil, nil, nil
 	case MusicPolicyTypeRotation, MusicPolicyTypeShuffle:
-		return a.resolveSetContent(routine)
+		return a.resolveSetContent(ctx, routine, dryRun)
+	case MusicPolicyTypeFixed:
+		content, err := a.resolveFixedContent(ctx, routine)
+		return content, routineContentSummary(routine, content), nil, err
 	default:
 		// Check if there's content even without explicit policy
+		var content *scene.MusicContent
+		var err error
 		if routine.MusicContentJSON != nil && *routine.MusicContentJSON != "" {
-			return a.resolveDirectContentFromJSON(*routine.MusicContentJSON, routine)
-		}
-		if routine.MusicSonosFavoriteID != nil && *routine.MusicSonosFavoriteID != "" {
-			return a.resolveFavorite(*routine.MusicSonosFavoriteID, routine)
+			content, err = a.resolveDirectContentFromJSON(ctx, *routine.MusicContentJSON, routine)
+		} else if routine.MusicSonosFavoriteID != nil && *routine.MusicSonosFavoriteID != "" {
+			content, err = a.resolveFavorite(ctx, *routine.MusicSonosFavoriteID, routine)
 		}
-		return nil, nil // No music configured
+		return content, routineContentSummary(routine, content), nil, err
 	}
 }
 
 // resolveFixedContent resolves FIXED policy content
 // Priority: DirectContent (MusicContentJSON) > Sonos Favorite
-func (a *RoutineExecutorAdapter) resolveFixedContent(routine *Routine) (*scene.MusicContent, error) {
+func (a *RoutineExecutorAdapter) resolveFixedContent(ctx context.Context, routine *Routine) (*scene.MusicContent, error) {
 	// Try DirectContent first (preferred path - bypasses 70-favorite limit)
 	if routine.MusicContentJSON != nil && *routine.MusicContentJSON != "" {
-		return a.resolveDirectContentFromJSON(*routine.MusicContentJSON, routine)
+		return a.resolveDirectContentFromJSON(ctx, *routine.MusicContentJSON, routine)
 	}
 
 	// Fallback to Sonos Favorite
 	if routine.MusicSonosFavoriteID != nil && *routine.MusicSonosFavoriteID != "" {
-		return a.resolveFavorite(*routine.MusicSonosFavoriteID, routine)
+		return a.resolveFavorite(ctx, *routine.MusicSonosFavoriteID, routine)
 	}
 
 	return nil, nil
 }
 
-// resolveSetContent selects an item from music set and resolves it
-func (a *RoutineExecutorAdapter) resolveSetContent(routine *Routine) (*scene.MusicContent, error) {
-	if routine.MusicSetID == nil || *routine.MusicSetID == "" {
-		return nil, nil
+// resolveSetContent selects an item from the routine's music sets, by weight when there
+// are several, and resolves it. The play is recorded unless it's a dry run. Once an item
+// is selected, the selection is returned even if it can't be resolved.
+func (a *RoutineExecutorAdapter) resolveSetContent(ctx context.Context, routine *Routine, dryRun bool) (*scene.MusicContent, *ExecutionContent, *music.SelectionResult, error) {
+	sets := routine.WeightedMusicSets()
+	if len(sets) == 0 {
+		return nil, nil, nil, nil
+	}
+	logger := logging.From(ctx, a.logger)
+	setIDs := make([]string, 0, len(sets))
+	for _, set := range sets {
+		setIDs = append(setIDs, set.SetID)
 	}
 
-	// Select item from set
+	// Select item from the sets
 	input := music.SelectItemInput{
 		NoRepeatWindowMinutes: routine.MusicNoRepeatWindowMinutes,
+		NoRepeatScope:         routine.MusicNoRepeatScope,
+		RoutineID:             routine.RoutineID,
+		DryRun:                dryRun,
 	}
-	result, err := a.musicService.SelectItem(*routine.MusicSetID, input)
+	result, err := a.musicService.SelectItemFromSets(sets, input)
 	if err != nil {
-		return nil, fmt.Errorf("select item from set %s: %w", *routine.MusicSetID, err)
+		return nil, nil, nil, fmt.Errorf("select item from set %s: %w", strings.Join(setIDs, ", "), err)
 	}
 	if result == nil || result.Item == nil {
-		return nil, fmt.Errorf("no item selected from set %s", *routine.MusicSetID)
+		return nil, nil, nil, fmt.Errorf("no item selected from set %s", strings.Join(setIDs, ", "))
 	}
 
 	item := result.Item
-	a.logger.Printf("Selected item from set %s: favoriteID=%s position=%d",
-		*routine.MusicSetID, item.SonosFavoriteID, item.Position)
+	logger.Info("Selected item from set",
+		"music_set_id", item.SetID, "favorite_id", item.SonosFavoriteID, "position", item.Position)
+	recordPlay := func() {
+		if dryRun {
+			return
+		}
+		routineID := routine.RoutineID
+		if err := a.musicService.RecordPlay(item.SonosFavoriteID, &item.SetID, &routineID); err != nil {
+			logger.Warn("Failed to record play history", "error", err)
+		}
+	}
 
 	// Try DirectContent first (check ContentJSON on the item)
 	if item.ContentJSON != nil && *item.ContentJSON != "" {
-		content, err := a.resolveDirectContentFromJSON(*item.ContentJSON, routine)
+		content, err := a.resolveDirectContentFromJSON(ctx, *item.ContentJSON, routine)
 		if err == nil && content != nil {
-			// Record play history
-			routineID := routine.RoutineID
-			if err := a.musicService.RecordPlay(item.SonosFavoriteID, routine.MusicSetID, &routineID); err != nil {
-				a.logger.Printf("Warning: failed to record play history: %v", err)
-			}
-			return content, nil
+			recordPlay()
+			return content, setItemContentSummary(item, content), result, nil
 		}
-		a.logger.Printf("DirectContent resolution failed, trying favorite: %v", err)
+		logger.Warn("DirectContent resolution failed, trying favorite", "error", err)
 	}
 
 	// Fallback to Sonos Favorite if available
 	if item.SonosFavoriteID != "" {
-		content, err := a.resolveFavorite(item.SonosFavoriteID, routine)
+		content, err := a.resolveFavorite(ctx, item.SonosFavoriteID, routine)
 		if err == nil && content != nil {
-			// Record play history
-			routineID := routine.RoutineID
-			if err := a.musicService.RecordPlay(item.SonosFavoriteID, routine.MusicSetID, &routineID); err != nil {
-				a.logger.Printf("Warning: failed to record play history: %v", err)
-			}
-			return content, nil
+			recordPlay()
+			return content, setItemContentSummary(item, content), result, nil
 		}
-		return nil, fmt.Errorf("resolve favorite %s: %w", item.SonosFavoriteID, err)
+		return nil, nil, result, fmt.Errorf("resolve favorite %s: %w", item.SonosFavoriteID, err)
 	}
 
-	return nil, fmt.Errorf("set item has no resolvable content")
+	return nil, nil, result, fmt.Errorf("set item has no resolvable content")
 }
 
 // directContent represents the JSON structure stored in MusicContentJSON
@@ -164,15 +475,20 @@ type directContent struct {
 	ContentType *string `json:"content_type"`
 	ContentID   *string `json:"content_id"`
 	Title       *string `json:"title"`
+	Filename    *string `json:"filename"` // local_file only
 }
 
-// resolveDirectContentFromJSON parses JSON and resolves DirectContent
-func (a *RoutineExecutorAdapter) resolveDirectContentFromJSON(contentJSON string, routine *Routine) (*scene.MusicContent, error) {
+// resolveDirectContentFromJSON parses stored content JSON and resolves it: DirectContent
+// from a streaming service, or a local file served by the hub
+func (a *RoutineExecutorAdapter) resolveDirectContentFromJSON(ctx context.Context, contentJSON string, routine *Routine) (*scene.MusicContent, error) {
 	// Parse the stored JSON
 	var content directContent
 	if err := json.Unmarshal([]byte(contentJSON), &content); err != nil {
 		return nil, fmt.Errorf("parse content JSON: %w", err)
 	}
+	if content.Type == "local_file" {
+		return a.resolveLocalFile(ctx, content)
+	}
 
 	// Validate required fields for direct content
 	if content.Service == nil || *content.Service == "" {
@@ -193,16 +509,16 @@ func (a *RoutineExecutorAdapter) resolveDirectContentFromJSON(contentJSON string
 		title = *content.Title
 	}
 
-	deviceIP, err := a.getDeviceIP(routine)
+	deviceIP, err := a.getDeviceIP(ctx, routine)
 	if err != nil {
 		return nil, fmt.Errorf("get device IP: %w", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
 	defer cancel()
 
-	a.logger.Printf("Resolving DirectContent: service=%s type=%s id=%s title=%s deviceIP=%s",
-		service, contentType, contentID, title, deviceIP)
+	logging.From(ctx, a.logger).Info("Resolving DirectContent",
+		"service", service, "content_type", contentType, "content_id", contentID, "title", title, "device_ip", deviceIP)
 
 	// Use ContentResolver to build URI and metadata
 	playable, err := a.contentResolver.ResolveDirectContent(ctx, service, contentType, contentID, title, deviceIP)
@@ -218,14 +534,38 @@ func (a *RoutineExecutorAdapter) resolveDirectContentFromJSON(contentJSON string
 	}, nil
 }
 
+// resolveLocalFile resolves an audio file served by the hub to playable content
+func (a *RoutineExecutorAdapter) resolveLocalFile(ctx context.Context, content directContent) (*scene.MusicContent, error) {
+	if content.Filename == nil || *content.Filename == "" {
+		return nil, fmt.Errorf("local_file content missing filename")
+	}
+	title := ""
+	if content.Title != nil {
+		title = *content.Title
+	}
+
+	logging.From(ctx, a.logger).Info("Resolving local file", "filename", *content.Filename)
+	playable, err := a.contentResolver.ResolveLocalFile(*content.Filename, title)
+	if err != nil {
+		return nil, fmt.Errorf("resolve local file %s: %w", *content.Filename, err)
+	}
+
+	return &scene.MusicContent{
+		Type:      "local_file",
+		URI:       playable.URI,
+		Metadata:  playable.Metadata,
+		UsesQueue: playable.UsesQueue,
+	}, nil
+}
+
 // resolveFavorite resolves a Sonos Favorite ID to playable content
-func (a *RoutineExecutorAdapter) resolveFavorite(favoriteID string, routine *Routine) (*scene.MusicContent, error) {
-	deviceIP, err := a.getDeviceIP(routine)
+func (a *RoutineExecutorAdapter) resolveFavorite(ctx context.Context, favoriteID string, routine *Routine) (*scene.MusicContent, error) {
+	deviceIP, err := a.getDeviceIP(ctx, routine)
 	if err != nil {
 		return nil, fmt.Errorf("get device IP: %w", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
 	defer cancel()
 
 	playable, err := a.contentResolver.ResolveFavorite(ctx, favoriteID, deviceIP)
@@ -242,25 +582,27 @@ func (a *RoutineExecutorAdapter) resolveFavorite(favoriteID string, routine *Rou
 }
 
 // getDeviceIP gets a device IP for content resolution
-func (a *RoutineExecutorAdapter) getDeviceIP(routine *Routine) (string, error) {
+func (a *RoutineExecutorAdapter) getDeviceIP(ctx context.Context, routine *Routine) (string, error) {
+	logger := logging.From(ctx, a.logger)
+
 	// Try routine's speakers first
 	if len(routine.SpeakersJSON) > 0 {
 		udn := routine.SpeakersJSON[0].UDN
 		ip, err := a.deviceService.ResolveDeviceIP(udn)
 		if err != nil {
-			a.logger.Printf("Warning: error resolving IP for speaker %s: %v", udn, err)
+			logger.Warn("Error resolving IP for speaker", "udn", udn, "error", err)
 		} else if ip != "" {
 			return ip, nil
 		} else {
-			a.logger.Printf("Warning: speaker %s not found in topology, using fallback", udn)
+			logger.Warn("Speaker not found in topology, using fallback", "udn", udn)
 		}
 	}
 
 	// Fallback to any discovered device
 	topology, err := a.deviceService.GetTopology()
 	if err == nil && len(topology.Devices) > 0 {
-		a.logger.Printf("Using fallback device for content resolution: %s (%s)",
-			topology.Devices[0].RoomName, topology.Devices[0].IP)
+		logger.Info("Using fallback device for content resolution",
+			"room", topology.Devices[0].RoomName, "ip", topology.Devices[0].IP)
 		return topology.Devices[0].IP, nil
 	}
 
@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/audiofiles"
+	"github.com/strefethen/sonos-hub-go/internal/config"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/music"
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
+)
+
+func TestRoutineExecutorAdapter_HolidayOverride(t *testing.T) {
+	_, _, holidaysRepo, _ := setupTestDB(t)
+	_, err := holidaysRepo.Create(CreateHolidayInput{
+		Date:      time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC),
+		Name:      "Christmas",
+		Recurring: true,
+	})
+	require.NoError(t, err)
+
+	adapter := &RoutineExecutorAdapter{holidaysRepo: holidaysRepo, logger: logging.Discard()}
+	setID := "set-christmas"
+	routine := &Routine{
+		RoutineID:         "routine-1",
+		Timezone:          "America/Los_Angeles",
+		HolidayBehavior:   HolidayBehaviorPlayAlternate,
+		HolidayMusicSetID: &setID,
+	}
+
+	// 07:00 on Christmas morning in Los Angeles is 15:00 UTC
+	christmasMorning := time.Date(2025, 12, 25, 15, 0, 0, 0, time.UTC)
+	require.Equal(t, &HolidayOverride{HolidayName: "Christmas", MusicSetID: setID}, adapter.holidayOverride(context.Background(), routine, christmasMorning))
+
+	// 20:00 on Christmas Eve in Los Angeles is already Christmas in UTC
+	christmasEve := time.Date(2025, 12, 25, 4, 0, 0, 0, time.UTC)
+	require.Nil(t, adapter.holidayOverride(context.Background(), routine, christmasEve))
+
+	t.Run("other behaviors play normal content", func(t *testing.T) {
+		run := *routine
+		run.HolidayBehavior = HolidayBehaviorRun
+		require.Nil(t, adapter.holidayOverride(context.Background(), &run, christmasMorning))
+	})
+
+	t.Run("no holiday set", func(t *testing.T) {
+		noSet := *routine
+		noSet.HolidayMusicSetID = nil
+		require.Nil(t, adapter.holidayOverride(context.Background(), &noSet, christmasMorning))
+	})
+
+	t.Run("no holidays repository", func(t *testing.T) {
+		require.Nil(t, (&RoutineExecutorAdapter{}).holidayOverride(context.Background(), routine, christmasMorning))
+	})
+}
+
+func TestRoutineExecutorAdapter_HolidayAtScheduledTime(t *testing.T) {
+	dbPair := setupRunnerTestDB(t)
+	musicService := music.NewService(config.Config{}, dbPair, logging.Discard())
+	holidaysRepo := NewHolidaysRepository(dbPair)
+	christmasMorning := time.Date(2025, 12, 25, 7, 0, 0, 0, time.UTC)
+	_, err := holidaysRepo.Create(CreateHolidayInput{Date: christmasMorning, Name: "Christmas", Recurring: true})
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "carols.mp3"), []byte("ID3"), 0o644))
+	set, err := musicService.CreateSet(music.CreateSetInput{Name: "Christmas", SelectionPolicy: "ROTATION"})
+	require.NoError(t, err)
+	contentJSON := `{"type":"local_file","filename":"carols.mp3"}`
+	_, err = musicService.AddItem(set.SetID, music.AddItemInput{SonosFavoriteID: "local:carols.mp3", ContentType: "local_file", ContentJSON: &contentJSON})
+	require.NoError(t, err)
+	resolver := sonos.NewContentResolver(nil, nil, time.Second, nil)
+	resolver.SetLocalFileProvider(audiofiles.NewLibrary(dir, "http://192.168.1.5:9000"))
+
+	adapter := &RoutineExecutorAdapter{
+		sceneExecutor:   &fakeSceneExecutor{},
+		musicService:    musicService,
+		contentResolver: resolver,
+		holidaysRepo:    holidaysRepo,
+		logger:          logging.Discard(),
+	}
+	routine := &Routine{
+		RoutineID:         "routine-1",
+		SceneID:           "scene-1",
+		Timezone:          "UTC",
+		HolidayBehavior:   HolidayBehaviorPlayAlternate,
+		HolidayMusicSetID: &set.SetID,
+	}
+
+	// A Christmas run retried on Boxing Day still plays the holiday set
+	execution, err := adapter.ExecuteRoutine(context.Background(), routine, christmasMorning, nil)
+	require.NoError(t, err)
+	require.Equal(t, &HolidayOverride{HolidayName: "Christmas", MusicSetID: set.SetID}, execution.Detail.HolidayOverride)
+
+	execution, err = adapter.ExecuteRoutine(context.Background(), routine, christmasMorning.AddDate(0, 0, 1), nil)
+	require.NoError(t, err)
+	require.Nil(t, execution.Detail.HolidayOverride)
+}
+
+func TestRoutineExecutorAdapter_LocalFileContent(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "wake up.mp3"), []byte("ID3"), 0o644))
+	resolver := sonos.NewContentResolver(nil, nil, time.Second, nil)
+	resolver.SetLocalFileProvider(audiofiles.NewLibrary(dir, "http://192.168.1.5:9000"))
+	adapter := &RoutineExecutorAdapter{contentResolver: resolver, logger: logging.Discard()}
+
+	filename := "wake up.mp3"
+	contentJSON := buildMusicContentJSON(&MusicPolicy{
+		Type:         "FIXED",
+		MusicContent: &MusicContentAPI{Type: "local_file", Filename: &filename},
+	})
+	require.JSONEq(t, `{"type":"local_file","filename":"wake up.mp3","title":"wake up.mp3"}`, contentJSON)
+
+	content, err := adapter.resolveDirectContentFromJSON(context.Background(), contentJSON, &Routine{RoutineID: "routine-1"})
+	require.NoError(t, err)
+	require.Equal(t, "local_file", content.Type)
+	require.Equal(t, "http://192.168.1.5:9000/v1/assets/audio/wake%20up.mp3", content.URI)
+	require.False(t, content.UsesQueue)
+
+	_, err = adapter.resolveDirectContentFromJSON(context.Background(), `{"type":"local_file","filename":"missing.mp3"}`, &Routine{})
+	require.ErrorContains(t, err, "audio file not found")
+}
+
+func TestValidateLocalFileContent(t *testing.T) {
+	policy := func(filename string) *MusicPolicy {
+		return &MusicPolicy{Type: "FIXED", MusicContent: &MusicContentAPI{Type: "local_file", Filename: &filename}}
+	}
+	require.NoError(t, validateLocalFileContent(policy("alarm.mp3")))
+	require.NoError(t, validateLocalFileContent(&MusicPolicy{Type: "FIXED"}))
+	for _, filename := range []string{"", "../alarm.mp3", "sounds/alarm.mp3", "alarm.exe"} {
+		require.Error(t, validateLocalFileContent(policy(filename)), filename)
+	}
+}
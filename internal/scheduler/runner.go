@@ -1,12 +1,16 @@
 package scheduler
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
 
+	"github.com/strefethen/sonos-hub-go/internal/logging"
 	"github.com/strefethen/sonos-hub-go/internal/scene"
+	"github.com/strefethen/sonos-hub-go/internal/webhooks"
 )
 
 // ==========================================================================
@@ -20,11 +24,31 @@ const (
 	// DefaultMaxRetries is the default maximum number of retry attempts.
 	DefaultMaxRetries = 3
 
+	// DefaultRetryBackoffSeconds is the default wait before retrying a failed job.
+	// The wait doubles with each further failure.
+	DefaultRetryBackoffSeconds = 2
+
+	// MaxRoutineAttempts caps a routine's max_attempts.
+	MaxRoutineAttempts = 10
+
+	// MaxRetryBackoffSeconds caps a routine's retry_backoff_seconds and the doubled wait.
+	MaxRetryBackoffSeconds = 3600
+
 	// StaleJobTimeout is the duration after which a claimed job is considered stale.
 	StaleJobTimeout = 5 * time.Minute
 
 	// MaxPendingJobs is the maximum number of pending jobs to fetch per poll.
 	MaxPendingJobs = 100
+
+	// MissedJobGracePeriod is how overdue a pending job must be at startup or resume
+	// to count as missed. Jobs within the grace period run normally.
+	MissedJobGracePeriod = 2 * time.Minute
+
+	// MaxJobLogEntries caps the step log kept per job across all attempts.
+	MaxJobLogEntries = 50
+
+	// MaxJobLogErrorLength caps the error text stored per step log entry.
+	MaxJobLogErrorLength = 500
 )
 
 // ==========================================================================
@@ -34,7 +58,22 @@ const (
 // SceneExecutor defines the interface for scene execution.
 // This allows dependency injection for testing and decouples the scheduler from the scene package.
 type SceneExecutor interface {
-	ExecuteScene(sceneID string, idempotencyKey *string, options scene.ExecuteOptions) (*scene.SceneExecution, error)
+	ExecuteScene(ctx context.Context, sceneID string, idempotencyKey *string, options scene.ExecuteOptions) (*scene.SceneExecution, error)
+}
+
+// EventPublisher notifies outside services of routine runs (implemented by webhooks.Dispatcher).
+// Publish must not block.
+type EventPublisher interface {
+	Publish(event webhooks.Event, data map[string]any)
+}
+
+// RuntimeSettings supplies the settings that can change without a restart (implemented
+// by settings.Service). Each call reads the current value.
+type RuntimeSettings interface {
+	// DefaultTimezone is the timezone for routines created without one.
+	DefaultTimezone() string
+	// InQuietHours reports whether t falls in the hub's quiet hours.
+	InQuietHours(t time.Time) bool
 }
 
 // ==========================================================================
@@ -47,20 +86,24 @@ type SceneExecutor interface {
 // - Claiming and executing jobs atomically
 // - Retry logic with exponential backoff
 // - Recovery of stale claimed jobs after crashes
+// - Catch-up decisions for jobs missed while the hub was down
 type JobRunner struct {
-	logger          *log.Logger
+	logger          *slog.Logger
 	jobsRepo        *JobsRepository
 	routinesRepo    *RoutinesRepository
 	routineExecutor RoutineExecutor
 	pollInterval    time.Duration
 	maxRetries      int
+	events          EventPublisher
+	wakeRamper      *WakeRamper
+	runtime         RuntimeSettings
 	stopCh          chan struct{}
 	wg              sync.WaitGroup
 }
 
 // NewJobRunner creates a new JobRunner instance.
 func NewJobRunner(
-	logger *log.Logger,
+	logger *slog.Logger,
 	jobsRepo *JobsRepository,
 	routinesRepo *RoutinesRepository,
 	routineExecutor RoutineExecutor,
@@ -68,7 +111,7 @@ func NewJobRunner(
 	maxRetries int,
 ) *JobRunner {
 	if logger == nil {
-		logger = log.Default()
+		logger = slog.Default()
 	}
 	if pollInterval <= 0 {
 		pollInterval = DefaultPollInterval
@@ -88,14 +131,36 @@ func NewJobRunner(
 	}
 }
 
+// SetEventPublisher publishes routine.completed and routine.failed as jobs finish.
+// Call it before Start.
+func (r *JobRunner) SetEventPublisher(events EventPublisher) {
+	r.events = events
+}
+
+// SetWakeRamper enables wake profiles: once a job with a wake completes, its ramp is
+// started. Call it before Start.
+func (r *JobRunner) SetWakeRamper(wakeRamper *WakeRamper) {
+	r.wakeRamper = wakeRamper
+}
+
+// SetRuntimeSettings skips scheduled runs that fall in quiet hours. Call it before Start.
+func (r *JobRunner) SetRuntimeSettings(runtime RuntimeSettings) {
+	r.runtime = runtime
+}
+
 // Start begins the polling loop in a goroutine.
 // It first recovers any stale claimed jobs, then starts polling for pending jobs.
+// A stopped runner can be started again.
 func (r *JobRunner) Start() {
-	r.logger.Printf("Job runner starting with poll interval: %v, max retries: %d", r.pollInterval, r.maxRetries)
+	r.logger.Info("Job runner starting", "poll_interval", r.pollInterval, "max_retries", r.maxRetries)
+	r.stopCh = make(chan struct{})
 
 	// Recover stale jobs on startup
 	r.recoverStaleJobs()
 
+	// Decide what to do with jobs that came due while the hub was down
+	r.handleMissedJobs(time.Now().UTC())
+
 	r.wg.Add(1)
 	go func() {
 		defer r.wg.Done()
@@ -106,10 +171,10 @@ func (r *JobRunner) Start() {
 // Stop gracefully stops the runner.
 // It signals the polling loop to stop and waits for it to complete.
 func (r *JobRunner) Stop() {
-	r.logger.Println("Job runner stopping...")
+	r.logger.Info("Job runner stopping")
 	close(r.stopCh)
 	r.wg.Wait()
-	r.logger.Println("Job runner stopped")
+	r.logger.Info("Job runner stopped")
 }
 
 // runPollLoop runs the main polling loop until stopped.
@@ -119,22 +184,38 @@ func (r *JobRunner) runPollLoop() {
 
 	// Do an initial poll immediately
 	r.poll()
+	lastPoll := time.Now()
 
 	for {
 		select {
 		case <-r.stopCh:
 			return
 		case <-ticker.C:
+			// Resuming from a suspend is treated like a restart
+			now := time.Now()
+			if suspended := suspendedFor(lastPoll, now); suspended > MissedJobGracePeriod {
+				r.logger.Info("Resumed after a suspend, checking for missed jobs", "suspended", suspended.Round(time.Second))
+				r.handleMissedJobs(now.UTC())
+			}
+			lastPoll = now
 			r.poll()
 		}
 	}
 }
 
+// suspendedFor returns how long the host was suspended between since and now, both read
+// from time.Now. The monotonic clock stops during a suspend while the wall clock doesn't,
+// so their difference is the time suspended; time spent busy, such as in a slow poll,
+// advances both alike.
+func suspendedFor(since, now time.Time) time.Duration {
+	return now.Round(0).Sub(since.Round(0)) - now.Sub(since)
+}
+
 // poll checks for pending jobs and executes them.
 func (r *JobRunner) poll() {
 	jobs, err := r.jobsRepo.GetPendingJobs(MaxPendingJobs)
 	if err != nil {
-		r.logger.Printf("Error fetching pending jobs: %v", err)
+		r.logger.Error("Error fetching pending jobs", "error", err)
 		return
 	}
 
@@ -142,7 +223,7 @@ func (r *JobRunner) poll() {
 		return
 	}
 
-	r.logger.Printf("Found %d pending job(s)", len(jobs))
+	r.logger.Debug("Found pending jobs", "count", len(jobs))
 
 	now := time.Now().UTC()
 	for i := range jobs {
@@ -153,102 +234,319 @@ func (r *JobRunner) poll() {
 			continue
 		}
 
-		// Check retry_after if set
-		if job.RetryAfter != nil && job.RetryAfter.After(now) {
-			continue
-		}
-
 		if err := r.executeJob(job); err != nil {
-			r.logger.Printf("Error executing job %s: %v", job.JobID, err)
+			r.jobLogger(job).Error("Error executing job", "error", err)
 		}
 	}
 }
 
+// jobStepLog collects step log entries for one attempt of a job.
+type jobStepLog struct {
+	attempt int
+	entries []JobLogEntry
+}
+
+// record adds a step that began at startedAt, failed if err is non-nil.
+func (l *jobStepLog) record(step string, startedAt time.Time, err error) {
+	entry := JobLogEntry{
+		Attempt:    l.attempt,
+		Step:       step,
+		Status:     JobLogStatusOK,
+		StartedAt:  startedAt.UTC(),
+		DurationMs: time.Since(startedAt).Milliseconds(),
+	}
+	if err != nil {
+		entry.Status = JobLogStatusFailed
+		entry.Error = err.Error()
+	}
+	l.entries = append(l.entries, entry)
+}
+
+// jobLogger returns the runner's logger with the job's IDs on every line.
+func (r *JobRunner) jobLogger(job *Job) *slog.Logger {
+	return r.logger.With("job_id", job.JobID, "routine_id", job.RoutineID)
+}
+
+// skippedRun reports whether err means a routine policy skipped the run, as opposed to
+// the run failing, and returns the skip's reason and detail.
+func skippedRun(err error) (string, *ExecutionDetail, bool) {
+	var tvSkip *TVModeSkipError
+	if errors.As(err, &tvSkip) {
+		return tvSkip.Error(), tvSkip.Detail, true
+	}
+	var occasionSkip *OccasionSkipError
+	if errors.As(err, &occasionSkip) {
+		return occasionSkip.Error(), occasionSkip.Detail, true
+	}
+	return "", nil, false
+}
+
 // executeJob claims and runs a single job.
 func (r *JobRunner) executeJob(job *Job) error {
-	r.logger.Printf("Claiming job %s (routine: %s, scheduled: %s)",
-		job.JobID, job.RoutineID, job.ScheduledFor.Format(time.RFC3339))
+	logger := r.jobLogger(job)
+	logger.Info("Claiming job", "scheduled_for", job.ScheduledFor.Format(time.RFC3339))
+
+	stepLog := &jobStepLog{attempt: job.Attempts + 1}
 
 	// Step 1: Claim job (atomic status update)
+	stepStart := time.Now()
 	if err := r.jobsRepo.ClaimJob(job.JobID); err != nil {
+		// Another runner may own the job, so leave its log alone
 		return fmt.Errorf("failed to claim job: %w", err)
 	}
+	stepLog.record("claim", stepStart, nil)
+
+	defer func() {
+		if err := r.jobsRepo.AppendJobLog(job.JobID, stepLog.entries); err != nil {
+			logger.Warn("Failed to save step log", "error", err)
+		}
+	}()
 
 	// Step 2: Start job (set status=RUNNING)
+	stepStart = time.Now()
 	if err := r.jobsRepo.StartJob(job.JobID); err != nil {
 		// Job was claimed but we failed to start it - mark for retry
-		r.handleJobFailure(job, fmt.Errorf("failed to start job: %w", err))
+		stepLog.record("start", stepStart, err)
+		r.handleJobFailure(job, nil, fmt.Errorf("failed to start job: %w", err))
 		return err
 	}
+	stepLog.record("start", stepStart, nil)
 
 	// Step 3: Get routine for job
+	stepStart = time.Now()
 	routine, err := r.routinesRepo.GetByID(job.RoutineID)
+	if err == nil && routine == nil {
+		if _, deleted, lookupErr := r.routinesRepo.GetByIDIncludingDeleted(job.RoutineID); lookupErr == nil && deleted {
+			// Jobs queued before the routine was deleted don't run; restoring it before
+			// they're due keeps them
+			stepLog.record("load_routine", stepStart, nil)
+			if err := r.jobsRepo.SkipJob(job.JobID, "routine_deleted"); err != nil {
+				logger.Error("Error skipping job", "error", err)
+				return err
+			}
+			logger.Info("Job skipped", "reason", "routine_deleted")
+			return nil
+		}
+		err = fmt.Errorf("routine not found: %s", job.RoutineID)
+	} else if err != nil {
+		err = fmt.Errorf("failed to get routine: %w", err)
+	}
+	stepLog.record("load_routine", stepStart, err)
 	if err != nil {
-		r.handleJobFailure(job, fmt.Errorf("failed to get routine: %w", err))
+		r.handleJobFailure(job, nil, err)
 		return err
 	}
-	if routine == nil {
-		err := fmt.Errorf("routine not found: %s", job.RoutineID)
-		r.handleJobFailure(job, err)
-		return err
+
+	// Scheduled runs don't play in quiet hours; manual ones always do
+	if !job.Manual && r.runtime != nil && r.runtime.InQuietHours(job.ScheduledFor) {
+		if err := r.jobsRepo.SkipJob(job.JobID, "quiet_hours"); err != nil {
+			logger.Error("Error skipping job", "error", err)
+			return err
+		}
+		logger.Info("Job skipped", "reason", "quiet_hours")
+		return nil
 	}
 
 	// Step 4: Execute routine (handles music resolution and scene execution)
-	execution, err := r.routineExecutor.ExecuteRoutine(routine, job.IdempotencyKey)
+	stepStart = time.Now()
+	// Lines logged while executing the routine carry the job's IDs
+	ctx := logging.With(context.Background(), "job_id", job.JobID, "routine_id", job.RoutineID)
+	execution, err := r.routineExecutor.ExecuteRoutine(ctx, routine, job.ScheduledFor, job.IdempotencyKey)
+	if reason, detail, skipped := skippedRun(err); skipped {
+		// A policy skipped the run; retrying won't change its mind
+		stepLog.record("execute_routine", stepStart, nil)
+		if err := r.jobsRepo.SkipJobWithDetail(job.JobID, reason, detail); err != nil {
+			logger.Error("Error skipping job", "error", err)
+			return err
+		}
+		logger.Info("Job skipped", "reason", reason)
+		return nil
+	}
+	stepLog.record("execute_routine", stepStart, err)
 	if err != nil {
-		r.handleJobFailure(job, err)
+		r.handleJobFailure(job, routine, err)
 		return err
 	}
 
 	// Step 5: Complete job with result
 	sceneExecutionID := ""
+	var detail *ExecutionDetail
 	if execution != nil {
-		sceneExecutionID = execution.SceneExecutionID
+		if execution.SceneExecution != nil {
+			sceneExecutionID = execution.SceneExecution.SceneExecutionID
+		}
+		detail = execution.Detail
+
+		if len(execution.Snapshots) > 0 {
+			// Move any unrestored snapshot from an earlier run onto this job
+			if err := r.jobsRepo.ClearPlaybackSnapshots(job.RoutineID); err != nil {
+				logger.Warn("Failed to clear earlier playback snapshots", "error", err)
+			}
+			if err := r.jobsRepo.SetPlaybackSnapshot(job.JobID, execution.Snapshots); err != nil {
+				logger.Warn("Failed to save playback snapshot", "error", err)
+			}
+		}
 	}
 
-	if err := r.jobsRepo.CompleteJob(job.JobID, sceneExecutionID); err != nil {
-		r.logger.Printf("Warning: failed to mark job %s as completed: %v", job.JobID, err)
+	stepStart = time.Now()
+	err = r.jobsRepo.CompleteJobWithDetail(job.JobID, sceneExecutionID, detail)
+	stepLog.record("complete", stepStart, err)
+	if err != nil {
+		logger.Warn("Failed to mark job as completed", "error", err)
 		// Don't return error here - the job was actually executed
 	}
+	if r.wakeRamper != nil && detail != nil && detail.Wake != nil {
+		ramp := *detail.Wake
+		r.wakeRamper.Start(ctx, job.JobID, &ramp)
+	}
 
 	// Step 6: Update routine's last_run_at
 	if err := r.routinesRepo.UpdateLastRunAt(job.RoutineID, time.Now().UTC()); err != nil {
-		r.logger.Printf("Warning: failed to update last_run_at for routine %s: %v", job.RoutineID, err)
+		logger.Warn("Failed to update routine last_run_at", "error", err)
 		// Don't return error - this is not critical
 	}
 
-	r.logger.Printf("Job %s completed successfully (execution: %s)", job.JobID, sceneExecutionID)
+	logger.Info("Job completed successfully", "scene_execution_id", sceneExecutionID)
+	r.publish(webhooks.EventRoutineCompleted, job, routine, map[string]any{
+		"scene_execution_id": sceneExecutionID,
+	})
 	return nil
 }
 
-// handleJobFailure processes a job failure with retry logic.
-func (r *JobRunner) handleJobFailure(job *Job, execErr error) {
+// publish sends a routine event, if there's a publisher. routine may be nil.
+func (r *JobRunner) publish(event webhooks.Event, job *Job, routine *Routine, data map[string]any) {
+	if r.events == nil {
+		return
+	}
+	data["job_id"] = job.JobID
+	data["routine_id"] = job.RoutineID
+	if routine != nil {
+		data["routine_name"] = routine.Name
+	}
+	r.events.Publish(event, data)
+}
+
+// RetryBackoff returns how long to wait before retrying a job that has failed the given
+// number of times: base after the first failure, doubling after each one, capped at
+// MaxRetryBackoffSeconds.
+func RetryBackoff(baseSeconds, failures int) time.Duration {
+	if baseSeconds <= 0 {
+		baseSeconds = DefaultRetryBackoffSeconds
+	}
+	backoff := time.Duration(baseSeconds) * time.Second
+	for i := 1; i < failures && backoff < MaxRetryBackoffSeconds*time.Second; i++ {
+		backoff *= 2
+	}
+	return min(backoff, MaxRetryBackoffSeconds*time.Second)
+}
+
+// retryPolicy returns the attempt limit and base backoff for a job's routine.
+// Jobs whose routine could not be loaded use the runner's defaults.
+func (r *JobRunner) retryPolicy(routine *Routine) (maxAttempts, backoffSeconds int) {
+	maxAttempts, backoffSeconds = r.maxRetries, DefaultRetryBackoffSeconds
+	if routine != nil {
+		if routine.MaxAttempts > 0 {
+			maxAttempts = routine.MaxAttempts
+		}
+		if routine.RetryBackoffSeconds > 0 {
+			backoffSeconds = routine.RetryBackoffSeconds
+		}
+	}
+	return maxAttempts, backoffSeconds
+}
+
+// handleJobFailure processes a job failure with the routine's retry policy.
+// routine may be nil when the failure happened before it was loaded.
+func (r *JobRunner) handleJobFailure(job *Job, routine *Routine, execErr error) {
 	errMsg := execErr.Error()
 	attempts := job.Attempts + 1
+	maxAttempts, backoffSeconds := r.retryPolicy(routine)
+	logger := r.jobLogger(job)
 
 	// Check if we can retry
-	canRetry := attempts < r.maxRetries
+	canRetry := attempts < maxAttempts
 
 	if canRetry {
-		// Calculate exponential backoff: 1s, 2s, 4s, 8s, etc.
-		backoffSeconds := 1 << attempts // 2^attempts
-		retryAfter := time.Now().UTC().Add(time.Duration(backoffSeconds) * time.Second)
+		retryAfter := time.Now().UTC().Add(RetryBackoff(backoffSeconds, attempts))
 
-		r.logger.Printf("Job %s failed (attempt %d/%d): %s. Will retry after %s",
-			job.JobID, attempts, r.maxRetries, errMsg, retryAfter.Format(time.RFC3339))
+		logger.Warn("Job failed, will retry",
+			"attempt", attempts, "max_attempts", maxAttempts, "error", errMsg, "retry_after", retryAfter.Format(time.RFC3339))
 
 		// Set retry_after for the job
 		if err := r.jobsRepo.SetRetryAfter(job.JobID, retryAfter); err != nil {
-			r.logger.Printf("Warning: failed to set retry_after for job %s: %v", job.JobID, err)
+			logger.Warn("Failed to set retry_after", "error", err)
 		}
 	} else {
-		r.logger.Printf("Job %s failed permanently after %d attempts: %s",
-			job.JobID, attempts, errMsg)
+		logger.Error("Job failed permanently", "attempts", attempts, "error", errMsg)
+		r.publish(webhooks.EventRoutineFailed, job, routine, map[string]any{
+			"error":    errMsg,
+			"attempts": attempts,
+		})
 	}
 
 	// Update job status
-	if err := r.jobsRepo.FailJob(job.JobID, errMsg, canRetry); err != nil {
-		r.logger.Printf("Error updating failed job %s: %v", job.JobID, err)
+	if err := r.jobsRepo.FailJobWithReason(job.JobID, errMsg, jobFailureReason(execErr), canRetry); err != nil {
+		logger.Error("Error updating failed job", "error", err)
+	}
+}
+
+// DecideMissedRun applies a routine's missed_run_policy to a job that is overdue by the given duration.
+// Unknown or empty policies are treated as skip to avoid surprise playback after a reboot.
+func DecideMissedRun(routine *Routine, overdue time.Duration) MissedRunDecision {
+	switch routine.MissedRunPolicy {
+	case MissedRunPolicyRunImmediately:
+		return MissedRunDecisionRun
+	case MissedRunPolicyRunIfWithinMinutes:
+		if routine.MissedRunWithinMinutes != nil && overdue <= time.Duration(*routine.MissedRunWithinMinutes)*time.Minute {
+			return MissedRunDecisionRun
+		}
+		return MissedRunDecisionSkip
+	default:
+		return MissedRunDecisionSkip
+	}
+}
+
+// handleMissedJobs evaluates pending jobs whose scheduled time passed while the runner was
+// not polling and records a run or skip decision for each. Jobs that were already attempted
+// or already decided are left to the normal poll and retry logic.
+func (r *JobRunner) handleMissedJobs(now time.Time) {
+	jobs, err := r.jobsRepo.GetPendingJobs(MaxPendingJobs)
+	if err != nil {
+		r.logger.Error("Error fetching pending jobs for missed-run check", "error", err)
+		return
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+		if job.Attempts > 0 || job.MissedRunDecision != nil {
+			continue
+		}
+		overdue := now.Sub(job.ScheduledFor)
+		if overdue <= MissedJobGracePeriod {
+			continue
+		}
+
+		routine, err := r.routinesRepo.GetByID(job.RoutineID)
+		if err != nil || routine == nil {
+			// Leave it to executeJob, which fails jobs whose routine is gone
+			continue
+		}
+
+		decision := DecideMissedRun(routine, overdue)
+		if err := r.jobsRepo.SetMissedRunDecision(job.JobID, decision); err != nil {
+			r.jobLogger(job).Error("Error recording missed-run decision", "error", err)
+			continue
+		}
+
+		if decision == MissedRunDecisionSkip {
+			if err := r.jobsRepo.SkipJob(job.JobID, "missed_run"); err != nil {
+				r.jobLogger(job).Error("Error skipping missed job", "error", err)
+				continue
+			}
+		}
+
+		r.jobLogger(job).Info("Missed job",
+			"overdue", overdue.Round(time.Second), "policy", routine.MissedRunPolicy, "decision", decision)
 	}
 }
 
@@ -258,11 +556,11 @@ func (r *JobRunner) recoverStaleJobs() {
 	// Recover stale CLAIMED jobs
 	staleJobs, err := r.jobsRepo.GetStaleClaimedJobs(StaleJobTimeout)
 	if err != nil {
-		r.logger.Printf("Error fetching stale claimed jobs: %v", err)
+		r.logger.Error("Error fetching stale claimed jobs", "error", err)
 	} else if len(staleJobs) == 0 {
-		r.logger.Println("No stale claimed jobs to recover")
+		r.logger.Debug("No stale claimed jobs to recover")
 	} else {
-		r.logger.Printf("Found %d stale claimed job(s) to recover", len(staleJobs))
+		r.logger.Info("Found stale claimed jobs to recover", "count", len(staleJobs))
 		for _, job := range staleJobs {
 			r.recoverStaleJob(&job, "claim")
 		}
@@ -271,11 +569,11 @@ func (r *JobRunner) recoverStaleJobs() {
 	// Recover stale RUNNING jobs
 	staleRunning, err := r.jobsRepo.GetStaleRunningJobs(StaleJobTimeout)
 	if err != nil {
-		r.logger.Printf("Error fetching stale running jobs: %v", err)
+		r.logger.Error("Error fetching stale running jobs", "error", err)
 	} else if len(staleRunning) == 0 {
-		r.logger.Println("No stale running jobs to recover")
+		r.logger.Debug("No stale running jobs to recover")
 	} else {
-		r.logger.Printf("Found %d stale running job(s) to recover", len(staleRunning))
+		r.logger.Info("Found stale running jobs to recover", "count", len(staleRunning))
 		for _, job := range staleRunning {
 			r.recoverStaleJob(&job, "running")
 		}
@@ -290,23 +588,24 @@ func (r *JobRunner) recoverStaleJob(job *Job, staleType string) {
 	} else if staleType == "running" && job.StartedAt != nil {
 		timeStr = job.StartedAt.Format(time.RFC3339)
 	}
-	r.logger.Printf("Recovering stale %s job %s (since: %s)", staleType, job.JobID, timeStr)
+	logger := r.jobLogger(job)
+	logger.Info("Recovering stale job", "stale_type", staleType, "since", timeStr)
 
 	// Reset job to pending status for retry
 	errMsg := fmt.Sprintf("job recovered after stale %s timeout", staleType)
-	canRetry := job.Attempts < r.maxRetries
+	routine, _ := r.routinesRepo.GetByID(job.RoutineID) // nil falls back to the runner defaults
+	maxAttempts, _ := r.retryPolicy(routine)
+	canRetry := job.Attempts < maxAttempts
 
 	if err := r.jobsRepo.FailJob(job.JobID, errMsg, canRetry); err != nil {
-		r.logger.Printf("Error recovering stale job %s: %v", job.JobID, err)
+		logger.Error("Error recovering stale job", "error", err)
 		return
 	}
 
 	if canRetry {
-		r.logger.Printf("Stale job %s reset to pending for retry (attempt %d/%d)",
-			job.JobID, job.Attempts+1, r.maxRetries)
+		logger.Info("Stale job reset to pending for retry", "attempt", job.Attempts+1, "max_attempts", maxAttempts)
 	} else {
-		r.logger.Printf("Stale job %s marked as failed (max retries exceeded)",
-			job.JobID)
+		logger.Warn("Stale job marked as failed (max retries exceeded)")
 	}
 }
 
@@ -1,9 +1,11 @@
 package scheduler
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
-	"log"
-	"os"
+	"log/slog"
 	"path/filepath"
 	"sync"
 	"testing"
@@ -14,7 +16,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/strefethen/sonos-hub-go/internal/db"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
 	"github.com/strefethen/sonos-hub-go/internal/scene"
+	"github.com/strefethen/sonos-hub-go/internal/webhooks"
 )
 
 // ==========================================================================
@@ -28,7 +32,8 @@ type mockRoutineExecutor struct {
 	shouldFail     bool
 	failError      error
 	delay          time.Duration
-	dbPair         *db.DBPair // For creating real scene executions
+	dbPair         *db.DBPair   // For creating real scene executions
+	logger         *slog.Logger // Logs each execution with the context's attributes
 }
 
 func newMockRoutineExecutor() *mockRoutineExecutor {
@@ -44,7 +49,7 @@ func newMockRoutineExecutorWithDB(dbPair *db.DBPair) *mockRoutineExecutor {
 	}
 }
 
-func (m *mockRoutineExecutor) ExecuteRoutine(routine *Routine, idempotencyKey *string) (*scene.SceneExecution, error) {
+func (m *mockRoutineExecutor) ExecuteRoutine(ctx context.Context, routine *Routine, scheduledFor time.Time, idempotencyKey *string) (*RoutineExecution, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -56,6 +61,9 @@ func (m *mockRoutineExecutor) ExecuteRoutine(routine *Routine, idempotencyKey *s
 		return nil, m.failError
 	}
 
+	if m.logger != nil {
+		logging.From(ctx, m.logger).Info("Executing routine")
+	}
 	m.executionCount++
 	execID := "exec-" + routine.SceneID + "-" + time.Now().Format("20060102150405.000000")
 	now := time.Now().UTC().Format("2006-01-02T15:04:05Z07:00")
@@ -79,7 +87,7 @@ func (m *mockRoutineExecutor) ExecuteRoutine(routine *Routine, idempotencyKey *s
 		StartedAt:        time.Now().UTC(),
 	}
 	m.executions = append(m.executions, execution)
-	return execution, nil
+	return &RoutineExecution{SceneExecution: execution, Detail: buildExecutionDetail(routine, execution, nil)}, nil
 }
 
 func (m *mockRoutineExecutor) setFailure(fail bool, err error) {
@@ -145,8 +153,8 @@ func createTestJob(t *testing.T, repo *JobsRepository, routineID string, schedul
 	return job
 }
 
-func newTestLogger() *log.Logger {
-	return log.New(os.Stdout, "[test] ", log.LstdFlags|log.Lshortfile)
+func newTestLogger() *slog.Logger {
+	return slog.Default()
 }
 
 // ==========================================================================
@@ -236,9 +244,30 @@ func TestJobRunner_ExecuteJob(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, JobStatusCompleted, updatedJob.Status)
 		assert.NotNil(t, updatedJob.SceneExecutionID)
+		assert.NotNil(t, updatedJob.ExecutionDetail)
 		assert.Equal(t, 1, executor.getExecutionCount())
 	})
 
+	t.Run("passes the job ID to the routine executor's logs", func(t *testing.T) {
+		var buf bytes.Buffer
+		executor := newMockRoutineExecutorWithDB(dbPair)
+		executor.logger = slog.New(slog.NewJSONHandler(&buf, nil))
+		sceneID := createTestScene(t, dbPair)
+		routine := createTestRoutine(t, routinesRepo, sceneID)
+		job := createTestJob(t, jobsRepo, routine.RoutineID, time.Now().UTC().Add(-1*time.Minute))
+
+		runner := NewJobRunner(logger, jobsRepo, routinesRepo, executor, 100*time.Millisecond, 3)
+		runner.Start()
+		time.Sleep(300 * time.Millisecond)
+		runner.Stop()
+
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+		assert.Equal(t, "Executing routine", line["msg"])
+		assert.Equal(t, job.JobID, line["job_id"])
+		assert.Equal(t, routine.RoutineID, line["routine_id"])
+	})
+
 	t.Run("does not execute future jobs", func(t *testing.T) {
 		executor2 := newMockRoutineExecutor()
 		sceneID := createTestScene(t, dbPair)
@@ -317,6 +346,150 @@ func TestJobRunner_RetryLogic(t *testing.T) {
 	})
 }
 
+func TestJobRunner_RoutineRetryPolicy(t *testing.T) {
+	dbPair := setupRunnerTestDB(t)
+
+	jobsRepo := NewJobsRepository(dbPair)
+	routinesRepo := NewRoutinesRepository(dbPair)
+	executor := newMockRoutineExecutor()
+	executor.setFailure(true, errors.New("speaker offline"))
+	logger := newTestLogger()
+	runner := NewJobRunner(logger, jobsRepo, routinesRepo, executor, 100*time.Millisecond, 3)
+
+	t.Run("defaults to the runner's attempts with a two second backoff", func(t *testing.T) {
+		routine := createTestRoutine(t, routinesRepo, createTestScene(t, dbPair))
+		require.Zero(t, routine.MaxAttempts)
+		require.Equal(t, DefaultRetryBackoffSeconds, routine.RetryBackoffSeconds)
+
+		fiveRetries := NewJobRunner(logger, jobsRepo, routinesRepo, executor, 100*time.Millisecond, 5)
+		maxAttempts, backoffSeconds := fiveRetries.retryPolicy(routine)
+		require.Equal(t, 5, maxAttempts)
+		require.Equal(t, DefaultRetryBackoffSeconds, backoffSeconds)
+	})
+
+	t.Run("waits the routine's backoff before retrying", func(t *testing.T) {
+		routine := createTestRoutine(t, routinesRepo, createTestScene(t, dbPair))
+		backoff := 120
+		_, err := routinesRepo.Update(routine.RoutineID, UpdateRoutineInput{RetryBackoffSeconds: &backoff})
+		require.NoError(t, err)
+		job := createTestJob(t, jobsRepo, routine.RoutineID, time.Now().UTC().Add(-1*time.Minute))
+
+		before := time.Now().UTC()
+		require.Error(t, runner.executeJob(job))
+
+		updatedJob, err := jobsRepo.GetByID(job.JobID)
+		require.NoError(t, err)
+		assert.Equal(t, JobStatusPending, updatedJob.Status)
+		require.NotNil(t, updatedJob.RetryAfter)
+		assert.WithinDuration(t, before.Add(2*time.Minute), *updatedJob.RetryAfter, 2*time.Second)
+
+		// Not eligible again until the backoff passes
+		pending, err := jobsRepo.GetPendingJobs(MaxPendingJobs)
+		require.NoError(t, err)
+		for _, p := range pending {
+			assert.NotEqual(t, job.JobID, p.JobID)
+		}
+	})
+
+	t.Run("fails permanently at the routine's max attempts", func(t *testing.T) {
+		routine := createTestRoutine(t, routinesRepo, createTestScene(t, dbPair))
+		maxAttempts := 1
+		_, err := routinesRepo.Update(routine.RoutineID, UpdateRoutineInput{MaxAttempts: &maxAttempts})
+		require.NoError(t, err)
+		job := createTestJob(t, jobsRepo, routine.RoutineID, time.Now().UTC().Add(-1*time.Minute))
+
+		require.Error(t, runner.executeJob(job))
+
+		updatedJob, err := jobsRepo.GetByID(job.JobID)
+		require.NoError(t, err)
+		assert.Equal(t, JobStatusFailed, updatedJob.Status)
+		assert.Equal(t, 1, updatedJob.Attempts)
+		assert.Nil(t, updatedJob.RetryAfter)
+	})
+}
+
+type publishedEvent struct {
+	event webhooks.Event
+	data  map[string]any
+}
+
+type recordingPublisher struct {
+	events []publishedEvent
+}
+
+func (p *recordingPublisher) Publish(event webhooks.Event, data map[string]any) {
+	p.events = append(p.events, publishedEvent{event: event, data: data})
+}
+
+func TestJobRunner_PublishesEvents(t *testing.T) {
+	dbPair := setupRunnerTestDB(t)
+
+	jobsRepo := NewJobsRepository(dbPair)
+	routinesRepo := NewRoutinesRepository(dbPair)
+	executor := newMockRoutineExecutor()
+	runner := NewJobRunner(newTestLogger(), jobsRepo, routinesRepo, executor, 100*time.Millisecond, 3)
+	publisher := &recordingPublisher{}
+	runner.SetEventPublisher(publisher)
+
+	t.Run("completed", func(t *testing.T) {
+		publisher.events = nil
+		routine := createTestRoutine(t, routinesRepo, createTestScene(t, dbPair))
+		job := createTestJob(t, jobsRepo, routine.RoutineID, time.Now().UTC().Add(-1*time.Minute))
+
+		require.NoError(t, runner.executeJob(job))
+
+		require.Len(t, publisher.events, 1)
+		require.Equal(t, webhooks.EventRoutineCompleted, publisher.events[0].event)
+		require.Equal(t, job.JobID, publisher.events[0].data["job_id"])
+		require.Equal(t, routine.RoutineID, publisher.events[0].data["routine_id"])
+		require.Equal(t, routine.Name, publisher.events[0].data["routine_name"])
+	})
+
+	t.Run("failed only once no retries are left", func(t *testing.T) {
+		publisher.events = nil
+		executor.setFailure(true, errors.New("speaker offline"))
+		defer executor.setFailure(false, nil)
+
+		routine := createTestRoutine(t, routinesRepo, createTestScene(t, dbPair))
+		maxAttempts := 2
+		_, err := routinesRepo.Update(routine.RoutineID, UpdateRoutineInput{MaxAttempts: &maxAttempts})
+		require.NoError(t, err)
+		job := createTestJob(t, jobsRepo, routine.RoutineID, time.Now().UTC().Add(-1*time.Minute))
+
+		require.Error(t, runner.executeJob(job))
+		require.Empty(t, publisher.events, "a failure that will be retried isn't published")
+
+		job, err = jobsRepo.GetByID(job.JobID)
+		require.NoError(t, err)
+		require.Error(t, runner.executeJob(job))
+
+		require.Len(t, publisher.events, 1)
+		require.Equal(t, webhooks.EventRoutineFailed, publisher.events[0].event)
+		require.Equal(t, "speaker offline", publisher.events[0].data["error"])
+		require.Equal(t, 2, publisher.events[0].data["attempts"])
+	})
+}
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		base     int
+		failures int
+		want     time.Duration
+	}{
+		{2, 1, 2 * time.Second},
+		{2, 2, 4 * time.Second},
+		{2, 3, 8 * time.Second},
+		{60, 3, 4 * time.Minute},
+		{0, 1, DefaultRetryBackoffSeconds * time.Second},
+		{600, 5, MaxRetryBackoffSeconds * time.Second},
+		{2, 100, MaxRetryBackoffSeconds * time.Second},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, RetryBackoff(tt.base, tt.failures), "base %d, failures %d", tt.base, tt.failures)
+	}
+}
+
 func TestJobRunner_RecoverStaleJobs(t *testing.T) {
 	dbPair := setupRunnerTestDB(t)
 
@@ -470,6 +643,61 @@ func TestJobRunner_MissingRoutine(t *testing.T) {
 		// Executor should not have been called
 		assert.Equal(t, 0, executor.getExecutionCount())
 	})
+
+	t.Run("job is skipped when its routine is soft-deleted", func(t *testing.T) {
+		sceneID := createTestScene(t, dbPair)
+		routine := createTestRoutine(t, routinesRepo, sceneID)
+		job := createTestJob(t, jobsRepo, routine.RoutineID, time.Now().UTC().Add(-1*time.Minute))
+		require.NoError(t, routinesRepo.Delete(routine.RoutineID))
+
+		runner := NewJobRunner(logger, jobsRepo, routinesRepo, executor, 100*time.Millisecond, 3)
+		runner.Start()
+		time.Sleep(200 * time.Millisecond)
+		runner.Stop()
+
+		skippedJob, err := jobsRepo.GetByID(job.JobID)
+		require.NoError(t, err)
+		assert.Equal(t, JobStatusSkipped, skippedJob.Status)
+		require.NotNil(t, skippedJob.LastError)
+		assert.Equal(t, "routine_deleted", *skippedJob.LastError)
+		assert.Equal(t, 0, executor.getExecutionCount())
+	})
+}
+
+// fakeRuntimeSettings reports every time as in quiet hours, or none.
+type fakeRuntimeSettings struct {
+	timezone string
+	quiet    bool
+}
+
+func (f fakeRuntimeSettings) DefaultTimezone() string     { return f.timezone }
+func (f fakeRuntimeSettings) InQuietHours(time.Time) bool { return f.quiet }
+
+func TestJobRunner_QuietHours(t *testing.T) {
+	dbPair := setupRunnerTestDB(t)
+	jobsRepo := NewJobsRepository(dbPair)
+	routinesRepo := NewRoutinesRepository(dbPair)
+	executor := newMockRoutineExecutorWithDB(dbPair)
+	runner := NewJobRunner(newTestLogger(), jobsRepo, routinesRepo, executor, 100*time.Millisecond, 3)
+	runner.SetRuntimeSettings(fakeRuntimeSettings{quiet: true})
+
+	routine := createTestRoutine(t, routinesRepo, createTestScene(t, dbPair))
+	scheduled := createTestJob(t, jobsRepo, routine.RoutineID, time.Now().UTC())
+	require.NoError(t, runner.executeJob(scheduled))
+
+	skipped, err := jobsRepo.GetByID(scheduled.JobID)
+	require.NoError(t, err)
+	assert.Equal(t, JobStatusSkipped, skipped.Status)
+	assert.Equal(t, "quiet_hours", *skipped.LastError)
+	assert.Equal(t, 0, executor.getExecutionCount())
+
+	// Manual triggers play anyway
+	triggered, err := jobsRepo.Create(CreateJobInput{RoutineID: routine.RoutineID, ScheduledFor: time.Now().UTC().Add(time.Minute), Manual: true})
+	require.NoError(t, err)
+	require.NoError(t, runner.executeJob(triggered))
+	completed, err := jobsRepo.GetByID(triggered.JobID)
+	require.NoError(t, err)
+	assert.Equal(t, JobStatusCompleted, completed.Status)
 }
 
 func TestJobRunner_UpdateLastRunAt(t *testing.T) {
@@ -536,3 +764,124 @@ func TestJobRunner_IdempotencyKey(t *testing.T) {
 		assert.Equal(t, idempotencyKey, *executor.executions[0].IdempotencyKey)
 	})
 }
+
+func TestSuspendedFor(t *testing.T) {
+	lastPoll := time.Now()
+
+	// A poll that ran long isn't a suspend, however long it took
+	require.Zero(t, suspendedFor(lastPoll, lastPoll.Add(10*time.Minute)))
+}
+
+func TestDecideMissedRun(t *testing.T) {
+	thirty := 30
+
+	assert.Equal(t, MissedRunDecisionSkip, DecideMissedRun(&Routine{MissedRunPolicy: MissedRunPolicySkip}, time.Hour))
+	assert.Equal(t, MissedRunDecisionSkip, DecideMissedRun(&Routine{}, time.Minute))
+	assert.Equal(t, MissedRunDecisionRun, DecideMissedRun(&Routine{MissedRunPolicy: MissedRunPolicyRunImmediately}, 5*time.Hour))
+	assert.Equal(t, MissedRunDecisionRun, DecideMissedRun(&Routine{MissedRunPolicy: MissedRunPolicyRunIfWithinMinutes, MissedRunWithinMinutes: &thirty}, 20*time.Minute))
+	assert.Equal(t, MissedRunDecisionSkip, DecideMissedRun(&Routine{MissedRunPolicy: MissedRunPolicyRunIfWithinMinutes, MissedRunWithinMinutes: &thirty}, 45*time.Minute))
+	assert.Equal(t, MissedRunDecisionSkip, DecideMissedRun(&Routine{MissedRunPolicy: MissedRunPolicyRunIfWithinMinutes}, time.Minute))
+}
+
+func TestJobRunner_MissedJobsOnStartup(t *testing.T) {
+	dbPair := setupRunnerTestDB(t)
+
+	jobsRepo := NewJobsRepository(dbPair)
+	routinesRepo := NewRoutinesRepository(dbPair)
+	executor := newMockRoutineExecutorWithDB(dbPair)
+	logger := newTestLogger()
+
+	createRoutineWithPolicy := func(policy MissedRunPolicy, withinMinutes *int) *Routine {
+		routine, err := routinesRepo.Create(CreateRoutineInput{
+			Name:                   "Missed " + string(policy),
+			Timezone:               "UTC",
+			ScheduleType:           ScheduleTypeWeekly,
+			ScheduleTime:           "03:00",
+			SceneID:                createTestScene(t, dbPair),
+			MissedRunPolicy:        policy,
+			MissedRunWithinMinutes: withinMinutes,
+		})
+		require.NoError(t, err)
+		return routine
+	}
+
+	sixty := 60
+	defaultRoutine := createRoutineWithPolicy("", nil)
+	require.Equal(t, MissedRunPolicySkip, defaultRoutine.MissedRunPolicy)
+	immediateRoutine := createRoutineWithPolicy(MissedRunPolicyRunImmediately, nil)
+	withinRoutine := createRoutineWithPolicy(MissedRunPolicyRunIfWithinMinutes, &sixty)
+
+	now := time.Now().UTC()
+	skippedJob := createTestJob(t, jobsRepo, defaultRoutine.RoutineID, now.Add(-5*time.Hour))
+	immediateJob := createTestJob(t, jobsRepo, immediateRoutine.RoutineID, now.Add(-5*time.Hour))
+	tooLateJob := createTestJob(t, jobsRepo, withinRoutine.RoutineID, now.Add(-3*time.Hour))
+	withinJob := createTestJob(t, jobsRepo, withinRoutine.RoutineID, now.Add(-30*time.Minute))
+	recentJob := createTestJob(t, jobsRepo, defaultRoutine.RoutineID, now.Add(-30*time.Second))
+
+	runner := NewJobRunner(logger, jobsRepo, routinesRepo, executor, 100*time.Millisecond, 3)
+	runner.Start()
+	time.Sleep(300 * time.Millisecond)
+	runner.Stop()
+
+	assertJob := func(jobID string, status JobStatus, decision *MissedRunDecision) {
+		job, err := jobsRepo.GetByID(jobID)
+		require.NoError(t, err)
+		assert.Equal(t, status, job.Status, jobID)
+		assert.Equal(t, decision, job.MissedRunDecision, jobID)
+	}
+	run, skip := MissedRunDecisionRun, MissedRunDecisionSkip
+
+	assertJob(skippedJob.JobID, JobStatusSkipped, &skip)
+	assertJob(immediateJob.JobID, JobStatusCompleted, &run)
+	assertJob(tooLateJob.JobID, JobStatusSkipped, &skip)
+	assertJob(withinJob.JobID, JobStatusCompleted, &run)
+	// Within the grace period: not a missed job, runs normally with no decision recorded
+	assertJob(recentJob.JobID, JobStatusCompleted, nil)
+	assert.Equal(t, 3, executor.getExecutionCount())
+}
+
+func TestJobRunner_StepLog(t *testing.T) {
+	dbPair := setupRunnerTestDB(t)
+
+	jobsRepo := NewJobsRepository(dbPair)
+	routinesRepo := NewRoutinesRepository(dbPair)
+	executor := newMockRoutineExecutorWithDB(dbPair)
+	logger := newTestLogger()
+
+	sceneID := createTestScene(t, dbPair)
+	routine := createTestRoutine(t, routinesRepo, sceneID)
+	job := createTestJob(t, jobsRepo, routine.RoutineID, time.Now().UTC().Add(-1*time.Minute))
+
+	runner := NewJobRunner(logger, jobsRepo, routinesRepo, executor, 100*time.Millisecond, 3)
+
+	// First attempt fails while executing the routine
+	executor.setFailure(true, errors.New("speaker unreachable"))
+	require.Error(t, runner.executeJob(job))
+
+	entries, err := jobsRepo.GetJobLog(job.JobID)
+	require.NoError(t, err)
+	steps := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		steps = append(steps, entry.Step)
+	}
+	assert.Equal(t, []string{"claim", "start", "load_routine", "execute_routine"}, steps)
+	failed := entries[len(entries)-1]
+	assert.Equal(t, 1, failed.Attempt)
+	assert.Equal(t, JobLogStatusFailed, failed.Status)
+	assert.Equal(t, "speaker unreachable", failed.Error)
+
+	// Retry succeeds and appends its own steps
+	executor.setFailure(false, nil)
+	job, err = jobsRepo.GetByID(job.JobID)
+	require.NoError(t, err)
+	require.NoError(t, runner.executeJob(job))
+
+	entries, err = jobsRepo.GetJobLog(job.JobID)
+	require.NoError(t, err)
+	require.Len(t, entries, 9)
+	for _, entry := range entries[4:] {
+		assert.Equal(t, 2, entry.Attempt)
+		assert.Equal(t, JobLogStatusOK, entry.Status, entry.Step)
+	}
+	assert.Equal(t, "complete", entries[8].Step)
+}
@@ -1,10 +1,11 @@
 package scheduler
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
 
@@ -15,7 +16,7 @@ import (
 // Service provides scheduler management functionality.
 type Service struct {
 	cfg             config.Config
-	logger          *log.Logger
+	logger          *slog.Logger
 	reader          *sql.DB // For ad-hoc read queries
 	writer          *sql.DB // For ad-hoc write queries
 	routinesRepo    *RoutinesRepository
@@ -37,17 +38,20 @@ type Service struct {
 func NewService(
 	cfg config.Config,
 	dbPair DBPair,
-	logger *log.Logger,
+	logger *slog.Logger,
 	routineExecutor RoutineExecutor,
 ) *Service {
 	if logger == nil {
-		logger = log.Default()
+		logger = slog.Default()
 	}
 
 	routinesRepo := NewRoutinesRepository(dbPair)
 	jobsRepo := NewJobsRepository(dbPair)
 	holidaysRepo := NewHolidaysRepository(dbPair)
 	generator := NewJobGenerator(routinesRepo, jobsRepo, holidaysRepo, logger)
+	if cfg.HasCoordinates {
+		generator.SetCoordinates(&Coordinates{Latitude: cfg.Latitude, Longitude: cfg.Longitude})
+	}
 
 	// Create job runner
 	runner := NewJobRunner(
@@ -78,6 +82,22 @@ func NewService(
 // Lifecycle
 // ==========================================================================
 
+// SetEventPublisher publishes routine.completed and routine.failed as jobs finish.
+// Call it before Start.
+func (s *Service) SetEventPublisher(events EventPublisher) {
+	s.runner.SetEventPublisher(events)
+}
+
+// SetWakeRamper starts the wake ramps of routines with a wake_profile. Call it before Start.
+func (s *Service) SetWakeRamper(wakeRamper *WakeRamper) {
+	s.runner.SetWakeRamper(wakeRamper)
+}
+
+// SetRuntimeSettings skips scheduled runs that fall in quiet hours. Call it before Start.
+func (s *Service) SetRuntimeSettings(runtime RuntimeSettings) {
+	s.runner.SetRuntimeSettings(runtime)
+}
+
 // Start starts the job runner and generation ticker.
 func (s *Service) Start() {
 	s.mu.Lock()
@@ -89,7 +109,7 @@ func (s *Service) Start() {
 	s.stopChan = make(chan struct{})
 	s.mu.Unlock()
 
-	s.logger.Printf("Starting scheduler service")
+	s.logger.Info("Starting scheduler service")
 
 	// Start job runner (it has its own goroutine management)
 	s.runner.Start()
@@ -117,14 +137,14 @@ func (s *Service) Stop() {
 	close(s.stopChan)
 	s.mu.Unlock()
 
-	s.logger.Printf("Stopping scheduler service")
+	s.logger.Info("Stopping scheduler service")
 
 	// Stop the job runner
 	s.runner.Stop()
 
 	// Wait for generation ticker to stop
 	s.wg.Wait()
-	s.logger.Printf("Scheduler service stopped")
+	s.logger.Info("Scheduler service stopped")
 }
 
 func (s *Service) runGenerationTicker() {
@@ -135,9 +155,9 @@ func (s *Service) runGenerationTicker() {
 
 	// Generate jobs immediately on start
 	if count, err := s.GenerateUpcomingJobs(); err != nil {
-		s.logger.Printf("Error generating jobs on start: %v", err)
+		s.logger.Error("Error generating jobs on start", "error", err)
 	} else if count > 0 {
-		s.logger.Printf("Generated %d job(s) on startup", count)
+		s.logger.Info("Generated jobs on startup", "count", count)
 	}
 
 	for {
@@ -146,9 +166,9 @@ func (s *Service) runGenerationTicker() {
 			return
 		case <-ticker.C:
 			if count, err := s.GenerateUpcomingJobs(); err != nil {
-				s.logger.Printf("Error generating jobs: %v", err)
+				s.logger.Error("Error generating jobs", "error", err)
 			} else if count > 0 {
-				s.logger.Printf("Generated %d job(s)", count)
+				s.logger.Info("Generated jobs", "count", count)
 			}
 		}
 	}
@@ -423,6 +443,32 @@ func (s *Service) ListJobsForRoutine(routineID string, limit, offset int) ([]Job
 	return s.jobsRepo.ListByRoutineID(routineID, limit, offset)
 }
 
+// RecentExecutions returns the limit most recent runs that finished (completed, failed
+// or skipped), newest first, formatted as GET /v1/executions formats them.
+func (s *Service) RecentExecutions(limit int) ([]map[string]any, error) {
+	jobs, _, err := s.jobsRepo.ListAll(JobQueryFilters{
+		Statuses: []JobStatus{JobStatusCompleted, JobStatusFailed, JobStatusSkipped},
+		Limit:    limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	routineNames := make(map[string]string)
+	routines, _, err := s.routinesRepo.List(1000, 0, false)
+	if err == nil {
+		for _, routine := range routines {
+			routineNames[routine.RoutineID] = routine.Name
+		}
+	}
+
+	executions := make([]map[string]any, 0, len(jobs))
+	for _, job := range jobs {
+		executions = append(executions, formatJobAsExecution(&job, routineNames))
+	}
+	return executions, nil
+}
+
 // ==========================================================================
 // Holiday Management
 // ==========================================================================
@@ -470,6 +516,11 @@ func (s *Service) IsHoliday(date time.Time) (bool, *Holiday, error) {
 // Job Generation
 // ==========================================================================
 
+// JobGenerator returns the generator used for scheduling, which also computes upcoming runs for display.
+func (s *Service) JobGenerator() *JobGenerator {
+	return s.generator
+}
+
 // GenerateUpcomingJobs generates jobs for all due routines.
 // This is called periodically by the generation ticker.
 func (s *Service) GenerateUpcomingJobs() (int, error) {
@@ -491,8 +542,8 @@ func NewSceneServiceAdapter(sceneService *scene.Service) *SceneServiceAdapter {
 }
 
 // ExecuteScene implements SceneExecutor interface.
-func (a *SceneServiceAdapter) ExecuteScene(sceneID string, idempotencyKey *string, options scene.ExecuteOptions) (*scene.SceneExecution, error) {
-	return a.sceneService.ExecuteScene(sceneID, idempotencyKey, options)
+func (a *SceneServiceAdapter) ExecuteScene(ctx context.Context, sceneID string, idempotencyKey *string, options scene.ExecuteOptions) (*scene.SceneExecution, error) {
+	return a.sceneService.ExecuteScene(ctx, sceneID, idempotencyKey, options)
 }
 
 // ==========================================================================
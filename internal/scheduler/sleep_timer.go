@@ -0,0 +1,41 @@
+package scheduler
+
+import (
+	"context"
+
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/scene"
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
+)
+
+// SetSleepTimerController enables sleep_timer_minutes: once a routine's playback has
+// started, the sleep timer is armed on the coordinator so playback stops on its own.
+func (a *RoutineExecutorAdapter) SetSleepTimerController(client sonos.SleepTimerClient) {
+	a.sleepTimer = client
+}
+
+// applySleepTimer arms the routine's sleep timer on the coordinator the scene played on.
+// Failures are logged and don't fail the run. Returns the minutes armed, or nil when no
+// timer was set.
+func (a *RoutineExecutorAdapter) applySleepTimer(ctx context.Context, routine *Routine, execution *scene.SceneExecution) *int {
+	if a.sleepTimer == nil || a.ipResolver == nil || routine.SleepTimerMinutes == nil || *routine.SleepTimerMinutes <= 0 {
+		return nil
+	}
+	if execution == nil || execution.CoordinatorUsedUDN == nil {
+		return nil
+	}
+
+	ip, err := a.ipResolver.ResolveDeviceIP(*execution.CoordinatorUsedUDN)
+	if err != nil || ip == "" {
+		logging.From(ctx, a.logger).Warn("Failed to resolve coordinator to set sleep timer",
+			"udn", *execution.CoordinatorUsedUDN, "error", err)
+		return nil
+	}
+
+	if err := sonos.SetSleepTimer(a.sleepTimer, ip, *routine.SleepTimerMinutes); err != nil {
+		logging.From(ctx, a.logger).Warn("Failed to set sleep timer", "error", err)
+		return nil
+	}
+	minutes := *routine.SleepTimerMinutes
+	return &minutes
+}
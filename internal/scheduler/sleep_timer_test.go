@@ -0,0 +1,80 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/scene"
+)
+
+type fakeSleepTimerClient struct {
+	durations map[string]string
+}
+
+func (f *fakeSleepTimerClient) ConfigureSleepTimer(deviceIP, duration string) error {
+	f.durations[deviceIP] = duration
+	return nil
+}
+
+func TestRoutinesRepository_SleepTimerMinutes(t *testing.T) {
+	routinesRepo, _, _, scenesRepo := setupTestDB(t)
+
+	s, err := scenesRepo.Create(scene.CreateSceneInput{
+		Name:    "Test Scene",
+		Members: []scene.SceneMember{},
+	})
+	require.NoError(t, err)
+
+	minutes := 45
+	routine, err := routinesRepo.Create(CreateRoutineInput{
+		Name:              "Bedtime",
+		Timezone:          "UTC",
+		ScheduleTime:      "22:00",
+		SceneID:           s.SceneID,
+		SleepTimerMinutes: &minutes,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, routine.SleepTimerMinutes)
+	require.Equal(t, 45, *routine.SleepTimerMinutes)
+
+	// Unrelated updates keep the timer; zero clears it
+	newName := "Bedtime Music"
+	updated, err := routinesRepo.Update(routine.RoutineID, UpdateRoutineInput{Name: &newName})
+	require.NoError(t, err)
+	require.Equal(t, routine.SleepTimerMinutes, updated.SleepTimerMinutes)
+
+	zero := 0
+	updated, err = routinesRepo.Update(routine.RoutineID, UpdateRoutineInput{SleepTimerMinutes: &zero})
+	require.NoError(t, err)
+	require.Nil(t, updated.SleepTimerMinutes)
+}
+
+func TestRoutineExecutorAdapter_SleepTimer(t *testing.T) {
+	client := &fakeSleepTimerClient{durations: map[string]string{}}
+	adapter := &RoutineExecutorAdapter{
+		sceneExecutor: &coordinatorSceneExecutor{coordinatorUDN: "udn-bedroom"},
+		ipResolver:    fakeIPResolver{"udn-bedroom": "10.0.0.1"},
+		logger:        logging.Discard(),
+	}
+	adapter.SetSleepTimerController(client)
+
+	t.Run("no sleep timer leaves the speaker alone", func(t *testing.T) {
+		execution, err := adapter.ExecuteRoutine(context.Background(), &Routine{RoutineID: "routine-1", SceneID: "scene-1"}, time.Time{}, nil)
+		require.NoError(t, err)
+		require.Nil(t, execution.Detail.SleepTimerMinutes)
+		require.Empty(t, client.durations)
+	})
+
+	t.Run("sleep timer is armed on the coordinator", func(t *testing.T) {
+		minutes := 30
+		routine := &Routine{RoutineID: "routine-1", SceneID: "scene-1", SleepTimerMinutes: &minutes}
+		execution, err := adapter.ExecuteRoutine(context.Background(), routine, time.Time{}, nil)
+		require.NoError(t, err)
+		require.Equal(t, &minutes, execution.Detail.SleepTimerMinutes)
+		require.Equal(t, "00:30:00", client.durations["10.0.0.1"])
+	})
+}
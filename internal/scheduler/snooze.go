@@ -0,0 +1,42 @@
+package scheduler
+
+import (
+	"time"
+)
+
+// MaxSnoozeDurationMinutes bounds a relative snooze to one week.
+const MaxSnoozeDurationMinutes = 7 * 24 * 60
+
+// SnoozePreset is a named snooze whose end is computed server-side.
+type SnoozePreset string
+
+const (
+	// SnoozePresetUntilTomorrow snoozes for the rest of today, resuming with the routine's
+	// next scheduled occurrence on a later day.
+	SnoozePresetUntilTomorrow SnoozePreset = "until_tomorrow"
+)
+
+// SnoozeUntilTomorrow returns when an until_tomorrow snooze ends: the start of the day
+// (in the routine's timezone) of the routine's next occurrence after today. Ending at the
+// start of that day rather than at the occurrence itself lets the occurrence fire.
+// Falls back to the start of tomorrow when the next occurrence can't be computed.
+func (g *JobGenerator) SnoozeUntilTomorrow(routine *Routine, now time.Time) time.Time {
+	loc, err := time.LoadLocation(routine.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	local := now.In(loc)
+	tomorrow := time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, loc)
+	if g == nil {
+		return tomorrow
+	}
+
+	next, err := g.CalculateNextRun(routine, tomorrow.Add(-time.Nanosecond))
+	if err != nil || next.IsZero() {
+		return tomorrow
+	}
+
+	next = next.In(loc)
+	return time.Date(next.Year(), next.Month(), next.Day(), 0, 0, 0, 0, loc)
+}
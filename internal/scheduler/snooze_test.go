@@ -0,0 +1,35 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnoozeUntilTomorrow(t *testing.T) {
+	generator := NewJobGenerator(nil, nil, nil, nil)
+	ny, _ := time.LoadLocation("America/New_York")
+
+	routine := &Routine{
+		ScheduleType:     ScheduleTypeWeekly,
+		ScheduleWeekdays: []int{int(time.Monday), int(time.Wednesday)},
+		ScheduleTime:     "07:00",
+		Timezone:         "America/New_York",
+		Enabled:          true,
+	}
+
+	// Monday June 23, 2025 at 22:30 in New York is already Tuesday in UTC.
+	// The next occurrence after today is Wednesday, so the snooze ends at its midnight.
+	now := time.Date(2025, 6, 24, 2, 30, 0, 0, time.UTC)
+	require.Equal(t, time.Date(2025, 6, 25, 0, 0, 0, 0, ny), generator.SnoozeUntilTomorrow(routine, now))
+
+	// A daily routine resumes tomorrow
+	routine.ScheduleWeekdays = []int{0, 1, 2, 3, 4, 5, 6}
+	require.Equal(t, time.Date(2025, 6, 24, 0, 0, 0, 0, ny), generator.SnoozeUntilTomorrow(routine, now))
+
+	// Without a generator the snooze ends at the start of tomorrow
+	var noGenerator *JobGenerator
+	routine.ScheduleWeekdays = []int{int(time.Wednesday)}
+	require.Equal(t, time.Date(2025, 6, 24, 0, 0, 0, 0, ny), noGenerator.SnoozeUntilTomorrow(routine, now))
+}
@@ -0,0 +1,120 @@
+package scheduler
+
+import (
+	"math"
+	"time"
+)
+
+// MaxSolarOffsetMinutes bounds how far before or after sunrise/sunset a routine may fire.
+const MaxSolarOffsetMinutes = 180
+
+// solarZenith is the official sunrise/sunset zenith, which accounts for refraction
+// and the sun's radius.
+const solarZenith = 90.833
+
+// Coordinates is the hub's location, used to compute sunrise and sunset.
+type Coordinates struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// SolarEventTime returns the time of sunrise or sunset on the given calendar date in loc,
+// using the NOAA almanac algorithm (accurate to a minute or two outside polar regions).
+// Only date's year, month, and day are used. Returns false when the sun does not rise
+// or set that day.
+func SolarEventTime(date time.Time, mode TimeMode, coords Coordinates, loc *time.Location) (time.Time, bool) {
+	if !mode.IsSolar() {
+		return time.Time{}, false
+	}
+	sunrise := mode == TimeModeSunrise
+
+	dayOfYear := float64(time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC).YearDay())
+	lngHour := coords.Longitude / 15
+
+	// Approximate time of the event
+	t := dayOfYear + (18-lngHour)/24
+	if sunrise {
+		t = dayOfYear + (6-lngHour)/24
+	}
+
+	// Sun's mean anomaly and true longitude
+	meanAnomaly := 0.9856*t - 3.289
+	trueLongitude := normalizeDegrees(meanAnomaly + 1.916*sinDeg(meanAnomaly) + 0.020*sinDeg(2*meanAnomaly) + 282.634)
+
+	// Right ascension, moved into the same quadrant as the true longitude, in hours
+	rightAscension := normalizeDegrees(radToDeg(math.Atan(0.91764 * tanDeg(trueLongitude))))
+	rightAscension += math.Floor(trueLongitude/90)*90 - math.Floor(rightAscension/90)*90
+	rightAscension /= 15
+
+	// Sun's declination and local hour angle
+	sinDec := 0.39782 * sinDeg(trueLongitude)
+	cosDec := math.Cos(math.Asin(sinDec))
+	cosHourAngle := (cosDeg(solarZenith) - sinDec*sinDeg(coords.Latitude)) / (cosDec * cosDeg(coords.Latitude))
+	if cosHourAngle > 1 || cosHourAngle < -1 {
+		return time.Time{}, false
+	}
+
+	hourAngle := radToDeg(math.Acos(cosHourAngle))
+	if sunrise {
+		hourAngle = 360 - hourAngle
+	}
+	hourAngle /= 15
+
+	localMeanTime := hourAngle + rightAscension - 0.06571*t - 6.622
+	utcHours := math.Mod(localMeanTime-lngHour, 24)
+	if utcHours < 0 {
+		utcHours += 24
+	}
+
+	event := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC).
+		Add(time.Duration(utcHours * float64(time.Hour))).In(loc)
+
+	// The UTC calculation can land on the neighbouring local day; pull it back onto date
+	if diff := daysBetween(date, event); diff != 0 {
+		event = event.AddDate(0, 0, -diff)
+	}
+
+	return event.Truncate(time.Minute), true
+}
+
+// calculateSolarNextRun finds the next run of a sunrise/sunset schedule. The schedule type
+// picks the dates; each candidate date's run time is the solar event plus the offset.
+func (g *JobGenerator) calculateSolarNextRun(routine *Routine, after time.Time, loc *time.Location) (time.Time, error) {
+	dates := *routine
+	dates.ScheduleTime = "00:00"
+	dates.ScheduleTimeMode = TimeModeFixed
+
+	offset := time.Duration(routine.ScheduleOffsetMinutes) * time.Minute
+
+	// Start just before midnight so today is a candidate date, then walk forward.
+	// A year of dates covers polar nights where the sun never rises.
+	cursor := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, loc).Add(-time.Nanosecond)
+	for i := 0; i < 366; i++ {
+		day, err := g.CalculateNextRun(&dates, cursor)
+		if err != nil || day.IsZero() {
+			return day, err
+		}
+
+		if event, ok := SolarEventTime(day, routine.ScheduleTimeMode, *g.coordinates, loc); ok {
+			if run := event.Add(offset); run.After(after) {
+				return run, nil
+			}
+		}
+		cursor = day
+	}
+
+	return time.Time{}, nil
+}
+
+func normalizeDegrees(deg float64) float64 {
+	deg = math.Mod(deg, 360)
+	if deg < 0 {
+		deg += 360
+	}
+	return deg
+}
+
+func radToDeg(rad float64) float64 { return rad * 180 / math.Pi }
+func sinDeg(deg float64) float64   { return math.Sin(deg * math.Pi / 180) }
+func cosDeg(deg float64) float64   { return math.Cos(deg * math.Pi / 180) }
+func tanDeg(deg float64) float64   { return math.Tan(deg * math.Pi / 180) }
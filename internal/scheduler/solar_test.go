@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSolarEventTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		coords   Coordinates
+		timezone string
+		date     time.Time
+		mode     TimeMode
+		want     string // HH:MM local, from published almanac tables
+	}{
+		{"new york sunrise", Coordinates{40.7128, -74.0060}, "America/New_York", time.Date(2025, 6, 21, 0, 0, 0, 0, time.UTC), TimeModeSunrise, "05:25"},
+		{"new york sunset", Coordinates{40.7128, -74.0060}, "America/New_York", time.Date(2025, 6, 21, 0, 0, 0, 0, time.UTC), TimeModeSunset, "20:31"},
+		{"london winter sunrise", Coordinates{51.5074, -0.1278}, "Europe/London", time.Date(2025, 12, 21, 0, 0, 0, 0, time.UTC), TimeModeSunrise, "08:04"},
+		// Sunset falls on the next UTC day
+		{"los angeles sunset", Coordinates{34.0522, -118.2437}, "America/Los_Angeles", time.Date(2025, 6, 21, 0, 0, 0, 0, time.UTC), TimeModeSunset, "20:08"},
+		// Sunrise falls on the previous UTC day
+		{"sydney sunrise", Coordinates{-33.8688, 151.2093}, "Australia/Sydney", time.Date(2025, 12, 21, 0, 0, 0, 0, time.UTC), TimeModeSunrise, "05:41"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, err := time.LoadLocation(tt.timezone)
+			require.NoError(t, err)
+
+			got, ok := SolarEventTime(tt.date, tt.mode, tt.coords, loc)
+			require.True(t, ok)
+			require.Equal(t, tt.date.Day(), got.Day(), "event should fall on the requested local date")
+
+			want, err := time.ParseInLocation("2006-01-02 15:04", tt.date.Format("2006-01-02")+" "+tt.want, loc)
+			require.NoError(t, err)
+			require.InDelta(t, 0, got.Sub(want).Minutes(), 3, "got %s, want %s", got.Format("15:04"), tt.want)
+		})
+	}
+}
+
+func TestSolarEventTime_PolarNight(t *testing.T) {
+	tromso := Coordinates{Latitude: 69.6496, Longitude: 18.9560}
+	_, ok := SolarEventTime(time.Date(2025, 12, 21, 0, 0, 0, 0, time.UTC), TimeModeSunrise, tromso, time.UTC)
+	require.False(t, ok)
+
+	_, ok = SolarEventTime(time.Date(2025, 12, 21, 0, 0, 0, 0, time.UTC), TimeModeFixed, tromso, time.UTC)
+	require.False(t, ok)
+}
+
+func solarTestRoutine(mode TimeMode, offset int) *Routine {
+	return &Routine{
+		RoutineID:             "test-solar",
+		ScheduleType:          ScheduleTypeWeekly,
+		ScheduleWeekdays:      []int{0, 1, 2, 3, 4, 5, 6},
+		ScheduleTime:          "07:00",
+		ScheduleTimeMode:      mode,
+		ScheduleOffsetMinutes: offset,
+		Timezone:              "America/New_York",
+		Enabled:               true,
+	}
+}
+
+func TestCalculateNextRun_Sunrise(t *testing.T) {
+	generator := NewJobGenerator(nil, nil, nil, nil)
+	generator.SetCoordinates(&Coordinates{Latitude: 40.7128, Longitude: -74.0060})
+	ny, _ := time.LoadLocation("America/New_York")
+	routine := solarTestRoutine(TimeModeSunrise, 15)
+
+	sunrise, ok := SolarEventTime(time.Date(2025, 6, 21, 0, 0, 0, 0, time.UTC), TimeModeSunrise, *generator.coordinates, ny)
+	require.True(t, ok)
+
+	// Before today's sunrise: today, 15 minutes after sunrise
+	nextRun, err := generator.CalculateNextRun(routine, time.Date(2025, 6, 21, 1, 0, 0, 0, ny))
+	require.NoError(t, err)
+	require.Equal(t, sunrise.Add(15*time.Minute), nextRun)
+
+	// After today's run: tomorrow's sunrise
+	nextRun, err = generator.CalculateNextRun(routine, time.Date(2025, 6, 21, 12, 0, 0, 0, ny))
+	require.NoError(t, err)
+	require.Equal(t, 22, nextRun.Day())
+	require.Equal(t, 5, nextRun.Hour())
+}
+
+func TestCalculateNextRun_SunsetNegativeOffsetSkipsUnscheduledDays(t *testing.T) {
+	generator := NewJobGenerator(nil, nil, nil, nil)
+	generator.SetCoordinates(&Coordinates{Latitude: 40.7128, Longitude: -74.0060})
+	ny, _ := time.LoadLocation("America/New_York")
+	routine := solarTestRoutine(TimeModeSunset, -30)
+	routine.ScheduleWeekdays = []int{int(time.Monday)}
+
+	// Saturday June 21, 2025 -> Monday June 23
+	nextRun, err := generator.CalculateNextRun(routine, time.Date(2025, 6, 21, 12, 0, 0, 0, ny))
+	require.NoError(t, err)
+	sunset, ok := SolarEventTime(time.Date(2025, 6, 23, 0, 0, 0, 0, time.UTC), TimeModeSunset, *generator.coordinates, ny)
+	require.True(t, ok)
+	require.Equal(t, sunset.Add(-30*time.Minute), nextRun)
+}
+
+func TestCalculateNextRun_SolarWithoutCoordinates(t *testing.T) {
+	generator := NewJobGenerator(nil, nil, nil, nil)
+	ny, _ := time.LoadLocation("America/New_York")
+
+	nextRun, err := generator.CalculateNextRun(solarTestRoutine(TimeModeSunrise, 15), time.Date(2025, 6, 21, 1, 0, 0, 0, ny))
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2025, 6, 21, 7, 0, 0, 0, ny), nextRun)
+}
+
+func TestUpcomingRun(t *testing.T) {
+	generator := NewJobGenerator(nil, nil, nil, nil)
+	ny, _ := time.LoadLocation("America/New_York")
+	now := time.Date(2025, 6, 21, 1, 0, 0, 0, ny)
+
+	routine := solarTestRoutine(TimeModeFixed, 0)
+	next := generator.UpcomingRun(routine, now)
+	require.NotNil(t, next)
+	require.Equal(t, time.Date(2025, 6, 21, 7, 0, 0, 0, ny).UTC(), *next)
+
+	routine.SkipNext = true
+	next = generator.UpcomingRun(routine, now)
+	require.NotNil(t, next)
+	require.Equal(t, time.Date(2025, 6, 22, 7, 0, 0, 0, ny).UTC(), *next)
+
+	routine.SkipNext = false
+	snoozeUntil := time.Date(2025, 6, 23, 12, 0, 0, 0, ny)
+	routine.SnoozeUntil = &snoozeUntil
+	next = generator.UpcomingRun(routine, now)
+	require.NotNil(t, next)
+	require.Equal(t, time.Date(2025, 6, 24, 7, 0, 0, 0, ny).UTC(), *next)
+
+	routine.Enabled = false
+	require.Nil(t, generator.UpcomingRun(routine, now))
+
+	var noGenerator *JobGenerator
+	require.Nil(t, noGenerator.UpcomingRun(solarTestRoutine(TimeModeFixed, 0), now))
+}
@@ -0,0 +1,194 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/audit"
+	"github.com/strefethen/sonos-hub-go/internal/devices"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/music"
+	"github.com/strefethen/sonos-hub-go/internal/scene"
+	"github.com/strefethen/sonos-hub-go/internal/templates"
+)
+
+// defaultTemplateSpeakerVolume is used for suggested speakers that don't give a volume.
+const defaultTemplateSpeakerVolume = 25
+
+// instantiateTemplateRequest holds the overrides applied over a template's defaults.
+// Anything left out comes from the template.
+type instantiateTemplateRequest struct {
+	Name         *string        `json:"name,omitempty"`
+	Speakers     []SpeakerInput `json:"speakers,omitempty"` // Instead of the template's suggested speakers
+	ScheduleTime *string        `json:"schedule_time,omitempty"`
+	MusicSetID   *string        `json:"music_set_id,omitempty"`
+}
+
+// suggestedSpeaker is an entry in a template's suggested_speakers.
+type suggestedSpeaker struct {
+	Room   string `json:"room"`
+	Volume *int   `json:"volume"`
+}
+
+// instantiateTemplate handles POST /v1/routine-templates/{template_id}/instantiate
+// It creates a routine, and its scene, from the template with the overrides applied.
+func instantiateTemplate(templatesService *templates.Service, routinesRepo *RoutinesRepository, sceneService *scene.Service, deviceService *devices.Service, musicService *music.Service, nextRuns *JobGenerator, recorder audit.Recorder, runtime RuntimeSettings) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		localTZ, err := parseLocalTimeZone(r)
+		if err != nil {
+			return err
+		}
+
+		templateID := chi.URLParam(r, "template_id")
+		template, err := templatesService.GetTemplate(templateID)
+		if err != nil {
+			logging.From(r.Context(), nil).Error("Failed to get template", "template_id", templateID, "error", err)
+			return apperrors.NewInternalError("Failed to fetch template")
+		}
+		if template == nil {
+			return apperrors.NewNotFoundError("Template not found", map[string]any{"template_id": templateID})
+		}
+
+		var overrides instantiateTemplateRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&overrides); err != nil {
+				return apperrors.NewValidationError("invalid request body", nil)
+			}
+		}
+
+		deviceRoomMap := buildDeviceRoomMap(deviceService)
+		req, err := mergeTemplate(template, overrides, deviceRoomMap)
+		if err != nil {
+			return err
+		}
+
+		routine, err := createRoutineFromRequest(r, req, routinesRepo, sceneService, musicService, recorder, runtime)
+		if err != nil {
+			return err
+		}
+		logging.From(r.Context(), nil).Info("Created routine from template", "template_id", templateID, "routine_id", routine.RoutineID)
+
+		result := formatRoutineWithEnrichment(routine, deviceRoomMap, musicService, nextRuns, localTZ)
+		result["warnings"] = routineConflictWarnings(r, routine, routinesRepo, sceneService, nextRuns, deviceRoomMap)
+		return api.WriteResource(w, http.StatusCreated, result)
+	}
+}
+
+// mergeTemplate builds the request to create a routine from template's defaults with
+// the overrides applied. deviceRoomMap maps speaker UDNs to rooms, for finding the
+// template's suggested speakers.
+func mergeTemplate(template *templates.RoutineTemplate, overrides instantiateTemplateRequest, deviceRoomMap map[string]string) (createRoutineRequest, error) {
+	templateID := template.TemplateID
+	arcTVPolicy := ArcTVPolicy(template.ArcTVPolicy)
+	req := createRoutineRequest{
+		CreateRoutineInput: CreateRoutineInput{
+			Name:                       template.Name,
+			Timezone:                   template.Timezone,
+			ScheduleType:               ScheduleType(template.ScheduleType),
+			ScheduleMonth:              template.ScheduleMonth,
+			ScheduleDay:                template.ScheduleDay,
+			ScheduleTime:               template.ScheduleTime,
+			HolidayBehavior:            HolidayBehavior(template.HolidayBehavior),
+			MusicPolicyType:            MusicPolicyType(template.MusicPolicyType),
+			MusicSetID:                 template.MusicSetID,
+			MusicSonosFavoriteID:       template.MusicSonosFavoriteID,
+			MusicNoRepeatWindowMinutes: template.MusicNoRepeatWindowMinutes,
+			MusicFallbackBehavior:      template.MusicFallbackBehavior,
+			ArcTVPolicy:                &arcTVPolicy,
+			TemplateID:                 &templateID,
+		},
+	}
+	if template.ScheduleWeekdays != nil {
+		if err := json.Unmarshal([]byte(*template.ScheduleWeekdays), &req.ScheduleWeekdays); err != nil {
+			return req, apperrors.NewInternalError("Template has invalid schedule_weekdays")
+		}
+	}
+
+	if overrides.Name != nil {
+		name := strings.TrimSpace(*overrides.Name)
+		if name == "" {
+			return req, templateConflictError("name must not be empty", "name", "name", nil)
+		}
+		req.Name = name
+	}
+
+	if overrides.ScheduleTime != nil {
+		if err := validateSchedule(*overrides.ScheduleTime, nil, nil, nil, ""); err != nil {
+			return req, templateConflictError("schedule_time must be HH:MM in 24-hour format", "schedule_time", "schedule_time", map[string]any{
+				"template_value": template.ScheduleTime,
+				"override_value": *overrides.ScheduleTime,
+			})
+		}
+		req.ScheduleTime = *overrides.ScheduleTime
+	}
+
+	if overrides.MusicSetID != nil {
+		if req.MusicPolicyType == MusicPolicyTypeFixed {
+			return req, templateConflictError("music_set_id can't be used with the template's FIXED music policy, which plays a Sonos favorite", "music_policy_type", "music_set_id", map[string]any{
+				"template_value": template.MusicPolicyType,
+				"override_value": *overrides.MusicSetID,
+			})
+		}
+		req.MusicSetID = overrides.MusicSetID
+	}
+
+	if len(overrides.Speakers) > 0 {
+		req.Speakers = overrides.Speakers
+		return req, nil
+	}
+	speakers, err := suggestedSpeakers(template, deviceRoomMap)
+	if err != nil {
+		return req, err
+	}
+	req.Speakers = speakers
+	return req, nil
+}
+
+// suggestedSpeakers finds the speakers for a template's suggested rooms.
+func suggestedSpeakers(template *templates.RoutineTemplate, deviceRoomMap map[string]string) ([]SpeakerInput, error) {
+	var suggested []suggestedSpeaker
+	if template.SuggestedSpeakers != nil {
+		if err := json.Unmarshal([]byte(*template.SuggestedSpeakers), &suggested); err != nil {
+			return nil, apperrors.NewInternalError("Template has invalid suggested_speakers")
+		}
+	}
+	if len(suggested) == 0 {
+		return nil, templateConflictError("speakers is required: the template doesn't suggest any", "suggested_speakers", "speakers", nil)
+	}
+
+	udnsByRoom := make(map[string]string, len(deviceRoomMap))
+	for udn, room := range deviceRoomMap {
+		udnsByRoom[strings.ToLower(room)] = udn
+	}
+
+	speakers := make([]SpeakerInput, 0, len(suggested))
+	for _, s := range suggested {
+		udn, ok := udnsByRoom[strings.ToLower(s.Room)]
+		if !ok {
+			return nil, templateConflictError("the template suggests a room with no speaker; choose speakers instead", "suggested_speakers", "speakers", map[string]any{
+				"room": s.Room,
+			})
+		}
+		volume := defaultTemplateSpeakerVolume
+		if s.Volume != nil {
+			volume = *s.Volume
+		}
+		speakers = append(speakers, SpeakerInput{UDN: udn, Volume: volume})
+	}
+	return speakers, nil
+}
+
+// templateConflictError reports an override that conflicts with a template field.
+func templateConflictError(message, templateField, override string, details map[string]any) error {
+	if details == nil {
+		details = make(map[string]any, 2)
+	}
+	details["template_field"] = templateField
+	details["override"] = override
+	return apperrors.NewValidationError(message, details)
+}
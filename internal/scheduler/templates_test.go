@@ -0,0 +1,167 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/config"
+	"github.com/strefethen/sonos-hub-go/internal/db"
+	"github.com/strefethen/sonos-hub-go/internal/scene"
+	"github.com/strefethen/sonos-hub-go/internal/templates"
+)
+
+func strPtr(s string) *string { return &s }
+
+func testTemplate() *templates.RoutineTemplate {
+	return &templates.RoutineTemplate{
+		TemplateID:        "weekday-wake",
+		Name:              "Weekday Wake Up",
+		Timezone:          "America/Los_Angeles",
+		ScheduleType:      "weekly",
+		ScheduleWeekdays:  strPtr("[1,2,3,4,5]"),
+		ScheduleTime:      "07:00",
+		SuggestedSpeakers: strPtr(`[{"room":"Bedroom","volume":15},{"room":"Kitchen"}]`),
+		MusicPolicyType:   "FIXED",
+		HolidayBehavior:   "SKIP",
+		ArcTVPolicy:       "SKIP",
+	}
+}
+
+func TestMergeTemplate(t *testing.T) {
+	deviceRoomMap := map[string]string{"RINCON_BEDROOM": "Bedroom", "RINCON_KITCHEN": "kitchen"}
+
+	t.Run("template defaults", func(t *testing.T) {
+		req, err := mergeTemplate(testTemplate(), instantiateTemplateRequest{}, deviceRoomMap)
+		require.NoError(t, err)
+		require.Equal(t, "Weekday Wake Up", req.Name)
+		require.Equal(t, ScheduleTypeWeekly, req.ScheduleType)
+		require.Equal(t, []int{1, 2, 3, 4, 5}, req.ScheduleWeekdays)
+		require.Equal(t, "07:00", req.ScheduleTime)
+		require.Equal(t, MusicPolicyTypeFixed, req.MusicPolicyType)
+		require.Equal(t, "weekday-wake", *req.TemplateID)
+		require.Equal(t, []SpeakerInput{
+			{UDN: "RINCON_BEDROOM", Volume: 15},
+			{UDN: "RINCON_KITCHEN", Volume: defaultTemplateSpeakerVolume},
+		}, req.Speakers)
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		template := testTemplate()
+		template.MusicPolicyType = "ROTATION"
+		template.MusicSetID = strPtr("set-calm")
+
+		req, err := mergeTemplate(template, instantiateTemplateRequest{
+			Name:         strPtr("Early Start"),
+			Speakers:     []SpeakerInput{{UDN: "RINCON_OFFICE", Volume: 30}},
+			ScheduleTime: strPtr("06:15"),
+			MusicSetID:   strPtr("set-upbeat"),
+		}, nil)
+		require.NoError(t, err)
+		require.Equal(t, "Early Start", req.Name)
+		require.Equal(t, "06:15", req.ScheduleTime)
+		require.Equal(t, "set-upbeat", *req.MusicSetID)
+		require.Equal(t, []SpeakerInput{{UDN: "RINCON_OFFICE", Volume: 30}}, req.Speakers)
+	})
+
+	conflicts := []struct {
+		name          string
+		template      func(*templates.RoutineTemplate)
+		overrides     instantiateTemplateRequest
+		roomMap       map[string]string
+		templateField string
+		override      string
+	}{
+		{"music set with fixed policy", nil, instantiateTemplateRequest{MusicSetID: strPtr("set-upbeat")}, deviceRoomMap, "music_policy_type", "music_set_id"},
+		{"invalid schedule time", nil, instantiateTemplateRequest{ScheduleTime: strPtr("7am")}, deviceRoomMap, "schedule_time", "schedule_time"},
+		{"empty name", nil, instantiateTemplateRequest{Name: strPtr("  ")}, deviceRoomMap, "name", "name"},
+		{"suggested room missing", nil, instantiateTemplateRequest{}, map[string]string{"RINCON_BEDROOM": "Bedroom"}, "suggested_speakers", "speakers"},
+		{"no suggested speakers", func(t *templates.RoutineTemplate) { t.SuggestedSpeakers = nil }, instantiateTemplateRequest{}, deviceRoomMap, "suggested_speakers", "speakers"},
+	}
+	for _, tt := range conflicts {
+		t.Run(tt.name, func(t *testing.T) {
+			template := testTemplate()
+			if tt.template != nil {
+				tt.template(template)
+			}
+			_, err := mergeTemplate(template, tt.overrides, tt.roomMap)
+			require.Error(t, err)
+
+			var appErr *apperrors.AppError
+			require.True(t, errors.As(err, &appErr))
+			require.Equal(t, http.StatusBadRequest, appErr.StatusCode)
+			require.Equal(t, tt.templateField, appErr.Details["template_field"])
+			require.Equal(t, tt.override, appErr.Details["override"])
+		})
+	}
+}
+
+func TestInstantiateTemplateRoute(t *testing.T) {
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+
+	_, err = dbPair.Writer().Exec(`
+		INSERT INTO routine_templates (template_id, name, category, timezone, schedule_type, schedule_weekdays,
+		                               schedule_time, suggested_speakers, music_policy_type, holiday_behavior, arc_tv_policy)
+		VALUES ('weekday-wake', 'Weekday Wake Up', 'morning', 'UTC', 'weekly', '[1,2,3,4,5]',
+		        '07:00', '[{"room":"Bedroom"}]', 'FIXED', 'SKIP', 'SKIP')
+	`)
+	require.NoError(t, err)
+
+	routinesRepo := NewRoutinesRepository(dbPair)
+	sceneService := scene.NewService(config.Config{}, dbPair, nil, nil, nil)
+	recorder := &fakeAuditRecorder{}
+	router := chi.NewRouter()
+	RegisterRoutes(router, RouteDeps{
+		Routines:  routinesRepo,
+		Jobs:      NewJobsRepository(dbPair),
+		Holidays:  NewHolidaysRepository(dbPair),
+		Scenes:    sceneService,
+		Recorder:  recorder,
+		Templates: templates.NewService(dbPair),
+	})
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("/v1/routine-templates/missing/instantiate", `{}`)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	// No devices are known, so the suggested Bedroom speaker can't be found
+	rec = post("/v1/routine-templates/weekday-wake/instantiate", `{}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = post("/v1/routine-templates/weekday-wake/instantiate", `{"name":"Early Start","schedule_time":"06:15","speakers":[{"udn":"RINCON_OFFICE","volume":20}]}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var created map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	routine, err := routinesRepo.GetByID(created["id"].(string))
+	require.NoError(t, err)
+	require.Equal(t, "Early Start", routine.Name)
+	require.Equal(t, "06:15", routine.ScheduleTime)
+	require.Equal(t, []int{1, 2, 3, 4, 5}, routine.ScheduleWeekdays)
+	require.Equal(t, "weekday-wake", *routine.TemplateID)
+
+	routineScene, err := sceneService.GetScene(routine.SceneID)
+	require.NoError(t, err)
+	require.Equal(t, "Routine: Early Start", routineScene.Name)
+	require.Len(t, routineScene.Members, 1)
+
+	require.Len(t, recorder.changes, 1)
+	require.Equal(t, routine.RoutineID, recorder.changes[0].ResourceID)
+}
@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// MediaInfoProvider reads what a speaker is playing, used to detect TV mode.
+// It is implemented by sonos.Service.
+type MediaInfoProvider interface {
+	GetMediaInfo(deviceIP string) (soap.MediaInfo, error)
+}
+
+// TVPolicyAction is what a routine's arc_tv_policy did about speakers in TV mode.
+type TVPolicyAction string
+
+const (
+	TVPolicyActionSkipped      TVPolicyAction = "skipped"       // The run was skipped
+	TVPolicyActionUsedFallback TVPolicyAction = "used_fallback" // TV speakers were left out
+	TVPolicyActionPlayed       TVPolicyAction = "played"        // Played over the TV anyway
+)
+
+// TVPolicyDecision records how arc_tv_policy handled a run whose speakers were in TV mode.
+type TVPolicyDecision struct {
+	Policy     ArcTVPolicy    `json:"policy"`
+	Action     TVPolicyAction `json:"action"`
+	TVModeUDNs []string       `json:"tv_mode_udns"`
+}
+
+// TVModeSkipError is returned by ExecuteRoutine when arc_tv_policy skips a run.
+// Detail describes the skipped run for the executions history.
+type TVModeSkipError struct {
+	Decision *TVPolicyDecision
+	Detail   *ExecutionDetail
+	Rooms    []string
+}
+
+func (e *TVModeSkipError) Error() string {
+	return fmt.Sprintf("Skipped by arc_tv_policy %s: %s in TV mode", e.Decision.Policy, strings.Join(e.Rooms, ", "))
+}
+
+// decideTVPolicy applies a routine's arc_tv_policy to the speakers found in TV mode.
+// USE_FALLBACK skips the run when every speaker is in TV mode, since none are left to
+// play on. Returns nil when no speaker is in TV mode or the routine has no policy.
+func decideTVPolicy(policy *string, tvModeUDNs []string, speakerCount int) *TVPolicyDecision {
+	if policy == nil || len(tvModeUDNs) == 0 {
+		return nil
+	}
+
+	decision := &TVPolicyDecision{Policy: ArcTVPolicy(*policy), TVModeUDNs: tvModeUDNs}
+	switch decision.Policy {
+	case ArcTVPolicySkip:
+		decision.Action = TVPolicyActionSkipped
+	case ArcTVPolicyUseFallback:
+		decision.Action = TVPolicyActionUsedFallback
+		if len(tvModeUDNs) >= speakerCount {
+			decision.Action = TVPolicyActionSkipped
+		}
+	case ArcTVPolicyAlwaysPlay:
+		decision.Action = TVPolicyActionPlayed
+	default:
+		return nil
+	}
+	return decision
+}
+
+// SetTVModeDetector enables arc_tv_policy enforcement: before a run, the routine's
+// speakers are checked for TV audio (see sonos.IsTVInputURI).
+func (a *RoutineExecutorAdapter) SetTVModeDetector(media MediaInfoProvider) {
+	a.mediaInfo = media
+}
+
+// tvModeSpeakers returns the speakers currently playing TV audio. Speakers that can't
+// be reached are left to the scene's fallback handling.
+func (a *RoutineExecutorAdapter) tvModeSpeakers(ctx context.Context, speakers []Speaker) []string {
+	if a.mediaInfo == nil || a.ipResolver == nil {
+		return nil
+	}
+
+	var udns []string
+	for _, speaker := range speakers {
+		ip, err := a.ipResolver.ResolveDeviceIP(speaker.UDN)
+		if err != nil || ip == "" {
+			continue
+		}
+		mediaInfo, err := a.mediaInfo.GetMediaInfo(ip)
+		if err != nil {
+			logging.From(ctx, a.logger).Warn("Failed to check TV mode for speaker", "udn", speaker.UDN, "error", err)
+			continue
+		}
+		if sonos.IsTVInputURI(mediaInfo.CurrentURI) {
+			udns = append(udns, speaker.UDN)
+		}
+	}
+	return udns
+}
+
+// withoutDevices drops the given speakers from an execution detail.
+func withoutDevices(detail *ExecutionDetail, udns []string) {
+	devices := detail.Devices[:0]
+	for _, device := range detail.Devices {
+		excluded := false
+		for _, udn := range udns {
+			if device.UDN == udn {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			devices = append(devices, device)
+		}
+	}
+	detail.Devices = devices
+}
@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/scene"
+)
+
+type fakeSceneExecutor struct {
+	options []scene.ExecuteOptions
+}
+
+func (f *fakeSceneExecutor) ExecuteScene(ctx context.Context, sceneID string, idempotencyKey *string, options scene.ExecuteOptions) (*scene.SceneExecution, error) {
+	f.options = append(f.options, options)
+	return &scene.SceneExecution{SceneExecutionID: "exec-1", SceneID: sceneID, Status: scene.ExecutionStatusPlayingConfirmed}, nil
+}
+
+func TestDecideTVPolicy(t *testing.T) {
+	policy := func(p ArcTVPolicy) *string {
+		s := string(p)
+		return &s
+	}
+	tv := []string{"udn-arc"}
+
+	require.Nil(t, decideTVPolicy(policy(ArcTVPolicySkip), nil, 2), "no speakers in TV mode")
+	require.Nil(t, decideTVPolicy(nil, tv, 2), "no policy")
+	require.Equal(t, TVPolicyActionSkipped, decideTVPolicy(policy(ArcTVPolicySkip), tv, 2).Action)
+	require.Equal(t, TVPolicyActionUsedFallback, decideTVPolicy(policy(ArcTVPolicyUseFallback), tv, 2).Action)
+	require.Equal(t, TVPolicyActionSkipped, decideTVPolicy(policy(ArcTVPolicyUseFallback), tv, 1).Action, "nothing left to play on")
+	require.Equal(t, TVPolicyActionPlayed, decideTVPolicy(policy(ArcTVPolicyAlwaysPlay), tv, 2).Action)
+}
+
+func TestRoutineExecutorAdapter_TVPolicy(t *testing.T) {
+	sceneExecutor := &fakeSceneExecutor{}
+	adapter := &RoutineExecutorAdapter{
+		sceneExecutor: sceneExecutor,
+		ipResolver:    fakeIPResolver{"udn-arc": "10.0.0.1", "udn-den": "10.0.0.2"},
+		logger:        logging.Discard(),
+	}
+	adapter.SetTVModeDetector(&fakePlaybackController{uris: map[string]string{"10.0.0.1": "x-sonos-htastream:RINCON_ARC:spdif", "10.0.0.2": "x-rincon-queue:RINCON_DEN#0"}})
+	routine := func(policy ArcTVPolicy) *Routine {
+		p := string(policy)
+		return &Routine{
+			RoutineID:    "routine-1",
+			SceneID:      "scene-1",
+			ArcTVPolicy:  &p,
+			SpeakersJSON: []Speaker{{UDN: "udn-arc"}, {UDN: "udn-den"}},
+		}
+	}
+
+	t.Run("SKIP skips the run", func(t *testing.T) {
+		_, err := adapter.ExecuteRoutine(context.Background(), routine(ArcTVPolicySkip), time.Time{}, nil)
+		var skip *TVModeSkipError
+		require.True(t, errors.As(err, &skip))
+		require.Equal(t, []string{"udn-arc"}, skip.Detail.TVPolicy.TVModeUDNs)
+		require.Contains(t, err.Error(), "udn-arc in TV mode")
+		require.Empty(t, sceneExecutor.options)
+	})
+
+	t.Run("USE_FALLBACK plays on the other speakers", func(t *testing.T) {
+		execution, err := adapter.ExecuteRoutine(context.Background(), routine(ArcTVPolicyUseFallback), time.Time{}, nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"udn-arc"}, sceneExecutor.options[0].ExcludeMembers)
+		require.True(t, execution.Detail.FallbackUsed)
+		require.Equal(t, TVPolicyActionUsedFallback, execution.Detail.TVPolicy.Action)
+		require.Len(t, execution.Detail.Devices, 1)
+		require.Equal(t, "udn-den", execution.Detail.Devices[0].UDN)
+	})
+
+	t.Run("ALWAYS_PLAY plays everywhere", func(t *testing.T) {
+		execution, err := adapter.ExecuteRoutine(context.Background(), routine(ArcTVPolicyAlwaysPlay), time.Time{}, nil)
+		require.NoError(t, err)
+		require.Empty(t, sceneExecutor.options[1].ExcludeMembers)
+		require.Equal(t, TVPolicyActionPlayed, execution.Detail.TVPolicy.Action)
+		require.Len(t, execution.Detail.Devices, 2)
+	})
+}
+
+func TestJobRunner_TVModeSkip(t *testing.T) {
+	dbPair := setupRunnerTestDB(t)
+	jobsRepo := NewJobsRepository(dbPair)
+	routinesRepo := NewRoutinesRepository(dbPair)
+	executor := newMockRoutineExecutor()
+
+	routine := createTestRoutine(t, routinesRepo, createTestScene(t, dbPair))
+	job := createTestJob(t, jobsRepo, routine.RoutineID, time.Now().UTC().Add(-time.Minute))
+
+	decision := &TVPolicyDecision{Policy: ArcTVPolicySkip, Action: TVPolicyActionSkipped, TVModeUDNs: []string{"udn-arc"}}
+	executor.setFailure(true, &TVModeSkipError{
+		Decision: decision,
+		Detail:   &ExecutionDetail{Devices: []ExecutionDevice{{UDN: "udn-arc"}}, TVPolicy: decision},
+		Rooms:    []string{"Living Room"},
+	})
+
+	runner := NewJobRunner(newTestLogger(), jobsRepo, routinesRepo, executor, 100*time.Millisecond, 3)
+	require.NoError(t, runner.executeJob(job))
+
+	skipped, err := jobsRepo.GetByID(job.JobID)
+	require.NoError(t, err)
+	require.Equal(t, JobStatusSkipped, skipped.Status)
+	require.Equal(t, 0, skipped.Attempts, "skips are not retried")
+	require.Equal(t, "Skipped by arc_tv_policy SKIP: Living Room in TV mode", *skipped.LastError)
+	require.Equal(t, decision, skipped.ExecutionDetail.TVPolicy)
+
+	formatted := formatJobAsExecution(skipped, nil)
+	require.Equal(t, "skipped", formatted["outcome"])
+	require.Equal(t, "tv_mode_active", formatted["failure_reason"])
+	require.Equal(t, "skipped", formatted["tv_policy"].(map[string]any)["action"])
+}
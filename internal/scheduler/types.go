@@ -3,6 +3,9 @@ package scheduler
 import (
 	"database/sql"
 	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/music"
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
 )
 
 // ==========================================================================
@@ -30,8 +33,68 @@ const (
 	HolidayBehaviorSkip  HolidayBehavior = "SKIP"
 	HolidayBehaviorDelay HolidayBehavior = "DELAY"
 	HolidayBehaviorRun   HolidayBehavior = "RUN"
+
+	// HolidayBehaviorPlayAlternate runs on schedule but plays the routine's holiday music set
+	HolidayBehaviorPlayAlternate HolidayBehavior = "PLAY_ALTERNATE"
 )
 
+// MissedRunPolicy determines what happens to a job whose scheduled time passed while the hub was down.
+type MissedRunPolicy string
+
+const (
+	MissedRunPolicyRunImmediately     MissedRunPolicy = "run_immediately"
+	MissedRunPolicySkip               MissedRunPolicy = "skip"
+	MissedRunPolicyRunIfWithinMinutes MissedRunPolicy = "run_if_within_minutes"
+)
+
+// IsValid reports whether the policy is a known missed-run policy.
+func (p MissedRunPolicy) IsValid() bool {
+	switch p {
+	case MissedRunPolicyRunImmediately, MissedRunPolicySkip, MissedRunPolicyRunIfWithinMinutes:
+		return true
+	}
+	return false
+}
+
+// MissedRunDecision is the catch-up decision recorded on a job that was missed during downtime.
+type MissedRunDecision string
+
+const (
+	MissedRunDecisionRun  MissedRunDecision = "RUN"
+	MissedRunDecisionSkip MissedRunDecision = "SKIP"
+)
+
+// JobLogStatus is the outcome of a single step in a job's execution log.
+type JobLogStatus string
+
+const (
+	JobLogStatusOK     JobLogStatus = "ok"
+	JobLogStatusFailed JobLogStatus = "failed"
+)
+
+// TimeMode selects whether a schedule fires at its fixed time or relative to the sun.
+type TimeMode string
+
+const (
+	TimeModeFixed   TimeMode = "fixed"
+	TimeModeSunrise TimeMode = "sunrise"
+	TimeModeSunset  TimeMode = "sunset"
+)
+
+// IsValid reports whether the mode is a known time mode.
+func (m TimeMode) IsValid() bool {
+	switch m {
+	case TimeModeFixed, TimeModeSunrise, TimeModeSunset:
+		return true
+	}
+	return false
+}
+
+// IsSolar reports whether the mode is relative to sunrise or sunset.
+func (m TimeMode) IsSolar() bool {
+	return m == TimeModeSunrise || m == TimeModeSunset
+}
+
 // ScheduleType represents the type of schedule.
 type ScheduleType string
 
@@ -57,10 +120,52 @@ type Schedule struct {
 	RunAt           *time.Time   `json:"run_at,omitempty"`
 }
 
+// NormalizedSchedule is the canonical schedule exactly as stored in a routine's
+// flattened schedule columns. Absent fields are explicit (empty weekdays, null month/day)
+// so that create-then-fetch round-trips losslessly.
+type NormalizedSchedule struct {
+	Type            ScheduleType    `json:"type"`
+	Time            string          `json:"time"`
+	Weekdays        []int           `json:"weekdays"`
+	Month           *int            `json:"month"`
+	Day             *int            `json:"day"`
+	IntervalDays    *int            `json:"interval_days"`
+	AnchorDate      *string         `json:"anchor_date"`
+	TimeMode        TimeMode        `json:"time_mode"`
+	OffsetMinutes   int             `json:"offset_minutes"`
+	Timezone        string          `json:"timezone"`
+	HolidayBehavior HolidayBehavior `json:"holiday_behavior"`
+}
+
+// NormalizeSchedule builds the canonical schedule for a routine.
+func NormalizeSchedule(routine *Routine) NormalizedSchedule {
+	weekdays := routine.ScheduleWeekdays
+	if weekdays == nil {
+		weekdays = []int{}
+	}
+	timeMode := routine.ScheduleTimeMode
+	if timeMode == "" {
+		timeMode = TimeModeFixed
+	}
+	return NormalizedSchedule{
+		Type:            routine.ScheduleType,
+		Time:            routine.ScheduleTime,
+		Weekdays:        weekdays,
+		Month:           routine.ScheduleMonth,
+		Day:             routine.ScheduleDay,
+		IntervalDays:    routine.ScheduleIntervalDays,
+		AnchorDate:      routine.ScheduleAnchorDate,
+		TimeMode:        timeMode,
+		OffsetMinutes:   routine.ScheduleOffsetMinutes,
+		Timezone:        routine.Timezone,
+		HolidayBehavior: routine.HolidayBehavior,
+	}
+}
+
 // MusicPolicy defines the music selection policy for a routine (API model).
 // This matches the structure iOS sends for music_policy in create/update requests.
 type MusicPolicy struct {
-	// Policy type: "FIXED", "ROTATION", "SHUFFLE"
+	// Policy type: "FIXED", "ROTATION", "SHUFFLE", or "NONE" to choose no music
 	Type string `json:"type,omitempty"`
 
 	// For FIXED policy with Sonos favorites
@@ -78,11 +183,20 @@ type MusicPolicy struct {
 	NoRepeatWindow          *int    `json:"no_repeat_window,omitempty"`
 	NoRepeatWindowMinutes   *int    `json:"no_repeat_window_minutes,omitempty"`
 	FallbackBehavior        *string `json:"fallback_behavior,omitempty"`
+
+	// Several sets to pick from by weight; set_id is shorthand for a single set
+	Sets []music.WeightedSet `json:"sets,omitempty"`
+
+	// Whose plays no_repeat_window_minutes counts: set, routine or global
+	NoRepeatScope *music.NoRepeatScope `json:"no_repeat_scope,omitempty"`
+
+	// Shuffle, repeat and crossfade applied after playback starts (any policy type)
+	PlayMode *sonos.PlayModeUpdate `json:"play_mode,omitempty"`
 }
 
 // MusicContentAPI represents direct music content for API serialization.
 type MusicContentAPI struct {
-	Type        string  `json:"type"`                    // "direct" or "sonos_favorite"
+	Type        string  `json:"type"`                    // "direct", "sonos_favorite" or "local_file"
 	Service     *string `json:"service,omitempty"`       // "spotify", "apple_music"
 	ContentType *string `json:"content_type,omitempty"`
 	ContentID   *string `json:"content_id,omitempty"`
@@ -92,6 +206,7 @@ type MusicContentAPI struct {
 	Name        *string `json:"name,omitempty"`          // Display name
 	ServiceLogoUrl *string `json:"service_logo_url,omitempty"`
 	ServiceName *string `json:"service_name,omitempty"`
+	Filename    *string `json:"filename,omitempty"`      // For local_file type: a file in the hub's audio directory
 }
 
 // ==========================================================================
@@ -133,28 +248,79 @@ type Routine struct {
 	ArcTVPolicy                     *string `json:"arc_tv_policy,omitempty"`
 	OccasionsEnabled                bool    `json:"occasions_enabled"`
 
+	// Catch-up behavior for jobs missed while the hub was down
+	MissedRunPolicy        MissedRunPolicy `json:"missed_run_policy"`
+	MissedRunWithinMinutes *int            `json:"missed_run_within_minutes,omitempty"`
+
+	// INTERVAL schedules run every ScheduleIntervalDays days counted from ScheduleAnchorDate (YYYY-MM-DD)
+	ScheduleIntervalDays *int    `json:"schedule_interval_days,omitempty"`
+	ScheduleAnchorDate   *string `json:"schedule_anchor_date,omitempty"`
+
+	// Auto-stop: playback started by the routine is stopped after DurationMinutes
+	DurationMinutes *int `json:"duration_minutes,omitempty"`
+
+	// Sunrise/sunset schedules fire ScheduleOffsetMinutes from the solar event;
+	// ScheduleTime is the fallback when the hub has no coordinates
+	ScheduleTimeMode      TimeMode `json:"schedule_time_mode"`
+	ScheduleOffsetMinutes int      `json:"schedule_offset_minutes"`
+
+	// Retry policy for failed jobs: up to MaxAttempts runs, waiting RetryBackoffSeconds
+	// after the first failure and doubling after each one. MaxAttempts is 0 when unset,
+	// and the runner's own limit applies
+	MaxAttempts         int `json:"max_attempts"`
+	RetryBackoffSeconds int `json:"retry_backoff_seconds"`
+
+	// Music set played instead of the routine's content on holidays (PLAY_ALTERNATE)
+	HolidayMusicSetID *string `json:"holiday_music_set_id,omitempty"`
+
+	// Sets a ROTATION/SHUFFLE routine picks from, by weight; MusicSetID is the first
+	MusicSets []music.WeightedSet `json:"music_sets,omitempty"`
+
+	// Playback captured before a run is put back after DurationMinutes or on request
+	RestorePreviousState bool `json:"restore_previous_state"`
+
+	// Shuffle, repeat and crossfade applied once the routine's playback has started
+	MusicPlayMode *sonos.PlayModeUpdate `json:"music_play_mode,omitempty"`
+
+	// Sleep timer armed on the coordinator once the routine's playback has started
+	SleepTimerMinutes *int `json:"sleep_timer_minutes,omitempty"`
+
+	// Whose plays the no-repeat window counts when picking from the routine's sets
+	MusicNoRepeatScope music.NoRepeatScope `json:"music_no_repeat_scope"`
+
+	// What a NONE routine does to its speakers instead of playing music
+	Actions []RoutineAction `json:"actions,omitempty"`
+
+	// Starts playback quietly and ramps the volume up once it is playing
+	WakeProfile *WakeProfile `json:"wake_profile,omitempty"`
+
 	// API compatibility fields (for serialization with Schedule struct)
 	Description *string      `json:"description,omitempty"`
 	Schedule    Schedule     `json:"-"` // Excluded from JSON, construct from flat fields
 	MusicPolicy *MusicPolicy `json:"music_policy,omitempty"`
 	LastRunAt   *time.Time   `json:"last_run_at,omitempty"`
 	NextRunAt   *time.Time   `json:"next_run_at,omitempty"`
+	DeletedAt   *time.Time   `json:"deleted_at,omitempty"` // Only set by ListIncludingDeleted
 }
 
 // Job represents a scheduled job instance (database model).
 type Job struct {
-	JobID            string     `json:"job_id"`
-	RoutineID        string     `json:"routine_id"`
-	ScheduledFor     time.Time  `json:"scheduled_for"`
-	Status           JobStatus  `json:"status"`
-	Attempts         int        `json:"attempts"`
-	LastError        *string    `json:"last_error,omitempty"`
-	SceneExecutionID *string    `json:"scene_execution_id,omitempty"`
-	RetryAfter       *time.Time `json:"retry_after,omitempty"`
-	ClaimedAt        *time.Time `json:"claimed_at,omitempty"`
-	IdempotencyKey   *string    `json:"idempotency_key,omitempty"`
-	CreatedAt        time.Time  `json:"created_at"`
-	UpdatedAt        time.Time  `json:"updated_at"`
+	JobID             string             `json:"job_id"`
+	RoutineID         string             `json:"routine_id"`
+	ScheduledFor      time.Time          `json:"scheduled_for"`
+	Status            JobStatus          `json:"status"`
+	Attempts          int                `json:"attempts"`
+	LastError         *string            `json:"last_error,omitempty"`
+	SceneExecutionID  *string            `json:"scene_execution_id,omitempty"`
+	RetryAfter        *time.Time         `json:"retry_after,omitempty"`
+	ClaimedAt         *time.Time         `json:"claimed_at,omitempty"`
+	IdempotencyKey    *string            `json:"idempotency_key,omitempty"`
+	Manual            bool               `json:"manual"` // Triggered by hand rather than by the schedule
+	FailureReason     *string            `json:"failure_reason,omitempty"` // Why a failed attempt failed, when known
+	MissedRunDecision *MissedRunDecision `json:"missed_run_decision,omitempty"`
+	ExecutionDetail   *ExecutionDetail   `json:"execution_detail,omitempty"` // Set when the job completes
+	CreatedAt         time.Time          `json:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at"`
 
 	// API compatibility fields
 	StartedAt   *time.Time `json:"started_at,omitempty"`
@@ -162,6 +328,77 @@ type Job struct {
 	Result      *string    `json:"result,omitempty"`
 }
 
+// JobLogEntry is one step of a job's execution log. Entries from every attempt are
+// kept in order, so a retried job shows each attempt's steps.
+type JobLogEntry struct {
+	Attempt    int          `json:"attempt"`
+	Step       string       `json:"step"`
+	Status     JobLogStatus `json:"status"`
+	StartedAt  time.Time    `json:"started_at"`
+	DurationMs int64        `json:"duration_ms"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// ExecutionDetail summarizes what a completed job did: the speakers it played on, the
+// volumes it applied, and the music it started. It is stored on the job for the
+// executions history.
+type ExecutionDetail struct {
+	Devices      []ExecutionDevice `json:"devices"`
+	Content      *ExecutionContent `json:"content,omitempty"`
+	FallbackUsed bool              `json:"fallback_used"`
+
+	// Set when a PLAY_ALTERNATE routine played its holiday music set
+	HolidayOverride *HolidayOverride `json:"holiday_override,omitempty"`
+
+	// Set when arc_tv_policy acted on speakers in TV mode
+	TVPolicy *TVPolicyDecision `json:"tv_policy,omitempty"`
+
+	// Set when an occasions_enabled routine's music set was out of season
+	Occasion *OccasionDecision `json:"occasion,omitempty"`
+
+	// Play mode applied after playback started, when the routine sets one
+	PlayMode *sonos.PlayMode `json:"play_mode,omitempty"`
+
+	// Sleep timer armed after playback started, when the routine sets one
+	SleepTimerMinutes *int `json:"sleep_timer_minutes,omitempty"`
+
+	// Actions a NONE routine ran instead of playing music, in order
+	Actions []ExecutionAction `json:"actions,omitempty"`
+
+	// Speakers left out of the run because they, and their fallbacks, were offline
+	OfflineUDNs []string `json:"offline_udns,omitempty"`
+
+	// The wake ramp started after playback, when the routine has a wake profile. Its
+	// status is running until the ramp finishes.
+	Wake *WakeRamp `json:"wake,omitempty"`
+}
+
+// HolidayOverride records the holiday that swapped a routine's music for its holiday set.
+type HolidayOverride struct {
+	HolidayName string `json:"holiday_name"`
+	MusicSetID  string `json:"music_set_id"`
+}
+
+// ExecutionDevice is one speaker a job played on.
+type ExecutionDevice struct {
+	UDN      string `json:"udn"`
+	RoomName string `json:"room_name,omitempty"`
+	Volume   *int   `json:"volume,omitempty"`   // Volume applied, if the routine sets one
+	Fallback bool   `json:"fallback,omitempty"` // Stood in for an unreachable speaker
+
+	// EQ applied after playback started, when the speaker's audio_settings set one
+	AudioSettings *sonos.AudioSettings `json:"audio_settings,omitempty"`
+}
+
+// ExecutionContent is the music a job started.
+type ExecutionContent struct {
+	Type        string `json:"type"` // sonos_favorite, direct
+	Title       string `json:"title,omitempty"`
+	ArtworkURL  string `json:"artwork_url,omitempty"`
+	ServiceName string `json:"service_name,omitempty"`
+	URI         string `json:"uri,omitempty"`
+}
+
 // Holiday represents a holiday date (database model).
 type Holiday struct {
 	Date     string `json:"date"`
@@ -261,6 +498,14 @@ type CreateHolidayInputAPI struct {
 // SpeakerInput represents a speaker configuration from iOS.
 // This is used in routine creation/update requests from the iOS app.
 type SpeakerInput struct {
-	UDN    string `json:"udn"`
-	Volume int    `json:"volume"`
+	UDN         string `json:"udn"`
+	Volume      int    `json:"volume"`
+	FallbackUDN string `json:"fallback_udn,omitempty"`
+
+	// Fade in from 0 to Volume over FadeInMs once playback starts
+	FadeInMs  *int   `json:"fade_in_ms,omitempty"`
+	FadeCurve string `json:"fade_curve,omitempty"` // linear (default), ease-in, ease-out
+
+	// EQ applied to the speaker once playback starts
+	AudioSettings *sonos.AudioSettings `json:"audio_settings,omitempty"`
 }
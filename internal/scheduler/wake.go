@@ -0,0 +1,363 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+const (
+	// MaxWakeRampMinutes caps a wake profile's ramp_minutes.
+	MaxWakeRampMinutes = 60
+
+	// WakeVolumeCheckInterval is how often a running wake ramp reads its speakers' volume
+	// to notice that someone has changed it by hand.
+	WakeVolumeCheckInterval = 30 * time.Second
+
+	// WakeVolumeDivergence is how far a speaker's volume can drift from the ramp's level
+	// before the ramp treats it as a manual change and stops.
+	WakeVolumeDivergence = 3
+)
+
+// WakeProfile starts a routine's playback quietly and brings the volume up over several
+// minutes once it is playing.
+type WakeProfile struct {
+	StartVolume int `json:"start_volume"`
+	EndVolume   int `json:"end_volume"`
+	RampMinutes int `json:"ramp_minutes"`
+}
+
+// WakeStatus is where a wake ramp has got to.
+type WakeStatus string
+
+const (
+	WakeStatusRunning   WakeStatus = "running"
+	WakeStatusCompleted WakeStatus = "completed"
+	WakeStatusAborted   WakeStatus = "aborted" // Someone changed the volume by hand
+	WakeStatusFailed    WakeStatus = "failed"  // The speakers couldn't be reached
+)
+
+// WakeRamp is a routine run's wake ramp. While it runs it is stored on the run's job,
+// so a hub restart picks it up where it left off; once it finishes it is recorded in
+// the job's execution detail.
+type WakeRamp struct {
+	WakeProfile
+	UDNs      []string   `json:"udns"`
+	StartedAt time.Time  `json:"started_at"`
+	Volume    int        `json:"volume"` // Last level set
+	Status    WakeStatus `json:"status"`
+
+	AbortedUDN     string     `json:"aborted_udn,omitempty"`     // Speaker whose volume was changed by hand
+	ObservedVolume *int       `json:"observed_volume,omitempty"` // Its volume at the time
+	Error          string     `json:"error,omitempty"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+}
+
+// newWakeRamp starts a ramp for the speakers a run played on.
+func newWakeRamp(profile WakeProfile, detail *ExecutionDetail, now time.Time) *WakeRamp {
+	ramp := &WakeRamp{
+		WakeProfile: profile,
+		UDNs:        []string{},
+		StartedAt:   now.UTC().Truncate(time.Second),
+		Volume:      profile.StartVolume,
+		Status:      WakeStatusRunning,
+	}
+	for _, device := range detail.Devices {
+		ramp.UDNs = append(ramp.UDNs, device.UDN)
+	}
+	return ramp
+}
+
+// validateWakeProfile checks a routine's wake_profile. A profile with ramp_minutes 0
+// clears it on update.
+func validateWakeProfile(profile *WakeProfile, policyType MusicPolicyType) error {
+	if profile == nil || profile.RampMinutes == 0 {
+		return nil
+	}
+	details := map[string]any{"wake_profile": profile}
+	if policyType == MusicPolicyTypeNone {
+		return apperrors.NewValidationError("wake_profile needs a routine that plays music", map[string]any{"music_policy_type": string(policyType)})
+	}
+	if profile.RampMinutes < 1 || profile.RampMinutes > MaxWakeRampMinutes {
+		return apperrors.NewValidationError("wake_profile.ramp_minutes must be between 1 and "+strconv.Itoa(MaxWakeRampMinutes), details)
+	}
+	if profile.StartVolume < 0 || profile.StartVolume > 100 || profile.EndVolume < 0 || profile.EndVolume > 100 {
+		return apperrors.NewValidationError("wake_profile volumes must be between 0 and 100", details)
+	}
+	if profile.EndVolume <= profile.StartVolume {
+		return apperrors.NewValidationError("wake_profile.end_volume must be above start_volume", details)
+	}
+	return nil
+}
+
+// wakeSteps returns the level changes of a ramp, on the same linear schedule as the
+// volume ramp endpoint.
+func wakeSteps(profile WakeProfile) []sonos.RampStep {
+	steps := []sonos.RampStep{}
+	current := profile.StartVolume
+	for _, step := range sonos.RampSchedule(profile.StartVolume, profile.EndVolume, profile.RampMinutes*60*1000, "linear") {
+		if step.Level != current {
+			steps = append(steps, step)
+			current = step.Level
+		}
+	}
+	return steps
+}
+
+// remainingWakeSteps returns the steps a ramp has left at now: those above the level it
+// has reached, less the ones that fell due while the hub was down, bar the latest.
+func remainingWakeSteps(ramp *WakeRamp, now time.Time) []sonos.RampStep {
+	steps := wakeSteps(ramp.WakeProfile)
+	remaining := []sonos.RampStep{}
+	for i, step := range steps {
+		if step.Level <= ramp.Volume {
+			continue // Reached before a restart
+		}
+		if i+1 < len(steps) && !ramp.StartedAt.Add(steps[i+1].At).After(now) {
+			continue // Fell due while the hub was down; go straight to the latest
+		}
+		remaining = append(remaining, step)
+	}
+	return remaining
+}
+
+// WakeVolumeController reads and sets speaker volume for wake ramps.
+// It is implemented by sonos.Service.
+type WakeVolumeController interface {
+	GetVolume(deviceIP string) (soap.VolumeInfo, error)
+	SetVolume(deviceIP string, level int) error
+	SetMute(deviceIP string, muted bool) error
+}
+
+// WakeRamper runs routines' wake ramps in the background. Each running ramp's progress
+// is saved on its job, and Resume picks the ramps back up after a restart.
+type WakeRamper struct {
+	controller WakeVolumeController
+	resolver   DeviceIPResolver
+	jobsRepo   *JobsRepository
+	logger     *slog.Logger
+	now        func() time.Time
+	sleep      func(ctx context.Context, d time.Duration) bool // false once ctx is done
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// wakeSpeaker is a speaker a ramp drives.
+type wakeSpeaker struct {
+	udn string
+	ip  string
+}
+
+// NewWakeRamper creates a WakeRamper.
+func NewWakeRamper(controller WakeVolumeController, resolver DeviceIPResolver, jobsRepo *JobsRepository, logger *slog.Logger) *WakeRamper {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WakeRamper{
+		controller: controller,
+		resolver:   resolver,
+		jobsRepo:   jobsRepo,
+		logger:     logger,
+		now:        time.Now,
+		sleep:      sleepContext,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Start unmutes the run's speakers and ramps them up in the background. The log lines
+// carry ctx's log attributes, such as the run's job_id.
+func (w *WakeRamper) Start(ctx context.Context, jobID string, ramp *WakeRamp) {
+	logger := logging.From(ctx, w.logger)
+	if err := w.jobsRepo.SetWakeRamp(jobID, ramp); err != nil {
+		logger.Warn("Failed to save wake ramp", "error", err)
+	}
+	speakers := w.resolveSpeakers(ramp, logger)
+	for _, speaker := range speakers {
+		if err := w.controller.SetMute(speaker.ip, false); err != nil {
+			logger.Warn("Failed to unmute speaker for wake ramp", "udn", speaker.udn, "error", err)
+		}
+	}
+	logger.Info("Wake ramp started", "end_volume", ramp.EndVolume, "ramp_minutes", ramp.RampMinutes)
+	w.goRun(jobID, ramp, speakers, logger)
+}
+
+// Resume restarts the ramps that were running when the hub stopped, skipping the steps
+// that fell due while it was down. Speakers are left muted or unmuted as they are.
+func (w *WakeRamper) Resume() {
+	ramps, err := w.jobsRepo.ListWakeRamps()
+	if err != nil {
+		w.logger.Warn("Failed to load wake ramps", "error", err)
+		return
+	}
+	for jobID, ramp := range ramps {
+		logger := w.logger.With("job_id", jobID)
+		logger.Info("Resuming wake ramp", "volume", ramp.Volume, "end_volume", ramp.EndVolume)
+		w.goRun(jobID, ramp, nil, logger)
+	}
+}
+
+// Stop halts the running ramps and waits for them to exit. They stay saved as running,
+// so the next Resume carries on with them.
+func (w *WakeRamper) Stop() {
+	w.cancel()
+	w.wg.Wait()
+}
+
+// goRun runs a ramp in the background until it finishes or the ramper stops. Nil
+// speakers are resolved in the background, as resolving can wait on a rescan.
+func (w *WakeRamper) goRun(jobID string, ramp *WakeRamp, speakers []wakeSpeaker, logger *slog.Logger) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		if speakers == nil {
+			speakers = w.resolveSpeakers(ramp, logger)
+		}
+		w.run(w.ctx, jobID, ramp, speakers, logger)
+	}()
+}
+
+// resolveSpeakers resolves the ramp's speakers, leaving out any that can't be.
+func (w *WakeRamper) resolveSpeakers(ramp *WakeRamp, logger *slog.Logger) []wakeSpeaker {
+	speakers := []wakeSpeaker{}
+	for _, udn := range ramp.UDNs {
+		ip, err := w.resolver.ResolveDeviceIP(udn)
+		if err != nil || ip == "" {
+			logger.Warn("Failed to resolve speaker for wake ramp", "udn", udn, "error", err)
+			continue
+		}
+		speakers = append(speakers, wakeSpeaker{udn: udn, ip: ip})
+	}
+	return speakers
+}
+
+// run steps the speakers' volume up on the ramp's schedule with sonos.RampRunner, saving
+// each level reached. Before each step, and at least every WakeVolumeCheckInterval, it
+// reads the speakers' volume and stops if someone has changed it. A speaker that fails
+// to take a step is dropped from the ramp, and from the checks. Returns early, leaving
+// the ramp running, when ctx is done.
+func (w *WakeRamper) run(ctx context.Context, jobID string, ramp *WakeRamp, speakers []wakeSpeaker, logger *slog.Logger) {
+	if len(speakers) == 0 {
+		ramp.Error = "no speakers could be reached"
+		w.finish(jobID, ramp, WakeStatusFailed, logger)
+		return
+	}
+
+	ips := make([]string, 0, len(speakers))
+	for _, speaker := range speakers {
+		ips = append(ips, speaker.ip)
+	}
+	dropped := map[string]string{}
+	aborted := false
+	runner := sonos.RampRunner{
+		SetVolume: func(_ context.Context, deviceIP string, level int) error {
+			return w.controller.SetVolume(deviceIP, level)
+		},
+		WaitUntil: func(ctx context.Context, due time.Time) bool {
+			for wait := due.Sub(w.now()); wait > 0; wait = due.Sub(w.now()) {
+				if !w.sleep(ctx, min(wait, WakeVolumeCheckInterval)) {
+					return false
+				}
+				if wait > WakeVolumeCheckInterval && w.volumeChanged(ramp, speakers, dropped, logger) {
+					aborted = true
+					return false
+				}
+			}
+			aborted = w.volumeChanged(ramp, speakers, dropped, logger)
+			return !aborted
+		},
+		OnStep: func(level, _ int, failed map[string]string) {
+			for _, speaker := range speakers {
+				reason, failedNow := failed[speaker.ip]
+				if _, seen := dropped[speaker.ip]; failedNow && !seen {
+					logger.Warn("Wake ramp failed to set volume, leaving speaker out", "udn", speaker.udn, "volume", level, "error", reason)
+					dropped[speaker.ip] = reason
+				}
+			}
+			if len(dropped) == len(speakers) {
+				return
+			}
+			ramp.Volume = level
+			if err := w.jobsRepo.SetWakeRamp(jobID, ramp); err != nil {
+				logger.Warn("Failed to save wake ramp", "error", err)
+			}
+		},
+	}
+	runner.Run(ctx, ips, remainingWakeSteps(ramp, w.now()), ramp.StartedAt)
+
+	switch {
+	case aborted:
+		w.finish(jobID, ramp, WakeStatusAborted, logger)
+	case ctx.Err() != nil:
+	case len(dropped) == len(speakers):
+		ramp.Error = "failed to set volume on every speaker"
+		w.finish(jobID, ramp, WakeStatusFailed, logger)
+	default:
+		w.finish(jobID, ramp, WakeStatusCompleted, logger)
+	}
+}
+
+// volumeChanged reports whether a speaker's volume has moved away from the ramp's level,
+// recording which speaker on the ramp. Speakers dropped from the ramp, by IP, and those
+// that can't be read are ignored.
+func (w *WakeRamper) volumeChanged(ramp *WakeRamp, speakers []wakeSpeaker, dropped map[string]string, logger *slog.Logger) bool {
+	for _, speaker := range speakers {
+		if _, ok := dropped[speaker.ip]; ok {
+			continue
+		}
+		volume, err := w.controller.GetVolume(speaker.ip)
+		if err != nil {
+			logger.Debug("Wake ramp failed to read volume", "udn", speaker.udn, "error", err)
+			continue
+		}
+		if diff := volume.CurrentVolume - ramp.Volume; diff > WakeVolumeDivergence || diff < -WakeVolumeDivergence {
+			observed := volume.CurrentVolume
+			ramp.AbortedUDN = speaker.udn
+			ramp.ObservedVolume = &observed
+			return true
+		}
+	}
+	return false
+}
+
+// finish records how the ramp ended in its job's execution detail.
+func (w *WakeRamper) finish(jobID string, ramp *WakeRamp, status WakeStatus, logger *slog.Logger) {
+	finishedAt := w.now().UTC().Truncate(time.Second)
+	ramp.Status = status
+	ramp.FinishedAt = &finishedAt
+	if err := w.jobsRepo.FinishWakeRamp(jobID, ramp); err != nil {
+		logger.Warn("Failed to save finished wake ramp", "error", err)
+	}
+
+	switch status {
+	case WakeStatusAborted:
+		logger.Info("Wake ramp stopped after a manual volume change",
+			"udn", ramp.AbortedUDN, "volume", ramp.Volume, "observed_volume", *ramp.ObservedVolume)
+	case WakeStatusFailed:
+		logger.Warn("Wake ramp failed", "volume", ramp.Volume, "error", ramp.Error)
+	default:
+		logger.Info("Wake ramp completed", "volume", ramp.Volume)
+	}
+}
+
+// sleepContext waits for d, returning false if ctx is done first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
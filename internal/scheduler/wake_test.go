@@ -0,0 +1,253 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// fakeWakeController holds each speaker's volume and records the commands sent to it.
+type fakeWakeController struct {
+	mu      sync.Mutex
+	volumes map[string]int
+	calls   []string
+	failIPs map[string]bool // Speakers SetVolume fails on
+}
+
+func (f *fakeWakeController) GetVolume(deviceIP string) (soap.VolumeInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return soap.VolumeInfo{CurrentVolume: f.volumes[deviceIP]}, nil
+}
+
+func (f *fakeWakeController) SetVolume(deviceIP string, level int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failIPs[deviceIP] {
+		return errors.New("speaker unreachable")
+	}
+	f.volumes[deviceIP] = level
+	f.calls = append(f.calls, fmt.Sprintf("%s volume %d", deviceIP, level))
+	return nil
+}
+
+func (f *fakeWakeController) SetMute(deviceIP string, muted bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, fmt.Sprintf("%s mute %t", deviceIP, muted))
+	return nil
+}
+
+func (f *fakeWakeController) recorded() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+// newTestWakeRamper returns a ramper whose clock starts at now and jumps ahead instead
+// of sleeping. onSleep, if set, is called with the time after each jump.
+func newTestWakeRamper(jobsRepo *JobsRepository, controller *fakeWakeController, now time.Time, onSleep func(time.Time)) *WakeRamper {
+	ramper := NewWakeRamper(controller, fakeIPResolver{"udn-kitchen": "10.0.0.1", "udn-den": "10.0.0.2"}, jobsRepo, logging.Discard())
+	ramper.now = func() time.Time { return now }
+	ramper.sleep = func(ctx context.Context, d time.Duration) bool {
+		now = now.Add(d)
+		if onSleep != nil {
+			onSleep(now)
+		}
+		return ctx.Err() == nil
+	}
+	return ramper
+}
+
+func TestWakeSteps(t *testing.T) {
+	steps := wakeSteps(WakeProfile{StartVolume: 5, EndVolume: 35, RampMinutes: 10})
+	require.Len(t, steps, 30, "one step per level")
+	require.Equal(t, 6, steps[0].Level)
+	require.Equal(t, 35, steps[29].Level)
+	require.Less(t, steps[29].At, 10*time.Minute)
+	for i := 1; i < len(steps); i++ {
+		require.Greater(t, steps[i].At, steps[i-1].At)
+	}
+}
+
+func TestValidateWakeProfile(t *testing.T) {
+	valid := &WakeProfile{StartVolume: 5, EndVolume: 35, RampMinutes: 10}
+	require.NoError(t, validateWakeProfile(nil, MusicPolicyTypeFixed))
+	require.NoError(t, validateWakeProfile(valid, MusicPolicyTypeFixed))
+	require.NoError(t, validateWakeProfile(&WakeProfile{}, MusicPolicyTypeNone), "ramp_minutes 0 clears")
+	require.Error(t, validateWakeProfile(valid, MusicPolicyTypeNone))
+	require.Error(t, validateWakeProfile(&WakeProfile{StartVolume: 5, EndVolume: 35, RampMinutes: MaxWakeRampMinutes + 1}, MusicPolicyTypeFixed))
+	require.Error(t, validateWakeProfile(&WakeProfile{StartVolume: 35, EndVolume: 5, RampMinutes: 10}, MusicPolicyTypeFixed))
+	require.Error(t, validateWakeProfile(&WakeProfile{StartVolume: 5, EndVolume: 101, RampMinutes: 10}, MusicPolicyTypeFixed))
+}
+
+func TestWakeRamper_RampsAfterRoutineRun(t *testing.T) {
+	dbPair := setupRunnerTestDB(t)
+	jobsRepo := NewJobsRepository(dbPair)
+	routinesRepo := NewRoutinesRepository(dbPair)
+
+	routine := createTestRoutine(t, routinesRepo, createTestScene(t, dbPair))
+	routine, err := routinesRepo.Update(routine.RoutineID, UpdateRoutineInput{
+		WakeProfile:  &WakeProfile{StartVolume: 5, EndVolume: 35, RampMinutes: 10},
+		SpeakersJSON: []Speaker{{UDN: "udn-kitchen"}, {UDN: "udn-den"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &WakeProfile{StartVolume: 5, EndVolume: 35, RampMinutes: 10}, routine.WakeProfile)
+
+	controller := &fakeWakeController{volumes: map[string]int{"10.0.0.1": 5, "10.0.0.2": 5}}
+	ramper := newTestWakeRamper(jobsRepo, controller, time.Now(), nil)
+	sceneExecutor := &fakeSceneExecutor{}
+	adapter := &RoutineExecutorAdapter{sceneExecutor: sceneExecutor, logger: logging.Discard()}
+	runner := NewJobRunner(newTestLogger(), jobsRepo, routinesRepo, adapter, 100*time.Millisecond, 3)
+	runner.SetWakeRamper(ramper)
+
+	job := createTestJob(t, jobsRepo, routine.RoutineID, time.Now().UTC().Add(-time.Minute))
+	require.NoError(t, runner.executeJob(job))
+	ramper.wg.Wait()
+
+	require.Equal(t, 5, *sceneExecutor.options[0].StartVolume, "the scene starts playback quietly")
+	calls := controller.recorded()
+	require.Equal(t, []string{"10.0.0.1 mute false", "10.0.0.2 mute false"}, calls[:2])
+	require.ElementsMatch(t, []string{"10.0.0.1 volume 6", "10.0.0.2 volume 6"}, calls[2:4])
+	require.Len(t, calls, 2+2*30)
+	require.Equal(t, map[string]int{"10.0.0.1": 35, "10.0.0.2": 35}, controller.volumes)
+
+	completed, err := jobsRepo.GetByID(job.JobID)
+	require.NoError(t, err)
+	wake := completed.ExecutionDetail.Wake
+	require.Equal(t, WakeStatusCompleted, wake.Status)
+	require.Equal(t, 35, wake.Volume)
+	require.Equal(t, []string{"udn-kitchen", "udn-den"}, wake.UDNs)
+	require.NotNil(t, wake.FinishedAt)
+	ramps, err := jobsRepo.ListWakeRamps()
+	require.NoError(t, err)
+	require.Empty(t, ramps)
+}
+
+func TestWakeRamper_AbortsOnManualVolumeChange(t *testing.T) {
+	dbPair := setupRunnerTestDB(t)
+	jobsRepo := NewJobsRepository(dbPair)
+	routine := createTestRoutine(t, NewRoutinesRepository(dbPair), createTestScene(t, dbPair))
+	job := createTestJob(t, jobsRepo, routine.RoutineID, time.Now().UTC())
+
+	start := time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC)
+	controller := &fakeWakeController{volumes: map[string]int{"10.0.0.1": 5, "10.0.0.2": 5}}
+	ramper := newTestWakeRamper(jobsRepo, controller, start, func(now time.Time) {
+		if now.After(start.Add(3 * time.Minute)) {
+			controller.mu.Lock()
+			controller.volumes["10.0.0.2"] = 60 // Someone turned the den up
+			controller.mu.Unlock()
+		}
+	})
+
+	ramp := &WakeRamp{
+		WakeProfile: WakeProfile{StartVolume: 5, EndVolume: 35, RampMinutes: 10},
+		UDNs:        []string{"udn-kitchen", "udn-den"},
+		StartedAt:   start,
+		Volume:      5,
+		Status:      WakeStatusRunning,
+	}
+	require.NoError(t, jobsRepo.CompleteJobWithDetail(job.JobID, "", &ExecutionDetail{Devices: []ExecutionDevice{}, Wake: ramp}))
+	ramper.Start(context.Background(), job.JobID, ramp)
+	ramper.wg.Wait()
+
+	require.Equal(t, 14, controller.volumes["10.0.0.1"], "the ramp stops where it was")
+	completed, err := jobsRepo.GetByID(job.JobID)
+	require.NoError(t, err)
+	wake := completed.ExecutionDetail.Wake
+	require.Equal(t, WakeStatusAborted, wake.Status)
+	require.Equal(t, "udn-den", wake.AbortedUDN)
+	require.Equal(t, 60, *wake.ObservedVolume)
+	require.Equal(t, 14, wake.Volume)
+}
+
+func TestWakeRamper_ResumesAfterRestart(t *testing.T) {
+	dbPair := setupRunnerTestDB(t)
+	jobsRepo := NewJobsRepository(dbPair)
+	routine := createTestRoutine(t, NewRoutinesRepository(dbPair), createTestScene(t, dbPair))
+	job := createTestJob(t, jobsRepo, routine.RoutineID, time.Now().UTC())
+
+	// The hub stopped at volume 10, and came back five minutes into the ramp
+	start := time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC)
+	require.NoError(t, jobsRepo.CompleteJobWithDetail(job.JobID, "", &ExecutionDetail{Devices: []ExecutionDevice{}}))
+	require.NoError(t, jobsRepo.SetWakeRamp(job.JobID, &WakeRamp{
+		WakeProfile: WakeProfile{StartVolume: 5, EndVolume: 35, RampMinutes: 10},
+		UDNs:        []string{"udn-kitchen"},
+		StartedAt:   start,
+		Volume:      10,
+		Status:      WakeStatusRunning,
+	}))
+
+	controller := &fakeWakeController{volumes: map[string]int{"10.0.0.1": 10}}
+	ramper := newTestWakeRamper(jobsRepo, controller, start.Add(5*time.Minute), nil)
+	ramper.Resume()
+	ramper.wg.Wait()
+
+	calls := controller.recorded()
+	require.Equal(t, "10.0.0.1 volume 20", calls[0], "steps that fell due while down are skipped")
+	require.Equal(t, "10.0.0.1 volume 35", calls[len(calls)-1])
+	require.NotContains(t, calls, "10.0.0.1 mute false")
+
+	completed, err := jobsRepo.GetByID(job.JobID)
+	require.NoError(t, err)
+	require.Equal(t, WakeStatusCompleted, completed.ExecutionDetail.Wake.Status)
+}
+
+func TestWakeRamper_DropsSpeakersThatFailToSetVolume(t *testing.T) {
+	dbPair := setupRunnerTestDB(t)
+	jobsRepo := NewJobsRepository(dbPair)
+	routine := createTestRoutine(t, NewRoutinesRepository(dbPair), createTestScene(t, dbPair))
+	job := createTestJob(t, jobsRepo, routine.RoutineID, time.Now().UTC())
+
+	// The den stops answering SetVolume, so its volume stays behind the ramp's
+	start := time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC)
+	controller := &fakeWakeController{volumes: map[string]int{"10.0.0.1": 5, "10.0.0.2": 5}, failIPs: map[string]bool{"10.0.0.2": true}}
+	ramper := newTestWakeRamper(jobsRepo, controller, start, nil)
+
+	ramp := &WakeRamp{
+		WakeProfile: WakeProfile{StartVolume: 5, EndVolume: 35, RampMinutes: 10},
+		UDNs:        []string{"udn-kitchen", "udn-den"},
+		StartedAt:   start,
+		Volume:      5,
+		Status:      WakeStatusRunning,
+	}
+	require.NoError(t, jobsRepo.CompleteJobWithDetail(job.JobID, "", &ExecutionDetail{Devices: []ExecutionDevice{}, Wake: ramp}))
+	ramper.Start(context.Background(), job.JobID, ramp)
+	ramper.wg.Wait()
+
+	completed, err := jobsRepo.GetByID(job.JobID)
+	require.NoError(t, err)
+	wake := completed.ExecutionDetail.Wake
+	require.Equal(t, WakeStatusCompleted, wake.Status, "the den isn't mistaken for a manual change")
+	require.Equal(t, 35, controller.volumes["10.0.0.1"])
+	require.Equal(t, 5, controller.volumes["10.0.0.2"])
+
+	t.Run("every speaker failing fails the ramp", func(t *testing.T) {
+		job := createTestJob(t, jobsRepo, routine.RoutineID, time.Now().UTC().Add(time.Minute))
+		controller := &fakeWakeController{volumes: map[string]int{"10.0.0.1": 5}, failIPs: map[string]bool{"10.0.0.1": true}}
+		ramper := newTestWakeRamper(jobsRepo, controller, start, nil)
+		ramp := &WakeRamp{
+			WakeProfile: WakeProfile{StartVolume: 5, EndVolume: 35, RampMinutes: 10},
+			UDNs:        []string{"udn-kitchen"},
+			StartedAt:   start,
+			Volume:      5,
+			Status:      WakeStatusRunning,
+		}
+		require.NoError(t, jobsRepo.CompleteJobWithDetail(job.JobID, "", &ExecutionDetail{Devices: []ExecutionDevice{}, Wake: ramp}))
+		ramper.Start(context.Background(), job.JobID, ramp)
+		ramper.wg.Wait()
+
+		failed, err := jobsRepo.GetByID(job.JobID)
+		require.NoError(t, err)
+		require.Equal(t, WakeStatusFailed, failed.ExecutionDetail.Wake.Status)
+		require.Equal(t, 5, failed.ExecutionDetail.Wake.Volume)
+	})
+}
@@ -1,10 +1,8 @@
 package server
 
 import (
-	"bufio"
 	"context"
-	"log"
-	"net"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
@@ -16,14 +14,25 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 
 	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apikeys"
 	"github.com/strefethen/sonos-hub-go/internal/applemusic"
+	"github.com/strefethen/sonos-hub-go/internal/artwork"
+	"github.com/strefethen/sonos-hub-go/internal/audiofiles"
 	"github.com/strefethen/sonos-hub-go/internal/audit"
 	"github.com/strefethen/sonos-hub-go/internal/auth"
+	"github.com/strefethen/sonos-hub-go/internal/backup"
 	"github.com/strefethen/sonos-hub-go/internal/config"
 	"github.com/strefethen/sonos-hub-go/internal/db"
 	"github.com/strefethen/sonos-hub-go/internal/devices"
+	"github.com/strefethen/sonos-hub-go/internal/idempotency"
+	"github.com/strefethen/sonos-hub-go/internal/maintenance"
+	"github.com/strefethen/sonos-hub-go/internal/mdns"
+	"github.com/strefethen/sonos-hub-go/internal/mqtt"
 	"github.com/strefethen/sonos-hub-go/internal/music"
+	"github.com/strefethen/sonos-hub-go/internal/nowplaying"
 	"github.com/strefethen/sonos-hub-go/internal/openapi"
+	"github.com/strefethen/sonos-hub-go/internal/ratelimit"
+	"github.com/strefethen/sonos-hub-go/internal/retention"
 	"github.com/strefethen/sonos-hub-go/internal/scene"
 	"github.com/strefethen/sonos-hub-go/internal/scheduler"
 	"github.com/strefethen/sonos-hub-go/internal/settings"
@@ -34,56 +43,44 @@ import (
 	"github.com/strefethen/sonos-hub-go/internal/spotifysearch"
 	"github.com/strefethen/sonos-hub-go/internal/system"
 	"github.com/strefethen/sonos-hub-go/internal/templates"
+	"github.com/strefethen/sonos-hub-go/internal/webhooks"
 )
 
-// responseWriter wraps http.ResponseWriter to capture status code
-type responseWriter struct {
-	http.ResponseWriter
-	status int
-}
-
-func (rw *responseWriter) WriteHeader(code int) {
-	rw.status = code
-	rw.ResponseWriter.WriteHeader(code)
-}
-
-// Hijack implements http.Hijacker for WebSocket support
-func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	if hijacker, ok := rw.ResponseWriter.(http.Hijacker); ok {
-		return hijacker.Hijack()
-	}
-	return nil, nil, http.ErrNotSupported
-}
-
-// requestLoggerMiddleware logs all incoming HTTP requests
-func requestLoggerMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		wrapped := &responseWriter{ResponseWriter: w, status: http.StatusOK}
-		next.ServeHTTP(wrapped, r)
-		log.Printf("%s %s %d %s", r.Method, r.URL.RequestURI(), wrapped.status, time.Since(start).Round(time.Millisecond))
-	})
-}
-
 // Options controls server wiring.
 type Options struct {
 	DisableDiscovery bool
+	// AdvertiseMDNS registers the hub over mDNS until shutdown
+	AdvertiseMDNS bool
 }
 
 // NewHandler builds the HTTP handler and returns a shutdown function.
 func NewHandler(cfg config.Config, options Options) (http.Handler, func(context.Context) error, error) {
-	log.Printf("Using database: %s", cfg.SQLiteDBPath)
+	slog.Info("Using database", "path", cfg.SQLiteDBPath)
 	dbPair, err := db.Init(cfg.SQLiteDBPath)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	apiKeys := apikeys.NewRepository(dbPair)
+	identity, err := system.LoadIdentity(dbPair, cfg.HubName)
+	if err != nil {
+		dbPair.Close()
+		return nil, nil, err
+	}
+
 	router := chi.NewRouter()
 	router.Use(middleware.StripSlashes) // Handle trailing slashes like Node.js
-	router.Use(requestLoggerMiddleware)
 	router.Use(api.RequestIDMiddleware)
 	router.Use(api.RecovererMiddleware)
-	router.Use(auth.Middleware(cfg))
+	router.Use(auth.Middleware(cfg, apiKeys))
+	// Polling clients can overwhelm speakers with SOAP calls; the parity suite is exempt
+	sonosLimiter := ratelimit.NewLimiter(cfg.SonosRateLimitPerSec, cfg.SonosRateLimitBurst)
+	router.Use(ratelimit.Middleware(sonosLimiter, "/v1/sonos/", func(r *http.Request) bool {
+		return auth.IsTestModeRequest(r, cfg)
+	}))
+	// Retried creates and triggers with the same Idempotency-Key replay the first response
+	router.Use(idempotency.Middleware(idempotency.NewRepository(dbPair),
+		"/v1/routines", "/v1/routines/*/trigger", "/v1/routines/*/run", "/v1/music/sets"))
 
 	registerHealthRoutes(router)
 	openapi.RegisterRoutes(router)
@@ -92,9 +89,20 @@ func NewHandler(cfg config.Config, options Options) (http.Handler, func(context.
 	shutdownCtx, shutdownCancel := context.WithCancel(context.Background())
 	pairingStore.StartCleanup(shutdownCtx, time.Minute)
 	auth.RegisterRoutes(router, pairingStore, cfg)
+	apikeys.RegisterRoutes(router, apiKeys)
+
+	// Routine results and offline speakers are POSTed to registered webhooks
+	webhooksRepo := webhooks.NewRepository(dbPair)
+	webhookDispatcher := webhooks.NewDispatcher(webhooksRepo, nil)
+	webhooks.RegisterRoutes(router, webhooksRepo)
 
 	soapClient := soap.NewClient(time.Duration(cfg.SonosTimeoutMs) * time.Millisecond)
 	deviceService := devices.NewService(cfg, nil, soapClient)
+	if err := deviceService.SetSettingsRepository(devices.NewSettingsRepository(dbPair)); err != nil {
+		slog.Warn("Failed to load device aliases", "error", err)
+	}
+	// Calls to a speaker whose DHCP lease changed find its new address and retry once
+	soapClient.SetRelocator(deviceService)
 
 	// Create zone cache for sharing between sonos service and event manager
 	zoneCache := sonos.NewZoneGroupCache(time.Duration(cfg.ZoneCacheTTLSeconds) * time.Second)
@@ -133,19 +141,29 @@ func NewHandler(cfg config.Config, options Options) (http.Handler, func(context.
 					ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 					defer cancel()
 					if err := eventManager.SubscribeDevice(ctx, ip, udn); err != nil {
-						log.Printf("UPNP: Failed to subscribe to %s: %v", ip, err)
+						slog.Warn("UPNP: Failed to subscribe", "ip", ip, "error", err)
 					}
 				}(device.IP, device.UDN)
 			}
 
 			// Log discovery callback summary (only when there are new devices or first run)
 			if newDevices > 0 || alreadySubscribed == 0 {
-				log.Printf("UPNP: Discovery callback: %d devices, %d already subscribed, %d new",
-					len(discovered), alreadySubscribed, newDevices)
+				slog.Info("UPNP: Discovery callback",
+					"devices", len(discovered), "already_subscribed", alreadySubscribed, "new", newDevices)
 			}
 		})
 	}
 
+	deviceService.SetOfflineCallback(func(device devices.LogicalDevice) {
+		webhookDispatcher.Publish(webhooks.EventDeviceOffline, map[string]any{
+			"udn":          device.UDN,
+			"room_name":    device.RoomName,
+			"model":        device.Model,
+			"ip":           device.IP,
+			"last_seen_at": api.RFC3339Millis(device.LastSeenAt),
+		})
+	})
+
 	// Only disable discovery if explicitly requested via options (for tests)
 	// AllowTestMode is for auth bypass only, not for skipping device discovery
 	if options.DisableDiscovery {
@@ -164,6 +182,15 @@ func NewHandler(cfg config.Config, options Options) (http.Handler, func(context.
 	// Create sonos service with state provider for hybrid data layer
 	sonosService := sonos.NewServiceWithStateProvider(deviceService, soapClient, cfg.DefaultSonosIP, time.Duration(cfg.SonosTimeoutMs)*time.Millisecond, time.Duration(cfg.ZoneCacheTTLSeconds)*time.Second, stateProvider)
 	sonosService.ZoneCache = zoneCache // Use the shared zone cache
+	sonosService.FavoritesCache = sonos.NewFavoritesCache(time.Duration(cfg.FavoritesCacheTTLSeconds) * time.Second)
+	sonosService.Limiter = sonos.NewSOAPLimiter(sonos.LimiterConfig{
+		MaxConcurrent:          cfg.SOAPMaxConcurrent,
+		MaxConcurrentPerDevice: cfg.SOAPMaxConcurrentPerDevice,
+		BreakerThreshold:       cfg.SOAPBreakerThreshold,
+		BreakerCooldown:        time.Duration(cfg.SOAPBreakerCooldownSec) * time.Second,
+	})
+	sonosService.PartySnapshots = sonos.NewPartySnapshotsRepository(dbPair)
+	deviceService.SetSOAPStatsProvider(sonosService.Limiter)
 	sonos.RegisterRoutes(router, sonosService)
 
 	// UPnP callback handler - will be wired up outside Chi to bypass method restrictions
@@ -179,18 +206,36 @@ func NewHandler(cfg config.Config, options Options) (http.Handler, func(context.
 
 		// Start event manager
 		if err := eventManager.Start(); err != nil {
-			log.Printf("Warning: Failed to start UPnP event manager: %v", err)
+			slog.Warn("Failed to start UPnP event manager", "error", err)
 		}
 	}
 
+	// Now-playing history: routes always available, sampler only runs with live devices
+	nowPlayingRepo := nowplaying.NewRepository(dbPair)
+	nowplaying.RegisterRoutes(router, nowPlayingRepo)
+	var nowPlayingSampler *nowplaying.Sampler
+	if cfg.NowPlayingSampleIntervalSec > 0 && !options.DisableDiscovery {
+		nowPlayingSampler = nowplaying.NewSampler(sonosService, nowPlayingRepo, time.Duration(cfg.NowPlayingSampleIntervalSec)*time.Second, nil)
+		nowPlayingSampler.Start()
+	}
+
 	playService := sonos.NewPlayService(soapClient, deviceService, time.Duration(cfg.SonosTimeoutMs)*time.Millisecond, nil)
+	playService.SetFavoritesProvider(sonosService) // Share the favorites cache with PlayFavorite
+	audioLibrary := audiofiles.NewLibrary(cfg.AudioDir, cfg.PublicBaseURL)
+	playService.SetLocalFileProvider(audioLibrary)
 	sonos.RegisterPlayRoutes(router, playService)
+	audiofiles.RegisterRoutes(router, audioLibrary)
+
+	// Created ahead of its routes: scene, music set and routine changes are audited
+	auditService := audit.NewService(cfg, dbPair, nil)
 
 	sceneService := scene.NewService(cfg, dbPair, nil, deviceService, soapClient)
-	scene.RegisterRoutes(router, sceneService)
+	scene.RegisterRoutes(router, sceneService, auditService)
 
 	// Create Spotify search connection manager (for Chrome extension WebSocket)
 	spotifySearchManager := spotifysearch.NewConnectionManager()
+	spotifySearchManager.SetReconnectGrace(time.Duration(cfg.SpotifySearchReconnectGraceMs) * time.Millisecond)
+	spotifySearchManager.SetEventRecorder(auditService)
 	spotifysearch.RegisterRoutes(router, spotifySearchManager)
 
 	// Create Apple Music client if configured
@@ -203,7 +248,7 @@ func NewHandler(cfg config.Config, options Options) (http.Handler, func(context.
 			Expiry:         time.Duration(cfg.AppleTokenExpirySec) * time.Second,
 		})
 		if err != nil {
-			log.Printf("Warning: Failed to create Apple Music token manager: %v", err)
+			slog.Warn("Failed to create Apple Music token manager", "error", err)
 		} else {
 			appleClient = applemusic.NewClient(applemusic.ClientConfig{
 				TokenManager: tokenManager,
@@ -211,13 +256,36 @@ func NewHandler(cfg config.Config, options Options) (http.Handler, func(context.
 				Storefront:   cfg.DefaultStorefront,
 				Timeout:      time.Duration(cfg.SonosTimeoutMs) * time.Millisecond,
 			})
-			log.Printf("Apple Music client initialized (storefront: %s)", cfg.DefaultStorefront)
+			slog.Info("Apple Music client initialized", "storefront", cfg.DefaultStorefront)
 		}
 	}
 
 	// Create music service (needed for scheduler routes)
 	musicService := music.NewService(cfg, dbPair, nil)
-	music.RegisterRoutes(router, musicService, spotifySearchManager, appleClient, soapClient, deviceService)
+	music.RegisterRoutes(router, musicService, spotifySearchManager, appleClient, soapClient, deviceService, auditService)
+	sonosService.SetMembership = musicService // Enables ?set_id= on /v1/sonos/favorites
+	// Plays sets on demand for POST /v1/music/sets/{set_id}/play
+	musicService.SetPlayback(playService, sonosService)
+	musicService.SetFavoriteCatalog(sonosService) // Flags imported set items missing their favorite
+
+	// Local copies of favorite artwork for set items and routines
+	var favoriteArtwork *artwork.FavoriteCache
+	if cfg.ArtworkDir != "" {
+		favoriteArtwork, err = artwork.NewFavoriteCache(dbPair, cfg.ArtworkDir, artwork.SOAPFavoriteSource{
+			Client:   soapClient,
+			DeviceIP: cfg.DefaultSonosIP,
+			Timeout:  30 * time.Second,
+		})
+		if err != nil {
+			slog.Warn("Favorite artwork cache unavailable", "error", err)
+			favoriteArtwork = nil
+		} else {
+			musicService.SetFavoriteArtwork(favoriteArtwork)
+			if !options.DisableDiscovery {
+				favoriteArtwork.StartRefreshJob()
+			}
+		}
+	}
 
 	// Create content resolver for routine execution (handles direct service playback)
 	contentResolver := sonos.NewContentResolver(
@@ -226,6 +294,8 @@ func NewHandler(cfg config.Config, options Options) (http.Handler, func(context.
 		time.Duration(cfg.SonosTimeoutMs)*time.Millisecond,
 		nil,
 	)
+	contentResolver.SetFavoritesProvider(sonosService)
+	contentResolver.SetLocalFileProvider(audioLibrary)
 
 	// Create scene adapter for the routine executor
 	sceneAdapter := scheduler.NewSceneServiceAdapter(sceneService)
@@ -239,34 +309,132 @@ func NewHandler(cfg config.Config, options Options) (http.Handler, func(context.
 		time.Duration(cfg.SonosTimeoutMs)*time.Millisecond,
 	)
 
+	// Stop routine playback after the routine's duration_minutes
+	autoStopper := scheduler.NewAutoStopper(sonosService, deviceService, nil)
+	autoStopper.SetGroupRestorer(sceneService)
+	routineExecutor.SetAutoStopper(autoStopper)
+
+	// Play a routine's holiday music set on holidays (PLAY_ALTERNATE)
+	holidaysRepo := scheduler.NewHolidaysRepository(dbPair)
+	routineExecutor.SetHolidaysRepository(holidaysRepo)
+
+	// Run routines created from a bare scene_id on the scene's members
+	routineExecutor.SetSceneService(sceneService)
+
+	// Enforce arc_tv_policy when a routine's speakers are in TV mode
+	routineExecutor.SetTVModeDetector(sonosService)
+
+	// Apply music_policy.play_mode (shuffle, repeat, crossfade) once playback starts
+	routineExecutor.SetPlayModeController(sonosService)
+
+	// Apply each speaker's audio_settings (night mode, speech enhancement, EQ) once playback starts
+	routineExecutor.SetAudioSettingsController(sonosService)
+
+	// Arm the coordinator's sleep timer for routines with sleep_timer_minutes
+	routineExecutor.SetSleepTimerController(sonosService)
+
+	// Run set_volume, stop and pause for routines that play no music
+	routineExecutor.SetActionController(sonosService)
+
+	// Put back what was playing after restore_previous_state routines
+	jobsRepo := scheduler.NewJobsRepository(dbPair)
+	playbackRestorer := scheduler.NewPlaybackRestorer(sonosService, deviceService, jobsRepo, autoStopper, nil)
+	autoStopper.SetPlaybackRestorer(playbackRestorer)
+	routineExecutor.SetPlaybackRestorer(playbackRestorer)
+
+	// Create settings service (before the scheduler, which reads the default timezone and
+	// quiet hours, and audit, which reads retention, from runtime settings)
+	settingsService := settings.NewService(cfg, dbPair, nil)
+
 	// Create scheduler service with routine executor
 	schedulerService := scheduler.NewService(cfg, dbPair, nil, routineExecutor)
-	scheduler.RegisterRoutes(router,
-		scheduler.NewRoutinesRepository(dbPair),
-		scheduler.NewJobsRepository(dbPair),
-		scheduler.NewHolidaysRepository(dbPair),
-		sceneService,
-		deviceService,
-		musicService,
-	)
+	schedulerService.SetRuntimeSettings(settingsService)
+	routineEvents := eventPublishers{webhookDispatcher}
+
+	// MQTT is off unless a broker is configured
+	var mqttBridge *mqtt.Bridge
+	if cfg.MQTTBrokerURL != "" {
+		mqttBridge = mqtt.NewBridge(mqtt.Config{
+			BrokerURL:     cfg.MQTTBrokerURL,
+			ClientID:      "sonos-hub-" + identity.HubID,
+			Username:      cfg.MQTTUsername,
+			Password:      cfg.MQTTPassword,
+			TopicPrefix:   cfg.MQTTTopicPrefix,
+			StateInterval: time.Duration(cfg.MQTTStateIntervalSec) * time.Second,
+		}, sonosService, sonosService, nil)
+		mqttBridge.Start()
+		routineEvents = append(routineEvents, mqttBridge)
+	}
+	schedulerService.SetEventPublisher(routineEvents)
+
+	// Ramp wake_profile routines up once playback starts, carrying on with ramps a restart interrupted
+	wakeRamper := scheduler.NewWakeRamper(sonosService, deviceService, jobsRepo, nil)
+	schedulerService.SetWakeRamper(wakeRamper)
+	wakeRamper.Resume()
+	routinesRepo := scheduler.NewRoutinesRepository(dbPair)
+	templatesService := templates.NewService(dbPair)
+	scheduler.RegisterRoutes(router, scheduler.RouteDeps{
+		Routines:         routinesRepo,
+		Jobs:             jobsRepo,
+		Holidays:         holidaysRepo,
+		Scenes:           sceneService,
+		Devices:          deviceService,
+		Music:            musicService,
+		TriggerCooldown:  scheduler.NewTriggerCooldown(time.Duration(cfg.RoutineTriggerCooldownSec) * time.Second),
+		NextRuns:         schedulerService.JobGenerator(),
+		PlaybackRestorer: playbackRestorer,
+		Recorder:         auditService,
+		Templates:        templatesService,
+		Planner:          routineExecutor,
+		Runtime:          settingsService,
+	})
 	schedulerService.Start()
 
-	// Create audit service
-	auditService := audit.NewService(cfg, dbPair, nil)
+	settings.RegisterRoutes(router, settingsService)
+
+	// Audit log routes and pruning
 	audit.RegisterRoutes(router, auditService)
+	auditService.SetRetentionProvider(settingsService)
 	auditService.StartPruneJob()
 
+	// Backfills and cache refreshes on demand, for installs without shell access
+	maintenanceRunner := maintenance.NewRunner(maintenance.Env{
+		DB:        dbPair,
+		Artwork:   favoriteArtwork,
+		Favorites: sonosService,
+	}, auditService)
+	maintenance.RegisterRoutes(router, maintenanceRunner)
+
+	// Prune old finished jobs and play history nightly
+	retentionPruner := retention.NewPruner(jobsRepo, music.NewPlayHistoryRepository(dbPair),
+		cfg.JobRetentionDays, cfg.HistoryRetentionDays, nil)
+	// Deleted routines and their scenes are purged once they can no longer be restored
+	retentionPruner.SetDeletedRoutinePurge(scheduler.NewDeletedRoutinePurger(routinesRepo, sceneService, nil), cfg.DeletedRoutineRetentionDays)
+	retentionPruner.Start()
+
+	// Database backup download and restore; restores pause the scheduler
+	backup.RegisterRoutes(router, backup.NewService(dbPair, schedulerService, auditService))
+
 	// Create system service (with scheduler for status reporting, music service for set enrichment)
 	systemService := system.NewService(cfg, dbPair, nil, deviceService, musicService, schedulerService)
+	systemService.SetRetentionStatusProvider(retentionPruner)
+	systemService.SetRateLimitStatsProvider(sonosLimiter)
+	systemService.SetExecutionsProvider(schedulerService)
+	if !options.DisableDiscovery {
+		// The dashboard's now-playing section asks the speakers
+		systemService.SetNowPlayingProvider(sonosService)
+	}
+	systemService.SetIdentity(identity)
 	system.RegisterRoutes(router, systemService)
 
-	// Create templates service
-	templatesService := templates.NewService(dbPair)
-	templates.RegisterRoutes(router, templatesService)
+	// Advertise the hub so apps can find it; a failure only means they must be given its address
+	var advertiser *mdns.Advertiser
+	if options.AdvertiseMDNS {
+		advertiser = advertiseHub(identity, cfg.Port)
+	}
 
-	// Create settings service
-	settingsService := settings.NewService(dbPair, nil)
-	settings.RegisterRoutes(router, settingsService)
+	// Template routes (instantiating a template is a scheduler route)
+	templates.RegisterRoutes(router, templatesService)
 
 	// Create Sonos Cloud service (only if configured)
 	if cfg.SonosClientID != "" && cfg.SonosClientSecret != "" {
@@ -284,15 +452,48 @@ func NewHandler(cfg config.Config, options Options) (http.Handler, func(context.
 		sonoscloud.RegisterWebhookRoute(router, stateCache, nil)
 	}
 
+	// Album art proxy; rewriting response URLs to use it is opt-in
+	artwork.SetRewriteEnabled(false)
+	var artworkProxy *artwork.Proxy
+	if cfg.ArtworkCacheDir != "" {
+		artworkProxy, err = artwork.NewProxy(artwork.Config{
+			CacheDir:      cfg.ArtworkCacheDir,
+			CacheMaxBytes: int64(cfg.ArtworkCacheMaxMB) << 20,
+			IsDeviceIP:    deviceService.IsKnownDeviceIP,
+		})
+		if err != nil {
+			slog.Warn("Artwork proxy unavailable", "error", err)
+			artworkProxy = nil
+		} else {
+			artwork.SetRewriteEnabled(cfg.ArtworkProxyEnabled)
+		}
+	}
+	artwork.RegisterRoutes(router, artworkProxy, favoriteArtwork)
+
 	// Serve static files with caching headers (matching Node.js behavior)
 	fileServer := http.FileServer(http.Dir("./assets"))
 	router.Handle("/v1/assets/*", http.StripPrefix("/v1/assets/", staticFileHandler(fileServer)))
 
 	shutdown := func(ctx context.Context) error {
 		shutdownCancel()
+		advertiser.Stop()
 		schedulerService.Stop()
+		autoStopper.Stop()
+		wakeRamper.Stop()
 		auditService.StopPruneJob()
+		retentionPruner.Stop()
+		if favoriteArtwork != nil {
+			favoriteArtwork.StopRefreshJob()
+		}
+		maintenanceRunner.Wait()
+		if nowPlayingSampler != nil {
+			nowPlayingSampler.Stop()
+		}
 		deviceService.StopPeriodicDiscovery()
+		webhookDispatcher.Stop()
+		if mqttBridge != nil {
+			mqttBridge.Stop()
+		}
 		spotifySearchManager.Close()
 		// Stop UPnP event manager (unsubscribes from all devices)
 		if eventManager != nil && eventManager.IsEnabled() {
@@ -319,6 +520,35 @@ func NewHandler(cfg config.Config, options Options) (http.Handler, func(context.
 	return handler, shutdown, nil
 }
 
+// eventPublishers sends routine events to each publisher in turn.
+type eventPublishers []scheduler.EventPublisher
+
+func (publishers eventPublishers) Publish(event webhooks.Event, data map[string]any) {
+	for _, publisher := range publishers {
+		publisher.Publish(event, data)
+	}
+}
+
+// advertiseHub registers the hub over mDNS with its ID and versions in the TXT record.
+// Returns nil, after logging why, if it can't be advertised.
+func advertiseHub(identity system.Identity, port string) *mdns.Advertiser {
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		slog.Warn("MDNS: Not advertising hub, port is not a number", "port", port)
+		return nil
+	}
+	advertiser, err := mdns.Advertise(identity.Name, portNumber, map[string]string{
+		"hub_id":      identity.HubID,
+		"version":     identity.Version,
+		"api_version": identity.APIVersion,
+	})
+	if err != nil {
+		slog.Warn("MDNS: Failed to advertise hub", "error", err)
+		return nil
+	}
+	return advertiser
+}
+
 // staticFileHandler wraps a file server with caching headers matching Node.js behavior
 func staticFileHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -3,7 +3,8 @@ package settings
 import (
 	"database/sql"
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 
 	"github.com/strefethen/sonos-hub-go/internal/api"
 	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/config"
 )
 
 // TVRoutingSettings holds TV routing configuration.
@@ -32,19 +34,21 @@ type DBPair interface {
 // Service provides settings management functionality.
 // Uses separate reader/writer connections for optimal SQLite concurrency.
 type Service struct {
+	cfg    config.Config
 	reader *sql.DB // For SELECT queries
 	writer *sql.DB // For INSERT/UPDATE/DELETE
-	logger *log.Logger
+	logger *slog.Logger
 }
 
 // NewService creates a new settings service.
 // Accepts a DBPair for optimal SQLite concurrency with separate reader/writer pools.
-func NewService(dbPair DBPair, logger *log.Logger) *Service {
+func NewService(cfg config.Config, dbPair DBPair, logger *slog.Logger) *Service {
 	if logger == nil {
-		logger = log.Default()
+		logger = slog.Default()
 	}
 
 	return &Service{
+		cfg:    cfg,
 		reader: dbPair.Reader(),
 		writer: dbPair.Writer(),
 		logger: logger,
@@ -53,10 +57,52 @@ func NewService(dbPair DBPair, logger *log.Logger) *Service {
 
 // RegisterRoutes wires settings routes to the router.
 func RegisterRoutes(router chi.Router, service *Service) {
+	router.Method(http.MethodGet, "/v1/settings", api.Handler(getSettings(service)))
+	router.Method(http.MethodPatch, "/v1/settings", api.Handler(updateSettings(service)))
 	router.Method(http.MethodGet, "/v1/settings/tv-routing", api.Handler(getTVRoutingSettings(service)))
 	router.Method(http.MethodPut, "/v1/settings/tv-routing", api.Handler(updateTVRoutingSettings(service)))
 }
 
+// getSettings handles GET /v1/settings
+func getSettings(service *Service) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		runtime, err := service.GetRuntimeSettings()
+		if err != nil {
+			return apperrors.NewInternalError("Failed to get settings")
+		}
+
+		return api.WriteResource(w, http.StatusOK, formatSettings(service.cfg, runtime))
+	}
+}
+
+// updateSettings handles PATCH /v1/settings
+// Only runtime-editable settings are accepted; unknown fields are rejected.
+func updateSettings(service *Service) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var input UpdateRuntimeSettingsInput
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&input); err != nil {
+			return apperrors.NewValidationError("invalid request body", map[string]any{
+				"editable_fields": []string{"default_timezone", "quiet_hours", "audit_retention_days"},
+			})
+		}
+
+		runtime, err := service.UpdateRuntimeSettings(input)
+		if err != nil {
+			var validationErr *ValidationError
+			if errors.As(err, &validationErr) {
+				return apperrors.NewValidationError(validationErr.Message, map[string]any{
+					"field": validationErr.Field,
+				})
+			}
+			return apperrors.NewInternalError("Failed to update settings")
+		}
+
+		return api.WriteResource(w, http.StatusOK, formatSettings(service.cfg, runtime))
+	}
+}
+
 // getTVRoutingSettings handles GET /v1/settings/tv-routing
 func getTVRoutingSettings(service *Service) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
@@ -133,7 +179,7 @@ func (s *Service) GetTVRoutingSettings() (*TVRoutingSettings, error) {
 	if err == nil && value.Valid && value.String != "" {
 		// New JSON format
 		if err := json.Unmarshal([]byte(value.String), settings); err != nil {
-			s.logger.Printf("Failed to parse tv_routing JSON: %v", err)
+			s.logger.Warn("Failed to parse tv_routing JSON", "error", err)
 		}
 		settings.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
 		if settings.UpdatedAt.IsZero() {
@@ -238,6 +284,33 @@ func (s *Service) UpdateTVRoutingSettings(input UpdateTVRoutingInput) (*TVRoutin
 	return current, nil
 }
 
+// formatSettings formats the server config snapshot and runtime settings for JSON response.
+func formatSettings(cfg config.Config, runtime *RuntimeSettings) map[string]any {
+	var quietHours any
+	if runtime.QuietHours != nil {
+		quietHours = map[string]any{
+			"start": runtime.QuietHours.Start,
+			"end":   runtime.QuietHours.End,
+		}
+	}
+
+	var updatedAt any
+	if !runtime.UpdatedAt.IsZero() {
+		updatedAt = runtime.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+
+	return map[string]any{
+		"object": "settings",
+		"server": serverSettings(cfg),
+		"runtime": map[string]any{
+			"default_timezone":     runtime.DefaultTimezone,
+			"quiet_hours":          quietHours,
+			"audit_retention_days": runtime.AuditRetentionDays,
+		},
+		"updated_at": updatedAt,
+	}
+}
+
 // formatTVRoutingSettings formats TVRoutingSettings for JSON response.
 func formatTVRoutingSettings(settings *TVRoutingSettings) map[string]any {
 	result := map[string]any{
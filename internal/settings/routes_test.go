@@ -172,3 +172,23 @@ func TestValidPolicies(t *testing.T) {
 		require.Equal(t, policy, settings.ArcTVPolicy)
 	}
 }
+
+func TestQuietHoursContains(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 3, 2, hour, minute, 0, 0, loc)
+	}
+
+	overnight := &QuietHours{Start: "22:00", End: "07:00"}
+	require.True(t, overnight.Contains(at(22, 0), loc))
+	require.True(t, overnight.Contains(at(3, 30), loc))
+	require.False(t, overnight.Contains(at(7, 0), loc), "end is exclusive")
+	require.False(t, overnight.Contains(at(12, 0), loc))
+	require.True(t, overnight.Contains(at(23, 0).UTC(), loc), "read in the given timezone")
+
+	afternoon := &QuietHours{Start: "13:00", End: "15:00"}
+	require.True(t, afternoon.Contains(at(14, 59), loc))
+	require.False(t, afternoon.Contains(at(15, 0), loc))
+	require.False(t, (&QuietHours{Start: "09:00", End: "09:00"}).Contains(at(9, 0), loc))
+}
@@ -0,0 +1,226 @@
+package settings
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/config"
+)
+
+// Runtime setting defaults, used until a value is saved via PATCH /v1/settings.
+const (
+	DefaultTimezone           = "America/Los_Angeles"
+	DefaultAuditRetentionDays = 90
+	MinAuditRetentionDays     = 1
+	MaxAuditRetentionDays     = 3650
+)
+
+// runtimeSettingsKey is the settings table key holding the runtime settings JSON blob.
+const runtimeSettingsKey = "runtime"
+
+var quietHoursTimeRegex = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d$`)
+
+// QuietHours is a daily window (HH:MM, in the default timezone) during which scheduled
+// routines don't run. End may be earlier than Start for windows that cross midnight.
+type QuietHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// Contains reports whether t, read in loc, falls in the window. Start is inclusive and
+// End exclusive, so a window whose start and end match is empty.
+func (q *QuietHours) Contains(t time.Time, loc *time.Location) bool {
+	local := t.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	start, end := clockMinutes(q.Start), clockMinutes(q.End)
+	if start <= end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// clockMinutes returns the minutes since midnight of a validated HH:MM time.
+func clockMinutes(hhmm string) int {
+	parsed, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		return 0
+	}
+	return parsed.Hour()*60 + parsed.Minute()
+}
+
+// RuntimeSettings holds the settings that can be changed without a restart.
+type RuntimeSettings struct {
+	DefaultTimezone    string      `json:"default_timezone"`
+	QuietHours         *QuietHours `json:"quiet_hours,omitempty"`
+	AuditRetentionDays int         `json:"audit_retention_days"`
+	UpdatedAt          time.Time   `json:"updated_at"`
+}
+
+// UpdateRuntimeSettingsInput represents the request body for PATCH /v1/settings.
+// QuietHours is raw so an explicit null (clear) can be told apart from an omitted field.
+type UpdateRuntimeSettingsInput struct {
+	DefaultTimezone    *string         `json:"default_timezone,omitempty"`
+	QuietHours         json.RawMessage `json:"quiet_hours,omitempty"`
+	AuditRetentionDays *int            `json:"audit_retention_days,omitempty"`
+}
+
+// GetRuntimeSettings returns the saved runtime settings merged over defaults.
+// Always reads from the database so edits take effect without a restart.
+func (s *Service) GetRuntimeSettings() (*RuntimeSettings, error) {
+	settings := &RuntimeSettings{
+		DefaultTimezone:    DefaultTimezone,
+		AuditRetentionDays: DefaultAuditRetentionDays,
+	}
+
+	var value, updatedAt string
+	err := s.reader.QueryRow(`
+		SELECT value, updated_at FROM settings WHERE key = ?
+	`, runtimeSettingsKey).Scan(&value, &updatedAt)
+	if err == sql.ErrNoRows {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(value), settings); err != nil {
+		s.logger.Warn("Failed to parse runtime settings JSON", "error", err)
+	}
+	settings.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	return settings, nil
+}
+
+// UpdateRuntimeSettings validates and persists a partial update.
+// Returns a *ValidationError describing the first invalid field.
+func (s *Service) UpdateRuntimeSettings(input UpdateRuntimeSettingsInput) (*RuntimeSettings, error) {
+	current, err := s.GetRuntimeSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	if input.DefaultTimezone != nil {
+		if _, err := time.LoadLocation(*input.DefaultTimezone); err != nil || *input.DefaultTimezone == "" {
+			return nil, &ValidationError{Field: "default_timezone", Message: "default_timezone must be a valid IANA timezone"}
+		}
+		current.DefaultTimezone = *input.DefaultTimezone
+	}
+
+	if len(input.QuietHours) > 0 {
+		if string(input.QuietHours) == "null" {
+			current.QuietHours = nil
+		} else {
+			var quietHours QuietHours
+			if err := json.Unmarshal(input.QuietHours, &quietHours); err != nil ||
+				!quietHoursTimeRegex.MatchString(quietHours.Start) || !quietHoursTimeRegex.MatchString(quietHours.End) {
+				return nil, &ValidationError{Field: "quiet_hours", Message: "quiet_hours must have start and end in HH:MM format, or be null"}
+			}
+			current.QuietHours = &quietHours
+		}
+	}
+
+	if input.AuditRetentionDays != nil {
+		days := *input.AuditRetentionDays
+		if days < MinAuditRetentionDays || days > MaxAuditRetentionDays {
+			return nil, &ValidationError{
+				Field:   "audit_retention_days",
+				Message: fmt.Sprintf("audit_retention_days must be between %d and %d", MinAuditRetentionDays, MaxAuditRetentionDays),
+			}
+		}
+		current.AuditRetentionDays = days
+	}
+
+	now := time.Now().UTC()
+	current.UpdatedAt = now
+
+	jsonBytes, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.writer.Exec(`
+		INSERT INTO settings (key, value, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET
+			value = excluded.value,
+			updated_at = excluded.updated_at
+	`, runtimeSettingsKey, string(jsonBytes), now.Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+
+	return current, nil
+}
+
+// AuditRetentionDays implements audit.RetentionProvider.
+// Falls back to the default if settings cannot be read.
+func (s *Service) AuditRetentionDays() int {
+	settings, err := s.GetRuntimeSettings()
+	if err != nil {
+		s.logger.Warn("Failed to read runtime settings, using default audit retention", "error", err)
+		return DefaultAuditRetentionDays
+	}
+	return settings.AuditRetentionDays
+}
+
+// DefaultTimezone returns the timezone for routines created without one.
+// Falls back to the default if settings cannot be read.
+func (s *Service) DefaultTimezone() string {
+	settings, err := s.GetRuntimeSettings()
+	if err != nil {
+		s.logger.Warn("Failed to read runtime settings, using default timezone", "error", err)
+		return DefaultTimezone
+	}
+	return settings.DefaultTimezone
+}
+
+// InQuietHours reports whether t falls in the quiet hours, read in the default timezone.
+// Returns false when no quiet hours are set or settings cannot be read.
+func (s *Service) InQuietHours(t time.Time) bool {
+	settings, err := s.GetRuntimeSettings()
+	if err != nil {
+		s.logger.Warn("Failed to read runtime settings, ignoring quiet hours", "error", err)
+		return false
+	}
+	if settings.QuietHours == nil {
+		return false
+	}
+	loc, err := time.LoadLocation(settings.DefaultTimezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return settings.QuietHours.Contains(t, loc)
+}
+
+// ValidationError is returned when a settings update contains an invalid value.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// serverSettings returns the non-sensitive subset of config plus derived feature flags.
+// Secrets (JWT secret, API keys, OAuth client credentials) and host details are never included.
+func serverSettings(cfg config.Config) map[string]any {
+	return map[string]any{
+		"environment":                         cfg.NodeEnv,
+		"sonos_timeout_ms":                    cfg.SonosTimeoutMs,
+		"zone_cache_ttl_seconds":              cfg.ZoneCacheTTLSeconds,
+		"upnp_state_cache_ttl_seconds":        cfg.UPnPStateCacheTTLSeconds,
+		"ssdp_rescan_interval_ms":             cfg.SSDPRescanIntervalMs,
+		"default_storefront":                  cfg.DefaultStorefront,
+		"routine_trigger_cooldown_seconds":    cfg.RoutineTriggerCooldownSec,
+		"now_playing_sample_interval_seconds": cfg.NowPlayingSampleIntervalSec,
+		"features": map[string]any{
+			"apple_music":         cfg.AppleTeamID != "" && cfg.AppleKeyID != "" && cfg.ApplePrivateKeyPath != "",
+			"sonos_cloud":         cfg.SonosClientID != "" && cfg.SonosClientSecret != "",
+			"upnp_events":         cfg.UPnPEventsEnabled,
+			"now_playing_history": cfg.NowPlayingSampleIntervalSec > 0,
+		},
+	}
+}
@@ -0,0 +1,245 @@
+package sonos
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+var (
+	alarmTimePattern       = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d:[0-5]\d$`)
+	alarmRecurrencePattern = regexp.MustCompile(`^(ONCE|WEEKDAYS|WEEKENDS|DAILY|ON_[0-6]{1,7})$`)
+)
+
+// AlarmFields are the settable fields of a native Sonos alarm, named as the list
+// endpoint returns them. Nil fields are left as they are (or defaulted on create).
+type AlarmFields struct {
+	StartTime          *string `json:"start_time"`
+	Duration           *string `json:"duration"`
+	Recurrence         *string `json:"recurrence"`
+	Enabled            *bool   `json:"enabled"`
+	RoomUUID           *string `json:"room_uuid"`
+	ProgramURI         *string `json:"program_uri"`
+	ProgramMetadata    *string `json:"program_metadata"`
+	PlayMode           *string `json:"play_mode"`
+	Volume             *int    `json:"volume"`
+	IncludeLinkedZones *bool   `json:"include_linked_zones"`
+}
+
+// newAlarm returns the alarm the Sonos app creates by default: the chime, daily, for two
+// hours, in the given room.
+func newAlarm(roomUUID string) soap.Alarm {
+	return soap.Alarm{
+		Duration:   "02:00:00",
+		Recurrence: "DAILY",
+		Enabled:    true,
+		RoomUUID:   roomUUID,
+		ProgramURI: "x-rincon-buzzer:0",
+		PlayMode:   "NORMAL",
+		Volume:     20,
+	}
+}
+
+// Apply validates the fields and copies the ones that are set onto alarm.
+func (f AlarmFields) Apply(alarm *soap.Alarm) error {
+	if f.StartTime != nil && !alarmTimePattern.MatchString(*f.StartTime) {
+		return apperrors.NewValidationError("start_time must be HH:MM:SS", nil)
+	}
+	if f.Duration != nil && !alarmTimePattern.MatchString(*f.Duration) {
+		return apperrors.NewValidationError("duration must be HH:MM:SS", nil)
+	}
+	if f.Recurrence != nil && !alarmRecurrencePattern.MatchString(*f.Recurrence) {
+		return apperrors.NewValidationError("recurrence must be ONCE, WEEKDAYS, WEEKENDS, DAILY or ON_ followed by weekday digits (0 = Sunday)", nil)
+	}
+	if f.RoomUUID != nil && *f.RoomUUID == "" {
+		return apperrors.NewValidationError("room_uuid must not be empty", nil)
+	}
+	if f.ProgramURI != nil && *f.ProgramURI == "" {
+		return apperrors.NewValidationError("program_uri must not be empty", nil)
+	}
+	if f.PlayMode != nil && !isSonosPlayMode(*f.PlayMode) {
+		return apperrors.NewValidationError("play_mode must be a Sonos play mode such as NORMAL or SHUFFLE_NOREPEAT", nil)
+	}
+	if f.Volume != nil && (*f.Volume < 0 || *f.Volume > 100) {
+		return apperrors.NewValidationError("volume must be between 0 and 100", nil)
+	}
+
+	if f.StartTime != nil {
+		alarm.StartTime = *f.StartTime
+	}
+	if f.Duration != nil {
+		alarm.Duration = *f.Duration
+	}
+	if f.Recurrence != nil {
+		alarm.Recurrence = *f.Recurrence
+	}
+	if f.Enabled != nil {
+		alarm.Enabled = *f.Enabled
+	}
+	if f.RoomUUID != nil {
+		alarm.RoomUUID = *f.RoomUUID
+	}
+	if f.ProgramURI != nil {
+		alarm.ProgramURI = *f.ProgramURI
+	}
+	if f.ProgramMetadata != nil {
+		alarm.ProgramMetaData = *f.ProgramMetadata
+	}
+	if f.PlayMode != nil {
+		alarm.PlayMode = *f.PlayMode
+	}
+	if f.Volume != nil {
+		alarm.Volume = *f.Volume
+	}
+	if f.IncludeLinkedZones != nil {
+		alarm.IncludeLinkedZones = *f.IncludeLinkedZones
+	}
+	return nil
+}
+
+// isSonosPlayMode reports whether mode is an AVTransport play mode.
+func isSonosPlayMode(mode string) bool {
+	shuffle, repeat := ParseSonosPlayMode(mode)
+	known, _ := SonosPlayMode(shuffle, repeat)
+	return known == mode
+}
+
+func formatAlarm(alarm soap.Alarm) map[string]any {
+	return map[string]any{
+		"object":               "alarm",
+		"id":                   alarm.ID,
+		"start_time":           alarm.StartTime,
+		"duration":             alarm.Duration,
+		"recurrence":           alarm.Recurrence,
+		"enabled":              alarm.Enabled,
+		"room_uuid":            alarm.RoomUUID,
+		"program_uri":          alarm.ProgramURI,
+		"program_metadata":     alarm.ProgramMetaData,
+		"play_mode":            alarm.PlayMode,
+		"volume":               alarm.Volume,
+		"include_linked_zones": alarm.IncludeLinkedZones,
+	}
+}
+
+// findAlarm returns the alarm with id from the household's alarm list.
+func findAlarm(service *Service, deviceIP, id string) (soap.Alarm, error) {
+	result, err := service.ListAlarms(deviceIP)
+	if err != nil {
+		return soap.Alarm{}, apperrors.NewInternalError("Failed to fetch alarms")
+	}
+	for _, alarm := range result.Alarms {
+		if alarm.ID == id {
+			return alarm, nil
+		}
+	}
+	return soap.Alarm{}, apperrors.NewAppError(apperrors.ErrorCodeNotFound, "Alarm not found", http.StatusNotFound, map[string]any{"alarm_id": id}, nil)
+}
+
+// createAlarmHandler handles POST /v1/sonos/alarms. The alarm plays in the udn's room
+// unless room_uuid says otherwise.
+func createAlarmHandler(service *Service) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var body struct {
+			UDN string `json:"udn"`
+			AlarmFields
+		}
+		if err := decodeJSON(r, &body); err != nil || body.UDN == "" {
+			return apperrors.NewValidationError("udn is required", nil)
+		}
+		if body.StartTime == nil {
+			return apperrors.NewValidationError("start_time is required", nil)
+		}
+
+		alarm := newAlarm(body.UDN)
+		if err := body.Apply(&alarm); err != nil {
+			return err
+		}
+
+		deviceIP, err := service.ResolveDeviceIP(body.UDN)
+		if err != nil {
+			return deviceFailure(err, body.UDN, "", "Failed to resolve device")
+		}
+
+		id, err := service.CreateAlarm(deviceIP, alarm)
+		if err != nil {
+			return apperrors.NewInternalError("Failed to create alarm")
+		}
+		alarm.ID = id
+
+		response := formatAlarm(alarm)
+		response["created_at"] = api.RFC3339Millis(time.Now())
+		addDebugTargets(r, response, deviceIP, []string{deviceIP})
+
+		return api.WriteAction(w, http.StatusCreated, response)
+	}
+}
+
+// updateAlarmHandler handles PATCH /v1/sonos/alarms/{id}. Sonos replaces every field on
+// update, so the current alarm is read and the given fields merged into it.
+func updateAlarmHandler(service *Service) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		id := chi.URLParam(r, "id")
+		var body struct {
+			UDN string `json:"udn"`
+			AlarmFields
+		}
+		if err := decodeJSON(r, &body); err != nil || body.UDN == "" {
+			return apperrors.NewValidationError("udn is required", nil)
+		}
+
+		deviceIP, err := service.ResolveDeviceIP(body.UDN)
+		if err != nil {
+			return deviceFailure(err, body.UDN, "", "Failed to resolve device")
+		}
+
+		alarm, err := findAlarm(service, deviceIP, id)
+		if err != nil {
+			return err
+		}
+		if err := body.Apply(&alarm); err != nil {
+			return err
+		}
+
+		if err := service.UpdateAlarm(deviceIP, alarm); err != nil {
+			return apperrors.NewInternalError("Failed to update alarm")
+		}
+
+		response := formatAlarm(alarm)
+		response["updated_at"] = api.RFC3339Millis(time.Now())
+		addDebugTargets(r, response, deviceIP, []string{deviceIP})
+
+		return api.WriteAction(w, http.StatusOK, response)
+	}
+}
+
+// deleteAlarmHandler handles DELETE /v1/sonos/alarms/{id}?udn=.
+func deleteAlarmHandler(service *Service) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		id := chi.URLParam(r, "id")
+		udn := r.URL.Query().Get("udn")
+		if udn == "" {
+			return apperrors.NewValidationError("udn query parameter is required", nil)
+		}
+
+		deviceIP, err := service.ResolveDeviceIP(udn)
+		if err != nil {
+			return deviceFailure(err, udn, "", "Failed to resolve device")
+		}
+
+		if _, err := findAlarm(service, deviceIP, id); err != nil {
+			return err
+		}
+		if err := service.DestroyAlarm(deviceIP, id); err != nil {
+			return apperrors.NewInternalError("Failed to delete alarm")
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
@@ -0,0 +1,45 @@
+package sonos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlarmFields_Apply(t *testing.T) {
+	alarm := newAlarm("RINCON_KITCHEN")
+	start := "07:30:00"
+	recurrence := "ON_12345"
+	enabled := false
+	volume := 35
+	err := AlarmFields{StartTime: &start, Recurrence: &recurrence, Enabled: &enabled, Volume: &volume}.Apply(&alarm)
+	require.NoError(t, err)
+	require.Equal(t, "07:30:00", alarm.StartTime)
+	require.Equal(t, "ON_12345", alarm.Recurrence)
+	require.False(t, alarm.Enabled)
+	require.Equal(t, 35, alarm.Volume)
+	require.Equal(t, "RINCON_KITCHEN", alarm.RoomUUID)
+	require.Equal(t, "02:00:00", alarm.Duration, "unset fields keep their value")
+
+	tooLoud := 101
+	invalid := []AlarmFields{
+		{StartTime: strPtr("7:30")},
+		{Duration: strPtr("24:00:00")},
+		{Recurrence: strPtr("ON_7")},
+		{PlayMode: strPtr("SHUFFLE_ALL")},
+		{Volume: &tooLoud},
+		{ProgramURI: strPtr("")},
+	}
+	for _, fields := range invalid {
+		before := alarm
+		require.Error(t, fields.Apply(&alarm))
+		require.Equal(t, before, alarm, "invalid fields change nothing")
+	}
+}
+
+func TestIsSonosPlayMode(t *testing.T) {
+	for _, mode := range []string{"NORMAL", "REPEAT_ALL", "REPEAT_ONE", "SHUFFLE", "SHUFFLE_NOREPEAT", "SHUFFLE_REPEAT_ONE"} {
+		require.True(t, isSonosPlayMode(mode), mode)
+	}
+	require.False(t, isSonosPlayMode("RANDOM"))
+}
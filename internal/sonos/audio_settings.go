@@ -0,0 +1,258 @@
+package sonos
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// RenderingControl EQ types for home theater settings.
+const (
+	eqNightMode   = "NightMode"
+	eqDialogLevel = "DialogLevel" // Speech enhancement
+)
+
+// Audio setting limits.
+const (
+	MinToneLevel   = -10
+	MaxToneLevel   = 10
+	MaxDialogLevel = 4 // Most devices only accept 0 (off) and 1
+)
+
+// AudioSettings are a speaker's EQ settings. When read, settings the speaker doesn't
+// support are nil; when applied, nil settings are left as they are.
+type AudioSettings struct {
+	NightMode   *bool `json:"night_mode"`
+	DialogLevel *int  `json:"dialog_level"`
+	Bass        *int  `json:"bass"`
+	Treble      *int  `json:"treble"`
+	Loudness    *bool `json:"loudness"`
+}
+
+// IsEmpty reports whether no setting is set.
+func (s AudioSettings) IsEmpty() bool {
+	return s.NightMode == nil && s.DialogLevel == nil && s.Bass == nil && s.Treble == nil && s.Loudness == nil
+}
+
+// Validate checks that levels are in range.
+func (s AudioSettings) Validate() error {
+	if s.DialogLevel != nil && (*s.DialogLevel < 0 || *s.DialogLevel > MaxDialogLevel) {
+		return fmt.Errorf("dialog_level must be between 0 and %d", MaxDialogLevel)
+	}
+	if s.Bass != nil && (*s.Bass < MinToneLevel || *s.Bass > MaxToneLevel) {
+		return fmt.Errorf("bass must be between %d and %d", MinToneLevel, MaxToneLevel)
+	}
+	if s.Treble != nil && (*s.Treble < MinToneLevel || *s.Treble > MaxToneLevel) {
+		return fmt.Errorf("treble must be between %d and %d", MinToneLevel, MaxToneLevel)
+	}
+	return nil
+}
+
+// UnsupportedAudioSettingError is returned when a speaker rejects a setting, typically
+// night_mode or dialog_level on a speaker that isn't a home theater device.
+type UnsupportedAudioSettingError struct {
+	Setting string
+	Err     error
+}
+
+func (e *UnsupportedAudioSettingError) Error() string {
+	return fmt.Sprintf("speaker does not support %s: %v", e.Setting, e.Err)
+}
+
+func (e *UnsupportedAudioSettingError) Unwrap() error {
+	return e.Err
+}
+
+// AudioSettingsClient reads and changes a speaker's EQ. It is implemented by Service.
+type AudioSettingsClient interface {
+	GetEQ(deviceIP, eqType string) (int, error)
+	SetEQ(deviceIP, eqType string, value int) error
+	GetBass(deviceIP string) (int, error)
+	SetBass(deviceIP string, level int) error
+	GetTreble(deviceIP string) (int, error)
+	SetTreble(deviceIP string, level int) error
+	GetLoudness(deviceIP string) (bool, error)
+	SetLoudness(deviceIP string, enabled bool) error
+}
+
+// isRejected reports whether err is the speaker refusing the action, rather than the
+// speaker being unreachable.
+func isRejected(err error) bool {
+	var rejected *soap.SonosRejectedError
+	return errors.As(err, &rejected)
+}
+
+// GetAudioSettings reads a speaker's EQ. Settings the speaker rejects are left nil.
+func GetAudioSettings(client AudioSettingsClient, deviceIP string) (AudioSettings, error) {
+	var settings AudioSettings
+
+	readInt := func(setting string, read func() (int, error)) (*int, error) {
+		value, err := read()
+		if err != nil {
+			if isRejected(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to get %s: %w", setting, err)
+		}
+		return &value, nil
+	}
+
+	nightMode, err := readInt("night_mode", func() (int, error) { return client.GetEQ(deviceIP, eqNightMode) })
+	if err != nil {
+		return AudioSettings{}, err
+	}
+	if nightMode != nil {
+		enabled := *nightMode != 0
+		settings.NightMode = &enabled
+	}
+	if settings.DialogLevel, err = readInt("dialog_level", func() (int, error) { return client.GetEQ(deviceIP, eqDialogLevel) }); err != nil {
+		return AudioSettings{}, err
+	}
+	if settings.Bass, err = readInt("bass", func() (int, error) { return client.GetBass(deviceIP) }); err != nil {
+		return AudioSettings{}, err
+	}
+	if settings.Treble, err = readInt("treble", func() (int, error) { return client.GetTreble(deviceIP) }); err != nil {
+		return AudioSettings{}, err
+	}
+
+	loudness, err := client.GetLoudness(deviceIP)
+	if err != nil && !isRejected(err) {
+		return AudioSettings{}, fmt.Errorf("failed to get loudness: %w", err)
+	}
+	if err == nil {
+		settings.Loudness = &loudness
+	}
+
+	return settings, nil
+}
+
+// ApplyAudioSettings sends each setting in update to a speaker. A setting the speaker
+// rejects returns an UnsupportedAudioSettingError; settings before it are still applied.
+func ApplyAudioSettings(client AudioSettingsClient, deviceIP string, update AudioSettings) error {
+	if err := update.Validate(); err != nil {
+		return err
+	}
+
+	apply := func(setting string, set func() error) error {
+		if err := set(); err != nil {
+			if isRejected(err) {
+				return &UnsupportedAudioSettingError{Setting: setting, Err: err}
+			}
+			return fmt.Errorf("failed to set %s: %w", setting, err)
+		}
+		return nil
+	}
+
+	if update.NightMode != nil {
+		value := 0
+		if *update.NightMode {
+			value = 1
+		}
+		if err := apply("night_mode", func() error { return client.SetEQ(deviceIP, eqNightMode, value) }); err != nil {
+			return err
+		}
+	}
+	if update.DialogLevel != nil {
+		if err := apply("dialog_level", func() error { return client.SetEQ(deviceIP, eqDialogLevel, *update.DialogLevel) }); err != nil {
+			return err
+		}
+	}
+	if update.Bass != nil {
+		if err := apply("bass", func() error { return client.SetBass(deviceIP, *update.Bass) }); err != nil {
+			return err
+		}
+	}
+	if update.Treble != nil {
+		if err := apply("treble", func() error { return client.SetTreble(deviceIP, *update.Treble) }); err != nil {
+			return err
+		}
+	}
+	if update.Loudness != nil {
+		if err := apply("loudness", func() error { return client.SetLoudness(deviceIP, *update.Loudness) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatAudioSettings(udn string, settings AudioSettings) map[string]any {
+	return map[string]any{
+		"object":       "audio_settings",
+		"udn":          udn,
+		"night_mode":   settings.NightMode,
+		"dialog_level": settings.DialogLevel,
+		"bass":         settings.Bass,
+		"treble":       settings.Treble,
+		"loudness":     settings.Loudness,
+	}
+}
+
+// getAudioSettingsHandler handles GET /v1/sonos/players/{udn}/audio-settings.
+func getAudioSettingsHandler(service *Service) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		udn := chi.URLParam(r, "udn")
+		if udn == "" {
+			return apperrors.NewValidationError("udn is required", nil)
+		}
+
+		deviceIP, err := service.ResolveDeviceIP(udn)
+		if err != nil {
+			return deviceFailure(err, udn, "", "Failed to resolve device")
+		}
+
+		settings, err := GetAudioSettings(service, deviceIP)
+		if err != nil {
+			return apperrors.NewInternalError("Failed to fetch audio settings")
+		}
+
+		return api.WriteResource(w, http.StatusOK, formatAudioSettings(udn, settings))
+	}
+}
+
+// setAudioSettingsHandler handles POST /v1/sonos/players/{udn}/audio-settings. EQ is
+// per speaker, so grouped speakers are not redirected to their coordinator.
+func setAudioSettingsHandler(service *Service) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		udn := chi.URLParam(r, "udn")
+		if udn == "" {
+			return apperrors.NewValidationError("udn is required", nil)
+		}
+		var body AudioSettings
+		if err := decodeJSON(r, &body); err != nil || body.IsEmpty() {
+			return apperrors.NewValidationError("at least one of night_mode, dialog_level, bass, treble and loudness is required", nil)
+		}
+		if err := body.Validate(); err != nil {
+			return apperrors.NewValidationError(err.Error(), nil)
+		}
+
+		deviceIP, err := service.ResolveDeviceIP(udn)
+		if err != nil {
+			return deviceFailure(err, udn, "", "Failed to resolve device")
+		}
+
+		if err := ApplyAudioSettings(service, deviceIP, body); err != nil {
+			var unsupported *UnsupportedAudioSettingError
+			if errors.As(err, &unsupported) {
+				return apperrors.NewAppError(apperrors.ErrorCodeSonosRejected, "Speaker does not support "+unsupported.Setting,
+					http.StatusBadRequest, map[string]any{"setting": unsupported.Setting}, nil)
+			}
+			return apperrors.NewInternalError("Failed to apply audio settings")
+		}
+
+		settings, err := GetAudioSettings(service, deviceIP)
+		if err != nil {
+			return apperrors.NewInternalError("Failed to fetch audio settings")
+		}
+
+		response := formatAudioSettings(udn, settings)
+		addDebugTargets(r, response, deviceIP, []string{deviceIP})
+
+		return api.WriteAction(w, http.StatusOK, response)
+	}
+}
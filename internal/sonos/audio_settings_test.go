@@ -0,0 +1,88 @@
+package sonos
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// fakeAudioSettingsClient is a speaker's EQ; a nil eq map is a speaker without home
+// theater settings.
+type fakeAudioSettingsClient struct {
+	eq       map[string]int
+	bass     int
+	treble   int
+	loudness bool
+}
+
+func (f *fakeAudioSettingsClient) GetEQ(deviceIP, eqType string) (int, error) {
+	value, ok := f.eq[eqType]
+	if !ok {
+		return 0, &soap.SonosRejectedError{Action: "GetEQ", Code: "402"}
+	}
+	return value, nil
+}
+
+func (f *fakeAudioSettingsClient) SetEQ(deviceIP, eqType string, value int) error {
+	if _, ok := f.eq[eqType]; !ok {
+		return &soap.SonosRejectedError{Action: "SetEQ", Code: "402"}
+	}
+	f.eq[eqType] = value
+	return nil
+}
+
+func (f *fakeAudioSettingsClient) GetBass(deviceIP string) (int, error) { return f.bass, nil }
+func (f *fakeAudioSettingsClient) SetBass(deviceIP string, level int) error {
+	f.bass = level
+	return nil
+}
+func (f *fakeAudioSettingsClient) GetTreble(deviceIP string) (int, error) { return f.treble, nil }
+func (f *fakeAudioSettingsClient) SetTreble(deviceIP string, level int) error {
+	f.treble = level
+	return nil
+}
+func (f *fakeAudioSettingsClient) GetLoudness(deviceIP string) (bool, error) { return f.loudness, nil }
+func (f *fakeAudioSettingsClient) SetLoudness(deviceIP string, enabled bool) error {
+	f.loudness = enabled
+	return nil
+}
+
+func TestGetAudioSettings(t *testing.T) {
+	arc := &fakeAudioSettingsClient{eq: map[string]int{"NightMode": 1, "DialogLevel": 0}, bass: 2, treble: -1, loudness: true}
+	settings, err := GetAudioSettings(arc, "10.0.0.1")
+	require.NoError(t, err)
+	require.True(t, *settings.NightMode)
+	require.Equal(t, 0, *settings.DialogLevel)
+	require.Equal(t, 2, *settings.Bass)
+	require.Equal(t, -1, *settings.Treble)
+	require.True(t, *settings.Loudness)
+
+	// Speakers without home theater EQ report those settings as null
+	one := &fakeAudioSettingsClient{}
+	settings, err = GetAudioSettings(one, "10.0.0.2")
+	require.NoError(t, err)
+	require.Nil(t, settings.NightMode)
+	require.Nil(t, settings.DialogLevel)
+	require.Equal(t, 0, *settings.Bass)
+}
+
+func TestApplyAudioSettings(t *testing.T) {
+	on := true
+	bass := 4
+	arc := &fakeAudioSettingsClient{eq: map[string]int{"NightMode": 0, "DialogLevel": 0}}
+	require.NoError(t, ApplyAudioSettings(arc, "10.0.0.1", AudioSettings{NightMode: &on, Bass: &bass}))
+	require.Equal(t, 1, arc.eq["NightMode"])
+	require.Equal(t, 4, arc.bass)
+
+	one := &fakeAudioSettingsClient{}
+	err := ApplyAudioSettings(one, "10.0.0.2", AudioSettings{NightMode: &on})
+	var unsupported *UnsupportedAudioSettingError
+	require.True(t, errors.As(err, &unsupported))
+	require.Equal(t, "night_mode", unsupported.Setting)
+
+	tooLoud := 11
+	require.Error(t, ApplyAudioSettings(arc, "10.0.0.1", AudioSettings{Treble: &tooLoud}))
+}
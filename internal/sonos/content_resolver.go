@@ -3,23 +3,26 @@ package sonos
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"path"
 	"regexp"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/strefethen/sonos-hub-go/internal/logging"
 	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
 )
 
 // MusicContent represents content to be played
 type MusicContent struct {
-	Type        string  `json:"type"`                     // "sonos_favorite" or "direct"
+	Type        string  `json:"type"`                     // "sonos_favorite", "direct" or "local_file"
 	FavoriteID  *string `json:"favorite_id,omitempty"`    // e.g., "FV:2/34"
 	Service     *string `json:"service,omitempty"`        // spotify, apple_music
 	ContentType *string `json:"content_type,omitempty"`   // playlist, album, track, station
 	ContentID   *string `json:"content_id,omitempty"`     // service-specific content ID
 	Title       *string `json:"title,omitempty"`          // optional title for display
+	Filename    *string `json:"filename,omitempty"`       // local_file: a file in the hub's audio directory
 }
 
 // PlayableContent is the resolved content ready for playback
@@ -52,6 +55,7 @@ type ServiceStatus struct {
 	Status                string   `json:"status"` // "ready", "needs_bootstrap", "not_supported"
 	Ready                 bool     `json:"ready"`
 	HasCredential         bool     `json:"has_credential"`
+	AccountSerial         string   `json:"account_serial,omitempty"` // The linked account's sn, from its favorites
 	SupportedContentTypes []string `json:"supported_content_types,omitempty"`
 	LogoURL               string   `json:"logo_url,omitempty"`
 	Error                 string   `json:"error,omitempty"`
@@ -74,6 +78,8 @@ const (
 	SIDSpotify     = "12"
 	SIDAppleMusic  = "204"
 	SIDAmazonMusic = "201"
+	SIDPandora     = "236"
+	SIDIHeartRadio = "6"
 )
 
 // Service name constants
@@ -81,6 +87,9 @@ const (
 	ServiceSpotify     = "spotify"
 	ServiceAppleMusic  = "apple_music"
 	ServiceAmazonMusic = "amazon_music"
+	ServicePandora     = "pandora"
+	ServiceIHeartRadio = "iheartradio"
+	ServiceLocalFile   = "local_file" // Audio files served by the hub
 )
 
 // Status values
@@ -101,6 +110,8 @@ var serviceDisplayNames = map[string]string{
 	ServiceSpotify:     "Spotify",
 	ServiceAppleMusic:  "Apple Music",
 	ServiceAmazonMusic: "Amazon Music",
+	ServicePandora:     "Pandora",
+	ServiceIHeartRadio: "iHeartRadio",
 }
 
 var serviceSupportedContentTypes = map[string][]string{
@@ -127,14 +138,14 @@ type cachedCredentials struct {
 type CredentialExtractor struct {
 	soapClient *soap.Client
 	timeout    time.Duration
-	logger     *log.Logger
+	logger     *slog.Logger
 	cache      map[string]*cachedCredentials
 	cacheMu    sync.RWMutex
 	cacheTTL   time.Duration
 }
 
 // NewCredentialExtractor creates a new CredentialExtractor
-func NewCredentialExtractor(soapClient *soap.Client, timeout time.Duration, logger *log.Logger) *CredentialExtractor {
+func NewCredentialExtractor(soapClient *soap.Client, timeout time.Duration, logger *slog.Logger) *CredentialExtractor {
 	return &CredentialExtractor{
 		soapClient: soapClient,
 		timeout:    timeout,
@@ -190,7 +201,7 @@ func (e *CredentialExtractor) ExtractFromFavorites(ctx context.Context, deviceIP
 				credentials[creds.Service] = creds
 				e.cacheCredentials(creds.Service, creds)
 				if e.logger != nil {
-					e.logger.Printf("Extracted credentials for %s: SID=%s, SN=%s", creds.Service, creds.SID, creds.SN)
+					logging.From(ctx, e.logger).Info("Extracted credentials", "service", creds.Service, "sid", creds.SID, "sn", creds.SN)
 				}
 			}
 		}
@@ -383,11 +394,11 @@ func (e *CredentialExtractor) ClearCache() {
 
 // URIBuilder builds Sonos-compatible URIs for different services
 type URIBuilder struct {
-	logger *log.Logger
+	logger *slog.Logger
 }
 
 // NewURIBuilder creates a new URIBuilder
-func NewURIBuilder(logger *log.Logger) *URIBuilder {
+func NewURIBuilder(logger *slog.Logger) *URIBuilder {
 	return &URIBuilder{logger: logger}
 }
 
@@ -465,15 +476,11 @@ func (b *URIBuilder) buildAppleMusicURI(contentType, contentID string, creds *Se
 	}
 
 	switch contentType {
-	case "playlist":
-		// Format: x-rincon-cpcontainer:1006206cplaylist%3A{id}?sid=204&flags=8300&sn={sn}
-		return fmt.Sprintf("x-rincon-cpcontainer:1006206cplaylist%%3A%s?sid=%s&flags=8300&sn=%s",
-			contentID, creds.SID, sn), nil
-	case "album":
-		// Format: x-rincon-cpcontainer:1004206clibraryalbum%3Al.{id}?sid=204&flags=8300&sn={sn}
-		// Note: Apple Music albums use "libraryalbum:l." prefix
-		return fmt.Sprintf("x-rincon-cpcontainer:1004206clibraryalbum%%3Al.%s?sid=%s&flags=8300&sn=%s",
-			contentID, creds.SID, sn), nil
+	case "playlist", "album":
+		// Format: x-rincon-cpcontainer:{item id}?sid=204&flags=8300&sn={sn}
+		// The account serial (sn) selects which linked Apple Music account plays it
+		return fmt.Sprintf("x-rincon-cpcontainer:%s?sid=%s&flags=8300&sn=%s",
+			appleMusicContainerItemID(contentType, contentID), creds.SID, sn), nil
 	case "track":
 		// Format: x-sonos-http:10032028song%3A{id}.mp4?sid=204&flags=8232&sn={sn}
 		return fmt.Sprintf("x-sonos-http:10032028song%%3A%s.mp4?sid=%s&flags=8232&sn=%s",
@@ -526,10 +533,10 @@ func (b *URIBuilder) buildAppleMusicMetadata(contentType, contentID, title strin
 	switch contentType {
 	case "playlist":
 		upnpClass = "object.container.playlistContainer"
-		itemID = "1006206cplaylist%3A" + contentID
+		itemID = appleMusicContainerItemID(contentType, contentID)
 	case "album":
 		upnpClass = "object.container.album.musicAlbum"
-		itemID = "1004206clibraryalbum%3Al." + contentID
+		itemID = appleMusicContainerItemID(contentType, contentID)
 	case "track":
 		upnpClass = "object.item.audioItem.musicTrack"
 		itemID = "10032028song%3A" + contentID + ".mp4"
@@ -543,6 +550,69 @@ func (b *URIBuilder) buildAppleMusicMetadata(contentType, contentID, title strin
 	return buildDidlMetadataWithToken(itemID, title, upnpClass, creds), nil
 }
 
+// appleMusicContainerItemID builds the Sonos item ID for an Apple Music album or playlist.
+// Catalog and library content are addressed differently, told apart by the ID's form:
+//
+//	album:{id}              catalog album, a numeric ID as search returns (1440857781)
+//	libraryalbum:l.{id}     library album (l.xxx); other IDs are taken as library IDs
+//	                        without their "l." prefix, as callers have always passed them
+//	playlist:{id}           catalog playlist (pl.xxx)
+//	libraryplaylist:{id}    library playlist (p.xxx)
+func appleMusicContainerItemID(contentType, contentID string) string {
+	if contentType == "playlist" {
+		if strings.HasPrefix(contentID, "p.") {
+			return "1006206clibraryplaylist%3A" + contentID
+		}
+		return "1006206cplaylist%3A" + contentID
+	}
+
+	switch {
+	case isNumericID(contentID):
+		return "1004206calbum%3A" + contentID
+	case strings.HasPrefix(contentID, "l."):
+		return "1004206clibraryalbum%3A" + contentID
+	default:
+		return "1004206clibraryalbum%3Al." + contentID
+	}
+}
+
+// isNumericID reports whether id is all digits, as Apple Music catalog IDs are.
+func isNumericID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, c := range id {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeContentType maps the plural content types music search returns ("albums",
+// "playlists", "songs") to the singular ones URIs are built for.
+func normalizeContentType(contentType string) string {
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	switch contentType {
+	case "albums":
+		return "album"
+	case "playlists":
+		return "playlist"
+	case "songs", "song", "tracks":
+		return "track"
+	case "stations":
+		return "station"
+	case "podcasts":
+		return "podcast"
+	case "episodes":
+		return "episode"
+	case "artists":
+		return "artist"
+	default:
+		return contentType
+	}
+}
+
 func buildDidlMetadata(itemID, title, upnpClass, accountID string) string {
 	if title == "" {
 		title = "Unknown"
@@ -574,7 +644,7 @@ func buildDidlMetadataWithToken(itemID, title, upnpClass string, creds *ServiceC
 	}
 
 	return fmt.Sprintf(`<DIDL-Lite xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/" xmlns:r="urn:schemas-rinconnetworks-com:metadata-1-0/" xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/"><item id="%s" parentID="-1" restricted="true"><dc:title>%s</dc:title><upnp:class>%s</upnp:class><desc id="cdudn" nameSpace="urn:schemas-rinconnetworks-com:metadata-1-0/">%s</desc></item></DIDL-Lite>`,
-		itemID, title, upnpClass, descValue)
+		itemID, escapeXMLContent(title), upnpClass, descValue)
 }
 
 // DeviceResolver interface for device IP resolution
@@ -582,18 +652,36 @@ type DeviceResolver interface {
 	ResolveDeviceIP(deviceID string) (string, error)
 }
 
+// FavoritesProvider supplies the household's favorites, typically from a shared cache.
+// This is implemented by Service so favorite playback reuses the favorites list browse.
+type FavoritesProvider interface {
+	// BrowseAllFavorites returns every favorite, possibly from cache.
+	BrowseAllFavorites() ([]soap.FavoriteItem, error)
+	// RefreshFavorites discards any cached favorites and browses them again.
+	RefreshFavorites() ([]soap.FavoriteItem, error)
+}
+
+// LocalFileProvider resolves audio files in the hub's audio directory to the URLs speakers
+// fetch them from. This is implemented by audiofiles.Library.
+type LocalFileProvider interface {
+	// FileURL returns the file's URL, or an error if it isn't a playable file.
+	FileURL(filename string) (string, error)
+}
+
 // ContentResolver is the main orchestrator for resolving music content to playable URIs
 type ContentResolver struct {
 	soapClient          *soap.Client
 	credentialExtractor *CredentialExtractor
 	uriBuilder          *URIBuilder
 	deviceService       DeviceResolver
+	favorites           FavoritesProvider
+	localFiles          LocalFileProvider
 	timeout             time.Duration
-	logger              *log.Logger
+	logger              *slog.Logger
 }
 
 // NewContentResolver creates a new ContentResolver
-func NewContentResolver(soapClient *soap.Client, deviceResolver DeviceResolver, timeout time.Duration, logger *log.Logger) *ContentResolver {
+func NewContentResolver(soapClient *soap.Client, deviceResolver DeviceResolver, timeout time.Duration, logger *slog.Logger) *ContentResolver {
 	return &ContentResolver{
 		soapClient:          soapClient,
 		credentialExtractor: NewCredentialExtractor(soapClient, timeout, logger),
@@ -631,27 +719,94 @@ func (r *ContentResolver) ResolveContent(ctx context.Context, content MusicConte
 		}
 		return r.ResolveDirectContent(ctx, *content.Service, *content.ContentType, *content.ContentID, title, deviceIP)
 
+	case "local_file":
+		if content.Filename == nil || *content.Filename == "" {
+			return nil, fmt.Errorf("filename is required for local_file type")
+		}
+		title := ""
+		if content.Title != nil {
+			title = *content.Title
+		}
+		return r.ResolveLocalFile(*content.Filename, title)
+
 	default:
 		return nil, fmt.Errorf("unknown content type: %s", content.Type)
 	}
 }
 
-// ResolveFavorite fetches a Sonos favorite and returns playable content
-func (r *ContentResolver) ResolveFavorite(ctx context.Context, favoriteID, deviceIP string) (*PlayableContent, error) {
-	// Browse favorites
+// SetFavoritesProvider makes favorite lookups use the given provider instead of browsing the device.
+func (r *ContentResolver) SetFavoritesProvider(provider FavoritesProvider) {
+	r.favorites = provider
+}
+
+// SetLocalFileProvider enables local_file content, played from the hub's audio directory.
+func (r *ContentResolver) SetLocalFileProvider(provider LocalFileProvider) {
+	r.localFiles = provider
+}
+
+// ResolveLocalFile builds playable content for a file in the hub's audio directory. The
+// title defaults to the file name without its extension.
+func (r *ContentResolver) ResolveLocalFile(filename, title string) (*PlayableContent, error) {
+	if r.localFiles == nil {
+		return nil, &ContentUnavailableError{Reason: "audio files are not available on this hub"}
+	}
+	uri, err := r.localFiles.FileURL(filename)
+	if err != nil {
+		return nil, &ContentUnavailableError{Reason: err.Error()}
+	}
+
+	if title == "" {
+		title = strings.TrimSuffix(filename, path.Ext(filename))
+	}
+	return &PlayableContent{
+		URI:         uri,
+		Metadata:    buildDidlMetadata(escapeXMLContent(uri), escapeXMLContent(title), "object.item.audioItem.musicTrack", "RINCON_AssociatedZPUDN"),
+		Title:       title,
+		ContentType: "track",
+		Service:     ServiceLocalFile,
+	}, nil
+}
+
+// findFavorite looks up a favorite by ID. With a favorites provider the cached list is
+// searched first and refreshed once on a miss, in case the favorite was added since.
+// Without one, or if the provider fails, the device is browsed directly.
+func (r *ContentResolver) findFavorite(ctx context.Context, favoriteID, deviceIP string) (*soap.FavoriteItem, error) {
+	if r.favorites != nil {
+		items, err := r.favorites.BrowseAllFavorites()
+		if err == nil {
+			if favorite := findFavoriteByID(items, favoriteID); favorite != nil {
+				return favorite, nil
+			}
+			items, err = r.favorites.RefreshFavorites()
+		}
+		if err == nil {
+			return findFavoriteByID(items, favoriteID), nil
+		}
+	}
+
 	browseResult, err := r.soapClient.Browse(ctx, deviceIP, "FV:2", "BrowseDirectChildren", "*", 0, 100)
 	if err != nil {
 		return nil, fmt.Errorf("failed to browse favorites: %w", err)
 	}
+	return findFavoriteByID(browseResult.Items, favoriteID), nil
+}
 
-	// Find the specific favorite
-	var favorite *soap.FavoriteItem
-	for i := range browseResult.Items {
-		if browseResult.Items[i].ID == favoriteID {
-			favorite = &browseResult.Items[i]
-			break
+// findFavoriteByID returns the favorite with the given ID, or nil.
+func findFavoriteByID(items []soap.FavoriteItem, favoriteID string) *soap.FavoriteItem {
+	for i := range items {
+		if items[i].ID == favoriteID {
+			return &items[i]
 		}
 	}
+	return nil
+}
+
+// ResolveFavorite fetches a Sonos favorite and returns playable content
+func (r *ContentResolver) ResolveFavorite(ctx context.Context, favoriteID, deviceIP string) (*PlayableContent, error) {
+	favorite, err := r.findFavorite(ctx, favoriteID, deviceIP)
+	if err != nil {
+		return nil, err
+	}
 
 	if favorite == nil {
 		return nil, &FavoriteNotFoundError{FavoriteID: favoriteID}
@@ -663,12 +818,19 @@ func (r *ContentResolver) ResolveFavorite(ctx context.Context, favoriteID, devic
 	// Determine service from resource URI
 	service := detectServiceName(favorite.Resource, favorite.ResourceMetaData)
 
+	// Radio stations are played with a rebuilt metadata wrapper rather than the favorite's own
+	metadata := favorite.ResourceMetaData
+	if radio := radioServiceForResource(favorite.Resource); radio != nil {
+		contentType = "station"
+		metadata = radioFavoriteMetadata(radio, *favorite)
+	}
+
 	// Determine if queue-based playback is needed
 	usesQueue := strings.HasPrefix(strings.ToLower(favorite.Resource), "x-rincon-cpcontainer")
 
 	return &PlayableContent{
 		URI:         favorite.Resource,
-		Metadata:    favorite.ResourceMetaData,
+		Metadata:    metadata,
 		Title:       favorite.Title,
 		ContentType: contentType,
 		Service:     service,
@@ -676,12 +838,14 @@ func (r *ContentResolver) ResolveFavorite(ctx context.Context, favoriteID, devic
 	}, nil
 }
 
-// ResolveDirectContent builds playable content for a direct service request
+// ResolveDirectContent builds playable content for a direct service request.
+// contentType may be singular ("album") or plural as search returns it ("albums").
 func (r *ContentResolver) ResolveDirectContent(ctx context.Context, service, contentType, contentID, title, deviceIP string) (*PlayableContent, error) {
 	// Validate service is supported
 	if !r.isServiceSupported(service) {
 		return nil, &ServiceNotSupportedError{Service: service}
 	}
+	contentType = normalizeContentType(contentType)
 
 	// Get credentials from favorites
 	creds, err := r.credentialExtractor.GetCredentials(ctx, service, deviceIP)
@@ -689,6 +853,13 @@ func (r *ContentResolver) ResolveDirectContent(ctx context.Context, service, con
 		return nil, err
 	}
 
+	// Apple Music queues albums and playlists from the account the serial names; without
+	// one the speaker rejects them, so the account has to be linked on this household
+	// (shown by a favorite from it) first
+	if service == ServiceAppleMusic && (contentType == "album" || contentType == "playlist") && creds.SN == "" {
+		return nil, &ServiceNeedsBootstrapError{Service: service}
+	}
+
 	// Build URI
 	uri, err := r.uriBuilder.BuildURI(service, contentType, contentID, creds)
 	if err != nil {
@@ -729,7 +900,7 @@ func (r *ContentResolver) UsesQueuePlayback(content MusicContent) bool {
 		return false
 	}
 	if content.ContentType != nil {
-		ct := *content.ContentType
+		ct := normalizeContentType(*content.ContentType)
 		// Playlists, albums, and podcasts use queue; tracks and stations use direct
 		return ct == "playlist" || ct == "album" || ct == "podcast"
 	}
@@ -824,10 +995,29 @@ func (r *ContentResolver) ValidateContent(ctx context.Context, content MusicCont
 		result.ContentType = *content.ContentType
 		result.CanBeQueued = r.UsesQueuePlayback(content)
 
+	case "local_file":
+		result.Service = ServiceLocalFile
+		if content.Filename == nil || *content.Filename == "" {
+			result.Valid = false
+			result.Error = "filename is required"
+			return result, nil
+		}
+		playable, err := r.ResolveLocalFile(*content.Filename, "")
+		if err != nil {
+			result.Valid = false
+			result.Error = err.Error()
+			result.Remediation = "List playable files with GET /v1/audio-files"
+			return result, nil
+		}
+
+		result.Valid = true
+		result.ContentType = playable.ContentType
+		result.ServiceReady = true
+
 	default:
 		result.Valid = false
 		result.Error = fmt.Sprintf("unknown content type: %s", content.Type)
-		result.Remediation = "Valid types are: sonos_favorite, direct"
+		result.Remediation = "Valid types are: sonos_favorite, direct, local_file"
 	}
 
 	return result, nil
@@ -861,6 +1051,9 @@ func (r *ContentResolver) GetServiceCapabilities(deviceIP string) []ServiceStatu
 			} else {
 				status.Ready = true
 				status.HasCredential = creds != nil
+				if creds != nil {
+					status.AccountSerial = creds.SN
+				}
 			}
 		} else {
 			status.Ready = false
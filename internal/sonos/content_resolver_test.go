@@ -2,8 +2,8 @@ package sonos
 
 import (
 	"context"
-	"log"
-	"os"
+	"fmt"
+	"log/slog"
 	"testing"
 	"time"
 
@@ -28,12 +28,12 @@ func (m *mockDeviceResolver) ResolveDeviceIP(deviceID string) (string, error) {
 type testHelper struct {
 	soapClient *soap.Client
 	resolver   *ContentResolver
-	logger     *log.Logger
+	logger     *slog.Logger
 }
 
 func setupTest(t *testing.T) *testHelper {
 	t.Helper()
-	logger := log.New(os.Stderr, "[test] ", log.LstdFlags)
+	logger := slog.Default()
 	soapClient := soap.NewClient(5 * time.Second)
 	deviceResolver := &mockDeviceResolver{ip: "192.168.1.100"}
 	resolver := NewContentResolver(soapClient, deviceResolver, 5*time.Second, logger)
@@ -46,7 +46,7 @@ func setupTest(t *testing.T) *testHelper {
 }
 
 func TestNewContentResolver(t *testing.T) {
-	logger := log.New(os.Stderr, "[test] ", log.LstdFlags)
+	logger := slog.Default()
 	soapClient := soap.NewClient(5 * time.Second)
 	deviceResolver := &mockDeviceResolver{ip: "192.168.1.100"}
 
@@ -156,7 +156,7 @@ func TestUsesQueuePlayback(t *testing.T) {
 }
 
 func TestURIBuilder_BuildSpotifyURI(t *testing.T) {
-	logger := log.New(os.Stderr, "[test] ", log.LstdFlags)
+	logger := slog.Default()
 	builder := NewURIBuilder(logger)
 
 	creds := &ServiceCredentials{
@@ -230,7 +230,7 @@ func TestURIBuilder_BuildSpotifyURI(t *testing.T) {
 }
 
 func TestURIBuilder_BuildAppleMusicURI(t *testing.T) {
-	logger := log.New(os.Stderr, "[test] ", log.LstdFlags)
+	logger := slog.Default()
 	builder := NewURIBuilder(logger)
 
 	creds := &ServiceCredentials{
@@ -254,12 +254,31 @@ func TestURIBuilder_BuildAppleMusicURI(t *testing.T) {
 			expectURI: "x-rincon-cpcontainer:1006206cplaylist%3Apl.u-aZb0kMBIqqJv0L?sid=204&flags=8300&sn=1",
 		},
 		{
-			name:        "apple music album",
+			name:        "apple music library playlist",
+			contentType: "playlist",
+			contentID:   "p.ldvAMJXhV2QgYR",
+			expectURI:   "x-rincon-cpcontainer:1006206clibraryplaylist%3Ap.ldvAMJXhV2QgYR?sid=204&flags=8300&sn=1",
+		},
+		{
+			name:        "apple music catalog album",
 			contentType: "album",
 			contentID:   "1234567890",
+			// Format: x-rincon-cpcontainer:1004206calbum%3A{id}?sid=204&flags=8300&sn={sn}
+			// Note: search returns numeric catalog IDs, which use the "album:" prefix
+			expectURI: "x-rincon-cpcontainer:1004206calbum%3A1234567890?sid=204&flags=8300&sn=1",
+		},
+		{
+			name:        "apple music library album",
+			contentType: "album",
+			contentID:   "l.JsDtFFK",
 			// Format: x-rincon-cpcontainer:1004206clibraryalbum%3Al.{id}?sid=204&flags=8300&sn={sn}
-			// Note: Apple Music albums use "libraryalbum:l." prefix
-			expectURI: "x-rincon-cpcontainer:1004206clibraryalbum%3Al.1234567890?sid=204&flags=8300&sn=1",
+			expectURI: "x-rincon-cpcontainer:1004206clibraryalbum%3Al.JsDtFFK?sid=204&flags=8300&sn=1",
+		},
+		{
+			name:        "apple music library album without l. prefix",
+			contentType: "album",
+			contentID:   "JsDtFFK",
+			expectURI:   "x-rincon-cpcontainer:1004206clibraryalbum%3Al.JsDtFFK?sid=204&flags=8300&sn=1",
 		},
 		{
 			name:        "apple music track",
@@ -290,8 +309,104 @@ func TestURIBuilder_BuildAppleMusicURI(t *testing.T) {
 	}
 }
 
+func TestResolveDirectContent_AppleMusicContainers(t *testing.T) {
+	resolver := NewContentResolver(nil, &mockDeviceResolver{ip: "192.168.1.100"}, time.Second, slog.Default())
+	resolver.credentialExtractor.cacheCredentials(ServiceAppleMusic, &ServiceCredentials{
+		Service: ServiceAppleMusic,
+		SID:     "204",
+		SN:      "7",
+		Token:   "52231",
+	})
+
+	tests := []struct {
+		name         string
+		contentType  string
+		contentID    string
+		expectURI    string
+		expectItemID string
+		expectClass  string
+	}{
+		{
+			name:         "catalog album from search",
+			contentType:  "albums",
+			contentID:    "1440857781",
+			expectURI:    "x-rincon-cpcontainer:1004206calbum%3A1440857781?sid=204&flags=8300&sn=7",
+			expectItemID: "1004206calbum%3A1440857781",
+			expectClass:  "object.container.album.musicAlbum",
+		},
+		{
+			name:         "library album",
+			contentType:  "album",
+			contentID:    "l.JsDtFFK",
+			expectURI:    "x-rincon-cpcontainer:1004206clibraryalbum%3Al.JsDtFFK?sid=204&flags=8300&sn=7",
+			expectItemID: "1004206clibraryalbum%3Al.JsDtFFK",
+			expectClass:  "object.container.album.musicAlbum",
+		},
+		{
+			name:         "catalog playlist from search",
+			contentType:  "playlists",
+			contentID:    "pl.f4d106fed2bd41149aaacabb233eb5eb",
+			expectURI:    "x-rincon-cpcontainer:1006206cplaylist%3Apl.f4d106fed2bd41149aaacabb233eb5eb?sid=204&flags=8300&sn=7",
+			expectItemID: "1006206cplaylist%3Apl.f4d106fed2bd41149aaacabb233eb5eb",
+			expectClass:  "object.container.playlistContainer",
+		},
+		{
+			name:         "library playlist",
+			contentType:  "Playlist",
+			contentID:    "p.ldvAMJXhV2QgYR",
+			expectURI:    "x-rincon-cpcontainer:1006206clibraryplaylist%3Ap.ldvAMJXhV2QgYR?sid=204&flags=8300&sn=7",
+			expectItemID: "1006206clibraryplaylist%3Ap.ldvAMJXhV2QgYR",
+			expectClass:  "object.container.playlistContainer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			playable, err := resolver.ResolveDirectContent(context.Background(), ServiceAppleMusic, tt.contentType, tt.contentID, "Kind of Blue & More", "192.168.1.100")
+			require.NoError(t, err)
+			require.Equal(t, tt.expectURI, playable.URI)
+			require.True(t, playable.UsesQueue)
+			require.Contains(t, playable.Metadata, `<item id="`+tt.expectItemID+`" parentID="-1"`)
+			require.Contains(t, playable.Metadata, "<upnp:class>"+tt.expectClass+"</upnp:class>")
+			require.Contains(t, playable.Metadata, "SA_RINCON52231_X_#Svc52231-0-Token")
+			require.Contains(t, playable.Metadata, "<dc:title>Kind of Blue &amp; More</dc:title>")
+		})
+	}
+
+	t.Run("account not linked", func(t *testing.T) {
+		// A favorite without an account serial can't say which account plays the album
+		resolver.credentialExtractor.cacheCredentials(ServiceAppleMusic, &ServiceCredentials{Service: ServiceAppleMusic, SID: "204", Token: "52231"})
+		_, err := resolver.ResolveDirectContent(context.Background(), ServiceAppleMusic, "albums", "1440857781", "", "192.168.1.100")
+		var bootstrapErr *ServiceNeedsBootstrapError
+		require.ErrorAs(t, err, &bootstrapErr)
+		require.Equal(t, ServiceAppleMusic, bootstrapErr.Service)
+
+		// Single songs play without it
+		playable, err := resolver.ResolveDirectContent(context.Background(), ServiceAppleMusic, "songs", "1440857786", "", "192.168.1.100")
+		require.NoError(t, err)
+		require.False(t, playable.UsesQueue)
+	})
+}
+
+func TestNormalizeContentType(t *testing.T) {
+	tests := map[string]string{
+		"albums":    "album",
+		"playlists": "playlist",
+		"songs":     "track",
+		"tracks":    "track",
+		"stations":  "station",
+		"podcasts":  "podcast",
+		" Album ":   "album",
+		"station":   "station",
+		"radio":     "radio",
+	}
+	for input, want := range tests {
+		require.Equal(t, want, normalizeContentType(input), input)
+	}
+}
+
 func TestURIBuilder_UnsupportedService(t *testing.T) {
-	logger := log.New(os.Stderr, "[test] ", log.LstdFlags)
+	logger := slog.Default()
 	builder := NewURIBuilder(logger)
 
 	creds := &ServiceCredentials{
@@ -307,7 +422,7 @@ func TestURIBuilder_UnsupportedService(t *testing.T) {
 }
 
 func TestURIBuilder_BuildMetadata(t *testing.T) {
-	logger := log.New(os.Stderr, "[test] ", log.LstdFlags)
+	logger := slog.Default()
 	builder := NewURIBuilder(logger)
 
 	creds := &ServiceCredentials{
@@ -325,7 +440,7 @@ func TestURIBuilder_BuildMetadata(t *testing.T) {
 }
 
 func TestCredentialExtractor_ExtractFromItem(t *testing.T) {
-	logger := log.New(os.Stderr, "[test] ", log.LstdFlags)
+	logger := slog.Default()
 	soapClient := soap.NewClient(5 * time.Second)
 	extractor := NewCredentialExtractor(soapClient, 5*time.Second, logger)
 
@@ -393,7 +508,7 @@ func TestCredentialExtractor_ExtractFromItem(t *testing.T) {
 }
 
 func TestCredentialExtractor_DetectServiceFromItem(t *testing.T) {
-	logger := log.New(os.Stderr, "[test] ", log.LstdFlags)
+	logger := slog.Default()
 	soapClient := soap.NewClient(5 * time.Second)
 	extractor := NewCredentialExtractor(soapClient, 5*time.Second, logger)
 
@@ -462,7 +577,7 @@ func TestCredentialExtractor_DetectServiceFromItem(t *testing.T) {
 }
 
 func TestCredentialExtractor_CacheOperations(t *testing.T) {
-	logger := log.New(os.Stderr, "[test] ", log.LstdFlags)
+	logger := slog.Default()
 	soapClient := soap.NewClient(5 * time.Second)
 	extractor := NewCredentialExtractor(soapClient, 5*time.Second, logger)
 
@@ -493,7 +608,7 @@ func TestCredentialExtractor_CacheOperations(t *testing.T) {
 }
 
 func TestCredentialExtractor_GetServiceStatus(t *testing.T) {
-	logger := log.New(os.Stderr, "[test] ", log.LstdFlags)
+	logger := slog.Default()
 	soapClient := soap.NewClient(5 * time.Second)
 	extractor := NewCredentialExtractor(soapClient, 5*time.Second, logger)
 
@@ -806,7 +921,7 @@ func TestServiceMaps(t *testing.T) {
 }
 
 func TestCredentialExtractor_HasSID(t *testing.T) {
-	logger := log.New(os.Stderr, "[test] ", log.LstdFlags)
+	logger := slog.Default()
 	soapClient := soap.NewClient(5 * time.Second)
 	extractor := NewCredentialExtractor(soapClient, 5*time.Second, logger)
 
@@ -848,3 +963,39 @@ func TestCredentialExtractor_HasSID(t *testing.T) {
 func strPtr(s string) *string {
 	return &s
 }
+
+type fakeLocalFiles map[string]string
+
+func (f fakeLocalFiles) FileURL(filename string) (string, error) {
+	if url, ok := f[filename]; ok {
+		return url, nil
+	}
+	return "", fmt.Errorf("audio file not found: %s", filename)
+}
+
+func TestResolveContent_LocalFile(t *testing.T) {
+	resolver := NewContentResolver(nil, nil, time.Second, nil)
+	filename := "Rise & Shine.mp3"
+	content := MusicContent{Type: "local_file", Filename: &filename}
+
+	_, err := resolver.ResolveContent(context.Background(), content, "192.168.1.10")
+	var unavailable *ContentUnavailableError
+	require.ErrorAs(t, err, &unavailable, "without a provider")
+
+	resolver.SetLocalFileProvider(fakeLocalFiles{filename: "http://192.168.1.5:9000/v1/assets/audio/Rise%20&%20Shine.mp3"})
+	playable, err := resolver.ResolveContent(context.Background(), content, "192.168.1.10")
+	require.NoError(t, err)
+	require.Equal(t, "http://192.168.1.5:9000/v1/assets/audio/Rise%20&%20Shine.mp3", playable.URI)
+	require.Equal(t, "Rise & Shine", playable.Title)
+	require.Equal(t, "track", playable.ContentType)
+	require.Equal(t, ServiceLocalFile, playable.Service)
+	require.False(t, playable.UsesQueue)
+	require.Contains(t, playable.Metadata, `<item id="http://192.168.1.5:9000/v1/assets/audio/Rise%20&amp;%20Shine.mp3"`)
+	require.Contains(t, playable.Metadata, "<dc:title>Rise &amp; Shine</dc:title>")
+	require.Contains(t, playable.Metadata, "<upnp:class>object.item.audioItem.musicTrack</upnp:class>")
+
+	missing := "missing.mp3"
+	_, err = resolver.ResolveContent(context.Background(), MusicContent{Type: "local_file", Filename: &missing}, "192.168.1.10")
+	require.ErrorAs(t, err, &unavailable)
+	require.Contains(t, err.Error(), "audio file not found")
+}
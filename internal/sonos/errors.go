@@ -0,0 +1,140 @@
+package sonos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// DeviceNotFoundError is returned by ResolveDeviceIP when no speaker has the UDN.
+type DeviceNotFoundError struct {
+	UDN string
+}
+
+func (e *DeviceNotFoundError) Error() string {
+	if e.UDN == "" {
+		return "device not found"
+	}
+	return "device not found: " + e.UDN
+}
+
+// DeviceUnreachableError means a speaker didn't answer a SOAP action: the connection
+// failed, its circuit breaker is open, or, when Timeout is set, it answered too slowly.
+type DeviceUnreachableError struct {
+	UDN     string
+	IP      string
+	Action  string
+	Timeout bool
+	Err     error
+}
+
+func (e *DeviceUnreachableError) Error() string {
+	if e.Timeout {
+		return fmt.Sprintf("device %s timed out: %v", e.IP, e.Err)
+	}
+	return fmt.Sprintf("device %s unreachable: %v", e.IP, e.Err)
+}
+
+func (e *DeviceUnreachableError) Unwrap() error {
+	return e.Err
+}
+
+// SOAPFaultError means a speaker rejected a SOAP action with a UPnP fault, e.g. 701
+// when a transition isn't available in the current transport state.
+type SOAPFaultError struct {
+	UDN         string
+	IP          string
+	Action      string
+	FaultCode   string
+	Description string
+}
+
+func (e *SOAPFaultError) Error() string {
+	if e.Description == "" {
+		return fmt.Sprintf("device %s rejected %s: upnp error %s", e.IP, e.Action, e.FaultCode)
+	}
+	return fmt.Sprintf("device %s rejected %s: upnp error %s (%s)", e.IP, e.Action, e.FaultCode, e.Description)
+}
+
+// wrapDeviceError adds the speaker's UDN and IP to err from a call to it, turning SOAP
+// timeouts, connection failures and faults into DeviceUnreachableError and
+// SOAPFaultError. Other errors are returned unchanged.
+func wrapDeviceError(udn, ip string, err error) error {
+	var notFound *DeviceNotFoundError
+	var unreachable *DeviceUnreachableError
+	var fault *SOAPFaultError
+	if err == nil || errors.As(err, &notFound) || errors.As(err, &unreachable) || errors.As(err, &fault) {
+		return err
+	}
+
+	var rejected *soap.SonosRejectedError
+	if errors.As(err, &rejected) {
+		return &SOAPFaultError{UDN: udn, IP: ip, Action: rejected.Action, FaultCode: rejected.Code, Description: rejected.Description}
+	}
+	var timeout *soap.SonosTimeoutError
+	if errors.As(err, &timeout) {
+		return &DeviceUnreachableError{UDN: udn, IP: ip, Action: timeout.Action, Timeout: true, Err: err}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &DeviceUnreachableError{UDN: udn, IP: ip, Timeout: true, Err: err}
+	}
+	var connection *soap.SonosUnreachableError
+	if errors.As(err, &connection) {
+		return &DeviceUnreachableError{UDN: udn, IP: ip, Action: connection.Action, Err: err}
+	}
+	if errors.Is(err, ErrDeviceUnavailable) {
+		return &DeviceUnreachableError{UDN: udn, IP: ip, Err: err}
+	}
+	return err
+}
+
+// deviceFailure turns err from a request about the speaker udn at ip into an API error:
+// 404 for an unknown speaker, 504 when the speaker timed out, 502 when it couldn't be
+// reached or rejected the action, and a 500 with message for anything else, which is
+// the hub's own failure.
+func deviceFailure(err error, udn, ip, message string) error {
+	err = wrapDeviceError(udn, ip, err)
+
+	var notFound *DeviceNotFoundError
+	if errors.As(err, &notFound) {
+		return apperrors.NewAppError(apperrors.ErrorCodeDeviceNotFound, "Speaker not found", http.StatusNotFound,
+			map[string]any{"udn": udn}, nil)
+	}
+
+	var unreachable *DeviceUnreachableError
+	if errors.As(err, &unreachable) {
+		details := deviceErrorDetails(udn, ip, unreachable.Action)
+		if unreachable.Timeout {
+			return apperrors.NewAppError(apperrors.ErrorCodeSonosTimeout, message+": speaker timed out", http.StatusGatewayTimeout, details, nil)
+		}
+		return apperrors.NewAppError(apperrors.ErrorCodeSonosUnreachable, message+": speaker unreachable", http.StatusBadGateway, details, nil)
+	}
+
+	var fault *SOAPFaultError
+	if errors.As(err, &fault) {
+		details := deviceErrorDetails(udn, ip, fault.Action)
+		details["fault_code"] = fault.FaultCode
+		if fault.Description != "" {
+			details["fault_description"] = fault.Description
+		}
+		return apperrors.NewAppError(apperrors.ErrorCodeSonosRejected, message+": speaker rejected "+fault.Action, http.StatusBadGateway, details, nil)
+	}
+
+	return apperrors.NewInternalError(message)
+}
+
+// deviceErrorDetails is the detail for an error about a speaker; empty fields are left out.
+func deviceErrorDetails(udn, ip, action string) map[string]any {
+	details := map[string]any{"udn": udn}
+	if ip != "" {
+		details["ip"] = ip
+	}
+	if action != "" {
+		details["soap_action"] = action
+	}
+	return details
+}
@@ -0,0 +1,110 @@
+package sonos
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+func TestWrapDeviceError(t *testing.T) {
+	var fault *SOAPFaultError
+	require.True(t, errors.As(wrapDeviceError("RINCON_1", "10.0.0.1", &soap.SonosRejectedError{Action: "Play", Code: "701", Description: "Transition not available"}), &fault))
+	require.Equal(t, &SOAPFaultError{UDN: "RINCON_1", IP: "10.0.0.1", Action: "Play", FaultCode: "701", Description: "Transition not available"}, fault)
+
+	var unreachable *DeviceUnreachableError
+	require.True(t, errors.As(wrapDeviceError("RINCON_1", "10.0.0.1", &soap.SonosTimeoutError{Action: "GetVolume"}), &unreachable))
+	require.True(t, unreachable.Timeout)
+	require.Equal(t, "GetVolume", unreachable.Action)
+
+	require.True(t, errors.As(wrapDeviceError("RINCON_1", "10.0.0.1", &soap.SonosUnreachableError{Action: "Stop", Err: errors.New("connection refused")}), &unreachable))
+	require.False(t, unreachable.Timeout)
+
+	require.True(t, errors.As(wrapDeviceError("RINCON_1", "10.0.0.1", ErrDeviceUnavailable), &unreachable))
+	require.ErrorIs(t, unreachable, ErrDeviceUnavailable)
+
+	other := errors.New("parse failure")
+	require.Equal(t, other, wrapDeviceError("RINCON_1", "10.0.0.1", other))
+	require.NoError(t, wrapDeviceError("RINCON_1", "10.0.0.1", nil))
+}
+
+// fakeSpeaker serves SOAP requests the way a misbehaving speaker would.
+func fakeSpeaker(t *testing.T, handler http.HandlerFunc) string {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestDeviceErrorRoutes(t *testing.T) {
+	faulting := fakeSpeaker(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><s:Fault>
+			<faultcode>s:Client</faultcode><faultstring>UPnPError</faultstring><detail>
+			<UPnPError xmlns="urn:schemas-upnp-org:control-1-0"><errorCode>701</errorCode></UPnPError>
+			</detail></s:Fault></s:Body></s:Envelope>`))
+	})
+	slow := fakeSpeaker(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+	closed := httptest.NewServer(http.NotFoundHandler())
+	unreachable := strings.TrimPrefix(closed.URL, "http://")
+	closed.Close()
+
+	tests := []struct {
+		name     string
+		deviceIP string
+		method   string
+		path     string
+		body     string
+		status   int
+		code     string
+		details  map[string]any
+	}{
+		{"unknown speaker", "", http.MethodPost, "/v1/sonos/playback/pause", `{"udn":"RINCON_1"}`,
+			http.StatusNotFound, "DEVICE_NOT_FOUND", map[string]any{"udn": "RINCON_1"}},
+		{"fault", faulting, http.MethodPost, "/v1/sonos/playback/play", `{"udn":"RINCON_1"}`,
+			http.StatusBadGateway, "SONOS_REJECTED", map[string]any{"udn": "RINCON_1", "ip": faulting, "soap_action": "Play", "fault_code": "701"}},
+		{"timeout", slow, http.MethodPost, "/v1/sonos/volume/set", `{"udn":"RINCON_1","level":20}`,
+			http.StatusGatewayTimeout, "SONOS_TIMEOUT", map[string]any{"udn": "RINCON_1", "ip": slow, "soap_action": "GetVolume"}},
+		{"unreachable", unreachable, http.MethodGet, "/v1/sonos/groups?udn=RINCON_1", "",
+			http.StatusBadGateway, "SONOS_UNREACHABLE", map[string]any{"udn": "RINCON_1", "ip": unreachable, "soap_action": "GetZoneGroupState"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &Service{
+				SoapClient:      soap.NewClient(200 * time.Millisecond),
+				DefaultDeviceIP: tt.deviceIP,
+				SoapTimeout:     200 * time.Millisecond,
+				ZoneCache:       NewZoneGroupCache(time.Second),
+			}
+			router := chi.NewRouter()
+			RegisterRoutes(router, service)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", "application/problem+json")
+			router.ServeHTTP(rec, req)
+			require.Equal(t, tt.status, rec.Code, rec.Body.String())
+
+			var problem struct {
+				Code    string         `json:"code"`
+				Details map[string]any `json:"details"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+			require.Equal(t, tt.code, problem.Code)
+			require.Equal(t, tt.details, problem.Details)
+		})
+	}
+}
@@ -3,7 +3,7 @@ package events
 import (
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 )
@@ -110,13 +110,13 @@ func (m *Manager) handleNotify(sid string, seq int, serviceType ServiceType, sou
 	// Find the subscription
 	sub := m.findSubscriptionBySID(sid)
 	if sub == nil {
-		log.Printf("UPNP: Received event for unknown SID: %s", sid)
+		slog.Warn("UPNP: Received event for unknown SID", "sid", sid)
 		return
 	}
 
 	// Check sequence number for missed events
 	if seq > 0 && seq != sub.SEQ+1 && sub.SEQ > 0 {
-		log.Printf("UPNP: Sequence gap detected: expected %d, got %d", sub.SEQ+1, seq)
+		slog.Warn("UPNP: Sequence gap detected", "sid", sid, "expected", sub.SEQ+1, "got", seq)
 	}
 
 	// Update sequence number
@@ -125,12 +125,13 @@ func (m *Manager) handleNotify(sid string, seq int, serviceType ServiceType, sou
 	// Parse the event
 	event, err := ParseNotifyBody(body, serviceType)
 	if err != nil {
-		log.Printf("UPNP: Failed to parse event body: %v", err)
+		slog.Warn("UPNP: Failed to parse event body", "sid", sid, "error", err)
 		return
 	}
 
 	// Update the state cache
 	m.processEvent(event, sourceIP, sub.DeviceUDN)
+	m.markLive(sub.DeviceIP, serviceType)
 
 	m.mu.Lock()
 	m.stats.EventsProcessed++
@@ -157,7 +158,7 @@ func (m *Manager) processEvent(event *NotifyEvent, deviceIP string, deviceUDN st
 			AVTransportURIMetaData: event.Properties["AVTransportURIMetaData"],
 		}
 		m.stateCache.UpdateTransport(deviceIP, avEvent)
-		log.Printf("UPNP: AVTransport state updated for %s: %s", deviceIP, avEvent.TransportState)
+		slog.Debug("UPNP: AVTransport state updated", "ip", deviceIP, "state", avEvent.TransportState)
 
 	case ServiceRenderingControl:
 		volume := 0
@@ -175,13 +176,13 @@ func (m *Manager) processEvent(event *NotifyEvent, deviceIP string, deviceUDN st
 			Muted:  muted,
 		}
 		m.stateCache.UpdateVolume(deviceIP, rcEvent)
-		log.Printf("UPNP: RenderingControl state updated for %s: vol=%d, mute=%v", deviceIP, volume, muted)
+		slog.Debug("UPNP: RenderingControl state updated", "ip", deviceIP, "volume", volume, "muted", muted)
 
 	case ServiceZoneGroupTopology:
 		// Invalidate the zone cache when topology changes
 		if m.zoneCache != nil {
 			m.zoneCache.Invalidate()
-			log.Printf("UPNP: Zone topology changed, cache invalidated")
+			slog.Info("UPNP: Zone topology changed, cache invalidated")
 		}
 	}
 
@@ -191,6 +192,20 @@ func (m *Manager) processEvent(event *NotifyEvent, deviceIP string, deviceUDN st
 	}
 }
 
+// markLive marks a device's cached state live once it is subscribed to every configured
+// service and has reported both transport and volume, so the state is complete and
+// every later change will arrive as an event. The state is keyed by the subscription's
+// device IP, as when removeSubscription clears it.
+func (m *Manager) markLive(deviceIP string, serviceType ServiceType) {
+	if m.stateCache == nil || serviceType == ServiceZoneGroupTopology {
+		return
+	}
+	if !m.IsDeviceFullySubscribed(deviceIP) {
+		return
+	}
+	m.stateCache.SetLive(deviceIP, true)
+}
+
 func parseInt(s string) (int, error) {
 	var n int
 	_, err := fmt.Sscanf(s, "%d", &n)
@@ -3,7 +3,7 @@ package events
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
 	"sync"
 	"time"
@@ -60,7 +60,7 @@ func NewManager(config ManagerConfig, port int, zoneCache *sonos.ZoneGroupCache)
 // It discovers the local IP and starts the renewal loop.
 func (m *Manager) Start() error {
 	if !m.config.Enabled {
-		log.Printf("UPNP: Event subscriptions disabled")
+		slog.Info("UPNP: Event subscriptions disabled")
 		return nil
 	}
 
@@ -78,7 +78,7 @@ func (m *Manager) Start() error {
 	}
 	m.callbackURL = fmt.Sprintf("http://%s:%d/upnp/notify", m.localIP, port)
 
-	log.Printf("UPNP: Event manager started, callback URL: %s", m.callbackURL)
+	slog.Info("UPNP: Event manager started", "callback_url", m.callbackURL)
 
 	// Start renewal goroutine
 	go m.renewalLoop()
@@ -101,7 +101,7 @@ func (m *Manager) Stop(ctx context.Context) error {
 	// Unsubscribe from all devices
 	m.unsubscribeAll(ctx)
 
-	log.Printf("UPNP: Event manager stopped")
+	slog.Info("UPNP: Event manager stopped")
 	return nil
 }
 
@@ -118,6 +118,9 @@ func (m *Manager) SubscribeDevice(ctx context.Context, deviceIP, deviceUDN strin
 		return nil
 	}
 
+	// A device that rebooted onto a new IP has forgotten the old subscriptions
+	m.forgetMovedDevice(deviceIP, deviceUDN)
+
 	// Check backoff before attempting subscription
 	if !m.shouldAttemptSubscription(deviceIP) {
 		return nil
@@ -163,7 +166,7 @@ func (m *Manager) SubscribeDevice(ctx context.Context, deviceIP, deviceUDN strin
 
 		sid, timeout, err := m.subClient.Subscribe(ctx, deviceIP, path, callbackURL, m.config.SubscriptionTimeout)
 		if err != nil {
-			log.Printf("UPNP: Failed to subscribe %s on %s: %v", serviceType, deviceIP, err)
+			slog.Warn("UPNP: Failed to subscribe", "service", serviceType, "ip", deviceIP, "error", err)
 			m.mu.Lock()
 			m.stats.SubscriptionFailures++
 			m.mu.Unlock()
@@ -187,6 +190,7 @@ func (m *Manager) SubscribeDevice(ctx context.Context, deviceIP, deviceUDN strin
 			Timeout:      timeout,
 			SubscribedAt: m.now(),
 			RenewAt:      m.now().Add(time.Duration(renewIn) * time.Second),
+			ExpiresAt:    m.now().Add(time.Duration(timeout) * time.Second),
 		}
 
 		m.addSubscription(sub)
@@ -200,7 +204,7 @@ func (m *Manager) SubscribeDevice(ctx context.Context, deviceIP, deviceUDN strin
 		m.mu.Unlock()
 
 		successCount++
-		log.Printf("UPNP: Subscribed to %s on %s (SID: %s, timeout: %ds)", serviceType, deviceIP, sid, timeout)
+		slog.Info("UPNP: Subscribed", "service", serviceType, "ip", deviceIP, "sid", sid, "timeout_sec", timeout)
 	}
 
 	// Update failure count for backoff
@@ -234,7 +238,7 @@ func (m *Manager) UnsubscribeDevice(ctx context.Context, deviceIP string) {
 		}
 
 		if err := m.subClient.Unsubscribe(ctx, deviceIP, path, sid); err != nil {
-			log.Printf("UPNP: Failed to unsubscribe %s: %v", sid, err)
+			slog.Warn("UPNP: Failed to unsubscribe", "sid", sid, "error", err)
 		}
 
 		m.removeSubscription(sid)
@@ -304,6 +308,11 @@ func (m *Manager) removeSubscription(sid string) {
 
 	delete(m.subscriptions, sid)
 
+	// Without every subscription, changes may go unreported
+	if m.stateCache != nil {
+		m.stateCache.SetLive(sub.DeviceIP, false)
+	}
+
 	// Remove from device subs list
 	if sids, ok := m.deviceSubs[sub.DeviceIP]; ok {
 		for i, s := range sids {
@@ -332,6 +341,28 @@ func (m *Manager) removeSubscription(sid string) {
 	}
 }
 
+// forgetMovedDevice drops subscriptions held for deviceUDN under an IP other than
+// deviceIP. They aren't unsubscribed: the device no longer answers at the old IP.
+func (m *Manager) forgetMovedDevice(deviceIP, deviceUDN string) {
+	if deviceUDN == "" {
+		return
+	}
+
+	m.mu.RLock()
+	var stale []string
+	for sid, sub := range m.subscriptions {
+		if sub.DeviceUDN == deviceUDN && sub.DeviceIP != deviceIP {
+			stale = append(stale, sid)
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, sid := range stale {
+		slog.Info("UPNP: Device moved, dropping subscription", "udn", deviceUDN, "ip", deviceIP, "sid", sid)
+		m.removeSubscription(sid)
+	}
+}
+
 // findSubscriptionBySID finds a subscription by its SID.
 func (m *Manager) findSubscriptionBySID(sid string) *Subscription {
 	m.mu.RLock()
@@ -387,17 +418,30 @@ func (m *Manager) renewExpiring() {
 
 		if err == ErrSubscriptionNotFound {
 			// Subscription expired, need to resubscribe
-			log.Printf("UPNP: Subscription expired, resubscribing: %s", sub.SID)
+			slog.Info("UPNP: Subscription expired, resubscribing", "sid", sub.SID)
 			m.removeSubscription(sub.SID)
 			m.SubscribeDevice(context.Background(), sub.DeviceIP, sub.DeviceUDN)
 			continue
 		}
 
 		if err != nil {
-			log.Printf("UPNP: Failed to renew %s: %v", sub.SID, err)
+			slog.Warn("UPNP: Failed to renew subscription", "sid", sub.SID, "error", err)
 			m.mu.Lock()
 			m.stats.RenewalFailures++
 			m.mu.Unlock()
+
+			// Events may stop arriving, so the TTL applies again until the subscription is back
+			if m.stateCache != nil {
+				m.stateCache.SetLive(sub.DeviceIP, false)
+			}
+
+			if sub.IsExpired() {
+				// The device has dropped the subscription by now (rebooted or offline),
+				// so start over; subscription backoff covers devices still unreachable
+				slog.Info("UPNP: Subscription lapsed, resubscribing", "sid", sub.SID)
+				m.removeSubscription(sub.SID)
+				m.SubscribeDevice(context.Background(), sub.DeviceIP, sub.DeviceUDN)
+			}
 			continue
 		}
 
@@ -410,9 +454,10 @@ func (m *Manager) renewExpiring() {
 		m.mu.Lock()
 		sub.Timeout = timeout
 		sub.RenewAt = m.now().Add(time.Duration(renewIn) * time.Second)
+		sub.ExpiresAt = m.now().Add(time.Duration(timeout) * time.Second)
 		m.mu.Unlock()
 
-		log.Printf("UPNP: Renewed subscription %s (timeout: %ds)", sub.SID, timeout)
+		slog.Debug("UPNP: Renewed subscription", "sid", sub.SID, "timeout_sec", timeout)
 	}
 }
 
@@ -0,0 +1,96 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestManager() *Manager {
+	config := DefaultManagerConfig()
+	config.Services = []ServiceType{ServiceAVTransport, ServiceRenderingControl}
+	return NewManager(config, 8080, nil)
+}
+
+func addTestSubscriptions(m *Manager, deviceIP, deviceUDN string) {
+	m.subscribedDevices[deviceIP] = &DeviceSubscriptionState{
+		DeviceIP:  deviceIP,
+		DeviceUDN: deviceUDN,
+		Services:  make(map[ServiceType]string),
+	}
+	for _, serviceType := range m.config.Services {
+		sid := "uuid:" + deviceIP + "-" + string(serviceType)
+		m.addSubscription(&Subscription{SID: sid, DeviceIP: deviceIP, DeviceUDN: deviceUDN, ServiceType: serviceType})
+		m.subscribedDevices[deviceIP].Services[serviceType] = sid
+	}
+}
+
+func TestManager_MarksStateLiveWhenFullySubscribed(t *testing.T) {
+	m := newTestManager()
+	m.stateCache = NewStateCache(time.Millisecond)
+	addTestSubscriptions(m, "10.0.0.1", "RINCON_A")
+
+	m.stateCache.UpdateTransport("10.0.0.1", &AVTransportEvent{TransportState: "PLAYING"})
+	m.stateCache.UpdateVolume("10.0.0.1", &RenderingControlEvent{Volume: 30})
+	m.markLive("10.0.0.1", ServiceRenderingControl)
+
+	time.Sleep(5 * time.Millisecond)
+	require.NotNil(t, m.stateCache.Get("10.0.0.1"))
+
+	// Losing a subscription means changes may be missed, so the TTL applies again
+	m.removeSubscription("uuid:10.0.0.1-" + string(ServiceAVTransport))
+	require.False(t, m.IsDeviceFullySubscribed("10.0.0.1"))
+	time.Sleep(5 * time.Millisecond)
+	require.Nil(t, m.stateCache.Get("10.0.0.1"))
+}
+
+func TestManager_FailedRenewalClearsLive(t *testing.T) {
+	m := newTestManager()
+	m.stateCache = NewStateCache(time.Millisecond)
+	m.subClient = NewSubscriptionClient(time.Second)
+	// Nothing listens on loopback, so renewing fails outright
+	addTestSubscriptions(m, "127.0.0.1", "RINCON_A")
+	for _, sub := range m.subscriptions {
+		sub.RenewAt = time.Now().Add(-time.Second)
+		sub.ExpiresAt = time.Now().Add(time.Minute)
+	}
+
+	m.stateCache.UpdateTransport("127.0.0.1", &AVTransportEvent{TransportState: "PLAYING"})
+	m.stateCache.UpdateVolume("127.0.0.1", &RenderingControlEvent{Volume: 30})
+	m.markLive("127.0.0.1", ServiceRenderingControl)
+
+	m.renewExpiring()
+	require.True(t, m.IsDeviceFullySubscribed("127.0.0.1"), "the subscription is kept until it lapses")
+	time.Sleep(5 * time.Millisecond)
+	require.Nil(t, m.stateCache.Get("127.0.0.1"))
+}
+
+func TestManager_MarkLiveRequiresFullSubscription(t *testing.T) {
+	m := newTestManager()
+	m.stateCache = NewStateCache(time.Millisecond)
+	m.stateCache.UpdateTransport("10.0.0.1", &AVTransportEvent{TransportState: "PLAYING"})
+	m.stateCache.UpdateVolume("10.0.0.1", &RenderingControlEvent{Volume: 30})
+
+	m.markLive("10.0.0.1", ServiceAVTransport)
+	time.Sleep(5 * time.Millisecond)
+	require.Nil(t, m.stateCache.Get("10.0.0.1"))
+}
+
+func TestManager_ForgetMovedDevice(t *testing.T) {
+	m := newTestManager()
+	addTestSubscriptions(m, "10.0.0.1", "RINCON_A")
+	addTestSubscriptions(m, "10.0.0.2", "RINCON_B")
+
+	m.forgetMovedDevice("10.0.0.7", "RINCON_A")
+
+	require.False(t, m.IsDeviceFullySubscribed("10.0.0.1"))
+	require.True(t, m.IsDeviceFullySubscribed("10.0.0.2"))
+	require.Len(t, m.subscriptions, 2)
+	require.NotContains(t, m.deviceSubs, "10.0.0.1")
+}
+
+func TestSubscription_IsExpired(t *testing.T) {
+	require.True(t, (&Subscription{ExpiresAt: time.Now().Add(-time.Second)}).IsExpired())
+	require.False(t, (&Subscription{ExpiresAt: time.Now().Add(time.Minute)}).IsExpired())
+}
@@ -1,9 +1,12 @@
 package events
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
 	"sync"
 	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos"
 )
 
 // StateCache provides thread-safe caching of device playback states.
@@ -39,13 +42,13 @@ func (c *StateCache) Get(deviceIP string) *DeviceState {
 		for ip := range c.states {
 			ips = append(ips, ip)
 		}
-		log.Printf("CACHE: Miss for %s, cache has: %v", deviceIP, ips)
+		slog.Debug("CACHE: Miss", "ip", deviceIP, "cached_ips", ips)
 		c.misses++
 		return nil
 	}
 
 	if !state.IsFresh(c.ttl) {
-		log.Printf("CACHE: Stale data for %s (age: %v, ttl: %v)", deviceIP, time.Since(state.UpdatedAt), c.ttl)
+		slog.Debug("CACHE: Stale data", "ip", deviceIP, "age", time.Since(state.UpdatedAt), "ttl", c.ttl)
 		c.misses++
 		return nil
 	}
@@ -53,6 +56,7 @@ func (c *StateCache) Get(deviceIP string) *DeviceState {
 	c.hits++
 	// Return a copy to prevent races
 	stateCopy := *state
+	stateCopy.RelativeTime = state.currentRelTime(time.Now())
 	return &stateCopy
 }
 
@@ -65,6 +69,7 @@ func (c *StateCache) GetByUDN(udn string) *DeviceState {
 		if state.DeviceUDN == udn && state.IsFresh(c.ttl) {
 			c.hits++
 			stateCopy := *state
+			stateCopy.RelativeTime = state.currentRelTime(time.Now())
 			return &stateCopy
 		}
 	}
@@ -99,6 +104,17 @@ func (c *StateCache) UpdateTransport(deviceIP string, event *AVTransportEvent) {
 	now := time.Now()
 	hasTransportState := false // Track if we got meaningful transport state data
 
+	// Events don't report position while playing, so it is tracked from the last known
+	// position: frozen when playback stops and restarted at zero on a new track.
+	if event.TransportState != "" && event.TransportState != state.TransportState {
+		state.RelativeTime = state.currentRelTime(now)
+		state.PositionUpdatedAt = now
+	}
+	if event.CurrentTrackURI != "" && state.CurrentTrackURI != "" && event.CurrentTrackURI != state.CurrentTrackURI {
+		state.RelativeTime = "0:00:00"
+		state.PositionUpdatedAt = now
+	}
+
 	if event.TransportState != "" {
 		state.TransportState = event.TransportState
 		hasTransportState = true
@@ -117,6 +133,7 @@ func (c *StateCache) UpdateTransport(deviceIP string, event *AVTransportEvent) {
 	}
 	if event.RelTime != "" {
 		state.RelativeTime = event.RelTime
+		state.PositionUpdatedAt = now
 	}
 	if event.AVTransportURI != "" {
 		state.AVTransportURI = event.AVTransportURI
@@ -156,6 +173,42 @@ func (c *StateCache) UpdateVolume(deviceIP string, event *RenderingControlEvent)
 	state.Source = "upnp_event"
 }
 
+// SetLive marks whether a device's state is kept current by event subscriptions.
+// Live states stay fresh past the TTL, up to MaxLiveStateAge. A state only becomes live once it has both
+// transport and volume; does nothing for devices not in the cache.
+func (c *StateCache) SetLive(deviceIP string, live bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state, ok := c.states[deviceIP]
+	if !ok || state.Live == live {
+		return
+	}
+	if live && (state.TransportUpdatedAt.IsZero() || state.VolumeUpdatedAt.IsZero()) {
+		return
+	}
+	state.Live = live
+	if !live {
+		// Start the TTL from now so the last events are still used briefly
+		state.UpdatedAt = time.Now()
+	}
+}
+
+// currentRelTime returns the track position at now, advancing the last known
+// position by the time spent playing since. The position is capped at the track
+// duration, and returned as-is when it isn't known.
+func (s *DeviceState) currentRelTime(now time.Time) string {
+	if s.TransportState != "PLAYING" || s.PositionUpdatedAt.IsZero() || s.RelativeTime == "" || s.RelativeTime == "NOT_IMPLEMENTED" {
+		return s.RelativeTime
+	}
+
+	seconds := sonos.ParseDuration(s.RelativeTime) + int(now.Sub(s.PositionUpdatedAt)/time.Second)
+	if duration := sonos.ParseDuration(s.TrackDuration); duration > 0 && seconds > duration {
+		seconds = duration
+	}
+	return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
 // SetUDN associates a UDN with a device IP.
 func (c *StateCache) SetUDN(deviceIP, udn string) {
 	c.mu.Lock()
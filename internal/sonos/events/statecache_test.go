@@ -0,0 +1,85 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateCache_LiveStateOutlivesTTL(t *testing.T) {
+	cache := NewStateCache(time.Millisecond)
+	cache.UpdateTransport("10.0.0.1", &AVTransportEvent{TransportState: "PAUSED_PLAYBACK"})
+
+	// Not live until volume has been reported too
+	cache.SetLive("10.0.0.1", true)
+	time.Sleep(5 * time.Millisecond)
+	require.Nil(t, cache.Get("10.0.0.1"))
+
+	cache.UpdateVolume("10.0.0.1", &RenderingControlEvent{Volume: 20})
+	cache.SetLive("10.0.0.1", true)
+	time.Sleep(5 * time.Millisecond)
+	state := cache.Get("10.0.0.1")
+	require.NotNil(t, state)
+	require.Equal(t, "PAUSED_PLAYBACK", state.TransportState)
+	require.Equal(t, 20, state.Volume)
+	require.Zero(t, cache.Prune())
+
+	cache.SetLive("10.0.0.1", false)
+	time.Sleep(5 * time.Millisecond)
+	require.Nil(t, cache.Get("10.0.0.1"))
+}
+
+func TestDeviceState_IsFreshBoundsLiveState(t *testing.T) {
+	state := &DeviceState{Live: true, UpdatedAt: time.Now().Add(-time.Hour)}
+	require.False(t, state.IsFresh(time.Second))
+
+	state.UpdatedAt = time.Now().Add(-time.Minute)
+	require.True(t, state.IsFresh(time.Second))
+	state.Live = false
+	require.False(t, state.IsFresh(time.Second))
+}
+
+func TestStateCache_SetLiveUnknownDevice(t *testing.T) {
+	cache := NewStateCache(time.Minute)
+	cache.SetLive("10.0.0.9", true)
+	_, _, size := cache.Stats()
+	require.Zero(t, size)
+}
+
+func TestDeviceState_CurrentRelTime(t *testing.T) {
+	now := time.Now()
+	state := &DeviceState{
+		TransportState:    "PLAYING",
+		RelativeTime:      "0:01:05",
+		TrackDuration:     "0:03:00",
+		PositionUpdatedAt: now.Add(-10 * time.Second),
+	}
+	require.Equal(t, "0:01:15", state.currentRelTime(now))
+
+	// Capped at the track duration
+	require.Equal(t, "0:03:00", state.currentRelTime(now.Add(time.Hour)))
+
+	// Not advanced while paused or when the position is unknown
+	state.TransportState = "PAUSED_PLAYBACK"
+	require.Equal(t, "0:01:05", state.currentRelTime(now))
+	state.TransportState = "PLAYING"
+	state.RelativeTime = ""
+	require.Equal(t, "", state.currentRelTime(now))
+}
+
+func TestStateCache_TracksPositionAcrossEvents(t *testing.T) {
+	cache := NewStateCache(time.Minute)
+	cache.UpdateTransport("10.0.0.1", &AVTransportEvent{TransportState: "PLAYING", CurrentTrackURI: "track-1", RelTime: "0:00:30"})
+
+	// Pausing freezes the position where playback stopped
+	cache.mu.Lock()
+	cache.states["10.0.0.1"].PositionUpdatedAt = time.Now().Add(-20 * time.Second)
+	cache.mu.Unlock()
+	cache.UpdateTransport("10.0.0.1", &AVTransportEvent{TransportState: "PAUSED_PLAYBACK"})
+	require.Equal(t, "0:00:50", cache.Get("10.0.0.1").RelativeTime)
+
+	// A new track starts from zero
+	cache.UpdateTransport("10.0.0.1", &AVTransportEvent{TransportState: "PLAYING", CurrentTrackURI: "track-2"})
+	require.Equal(t, "0:00:00", cache.Get("10.0.0.1").RelativeTime)
+}
@@ -11,6 +11,10 @@ const (
 	ZoneGroupTopologyEventPath = "/ZoneGroupTopology/Event"
 )
 
+// MaxLiveStateAge bounds how long a live state stays fresh without an event, in case
+// the subscriptions lapse without the hub noticing.
+const MaxLiveStateAge = 30 * time.Minute
+
 // ServiceType represents the type of UPnP service
 type ServiceType string
 
@@ -39,6 +43,7 @@ type Subscription struct {
 	Timeout     int         // Subscription timeout in seconds
 	SubscribedAt time.Time  // When the subscription was created
 	RenewAt      time.Time  // When the subscription should be renewed
+	ExpiresAt    time.Time  // When the device drops the subscription unless renewed
 	SEQ         int         // Last received sequence number
 }
 
@@ -50,7 +55,7 @@ func (s *Subscription) IsExpiringSoon() bool {
 
 // IsExpired returns true if the subscription has expired
 func (s *Subscription) IsExpired() bool {
-	return time.Now().After(s.SubscribedAt.Add(time.Duration(s.Timeout) * time.Second))
+	return time.Now().After(s.ExpiresAt)
 }
 
 // DeviceState represents the current playback state of a device
@@ -83,13 +88,22 @@ type DeviceState struct {
 	UpdatedAt          time.Time
 	TransportUpdatedAt time.Time
 	VolumeUpdatedAt    time.Time
+	PositionUpdatedAt  time.Time // When RelativeTime was last known to be accurate
 
 	// Source tracking
 	Source string // "upnp_event", "soap_poll"
+
+	// Live is set while the device's event subscriptions are active. Events arrive on
+	// every change, so a live state stays current however long the device is quiet.
+	Live bool
 }
 
-// IsFresh returns true if the state was updated within the TTL
+// IsFresh returns true if the state was updated within the TTL, or within
+// MaxLiveStateAge for live states
 func (s *DeviceState) IsFresh(ttl time.Duration) bool {
+	if s.Live && ttl < MaxLiveStateAge {
+		ttl = MaxLiveStateAge
+	}
 	return time.Since(s.UpdatedAt) <= ttl
 }
 
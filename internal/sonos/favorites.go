@@ -0,0 +1,104 @@
+package sonos
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// favoritesPageSize is the Browse page size used when loading every favorite.
+const favoritesPageSize = 100
+
+// defaultFavoritesCacheTTL is used until the configured TTL is applied.
+const defaultFavoritesCacheTTL = 5 * time.Minute
+
+// BrowseAllFavorites returns every favorite, served from the favorites cache when fresh.
+func (service *Service) BrowseAllFavorites() ([]soap.FavoriteItem, error) {
+	items, _, err := service.CachedFavorites()
+	return items, err
+}
+
+// CachedFavorites returns every favorite and the age of the data.
+// The age is zero when the favorites were just browsed.
+func (service *Service) CachedFavorites() ([]soap.FavoriteItem, time.Duration, error) {
+	if service.FavoritesCache == nil {
+		items, err := service.fetchAllFavorites()
+		return items, 0, err
+	}
+	return service.FavoritesCache.GetOrFetch(service.fetchAllFavorites)
+}
+
+// RefreshFavorites discards cached favorites and browses them again.
+func (service *Service) RefreshFavorites() ([]soap.FavoriteItem, error) {
+	if service.FavoritesCache != nil {
+		service.FavoritesCache.Invalidate()
+	}
+	items, _, err := service.CachedFavorites()
+	return items, err
+}
+
+// fetchAllFavorites loads every favorite by paging through Browse.
+func (service *Service) fetchAllFavorites() ([]soap.FavoriteItem, error) {
+	var items []soap.FavoriteItem
+	for {
+		result, err := service.BrowseFavorites(len(items), favoritesPageSize)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, result.Items...)
+		if len(result.Items) == 0 || len(items) >= result.TotalMatches {
+			return items, nil
+		}
+	}
+}
+
+// pageFavorites returns the favorites in [start, start+count) and whether more follow.
+func pageFavorites(items []soap.FavoriteItem, start, count int) ([]soap.FavoriteItem, bool) {
+	if start >= len(items) {
+		return []soap.FavoriteItem{}, false
+	}
+	end := start + count
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end], end < len(items)
+}
+
+// parseFavoriteOrdinal parses a favorite's string ordinal.
+// Returns false when the ordinal is missing or not a non-negative integer.
+func parseFavoriteOrdinal(raw string) (int, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, false
+	}
+	ordinal, err := strconv.Atoi(raw)
+	if err != nil || ordinal < 0 {
+		return 0, false
+	}
+	return ordinal, true
+}
+
+// RecentFavorites returns up to count favorites, newest first.
+// Sonos assigns increasing ordinals as favorites are added, so the highest ordinals are
+// the most recently saved. Favorites without a usable ordinal follow in browse order.
+func RecentFavorites(items []soap.FavoriteItem, count int) []soap.FavoriteItem {
+	sorted := make([]soap.FavoriteItem, len(items))
+	copy(sorted, items)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		left, leftOK := parseFavoriteOrdinal(sorted[i].Ordinal)
+		right, rightOK := parseFavoriteOrdinal(sorted[j].Ordinal)
+		if leftOK != rightOK {
+			return leftOK
+		}
+		return leftOK && left > right
+	})
+
+	if count < len(sorted) {
+		sorted = sorted[:count]
+	}
+	return sorted
+}
@@ -0,0 +1,153 @@
+package sonos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+func favoriteIDs(items []soap.FavoriteItem) []string {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	return ids
+}
+
+func TestRecentFavoritesOrdersByOrdinalDescending(t *testing.T) {
+	items := []soap.FavoriteItem{
+		{ID: "FV:2/1", Ordinal: "3"},
+		{ID: "FV:2/2", Ordinal: "12"},
+		{ID: "FV:2/3", Ordinal: " 7 "},
+		{ID: "FV:2/4", Ordinal: "1"},
+	}
+
+	recent := RecentFavorites(items, 3)
+	require.Equal(t, []string{"FV:2/2", "FV:2/3", "FV:2/1"}, favoriteIDs(recent))
+}
+
+func TestRecentFavoritesMissingOrdinalsFollowInBrowseOrder(t *testing.T) {
+	items := []soap.FavoriteItem{
+		{ID: "a", Ordinal: ""},
+		{ID: "b", Ordinal: "2"},
+		{ID: "c", Ordinal: "not-a-number"},
+		{ID: "d", Ordinal: "5"},
+	}
+
+	recent := RecentFavorites(items, 10)
+	require.Equal(t, []string{"d", "b", "a", "c"}, favoriteIDs(recent))
+}
+
+func TestRecentFavoritesWithoutOrdinalsKeepsBrowseOrder(t *testing.T) {
+	items := []soap.FavoriteItem{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+
+	recent := RecentFavorites(items, 2)
+	require.Equal(t, []string{"a", "b"}, favoriteIDs(recent))
+	require.Equal(t, "a", items[0].ID, "input must not be reordered")
+}
+
+func TestFavoritesCacheServesFreshItemsWithoutRefetching(t *testing.T) {
+	cache := NewFavoritesCache(time.Minute)
+	fetches := 0
+	fetcher := func() ([]soap.FavoriteItem, error) {
+		fetches++
+		return []soap.FavoriteItem{{ID: "FV:2/1"}}, nil
+	}
+
+	items, age, err := cache.GetOrFetch(fetcher)
+	require.NoError(t, err)
+	require.Equal(t, time.Duration(0), age)
+	require.Equal(t, []string{"FV:2/1"}, favoriteIDs(items))
+
+	_, _, err = cache.GetOrFetch(fetcher)
+	require.NoError(t, err)
+	require.Equal(t, 1, fetches)
+
+	cache.Invalidate()
+	_, _, err = cache.GetOrFetch(fetcher)
+	require.NoError(t, err)
+	require.Equal(t, 2, fetches)
+}
+
+func TestFavoritesCacheCachesEmptyFavorites(t *testing.T) {
+	cache := NewFavoritesCache(time.Minute)
+	cache.Set(nil)
+
+	items, _, ok := cache.Get()
+	require.True(t, ok)
+	require.Empty(t, items)
+}
+
+func TestFavoritesCacheZeroTTLDisablesCaching(t *testing.T) {
+	cache := NewFavoritesCache(0)
+	cache.Set([]soap.FavoriteItem{{ID: "FV:2/1"}})
+
+	_, _, ok := cache.Get()
+	require.False(t, ok)
+}
+
+func TestPageFavorites(t *testing.T) {
+	items := []soap.FavoriteItem{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+
+	page, hasMore := pageFavorites(items, 0, 2)
+	require.Equal(t, []string{"a", "b"}, favoriteIDs(page))
+	require.True(t, hasMore)
+
+	page, hasMore = pageFavorites(items, 2, 2)
+	require.Equal(t, []string{"c"}, favoriteIDs(page))
+	require.False(t, hasMore)
+
+	page, hasMore = pageFavorites(items, 5, 2)
+	require.Empty(t, page)
+	require.False(t, hasMore)
+}
+
+type fakeFavoritesProvider struct {
+	cached    []soap.FavoriteItem
+	refreshed []soap.FavoriteItem
+	refreshes int
+}
+
+func (p *fakeFavoritesProvider) BrowseAllFavorites() ([]soap.FavoriteItem, error) {
+	return p.cached, nil
+}
+
+func (p *fakeFavoritesProvider) RefreshFavorites() ([]soap.FavoriteItem, error) {
+	p.refreshes++
+	return p.refreshed, nil
+}
+
+func TestResolveFavoriteUsesFavoritesProvider(t *testing.T) {
+	provider := &fakeFavoritesProvider{
+		cached: []soap.FavoriteItem{{ID: "FV:2/1", Title: "Morning Jazz", Resource: "x-sonosapi-radio:abc"}},
+	}
+	resolver := NewContentResolver(nil, nil, time.Second, nil)
+	resolver.SetFavoritesProvider(provider)
+
+	playable, err := resolver.ResolveFavorite(context.Background(), "FV:2/1", "192.168.1.10")
+	require.NoError(t, err)
+	require.Equal(t, "Morning Jazz", playable.Title)
+	require.Equal(t, 0, provider.refreshes)
+}
+
+func TestResolveFavoriteRefreshesProviderOnMiss(t *testing.T) {
+	provider := &fakeFavoritesProvider{
+		cached:    []soap.FavoriteItem{{ID: "FV:2/1"}},
+		refreshed: []soap.FavoriteItem{{ID: "FV:2/1"}, {ID: "FV:2/2", Title: "New Favorite"}},
+	}
+	resolver := NewContentResolver(nil, nil, time.Second, nil)
+	resolver.SetFavoritesProvider(provider)
+
+	playable, err := resolver.ResolveFavorite(context.Background(), "FV:2/2", "192.168.1.10")
+	require.NoError(t, err)
+	require.Equal(t, "New Favorite", playable.Title)
+	require.Equal(t, 1, provider.refreshes)
+
+	_, err = resolver.ResolveFavorite(context.Background(), "FV:2/9", "192.168.1.10")
+	var notFound *FavoriteNotFoundError
+	require.ErrorAs(t, err, &notFound)
+}
@@ -0,0 +1,82 @@
+package sonos
+
+import (
+	"sync"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// FavoritesCache caches the household's Sonos favorites with a configurable TTL.
+// Favorites are shared across every speaker and only change when the user edits them
+// in the Sonos app, so one browse can serve the favorites list and favorite playback.
+// A TTL of zero disables caching.
+type FavoritesCache struct {
+	mu       sync.RWMutex
+	items    []soap.FavoriteItem
+	cachedAt time.Time
+	ttl      time.Duration
+}
+
+// NewFavoritesCache creates a new cache with the specified TTL.
+func NewFavoritesCache(ttl time.Duration) *FavoritesCache {
+	return &FavoritesCache{
+		ttl: ttl,
+	}
+}
+
+// Get returns the cached favorites and their age if the cache is populated and fresh.
+// Returns false if the cache is empty, expired, or disabled.
+func (c *FavoritesCache) Get() ([]soap.FavoriteItem, time.Duration, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.items == nil || c.ttl <= 0 {
+		return nil, 0, false
+	}
+
+	age := time.Since(c.cachedAt)
+	if age > c.ttl {
+		return nil, 0, false
+	}
+
+	return c.items, age, true
+}
+
+// Set stores the favorites in the cache. Nil is stored as an empty list so a
+// household with no favorites is still cached.
+func (c *FavoritesCache) Set(items []soap.FavoriteItem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if items == nil {
+		items = []soap.FavoriteItem{}
+	}
+	c.items = items
+	c.cachedAt = time.Now()
+}
+
+// Invalidate clears the cache. Call this when the app reports favorites changed.
+func (c *FavoritesCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = nil
+	c.cachedAt = time.Time{}
+}
+
+// GetOrFetch returns cached favorites if fresh, otherwise calls the fetcher and caches the result.
+// The returned duration is the age of the data, which is zero for a fresh fetch.
+func (c *FavoritesCache) GetOrFetch(fetcher func() ([]soap.FavoriteItem, error)) ([]soap.FavoriteItem, time.Duration, error) {
+	if items, age, ok := c.Get(); ok {
+		return items, age, nil
+	}
+
+	items, err := fetcher()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	c.Set(items)
+	return items, 0, nil
+}
@@ -0,0 +1,161 @@
+package sonos
+
+import (
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// Group transport confirmation polling. Members can lag the coordinator briefly
+// while they drain buffered audio, so the state is re-checked a few times.
+var (
+	groupConfirmAttempts = 4
+	groupConfirmDelay    = 250 * time.Millisecond
+)
+
+// groupTransportClient is the subset of Service used to redirect and confirm group transport actions.
+type groupTransportClient interface {
+	GetZoneGroupState(deviceIP string) (soap.ZoneGroupState, error)
+	GetTransportInfo(deviceIP string) (soap.TransportInfo, error)
+}
+
+// GroupTarget is the group a device belongs to, resolved to its coordinator.
+type GroupTarget struct {
+	CoordinatorIP  string
+	CoordinatorUDN string
+	Redirected     bool // True when the requested device was a group member, not the coordinator
+	Members        []GroupMemberTarget
+}
+
+// GroupMemberTarget is a visible member of a group.
+type GroupMemberTarget struct {
+	IP       string
+	UDN      string
+	RoomName string
+}
+
+// GroupMemberConfirmation is the observed transport state of one group member after an action.
+type GroupMemberConfirmation struct {
+	GroupMemberTarget
+	State     string
+	Confirmed bool
+}
+
+// GroupConfirmation reports whether every member of a group reached the expected transport state.
+type GroupConfirmation struct {
+	Confirmed bool
+	Members   []GroupMemberConfirmation
+}
+
+// ResolveGroupCoordinator auto-redirects a device to the coordinator of its group.
+// If zone group state is unavailable, the device itself is treated as a standalone coordinator.
+func ResolveGroupCoordinator(client groupTransportClient, deviceIP string) GroupTarget {
+	standalone := GroupTarget{
+		CoordinatorIP: deviceIP,
+		Members:       []GroupMemberTarget{{IP: deviceIP}},
+	}
+
+	zoneState, err := client.GetZoneGroupState(deviceIP)
+	if err != nil {
+		return standalone
+	}
+	uuidToIP := BuildUUIDToIPMap(&zoneState)
+
+	for _, group := range zoneState.Groups {
+		var target GroupTarget
+		containsDevice := false
+		for _, member := range group.Members {
+			if !member.IsVisible {
+				continue
+			}
+			ip := uuidToIP[member.UUID]
+			if ip == "" {
+				continue
+			}
+			if ip == deviceIP {
+				containsDevice = true
+			}
+			if member.IsCoordinator || member.UUID == group.Coordinator {
+				target.CoordinatorIP = ip
+				target.CoordinatorUDN = member.UUID
+			}
+			target.Members = append(target.Members, GroupMemberTarget{IP: ip, UDN: member.UUID, RoomName: member.ZoneName})
+		}
+		if !containsDevice || target.CoordinatorIP == "" {
+			continue
+		}
+		target.Redirected = target.CoordinatorIP != deviceIP
+		return target
+	}
+
+	return standalone
+}
+
+// ConfirmGroupTransport polls every group member until each reports one of the expected
+// transport states, or the attempts run out. Members that cannot be queried are unconfirmed.
+func ConfirmGroupTransport(client groupTransportClient, target GroupTarget, expectedStates ...string) GroupConfirmation {
+	expected := make(map[string]bool, len(expectedStates))
+	for _, state := range expectedStates {
+		expected[state] = true
+	}
+
+	members := make([]GroupMemberConfirmation, len(target.Members))
+	for i, member := range target.Members {
+		members[i] = GroupMemberConfirmation{GroupMemberTarget: member}
+	}
+
+	for attempt := 0; attempt < groupConfirmAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(groupConfirmDelay)
+		}
+
+		allConfirmed := true
+		for i := range members {
+			if members[i].Confirmed {
+				continue
+			}
+			info, err := client.GetTransportInfo(members[i].IP)
+			if err == nil {
+				members[i].State = info.CurrentTransportState
+				members[i].Confirmed = expected[info.CurrentTransportState]
+			}
+			if !members[i].Confirmed {
+				allConfirmed = false
+			}
+		}
+
+		if allConfirmed {
+			return GroupConfirmation{Confirmed: true, Members: members}
+		}
+	}
+
+	return GroupConfirmation{Confirmed: false, Members: members}
+}
+
+// formatGroupConfirmation formats a group confirmation for JSON response.
+func formatGroupConfirmation(target GroupTarget, confirmation GroupConfirmation) map[string]any {
+	members := make([]map[string]any, 0, len(confirmation.Members))
+	for _, member := range confirmation.Members {
+		members = append(members, map[string]any{
+			"udn":       emptyToNil(member.UDN),
+			"room_name": emptyToNil(member.RoomName),
+			"state":     emptyToNil(member.State),
+			"confirmed": member.Confirmed,
+		})
+	}
+	return map[string]any{
+		"coordinator_udn": emptyToNil(target.CoordinatorUDN),
+		"redirected":      target.Redirected,
+		"confirmed":       confirmation.Confirmed,
+		"members":         members,
+	}
+}
+
+// memberIPs returns the IP of every member of the group.
+func (target GroupTarget) memberIPs() []string {
+	ips := make([]string, 0, len(target.Members))
+	for _, member := range target.Members {
+		ips = append(ips, member.IP)
+	}
+	return ips
+}
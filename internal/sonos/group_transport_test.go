@@ -0,0 +1,134 @@
+package sonos
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// fakeGroupClient simulates a grouped topology where transport actions sent to the
+// coordinator propagate to every member.
+type fakeGroupClient struct {
+	mu        sync.Mutex
+	zoneState soap.ZoneGroupState
+	states    map[string]string
+	stuck     map[string]bool // Members that ignore propagation
+}
+
+func (f *fakeGroupClient) GetZoneGroupState(deviceIP string) (soap.ZoneGroupState, error) {
+	return f.zoneState, nil
+}
+
+func (f *fakeGroupClient) GetTransportInfo(deviceIP string) (soap.TransportInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	state, ok := f.states[deviceIP]
+	if !ok {
+		return soap.TransportInfo{}, errors.New("unreachable")
+	}
+	return soap.TransportInfo{CurrentTransportState: state}, nil
+}
+
+// apply simulates a transport action sent to deviceIP. Only the group coordinator
+// propagates the new state to the rest of its group.
+func (f *fakeGroupClient) apply(deviceIP, state string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if deviceIP != "192.168.1.10" {
+		f.states[deviceIP] = state
+		return
+	}
+	for _, ip := range []string{"192.168.1.10", "192.168.1.11"} {
+		if !f.stuck[ip] {
+			f.states[ip] = state
+		}
+	}
+}
+
+func groupedTopology() soap.ZoneGroupState {
+	return soap.ZoneGroupState{
+		Groups: []soap.ZoneGroup{
+			{
+				ID:          "group-1",
+				Coordinator: "RINCON_LIVING",
+				Members: []soap.ZoneMember{
+					{UUID: "RINCON_LIVING", ZoneName: "Living Room", Location: "http://192.168.1.10:1400/xml", IsCoordinator: true, IsVisible: true},
+					{UUID: "RINCON_KITCHEN", ZoneName: "Kitchen", Location: "http://192.168.1.11:1400/xml", IsVisible: true},
+				},
+			},
+			{
+				ID:          "group-2",
+				Coordinator: "RINCON_OFFICE",
+				Members: []soap.ZoneMember{
+					{UUID: "RINCON_OFFICE", ZoneName: "Office", Location: "http://192.168.1.20:1400/xml", IsCoordinator: true, IsVisible: true},
+				},
+			},
+		},
+	}
+}
+
+func withFastGroupConfirm(t *testing.T) {
+	t.Helper()
+	attempts, delay := groupConfirmAttempts, groupConfirmDelay
+	groupConfirmAttempts, groupConfirmDelay = 2, 0
+	t.Cleanup(func() {
+		groupConfirmAttempts, groupConfirmDelay = attempts, delay
+	})
+}
+
+func TestResolveGroupCoordinatorRedirectsMember(t *testing.T) {
+	client := &fakeGroupClient{zoneState: groupedTopology()}
+
+	target := ResolveGroupCoordinator(client, "192.168.1.11")
+	require.Equal(t, "192.168.1.10", target.CoordinatorIP)
+	require.Equal(t, "RINCON_LIVING", target.CoordinatorUDN)
+	require.True(t, target.Redirected)
+	require.Equal(t, []string{"192.168.1.10", "192.168.1.11"}, target.memberIPs())
+
+	target = ResolveGroupCoordinator(client, "192.168.1.20")
+	require.Equal(t, "192.168.1.20", target.CoordinatorIP)
+	require.False(t, target.Redirected)
+	require.Len(t, target.Members, 1)
+}
+
+func TestPausePropagatesAcrossGroup(t *testing.T) {
+	withFastGroupConfirm(t)
+	client := &fakeGroupClient{
+		zoneState: groupedTopology(),
+		states:    map[string]string{"192.168.1.10": "PLAYING", "192.168.1.11": "PLAYING"},
+	}
+
+	// Pause requested on the member is redirected to the coordinator
+	target := ResolveGroupCoordinator(client, "192.168.1.11")
+	client.apply(target.CoordinatorIP, "PAUSED_PLAYBACK")
+
+	confirmation := ConfirmGroupTransport(client, target, "PAUSED_PLAYBACK", "STOPPED")
+	require.True(t, confirmation.Confirmed)
+	require.Len(t, confirmation.Members, 2)
+	for _, member := range confirmation.Members {
+		require.True(t, member.Confirmed, member.RoomName)
+		require.Equal(t, "PAUSED_PLAYBACK", member.State)
+	}
+}
+
+func TestPauseUnconfirmedWhenMemberKeepsPlaying(t *testing.T) {
+	withFastGroupConfirm(t)
+	client := &fakeGroupClient{
+		zoneState: groupedTopology(),
+		states:    map[string]string{"192.168.1.10": "PLAYING", "192.168.1.11": "PLAYING"},
+		stuck:     map[string]bool{"192.168.1.11": true},
+	}
+
+	target := ResolveGroupCoordinator(client, "192.168.1.10")
+	client.apply(target.CoordinatorIP, "PAUSED_PLAYBACK")
+
+	confirmation := ConfirmGroupTransport(client, target, "PAUSED_PLAYBACK", "STOPPED")
+	require.False(t, confirmation.Confirmed)
+	require.True(t, confirmation.Members[0].Confirmed)
+	require.False(t, confirmation.Members[1].Confirmed)
+	require.Equal(t, "PLAYING", confirmation.Members[1].State)
+}
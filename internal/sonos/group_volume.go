@@ -0,0 +1,320 @@
+package sonos
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// Group volume methods.
+const (
+	GroupVolumeMethodGroupRendering = "group_rendering_control" // The coordinator's GroupRenderingControl service
+	GroupVolumeMethodPerDevice      = "per_device"              // Each member's RenderingControl, for firmware without it
+)
+
+// groupVolumeClient is the subset of Service used to read and change a group's volume.
+type groupVolumeClient interface {
+	groupTransportClient
+	GetDeviceDescription(deviceIP string) (soap.DeviceDescription, error)
+	GetGroupVolume(coordinatorIP string) (int, error)
+	SetGroupRenderingVolume(coordinatorIP string, level int) error
+	AdjustGroupVolume(coordinatorIP string, adjustment int) (int, error)
+	GetVolume(deviceIP string) (soap.VolumeInfo, error)
+	SetVolume(deviceIP string, level int) error
+}
+
+// groupVolumeChange is the outcome of setting or adjusting a group's volume.
+type groupVolumeChange struct {
+	Method         string
+	Volume         int
+	PreviousVolume int
+	Results        []deviceVolumeResult // Per-member results; only for GroupVolumeMethodPerDevice
+}
+
+// groupRenderingSupport remembers which devices list GroupRenderingControl, so each
+// device's description is fetched once. The zero value is ready to use.
+type groupRenderingSupport struct {
+	mu        sync.Mutex
+	supported map[string]bool // Device IP -> lists GroupRenderingControl
+}
+
+// groupVolumeMethod picks how to control the volume of the group coordinated by
+// coordinatorIP. Older firmware doesn't list GroupRenderingControl in its device
+// description. A description that can't be fetched is an error rather than a sign of
+// older firmware, since setting each member's volume would lose their balance.
+func groupVolumeMethod(client groupVolumeClient, support *groupRenderingSupport, coordinatorIP string) (string, error) {
+	support.mu.Lock()
+	supported, known := support.supported[coordinatorIP]
+	support.mu.Unlock()
+
+	if !known {
+		desc, err := client.GetDeviceDescription(coordinatorIP)
+		if err != nil {
+			return "", err
+		}
+		supported = desc.HasService(string(soap.ServiceGroupRenderingControl))
+
+		support.mu.Lock()
+		if support.supported == nil {
+			support.supported = make(map[string]bool)
+		}
+		support.supported[coordinatorIP] = supported
+		support.mu.Unlock()
+	}
+
+	if supported {
+		return GroupVolumeMethodGroupRendering, nil
+	}
+	return GroupVolumeMethodPerDevice, nil
+}
+
+// getGroupVolume returns the volume of target's group. Without GroupRenderingControl
+// it's the average of the members that answered, as the Sonos app shows it.
+func getGroupVolume(client groupVolumeClient, target GroupTarget, method string) (int, error) {
+	if method == GroupVolumeMethodGroupRendering {
+		return client.GetGroupVolume(target.CoordinatorIP)
+	}
+
+	volumes := getMemberVolumes(client, target.memberIPs())
+	total, answered := 0, 0
+	var firstErr error
+	for _, volume := range volumes {
+		if volume.err != nil {
+			if firstErr == nil {
+				firstErr = volume.err
+			}
+			continue
+		}
+		total += volume.level
+		answered++
+	}
+	if answered == 0 {
+		return 0, firstErr
+	}
+	return (total + answered/2) / answered, nil
+}
+
+// setGroupVolume sets target's group to level. GroupRenderingControl scales the members
+// so their relative volumes are kept; the per-device fallback sets each member to level.
+func setGroupVolume(client groupVolumeClient, target GroupTarget, method string, level int) (groupVolumeChange, error) {
+	previous, err := getGroupVolume(client, target, method)
+	if err != nil {
+		return groupVolumeChange{}, err
+	}
+	change := groupVolumeChange{Method: method, Volume: level, PreviousVolume: previous}
+
+	if method == GroupVolumeMethodGroupRendering {
+		return change, client.SetGroupRenderingVolume(target.CoordinatorIP, level)
+	}
+
+	memberIPs := target.memberIPs()
+	levels := make([]int, len(memberIPs))
+	for i := range levels {
+		levels[i] = level
+	}
+	change.Results = setMemberVolumes(client, memberIPs, levels)
+	return change, nil
+}
+
+// adjustGroupVolume changes target's group volume by adjustment. The per-device fallback
+// applies the adjustment to each member's own volume, clamped to 0-100, which keeps
+// their relative volumes until one of them reaches a limit.
+func adjustGroupVolume(client groupVolumeClient, target GroupTarget, method string, adjustment int) (groupVolumeChange, error) {
+	if method == GroupVolumeMethodGroupRendering {
+		previous, err := client.GetGroupVolume(target.CoordinatorIP)
+		if err != nil {
+			return groupVolumeChange{}, err
+		}
+		volume, err := client.AdjustGroupVolume(target.CoordinatorIP, adjustment)
+		if err != nil {
+			return groupVolumeChange{}, err
+		}
+		return groupVolumeChange{Method: method, Volume: volume, PreviousVolume: previous}, nil
+	}
+
+	memberIPs := target.memberIPs()
+	volumes := getMemberVolumes(client, memberIPs)
+	levels := make([]int, len(memberIPs))
+	previousTotal, newTotal, answered := 0, 0, 0
+	var firstErr error
+	for i, volume := range volumes {
+		if volume.err != nil {
+			if firstErr == nil {
+				firstErr = volume.err
+			}
+			levels[i] = -1
+			continue
+		}
+		levels[i] = clampVolume(volume.level + adjustment)
+		previousTotal += volume.level
+		newTotal += levels[i]
+		answered++
+	}
+	if answered == 0 {
+		return groupVolumeChange{}, firstErr
+	}
+
+	change := groupVolumeChange{
+		Method:         method,
+		Volume:         (newTotal + answered/2) / answered,
+		PreviousVolume: (previousTotal + answered/2) / answered,
+		Results:        setMemberVolumes(client, memberIPs, levels),
+	}
+	for i, volume := range volumes {
+		if volume.err != nil {
+			change.Results[i] = deviceVolumeResult{IP: memberIPs[i], Error: volume.err.Error()}
+		}
+	}
+	return change, nil
+}
+
+// memberVolume is a member's volume, or the error reading it.
+type memberVolume struct {
+	level int
+	err   error
+}
+
+// getMemberVolumes reads the volume of each speaker in parallel.
+func getMemberVolumes(client groupVolumeClient, memberIPs []string) []memberVolume {
+	volumes := make([]memberVolume, len(memberIPs))
+	var wg sync.WaitGroup
+	for i, ip := range memberIPs {
+		wg.Add(1)
+		go func(idx int, targetIP string) {
+			defer wg.Done()
+			info, err := client.GetVolume(targetIP)
+			volumes[idx] = memberVolume{level: info.CurrentVolume, err: err}
+		}(i, ip)
+	}
+	wg.Wait()
+	return volumes
+}
+
+// setMemberVolumes sets each speaker to its level in parallel. Speakers with a
+// negative level are skipped and left without a result.
+func setMemberVolumes(client groupVolumeClient, memberIPs []string, levels []int) []deviceVolumeResult {
+	results := make([]deviceVolumeResult, len(memberIPs))
+	var wg sync.WaitGroup
+	for i, ip := range memberIPs {
+		if levels[i] < 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(idx int, targetIP string) {
+			defer wg.Done()
+			err := client.SetVolume(targetIP, levels[idx])
+			result := deviceVolumeResult{IP: targetIP, Success: err == nil}
+			if err != nil {
+				result.Error = err.Error()
+			}
+			results[idx] = result
+		}(i, ip)
+	}
+	wg.Wait()
+	return results
+}
+
+func clampVolume(level int) int {
+	return max(0, min(100, level))
+}
+
+// formatGroupVolume is the response body shared by the group volume endpoints.
+func formatGroupVolume(object, udn string, target GroupTarget, method string, volume int) map[string]any {
+	return map[string]any{
+		"object":          object,
+		"udn":             udn,
+		"coordinator_udn": emptyToNil(target.CoordinatorUDN),
+		"method":          method,
+		"volume":          volume,
+	}
+}
+
+// getGroupVolumeHandler handles GET /v1/sonos/volume/group?udn=.
+func getGroupVolumeHandler(service *Service) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		udn := r.URL.Query().Get("udn")
+		if udn == "" {
+			return apperrors.NewValidationError("udn query parameter is required", nil)
+		}
+
+		deviceIP, err := service.ResolveDeviceIP(udn)
+		if err != nil {
+			return deviceFailure(err, udn, "", "Failed to resolve device")
+		}
+
+		target := ResolveGroupCoordinator(service, deviceIP)
+		method, err := groupVolumeMethod(service, &service.groupRendering, target.CoordinatorIP)
+		if err != nil {
+			return deviceFailure(err, udn, target.CoordinatorIP, "Failed to fetch group volume")
+		}
+		volume, err := getGroupVolume(service, target, method)
+		if err != nil {
+			return deviceFailure(err, udn, target.CoordinatorIP, "Failed to fetch group volume")
+		}
+
+		response := formatGroupVolume("group_volume", udn, target, method, volume)
+		addDebugTargets(r, response, deviceIP, target.memberIPs())
+
+		return api.WriteResource(w, http.StatusOK, response)
+	}
+}
+
+// setGroupVolumeHandler handles POST /v1/sonos/volume/group. The body has either an
+// absolute level or a relative adjustment.
+func setGroupVolumeHandler(service *Service) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var body struct {
+			UDN        string `json:"udn"`
+			Level      *int   `json:"level"`
+			Adjustment *int   `json:"adjustment"`
+		}
+		if err := decodeJSON(r, &body); err != nil || body.UDN == "" {
+			return apperrors.NewValidationError("udn is required", nil)
+		}
+		if (body.Level == nil) == (body.Adjustment == nil) {
+			return apperrors.NewValidationError("Exactly one of level or adjustment is required", nil)
+		}
+		if body.Level != nil && (*body.Level < 0 || *body.Level > 100) {
+			return apperrors.NewValidationError("level must be between 0 and 100", nil)
+		}
+		if body.Adjustment != nil && (*body.Adjustment < -100 || *body.Adjustment > 100) {
+			return apperrors.NewValidationError("adjustment must be between -100 and 100", nil)
+		}
+
+		deviceIP, err := service.ResolveDeviceIP(body.UDN)
+		if err != nil {
+			return deviceFailure(err, body.UDN, "", "Failed to resolve device")
+		}
+
+		target := ResolveGroupCoordinator(service, deviceIP)
+		method, err := groupVolumeMethod(service, &service.groupRendering, target.CoordinatorIP)
+		if err != nil {
+			return deviceFailure(err, body.UDN, target.CoordinatorIP, "Failed to set group volume")
+		}
+		var change groupVolumeChange
+		if body.Level != nil {
+			change, err = setGroupVolume(service, target, method, *body.Level)
+		} else {
+			change, err = adjustGroupVolume(service, target, method, *body.Adjustment)
+		}
+		if err != nil {
+			return deviceFailure(err, body.UDN, target.CoordinatorIP, "Failed to set group volume")
+		}
+
+		response := formatGroupVolume("group_volume_action", body.UDN, target, change.Method, change.Volume)
+		response["previous_volume"] = change.PreviousVolume
+		response["all_succeeded"] = true
+		if change.Results != nil {
+			succeeded, failed := countResults(change.Results)
+			response["all_succeeded"] = failed == 0
+			response["succeeded_count"] = succeeded
+			response["failed_count"] = failed
+		}
+		addDebugTargets(r, response, deviceIP, target.memberIPs())
+
+		return api.WriteAction(w, http.StatusOK, response)
+	}
+}
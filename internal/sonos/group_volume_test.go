@@ -0,0 +1,179 @@
+package sonos
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+type fakeGroupVolumeClient struct {
+	mu           sync.Mutex
+	groupControl bool
+	groupVolume  int
+	volumes      map[string]int // IP -> volume
+	failIP       string
+	descErr      error
+	descFetches  int
+	calls        []string
+}
+
+func (f *fakeGroupVolumeClient) GetZoneGroupState(deviceIP string) (soap.ZoneGroupState, error) {
+	return soap.ZoneGroupState{}, errors.New("not used")
+}
+
+func (f *fakeGroupVolumeClient) GetTransportInfo(deviceIP string) (soap.TransportInfo, error) {
+	return soap.TransportInfo{}, errors.New("not used")
+}
+
+func (f *fakeGroupVolumeClient) GetDeviceDescription(deviceIP string) (soap.DeviceDescription, error) {
+	f.descFetches++
+	if f.descErr != nil {
+		return soap.DeviceDescription{}, f.descErr
+	}
+	desc := soap.DeviceDescription{ServiceTypes: []string{"urn:schemas-upnp-org:service:RenderingControl:1"}}
+	if f.groupControl {
+		desc.ServiceTypes = append(desc.ServiceTypes, "urn:schemas-upnp-org:service:GroupRenderingControl:1")
+	}
+	return desc, nil
+}
+
+func (f *fakeGroupVolumeClient) GetGroupVolume(coordinatorIP string) (int, error) {
+	return f.groupVolume, nil
+}
+
+func (f *fakeGroupVolumeClient) SetGroupRenderingVolume(coordinatorIP string, level int) error {
+	f.record(fmt.Sprintf("SetGroupVolume %s %d", coordinatorIP, level))
+	f.groupVolume = level
+	return nil
+}
+
+func (f *fakeGroupVolumeClient) AdjustGroupVolume(coordinatorIP string, adjustment int) (int, error) {
+	f.record(fmt.Sprintf("SetRelativeGroupVolume %s %d", coordinatorIP, adjustment))
+	f.groupVolume = clampVolume(f.groupVolume + adjustment)
+	return f.groupVolume, nil
+}
+
+func (f *fakeGroupVolumeClient) GetVolume(deviceIP string) (soap.VolumeInfo, error) {
+	if deviceIP == f.failIP {
+		return soap.VolumeInfo{}, errors.New("timeout")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return soap.VolumeInfo{CurrentVolume: f.volumes[deviceIP]}, nil
+}
+
+func (f *fakeGroupVolumeClient) SetVolume(deviceIP string, level int) error {
+	f.record(fmt.Sprintf("SetVolume %s %d", deviceIP, level))
+	return nil
+}
+
+func (f *fakeGroupVolumeClient) record(call string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+}
+
+func (f *fakeGroupVolumeClient) sortedCalls() []string {
+	sort.Strings(f.calls)
+	return f.calls
+}
+
+func TestGroupVolume(t *testing.T) {
+	target := GroupTarget{
+		CoordinatorIP:  "10.0.0.1",
+		CoordinatorUDN: "RINCON_KITCHEN",
+		Members:        []GroupMemberTarget{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}},
+	}
+	newClient := func(groupControl bool) *fakeGroupVolumeClient {
+		return &fakeGroupVolumeClient{
+			groupControl: groupControl,
+			groupVolume:  30,
+			volumes:      map[string]int{"10.0.0.1": 20, "10.0.0.2": 41},
+		}
+	}
+
+	t.Run("detects GroupRenderingControl", func(t *testing.T) {
+		client := newClient(true)
+		support := &groupRenderingSupport{}
+		for range 2 {
+			method, err := groupVolumeMethod(client, support, "10.0.0.1")
+			require.NoError(t, err)
+			require.Equal(t, GroupVolumeMethodGroupRendering, method)
+		}
+		require.Equal(t, 1, client.descFetches, "the description is fetched once per device")
+
+		method, err := groupVolumeMethod(newClient(false), &groupRenderingSupport{}, "10.0.0.1")
+		require.NoError(t, err)
+		require.Equal(t, GroupVolumeMethodPerDevice, method)
+	})
+
+	t.Run("an unreachable coordinator is an error, not older firmware", func(t *testing.T) {
+		client := newClient(true)
+		client.descErr = &soap.SonosUnreachableError{Action: "GetDeviceDescription", Err: errors.New("connection refused")}
+		support := &groupRenderingSupport{}
+		_, err := groupVolumeMethod(client, support, "10.0.0.1")
+		require.Error(t, err)
+
+		client.descErr = nil
+		method, err := groupVolumeMethod(client, support, "10.0.0.1")
+		require.NoError(t, err)
+		require.Equal(t, GroupVolumeMethodGroupRendering, method, "failures aren't cached")
+	})
+
+	t.Run("level uses the coordinator", func(t *testing.T) {
+		client := newClient(true)
+		change, err := setGroupVolume(client, target, GroupVolumeMethodGroupRendering, 40)
+		require.NoError(t, err)
+		require.Equal(t, groupVolumeChange{Method: GroupVolumeMethodGroupRendering, Volume: 40, PreviousVolume: 30}, change)
+		require.Equal(t, []string{"SetGroupVolume 10.0.0.1 40"}, client.calls)
+	})
+
+	t.Run("adjustment uses the coordinator", func(t *testing.T) {
+		client := newClient(true)
+		change, err := adjustGroupVolume(client, target, GroupVolumeMethodGroupRendering, -5)
+		require.NoError(t, err)
+		require.Equal(t, groupVolumeChange{Method: GroupVolumeMethodGroupRendering, Volume: 25, PreviousVolume: 30}, change)
+		require.Equal(t, []string{"SetRelativeGroupVolume 10.0.0.1 -5"}, client.calls)
+	})
+
+	t.Run("fallback reads the average", func(t *testing.T) {
+		volume, err := getGroupVolume(newClient(false), target, GroupVolumeMethodPerDevice)
+		require.NoError(t, err)
+		require.Equal(t, 31, volume)
+	})
+
+	t.Run("fallback level sets every member", func(t *testing.T) {
+		client := newClient(false)
+		change, err := setGroupVolume(client, target, GroupVolumeMethodPerDevice, 40)
+		require.NoError(t, err)
+		require.Equal(t, 31, change.PreviousVolume)
+		require.Equal(t, []string{"SetVolume 10.0.0.1 40", "SetVolume 10.0.0.2 40"}, client.sortedCalls())
+		require.Len(t, change.Results, 2)
+	})
+
+	t.Run("fallback adjustment moves each member", func(t *testing.T) {
+		client := newClient(false)
+		change, err := adjustGroupVolume(client, target, GroupVolumeMethodPerDevice, -25)
+		require.NoError(t, err)
+		require.Equal(t, []string{"SetVolume 10.0.0.1 0", "SetVolume 10.0.0.2 16"}, client.sortedCalls())
+		require.Equal(t, 8, change.Volume)
+		require.Equal(t, 31, change.PreviousVolume)
+	})
+
+	t.Run("fallback adjustment skips members it can't read", func(t *testing.T) {
+		client := newClient(false)
+		client.failIP = "10.0.0.2"
+		change, err := adjustGroupVolume(client, target, GroupVolumeMethodPerDevice, 5)
+		require.NoError(t, err)
+		require.Equal(t, []string{"SetVolume 10.0.0.1 25"}, client.calls)
+		succeeded, failed := countResults(change.Results)
+		require.Equal(t, 1, succeeded)
+		require.Equal(t, 1, failed)
+	})
+}
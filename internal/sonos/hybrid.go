@@ -1,7 +1,7 @@
 package sonos
 
 import (
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
@@ -33,13 +33,13 @@ type HybridGroupResult struct {
 // Returns nil if cache is unavailable or stale for this device.
 func GetPlaybackFromCache(svc *Service, coordinatorIP string) *HybridPlaybackInfo {
 	if svc.StateProvider == nil {
-		log.Printf("HYBRID: StateProvider is nil")
+		slog.Debug("HYBRID: StateProvider is nil")
 		return nil
 	}
 
 	state := svc.StateProvider.GetPlaybackState(coordinatorIP)
 	if state == nil {
-		log.Printf("HYBRID: No cached state for %s", coordinatorIP)
+		slog.Debug("HYBRID: No cached state", "ip", coordinatorIP)
 		return nil
 	}
 
@@ -47,7 +47,7 @@ func GetPlaybackFromCache(svc *Service, coordinatorIP string) *HybridPlaybackInf
 	// This fixes the issue where position-only events update UpdatedAt but not TransportState,
 	// making the cache appear fresh while actually having stale/empty transport state
 	if state.TransportState == "" {
-		log.Printf("HYBRID: Empty TransportState in cache for %s, falling back to SOAP", coordinatorIP)
+		slog.Debug("HYBRID: Empty TransportState in cache, falling back to SOAP", "ip", coordinatorIP)
 		return nil
 	}
 
@@ -98,12 +98,12 @@ func GetPlaybackFromCache(svc *Service, coordinatorIP string) *HybridPlaybackInf
 func FetchPlaybackWithFallback(svc *Service, coordinatorIP string) HybridPlaybackInfo {
 	// Try cache first
 	if cached := GetPlaybackFromCache(svc, coordinatorIP); cached != nil {
-		log.Printf("HYBRID: Cache hit for %s (age: %v)", coordinatorIP, cached.CacheAge.Round(time.Millisecond))
+		slog.Debug("HYBRID: Cache hit", "ip", coordinatorIP, "age", cached.CacheAge.Round(time.Millisecond))
 		return *cached
 	}
 
 	// Fall back to SOAP
-	log.Printf("HYBRID: SOAP fallback for %s", coordinatorIP)
+	slog.Debug("HYBRID: SOAP fallback", "ip", coordinatorIP)
 	start := time.Now()
 	playback := FetchGroupPlayback(svc, coordinatorIP)
 
@@ -139,7 +139,7 @@ func FetchAllGroupsPlaybackHybrid(svc *Service, coordinators []CoordinatorInfo)
 	// Log cache statistics
 	cacheHits := len(coordinators) - len(cacheMisses)
 	if cacheHits > 0 || len(cacheMisses) > 0 {
-		log.Printf("HYBRID: %d cache hits, %d SOAP fetches needed", cacheHits, len(cacheMisses))
+		slog.Debug("HYBRID: Cache lookup", "cache_hits", cacheHits, "soap_fetches", len(cacheMisses))
 	}
 
 	// Phase 2: If no cache misses, we're done
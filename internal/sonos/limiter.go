@@ -0,0 +1,210 @@
+package sonos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/devices"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// Default SOAP concurrency limits and circuit breaker settings.
+const (
+	DefaultMaxConcurrentSOAP          = 16
+	DefaultMaxConcurrentSOAPPerDevice = 4
+	DefaultBreakerThreshold           = 3
+	DefaultBreakerCooldown            = 30 * time.Second
+)
+
+// ErrDeviceUnavailable is returned without calling a device while its circuit breaker is open.
+var ErrDeviceUnavailable = errors.New("device unavailable")
+
+// LimiterConfig configures a SOAPLimiter.
+type LimiterConfig struct {
+	MaxConcurrent          int           // SOAP calls in flight across all devices
+	MaxConcurrentPerDevice int           // SOAP calls in flight to one device
+	BreakerThreshold       int           // Consecutive failures that open a device's breaker; 0 disables it
+	BreakerCooldown        time.Duration // How long an open breaker short-circuits calls
+}
+
+// DefaultLimiterConfig returns the default limits.
+func DefaultLimiterConfig() LimiterConfig {
+	return LimiterConfig{
+		MaxConcurrent:          DefaultMaxConcurrentSOAP,
+		MaxConcurrentPerDevice: DefaultMaxConcurrentSOAPPerDevice,
+		BreakerThreshold:       DefaultBreakerThreshold,
+		BreakerCooldown:        DefaultBreakerCooldown,
+	}
+}
+
+// SOAPLimiter bounds concurrent SOAP calls, globally and per device, and trips a
+// per-device circuit breaker after consecutive timeouts so fan-out requests don't pile up
+// waiting on a speaker that isn't answering. A nil SOAPLimiter runs calls unbounded.
+type SOAPLimiter struct {
+	cfg    LimiterConfig
+	global chan struct{}
+	now    func() time.Time
+
+	mu      sync.Mutex
+	devices map[string]*deviceLimiter
+}
+
+type deviceLimiter struct {
+	slots     chan struct{}
+	failures  int       // Consecutive timeouts or connection failures
+	openUntil time.Time // Calls are short-circuited until then
+}
+
+// NewSOAPLimiter creates a SOAPLimiter. Unset limits and cooldown use the defaults.
+func NewSOAPLimiter(cfg LimiterConfig) *SOAPLimiter {
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = DefaultMaxConcurrentSOAP
+	}
+	if cfg.MaxConcurrentPerDevice <= 0 {
+		cfg.MaxConcurrentPerDevice = DefaultMaxConcurrentSOAPPerDevice
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = DefaultBreakerCooldown
+	}
+	return &SOAPLimiter{
+		cfg:     cfg,
+		global:  make(chan struct{}, cfg.MaxConcurrent),
+		now:     time.Now,
+		devices: make(map[string]*deviceLimiter),
+	}
+}
+
+// Do runs call, a SOAP call to deviceIP, once a slot is free for the device and
+// globally. While the device's breaker is open it returns ErrDeviceUnavailable instead.
+func (l *SOAPLimiter) Do(deviceIP string, call func() error) error {
+	return l.DoContext(context.Background(), deviceIP, call)
+}
+
+// DoContext is Do for callers that may cancel: it stops waiting for a slot, without
+// calling the device, once ctx is done.
+func (l *SOAPLimiter) DoContext(ctx context.Context, deviceIP string, call func() error) error {
+	if l == nil {
+		return call()
+	}
+
+	device := l.device(deviceIP)
+	if err := l.checkBreaker(deviceIP, device); err != nil {
+		return err
+	}
+
+	// Take the device slot first so calls queued behind a slow device don't hold global slots
+	select {
+	case device.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-device.slots }()
+
+	// The breaker may have opened while this call was queued
+	if err := l.checkBreaker(deviceIP, device); err != nil {
+		return err
+	}
+
+	select {
+	case l.global <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	err := call()
+	<-l.global
+
+	l.record(deviceIP, device, err)
+	return err
+}
+
+func (l *SOAPLimiter) device(deviceIP string) *deviceLimiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	device, ok := l.devices[deviceIP]
+	if !ok {
+		device = &deviceLimiter{slots: make(chan struct{}, l.cfg.MaxConcurrentPerDevice)}
+		l.devices[deviceIP] = device
+	}
+	return device
+}
+
+func (l *SOAPLimiter) checkBreaker(deviceIP string, device *deviceLimiter) error {
+	l.mu.Lock()
+	openUntil := device.openUntil
+	l.mu.Unlock()
+
+	if l.now().Before(openUntil) {
+		return fmt.Errorf("%w: %s (circuit open until %s)", ErrDeviceUnavailable, deviceIP, openUntil.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// record updates the device's breaker with the result of a call. Once the cooldown has
+// passed calls go through again, but the failure count is kept, so a single further
+// failure reopens the breaker.
+func (l *SOAPLimiter) record(deviceIP string, device *deviceLimiter, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !isDeviceFailure(err) {
+		device.failures = 0
+		device.openUntil = time.Time{}
+		return
+	}
+
+	device.failures++
+	if l.cfg.BreakerThreshold > 0 && device.failures >= l.cfg.BreakerThreshold {
+		device.openUntil = l.now().Add(l.cfg.BreakerCooldown)
+		slog.Warn("SOAP: Circuit open after consecutive failures",
+			"ip", deviceIP, "failures", device.failures, "retry_after", l.cfg.BreakerCooldown)
+	}
+}
+
+// isDeviceFailure reports whether err means the device didn't answer. A rejected action
+// is an answer, and a call the caller cancelled says nothing about the device, so
+// neither counts towards the breaker.
+func isDeviceFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var timeout *soap.SonosTimeoutError
+	var unreachable *soap.SonosUnreachableError
+	return errors.As(err, &timeout) || errors.As(err, &unreachable) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// SOAPStats reports the limiter's concurrency and each device's breaker for
+// /v1/devices/stats.
+func (l *SOAPLimiter) SOAPStats() devices.SOAPStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	breakers := make([]devices.CircuitBreakerStatus, 0, len(l.devices))
+	for ip, device := range l.devices {
+		status := devices.CircuitBreakerStatus{
+			IP:                  ip,
+			State:               devices.CircuitClosed,
+			ConsecutiveFailures: device.failures,
+			InFlight:            len(device.slots),
+		}
+		if now.Before(device.openUntil) {
+			openUntil := device.openUntil
+			status.State = devices.CircuitOpen
+			status.OpenUntil = &openUntil
+		}
+		breakers = append(breakers, status)
+	}
+	sort.Slice(breakers, func(i, j int) bool { return breakers[i].IP < breakers[j].IP })
+
+	return devices.SOAPStats{
+		InFlight:               len(l.global),
+		MaxConcurrent:          l.cfg.MaxConcurrent,
+		MaxConcurrentPerDevice: l.cfg.MaxConcurrentPerDevice,
+		Breakers:               breakers,
+	}
+}
@@ -0,0 +1,138 @@
+package sonos
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/devices"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+func TestSOAPLimiter_Breaker(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewSOAPLimiter(LimiterConfig{BreakerThreshold: 2, BreakerCooldown: 30 * time.Second})
+	limiter.now = func() time.Time { return now }
+
+	timeout := func() error { return &soap.SonosTimeoutError{Action: "GetVolume"} }
+	calls := 0
+	ok := func() error { calls++; return nil }
+
+	// A rejection is an answer, so it resets the failure count
+	require.Error(t, limiter.Do("10.0.0.1", timeout))
+	require.Error(t, limiter.Do("10.0.0.1", func() error { return &soap.SonosRejectedError{Action: "SetEQ", Code: "402"} }))
+	require.Error(t, limiter.Do("10.0.0.1", timeout))
+	require.NoError(t, limiter.Do("10.0.0.1", ok))
+
+	// Consecutive timeouts open the breaker, and calls fail without reaching the device
+	require.Error(t, limiter.Do("10.0.0.1", timeout))
+	require.Error(t, limiter.Do("10.0.0.1", timeout))
+	err := limiter.Do("10.0.0.1", ok)
+	require.ErrorIs(t, err, ErrDeviceUnavailable)
+	require.Equal(t, 1, calls)
+
+	// Other devices are unaffected
+	require.NoError(t, limiter.Do("10.0.0.2", ok))
+
+	stats := limiter.SOAPStats()
+	require.Len(t, stats.Breakers, 2)
+	require.Equal(t, devices.CircuitOpen, stats.Breakers[0].State)
+	require.Equal(t, 2, stats.Breakers[0].ConsecutiveFailures)
+	require.Equal(t, now.Add(30*time.Second), *stats.Breakers[0].OpenUntil)
+	require.Equal(t, devices.CircuitClosed, stats.Breakers[1].State)
+
+	// After the cooldown one more timeout reopens it; a success closes it
+	now = now.Add(31 * time.Second)
+	require.Error(t, limiter.Do("10.0.0.1", timeout))
+	require.ErrorIs(t, limiter.Do("10.0.0.1", ok), ErrDeviceUnavailable)
+
+	now = now.Add(31 * time.Second)
+	require.NoError(t, limiter.Do("10.0.0.1", ok))
+	require.Equal(t, devices.CircuitClosed, limiter.SOAPStats().Breakers[0].State)
+	require.Equal(t, 0, limiter.SOAPStats().Breakers[0].ConsecutiveFailures)
+}
+
+func TestSOAPLimiter_BreakerDisabled(t *testing.T) {
+	limiter := NewSOAPLimiter(LimiterConfig{})
+	for i := 0; i < 5; i++ {
+		require.Error(t, limiter.Do("10.0.0.1", func() error { return &soap.SonosTimeoutError{Action: "GetVolume"} }))
+	}
+	require.NoError(t, limiter.Do("10.0.0.1", func() error { return nil }))
+}
+
+func TestSOAPLimiter_Concurrency(t *testing.T) {
+	limiter := NewSOAPLimiter(LimiterConfig{MaxConcurrent: 3, MaxConcurrentPerDevice: 2})
+
+	var inFlight, maxInFlight, maxDevice int32
+	perDevice := map[string]*int32{"10.0.0.1": new(int32), "10.0.0.2": new(int32), "10.0.0.3": new(int32)}
+	var mu sync.Mutex
+	track := func(current, max *int32) {
+		value := atomic.AddInt32(current, 1)
+		mu.Lock()
+		if value > *max {
+			*max = value
+		}
+		mu.Unlock()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		ip := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}[i%3]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = limiter.Do(ip, func() error {
+				track(&inFlight, &maxInFlight)
+				track(perDevice[ip], &maxDevice)
+				time.Sleep(5 * time.Millisecond)
+				atomic.AddInt32(perDevice[ip], -1)
+				atomic.AddInt32(&inFlight, -1)
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	require.LessOrEqual(t, maxInFlight, int32(3))
+	require.LessOrEqual(t, maxDevice, int32(2))
+	require.Equal(t, 0, limiter.SOAPStats().InFlight)
+}
+
+func TestSOAPLimiter_DoContextStopsWaiting(t *testing.T) {
+	limiter := NewSOAPLimiter(LimiterConfig{MaxConcurrentPerDevice: 1})
+
+	// Hold the device's only slot
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_ = limiter.Do("10.0.0.1", func() error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	called := false
+	err := limiter.DoContext(ctx, "10.0.0.1", func() error {
+		called = true
+		return nil
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.False(t, called)
+	require.Equal(t, 0, limiter.SOAPStats().Breakers[0].ConsecutiveFailures, "waiting isn't a device failure")
+}
+
+func TestSOAPLimiter_Nil(t *testing.T) {
+	var limiter *SOAPLimiter
+	sentinel := errors.New("boom")
+	require.ErrorIs(t, limiter.Do("10.0.0.1", func() error { return sentinel }), sentinel)
+}
@@ -3,6 +3,7 @@ package sonos
 import (
 	"bytes"
 	"encoding/xml"
+	"fmt"
 	"strings"
 )
 
@@ -92,6 +93,14 @@ func ParseDuration(duration string) int {
 	return (hours * 3600) + (minutes * 60) + seconds
 }
 
+// FormatDuration converts seconds to the H:MM:SS format used by AVTransport.
+func FormatDuration(seconds int) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+	return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
 // GetServiceLogoFromName returns a static logo path for known service names.
 func GetServiceLogoFromName(serviceName string) string {
 	name := strings.ToLower(serviceName)
@@ -105,8 +114,8 @@ func GetServiceLogoFromName(serviceName string) string {
 		return "/v1/assets/service-logos/amazon-music.png"
 	case strings.Contains(name, "tunein") || strings.Contains(name, "radiotime"):
 		return "/v1/assets/service-logos/tunein.png"
-	case strings.Contains(name, "pandora"):
-		return "/v1/assets/service-logos/pandora.png"
+	case strings.Contains(name, "pandora") || strings.Contains(name, "iheart"):
+		return "" // No logo asset yet
 	case strings.Contains(name, "deezer"):
 		return "/v1/assets/service-logos/deezer.png"
 	case strings.Contains(name, "tidal"):
@@ -240,6 +249,11 @@ func detectServiceName(trackURI string, metadata string) string {
 	uri := strings.ToLower(trackURI)
 	meta := strings.ToLower(metadata)
 
+	// A radio station's sid names its service; its title might name another
+	if radio := radioServiceForResource(trackURI); radio != nil {
+		return serviceDisplayNames[radio.Service]
+	}
+
 	switch {
 	case strings.Contains(uri, "spotify") || strings.Contains(meta, "spotify"):
 		return "Spotify"
@@ -263,6 +277,8 @@ func detectServiceName(trackURI string, metadata string) string {
 		return "TuneIn"
 	case strings.Contains(uri, "pandora") || strings.Contains(meta, "pandora"):
 		return "Pandora"
+	case strings.Contains(uri, "iheart") || strings.Contains(meta, "iheart"):
+		return "iHeartRadio"
 	case strings.Contains(uri, "deezer") || strings.Contains(meta, "deezer"):
 		return "Deezer"
 	case strings.Contains(uri, "tidal") ||
@@ -0,0 +1,151 @@
+package sonos
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+)
+
+// Mute scopes.
+const (
+	MuteScopeDevice = "device" // Just the given speaker
+	MuteScopeGroup  = "group"  // Every visible speaker in its group
+)
+
+// parseMuteScope validates scope, defaulting to device.
+func parseMuteScope(scope string) (string, error) {
+	switch scope {
+	case "":
+		return MuteScopeDevice, nil
+	case MuteScopeDevice, MuteScopeGroup:
+		return scope, nil
+	default:
+		return "", apperrors.NewValidationError("scope must be device or group", nil)
+	}
+}
+
+// muteTargetIPs returns the speakers a mute request with scope applies to.
+func muteTargetIPs(service *Service, deviceIP, scope string) []string {
+	if scope == MuteScopeGroup {
+		return getGroupMemberIPs(service, deviceIP)
+	}
+	return []string{deviceIP}
+}
+
+// getMuteHandler handles GET /v1/sonos/volume/mute?udn=&scope=. For group scope, muted
+// is true only when every member that responded is muted.
+func getMuteHandler(service *Service) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		udn := r.URL.Query().Get("udn")
+		if udn == "" {
+			return apperrors.NewValidationError("udn query parameter is required", nil)
+		}
+		scope, err := parseMuteScope(r.URL.Query().Get("scope"))
+		if err != nil {
+			return err
+		}
+
+		deviceIP, err := service.ResolveDeviceIP(udn)
+		if err != nil {
+			return deviceFailure(err, udn, "", "Failed to resolve device")
+		}
+
+		deviceMute, err := service.GetMute(deviceIP)
+		if err != nil {
+			return deviceFailure(err, udn, deviceIP, "Failed to fetch mute state")
+		}
+
+		memberIPs := muteTargetIPs(service, deviceIP, scope)
+		others := make([]string, 0, len(memberIPs))
+		for _, ip := range memberIPs {
+			if ip != deviceIP {
+				others = append(others, ip)
+			}
+		}
+		summary := FetchGroupMute(service, &deviceMute, others)
+
+		response := map[string]any{
+			"object":    "mute_state",
+			"udn":       udn,
+			"scope":     scope,
+			"muted":     summary.AllMuted,
+			"any_muted": summary.AnyMuted,
+		}
+		addDebugTargets(r, response, deviceIP, memberIPs)
+
+		return api.WriteResource(w, http.StatusOK, response)
+	}
+}
+
+// setMuteHandler handles POST /v1/sonos/volume/mute.
+func setMuteHandler(service *Service) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var body struct {
+			UDN   string `json:"udn"`
+			Muted *bool  `json:"muted"`
+			Scope string `json:"scope"`
+		}
+		if err := decodeJSON(r, &body); err != nil || body.UDN == "" {
+			return apperrors.NewValidationError("udn is required", nil)
+		}
+		if body.Muted == nil {
+			return apperrors.NewValidationError("muted is required", nil)
+		}
+		scope, err := parseMuteScope(body.Scope)
+		if err != nil {
+			return err
+		}
+
+		deviceIP, err := service.ResolveDeviceIP(body.UDN)
+		if err != nil {
+			return deviceFailure(err, body.UDN, "", "Failed to resolve device")
+		}
+
+		memberIPs := muteTargetIPs(service, deviceIP, scope)
+		results := setMuteOnDevices(r.Context(), service, memberIPs, *body.Muted)
+		succeeded, failed := countResults(results)
+
+		response := map[string]any{
+			"object":          "mute_action",
+			"udn":             body.UDN,
+			"scope":           scope,
+			"muted":           *body.Muted,
+			"all_succeeded":   failed == 0,
+			"succeeded_count": succeeded,
+			"failed_count":    failed,
+			"muted_at":        api.RFC3339Millis(time.Now()),
+		}
+		addDebugTargets(r, response, deviceIP, memberIPs)
+
+		return api.WriteAction(w, http.StatusOK, response)
+	}
+}
+
+// setMuteOnDevices mutes or unmutes each speaker in parallel, through the service's
+// SOAPLimiter.
+func setMuteOnDevices(ctx context.Context, service *Service, memberIPs []string, muted bool) []deviceVolumeResult {
+	results := make([]deviceVolumeResult, len(memberIPs))
+	var wg sync.WaitGroup
+
+	for i, ip := range memberIPs {
+		wg.Add(1)
+		go func(idx int, targetIP string) {
+			defer wg.Done()
+			err := service.Limiter.DoContext(ctx, targetIP, func() error {
+				return service.SetMuteContext(ctx, targetIP, muted)
+			})
+			result := deviceVolumeResult{IP: targetIP, Success: err == nil}
+			if err != nil {
+				result.Error = err.Error()
+			}
+			results[idx] = result
+		}(i, ip)
+	}
+
+	wg.Wait()
+	return results
+}
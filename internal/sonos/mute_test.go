@@ -0,0 +1,20 @@
+package sonos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMuteScope(t *testing.T) {
+	scope, err := parseMuteScope("")
+	require.NoError(t, err)
+	require.Equal(t, MuteScopeDevice, scope)
+
+	scope, err = parseMuteScope("group")
+	require.NoError(t, err)
+	require.Equal(t, MuteScopeGroup, scope)
+
+	_, err = parseMuteScope("household")
+	require.Error(t, err)
+}
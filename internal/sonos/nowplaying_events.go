@@ -0,0 +1,361 @@
+package sonos
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+)
+
+// Now-playing event stream tuning.
+const (
+	// DefaultNowPlayingPollInterval is how often the household is polled for changes
+	// while clients are connected.
+	DefaultNowPlayingPollInterval = 2 * time.Second
+
+	// nowPlayingSendBuffer is how many events may queue for a connection. A client that
+	// falls this far behind is disconnected rather than buffered without bound.
+	nowPlayingSendBuffer = 32
+
+	eventsWriteTimeout = 10 * time.Second
+	eventsPingInterval = 30 * time.Second
+	eventsPongTimeout  = 2 * eventsPingInterval
+)
+
+// Now-playing event types.
+const (
+	NowPlayingEventSnapshot     = "snapshot"      // Every group, sent first on each connection
+	NowPlayingEventGroupUpdated = "group_updated" // A group's playback, volume or membership changed
+	NowPlayingEventGroupRemoved = "group_removed" // A group no longer exists
+)
+
+// NowPlayingEvent is a message pushed to /v1/sonos/events clients. Groups use the same
+// shape as the now-playing endpoint.
+type NowPlayingEvent struct {
+	Type          string           `json:"type"`
+	Groups        []map[string]any `json:"groups,omitempty"`         // snapshot
+	Group         map[string]any   `json:"group,omitempty"`          // group_updated
+	CoordinatorID string           `json:"coordinator_id,omitempty"` // group_removed
+}
+
+// nowPlayingFetcher returns the household's now-playing groups as seen from entryIP.
+type nowPlayingFetcher func(entryIP string) ([]map[string]any, error)
+
+// nowPlayingGroupState is the last pushed state of one group.
+type nowPlayingGroupState struct {
+	group     map[string]any
+	signature string
+}
+
+// nowPlayingSubscriber is one connected client.
+type nowPlayingSubscriber struct {
+	send       chan NowPlayingEvent
+	snapshotOK bool // Whether the client has received its initial snapshot
+}
+
+// NowPlayingHub polls now-playing state while clients are connected and pushes each
+// group that changed to every client, so clients don't each poll the speakers. The poll
+// loop only runs while at least one client is connected.
+type NowPlayingHub struct {
+	fetch    nowPlayingFetcher
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu          sync.Mutex
+	subscribers map[*nowPlayingSubscriber]struct{}
+	entryIP     string
+	order       []string // Coordinator IDs in fetch order
+	groups      map[string]nowPlayingGroupState
+	hasSnapshot bool
+	stop        chan struct{}
+}
+
+// NewNowPlayingHub creates a hub that polls the service every interval.
+func NewNowPlayingHub(service *Service, interval time.Duration, logger *slog.Logger) *NowPlayingHub {
+	return newNowPlayingHub(func(entryIP string) ([]map[string]any, error) {
+		groups, _, err := fetchNowPlayingGroups(service, entryIP, false, false)
+		return groups, err
+	}, interval, logger)
+}
+
+func newNowPlayingHub(fetch nowPlayingFetcher, interval time.Duration, logger *slog.Logger) *NowPlayingHub {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if interval <= 0 {
+		interval = DefaultNowPlayingPollInterval
+	}
+	return &NowPlayingHub{
+		fetch:       fetch,
+		interval:    interval,
+		logger:      logger,
+		subscribers: make(map[*nowPlayingSubscriber]struct{}),
+		groups:      make(map[string]nowPlayingGroupState),
+	}
+}
+
+// subscribe registers a client. Groups are read through entryIP, the most recent
+// client's speaker; every speaker in a household sees the same groups.
+func (h *NowPlayingHub) subscribe(entryIP string) *nowPlayingSubscriber {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub := &nowPlayingSubscriber{send: make(chan NowPlayingEvent, nowPlayingSendBuffer)}
+	h.subscribers[sub] = struct{}{}
+	h.entryIP = entryIP
+
+	if h.hasSnapshot {
+		sub.send <- h.snapshotLocked()
+		sub.snapshotOK = true
+	}
+	if h.stop == nil {
+		h.stop = make(chan struct{})
+		go h.run(h.stop)
+	}
+	return sub
+}
+
+// unsubscribe removes a client and stops polling once none are left.
+func (h *NowPlayingHub) unsubscribe(sub *nowPlayingSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeLocked(sub)
+}
+
+func (h *NowPlayingHub) removeLocked(sub *nowPlayingSubscriber) {
+	if _, ok := h.subscribers[sub]; !ok {
+		return
+	}
+	delete(h.subscribers, sub)
+	close(sub.send)
+
+	if len(h.subscribers) == 0 && h.stop != nil {
+		close(h.stop)
+		h.stop = nil
+		// Nobody is watching, so the state will be stale by the next connection
+		h.order = nil
+		h.groups = make(map[string]nowPlayingGroupState)
+		h.hasSnapshot = false
+	}
+}
+
+// run polls until stop is closed.
+func (h *NowPlayingHub) run(stop chan struct{}) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		h.poll(stop)
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll fetches the groups and pushes what changed since the last poll.
+func (h *NowPlayingHub) poll(stop chan struct{}) {
+	h.mu.Lock()
+	entryIP := h.entryIP
+	h.mu.Unlock()
+
+	groups, err := h.fetch(entryIP)
+	if err != nil {
+		h.logger.Warn("Now-playing events: failed to fetch groups", "error", err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	select {
+	case <-stop:
+		// Every client left while fetching
+		return
+	default:
+	}
+
+	events := h.applyLocked(groups)
+	for sub := range h.subscribers {
+		if !sub.snapshotOK {
+			h.sendLocked(sub, h.snapshotLocked())
+			sub.snapshotOK = true
+			continue
+		}
+		for _, event := range events {
+			if !h.sendLocked(sub, event) {
+				break
+			}
+		}
+	}
+}
+
+// applyLocked records the fetched groups and returns the events describing the change.
+// The first fetch produces no events; clients get it as their snapshot.
+func (h *NowPlayingHub) applyLocked(groups []map[string]any) []NowPlayingEvent {
+	var events []NowPlayingEvent
+
+	order := make([]string, 0, len(groups))
+	current := make(map[string]nowPlayingGroupState, len(groups))
+	for _, group := range groups {
+		coordinatorID, _ := group["coordinator_id"].(string)
+		state := nowPlayingGroupState{group: group, signature: nowPlayingSignature(group)}
+		order = append(order, coordinatorID)
+		current[coordinatorID] = state
+
+		if previous, ok := h.groups[coordinatorID]; h.hasSnapshot && (!ok || previous.signature != state.signature) {
+			events = append(events, NowPlayingEvent{Type: NowPlayingEventGroupUpdated, Group: group})
+		}
+	}
+	if h.hasSnapshot {
+		for _, coordinatorID := range h.order {
+			if _, ok := current[coordinatorID]; !ok {
+				events = append(events, NowPlayingEvent{Type: NowPlayingEventGroupRemoved, CoordinatorID: coordinatorID})
+			}
+		}
+	}
+
+	h.order = order
+	h.groups = current
+	h.hasSnapshot = true
+	return events
+}
+
+func (h *NowPlayingHub) snapshotLocked() NowPlayingEvent {
+	groups := make([]map[string]any, 0, len(h.order))
+	for _, coordinatorID := range h.order {
+		groups = append(groups, h.groups[coordinatorID].group)
+	}
+	return NowPlayingEvent{Type: NowPlayingEventSnapshot, Groups: groups}
+}
+
+// sendLocked queues an event for a client, disconnecting it if its buffer is full.
+func (h *NowPlayingHub) sendLocked(sub *nowPlayingSubscriber, event NowPlayingEvent) bool {
+	select {
+	case sub.send <- event:
+		return true
+	default:
+		h.logger.Warn("Now-playing events: client fell behind, disconnecting", "buffer", nowPlayingSendBuffer)
+		h.removeLocked(sub)
+		return false
+	}
+}
+
+// nowPlayingSignature identifies a group's state for change detection. The track
+// position is left out: it moves every poll while playing, and clients advance it
+// themselves from position_seconds.
+func nowPlayingSignature(group map[string]any) string {
+	stable := group
+	if playback, ok := group["playback"].(map[string]any); ok {
+		if track, ok := playback["track"].(map[string]any); ok {
+			trackCopy := make(map[string]any, len(track))
+			for key, value := range track {
+				if key != "position_seconds" {
+					trackCopy[key] = value
+				}
+			}
+			playbackCopy := make(map[string]any, len(playback))
+			for key, value := range playback {
+				playbackCopy[key] = value
+			}
+			playbackCopy["track"] = trackCopy
+
+			stable = make(map[string]any, len(group))
+			for key, value := range group {
+				stable[key] = value
+			}
+			stable["playback"] = playbackCopy
+		}
+	}
+
+	data, err := json.Marshal(stable)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+var eventsUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool {
+		return true // Native clients don't send a browser origin
+	},
+}
+
+// nowPlayingEventsHandler upgrades GET /v1/sonos/events?udn= to a WebSocket that
+// streams now-playing events until the client disconnects.
+func nowPlayingEventsHandler(service *Service, hub *NowPlayingHub) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		udn := r.URL.Query().Get("udn")
+		if udn == "" {
+			return apperrors.NewValidationError("udn query parameter is required", nil)
+		}
+		entryIP, err := service.ResolveDeviceIP(udn)
+		if err != nil {
+			return deviceFailure(err, udn, "", "Failed to resolve device")
+		}
+
+		conn, err := eventsUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// Upgrade failed - error already written to response
+			return nil
+		}
+
+		sub := hub.subscribe(entryIP)
+		go readNowPlayingClient(conn, hub, sub)
+		writeNowPlayingEvents(conn, sub)
+		return nil
+	}
+}
+
+// readNowPlayingClient discards client messages and unsubscribes when the client
+// disconnects or stops answering pings.
+func readNowPlayingClient(conn *websocket.Conn, hub *NowPlayingHub, sub *nowPlayingSubscriber) {
+	defer hub.unsubscribe(sub)
+
+	conn.SetReadLimit(4096)
+	_ = conn.SetReadDeadline(time.Now().Add(eventsPongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(eventsPongTimeout))
+	})
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// writeNowPlayingEvents sends queued events and keepalive pings until the subscription
+// ends, then closes the connection.
+func writeNowPlayingEvents(conn *websocket.Conn, sub *nowPlayingSubscriber) {
+	ticker := time.NewTicker(eventsPingInterval)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case event, ok := <-sub.send:
+			_ = conn.SetWriteDeadline(time.Now().Add(eventsWriteTimeout))
+			if !ok {
+				_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(eventsWriteTimeout))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
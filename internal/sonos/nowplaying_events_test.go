@@ -0,0 +1,180 @@
+package sonos
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+)
+
+// fakeHousehold serves now-playing groups whose state tests change between polls.
+type fakeHousehold struct {
+	mu     sync.Mutex
+	groups map[string]map[string]any
+	order  []string
+}
+
+func (f *fakeHousehold) set(coordinatorID, state string, position int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.groups[coordinatorID]; !ok {
+		f.order = append(f.order, coordinatorID)
+	}
+	f.groups[coordinatorID] = map[string]any{
+		"coordinator_id": coordinatorID,
+		"room_name":      coordinatorID,
+		"playback": map[string]any{
+			"state":  state,
+			"volume": 20,
+			"track":  map[string]any{"title": "Song", "position_seconds": position},
+		},
+	}
+}
+
+func (f *fakeHousehold) remove(coordinatorID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.groups, coordinatorID)
+	for i, id := range f.order {
+		if id == coordinatorID {
+			f.order = append(f.order[:i], f.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func (f *fakeHousehold) fetch(entryIP string) ([]map[string]any, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	groups := make([]map[string]any, 0, len(f.order))
+	for _, id := range f.order {
+		groups = append(groups, f.groups[id])
+	}
+	return groups, nil
+}
+
+func newTestHub(household *fakeHousehold) *NowPlayingHub {
+	// A long interval leaves polling to the test after the first poll
+	return newNowPlayingHub(household.fetch, time.Hour, logging.Discard())
+}
+
+func receiveEvent(t *testing.T, sub *nowPlayingSubscriber) NowPlayingEvent {
+	t.Helper()
+	select {
+	case event := <-sub.send:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return NowPlayingEvent{}
+	}
+}
+
+func TestNowPlayingHub_PushesChanges(t *testing.T) {
+	household := &fakeHousehold{groups: map[string]map[string]any{}}
+	household.set("RINCON_KITCHEN", "PLAYING", 10)
+	household.set("RINCON_DEN", "STOPPED", 0)
+	hub := newTestHub(household)
+
+	sub := hub.subscribe("10.0.0.1")
+	snapshot := receiveEvent(t, sub)
+	require.Equal(t, NowPlayingEventSnapshot, snapshot.Type)
+	require.Len(t, snapshot.Groups, 2)
+
+	// Position moving on its own isn't a change
+	household.set("RINCON_KITCHEN", "PLAYING", 12)
+	hub.poll(hub.stop)
+	require.Empty(t, sub.send)
+
+	household.set("RINCON_KITCHEN", "PAUSED_PLAYBACK", 12)
+	hub.poll(hub.stop)
+	updated := receiveEvent(t, sub)
+	require.Equal(t, NowPlayingEventGroupUpdated, updated.Type)
+	require.Equal(t, "RINCON_KITCHEN", updated.Group["coordinator_id"])
+	require.Empty(t, sub.send)
+
+	household.remove("RINCON_DEN")
+	hub.poll(hub.stop)
+	removed := receiveEvent(t, sub)
+	require.Equal(t, NowPlayingEvent{Type: NowPlayingEventGroupRemoved, CoordinatorID: "RINCON_DEN"}, removed)
+
+	// A later client starts from the current state
+	late := hub.subscribe("10.0.0.2")
+	lateSnapshot := receiveEvent(t, late)
+	require.Len(t, lateSnapshot.Groups, 1)
+	require.Equal(t, "PAUSED_PLAYBACK", lateSnapshot.Groups[0]["playback"].(map[string]any)["state"])
+
+	hub.unsubscribe(sub)
+	hub.unsubscribe(late)
+	require.Nil(t, hub.stop, "polling stops with the last client")
+	require.False(t, hub.hasSnapshot)
+}
+
+func TestNowPlayingHub_DropsSlowClients(t *testing.T) {
+	household := &fakeHousehold{groups: map[string]map[string]any{}}
+	household.set("RINCON_KITCHEN", "PLAYING", 0)
+	hub := newTestHub(household)
+
+	sub := hub.subscribe("10.0.0.1")
+	require.Eventually(t, func() bool { return len(sub.send) == 1 }, time.Second, 5*time.Millisecond)
+
+	for i := 0; i <= nowPlayingSendBuffer; i++ {
+		state := "PLAYING"
+		if i%2 == 0 {
+			state = "PAUSED_PLAYBACK"
+		}
+		household.set("RINCON_KITCHEN", state, 0)
+		hub.poll(hub.stop)
+	}
+
+	hub.mu.Lock()
+	_, subscribed := hub.subscribers[sub]
+	hub.mu.Unlock()
+	require.False(t, subscribed)
+
+	// The queued events drain, then the closed channel ends the connection
+	for range sub.send {
+	}
+}
+
+func TestNowPlayingEvents_WebSocket(t *testing.T) {
+	household := &fakeHousehold{groups: map[string]map[string]any{}}
+	household.set("RINCON_KITCHEN", "PLAYING", 0)
+	hub := newNowPlayingHub(household.fetch, 10*time.Millisecond, logging.Discard())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := eventsUpgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		sub := hub.subscribe("10.0.0.1")
+		go readNowPlayingClient(conn, hub, sub)
+		writeNowPlayingEvents(conn, sub)
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+
+	var event NowPlayingEvent
+	require.NoError(t, conn.ReadJSON(&event))
+	require.Equal(t, NowPlayingEventSnapshot, event.Type)
+	require.Equal(t, "RINCON_KITCHEN", event.Groups[0]["coordinator_id"])
+
+	household.set("RINCON_KITCHEN", "STOPPED", 0)
+	require.NoError(t, conn.ReadJSON(&event))
+	require.Equal(t, NowPlayingEventGroupUpdated, event.Type)
+	require.Equal(t, "STOPPED", event.Group["playback"].(map[string]any)["state"])
+
+	// Disconnecting unsubscribes and stops polling
+	conn.Close()
+	require.Eventually(t, func() bool {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+		return len(hub.subscribers) == 0 && hub.stop == nil
+	}, time.Second, 5*time.Millisecond)
+}
@@ -23,6 +23,8 @@ type CoordinatorInfo struct {
 	IP               string
 	HdmiCecAvailable bool
 	MemberRooms      []string
+	MemberUUIDs      []string // UUID of each of MemberRooms
+	MemberIPs        []string // Non-coordinator visible members with a known IP
 }
 
 // GroupPlaybackResult combines coordinator info with fetched playback data.
@@ -34,12 +36,17 @@ type GroupPlaybackResult struct {
 // FetchGroupPlayback fetches all playback info for a single group in parallel.
 // It uses smart skipping: if transport state is STOPPED, it skips position info
 // since there's no track progress to report. Media info is always fetched
-// because it's needed for TV mode detection.
+// because it's needed for TV mode detection. Calls go through the service's
+// SOAPLimiter, so an unresponsive coordinator fails fast once its breaker opens.
 func FetchGroupPlayback(svc *Service, coordinatorIP string) GroupPlaybackInfo {
 	result := GroupPlaybackInfo{}
 
 	// First, fetch transport info (needed for smart skipping decision)
-	transport, err := svc.GetTransportInfo(coordinatorIP)
+	var transport soap.TransportInfo
+	err := svc.Limiter.Do(coordinatorIP, func() (err error) {
+		transport, err = svc.GetTransportInfo(coordinatorIP)
+		return err
+	})
 	if err != nil {
 		result.Error = err
 		return result
@@ -59,7 +66,11 @@ func FetchGroupPlayback(svc *Service, coordinatorIP string) GroupPlaybackInfo {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		vol, err := svc.GetVolume(coordinatorIP)
+		var vol soap.VolumeInfo
+		err := svc.Limiter.Do(coordinatorIP, func() (err error) {
+			vol, err = svc.GetVolume(coordinatorIP)
+			return err
+		})
 		mu.Lock()
 		if err == nil {
 			result.VolumeInfo = &vol
@@ -71,7 +82,11 @@ func FetchGroupPlayback(svc *Service, coordinatorIP string) GroupPlaybackInfo {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		mute, err := svc.GetMute(coordinatorIP)
+		var mute soap.MuteInfo
+		err := svc.Limiter.Do(coordinatorIP, func() (err error) {
+			mute, err = svc.GetMute(coordinatorIP)
+			return err
+		})
 		mu.Lock()
 		if err == nil {
 			result.MuteInfo = &mute
@@ -84,7 +99,11 @@ func FetchGroupPlayback(svc *Service, coordinatorIP string) GroupPlaybackInfo {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			pos, err := svc.GetPositionInfo(coordinatorIP)
+			var pos soap.PositionInfo
+			err := svc.Limiter.Do(coordinatorIP, func() (err error) {
+				pos, err = svc.GetPositionInfo(coordinatorIP)
+				return err
+			})
 			mu.Lock()
 			if err == nil {
 				result.PositionInfo = &pos
@@ -97,7 +116,11 @@ func FetchGroupPlayback(svc *Service, coordinatorIP string) GroupPlaybackInfo {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		media, err := svc.GetMediaInfo(coordinatorIP)
+		var media soap.MediaInfo
+		err := svc.Limiter.Do(coordinatorIP, func() (err error) {
+			media, err = svc.GetMediaInfo(coordinatorIP)
+			return err
+		})
 		mu.Lock()
 		if err == nil {
 			result.MediaInfo = &media
@@ -132,6 +155,79 @@ func FetchAllGroupsPlayback(svc *Service, coordinators []CoordinatorInfo) []Grou
 	return results
 }
 
+// GroupMuteSummary combines the mute state of every member of a group.
+type GroupMuteSummary struct {
+	AllMuted bool
+	AnyMuted bool
+}
+
+// SummarizeMute builds a GroupMuteSummary from individual member mute states.
+// An empty input reports neither all nor any muted.
+func SummarizeMute(states []bool) GroupMuteSummary {
+	if len(states) == 0 {
+		return GroupMuteSummary{}
+	}
+	summary := GroupMuteSummary{AllMuted: true}
+	for _, muted := range states {
+		if muted {
+			summary.AnyMuted = true
+		} else {
+			summary.AllMuted = false
+		}
+	}
+	return summary
+}
+
+// FetchGroupMute queries GetMute on each non-coordinator member in parallel and
+// summarizes it together with the coordinator's already-fetched mute state.
+// Members that fail to respond are left out of the summary.
+func FetchGroupMute(svc *Service, coordinatorMute *soap.MuteInfo, memberIPs []string) GroupMuteSummary {
+	states := make([]bool, 0, len(memberIPs)+1)
+	if coordinatorMute != nil {
+		states = append(states, coordinatorMute.CurrentMute)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, ip := range memberIPs {
+		wg.Add(1)
+		go func(memberIP string) {
+			defer wg.Done()
+			var mute soap.MuteInfo
+			err := svc.Limiter.Do(memberIP, func() (err error) {
+				mute, err = svc.GetMute(memberIP)
+				return err
+			})
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			states = append(states, mute.CurrentMute)
+			mu.Unlock()
+		}(ip)
+	}
+	wg.Wait()
+
+	return SummarizeMute(states)
+}
+
+// FetchAllGroupMutes computes the mute summary for every group in parallel.
+func FetchAllGroupMutes(svc *Service, results []HybridGroupResult) []GroupMuteSummary {
+	summaries := make([]GroupMuteSummary, len(results))
+	var wg sync.WaitGroup
+
+	for i, result := range results {
+		wg.Add(1)
+		go func(idx int, group HybridGroupResult) {
+			defer wg.Done()
+			summaries[idx] = FetchGroupMute(svc, group.Playback.MuteInfo, group.Coordinator.MemberIPs)
+		}(i, result)
+	}
+
+	wg.Wait()
+	return summaries
+}
+
 // ExtractCoordinators extracts coordinator info from zone group state.
 // This is a helper function to prepare data for parallel fetching.
 func ExtractCoordinators(zoneState *soap.ZoneGroupState, uuidToIP map[string]string) []CoordinatorInfo {
@@ -165,11 +261,17 @@ func ExtractCoordinators(zoneState *soap.ZoneGroupState, uuidToIP map[string]str
 		}
 
 		memberRooms := make([]string, 0)
+		memberUUIDs := make([]string, 0)
+		memberIPs := make([]string, 0)
 		for _, member := range visibleMembers {
 			if member.IsCoordinator {
 				continue
 			}
 			memberRooms = append(memberRooms, member.ZoneName)
+			memberUUIDs = append(memberUUIDs, member.UUID)
+			if ip := uuidToIP[member.UUID]; ip != "" {
+				memberIPs = append(memberIPs, ip)
+			}
 		}
 
 		coordinators = append(coordinators, CoordinatorInfo{
@@ -178,6 +280,8 @@ func ExtractCoordinators(zoneState *soap.ZoneGroupState, uuidToIP map[string]str
 			IP:               coordinatorIP,
 			HdmiCecAvailable: coordinator.HdmiCecAvailable,
 			MemberRooms:      memberRooms,
+			MemberUUIDs:      memberUUIDs,
+			MemberIPs:        memberIPs,
 		})
 	}
 
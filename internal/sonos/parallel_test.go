@@ -0,0 +1,42 @@
+package sonos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+func TestSummarizeMute(t *testing.T) {
+	require.Equal(t, GroupMuteSummary{}, SummarizeMute(nil))
+	require.Equal(t, GroupMuteSummary{AllMuted: true, AnyMuted: true}, SummarizeMute([]bool{true, true}))
+	require.Equal(t, GroupMuteSummary{AllMuted: false, AnyMuted: true}, SummarizeMute([]bool{false, true}))
+	require.Equal(t, GroupMuteSummary{AllMuted: false, AnyMuted: false}, SummarizeMute([]bool{false, false}))
+}
+
+func TestExtractCoordinatorsIncludesMemberIPs(t *testing.T) {
+	zoneState := &soap.ZoneGroupState{
+		Groups: []soap.ZoneGroup{
+			{
+				ID: "group-1",
+				Members: []soap.ZoneMember{
+					{UUID: "RINCON_A", ZoneName: "Kitchen", Location: "http://192.168.1.10:1400/xml", IsCoordinator: true, IsVisible: true},
+					{UUID: "RINCON_B", ZoneName: "Dining", Location: "http://192.168.1.11:1400/xml", IsVisible: true},
+					{UUID: "RINCON_C", ZoneName: "Sub", Location: "http://192.168.1.12:1400/xml", IsVisible: false},
+				},
+			},
+		},
+	}
+
+	coordinators := ExtractCoordinators(zoneState, BuildUUIDToIPMap(zoneState))
+	require.Len(t, coordinators, 1)
+	require.Equal(t, "192.168.1.10", coordinators[0].IP)
+	require.Equal(t, []string{"Dining"}, coordinators[0].MemberRooms)
+	require.Equal(t, []string{"192.168.1.11"}, coordinators[0].MemberIPs)
+}
+
+func TestFetchGroupMuteWithoutMembersUsesCoordinator(t *testing.T) {
+	summary := FetchGroupMute(nil, &soap.MuteInfo{CurrentMute: true}, nil)
+	require.Equal(t, GroupMuteSummary{AllMuted: true, AnyMuted: true}, summary)
+}
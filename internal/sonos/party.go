@@ -0,0 +1,317 @@
+package sonos
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// partyClient is the subset of Service used to start party mode and restore the groups.
+type partyClient interface {
+	PlaybackStateClient
+	SetAVTransportURI(deviceIP string, uri string) error
+	BecomeCoordinatorOfStandaloneGroup(deviceIP string) error
+}
+
+// PartyMemberResult is what joining or regrouping did to one speaker.
+type PartyMemberResult struct {
+	UDN     string `json:"udn"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// PartyGroupResult is how one of the original groups was put back.
+type PartyGroupResult struct {
+	CoordinatorUDN   string              `json:"coordinator_udn"`
+	MemberResults    []PartyMemberResult `json:"member_results"`
+	PlaybackRestored bool                `json:"playback_restored"`
+	Error            string              `json:"error,omitempty"`
+}
+
+// succeeded reports whether the group was fully put back.
+func (result *PartyGroupResult) succeeded() bool {
+	if result.Error != "" {
+		return false
+	}
+	for _, member := range result.MemberResults {
+		if !member.Success {
+			return false
+		}
+	}
+	return true
+}
+
+// capturePartyGroups records each group's visible members and what its coordinator is
+// playing. A coordinator whose playback can't be captured is kept without it, so the
+// grouping can still be put back.
+func capturePartyGroups(client partyClient, zoneState *soap.ZoneGroupState, uuidToIP map[string]string) ([]PartyGroupSnapshot, []error) {
+	groups := make([]PartyGroupSnapshot, 0, len(zoneState.Groups))
+	var captureErrs []error
+	for _, group := range zoneState.Groups {
+		if !memberVisible(group, group.Coordinator) {
+			continue
+		}
+		snapshot := PartyGroupSnapshot{CoordinatorUDN: group.Coordinator, MemberUDNs: []string{}}
+		for _, member := range group.Members {
+			if member.IsVisible && member.UUID != group.Coordinator {
+				snapshot.MemberUDNs = append(snapshot.MemberUDNs, member.UUID)
+			}
+		}
+		if ip := uuidToIP[group.Coordinator]; ip != "" {
+			playback, err := CapturePlaybackSnapshot(client, ip, group.Coordinator)
+			if err != nil {
+				captureErrs = append(captureErrs, fmt.Errorf("%s: %w", group.Coordinator, err))
+			}
+			snapshot.Playback = playback
+		}
+		groups = append(groups, snapshot)
+	}
+	return groups, captureErrs
+}
+
+// memberVisible reports whether the group has a visible member with uuid.
+func memberVisible(group soap.ZoneGroup, uuid string) bool {
+	for _, member := range group.Members {
+		if member.UUID == uuid {
+			return member.IsVisible
+		}
+	}
+	return false
+}
+
+// joinParty joins every visible speaker to the one with coordinatorUUID. When that
+// speaker is a member of another group it leaves first, and fails the party if it can't.
+// Speakers already in its group are left as they are.
+func joinParty(client partyClient, zoneState *soap.ZoneGroupState, uuidToIP map[string]string, coordinatorUUID string) ([]PartyMemberResult, error) {
+	coordinatorIP := uuidToIP[coordinatorUUID]
+	for _, group := range zoneState.Groups {
+		if group.Coordinator != coordinatorUUID && memberVisible(group, coordinatorUUID) {
+			if err := client.BecomeCoordinatorOfStandaloneGroup(coordinatorIP); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	results := []PartyMemberResult{}
+	for _, group := range zoneState.Groups {
+		for _, member := range group.Members {
+			if !member.IsVisible || member.UUID == coordinatorUUID {
+				continue
+			}
+			result := PartyMemberResult{UDN: member.UUID, Success: true}
+			if group.Coordinator != coordinatorUUID {
+				result = joinGroup(client, uuidToIP[member.UUID], member.UUID, coordinatorUUID)
+			}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// joinGroup makes the speaker with udn a member of the group coordinated by coordinatorUUID.
+func joinGroup(client partyClient, deviceIP, udn, coordinatorUUID string) PartyMemberResult {
+	if deviceIP == "" {
+		return PartyMemberResult{UDN: udn, Error: "Unable to resolve device"}
+	}
+	if err := client.SetAVTransportURI(deviceIP, "x-rincon:"+coordinatorUUID); err != nil {
+		return PartyMemberResult{UDN: udn, Error: err.Error()}
+	}
+	return PartyMemberResult{UDN: udn, Success: true}
+}
+
+// restoreParty re-creates the groups in snapshot and resumes what each was playing.
+// The other coordinators leave the party first, then the members rejoin their
+// coordinators, with the party's coordinator last in case it was a member itself.
+// Speakers that weren't in the snapshot stay with the party's coordinator.
+func restoreParty(client partyClient, snapshot *PartySnapshot, uuidToIP map[string]string) []PartyGroupResult {
+	results := make([]PartyGroupResult, len(snapshot.Groups))
+	for i, group := range snapshot.Groups {
+		results[i] = PartyGroupResult{CoordinatorUDN: group.CoordinatorUDN, MemberResults: []PartyMemberResult{}}
+		ip := uuidToIP[group.CoordinatorUDN]
+		if ip == "" {
+			results[i].Error = "Unable to resolve device"
+			continue
+		}
+		if group.CoordinatorUDN == snapshot.CoordinatorUDN {
+			continue
+		}
+		if err := client.BecomeCoordinatorOfStandaloneGroup(ip); err != nil {
+			results[i].Error = err.Error()
+		}
+	}
+
+	partyCoordinatorGroup := -1
+	for i, group := range snapshot.Groups {
+		if results[i].Error != "" {
+			continue
+		}
+		for _, memberUDN := range group.MemberUDNs {
+			if memberUDN == snapshot.CoordinatorUDN {
+				partyCoordinatorGroup = i
+				continue
+			}
+			results[i].MemberResults = append(results[i].MemberResults,
+				joinGroup(client, uuidToIP[memberUDN], memberUDN, group.CoordinatorUDN))
+		}
+	}
+	if partyCoordinatorGroup >= 0 {
+		result := &results[partyCoordinatorGroup]
+		result.MemberResults = append(result.MemberResults, joinGroup(client,
+			uuidToIP[snapshot.CoordinatorUDN], snapshot.CoordinatorUDN, result.CoordinatorUDN))
+	}
+
+	for i, group := range snapshot.Groups {
+		if results[i].Error != "" || group.Playback == nil {
+			continue
+		}
+		if err := restorePartyPlayback(client, uuidToIP[group.CoordinatorUDN], group.Playback); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].PlaybackRestored = true
+	}
+	return results
+}
+
+// restorePartyPlayback puts a coordinator's playback back. Unlike after a routine, TV
+// audio and line-in are re-selected too: the party only moved the speaker off them.
+func restorePartyPlayback(client partyClient, deviceIP string, snapshot *PlaybackSnapshot) error {
+	if snapshot.SkipReason == SnapshotSkipTV || snapshot.SkipReason == SnapshotSkipLineIn {
+		input := *snapshot
+		input.SkipReason = ""
+		snapshot = &input
+	}
+	return RestorePlaybackSnapshot(client, deviceIP, snapshot)
+}
+
+// startPartyHandler handles POST /v1/sonos/groups/party. It snapshots the current groups
+// and playback, then joins every visible speaker to coordinator_udn. The coordinator
+// keeps playing whatever it was, unless it had to leave another group first.
+func startPartyHandler(service *Service) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var body struct {
+			CoordinatorUDN string `json:"coordinator_udn"`
+		}
+		if err := decodeJSON(r, &body); err != nil || body.CoordinatorUDN == "" {
+			return apperrors.NewValidationError("coordinator_udn is required", nil)
+		}
+		if service.PartySnapshots == nil {
+			return apperrors.NewAppError("SERVICE_UNAVAILABLE", "Party snapshots not available", 503, nil, nil)
+		}
+
+		coordinatorIP, err := service.ResolveDeviceIP(body.CoordinatorUDN)
+		if err != nil {
+			return deviceFailure(err, body.CoordinatorUDN, "", "Failed to resolve coordinator device")
+		}
+		zoneState, err := service.GetZoneGroupState(coordinatorIP)
+		if err != nil {
+			return deviceFailure(err, body.CoordinatorUDN, coordinatorIP, "Failed to fetch zone group state")
+		}
+
+		uuidToIP := BuildUUIDToIPMap(&zoneState)
+		coordinatorUUID := ""
+		for uuid, ip := range uuidToIP {
+			if ip == coordinatorIP {
+				coordinatorUUID = uuid
+				break
+			}
+		}
+		if coordinatorUUID == "" {
+			return apperrors.NewValidationError("Could not determine coordinator UUID", nil)
+		}
+
+		logger := logging.From(r.Context(), nil)
+		groups, captureErrs := capturePartyGroups(service, &zoneState, uuidToIP)
+		for _, err := range captureErrs {
+			logger.Warn("Failed to snapshot playback for party", "error", err)
+		}
+		snapshot, err := service.PartySnapshots.Create(coordinatorUUID, groups)
+		if err != nil {
+			logger.Error("Failed to save party snapshot", "error", err)
+			return apperrors.NewInternalError("Failed to save party snapshot")
+		}
+
+		memberResults, err := joinParty(service, &zoneState, uuidToIP, coordinatorUUID)
+		if err != nil {
+			return deviceFailure(err, body.CoordinatorUDN, coordinatorIP, "Failed to make coordinator standalone")
+		}
+		allSucceeded := true
+		for _, result := range memberResults {
+			allSucceeded = allSucceeded && result.Success
+		}
+
+		return api.WriteAction(w, http.StatusOK, map[string]any{
+			"object":           "party_action",
+			"snapshot_id":      snapshot.SnapshotID,
+			"coordinator_udn":  body.CoordinatorUDN,
+			"coordinator_uuid": coordinatorUUID,
+			"groups_captured":  len(groups),
+			"member_results":   memberResults,
+			"all_succeeded":    allSucceeded,
+			"expires_at":       api.RFC3339Millis(snapshot.ExpiresAt),
+		})
+	}
+}
+
+// restorePartyHandler handles POST /v1/sonos/groups/restore/{snapshot_id}. The snapshot
+// is deleted once every group is restored; after a partial failure it's kept, so the
+// restore can be retried until it expires.
+func restorePartyHandler(service *Service) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		snapshotID := chi.URLParam(r, "snapshot_id")
+		if service.PartySnapshots == nil {
+			return apperrors.NewAppError("SERVICE_UNAVAILABLE", "Party snapshots not available", 503, nil, nil)
+		}
+
+		logger := logging.From(r.Context(), nil)
+		snapshot, err := service.PartySnapshots.Get(snapshotID)
+		if errors.Is(err, ErrPartySnapshotNotFound) {
+			return apperrors.NewNotFoundResource("Party snapshot", snapshotID)
+		}
+		if err != nil {
+			logger.Error("Failed to load party snapshot", "snapshot_id", snapshotID, "error", err)
+			return apperrors.NewInternalError("Failed to load party snapshot")
+		}
+
+		entryIP := service.EntryDeviceIP()
+		if entryIP == "" {
+			if entryIP, err = service.ResolveDeviceIP(snapshot.CoordinatorUDN); err != nil {
+				return deviceFailure(err, snapshot.CoordinatorUDN, "", "Failed to resolve device")
+			}
+		}
+		zoneState, err := service.GetZoneGroupState(entryIP)
+		if err != nil {
+			return deviceFailure(err, snapshot.CoordinatorUDN, entryIP, "Failed to fetch zone group state")
+		}
+
+		groupResults := restoreParty(service, snapshot, BuildUUIDToIPMap(&zoneState))
+		allSucceeded := true
+		for i := range groupResults {
+			allSucceeded = allSucceeded && groupResults[i].succeeded()
+		}
+
+		snapshotKept := !allSucceeded
+		if allSucceeded {
+			if err := service.PartySnapshots.Delete(snapshotID); err != nil {
+				// The groups are back; the snapshot expires on its own
+				logger.Warn("Failed to delete party snapshot", "snapshot_id", snapshotID, "error", err)
+				snapshotKept = true
+			}
+		}
+
+		return api.WriteAction(w, http.StatusOK, map[string]any{
+			"object":        "party_restore",
+			"snapshot_id":   snapshotID,
+			"group_results": groupResults,
+			"all_succeeded": allSucceeded,
+			"snapshot_kept": snapshotKept,
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package sonos
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PartySnapshotTTL is how long a party snapshot can be restored.
+const PartySnapshotTTL = 24 * time.Hour
+
+// ErrPartySnapshotNotFound is returned when a party snapshot doesn't exist or has expired.
+var ErrPartySnapshotNotFound = errors.New("party snapshot not found")
+
+// DBPair interface for dependency injection (matches db.DBPair).
+type DBPair interface {
+	Reader() *sql.DB
+	Writer() *sql.DB
+}
+
+// PartyGroupSnapshot is one group as it was before party mode.
+type PartyGroupSnapshot struct {
+	CoordinatorUDN string            `json:"coordinator_udn"`
+	MemberUDNs     []string          `json:"member_udns"`        // Visible members other than the coordinator
+	Playback       *PlaybackSnapshot `json:"playback,omitempty"` // The coordinator's playback; nil when it couldn't be captured
+}
+
+// PartySnapshot is the household's grouping and playback before party mode.
+type PartySnapshot struct {
+	SnapshotID     string
+	CoordinatorUDN string // The party's coordinator
+	Groups         []PartyGroupSnapshot
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+}
+
+// PartySnapshotsRepository stores party snapshots, so a hub restart between starting a
+// party and restoring doesn't lose the original groups.
+type PartySnapshotsRepository struct {
+	reader *sql.DB
+	writer *sql.DB
+	now    func() time.Time
+}
+
+// NewPartySnapshotsRepository creates a party snapshot repository.
+func NewPartySnapshotsRepository(dbPair DBPair) *PartySnapshotsRepository {
+	return &PartySnapshotsRepository{
+		reader: dbPair.Reader(),
+		writer: dbPair.Writer(),
+		now:    time.Now,
+	}
+}
+
+// Create stores a snapshot of groups that expires after PartySnapshotTTL. Expired
+// snapshots are deleted first.
+func (r *PartySnapshotsRepository) Create(coordinatorUDN string, groups []PartyGroupSnapshot) (*PartySnapshot, error) {
+	now := r.now().UTC().Truncate(time.Second)
+	if _, err := r.writer.Exec(`DELETE FROM party_snapshots WHERE expires_at <= ?`, now.Format(time.RFC3339)); err != nil {
+		return nil, fmt.Errorf("failed to delete expired party snapshots: %w", err)
+	}
+
+	snapshot := &PartySnapshot{
+		SnapshotID:     uuid.NewString(),
+		CoordinatorUDN: coordinatorUDN,
+		Groups:         groups,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(PartySnapshotTTL),
+	}
+	groupsJSON, err := json.Marshal(groups)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.writer.Exec(`
+		INSERT INTO party_snapshots (snapshot_id, coordinator_udn, groups, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, snapshot.SnapshotID, coordinatorUDN, string(groupsJSON),
+		snapshot.CreatedAt.Format(time.RFC3339), snapshot.ExpiresAt.Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Get returns a snapshot, or ErrPartySnapshotNotFound when it doesn't exist or has expired.
+func (r *PartySnapshotsRepository) Get(snapshotID string) (*PartySnapshot, error) {
+	var (
+		snapshot   PartySnapshot
+		groupsJSON string
+		createdAt  string
+		expiresAt  string
+	)
+	err := r.reader.QueryRow(`
+		SELECT snapshot_id, coordinator_udn, groups, created_at, expires_at
+		FROM party_snapshots WHERE snapshot_id = ? AND expires_at > ?
+	`, snapshotID, r.now().UTC().Format(time.RFC3339)).Scan(&snapshot.SnapshotID, &snapshot.CoordinatorUDN, &groupsJSON, &createdAt, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPartySnapshotNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(groupsJSON), &snapshot.Groups); err != nil {
+		return nil, fmt.Errorf("party snapshot %s groups: %w", snapshot.SnapshotID, err)
+	}
+	snapshot.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	snapshot.ExpiresAt, _ = time.Parse(time.RFC3339, expiresAt)
+	return &snapshot, nil
+}
+
+// Delete removes a snapshot once it has been restored.
+func (r *PartySnapshotsRepository) Delete(snapshotID string) error {
+	_, err := r.writer.Exec(`DELETE FROM party_snapshots WHERE snapshot_id = ?`, snapshotID)
+	return err
+}
@@ -0,0 +1,229 @@
+package sonos
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/db"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// fakePartyClient is a household of speakers that records the commands they receive.
+type fakePartyClient struct {
+	uris   map[string]string // IP -> current transport URI
+	states map[string]string // IP -> transport state
+	failIP string
+	calls  []string
+}
+
+func (f *fakePartyClient) GetMediaInfo(deviceIP string) (soap.MediaInfo, error) {
+	if deviceIP == f.failIP {
+		return soap.MediaInfo{}, errors.New("timeout")
+	}
+	return soap.MediaInfo{CurrentURI: f.uris[deviceIP]}, nil
+}
+
+func (f *fakePartyClient) GetPositionInfo(deviceIP string) (soap.PositionInfo, error) {
+	return soap.PositionInfo{Track: 2, RelTime: "0:00:30"}, nil
+}
+
+func (f *fakePartyClient) GetTransportInfo(deviceIP string) (soap.TransportInfo, error) {
+	return soap.TransportInfo{CurrentTransportState: f.states[deviceIP]}, nil
+}
+
+func (f *fakePartyClient) GetVolume(deviceIP string) (soap.VolumeInfo, error) {
+	return soap.VolumeInfo{CurrentVolume: 25}, nil
+}
+
+func (f *fakePartyClient) SetAVTransportURIWithMetadata(deviceIP, uri, metadata string) error {
+	f.calls = append(f.calls, fmt.Sprintf("uri %s %s", deviceIP, uri))
+	return nil
+}
+
+func (f *fakePartyClient) SetAVTransportURI(deviceIP string, uri string) error {
+	f.calls = append(f.calls, fmt.Sprintf("uri %s %s", deviceIP, uri))
+	return nil
+}
+
+func (f *fakePartyClient) Seek(deviceIP, unit, target string) error {
+	f.calls = append(f.calls, fmt.Sprintf("seek %s %s %s", deviceIP, unit, target))
+	return nil
+}
+
+func (f *fakePartyClient) SetVolume(deviceIP string, level int) error {
+	f.calls = append(f.calls, fmt.Sprintf("volume %s %d", deviceIP, level))
+	return nil
+}
+
+func (f *fakePartyClient) Play(deviceIP string) error {
+	f.calls = append(f.calls, "play "+deviceIP)
+	return nil
+}
+
+func (f *fakePartyClient) BecomeCoordinatorOfStandaloneGroup(deviceIP string) error {
+	if deviceIP == f.failIP {
+		return errors.New("timeout")
+	}
+	f.calls = append(f.calls, "standalone "+deviceIP)
+	return nil
+}
+
+// partyZoneState is the kitchen grouped with the den, the office grouped with the
+// bedroom and its subwoofer, and the TV room on its own.
+func partyZoneState() soap.ZoneGroupState {
+	return soap.ZoneGroupState{Groups: []soap.ZoneGroup{
+		{Coordinator: "RINCON_KITCHEN", Members: []soap.ZoneMember{
+			{UUID: "RINCON_KITCHEN", Location: "http://10.0.0.1:1400/xml", IsCoordinator: true, IsVisible: true},
+			{UUID: "RINCON_DEN", Location: "http://10.0.0.2:1400/xml", IsVisible: true},
+		}},
+		{Coordinator: "RINCON_OFFICE", Members: []soap.ZoneMember{
+			{UUID: "RINCON_OFFICE", Location: "http://10.0.0.3:1400/xml", IsCoordinator: true, IsVisible: true},
+			{UUID: "RINCON_BEDROOM", Location: "http://10.0.0.4:1400/xml", IsVisible: true},
+			{UUID: "RINCON_SUB", Location: "http://10.0.0.5:1400/xml", IsSubwoofer: true},
+		}},
+		{Coordinator: "RINCON_TV", Members: []soap.ZoneMember{
+			{UUID: "RINCON_TV", Location: "http://10.0.0.6:1400/xml", IsCoordinator: true, IsVisible: true},
+		}},
+	}}
+}
+
+func TestPartyMode(t *testing.T) {
+	zoneState := partyZoneState()
+	uuidToIP := BuildUUIDToIPMap(&zoneState)
+	newClient := func() *fakePartyClient {
+		return &fakePartyClient{
+			uris: map[string]string{
+				"10.0.0.1": "x-rincon-queue:RINCON_KITCHEN#0",
+				"10.0.0.3": "x-sonosapi-stream:s1234",
+				"10.0.0.6": "x-sonos-htastream:RINCON_TV:spdif",
+			},
+			states: map[string]string{"10.0.0.1": "PLAYING", "10.0.0.3": "PAUSED_PLAYBACK", "10.0.0.6": "PLAYING"},
+		}
+	}
+
+	t.Run("captures each group", func(t *testing.T) {
+		groups, errs := capturePartyGroups(newClient(), &zoneState, uuidToIP)
+		require.Empty(t, errs)
+		require.Len(t, groups, 3)
+		require.Equal(t, []string{"RINCON_DEN"}, groups[0].MemberUDNs)
+		require.Equal(t, []string{"RINCON_BEDROOM"}, groups[1].MemberUDNs, "hidden speakers follow their room")
+		require.Equal(t, 2, groups[0].Playback.Track)
+		require.Equal(t, SnapshotSkipTV, groups[2].Playback.SkipReason)
+	})
+
+	t.Run("keeps groups whose playback can't be captured", func(t *testing.T) {
+		client := newClient()
+		client.failIP = "10.0.0.3"
+		groups, errs := capturePartyGroups(client, &zoneState, uuidToIP)
+		require.Len(t, errs, 1)
+		require.Nil(t, groups[1].Playback)
+		require.Equal(t, []string{"RINCON_BEDROOM"}, groups[1].MemberUDNs)
+	})
+
+	t.Run("joins everyone to a member after it leaves its group", func(t *testing.T) {
+		client := newClient()
+		results, err := joinParty(client, &zoneState, uuidToIP, "RINCON_BEDROOM")
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"standalone 10.0.0.4",
+			"uri 10.0.0.1 x-rincon:RINCON_BEDROOM",
+			"uri 10.0.0.2 x-rincon:RINCON_BEDROOM",
+			"uri 10.0.0.3 x-rincon:RINCON_BEDROOM",
+			"uri 10.0.0.6 x-rincon:RINCON_BEDROOM",
+		}, client.calls)
+		require.Len(t, results, 4)
+	})
+
+	t.Run("leaves the coordinator's own members in place", func(t *testing.T) {
+		client := newClient()
+		results, err := joinParty(client, &zoneState, uuidToIP, "RINCON_KITCHEN")
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"uri 10.0.0.3 x-rincon:RINCON_KITCHEN",
+			"uri 10.0.0.4 x-rincon:RINCON_KITCHEN",
+			"uri 10.0.0.6 x-rincon:RINCON_KITCHEN",
+		}, client.calls)
+		require.Equal(t, PartyMemberResult{UDN: "RINCON_DEN", Success: true}, results[0])
+	})
+
+	t.Run("restores the groups and playback", func(t *testing.T) {
+		groups, _ := capturePartyGroups(newClient(), &zoneState, uuidToIP)
+		client := newClient()
+		results := restoreParty(client, &PartySnapshot{CoordinatorUDN: "RINCON_BEDROOM", Groups: groups}, uuidToIP)
+		require.Equal(t, []string{
+			"standalone 10.0.0.1",
+			"standalone 10.0.0.3",
+			"standalone 10.0.0.6",
+			"uri 10.0.0.2 x-rincon:RINCON_KITCHEN",
+			"uri 10.0.0.4 x-rincon:RINCON_OFFICE",
+			"uri 10.0.0.1 x-rincon-queue:RINCON_KITCHEN#0",
+			"seek 10.0.0.1 TRACK_NR 2",
+			"seek 10.0.0.1 REL_TIME 0:00:30",
+			"volume 10.0.0.1 25",
+			"play 10.0.0.1",
+			"uri 10.0.0.3 x-sonosapi-stream:s1234",
+			"volume 10.0.0.3 25",
+			"uri 10.0.0.6 x-sonos-htastream:RINCON_TV:spdif",
+			"volume 10.0.0.6 25",
+			"play 10.0.0.6",
+		}, client.calls)
+		for i := range results {
+			require.True(t, results[i].succeeded(), results[i].CoordinatorUDN)
+			require.True(t, results[i].PlaybackRestored)
+		}
+	})
+
+	t.Run("a coordinator that can't leave keeps its members", func(t *testing.T) {
+		groups, _ := capturePartyGroups(newClient(), &zoneState, uuidToIP)
+		client := newClient()
+		client.failIP = "10.0.0.3"
+		results := restoreParty(client, &PartySnapshot{CoordinatorUDN: "RINCON_KITCHEN", Groups: groups}, uuidToIP)
+		require.False(t, results[1].succeeded())
+		require.NotContains(t, client.calls, "uri 10.0.0.4 x-rincon:RINCON_OFFICE")
+		require.True(t, results[0].succeeded())
+	})
+}
+
+func TestPartySnapshotsRepository(t *testing.T) {
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	repo := NewPartySnapshotsRepository(dbPair)
+	repo.now = func() time.Time { return now }
+
+	groups := []PartyGroupSnapshot{{
+		CoordinatorUDN: "RINCON_KITCHEN",
+		MemberUDNs:     []string{"RINCON_DEN"},
+		Playback:       &PlaybackSnapshot{UDN: "RINCON_KITCHEN", TransportURI: "x-rincon-queue:RINCON_KITCHEN#0", Track: 3, Volume: 20},
+	}}
+	created, err := repo.Create("RINCON_KITCHEN", groups)
+	require.NoError(t, err)
+	require.Equal(t, now.Add(PartySnapshotTTL), created.ExpiresAt)
+
+	loaded, err := repo.Get(created.SnapshotID)
+	require.NoError(t, err)
+	require.Equal(t, created, loaded)
+
+	// Expired snapshots can't be restored, and are deleted by the next party
+	now = now.Add(PartySnapshotTTL)
+	_, err = repo.Get(created.SnapshotID)
+	require.ErrorIs(t, err, ErrPartySnapshotNotFound)
+
+	next, err := repo.Create("RINCON_DEN", nil)
+	require.NoError(t, err)
+	var count int
+	require.NoError(t, dbPair.Reader().QueryRow(`SELECT COUNT(*) FROM party_snapshots`).Scan(&count))
+	require.Equal(t, 1, count)
+
+	require.NoError(t, repo.Delete(next.SnapshotID))
+	_, err = repo.Get(next.SnapshotID)
+	require.ErrorIs(t, err, ErrPartySnapshotNotFound)
+}
@@ -3,10 +3,12 @@ package sonos
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/strefethen/sonos-hub-go/internal/devices"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
 	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
 )
 
@@ -42,6 +44,7 @@ type PlayFavoriteResponse struct {
 	ServiceLogoURL string    `json:"service_logo_url,omitempty"`
 	StartedAt      time.Time `json:"started_at"`
 	WasUngrouped   bool      `json:"was_ungrouped"`
+	PlaybackDetail
 }
 
 // PlayContentRequest represents a request to play direct content
@@ -66,6 +69,43 @@ type PlayContentResponse struct {
 	ContentType   string    `json:"content_type,omitempty"`
 	ContentID     string    `json:"content_id,omitempty"`
 	Title         string    `json:"title,omitempty"`
+	PlaybackDetail
+}
+
+// PlaybackDetail reports what was actually sent to the device so a wrong playback
+// can be traced to the URI the server built. The full DIDL metadata is only kept
+// for debug responses; see StripDebug.
+type PlaybackDetail struct {
+	PlaybackURI             string `json:"playback_uri,omitempty"`
+	PlaybackMetadataSummary string `json:"playback_metadata_summary,omitempty"`
+	PlaybackMetadata        string `json:"_playback_metadata,omitempty"`
+}
+
+// playbackMetadataSummaryLength caps the DIDL excerpt included in play responses.
+const playbackMetadataSummaryLength = 200
+
+// newPlaybackDetail builds the playback detail for resolved content.
+func newPlaybackDetail(playable *PlayableContent) PlaybackDetail {
+	return PlaybackDetail{
+		PlaybackURI:             playable.URI,
+		PlaybackMetadataSummary: summarizePlaybackMetadata(playable.Metadata),
+		PlaybackMetadata:        playable.Metadata,
+	}
+}
+
+// StripDebug drops the full metadata, leaving the URI and the truncated summary.
+func (d *PlaybackDetail) StripDebug() {
+	d.PlaybackMetadata = ""
+}
+
+// summarizePlaybackMetadata collapses whitespace in DIDL metadata and truncates it.
+func summarizePlaybackMetadata(metadata string) string {
+	summary := strings.Join(strings.Fields(metadata), " ")
+	runes := []rune(summary)
+	if len(runes) <= playbackMetadataSummaryLength {
+		return summary
+	}
+	return string(runes[:playbackMetadataSummaryLength]) + "..."
 }
 
 // ValidateContentRequest represents a request to validate content
@@ -94,11 +134,11 @@ type PlayService struct {
 	deviceService   *devices.Service
 	contentResolver *ContentResolver
 	timeout         time.Duration
-	logger          *log.Logger
+	logger          *slog.Logger
 }
 
 // NewPlayService creates a new PlayService
-func NewPlayService(soapClient *soap.Client, deviceService *devices.Service, timeout time.Duration, logger *log.Logger) *PlayService {
+func NewPlayService(soapClient *soap.Client, deviceService *devices.Service, timeout time.Duration, logger *slog.Logger) *PlayService {
 	return &PlayService{
 		soapClient:      soapClient,
 		deviceService:   deviceService,
@@ -108,6 +148,16 @@ func NewPlayService(soapClient *soap.Client, deviceService *devices.Service, tim
 	}
 }
 
+// SetFavoritesProvider makes favorite playback look up favorites through the given provider.
+func (s *PlayService) SetFavoritesProvider(provider FavoritesProvider) {
+	s.contentResolver.SetFavoritesProvider(provider)
+}
+
+// SetLocalFileProvider enables playing local_file content from the hub's audio directory.
+func (s *PlayService) SetLocalFileProvider(provider LocalFileProvider) {
+	s.contentResolver.SetLocalFileProvider(provider)
+}
+
 // resolveDeviceIP resolves the IP address for a device
 func (s *PlayService) resolveDeviceIP(udn *string, ip *string) (string, string, error) {
 	if ip != nil && *ip != "" {
@@ -169,7 +219,7 @@ func (s *PlayService) PlayFavorite(ctx context.Context, req PlayFavoriteRequest)
 	wasUngrouped := false
 	if req.GroupBehavior != nil && *req.GroupBehavior == GroupBehaviorUngroupAndPlay {
 		if err := s.soapClient.BecomeCoordinatorOfStandaloneGroup(ctx, deviceIP); err != nil {
-			s.logf("Warning: failed to ungroup device: %v", err)
+			s.warn(ctx, "Failed to ungroup device", "ip", deviceIP, "error", err)
 		} else {
 			wasUngrouped = true
 		}
@@ -194,7 +244,7 @@ func (s *PlayService) PlayFavorite(ctx context.Context, req PlayFavoriteRequest)
 		}
 
 		// Set transport URI to queue
-		queueURI := fmt.Sprintf("x-rincon-queue:%s#0", s.getDeviceUUID(ctx, deviceIP))
+		queueURI := QueueURI(s.getDeviceUUID(ctx, deviceIP))
 		if err := s.soapClient.SetAVTransportURI(ctx, deviceIP, queueURI, ""); err != nil {
 			return nil, fmt.Errorf("failed to set transport URI: %w", err)
 		}
@@ -212,13 +262,14 @@ func (s *PlayService) PlayFavorite(ctx context.Context, req PlayFavoriteRequest)
 
 	// Build response
 	response := &PlayFavoriteResponse{
-		Object:        "play_favorite_action",
-		UDN:           udn,
-		FavoriteID:    req.FavoriteID,
-		FavoriteTitle: playable.Title,
-		ContentType:   playable.ContentType,
-		StartedAt:     time.Now().UTC(),
-		WasUngrouped:  wasUngrouped,
+		Object:         "play_favorite_action",
+		UDN:            udn,
+		FavoriteID:     req.FavoriteID,
+		FavoriteTitle:  playable.Title,
+		ContentType:    playable.ContentType,
+		StartedAt:      time.Now().UTC(),
+		WasUngrouped:   wasUngrouped,
+		PlaybackDetail: newPlaybackDetail(playable),
 	}
 
 	if playable.Service != "" {
@@ -248,8 +299,8 @@ func (s *PlayService) PlayContent(ctx context.Context, req PlayContentRequest) (
 	}
 
 	// Stations can only use REPLACE_AND_PLAY
-	if req.Content.ContentType != nil && (*req.Content.ContentType == "station" || *req.Content.ContentType == "radio") {
-		if queueMode != QueueModeReplaceAndPlay {
+	if req.Content.ContentType != nil {
+		if contentType := normalizeContentType(*req.Content.ContentType); (contentType == "station" || contentType == "radio") && queueMode != QueueModeReplaceAndPlay {
 			return nil, fmt.Errorf("stations can only use REPLACE_AND_PLAY queue mode")
 		}
 	}
@@ -270,7 +321,7 @@ func (s *PlayService) PlayContent(ctx context.Context, req PlayContentRequest) (
 	wasUngrouped := false
 	if groupBehavior == GroupBehaviorUngroupAndPlay {
 		if err := s.soapClient.BecomeCoordinatorOfStandaloneGroup(ctx, deviceIP); err != nil {
-			s.logf("Warning: failed to ungroup device: %v", err)
+			s.warn(ctx, "Failed to ungroup device", "ip", deviceIP, "error", err)
 		} else {
 			wasUngrouped = true
 		}
@@ -279,6 +330,10 @@ func (s *PlayService) PlayContent(ctx context.Context, req PlayContentRequest) (
 	// Resolve content using content resolver
 	playable, err := s.contentResolver.ResolveContent(ctx, req.Content, deviceIP)
 	if err != nil {
+		switch err.(type) {
+		case *ServiceNotSupportedError, *ServiceNeedsBootstrapError, *ContentUnavailableError:
+			return nil, err // Callers tell these apart by type
+		}
 		return nil, fmt.Errorf("failed to resolve content: %w", err)
 	}
 
@@ -296,7 +351,7 @@ func (s *PlayService) PlayContent(ctx context.Context, req PlayContentRequest) (
 				return nil, fmt.Errorf("failed to add to queue: %w", err)
 			}
 
-			queueURI := fmt.Sprintf("x-rincon-queue:%s#0", s.getDeviceUUID(ctx, deviceIP))
+			queueURI := QueueURI(s.getDeviceUUID(ctx, deviceIP))
 			if err := s.soapClient.SetAVTransportURI(ctx, deviceIP, queueURI, ""); err != nil {
 				return nil, fmt.Errorf("failed to set transport URI: %w", err)
 			}
@@ -336,15 +391,16 @@ func (s *PlayService) PlayContent(ctx context.Context, req PlayContentRequest) (
 
 	// Build response
 	response := &PlayContentResponse{
-		Object:        "play_content_action",
-		UDN:           udn,
-		QueueMode:     queueMode,
-		GroupBehavior: groupBehavior,
-		WasUngrouped:  wasUngrouped,
-		StartedAt:     time.Now().UTC(),
-		Service:       playable.Service,
-		ContentType:   playable.ContentType,
-		Title:         playable.Title,
+		Object:         "play_content_action",
+		UDN:            udn,
+		QueueMode:      queueMode,
+		GroupBehavior:  groupBehavior,
+		WasUngrouped:   wasUngrouped,
+		StartedAt:      time.Now().UTC(),
+		Service:        playable.Service,
+		ContentType:    playable.ContentType,
+		Title:          playable.Title,
+		PlaybackDetail: newPlaybackDetail(playable),
 	}
 
 	if req.Content.ContentID != nil {
@@ -412,7 +468,7 @@ func (s *PlayService) GetServiceHealth(ctx context.Context, service, deviceIP st
 func (s *PlayService) getDeviceUUID(ctx context.Context, deviceIP string) string {
 	zoneState, err := s.soapClient.GetZoneGroupState(ctx, deviceIP)
 	if err != nil {
-		s.logf("Warning: failed to get zone group state: %v", err)
+		s.warn(ctx, "Failed to get zone group state", "ip", deviceIP, "error", err)
 		return deviceIP // Fallback to IP
 	}
 
@@ -433,12 +489,12 @@ func (s *PlayService) getDeviceUUID(ctx context.Context, deviceIP string) string
 func containsIP(location, ip string) bool {
 	return len(location) > 0 && len(ip) > 0 &&
 		(location == ip ||
-		 len(location) > len(ip)+9 && location[7:7+len(ip)] == ip)
+			len(location) > len(ip)+9 && location[7:7+len(ip)] == ip)
 }
 
-// logf logs a message if logger is available
-func (s *PlayService) logf(format string, args ...interface{}) {
+// warn logs a warning with ctx's attributes if logger is available
+func (s *PlayService) warn(ctx context.Context, msg string, args ...any) {
 	if s.logger != nil {
-		s.logger.Printf(format, args...)
+		logging.From(ctx, s.logger).Warn(msg, args...)
 	}
 }
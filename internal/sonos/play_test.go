@@ -0,0 +1,44 @@
+package sonos
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizePlaybackMetadata(t *testing.T) {
+	require.Equal(t, "", summarizePlaybackMetadata(""))
+	require.Equal(t, "<DIDL-Lite> <item/> </DIDL-Lite>", summarizePlaybackMetadata("<DIDL-Lite>\n  <item/>\n</DIDL-Lite>"))
+
+	long := strings.Repeat("x", playbackMetadataSummaryLength+50)
+	summary := summarizePlaybackMetadata(long)
+	require.Equal(t, strings.Repeat("x", playbackMetadataSummaryLength)+"...", summary)
+}
+
+func TestPlaybackDetailStripDebug(t *testing.T) {
+	response := PlayContentResponse{
+		Object: "play_content_action",
+		PlaybackDetail: newPlaybackDetail(&PlayableContent{
+			URI:      "x-sonosapi-hls-static:catalog%2falbums%2f123?sid=204",
+			Metadata: "<DIDL-Lite><item><dc:title>Blue</dc:title></item></DIDL-Lite>",
+		}),
+	}
+
+	data, err := json.Marshal(response)
+	require.NoError(t, err)
+	var withDebug map[string]any
+	require.NoError(t, json.Unmarshal(data, &withDebug))
+	require.Equal(t, "x-sonosapi-hls-static:catalog%2falbums%2f123?sid=204", withDebug["playback_uri"])
+	require.Contains(t, withDebug["playback_metadata_summary"], "<dc:title>Blue</dc:title>")
+	require.NotEmpty(t, withDebug["_playback_metadata"])
+
+	response.StripDebug()
+	data, err = json.Marshal(response)
+	require.NoError(t, err)
+	var stripped map[string]any
+	require.NoError(t, json.Unmarshal(data, &stripped))
+	require.Equal(t, withDebug["playback_uri"], stripped["playback_uri"])
+	require.NotContains(t, stripped, "_playback_metadata")
+}
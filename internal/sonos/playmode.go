@@ -0,0 +1,138 @@
+package sonos
+
+import (
+	"fmt"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// Repeat settings, as the API presents them.
+const (
+	RepeatNone = "none"
+	RepeatAll  = "all"
+	RepeatOne  = "one"
+)
+
+// sonosPlayModes maps shuffle and repeat to the AVTransport play mode. Sonos folds both
+// into one enum, and its names don't say which repeat they mean: SHUFFLE repeats all,
+// SHUFFLE_NOREPEAT doesn't.
+var sonosPlayModes = map[bool]map[string]string{
+	false: {RepeatNone: "NORMAL", RepeatAll: "REPEAT_ALL", RepeatOne: "REPEAT_ONE"},
+	true:  {RepeatNone: "SHUFFLE_NOREPEAT", RepeatAll: "SHUFFLE", RepeatOne: "SHUFFLE_REPEAT_ONE"},
+}
+
+// PlayMode is a group's shuffle, repeat and crossfade settings.
+type PlayMode struct {
+	Shuffle   bool   `json:"shuffle"`
+	Repeat    string `json:"repeat"`
+	Crossfade bool   `json:"crossfade"`
+}
+
+// PlayModeUpdate changes some play mode settings; nil fields are left as they are.
+type PlayModeUpdate struct {
+	Shuffle   *bool   `json:"shuffle,omitempty"`
+	Repeat    *string `json:"repeat,omitempty"`
+	Crossfade *bool   `json:"crossfade,omitempty"`
+}
+
+// IsEmpty reports whether the update changes nothing.
+func (u PlayModeUpdate) IsEmpty() bool {
+	return u.Shuffle == nil && u.Repeat == nil && u.Crossfade == nil
+}
+
+// Validate checks that repeat is one of none, all or one.
+func (u PlayModeUpdate) Validate() error {
+	if u.Repeat != nil {
+		if _, ok := sonosPlayModes[false][*u.Repeat]; !ok {
+			return fmt.Errorf("repeat must be one of none, all, one")
+		}
+	}
+	return nil
+}
+
+// SonosPlayMode returns the AVTransport play mode for shuffle and repeat.
+func SonosPlayMode(shuffle bool, repeat string) (string, error) {
+	mode, ok := sonosPlayModes[shuffle][repeat]
+	if !ok {
+		return "", fmt.Errorf("unknown repeat setting %q", repeat)
+	}
+	return mode, nil
+}
+
+// ParseSonosPlayMode splits an AVTransport play mode into shuffle and repeat. Unknown
+// modes read as NORMAL.
+func ParseSonosPlayMode(mode string) (shuffle bool, repeat string) {
+	for shuffle, modes := range sonosPlayModes {
+		for repeat, sonosMode := range modes {
+			if sonosMode == mode {
+				return shuffle, repeat
+			}
+		}
+	}
+	return false, RepeatNone
+}
+
+// PlayModeClient reads and changes a speaker's play mode. It is implemented by Service.
+type PlayModeClient interface {
+	GetTransportSettings(deviceIP string) (soap.TransportSettings, error)
+	SetPlayMode(deviceIP, mode string) error
+	GetCrossfadeMode(deviceIP string) (bool, error)
+	SetCrossfadeMode(deviceIP string, enabled bool) error
+}
+
+// GetPlayMode reads a group coordinator's play mode.
+func GetPlayMode(client PlayModeClient, deviceIP string) (PlayMode, error) {
+	settings, err := client.GetTransportSettings(deviceIP)
+	if err != nil {
+		return PlayMode{}, fmt.Errorf("failed to get transport settings: %w", err)
+	}
+	crossfade, err := client.GetCrossfadeMode(deviceIP)
+	if err != nil {
+		return PlayMode{}, fmt.Errorf("failed to get crossfade mode: %w", err)
+	}
+
+	shuffle, repeat := ParseSonosPlayMode(settings.PlayMode)
+	return PlayMode{Shuffle: shuffle, Repeat: repeat, Crossfade: crossfade}, nil
+}
+
+// ApplyPlayMode applies update to a group coordinator and returns the resulting play
+// mode. Shuffle and repeat share one AVTransport setting, so the current mode is read
+// first and only settings that change are sent.
+func ApplyPlayMode(client PlayModeClient, deviceIP string, update PlayModeUpdate) (PlayMode, error) {
+	if err := update.Validate(); err != nil {
+		return PlayMode{}, err
+	}
+
+	current, err := GetPlayMode(client, deviceIP)
+	if err != nil {
+		return PlayMode{}, err
+	}
+
+	next := current
+	if update.Shuffle != nil {
+		next.Shuffle = *update.Shuffle
+	}
+	if update.Repeat != nil {
+		next.Repeat = *update.Repeat
+	}
+	if update.Crossfade != nil {
+		next.Crossfade = *update.Crossfade
+	}
+
+	if next.Shuffle != current.Shuffle || next.Repeat != current.Repeat {
+		mode, err := SonosPlayMode(next.Shuffle, next.Repeat)
+		if err != nil {
+			return PlayMode{}, err
+		}
+		if err := client.SetPlayMode(deviceIP, mode); err != nil {
+			return PlayMode{}, fmt.Errorf("failed to set play mode: %w", err)
+		}
+	}
+	if next.Crossfade != current.Crossfade {
+		if err := client.SetCrossfadeMode(deviceIP, next.Crossfade); err != nil {
+			return PlayMode{}, fmt.Errorf("failed to set crossfade mode: %w", err)
+		}
+	}
+
+	return next, nil
+}
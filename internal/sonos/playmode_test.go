@@ -0,0 +1,118 @@
+package sonos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+type fakePlayModeClient struct {
+	mode         string
+	crossfade    bool
+	setModes     []string
+	setCrossfade []bool
+}
+
+func (f *fakePlayModeClient) GetTransportSettings(string) (soap.TransportSettings, error) {
+	return soap.TransportSettings{PlayMode: f.mode}, nil
+}
+
+func (f *fakePlayModeClient) SetPlayMode(_ string, mode string) error {
+	f.mode = mode
+	f.setModes = append(f.setModes, mode)
+	return nil
+}
+
+func (f *fakePlayModeClient) GetCrossfadeMode(string) (bool, error) {
+	return f.crossfade, nil
+}
+
+func (f *fakePlayModeClient) SetCrossfadeMode(_ string, enabled bool) error {
+	f.crossfade = enabled
+	f.setCrossfade = append(f.setCrossfade, enabled)
+	return nil
+}
+
+func TestSonosPlayMode(t *testing.T) {
+	cases := []struct {
+		shuffle bool
+		repeat  string
+		mode    string
+	}{
+		{false, RepeatNone, "NORMAL"},
+		{false, RepeatAll, "REPEAT_ALL"},
+		{false, RepeatOne, "REPEAT_ONE"},
+		{true, RepeatNone, "SHUFFLE_NOREPEAT"},
+		{true, RepeatAll, "SHUFFLE"},
+		{true, RepeatOne, "SHUFFLE_REPEAT_ONE"},
+	}
+	for _, tc := range cases {
+		mode, err := SonosPlayMode(tc.shuffle, tc.repeat)
+		require.NoError(t, err)
+		require.Equal(t, tc.mode, mode)
+
+		shuffle, repeat := ParseSonosPlayMode(tc.mode)
+		require.Equal(t, tc.shuffle, shuffle, tc.mode)
+		require.Equal(t, tc.repeat, repeat, tc.mode)
+	}
+
+	_, err := SonosPlayMode(true, "sometimes")
+	require.Error(t, err)
+
+	shuffle, repeat := ParseSonosPlayMode("")
+	require.False(t, shuffle)
+	require.Equal(t, RepeatNone, repeat)
+}
+
+func TestPlayModeUpdate_Validate(t *testing.T) {
+	require.True(t, PlayModeUpdate{}.IsEmpty())
+	repeat := "all"
+	require.NoError(t, PlayModeUpdate{Repeat: &repeat}.Validate())
+	repeat = "forever"
+	require.Error(t, PlayModeUpdate{Repeat: &repeat}.Validate())
+}
+
+func TestApplyPlayMode(t *testing.T) {
+	t.Run("shuffle keeps the current repeat", func(t *testing.T) {
+		client := &fakePlayModeClient{mode: "REPEAT_ALL"}
+		shuffle := true
+
+		playMode, err := ApplyPlayMode(client, "192.168.1.20", PlayModeUpdate{Shuffle: &shuffle})
+		require.NoError(t, err)
+		require.Equal(t, PlayMode{Shuffle: true, Repeat: RepeatAll}, playMode)
+		require.Equal(t, []string{"SHUFFLE"}, client.setModes)
+		require.Empty(t, client.setCrossfade)
+	})
+
+	t.Run("repeat off keeps shuffle", func(t *testing.T) {
+		client := &fakePlayModeClient{mode: "SHUFFLE"}
+		repeat := RepeatNone
+
+		playMode, err := ApplyPlayMode(client, "192.168.1.20", PlayModeUpdate{Repeat: &repeat})
+		require.NoError(t, err)
+		require.Equal(t, PlayMode{Shuffle: true, Repeat: RepeatNone}, playMode)
+		require.Equal(t, []string{"SHUFFLE_NOREPEAT"}, client.setModes)
+	})
+
+	t.Run("unchanged settings aren't sent", func(t *testing.T) {
+		client := &fakePlayModeClient{mode: "SHUFFLE_NOREPEAT"}
+		shuffle, crossfade := true, true
+
+		playMode, err := ApplyPlayMode(client, "192.168.1.20", PlayModeUpdate{Shuffle: &shuffle, Crossfade: &crossfade})
+		require.NoError(t, err)
+		require.True(t, playMode.Crossfade)
+		require.Empty(t, client.setModes)
+		require.Equal(t, []bool{true}, client.setCrossfade)
+	})
+
+	t.Run("invalid repeat", func(t *testing.T) {
+		client := &fakePlayModeClient{mode: "NORMAL"}
+		repeat := "twice"
+
+		_, err := ApplyPlayMode(client, "192.168.1.20", PlayModeUpdate{Repeat: &repeat})
+		require.Error(t, err)
+		require.Empty(t, client.setModes)
+	})
+}
@@ -0,0 +1,30 @@
+package sonos
+
+import "github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+
+// previousRestartSeconds is how far into a track Previous restarts it rather than
+// going back a track, as the Sonos app does.
+const previousRestartSeconds = 3
+
+// previousClient is the subset of Service used to skip back.
+type previousClient interface {
+	GetPositionInfo(deviceIP string) (soap.PositionInfo, error)
+	Previous(deviceIP string) error
+	Seek(deviceIP, unit, target string) error
+}
+
+// skipPrevious restarts the current track when it's more than previousRestartSeconds
+// in, and otherwise goes back to the previous track. force always goes back. It reports
+// whether the track was restarted.
+func skipPrevious(client previousClient, deviceIP string, force bool) (bool, error) {
+	if !force {
+		position, err := client.GetPositionInfo(deviceIP)
+		if err != nil {
+			return false, err
+		}
+		if ParseDuration(position.RelTime) > previousRestartSeconds {
+			return true, client.Seek(deviceIP, "REL_TIME", FormatDuration(0))
+		}
+	}
+	return false, client.Previous(deviceIP)
+}
@@ -0,0 +1,53 @@
+package sonos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+type fakePreviousClient struct {
+	relTime string
+	calls   []string
+}
+
+func (f *fakePreviousClient) GetPositionInfo(deviceIP string) (soap.PositionInfo, error) {
+	return soap.PositionInfo{RelTime: f.relTime}, nil
+}
+
+func (f *fakePreviousClient) Previous(deviceIP string) error {
+	f.calls = append(f.calls, "Previous")
+	return nil
+}
+
+func (f *fakePreviousClient) Seek(deviceIP, unit, target string) error {
+	f.calls = append(f.calls, "Seek "+unit+" "+target)
+	return nil
+}
+
+func TestSkipPrevious(t *testing.T) {
+	tests := []struct {
+		name          string
+		relTime       string
+		force         bool
+		wantRestarted bool
+		wantCalls     []string
+	}{
+		{"well into the track restarts it", "0:01:30", false, true, []string{"Seek REL_TIME 0:00:00"}},
+		{"near the start goes back", "0:00:02", false, false, []string{"Previous"}},
+		{"at the threshold goes back", "0:00:03", false, false, []string{"Previous"}},
+		{"no position goes back", "NOT_IMPLEMENTED", false, false, []string{"Previous"}},
+		{"forced goes back", "0:01:30", true, false, []string{"Previous"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakePreviousClient{relTime: tt.relTime}
+			restarted, err := skipPrevious(client, "10.0.0.1", tt.force)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantRestarted, restarted)
+			require.Equal(t, tt.wantCalls, client.calls, "one skip per request")
+		})
+	}
+}
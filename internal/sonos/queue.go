@@ -0,0 +1,165 @@
+package sonos
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// listQueueHandler handles GET /v1/sonos/queue?udn=&start=&count=. A grouped speaker
+// is redirected to its coordinator, which holds the group's queue.
+func listQueueHandler(service *Service) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		udn := r.URL.Query().Get("udn")
+		if udn == "" {
+			return apperrors.NewValidationError("udn query parameter is required", nil)
+		}
+		startIndex, requestedCount, err := parseQueuePage(r)
+		if err != nil {
+			return err
+		}
+
+		deviceIP, err := service.ResolveDeviceIP(udn)
+		if err != nil {
+			return deviceFailure(err, udn, "", "Failed to resolve device")
+		}
+		target := ResolveGroupCoordinator(service, deviceIP)
+
+		result, err := service.BrowseQueue(target.CoordinatorIP, startIndex, requestedCount)
+		if err != nil {
+			return apperrors.NewInternalError("Failed to browse queue")
+		}
+
+		items := make([]map[string]any, 0, len(result.Items))
+		for i, item := range result.Items {
+			items = append(items, formatQueueItem(item, startIndex+i+1, target.CoordinatorIP))
+		}
+		hasMore := startIndex+len(result.Items) < result.TotalMatches
+
+		return api.WriteList(w, "/v1/sonos/queue", items, hasMore)
+	}
+}
+
+// addToQueueHandler handles POST /v1/sonos/queue/add.
+func addToQueueHandler(service *Service) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var body struct {
+			UDN         string `json:"udn"`
+			URI         string `json:"uri"`
+			Metadata    string `json:"metadata"`
+			Position    int    `json:"position"`
+			EnqueueNext bool   `json:"enqueue_next"`
+		}
+		if err := decodeJSON(r, &body); err != nil || body.UDN == "" {
+			return apperrors.NewValidationError("udn is required", nil)
+		}
+		if body.URI == "" {
+			return apperrors.NewValidationError("uri is required", nil)
+		}
+		if body.Position < 0 {
+			return apperrors.NewValidationError("position must be non-negative", nil)
+		}
+
+		deviceIP, err := service.ResolveDeviceIP(body.UDN)
+		if err != nil {
+			return deviceFailure(err, body.UDN, "", "Failed to resolve device")
+		}
+		target := ResolveGroupCoordinator(service, deviceIP)
+
+		firstTrack, err := service.AddURIToQueue(target.CoordinatorIP, body.URI, body.Metadata, body.Position, body.EnqueueNext)
+		if err != nil {
+			return apperrors.NewInternalError("Failed to add to queue")
+		}
+
+		response := map[string]any{
+			"object":             "queue_action",
+			"udn":                body.UDN,
+			"action":             "add",
+			"uri":                body.URI,
+			"first_track_number": firstTrack,
+			"added_at":           api.RFC3339Millis(time.Now()),
+		}
+		addDebugTargets(r, response, deviceIP, []string{target.CoordinatorIP})
+
+		return api.WriteAction(w, http.StatusOK, response)
+	}
+}
+
+// clearQueueHandler handles POST /v1/sonos/queue/clear.
+func clearQueueHandler(service *Service) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var body struct {
+			UDN string `json:"udn"`
+		}
+		if err := decodeJSON(r, &body); err != nil || body.UDN == "" {
+			return apperrors.NewValidationError("udn is required", nil)
+		}
+
+		deviceIP, err := service.ResolveDeviceIP(body.UDN)
+		if err != nil {
+			return deviceFailure(err, body.UDN, "", "Failed to resolve device")
+		}
+		target := ResolveGroupCoordinator(service, deviceIP)
+
+		if err := service.RemoveAllTracksFromQueue(target.CoordinatorIP); err != nil {
+			return apperrors.NewInternalError("Failed to clear queue")
+		}
+
+		response := map[string]any{
+			"object":     "queue_action",
+			"udn":        body.UDN,
+			"action":     "clear",
+			"cleared_at": api.RFC3339Millis(time.Now()),
+		}
+		addDebugTargets(r, response, deviceIP, []string{target.CoordinatorIP})
+
+		return api.WriteAction(w, http.StatusOK, response)
+	}
+}
+
+// parseQueuePage reads start and count, with the same limits as the favorites list.
+func parseQueuePage(r *http.Request) (int, int, error) {
+	startIndex := 0
+	if startStr := r.URL.Query().Get("start"); startStr != "" {
+		val, err := strconv.Atoi(startStr)
+		if err != nil || val < 0 {
+			return 0, 0, apperrors.NewValidationError("start must be a non-negative integer", nil)
+		}
+		startIndex = val
+	}
+
+	requestedCount := 100
+	if countStr := r.URL.Query().Get("count"); countStr != "" {
+		val, err := strconv.Atoi(countStr)
+		if err != nil || val < 1 || val > 1000 {
+			return 0, 0, apperrors.NewValidationError("count must be an integer between 1 and 1000", nil)
+		}
+		requestedCount = val
+	}
+
+	return startIndex, requestedCount, nil
+}
+
+// formatQueueItem formats a queue entry at its 1-based queue position.
+func formatQueueItem(item soap.MusicLibraryItem, position int, coordinatorIP string) map[string]any {
+	albumArt := item.AlbumArtURI
+	if albumArt != "" {
+		albumArt = NormalizeAlbumArtURI(albumArt, coordinatorIP)
+	}
+
+	return map[string]any{
+		"object":           "queue_item",
+		"id":               item.ID,
+		"position":         position,
+		"title":            item.Title,
+		"artist":           emptyToNil(item.ArtistName),
+		"album":            emptyToNil(item.AlbumName),
+		"album_art_uri":    emptyToNil(albumArt),
+		"uri":              item.Resource,
+		"duration_seconds": ParseDuration(item.Duration),
+	}
+}
@@ -0,0 +1,47 @@
+package sonos
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+func TestParseQueuePage(t *testing.T) {
+	start, count, err := parseQueuePage(httptest.NewRequest("GET", "/v1/sonos/queue?udn=x", nil))
+	require.NoError(t, err)
+	require.Equal(t, 0, start)
+	require.Equal(t, 100, count)
+
+	start, count, err = parseQueuePage(httptest.NewRequest("GET", "/v1/sonos/queue?udn=x&start=20&count=10", nil))
+	require.NoError(t, err)
+	require.Equal(t, 20, start)
+	require.Equal(t, 10, count)
+
+	_, _, err = parseQueuePage(httptest.NewRequest("GET", "/v1/sonos/queue?udn=x&start=-1", nil))
+	require.Error(t, err)
+	_, _, err = parseQueuePage(httptest.NewRequest("GET", "/v1/sonos/queue?udn=x&count=1001", nil))
+	require.Error(t, err)
+}
+
+func TestFormatQueueItem(t *testing.T) {
+	item := soap.MusicLibraryItem{
+		ID:          "Q:0/3",
+		Title:       "Song",
+		ArtistName:  "Artist",
+		AlbumArtURI: "/getaa?s=1&u=x-sonos-spotify%3a123",
+		Resource:    "x-sonos-spotify:123",
+		Duration:    "0:03:15",
+	}
+
+	formatted := formatQueueItem(item, 3, "192.168.1.20")
+	require.Equal(t, 3, formatted["position"])
+	require.Equal(t, "Song", formatted["title"])
+	require.Equal(t, "Artist", formatted["artist"])
+	require.Nil(t, formatted["album"])
+	require.Equal(t, "http://192.168.1.20:1400/getaa?s=1&u=x-sonos-spotify%3a123", formatted["album_art_uri"])
+	require.Equal(t, "x-sonos-spotify:123", formatted["uri"])
+	require.Equal(t, 195, formatted["duration_seconds"])
+}
@@ -0,0 +1,125 @@
+package sonos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// Station favorites as parsed from a speaker's FV:2 browse (see soap's parser tests for
+// the XML). The iHeartRadio favorite is an older one, saved without an account descriptor.
+var (
+	pandoraStationFavorite = soap.FavoriteItem{
+		ID:          "FV:2/41",
+		ParentID:    "FV:2",
+		Title:       "Miles Davis Radio",
+		UpnpClass:   "object.itemobject.item.sonos-favorite",
+		Resource:    "x-sonosapi-radio:ST%3a4287539617305620481?sid=236&flags=8300&sn=5",
+		ServiceName: "Pandora Station",
+		ResourceMetaData: `<DIDL-Lite xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/" xmlns:r="urn:schemas-rinconnetworks-com:metadata-1-0/" xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/">` +
+			`<item id="100c206cST%3a4287539617305620481" parentID="10082064myStations" restricted="true">` +
+			`<dc:title>Miles Davis Radio</dc:title><upnp:class>object.item.audioItem.audioBroadcast.#station</upnp:class>` +
+			`<desc id="cdudn" nameSpace="urn:schemas-rinconnetworks-com:metadata-1-0/">SA_RINCON60423_X_#Svc60423-0-Token</desc>` +
+			`</item></DIDL-Lite>`,
+	}
+
+	iHeartRadioStationFavorite = soap.FavoriteItem{
+		ID:          "FV:2/42",
+		ParentID:    "FV:2",
+		Title:       "Z100 & More",
+		UpnpClass:   "object.itemobject.item.sonos-favorite",
+		Resource:    "x-sonosapi-hls:live%3a1469?sid=6&flags=8232&sn=3",
+		ServiceName: "iHeartRadio Station",
+		ResourceMetaData: `<DIDL-Lite xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/" xmlns:r="urn:schemas-rinconnetworks-com:metadata-1-0/" xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/">` +
+			`<item id="F00092020live%3a1469" parentID="F00082064live" restricted="true">` +
+			`<dc:title>Z100 &amp; More</dc:title><upnp:class>object.item.audioItem.audioBroadcast</upnp:class>` +
+			`</item></DIDL-Lite>`,
+	}
+)
+
+func TestRadioServiceForResource(t *testing.T) {
+	tests := []struct {
+		resource string
+		want     string
+	}{
+		{pandoraStationFavorite.Resource, ServicePandora},
+		{iHeartRadioStationFavorite.Resource, ServiceIHeartRadio},
+		{"x-sonosapi-stream:live%3a1469?sid=6&flags=8224&sn=3", ServiceIHeartRadio},
+		{"X-SONOSAPI-RADIO:ST%3a1?sid=236", ServicePandora},
+		{"x-sonosapi-stream:s24861?sid=254&flags=8224&sn=0", ""},                                       // TuneIn
+		{"x-rincon-cpcontainer:1006206cplaylist%3a123?sid=6&flags=8300&sn=3", ""},                      // Not a station
+		{"x-sonosapi-radio:spotify%3aartistRadio%3a0kbYTNQb4Pb1rPbbaF0pT4?sid=12&flags=8300&sn=7", ""}, // Spotify
+	}
+	for _, tt := range tests {
+		radio := radioServiceForResource(tt.resource)
+		if tt.want == "" {
+			require.Nil(t, radio, tt.resource)
+			continue
+		}
+		require.NotNil(t, radio, tt.resource)
+		require.Equal(t, tt.want, radio.Service)
+	}
+}
+
+func TestFormatFavorite_RadioStations(t *testing.T) {
+	pandora := formatFavorite(pandoraStationFavorite)
+	require.Equal(t, "station", pandora["content_type"])
+	require.Empty(t, pandora["service_logo_url"], "no logo asset to point at")
+
+	iHeart := formatFavorite(iHeartRadioStationFavorite)
+	require.Equal(t, "station", iHeart["content_type"])
+	require.Empty(t, iHeart["service_logo_url"])
+
+	// Without the favorite's description, the service comes from the sid
+	favorite := iHeartRadioStationFavorite
+	favorite.ServiceName = ""
+	favorite.Title = "Prime Country"
+	require.Equal(t, "iHeartRadio", formatFavorite(favorite)["service_name"])
+}
+
+func TestDetectContentTypeFromClass_RadioStations(t *testing.T) {
+	// The resource decides, whatever class the favorite was saved with
+	require.Equal(t, "station", detectContentTypeFromClass("object.item.audioItem.musicTrack", iHeartRadioStationFavorite.Resource))
+	require.Equal(t, "station", detectContentTypeFromClass("", pandoraStationFavorite.Resource))
+	require.Equal(t, "track", detectContentTypeFromClass("object.item.audioItem.musicTrack", "x-sonos-http:song%3a1234.mp4?sid=204"))
+}
+
+func TestResolveFavorite_RadioStations(t *testing.T) {
+	resolver := NewContentResolver(nil, nil, time.Second, nil)
+	resolver.SetFavoritesProvider(&fakeFavoritesProvider{
+		cached: []soap.FavoriteItem{pandoraStationFavorite, iHeartRadioStationFavorite},
+	})
+
+	t.Run("pandora", func(t *testing.T) {
+		playable, err := resolver.ResolveFavorite(context.Background(), "FV:2/41", "192.168.1.10")
+		require.NoError(t, err)
+		require.Equal(t, pandoraStationFavorite.Resource, playable.URI)
+		require.Equal(t, "station", playable.ContentType)
+		require.Equal(t, "Pandora", playable.Service)
+		require.False(t, playable.UsesQueue)
+		require.Equal(t, buildDidlMetadata("100c206cST%3a4287539617305620481", "Miles Davis Radio",
+			"object.item.audioItem.audioBroadcast.#station", "SA_RINCON60423_X_#Svc60423-0-Token"), playable.Metadata)
+	})
+
+	t.Run("iheartradio without a descriptor", func(t *testing.T) {
+		playable, err := resolver.ResolveFavorite(context.Background(), "FV:2/42", "192.168.1.10")
+		require.NoError(t, err)
+		require.Equal(t, "station", playable.ContentType)
+		require.Equal(t, "iHeartRadio", playable.Service)
+		require.Contains(t, playable.Metadata, `<item id="F00092020live%3a1469" parentID="0"`)
+		require.Contains(t, playable.Metadata, "<dc:title>Z100 &amp; More</dc:title>")
+		require.Contains(t, playable.Metadata, ">SA_RINCON1543_X_#Svc1543-0-Token</desc>")
+	})
+
+	t.Run("without metadata", func(t *testing.T) {
+		favorite := pandoraStationFavorite
+		favorite.ResourceMetaData = ""
+		metadata := radioFavoriteMetadata(radioServiceForResource(favorite.Resource), favorite)
+		require.Contains(t, metadata, `<item id="100c206cST%3a4287539617305620481"`)
+		require.Contains(t, metadata, ">SA_RINCON60423_X_#Svc60423-0-Token</desc>")
+	})
+}
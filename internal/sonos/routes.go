@@ -1,7 +1,9 @@
 package sonos
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"math"
 	"net/http"
 	"regexp"
@@ -14,11 +16,14 @@ import (
 
 	"github.com/strefethen/sonos-hub-go/internal/api"
 	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/artwork"
 	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
 )
 
 // RegisterRoutes wires Sonos routes to the router.
 func RegisterRoutes(router chi.Router, service *Service) {
+	ramps := newVolumeRampManager(serviceVolumeSetter(service))
+
 	router.Route("/v1/sonos/playback", func(playback chi.Router) {
 		playback.Method(http.MethodPost, "/stop", api.Handler(func(w http.ResponseWriter, r *http.Request) error {
 			var body struct {
@@ -30,18 +35,25 @@ func RegisterRoutes(router chi.Router, service *Service) {
 
 			deviceIP, err := service.ResolveDeviceIP(body.UDN)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to resolve device")
+				return deviceFailure(err, body.UDN, "", "Failed to resolve device")
 			}
-			if err := service.Stop(deviceIP); err != nil {
-				return apperrors.NewInternalError("Failed to stop playback")
+			// Transport actions only take effect on the group coordinator
+			target := ResolveGroupCoordinator(service, deviceIP)
+			if err := service.Stop(target.CoordinatorIP); err != nil {
+				return deviceFailure(err, body.UDN, target.CoordinatorIP, "Failed to stop playback")
 			}
+			confirmation := ConfirmGroupTransport(service, target, "STOPPED")
 
-			return api.WriteAction(w, http.StatusOK, map[string]any{
+			response := map[string]any{
 				"object":     "playback_action",
 				"udn":        body.UDN,
 				"action":     "stop",
 				"stopped_at": api.RFC3339Millis(time.Now()),
-			})
+				"group":      formatGroupConfirmation(target, confirmation),
+			}
+			addDebugTargets(r, response, deviceIP, target.memberIPs())
+
+			return api.WriteAction(w, http.StatusOK, response)
 		}))
 
 		playback.Method(http.MethodPost, "/pause", api.Handler(func(w http.ResponseWriter, r *http.Request) error {
@@ -54,18 +66,25 @@ func RegisterRoutes(router chi.Router, service *Service) {
 
 			deviceIP, err := service.ResolveDeviceIP(body.UDN)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to resolve device")
+				return deviceFailure(err, body.UDN, "", "Failed to resolve device")
 			}
-			if err := service.Pause(deviceIP); err != nil {
-				return apperrors.NewInternalError("Failed to pause playback")
+			// Transport actions only take effect on the group coordinator
+			target := ResolveGroupCoordinator(service, deviceIP)
+			if err := service.Pause(target.CoordinatorIP); err != nil {
+				return deviceFailure(err, body.UDN, target.CoordinatorIP, "Failed to pause playback")
 			}
+			confirmation := ConfirmGroupTransport(service, target, "PAUSED_PLAYBACK", "STOPPED")
 
-			return api.WriteAction(w, http.StatusOK, map[string]any{
+			response := map[string]any{
 				"object":    "playback_action",
 				"udn":       body.UDN,
 				"action":    "pause",
 				"paused_at": api.RFC3339Millis(time.Now()),
-			})
+				"group":     formatGroupConfirmation(target, confirmation),
+			}
+			addDebugTargets(r, response, deviceIP, target.memberIPs())
+
+			return api.WriteAction(w, http.StatusOK, response)
 		}))
 
 		playback.Method(http.MethodPost, "/play", api.Handler(func(w http.ResponseWriter, r *http.Request) error {
@@ -78,18 +97,25 @@ func RegisterRoutes(router chi.Router, service *Service) {
 
 			deviceIP, err := service.ResolveDeviceIP(body.UDN)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to resolve device")
+				return deviceFailure(err, body.UDN, "", "Failed to resolve device")
 			}
-			if err := service.Play(deviceIP); err != nil {
-				return apperrors.NewInternalError("Failed to start playback")
+			// Transport actions only take effect on the group coordinator
+			target := ResolveGroupCoordinator(service, deviceIP)
+			if err := service.Play(target.CoordinatorIP); err != nil {
+				return deviceFailure(err, body.UDN, target.CoordinatorIP, "Failed to start playback")
 			}
+			confirmation := ConfirmGroupTransport(service, target, "PLAYING", "TRANSITIONING")
 
-			return api.WriteAction(w, http.StatusOK, map[string]any{
+			response := map[string]any{
 				"object":     "playback_action",
 				"udn":        body.UDN,
 				"action":     "play",
 				"resumed_at": api.RFC3339Millis(time.Now()),
-			})
+				"group":      formatGroupConfirmation(target, confirmation),
+			}
+			addDebugTargets(r, response, deviceIP, target.memberIPs())
+
+			return api.WriteAction(w, http.StatusOK, response)
 		}))
 
 		playback.Method(http.MethodPost, "/next", api.Handler(func(w http.ResponseWriter, r *http.Request) error {
@@ -102,23 +128,27 @@ func RegisterRoutes(router chi.Router, service *Service) {
 
 			deviceIP, err := service.ResolveDeviceIP(body.UDN)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to resolve device")
+				return deviceFailure(err, body.UDN, "", "Failed to resolve device")
 			}
 			if err := service.Next(deviceIP); err != nil {
-				return apperrors.NewInternalError("Failed to skip track")
+				return deviceFailure(err, body.UDN, deviceIP, "Failed to skip track")
 			}
 
-			return api.WriteAction(w, http.StatusOK, map[string]any{
+			response := map[string]any{
 				"object":     "playback_action",
 				"udn":        body.UDN,
 				"action":     "next",
 				"skipped_at": api.RFC3339Millis(time.Now()),
-			})
+			}
+			addDebugTargets(r, response, deviceIP, nil)
+
+			return api.WriteAction(w, http.StatusOK, response)
 		}))
 
 		playback.Method(http.MethodPost, "/previous", api.Handler(func(w http.ResponseWriter, r *http.Request) error {
 			var body struct {
-				UDN string `json:"udn"`
+				UDN           string `json:"udn"`
+				ForcePrevious bool   `json:"force_previous"` // Go back a track even when well into this one
 			}
 			if err := decodeJSON(r, &body); err != nil || body.UDN == "" {
 				return apperrors.NewValidationError("udn is required", nil)
@@ -126,21 +156,146 @@ func RegisterRoutes(router chi.Router, service *Service) {
 
 			deviceIP, err := service.ResolveDeviceIP(body.UDN)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to resolve device")
+				return deviceFailure(err, body.UDN, "", "Failed to resolve device")
 			}
-			if err := service.Previous(deviceIP); err != nil {
-				return apperrors.NewInternalError("Failed to skip track")
+			restarted, err := skipPrevious(service, deviceIP, body.ForcePrevious)
+			if err != nil {
+				return deviceFailure(err, body.UDN, deviceIP, "Failed to skip track")
 			}
-			if err := service.Previous(deviceIP); err != nil {
-				return apperrors.NewInternalError("Failed to skip track")
+
+			response := map[string]any{
+				"object":          "playback_action",
+				"udn":             body.UDN,
+				"action":          "previous",
+				"restarted_track": restarted,
+				"skipped_at":      api.RFC3339Millis(time.Now()),
 			}
+			addDebugTargets(r, response, deviceIP, nil)
 
-			return api.WriteAction(w, http.StatusOK, map[string]any{
-				"object":     "playback_action",
-				"udn":        body.UDN,
-				"action":     "previous",
-				"skipped_at": api.RFC3339Millis(time.Now()),
-			})
+			return api.WriteAction(w, http.StatusOK, response)
+		}))
+
+		playback.Method(http.MethodPost, "/seek", api.Handler(func(w http.ResponseWriter, r *http.Request) error {
+			var body struct {
+				UDN             string   `json:"udn"`
+				PositionSeconds *float64 `json:"position_seconds"`
+				TrackNumber     *int     `json:"track_number"`
+			}
+			if err := decodeJSON(r, &body); err != nil || body.UDN == "" {
+				return apperrors.NewValidationError("udn is required", nil)
+			}
+			if body.PositionSeconds == nil && body.TrackNumber == nil {
+				return apperrors.NewValidationError("position_seconds or track_number is required", nil)
+			}
+			if body.PositionSeconds != nil && *body.PositionSeconds < 0 {
+				return apperrors.NewValidationError("position_seconds must be non-negative", nil)
+			}
+			if body.TrackNumber != nil && *body.TrackNumber < 1 {
+				return apperrors.NewValidationError("track_number must be at least 1", nil)
+			}
+
+			deviceIP, err := service.ResolveDeviceIP(body.UDN)
+			if err != nil {
+				return deviceFailure(err, body.UDN, "", "Failed to resolve device")
+			}
+			// Transport actions only take effect on the group coordinator
+			target := ResolveGroupCoordinator(service, deviceIP)
+
+			if body.TrackNumber != nil {
+				if err := service.Seek(target.CoordinatorIP, "TRACK_NR", strconv.Itoa(*body.TrackNumber)); err != nil {
+					return deviceFailure(err, body.UDN, target.CoordinatorIP, "Failed to seek to track")
+				}
+			}
+
+			if body.PositionSeconds != nil {
+				// Checked against the track being sought within, after any track change
+				positionInfo, err := service.GetPositionInfo(target.CoordinatorIP)
+				if err != nil {
+					return deviceFailure(err, body.UDN, target.CoordinatorIP, "Failed to fetch position info")
+				}
+				position := int(math.Round(*body.PositionSeconds))
+				if err := validateSeekPosition(position, positionInfo.TrackDuration); err != nil {
+					return err
+				}
+				if err := service.Seek(target.CoordinatorIP, "REL_TIME", FormatDuration(position)); err != nil {
+					return deviceFailure(err, body.UDN, target.CoordinatorIP, "Failed to seek")
+				}
+			}
+
+			positionInfo, err := service.GetPositionInfo(target.CoordinatorIP)
+			if err != nil {
+				return deviceFailure(err, body.UDN, target.CoordinatorIP, "Failed to fetch position info")
+			}
+
+			response := map[string]any{
+				"object":           "playback_action",
+				"udn":              body.UDN,
+				"action":           "seek",
+				"track_number":     positionInfo.Track,
+				"position":         positionInfo.RelTime,
+				"position_seconds": ParseDuration(positionInfo.RelTime),
+				"duration_seconds": ParseDuration(positionInfo.TrackDuration),
+				"sought_at":        api.RFC3339Millis(time.Now()),
+			}
+			addDebugTargets(r, response, deviceIP, target.memberIPs())
+
+			return api.WriteAction(w, http.StatusOK, response)
+		}))
+
+		playback.Method(http.MethodPost, "/source", setSourceHandler(service))
+		playback.Method(http.MethodGet, "/sleep-timer", getSleepTimerHandler(service))
+		playback.Method(http.MethodPost, "/sleep-timer", setSleepTimerHandler(service))
+
+		playback.Method(http.MethodGet, "/play-mode", api.Handler(func(w http.ResponseWriter, r *http.Request) error {
+			udn := r.URL.Query().Get("udn")
+			if udn == "" {
+				return apperrors.NewValidationError("udn query parameter is required", nil)
+			}
+
+			deviceIP, err := service.ResolveDeviceIP(udn)
+			if err != nil {
+				return deviceFailure(err, udn, "", "Failed to resolve device")
+			}
+			// Play mode belongs to the group coordinator
+			target := ResolveGroupCoordinator(service, deviceIP)
+			playMode, err := GetPlayMode(service, target.CoordinatorIP)
+			if err != nil {
+				return deviceFailure(err, udn, target.CoordinatorIP, "Failed to fetch play mode")
+			}
+
+			return api.WriteResource(w, http.StatusOK, formatPlayMode(udn, playMode))
+		}))
+
+		playback.Method(http.MethodPost, "/play-mode", api.Handler(func(w http.ResponseWriter, r *http.Request) error {
+			var body struct {
+				UDN string `json:"udn"`
+				PlayModeUpdate
+			}
+			if err := decodeJSON(r, &body); err != nil || body.UDN == "" {
+				return apperrors.NewValidationError("udn is required", nil)
+			}
+			if body.PlayModeUpdate.IsEmpty() {
+				return apperrors.NewValidationError("At least one of shuffle, repeat or crossfade is required", nil)
+			}
+			if err := body.PlayModeUpdate.Validate(); err != nil {
+				return apperrors.NewValidationError(err.Error(), nil)
+			}
+
+			deviceIP, err := service.ResolveDeviceIP(body.UDN)
+			if err != nil {
+				return deviceFailure(err, body.UDN, "", "Failed to resolve device")
+			}
+			// Play mode belongs to the group coordinator
+			target := ResolveGroupCoordinator(service, deviceIP)
+			playMode, err := ApplyPlayMode(service, target.CoordinatorIP, body.PlayModeUpdate)
+			if err != nil {
+				return deviceFailure(err, body.UDN, target.CoordinatorIP, "Failed to set play mode")
+			}
+
+			response := formatPlayMode(body.UDN, playMode)
+			addDebugTargets(r, response, deviceIP, []string{target.CoordinatorIP})
+
+			return api.WriteAction(w, http.StatusOK, response)
 		}))
 
 		playback.Method(http.MethodGet, "/state", api.Handler(func(w http.ResponseWriter, r *http.Request) error {
@@ -151,11 +306,11 @@ func RegisterRoutes(router chi.Router, service *Service) {
 
 			deviceIP, err := service.ResolveDeviceIP(udn)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to resolve device")
+				return deviceFailure(err, udn, "", "Failed to resolve device")
 			}
 			state, err := service.GetTransportInfo(deviceIP)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to fetch transport state")
+				return deviceFailure(err, udn, deviceIP, "Failed to fetch transport state")
 			}
 
 			return api.WriteResource(w, http.StatusOK, map[string]any{
@@ -175,46 +330,17 @@ func RegisterRoutes(router chi.Router, service *Service) {
 
 			// Check for debug flag to include data sources
 			includeDebug := r.URL.Query().Get("debug") == "true"
+			// Opt in to the hub's device aliases for room names
+			useAliases := r.URL.Query().Get("use_aliases") == "true"
 
 			entryIP, err := service.ResolveDeviceIP(udn)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to resolve device")
+				return deviceFailure(err, udn, "", "Failed to resolve device")
 			}
 
-			// Use cached zone group state (30s TTL by default)
-			zoneState, err := service.GetZoneGroupStateCached(entryIP)
+			groups, dataSources, err := fetchNowPlayingGroups(service, entryIP, includeDebug, useAliases)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to fetch zone group state")
-			}
-
-			// Build UUID to IP mapping
-			uuidToIP := BuildUUIDToIPMap(zoneState)
-
-			// Extract coordinator info for parallel fetching
-			coordinators := ExtractCoordinators(zoneState, uuidToIP)
-
-			// Fetch all groups using hybrid approach (cache-first with SOAP fallback)
-			results, dataSources := FetchAllGroupsPlaybackHybrid(service, coordinators)
-
-			// Build response from hybrid results
-			groups := make([]map[string]any, 0, len(results))
-			for _, result := range results {
-				// Convert HybridGroupResult to GroupPlaybackResult for buildNowPlayingGroup
-				groupResult := GroupPlaybackResult{
-					Coordinator: result.Coordinator,
-					Playback:    result.Playback.GroupPlaybackInfo,
-				}
-				groupData := buildNowPlayingGroup(groupResult)
-				if groupData != nil {
-					// Add data source to group if debugging
-					if includeDebug {
-						groupData["_data_source"] = string(result.Playback.Source)
-						if result.Playback.Source == DataSourceCache {
-							groupData["_cache_age_ms"] = result.Playback.CacheAge.Milliseconds()
-						}
-					}
-					groups = append(groups, groupData)
-				}
+				return deviceFailure(err, udn, entryIP, "Failed to fetch zone group state")
 			}
 
 			response := map[string]any{
@@ -236,6 +362,10 @@ func RegisterRoutes(router chi.Router, service *Service) {
 		}))
 	})
 
+	// Push now-playing changes over a WebSocket instead of clients polling /now-playing
+	nowPlayingHub := NewNowPlayingHub(service, DefaultNowPlayingPollInterval, nil)
+	router.Method(http.MethodGet, "/v1/sonos/events", nowPlayingEventsHandler(service, nowPlayingHub))
+
 	router.Route("/v1/sonos/groups", func(groups chi.Router) {
 		groups.Method(http.MethodGet, "/", api.Handler(func(w http.ResponseWriter, r *http.Request) error {
 			udn := r.URL.Query().Get("udn")
@@ -245,12 +375,12 @@ func RegisterRoutes(router chi.Router, service *Service) {
 
 			deviceIP, err := service.ResolveDeviceIP(udn)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to resolve device")
+				return deviceFailure(err, udn, "", "Failed to resolve device")
 			}
 
 			zoneState, err := service.GetZoneGroupState(deviceIP)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to fetch zone group state")
+				return deviceFailure(err, udn, deviceIP, "Failed to fetch zone group state")
 			}
 
 			groupsResponse := make([]map[string]any, 0, len(zoneState.Groups))
@@ -313,7 +443,7 @@ func RegisterRoutes(router chi.Router, service *Service) {
 
 			coordinatorIP, err := service.ResolveDeviceIP(body.CoordinatorUDN)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to resolve coordinator device")
+				return deviceFailure(err, body.CoordinatorUDN, "", "Failed to resolve coordinator device")
 			}
 
 			memberIPs := make([]string, 0, len(memberUDNs))
@@ -328,12 +458,12 @@ func RegisterRoutes(router chi.Router, service *Service) {
 
 			zoneAttrs, err := service.GetZoneAttributes(coordinatorIP)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to fetch zone attributes")
+				return deviceFailure(err, body.CoordinatorUDN, coordinatorIP, "Failed to fetch zone attributes")
 			}
 
 			topology, err := service.GetZoneGroupState(coordinatorIP)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to fetch zone group state")
+				return deviceFailure(err, body.CoordinatorUDN, coordinatorIP, "Failed to fetch zone group state")
 			}
 
 			coordinatorUUID := ""
@@ -398,6 +528,9 @@ func RegisterRoutes(router chi.Router, service *Service) {
 			})
 		}))
 
+		groups.Method(http.MethodPost, "/party", startPartyHandler(service))
+		groups.Method(http.MethodPost, "/restore/{snapshot_id}", restorePartyHandler(service))
+
 		groups.Method(http.MethodPost, "/ungroup", api.Handler(func(w http.ResponseWriter, r *http.Request) error {
 			var body struct {
 				UDNs []string `json:"udns"`
@@ -482,6 +615,12 @@ func RegisterRoutes(router chi.Router, service *Service) {
 		}))
 	})
 
+	router.Route("/v1/sonos/queue", func(queue chi.Router) {
+		queue.Method(http.MethodGet, "/", listQueueHandler(service))
+		queue.Method(http.MethodPost, "/add", addToQueueHandler(service))
+		queue.Method(http.MethodPost, "/clear", clearQueueHandler(service))
+	})
+
 	router.Route("/v1/sonos/volume", func(volume chi.Router) {
 		volume.Method(http.MethodPost, "/", api.Handler(func(w http.ResponseWriter, r *http.Request) error {
 			var body struct {
@@ -505,12 +644,12 @@ func RegisterRoutes(router chi.Router, service *Service) {
 
 			deviceIP, err := service.ResolveDeviceIP(body.UDN)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to resolve device")
+				return deviceFailure(err, body.UDN, "", "Failed to resolve device")
 			}
 
 			currentVolume, err := service.GetVolume(deviceIP)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to fetch volume")
+				return deviceFailure(err, body.UDN, deviceIP, "Failed to fetch volume")
 			}
 
 			memberIPs := getGroupMemberIPs(service, deviceIP)
@@ -520,13 +659,20 @@ func RegisterRoutes(router chi.Router, service *Service) {
 
 			target := int(math.Round(*body.Volume))
 			ramped := false
+			var rampID any
 			var results []deviceVolumeResult
 			if body.Ramp != nil && body.Ramp.Enabled && body.Ramp.DurationMs != nil && *body.Ramp.DurationMs > 0 {
 				curve := body.Ramp.Curve
 				if curve == "" {
 					curve = "linear"
 				}
-				results = executeVolumeRamp(service, memberIPs, currentVolume.CurrentVolume, target, *body.Ramp.DurationMs, curve)
+				// Wait for the ramp so the response reports its outcome
+				ramp := ramps.Start(body.UDN, memberIPs, currentVolume.CurrentVolume, target, *body.Ramp.DurationMs, curve)
+				<-ramp.done
+				ramp.mu.Lock()
+				results = ramp.results
+				ramp.mu.Unlock()
+				rampID = ramp.id
 				ramped = true
 			} else {
 				results = setVolumeOnDevices(service, memberIPs, target)
@@ -534,16 +680,20 @@ func RegisterRoutes(router chi.Router, service *Service) {
 
 			succeeded, failed := countResults(results)
 
-			return api.WriteAction(w, http.StatusOK, map[string]any{
+			response := map[string]any{
 				"object":          "volume_action",
 				"udn":             body.UDN,
 				"volume":          target,
 				"previous_volume": currentVolume.CurrentVolume,
 				"ramped":          ramped,
+				"ramp_id":         rampID,
 				"all_succeeded":   failed == 0,
 				"succeeded_count": succeeded,
 				"failed_count":    failed,
-			})
+			}
+			addDebugTargets(r, response, deviceIP, memberIPs)
+
+			return api.WriteAction(w, http.StatusOK, response)
 		}))
 
 		volume.Method(http.MethodPost, "/set", api.Handler(func(w http.ResponseWriter, r *http.Request) error {
@@ -563,12 +713,12 @@ func RegisterRoutes(router chi.Router, service *Service) {
 
 			deviceIP, err := service.ResolveDeviceIP(body.UDN)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to resolve device")
+				return deviceFailure(err, body.UDN, "", "Failed to resolve device")
 			}
 
 			currentVolume, err := service.GetVolume(deviceIP)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to fetch volume")
+				return deviceFailure(err, body.UDN, deviceIP, "Failed to fetch volume")
 			}
 
 			memberIPs := getGroupMemberIPs(service, deviceIP)
@@ -580,7 +730,7 @@ func RegisterRoutes(router chi.Router, service *Service) {
 			results := setVolumeOnDevices(service, memberIPs, target)
 			succeeded, failed := countResults(results)
 
-			return api.WriteAction(w, http.StatusOK, map[string]any{
+			response := map[string]any{
 				"object":          "volume_action",
 				"udn":             body.UDN,
 				"level":           target,
@@ -588,70 +738,21 @@ func RegisterRoutes(router chi.Router, service *Service) {
 				"all_succeeded":   failed == 0,
 				"succeeded_count": succeeded,
 				"failed_count":    failed,
-			})
-		}))
-
-		volume.Method(http.MethodPost, "/ramp", api.Handler(func(w http.ResponseWriter, r *http.Request) error {
-			var body struct {
-				UDN         string   `json:"udn"`
-				TargetLevel *float64 `json:"target_level"`
-				DurationMs  *int     `json:"duration_ms"`
-				Curve       string   `json:"curve"`
-			}
-			if err := decodeJSON(r, &body); err != nil {
-				return apperrors.NewValidationError("udn is required", nil)
-			}
-			if body.UDN == "" {
-				return apperrors.NewValidationError("udn is required", nil)
-			}
-			if body.TargetLevel == nil || *body.TargetLevel < 0 || *body.TargetLevel > 100 {
-				return apperrors.NewValidationError("target_level must be a number between 0 and 100", nil)
-			}
-
-			durationMs := 2000
-			if body.DurationMs != nil {
-				durationMs = *body.DurationMs
-			}
-			if durationMs < 0 {
-				return apperrors.NewValidationError("duration_ms must be non-negative", nil)
-			}
-
-			curve := body.Curve
-			if curve == "" {
-				curve = "linear"
-			}
-
-			deviceIP, err := service.ResolveDeviceIP(body.UDN)
-			if err != nil {
-				return apperrors.NewInternalError("Failed to resolve device")
 			}
+			addDebugTargets(r, response, deviceIP, memberIPs)
 
-			currentVolume, err := service.GetVolume(deviceIP)
-			if err != nil {
-				return apperrors.NewInternalError("Failed to fetch volume")
-			}
+			return api.WriteAction(w, http.StatusOK, response)
+		}))
 
-			memberIPs := getGroupMemberIPs(service, deviceIP)
-			if len(memberIPs) == 0 {
-				return apperrors.NewValidationError("No devices resolved for volume control", nil)
-			}
+		volume.Method(http.MethodGet, "/group", getGroupVolumeHandler(service))
+		volume.Method(http.MethodPost, "/group", setGroupVolumeHandler(service))
 
-			target := int(math.Round(*body.TargetLevel))
-			results := executeVolumeRamp(service, memberIPs, currentVolume.CurrentVolume, target, durationMs, curve)
-			succeeded, failed := countResults(results)
+		volume.Method(http.MethodPost, "/ramp", startVolumeRampHandler(service, ramps))
+		volume.Method(http.MethodGet, "/ramps/{ramp_id}", getVolumeRampHandler(ramps))
+		volume.Method(http.MethodDelete, "/ramps/{ramp_id}", cancelVolumeRampHandler(ramps))
 
-			return api.WriteAction(w, http.StatusOK, map[string]any{
-				"object":          "volume_ramp",
-				"udn":             body.UDN,
-				"start_level":     currentVolume.CurrentVolume,
-				"target_level":    target,
-				"duration_ms":     durationMs,
-				"curve":           curve,
-				"all_succeeded":   failed == 0,
-				"succeeded_count": succeeded,
-				"failed_count":    failed,
-			})
-		}))
+		volume.Method(http.MethodGet, "/mute", getMuteHandler(service))
+		volume.Method(http.MethodPost, "/mute", setMuteHandler(service))
 	})
 
 	router.Method(http.MethodGet, "/v1/sonos/alarms", api.Handler(func(w http.ResponseWriter, r *http.Request) error {
@@ -662,7 +763,7 @@ func RegisterRoutes(router chi.Router, service *Service) {
 
 		deviceIP, err := service.ResolveDeviceIP(udn)
 		if err != nil {
-			return apperrors.NewInternalError("Failed to resolve device")
+			return deviceFailure(err, udn, "", "Failed to resolve device")
 		}
 
 		result, err := service.ListAlarms(deviceIP)
@@ -672,19 +773,7 @@ func RegisterRoutes(router chi.Router, service *Service) {
 
 		alarms := make([]map[string]any, 0, len(result.Alarms))
 		for _, alarm := range result.Alarms {
-			alarms = append(alarms, map[string]any{
-				"id":                   alarm.ID,
-				"start_time":           alarm.StartTime,
-				"duration":             alarm.Duration,
-				"recurrence":           alarm.Recurrence,
-				"enabled":              alarm.Enabled,
-				"room_uuid":            alarm.RoomUUID,
-				"program_uri":          alarm.ProgramURI,
-				"program_metadata":     alarm.ProgramMetaData,
-				"play_mode":            alarm.PlayMode,
-				"volume":               alarm.Volume,
-				"include_linked_zones": alarm.IncludeLinkedZones,
-			})
+			alarms = append(alarms, formatAlarm(alarm))
 		}
 
 		return api.WriteResource(w, http.StatusOK, map[string]any{
@@ -694,6 +783,10 @@ func RegisterRoutes(router chi.Router, service *Service) {
 		})
 	}))
 
+	router.Method(http.MethodPost, "/v1/sonos/alarms", createAlarmHandler(service))
+	router.Method(http.MethodPatch, "/v1/sonos/alarms/{id}", updateAlarmHandler(service))
+	router.Method(http.MethodDelete, "/v1/sonos/alarms/{id}", deleteAlarmHandler(service))
+
 	router.Method(http.MethodGet, "/v1/sonos/favorites", api.Handler(func(w http.ResponseWriter, r *http.Request) error {
 		startStr := r.URL.Query().Get("start")
 		countStr := r.URL.Query().Get("count")
@@ -716,58 +809,78 @@ func RegisterRoutes(router chi.Router, service *Service) {
 			requestedCount = val
 		}
 
-		result, err := service.BrowseFavorites(startIndex, requestedCount)
+		// Optional set_id annotates each favorite with in_set (off by default to skip the extra query)
+		var setFavoriteIDs map[string]bool
+		if setID := r.URL.Query().Get("set_id"); setID != "" {
+			if service.SetMembership == nil {
+				return apperrors.NewAppError("SERVICE_UNAVAILABLE", "Music catalog not available", 503, nil, nil)
+			}
+			ids, err := service.SetMembership.SetFavoriteIDs(setID)
+			if err != nil {
+				return apperrors.NewInternalError("Failed to fetch set items")
+			}
+			if ids == nil {
+				return apperrors.NewAppError(apperrors.ErrorCodeSetNotFound, "Set not found", 404, map[string]any{"set_id": setID}, nil)
+			}
+			setFavoriteIDs = ids
+		}
+
+		items, cacheAge, err := service.CachedFavorites()
 		if err != nil {
 			return apperrors.NewInternalError("Failed to fetch favorites")
 		}
 
-		favorites := make([]map[string]any, 0, len(result.Items))
-		for _, fav := range result.Items {
-			// Detect content type from upnp:class
-			contentType := fav.ContentType
-			if contentType == "" {
-				contentType = detectContentTypeFromClass(fav.UpnpClass, fav.Resource)
-			}
-
-			// Fall back to detecting service name from resource/metadata
-			serviceName := fav.ServiceName
-			if serviceName == "" {
-				serviceName = detectServiceName(fav.Resource, fav.ResourceMetaData)
-			}
-
-			// Fall back to deriving logo URL from service name
-			serviceLogoURL := fav.ServiceLogoURL
-			if serviceLogoURL == "" && serviceName != "" {
-				serviceLogoURL = GetServiceLogoFromName(serviceName)
-			}
-
-			// Convert ordinal to integer (Node.js returns number)
-			ordinal, _ := strconv.Atoi(fav.Ordinal)
-
-			favorites = append(favorites, map[string]any{
-				"id":                fav.ID,
-				"parent_id":         fav.ParentID,
-				"title":             fav.Title,
-				"ordinal":           ordinal,
-				"upnp_class":        fav.UpnpClass,
-				"content_type":      contentType,
-				"favorite_type":     fav.FavoriteType,
-				"service_name":      serviceName,
-				"service_logo_url":  serviceLogoURL,
-				"album_art_uri":     fav.AlbumArtURI,
-				"resource":          fav.Resource,
-				"protocol_info":     fav.ProtocolInfo,
-				"resource_metadata": fav.ResourceMetaData,
-			})
+		page, hasMore := pageFavorites(items, startIndex, requestedCount)
+		favorites := make([]map[string]any, 0, len(page))
+		for _, fav := range page {
+			favorite := formatFavorite(fav)
+			if setFavoriteIDs != nil {
+				favorite["in_set"] = setFavoriteIDs[fav.ID]
+			}
+			favorites = append(favorites, favorite)
+		}
+
+		return writeFavoritesList(w, r, "/v1/sonos/favorites", favorites, hasMore, cacheAge)
+	}))
+
+	// Newest favorites first, by ordinal (Sonos has no add-date)
+	router.Method(http.MethodGet, "/v1/sonos/favorites/recent", api.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		count := 10
+		if countStr := r.URL.Query().Get("count"); countStr != "" {
+			val, err := strconv.Atoi(countStr)
+			if err != nil || val < 1 || val > 100 {
+				return apperrors.NewValidationError("count must be an integer between 1 and 100", nil)
+			}
+			count = val
+		}
+
+		items, cacheAge, err := service.CachedFavorites()
+		if err != nil {
+			return apperrors.NewInternalError("Failed to fetch favorites")
 		}
 
-		// Add object field to each favorite
-		for i := range favorites {
-			favorites[i]["object"] = "favorite"
+		recent := RecentFavorites(items, count)
+		favorites := make([]map[string]any, 0, len(recent))
+		for _, fav := range recent {
+			favorites = append(favorites, formatFavorite(fav))
 		}
 
-		hasMore := startIndex+len(favorites) < result.TotalMatches
-		return api.WriteList(w, "/v1/sonos/favorites", favorites, hasMore)
+		return writeFavoritesList(w, r, "/v1/sonos/favorites/recent", favorites, len(items) > len(recent), cacheAge)
+	}))
+
+	// Called by the app after the user edits favorites so the next list and playback see the change
+	router.Method(http.MethodPost, "/v1/sonos/favorites/refresh", api.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		items, err := service.RefreshFavorites()
+		if err != nil {
+			return apperrors.NewInternalError("Failed to fetch favorites")
+		}
+
+		return api.WriteAction(w, http.StatusOK, map[string]any{
+			"object":          "favorites_refresh",
+			"refreshed":       true,
+			"total_favorites": len(items),
+			"refreshed_at":    api.RFC3339Millis(time.Now()),
+		})
 	}))
 
 	router.Route("/v1/sonos/players", func(players chi.Router) {
@@ -779,12 +892,12 @@ func RegisterRoutes(router chi.Router, service *Service) {
 
 			deviceIP, err := service.ResolveDeviceIP(udn)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to resolve device")
+				return deviceFailure(err, udn, "", "Failed to resolve device")
 			}
 
 			zoneState, err := service.GetZoneGroupState(deviceIP)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to fetch zone group state")
+				return deviceFailure(err, udn, deviceIP, "Failed to fetch zone group state")
 			}
 
 			playersResponse := make([]map[string]any, 0)
@@ -823,33 +936,36 @@ func RegisterRoutes(router chi.Router, service *Service) {
 
 			deviceIP, err := service.ResolveDeviceIP(udn)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to resolve device")
+				return deviceFailure(err, udn, "", "Failed to resolve device")
 			}
 
 			transportInfo, err := service.GetTransportInfo(deviceIP)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to fetch transport info")
+				return deviceFailure(err, udn, deviceIP, "Failed to fetch transport info")
 			}
 			positionInfo, err := service.GetPositionInfo(deviceIP)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to fetch position info")
+				return deviceFailure(err, udn, deviceIP, "Failed to fetch position info")
 			}
 			volumeInfo, err := service.GetVolume(deviceIP)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to fetch volume")
+				return deviceFailure(err, udn, deviceIP, "Failed to fetch volume")
 			}
 			muteInfo, err := service.GetMute(deviceIP)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to fetch mute state")
+				return deviceFailure(err, udn, deviceIP, "Failed to fetch mute state")
 			}
 
 			var currentTrack any = nil
 			if positionInfo.TrackURI != "" {
 				currentTrack = map[string]any{
-					"uri":      positionInfo.TrackURI,
-					"duration": positionInfo.TrackDuration,
-					"position": positionInfo.RelTime,
-					"metadata": emptyToNil(positionInfo.TrackMetaData),
+					"uri":              positionInfo.TrackURI,
+					"duration":         positionInfo.TrackDuration,
+					"position":         positionInfo.RelTime,
+					"duration_seconds": ParseDuration(positionInfo.TrackDuration),
+					"position_seconds": ParseDuration(positionInfo.RelTime),
+					"track_number":     positionInfo.Track,
+					"metadata":         emptyToNil(positionInfo.TrackMetaData),
 				}
 			}
 
@@ -861,9 +977,14 @@ func RegisterRoutes(router chi.Router, service *Service) {
 				"volume":           volumeInfo.CurrentVolume,
 				"muted":            muteInfo.CurrentMute,
 				"current_track":    currentTrack,
+
+				"remaining_sleep_timer": remainingSleepTimer(service, deviceIP),
 			})
 		}))
 
+		players.Method(http.MethodGet, "/{udn}/audio-settings", getAudioSettingsHandler(service))
+		players.Method(http.MethodPost, "/{udn}/audio-settings", setAudioSettingsHandler(service))
+
 		players.Method(http.MethodGet, "/{udn}/tv-status", api.Handler(func(w http.ResponseWriter, r *http.Request) error {
 			udn := chi.URLParam(r, "udn")
 			if udn == "" {
@@ -872,35 +993,29 @@ func RegisterRoutes(router chi.Router, service *Service) {
 
 			deviceIP, err := service.ResolveDeviceIP(udn)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to resolve device")
+				return deviceFailure(err, udn, "", "Failed to resolve device")
 			}
 
 			positionInfo, err := service.GetPositionInfo(deviceIP)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to fetch position info")
+				return deviceFailure(err, udn, deviceIP, "Failed to fetch position info")
 			}
 			transportInfo, err := service.GetTransportInfo(deviceIP)
 			if err != nil {
-				return apperrors.NewInternalError("Failed to fetch transport info")
+				return deviceFailure(err, udn, deviceIP, "Failed to fetch transport info")
 			}
 
 			currentURI := positionInfo.TrackURI
-			isTVInput := false
-			for _, pattern := range tvInputPatterns {
-				if strings.HasPrefix(currentURI, pattern) {
-					isTVInput = true
-					break
-				}
-			}
+			isTVInput := IsTVInputURI(currentURI) || IsLineInURI(currentURI)
 
 			isPlaying := transportInfo.CurrentTransportState == "PLAYING"
 			isTVActive := isTVInput && isPlaying
 
 			source := "idle"
 			switch {
-			case strings.HasPrefix(currentURI, "x-sonos-htastream:") || strings.HasPrefix(currentURI, "x-sonos-vli:"):
+			case IsTVInputURI(currentURI):
 				source = "tv"
-			case strings.HasPrefix(currentURI, "x-rincon-stream:"):
+			case IsLineInURI(currentURI):
 				source = "line-in"
 			case currentURI != "":
 				source = "music"
@@ -970,6 +1085,9 @@ func RegisterPlayRoutes(router chi.Router, playService *PlayService) {
 			}
 			return apperrors.NewInternalError("Failed to play favorite: " + err.Error())
 		}
+		if r.URL.Query().Get("debug") != "true" {
+			result.StripDebug()
+		}
 
 		return api.WriteAction(w, http.StatusOK, result)
 	}))
@@ -988,17 +1106,36 @@ func RegisterPlayRoutes(router chi.Router, playService *PlayService) {
 		if req.Content.Type == "" {
 			return apperrors.NewValidationError("content.type is required", nil)
 		}
+		if req.Content.Type == "local_file" && (req.Content.Filename == nil || *req.Content.Filename == "") {
+			return apperrors.NewValidationError("content.filename is required for local_file content", nil)
+		}
 
 		result, err := playService.PlayContent(r.Context(), req)
 		if err != nil {
 			if _, ok := err.(*ServiceNotSupportedError); ok {
 				return apperrors.NewValidationError(err.Error(), nil)
 			}
-			if _, ok := err.(*ServiceNeedsBootstrapError); ok {
+			if _, ok := err.(*ContentUnavailableError); ok {
 				return apperrors.NewValidationError(err.Error(), nil)
 			}
+			if bootstrapErr, ok := err.(*ServiceNeedsBootstrapError); ok {
+				return apperrors.NewAppError(
+					apperrors.ErrorCodeServiceNotBootstrapped,
+					err.Error(),
+					400,
+					map[string]any{"service": bootstrapErr.Service},
+					&apperrors.Remediation{
+						Action:     "add_favorite",
+						Endpoint:   "/v1/sonos/services",
+						UserAction: fmt.Sprintf("Add a %s favorite in the Sonos app on this household", serviceDisplayNames[bootstrapErr.Service]),
+					},
+				)
+			}
 			return apperrors.NewInternalError("Failed to play content: " + err.Error())
 		}
+		if r.URL.Query().Get("debug") != "true" {
+			result.StripDebug()
+		}
 
 		return api.WriteAction(w, http.StatusOK, result)
 	}))
@@ -1074,6 +1211,174 @@ type soapMember struct {
 	HdmiCecAvailable bool
 }
 
+// NowPlayingGroups returns every group's now-playing entry, as GET
+// /v1/sonos/playback/now-playing does, asking an online device. Returns an empty list
+// when there is no device to ask.
+func (service *Service) NowPlayingGroups() ([]map[string]any, error) {
+	entryIP := service.EntryDeviceIP()
+	if entryIP == "" {
+		return []map[string]any{}, nil
+	}
+	groups, _, err := fetchNowPlayingGroups(service, entryIP, false, false)
+	return groups, err
+}
+
+// NowPlayingDevices returns a now-playing entry for every speaker in a playing group:
+// its group's entry from NowPlayingGroups, with the speaker's own udn, room_name, and
+// playback volume and muted. Grouped members' volume and mute are read from each member;
+// members that don't answer are left out.
+func (service *Service) NowPlayingDevices() ([]map[string]any, error) {
+	entryIP := service.EntryDeviceIP()
+	if entryIP == "" {
+		return []map[string]any{}, nil
+	}
+	zoneState, err := service.GetZoneGroupStateCached(entryIP)
+	if err != nil {
+		return nil, err
+	}
+	groups, _, err := fetchNowPlayingGroups(service, entryIP, false, false)
+	if err != nil {
+		return nil, err
+	}
+	uuidToIP := BuildUUIDToIPMap(zoneState)
+	coordinators := make(map[string]CoordinatorInfo)
+	for _, coord := range ExtractCoordinators(zoneState, uuidToIP) {
+		coordinators[coord.UUID] = coord
+	}
+
+	devices := make([]map[string]any, 0, len(groups))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, group := range groups {
+		udn, _ := group["coordinator_id"].(string)
+		playback, _ := group["playback"].(map[string]any)
+		devices = append(devices, nowPlayingDevice(group, udn, group["room_name"], playback["volume"], playback["muted"]))
+
+		coord := coordinators[udn]
+		for i, memberUUID := range coord.MemberUUIDs {
+			memberIP := uuidToIP[memberUUID]
+			if memberIP == "" {
+				continue
+			}
+			wg.Add(1)
+			go func(group map[string]any, memberUUID, roomName, memberIP string) {
+				defer wg.Done()
+				var volume soap.VolumeInfo
+				var mute soap.MuteInfo
+				err := service.Limiter.Do(memberIP, func() (err error) {
+					if volume, err = service.GetVolume(memberIP); err != nil {
+						return err
+					}
+					mute, err = service.GetMute(memberIP)
+					return err
+				})
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				devices = append(devices, nowPlayingDevice(group, memberUUID, roomName, volume.CurrentVolume, mute.CurrentMute))
+				mu.Unlock()
+			}(group, memberUUID, coord.MemberRooms[i], memberIP)
+		}
+	}
+	wg.Wait()
+	return devices, nil
+}
+
+// nowPlayingDevice is a speaker's now-playing entry: a copy of its group's entry with
+// the speaker's own udn, room name, volume and mute.
+func nowPlayingDevice(group map[string]any, udn string, roomName, volume, muted any) map[string]any {
+	device := make(map[string]any, len(group)+1)
+	for key, value := range group {
+		device[key] = value
+	}
+	device["udn"] = udn
+	device["room_name"] = roomName
+
+	groupPlayback, _ := group["playback"].(map[string]any)
+	playback := make(map[string]any, len(groupPlayback))
+	for key, value := range groupPlayback {
+		playback[key] = value
+	}
+	playback["volume"] = volume
+	playback["muted"] = muted
+	device["playback"] = playback
+	return device
+}
+
+// fetchNowPlayingGroups builds the now-playing entry for every group in the household,
+// as seen from entryIP. includeDebug adds each group's data source; useAliases shows the
+// speakers' aliases as their room names.
+func fetchNowPlayingGroups(service *Service, entryIP string, includeDebug, useAliases bool) ([]map[string]any, map[string]DataSource, error) {
+	// Use cached zone group state (30s TTL by default)
+	zoneState, err := service.GetZoneGroupStateCached(entryIP)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Build UUID to IP mapping
+	uuidToIP := BuildUUIDToIPMap(zoneState)
+
+	// Extract coordinator info for parallel fetching
+	coordinators := ExtractCoordinators(zoneState, uuidToIP)
+
+	// Fetch all groups using hybrid approach (cache-first with SOAP fallback)
+	results, dataSources := FetchAllGroupsPlaybackHybrid(service, coordinators)
+
+	// Members can be muted individually, so summarize mute across each whole group
+	muteSummaries := FetchAllGroupMutes(service, results)
+
+	// Build response from hybrid results
+	groups := make([]map[string]any, 0, len(results))
+	for i, result := range results {
+		// Convert HybridGroupResult to GroupPlaybackResult for buildNowPlayingGroup
+		groupResult := GroupPlaybackResult{
+			Coordinator: result.Coordinator,
+			Playback:    result.Playback.GroupPlaybackInfo,
+		}
+		groupData := buildNowPlayingGroup(groupResult)
+		if groupData != nil {
+			groupData["group_mute"] = map[string]any{
+				"all_muted": muteSummaries[i].AllMuted,
+				"any_muted": muteSummaries[i].AnyMuted,
+			}
+			if useAliases && service.DeviceService != nil {
+				applyRoomAliases(groupData, result.Coordinator, service.DeviceService.Alias)
+			}
+			// Add data source to group if debugging
+			if includeDebug {
+				groupData["_data_source"] = string(result.Playback.Source)
+				if result.Playback.Source == DataSourceCache {
+					groupData["_cache_age_ms"] = result.Playback.CacheAge.Milliseconds()
+				}
+			}
+			groups = append(groups, groupData)
+		}
+	}
+
+	return groups, dataSources, nil
+}
+
+// applyRoomAliases replaces a now-playing group's room names with the speakers' aliases,
+// keeping the coordinator's Sonos room name as sonos_room_name.
+func applyRoomAliases(group map[string]any, coord CoordinatorInfo, alias func(udn string) string) {
+	group["sonos_room_name"] = coord.ZoneName
+	if name := alias(coord.UUID); name != "" {
+		group["room_name"] = name
+	}
+
+	memberRooms := make([]string, len(coord.MemberRooms))
+	for i, room := range coord.MemberRooms {
+		memberRooms[i] = room
+		if i < len(coord.MemberUUIDs) {
+			if name := alias(coord.MemberUUIDs[i]); name != "" {
+				memberRooms[i] = name
+			}
+		}
+	}
+	group["member_rooms"] = memberRooms
+}
+
 // buildNowPlayingGroup builds the response map for a single group from parallel fetch results.
 func buildNowPlayingGroup(result GroupPlaybackResult) map[string]any {
 	coord := result.Coordinator
@@ -1134,7 +1439,7 @@ func buildNowPlayingGroup(result GroupPlaybackResult) map[string]any {
 		if metadata != nil {
 			albumArt := metadata.AlbumArtURI
 			if albumArt != "" {
-				albumArt = normalizeAlbumArtURI(albumArt, coord.IP)
+				albumArt = NormalizeAlbumArtURI(albumArt, coord.IP)
 			}
 
 			serviceLogo := ""
@@ -1210,14 +1515,13 @@ type deviceVolumeResult struct {
 	Error   string
 }
 
-func normalizeAlbumArtURI(uri string, deviceIP string) string {
-	if strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://") {
-		return uri
-	}
+// NormalizeAlbumArtURI makes device-relative album art paths absolute, then routes them
+// through the artwork proxy when ARTWORK_PROXY_ENABLED is set.
+func NormalizeAlbumArtURI(uri string, deviceIP string) string {
 	if strings.HasPrefix(uri, "/") {
-		return "http://" + deviceIP + ":1400" + uri
+		uri = "http://" + deviceIP + ":1400" + uri
 	}
-	return uri
+	return artwork.RewriteURL(uri)
 }
 
 // detectContentTypeFromClass detects content type from upnp:class and resource URI.
@@ -1225,7 +1529,12 @@ func detectContentTypeFromClass(upnpClass, resource string) string {
 	class := strings.ToLower(upnpClass)
 	res := strings.ToLower(resource)
 
-	// Check for radio/streaming first
+	// Pandora and iHeartRadio stations, whatever class the favorite was saved with
+	if radioServiceForResource(resource) != nil {
+		return "station"
+	}
+
+	// Then any other radio or stream
 	if strings.Contains(class, "audiobroadcast") || strings.Contains(class, "radio") ||
 		strings.Contains(res, "x-sonosapi-stream") || strings.Contains(res, "x-sonosapi-radio") {
 		return "station"
@@ -1253,17 +1562,62 @@ func emptyToNil(value string) any {
 	return value
 }
 
+// formatPlayMode formats a group's play mode for the play-mode endpoints.
+func formatPlayMode(udn string, playMode PlayMode) map[string]any {
+	return map[string]any{
+		"object":    "play_mode",
+		"udn":       udn,
+		"shuffle":   playMode.Shuffle,
+		"repeat":    playMode.Repeat,
+		"crossfade": playMode.Crossfade,
+	}
+}
+
+// validateSeekPosition checks that a seek target falls within the current track.
+// Streams report no duration and can't be sought within.
+func validateSeekPosition(positionSeconds int, trackDuration string) error {
+	duration := ParseDuration(trackDuration)
+	if duration == 0 {
+		return apperrors.NewValidationError("The current track does not support seeking", nil)
+	}
+	if positionSeconds > duration {
+		return apperrors.NewValidationError(fmt.Sprintf("position_seconds exceeds the track duration of %d seconds", duration), nil)
+	}
+	return nil
+}
+
+// addDebugTargets adds the resolved device IP (and, for group operations, the member IPs
+// the command was sent to) when the request has ?debug=true. IPs are omitted by default.
+func addDebugTargets(r *http.Request, response map[string]any, resolvedIP string, targetIPs []string) {
+	if r.URL.Query().Get("debug") != "true" {
+		return
+	}
+	response["_resolved_ip"] = resolvedIP
+	if targetIPs != nil {
+		response["_target_ips"] = targetIPs
+	}
+}
+
+// writeFavoritesList writes a favorites list. With ?debug=true it also reports how old
+// the cached favorites are, in milliseconds.
+func writeFavoritesList(w http.ResponseWriter, r *http.Request, url string, favorites []map[string]any, hasMore bool, cacheAge time.Duration) error {
+	if r.URL.Query().Get("debug") != "true" {
+		return api.WriteList(w, url, favorites, hasMore)
+	}
+	return api.WriteJSON(w, http.StatusOK, map[string]any{
+		"object":        "list",
+		"data":          favorites,
+		"has_more":      hasMore,
+		"url":           url,
+		"_cache_age_ms": cacheAge.Milliseconds(),
+	})
+}
+
 func decodeJSON(r *http.Request, dst any) error {
 	decoder := json.NewDecoder(r.Body)
 	return decoder.Decode(dst)
 }
 
-var tvInputPatterns = []string{
-	"x-sonos-htastream:",
-	"x-rincon-stream:",
-	"x-sonos-vli:",
-}
-
 // ipRegex extracts IP address from Sonos location URLs (e.g., "http://192.168.1.10:1400/xml/device_description.xml")
 var ipRegex = regexp.MustCompile(`http://([^:]+):`)
 
@@ -1304,49 +1658,24 @@ func getGroupMemberIPs(service *Service, targetDeviceIP string) []string {
 }
 
 func setVolumeOnDevices(service *Service, memberIPs []string, level int) []deviceVolumeResult {
-	results := make([]deviceVolumeResult, len(memberIPs))
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-
-	for i, ip := range memberIPs {
-		wg.Add(1)
-		go func(idx int, targetIP string) {
-			defer wg.Done()
-			err := service.SetVolume(targetIP, level)
-			result := deviceVolumeResult{IP: targetIP, Success: err == nil}
-			if err != nil {
-				result.Error = err.Error()
-			}
-			mu.Lock()
-			results[idx] = result
-			mu.Unlock()
-		}(i, ip)
-	}
-
-	wg.Wait()
-	return results
+	return setVolumes(context.Background(), serviceVolumeSetter(service), memberIPs, level)
 }
 
-func executeVolumeRamp(service *Service, memberIPs []string, startLevel, targetLevel, durationMs int, curve string) []deviceVolumeResult {
-	if durationMs <= 0 {
-		return setVolumeOnDevices(service, memberIPs, targetLevel)
-	}
-
-	if startLevel == targetLevel {
-		results := make([]deviceVolumeResult, 0, len(memberIPs))
-		for _, ip := range memberIPs {
-			results = append(results, deviceVolumeResult{IP: ip, Success: true})
-		}
-		return results
-	}
+// SetGroupVolume sets every visible speaker grouped with deviceIP to level, in parallel,
+// and returns how many speakers succeeded and failed.
+func (service *Service) SetGroupVolume(ctx context.Context, deviceIP string, level int) (int, int) {
+	return countResults(setVolumes(ctx, serviceVolumeSetter(service), getGroupMemberIPs(service, deviceIP), level))
+}
 
+// VolumeRampSteps splits a ramp from startLevel to targetLevel over durationMs into ~50ms
+// steps shaped by curve (linear, ease-in, or ease-out; anything else is linear). Returns
+// the level for each step, ending at targetLevel, and the delay between steps.
+func VolumeRampSteps(startLevel, targetLevel, durationMs int, curve string) ([]int, time.Duration) {
 	stepCount := int(math.Max(1, float64(durationMs/50)))
 	levelDiff := float64(targetLevel - startLevel)
 	stepDelay := time.Duration(float64(durationMs)/float64(stepCount)) * time.Millisecond
 
-	failedDevices := map[string]struct{}{}
-	var lastResults []deviceVolumeResult
-
+	levels := make([]int, stepCount)
 	for step := 1; step <= stepCount; step++ {
 		progress := float64(step) / float64(stepCount)
 		switch curve {
@@ -1355,61 +1684,10 @@ func executeVolumeRamp(service *Service, memberIPs []string, startLevel, targetL
 		case "ease-out":
 			progress = 1 - math.Pow(1-progress, 2)
 		}
-
-		newLevel := int(math.Round(float64(startLevel) + levelDiff*progress))
-		activeIPs := make([]string, 0, len(memberIPs))
-		for _, ip := range memberIPs {
-			if _, ok := failedDevices[ip]; !ok {
-				activeIPs = append(activeIPs, ip)
-			}
-		}
-		if len(activeIPs) == 0 {
-			break
-		}
-
-		stepResults := setVolumeOnDevices(service, activeIPs, newLevel)
-		for _, result := range stepResults {
-			if !result.Success {
-				failedDevices[result.IP] = struct{}{}
-			}
-		}
-		lastResults = stepResults
-
-		if step < stepCount {
-			time.Sleep(stepDelay)
-		}
-	}
-
-	finalIPs := make([]string, 0, len(memberIPs))
-	for _, ip := range memberIPs {
-		if _, ok := failedDevices[ip]; !ok {
-			finalIPs = append(finalIPs, ip)
-		}
-	}
-	if len(finalIPs) > 0 {
-		lastResults = setVolumeOnDevices(service, finalIPs, targetLevel)
-	}
-
-	results := make([]deviceVolumeResult, 0, len(memberIPs))
-	for _, ip := range memberIPs {
-		if _, ok := failedDevices[ip]; ok {
-			results = append(results, deviceVolumeResult{IP: ip, Success: false, Error: "Device failed during ramp"})
-			continue
-		}
-		found := false
-		for _, res := range lastResults {
-			if res.IP == ip {
-				results = append(results, res)
-				found = true
-				break
-			}
-		}
-		if !found {
-			results = append(results, deviceVolumeResult{IP: ip, Success: false, Error: "Result not found"})
-		}
+		levels[step-1] = int(math.Round(float64(startLevel) + levelDiff*progress))
 	}
 
-	return results
+	return levels, stepDelay
 }
 
 func countResults(results []deviceVolumeResult) (int, int) {
@@ -1424,3 +1702,45 @@ func countResults(results []deviceVolumeResult) (int, int) {
 	}
 	return succeeded, failed
 }
+
+// formatFavorite formats a Sonos favorite for JSON response, filling in content type,
+// service name and logo when the favorite metadata omits them.
+func formatFavorite(fav soap.FavoriteItem) map[string]any {
+	// Detect content type from upnp:class
+	contentType := fav.ContentType
+	if contentType == "" {
+		contentType = detectContentTypeFromClass(fav.UpnpClass, fav.Resource)
+	}
+
+	// Fall back to detecting service name from resource/metadata
+	serviceName := fav.ServiceName
+	if serviceName == "" {
+		serviceName = detectServiceName(fav.Resource, fav.ResourceMetaData)
+	}
+
+	// Fall back to deriving logo URL from service name
+	serviceLogoURL := fav.ServiceLogoURL
+	if serviceLogoURL == "" && serviceName != "" {
+		serviceLogoURL = GetServiceLogoFromName(serviceName)
+	}
+
+	// Convert ordinal to integer (Node.js returns number); missing ordinals become 0
+	ordinal, _ := parseFavoriteOrdinal(fav.Ordinal)
+
+	return map[string]any{
+		"object":            "favorite",
+		"id":                fav.ID,
+		"parent_id":         fav.ParentID,
+		"title":             fav.Title,
+		"ordinal":           ordinal,
+		"upnp_class":        fav.UpnpClass,
+		"content_type":      contentType,
+		"favorite_type":     fav.FavoriteType,
+		"service_name":      serviceName,
+		"service_logo_url":  serviceLogoURL,
+		"album_art_uri":     fav.AlbumArtURI,
+		"resource":          fav.Resource,
+		"protocol_info":     fav.ProtocolInfo,
+		"resource_metadata": fav.ResourceMetaData,
+	}
+}
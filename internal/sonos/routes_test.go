@@ -0,0 +1,114 @@
+package sonos
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddDebugTargets(t *testing.T) {
+	t.Run("omitted without debug flag", func(t *testing.T) {
+		r := httptest.NewRequest("POST", "/v1/sonos/volume/set", nil)
+		response := map[string]any{"object": "volume_action"}
+		addDebugTargets(r, response, "192.168.1.20", []string{"192.168.1.20", "192.168.1.21"})
+
+		require.NotContains(t, response, "_resolved_ip")
+		require.NotContains(t, response, "_target_ips")
+	})
+
+	t.Run("included with debug=true", func(t *testing.T) {
+		r := httptest.NewRequest("POST", "/v1/sonos/volume/set?debug=true", nil)
+		response := map[string]any{"object": "volume_action"}
+		addDebugTargets(r, response, "192.168.1.20", []string{"192.168.1.20", "192.168.1.21"})
+
+		require.Equal(t, "192.168.1.20", response["_resolved_ip"])
+		require.Equal(t, []string{"192.168.1.20", "192.168.1.21"}, response["_target_ips"])
+	})
+
+	t.Run("single target omits member list", func(t *testing.T) {
+		r := httptest.NewRequest("POST", "/v1/sonos/playback/pause?debug=true", nil)
+		response := map[string]any{"object": "playback_action"}
+		addDebugTargets(r, response, "192.168.1.20", nil)
+
+		require.Equal(t, "192.168.1.20", response["_resolved_ip"])
+		require.NotContains(t, response, "_target_ips")
+	})
+}
+
+func TestVolumeRampSteps(t *testing.T) {
+	levels, stepDelay := VolumeRampSteps(0, 40, 1000, "linear")
+	require.Len(t, levels, 20)
+	require.Equal(t, 50*time.Millisecond, stepDelay)
+	require.Equal(t, 40, levels[len(levels)-1])
+	require.Equal(t, 20, levels[9])
+
+	easeIn, _ := VolumeRampSteps(0, 40, 1000, "ease-in")
+	easeOut, _ := VolumeRampSteps(0, 40, 1000, "ease-out")
+	require.Less(t, easeIn[9], levels[9])
+	require.Greater(t, easeOut[9], levels[9])
+	require.Equal(t, 40, easeIn[len(easeIn)-1])
+
+	// Short ramps still take one step to the target
+	levels, _ = VolumeRampSteps(30, 10, 20, "linear")
+	require.Equal(t, []int{10}, levels)
+}
+
+func TestValidateSeekPosition(t *testing.T) {
+	require.NoError(t, validateSeekPosition(95, "0:03:30"))
+	require.NoError(t, validateSeekPosition(210, "0:03:30"))
+	require.Error(t, validateSeekPosition(211, "0:03:30"))
+
+	// Streams have no duration to seek within
+	require.Error(t, validateSeekPosition(0, "0:00:00"))
+	require.Error(t, validateSeekPosition(10, "NOT_IMPLEMENTED"))
+	require.Error(t, validateSeekPosition(10, ""))
+}
+
+func TestFormatDuration(t *testing.T) {
+	require.Equal(t, "0:01:35", FormatDuration(95))
+	require.Equal(t, "1:02:03", FormatDuration(3723))
+	require.Equal(t, "0:00:00", FormatDuration(-5))
+	require.Equal(t, 3723, ParseDuration(FormatDuration(3723)))
+}
+
+func TestApplyRoomAliases(t *testing.T) {
+	aliases := map[string]string{"RINCON_KIDS": "Kids Room"}
+	coord := CoordinatorInfo{
+		UUID:        "RINCON_DEN",
+		ZoneName:    "Den",
+		MemberRooms: []string{"Den", "Bedroom 2"},
+		MemberUUIDs: []string{"RINCON_DEN", "RINCON_KIDS"},
+	}
+	group := map[string]any{"room_name": "Den", "member_rooms": coord.MemberRooms}
+
+	applyRoomAliases(group, coord, func(udn string) string { return aliases[udn] })
+	require.Equal(t, "Den", group["room_name"], "a coordinator without an alias keeps its room name")
+	require.Equal(t, "Den", group["sonos_room_name"])
+	require.Equal(t, []string{"Den", "Kids Room"}, group["member_rooms"])
+	require.Equal(t, []string{"Den", "Bedroom 2"}, coord.MemberRooms)
+
+	coord.UUID = "RINCON_KIDS"
+	applyRoomAliases(group, coord, func(udn string) string { return aliases[udn] })
+	require.Equal(t, "Kids Room", group["room_name"])
+}
+
+func TestNowPlayingDevice(t *testing.T) {
+	group := map[string]any{
+		"coordinator_id": "RINCON_DEN",
+		"room_name":      "Den",
+		"member_rooms":   []string{"Kitchen"},
+		"playback":       map[string]any{"state": "PLAYING", "volume": 40, "muted": false},
+	}
+
+	device := nowPlayingDevice(group, "RINCON_KITCHEN", "Kitchen", 15, true)
+	require.Equal(t, "RINCON_KITCHEN", device["udn"])
+	require.Equal(t, "Kitchen", device["room_name"])
+	require.Equal(t, "RINCON_DEN", device["coordinator_id"])
+	require.Equal(t, map[string]any{"state": "PLAYING", "volume": 15, "muted": true}, device["playback"])
+
+	// The group's entry is left as it was
+	require.Equal(t, "Den", group["room_name"])
+	require.Equal(t, 40, group["playback"].(map[string]any)["volume"])
+}
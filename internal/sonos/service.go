@@ -2,7 +2,6 @@ package sonos
 
 import (
 	"context"
-	"errors"
 	"time"
 
 	"github.com/strefethen/sonos-hub-go/internal/devices"
@@ -34,6 +33,13 @@ type StateProvider interface {
 	GetPlaybackState(deviceIP string) *PlaybackState
 }
 
+// SetMembershipProvider reports which favorites already belong to a music set.
+// This is implemented by music.Service but defined here to keep sonos free of catalog dependencies.
+type SetMembershipProvider interface {
+	// SetFavoriteIDs returns the favorite IDs in a set, or nil if the set does not exist.
+	SetFavoriteIDs(setID string) (map[string]bool, error)
+}
+
 // Service exposes Sonos operations needed by routes.
 type Service struct {
 	DeviceService   *devices.Service
@@ -41,7 +47,13 @@ type Service struct {
 	DefaultDeviceIP string
 	SoapTimeout     time.Duration
 	ZoneCache       *ZoneGroupCache
-	StateProvider   StateProvider // UPnP event state cache for hybrid data layer
+	FavoritesCache  *FavoritesCache           // Shared favorites list for the favorites routes and favorite playback
+	StateProvider   StateProvider             // UPnP event state cache for hybrid data layer
+	SetMembership   SetMembershipProvider     // Music catalog lookup for favorites in_set annotation
+	Limiter         *SOAPLimiter              // Bounds fan-out SOAP calls and short-circuits unresponsive devices
+	PartySnapshots  *PartySnapshotsRepository // Groups saved by party mode; party routes are unavailable when nil
+
+	groupRendering groupRenderingSupport // Which coordinators support group volume
 }
 
 // NewService creates a new Sonos service with the given dependencies.
@@ -52,6 +64,8 @@ func NewService(deviceService *devices.Service, soapClient *soap.Client, default
 		DefaultDeviceIP: defaultIP,
 		SoapTimeout:     timeout,
 		ZoneCache:       NewZoneGroupCache(30 * time.Second), // Default 30s TTL
+		FavoritesCache:  NewFavoritesCache(defaultFavoritesCacheTTL),
+		Limiter:         NewSOAPLimiter(DefaultLimiterConfig()),
 	}
 }
 
@@ -63,6 +77,8 @@ func NewServiceWithConfig(deviceService *devices.Service, soapClient *soap.Clien
 		DefaultDeviceIP: defaultIP,
 		SoapTimeout:     timeout,
 		ZoneCache:       NewZoneGroupCache(zoneCacheTTL),
+		FavoritesCache:  NewFavoritesCache(defaultFavoritesCacheTTL),
+		Limiter:         NewSOAPLimiter(DefaultLimiterConfig()),
 	}
 }
 
@@ -74,15 +90,18 @@ func NewServiceWithStateProvider(deviceService *devices.Service, soapClient *soa
 		DefaultDeviceIP: defaultIP,
 		SoapTimeout:     timeout,
 		ZoneCache:       NewZoneGroupCache(zoneCacheTTL),
+		FavoritesCache:  NewFavoritesCache(defaultFavoritesCacheTTL),
+		Limiter:         NewSOAPLimiter(DefaultLimiterConfig()),
 		StateProvider:   stateProvider,
 	}
 }
 
-// ResolveDeviceIP resolves the IP for a device ID, falling back to default.
+// ResolveDeviceIP resolves the IP for a device ID, falling back to default. It returns a
+// DeviceNotFoundError when the device is unknown and there's no default.
 func (service *Service) ResolveDeviceIP(deviceID string) (string, error) {
 	if service.DeviceService == nil {
 		if service.DefaultDeviceIP == "" {
-			return "", errors.New("no device resolver available")
+			return "", &DeviceNotFoundError{UDN: deviceID}
 		}
 		return service.DefaultDeviceIP, nil
 	}
@@ -93,25 +112,76 @@ func (service *Service) ResolveDeviceIP(deviceID string) (string, error) {
 	}
 	if ip == "" {
 		if service.DefaultDeviceIP == "" {
-			return "", errors.New("device not found")
+			return "", &DeviceNotFoundError{UDN: deviceID}
 		}
 		return service.DefaultDeviceIP, nil
 	}
 	return ip, nil
 }
 
+// EntryDeviceIP picks a device to query household-wide state such as zone topology from,
+// preferring a discovered online device over the configured default.
+func (service *Service) EntryDeviceIP() string {
+	if service.DeviceService != nil {
+		if topology := service.DeviceService.GetTopologyIfCached(); topology != nil {
+			for _, device := range topology.Devices {
+				if device.IP != "" && device.Health != devices.DeviceHealthOffline {
+					return device.IP
+				}
+			}
+		}
+	}
+	return service.DefaultDeviceIP
+}
+
 func (service *Service) ListAlarms(deviceIP string) (soap.AlarmListResult, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
 	defer cancel()
 	return service.SoapClient.ListAlarms(ctx, deviceIP)
 }
 
+func (service *Service) CreateAlarm(deviceIP string, alarm soap.Alarm) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.CreateAlarm(ctx, deviceIP, alarm)
+}
+
+func (service *Service) UpdateAlarm(deviceIP string, alarm soap.Alarm) error {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.UpdateAlarm(ctx, deviceIP, alarm)
+}
+
+func (service *Service) DestroyAlarm(deviceIP, id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.DestroyAlarm(ctx, deviceIP, id)
+}
+
 func (service *Service) BrowseFavorites(start, count int) (soap.BrowseResult, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
 	defer cancel()
 	return service.SoapClient.Browse(ctx, service.DefaultDeviceIP, "FV:2", "BrowseDirectChildren", "*", start, count)
 }
 
+func (service *Service) BrowseQueue(deviceIP string, start, count int) (soap.MusicLibraryBrowseResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.BrowseQueue(ctx, deviceIP, start, count)
+}
+
+func (service *Service) AddURIToQueue(deviceIP, uri, metadata string, position int, enqueueNext bool) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.AddURIToQueue(ctx, deviceIP, uri, metadata, position, enqueueNext)
+}
+
+func (service *Service) RemoveAllTracksFromQueue(deviceIP string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.RemoveAllTracksFromQueue(ctx, deviceIP)
+}
+
 func (service *Service) Stop(deviceIP string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
 	defer cancel()
@@ -167,17 +237,117 @@ func (service *Service) GetVolume(deviceIP string) (soap.VolumeInfo, error) {
 }
 
 func (service *Service) SetVolume(deviceIP string, level int) error {
-	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	return service.SetVolumeContext(context.Background(), deviceIP, level)
+}
+
+// SetVolumeContext is SetVolume for callers that may cancel, such as volume ramps.
+func (service *Service) SetVolumeContext(ctx context.Context, deviceIP string, level int) error {
+	ctx, cancel := context.WithTimeout(ctx, service.SoapTimeout)
 	defer cancel()
 	return service.SoapClient.SetVolume(ctx, deviceIP, level)
 }
 
+// GetGroupVolume returns the volume of the group coordinated by coordinatorIP.
+func (service *Service) GetGroupVolume(coordinatorIP string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.GetGroupVolume(ctx, coordinatorIP)
+}
+
+// SetGroupRenderingVolume sets the group coordinated by coordinatorIP to level, scaling
+// the members so their relative volumes are kept.
+func (service *Service) SetGroupRenderingVolume(coordinatorIP string, level int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	if err := service.SoapClient.SnapshotGroupVolume(ctx, coordinatorIP); err != nil {
+		return err
+	}
+	return service.SoapClient.SetGroupVolume(ctx, coordinatorIP, level)
+}
+
+// AdjustGroupVolume changes the volume of the group coordinated by coordinatorIP by
+// adjustment, keeping the members' relative volumes, and returns the new group volume.
+func (service *Service) AdjustGroupVolume(coordinatorIP string, adjustment int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	if err := service.SoapClient.SnapshotGroupVolume(ctx, coordinatorIP); err != nil {
+		return 0, err
+	}
+	return service.SoapClient.SetRelativeGroupVolume(ctx, coordinatorIP, adjustment)
+}
+
 func (service *Service) GetMute(deviceIP string) (soap.MuteInfo, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
 	defer cancel()
 	return service.SoapClient.GetMute(ctx, deviceIP)
 }
 
+func (service *Service) SetMute(deviceIP string, muted bool) error {
+	return service.SetMuteContext(context.Background(), deviceIP, muted)
+}
+
+// SetMuteContext is SetMute for callers that may cancel, such as a request fanning out
+// to a group.
+func (service *Service) SetMuteContext(ctx context.Context, deviceIP string, muted bool) error {
+	ctx, cancel := context.WithTimeout(ctx, service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.SetMute(ctx, deviceIP, muted)
+}
+
+func (service *Service) GetBass(deviceIP string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.GetBass(ctx, deviceIP)
+}
+
+func (service *Service) SetBass(deviceIP string, level int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.SetBass(ctx, deviceIP, level)
+}
+
+func (service *Service) GetTreble(deviceIP string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.GetTreble(ctx, deviceIP)
+}
+
+func (service *Service) SetTreble(deviceIP string, level int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.SetTreble(ctx, deviceIP, level)
+}
+
+func (service *Service) GetLoudness(deviceIP string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.GetLoudness(ctx, deviceIP)
+}
+
+func (service *Service) SetLoudness(deviceIP string, enabled bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.SetLoudness(ctx, deviceIP, enabled)
+}
+
+func (service *Service) GetEQ(deviceIP, eqType string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.GetEQ(ctx, deviceIP, eqType)
+}
+
+func (service *Service) SetEQ(deviceIP, eqType string, value int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.SetEQ(ctx, deviceIP, eqType, value)
+}
+
+func (service *Service) GetDeviceDescription(deviceIP string) (soap.DeviceDescription, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.GetDeviceDescription(ctx, deviceIP)
+}
+
 func (service *Service) GetZoneGroupState(deviceIP string) (soap.ZoneGroupState, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
 	defer cancel()
@@ -216,6 +386,54 @@ func (service *Service) SetAVTransportURI(deviceIP string, uri string) error {
 	return service.SoapClient.SetAVTransportURI(ctx, deviceIP, uri, "")
 }
 
+func (service *Service) SetAVTransportURIWithMetadata(deviceIP, uri, metadata string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.SetAVTransportURI(ctx, deviceIP, uri, metadata)
+}
+
+func (service *Service) Seek(deviceIP, unit, target string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.Seek(ctx, deviceIP, unit, target)
+}
+
+func (service *Service) GetTransportSettings(deviceIP string) (soap.TransportSettings, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.GetTransportSettings(ctx, deviceIP)
+}
+
+func (service *Service) SetPlayMode(deviceIP, mode string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.SetPlayMode(ctx, deviceIP, mode)
+}
+
+func (service *Service) GetCrossfadeMode(deviceIP string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.GetCrossfadeMode(ctx, deviceIP)
+}
+
+func (service *Service) SetCrossfadeMode(deviceIP string, enabled bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.SetCrossfadeMode(ctx, deviceIP, enabled)
+}
+
+func (service *Service) ConfigureSleepTimer(deviceIP, duration string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.ConfigureSleepTimer(ctx, deviceIP, duration)
+}
+
+func (service *Service) GetRemainingSleepTimerDuration(deviceIP string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
+	defer cancel()
+	return service.SoapClient.GetRemainingSleepTimerDuration(ctx, deviceIP)
+}
+
 func (service *Service) BecomeCoordinatorOfStandaloneGroup(deviceIP string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), service.SoapTimeout)
 	defer cancel()
@@ -0,0 +1,129 @@
+package sonos
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+)
+
+// MaxSleepTimerMinutes is the longest sleep timer Sonos accepts (23:59).
+const MaxSleepTimerMinutes = 24*60 - 1
+
+// SleepTimerClient arms a speaker's sleep timer. It is implemented by Service.
+type SleepTimerClient interface {
+	ConfigureSleepTimer(deviceIP, duration string) error
+}
+
+// SleepTimerDuration formats minutes as the HH:MM:SS duration ConfigureSleepTimer takes.
+// Zero returns an empty duration, which cancels the timer.
+func SleepTimerDuration(minutes int) string {
+	if minutes <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%02d:%02d:00", minutes/60, minutes%60)
+}
+
+// ValidateSleepTimerMinutes checks that minutes is zero (cancel) or a duration Sonos accepts.
+func ValidateSleepTimerMinutes(minutes int) error {
+	if minutes < 0 || minutes > MaxSleepTimerMinutes {
+		return fmt.Errorf("must be between 0 and %d", MaxSleepTimerMinutes)
+	}
+	return nil
+}
+
+// SetSleepTimer arms the sleep timer on a group's coordinator, or cancels it when
+// minutes is zero.
+func SetSleepTimer(client SleepTimerClient, coordinatorIP string, minutes int) error {
+	return client.ConfigureSleepTimer(coordinatorIP, SleepTimerDuration(minutes))
+}
+
+// remainingSleepTimer returns the time left on the sleep timer of deviceIP's group, or
+// nil when no timer is set or it can't be read.
+func remainingSleepTimer(service *Service, deviceIP string) any {
+	target := ResolveGroupCoordinator(service, deviceIP)
+	remaining, err := service.GetRemainingSleepTimerDuration(target.CoordinatorIP)
+	if err != nil {
+		return nil
+	}
+	return emptyToNil(remaining)
+}
+
+func formatSleepTimer(udn, remaining string) map[string]any {
+	return map[string]any{
+		"object":                "sleep_timer",
+		"udn":                   udn,
+		"active":                remaining != "",
+		"remaining_sleep_timer": emptyToNil(remaining),
+		"remaining_seconds":     ParseDuration(remaining),
+	}
+}
+
+// getSleepTimerHandler handles GET /v1/sonos/playback/sleep-timer?udn=.
+func getSleepTimerHandler(service *Service) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		udn := r.URL.Query().Get("udn")
+		if udn == "" {
+			return apperrors.NewValidationError("udn query parameter is required", nil)
+		}
+
+		deviceIP, err := service.ResolveDeviceIP(udn)
+		if err != nil {
+			return deviceFailure(err, udn, "", "Failed to resolve device")
+		}
+
+		target := ResolveGroupCoordinator(service, deviceIP)
+		remaining, err := service.GetRemainingSleepTimerDuration(target.CoordinatorIP)
+		if err != nil {
+			return apperrors.NewInternalError("Failed to fetch sleep timer")
+		}
+
+		response := formatSleepTimer(udn, remaining)
+		addDebugTargets(r, response, deviceIP, []string{target.CoordinatorIP})
+
+		return api.WriteResource(w, http.StatusOK, response)
+	}
+}
+
+// setSleepTimerHandler handles POST /v1/sonos/playback/sleep-timer. The timer belongs to
+// the group, so it is set on the coordinator; a zero or null duration cancels it.
+func setSleepTimerHandler(service *Service) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var body struct {
+			UDN             string `json:"udn"`
+			DurationMinutes *int   `json:"duration_minutes"`
+		}
+		if err := decodeJSON(r, &body); err != nil || body.UDN == "" {
+			return apperrors.NewValidationError("udn is required", nil)
+		}
+		minutes := 0
+		if body.DurationMinutes != nil {
+			minutes = *body.DurationMinutes
+		}
+		if err := ValidateSleepTimerMinutes(minutes); err != nil {
+			return apperrors.NewValidationError("duration_minutes "+err.Error(), map[string]any{"duration_minutes": minutes})
+		}
+
+		deviceIP, err := service.ResolveDeviceIP(body.UDN)
+		if err != nil {
+			return deviceFailure(err, body.UDN, "", "Failed to resolve device")
+		}
+
+		target := ResolveGroupCoordinator(service, deviceIP)
+		if err := SetSleepTimer(service, target.CoordinatorIP, minutes); err != nil {
+			return apperrors.NewInternalError("Failed to set sleep timer")
+		}
+
+		response := formatSleepTimer(body.UDN, SleepTimerDuration(minutes))
+		response["duration_minutes"] = nil
+		if minutes > 0 {
+			response["duration_minutes"] = minutes
+		}
+		response["configured_at"] = api.RFC3339Millis(time.Now())
+		addDebugTargets(r, response, deviceIP, []string{target.CoordinatorIP})
+
+		return api.WriteAction(w, http.StatusOK, response)
+	}
+}
@@ -0,0 +1,21 @@
+package sonos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSleepTimerDuration(t *testing.T) {
+	require.Equal(t, "00:30:00", SleepTimerDuration(30))
+	require.Equal(t, "01:45:00", SleepTimerDuration(105))
+	require.Equal(t, "23:59:00", SleepTimerDuration(MaxSleepTimerMinutes))
+	require.Equal(t, "", SleepTimerDuration(0))
+}
+
+func TestValidateSleepTimerMinutes(t *testing.T) {
+	require.NoError(t, ValidateSleepTimerMinutes(0))
+	require.NoError(t, ValidateSleepTimerMinutes(MaxSleepTimerMinutes))
+	require.Error(t, ValidateSleepTimerMinutes(-1))
+	require.Error(t, ValidateSleepTimerMinutes(MaxSleepTimerMinutes+1))
+}
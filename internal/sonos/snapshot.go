@@ -0,0 +1,137 @@
+package sonos
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// Reasons a PlaybackSnapshot's source can't be put back.
+const (
+	SnapshotSkipTV      = "tv"      // TV audio follows the TV, not the speaker
+	SnapshotSkipLineIn  = "line_in" // Line-in follows whatever is plugged in
+	SnapshotSkipGrouped = "grouped" // Members play whatever their coordinator plays
+	SnapshotSkipEmpty   = "empty"   // Nothing was loaded
+)
+
+// PlaybackStateClient is the subset of Service used to capture and restore playback state.
+type PlaybackStateClient interface {
+	GetMediaInfo(deviceIP string) (soap.MediaInfo, error)
+	GetPositionInfo(deviceIP string) (soap.PositionInfo, error)
+	GetTransportInfo(deviceIP string) (soap.TransportInfo, error)
+	GetVolume(deviceIP string) (soap.VolumeInfo, error)
+	SetAVTransportURIWithMetadata(deviceIP, uri, metadata string) error
+	Seek(deviceIP, unit, target string) error
+	SetVolume(deviceIP string, level int) error
+	Play(deviceIP string) error
+}
+
+// PlaybackSnapshot is what a speaker was playing before something interrupted it.
+type PlaybackSnapshot struct {
+	UDN               string `json:"udn"`
+	TransportURI      string `json:"transport_uri"`
+	TransportMetadata string `json:"transport_metadata,omitempty"`
+	Track             int    `json:"track,omitempty"`    // Queue position, for queue playback
+	RelTime           string `json:"rel_time,omitempty"` // Position within the track (H:MM:SS)
+	TransportState    string `json:"transport_state"`
+	Volume            int    `json:"volume"`
+	SkipReason        string `json:"skip_reason,omitempty"` // Set when the source can't be restored
+}
+
+// Restorable reports whether the snapshot's source can be put back.
+func (s *PlaybackSnapshot) Restorable() bool {
+	return s.SkipReason == ""
+}
+
+// usesQueue reports whether the snapshot was playing from the speaker's queue, where
+// the track and position can be sought back to.
+func (s *PlaybackSnapshot) usesQueue() bool {
+	return strings.HasPrefix(s.TransportURI, "x-rincon-queue:")
+}
+
+// snapshotSkipReason classifies transport URIs whose source can't be re-selected.
+func snapshotSkipReason(uri string) string {
+	lower := strings.ToLower(uri)
+	switch {
+	case uri == "":
+		return SnapshotSkipEmpty
+	case IsTVInputURI(lower) || strings.Contains(lower, "spdif"):
+		return SnapshotSkipTV
+	case IsLineInURI(lower):
+		return SnapshotSkipLineIn
+	case strings.HasPrefix(lower, "x-rincon:"):
+		return SnapshotSkipGrouped
+	}
+	return ""
+}
+
+// CapturePlaybackSnapshot records what the speaker at deviceIP is playing, where it
+// is in the track, whether it is playing, and its volume.
+func CapturePlaybackSnapshot(client PlaybackStateClient, deviceIP, udn string) (*PlaybackSnapshot, error) {
+	media, err := client.GetMediaInfo(deviceIP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media info: %w", err)
+	}
+	transport, err := client.GetTransportInfo(deviceIP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transport info: %w", err)
+	}
+	volume, err := client.GetVolume(deviceIP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get volume: %w", err)
+	}
+
+	snapshot := &PlaybackSnapshot{
+		UDN:               udn,
+		TransportURI:      media.CurrentURI,
+		TransportMetadata: media.CurrentURIMetaData,
+		TransportState:    transport.CurrentTransportState,
+		Volume:            volume.CurrentVolume,
+		SkipReason:        snapshotSkipReason(media.CurrentURI),
+	}
+
+	if snapshot.Restorable() {
+		position, err := client.GetPositionInfo(deviceIP)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get position info: %w", err)
+		}
+		if snapshot.usesQueue() {
+			snapshot.Track = position.Track
+		}
+		snapshot.RelTime = position.RelTime
+	}
+
+	return snapshot, nil
+}
+
+// RestorePlaybackSnapshot puts a speaker back the way it was captured: the source, the
+// queue track and position, the volume, and playback if it was playing. Only the volume
+// is restored for snapshots whose source can't be put back. Seeking is best effort,
+// since streams can't seek.
+func RestorePlaybackSnapshot(client PlaybackStateClient, deviceIP string, snapshot *PlaybackSnapshot) error {
+	if snapshot.Restorable() {
+		if err := client.SetAVTransportURIWithMetadata(deviceIP, snapshot.TransportURI, snapshot.TransportMetadata); err != nil {
+			return fmt.Errorf("failed to set transport URI: %w", err)
+		}
+		if snapshot.usesQueue() && snapshot.Track > 0 {
+			if err := client.Seek(deviceIP, "TRACK_NR", strconv.Itoa(snapshot.Track)); err == nil {
+				if snapshot.RelTime != "" && snapshot.RelTime != "0:00:00" && snapshot.RelTime != "NOT_IMPLEMENTED" {
+					_ = client.Seek(deviceIP, "REL_TIME", snapshot.RelTime)
+				}
+			}
+		}
+	}
+
+	if err := client.SetVolume(deviceIP, snapshot.Volume); err != nil {
+		return fmt.Errorf("failed to set volume: %w", err)
+	}
+
+	if snapshot.Restorable() && snapshot.TransportState == "PLAYING" {
+		if err := client.Play(deviceIP); err != nil {
+			return fmt.Errorf("failed to resume playback: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package sonos
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// fakeSnapshotClient is a single speaker that records the commands it receives.
+type fakeSnapshotClient struct {
+	media     soap.MediaInfo
+	position  soap.PositionInfo
+	state     string
+	volume    int
+	calls     []string
+	seekError error
+}
+
+func (f *fakeSnapshotClient) GetMediaInfo(deviceIP string) (soap.MediaInfo, error) {
+	return f.media, nil
+}
+
+func (f *fakeSnapshotClient) GetPositionInfo(deviceIP string) (soap.PositionInfo, error) {
+	return f.position, nil
+}
+
+func (f *fakeSnapshotClient) GetTransportInfo(deviceIP string) (soap.TransportInfo, error) {
+	return soap.TransportInfo{CurrentTransportState: f.state}, nil
+}
+
+func (f *fakeSnapshotClient) GetVolume(deviceIP string) (soap.VolumeInfo, error) {
+	return soap.VolumeInfo{CurrentVolume: f.volume}, nil
+}
+
+func (f *fakeSnapshotClient) SetAVTransportURIWithMetadata(deviceIP, uri, metadata string) error {
+	f.calls = append(f.calls, "uri "+uri)
+	return nil
+}
+
+func (f *fakeSnapshotClient) Seek(deviceIP, unit, target string) error {
+	f.calls = append(f.calls, fmt.Sprintf("seek %s %s", unit, target))
+	return f.seekError
+}
+
+func (f *fakeSnapshotClient) SetVolume(deviceIP string, level int) error {
+	f.calls = append(f.calls, fmt.Sprintf("volume %d", level))
+	return nil
+}
+
+func (f *fakeSnapshotClient) Play(deviceIP string) error {
+	f.calls = append(f.calls, "play")
+	return nil
+}
+
+func TestCapturePlaybackSnapshot(t *testing.T) {
+	client := &fakeSnapshotClient{
+		media:    soap.MediaInfo{CurrentURI: "x-rincon-queue:RINCON_KITCHEN#0", CurrentURIMetaData: "<DIDL-Lite/>"},
+		position: soap.PositionInfo{Track: 4, RelTime: "0:01:23"},
+		state:    "PLAYING",
+		volume:   22,
+	}
+
+	snapshot, err := CapturePlaybackSnapshot(client, "10.0.0.1", "RINCON_KITCHEN")
+	require.NoError(t, err)
+	require.Equal(t, &PlaybackSnapshot{
+		UDN:               "RINCON_KITCHEN",
+		TransportURI:      "x-rincon-queue:RINCON_KITCHEN#0",
+		TransportMetadata: "<DIDL-Lite/>",
+		Track:             4,
+		RelTime:           "0:01:23",
+		TransportState:    "PLAYING",
+		Volume:            22,
+	}, snapshot)
+
+	for uri, reason := range map[string]string{
+		"x-sonos-htastream:RINCON_ARC:spdif": SnapshotSkipTV,
+		"x-rincon-stream:RINCON_PORT":        SnapshotSkipLineIn,
+		"x-rincon:RINCON_LIVING":             SnapshotSkipGrouped,
+		"":                                   SnapshotSkipEmpty,
+		"x-sonosapi-stream:s12345?sid=254&flags=8224": "",
+	} {
+		client.media = soap.MediaInfo{CurrentURI: uri}
+		snapshot, err := CapturePlaybackSnapshot(client, "10.0.0.1", "RINCON_KITCHEN")
+		require.NoError(t, err)
+		require.Equal(t, reason, snapshot.SkipReason, uri)
+	}
+}
+
+func TestRestorePlaybackSnapshot(t *testing.T) {
+	client := &fakeSnapshotClient{}
+	queue := &PlaybackSnapshot{TransportURI: "x-rincon-queue:RINCON_KITCHEN#0", Track: 4, RelTime: "0:01:23", TransportState: "PLAYING", Volume: 22}
+	require.NoError(t, RestorePlaybackSnapshot(client, "10.0.0.1", queue))
+	require.Equal(t, []string{"uri x-rincon-queue:RINCON_KITCHEN#0", "seek TRACK_NR 4", "seek REL_TIME 0:01:23", "volume 22", "play"}, client.calls)
+
+	// Paused streams are loaded but not started, and aren't sought
+	client = &fakeSnapshotClient{}
+	stream := &PlaybackSnapshot{TransportURI: "x-sonosapi-stream:s12345", RelTime: "0:10:00", TransportState: "PAUSED_PLAYBACK", Volume: 15}
+	require.NoError(t, RestorePlaybackSnapshot(client, "10.0.0.1", stream))
+	require.Equal(t, []string{"uri x-sonosapi-stream:s12345", "volume 15"}, client.calls)
+
+	// TV audio only gets its volume back
+	client = &fakeSnapshotClient{}
+	tv := &PlaybackSnapshot{TransportURI: "x-sonos-htastream:RINCON_ARC:spdif", TransportState: "PLAYING", Volume: 30, SkipReason: SnapshotSkipTV}
+	require.NoError(t, RestorePlaybackSnapshot(client, "10.0.0.1", tv))
+	require.Equal(t, []string{"volume 30"}, client.calls)
+
+	// A failed track seek skips the position seek but still resumes
+	client = &fakeSnapshotClient{seekError: fmt.Errorf("seek rejected")}
+	require.NoError(t, RestorePlaybackSnapshot(client, "10.0.0.1", queue))
+	require.Equal(t, []string{"uri x-rincon-queue:RINCON_KITCHEN#0", "seek TRACK_NR 4", "volume 22", "play"}, client.calls)
+}
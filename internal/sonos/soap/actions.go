@@ -113,6 +113,68 @@ func (c *Client) RemoveAllTracksFromQueue(ctx context.Context, ip string) error
 	return err
 }
 
+func (c *Client) GetTransportSettings(ctx context.Context, ip string) (TransportSettings, error) {
+	payload, err := c.ExecuteAction(ctx, ip, ServiceAVTransport, "GetTransportSettings", map[string]string{
+		"InstanceID": "0",
+	})
+	if err != nil {
+		return TransportSettings{}, err
+	}
+	return parseTransportSettings(payload), nil
+}
+
+func (c *Client) SetPlayMode(ctx context.Context, ip, mode string) error {
+	_, err := c.ExecuteAction(ctx, ip, ServiceAVTransport, "SetPlayMode", map[string]string{
+		"InstanceID":  "0",
+		"NewPlayMode": mode,
+	})
+	return err
+}
+
+func (c *Client) GetCrossfadeMode(ctx context.Context, ip string) (bool, error) {
+	payload, err := c.ExecuteAction(ctx, ip, ServiceAVTransport, "GetCrossfadeMode", map[string]string{
+		"InstanceID": "0",
+	})
+	if err != nil {
+		return false, err
+	}
+	return parseTextValue(payload, "CrossfadeMode") == "1", nil
+}
+
+func (c *Client) SetCrossfadeMode(ctx context.Context, ip string, enabled bool) error {
+	mode := "0"
+	if enabled {
+		mode = "1"
+	}
+	_, err := c.ExecuteAction(ctx, ip, ServiceAVTransport, "SetCrossfadeMode", map[string]string{
+		"InstanceID":    "0",
+		"CrossfadeMode": mode,
+	})
+	return err
+}
+
+// ConfigureSleepTimer arms the sleep timer for duration (HH:MM:SS); an empty duration
+// cancels it.
+func (c *Client) ConfigureSleepTimer(ctx context.Context, ip, duration string) error {
+	_, err := c.ExecuteAction(ctx, ip, ServiceAVTransport, "ConfigureSleepTimer", map[string]string{
+		"InstanceID":            "0",
+		"NewSleepTimerDuration": duration,
+	})
+	return err
+}
+
+// GetRemainingSleepTimerDuration returns the time left on the sleep timer (HH:MM:SS),
+// or an empty string when no timer is set.
+func (c *Client) GetRemainingSleepTimerDuration(ctx context.Context, ip string) (string, error) {
+	payload, err := c.ExecuteAction(ctx, ip, ServiceAVTransport, "GetRemainingSleepTimerDuration", map[string]string{
+		"InstanceID": "0",
+	})
+	if err != nil {
+		return "", err
+	}
+	return parseTextValue(payload, "RemainingSleepTimerDuration"), nil
+}
+
 func (c *Client) Seek(ctx context.Context, ip, unit, target string) error {
 	_, err := c.ExecuteAction(ctx, ip, ServiceAVTransport, "Seek", map[string]string{
 		"InstanceID": "0",
@@ -174,6 +236,127 @@ func (c *Client) SetMute(ctx context.Context, ip string, mute bool) error {
 	return err
 }
 
+// GetBass returns the bass level, -10 to 10.
+func (c *Client) GetBass(ctx context.Context, ip string) (int, error) {
+	payload, err := c.ExecuteAction(ctx, ip, ServiceRenderingControl, "GetBass", map[string]string{
+		"InstanceID": "0",
+	})
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(parseTextValue(payload, "CurrentBass"))
+}
+
+func (c *Client) SetBass(ctx context.Context, ip string, level int) error {
+	_, err := c.ExecuteAction(ctx, ip, ServiceRenderingControl, "SetBass", map[string]string{
+		"InstanceID":  "0",
+		"DesiredBass": strconv.Itoa(level),
+	})
+	return err
+}
+
+// GetTreble returns the treble level, -10 to 10.
+func (c *Client) GetTreble(ctx context.Context, ip string) (int, error) {
+	payload, err := c.ExecuteAction(ctx, ip, ServiceRenderingControl, "GetTreble", map[string]string{
+		"InstanceID": "0",
+	})
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(parseTextValue(payload, "CurrentTreble"))
+}
+
+func (c *Client) SetTreble(ctx context.Context, ip string, level int) error {
+	_, err := c.ExecuteAction(ctx, ip, ServiceRenderingControl, "SetTreble", map[string]string{
+		"InstanceID":    "0",
+		"DesiredTreble": strconv.Itoa(level),
+	})
+	return err
+}
+
+func (c *Client) GetLoudness(ctx context.Context, ip string) (bool, error) {
+	payload, err := c.ExecuteAction(ctx, ip, ServiceRenderingControl, "GetLoudness", map[string]string{
+		"InstanceID": "0",
+		"Channel":    "Master",
+	})
+	if err != nil {
+		return false, err
+	}
+	return parseTextValue(payload, "CurrentLoudness") == "1", nil
+}
+
+func (c *Client) SetLoudness(ctx context.Context, ip string, enabled bool) error {
+	_, err := c.ExecuteAction(ctx, ip, ServiceRenderingControl, "SetLoudness", map[string]string{
+		"InstanceID":      "0",
+		"Channel":         "Master",
+		"DesiredLoudness": boolArg(enabled),
+	})
+	return err
+}
+
+// GetEQ reads a home theater EQ setting such as NightMode or DialogLevel. Devices
+// without the setting reject the request.
+func (c *Client) GetEQ(ctx context.Context, ip, eqType string) (int, error) {
+	payload, err := c.ExecuteAction(ctx, ip, ServiceRenderingControl, "GetEQ", map[string]string{
+		"InstanceID": "0",
+		"EQType":     eqType,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(parseTextValue(payload, "CurrentValue"))
+}
+
+func (c *Client) SetEQ(ctx context.Context, ip, eqType string, value int) error {
+	_, err := c.ExecuteAction(ctx, ip, ServiceRenderingControl, "SetEQ", map[string]string{
+		"InstanceID":   "0",
+		"EQType":       eqType,
+		"DesiredValue": strconv.Itoa(value),
+	})
+	return err
+}
+
+// GroupRenderingControl Actions. These go to a group's coordinator and act on the whole
+// group, scaling members together so their relative volumes are kept.
+func (c *Client) GetGroupVolume(ctx context.Context, ip string) (int, error) {
+	payload, err := c.ExecuteAction(ctx, ip, ServiceGroupRenderingControl, "GetGroupVolume", map[string]string{
+		"InstanceID": "0",
+	})
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(parseTextValue(payload, "CurrentVolume"))
+}
+
+// SnapshotGroupVolume records the members' volume ratios, which SetGroupVolume and
+// SetRelativeGroupVolume scale from.
+func (c *Client) SnapshotGroupVolume(ctx context.Context, ip string) error {
+	_, err := c.ExecuteAction(ctx, ip, ServiceGroupRenderingControl, "SnapshotGroupVolume", map[string]string{
+		"InstanceID": "0",
+	})
+	return err
+}
+
+func (c *Client) SetGroupVolume(ctx context.Context, ip string, level int) error {
+	_, err := c.ExecuteAction(ctx, ip, ServiceGroupRenderingControl, "SetGroupVolume", map[string]string{
+		"InstanceID":    "0",
+		"DesiredVolume": strconv.Itoa(level),
+	})
+	return err
+}
+
+// SetRelativeGroupVolume changes the group volume by adjustment and returns the new volume.
+func (c *Client) SetRelativeGroupVolume(ctx context.Context, ip string, adjustment int) (int, error) {
+	payload, err := c.ExecuteAction(ctx, ip, ServiceGroupRenderingControl, "SetRelativeGroupVolume", map[string]string{
+		"InstanceID": "0",
+		"Adjustment": strconv.Itoa(adjustment),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(parseTextValue(payload, "NewVolume"))
+}
+
 // ZoneGroupTopology Actions
 func (c *Client) GetZoneGroupState(ctx context.Context, ip string) (ZoneGroupState, error) {
 	payload, err := c.ExecuteAction(ctx, ip, ServiceZoneGroupTopology, "GetZoneGroupState", map[string]string{})
@@ -202,6 +385,55 @@ func (c *Client) ListAlarms(ctx context.Context, ip string) (AlarmListResult, er
 	return parseAlarmList(payload), nil
 }
 
+// CreateAlarm creates an alarm and returns the ID Sonos assigned it. alarm.ID is ignored.
+func (c *Client) CreateAlarm(ctx context.Context, ip string, alarm Alarm) (string, error) {
+	args := alarmArgs(alarm)
+	delete(args, "ID")
+	payload, err := c.ExecuteAction(ctx, ip, ServiceAlarmClock, "CreateAlarm", args)
+	if err != nil {
+		return "", err
+	}
+	return parseTextValue(payload, "AssignedID"), nil
+}
+
+// UpdateAlarm replaces every field of the alarm with alarm.ID.
+func (c *Client) UpdateAlarm(ctx context.Context, ip string, alarm Alarm) error {
+	_, err := c.ExecuteAction(ctx, ip, ServiceAlarmClock, "UpdateAlarm", alarmArgs(alarm))
+	return err
+}
+
+func (c *Client) DestroyAlarm(ctx context.Context, ip, id string) error {
+	_, err := c.ExecuteAction(ctx, ip, ServiceAlarmClock, "DestroyAlarm", map[string]string{
+		"ID": id,
+	})
+	return err
+}
+
+// alarmArgs builds CreateAlarm/UpdateAlarm arguments. ProgramMetaData is sent as the
+// DIDL-Lite text ListAlarms returned; the envelope escapes it.
+func alarmArgs(alarm Alarm) map[string]string {
+	return map[string]string{
+		"ID":                 alarm.ID,
+		"StartLocalTime":     alarm.StartTime,
+		"Duration":           alarm.Duration,
+		"Recurrence":         alarm.Recurrence,
+		"Enabled":            boolArg(alarm.Enabled),
+		"RoomUUID":           alarm.RoomUUID,
+		"ProgramURI":         alarm.ProgramURI,
+		"ProgramMetaData":    alarm.ProgramMetaData,
+		"PlayMode":           alarm.PlayMode,
+		"Volume":             strconv.Itoa(alarm.Volume),
+		"IncludeLinkedZones": boolArg(alarm.IncludeLinkedZones),
+	}
+}
+
+func boolArg(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
+
 // ContentDirectory Actions
 func (c *Client) Browse(ctx context.Context, ip, objectID, browseFlag, filter string, startIndex, requestedCount int) (BrowseResult, error) {
 	payload, err := c.ExecuteAction(ctx, ip, ServiceContentDirectory, "Browse", map[string]string{
@@ -218,6 +450,46 @@ func (c *Client) Browse(ctx context.Context, ip, objectID, browseFlag, filter st
 	return parseBrowseResult(payload), nil
 }
 
+// BrowseQueue lists a speaker's queue (Q:0). Queue entries are parsed like library
+// tracks; a group's queue lives on its coordinator.
+func (c *Client) BrowseQueue(ctx context.Context, ip string, startIndex, requestedCount int) (MusicLibraryBrowseResult, error) {
+	payload, err := c.ExecuteAction(ctx, ip, ServiceContentDirectory, "Browse", map[string]string{
+		"ObjectID":       "Q:0",
+		"BrowseFlag":     "BrowseDirectChildren",
+		"Filter":         "*",
+		"StartingIndex":  strconv.Itoa(startIndex),
+		"RequestedCount": strconv.Itoa(requestedCount),
+		"SortCriteria":   "",
+	})
+	if err != nil {
+		return MusicLibraryBrowseResult{}, err
+	}
+	return parseMusicLibraryResult(payload, MusicLibraryTrack), nil
+}
+
+// BrowseMusicLibrary lists a music library container's direct children, e.g. "A:ALBUMARTIST"
+// for artists, then an artist's ID for their albums and an album's ID for its tracks.
+// Tracks are typed MusicLibraryTrack; containers are left untyped, their UpnpClass saying
+// what they are. requestedCount is capped at 1000 (Sonos max).
+func (c *Client) BrowseMusicLibrary(ctx context.Context, ip, containerID string, startingIndex, requestedCount int) (MusicLibraryBrowseResult, error) {
+	if requestedCount > 1000 {
+		requestedCount = 1000
+	}
+
+	payload, err := c.ExecuteAction(ctx, ip, ServiceContentDirectory, "Browse", map[string]string{
+		"ObjectID":       containerID,
+		"BrowseFlag":     "BrowseDirectChildren",
+		"Filter":         "*",
+		"StartingIndex":  strconv.Itoa(startingIndex),
+		"RequestedCount": strconv.Itoa(requestedCount),
+		"SortCriteria":   "",
+	})
+	if err != nil {
+		return MusicLibraryBrowseResult{}, err
+	}
+	return parseMusicLibraryResult(payload, ""), nil
+}
+
 // SearchMusicLibrary searches the music library for content matching the query.
 // Uses UPnP ContentDirectory Browse with A: prefix ObjectIDs for search patterns.
 //
@@ -0,0 +1,116 @@
+package soap
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// listAlarmsResponse is a ListAlarms response as a Sonos speaker sends it: the alarm list
+// is escaped XML, and ProgramMetaData inside it is escaped DIDL-Lite, so the metadata is
+// escaped twice on the wire.
+const listAlarmsResponse = `<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:ListAlarmsResponse xmlns:u="urn:schemas-upnp-org:service:AlarmClock:1"><CurrentAlarmList>&lt;Alarms&gt;&lt;Alarm ID=&quot;12&quot; StartTime=&quot;07:00:00&quot; Duration=&quot;02:00:00&quot; Recurrence=&quot;WEEKDAYS&quot; Enabled=&quot;1&quot; RoomUUID=&quot;RINCON_000E58A1B2C301400&quot; ProgramURI=&quot;x-sonosapi-stream:s24940?sid=254&amp;amp;flags=8224&amp;amp;sn=0&quot; ProgramMetaData=&quot;&amp;lt;DIDL-Lite xmlns:dc=&amp;quot;http://purl.org/dc/elements/1.1/&amp;quot; xmlns:upnp=&amp;quot;urn:schemas-upnp-org:metadata-1-0/upnp/&amp;quot; xmlns:r=&amp;quot;urn:schemas-rinconnetworks-com:metadata-1-0/&amp;quot; xmlns=&amp;quot;urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/&amp;quot;&amp;gt;&amp;lt;item id=&amp;quot;F00092020s24940&amp;quot; parentID=&amp;quot;-1&amp;quot; restricted=&amp;quot;true&amp;quot;&amp;gt;&amp;lt;dc:title&amp;gt;Rock &amp;amp;amp; Roll Radio&amp;lt;/dc:title&amp;gt;&amp;lt;upnp:class&amp;gt;object.item.audioItem.audioBroadcast&amp;lt;/upnp:class&amp;gt;&amp;lt;desc id=&amp;quot;cdudn&amp;quot; nameSpace=&amp;quot;urn:schemas-rinconnetworks-com:metadata-1-0/&amp;quot;&amp;gt;SA_RINCON65031_&amp;lt;/desc&amp;gt;&amp;lt;/item&amp;gt;&amp;lt;/DIDL-Lite&amp;gt;&quot; PlayMode=&quot;SHUFFLE_NOREPEAT&quot; Volume=&quot;25&quot; IncludeLinkedZones=&quot;0&quot;/&gt;&lt;/Alarms&gt;</CurrentAlarmList><CurrentAlarmListVersion>RINCON_000E58A1B2C301400:31</CurrentAlarmListVersion></u:ListAlarmsResponse></s:Body></s:Envelope>`
+
+const alarmMetadata = `<DIDL-Lite xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/" xmlns:r="urn:schemas-rinconnetworks-com:metadata-1-0/" xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/"><item id="F00092020s24940" parentID="-1" restricted="true"><dc:title>Rock &amp; Roll Radio</dc:title><upnp:class>object.item.audioItem.audioBroadcast</upnp:class><desc id="cdudn" nameSpace="urn:schemas-rinconnetworks-com:metadata-1-0/">SA_RINCON65031_</desc></item></DIDL-Lite>`
+
+// alarmTransport answers AlarmClock actions with canned responses and records each
+// request body by action.
+type alarmTransport struct {
+	requests map[string]string
+}
+
+func (t *alarmTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	action := strings.Trim(req.Header.Get("SOAPACTION"), `"`)
+	action = action[strings.Index(action, "#")+1:]
+	t.requests[action] = string(body)
+
+	response := `<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><u:` + action + `Response xmlns:u="urn:schemas-upnp-org:service:AlarmClock:1"></u:` + action + `Response></s:Body></s:Envelope>`
+	switch action {
+	case "ListAlarms":
+		response = listAlarmsResponse
+	case "CreateAlarm":
+		response = `<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><u:CreateAlarmResponse xmlns:u="urn:schemas-upnp-org:service:AlarmClock:1"><AssignedID>13</AssignedID></u:CreateAlarmResponse></s:Body></s:Envelope>`
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(response)), Header: http.Header{}}, nil
+}
+
+func newAlarmTestClient() (*Client, *alarmTransport) {
+	transport := &alarmTransport{requests: map[string]string{}}
+	return &Client{httpClient: &http.Client{Transport: transport}, timeout: time.Second}, transport
+}
+
+func TestListAlarms(t *testing.T) {
+	client, _ := newAlarmTestClient()
+
+	result, err := client.ListAlarms(context.Background(), "192.168.1.20")
+	require.NoError(t, err)
+	require.Equal(t, "RINCON_000E58A1B2C301400:31", result.AlarmListVersion)
+	require.Len(t, result.Alarms, 1)
+
+	alarm := result.Alarms[0]
+	require.Equal(t, "12", alarm.ID)
+	require.Equal(t, "07:00:00", alarm.StartTime)
+	require.Equal(t, "WEEKDAYS", alarm.Recurrence)
+	require.True(t, alarm.Enabled)
+	require.Equal(t, "x-sonosapi-stream:s24940?sid=254&flags=8224&sn=0", alarm.ProgramURI)
+	require.Equal(t, alarmMetadata, alarm.ProgramMetaData)
+	require.Equal(t, 25, alarm.Volume)
+	require.False(t, alarm.IncludeLinkedZones)
+}
+
+func TestUpdateAlarm_RoundTripsMetadata(t *testing.T) {
+	client, transport := newAlarmTestClient()
+
+	result, err := client.ListAlarms(context.Background(), "192.168.1.20")
+	require.NoError(t, err)
+	alarm := result.Alarms[0]
+	alarm.Enabled = false
+	require.NoError(t, client.UpdateAlarm(context.Background(), "192.168.1.20", alarm))
+
+	request := transport.requests["UpdateAlarm"]
+	require.Equal(t, "12", parseTextValue([]byte(request), "ID"))
+	require.Equal(t, "07:00:00", parseTextValue([]byte(request), "StartLocalTime"))
+	require.Equal(t, "0", parseTextValue([]byte(request), "Enabled"))
+	require.Equal(t, "25", parseTextValue([]byte(request), "Volume"))
+	require.Equal(t, alarm.ProgramURI, parseTextValue([]byte(request), "ProgramURI"))
+
+	// The metadata goes back escaped once, exactly as ListAlarms described it
+	require.Equal(t, alarmMetadata, parseTextValue([]byte(request), "ProgramMetaData"))
+	require.Contains(t, request, "&lt;dc:title&gt;Rock &amp;amp; Roll Radio&lt;/dc:title&gt;")
+}
+
+func TestCreateAndDestroyAlarm(t *testing.T) {
+	client, transport := newAlarmTestClient()
+
+	id, err := client.CreateAlarm(context.Background(), "192.168.1.20", Alarm{
+		ID:         "ignored",
+		StartTime:  "06:30:00",
+		Duration:   "01:00:00",
+		Recurrence: "ONCE",
+		Enabled:    true,
+		RoomUUID:   "RINCON_000E58A1B2C301400",
+		ProgramURI: "x-rincon-buzzer:0",
+		PlayMode:   "NORMAL",
+		Volume:     20,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "13", id)
+
+	request := transport.requests["CreateAlarm"]
+	require.NotContains(t, request, "<ID>")
+	require.Equal(t, "06:30:00", parseTextValue([]byte(request), "StartLocalTime"))
+	require.Equal(t, "1", parseTextValue([]byte(request), "Enabled"))
+	require.Equal(t, "0", parseTextValue([]byte(request), "IncludeLinkedZones"))
+
+	require.NoError(t, client.DestroyAlarm(context.Background(), "192.168.1.20", "13"))
+	require.Equal(t, "13", parseTextValue([]byte(transport.requests["DestroyAlarm"]), "ID"))
+}
@@ -17,6 +17,23 @@ import (
 type Client struct {
 	httpClient *http.Client
 	timeout    time.Duration
+	relocator  Relocator
+}
+
+// Relocator finds a device's new address after its old one stops answering, e.g. when a
+// DHCP lease changes (implemented by devices.Service).
+type Relocator interface {
+	// RelocateDevice returns the current address of the device last seen at staleIP, or
+	// ok=false if it isn't known or can't be found.
+	RelocateDevice(staleIP string) (newIP string, ok bool)
+}
+
+type noRelocateKey struct{}
+
+// WithoutRelocation marks ctx so that failed actions made with it aren't relocated and
+// retried. The relocator uses it for its own lookups.
+func WithoutRelocation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRelocateKey{}, true)
 }
 
 // NewClient creates a SOAP client with the given timeout.
@@ -36,6 +53,12 @@ func NewClient(timeout time.Duration) *Client {
 	}
 }
 
+// SetRelocator sets the relocator consulted when a device is unreachable or times out.
+// The action is retried once at the device's new address.
+func (c *Client) SetRelocator(relocator Relocator) {
+	c.relocator = relocator
+}
+
 // ExecuteAction sends a SOAP request and returns the raw response body.
 func (c *Client) ExecuteAction(
 	ctx context.Context,
@@ -43,6 +66,33 @@ func (c *Client) ExecuteAction(
 	service Service,
 	action string,
 	args map[string]string,
+) ([]byte, error) {
+	payload, err := c.executeAction(ctx, ip, service, action, args)
+	if err == nil || c.relocator == nil || ctx.Value(noRelocateKey{}) != nil || errors.Is(ctx.Err(), context.Canceled) {
+		return payload, err
+	}
+	var timeoutErr *SonosTimeoutError
+	var unreachableErr *SonosUnreachableError
+	if !errors.As(err, &timeoutErr) && !errors.As(err, &unreachableErr) {
+		return payload, err
+	}
+
+	newIP, ok := c.relocator.RelocateDevice(ip)
+	if !ok || newIP == ip {
+		return payload, err
+	}
+	// The first attempt may have used up ctx's deadline, so the retry gets its own
+	retryCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.timeout)
+	defer cancel()
+	return c.executeAction(retryCtx, newIP, service, action, args)
+}
+
+func (c *Client) executeAction(
+	ctx context.Context,
+	ip string,
+	service Service,
+	action string,
+	args map[string]string,
 ) ([]byte, error) {
 	serviceType := serviceTypes[service]
 	controlPath := controlPaths[service]
@@ -51,7 +101,7 @@ func (c *Client) ExecuteAction(
 	}
 
 	body := buildEnvelope(serviceType, action, args)
-	url := fmt.Sprintf("http://%s:1400%s", ip, controlPath)
+	url := fmt.Sprintf("http://%s%s", deviceAddr(ip), controlPath)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
@@ -86,6 +136,40 @@ func (c *Client) ExecuteAction(
 	return payload, nil
 }
 
+// GetDeviceDescription fetches the speaker's UPnP device description. It is a plain
+// HTTP document rather than a SOAP action.
+func (c *Client) GetDeviceDescription(ctx context.Context, ip string) (DeviceDescription, error) {
+	url := fmt.Sprintf("http://%s/xml/device_description.xml", deviceAddr(ip))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return DeviceDescription{}, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return DeviceDescription{}, &SonosUnreachableError{Action: "GetDeviceDescription", Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return DeviceDescription{}, fmt.Errorf("device description request failed: http %d", resp.StatusCode)
+	}
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return DeviceDescription{}, err
+	}
+	return parseDeviceDescription(payload), nil
+}
+
+// deviceAddr returns the host:port for a device. Sonos devices listen on port 1400;
+// an ip that already includes a port (as test servers do) is used as is.
+func deviceAddr(ip string) string {
+	if _, _, err := net.SplitHostPort(ip); err == nil {
+		return ip
+	}
+	return net.JoinHostPort(ip, "1400")
+}
+
 func buildEnvelope(serviceType, action string, args map[string]string) []byte {
 	var buf strings.Builder
 	buf.WriteString("<?xml version=\"1.0\" encoding=\"utf-8\"?>")
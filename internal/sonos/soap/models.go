@@ -1,5 +1,7 @@
 package soap
 
+import "strings"
+
 // TransportInfo mirrors Sonos GetTransportInfo response.
 type TransportInfo struct {
 	CurrentTransportState  string
@@ -7,6 +9,12 @@ type TransportInfo struct {
 	CurrentSpeed           string
 }
 
+// TransportSettings mirrors Sonos GetTransportSettings response.
+type TransportSettings struct {
+	PlayMode       string // NORMAL, REPEAT_ALL, REPEAT_ONE, SHUFFLE_NOREPEAT, SHUFFLE, SHUFFLE_REPEAT_ONE
+	RecQualityMode string
+}
+
 // PositionInfo mirrors Sonos GetPositionInfo response.
 type PositionInfo struct {
 	Track         int
@@ -77,6 +85,23 @@ type FavoriteItem struct {
 	ResourceMetaData string
 }
 
+// DeviceDescription is the part of a speaker's device_description.xml used to tell
+// which inputs it has.
+type DeviceDescription struct {
+	ModelName    string
+	ServiceTypes []string // Every UPnP service the speaker and its embedded devices offer
+}
+
+// HasService reports whether the speaker offers the named UPnP service, e.g. AudioIn.
+func (d DeviceDescription) HasService(name string) bool {
+	for _, serviceType := range d.ServiceTypes {
+		if strings.Contains(serviceType, ":service:"+name+":") {
+			return true
+		}
+	}
+	return false
+}
+
 // Alarm mirrors an alarm item from ListAlarms.
 type Alarm struct {
 	ID                 string
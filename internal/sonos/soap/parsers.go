@@ -35,6 +35,13 @@ func parseTransportInfo(payload []byte) TransportInfo {
 	}
 }
 
+func parseTransportSettings(payload []byte) TransportSettings {
+	return TransportSettings{
+		PlayMode:       parseTextValue(payload, "PlayMode"),
+		RecQualityMode: parseTextValue(payload, "RecQualityMode"),
+	}
+}
+
 func parsePositionInfo(payload []byte) PositionInfo {
 	trackStr := parseTextValue(payload, "Track")
 	track, _ := strconv.Atoi(trackStr)
@@ -75,3 +82,32 @@ func parseMute(payload []byte) MuteInfo {
 func parseDeviceUUID(payload []byte) string {
 	return parseTextValue(payload, "CurrentUUID")
 }
+
+func parseDeviceDescription(payload []byte) DeviceDescription {
+	var desc DeviceDescription
+	decoder := xml.NewDecoder(bytes.NewReader(payload))
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch se.Name.Local {
+		case "modelName":
+			var value string
+			// The root device comes first; embedded devices repeat the model name
+			if err := decoder.DecodeElement(&value, &se); err == nil && desc.ModelName == "" {
+				desc.ModelName = strings.TrimSpace(value)
+			}
+		case "serviceType":
+			var value string
+			if err := decoder.DecodeElement(&value, &se); err == nil {
+				desc.ServiceTypes = append(desc.ServiceTypes, strings.TrimSpace(value))
+			}
+		}
+	}
+	return desc
+}
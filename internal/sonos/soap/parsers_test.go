@@ -0,0 +1,140 @@
+package soap
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fiveDeviceDescription is a trimmed device_description.xml from a Sonos Five: the root
+// device lists its own services and embeds a media server and renderer with theirs.
+const fiveDeviceDescription = `<?xml version="1.0" encoding="utf-8" ?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <specVersion><major>1</major><minor>0</minor></specVersion>
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:ZonePlayer:1</deviceType>
+    <friendlyName>192.168.1.31 - Sonos Five - RINCON_000E58F1234501400</friendlyName>
+    <modelNumber>S23</modelNumber>
+    <modelName>Sonos Five</modelName>
+    <UDN>uuid:RINCON_000E58F1234501400</UDN>
+    <serviceList>
+      <service>
+        <serviceType>urn:schemas-upnp-org:service:AlarmClock:1</serviceType>
+        <serviceId>urn:upnp-org:serviceId:AlarmClock</serviceId>
+      </service>
+      <service>
+        <serviceType>urn:schemas-upnp-org:service:AudioIn:1</serviceType>
+        <serviceId>urn:upnp-org:serviceId:AudioIn</serviceId>
+      </service>
+    </serviceList>
+    <deviceList>
+      <device>
+        <deviceType>urn:schemas-upnp-org:device:MediaRenderer:1</deviceType>
+        <modelName>Sonos Five</modelName>
+        <UDN>uuid:RINCON_000E58F1234501400_MR</UDN>
+        <serviceList>
+          <service>
+            <serviceType>urn:schemas-upnp-org:service:RenderingControl:1</serviceType>
+            <serviceId>urn:upnp-org:serviceId:RenderingControl</serviceId>
+          </service>
+          <service>
+            <serviceType>urn:schemas-upnp-org:service:AVTransport:1</serviceType>
+            <serviceId>urn:upnp-org:serviceId:AVTransport</serviceId>
+          </service>
+        </serviceList>
+      </device>
+    </deviceList>
+  </device>
+</root>`
+
+func TestParseDeviceDescription(t *testing.T) {
+	desc := parseDeviceDescription([]byte(fiveDeviceDescription))
+	require.Equal(t, "Sonos Five", desc.ModelName)
+	require.Len(t, desc.ServiceTypes, 4)
+	require.True(t, desc.HasService("AudioIn"))
+	require.True(t, desc.HasService("AVTransport"))
+	require.False(t, desc.HasService("HTControl"))
+	require.False(t, desc.HasService("Audio"), "service names match whole")
+}
+
+func TestParseMusicLibraryResult_Browse(t *testing.T) {
+	didl := `<DIDL-Lite xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/" xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/">` +
+		`<container id="A:ALBUMARTIST/Miles%20Davis/Kind%20of%20Blue" parentID="A:ALBUMARTIST/Miles%20Davis" restricted="true">` +
+		`<dc:title>Kind of Blue</dc:title><upnp:class>object.container.album.musicAlbum</upnp:class>` +
+		`<upnp:albumArtURI>/getaa?s=1&amp;u=x-file-cifs%3a%2f%2fnas%2fso-what.flac</upnp:albumArtURI></container>` +
+		`<item id="S://nas/so-what.flac" parentID="A:ALBUMARTIST/Miles%20Davis/Kind%20of%20Blue" restricted="true">` +
+		`<res protocolInfo="x-file-cifs:*:audio/flac:*" duration="0:09:22">x-file-cifs://nas/so-what.flac</res>` +
+		`<dc:title>So What</dc:title><upnp:class>object.item.audioItem.musicTrack</upnp:class></item></DIDL-Lite>`
+	var escaped strings.Builder
+	require.NoError(t, xml.EscapeText(&escaped, []byte(didl)))
+	payload := []byte(`<s:Envelope><s:Body><u:BrowseResponse><Result>` + escaped.String() +
+		`</Result><NumberReturned>2</NumberReturned><TotalMatches>120</TotalMatches></u:BrowseResponse></s:Body></s:Envelope>`)
+
+	result := parseMusicLibraryResult(payload, "")
+	require.Equal(t, 2, result.NumberReturned)
+	require.Equal(t, 120, result.TotalMatches)
+	require.Len(t, result.Items, 2)
+
+	album := result.Items[0]
+	require.Equal(t, "A:ALBUMARTIST/Miles%20Davis/Kind%20of%20Blue", album.ID)
+	require.Equal(t, MusicLibraryContentType(""), album.ContentType)
+	require.Equal(t, "object.container.album.musicAlbum", album.UpnpClass)
+	require.Equal(t, "/getaa?s=1&u=x-file-cifs%3a%2f%2fnas%2fso-what.flac", album.AlbumArtURI)
+
+	track := result.Items[1]
+	require.Equal(t, MusicLibraryTrack, track.ContentType)
+	require.Equal(t, "So What", track.Title)
+	require.Equal(t, "x-file-cifs://nas/so-what.flac", track.Resource)
+	require.Equal(t, "0:09:22", track.Duration)
+}
+
+// Pandora and iHeartRadio station favorites as a speaker's FV:2 browse returns them.
+const (
+	pandoraFavoriteDidl = `<item id="FV:2/41" parentID="FV:2" restricted="false"><dc:title>Miles Davis Radio</dc:title>` +
+		`<upnp:class>object.itemobject.item.sonos-favorite</upnp:class><r:ordinal>12</r:ordinal>` +
+		`<res protocolInfo="sonos.com-http:*:audio/mpeg:*">x-sonosapi-radio:ST%3a4287539617305620481?sid=236&amp;flags=8300&amp;sn=5</res>` +
+		`<upnp:albumArtURI>https://content-images.p-cdn.us/images/public/int/4/5/2/5/00602567845254_500W_500H.jpg</upnp:albumArtURI>` +
+		`<r:type>instantPlay</r:type><r:description>Pandora Station</r:description>` +
+		`<r:resMD>&lt;DIDL-Lite xmlns:dc=&quot;http://purl.org/dc/elements/1.1/&quot; xmlns:upnp=&quot;urn:schemas-upnp-org:metadata-1-0/upnp/&quot; xmlns:r=&quot;urn:schemas-rinconnetworks-com:metadata-1-0/&quot; xmlns=&quot;urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/&quot;&gt;` +
+		`&lt;item id=&quot;100c206cST%3a4287539617305620481&quot; parentID=&quot;10082064myStations&quot; restricted=&quot;true&quot;&gt;` +
+		`&lt;dc:title&gt;Miles Davis Radio&lt;/dc:title&gt;&lt;upnp:class&gt;object.item.audioItem.audioBroadcast.#station&lt;/upnp:class&gt;` +
+		`&lt;desc id=&quot;cdudn&quot; nameSpace=&quot;urn:schemas-rinconnetworks-com:metadata-1-0/&quot;&gt;SA_RINCON60423_X_#Svc60423-0-Token&lt;/desc&gt;` +
+		`&lt;/item&gt;&lt;/DIDL-Lite&gt;</r:resMD></item>`
+
+	iHeartRadioFavoriteDidl = `<item id="FV:2/42" parentID="FV:2" restricted="false"><dc:title>Z100 &amp; More</dc:title>` +
+		`<upnp:class>object.itemobject.item.sonos-favorite</upnp:class><r:ordinal>13</r:ordinal>` +
+		`<res protocolInfo="x-sonosapi-hls:*:application/x-mpegURL:*">x-sonosapi-hls:live%3a1469?sid=6&amp;flags=8232&amp;sn=3</res>` +
+		`<upnp:albumArtURI>https://i.iheart.com/v3/re/new_assets/5bd31f59a3aff4c9cb08f50c</upnp:albumArtURI>` +
+		`<r:type>instantPlay</r:type><r:description>iHeartRadio Station</r:description>` +
+		`<r:resMD>&lt;DIDL-Lite xmlns:dc=&quot;http://purl.org/dc/elements/1.1/&quot; xmlns:upnp=&quot;urn:schemas-upnp-org:metadata-1-0/upnp/&quot; xmlns:r=&quot;urn:schemas-rinconnetworks-com:metadata-1-0/&quot; xmlns=&quot;urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/&quot;&gt;` +
+		`&lt;item id=&quot;F00092020live%3a1469&quot; parentID=&quot;F00082064live&quot; restricted=&quot;true&quot;&gt;` +
+		`&lt;dc:title&gt;Z100 &amp;amp; More&lt;/dc:title&gt;&lt;upnp:class&gt;object.item.audioItem.audioBroadcast&lt;/upnp:class&gt;` +
+		`&lt;/item&gt;&lt;/DIDL-Lite&gt;</r:resMD></item>`
+)
+
+func TestParseDidlFavorites_RadioStations(t *testing.T) {
+	didl := `<DIDL-Lite xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/" xmlns:r="urn:schemas-rinconnetworks-com:metadata-1-0/" xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/">` +
+		pandoraFavoriteDidl + iHeartRadioFavoriteDidl + `</DIDL-Lite>`
+
+	favorites := parseDidlFavorites([]byte(didl))
+	require.Len(t, favorites, 2)
+
+	pandora := favorites[0]
+	require.Equal(t, "FV:2/41", pandora.ID)
+	require.Equal(t, "Miles Davis Radio", pandora.Title)
+	require.Equal(t, "object.itemobject.item.sonos-favorite", pandora.UpnpClass)
+	require.Equal(t, "x-sonosapi-radio:ST%3a4287539617305620481?sid=236&flags=8300&sn=5", pandora.Resource)
+	require.Equal(t, "Pandora Station", pandora.ServiceName)
+	require.Equal(t, "12", pandora.Ordinal)
+	require.Contains(t, pandora.ResourceMetaData, `<item id="100c206cST%3a4287539617305620481" parentID="10082064myStations"`)
+	require.Contains(t, pandora.ResourceMetaData, "SA_RINCON60423_X_#Svc60423-0-Token")
+
+	iHeart := favorites[1]
+	require.Equal(t, "Z100 & More", iHeart.Title)
+	require.Equal(t, "x-sonosapi-hls:live%3a1469?sid=6&flags=8232&sn=3", iHeart.Resource)
+	require.Equal(t, "iHeartRadio Station", iHeart.ServiceName)
+	require.Contains(t, iHeart.ResourceMetaData, "<dc:title>Z100 &amp; More</dc:title>")
+	require.NotContains(t, iHeart.ResourceMetaData, "<desc")
+}
@@ -0,0 +1,138 @@
+package sonos
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+// Playback sources a group can be switched to.
+const (
+	SourceTV     = "tv"      // A home theater speaker's HDMI/optical input
+	SourceLineIn = "line_in" // A speaker's analog line-in
+	SourceQueue  = "queue"   // The coordinator's queue
+)
+
+// sourceServices are the UPnP services a speaker offers when it has the input.
+var sourceServices = map[string]string{
+	SourceTV:     "HTControl",
+	SourceLineIn: "AudioIn",
+}
+
+// TVInputURI is the transport URI that plays the TV input of the speaker with uuid.
+func TVInputURI(uuid string) string {
+	return fmt.Sprintf("x-sonos-htastream:%s:spdif", uuid)
+}
+
+// LineInURI is the transport URI that plays the line-in of the speaker with uuid.
+func LineInURI(uuid string) string {
+	return "x-rincon-stream:" + uuid
+}
+
+// QueueURI is the transport URI that plays the queue of the coordinator with uuid.
+func QueueURI(uuid string) string {
+	return fmt.Sprintf("x-rincon-queue:%s#0", uuid)
+}
+
+// IsTVInputURI reports whether uri plays a TV input, over HDMI/optical or the Sonos
+// voice line-in.
+func IsTVInputURI(uri string) bool {
+	return strings.HasPrefix(uri, "x-sonos-htastream:") || strings.HasPrefix(uri, "x-sonos-vli:")
+}
+
+// IsLineInURI reports whether uri plays a speaker's line-in.
+func IsLineInURI(uri string) bool {
+	return strings.HasPrefix(uri, "x-rincon-stream:")
+}
+
+// HasSource reports whether a speaker with the given description has the source's input.
+// Every speaker can play its queue.
+func HasSource(desc soap.DeviceDescription, source string) bool {
+	service, ok := sourceServices[source]
+	return !ok || desc.HasService(service)
+}
+
+// sourceURI returns the transport URI for source. Inputs belong to the requested speaker;
+// the queue belongs to the coordinator.
+func sourceURI(source, deviceUUID, coordinatorUUID string) string {
+	switch source {
+	case SourceTV:
+		return TVInputURI(deviceUUID)
+	case SourceLineIn:
+		return LineInURI(deviceUUID)
+	default:
+		return QueueURI(coordinatorUUID)
+	}
+}
+
+// setSourceHandler handles POST /v1/sonos/playback/source. The URI is set on the group
+// coordinator, so the whole group switches to the speaker's input.
+func setSourceHandler(service *Service) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var body struct {
+			UDN    string `json:"udn"`
+			Source string `json:"source"`
+		}
+		if err := decodeJSON(r, &body); err != nil || body.UDN == "" {
+			return apperrors.NewValidationError("udn is required", nil)
+		}
+		if body.Source != SourceTV && body.Source != SourceLineIn && body.Source != SourceQueue {
+			return apperrors.NewValidationError("source must be one of tv, line_in, queue", nil)
+		}
+
+		deviceIP, err := service.ResolveDeviceIP(body.UDN)
+		if err != nil {
+			return deviceFailure(err, body.UDN, "", "Failed to resolve device")
+		}
+
+		if body.Source != SourceQueue {
+			desc, err := service.GetDeviceDescription(deviceIP)
+			if err != nil {
+				return apperrors.NewInternalError("Failed to fetch device description")
+			}
+			if !HasSource(desc, body.Source) {
+				return apperrors.NewValidationError("Device has no "+body.Source+" input", map[string]any{
+					"udn":    body.UDN,
+					"model":  desc.ModelName,
+					"source": body.Source,
+				})
+			}
+		}
+
+		target := ResolveGroupCoordinator(service, deviceIP)
+		var deviceUUID string
+		for _, member := range target.Members {
+			if member.IP == deviceIP {
+				deviceUUID = member.UDN
+			}
+		}
+		if deviceUUID == "" || target.CoordinatorUDN == "" {
+			return apperrors.NewInternalError("Failed to resolve device from zone group state")
+		}
+
+		uri := sourceURI(body.Source, deviceUUID, target.CoordinatorUDN)
+		if err := service.SetAVTransportURI(target.CoordinatorIP, uri); err != nil {
+			return apperrors.NewInternalError("Failed to switch source")
+		}
+		if err := service.Play(target.CoordinatorIP); err != nil {
+			return apperrors.NewInternalError("Failed to start playback")
+		}
+
+		response := map[string]any{
+			"object":      "playback_action",
+			"udn":         body.UDN,
+			"action":      "source",
+			"source":      body.Source,
+			"uri":         uri,
+			"switched_at": api.RFC3339Millis(time.Now()),
+		}
+		addDebugTargets(r, response, deviceIP, []string{target.CoordinatorIP})
+
+		return api.WriteAction(w, http.StatusOK, response)
+	}
+}
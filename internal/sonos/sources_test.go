@@ -0,0 +1,40 @@
+package sonos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/sonos/soap"
+)
+
+func TestSourceURIs(t *testing.T) {
+	require.Equal(t, "x-sonos-htastream:RINCON_ARC01400:spdif", sourceURI(SourceTV, "RINCON_ARC01400", "RINCON_COORD01400"))
+	require.Equal(t, "x-rincon-stream:RINCON_FIVE01400", sourceURI(SourceLineIn, "RINCON_FIVE01400", "RINCON_COORD01400"))
+	require.Equal(t, "x-rincon-queue:RINCON_COORD01400#0", sourceURI(SourceQueue, "RINCON_FIVE01400", "RINCON_COORD01400"))
+
+	require.True(t, IsTVInputURI(TVInputURI("RINCON_ARC01400")))
+	require.True(t, IsTVInputURI("x-sonos-vli:RINCON_ARC01400:1,airplay"))
+	require.False(t, IsTVInputURI(LineInURI("RINCON_FIVE01400")))
+	require.True(t, IsLineInURI(LineInURI("RINCON_FIVE01400")))
+	require.False(t, IsLineInURI(QueueURI("RINCON_FIVE01400")))
+}
+
+func TestHasSource(t *testing.T) {
+	arc := soap.DeviceDescription{ServiceTypes: []string{
+		"urn:schemas-upnp-org:service:DeviceProperties:1",
+		"urn:schemas-upnp-org:service:HTControl:1",
+		"urn:schemas-upnp-org:service:AVTransport:1",
+	}}
+	five := soap.DeviceDescription{ServiceTypes: []string{
+		"urn:schemas-upnp-org:service:DeviceProperties:1",
+		"urn:schemas-upnp-org:service:AudioIn:1",
+		"urn:schemas-upnp-org:service:AVTransport:1",
+	}}
+
+	require.True(t, HasSource(arc, SourceTV))
+	require.False(t, HasSource(arc, SourceLineIn))
+	require.True(t, HasSource(five, SourceLineIn))
+	require.False(t, HasSource(five, SourceTV))
+	require.True(t, HasSource(five, SourceQueue))
+}
@@ -0,0 +1,465 @@
+package sonos
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+)
+
+// Volume ramp statuses.
+const (
+	RampStatusRunning    = "running"
+	RampStatusCompleted  = "completed"
+	RampStatusCancelled  = "cancelled"  // Stopped on request at the level it had reached
+	RampStatusSuperseded = "superseded" // Replaced by a newer ramp on the same speakers
+)
+
+// finishedRampRetention is how long a finished ramp can still be looked up.
+const finishedRampRetention = 10 * time.Minute
+
+// volumeSetter sets one speaker's volume, giving up when ctx is cancelled.
+type volumeSetter func(ctx context.Context, deviceIP string, level int) error
+
+// serviceVolumeSetter sets volume through the service's SOAPLimiter.
+func serviceVolumeSetter(service *Service) volumeSetter {
+	return func(ctx context.Context, deviceIP string, level int) error {
+		return service.Limiter.DoContext(ctx, deviceIP, func() error {
+			return service.SetVolumeContext(ctx, deviceIP, level)
+		})
+	}
+}
+
+// volumeRamp is a volume ramp running in the background on a group's speakers.
+type volumeRamp struct {
+	id          string
+	udn         string
+	memberIPs   []string
+	startLevel  int
+	targetLevel int
+	durationMs  int
+	curve       string
+	startedAt   time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{} // Closed once the ramp has stopped changing volume
+
+	mu           sync.Mutex
+	status       string
+	stopReason   string // Status to finish with when cancelled
+	currentLevel int
+	stepsDone    int
+	stepsTotal   int
+	results      []deviceVolumeResult
+	finishedAt   time.Time
+}
+
+// stop cancels the ramp, recording why unless it has already been stopped.
+func (ramp *volumeRamp) stop(reason string) {
+	ramp.mu.Lock()
+	if ramp.status == RampStatusRunning && ramp.stopReason == "" {
+		ramp.stopReason = reason
+	}
+	ramp.mu.Unlock()
+	ramp.cancel()
+}
+
+// volumeRampManager runs volume ramps in the background. A ramp started on speakers that
+// already have one running supersedes it.
+type volumeRampManager struct {
+	setVolume volumeSetter
+	now       func() time.Time
+
+	mu     sync.Mutex
+	ramps  map[string]*volumeRamp // By ID, including recently finished ramps
+	active map[string]*volumeRamp // Running ramp by member IP
+}
+
+func newVolumeRampManager(setVolume volumeSetter) *volumeRampManager {
+	return &volumeRampManager{
+		setVolume: setVolume,
+		now:       time.Now,
+		ramps:     make(map[string]*volumeRamp),
+		active:    make(map[string]*volumeRamp),
+	}
+}
+
+// Start begins ramping memberIPs from startLevel to targetLevel and returns at once.
+func (m *volumeRampManager) Start(udn string, memberIPs []string, startLevel, targetLevel, durationMs int, curve string) *volumeRamp {
+	ctx, cancel := context.WithCancel(context.Background())
+	ramp := &volumeRamp{
+		id:           uuid.NewString(),
+		udn:          udn,
+		memberIPs:    memberIPs,
+		startLevel:   startLevel,
+		targetLevel:  targetLevel,
+		durationMs:   durationMs,
+		curve:        curve,
+		startedAt:    m.now(),
+		cancel:       cancel,
+		done:         make(chan struct{}),
+		status:       RampStatusRunning,
+		currentLevel: startLevel,
+	}
+
+	m.mu.Lock()
+	m.pruneLocked()
+	superseded := make([]*volumeRamp, 0, 1)
+	for _, ip := range memberIPs {
+		if previous := m.active[ip]; previous != nil && !containsRamp(superseded, previous) {
+			superseded = append(superseded, previous)
+		}
+		m.active[ip] = ramp
+	}
+	m.ramps[ramp.id] = ramp
+	m.mu.Unlock()
+
+	for _, previous := range superseded {
+		previous.stop(RampStatusSuperseded)
+	}
+
+	go m.run(ctx, ramp, superseded)
+	return ramp
+}
+
+// Get returns the ramp with id, or nil if there is none.
+func (m *volumeRampManager) Get(id string) *volumeRamp {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ramps[id]
+}
+
+// Cancel stops the ramp with id at the level it has reached and waits for it to finish.
+// A ramp that has already finished is returned unchanged.
+func (m *volumeRampManager) Cancel(id string) *volumeRamp {
+	ramp := m.Get(id)
+	if ramp == nil {
+		return nil
+	}
+	ramp.stop(RampStatusCancelled)
+	<-ramp.done
+	return ramp
+}
+
+func (m *volumeRampManager) run(ctx context.Context, ramp *volumeRamp, superseded []*volumeRamp) {
+	defer close(ramp.done)
+
+	// Let superseded ramps stop first so their last step can't land after ours
+	for _, previous := range superseded {
+		<-previous.done
+	}
+
+	results := rampVolume(ctx, m.setVolume, ramp.memberIPs, ramp.startLevel, ramp.targetLevel, ramp.durationMs, ramp.curve,
+		func(level, stepsDone, stepsTotal int) {
+			ramp.mu.Lock()
+			ramp.currentLevel = level
+			ramp.stepsDone = stepsDone
+			ramp.stepsTotal = stepsTotal
+			ramp.mu.Unlock()
+		})
+
+	m.mu.Lock()
+	for _, ip := range ramp.memberIPs {
+		if m.active[ip] == ramp {
+			delete(m.active, ip)
+		}
+	}
+	m.mu.Unlock()
+
+	ramp.mu.Lock()
+	ramp.results = results
+	ramp.status = RampStatusCompleted
+	if ctx.Err() != nil && ramp.stopReason != "" {
+		ramp.status = ramp.stopReason
+	}
+	ramp.finishedAt = m.now()
+	ramp.mu.Unlock()
+	ramp.cancel()
+}
+
+// pruneLocked forgets ramps that finished more than finishedRampRetention ago.
+func (m *volumeRampManager) pruneLocked() {
+	cutoff := m.now().Add(-finishedRampRetention)
+	for id, ramp := range m.ramps {
+		ramp.mu.Lock()
+		expired := ramp.status != RampStatusRunning && ramp.finishedAt.Before(cutoff)
+		ramp.mu.Unlock()
+		if expired {
+			delete(m.ramps, id)
+		}
+	}
+}
+
+func containsRamp(ramps []*volumeRamp, ramp *volumeRamp) bool {
+	for _, candidate := range ramps {
+		if candidate == ramp {
+			return true
+		}
+	}
+	return false
+}
+
+// RampStep is a level a volume ramp sets, due At after the ramp starts.
+type RampStep struct {
+	At    time.Duration
+	Level int
+}
+
+// RampSchedule returns the steps of a ramp from startLevel to targetLevel over durationMs,
+// as split up by VolumeRampSteps.
+func RampSchedule(startLevel, targetLevel, durationMs int, curve string) []RampStep {
+	levels, stepDelay := VolumeRampSteps(startLevel, targetLevel, durationMs, curve)
+	steps := make([]RampStep, 0, len(levels))
+	for i, level := range levels {
+		steps = append(steps, RampStep{At: time.Duration(i) * stepDelay, Level: level})
+	}
+	return steps
+}
+
+// RampRunner drives speakers through a volume ramp's steps. The volume ramp endpoint and
+// routines' wake ramps both run on it.
+type RampRunner struct {
+	SetVolume func(ctx context.Context, deviceIP string, level int) error
+
+	// WaitUntil waits for a step to fall due, and returns false to end the ramp there.
+	// Nil sleeps until then, or until ctx is done.
+	WaitUntil func(ctx context.Context, due time.Time) bool
+
+	// OnStep, if set, is called after each step with the level set, the number of steps
+	// done, and the speakers that have failed so far, by IP.
+	OnStep func(level, stepsDone int, failed map[string]string)
+}
+
+// Run steps deviceIPs through steps, each due its At after startedAt. A speaker that
+// fails a step is dropped from the rest of the ramp, and the ramp ends once every speaker
+// has failed. When ctx is cancelled the ramp stops at the level it has reached. Returns
+// the speakers that failed, by IP, with why.
+func (runner RampRunner) Run(ctx context.Context, deviceIPs []string, steps []RampStep, startedAt time.Time) map[string]string {
+	waitUntil := runner.WaitUntil
+	if waitUntil == nil {
+		waitUntil = sleepUntil
+	}
+
+	failedDevices := map[string]string{}
+	for i, step := range steps {
+		if ctx.Err() != nil || !waitUntil(ctx, startedAt.Add(step.At)) {
+			break
+		}
+
+		activeIPs := make([]string, 0, len(deviceIPs))
+		for _, ip := range deviceIPs {
+			if _, failed := failedDevices[ip]; !failed {
+				activeIPs = append(activeIPs, ip)
+			}
+		}
+		if len(activeIPs) == 0 {
+			break
+		}
+
+		stepResults := setVolumes(ctx, runner.SetVolume, activeIPs, step.Level)
+		if ctx.Err() != nil {
+			// Failures from the cancelled step say nothing about the speakers
+			break
+		}
+		for _, result := range stepResults {
+			if !result.Success {
+				failedDevices[result.IP] = result.Error
+			}
+		}
+		if runner.OnStep != nil {
+			runner.OnStep(step.Level, i+1, failedDevices)
+		}
+	}
+	return failedDevices
+}
+
+// sleepUntil waits until due, returning false if ctx is done first.
+func sleepUntil(ctx context.Context, due time.Time) bool {
+	wait := time.Until(due)
+	if wait <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// rampVolume steps memberIPs from startLevel to targetLevel over durationMs, reporting
+// each step through onStep. A speaker that fails a step is dropped from the rest of the
+// ramp. When ctx is cancelled the ramp stops at the level it has reached.
+func rampVolume(ctx context.Context, setVolume volumeSetter, memberIPs []string, startLevel, targetLevel, durationMs int, curve string, onStep func(level, stepsDone, stepsTotal int)) []deviceVolumeResult {
+	steps := []RampStep{{Level: targetLevel}}
+	if durationMs > 0 && startLevel != targetLevel {
+		steps = RampSchedule(startLevel, targetLevel, durationMs, curve)
+	}
+
+	failedDevices := RampRunner{
+		SetVolume: setVolume,
+		OnStep: func(level, stepsDone int, _ map[string]string) {
+			onStep(level, stepsDone, len(steps))
+		},
+	}.Run(ctx, memberIPs, steps, time.Now())
+
+	results := make([]deviceVolumeResult, 0, len(memberIPs))
+	for _, ip := range memberIPs {
+		if reason, failed := failedDevices[ip]; failed {
+			results = append(results, deviceVolumeResult{IP: ip, Success: false, Error: "Device failed during ramp: " + reason})
+			continue
+		}
+		results = append(results, deviceVolumeResult{IP: ip, Success: true})
+	}
+	return results
+}
+
+// setVolumes sets every speaker in memberIPs to level in parallel.
+func setVolumes(ctx context.Context, setVolume volumeSetter, memberIPs []string, level int) []deviceVolumeResult {
+	results := make([]deviceVolumeResult, len(memberIPs))
+	var wg sync.WaitGroup
+
+	for i, ip := range memberIPs {
+		wg.Add(1)
+		go func(idx int, targetIP string) {
+			defer wg.Done()
+			err := setVolume(ctx, targetIP, level)
+			result := deviceVolumeResult{IP: targetIP, Success: err == nil}
+			if err != nil {
+				result.Error = err.Error()
+			}
+			results[idx] = result
+		}(i, ip)
+	}
+
+	wg.Wait()
+	return results
+}
+
+func formatVolumeRamp(ramp *volumeRamp) map[string]any {
+	ramp.mu.Lock()
+	defer ramp.mu.Unlock()
+
+	progress := 0.0
+	if ramp.stepsTotal > 0 {
+		progress = math.Round(float64(ramp.stepsDone)/float64(ramp.stepsTotal)*100) / 100
+	}
+
+	response := map[string]any{
+		"object":          "volume_ramp",
+		"id":              ramp.id,
+		"udn":             ramp.udn,
+		"status":          ramp.status,
+		"start_level":     ramp.startLevel,
+		"target_level":    ramp.targetLevel,
+		"current_level":   ramp.currentLevel,
+		"duration_ms":     ramp.durationMs,
+		"curve":           ramp.curve,
+		"progress":        progress,
+		"started_at":      api.RFC3339Millis(ramp.startedAt),
+		"finished_at":     nil,
+		"all_succeeded":   nil,
+		"succeeded_count": nil,
+		"failed_count":    nil,
+	}
+	if ramp.status != RampStatusRunning {
+		succeeded, failed := countResults(ramp.results)
+		response["finished_at"] = api.RFC3339Millis(ramp.finishedAt)
+		response["all_succeeded"] = failed == 0
+		response["succeeded_count"] = succeeded
+		response["failed_count"] = failed
+	}
+	return response
+}
+
+// startVolumeRampHandler handles POST /v1/sonos/volume/ramp. The ramp runs in the
+// background; its progress is at GET /v1/sonos/volume/ramps/{ramp_id}.
+func startVolumeRampHandler(service *Service, ramps *volumeRampManager) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var body struct {
+			UDN         string   `json:"udn"`
+			TargetLevel *float64 `json:"target_level"`
+			DurationMs  *int     `json:"duration_ms"`
+			Curve       string   `json:"curve"`
+		}
+		if err := decodeJSON(r, &body); err != nil {
+			return apperrors.NewValidationError("udn is required", nil)
+		}
+		if body.UDN == "" {
+			return apperrors.NewValidationError("udn is required", nil)
+		}
+		if body.TargetLevel == nil || *body.TargetLevel < 0 || *body.TargetLevel > 100 {
+			return apperrors.NewValidationError("target_level must be a number between 0 and 100", nil)
+		}
+
+		durationMs := 2000
+		if body.DurationMs != nil {
+			durationMs = *body.DurationMs
+		}
+		if durationMs < 0 {
+			return apperrors.NewValidationError("duration_ms must be non-negative", nil)
+		}
+
+		curve := body.Curve
+		if curve == "" {
+			curve = "linear"
+		}
+
+		deviceIP, err := service.ResolveDeviceIP(body.UDN)
+		if err != nil {
+			return deviceFailure(err, body.UDN, "", "Failed to resolve device")
+		}
+
+		currentVolume, err := service.GetVolume(deviceIP)
+		if err != nil {
+			return deviceFailure(err, body.UDN, deviceIP, "Failed to fetch volume")
+		}
+
+		memberIPs := getGroupMemberIPs(service, deviceIP)
+		if len(memberIPs) == 0 {
+			return apperrors.NewValidationError("No devices resolved for volume control", nil)
+		}
+
+		target := int(math.Round(*body.TargetLevel))
+		ramp := ramps.Start(body.UDN, memberIPs, currentVolume.CurrentVolume, target, durationMs, curve)
+
+		response := formatVolumeRamp(ramp)
+		addDebugTargets(r, response, deviceIP, memberIPs)
+
+		return api.WriteAction(w, http.StatusAccepted, response)
+	}
+}
+
+// getVolumeRampHandler handles GET /v1/sonos/volume/ramps/{ramp_id}.
+func getVolumeRampHandler(ramps *volumeRampManager) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		rampID := chi.URLParam(r, "ramp_id")
+		ramp := ramps.Get(rampID)
+		if ramp == nil {
+			return apperrors.NewNotFoundResource("Volume ramp", rampID)
+		}
+		return api.WriteResource(w, http.StatusOK, formatVolumeRamp(ramp))
+	}
+}
+
+// cancelVolumeRampHandler handles DELETE /v1/sonos/volume/ramps/{ramp_id}. The speakers
+// stay at the level the ramp had reached.
+func cancelVolumeRampHandler(ramps *volumeRampManager) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		rampID := chi.URLParam(r, "ramp_id")
+		ramp := ramps.Cancel(rampID)
+		if ramp == nil {
+			return apperrors.NewNotFoundResource("Volume ramp", rampID)
+		}
+		return api.WriteAction(w, http.StatusOK, formatVolumeRamp(ramp))
+	}
+}
@@ -0,0 +1,153 @@
+package sonos
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVolumes records the last level set on each speaker.
+type fakeVolumes struct {
+	mu     sync.Mutex
+	levels map[string]int
+	calls  int
+	fail   map[string]bool
+}
+
+func newFakeVolumes() *fakeVolumes {
+	return &fakeVolumes{levels: map[string]int{}, fail: map[string]bool{}}
+}
+
+func (f *fakeVolumes) set(ctx context.Context, deviceIP string, level int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.fail[deviceIP] {
+		return errors.New("unreachable")
+	}
+	f.levels[deviceIP] = level
+	return nil
+}
+
+func (f *fakeVolumes) level(deviceIP string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.levels[deviceIP]
+}
+
+func waitForRamp(t *testing.T, ramp *volumeRamp) {
+	t.Helper()
+	select {
+	case <-ramp.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("ramp did not finish")
+	}
+}
+
+func TestVolumeRampManager_Completes(t *testing.T) {
+	volumes := newFakeVolumes()
+	volumes.fail["10.0.0.2"] = true
+	manager := newVolumeRampManager(volumes.set)
+
+	ramp := manager.Start("RINCON_1", []string{"10.0.0.1", "10.0.0.2"}, 10, 20, 200, "linear")
+	waitForRamp(t, ramp)
+
+	require.Equal(t, 20, volumes.level("10.0.0.1"))
+	response := formatVolumeRamp(manager.Get(ramp.id))
+	require.Equal(t, RampStatusCompleted, response["status"])
+	require.Equal(t, 20, response["current_level"])
+	require.Equal(t, 1.0, response["progress"])
+	require.Equal(t, false, response["all_succeeded"])
+	require.Equal(t, 1, response["succeeded_count"])
+	require.Equal(t, 1, response["failed_count"])
+	require.NotNil(t, response["finished_at"])
+}
+
+func TestVolumeRampManager_CancelStopsAtCurrentLevel(t *testing.T) {
+	volumes := newFakeVolumes()
+	manager := newVolumeRampManager(volumes.set)
+
+	ramp := manager.Start("RINCON_1", []string{"10.0.0.1"}, 0, 100, 10000, "linear")
+	require.Eventually(t, func() bool { return volumes.level("10.0.0.1") > 0 }, time.Second, 5*time.Millisecond)
+
+	cancelled := manager.Cancel(ramp.id)
+	require.Same(t, ramp, cancelled)
+
+	response := formatVolumeRamp(cancelled)
+	require.Equal(t, RampStatusCancelled, response["status"])
+	level := volumes.level("10.0.0.1")
+	require.Less(t, level, 100)
+	require.Equal(t, level, response["current_level"])
+
+	// Nothing is set once the ramp has stopped
+	volumes.mu.Lock()
+	calls := volumes.calls
+	volumes.mu.Unlock()
+	time.Sleep(100 * time.Millisecond)
+	volumes.mu.Lock()
+	defer volumes.mu.Unlock()
+	require.Equal(t, calls, volumes.calls)
+	require.Nil(t, manager.Cancel("missing"))
+}
+
+func TestVolumeRampManager_NewRampSupersedesOverlapping(t *testing.T) {
+	volumes := newFakeVolumes()
+	manager := newVolumeRampManager(volumes.set)
+
+	first := manager.Start("RINCON_1", []string{"10.0.0.1", "10.0.0.2"}, 0, 100, 10000, "linear")
+	other := manager.Start("RINCON_3", []string{"10.0.0.3"}, 0, 100, 10000, "linear")
+	second := manager.Start("RINCON_2", []string{"10.0.0.2"}, 50, 40, 100, "linear")
+
+	waitForRamp(t, first)
+	waitForRamp(t, second)
+	require.Equal(t, RampStatusSuperseded, formatVolumeRamp(first)["status"])
+	require.Equal(t, RampStatusCompleted, formatVolumeRamp(second)["status"])
+	require.Equal(t, 40, volumes.level("10.0.0.2"))
+
+	// Ramps on other speakers keep running
+	require.Equal(t, RampStatusRunning, formatVolumeRamp(other)["status"])
+	manager.Cancel(other.id)
+}
+
+func TestVolumeRampManager_PrunesFinishedRamps(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	volumes := newFakeVolumes()
+	manager := newVolumeRampManager(volumes.set)
+	manager.now = func() time.Time { return now }
+
+	ramp := manager.Start("RINCON_1", []string{"10.0.0.1"}, 10, 20, 0, "linear")
+	waitForRamp(t, ramp)
+	require.NotNil(t, manager.Get(ramp.id))
+
+	now = now.Add(finishedRampRetention + time.Second)
+	manager.Start("RINCON_1", []string{"10.0.0.1"}, 20, 30, 0, "linear")
+	require.Nil(t, manager.Get(ramp.id))
+}
+
+func TestRampVolume_CancelPropagatesToSetter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var mu sync.Mutex
+	var steps []int
+	set := func(ctx context.Context, deviceIP string, level int) error {
+		mu.Lock()
+		steps = append(steps, level)
+		if len(steps) == 3 {
+			cancel()
+		}
+		mu.Unlock()
+		return ctx.Err()
+	}
+
+	results := rampVolume(ctx, set, []string{"10.0.0.1"}, 0, 100, 1000, "linear", func(int, int, int) {})
+
+	// The step cancelled mid-call isn't counted as a device failure
+	require.Len(t, steps, 3)
+	require.Equal(t, []deviceVolumeResult{{IP: "10.0.0.1", Success: true}}, results)
+}
@@ -3,13 +3,13 @@ package sonoscloud
 import (
 	"encoding/json"
 	"errors"
-	"log"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 
 	"github.com/strefethen/sonos-hub-go/internal/api"
 	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
 	"github.com/strefethen/sonos-hub-go/internal/sonos/events"
 )
 
@@ -341,7 +341,8 @@ func handleWebhook(stateCache *events.StateCache, resolver GroupToIPResolver) fu
 			return apperrors.NewValidationError("invalid webhook payload", nil)
 		}
 
-		log.Printf("WEBHOOK: Received %s event for group %s", event.Type, event.GroupID)
+		logger := logging.From(r.Context(), nil).With("group_id", event.GroupID)
+		logger.Info("WEBHOOK: Received event", "type", event.Type)
 
 		// Try to resolve group ID to IP for cache update
 		var deviceIP string
@@ -350,7 +351,7 @@ func handleWebhook(stateCache *events.StateCache, resolver GroupToIPResolver) fu
 			if err == nil {
 				deviceIP = ip
 			} else {
-				log.Printf("WEBHOOK: Could not resolve group %s to IP: %v", event.GroupID, err)
+				logger.Warn("WEBHOOK: Could not resolve group to IP", "error", err)
 			}
 		}
 
@@ -359,7 +360,7 @@ func handleWebhook(stateCache *events.StateCache, resolver GroupToIPResolver) fu
 		case "playbackStatus":
 			var data PlaybackStatusData
 			if err := json.Unmarshal(event.Data, &data); err != nil {
-				log.Printf("WEBHOOK: Failed to parse playbackStatus data: %v", err)
+				logger.Warn("WEBHOOK: Failed to parse playbackStatus data", "error", err)
 				break
 			}
 
@@ -368,13 +369,13 @@ func handleWebhook(stateCache *events.StateCache, resolver GroupToIPResolver) fu
 					PlaybackState: data.PlaybackState,
 				}
 				stateCache.UpdateFromCloud(deviceIP, playback, nil)
-				log.Printf("WEBHOOK: Updated cache for %s: state=%s", deviceIP, data.PlaybackState)
+				logger.Debug("WEBHOOK: Updated cache", "ip", deviceIP, "state", data.PlaybackState)
 			}
 
 		case "volume":
 			var data VolumeData
 			if err := json.Unmarshal(event.Data, &data); err != nil {
-				log.Printf("WEBHOOK: Failed to parse volume data: %v", err)
+				logger.Warn("WEBHOOK: Failed to parse volume data", "error", err)
 				break
 			}
 
@@ -384,13 +385,13 @@ func handleWebhook(stateCache *events.StateCache, resolver GroupToIPResolver) fu
 					Muted:  &data.Muted,
 				}
 				stateCache.UpdateFromCloud(deviceIP, playback, nil)
-				log.Printf("WEBHOOK: Updated cache for %s: volume=%d, muted=%v", deviceIP, data.Volume, data.Muted)
+				logger.Debug("WEBHOOK: Updated cache", "ip", deviceIP, "volume", data.Volume, "muted", data.Muted)
 			}
 
 		case "metadataStatus":
 			var data MetadataStatusData
 			if err := json.Unmarshal(event.Data, &data); err != nil {
-				log.Printf("WEBHOOK: Failed to parse metadataStatus data: %v", err)
+				logger.Warn("WEBHOOK: Failed to parse metadataStatus data", "error", err)
 				break
 			}
 
@@ -407,11 +408,11 @@ func handleWebhook(stateCache *events.StateCache, resolver GroupToIPResolver) fu
 					metadata.AlbumName = track.Album.Name
 				}
 				stateCache.UpdateFromCloud(deviceIP, nil, metadata)
-				log.Printf("WEBHOOK: Updated cache for %s: track=%s", deviceIP, track.Name)
+				logger.Debug("WEBHOOK: Updated cache", "ip", deviceIP, "track", track.Name)
 			}
 
 		default:
-			log.Printf("WEBHOOK: Unhandled event type: %s", event.Type)
+			logger.Warn("WEBHOOK: Unhandled event type", "type", event.Type)
 		}
 
 		// Always return success to acknowledge the webhook
@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
+
+	"github.com/strefethen/sonos-hub-go/internal/audit"
 )
 
 var (
@@ -22,6 +24,18 @@ var (
 	ErrExtensionDisconnected = errors.New("Extension disconnected")
 )
 
+// DefaultReconnectGrace is how long a search waits for the extension to reconnect.
+const DefaultReconnectGrace = 3 * time.Second
+
+// maxSearchAttempts bounds how many times a search is sent when the extension keeps
+// disconnecting before it answers.
+const maxSearchAttempts = 3
+
+// EventRecorder records audit events (implemented by audit.Service).
+type EventRecorder interface {
+	RecordEvent(input audit.WriteEventInput) (*audit.AuditEvent, error)
+}
+
 type pendingSearch struct {
 	requestID    string
 	query        string
@@ -35,7 +49,10 @@ type searchResponse struct {
 	err     error
 }
 
-// ConnectionManager manages the WebSocket connection to the Spotify search extension
+// ConnectionManager manages the WebSocket connection to the Spotify search extension.
+// The extension connects to the hub and reconnects on its own after a drop; searches
+// made while it's away wait up to the reconnect grace period for it to come back, and
+// searches it dropped are sent again once it has.
 type ConnectionManager struct {
 	mu              sync.RWMutex
 	conn            *websocket.Conn
@@ -43,6 +60,18 @@ type ConnectionManager struct {
 	requestCounter  uint64
 	searchTimeout   time.Duration
 	pingInterval    time.Duration
+	reconnectGrace  time.Duration
+	recorder        EventRecorder
+
+	// Closed while connected; replaced with an open channel on disconnect, so searches
+	// can wait for the next connection
+	connected chan struct{}
+
+	// Connection history, for status
+	lastConnectedAt    *time.Time
+	lastDisconnectedAt *time.Time
+	reconnectCount     int
+	queuedSearches     atomic.Int32
 
 	// For cleanup
 	stopPing chan struct{}
@@ -54,73 +83,104 @@ func NewConnectionManager() *ConnectionManager {
 		pendingSearches: make(map[string]*pendingSearch),
 		searchTimeout:   15 * time.Second,
 		pingInterval:    30 * time.Second,
+		reconnectGrace:  DefaultReconnectGrace,
+		connected:       make(chan struct{}),
 	}
 }
 
+// SetReconnectGrace sets how long searches wait for a disconnected extension to
+// reconnect. Zero fails them immediately.
+func (m *ConnectionManager) SetReconnectGrace(grace time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reconnectGrace = grace
+}
+
+// SetEventRecorder audits the extension connecting and disconnecting.
+func (m *ConnectionManager) SetEventRecorder(recorder EventRecorder) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.recorder = recorder
+}
+
 // SetConnection registers a new WebSocket connection from the extension
 func (m *ConnectionManager) SetConnection(conn *websocket.Conn) {
 	m.mu.Lock()
 
-	// Close existing connection if any
+	// Close existing connection if any. Searches sent on it won't be answered on the
+	// new one, so they're sent again.
+	replaced := m.conn != nil
 	if m.conn != nil {
 		m.conn.Close()
+		m.rejectPendingLocked()
 	}
 	if m.stopPing != nil {
 		close(m.stopPing)
 	}
 
+	now := time.Now()
+	var disconnectedFor time.Duration
+	if m.lastConnectedAt != nil {
+		m.reconnectCount++
+		if !replaced && m.lastDisconnectedAt != nil {
+			disconnectedFor = now.Sub(*m.lastDisconnectedAt)
+		}
+	}
+	m.lastConnectedAt = &now
+	reconnectCount := m.reconnectCount
+
 	m.conn = conn
 	m.stopPing = make(chan struct{})
+	if !replaced {
+		close(m.connected)
+	}
+	recorder := m.recorder
 	m.mu.Unlock()
 
 	// Start ping interval
 	go m.startPingLoop()
 
 	// Start message reader
-	go m.readMessages()
+	go m.readMessages(conn)
 
-	log.Printf("Spotify search extension connected")
+	slog.Info("Spotify search extension connected", "reconnect_count", reconnectCount)
+	m.record(recorder, audit.EventSpotifyConnected, audit.LevelInfo, "Spotify search extension connected", map[string]any{
+		"reconnect_count":     reconnectCount,
+		"disconnected_for_ms": disconnectedFor.Milliseconds(),
+	})
 }
 
 func (m *ConnectionManager) startPingLoop() {
+	m.mu.RLock()
+	stopPing := m.stopPing
+	m.mu.RUnlock()
+
 	ticker := time.NewTicker(m.pingInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			m.mu.RLock()
-			conn := m.conn
-			m.mu.RUnlock()
-
-			if conn != nil {
-				msg := PingMessage{Type: "ping"}
-				m.mu.Lock()
-				err := m.conn.WriteJSON(msg)
-				m.mu.Unlock()
-				if err != nil {
-					log.Printf("Failed to send ping: %v", err)
-				}
+			m.mu.Lock()
+			var err error
+			if m.conn != nil {
+				err = m.conn.WriteJSON(PingMessage{Type: "ping"})
+			}
+			m.mu.Unlock()
+			if err != nil {
+				slog.Warn("Failed to send ping", "error", err)
 			}
-		case <-m.stopPing:
+		case <-stopPing:
 			return
 		}
 	}
 }
 
-func (m *ConnectionManager) readMessages() {
-	m.mu.RLock()
-	conn := m.conn
-	m.mu.RUnlock()
-
-	if conn == nil {
-		return
-	}
-
+func (m *ConnectionManager) readMessages(conn *websocket.Conn) {
 	for {
 		_, message, err := conn.ReadMessage()
 		if err != nil {
-			m.handleDisconnect()
+			m.handleDisconnect(conn)
 			return
 		}
 
@@ -132,7 +192,7 @@ func (m *ConnectionManager) handleMessage(message []byte) {
 	// First parse just the type
 	var incoming IncomingMessage
 	if err := json.Unmarshal(message, &incoming); err != nil {
-		log.Printf("Failed to parse WebSocket message: %v", err)
+		slog.Warn("Failed to parse WebSocket message", "error", err)
 		return
 	}
 
@@ -143,12 +203,12 @@ func (m *ConnectionManager) handleMessage(message []byte) {
 	case "searchResult":
 		var result SearchResultMessage
 		if err := json.Unmarshal(message, &result); err != nil {
-			log.Printf("Failed to parse search result: %v", err)
+			slog.Warn("Failed to parse search result", "error", err)
 			return
 		}
 		m.handleSearchResult(&result)
 	default:
-		log.Printf("Unknown message type: %s", incoming.Type)
+		slog.Warn("Unknown message type", "type", incoming.Type)
 	}
 }
 
@@ -161,46 +221,80 @@ func (m *ConnectionManager) handleSearchResult(result *SearchResultMessage) {
 	m.mu.Unlock()
 
 	if !exists {
-		log.Printf("Received result for unknown request: %s", result.RequestID)
+		slog.Warn("Received result for unknown request", "search_request_id", result.RequestID)
 		return
 	}
 
 	duration := time.Since(pending.createdAt)
 
 	if result.Error != "" {
-		log.Printf("Search failed for query '%s': %s (took %v)", pending.query, result.Error, duration)
+		slog.Warn("Search failed", "query", pending.query, "error", result.Error, "duration", duration)
 		pending.resultCh <- searchResponse{err: errors.New(result.Error)}
 	} else {
-		log.Printf("Search completed for query '%s' (took %v)", pending.query, duration)
+		slog.Info("Search completed", "query", pending.query, "duration", duration)
 		pending.resultCh <- searchResponse{results: &result.Results}
 	}
 }
 
-func (m *ConnectionManager) handleDisconnect() {
+// handleDisconnect clears conn when it's still the current connection. A connection
+// that was replaced has already been dealt with.
+func (m *ConnectionManager) handleDisconnect(conn *websocket.Conn) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	if m.conn != conn {
+		m.mu.Unlock()
+		return
+	}
 
-	log.Printf("Spotify search extension disconnected")
+	now := time.Now()
+	var connectedFor time.Duration
+	if m.lastConnectedAt != nil {
+		connectedFor = now.Sub(*m.lastConnectedAt)
+	}
+	m.lastDisconnectedAt = &now
+	pendingCount := len(m.pendingSearches)
 
 	m.conn = nil
+	m.connected = make(chan struct{})
 	if m.stopPing != nil {
-		// Use a select to avoid panic if channel is already closed
-		select {
-		case <-m.stopPing:
-			// Already closed
-		default:
-			close(m.stopPing)
-		}
+		close(m.stopPing)
 		m.stopPing = nil
 	}
 
-	// Reject all pending searches
+	// Pending searches are sent again if the extension reconnects in time
+	m.rejectPendingLocked()
+	recorder := m.recorder
+	m.mu.Unlock()
+
+	slog.Info("Spotify search extension disconnected", "pending_searches", pendingCount)
+	m.record(recorder, audit.EventSpotifyDisconnected, audit.LevelWarn, "Spotify search extension disconnected", map[string]any{
+		"connected_for_ms": connectedFor.Milliseconds(),
+		"pending_searches": pendingCount,
+	})
+}
+
+// rejectPendingLocked fails every pending search with ErrExtensionDisconnected. Call
+// with mu held.
+func (m *ConnectionManager) rejectPendingLocked() {
 	for _, pending := range m.pendingSearches {
 		pending.resultCh <- searchResponse{err: ErrExtensionDisconnected}
 	}
 	m.pendingSearches = make(map[string]*pendingSearch)
 }
 
+func (m *ConnectionManager) record(recorder EventRecorder, eventType audit.EventType, level audit.EventLevel, message string, payload map[string]any) {
+	if recorder == nil {
+		return
+	}
+	if _, err := recorder.RecordEvent(audit.WriteEventInput{
+		Type:    string(eventType),
+		Level:   &level,
+		Message: message,
+		Payload: payload,
+	}); err != nil {
+		slog.Warn("Failed to record Spotify search connection event", "error", err)
+	}
+}
+
 // IsConnected returns whether the extension is connected
 func (m *ConnectionManager) IsConnected() bool {
 	m.mu.RLock()
@@ -219,21 +313,72 @@ func (m *ConnectionManager) GetStatus() ConnectionStatus {
 	}
 
 	return ConnectionStatus{
-		Extension:       status,
-		PendingSearches: len(m.pendingSearches),
+		Extension:          status,
+		PendingSearches:    len(m.pendingSearches),
+		QueuedSearches:     int(m.queuedSearches.Load()),
+		LastConnectedAt:    m.lastConnectedAt,
+		LastDisconnectedAt: m.lastDisconnectedAt,
+		ReconnectCount:     m.reconnectCount,
 	}
 }
 
-// Search performs a search via the extension
+// Search performs a search via the extension. While the extension is disconnected the
+// search waits up to the reconnect grace period for it to come back; a search the
+// extension drops by disconnecting is sent again once it reconnects.
 func (m *ConnectionManager) Search(ctx context.Context, query string, contentTypes []SpotifyContentType) (*GroupedSearchResults, error) {
+	for attempt := 1; ; attempt++ {
+		conn, err := m.waitForConnection(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		results, err := m.search(ctx, conn, query, contentTypes)
+		if errors.Is(err, ErrExtensionDisconnected) && attempt < maxSearchAttempts {
+			slog.Info("Resending search after extension disconnected", "query", query, "attempt", attempt+1)
+			continue
+		}
+		return results, err
+	}
+}
+
+// waitForConnection returns the extension's connection, waiting up to the reconnect
+// grace period when it's disconnected.
+func (m *ConnectionManager) waitForConnection(ctx context.Context) (*websocket.Conn, error) {
 	m.mu.RLock()
-	conn := m.conn
+	conn, connected, grace := m.conn, m.connected, m.reconnectGrace
 	m.mu.RUnlock()
 
-	if conn == nil {
+	if conn != nil {
+		return conn, nil
+	}
+	if grace <= 0 {
 		return nil, ErrExtensionNotConnected
 	}
 
+	m.queuedSearches.Add(1)
+	defer m.queuedSearches.Add(-1)
+
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-connected:
+		m.mu.RLock()
+		conn = m.conn
+		m.mu.RUnlock()
+		if conn == nil {
+			// Connected and dropped again already
+			return nil, ErrExtensionNotConnected
+		}
+		return conn, nil
+	case <-timer.C:
+		return nil, ErrExtensionNotConnected
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// search sends one search request on conn and waits for its result.
+func (m *ConnectionManager) search(ctx context.Context, conn *websocket.Conn, query string, contentTypes []SpotifyContentType) (*GroupedSearchResults, error) {
 	// Generate request ID
 	requestID := fmt.Sprintf("search_%d", atomic.AddUint64(&m.requestCounter, 1))
 
@@ -246,10 +391,6 @@ func (m *ConnectionManager) Search(ctx context.Context, query string, contentTyp
 		createdAt:    time.Now(),
 	}
 
-	m.mu.Lock()
-	m.pendingSearches[requestID] = pending
-	m.mu.Unlock()
-
 	// Send search request
 	request := SearchRequest{
 		Type:         "search",
@@ -259,17 +400,23 @@ func (m *ConnectionManager) Search(ctx context.Context, query string, contentTyp
 	}
 
 	m.mu.Lock()
-	err := m.conn.WriteJSON(request)
+	if m.conn != conn {
+		// Dropped or replaced since the caller got it
+		m.mu.Unlock()
+		return nil, ErrExtensionDisconnected
+	}
+	m.pendingSearches[requestID] = pending
+	err := conn.WriteJSON(request)
+	if err != nil {
+		delete(m.pendingSearches, requestID)
+	}
 	m.mu.Unlock()
 
 	if err != nil {
-		m.mu.Lock()
-		delete(m.pendingSearches, requestID)
-		m.mu.Unlock()
 		return nil, fmt.Errorf("failed to send search request: %w", err)
 	}
 
-	log.Printf("Sent search request for query '%s' (requestId: %s)", query, requestID)
+	slog.Info("Sent search request", "query", query, "search_request_id", requestID)
 
 	// Wait for result with timeout
 	select {
@@ -296,15 +443,10 @@ func (m *ConnectionManager) Close() {
 	if m.conn != nil {
 		m.conn.Close()
 		m.conn = nil
+		m.connected = make(chan struct{})
 	}
 	if m.stopPing != nil {
-		// Use a select to avoid panic if channel is already closed
-		select {
-		case <-m.stopPing:
-			// Already closed
-		default:
-			close(m.stopPing)
-		}
+		close(m.stopPing)
 		m.stopPing = nil
 	}
 }
@@ -0,0 +1,181 @@
+package spotifysearch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/audit"
+)
+
+type recordedEvents struct {
+	mu     sync.Mutex
+	events []audit.WriteEventInput
+}
+
+func (r *recordedEvents) RecordEvent(input audit.WriteEventInput) (*audit.AuditEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, input)
+	return &audit.AuditEvent{}, nil
+}
+
+func (r *recordedEvents) types() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var types []string
+	for _, event := range r.events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+// fakeExtension connects to the manager's WebSocket endpoint the way the browser
+// extension does.
+type fakeExtension struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+func connectExtension(t *testing.T, server *httptest.Server) *fakeExtension {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/spotify-search"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return &fakeExtension{t: t, conn: conn}
+}
+
+// nextSearch reads the next search request sent to the extension.
+func (e *fakeExtension) nextSearch() SearchRequest {
+	var request SearchRequest
+	require.NoError(e.t, e.conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(e.t, e.conn.ReadJSON(&request))
+	return request
+}
+
+func (e *fakeExtension) answer(request SearchRequest, trackName string) {
+	require.NoError(e.t, e.conn.WriteJSON(SearchResultMessage{
+		Type:      "searchResult",
+		RequestID: request.RequestID,
+		Results:   GroupedSearchResults{Tracks: []SpotifyTrack{{Name: trackName}}},
+	}))
+}
+
+func newTestManager(t *testing.T, grace time.Duration) (*ConnectionManager, *httptest.Server, *recordedEvents) {
+	manager := NewConnectionManager()
+	manager.SetReconnectGrace(grace)
+	events := &recordedEvents{}
+	manager.SetEventRecorder(events)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws/spotify-search", websocketHandler(manager))
+	server := httptest.NewServer(mux)
+	t.Cleanup(func() {
+		manager.Close()
+		server.Close()
+	})
+	return manager, server, events
+}
+
+type searchOutcome struct {
+	results *GroupedSearchResults
+	err     error
+}
+
+func startSearch(manager *ConnectionManager, query string) <-chan searchOutcome {
+	done := make(chan searchOutcome, 1)
+	go func() {
+		results, err := manager.Search(context.Background(), query, []SpotifyContentType{ContentTypeTracks})
+		done <- searchOutcome{results: results, err: err}
+	}()
+	return done
+}
+
+func TestConnectionManager_SearchWaitsForReconnect(t *testing.T) {
+	manager, server, _ := newTestManager(t, 2*time.Second)
+
+	done := startSearch(manager, "so what")
+	require.Eventually(t, func() bool { return manager.GetStatus().QueuedSearches == 1 }, time.Second, 5*time.Millisecond)
+
+	extension := connectExtension(t, server)
+	request := extension.nextSearch()
+	require.Equal(t, "so what", request.Query)
+	extension.answer(request, "So What")
+
+	outcome := <-done
+	require.NoError(t, outcome.err)
+	require.Equal(t, "So What", outcome.results.Tracks[0].Name)
+	require.Equal(t, 0, manager.GetStatus().QueuedSearches)
+}
+
+func TestConnectionManager_SearchFailsAfterGrace(t *testing.T) {
+	manager, _, _ := newTestManager(t, 50*time.Millisecond)
+
+	_, err := manager.Search(context.Background(), "so what", nil)
+	require.ErrorIs(t, err, ErrExtensionNotConnected)
+
+	manager.SetReconnectGrace(0)
+	start := time.Now()
+	_, err = manager.Search(context.Background(), "so what", nil)
+	require.ErrorIs(t, err, ErrExtensionNotConnected)
+	require.Less(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestConnectionManager_SearchResentAfterDisconnect(t *testing.T) {
+	manager, server, events := newTestManager(t, 2*time.Second)
+
+	first := connectExtension(t, server)
+	require.Eventually(t, manager.IsConnected, time.Second, 5*time.Millisecond)
+
+	done := startSearch(manager, "blue in green")
+	dropped := first.nextSearch()
+	first.conn.Close()
+	require.Eventually(t, func() bool { return !manager.IsConnected() }, time.Second, 5*time.Millisecond)
+
+	// The reconnected extension gets the search again, under a new request ID
+	second := connectExtension(t, server)
+	request := second.nextSearch()
+	require.Equal(t, "blue in green", request.Query)
+	require.NotEqual(t, dropped.RequestID, request.RequestID)
+	second.answer(request, "Blue in Green")
+
+	outcome := <-done
+	require.NoError(t, outcome.err)
+	require.Equal(t, "Blue in Green", outcome.results.Tracks[0].Name)
+
+	status := manager.GetStatus()
+	require.Equal(t, "connected", status.Extension)
+	require.Equal(t, 1, status.ReconnectCount)
+	require.NotNil(t, status.LastConnectedAt)
+	require.NotNil(t, status.LastDisconnectedAt)
+	require.Equal(t, []string{
+		string(audit.EventSpotifyConnected),
+		string(audit.EventSpotifyDisconnected),
+		string(audit.EventSpotifyConnected),
+	}, events.types())
+}
+
+func TestConnectionManager_ReplacedConnectionStaysConnected(t *testing.T) {
+	manager, server, events := newTestManager(t, 2*time.Second)
+
+	connectExtension(t, server)
+	require.Eventually(t, manager.IsConnected, time.Second, 5*time.Millisecond)
+	second := connectExtension(t, server)
+	require.Eventually(t, func() bool { return manager.GetStatus().ReconnectCount == 1 }, time.Second, 5*time.Millisecond)
+
+	// The first connection's reader exits when it's closed; that mustn't drop the second
+	done := startSearch(manager, "freddie freeloader")
+	request := second.nextSearch()
+	second.answer(request, "Freddie Freeloader")
+	outcome := <-done
+	require.NoError(t, outcome.err)
+	require.True(t, manager.IsConnected())
+	require.NotContains(t, events.types(), string(audit.EventSpotifyDisconnected))
+}
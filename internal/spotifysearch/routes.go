@@ -2,6 +2,7 @@ package spotifysearch
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/websocket"
@@ -36,13 +37,25 @@ func websocketHandler(manager *ConnectionManager) http.HandlerFunc {
 	}
 }
 
+// formatTime formats an optional timestamp, or nil when it's unset.
+func formatTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return api.RFC3339Millis(*t)
+}
+
 func statusHandler(manager *ConnectionManager) api.Handler {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		status := manager.GetStatus()
 		return api.WriteResource(w, http.StatusOK, map[string]any{
-			"object":           "spotify_search_status",
-			"extension":        status.Extension,
-			"pending_searches": status.PendingSearches,
+			"object":               "spotify_search_status",
+			"extension":            status.Extension,
+			"pending_searches":     status.PendingSearches,
+			"queued_searches":      status.QueuedSearches,
+			"last_connected_at":    formatTime(status.LastConnectedAt),
+			"last_disconnected_at": formatTime(status.LastDisconnectedAt),
+			"reconnect_count":      status.ReconnectCount,
 		})
 	}
 }
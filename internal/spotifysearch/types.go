@@ -1,5 +1,7 @@
 package spotifysearch
 
+import "time"
+
 // SpotifyContentType represents searchable content types
 type SpotifyContentType string
 
@@ -134,6 +136,10 @@ type SpotifyPodcast struct {
 
 // ConnectionStatus represents the extension connection state
 type ConnectionStatus struct {
-	Extension       string `json:"extension"` // "connected" or "disconnected"
-	PendingSearches int    `json:"pendingSearches"`
+	Extension          string     `json:"extension"` // "connected" or "disconnected"
+	PendingSearches    int        `json:"pendingSearches"`
+	QueuedSearches     int        `json:"queuedSearches"` // Waiting for the extension to reconnect
+	LastConnectedAt    *time.Time `json:"lastConnectedAt,omitempty"`
+	LastDisconnectedAt *time.Time `json:"lastDisconnectedAt,omitempty"`
+	ReconnectCount     int        `json:"reconnectCount"` // Connections after the first since startup
 }
@@ -0,0 +1,251 @@
+package system
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/strefethen/sonos-hub-go/internal/devices"
+)
+
+// DefaultDashboardSectionTimeout bounds each dashboard section, so one slow speaker
+// doesn't hold up the whole response.
+const DefaultDashboardSectionTimeout = 3 * time.Second
+
+// Dashboard section sizes.
+const (
+	dashboardUpcomingLimit   = 20 // Today's routines
+	dashboardOccurrenceLimit = 3
+	dashboardExecutionLimit  = 5
+)
+
+// Dashboard section names, as reported in DashboardError.
+const (
+	SectionUpcomingRoutines = "upcoming_routines"
+	SectionNextOccurrences  = "next_occurrences"
+	SectionNowPlaying       = "now_playing"
+	SectionRecentExecutions = "recent_executions"
+	SectionDevices          = "devices"
+	SectionSnoozedRoutines  = "snoozed_routines"
+	SectionAttentionItems   = "attention_items"
+)
+
+// DeviceCounts summarizes device health from the last discovery.
+type DeviceCounts struct {
+	Total         int        `json:"total"`
+	Online        int        `json:"online"` // Includes degraded devices
+	Degraded      int        `json:"degraded"`
+	Offline       int        `json:"offline"`
+	LastDiscovery *time.Time `json:"last_discovery,omitempty"`
+}
+
+// SnoozedRoutine is a routine whose runs are suppressed until SnoozeUntil.
+type SnoozedRoutine struct {
+	RoutineID   string    `json:"routine_id"`
+	Name        string    `json:"name"`
+	SnoozeUntil time.Time `json:"snooze_until"`
+}
+
+// DashboardError records a dashboard section that failed or timed out.
+type DashboardError struct {
+	Section string `json:"section"`
+	Message string `json:"message"`
+}
+
+// GetDashboardData returns data for the dashboard view. The sections are fetched
+// concurrently, each within the section timeout. A section that fails is left nil and
+// reported in Errors rather than failing the whole dashboard.
+func (s *Service) GetDashboardData(ctx context.Context) (*DashboardData, error) {
+	dashboard := &DashboardData{}
+
+	// Calculate end of today (like Node.js: endOfToday.setHours(23, 59, 59, 999))
+	now := time.Now()
+	endOfToday := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 999999999, now.Location())
+
+	var (
+		wg            sync.WaitGroup
+		mu            sync.Mutex
+		sectionErrors []DashboardError
+	)
+	failed := func(section string, err error) bool {
+		if err == nil {
+			return false
+		}
+		s.logger.Warn("Dashboard section failed", "section", section, "error", err)
+		mu.Lock()
+		sectionErrors = append(sectionErrors, DashboardError{Section: section, Message: err.Error()})
+		mu.Unlock()
+		return true
+	}
+
+	// Each section writes only its own fields, and nothing reads them until wg.Wait
+	wg.Go(func() {
+		routines, err := fetchSection(ctx, s.sectionTimeout, func(ctx context.Context) ([]RoutineSummary, error) {
+			return s.upcomingRoutines(ctx, now, endOfToday, dashboardUpcomingLimit)
+		})
+		if failed(SectionUpcomingRoutines, err) {
+			return
+		}
+		dashboard.UpcomingRoutines = routines
+		// The first is next up (backwards compat with iOS)
+		if len(routines) > 0 {
+			dashboard.NextRoutine = &routines[0]
+		}
+	})
+	wg.Go(func() {
+		occurrences, err := fetchSection(ctx, s.sectionTimeout, func(ctx context.Context) ([]RoutineSummary, error) {
+			return s.upcomingRoutines(ctx, now, time.Time{}, dashboardOccurrenceLimit)
+		})
+		if !failed(SectionNextOccurrences, err) {
+			dashboard.NextOccurrences = occurrences
+		}
+	})
+	wg.Go(func() {
+		groups, err := fetchSection(ctx, s.sectionTimeout, func(context.Context) ([]map[string]any, error) {
+			if s.nowPlaying == nil {
+				return []map[string]any{}, nil
+			}
+			return s.nowPlaying.NowPlayingGroups()
+		})
+		if !failed(SectionNowPlaying, err) {
+			dashboard.NowPlaying = groups
+		}
+	})
+	wg.Go(func() {
+		executions, err := fetchSection(ctx, s.sectionTimeout, func(context.Context) ([]map[string]any, error) {
+			if s.executions == nil {
+				return []map[string]any{}, nil
+			}
+			return s.executions.RecentExecutions(dashboardExecutionLimit)
+		})
+		if !failed(SectionRecentExecutions, err) {
+			dashboard.RecentExecutions = executions
+		}
+	})
+	wg.Go(func() {
+		counts, err := fetchSection(ctx, s.sectionTimeout, func(context.Context) (*DeviceCounts, error) {
+			return s.deviceCounts(), nil
+		})
+		if !failed(SectionDevices, err) {
+			dashboard.Devices = counts
+		}
+	})
+	wg.Go(func() {
+		snoozed, err := fetchSection(ctx, s.sectionTimeout, func(ctx context.Context) ([]SnoozedRoutine, error) {
+			return s.snoozedRoutines(ctx, now)
+		})
+		if !failed(SectionSnoozedRoutines, err) {
+			dashboard.SnoozedRoutines = snoozed
+		}
+	})
+	wg.Go(func() {
+		items, err := fetchSection(ctx, s.sectionTimeout, func(context.Context) ([]AttentionItem, error) {
+			return s.checkAttentionItems(), nil
+		})
+		if !failed(SectionAttentionItems, err) {
+			dashboard.AttentionItems = items
+		}
+	})
+	wg.Wait()
+
+	sort.Slice(sectionErrors, func(i, j int) bool { return sectionErrors[i].Section < sectionErrors[j].Section })
+	dashboard.Errors = sectionErrors
+	if dashboard.Errors == nil {
+		dashboard.Errors = []DashboardError{}
+	}
+	return dashboard, nil
+}
+
+// fetchSection runs fetch, giving up once timeout passes or ctx ends. A fetch that
+// can't be interrupted keeps running in the background, and its result is dropped.
+func fetchSection[T any](ctx context.Context, timeout time.Duration, fetch func(context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := fetch(ctx)
+		done <- result{value, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero T
+		if ctx.Err() == context.DeadlineExceeded {
+			return zero, fmt.Errorf("timed out after %s", timeout)
+		}
+		return zero, ctx.Err()
+	}
+}
+
+// deviceCounts counts devices by health from the cached topology, without triggering
+// discovery. Degraded devices count as online, as in system info.
+func (s *Service) deviceCounts() *DeviceCounts {
+	counts := &DeviceCounts{}
+	if s.deviceService == nil {
+		return counts
+	}
+	topology := s.deviceService.GetTopologyIfCached()
+	if topology == nil {
+		return counts
+	}
+
+	counts.Total = len(topology.Devices)
+	for _, device := range topology.Devices {
+		switch device.Health {
+		case devices.DeviceHealthOffline:
+			counts.Offline++
+		case devices.DeviceHealthDegraded:
+			counts.Degraded++
+			counts.Online++
+		default:
+			counts.Online++
+		}
+	}
+	if !topology.UpdatedAt.IsZero() {
+		lastDiscovery := topology.UpdatedAt
+		counts.LastDiscovery = &lastDiscovery
+	}
+	return counts
+}
+
+// snoozedRoutines returns the routines snoozed past now, soonest to wake first.
+func (s *Service) snoozedRoutines(ctx context.Context, now time.Time) ([]SnoozedRoutine, error) {
+	rows, err := s.reader.QueryContext(ctx, `
+		SELECT routine_id, name, snooze_until FROM routines
+		WHERE snooze_until IS NOT NULL AND snooze_until != ''
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query snoozed routines: %w", err)
+	}
+	defer rows.Close()
+
+	snoozed := []SnoozedRoutine{}
+	for rows.Next() {
+		var routineID, name string
+		var snoozeUntil sql.NullString
+		if err := rows.Scan(&routineID, &name, &snoozeUntil); err != nil {
+			return nil, fmt.Errorf("read snoozed routine: %w", err)
+		}
+		until := parseSnoozeUntil(snoozeUntil)
+		if until == nil || !until.After(now) { // Expired
+			continue
+		}
+		snoozed = append(snoozed, SnoozedRoutine{RoutineID: routineID, Name: name, SnoozeUntil: *until})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read snoozed routines: %w", err)
+	}
+
+	sort.Slice(snoozed, func(i, j int) bool { return snoozed[i].SnoozeUntil.Before(snoozed[j].SnoozeUntil) })
+	return snoozed, nil
+}
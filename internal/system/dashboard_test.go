@@ -0,0 +1,124 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/config"
+	"github.com/strefethen/sonos-hub-go/internal/db"
+)
+
+// slowNowPlaying stands in for speakers that don't answer in time.
+type slowNowPlaying struct{ delay time.Duration }
+
+func (p slowNowPlaying) NowPlayingGroups() ([]map[string]any, error) {
+	time.Sleep(p.delay)
+	return []map[string]any{{"group_id": "late"}}, nil
+}
+
+type fakeExecutions struct{ err error }
+
+func (p fakeExecutions) RecentExecutions(limit int) ([]map[string]any, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return []map[string]any{{"object": "execution", "id": "job-done"}}, nil
+}
+
+func setupDashboardDB(t *testing.T) *db.DBPair {
+	t.Helper()
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+
+	now := time.Now().UTC()
+	_, err = dbPair.Writer().Exec("INSERT INTO scenes (scene_id, name) VALUES ('scene-1', 'Scene')")
+	require.NoError(t, err)
+	for _, routine := range []struct {
+		id, name    string
+		snoozeUntil any
+	}{
+		{"routine-1", "Wake Up", nil},
+		{"routine-2", "Dinner", now.Add(100 * time.Hour).Format(time.RFC3339)},
+		{"routine-3", "Old Snooze", now.Add(-time.Hour).Format(time.RFC3339)},
+	} {
+		_, err := dbPair.Writer().Exec(`
+			INSERT INTO routines (routine_id, name, timezone, schedule_time, scene_id, snooze_until, created_at, updated_at)
+			VALUES (?, ?, 'UTC', '07:00', 'scene-1', ?, '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z')
+		`, routine.id, routine.name, routine.snoozeUntil)
+		require.NoError(t, err)
+	}
+	for i, job := range []struct {
+		routineID string
+		at        time.Time
+	}{
+		{"routine-1", now.Add(24 * time.Hour)},
+		{"routine-1", now.Add(48 * time.Hour)},
+		{"routine-2", now.Add(72 * time.Hour)},
+		{"routine-3", now.Add(96 * time.Hour)},
+	} {
+		_, err := dbPair.Writer().Exec(`
+			INSERT INTO jobs (job_id, routine_id, scheduled_for, status, created_at, updated_at)
+			VALUES (?, ?, ?, 'PENDING', '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z')
+		`, "job-"+string(rune('a'+i)), job.routineID, job.at.Format(time.RFC3339))
+		require.NoError(t, err)
+	}
+	return dbPair
+}
+
+func TestGetDashboardData(t *testing.T) {
+	service := NewService(config.Config{}, setupDashboardDB(t), nil, nil, nil, nil)
+	service.SetExecutionsProvider(fakeExecutions{})
+
+	dashboard, err := service.GetDashboardData(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, dashboard.Errors)
+
+	// One occurrence per routine, earliest first
+	require.Len(t, dashboard.NextOccurrences, 3)
+	require.Equal(t, "routine-1", dashboard.NextOccurrences[0].RoutineID)
+	require.Equal(t, "routine-2", dashboard.NextOccurrences[1].RoutineID)
+	require.True(t, dashboard.NextOccurrences[1].Suppressed)
+
+	require.Len(t, dashboard.SnoozedRoutines, 1)
+	require.Equal(t, "Dinner", dashboard.SnoozedRoutines[0].Name)
+	require.Equal(t, "job-done", dashboard.RecentExecutions[0]["id"])
+	require.Equal(t, &DeviceCounts{}, dashboard.Devices)
+	require.Empty(t, dashboard.NowPlaying)
+	require.NotNil(t, dashboard.UpcomingRoutines)
+}
+
+func TestGetDashboardData_FailedSections(t *testing.T) {
+	service := NewService(config.Config{}, setupDashboardDB(t), nil, nil, nil, nil)
+	service.sectionTimeout = 50 * time.Millisecond
+	service.SetNowPlayingProvider(slowNowPlaying{delay: time.Second})
+	service.SetExecutionsProvider(fakeExecutions{err: errors.New("database is locked")})
+
+	router := chi.NewRouter()
+	RegisterRoutes(router, service)
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/dashboard", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Less(t, time.Since(start), 500*time.Millisecond, "a slow section doesn't hold up the response")
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Nil(t, body["now_playing"])
+	require.Nil(t, body["recent_executions"])
+	require.Len(t, body["next_occurrences"], 3)
+	require.Equal(t, []any{
+		map[string]any{"section": "now_playing", "message": "timed out after 50ms"},
+		map[string]any{"section": "recent_executions", "message": "database is locked"},
+	}, body["_errors"])
+}
@@ -0,0 +1,68 @@
+package system
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIVersion is the REST API version clients talk to, advertised over mDNS.
+const APIVersion = "v1"
+
+// DefaultHubName names the hub when HUB_NAME is unset and the host name is unknown.
+const DefaultHubName = "Sonos Hub"
+
+// hubIDSettingsKey is the settings table key holding the hub ID.
+const hubIDSettingsKey = "hub_id"
+
+// Identity identifies this hub to clients that found it on the network.
+type Identity struct {
+	HubID      string // Generated on first start and kept in the database
+	Name       string
+	Version    string
+	APIVersion string
+}
+
+// LoadIdentity returns the hub's identity, generating and saving its hub ID on first
+// start. name defaults to the host name.
+func LoadIdentity(dbPair DBPair, name string) (Identity, error) {
+	hubID, err := loadHubID(dbPair)
+	if err != nil {
+		return Identity{}, err
+	}
+	if name == "" {
+		name, _ = os.Hostname()
+	}
+	if name == "" {
+		name = DefaultHubName
+	}
+	return Identity{HubID: hubID, Name: name, Version: Version, APIVersion: APIVersion}, nil
+}
+
+// loadHubID reads the saved hub ID, saving a new one if there is none. INSERT OR IGNORE
+// keeps the first ID saved if two processes start at once.
+func loadHubID(dbPair DBPair) (string, error) {
+	var hubID string
+	err := dbPair.Reader().QueryRow("SELECT value FROM settings WHERE key = ?", hubIDSettingsKey).Scan(&hubID)
+	if err == nil && hubID != "" {
+		return hubID, nil
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("read hub ID: %w", err)
+	}
+
+	if _, err := dbPair.Writer().Exec(
+		"INSERT OR IGNORE INTO settings (key, value, updated_at) VALUES (?, ?, ?)",
+		hubIDSettingsKey, uuid.NewString(), time.Now().UTC().Format(time.RFC3339),
+	); err != nil {
+		return "", fmt.Errorf("save hub ID: %w", err)
+	}
+	if err := dbPair.Writer().QueryRow("SELECT value FROM settings WHERE key = ?", hubIDSettingsKey).Scan(&hubID); err != nil {
+		return "", fmt.Errorf("read hub ID: %w", err)
+	}
+	return hubID, nil
+}
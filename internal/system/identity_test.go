@@ -0,0 +1,53 @@
+package system
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/auth"
+	"github.com/strefethen/sonos-hub-go/internal/config"
+	"github.com/strefethen/sonos-hub-go/internal/db"
+)
+
+func TestLoadIdentity(t *testing.T) {
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+
+	identity, err := LoadIdentity(dbPair, "Living Room Hub")
+	require.NoError(t, err)
+	require.NotEmpty(t, identity.HubID)
+	require.Equal(t, "Living Room Hub", identity.Name)
+	require.Equal(t, Version, identity.Version)
+	require.Equal(t, APIVersion, identity.APIVersion)
+
+	// The hub ID survives restarts; the name defaults to the host name
+	again, err := LoadIdentity(dbPair, "")
+	require.NoError(t, err)
+	require.Equal(t, identity.HubID, again.HubID)
+	require.NotEmpty(t, again.Name)
+
+	service := NewService(config.Config{}, dbPair, nil, nil, nil, nil)
+	service.SetIdentity(identity)
+	router := chi.NewRouter()
+	router.Use(auth.Middleware(config.Config{}, nil))
+	RegisterRoutes(router, service)
+
+	// Clients can identify the hub before they have a key
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/system/identity", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Equal(t, "hub_identity", body["object"])
+	require.Equal(t, identity.HubID, body["hub_id"])
+	require.Equal(t, "Living Room Hub", body["name"])
+	require.Equal(t, "v1", body["api_version"])
+}
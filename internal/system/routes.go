@@ -8,11 +8,14 @@ import (
 
 	"github.com/strefethen/sonos-hub-go/internal/api"
 	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+	"github.com/strefethen/sonos-hub-go/internal/ratelimit"
+	"github.com/strefethen/sonos-hub-go/internal/retention"
 )
 
 // RegisterRoutes wires system routes to the router.
 func RegisterRoutes(router chi.Router, service *Service) {
 	router.Method(http.MethodGet, "/v1/system/info", api.Handler(getSystemInfo(service)))
+	router.Method(http.MethodGet, "/v1/system/identity", api.Handler(getIdentity(service)))
 	router.Method(http.MethodGet, "/v1/dashboard", api.Handler(getDashboard(service)))
 }
 
@@ -28,10 +31,25 @@ func getSystemInfo(service *Service) func(w http.ResponseWriter, r *http.Request
 	}
 }
 
+// getIdentity handles GET /v1/system/identity. It returns what the hub advertises over
+// mDNS, so a client can confirm it found the instance it expected.
+func getIdentity(service *Service) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		identity := service.Identity()
+		return api.WriteResource(w, http.StatusOK, map[string]any{
+			"object":      "hub_identity",
+			"hub_id":      identity.HubID,
+			"name":        identity.Name,
+			"hub_version": identity.Version,
+			"api_version": identity.APIVersion,
+		})
+	}
+}
+
 // getDashboard handles GET /v1/dashboard
 func getDashboard(service *Service) func(w http.ResponseWriter, r *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
-		data, err := service.GetDashboardData()
+		data, err := service.GetDashboardData(r.Context())
 		if err != nil {
 			return apperrors.NewInternalError("Failed to get dashboard data")
 		}
@@ -62,15 +80,78 @@ func formatSystemInfo(info *SystemInfo) map[string]any {
 		result["last_discovery"] = nil
 	}
 
+	if info.Retention != nil {
+		result["retention"] = formatRetentionStatus(info.Retention)
+	}
+	if info.RateLimit != nil {
+		result["rate_limit"] = formatRateLimitStats(info.RateLimit)
+	}
+
 	return result
 }
 
-// formatDashboardData formats DashboardData for JSON response.
+// formatRetentionStatus formats retention.Status for JSON response.
+func formatRetentionStatus(status *retention.Status) map[string]any {
+	result := map[string]any{
+		"job_retention_days":     status.JobRetentionDays,
+		"history_retention_days": status.HistoryRetentionDays,
+		"last_pruned_at":         nil,
+		"jobs_deleted":           status.JobsDeleted,
+		"play_history_deleted":   status.HistoryDeleted,
+		"last_error":             nil,
+
+		"deleted_routine_retention_days": status.DeletedRoutineRetentionDays,
+		"deleted_routines_purged":        status.DeletedRoutinesPurged,
+	}
+	if status.LastPrunedAt != nil {
+		result["last_pruned_at"] = api.RFC3339Millis(*status.LastPrunedAt)
+	}
+	if status.LastError != "" {
+		result["last_error"] = status.LastError
+	}
+	return result
+}
+
+// formatRateLimitStats formats ratelimit.Stats for JSON response.
+func formatRateLimitStats(stats *ratelimit.Stats) map[string]any {
+	return map[string]any{
+		"enabled":             stats.Enabled,
+		"requests_per_second": stats.RequestsPerSecond,
+		"burst":               stats.Burst,
+		"tracked_clients":     stats.TrackedClients,
+		"allowed_total":       stats.Allowed,
+		"limited_total":       stats.Limited,
+	}
+}
+
+// formatDashboardData formats DashboardData for JSON response. Sections that failed are
+// null, with the reason in _errors.
 func formatDashboardData(data *DashboardData) map[string]any {
 	result := map[string]any{
 		"object":            "dashboard",
-		"upcoming_routines": formatRoutineSummaries(data.UpcomingRoutines),
-		"attention_items":   formatAttentionItems(data.AttentionItems),
+		"upcoming_routines": nil,
+		"attention_items":   nil,
+		"next_occurrences":  nil,
+		"now_playing":       data.NowPlaying,
+		"recent_executions": data.RecentExecutions,
+		"devices":           nil,
+		"snoozed_routines":  nil,
+		"_errors":           formatDashboardErrors(data.Errors),
+	}
+	if data.UpcomingRoutines != nil {
+		result["upcoming_routines"] = formatRoutineSummaries(data.UpcomingRoutines)
+	}
+	if data.AttentionItems != nil {
+		result["attention_items"] = formatAttentionItems(data.AttentionItems)
+	}
+	if data.NextOccurrences != nil {
+		result["next_occurrences"] = formatRoutineSummaries(data.NextOccurrences)
+	}
+	if data.Devices != nil {
+		result["devices"] = formatDeviceCounts(data.Devices)
+	}
+	if data.SnoozedRoutines != nil {
+		result["snoozed_routines"] = formatSnoozedRoutines(data.SnoozedRoutines)
 	}
 
 	// Always include "next_up" for API parity with Node.js (null if not set)
@@ -83,6 +164,43 @@ func formatDashboardData(data *DashboardData) map[string]any {
 	return result
 }
 
+// formatDeviceCounts formats DeviceCounts for JSON response.
+func formatDeviceCounts(counts *DeviceCounts) map[string]any {
+	result := map[string]any{
+		"total":          counts.Total,
+		"online":         counts.Online,
+		"degraded":       counts.Degraded,
+		"offline":        counts.Offline,
+		"last_discovery": nil,
+	}
+	if counts.LastDiscovery != nil {
+		result["last_discovery"] = counts.LastDiscovery.UTC().Format(time.RFC3339)
+	}
+	return result
+}
+
+// formatSnoozedRoutines formats a slice of SnoozedRoutine for JSON response.
+func formatSnoozedRoutines(routines []SnoozedRoutine) []map[string]any {
+	result := make([]map[string]any, 0, len(routines))
+	for _, routine := range routines {
+		result = append(result, map[string]any{
+			"routine_id":   routine.RoutineID,
+			"routine_name": routine.Name,
+			"snooze_until": routine.SnoozeUntil.UTC().Format(time.RFC3339),
+		})
+	}
+	return result
+}
+
+// formatDashboardErrors formats the failed dashboard sections for JSON response.
+func formatDashboardErrors(errors []DashboardError) []map[string]any {
+	result := make([]map[string]any, 0, len(errors))
+	for _, e := range errors {
+		result = append(result, map[string]any{"section": e.Section, "message": e.Message})
+	}
+	return result
+}
+
 // formatRoutineSummaries formats a slice of RoutineSummary for JSON response.
 func formatRoutineSummaries(routines []RoutineSummary) []map[string]any {
 	result := make([]map[string]any, 0, len(routines))
@@ -118,6 +236,16 @@ func formatRoutineSummary(r *RoutineSummary) map[string]any {
 		result["template_id"] = *r.TemplateID
 	}
 
+	// Snooze/skip state so clients can explain why a run won't fire
+	result["skip_next"] = r.SkipNext
+	result["suppressed"] = r.Suppressed
+	if r.SnoozeUntil != nil {
+		result["snooze_until"] = r.SnoozeUntil.UTC().Format(time.RFC3339)
+	}
+	if r.SuppressedReason != nil {
+		result["suppressed_reason"] = *r.SuppressedReason
+	}
+
 	return result
 }
 
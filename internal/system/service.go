@@ -1,26 +1,50 @@
 package system
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"runtime"
 	"time"
 
 	"github.com/strefethen/sonos-hub-go/internal/config"
 	"github.com/strefethen/sonos-hub-go/internal/devices"
 	"github.com/strefethen/sonos-hub-go/internal/music"
+	"github.com/strefethen/sonos-hub-go/internal/ratelimit"
+	"github.com/strefethen/sonos-hub-go/internal/retention"
 )
 
 // Version is the hub version, set at build time or defaulted.
 var Version = "1.0.0"
 
+// NowPlayingProvider provides every group's now-playing entry (implemented by sonos.Service).
+type NowPlayingProvider interface {
+	NowPlayingGroups() ([]map[string]any, error)
+}
+
+// ExecutionsProvider provides the most recent finished routine runs, formatted as
+// executions (implemented by scheduler.Service).
+type ExecutionsProvider interface {
+	RecentExecutions(limit int) ([]map[string]any, error)
+}
+
 // SchedulerStatusProvider provides scheduler running status.
 type SchedulerStatusProvider interface {
 	IsRunning() bool
 }
 
+// RetentionStatusProvider provides the outcome of the last retention prune.
+type RetentionStatusProvider interface {
+	Status() retention.Status
+}
+
+// RateLimitStatsProvider provides the /v1/sonos rate limiter's counters.
+type RateLimitStatsProvider interface {
+	Stats() ratelimit.Stats
+}
+
 // DBPair interface for dependency injection (matches db.DBPair).
 type DBPair interface {
 	Reader() *sql.DB
@@ -31,19 +55,25 @@ type DBPair interface {
 // Uses reader connection only as this service only performs SELECT queries.
 type Service struct {
 	cfg              config.Config
-	logger           *log.Logger
+	logger           *slog.Logger
 	reader           *sql.DB // Read-only queries
 	deviceService    *devices.Service
 	musicService     *music.Service
 	schedulerStatus  SchedulerStatusProvider
+	retentionStatus  RetentionStatusProvider
+	rateLimitStats   RateLimitStatsProvider
+	nowPlaying       NowPlayingProvider
+	executions       ExecutionsProvider
+	sectionTimeout   time.Duration // Per-section limit for dashboard fetches
+	identity         Identity
 	startTime        time.Time
 }
 
 // NewService creates a new system service.
 // Accepts a DBPair but only uses the reader (read-only service).
-func NewService(cfg config.Config, dbPair DBPair, logger *log.Logger, deviceService *devices.Service, musicService *music.Service, schedulerStatus SchedulerStatusProvider) *Service {
+func NewService(cfg config.Config, dbPair DBPair, logger *slog.Logger, deviceService *devices.Service, musicService *music.Service, schedulerStatus SchedulerStatusProvider) *Service {
 	if logger == nil {
-		logger = log.Default()
+		logger = slog.Default()
 	}
 
 	return &Service{
@@ -53,10 +83,41 @@ func NewService(cfg config.Config, dbPair DBPair, logger *log.Logger, deviceServ
 		deviceService:   deviceService,
 		musicService:    musicService,
 		schedulerStatus: schedulerStatus,
+		sectionTimeout:  DefaultDashboardSectionTimeout,
 		startTime:       time.Now(),
 	}
 }
 
+// SetRetentionStatusProvider sets the provider reported under retention in system info.
+func (s *Service) SetRetentionStatusProvider(provider RetentionStatusProvider) {
+	s.retentionStatus = provider
+}
+
+// SetRateLimitStatsProvider sets the provider reported under rate_limit in system info.
+func (s *Service) SetRateLimitStatsProvider(provider RateLimitStatsProvider) {
+	s.rateLimitStats = provider
+}
+
+// SetNowPlayingProvider sets the provider for the dashboard's now_playing section.
+func (s *Service) SetNowPlayingProvider(provider NowPlayingProvider) {
+	s.nowPlaying = provider
+}
+
+// SetExecutionsProvider sets the provider for the dashboard's recent_executions section.
+func (s *Service) SetExecutionsProvider(provider ExecutionsProvider) {
+	s.executions = provider
+}
+
+// SetIdentity sets the identity returned by GET /v1/system/identity.
+func (s *Service) SetIdentity(identity Identity) {
+	s.identity = identity
+}
+
+// Identity returns the hub's identity.
+func (s *Service) Identity() Identity {
+	return s.identity
+}
+
 // SystemInfo holds system information.
 // Matches Node.js system.ts SystemInfoResponse interface.
 type SystemInfo struct {
@@ -68,6 +129,8 @@ type SystemInfo struct {
 	DevicesTotal     int         `json:"devices_total"`
 	SchedulerRunning bool        `json:"scheduler_running"`
 	LastDiscovery    *time.Time  `json:"last_discovery,omitempty"`
+	Retention        *retention.Status `json:"retention,omitempty"`
+	RateLimit        *ratelimit.Stats  `json:"rate_limit,omitempty"`
 }
 
 // RoutineSummary is a summary of a routine for dashboard display.
@@ -81,8 +144,20 @@ type RoutineSummary struct {
 	MusicPreview *string    `json:"music_preview,omitempty"`
 	ArtworkURL   *string    `json:"artwork_url,omitempty"`
 	TemplateID   *string    `json:"template_id,omitempty"`
+
+	// Snooze/skip state, derived from the routine so the dashboard can explain suppressed runs
+	SkipNext         bool       `json:"skip_next"`
+	SnoozeUntil      *time.Time `json:"snooze_until,omitempty"`
+	Suppressed       bool       `json:"suppressed"`
+	SuppressedReason *string    `json:"suppressed_reason,omitempty"` // "skip_next" or "snoozed"
 }
 
+// Suppression reasons reported on RoutineSummary.
+const (
+	SuppressedReasonSkipNext = "skip_next"
+	SuppressedReasonSnoozed  = "snoozed"
+)
+
 // AttentionItem represents an item that needs user attention.
 type AttentionItem struct {
 	Type        string         `json:"type"`
@@ -92,11 +167,19 @@ type AttentionItem struct {
 	ResolveHint string         `json:"resolve_hint,omitempty"`
 }
 
-// DashboardData holds data for the dashboard view.
+// DashboardData holds data for the dashboard view. Each section is fetched separately; a
+// section that failed or timed out is nil and has an entry in Errors.
 type DashboardData struct {
 	NextRoutine      *RoutineSummary  `json:"next_routine,omitempty"`
 	UpcomingRoutines []RoutineSummary `json:"upcoming_routines"`
 	AttentionItems   []AttentionItem  `json:"attention_items"`
+
+	NextOccurrences  []RoutineSummary `json:"next_occurrences"`
+	NowPlaying       []map[string]any `json:"now_playing"`
+	RecentExecutions []map[string]any `json:"recent_executions"`
+	Devices          *DeviceCounts    `json:"devices"`
+	SnoozedRoutines  []SnoozedRoutine `json:"snoozed_routines"`
+	Errors           []DashboardError `json:"_errors"`
 }
 
 // GetSystemInfo returns current system information.
@@ -143,6 +226,18 @@ func (s *Service) GetSystemInfo() (*SystemInfo, error) {
 		schedulerRunning = s.schedulerStatus.IsRunning()
 	}
 
+	var retentionStatus *retention.Status
+	if s.retentionStatus != nil {
+		status := s.retentionStatus.Status()
+		retentionStatus = &status
+	}
+
+	var rateLimitStats *ratelimit.Stats
+	if s.rateLimitStats != nil {
+		stats := s.rateLimitStats.Stats()
+		rateLimitStats = &stats
+	}
+
 	return &SystemInfo{
 		HubVersion:       Version,
 		Uptime:           int64(time.Since(s.startTime).Seconds()),
@@ -152,32 +247,30 @@ func (s *Service) GetSystemInfo() (*SystemInfo, error) {
 		DevicesTotal:     devicesTotal,
 		SchedulerRunning: schedulerRunning,
 		LastDiscovery:    lastDiscovery,
+		Retention:        retentionStatus,
+		RateLimit:        rateLimitStats,
 	}, nil
 }
 
-// GetDashboardData returns data for the dashboard view.
-// Mirrors the Node.js implementation: queries PENDING jobs for today.
-func (s *Service) GetDashboardData() (*DashboardData, error) {
-	dashboard := &DashboardData{
-		UpcomingRoutines: []RoutineSummary{},
-		AttentionItems:   []AttentionItem{},
-	}
-
-	// Calculate end of today (like Node.js: endOfToday.setHours(23, 59, 59, 999))
-	now := time.Now()
-	endOfToday := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 999999999, now.Location())
+// upcomingRoutines returns the routines with PENDING jobs between from and until, earliest
+// first, each at its earliest job in the window. A zero until means no upper bound.
+// Mirrors Node.js: schedulerService.queryJobs({ status: 'PENDING', from: now, to: endOfToday })
+func (s *Service) upcomingRoutines(ctx context.Context, from, until time.Time, limit int) ([]RoutineSummary, error) {
+	routines := []RoutineSummary{}
 
-	// Query PENDING jobs for today, joined with routines for details
-	// This mirrors Node.js: schedulerService.queryJobs({ status: 'PENDING', from: now, to: endOfToday })
 	// Use subquery to deduplicate by routine_id, returning only the earliest job per routine
-	nowStr := now.UTC().Format(time.RFC3339)
-	endOfTodayStr := endOfToday.UTC().Format(time.RFC3339)
-	rows, err := s.reader.Query(`
+	fromStr := from.UTC().Format(time.RFC3339)
+	untilStr := "9999-12-31T23:59:59Z"
+	if !until.IsZero() {
+		untilStr = until.UTC().Format(time.RFC3339)
+	}
+	rows, err := s.reader.QueryContext(ctx, `
 		SELECT j.job_id, j.routine_id, j.scheduled_for,
 		       r.name, r.scene_id, r.speakers_json,
 		       r.music_policy_type, r.music_set_id, r.music_sonos_favorite_id,
 		       r.music_content_json, r.template_id,
-		       r.music_sonos_favorite_name, r.music_sonos_favorite_artwork_url
+		       r.music_sonos_favorite_name, r.music_sonos_favorite_artwork_url,
+		       r.skip_next, r.snooze_until
 		FROM jobs j
 		INNER JOIN routines r ON j.routine_id = r.routine_id
 		WHERE j.status = 'PENDING'
@@ -193,11 +286,10 @@ func (s *Service) GetDashboardData() (*DashboardData, error) {
 		      LIMIT 1
 		  )
 		ORDER BY j.scheduled_for ASC
-		LIMIT 20
-	`, nowStr, endOfTodayStr, nowStr, endOfTodayStr)
+		LIMIT ?
+	`, fromStr, untilStr, fromStr, untilStr, limit)
 	if err != nil {
-		s.logger.Printf("Failed to query jobs for dashboard: %v", err)
-		return dashboard, nil
+		return nil, fmt.Errorf("query upcoming jobs: %w", err)
 	}
 	defer rows.Close()
 
@@ -217,6 +309,8 @@ func (s *Service) GetDashboardData() (*DashboardData, error) {
 			templateID            sql.NullString
 			musicFavoriteName     sql.NullString
 			musicFavoriteArtwork  sql.NullString
+			skipNext              int
+			snoozeUntil           sql.NullString
 		)
 
 		if err := rows.Scan(
@@ -225,8 +319,9 @@ func (s *Service) GetDashboardData() (*DashboardData, error) {
 			&musicPolicyType, &musicSetID, &musicFavoriteID,
 			&musicContentJSON, &templateID,
 			&musicFavoriteName, &musicFavoriteArtwork,
+			&skipNext, &snoozeUntil,
 		); err != nil {
-			s.logger.Printf("Failed to scan job row: %v", err)
+			s.logger.Warn("Failed to scan job row", "error", err)
 			continue
 		}
 
@@ -236,7 +331,7 @@ func (s *Service) GetDashboardData() (*DashboardData, error) {
 			// Try alternate format
 			scheduledTime, err = time.Parse("2006-01-02 15:04:05", scheduledFor)
 			if err != nil {
-				s.logger.Printf("Failed to parse scheduled_for for job %s: %v", jobID, err)
+				s.logger.Warn("Failed to parse scheduled_for", "job_id", jobID, "error", err)
 				continue
 			}
 		}
@@ -250,6 +345,9 @@ func (s *Service) GetDashboardData() (*DashboardData, error) {
 			NextRunAt: &scheduledTime,
 		}
 
+		// Jobs generated before a snooze/skip was set stay PENDING, so flag them here
+		applySuppressionState(&summary, skipNext == 1, parseSnoozeUntil(snoozeUntil), from)
+
 		// Extract target rooms from scene members (primary) or speakers (fallback)
 		// Node.js gets target_rooms from scene members, not routine speakers
 		if sceneID != "" {
@@ -319,21 +417,57 @@ func (s *Service) GetDashboardData() (*DashboardData, error) {
 			}
 		}
 
-		dashboard.UpcomingRoutines = append(dashboard.UpcomingRoutines, summary)
+		routines = append(routines, summary)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read upcoming jobs: %w", err)
 	}
 
-	// Set the next routine if we have any (first item - backwards compat with iOS)
-	if len(dashboard.UpcomingRoutines) > 0 {
-		dashboard.NextRoutine = &dashboard.UpcomingRoutines[0]
+	return routines, nil
+}
+
+// applySuppressionState records the routine's skip/snooze state on the summary and
+// marks whether its scheduled run will be suppressed. Snoozes that have already
+// expired are ignored.
+func applySuppressionState(summary *RoutineSummary, skipNext bool, snoozeUntil *time.Time, now time.Time) {
+	summary.SkipNext = skipNext
+	if snoozeUntil != nil && snoozeUntil.After(now) {
+		summary.SnoozeUntil = snoozeUntil
 	}
 
-	// Check for attention items
-	dashboard.AttentionItems = s.checkAttentionItems()
+	var reason string
+	switch {
+	case skipNext:
+		reason = SuppressedReasonSkipNext
+	case summary.SnoozeUntil != nil && (summary.NextRunAt == nil || summary.NextRunAt.Before(*summary.SnoozeUntil)):
+		reason = SuppressedReasonSnoozed
+	}
+	if reason != "" {
+		summary.Suppressed = true
+		summary.SuppressedReason = &reason
+	}
+}
 
-	return dashboard, nil
+// parseSnoozeUntil parses a routines.snooze_until value, returning nil if unset or unparseable.
+func parseSnoozeUntil(value sql.NullString) *time.Time {
+	if !value.Valid || value.String == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value.String)
+	if err != nil {
+		t, err = time.Parse("2006-01-02 15:04:05", value.String)
+		if err != nil {
+			return nil
+		}
+	}
+	if t.IsZero() {
+		return nil
+	}
+	return &t
 }
 
-// buildDeviceRoomMap creates a map of udn -> room_name from the device service.
+// buildDeviceRoomMap creates a map of udn -> display name (alias or room name) from the
+// device service.
 // NON-BLOCKING: Returns empty map if topology not yet cached.
 func (s *Service) buildDeviceRoomMap() map[string]string {
 	deviceRoomMap := make(map[string]string)
@@ -342,7 +476,9 @@ func (s *Service) buildDeviceRoomMap() map[string]string {
 		topology := s.deviceService.GetTopologyIfCached()
 		if topology != nil {
 			for _, device := range topology.Devices {
-				deviceRoomMap[device.UDN] = device.RoomName
+				if !device.IsBonded {
+					deviceRoomMap[device.UDN] = device.DisplayName()
+				}
 			}
 		}
 	}
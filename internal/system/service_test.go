@@ -5,6 +5,9 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/ratelimit"
+	"github.com/strefethen/sonos-hub-go/internal/retention"
 )
 
 func TestSystemInfoDefaults(t *testing.T) {
@@ -39,6 +42,44 @@ func TestSystemInfoWithLastDiscovery(t *testing.T) {
 	require.Equal(t, now, *info.LastDiscovery)
 }
 
+func TestFormatSystemInfoRetention(t *testing.T) {
+	info := &SystemInfo{HubVersion: "1.0.0"}
+	_, ok := formatSystemInfo(info)["retention"]
+	require.False(t, ok)
+
+	info.Retention = &retention.Status{JobRetentionDays: 90, HistoryRetentionDays: 365}
+	formatted := formatSystemInfo(info)["retention"].(map[string]any)
+	require.Equal(t, 90, formatted["job_retention_days"])
+	require.Equal(t, 365, formatted["history_retention_days"])
+	require.Nil(t, formatted["last_pruned_at"])
+
+	prunedAt := time.Date(2026, 3, 10, 3, 0, 0, 0, time.UTC)
+	info.Retention.LastPrunedAt = &prunedAt
+	info.Retention.JobsDeleted = 12
+	info.Retention.HistoryDeleted = 40
+	formatted = formatSystemInfo(info)["retention"].(map[string]any)
+	require.Equal(t, "2026-03-10T03:00:00.000Z", formatted["last_pruned_at"])
+	require.Equal(t, int64(12), formatted["jobs_deleted"])
+	require.Equal(t, int64(40), formatted["play_history_deleted"])
+	require.Nil(t, formatted["last_error"])
+}
+
+func TestFormatSystemInfoRateLimit(t *testing.T) {
+	info := &SystemInfo{HubVersion: "1.0.0"}
+	_, ok := formatSystemInfo(info)["rate_limit"]
+	require.False(t, ok)
+
+	info.RateLimit = &ratelimit.Stats{Enabled: true, RequestsPerSecond: 5, Burst: 20, TrackedClients: 2, Allowed: 100, Limited: 7}
+	require.Equal(t, map[string]any{
+		"enabled":             true,
+		"requests_per_second": 5,
+		"burst":               20,
+		"tracked_clients":     2,
+		"allowed_total":       int64(100),
+		"limited_total":       int64(7),
+	}, formatSystemInfo(info)["rate_limit"])
+}
+
 func TestRoutineSummary(t *testing.T) {
 	nextRun := time.Now().Add(time.Hour)
 	summary := RoutineSummary{
@@ -118,3 +159,58 @@ func TestVersionDefault(t *testing.T) {
 	// Version should have a default value
 	require.NotEmpty(t, Version)
 }
+
+func TestApplySuppressionState(t *testing.T) {
+	now := time.Now()
+	nextRun := now.Add(2 * time.Hour)
+
+	t.Run("skip next", func(t *testing.T) {
+		summary := RoutineSummary{NextRunAt: &nextRun}
+		applySuppressionState(&summary, true, nil, now)
+
+		require.True(t, summary.SkipNext)
+		require.True(t, summary.Suppressed)
+		require.Equal(t, SuppressedReasonSkipNext, *summary.SuppressedReason)
+	})
+
+	t.Run("snoozed past next run", func(t *testing.T) {
+		until := now.Add(3 * time.Hour)
+		summary := RoutineSummary{NextRunAt: &nextRun}
+		applySuppressionState(&summary, false, &until, now)
+
+		require.True(t, summary.Suppressed)
+		require.Equal(t, SuppressedReasonSnoozed, *summary.SuppressedReason)
+		require.Equal(t, until, *summary.SnoozeUntil)
+	})
+
+	t.Run("snooze ends before next run", func(t *testing.T) {
+		until := now.Add(time.Hour)
+		summary := RoutineSummary{NextRunAt: &nextRun}
+		applySuppressionState(&summary, false, &until, now)
+
+		require.False(t, summary.Suppressed)
+		require.Nil(t, summary.SuppressedReason)
+		require.NotNil(t, summary.SnoozeUntil)
+	})
+
+	t.Run("expired snooze ignored", func(t *testing.T) {
+		until := now.Add(-time.Hour)
+		summary := RoutineSummary{NextRunAt: &nextRun}
+		applySuppressionState(&summary, false, &until, now)
+
+		require.False(t, summary.Suppressed)
+		require.Nil(t, summary.SnoozeUntil)
+	})
+}
+
+func TestFormatRoutineSummarySuppression(t *testing.T) {
+	nextRun := time.Now().Add(time.Hour)
+	summary := RoutineSummary{RoutineID: "routine-123", Name: "Evening", NextRunAt: &nextRun}
+	applySuppressionState(&summary, true, nil, time.Now())
+
+	result := formatRoutineSummary(&summary)
+	require.Equal(t, true, result["skip_next"])
+	require.Equal(t, true, result["suppressed"])
+	require.Equal(t, "skip_next", result["suppressed_reason"])
+	require.NotContains(t, result, "snooze_until")
+}
@@ -68,6 +68,26 @@ func NewService(dbPair DBPair) *Service {
 	}
 }
 
+// GetTemplate retrieves a template by ID. It returns nil if there is no such template.
+func (s *Service) GetTemplate(templateID string) (*RoutineTemplate, error) {
+	row := s.reader.QueryRow(`
+		SELECT template_id, name, description, category, sort_order, icon, image_name,
+		       gradient_color_1, gradient_color_2, accent_color,
+		       timezone, schedule_type, schedule_weekdays, schedule_month, schedule_day, schedule_time,
+		       suggested_speakers, music_policy_type, music_set_id, music_sonos_favorite_id,
+		       music_no_repeat_window_minutes, music_fallback_behavior,
+		       holiday_behavior, arc_tv_policy, created_at
+		FROM routine_templates
+		WHERE template_id = ?
+	`, templateID)
+
+	t, err := scanTemplateRow(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return t, err
+}
+
 // RegisterRoutes wires template routes to the router.
 func RegisterRoutes(router chi.Router, service *Service) {
 	router.Method(http.MethodGet, "/v1/routine-templates", api.Handler(listTemplates(service)))
@@ -129,26 +149,15 @@ func getTemplate(service *Service) func(w http.ResponseWriter, r *http.Request)
 	return func(w http.ResponseWriter, r *http.Request) error {
 		templateID := chi.URLParam(r, "template_id")
 
-		row := service.reader.QueryRow(`
-			SELECT template_id, name, description, category, sort_order, icon, image_name,
-			       gradient_color_1, gradient_color_2, accent_color,
-			       timezone, schedule_type, schedule_weekdays, schedule_month, schedule_day, schedule_time,
-			       suggested_speakers, music_policy_type, music_set_id, music_sonos_favorite_id,
-			       music_no_repeat_window_minutes, music_fallback_behavior,
-			       holiday_behavior, arc_tv_policy, created_at
-			FROM routine_templates
-			WHERE template_id = ?
-		`, templateID)
-
-		t, err := scanTemplateRow(row)
+		t, err := service.GetTemplate(templateID)
 		if err != nil {
-			if err == sql.ErrNoRows {
-				return apperrors.NewNotFoundError("Template not found", map[string]any{
-					"template_id": templateID,
-				})
-			}
 			return apperrors.NewInternalError("Failed to fetch template")
 		}
+		if t == nil {
+			return apperrors.NewNotFoundError("Template not found", map[string]any{
+				"template_id": templateID,
+			})
+		}
 
 		return api.WriteResource(w, http.StatusOK, formatTemplate(t))
 	}
@@ -0,0 +1,213 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderEvent     = "X-Sonos-Hub-Event"
+	HeaderDelivery  = "X-Sonos-Hub-Delivery"
+	HeaderSignature = "X-Sonos-Hub-Signature" // "sha256=" + hex HMAC-SHA256 of the body, keyed with the secret
+)
+
+// deliveryTimeout bounds each attempt to deliver an event.
+const deliveryTimeout = 5 * time.Second
+
+// defaultRetryDelays are the waits before each retry of a failed delivery.
+var defaultRetryDelays = []time.Duration{time.Second, 4 * time.Second, 16 * time.Second}
+
+// Dispatcher delivers events to the webhooks subscribed to them. Deliveries run in the
+// background, so publishing never waits on, or fails because of, a webhook.
+type Dispatcher struct {
+	repo        *Repository
+	client      *http.Client
+	logger      *slog.Logger
+	retryDelays []time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher creates a dispatcher for the webhooks in repo.
+func NewDispatcher(repo *Repository, logger *slog.Logger) *Dispatcher {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		repo:        repo,
+		client:      &http.Client{Timeout: deliveryTimeout},
+		logger:      logger,
+		retryDelays: defaultRetryDelays,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Payload is the JSON body POSTed to webhooks.
+type Payload struct {
+	ID        string         `json:"id"` // The delivery ID, also sent as X-Sonos-Hub-Delivery
+	Event     Event          `json:"event"`
+	CreatedAt string         `json:"created_at"`
+	Data      map[string]any `json:"data"`
+}
+
+// Publish sends event to every enabled webhook subscribed to it, in the background.
+func (d *Dispatcher) Publish(event Event, data map[string]any) {
+	if d.ctx.Err() != nil {
+		return
+	}
+	createdAt := time.Now()
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		webhooks, err := d.repo.ListSubscribed(event)
+		if err != nil {
+			d.logger.Warn("Failed to load webhooks", "event", event, "error", err)
+			return
+		}
+		for _, webhook := range webhooks {
+			d.wg.Add(1)
+			go func() {
+				defer d.wg.Done()
+				d.deliver(webhook, event, data, createdAt)
+			}()
+		}
+	}()
+}
+
+// Stop abandons pending retries and waits for deliveries in flight.
+func (d *Dispatcher) Stop() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+// Wait waits for deliveries in flight, retries included.
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// deliver POSTs the event to one webhook, retrying failed attempts, and records how it went.
+func (d *Dispatcher) deliver(webhook Webhook, event Event, data map[string]any, createdAt time.Time) {
+	delivery := Delivery{
+		DeliveryID: uuid.New().String(),
+		WebhookID:  webhook.WebhookID,
+		Event:      event,
+		CreatedAt:  createdAt,
+	}
+	logger := d.logger.With("webhook_id", webhook.WebhookID, "event", event, "delivery_id", delivery.DeliveryID)
+
+	body, err := json.Marshal(Payload{
+		ID:        delivery.DeliveryID,
+		Event:     event,
+		CreatedAt: api.RFC3339Millis(createdAt),
+		Data:      data,
+	})
+	if err != nil {
+		logger.Error("Failed to encode webhook payload", "error", err)
+		return
+	}
+
+	for {
+		delivery.Attempts++
+		status, retry, err := d.post(webhook, delivery.DeliveryID, event, body)
+		delivery.ResponseStatus = status
+		delivery.Error = nil
+		if err == nil {
+			delivery.Status = DeliveryStatusSucceeded
+			break
+		}
+		message := err.Error()
+		delivery.Status = DeliveryStatusFailed
+		delivery.Error = &message
+
+		if !retry || delivery.Attempts > len(d.retryDelays) {
+			break
+		}
+		logger.Info("Webhook delivery failed, will retry", "attempt", delivery.Attempts, "error", err)
+		if !d.sleep(d.retryDelays[delivery.Attempts-1]) {
+			break // Shutting down
+		}
+	}
+
+	delivery.CompletedAt = time.Now()
+	if delivery.Status == DeliveryStatusFailed {
+		logger.Warn("Webhook delivery failed", "attempts", delivery.Attempts, "error", *delivery.Error)
+	}
+	if err := d.repo.RecordDelivery(delivery); err != nil {
+		logger.Warn("Failed to record webhook delivery", "error", err)
+	}
+}
+
+// sleep waits before a retry. It returns false if the dispatcher stopped meanwhile.
+func (d *Dispatcher) sleep(delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-d.ctx.Done():
+		return false
+	}
+}
+
+// post makes one delivery attempt. It returns the response status, if there was a
+// response, and whether a failure is worth retrying: network errors, 408, 429 and 5xx
+// are; other responses won't change on a retry.
+func (d *Dispatcher) post(webhook Webhook, deliveryID string, event Event, body []byte) (*int, bool, error) {
+	ctx, cancel := context.WithTimeout(d.ctx, deliveryTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "sonos-hub-webhooks")
+	req.Header.Set(HeaderEvent, string(event))
+	req.Header.Set(HeaderDelivery, deliveryID)
+	if webhook.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(webhook.Secret, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	status := resp.StatusCode
+	if status >= 200 && status < 300 {
+		return &status, false, nil
+	}
+	retry := status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= 500
+	return &status, retry, fmt.Errorf("webhook responded %d", status)
+}
+
+// Sign returns the X-Sonos-Hub-Signature value for body: "sha256=" and the hex
+// HMAC-SHA256 of the body keyed with secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
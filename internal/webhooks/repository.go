@@ -0,0 +1,346 @@
+// Package webhooks notifies outside services, such as ntfy or Home Assistant, when
+// routines finish or speakers go offline, by POSTing signed JSON to registered URLs.
+package webhooks
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxDeliveriesPerWebhook caps the delivery history kept for each webhook.
+const maxDeliveriesPerWebhook = 100
+
+// deliveryTimeFormat stores delivery times to the microsecond, fixed width so they sort
+// as text.
+const deliveryTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// ErrNotFound is returned when a webhook doesn't exist.
+var ErrNotFound = errors.New("webhook not found")
+
+// Event is something webhooks can subscribe to.
+type Event string
+
+const (
+	EventRoutineCompleted Event = "routine.completed" // A routine's job ran
+	EventRoutineFailed    Event = "routine.failed"    // A routine's job failed with no retries left
+	EventDeviceOffline    Event = "device.offline"    // A speaker stopped answering discovery
+)
+
+// Events lists the events webhooks can subscribe to.
+var Events = []Event{EventRoutineCompleted, EventRoutineFailed, EventDeviceOffline}
+
+// IsValid reports whether e is a known event.
+func (e Event) IsValid() bool {
+	for _, event := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryStatus is how sending an event to a webhook ended.
+type DeliveryStatus string
+
+const (
+	DeliveryStatusSucceeded DeliveryStatus = "succeeded"
+	DeliveryStatusFailed    DeliveryStatus = "failed"
+)
+
+// DBPair interface for dependency injection (matches db.DBPair).
+type DBPair interface {
+	Reader() *sql.DB
+	Writer() *sql.DB
+}
+
+// Webhook is a URL notified of the events it subscribes to.
+type Webhook struct {
+	WebhookID string
+	URL       string
+	Secret    string // Signs payloads when set; never returned by the API
+	Events    []Event
+	Enabled   bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Subscribes reports whether the webhook wants event.
+func (w *Webhook) Subscribes(event Event) bool {
+	for _, subscribed := range w.Events {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery records sending one event to a webhook, after the last attempt.
+type Delivery struct {
+	DeliveryID     string
+	WebhookID      string
+	Event          Event
+	Status         DeliveryStatus
+	Attempts       int
+	ResponseStatus *int    // The last attempt's HTTP status, if it got a response
+	Error          *string // Why the last attempt failed
+	CreatedAt      time.Time
+	CompletedAt    time.Time
+}
+
+// CreateInput contains the fields for a new webhook.
+type CreateInput struct {
+	URL     string  `json:"url"`
+	Secret  string  `json:"secret"`
+	Events  []Event `json:"events"`
+	Enabled *bool   `json:"enabled,omitempty"` // Defaults to true
+}
+
+// UpdateInput contains the fields to change on a webhook. An empty secret stops signing.
+type UpdateInput struct {
+	URL     *string `json:"url,omitempty"`
+	Secret  *string `json:"secret,omitempty"`
+	Events  []Event `json:"events,omitempty"`
+	Enabled *bool   `json:"enabled,omitempty"`
+}
+
+// Repository stores webhooks and their delivery history.
+type Repository struct {
+	reader *sql.DB
+	writer *sql.DB
+	now    func() time.Time
+}
+
+// NewRepository creates a webhook repository.
+func NewRepository(dbPair DBPair) *Repository {
+	return &Repository{
+		reader: dbPair.Reader(),
+		writer: dbPair.Writer(),
+		now:    time.Now,
+	}
+}
+
+// Create stores a new webhook.
+func (r *Repository) Create(input CreateInput) (*Webhook, error) {
+	now := r.now().UTC().Truncate(time.Second)
+	webhook := &Webhook{
+		WebhookID: uuid.New().String(),
+		URL:       input.URL,
+		Secret:    input.Secret,
+		Events:    input.Events,
+		Enabled:   input.Enabled == nil || *input.Enabled,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	eventsJSON, err := json.Marshal(webhook.Events)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.writer.Exec(`
+		INSERT INTO webhooks (webhook_id, url, secret, events, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, webhook.WebhookID, webhook.URL, webhook.Secret, string(eventsJSON), webhook.Enabled,
+		now.Format(time.RFC3339), now.Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// Get returns a webhook, or ErrNotFound.
+func (r *Repository) Get(webhookID string) (*Webhook, error) {
+	row := r.reader.QueryRow(`
+		SELECT webhook_id, url, secret, events, enabled, created_at, updated_at
+		FROM webhooks WHERE webhook_id = ?
+	`, webhookID)
+	webhook, err := scanWebhook(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return webhook, err
+}
+
+// List returns every webhook, oldest first.
+func (r *Repository) List() ([]Webhook, error) {
+	rows, err := r.reader.Query(`
+		SELECT webhook_id, url, secret, events, enabled, created_at, updated_at
+		FROM webhooks ORDER BY created_at, webhook_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+// ListSubscribed returns the enabled webhooks subscribed to event.
+func (r *Repository) ListSubscribed(event Event) ([]Webhook, error) {
+	webhooks, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+	subscribed := []Webhook{}
+	for _, webhook := range webhooks {
+		if webhook.Enabled && webhook.Subscribes(event) {
+			subscribed = append(subscribed, webhook)
+		}
+	}
+	return subscribed, nil
+}
+
+// Update changes the fields set in input. Returns ErrNotFound if the webhook doesn't exist.
+func (r *Repository) Update(webhookID string, input UpdateInput) (*Webhook, error) {
+	webhook, err := r.Get(webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if input.URL != nil {
+		webhook.URL = *input.URL
+	}
+	if input.Secret != nil {
+		webhook.Secret = *input.Secret
+	}
+	if input.Events != nil {
+		webhook.Events = input.Events
+	}
+	if input.Enabled != nil {
+		webhook.Enabled = *input.Enabled
+	}
+	webhook.UpdatedAt = r.now().UTC().Truncate(time.Second)
+
+	eventsJSON, err := json.Marshal(webhook.Events)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.writer.Exec(`
+		UPDATE webhooks SET url = ?, secret = ?, events = ?, enabled = ?, updated_at = ?
+		WHERE webhook_id = ?
+	`, webhook.URL, webhook.Secret, string(eventsJSON), webhook.Enabled, webhook.UpdatedAt.Format(time.RFC3339), webhookID)
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// Delete removes a webhook and its delivery history. Returns ErrNotFound if it doesn't exist.
+func (r *Repository) Delete(webhookID string) error {
+	result, err := r.writer.Exec("DELETE FROM webhooks WHERE webhook_id = ?", webhookID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RecordDelivery stores a delivery, dropping the webhook's oldest beyond
+// maxDeliveriesPerWebhook. A webhook deleted mid-delivery leaves nothing to record.
+func (r *Repository) RecordDelivery(delivery Delivery) error {
+	_, err := r.writer.Exec(`
+		INSERT INTO webhook_deliveries (delivery_id, webhook_id, event, status, attempts, response_status, error, created_at, completed_at)
+		SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?
+		WHERE EXISTS (SELECT 1 FROM webhooks WHERE webhook_id = ?)
+	`, delivery.DeliveryID, delivery.WebhookID, string(delivery.Event), string(delivery.Status), delivery.Attempts,
+		delivery.ResponseStatus, delivery.Error, delivery.CreatedAt.UTC().Format(deliveryTimeFormat),
+		delivery.CompletedAt.UTC().Format(deliveryTimeFormat), delivery.WebhookID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.writer.Exec(`
+		DELETE FROM webhook_deliveries
+		WHERE webhook_id = ? AND delivery_id NOT IN (
+			SELECT delivery_id FROM webhook_deliveries WHERE webhook_id = ?
+			ORDER BY created_at DESC LIMIT ?
+		)
+	`, delivery.WebhookID, delivery.WebhookID, maxDeliveriesPerWebhook)
+	return err
+}
+
+// ListDeliveries returns a webhook's deliveries, newest first, and how many it has.
+func (r *Repository) ListDeliveries(webhookID string, limit, offset int) ([]Delivery, int, error) {
+	var total int
+	if err := r.reader.QueryRow("SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id = ?", webhookID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.reader.Query(`
+		SELECT delivery_id, webhook_id, event, status, attempts, response_status, error, created_at, completed_at
+		FROM webhook_deliveries WHERE webhook_id = ?
+		ORDER BY created_at DESC
+		LIMIT ? OFFSET ?
+	`, webhookID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	deliveries := []Delivery{}
+	for rows.Next() {
+		var (
+			delivery       Delivery
+			event, status  string
+			responseStatus sql.NullInt64
+			errorMsg       sql.NullString
+			createdAt      string
+			completedAt    string
+		)
+		if err := rows.Scan(&delivery.DeliveryID, &delivery.WebhookID, &event, &status, &delivery.Attempts,
+			&responseStatus, &errorMsg, &createdAt, &completedAt); err != nil {
+			return nil, 0, err
+		}
+		delivery.Event = Event(event)
+		delivery.Status = DeliveryStatus(status)
+		if responseStatus.Valid {
+			code := int(responseStatus.Int64)
+			delivery.ResponseStatus = &code
+		}
+		if errorMsg.Valid {
+			delivery.Error = &errorMsg.String
+		}
+		delivery.CreatedAt, _ = time.Parse(deliveryTimeFormat, createdAt)
+		delivery.CompletedAt, _ = time.Parse(deliveryTimeFormat, completedAt)
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, total, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWebhook(row rowScanner) (*Webhook, error) {
+	var (
+		webhook    Webhook
+		eventsJSON string
+		createdAt  string
+		updatedAt  string
+	)
+	if err := row.Scan(&webhook.WebhookID, &webhook.URL, &webhook.Secret, &eventsJSON, &webhook.Enabled, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(eventsJSON), &webhook.Events); err != nil {
+		return nil, fmt.Errorf("webhook %s events: %w", webhook.WebhookID, err)
+	}
+	webhook.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	webhook.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	return &webhook, nil
+}
@@ -0,0 +1,222 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/strefethen/sonos-hub-go/internal/api"
+	"github.com/strefethen/sonos-hub-go/internal/apperrors"
+)
+
+// maxURLLength caps the length of a webhook's URL.
+const maxURLLength = 2048
+
+// RegisterRoutes wires webhook routes to the router.
+func RegisterRoutes(router chi.Router, repo *Repository) {
+	router.Method(http.MethodPost, "/v1/webhooks", api.Handler(createWebhook(repo)))
+	router.Method(http.MethodGet, "/v1/webhooks", api.Handler(listWebhooks(repo)))
+	router.Method(http.MethodGet, "/v1/webhooks/{webhook_id}", api.Handler(getWebhook(repo)))
+	router.Method(http.MethodPut, "/v1/webhooks/{webhook_id}", api.Handler(updateWebhook(repo)))
+	router.Method(http.MethodDelete, "/v1/webhooks/{webhook_id}", api.Handler(deleteWebhook(repo)))
+	router.Method(http.MethodGet, "/v1/webhooks/{webhook_id}/deliveries", api.Handler(listDeliveries(repo)))
+}
+
+// createWebhook handles POST /v1/webhooks
+func createWebhook(repo *Repository) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var input CreateInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			return apperrors.NewValidationError("invalid request body", nil)
+		}
+		if err := validateURL(input.URL); err != nil {
+			return err
+		}
+		if err := validateEvents(input.Events); err != nil {
+			return err
+		}
+
+		webhook, err := repo.Create(input)
+		if err != nil {
+			return apperrors.NewInternalError("Failed to create webhook")
+		}
+		return api.WriteResource(w, http.StatusCreated, formatWebhook(webhook))
+	}
+}
+
+// listWebhooks handles GET /v1/webhooks
+func listWebhooks(repo *Repository) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		webhooks, err := repo.List()
+		if err != nil {
+			return apperrors.NewInternalError("Failed to list webhooks")
+		}
+
+		data := make([]map[string]any, 0, len(webhooks))
+		for i := range webhooks {
+			data = append(data, formatWebhook(&webhooks[i]))
+		}
+		return api.WriteList(w, "/v1/webhooks", data, false)
+	}
+}
+
+// getWebhook handles GET /v1/webhooks/{webhook_id}
+func getWebhook(repo *Repository) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		webhookID := chi.URLParam(r, "webhook_id")
+		webhook, err := repo.Get(webhookID)
+		if err != nil {
+			return repositoryError(err, webhookID, "Failed to get webhook")
+		}
+		return api.WriteResource(w, http.StatusOK, formatWebhook(webhook))
+	}
+}
+
+// updateWebhook handles PUT /v1/webhooks/{webhook_id}
+// Only the fields present are changed.
+func updateWebhook(repo *Repository) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		webhookID := chi.URLParam(r, "webhook_id")
+
+		var input UpdateInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			return apperrors.NewValidationError("invalid request body", nil)
+		}
+		if input.URL != nil {
+			if err := validateURL(*input.URL); err != nil {
+				return err
+			}
+		}
+		if input.Events != nil {
+			if err := validateEvents(input.Events); err != nil {
+				return err
+			}
+		}
+
+		webhook, err := repo.Update(webhookID, input)
+		if err != nil {
+			return repositoryError(err, webhookID, "Failed to update webhook")
+		}
+		return api.WriteResource(w, http.StatusOK, formatWebhook(webhook))
+	}
+}
+
+// deleteWebhook handles DELETE /v1/webhooks/{webhook_id}
+// The webhook's delivery history goes with it.
+func deleteWebhook(repo *Repository) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		webhookID := chi.URLParam(r, "webhook_id")
+		if err := repo.Delete(webhookID); err != nil {
+			return repositoryError(err, webhookID, "Failed to delete webhook")
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+// listDeliveries handles GET /v1/webhooks/{webhook_id}/deliveries
+// Newest first; the last 100 deliveries are kept.
+func listDeliveries(repo *Repository) api.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		webhookID := chi.URLParam(r, "webhook_id")
+		if _, err := repo.Get(webhookID); err != nil {
+			return repositoryError(err, webhookID, "Failed to get webhook")
+		}
+
+		limit := 20
+		offset := 0
+		if l := r.URL.Query().Get("limit"); l != "" {
+			if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= maxDeliveriesPerWebhook {
+				limit = parsed
+			}
+		}
+		if o := r.URL.Query().Get("offset"); o != "" {
+			if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+				offset = parsed
+			}
+		}
+
+		deliveries, total, err := repo.ListDeliveries(webhookID, limit, offset)
+		if err != nil {
+			return apperrors.NewInternalError("Failed to list webhook deliveries")
+		}
+
+		data := make([]map[string]any, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			data = append(data, formatDelivery(delivery))
+		}
+		hasMore := offset+len(deliveries) < total
+		return api.WriteList(w, "/v1/webhooks/"+webhookID+"/deliveries", data, hasMore)
+	}
+}
+
+// validateURL checks that a webhook URL is an absolute http(s) URL.
+func validateURL(raw string) error {
+	if raw == "" {
+		return apperrors.NewValidationError("url is required", nil)
+	}
+	if len(raw) > maxURLLength {
+		return apperrors.NewValidationError("url is too long", map[string]any{"max_length": maxURLLength})
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return apperrors.NewValidationError("url must be an http or https URL", map[string]any{"url": raw})
+	}
+	return nil
+}
+
+// validateEvents checks that a webhook subscribes to at least one known event.
+func validateEvents(events []Event) error {
+	if len(events) == 0 {
+		return apperrors.NewValidationError("events is required", map[string]any{"valid_events": Events})
+	}
+	for _, event := range events {
+		if !event.IsValid() {
+			return apperrors.NewValidationError("invalid event: "+string(event), map[string]any{"valid_events": Events})
+		}
+	}
+	return nil
+}
+
+// repositoryError maps repository errors to API errors.
+func repositoryError(err error, webhookID, message string) error {
+	if errors.Is(err, ErrNotFound) {
+		return apperrors.NewNotFoundResource("Webhook", webhookID)
+	}
+	return apperrors.NewInternalError(message)
+}
+
+// formatWebhook formats a webhook for responses. The secret is never returned.
+func formatWebhook(webhook *Webhook) map[string]any {
+	return map[string]any{
+		"object":     api.ObjectWebhook,
+		"id":         webhook.WebhookID,
+		"url":        webhook.URL,
+		"events":     webhook.Events,
+		"enabled":    webhook.Enabled,
+		"has_secret": webhook.Secret != "",
+		"created_at": api.RFC3339Millis(webhook.CreatedAt),
+		"updated_at": api.RFC3339Millis(webhook.UpdatedAt),
+	}
+}
+
+// formatDelivery formats a delivery for responses.
+func formatDelivery(delivery Delivery) map[string]any {
+	return map[string]any{
+		"object":          api.ObjectWebhookDelivery,
+		"id":              delivery.DeliveryID,
+		"webhook_id":      delivery.WebhookID,
+		"event":           string(delivery.Event),
+		"status":          string(delivery.Status),
+		"attempts":        delivery.Attempts,
+		"response_status": delivery.ResponseStatus,
+		"error":           delivery.Error,
+		"created_at":      api.RFC3339Millis(delivery.CreatedAt),
+		"completed_at":    api.RFC3339Millis(delivery.CompletedAt),
+	}
+}
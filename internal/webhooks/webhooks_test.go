@@ -0,0 +1,300 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/strefethen/sonos-hub-go/internal/db"
+	"github.com/strefethen/sonos-hub-go/internal/logging"
+)
+
+func setupTestRepo(t *testing.T) *Repository {
+	t.Helper()
+	dbPair, err := db.Init(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPair.Close() })
+	return NewRepository(dbPair)
+}
+
+func setupTestDispatcher(t *testing.T, repo *Repository) *Dispatcher {
+	t.Helper()
+	dispatcher := NewDispatcher(repo, logging.Discard())
+	dispatcher.retryDelays = []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond}
+	t.Cleanup(dispatcher.Stop)
+	return dispatcher
+}
+
+// receiver is a webhook endpoint that answers with the given statuses in turn, then 200.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	if len(rc.statuses) > 0 {
+		w.WriteHeader(rc.statuses[0])
+		rc.statuses = rc.statuses[1:]
+	}
+}
+
+func (rc *receiver) count() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return len(rc.requests)
+}
+
+func TestRepository_CRUD(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	created, err := repo.Create(CreateInput{URL: "https://ntfy.example/hub", Secret: "s3cret", Events: []Event{EventRoutineFailed}})
+	require.NoError(t, err)
+	require.True(t, created.Enabled, "webhooks are enabled by default")
+
+	webhook, err := repo.Get(created.WebhookID)
+	require.NoError(t, err)
+	require.Equal(t, created, webhook)
+
+	disabled := false
+	url := "https://ntfy.example/other"
+	updated, err := repo.Update(created.WebhookID, UpdateInput{URL: &url, Enabled: &disabled})
+	require.NoError(t, err)
+	require.Equal(t, url, updated.URL)
+	require.False(t, updated.Enabled)
+	require.Equal(t, "s3cret", updated.Secret, "fields left out are unchanged")
+	require.Equal(t, []Event{EventRoutineFailed}, updated.Events)
+
+	subscribed, err := repo.ListSubscribed(EventRoutineFailed)
+	require.NoError(t, err)
+	require.Empty(t, subscribed, "disabled webhooks get nothing")
+
+	require.NoError(t, repo.Delete(created.WebhookID))
+	require.ErrorIs(t, repo.Delete(created.WebhookID), ErrNotFound)
+	_, err = repo.Get(created.WebhookID)
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = repo.Update(created.WebhookID, UpdateInput{URL: &url})
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestRepository_DeliveryHistory(t *testing.T) {
+	repo := setupTestRepo(t)
+	webhook, err := repo.Create(CreateInput{URL: "https://ntfy.example/hub", Events: []Event{EventDeviceOffline}})
+	require.NoError(t, err)
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := range maxDeliveriesPerWebhook + 5 {
+		createdAt := start.Add(time.Duration(i) * time.Millisecond)
+		require.NoError(t, repo.RecordDelivery(Delivery{
+			DeliveryID:  fmt.Sprintf("delivery-%d", i),
+			WebhookID:   webhook.WebhookID,
+			Event:       EventDeviceOffline,
+			Status:      DeliveryStatusSucceeded,
+			Attempts:    1,
+			CreatedAt:   createdAt,
+			CompletedAt: createdAt,
+		}))
+	}
+
+	deliveries, total, err := repo.ListDeliveries(webhook.WebhookID, 10, 0)
+	require.NoError(t, err)
+	require.Equal(t, maxDeliveriesPerWebhook, total, "the oldest deliveries are dropped")
+	require.Len(t, deliveries, 10)
+	require.True(t, deliveries[0].CreatedAt.Equal(start.Add((maxDeliveriesPerWebhook+4)*time.Millisecond)), "newest first")
+	require.True(t, deliveries[0].CreatedAt.After(deliveries[1].CreatedAt))
+
+	// Deleting the webhook takes its history with it, and late deliveries aren't kept
+	require.NoError(t, repo.Delete(webhook.WebhookID))
+	require.NoError(t, repo.RecordDelivery(Delivery{DeliveryID: "late", WebhookID: webhook.WebhookID, Event: EventDeviceOffline,
+		Status: DeliveryStatusFailed, CreatedAt: start, CompletedAt: start}))
+	_, total, err = repo.ListDeliveries(webhook.WebhookID, 10, 0)
+	require.NoError(t, err)
+	require.Zero(t, total)
+}
+
+func TestDispatcher_SignedDelivery(t *testing.T) {
+	repo := setupTestRepo(t)
+	dispatcher := setupTestDispatcher(t, repo)
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	signed, err := repo.Create(CreateInput{URL: server.URL, Secret: "s3cret", Events: []Event{EventRoutineCompleted}})
+	require.NoError(t, err)
+	disabled := false
+	_, err = repo.Create(CreateInput{URL: server.URL, Events: []Event{EventRoutineCompleted}, Enabled: &disabled})
+	require.NoError(t, err)
+	_, err = repo.Create(CreateInput{URL: server.URL, Events: []Event{EventDeviceOffline}})
+	require.NoError(t, err)
+
+	dispatcher.Publish(EventRoutineCompleted, map[string]any{"routine_id": "routine-1"})
+	dispatcher.Wait()
+
+	require.Equal(t, 1, rc.count(), "only enabled webhooks subscribed to the event are called")
+	req, body := rc.requests[0], rc.bodies[0]
+	require.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	require.Equal(t, string(EventRoutineCompleted), req.Header.Get(HeaderEvent))
+	require.Equal(t, Sign("s3cret", body), req.Header.Get(HeaderSignature))
+
+	var payload Payload
+	require.NoError(t, json.Unmarshal(body, &payload))
+	require.Equal(t, EventRoutineCompleted, payload.Event)
+	require.Equal(t, req.Header.Get(HeaderDelivery), payload.ID)
+	require.Equal(t, "routine-1", payload.Data["routine_id"])
+
+	deliveries, _, err := repo.ListDeliveries(signed.WebhookID, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, payload.ID, deliveries[0].DeliveryID)
+	require.Equal(t, DeliveryStatusSucceeded, deliveries[0].Status)
+	require.Equal(t, 1, deliveries[0].Attempts)
+	require.Equal(t, http.StatusOK, *deliveries[0].ResponseStatus)
+	require.Nil(t, deliveries[0].Error)
+}
+
+func TestDispatcher_Retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantStatus   DeliveryStatus
+		wantAttempts int
+		wantResponse int
+	}{
+		{"server errors are retried", []int{http.StatusInternalServerError, http.StatusBadGateway}, DeliveryStatusSucceeded, 3, http.StatusOK},
+		{"gives up after three retries", []int{500, 500, 500, 500, 500}, DeliveryStatusFailed, 4, http.StatusInternalServerError},
+		{"client errors are not retried", []int{http.StatusNotFound}, DeliveryStatusFailed, 1, http.StatusNotFound},
+		{"rate limits are retried", []int{http.StatusTooManyRequests}, DeliveryStatusSucceeded, 2, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := setupTestRepo(t)
+			dispatcher := setupTestDispatcher(t, repo)
+			rc := &receiver{statuses: tt.statuses}
+			server := httptest.NewServer(rc)
+			defer server.Close()
+
+			webhook, err := repo.Create(CreateInput{URL: server.URL, Events: []Event{EventRoutineFailed}})
+			require.NoError(t, err)
+
+			dispatcher.Publish(EventRoutineFailed, map[string]any{})
+			dispatcher.Wait()
+
+			require.Equal(t, tt.wantAttempts, rc.count())
+			deliveries, _, err := repo.ListDeliveries(webhook.WebhookID, 10, 0)
+			require.NoError(t, err)
+			require.Len(t, deliveries, 1)
+			require.Equal(t, tt.wantStatus, deliveries[0].Status)
+			require.Equal(t, tt.wantAttempts, deliveries[0].Attempts)
+			require.Equal(t, tt.wantResponse, *deliveries[0].ResponseStatus)
+			require.Empty(t, rc.requests[0].Header.Get(HeaderSignature), "unsigned without a secret")
+		})
+	}
+}
+
+func TestDispatcher_Unreachable(t *testing.T) {
+	repo := setupTestRepo(t)
+	dispatcher := setupTestDispatcher(t, repo)
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	webhook, err := repo.Create(CreateInput{URL: url, Events: []Event{EventDeviceOffline}})
+	require.NoError(t, err)
+
+	dispatcher.Publish(EventDeviceOffline, map[string]any{"udn": "RINCON_KITCHEN"})
+	dispatcher.Wait()
+
+	deliveries, _, err := repo.ListDeliveries(webhook.WebhookID, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, DeliveryStatusFailed, deliveries[0].Status)
+	require.Equal(t, 4, deliveries[0].Attempts)
+	require.Nil(t, deliveries[0].ResponseStatus)
+	require.NotNil(t, deliveries[0].Error)
+}
+
+func TestRoutes(t *testing.T) {
+	repo := setupTestRepo(t)
+	router := chi.NewRouter()
+	RegisterRoutes(router, repo)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	for _, body := range []string{
+		`{"events":["routine.failed"]}`,
+		`{"url":"ftp://example.com","events":["routine.failed"]}`,
+		`{"url":"https://","events":["routine.failed"]}`,
+		`{"url":"https://example.com","events":[]}`,
+		`{"url":"https://example.com","events":["routine.started"]}`,
+	} {
+		require.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/v1/webhooks", body).Code, body)
+	}
+
+	rec := serve(http.MethodPost, "/v1/webhooks", `{"url":"https://ntfy.example/hub","secret":"s3cret","events":["routine.failed","device.offline"]}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	require.Equal(t, "webhook", created["object"])
+	require.Equal(t, []any{"routine.failed", "device.offline"}, created["events"])
+	require.Equal(t, true, created["has_secret"])
+	require.NotContains(t, created, "secret", "the secret is never returned")
+	webhookID := created["id"].(string)
+
+	rec = serve(http.MethodPut, "/v1/webhooks/"+webhookID, `{"secret":"","enabled":false}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var updated map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &updated))
+	require.Equal(t, false, updated["has_secret"])
+	require.Equal(t, false, updated["enabled"])
+	require.Equal(t, "https://ntfy.example/hub", updated["url"])
+	require.Equal(t, http.StatusBadRequest, serve(http.MethodPut, "/v1/webhooks/"+webhookID, `{"events":["nope"]}`).Code)
+
+	rec = serve(http.MethodGet, "/v1/webhooks", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list struct {
+		Data []map[string]any `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Data, 1)
+	require.Equal(t, webhookID, list.Data[0]["id"])
+
+	now := time.Now()
+	status := http.StatusServiceUnavailable
+	message := "webhook responded 503"
+	require.NoError(t, repo.RecordDelivery(Delivery{DeliveryID: "delivery-1", WebhookID: webhookID, Event: EventRoutineFailed,
+		Status: DeliveryStatusFailed, Attempts: 4, ResponseStatus: &status, Error: &message, CreatedAt: now, CompletedAt: now}))
+	rec = serve(http.MethodGet, "/v1/webhooks/"+webhookID+"/deliveries", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	list.Data = nil
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Data, 1)
+	require.Equal(t, "webhook_delivery", list.Data[0]["object"])
+	require.Equal(t, "failed", list.Data[0]["status"])
+	require.Equal(t, float64(503), list.Data[0]["response_status"])
+
+	require.Equal(t, http.StatusNoContent, serve(http.MethodDelete, "/v1/webhooks/"+webhookID, "").Code)
+	require.Equal(t, http.StatusNotFound, serve(http.MethodDelete, "/v1/webhooks/"+webhookID, "").Code)
+	require.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/v1/webhooks/"+webhookID, "").Code)
+	require.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/v1/webhooks/"+webhookID+"/deliveries", "").Code)
+}
@@ -49,6 +49,8 @@ func TestPhase0HealthAndAuth(t *testing.T) {
 	tempDir := t.TempDir()
 	dbPath := filepath.Join(tempDir, "sonos-hub.db")
 	t.Setenv("SQLITE_DB_PATH", dbPath)
+	t.Setenv("ARTWORK_CACHE_DIR", filepath.Join(tempDir, "artwork-cache"))
+	t.Setenv("ARTWORK_DIR", filepath.Join(tempDir, "artwork"))
 
 	cfg, err := config.Load()
 	require.NoError(t, err)
@@ -35,6 +35,8 @@ func setupAuditTestServer(t *testing.T) (*httptest.Server, func()) {
 	tempDir := t.TempDir()
 	dbPath := filepath.Join(tempDir, "sonos-hub.db")
 	t.Setenv("SQLITE_DB_PATH", dbPath)
+	t.Setenv("ARTWORK_CACHE_DIR", filepath.Join(tempDir, "artwork-cache"))
+	t.Setenv("ARTWORK_DIR", filepath.Join(tempDir, "artwork"))
 
 	cfg, err := config.Load()
 	require.NoError(t, err)
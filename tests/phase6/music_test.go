@@ -166,6 +166,61 @@ func TestMusicSetList(t *testing.T) {
 	}
 }
 
+func TestMusicSetListReorder(t *testing.T) {
+	ts, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	var setIDs []string
+	for i := 0; i < 3; i++ {
+		createPayload := map[string]any{
+			"name":             "Playlist " + string(rune('A'+i)),
+			"selection_policy": "ROTATION",
+		}
+		resp := doRequest(t, http.MethodPost, ts.URL+"/v1/music/sets", createPayload)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var createResp setResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&createResp))
+		resp.Body.Close()
+		setIDs = append(setIDs, createResp["id"].(string))
+	}
+
+	// Put the sets in creation order (default listing is newest first)
+	resp := doRequest(t, http.MethodPut, ts.URL+"/v1/music/sets/reorder", map[string]any{"set_ids": setIDs})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = doRequest(t, http.MethodGet, ts.URL+"/v1/music/sets", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var listResp listSetsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&listResp))
+	resp.Body.Close()
+
+	require.Len(t, listResp.Data, 3)
+	for i, set := range listResp.Data {
+		require.Equal(t, setIDs[i], set["id"])
+		require.Equal(t, float64(i), set["sort_order"])
+	}
+
+	// Missing a set - should return 400
+	resp = doRequest(t, http.MethodPut, ts.URL+"/v1/music/sets/reorder", map[string]any{"set_ids": setIDs[:2]})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Duplicate and unknown set IDs - should return 400
+	invalid := []string{setIDs[0], setIDs[0], "nonexistent-set"}
+	resp = doRequest(t, http.MethodPut, ts.URL+"/v1/music/sets/reorder", map[string]any{"set_ids": invalid})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var errResp map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+	resp.Body.Close()
+
+	errorData := errResp["error"].(map[string]any)
+	require.Equal(t, "VALIDATION_ERROR", errorData["code"])
+}
+
 // ==========================================================================
 // Item Management Tests
 // ==========================================================================
@@ -630,3 +685,146 @@ func TestMusicSetOperationsOnNonExistentSet(t *testing.T) {
 	require.Equal(t, http.StatusNotFound, resp.StatusCode)
 	resp.Body.Close()
 }
+
+func TestMusicSetShufflePreview(t *testing.T) {
+	ts, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	resp := doRequest(t, http.MethodPost, ts.URL+"/v1/music/sets", map[string]any{
+		"name":             "Shuffle Preview Playlist",
+		"selection_policy": "SHUFFLE",
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var createResp setResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&createResp))
+	resp.Body.Close()
+	setID := createResp["id"].(string)
+
+	// Empty set cannot be previewed
+	resp = doRequest(t, http.MethodGet, ts.URL+"/v1/music/sets/"+setID+"/shuffle-preview", nil)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	for _, favoriteID := range []string{"favorite-a", "favorite-b", "favorite-c"} {
+		resp = doRequest(t, http.MethodPost, ts.URL+"/v1/music/sets/"+setID+"/items", map[string]any{
+			"sonos_favorite_id": favoriteID,
+			"content_type":      "sonos_favorite",
+		})
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		resp.Body.Close()
+	}
+
+	// With a no-repeat window, the first picks cover every item before any repeat
+	resp = doRequest(t, http.MethodGet, ts.URL+"/v1/music/sets/"+setID+"/shuffle-preview?count=3&no_repeat_window_minutes=60", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var preview map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&preview))
+	resp.Body.Close()
+
+	require.Equal(t, "shuffle_preview", preview["object"])
+	require.Equal(t, float64(3), preview["count"])
+	items := preview["items"].([]any)
+	require.Len(t, items, 3)
+	seen := make(map[string]bool)
+	for _, item := range items {
+		seen[item.(map[string]any)["sonos_favorite_id"].(string)] = true
+	}
+	require.Len(t, seen, 3)
+
+	// Preview must not record history
+	resp = doRequest(t, http.MethodGet, ts.URL+"/v1/music/sets/"+setID+"/history", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var history listItemsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&history))
+	resp.Body.Close()
+	require.Empty(t, history.Data)
+
+	// Invalid count
+	resp = doRequest(t, http.MethodGet, ts.URL+"/v1/music/sets/"+setID+"/shuffle-preview?count=0", nil)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestMusicSetShufflePreviewRejectsRotation(t *testing.T) {
+	ts, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	resp := doRequest(t, http.MethodPost, ts.URL+"/v1/music/sets", map[string]any{
+		"name":             "Rotation Playlist",
+		"selection_policy": "ROTATION",
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var createResp setResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&createResp))
+	resp.Body.Close()
+
+	resp = doRequest(t, http.MethodGet, ts.URL+"/v1/music/sets/"+createResp["id"].(string)+"/shuffle-preview", nil)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = doRequest(t, http.MethodGet, ts.URL+"/v1/music/sets/missing-set/shuffle-preview", nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestMusicSetItemSearch(t *testing.T) {
+	ts, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	resp := doRequest(t, http.MethodPost, ts.URL+"/v1/music/sets", map[string]any{
+		"name":             "Search Playlist",
+		"selection_policy": "ROTATION",
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var createResp setResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&createResp))
+	resp.Body.Close()
+	setID := createResp["id"].(string)
+
+	items := []map[string]any{
+		{"sonos_favorite_id": "fav-jazz", "content_type": "sonos_favorite", "display_name": "Morning Jazz"},
+		{"sonos_favorite_id": "fav-album", "content_type": "sonos_favorite", "content_json": `{"title":"Blue","artist":"Joni Mitchell","album":"Blue"}`},
+		{"sonos_favorite_id": "fav-radio", "content_type": "sonos_favorite", "display_name": "Talk Radio"},
+	}
+	for _, item := range items {
+		resp = doRequest(t, http.MethodPost, ts.URL+"/v1/music/sets/"+setID+"/items", item)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		resp.Body.Close()
+	}
+
+	// Matches display_name, case-insensitive
+	resp = doRequest(t, http.MethodGet, ts.URL+"/v1/music/sets/"+setID+"/items/search?q=jazz", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var searchResp listItemsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&searchResp))
+	resp.Body.Close()
+	require.Len(t, searchResp.Data, 1)
+	require.Equal(t, "fav-jazz", searchResp.Data[0]["sonos_favorite_id"])
+	require.Equal(t, float64(0), searchResp.Data[0]["position"])
+
+	// Matches artist from content_json and reports the item's position
+	resp = doRequest(t, http.MethodGet, ts.URL+"/v1/music/sets/"+setID+"/items/search?q=mitchell", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&searchResp))
+	resp.Body.Close()
+	require.Len(t, searchResp.Data, 1)
+	require.Equal(t, "fav-album", searchResp.Data[0]["sonos_favorite_id"])
+	require.Equal(t, float64(1), searchResp.Data[0]["position"])
+
+	// No matches
+	resp = doRequest(t, http.MethodGet, ts.URL+"/v1/music/sets/"+setID+"/items/search?q=polka", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&searchResp))
+	resp.Body.Close()
+	require.Empty(t, searchResp.Data)
+
+	// Missing query
+	resp = doRequest(t, http.MethodGet, ts.URL+"/v1/music/sets/"+setID+"/items/search", nil)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Unknown set
+	resp = doRequest(t, http.MethodGet, ts.URL+"/v1/music/sets/nonexistent/items/search?q=jazz", nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+}
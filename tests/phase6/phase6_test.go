@@ -48,6 +48,8 @@ func setupTestServer(t *testing.T) (*httptest.Server, func()) {
 	tempDir := t.TempDir()
 	dbPath := filepath.Join(tempDir, "sonos-hub.db")
 	t.Setenv("SQLITE_DB_PATH", dbPath)
+	t.Setenv("ARTWORK_CACHE_DIR", filepath.Join(tempDir, "artwork-cache"))
+	t.Setenv("ARTWORK_DIR", filepath.Join(tempDir, "artwork"))
 
 	cfg, err := config.Load()
 	require.NoError(t, err)
@@ -182,6 +184,67 @@ func TestSceneNotFound(t *testing.T) {
 	require.Equal(t, "SCENE_NOT_FOUND", errorData["code"])
 }
 
+func TestSceneAdjustVolumes(t *testing.T) {
+	ts, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	resp := doRequest(t, http.MethodPost, ts.URL+"/v1/scenes", map[string]any{
+		"name": "Whole House",
+		"members": []map[string]any{
+			{"udn": "RINCON_TEST123456789", "target_volume": 40},
+			{"udn": "RINCON_TEST987654321", "target_volume": 95},
+			{"udn": "RINCON_TEST555555555"},
+		},
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created sceneResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	sceneID := created["id"].(string)
+
+	targetVolumes := func(scene sceneResponse) []any {
+		volumes := []any{}
+		for _, member := range scene["members"].([]any) {
+			volumes = append(volumes, member.(map[string]any)["target_volume"])
+		}
+		return volumes
+	}
+
+	// Scale down by 20%
+	resp = doRequest(t, http.MethodPost, ts.URL+"/v1/scenes/"+sceneID+"/adjust-volumes", map[string]any{"scale": 0.8})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var scaled sceneResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&scaled))
+	resp.Body.Close()
+	require.Equal(t, "scene", scaled["object"])
+	require.Equal(t, []any{float64(32), float64(76), nil}, targetVolumes(scaled))
+
+	// Delta is clamped to 100
+	resp = doRequest(t, http.MethodPost, ts.URL+"/v1/scenes/"+sceneID+"/adjust-volumes", map[string]any{"delta": 30})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var raised sceneResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&raised))
+	resp.Body.Close()
+	require.Equal(t, []any{float64(62), float64(100), nil}, targetVolumes(raised))
+
+	// Persisted
+	resp = doRequest(t, http.MethodGet, ts.URL+"/v1/scenes/"+sceneID, nil)
+	var fetched sceneResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&fetched))
+	resp.Body.Close()
+	require.Equal(t, []any{float64(62), float64(100), nil}, targetVolumes(fetched))
+
+	for _, body := range []map[string]any{{}, {"delta": 5, "scale": 1.5}, {"delta": 150}, {"scale": -1}} {
+		resp = doRequest(t, http.MethodPost, ts.URL+"/v1/scenes/"+sceneID+"/adjust-volumes", body)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+		resp.Body.Close()
+	}
+
+	resp = doRequest(t, http.MethodPost, ts.URL+"/v1/scenes/missing/adjust-volumes", map[string]any{"delta": 5})
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+}
+
 func TestSceneValidation(t *testing.T) {
 	ts, cleanup := setupTestServer(t)
 	defer cleanup()
@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/strefethen/sonos-hub-go/internal/config"
+	"github.com/strefethen/sonos-hub-go/internal/scheduler"
 	"github.com/strefethen/sonos-hub-go/internal/server"
 )
 
@@ -73,6 +74,8 @@ func setupSchedulerTestServer(t *testing.T) (*httptest.Server, func()) {
 	tempDir := t.TempDir()
 	dbPath := filepath.Join(tempDir, "sonos-hub.db")
 	t.Setenv("SQLITE_DB_PATH", dbPath)
+	t.Setenv("ARTWORK_CACHE_DIR", filepath.Join(tempDir, "artwork-cache"))
+	t.Setenv("ARTWORK_DIR", filepath.Join(tempDir, "artwork"))
 
 	cfg, err := config.Load()
 	require.NoError(t, err)
@@ -379,6 +382,58 @@ func TestSnoozeUnsnoozeRoutine(t *testing.T) {
 	require.Nil(t, unsnoozeResp["snooze_until"])
 }
 
+func TestSnoozeRoutineByDurationAndPreset(t *testing.T) {
+	ts, cleanup := setupSchedulerTestServer(t)
+	defer cleanup()
+
+	sceneID := createTestScene(t, ts)
+
+	resp := doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", map[string]any{
+		"name":     "Daily Routine",
+		"scene_id": sceneID,
+		"timezone": "America/New_York",
+		"schedule": map[string]any{"type": "weekly", "weekdays": []int{0, 1, 2, 3, 4, 5, 6}, "time": "07:30"},
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created routineResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	snoozeURL := ts.URL + "/v1/routines/" + created["id"].(string) + "/snooze"
+
+	snooze := func(body map[string]any) time.Time {
+		t.Helper()
+		resp := doSchedulerRequest(t, http.MethodPost, snoozeURL, body)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var routine routineResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&routine))
+		resp.Body.Close()
+		until, err := time.Parse(time.RFC3339, routine["snooze_until"].(string))
+		require.NoError(t, err)
+		return until
+	}
+
+	until := snooze(map[string]any{"duration_minutes": 90})
+	require.WithinDuration(t, time.Now().Add(90*time.Minute), until, time.Minute)
+
+	// The daily routine resumes at the start of tomorrow in its own timezone
+	ny, _ := time.LoadLocation("America/New_York")
+	today := time.Now().In(ny)
+	until = snooze(map[string]any{"preset": "until_tomorrow"})
+	require.Equal(t, time.Date(today.Year(), today.Month(), today.Day()+1, 0, 0, 0, 0, ny).UTC(), until.UTC())
+
+	for _, body := range []map[string]any{
+		{},
+		{"duration_minutes": 90, "preset": "until_tomorrow"},
+		{"duration_minutes": 90, "until": time.Now().Add(time.Hour).UTC().Format(time.RFC3339)},
+		{"duration_minutes": 0},
+		{"preset": "next_week"},
+	} {
+		resp = doSchedulerRequest(t, http.MethodPost, snoozeURL, body)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "body %v", body)
+		resp.Body.Close()
+	}
+}
+
 func TestSkipNextRoutine(t *testing.T) {
 	ts, cleanup := setupSchedulerTestServer(t)
 	defer cleanup()
@@ -450,6 +505,16 @@ func TestTriggerRoutine(t *testing.T) {
 	require.NotEmpty(t, triggerResp["id"])
 	require.Equal(t, routineID, triggerResp["routine_id"])
 	require.Equal(t, "PENDING", triggerResp["status"])
+
+	// Triggering again within the cooldown window is rejected, for both trigger and run
+	resp = doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines/"+routineID+"/trigger", nil)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.NotEmpty(t, resp.Header.Get("Retry-After"))
+	resp.Body.Close()
+
+	resp = doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines/"+routineID+"/run", nil)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	resp.Body.Close()
 }
 
 // ==========================================================================
@@ -678,6 +743,75 @@ func TestCheckIfDateIsHoliday(t *testing.T) {
 	require.Equal(t, false, checkResp["is_holiday"])
 }
 
+func TestCheckRecurringHoliday(t *testing.T) {
+	ts, cleanup := setupSchedulerTestServer(t)
+	defer cleanup()
+
+	resp := doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/holidays", map[string]any{
+		"name":      "Christmas",
+		"date":      "2024-12-25",
+		"recurring": true,
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created holidayResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	require.Equal(t, true, created["recurring"])
+
+	resp = doSchedulerRequest(t, http.MethodGet, ts.URL+"/v1/holidays/check?date=2025-12-25", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var checkResp holidayCheckResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&checkResp))
+	resp.Body.Close()
+
+	require.Equal(t, true, checkResp["is_holiday"])
+	holiday := checkResp["holiday"].(map[string]any)
+	require.Equal(t, "2024-12-25", holiday["id"])
+	require.Equal(t, "2025-12-25", holiday["date"])
+}
+
+func TestImportHolidays(t *testing.T) {
+	ts, cleanup := setupSchedulerTestServer(t)
+	defer cleanup()
+
+	importYear := func() map[string]any {
+		t.Helper()
+		resp := doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/holidays/import", map[string]any{"country": "US", "year": 2025})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var result map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		resp.Body.Close()
+		return result
+	}
+
+	result := importYear()
+	require.Equal(t, "holiday_import", result["object"])
+	require.Equal(t, float64(11), result["created_count"])
+	require.Equal(t, float64(0), result["skipped_count"])
+
+	// Importing again is a no-op
+	result = importYear()
+	require.Equal(t, float64(0), result["created_count"])
+	require.Equal(t, float64(11), result["skipped_count"])
+
+	resp := doSchedulerRequest(t, http.MethodGet, ts.URL+"/v1/holidays/check?date=2025-11-27", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var checkResp holidayCheckResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&checkResp))
+	resp.Body.Close()
+	require.Equal(t, true, checkResp["is_holiday"])
+
+	for _, body := range []map[string]any{
+		{"year": 2025},
+		{"country": "XX", "year": 2025},
+		{"country": "US"},
+	} {
+		resp = doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/holidays/import", body)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "body %v", body)
+		resp.Body.Close()
+	}
+}
+
 // ==========================================================================
 // Error Cases Tests
 // ==========================================================================
@@ -833,3 +967,978 @@ func TestListJobsForNonExistentRoutine(t *testing.T) {
 
 	require.Equal(t, "ROUTINE_NOT_FOUND", errResp.Error["code"])
 }
+
+func TestRoutineScheduleRoundTrip(t *testing.T) {
+	ts, cleanup := setupSchedulerTestServer(t)
+	defer cleanup()
+
+	sceneID := createTestScene(t, ts)
+
+	month := 12
+	day := 25
+	cases := []struct {
+		name     string
+		schedule map[string]any
+		expected map[string]any
+	}{
+		{
+			name:     "weekly",
+			schedule: map[string]any{"type": "weekly", "weekdays": []int{1, 3, 5}, "time": "07:30"},
+			expected: map[string]any{"type": "weekly", "weekdays": []any{float64(1), float64(3), float64(5)}, "time": "07:30", "month": nil, "day": nil},
+		},
+		{
+			name:     "monthly",
+			schedule: map[string]any{"type": "monthly", "day": day, "time": "09:00"},
+			expected: map[string]any{"type": "monthly", "weekdays": []any{}, "time": "09:00", "month": nil, "day": float64(day)},
+		},
+		{
+			name:     "yearly",
+			schedule: map[string]any{"type": "yearly", "month": month, "day": day, "time": "08:15"},
+			expected: map[string]any{"type": "yearly", "weekdays": []any{}, "time": "08:15", "month": float64(month), "day": float64(day)},
+		},
+		{
+			name:     "once",
+			schedule: map[string]any{"type": "once", "month": month, "day": day, "time": "06:45"},
+			expected: map[string]any{"type": "once", "weekdays": []any{}, "time": "06:45", "month": float64(month), "day": float64(day)},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", map[string]any{
+				"name":             "Round Trip " + tc.name,
+				"scene_id":         sceneID,
+				"timezone":         "America/Chicago",
+				"holiday_behavior": "DELAY",
+				"schedule":         tc.schedule,
+			})
+			require.Equal(t, http.StatusCreated, resp.StatusCode)
+			var created routineResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+			resp.Body.Close()
+			routineID := created["id"].(string)
+
+			resp = doSchedulerRequest(t, http.MethodGet, ts.URL+"/v1/routines/"+routineID+"/schedule", nil)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			var schedule map[string]any
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&schedule))
+			resp.Body.Close()
+
+			require.Equal(t, "routine_schedule", schedule["object"])
+			require.Equal(t, routineID, schedule["routine_id"])
+			require.Equal(t, "America/Chicago", schedule["timezone"])
+			require.Equal(t, "DELAY", schedule["holiday_behavior"])
+			for key, want := range tc.expected {
+				require.Equal(t, want, schedule[key], key)
+			}
+		})
+	}
+
+	resp := doSchedulerRequest(t, http.MethodGet, ts.URL+"/v1/routines/missing/schedule", nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestHolidayImpact(t *testing.T) {
+	ts, cleanup := setupSchedulerTestServer(t)
+	defer cleanup()
+
+	sceneID := createTestScene(t, ts)
+
+	createRoutine := func(name string, body map[string]any) string {
+		body["name"] = name
+		body["scene_id"] = sceneID
+		body["timezone"] = "UTC"
+		body["schedule_time"] = "07:00"
+		resp := doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", body)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var created routineResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+		resp.Body.Close()
+		return created["id"].(string)
+	}
+
+	// 2025-12-25 is a Thursday
+	skippedWeekly := createRoutine("Weekday Wakeup", map[string]any{"schedule_type": "weekly", "schedule_weekdays": []int{1, 2, 3, 4, 5}, "holiday_behavior": "SKIP"})
+	skippedYearly := createRoutine("Christmas Music", map[string]any{"schedule_type": "yearly", "schedule_month": 12, "schedule_day": 25, "holiday_behavior": "SKIP"})
+	createRoutine("Weekend Only", map[string]any{"schedule_type": "weekly", "schedule_weekdays": []int{0, 6}, "holiday_behavior": "SKIP"})
+	createRoutine("Delayed", map[string]any{"schedule_type": "weekly", "schedule_weekdays": []int{4}, "holiday_behavior": "DELAY"})
+	createRoutine("Disabled", map[string]any{"schedule_type": "weekly", "schedule_weekdays": []int{4}, "holiday_behavior": "SKIP", "enabled": false})
+
+	resp := doSchedulerRequest(t, http.MethodGet, ts.URL+"/v1/holidays/impact?date=2025-12-25", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var impact map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&impact))
+	resp.Body.Close()
+
+	require.Equal(t, "holiday_impact", impact["object"])
+	require.Equal(t, "2025-12-25", impact["date"])
+	require.Equal(t, float64(2), impact["total_skipped"])
+
+	ids := []string{}
+	for _, item := range impact["skipped_routines"].([]any) {
+		ids = append(ids, item.(map[string]any)["id"].(string))
+	}
+	require.ElementsMatch(t, []string{skippedWeekly, skippedYearly}, ids)
+
+	resp = doSchedulerRequest(t, http.MethodGet, ts.URL+"/v1/holidays/impact?date=12-25", nil)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = doSchedulerRequest(t, http.MethodGet, ts.URL+"/v1/holidays/impact", nil)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestRoutineInvalidLocalTimeZone(t *testing.T) {
+	ts, cleanup := setupSchedulerTestServer(t)
+	defer cleanup()
+
+	resp := doSchedulerRequest(t, http.MethodGet, ts.URL+"/v1/routines?tz=Not/AZone", nil)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = doSchedulerRequest(t, http.MethodGet, ts.URL+"/v1/routines?tz=routine", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestRoutineMissedRunPolicy(t *testing.T) {
+	ts, cleanup := setupSchedulerTestServer(t)
+	defer cleanup()
+
+	sceneID := createTestScene(t, ts)
+	basePayload := func() map[string]any {
+		return map[string]any{
+			"name":              "Catch Up",
+			"scene_id":          sceneID,
+			"timezone":          "UTC",
+			"schedule_type":     "weekly",
+			"schedule_weekdays": []int{1},
+			"schedule_time":     "07:30",
+		}
+	}
+
+	// Defaults to skip
+	resp := doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", basePayload())
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created routineResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	require.Equal(t, "skip", created["missed_run_policy"])
+	require.Nil(t, created["missed_run_within_minutes"])
+
+	// Unknown policy is rejected
+	payload := basePayload()
+	payload["missed_run_policy"] = "whenever"
+	resp = doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", payload)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// run_if_within_minutes requires a window
+	payload = basePayload()
+	payload["missed_run_policy"] = "run_if_within_minutes"
+	resp = doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", payload)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Update to a windowed policy
+	routineID := created["id"].(string)
+	resp = doSchedulerRequest(t, http.MethodPut, ts.URL+"/v1/routines/"+routineID, map[string]any{
+		"missed_run_policy":         "run_if_within_minutes",
+		"missed_run_within_minutes": 45,
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var updated routineResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&updated))
+	resp.Body.Close()
+	require.Equal(t, "run_if_within_minutes", updated["missed_run_policy"])
+	require.Equal(t, float64(45), updated["missed_run_within_minutes"])
+}
+
+func TestGetJobLog(t *testing.T) {
+	ts, cleanup := setupSchedulerTestServer(t)
+	defer cleanup()
+
+	sceneID := createTestScene(t, ts)
+	resp := doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", map[string]any{
+		"name":              "Logged Routine",
+		"scene_id":          sceneID,
+		"timezone":          "UTC",
+		"schedule_type":     "weekly",
+		"schedule_weekdays": []int{1},
+		"schedule_time":     "07:30",
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var createResp routineResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&createResp))
+	resp.Body.Close()
+
+	resp = doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines/"+createResp["id"].(string)+"/trigger", nil)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	var triggerResp jobResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&triggerResp))
+	resp.Body.Close()
+	jobID := triggerResp["id"].(string)
+
+	resp = doSchedulerRequest(t, http.MethodGet, ts.URL+"/v1/jobs/"+jobID+"/log", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var logResp map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&logResp))
+	resp.Body.Close()
+
+	require.Equal(t, "job_log", logResp["object"])
+	require.Equal(t, jobID, logResp["job_id"])
+	require.IsType(t, []any{}, logResp["steps"])
+
+	resp = doSchedulerRequest(t, http.MethodGet, ts.URL+"/v1/jobs/nonexistent/log", nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestRoutineIntervalSchedule(t *testing.T) {
+	ts, cleanup := setupSchedulerTestServer(t)
+	defer cleanup()
+
+	sceneID := createTestScene(t, ts)
+	payload := func(schedule map[string]any) map[string]any {
+		return map[string]any{
+			"name":     "Water Plants",
+			"scene_id": sceneID,
+			"timezone": "America/New_York",
+			"schedule": schedule,
+		}
+	}
+
+	resp := doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", payload(map[string]any{
+		"type":          "INTERVAL",
+		"time":          "08:00",
+		"interval_days": 3,
+		"anchor_date":   "2025-03-01",
+	}))
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created routineResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+
+	schedule := created["schedule"].(map[string]any)
+	require.Equal(t, "INTERVAL", schedule["type"])
+	require.Equal(t, float64(3), schedule["interval_days"])
+	require.Equal(t, "2025-03-01", schedule["anchor_date"])
+
+	// Interval below 1 is rejected
+	resp = doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", payload(map[string]any{
+		"type":          "INTERVAL",
+		"time":          "08:00",
+		"interval_days": 0,
+		"anchor_date":   "2025-03-01",
+	}))
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Missing anchor date is rejected
+	resp = doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", payload(map[string]any{
+		"type":          "INTERVAL",
+		"time":          "08:00",
+		"interval_days": 3,
+	}))
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Updating the interval keeps the existing anchor date
+	routineID := created["id"].(string)
+	resp = doSchedulerRequest(t, http.MethodPut, ts.URL+"/v1/routines/"+routineID, map[string]any{
+		"schedule": map[string]any{"interval_days": 5},
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var updated routineResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&updated))
+	resp.Body.Close()
+	require.Equal(t, float64(5), updated["schedule"].(map[string]any)["interval_days"])
+
+	resp = doSchedulerRequest(t, http.MethodPut, ts.URL+"/v1/routines/"+routineID, map[string]any{
+		"schedule": map[string]any{"interval_days": 0},
+	})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestRoutineDurationMinutes(t *testing.T) {
+	ts, cleanup := setupSchedulerTestServer(t)
+	defer cleanup()
+
+	sceneID := createTestScene(t, ts)
+
+	resp := doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", map[string]any{
+		"name":     "Bedtime Wind-Down",
+		"scene_id": sceneID,
+		"timezone": "America/New_York",
+		"schedule": map[string]any{
+			"type":             "weekly",
+			"weekdays":         []int{0, 1, 2, 3, 4},
+			"time":             "19:30",
+			"duration_minutes": 45,
+		},
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created routineResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	require.Equal(t, float64(45), created["schedule"].(map[string]any)["duration_minutes"])
+
+	routineID := created["id"].(string)
+
+	// Top-level field is accepted too
+	resp = doSchedulerRequest(t, http.MethodPut, ts.URL+"/v1/routines/"+routineID, map[string]any{
+		"duration_minutes": 60,
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var updated routineResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&updated))
+	resp.Body.Close()
+	require.Equal(t, float64(60), updated["schedule"].(map[string]any)["duration_minutes"])
+
+	resp = doSchedulerRequest(t, http.MethodPut, ts.URL+"/v1/routines/"+routineID, map[string]any{
+		"duration_minutes": 24*60 + 1,
+	})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Zero clears the duration
+	resp = doSchedulerRequest(t, http.MethodPut, ts.URL+"/v1/routines/"+routineID, map[string]any{
+		"duration_minutes": 0,
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var cleared routineResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&cleared))
+	resp.Body.Close()
+	require.NotContains(t, cleared["schedule"].(map[string]any), "duration_minutes")
+
+	resp = doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", map[string]any{
+		"name":             "Bad Duration",
+		"scene_id":         sceneID,
+		"timezone":         "America/New_York",
+		"duration_minutes": 0,
+		"schedule":         map[string]any{"type": "weekly", "weekdays": []int{1}, "time": "07:00"},
+	})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestRoutineRetryPolicy(t *testing.T) {
+	ts, cleanup := setupSchedulerTestServer(t)
+	defer cleanup()
+
+	sceneID := createTestScene(t, ts)
+
+	resp := doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", map[string]any{
+		"name":     "Morning Radio",
+		"scene_id": sceneID,
+		"timezone": "America/New_York",
+		"schedule": map[string]any{"type": "weekly", "weekdays": []int{1}, "time": "07:00"},
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created routineResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	require.Nil(t, created["max_attempts"], "unset, so the runner's limit applies")
+	require.Equal(t, float64(2), created["retry_backoff_seconds"])
+
+	routineID := created["id"].(string)
+
+	resp = doSchedulerRequest(t, http.MethodPut, ts.URL+"/v1/routines/"+routineID, map[string]any{
+		"max_attempts":          5,
+		"retry_backoff_seconds": 60,
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var updated routineResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&updated))
+	resp.Body.Close()
+	require.Equal(t, float64(5), updated["max_attempts"])
+	require.Equal(t, float64(60), updated["retry_backoff_seconds"])
+
+	// Jobs carry the routine's retry policy
+	resp = doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines/"+routineID+"/trigger", nil)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	var job map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&job))
+	resp.Body.Close()
+	require.Equal(t, float64(5), job["max_attempts"])
+	require.Equal(t, float64(60), job["retry_backoff_seconds"])
+
+	for _, body := range []map[string]any{
+		{"max_attempts": 0},
+		{"max_attempts": 11},
+		{"retry_backoff_seconds": 0},
+		{"retry_backoff_seconds": 3601},
+	} {
+		resp = doSchedulerRequest(t, http.MethodPut, ts.URL+"/v1/routines/"+routineID, body)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "body %v", body)
+		resp.Body.Close()
+	}
+}
+
+func TestRoutineSolarSchedule(t *testing.T) {
+	t.Setenv("HUB_LATITUDE", "40.7128")
+	t.Setenv("HUB_LONGITUDE", "-74.0060")
+	ts, cleanup := setupSchedulerTestServer(t)
+	defer cleanup()
+
+	sceneID := createTestScene(t, ts)
+	payload := func(schedule map[string]any) map[string]any {
+		return map[string]any{
+			"name":     "Sunrise Wake",
+			"scene_id": sceneID,
+			"timezone": "America/New_York",
+			"schedule": schedule,
+		}
+	}
+
+	resp := doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", payload(map[string]any{
+		"type":           "weekly",
+		"weekdays":       []int{0, 1, 2, 3, 4, 5, 6},
+		"time":           "07:00",
+		"time_mode":      "sunrise",
+		"offset_minutes": 15,
+	}))
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created routineResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+
+	schedule := created["schedule"].(map[string]any)
+	require.Equal(t, "sunrise", schedule["time_mode"])
+	require.Equal(t, float64(15), schedule["offset_minutes"])
+
+	// next_run_at is the computed sunrise time, not the 07:00 fallback
+	nextRunStr, ok := created["next_run_at"].(string)
+	require.True(t, ok, "next_run_at should be present")
+	nextRun, err := time.Parse(time.RFC3339, nextRunStr)
+	require.NoError(t, err)
+	require.True(t, nextRun.After(time.Now()))
+	ny, _ := time.LoadLocation("America/New_York")
+	sunrise, ok := scheduler.SolarEventTime(nextRun.In(ny), scheduler.TimeModeSunrise, scheduler.Coordinates{Latitude: 40.7128, Longitude: -74.0060}, ny)
+	require.True(t, ok)
+	require.True(t, sunrise.Add(15*time.Minute).Equal(nextRun), "next_run_at %s should be 15 minutes after sunrise %s", nextRun, sunrise)
+
+	routineID := created["id"].(string)
+	resp = doSchedulerRequest(t, http.MethodGet, ts.URL+"/v1/routines/"+routineID+"/schedule", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var normalized map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&normalized))
+	resp.Body.Close()
+	require.Equal(t, "sunrise", normalized["time_mode"])
+	require.Equal(t, float64(15), normalized["offset_minutes"])
+
+	resp = doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", payload(map[string]any{
+		"type": "weekly", "weekdays": []int{1}, "time": "07:00", "time_mode": "moonrise",
+	}))
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = doSchedulerRequest(t, http.MethodPut, ts.URL+"/v1/routines/"+routineID, map[string]any{
+		"schedule": map[string]any{"offset_minutes": 500},
+	})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestListExecutionsFilters(t *testing.T) {
+	ts, cleanup := setupSchedulerTestServer(t)
+	defer cleanup()
+
+	sceneID := createTestScene(t, ts)
+
+	routineIDs := make([]string, 0, 2)
+	for _, name := range []string{"Morning", "Evening"} {
+		resp := doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", map[string]any{
+			"name":     name,
+			"scene_id": sceneID,
+			"timezone": "UTC",
+			"schedule": map[string]any{"type": "weekly", "weekdays": []int{1}, "time": "07:00"},
+		})
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var created routineResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+		resp.Body.Close()
+		routineIDs = append(routineIDs, created["id"].(string))
+
+		resp = doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines/"+created["id"].(string)+"/trigger", nil)
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+		resp.Body.Close()
+	}
+
+	listExecutions := func(query string) listJobsResponse {
+		t.Helper()
+		resp := doSchedulerRequest(t, http.MethodGet, ts.URL+"/v1/executions?"+query, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var list listJobsResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+		resp.Body.Close()
+		return list
+	}
+
+	list := listExecutions("routine_id=" + routineIDs[0])
+	require.Len(t, list.Data, 1)
+	require.Equal(t, routineIDs[0], list.Data[0]["routine_id"])
+
+	today := time.Now().UTC().Format("2006-01-02")
+	list = listExecutions("from=" + today + "&to=" + today)
+	require.Len(t, list.Data, 2)
+
+	list = listExecutions("to=2020-01-01T00:00:00Z")
+	require.Empty(t, list.Data)
+
+	// Cursor pagination
+	first := listExecutions("limit=1")
+	require.Len(t, first.Data, 1)
+	require.True(t, first.HasMore)
+	second := listExecutions("limit=1&starting_after=" + first.Data[0]["id"].(string))
+	require.Len(t, second.Data, 1)
+	require.False(t, second.HasMore)
+	require.NotEqual(t, first.Data[0]["id"], second.Data[0]["id"])
+
+	for _, query := range []string{
+		"from=yesterday",
+		"from=2025-06-02&to=2025-06-01",
+		"starting_after=no-such-job",
+	} {
+		resp := doSchedulerRequest(t, http.MethodGet, ts.URL+"/v1/executions?"+query, nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "query %s", query)
+		resp.Body.Close()
+	}
+}
+
+func TestRoutineHolidayPlayAlternate(t *testing.T) {
+	ts, cleanup := setupSchedulerTestServer(t)
+	defer cleanup()
+
+	sceneID := createTestScene(t, ts)
+
+	resp := doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/music/sets", map[string]any{
+		"name":             "Christmas Music",
+		"selection_policy": "SHUFFLE",
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var set map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&set))
+	resp.Body.Close()
+	setID := set["id"].(string)
+
+	routine := map[string]any{
+		"name":             "Morning Radio",
+		"scene_id":         sceneID,
+		"timezone":         "America/New_York",
+		"schedule":         map[string]any{"type": "weekly", "weekdays": []int{1}, "time": "07:00"},
+		"holiday_behavior": "PLAY_ALTERNATE",
+	}
+
+	// The holiday set is required and must exist
+	resp = doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", routine)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	routine["holiday_music_set_id"] = "no-such-set"
+	resp = doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", routine)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+
+	routine["holiday_music_set_id"] = setID
+	resp = doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", routine)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created routineResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	require.Equal(t, "PLAY_ALTERNATE", created["holiday_behavior"])
+	require.Equal(t, setID, created["holiday_music_set_id"])
+
+	// Clearing the set while the behavior still needs it is rejected
+	resp = doSchedulerRequest(t, http.MethodPut, ts.URL+"/v1/routines/"+created["id"].(string), map[string]any{
+		"holiday_music_set_id": "",
+	})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = doSchedulerRequest(t, http.MethodPut, ts.URL+"/v1/routines/"+created["id"].(string), map[string]any{
+		"holiday_behavior":     "SKIP",
+		"holiday_music_set_id": "",
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var updated routineResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&updated))
+	resp.Body.Close()
+	require.Nil(t, updated["holiday_music_set_id"])
+}
+
+func TestCreateRoutineIdempotencyKey(t *testing.T) {
+	ts, cleanup := setupSchedulerTestServer(t)
+	defer cleanup()
+
+	sceneID := createTestScene(t, ts)
+
+	create := func(key, name string) (*http.Response, routineResponse) {
+		t.Helper()
+		payload, err := json.Marshal(map[string]any{
+			"name":     name,
+			"scene_id": sceneID,
+			"timezone": "America/Los_Angeles",
+			"schedule": map[string]any{"type": "weekly", "weekdays": []int{1}, "time": "07:30"},
+		})
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/v1/routines", bytes.NewReader(payload))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-Mode", "true")
+		req.Header.Set("Idempotency-Key", key)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var routine routineResponse
+		if resp.StatusCode == http.StatusCreated {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&routine))
+		}
+		return resp, routine
+	}
+
+	resp, first := create("create-1", "Morning")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	// A retry replays the original response instead of creating a second routine
+	resp, retry := create("create-1", "Morning")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, "true", resp.Header.Get("Idempotent-Replayed"))
+	require.Equal(t, first["id"], retry["id"])
+
+	resp, _ = create("create-1", "Evening")
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp = doSchedulerRequest(t, http.MethodGet, ts.URL+"/v1/routines", nil)
+	defer resp.Body.Close()
+	var list struct {
+		Data []routineResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Len(t, list.Data, 1)
+}
+
+func TestTriggerIdempotencyKeyAcrossCooldown(t *testing.T) {
+	t.Setenv("ROUTINE_TRIGGER_COOLDOWN_SECONDS", "1")
+	ts, cleanup := setupSchedulerTestServer(t)
+	defer cleanup()
+
+	sceneID := createTestScene(t, ts)
+
+	resp := doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", map[string]any{
+		"name":     "Cooldown",
+		"scene_id": sceneID,
+		"timezone": "America/New_York",
+		"schedule": map[string]any{"type": "weekly", "weekdays": []int{1}, "time": "07:30"},
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var routine routineResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&routine))
+	resp.Body.Close()
+	triggerURL := ts.URL + "/v1/routines/" + routine["id"].(string) + "/trigger"
+
+	trigger := func(key string) (*http.Response, jobResponse) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, triggerURL, nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Test-Mode", "true")
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var job jobResponse
+		if resp.StatusCode == http.StatusAccepted {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&job))
+		}
+		return resp, job
+	}
+
+	// Another client's trigger starts the cooldown
+	resp, first := trigger("")
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	resp, _ = trigger("trigger-1")
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.NotEmpty(t, resp.Header.Get("Retry-After"))
+
+	// Retrying the same key once the cooldown has passed runs the routine rather
+	// than replaying the 429
+	time.Sleep(1100 * time.Millisecond)
+	resp, retry := trigger("trigger-1")
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	require.Empty(t, resp.Header.Get("Idempotent-Replayed"))
+	require.NotEmpty(t, retry["id"])
+	require.NotEqual(t, first["id"], retry["id"])
+
+	// From then on the key replays the job it created
+	resp, replay := trigger("trigger-1")
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	require.Equal(t, "true", resp.Header.Get("Idempotent-Replayed"))
+	require.Equal(t, retry["id"], replay["id"])
+
+	resp = doSchedulerRequest(t, http.MethodGet, ts.URL+"/v1/routines/"+routine["id"].(string)+"/jobs", nil)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var jobs listJobsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&jobs))
+	var ids []any
+	for _, job := range jobs.Data {
+		ids = append(ids, job["id"])
+	}
+	require.Contains(t, ids, first["id"])
+	require.Contains(t, ids, retry["id"])
+}
+
+func TestRoutineScheduleValidation(t *testing.T) {
+	ts, cleanup := setupSchedulerTestServer(t)
+	defer cleanup()
+
+	sceneID := createTestScene(t, ts)
+
+	for _, body := range []map[string]any{
+		{"timezone": "America/New_York", "schedule": map[string]any{"type": "weekly", "weekdays": []int{1}, "time": "25:99"}},
+		{"timezone": "America/New_York", "schedule": map[string]any{"type": "weekly", "weekdays": []int{1}, "time": "7:30"}},
+		{"timezone": "America/New_York", "schedule": map[string]any{"type": "weekly", "weekdays": []int{0, 9}, "time": "07:30"}},
+		{"timezone": "America/New_York", "schedule": map[string]any{"type": "yearly", "month": 13, "day": 1, "time": "07:30"}},
+		{"timezone": "America/New_York", "schedule": map[string]any{"type": "yearly", "month": 2, "day": 30, "time": "07:30"}},
+		{"timezone": "America/New_York", "schedule": map[string]any{"type": "monthly", "day": 32, "time": "07:30"}},
+		{"timezone": "America/Los_Angels", "schedule": map[string]any{"type": "weekly", "weekdays": []int{1}, "time": "07:30"}},
+	} {
+		body["name"] = "Invalid"
+		body["scene_id"] = sceneID
+		resp := doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", body)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "body %v", body)
+		resp.Body.Close()
+	}
+
+	resp := doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", map[string]any{
+		"name":     "Leap Day",
+		"scene_id": sceneID,
+		"timezone": "America/New_York",
+		"schedule": map[string]any{"type": "yearly", "month": 2, "day": 29, "time": "07:30"},
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created routineResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	routineURL := ts.URL + "/v1/routines/" + created["id"].(string)
+
+	for _, body := range []map[string]any{
+		{"schedule": map[string]any{"month": 4, "day": 31}},
+		// Checked against the stored month (February)
+		{"schedule_day": 31},
+		{"timezone": "Mars/Olympus_Mons"},
+		{"schedule": map[string]any{"time": "24:00"}},
+	} {
+		resp = doSchedulerRequest(t, http.MethodPut, routineURL, body)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "body %v", body)
+		resp.Body.Close()
+	}
+
+	resp = doSchedulerRequest(t, http.MethodPut, routineURL, map[string]any{"schedule": map[string]any{"month": 3, "day": 31, "time": "23:59"}})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestListRoutineOccurrences(t *testing.T) {
+	ts, cleanup := setupSchedulerTestServer(t)
+	defer cleanup()
+
+	sceneID := createTestScene(t, ts)
+
+	resp := doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", map[string]any{
+		"name":     "Daily Routine",
+		"scene_id": sceneID,
+		"timezone": "America/New_York",
+		"schedule": map[string]any{"type": "weekly", "weekdays": []int{0, 1, 2, 3, 4, 5, 6}, "time": "07:30"},
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created routineResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	routineURL := ts.URL + "/v1/routines/" + created["id"].(string)
+
+	resp = doSchedulerRequest(t, http.MethodPost, routineURL+"/skip", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = doSchedulerRequest(t, http.MethodGet, routineURL+"/occurrences?count=3", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list struct {
+		Object string           `json:"object"`
+		Data   []map[string]any `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	resp.Body.Close()
+	require.Equal(t, "list", list.Object)
+	require.Len(t, list.Data, 3)
+
+	require.Equal(t, "routine_occurrence", list.Data[0]["object"])
+	require.Equal(t, false, list.Data[0]["will_run"])
+	require.Equal(t, "skip_next", list.Data[0]["skip_reason"])
+	require.Equal(t, true, list.Data[1]["will_run"])
+	require.Nil(t, list.Data[1]["skip_reason"])
+
+	// Timestamps are in the routine's timezone
+	ny, _ := time.LoadLocation("America/New_York")
+	first, err := time.Parse(time.RFC3339, list.Data[0]["scheduled_for"].(string))
+	require.NoError(t, err)
+	require.Equal(t, "07:30", first.In(ny).Format("15:04"))
+	_, offset := first.Zone()
+	_, nyOffset := first.In(ny).Zone()
+	require.Equal(t, nyOffset, offset)
+
+	for _, count := range []string{"0", "101", "abc"} {
+		resp = doSchedulerRequest(t, http.MethodGet, routineURL+"/occurrences?count="+count, nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "count %s", count)
+		resp.Body.Close()
+	}
+
+	resp = doSchedulerRequest(t, http.MethodGet, ts.URL+"/v1/routines/missing/occurrences", nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestRoutineSpeakerFadeIn(t *testing.T) {
+	ts, cleanup := setupSchedulerTestServer(t)
+	defer cleanup()
+
+	resp := doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", map[string]any{
+		"name":     "Wake Up",
+		"timezone": "America/New_York",
+		"schedule": map[string]any{"type": "weekly", "weekdays": []int{1, 2, 3, 4, 5}, "time": "06:45"},
+		"speakers": []map[string]any{
+			{"udn": "RINCON_BEDROOM", "volume": 25, "fade_in_ms": 60000, "fade_curve": "ease-in"},
+			{"udn": "RINCON_BATH", "volume": 15},
+		},
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created routineResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+
+	speakers := created["speakers"].([]any)
+	require.Len(t, speakers, 2)
+	bedroom := speakers[0].(map[string]any)
+	require.Equal(t, float64(60000), bedroom["fade_in_ms"])
+	require.Equal(t, "ease-in", bedroom["fade_curve"])
+	require.NotContains(t, speakers[1].(map[string]any), "fade_in_ms")
+
+	// The auto-created scene carries the fade for execution
+	resp = doSchedulerRequest(t, http.MethodGet, ts.URL+"/v1/scenes/"+created["scene_id"].(string), nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var sceneBody map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&sceneBody))
+	resp.Body.Close()
+	member := sceneBody["members"].([]any)[0].(map[string]any)
+	require.Equal(t, float64(60000), member["fade_in_ms"])
+	require.Equal(t, "ease-in", member["fade_curve"])
+
+	for _, speaker := range []map[string]any{
+		{"udn": "RINCON_BEDROOM", "volume": 25, "fade_in_ms": 60000, "fade_curve": "bounce"},
+		{"udn": "RINCON_BEDROOM", "volume": 25, "fade_in_ms": -1},
+	} {
+		resp = doSchedulerRequest(t, http.MethodPut, ts.URL+"/v1/routines/"+created["id"].(string), map[string]any{
+			"speakers": []map[string]any{speaker},
+		})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "speaker %v", speaker)
+		resp.Body.Close()
+	}
+}
+
+func TestRoutineGroupingMode(t *testing.T) {
+	ts, cleanup := setupSchedulerTestServer(t)
+	defer cleanup()
+
+	sceneGroupingMode := func(sceneID string) any {
+		resp := doSchedulerRequest(t, http.MethodGet, ts.URL+"/v1/scenes/"+sceneID, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var sceneBody map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&sceneBody))
+		resp.Body.Close()
+		return sceneBody["grouping_mode"]
+	}
+
+	resp := doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", map[string]any{
+		"name":          "Dinner",
+		"timezone":      "UTC",
+		"schedule":      map[string]any{"type": "weekly", "weekdays": []int{1, 2, 3, 4, 5}, "time": "18:00"},
+		"speakers":      []map[string]any{{"udn": "RINCON_KITCHEN", "volume": 20}, {"udn": "RINCON_DINING", "volume": 20}},
+		"grouping_mode": "grouped_then_restore",
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created routineResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	sceneID := created["scene_id"].(string)
+	require.Equal(t, "grouped_then_restore", sceneGroupingMode(sceneID))
+
+	resp = doSchedulerRequest(t, http.MethodPut, ts.URL+"/v1/routines/"+created["id"].(string), map[string]any{
+		"grouping_mode": "independent",
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+	require.Equal(t, "independent", sceneGroupingMode(sceneID))
+
+	resp = doSchedulerRequest(t, http.MethodPut, ts.URL+"/v1/routines/"+created["id"].(string), map[string]any{
+		"grouping_mode": "party",
+	})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Scenes created directly default to grouped
+	resp = doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/scenes", map[string]any{"name": "Plain", "members": []map[string]any{}})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var plain map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&plain))
+	resp.Body.Close()
+	require.Equal(t, "grouped", plain["grouping_mode"])
+
+	resp = doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/scenes", map[string]any{"name": "Bad", "members": []map[string]any{}, "grouping_mode": "party"})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestRoutineRestorePreviousState(t *testing.T) {
+	ts, cleanup := setupSchedulerTestServer(t)
+	defer cleanup()
+
+	resp := doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines", map[string]any{
+		"name":                   "Announcement",
+		"timezone":               "UTC",
+		"schedule":               map[string]any{"type": "weekly", "weekdays": []int{1, 2, 3, 4, 5}, "time": "12:00", "duration_minutes": 5},
+		"speakers":               []map[string]any{{"udn": "RINCON_KITCHEN", "volume": 30}},
+		"restore_previous_state": true,
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created routineResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	require.Equal(t, true, created["restore_previous_state"])
+	routineID := created["id"].(string)
+
+	resp = doSchedulerRequest(t, http.MethodPut, ts.URL+"/v1/routines/"+routineID, map[string]any{
+		"restore_previous_state": false,
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var updated routineResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&updated))
+	resp.Body.Close()
+	require.Equal(t, false, updated["restore_previous_state"])
+
+	// Nothing has run, so there is nothing to put back
+	resp = doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines/"+routineID+"/restore-playback", nil)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = doSchedulerRequest(t, http.MethodPost, ts.URL+"/v1/routines/missing/restore-playback", nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+}
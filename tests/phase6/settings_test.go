@@ -0,0 +1,80 @@
+package phase6
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettingsGetAndPatch(t *testing.T) {
+	t.Setenv("APPLE_PRIVATE_KEY_PATH", "/secret/AuthKey.p8")
+	ts, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	// Defaults before anything is saved
+	resp := doRequest(t, http.MethodGet, ts.URL+"/v1/settings", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	resp.Body.Close()
+
+	require.Equal(t, "settings", body["object"])
+	runtime := body["runtime"].(map[string]any)
+	require.Equal(t, "America/Los_Angeles", runtime["default_timezone"])
+	require.Nil(t, runtime["quiet_hours"])
+	require.Equal(t, float64(90), runtime["audit_retention_days"])
+
+	// Secrets never leak into the response
+	raw, err := json.Marshal(body)
+	require.NoError(t, err)
+	require.False(t, strings.Contains(string(raw), "this-is-a-development-secret"))
+	require.False(t, strings.Contains(string(raw), "AuthKey.p8"))
+
+	// Partial update
+	resp = doRequest(t, http.MethodPatch, ts.URL+"/v1/settings", map[string]any{
+		"default_timezone":     "Europe/London",
+		"quiet_hours":          map[string]any{"start": "22:00", "end": "07:00"},
+		"audit_retention_days": 30,
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	// Re-read reflects the update without restart
+	resp = doRequest(t, http.MethodGet, ts.URL+"/v1/settings", nil)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	resp.Body.Close()
+	runtime = body["runtime"].(map[string]any)
+	require.Equal(t, "Europe/London", runtime["default_timezone"])
+	require.Equal(t, map[string]any{"start": "22:00", "end": "07:00"}, runtime["quiet_hours"])
+	require.Equal(t, float64(30), runtime["audit_retention_days"])
+	require.NotNil(t, body["updated_at"])
+
+	// Clearing quiet hours leaves other fields untouched
+	resp = doRequest(t, http.MethodPatch, ts.URL+"/v1/settings", map[string]any{"quiet_hours": nil})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	resp.Body.Close()
+	runtime = body["runtime"].(map[string]any)
+	require.Nil(t, runtime["quiet_hours"])
+	require.Equal(t, "Europe/London", runtime["default_timezone"])
+}
+
+func TestSettingsPatchValidation(t *testing.T) {
+	ts, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	cases := []map[string]any{
+		{"default_timezone": "Mars/Olympus"},
+		{"quiet_hours": map[string]any{"start": "25:00", "end": "07:00"}},
+		{"audit_retention_days": 0},
+		{"jwt_secret": "nope"}, // not editable
+	}
+	for _, payload := range cases {
+		resp := doRequest(t, http.MethodPatch, ts.URL+"/v1/settings", payload)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "payload: %v", payload)
+		resp.Body.Close()
+	}
+}